@@ -56,6 +56,7 @@ const (
 	FlagLauncher            = "--launcher"
 	FlagWait                = "--wait"
 	FlagSessionID           = "--session-id"
+	FlagZeroLogging         = "zero-logging"
 )
 
 func main() { //nolint:funlen
@@ -74,24 +75,17 @@ func main() { //nolint:funlen
 
 	locations := locations.New(locationsProvider, constants.ConfigName)
 
-	logsPath, err := locations.ProvideLogsPath()
-	if err != nil {
-		l.WithError(err).Fatal("Failed to get logs path")
-	}
-
 	sessionID := logging.NewSessionID()
-	crashHandler.AddRecoveryAction(logging.DumpStackTrace(logsPath, sessionID, launcherName))
 
 	var closer io.Closer
-	if closer, err = logging.Init(
-		logsPath,
-		sessionID,
-		logging.LauncherShortAppName,
-		logging.DefaultMaxLogFileSize,
-		logging.NoPruning,
-		os.Getenv("VERBOSITY"),
-	); err != nil {
-		l.WithError(err).Fatal("Failed to setup logging")
+
+	if hasFlag(os.Args[1:], FlagZeroLogging) {
+		// The bridge process takes the same flag, so neither process writes logs to disk.
+		if closer, err = logging.InitInMemory(logging.DefaultMemoryLogSize, os.Getenv("VERBOSITY")); err != nil {
+			l.WithError(err).Fatal("Failed to setup logging")
+		}
+	} else {
+		closer = initLoggingOnDisk(l, locations, crashHandler, sessionID)
 	}
 
 	defer func() {
@@ -171,6 +165,29 @@ func main() { //nolint:funlen
 	}
 }
 
+func initLoggingOnDisk(l *logrus.Entry, locations *locations.Locations, crashHandler *crash.Handler, sessionID logging.SessionID) io.Closer {
+	logsPath, err := locations.ProvideLogsPath()
+	if err != nil {
+		l.WithError(err).Fatal("Failed to get logs path")
+	}
+
+	crashHandler.AddRecoveryAction(logging.DumpStackTrace(logsPath, sessionID, launcherName))
+
+	closer, err := logging.Init(
+		logsPath,
+		sessionID,
+		logging.LauncherShortAppName,
+		logging.DefaultMaxLogFileSize,
+		logging.NoPruning,
+		os.Getenv("VERBOSITY"),
+	)
+	if err != nil {
+		l.WithError(err).Fatal("Failed to setup logging")
+	}
+
+	return closer
+}
+
 // appendLauncherPath add launcher path if missing.
 func appendLauncherPath(path string, args []string) []string {
 	if !sliceContains(args, FlagLauncher) {
@@ -34,11 +34,12 @@ import (
 	"github.com/ProtonMail/proton-bridge/v3/internal/cookies"
 	"github.com/ProtonMail/proton-bridge/v3/internal/crash"
 	"github.com/ProtonMail/proton-bridge/v3/internal/events"
-	"github.com/ProtonMail/proton-bridge/v3/internal/focus"
 	"github.com/ProtonMail/proton-bridge/v3/internal/frontend/theme"
 	"github.com/ProtonMail/proton-bridge/v3/internal/locations"
 	"github.com/ProtonMail/proton-bridge/v3/internal/logging"
+	"github.com/ProtonMail/proton-bridge/v3/internal/secret"
 	"github.com/ProtonMail/proton-bridge/v3/internal/sentry"
+	"github.com/ProtonMail/proton-bridge/v3/internal/tenant"
 	"github.com/ProtonMail/proton-bridge/v3/internal/useragent"
 	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
 	"github.com/ProtonMail/proton-bridge/v3/pkg/restarter"
@@ -72,6 +73,19 @@ const (
 
 	flagLogIMAP = "log-imap"
 	flagLogSMTP = "log-smtp"
+
+	flagZeroLogging = "zero-logging"
+
+	flagHarden      = "harden"
+	flagHardenAllow = "harden-allow"
+
+	flagMultiTenant = "multi-tenant"
+
+	flagSafeMode = "safe-mode"
+
+	flagConfig = "config"
+
+	flagUpdateLogKey = "update-log-key"
 )
 
 // Hidden flags.
@@ -81,6 +95,7 @@ const (
 	flagParentPID        = "parent-pid"
 	flagSoftwareRenderer = "software-renderer"
 	flagSessionID        = "session-id"
+	flagNoSecretLock     = "no-secret-lock"
 )
 
 const (
@@ -137,6 +152,35 @@ func New() *cli.App {
 			Name:  flagLogSMTP,
 			Usage: "Enable logging of SMTP communications (may contain decrypted data!)",
 		},
+		&cli.BoolFlag{
+			Name:  flagZeroLogging,
+			Usage: "Never write logs to disk; only keep the logs of the current session in memory",
+		},
+		&cli.BoolFlag{
+			Name:  flagHarden,
+			Usage: "Restrict system calls and filesystem access once started (Linux only; filesystem and capability restrictions need a build without cgo)",
+		},
+		&cli.StringSliceFlag{
+			Name:  flagHardenAllow,
+			Usage: "Directory which exports, imports and message cache moves may use once hardened; may be repeated",
+		},
+		&cli.BoolFlag{
+			Name:  flagMultiTenant,
+			Usage: "Also host isolated bridges for other tenants, e.g. family members, managed from the CLI",
+		},
+		&cli.BoolFlag{
+			Name:  flagSafeMode,
+			Usage: "Start without the optional features, on the default ports, serving the cached mail read-only, and log verbosely to find a setting which prevents normal startup",
+		},
+		&cli.StringFlag{
+			Name:    flagConfig,
+			Usage:   "Apply the settings and log in the accounts of a YAML or TOML configuration file at startup; they override the saved settings",
+			EnvVars: []string{"BRIDGE_CONFIG_FILE"},
+		},
+		&cli.StringFlag{
+			Name:  flagUpdateLogKey,
+			Usage: "Only install updates whose provenance attestation is in the release transparency log, checking the log checkpoints with the armored public key in the given file",
+		},
 
 		// Hidden flags
 		&cli.BoolFlag{
@@ -165,6 +209,11 @@ func New() *cli.App {
 			Name:   flagSessionID,
 			Hidden: true,
 		},
+		&cli.BoolFlag{
+			Name:   flagNoSecretLock,
+			Usage:  "Don't lock secrets into memory",
+			Hidden: true,
+		},
 	}
 
 	app.Action = run
@@ -179,6 +228,11 @@ func run(c *cli.Context) error {
 		return fmt.Errorf("could not create version: %w", err)
 	}
 
+	// Secrets are locked into memory unless told otherwise, e.g. where the memory lock limit is too low.
+	if c.Bool(flagNoSecretLock) {
+		secret.SetLocking(false)
+	}
+
 	// Create a user agent that will be used for all requests.
 	identifier := useragent.New()
 
@@ -233,7 +287,7 @@ func run(c *cli.Context) error {
 							logrus.WithError(err).Error("Failed to get settings path")
 						}
 
-						return withSingleInstance(settings, locations.GetLockFile(), version, func() error {
+						return withSingleInstance(settings, locations.GetLockFile(), version, c.Args().Slice(), func() error {
 							// Unlock the encrypted vault.
 							return WithVault(locations, crashHandler, func(v *vault.Vault, insecure, corrupt bool) error {
 								if !v.Migrated() {
@@ -279,8 +333,10 @@ func run(c *cli.Context) error {
 										// Start telemetry heartbeat process
 										b.StartHeartbeat(b)
 
-										// Run the frontend.
-										return runFrontend(c, crashHandler, restarter, locations, b, eventCh, quitCh, c.Int(flagParentPID))
+										// Host the tenants if requested, then run the frontend.
+										return withTenants(c, locations, b, version, identifier, crashHandler, reporter, func(tenants *tenant.Manager) error {
+											return runFrontend(c, crashHandler, restarter, locations, b, tenants, eventCh, quitCh, c.Int(flagParentPID))
+										})
 									})
 								})
 							})
@@ -292,17 +348,17 @@ func run(c *cli.Context) error {
 	})
 }
 
-// If there's another instance already running, try to raise it and exit.
-func withSingleInstance(settingPath, lockFile string, version *semver.Version, fn func() error) error {
+// If there's another instance already running, try to raise it, passing it the arguments, e.g. a mailto: URL, and exit.
+func withSingleInstance(settingPath, lockFile string, version *semver.Version, args []string, fn func() error) error {
 	logrus.Debug("Checking for other instances")
 	defer logrus.Debug("Single instance stopped")
 
 	lock, err := checkSingleInstance(settingPath, lockFile, version)
 	if err != nil {
-		logrus.Info("Another instance is already running; raising it")
+		logrus.WithError(err).Info("Another instance is already running; raising it")
 
-		if ok := focus.TryRaise(settingPath); !ok {
-			return fmt.Errorf("another instance is already running but it could not be raised")
+		if ok := passToRunningInstance(settingPath, args); !ok {
+			return fmt.Errorf("another instance is already running but it could not be raised: %w", err)
 		}
 
 		logrus.Info("The other instance has been raised")
@@ -312,7 +368,7 @@ func withSingleInstance(settingPath, lockFile string, version *semver.Version, f
 
 	defer func() {
 		if err := lock.Close(); err != nil {
-			logrus.WithError(err).Error("Failed to close lock file")
+			logrus.WithError(err).Error("Failed to release lock")
 		}
 	}()
 
@@ -324,43 +380,73 @@ func withLogging(c *cli.Context, crashHandler *crash.Handler, locations *locatio
 	logrus.Debug("Initializing logging")
 	defer logrus.Debug("Logging stopped")
 
+	var (
+		closer io.Closer
+		err    error
+	)
+
+	if c.Bool(flagZeroLogging) {
+		// Logs are kept in memory only and no stack trace is dumped if we crash.
+		if closer, err = logging.InitInMemory(logging.DefaultMemoryLogSize, getLogLevel(c)); err != nil {
+			return fmt.Errorf("could not initialize logging: %w", err)
+		}
+
+		logrus.Info("Zero-logging mode enabled, logs are not written to disk")
+	} else if closer, err = initLoggingOnDisk(c, crashHandler, locations); err != nil {
+		return err
+	}
+
+	logrus.
+		WithField("appName", constants.FullAppName).
+		WithField("version", constants.Version).
+		WithField("revision", constants.Revision).
+		WithField("tag", constants.Tag).
+		WithField("build", constants.BuildTime).
+		WithField("runtime", runtime.GOOS).
+		WithField("args", os.Args).
+		WithField("SentryID", sentry.GetProtectedHostname()).
+		Info("Run app")
+
+	return fn(closer)
+}
+
+func initLoggingOnDisk(c *cli.Context, crashHandler *crash.Handler, locations *locations.Locations) (io.Closer, error) {
 	// Get a place to keep our logs.
 	logsPath, err := locations.ProvideLogsPath()
 	if err != nil {
-		return fmt.Errorf("could not provide logs path: %w", err)
+		return nil, fmt.Errorf("could not provide logs path: %w", err)
 	}
 
 	logrus.WithField("path", logsPath).Debug("Received logs path")
 
 	// Initialize logging.
 	sessionID := logging.NewSessionIDFromString(c.String(flagSessionID))
-	var closer io.Closer
-	if closer, err = logging.Init(
+
+	closer, err := logging.Init(
 		logsPath,
 		sessionID,
 		logging.BridgeShortAppName,
 		logging.DefaultMaxLogFileSize,
 		logging.DefaultPruningSize,
-		c.String(flagLogLevel),
-	); err != nil {
-		return fmt.Errorf("could not initialize logging: %w", err)
+		getLogLevel(c),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("could not initialize logging: %w", err)
 	}
 
 	// Ensure we dump a stack trace if we crash.
 	crashHandler.AddRecoveryAction(logging.DumpStackTrace(logsPath, sessionID, appShortName))
 
-	logrus.
-		WithField("appName", constants.FullAppName).
-		WithField("version", constants.Version).
-		WithField("revision", constants.Revision).
-		WithField("tag", constants.Tag).
-		WithField("build", constants.BuildTime).
-		WithField("runtime", runtime.GOOS).
-		WithField("args", os.Args).
-		WithField("SentryID", sentry.GetProtectedHostname()).
-		Info("Run app")
+	return closer, nil
+}
 
-	return fn(closer)
+// getLogLevel returns the log level set on the command line, which is always debug in safe mode.
+func getLogLevel(c *cli.Context) string {
+	if c.Bool(flagSafeMode) {
+		return "debug"
+	}
+
+	return c.String(flagLogLevel)
 }
 
 // WithLocations provides access to locations where we store our files.
@@ -442,20 +528,9 @@ func withCookieJar(vault *vault.Vault, fn func(http.CookieJar) error) error {
 	logrus.Debug("Creating cookie jar")
 	defer logrus.Debug("Cookie jar stopped")
 
-	// Create the underlying cookie jar.
-	jar, err := cookiejar.New(nil)
+	persister, err := newCookieJar(vault)
 	if err != nil {
-		return fmt.Errorf("could not create cookie jar: %w", err)
-	}
-
-	// Create the cookie jar which persists to the vault.
-	persister, err := cookies.NewCookieJar(jar, vault)
-	if err != nil {
-		return fmt.Errorf("could not create cookie jar: %w", err)
-	}
-
-	if err := setDeviceCookies(persister); err != nil {
-		return fmt.Errorf("could not set device cookies: %w", err)
+		return err
 	}
 
 	// Persist the cookies to the vault when we close.
@@ -470,6 +545,27 @@ func withCookieJar(vault *vault.Vault, fn func(http.CookieJar) error) error {
 	return fn(persister)
 }
 
+// newCookieJar returns a cookie jar which persists its cookies to the vault.
+func newCookieJar(vault *vault.Vault) (*cookies.Jar, error) {
+	// Create the underlying cookie jar.
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not create cookie jar: %w", err)
+	}
+
+	// Create the cookie jar which persists to the vault.
+	persister, err := cookies.NewCookieJar(jar, vault)
+	if err != nil {
+		return nil, fmt.Errorf("could not create cookie jar: %w", err)
+	}
+
+	if err := setDeviceCookies(persister); err != nil {
+		return nil, fmt.Errorf("could not set device cookies: %w", err)
+	}
+
+	return persister, nil
+}
+
 func setDeviceCookies(jar *cookies.Jar) error {
 	url, err := url.Parse(constants.APIHost)
 	if err != nil {
@@ -18,8 +18,12 @@
 package app
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"os"
+	"path/filepath"
 	"runtime"
 
 	"github.com/Masterminds/semver/v3"
@@ -32,6 +36,7 @@ import (
 	"github.com/ProtonMail/proton-bridge/v3/internal/dialer"
 	"github.com/ProtonMail/proton-bridge/v3/internal/events"
 	"github.com/ProtonMail/proton-bridge/v3/internal/locations"
+	"github.com/ProtonMail/proton-bridge/v3/internal/mailto"
 	"github.com/ProtonMail/proton-bridge/v3/internal/sentry"
 	"github.com/ProtonMail/proton-bridge/v3/internal/updater"
 	"github.com/ProtonMail/proton-bridge/v3/internal/useragent"
@@ -69,10 +74,68 @@ func withBridge(
 		}
 	}
 
+	// Create the autostarter.
+	autostarter := newAutostarter(exe)
+
+	// Create the registration of bridge as the mailto: URL handler.
+	mailtoHandler := mailto.NewHandler(exe)
+
+	// Create the update installer.
+	updater, err := newUpdater(c, locations)
+	if err != nil {
+		return fmt.Errorf("could not create updater: %w", err)
+	}
+
+	// Create a new bridge.
+	bridge, eventCh, err := newBridge(c, locations, vault, autostarter, mailtoHandler, updater, version, identifier, crashHandler, reporter, cookieJar)
+	if err != nil {
+		return fmt.Errorf("could not create bridge: %w", err)
+	}
+
+	// Ensure we close bridge when we exit.
+	defer bridge.Close(c.Context)
+
+	// Apply the configuration file, if any.
+	if path := c.String(flagConfig); path != "" {
+		if err := applyConfigFile(c.Context, bridge, path); err != nil {
+			return err
+		}
+	}
+
+	// Compose the message of the mailto: URL bridge was launched with as their handler, if any.
+	if url, ok := mailto.Find(c.Args().Slice()); ok {
+		if err := bridge.HandleMailto(url); err != nil {
+			logrus.WithError(err).Error("Failed to compose mailto URL")
+		}
+	}
+
+	// Restrict the process now that it is set up.
+	if c.Bool(flagHarden) {
+		harden(bridge, exe, locations, vault, c.StringSlice(flagHardenAllow))
+	}
+
+	return fn(bridge, eventCh)
+}
+
+// newBridge creates a bridge along with the dialers it uses to reach the API.
+func newBridge(
+	c *cli.Context,
+	locations *locations.Locations,
+	vault *vault.Vault,
+	autostarter bridge.Autostarter,
+	mailtoHandler bridge.MailtoHandler,
+	updater bridge.Updater,
+	version *semver.Version,
+	identifier *useragent.UserAgent,
+	crashHandler *crash.Handler,
+	reporter *sentry.Reporter,
+	cookieJar http.CookieJar,
+) (*bridge.Bridge, <-chan events.Event, error) {
 	// Create the underlying dialer used by the bridge.
 	// It only connects to trusted servers and reports any untrusted servers it finds.
+	basicDialer := dialer.NewBasicTLSDialer(constants.APIHost)
 	pinningDialer := dialer.NewPinningTLSDialer(
-		dialer.NewBasicTLSDialer(constants.APIHost),
+		basicDialer,
 		dialer.NewTLSReporter(constants.APIHost, constants.AppVersion(version.Original()), identifier, dialer.TrustedAPIPins),
 		dialer.NewTLSPinChecker(dialer.TrustedAPIPins),
 	)
@@ -80,21 +143,15 @@ func withBridge(
 	// Create a proxy dialer which switches to a proxy if the request fails.
 	proxyDialer := dialer.NewProxyTLSDialer(pinningDialer, constants.APIHost, crashHandler)
 
-	// Create the autostarter.
-	autostarter := newAutostarter(exe)
+	// Connect through Tor or with the DoH resolver, depending on the settings.
+	basicDialer.SetNetDialer(proxyDialer.GetNetDialer())
 
-	// Create the update installer.
-	updater, err := newUpdater(locations)
-	if err != nil {
-		return fmt.Errorf("could not create updater: %w", err)
-	}
-
-	// Create a new bridge.
-	bridge, eventCh, err := bridge.New(
+	return bridge.New(
 		// The app stuff.
 		locations,
 		vault,
 		autostarter,
+		mailtoHandler,
 		updater,
 		version,
 
@@ -115,15 +172,39 @@ func withBridge(
 		c.String(flagLogIMAP) == "client" || c.String(flagLogIMAP) == "all",
 		c.String(flagLogIMAP) == "server" || c.String(flagLogIMAP) == "all",
 		c.Bool(flagLogSMTP),
+
+		// The recovery stuff.
+		c.Bool(flagSafeMode),
 	)
+}
+
+// applyConfigFile applies the settings of the configuration file at the given path and logs what changed.
+// bridge doesn't start with a file which can't be read or holds invalid settings, as it is likely a mistake.
+func applyConfigFile(ctx context.Context, b *bridge.Bridge, path string) error {
+	logrus.WithField("path", path).Info("Applying configuration file")
+
+	file, err := bridge.LoadConfigFile(path)
 	if err != nil {
-		return fmt.Errorf("could not create bridge: %w", err)
+		return fmt.Errorf("could not load configuration file: %w", err)
 	}
 
-	// Ensure we close bridge when we exit.
-	defer bridge.Close(c.Context)
+	changes, err := b.ApplyConfigFile(ctx, file)
 
-	return fn(bridge, eventCh)
+	for _, change := range changes {
+		logrus.WithField("change", change.String()).Info("Setting changed by configuration file")
+	}
+
+	if errors.Is(err, bridge.ErrInvalidConfigFile) {
+		return err
+	} else if err != nil {
+		logrus.WithError(err).Error("Failed to apply configuration file")
+	}
+
+	for _, issue := range b.ValidateConfiguration(ctx) {
+		logrus.WithField("issue", issue.String()).Warn("Configuration issue")
+	}
+
+	return nil
 }
 
 func newAutostarter(exe string) *autostart.App {
@@ -136,7 +217,7 @@ func newAutostarter(exe string) *autostart.App {
 	}
 }
 
-func newUpdater(locations *locations.Locations) (*updater.Updater, error) {
+func newUpdater(c *cli.Context, locations *locations.Locations) (*updater.Updater, error) {
 	updatesDir, err := locations.ProvideUpdatesPath()
 	if err != nil {
 		return nil, fmt.Errorf("could not provide updates path: %w", err)
@@ -154,10 +235,34 @@ func newUpdater(locations *locations.Locations) (*updater.Updater, error) {
 		return nil, fmt.Errorf("could not create key ring: %w", err)
 	}
 
-	return updater.NewUpdater(
+	upd := updater.NewUpdater(
 		updater.NewInstaller(versioner.New(updatesDir)),
 		verifier,
 		constants.UpdateName,
 		runtime.GOOS,
-	), nil
+	)
+
+	// The attestations are only required when asked for, as not every release publishes them.
+	if path := c.String(flagUpdateLogKey); path != "" {
+		armored, err := os.ReadFile(filepath.Clean(path))
+		if err != nil {
+			return nil, fmt.Errorf("could not read transparency log key: %w", err)
+		}
+
+		logKey, err := crypto.NewKeyFromArmored(string(armored))
+		if err != nil {
+			return nil, fmt.Errorf("could not create transparency log key from armored: %w", err)
+		}
+
+		logVerifier, err := crypto.NewKeyRing(logKey)
+		if err != nil {
+			return nil, fmt.Errorf("could not create transparency log key ring: %w", err)
+		}
+
+		if err := upd.RequireAttestation(logVerifier); err != nil {
+			return nil, err
+		}
+	}
+
+	return upd, nil
 }
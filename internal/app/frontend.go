@@ -26,6 +26,7 @@ import (
 	bridgeCLI "github.com/ProtonMail/proton-bridge/v3/internal/frontend/cli"
 	"github.com/ProtonMail/proton-bridge/v3/internal/frontend/grpc"
 	"github.com/ProtonMail/proton-bridge/v3/internal/locations"
+	"github.com/ProtonMail/proton-bridge/v3/internal/tenant"
 	"github.com/ProtonMail/proton-bridge/v3/pkg/restarter"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
@@ -37,6 +38,7 @@ func runFrontend(
 	restarter *restarter.Restarter,
 	locations *locations.Locations,
 	bridge *bridge.Bridge,
+	tenants *tenant.Manager,
 	eventCh <-chan events.Event,
 	quitCh <-chan struct{},
 	parentPID int,
@@ -46,7 +48,7 @@ func runFrontend(
 
 	switch {
 	case c.Bool(flagCLI):
-		return bridgeCLI.New(bridge, restarter, eventCh, crashHandler, quitCh).Loop()
+		return bridgeCLI.New(bridge, tenants, restarter, eventCh, crashHandler, quitCh).Loop()
 
 	case c.Bool(flagNonInteractive):
 		<-quitCh
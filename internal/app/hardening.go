@@ -0,0 +1,86 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
+	"github.com/ProtonMail/proton-bridge/v3/internal/hardening"
+	"github.com/ProtonMail/proton-bridge/v3/internal/locations"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+	"github.com/sirupsen/logrus"
+)
+
+// harden restricts the process now that the keychain has been read and bridge is set up.
+// Beyond its own directories, bridge keeps access to the system directories, to the pass keychain helper's stores
+// and to the given directories, where exports, imports and message cache moves may then happen.
+func harden(b *bridge.Bridge, exe string, locations *locations.Locations, vault *vault.Vault, allowed []string) {
+	cfg := hardening.Config{
+		WritablePaths: append(locations.GetDataDirs(), vault.GetGluonCacheDir(), os.TempDir(), "/dev"),
+		ReadOnlyPaths: append(hardening.DefaultReadOnlyPaths(), filepath.Dir(exe)),
+	}
+
+	for _, path := range allowed {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			logrus.WithError(err).WithField("path", path).Warn("Ignoring invalid hardening path")
+			continue
+		}
+
+		cfg.WritablePaths = append(cfg.WritablePaths, abs)
+	}
+
+	if home, err := os.UserHomeDir(); err == nil {
+		cfg.WritablePaths = append(cfg.WritablePaths, filepath.Join(home, ".password-store"), filepath.Join(home, ".gnupg"))
+	}
+
+	status := hardening.Apply(cfg)
+
+	if status.Landlock == nil {
+		b.SetHardenedPaths(cfg.WritablePaths)
+	}
+
+	log := logrus.WithFields(logrus.Fields{
+		"capabilities": errString(status.Capabilities),
+		"landlock":     errString(status.Landlock),
+		"seccomp":      errString(status.Seccomp),
+	})
+
+	if status.Capabilities == nil && status.Landlock == nil && status.Seccomp == nil {
+		log.Info("Process hardened")
+		return
+	}
+
+	log.Warn("Process only partially hardened")
+
+	// Builds linking cgo can only install the seccomp filter, which the kernel synchronizes to all threads.
+	if errors.Is(status.Landlock, hardening.ErrNeedsNoCgo) || errors.Is(status.Capabilities, hardening.ErrNeedsNoCgo) {
+		log.Warn("Filesystem and capability restrictions need a build without cgo (CGO_ENABLED=0)")
+	}
+}
+
+func errString(err error) string {
+	if err != nil {
+		return err.Error()
+	}
+
+	return "applied"
+}
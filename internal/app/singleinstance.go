@@ -18,31 +18,43 @@
 package app
 
 import (
+	"errors"
 	"fmt"
-	"os"
 	"time"
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/ProtonMail/proton-bridge/v3/internal/focus"
-	"github.com/allan-simon/go-singleinstance"
 	"github.com/sirupsen/logrus"
 )
 
+const (
+	// lockTimeout is how long to wait for an older instance which was killed to release the lock.
+	lockTimeout = 10 * time.Second
+
+	// commandTimeout is how long to keep trying to pass the arguments to the running instance,
+	// which may not serve the focus service yet if it's still starting.
+	commandTimeout = 10 * time.Second
+
+	singleInstanceRetryInterval = 100 * time.Millisecond
+)
+
 // checkSingleInstance checks if another instance of the application is already running.
-// It tries to create a lock file at the given path.
-// If it succeeds, it returns the lock file and a nil error.
+// It tries to take the lock on the file at the given path.
+// If it succeeds, it returns the lock and a nil error.
 //
-// For macOS and Linux when already running version is older than this instance
+// When the already running version is older than this instance
 // it will kill old and continue with this new bridge (i.e. no error returned).
-func checkSingleInstance(settingPath, lockFilePath string, curVersion *semver.Version) (*os.File, error) {
-	if lock, err := singleinstance.CreateLockFile(lockFilePath); err == nil {
-		logrus.WithField("path", lockFilePath).Debug("Created lock file; no other instance is running")
+func checkSingleInstance(settingPath, lockFilePath string, curVersion *semver.Version) (*focus.Lock, error) {
+	lock, err := focus.TryLock(lockFilePath)
+	if err == nil {
+		logrus.WithField("path", lockFilePath).Debug("Took the lock; no other instance is running")
 		return lock, nil
+	} else if !errors.Is(err, focus.ErrLocked) {
+		return nil, err
 	}
 
-	logrus.Warn("Failed to create lock file; another instance is running")
+	logrus.Warn("The lock is held; another instance is running")
 
-	// We couldn't create the lock file, so another instance is probably running.
 	// Check if it's an older version of the app.
 	lastVersion, ok := focus.TryVersion(settingPath)
 	if !ok {
@@ -54,7 +66,7 @@ func checkSingleInstance(settingPath, lockFilePath string, curVersion *semver.Ve
 	}
 
 	// The other instance is an older version, so we should kill it.
-	pid, err := getPID(lockFilePath)
+	pid, err := focus.ReadLockPID(lockFilePath)
 	if err != nil {
 		return nil, err
 	}
@@ -63,8 +75,24 @@ func checkSingleInstance(settingPath, lockFilePath string, curVersion *semver.Ve
 		return nil, err
 	}
 
-	// Need to wait some time to release file lock
-	time.Sleep(time.Second)
+	// The lock is released once the killed instance has exited.
+	for deadline := time.Now().Add(lockTimeout); ; time.Sleep(singleInstanceRetryInterval) {
+		if lock, err := focus.TryLock(lockFilePath); !errors.Is(err, focus.ErrLocked) || time.Now().After(deadline) {
+			return lock, err
+		}
+	}
+}
 
-	return singleinstance.CreateLockFile(lockFilePath)
+// passToRunningInstance raises the running instance, passing it the given arguments.
+// It keeps trying for a while, as the instance may still be starting.
+func passToRunningInstance(settingPath string, args []string) bool {
+	for deadline := time.Now().Add(commandTimeout); ; time.Sleep(singleInstanceRetryInterval) {
+		if focus.TryCommand(settingPath, args) {
+			return true
+		}
+
+		if time.Now().After(deadline) {
+			return false
+		}
+	}
 }
@@ -0,0 +1,261 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/ProtonMail/gluon/async"
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
+	"github.com/ProtonMail/proton-bridge/v3/internal/constants"
+	"github.com/ProtonMail/proton-bridge/v3/internal/crash"
+	"github.com/ProtonMail/proton-bridge/v3/internal/locations"
+	"github.com/ProtonMail/proton-bridge/v3/internal/sentry"
+	"github.com/ProtonMail/proton-bridge/v3/internal/tenant"
+	"github.com/ProtonMail/proton-bridge/v3/internal/updater"
+	"github.com/ProtonMail/proton-bridge/v3/internal/useragent"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+	"github.com/ProtonMail/proton-bridge/v3/pkg/keychain"
+	"github.com/ProtonMail/proton-bridge/v3/pkg/ports"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/exp/slices"
+)
+
+// withTenants hosts the tenants in multi-tenant mode. Otherwise, the tenant manager passed to fn is nil.
+func withTenants(
+	c *cli.Context,
+	locations *locations.Locations,
+	daemon *bridge.Bridge,
+	version *semver.Version,
+	identifier *useragent.UserAgent,
+	crashHandler *crash.Handler,
+	reporter *sentry.Reporter,
+	fn func(*tenant.Manager) error,
+) error {
+	if !c.Bool(flagMultiTenant) {
+		return fn(nil)
+	}
+
+	logrus.Debug("Creating tenants")
+	defer logrus.Debug("Tenants stopped")
+
+	tenantsDir, err := locations.ProvideTenantsPath()
+	if err != nil {
+		return fmt.Errorf("could not provide tenants path: %w", err)
+	}
+
+	manager := tenant.NewManager(tenantsDir, constants.ConfigName, newTenantStarter(c, daemon, version, identifier, crashHandler, reporter), removeTenant)
+
+	if err := manager.Load(c.Context); err != nil {
+		return fmt.Errorf("could not load tenants: %w", err)
+	}
+
+	defer manager.Close(c.Context)
+
+	return fn(manager)
+}
+
+func newTenantStarter(
+	c *cli.Context,
+	daemon *bridge.Bridge,
+	version *semver.Version,
+	identifier *useragent.UserAgent,
+	crashHandler *crash.Handler,
+	reporter *sentry.Reporter,
+) tenant.StartFunc {
+	return func(ctx context.Context, name string, locations *locations.Locations, others []*bridge.Bridge) (*bridge.Bridge, func(context.Context), error) {
+		b, stop, err := startTenant(c, name, locations, version, identifier, crashHandler, reporter)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if err := assignTenantPorts(ctx, b, append(others, daemon)); err != nil {
+			stop(ctx)
+			return nil, nil, err
+		}
+
+		return b, stop, nil
+	}
+}
+
+// startTenant creates the bridge of a tenant, with its own vault and keychain entry.
+// The daemon itself handles updates and autostart, so the tenant's bridge doesn't.
+func startTenant(
+	c *cli.Context,
+	name string,
+	locations *locations.Locations,
+	version *semver.Version,
+	identifier *useragent.UserAgent,
+	crashHandler *crash.Handler,
+	reporter *sentry.Reporter,
+) (*bridge.Bridge, func(context.Context), error) {
+	log := logrus.WithField("tenant", name)
+
+	vault, insecure, corrupt, err := newVault(locations, getTenantKeychainName(name), crashHandler)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not create vault: %w", err)
+	}
+
+	cookieJar, err := newCookieJar(vault)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	b, eventCh, err := newBridge(c, locations, vault, &tenantAutostarter{}, &tenantMailtoHandler{}, &tenantUpdater{version: version}, version, identifier, crashHandler, reporter, cookieJar)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not create bridge: %w", err)
+	}
+
+	if insecure {
+		log.Warn("The vault key could not be retrieved; the vault will not be encrypted")
+		b.PushError(bridge.ErrVaultInsecure)
+	}
+
+	if corrupt {
+		log.Warn("The vault is corrupt and has been wiped")
+		b.PushError(bridge.ErrVaultCorrupt)
+	}
+
+	// Nobody watches the events of the tenants; drain them.
+	go func() {
+		defer async.HandlePanic(crashHandler)
+
+		for event := range eventCh {
+			log.WithField("event", event).Debug("Tenant event")
+		}
+	}()
+
+	log.Info("Tenant started")
+
+	return b, func(ctx context.Context) {
+		b.Close(ctx)
+
+		if err := cookieJar.PersistCookies(); err != nil {
+			log.WithError(err).Error("Failed to persist cookies")
+		}
+
+		log.Info("Tenant stopped")
+	}, nil
+}
+
+// assignTenantPorts moves the listeners of a tenant to other ports if the given bridges already use its ports.
+// Ports are only free once listened on, so a new tenant could otherwise be given the ports of an idle bridge.
+func assignTenantPorts(ctx context.Context, b *bridge.Bridge, others []*bridge.Bridge) error {
+	used := make([]int, 0, 2*len(others))
+
+	for _, other := range others {
+		used = append(used, other.GetIMAPPort(), other.GetSMTPPort())
+	}
+
+	if port := b.GetIMAPPort(); slices.Contains(used, port) {
+		if err := b.SetIMAPPort(ctx, ports.FindFreePortFrom(port, used...)); err != nil {
+			return fmt.Errorf("could not set IMAP port: %w", err)
+		}
+	}
+
+	used = append(used, b.GetIMAPPort())
+
+	if port := b.GetSMTPPort(); slices.Contains(used, port) {
+		if err := b.SetSMTPPort(ctx, ports.FindFreePortFrom(port, used...)); err != nil {
+			return fmt.Errorf("could not set SMTP port: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// removeTenant logs out the accounts of a tenant and removes its vault key from the keychain.
+func removeTenant(ctx context.Context, name string, b *bridge.Bridge, locations *locations.Locations) error {
+	for _, userID := range b.GetUserIDs() {
+		if err := b.DeleteUser(ctx, userID); err != nil {
+			return fmt.Errorf("could not delete user: %w", err)
+		}
+	}
+
+	vaultDir, err := locations.ProvideSettingsPath()
+	if err != nil {
+		return fmt.Errorf("could not get vault dir: %w", err)
+	}
+
+	helper, err := vault.GetHelper(vaultDir)
+	if err != nil {
+		return fmt.Errorf("could not get keychain helper: %w", err)
+	}
+
+	kc, err := keychain.NewKeychain(helper, getTenantKeychainName(name))
+	if errors.Is(err, keychain.ErrNoKeychain) {
+		// Without keychain, the vault of the tenant is not encrypted; there is no key to remove.
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("could not create keychain: %w", err)
+	}
+
+	return kc.Clear()
+}
+
+// getTenantKeychainName returns the name scoping the keychain entries of a tenant.
+func getTenantKeychainName(name string) string {
+	return constants.KeyChainName + "-tenant-" + name
+}
+
+// tenantAutostarter never starts the bridge of a tenant on its own; it runs as part of the daemon.
+type tenantAutostarter struct{}
+
+func (*tenantAutostarter) Enable() error {
+	return errors.New("autostart is managed by the daemon")
+}
+
+func (*tenantAutostarter) Disable() error {
+	return errors.New("autostart is managed by the daemon")
+}
+
+func (*tenantAutostarter) IsEnabled() bool {
+	return false
+}
+
+// tenantMailtoHandler never registers the bridge of a tenant as the mailto: URL handler, which is the daemon's.
+type tenantMailtoHandler struct{}
+
+func (*tenantMailtoHandler) Register() error {
+	return errors.New("the mailto handler is managed by the daemon")
+}
+
+func (*tenantMailtoHandler) Unregister() error {
+	return errors.New("the mailto handler is managed by the daemon")
+}
+
+func (*tenantMailtoHandler) IsRegistered() bool {
+	return false
+}
+
+// tenantUpdater never updates the bridge of a tenant on its own; it is updated along with the daemon.
+type tenantUpdater struct {
+	version *semver.Version
+}
+
+func (u *tenantUpdater) GetVersionInfo(context.Context, updater.Downloader, updater.Channel) (updater.VersionInfo, error) {
+	return updater.VersionInfo{Version: u.version}, nil
+}
+
+func (u *tenantUpdater) InstallUpdate(context.Context, updater.Downloader, updater.VersionInfo) (updater.Verification, error) {
+	return updater.Verification{}, errors.New("updates are installed by the daemon")
+}
@@ -18,12 +18,14 @@
 package app
 
 import (
+	"errors"
 	"fmt"
 	"path"
 
 	"github.com/ProtonMail/gluon/async"
 	"github.com/ProtonMail/proton-bridge/v3/internal/constants"
 	"github.com/ProtonMail/proton-bridge/v3/internal/locations"
+	"github.com/ProtonMail/proton-bridge/v3/internal/secret"
 	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
 	"github.com/ProtonMail/proton-bridge/v3/pkg/keychain"
 	"github.com/sirupsen/logrus"
@@ -34,7 +36,7 @@ func WithVault(locations *locations.Locations, panicHandler async.PanicHandler,
 	defer logrus.Debug("Vault stopped")
 
 	// Create the encVault.
-	encVault, insecure, corrupt, err := newVault(locations, panicHandler)
+	encVault, insecure, corrupt, err := newVault(locations, constants.KeyChainName, panicHandler)
 	if err != nil {
 		return fmt.Errorf("could not create vault: %w", err)
 	}
@@ -49,7 +51,7 @@ func WithVault(locations *locations.Locations, panicHandler async.PanicHandler,
 	return fn(encVault, insecure, corrupt)
 }
 
-func newVault(locations *locations.Locations, panicHandler async.PanicHandler) (*vault.Vault, bool, bool, error) {
+func newVault(locations *locations.Locations, keychainName string, panicHandler async.PanicHandler) (*vault.Vault, bool, bool, error) {
 	vaultDir, err := locations.ProvideSettingsPath()
 	if err != nil {
 		return nil, false, false, fmt.Errorf("could not get vault dir: %w", err)
@@ -57,19 +59,20 @@ func newVault(locations *locations.Locations, panicHandler async.PanicHandler) (
 
 	logrus.WithField("vaultDir", vaultDir).Debug("Loading vault from directory")
 
-	var (
-		vaultKey []byte
-		insecure bool
-	)
+	// The vault key is only needed until the vault is created.
+	vaultKey := secret.New(0)
+	defer func() { vaultKey.Destroy() }()
 
-	if key, err := loadVaultKey(vaultDir); err != nil {
+	var insecure bool
+
+	if key, err := loadVaultKey(vaultDir, keychainName); err != nil {
 		logrus.WithError(err).Error("Could not load/create vault key")
 		insecure = true
 
 		// We store the insecure vault in a separate directory
 		vaultDir = path.Join(vaultDir, "insecure")
 	} else {
-		vaultKey = key
+		vaultKey = secret.NewFromBytes(key)
 	}
 
 	gluonCacheDir, err := locations.ProvideGluonCachePath()
@@ -77,7 +80,7 @@ func newVault(locations *locations.Locations, panicHandler async.PanicHandler) (
 		return nil, false, false, fmt.Errorf("could not provide gluon path: %w", err)
 	}
 
-	vault, corrupt, err := vault.New(vaultDir, gluonCacheDir, vaultKey, panicHandler)
+	vault, corrupt, err := vault.New(vaultDir, gluonCacheDir, vaultKey.Bytes(), panicHandler)
 	if err != nil {
 		return nil, false, false, fmt.Errorf("could not create vault: %w", err)
 	}
@@ -85,13 +88,13 @@ func newVault(locations *locations.Locations, panicHandler async.PanicHandler) (
 	return vault, insecure, corrupt, nil
 }
 
-func loadVaultKey(vaultDir string) ([]byte, error) {
+func loadVaultKey(vaultDir, keychainName string) ([]byte, error) {
 	helper, err := vault.GetHelper(vaultDir)
 	if err != nil {
 		return nil, fmt.Errorf("could not get keychain helper: %w", err)
 	}
 
-	kc, err := keychain.NewKeychain(helper, constants.KeyChainName)
+	kc, err := keychain.NewKeychain(helper, keychainName)
 	if err != nil {
 		return nil, fmt.Errorf("could not create keychain: %w", err)
 	}
@@ -101,9 +104,39 @@ func loadVaultKey(vaultDir string) ([]byte, error) {
 		return nil, fmt.Errorf("could not check for vault key: %w", err)
 	}
 
-	if has {
-		return vault.GetVaultKey(kc)
+	if !has {
+		return vault.NewVaultKey(kc)
+	}
+
+	key, err := vault.GetVaultKey(kc)
+	if err != nil {
+		return nil, err
 	}
 
-	return vault.NewVaultKey(kc)
+	if err := rekeyVault(kc, vaultDir, key); err != nil {
+		secret.Wipe(key)
+		return nil, fmt.Errorf("could not re-encrypt vault with rotated key: %w", err)
+	}
+
+	return key, nil
+}
+
+// rekeyVault re-encrypts the vault with the given key if the key was rotated in the keychain since the vault was written.
+func rekeyVault(kc *keychain.Keychain, vaultDir string, key []byte) error {
+	if needsRekey, err := vault.NeedsRekey(vaultDir, key); err != nil || !needsRekey {
+		return err
+	}
+
+	prevKey, err := vault.GetPreviousVaultKey(kc)
+	if errors.Is(err, keychain.ErrNoPreviousVersion) {
+		// The key wasn't rotated, the vault is corrupt.
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer secret.Wipe(prevKey)
+
+	logrus.Info("Vault key was rotated, re-encrypting vault")
+
+	return vault.Rekey(vaultDir, prevKey, key)
 }
@@ -0,0 +1,83 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/gopenpgp/v2/crypto"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// accessTokenSize is the number of random bytes of the tokens of access grants.
+const accessTokenSize = 32
+
+// GetAccessGrants returns the grants of roles to clients of the gRPC service, including the revoked ones.
+func (bridge *Bridge) GetAccessGrants() []vault.AccessGrant {
+	return bridge.vault.GetAccessGrants()
+}
+
+// AddAccessGrant grants the given role to a new token, which is returned.
+// The token is only known to the caller; bridge merely keeps its hash.
+func (bridge *Bridge) AddAccessGrant(name string, role vault.AccessRole) (vault.AccessGrant, string, error) {
+	if name = strings.TrimSpace(name); name == "" {
+		return vault.AccessGrant{}, "", ErrInvalidAccessGrantName
+	}
+
+	tok, err := crypto.RandomToken(accessTokenSize)
+	if err != nil {
+		return vault.AccessGrant{}, "", fmt.Errorf("could not generate token: %w", err)
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(tok)
+
+	if err := bridge.vault.AddAccessGrant(uuid.NewString(), name, role, token, time.Now()); err != nil {
+		return vault.AccessGrant{}, "", err
+	}
+
+	grant, _ := bridge.vault.GetAccessGrantByToken(token)
+
+	logrus.WithFields(logrus.Fields{
+		"grantID": grant.ID,
+		"name":    grant.Name,
+		"role":    grant.Role,
+	}).Info("Access granted")
+
+	return grant, token, nil
+}
+
+// RevokeAccessGrant revokes the grant with the given ID; its token no longer gives access.
+func (bridge *Bridge) RevokeAccessGrant(grantID string) error {
+	if err := bridge.vault.RevokeAccessGrant(grantID, time.Now()); err != nil {
+		return err
+	}
+
+	logrus.WithField("grantID", grantID).Info("Access revoked")
+
+	return nil
+}
+
+// GetAccessGrantByToken returns the grant of the given token, if it is valid.
+func (bridge *Bridge) GetAccessGrantByToken(token string) (vault.AccessGrant, bool) {
+	return bridge.vault.GetAccessGrantByToken(token)
+}
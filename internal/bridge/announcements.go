@@ -0,0 +1,170 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/events"
+	"github.com/ProtonMail/proton-bridge/v3/internal/safe"
+	"github.com/ProtonMail/proton-bridge/v3/internal/services/imapservice"
+	"github.com/bradenaw/juniper/xslices"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/exp/maps"
+)
+
+// The announcements of the same kind replace each other, such that only the latest is posted.
+const (
+	announcementKindUpdate  = "update"
+	announcementKindTLSCert = "tls-cert"
+)
+
+var errorAnnouncementSubjects = map[ErrorCode]string{ //nolint:gochecknoglobals
+	ErrorCodeSyncFailed:     "Your account could not be synchronized",
+	ErrorCodeSendFailed:     "A message could not be sent",
+	ErrorCodeAuthExpired:    "Your session has expired",
+	ErrorCodeUserLoadFailed: "Your account could not be loaded",
+	ErrorCodeBadEvent:       "Your account is out of sync",
+	ErrorCodeKeychain:       "Your keychain is not available",
+	ErrorCodeVaultCorrupt:   "Your settings were reset",
+	ErrorCodeInterference:   "Security software is interfering with Bridge",
+}
+
+// announcer keeps track of the bridge announcements posted to the read-only Proton Bridge mailbox of every user:
+// the notes of updates and the TLS certificate warnings. The active errors are posted along with them.
+type announcer struct {
+	announcements map[string]imapservice.Announcement
+	lock          sync.Mutex
+}
+
+func newAnnouncer() *announcer {
+	return &announcer{announcements: make(map[string]imapservice.Announcement)}
+}
+
+// handleEvent records the announcement matching the given event, returning whether there's one.
+func (a *announcer) handleEvent(event events.Event) bool {
+	now := time.Now()
+
+	switch event := event.(type) {
+	case events.UpdateAvailable:
+		if event.Silent {
+			return false
+		}
+
+		body := "Install it from the Bridge application."
+
+		if !event.Compatible {
+			body = "This version can't be installed automatically. Download and install it from " + event.Version.LandingPage + "."
+		}
+
+		a.set(announcementKindUpdate, imapservice.Announcement{
+			ID:      "update-available-" + event.Version.Version.String(),
+			Time:    now,
+			Subject: fmt.Sprintf("Proton Mail Bridge %v is available", event.Version.Version),
+			Body:    body + "\r\n\r\nRelease notes: " + event.Version.ReleaseNotesPage + "\r\n",
+		})
+
+	case events.UpdateInstalled:
+		a.set(announcementKindUpdate, imapservice.Announcement{
+			ID:      "update-installed-" + event.Version.Version.String(),
+			Time:    now,
+			Subject: fmt.Sprintf("Proton Mail Bridge was updated to %v", event.Version.Version),
+			Body:    "The new version is used once Bridge restarts.\r\n\r\nRelease notes: " + event.Version.ReleaseNotesPage + "\r\n",
+		})
+
+	case events.UpdateForced:
+		a.set(announcementKindUpdate, imapservice.Announcement{
+			ID:      "update-forced",
+			Time:    now,
+			Subject: "Proton Mail Bridge must be updated",
+			Body:    "This version of Bridge is no longer supported. Download and install the latest version from the Proton website.\r\n",
+		})
+
+	case events.TLSCertExpiring:
+		a.set(announcementKindTLSCert, imapservice.Announcement{
+			ID:      fmt.Sprintf("tls-cert-expiring-%d", event.NotAfter.Unix()),
+			Time:    now,
+			Subject: "The TLS certificate of Bridge expires on " + event.NotAfter.Format("January 2, 2006"),
+			Body:    "Bridge can't renew the certificate you provided. Import a renewed one before it expires, or your email client won't be able to connect.\r\n",
+		})
+
+	case events.TLSCertRenewed:
+		a.set(announcementKindTLSCert, imapservice.Announcement{
+			ID:      fmt.Sprintf("tls-cert-renewed-%d", event.NotAfter.Unix()),
+			Time:    now,
+			Subject: "Bridge renewed its TLS certificate",
+			Body:    "If your email client trusted the previous certificate, make it trust the new one, valid until " + event.NotAfter.Format("January 2, 2006") + ".\r\n",
+		})
+
+	default:
+		return false
+	}
+
+	return true
+}
+
+func (a *announcer) set(kind string, announcement imapservice.Announcement) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	a.announcements[kind] = announcement
+}
+
+func (a *announcer) list() []imapservice.Announcement {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	return maps.Values(a.announcements)
+}
+
+// newErrorAnnouncements returns the announcements of the given active errors.
+// An error raised again after it was resolved or dismissed is announced anew.
+func newErrorAnnouncements(activeErrs []ActiveError) []imapservice.Announcement {
+	return xslices.Map(activeErrs, func(activeErr ActiveError) imapservice.Announcement {
+		body := activeErr.Action + "\r\n"
+
+		if activeErr.Message != "" {
+			body += "\r\nError: " + activeErr.Message + "\r\n"
+		}
+
+		return imapservice.Announcement{
+			ID:      fmt.Sprintf("error-%v-%d", activeErr.Code, activeErr.FirstSeen.UnixNano()),
+			Time:    activeErr.FirstSeen,
+			Subject: errorAnnouncementSubjects[activeErr.Code],
+			Body:    body,
+		}
+	})
+}
+
+// postAnnouncements posts the current announcements and the active errors to the Proton Bridge mailbox of every user.
+func (bridge *Bridge) postAnnouncements(ctx context.Context) {
+	shared := append(bridge.announcer.list(), newErrorAnnouncements(bridge.errorCenter.list(""))...)
+
+	safe.RLock(func() {
+		for userID, user := range bridge.users {
+			announcements := append(newErrorAnnouncements(bridge.errorCenter.list(userID)), shared...)
+
+			if err := user.SetAnnouncements(ctx, announcements); err != nil {
+				logrus.WithError(err).WithField("userID", userID).Error("Failed to post announcements")
+			}
+		}
+	}, bridge.usersLock)
+}
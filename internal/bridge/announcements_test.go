@@ -0,0 +1,86 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/go-proton-api/server"
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
+	"github.com/ProtonMail/proton-bridge/v3/internal/constants"
+	"github.com/bradenaw/juniper/xslices"
+	"github.com/emersion/go-imap"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBridge_Announcements(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		_, _, err := s.CreateUser("user", password)
+		require.NoError(t, err)
+
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, mocks *bridge.Mocks) {
+			require.NoError(t, b.SetAutoUpdate(false))
+
+			userLoginAndSync(ctx, t, b, "user", password)
+
+			info, err := b.QueryUserInfo("user")
+			require.NoError(t, err)
+
+			client, err := eventuallyDial(fmt.Sprintf("%v:%v", constants.Host, b.GetIMAPPort()))
+			require.NoError(t, err)
+			require.NoError(t, client.Login(info.Addresses[0], string(info.BridgePass)))
+			defer func() { _ = client.Logout() }()
+
+			// The mailbox is hidden while there's nothing to announce.
+			require.NotContains(t, xslices.Map(clientList(client), func(info *imap.MailboxInfo) string { return info.Name }), "Proton Bridge")
+
+			// An available update is announced in the Proton Bridge mailbox.
+			mocks.Updater.SetLatestVersion(v2_4_0, v2_3_0)
+			b.CheckForUpdates()
+
+			require.Eventually(t, func() bool {
+				status, err := client.Status("Proton Bridge", []imap.StatusItem{imap.StatusMessages})
+				return err == nil && status.Messages == 1
+			}, 10*time.Second, 100*time.Millisecond)
+
+			messages, err := clientFetch(client, "Proton Bridge")
+			require.NoError(t, err)
+			require.Len(t, messages, 1)
+			require.Equal(t, "Proton Mail Bridge 2.4.0 is available", messages[0].Envelope.Subject)
+
+			// Announcements can be marked as read but not copied elsewhere.
+			require.NoError(t, clientStore(client, 1, 1, false, imap.FormatFlagsOp(imap.AddFlags, true), imap.SeenFlag))
+			require.Error(t, client.Copy(&imap.SeqSet{Set: []imap.Seq{{Start: 1, Stop: 1}}}, "INBOX"))
+
+			// Deleting the announcement dismisses it.
+			require.NoError(t, clientStore(client, 1, 1, false, imap.FormatFlagsOp(imap.AddFlags, true), imap.DeletedFlag))
+			require.NoError(t, client.Expunge(nil))
+
+			b.CheckForUpdates()
+
+			require.Never(t, func() bool {
+				status, err := client.Status("Proton Bridge", []imap.StatusItem{imap.StatusMessages})
+				return err != nil || status.Messages != 0
+			}, time.Second, 100*time.Millisecond)
+		})
+	})
+}
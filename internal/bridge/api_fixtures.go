@@ -0,0 +1,71 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+// GetRecordAPIFixtures returns whether the API traffic is recorded to fixture files.
+func (bridge *Bridge) GetRecordAPIFixtures() bool {
+	return bridge.vault.GetRecordAPIFixtures()
+}
+
+// SetRecordAPIFixtures sets whether the API traffic is recorded to fixture files, in the logs directory.
+// The fixtures hold every API request and response with the tokens, keys and login proofs stripped, so that
+// a user can attach them to a bug report and the exchange can be replayed offline to reproduce it.
+// Messages and attachments stay encrypted in the fixtures, but their metadata, e.g. subjects, is recorded as is.
+func (bridge *Bridge) SetRecordAPIFixtures(record bool) error {
+	if err := bridge.vault.SetRecordAPIFixtures(record); err != nil {
+		return err
+	}
+
+	// The API traffic isn't recorded in safe mode; it is once bridge starts normally.
+	if bridge.safeMode != nil {
+		return nil
+	}
+
+	return bridge.applyRecordAPIFixtures(record)
+}
+
+// GetAPIFixturePath returns the path of the fixture file the API traffic is recorded to, if any.
+func (bridge *Bridge) GetAPIFixturePath() string {
+	return bridge.fixtureRecorder.Path()
+}
+
+func (bridge *Bridge) applyRecordAPIFixtures(record bool) error {
+	if !record {
+		return bridge.fixtureRecorder.Stop()
+	}
+
+	logsPath, err := bridge.locator.ProvideLogsPath()
+	if err != nil {
+		return fmt.Errorf("failed to get logs path: %w", err)
+	}
+
+	path, err := bridge.fixtureRecorder.Start(logsPath)
+	if err != nil {
+		return err
+	}
+
+	logrus.WithField("path", path).Warn("Recording API traffic to fixture file")
+
+	return nil
+}
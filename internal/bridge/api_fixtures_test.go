@@ -0,0 +1,78 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/go-proton-api/server"
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
+	"github.com/ProtonMail/proton-bridge/v3/internal/network"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBridge_RecordAPIFixtures(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		var path string
+
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			require.False(t, b.GetRecordAPIFixtures())
+			require.Empty(t, b.GetAPIFixturePath())
+
+			require.NoError(t, b.SetRecordAPIFixtures(true))
+
+			path = b.GetAPIFixturePath()
+			require.NotEmpty(t, path)
+
+			_, err := b.LoginFull(ctx, username, password, nil, nil)
+			require.NoError(t, err)
+		})
+
+		fixtures, err := network.LoadFixtures(path)
+		require.NoError(t, err)
+		require.NotEmpty(t, fixtures)
+
+		// The session tokens of the login are stripped.
+		var loggedIn bool
+
+		for _, fixture := range fixtures {
+			if auth := fixture.Request.Header.Get("Authorization"); auth != "" {
+				require.Equal(t, "<redacted>", auth)
+			}
+
+			if fixture.Method == http.MethodPost && fixture.Path == "/auth/v4" {
+				require.Contains(t, string(fixture.Response.JSON), `"AccessToken":"<redacted>"`)
+				loggedIn = true
+			}
+		}
+
+		require.True(t, loggedIn)
+
+		// Recording goes on in the next session, until stopped.
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			require.True(t, b.GetRecordAPIFixtures())
+			require.NotEmpty(t, b.GetAPIFixturePath())
+
+			require.NoError(t, b.SetRecordAPIFixtures(false))
+			require.Empty(t, b.GetAPIFixturePath())
+		})
+	})
+}
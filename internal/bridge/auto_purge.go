@@ -0,0 +1,63 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"context"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/proton-bridge/v3/internal/safe"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+)
+
+// GetAutoPurge returns the Trash and Spam auto-purge settings of the given user.
+func (bridge *Bridge) GetAutoPurge(userID string) (vault.AutoPurge, error) {
+	return safe.RLockRetErr(func() (vault.AutoPurge, error) {
+		user, ok := bridge.users[userID]
+		if !ok {
+			return vault.AutoPurge{}, ErrNoSuchUser
+		}
+
+		return user.GetAutoPurge(), nil
+	}, bridge.usersLock)
+}
+
+// SetAutoPurge sets after how many days messages in Trash and Spam of the given user are permanently deleted.
+// Messages are announced with an events.UserAutoPurgePending event before they are deleted.
+func (bridge *Bridge) SetAutoPurge(userID string, purge vault.AutoPurge) error {
+	return safe.RLockRet(func() error {
+		user, ok := bridge.users[userID]
+		if !ok {
+			return ErrNoSuchUser
+		}
+
+		return user.SetAutoPurge(purge)
+	}, bridge.usersLock)
+}
+
+// PreviewAutoPurge returns, by label ID, the messages of the given user the auto-purge would delete.
+func (bridge *Bridge) PreviewAutoPurge(ctx context.Context, userID string) (map[string][]proton.MessageMetadata, error) {
+	return safe.RLockRetErr(func() (map[string][]proton.MessageMetadata, error) {
+		user, ok := bridge.users[userID]
+		if !ok {
+			return nil, ErrNoSuchUser
+		}
+
+		return user.PreviewAutoPurge(ctx)
+	}, bridge.usersLock)
+}
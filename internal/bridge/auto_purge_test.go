@@ -0,0 +1,185 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/gluon/async"
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/go-proton-api/server"
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
+	"github.com/ProtonMail/proton-bridge/v3/internal/events"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBridge_AutoPurge(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		_, addrID, err := s.CreateUser("user", password)
+		require.NoError(t, err)
+
+		var trashIDs []string
+
+		withClient(ctx, t, s, "user", password, func(ctx context.Context, c *proton.Client) {
+			trashIDs = createNumMessages(ctx, t, c, addrID, proton.TrashLabel, 3)
+			createNumMessages(ctx, t, c, addrID, proton.InboxLabel, 1)
+		})
+
+		var userID string
+
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			userLoginAndSync(ctx, t, b, "user", password)
+
+			info, err := b.QueryUserInfo("user")
+			require.NoError(t, err)
+
+			userID = info.UserID
+		})
+
+		// Pretend the messages have been in Trash for two days.
+		setAutoPurgeState(t, locator, storeKey, userID, func(state *vault.AutoPurgeState) {
+			state.Seen = map[string]map[string]time.Time{proton.TrashLabel: {}}
+
+			for _, messageID := range trashIDs {
+				state.Seen[proton.TrashLabel][messageID] = time.Now().AddDate(0, 0, -2)
+			}
+		})
+
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			pendingCh, donePending := b.GetEvents(events.UserAutoPurgePending{})
+			defer donePending()
+
+			purgedCh, donePurged := b.GetEvents(events.UserAutoPurged{})
+			defer donePurged()
+
+			require.NoError(t, b.SetAutoPurge(userID, vault.AutoPurge{TrashDays: 1}))
+
+			// The old messages are first only announced.
+			pending, ok := (<-pendingCh).(events.UserAutoPurgePending)
+			require.True(t, ok)
+			require.Equal(t, proton.TrashLabel, pending.LabelID)
+			require.Equal(t, 3, pending.Count)
+			require.True(t, pending.At.After(time.Now().Add(time.Hour)))
+
+			preview, err := b.PreviewAutoPurge(ctx, userID)
+			require.NoError(t, err)
+			require.Len(t, preview[proton.TrashLabel], 3)
+			require.NotContains(t, preview, proton.SpamLabel)
+
+			// Another run before the announced time doesn't delete them.
+			require.NoError(t, b.SetAutoPurge(userID, vault.AutoPurge{TrashDays: 1}))
+
+			select {
+			case <-purgedCh:
+				require.Fail(t, "messages should not be purged before the announced time")
+
+			case <-time.After(time.Second):
+			}
+		})
+
+		// Pretend the announced time has passed.
+		setAutoPurgeState(t, locator, storeKey, userID, func(state *vault.AutoPurgeState) {
+			require.Len(t, state.PurgeAt, 3)
+
+			for messageID := range state.PurgeAt {
+				state.PurgeAt[messageID] = time.Now().Add(-time.Minute)
+			}
+		})
+
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			purgedCh, donePurged := b.GetEvents(events.UserAutoPurged{})
+			defer donePurged()
+
+			// The next run deletes them.
+			require.NoError(t, b.SetAutoPurge(userID, vault.AutoPurge{TrashDays: 1}))
+
+			purged, ok := (<-purgedCh).(events.UserAutoPurged)
+			require.True(t, ok)
+			require.Equal(t, proton.TrashLabel, purged.LabelID)
+			require.Equal(t, 3, purged.Count)
+
+			withClient(ctx, t, s, "user", password, func(ctx context.Context, c *proton.Client) {
+				require.Eventually(t, func() bool {
+					trash, err := c.GetMessageMetadataPage(ctx, 0, 10, proton.MessageFilter{LabelID: proton.TrashLabel})
+					return err == nil && len(trash) == 0
+				}, 10*time.Second, 100*time.Millisecond)
+
+				inbox, err := c.GetMessageMetadataPage(ctx, 0, 10, proton.MessageFilter{LabelID: proton.InboxLabel})
+				require.NoError(t, err)
+				require.Len(t, inbox, 1)
+			})
+		})
+	})
+}
+
+func TestBridge_AutoPurge_NewlyTrashed(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		_, addrID, err := s.CreateUser("user", password)
+		require.NoError(t, err)
+
+		// The messages are old, but only just arrived in Trash as far as bridge knows.
+		withClient(ctx, t, s, "user", password, func(ctx context.Context, c *proton.Client) {
+			createNumMessages(ctx, t, c, addrID, proton.TrashLabel, 3)
+		})
+
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			userLoginAndSync(ctx, t, b, "user", password)
+
+			info, err := b.QueryUserInfo("user")
+			require.NoError(t, err)
+
+			userID := info.UserID
+
+			pendingCh, donePending := b.GetEvents(events.UserAutoPurgePending{})
+			defer donePending()
+
+			require.NoError(t, b.SetAutoPurge(userID, vault.AutoPurge{TrashDays: 1}))
+
+			select {
+			case <-pendingCh:
+				require.Fail(t, "messages should not be announced before they have been in Trash long enough")
+
+			case <-time.After(time.Second):
+			}
+
+			preview, err := b.PreviewAutoPurge(ctx, userID)
+			require.NoError(t, err)
+			require.Empty(t, preview[proton.TrashLabel])
+		})
+	})
+}
+
+// setAutoPurgeState modifies the auto-purge state of the given user in the vault, while bridge isn't running.
+func setAutoPurgeState(t *testing.T, locator bridge.Locator, vaultKey []byte, userID string, fn func(*vault.AutoPurgeState)) {
+	vaultDir, err := locator.ProvideSettingsPath()
+	require.NoError(t, err)
+
+	v, _, err := vault.New(vaultDir, t.TempDir(), vaultKey, async.NoopPanicHandler{})
+	require.NoError(t, err)
+
+	require.NoError(t, v.GetUser(userID, func(user *vault.User) {
+		state := user.AutoPurgeState()
+		fn(&state)
+		require.NoError(t, user.SetAutoPurgeState(state))
+	}))
+
+	require.NoError(t, v.Close())
+}
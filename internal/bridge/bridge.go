@@ -23,8 +23,10 @@ import (
 	"crypto/tls"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/Masterminds/semver/v3"
@@ -38,10 +40,15 @@ import (
 	"github.com/ProtonMail/proton-bridge/v3/internal/events"
 	"github.com/ProtonMail/proton-bridge/v3/internal/focus"
 	"github.com/ProtonMail/proton-bridge/v3/internal/identifier"
+	"github.com/ProtonMail/proton-bridge/v3/internal/indexhook"
+	"github.com/ProtonMail/proton-bridge/v3/internal/mailto"
+	"github.com/ProtonMail/proton-bridge/v3/internal/network"
 	"github.com/ProtonMail/proton-bridge/v3/internal/safe"
 	"github.com/ProtonMail/proton-bridge/v3/internal/sentry"
+	"github.com/ProtonMail/proton-bridge/v3/internal/services/imapservice"
 	"github.com/ProtonMail/proton-bridge/v3/internal/services/imapsmtpserver"
 	"github.com/ProtonMail/proton-bridge/v3/internal/services/syncservice"
+	"github.com/ProtonMail/proton-bridge/v3/internal/services/unifiedinbox"
 	"github.com/ProtonMail/proton-bridge/v3/internal/telemetry"
 	"github.com/ProtonMail/proton-bridge/v3/internal/user"
 	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
@@ -63,8 +70,17 @@ type Bridge struct {
 	proxyCtl   ProxyController
 	identifier identifier.Identifier
 
-	// tlsConfig holds the bridge TLS config used by the IMAP and SMTP servers.
+	// tlsConfig holds the bridge TLS config used by the IMAP and SMTP servers; it serves tlsCert.
 	tlsConfig *tls.Config
+	tlsCert   atomic.Pointer[tls.Certificate]
+
+	// goTLSCertCheck triggers a check of the expiry of the TLS certificate.
+	goTLSCertCheck func()
+
+	// goInterferenceCheck triggers a check for security software interfering with bridge.
+	// interference holds the kinds of interference found by the last check; it's only used by the check.
+	goInterferenceCheck func()
+	interference        map[events.InterferenceKind]bool
 
 	// imapServer is the bridge's IMAP server.
 	imapEventCh chan imapEvents.Event
@@ -88,6 +104,9 @@ type Bridge struct {
 	// autostarter is the bridge's autostarter.
 	autostarter Autostarter
 
+	// mailtoHandler registers bridge as the handler of mailto: URLs.
+	mailtoHandler MailtoHandler
+
 	// locator is the bridge's locator.
 	locator Locator
 
@@ -104,6 +123,15 @@ type Bridge struct {
 	// errors contains errors encountered during startup.
 	errors []error
 
+	// errorCenter keeps track of the user-facing errors that are still active.
+	errorCenter *errorCenter
+
+	// announcer keeps track of the announcements posted to the users' Proton Bridge mailbox.
+	announcer *announcer
+
+	// goAnnounce triggers a post of the announcements and active errors to the users' Proton Bridge mailbox.
+	goAnnounce func()
+
 	// These control the bridge's IMAP and SMTP logging behaviour.
 	logIMAPClient bool
 	logIMAPServer bool
@@ -129,6 +157,83 @@ type Bridge struct {
 
 	serverManager *imapsmtpserver.Service
 	syncService   *syncservice.Service
+
+	// clientShims are the workarounds for IMAP client quirks, shared by the connectors of all users.
+	clientShims *imapservice.ClientShims
+
+	// hardenedPaths are the only directories the process may write to once hardened; any if empty.
+	hardenedPaths     []string
+	hardenedPathsLock sync.RWMutex
+
+	// redactedMessages are the messages of all users whose bodies aren't kept in the IMAP store.
+	redactedMessages *imapservice.RedactedMessages
+
+	// indexHook runs the user's local mail indexer when new messages arrive.
+	indexHook *indexhook.Hook
+
+	// dndActive is set while do not disturb is active.
+	dndActive atomic.Bool
+
+	// goDoNotDisturb triggers an evaluation of the do not disturb schedule.
+	goDoNotDisturb func()
+
+	// diskSpaceLow is set while syncing is paused because the message cache's disk is almost full.
+	diskSpaceLow atomic.Bool
+
+	// clockOffset is the API's clock minus the local clock, as measured on the last API response.
+	clockOffset atomic.Int64
+
+	// clockSkewed is set while the local clock differs from the API's by more than MaxClockSkew.
+	clockSkewed atomic.Bool
+
+	// trafficShaper batches and delays the API requests in privacy mode.
+	trafficShaper *network.TrafficShaper
+
+	// faultInjector injects faults into the API requests for resilience testing.
+	faultInjector *network.FaultInjector
+
+	// diskFullInjected simulates a full message cache disk for resilience testing.
+	diskFullInjected atomic.Bool
+
+	// goDiskSpaceCheck triggers a check of the free disk space.
+	goDiskSpaceCheck func()
+
+	// fixtureRecorder records the API traffic to fixture files for bug reports.
+	fixtureRecorder *network.FixtureRecorder
+
+	// torStatus is the TorStatus of the connection to the API through Tor.
+	torStatus atomic.Int32
+
+	// goTorCheck triggers a check of the Tor circuit.
+	goTorCheck func()
+
+	// unifiedInbox aggregates the inboxes of all users; it is nil while no user is part of it.
+	unifiedInbox     *unifiedinbox.Connector
+	unifiedInboxLock sync.Mutex
+
+	// goUnifiedInbox triggers a refresh of the unified inbox.
+	goUnifiedInbox func()
+
+	// pairingCode is the pending pairing code, if any; pairingLock also guards lastPairingAttempt.
+	pairingCode        *pairingCode
+	lastPairingAttempt time.Time
+	pairingLock        sync.Mutex
+
+	// metricsServer serves the Prometheus metrics; it is nil while they aren't served.
+	metricsServer     *http.Server
+	metricsListener   net.Listener
+	metricsServerLock sync.Mutex
+
+	// exports holds the functions canceling the running mailbox exports, by user ID.
+	exports     map[string]context.CancelFunc
+	exportsLock sync.Mutex
+
+	// imports holds the functions canceling the running message imports, by user ID.
+	imports     map[string]context.CancelFunc
+	importsLock sync.Mutex
+
+	// safeMode holds the settings used in place of the saved ones in safe mode; it is nil otherwise.
+	safeMode *safeModeSettings
 }
 
 // New creates a new bridge.
@@ -136,6 +241,7 @@ func New(
 	locator Locator, // the locator to provide paths to store data
 	vault *vault.Vault, // the bridge's encrypted data store
 	autostarter Autostarter, // the autostarter to manage autostart settings
+	mailtoHandler MailtoHandler, // the registration of bridge as the handler of mailto: URLs
 	updater Updater, // the updater to fetch and install updates
 	curVersion *semver.Version, // the current version of the bridge
 
@@ -151,9 +257,23 @@ func New(
 
 	logIMAPClient, logIMAPServer bool, // whether to log IMAP client/server activity
 	logSMTP bool, // whether to log SMTP activity
+
+	safeMode bool, // whether to skip the optional subsystems, see IsSafeMode
 ) (*Bridge, <-chan events.Event, error) {
+	// faultInjector injects faults into the API requests, below everything else, as a flaky network would.
+	faultInjector := network.NewFaultInjector(roundTripper)
+
+	// trafficShaper batches and delays the API requests in privacy mode.
+	trafficShaper := network.NewTrafficShaper(faultInjector)
+
+	// apiMetrics records the duration of the API requests, including the delays added by shaping.
+	apiMetrics := network.NewMetricsRoundTripper(trafficShaper)
+
+	// fixtureRecorder records the API traffic as the client sees it, after shaping.
+	fixtureRecorder := network.NewFixtureRecorder(apiMetrics)
+
 	// api is the user's API manager.
-	api := proton.New(newAPIOptions(apiURL, curVersion, cookieJar, roundTripper, panicHandler)...)
+	api := proton.New(newAPIOptions(apiURL, curVersion, cookieJar, fixtureRecorder, panicHandler)...)
 
 	// tasks holds all the bridge's background tasks.
 	tasks := async.NewGroup(context.Background(), panicHandler)
@@ -169,16 +289,21 @@ func New(
 		locator,
 		vault,
 		autostarter,
+		mailtoHandler,
 		updater,
 		curVersion,
 		panicHandler,
 		reporter,
 
 		api,
+		trafficShaper,
+		faultInjector,
+		fixtureRecorder,
 		identifier,
 		proxyCtl,
 		uidValidityGenerator,
 		logIMAPClient, logIMAPServer, logSMTP,
+		safeMode,
 	)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to create bridge: %w", err)
@@ -202,19 +327,24 @@ func newBridge(
 	locator Locator,
 	vault *vault.Vault,
 	autostarter Autostarter,
+	mailtoHandler MailtoHandler,
 	updater Updater,
 	curVersion *semver.Version,
 	panicHandler async.PanicHandler,
 	reporter reporter.Reporter,
 
 	api *proton.Manager,
+	trafficShaper *network.TrafficShaper,
+	faultInjector *network.FaultInjector,
+	fixtureRecorder *network.FixtureRecorder,
 	identifier identifier.Identifier,
 	proxyCtl ProxyController,
 	uidValidityGenerator imap.UIDValidityGenerator,
 
 	logIMAPClient, logIMAPServer, logSMTP bool,
+	safeMode bool,
 ) (*Bridge, error) {
-	tlsConfig, err := loadTLSConfig(vault)
+	tlsCert, err := loadTLSCert(vault)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load TLS config: %w", err)
 	}
@@ -236,17 +366,29 @@ func newBridge(
 		return nil, fmt.Errorf("failed to create focus service: %w", err)
 	}
 
+	// In safe mode, the index hook stays disabled and the servers listen on the default ports.
+	var safeModeSettings *safeModeSettings
+
+	indexHook := vault.GetIndexHook()
+
+	if safeMode {
+		safeModeSettings = newSafeModeSettings()
+		indexHook = ""
+	}
+
 	bridge := &Bridge{
 		vault: vault,
 
 		users:     make(map[string]*user.User),
 		usersLock: safe.NewRWMutex(),
 
-		api:        api,
-		proxyCtl:   proxyCtl,
-		identifier: identifier,
+		api:             api,
+		trafficShaper:   trafficShaper,
+		faultInjector:   faultInjector,
+		fixtureRecorder: fixtureRecorder,
+		proxyCtl:        proxyCtl,
+		identifier:      identifier,
 
-		tlsConfig:   tlsConfig,
 		imapEventCh: imapEventCh,
 
 		updater:   updater,
@@ -259,24 +401,42 @@ func newBridge(
 		panicHandler: panicHandler,
 		reporter:     reporter,
 
-		focusService: focusService,
-		autostarter:  autostarter,
-		locator:      locator,
+		focusService:  focusService,
+		autostarter:   autostarter,
+		mailtoHandler: mailtoHandler,
+		locator:       locator,
 
 		logIMAPClient: logIMAPClient,
 		logIMAPServer: logIMAPServer,
 		logSMTP:       logSMTP,
 
+		safeMode: safeModeSettings,
+
 		firstStart:  firstStart,
 		lastVersion: lastVersion,
 
 		tasks:       tasks,
 		syncService: syncservice.NewService(reporter, panicHandler),
+		indexHook:   indexhook.New(indexHook, indexhook.DefaultDelay),
+		errorCenter: newErrorCenter(),
+		announcer:   newAnnouncer(),
+		clientShims: imapservice.NewClientShims(vault.GetDisabledClientShims()),
+
+		redactedMessages: imapservice.NewRedactedMessages(),
+
+		exports: make(map[string]context.CancelFunc),
+		imports: make(map[string]context.CancelFunc),
 	}
 
+	// The servers get the certificate and TLS policy for each connection so that they apply without restarting them.
+	bridge.tlsCert.Store(tlsCert)
+	bridge.tlsConfig = newTLSConfig(&bridge.tlsCert, vault.GetTLSPolicy)
+
 	bridge.serverManager = imapsmtpserver.NewService(context.Background(),
 		&bridgeSMTPSettings{b: bridge},
 		&bridgeIMAPSettings{b: bridge},
+		&bridgePOP3Settings{b: bridge},
+		&bridgeNNTPSettings{b: bridge},
 		&bridgeEventPublisher{b: bridge},
 		panicHandler,
 		reporter,
@@ -290,15 +450,34 @@ func newBridge(
 
 	bridge.syncService.Run(bridge.tasks)
 
+	bridge.tasks.Once(bridge.indexHook.Run)
+
 	return bridge, nil
 }
 
 func (bridge *Bridge) init(tlsReporter TLSReporter) error {
-	// Enable or disable the proxy at startup.
-	if bridge.vault.GetProxyAllowed() {
-		bridge.proxyCtl.AllowProxy()
+	// Apply the DoH resolver settings at startup.
+	bridge.proxyCtl.SetResolverSettings(newResolverSettings(bridge.vault.GetResolver()))
+
+	// Enable or disable Tor and the proxy at startup.
+	bridge.applyTorSettings(bridge.vault.GetTor())
+
+	// Enable or disable privacy mode at startup.
+	bridge.applyPrivacyMode(bridge.GetPrivacyMode())
+
+	if bridge.safeMode != nil {
+		// Tell which settings aren't applied, and which of them fail to.
+		bridge.logSafeModeOverrides()
 	} else {
-		bridge.proxyCtl.DisallowProxy()
+		// Keep recording the API traffic if it was recorded in the previous session.
+		if err := bridge.applyRecordAPIFixtures(bridge.vault.GetRecordAPIFixtures()); err != nil {
+			logrus.WithError(err).Error("Failed to start recording API fixtures")
+		}
+
+		// Serve the metrics if they were served in the previous session.
+		if err := bridge.applyMetricsListener(bridge.vault.GetMetricsListener()); err != nil {
+			logrus.WithError(err).Error("Failed to serve metrics")
+		}
 	}
 
 	// Handle connection up/down events.
@@ -337,6 +516,15 @@ func (bridge *Bridge) init(tlsReporter TLSReporter) error {
 		return nil
 	})
 
+	// Check the local clock against the API's on every response.
+	bridge.api.AddPostRequestHook(bridge.measureClockOffset)
+
+	// Tell the slow command log which IMAP commands call the API.
+	bridge.api.AddPreRequestHook(func(_ *resty.Client, req *resty.Request) error {
+		bridge.serverManager.ReportRemoteCall(req.Context())
+		return nil
+	})
+
 	// Publish a TLS issue event if a TLS issue is encountered.
 	bridge.tasks.Once(func(ctx context.Context) {
 		async.RangeContext(ctx, tlsReporter.GetTLSIssueCh(), func(struct{}) {
@@ -347,9 +535,19 @@ func (bridge *Bridge) init(tlsReporter TLSReporter) error {
 
 	// Publish a raise event if the focus service is called.
 	bridge.tasks.Once(func(ctx context.Context) {
-		async.RangeContext(ctx, bridge.focusService.GetRaiseCh(), func(struct{}) {
-			logrus.Info("Focus service requested raise")
-			bridge.publish(events.Raise{})
+		async.RangeContext(ctx, bridge.focusService.GetRaiseCh(), func(args []string) {
+			// A mailto: URL is composed in the mail client rather than raising bridge, unless it can't be.
+			if url, ok := mailto.Find(args); ok {
+				err := bridge.HandleMailto(url)
+				if err == nil {
+					return
+				}
+
+				logrus.WithError(err).Warn("Failed to compose mailto URL")
+			}
+
+			logrus.WithField("args", args).Info("Focus service requested raise")
+			bridge.publish(events.Raise{Args: args})
 		})
 	})
 
@@ -361,6 +559,11 @@ func (bridge *Bridge) init(tlsReporter TLSReporter) error {
 		})
 	})
 
+	// Post the announcements to the users' Proton Bridge mailbox when triggered.
+	bridge.goAnnounce = bridge.tasks.Trigger(func(ctx context.Context) {
+		bridge.postAnnouncements(ctx)
+	})
+
 	// Attempt to load users from the vault when triggered.
 	bridge.goLoad = bridge.tasks.Trigger(func(ctx context.Context) {
 		if err := bridge.loadUsers(ctx); err != nil {
@@ -388,6 +591,61 @@ func (bridge *Bridge) init(tlsReporter TLSReporter) error {
 	})
 	defer bridge.goUpdate()
 
+	// Evaluate the do not disturb schedule periodically.
+	bridge.goDoNotDisturb = bridge.tasks.PeriodicOrTrigger(DoNotDisturbCheckInterval, 0, func(ctx context.Context) {
+		bridge.updateDoNotDisturb()
+	})
+	defer bridge.goDoNotDisturb()
+
+	// Refresh the unified inbox periodically or when triggered.
+	bridge.goUnifiedInbox = bridge.tasks.PeriodicOrTrigger(UnifiedInboxRefreshInterval, 0, func(ctx context.Context) {
+		bridge.refreshUnifiedInbox(ctx)
+	})
+
+	// Check the Tor circuit periodically or when triggered.
+	bridge.goTorCheck = bridge.tasks.PeriodicOrTrigger(TorCheckInterval, 0, func(ctx context.Context) {
+		bridge.checkTorCircuit(ctx)
+	})
+	defer bridge.goTorCheck()
+
+	// Check the expiry of the TLS certificate periodically or when triggered.
+	bridge.goTLSCertCheck = bridge.tasks.PeriodicOrTrigger(TLSCertCheckInterval, 0, func(ctx context.Context) {
+		bridge.checkTLSCert()
+	})
+	defer bridge.goTLSCertCheck()
+
+	// Check for security software interfering with bridge periodically or when triggered.
+	bridge.goInterferenceCheck = bridge.tasks.PeriodicOrTrigger(InterferenceCheckInterval, 0, func(ctx context.Context) {
+		bridge.checkInterference(ctx)
+	})
+	defer bridge.goInterferenceCheck()
+
+	// Check again whenever the IMAP or SMTP server starts listening, as they may not be when bridge starts.
+	serverReadyCh, _ := bridge.GetEvents(events.IMAPServerReady{}, events.SMTPServerReady{})
+	bridge.tasks.Once(func(ctx context.Context) {
+		async.RangeContext(ctx, serverReadyCh, func(events.Event) {
+			bridge.goInterferenceCheck()
+		})
+	})
+
+	// Check the free disk space periodically or when triggered.
+	bridge.goDiskSpaceCheck = bridge.tasks.PeriodicOrTrigger(DiskSpaceCheckInterval, 0, func(ctx context.Context) {
+		bridge.checkDiskSpace(ctx)
+	})
+	defer bridge.goDiskSpaceCheck()
+
+	// Check the local clock against the API's at startup and periodically.
+	checkClockSkew := bridge.tasks.PeriodicOrTrigger(ClockSkewCheckInterval, 0, func(ctx context.Context) {
+		bridge.checkClockSkew(ctx)
+	})
+	defer checkClockSkew()
+
+	// Purge removed data which can no longer be restored.
+	purgeRemovals := bridge.tasks.PeriodicOrTrigger(RemovalPurgeInterval, 0, func(ctx context.Context) {
+		bridge.purgeExpiredRemovals()
+	})
+	defer purgeRemovals()
+
 	// Install updates when available.
 	bridge.tasks.Once(func(ctx context.Context) {
 		async.RangeContext(ctx, bridge.installCh, func(job installJob) {
@@ -408,6 +666,7 @@ func (bridge *Bridge) GetEvents(ofType ...events.Event) (<-chan events.Event, co
 
 func (bridge *Bridge) PushError(err error) {
 	bridge.errors = append(bridge.errors, err)
+	bridge.errorCenter.handleStartupError(err)
 }
 
 func (bridge *Bridge) GetErrors() []error {
@@ -429,12 +688,25 @@ func (bridge *Bridge) Close(ctx context.Context) {
 		logrus.WithError(err).Error("Failed to close servers")
 	}
 
+	// Stop serving the metrics, if they are.
+	if err := bridge.applyMetricsListener(""); err != nil {
+		logrus.WithError(err).Error("Failed to stop serving metrics")
+	}
+
 	// Stop all ongoing tasks.
 	bridge.tasks.CancelAndWait()
 
+	// Close the unified inbox, if any.
+	bridge.closeUnifiedInbox()
+
 	// Close the focus service.
 	bridge.focusService.Close()
 
+	// Close the API fixture file, if any.
+	if err := bridge.fixtureRecorder.Stop(); err != nil {
+		logrus.WithError(err).Error("Failed to close API fixture file")
+	}
+
 	// Close the watchers.
 	bridge.watchersLock.Lock()
 	defer bridge.watchersLock.Unlock()
@@ -452,6 +724,10 @@ func (bridge *Bridge) publish(event events.Event) {
 
 	logrus.WithField("event", event).Debug("Publishing event")
 
+	if errorChanged, announced := bridge.errorCenter.handleEvent(event), bridge.announcer.handleEvent(event); errorChanged || announced {
+		bridge.goAnnounce()
+	}
+
 	for _, watcher := range bridge.watchers {
 		if watcher.IsWatching(event) {
 			if ok := watcher.Send(event); !ok {
@@ -525,18 +801,6 @@ func (bridge *Bridge) onStatusDown(ctx context.Context) {
 	}
 }
 
-func loadTLSConfig(vault *vault.Vault) (*tls.Config, error) {
-	cert, err := tls.X509KeyPair(vault.GetBridgeTLSCert())
-	if err != nil {
-		return nil, err
-	}
-
-	return &tls.Config{
-		Certificates: []tls.Certificate{cert},
-		MinVersion:   tls.VersionTLS12,
-	}, nil
-}
-
 func min(a, b time.Duration) time.Duration {
 	if a < b {
 		return a
@@ -487,6 +487,50 @@ func TestBridge_AutoUpdate(t *testing.T) {
 	})
 }
 
+func TestBridge_UpdateVerification(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, vaultKey []byte) {
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, vaultKey, func(bridge *bridge.Bridge, mocks *bridge.Mocks) {
+			require.NoError(t, bridge.SetAutoUpdate(true))
+
+			updateCh, done := bridge.GetEvents(events.UpdateInstalled{})
+			defer done()
+
+			// Install a new version.
+			mocks.Updater.SetLatestVersion(v2_4_0, v2_3_0)
+			bridge.CheckForUpdates()
+			<-updateCh
+		})
+
+		vaultDir, err := locator.ProvideSettingsPath()
+		require.NoError(t, err)
+
+		v, _, err := vault.New(vaultDir, t.TempDir(), vaultKey, async.NoopPanicHandler{})
+		require.NoError(t, err)
+
+		// The verification of the installed version is kept.
+		require.Equal(t, v2_4_0.String(), v.GetUpdateVerification().Version)
+
+		// Pretend the running version is the one that was installed.
+		require.NoError(t, v.SetUpdateVerification(updater.Verification{Version: v2_3_0.String(), LogID: "bridge-releases"}))
+		require.NoError(t, v.Close())
+
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, vaultKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			verification, err := b.GetUpdateVerification()
+			require.NoError(t, err)
+			require.Equal(t, "bridge-releases", verification.LogID)
+		})
+	})
+}
+
+func TestBridge_UpdateVerification_NotInstalledByUpdater(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, vaultKey []byte) {
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, vaultKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			_, err := b.GetUpdateVerification()
+			require.ErrorIs(t, err, bridge.ErrNoUpdateVerification)
+		})
+	})
+}
+
 func TestBridge_ManualUpdate(t *testing.T) {
 	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, vaultKey []byte) {
 		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, vaultKey, func(bridge *bridge.Bridge, mocks *bridge.Mocks) {
@@ -925,6 +969,7 @@ func withBridgeNoMocks(
 	vaultKey []byte,
 	tests func(*bridge.Bridge),
 	waitOnServers bool,
+	safeMode bool,
 ) {
 	// Bridge will disable the proxy by default at startup.
 	mocks.ProxyCtl.EXPECT().DisallowProxy()
@@ -948,6 +993,7 @@ func withBridgeNoMocks(
 		locator,
 		vault,
 		mocks.Autostarter,
+		mocks.MailtoHandler,
 		mocks.Updater,
 		v2_3_0,
 
@@ -966,6 +1012,9 @@ func withBridgeNoMocks(
 		os.Getenv("BRIDGE_LOG_IMAP_CLIENT") == "1",
 		os.Getenv("BRIDGE_LOG_IMAP_SERVER") == "1",
 		os.Getenv("BRIDGE_LOG_SMTP") == "1",
+
+		// The safe mode.
+		safeMode,
 	)
 	require.NoError(t, err)
 	require.Empty(t, bridge.GetErrors())
@@ -1007,7 +1056,7 @@ func withBridge(
 	withMocks(t, func(mocks *bridge.Mocks) {
 		withBridgeNoMocks(ctx, t, mocks, apiURL, netCtl, locator, vaultKey, func(bridge *bridge.Bridge) {
 			tests(bridge, mocks)
-		}, false)
+		}, false, false)
 	})
 }
 
@@ -1024,7 +1073,7 @@ func withBridgeWaitForServers(
 	withMocks(t, func(mocks *bridge.Mocks) {
 		withBridgeNoMocks(ctx, t, mocks, apiURL, netCtl, locator, vaultKey, func(bridge *bridge.Bridge) {
 			tests(bridge, mocks)
-		}, true)
+		}, true, false)
 	})
 }
 
@@ -18,6 +18,7 @@
 package bridge
 
 import (
+	"bytes"
 	"context"
 	"io"
 
@@ -49,12 +50,7 @@ func (bridge *Bridge) ReportBug(ctx context.Context, osType, osVersion, title, d
 	var attachment []proton.ReportBugAttachment
 
 	if attachLogs {
-		logsPath, err := bridge.locator.ProvideLogsPath()
-		if err != nil {
-			return err
-		}
-
-		buffer, err := logging.ZipLogsForBugReport(logsPath, DefaultMaxSessionCountForBugReport, DefaultMaxBugReportZipSize)
+		buffer, err := bridge.zipLogsForBugReport()
 		if err != nil {
 			return err
 		}
@@ -93,3 +89,26 @@ func (bridge *Bridge) ReportBug(ctx context.Context, osType, osVersion, title, d
 		Email:    email,
 	}, attachment...)
 }
+
+// GetSessionLogs returns the logs of the current session. They are only available when logs are kept in memory.
+func (bridge *Bridge) GetSessionLogs() ([]byte, error) {
+	log := logging.GetMemoryLog()
+	if log == nil {
+		return nil, ErrLogsOnDisk
+	}
+
+	return log.Bytes(), nil
+}
+
+func (bridge *Bridge) zipLogsForBugReport() (*bytes.Buffer, error) {
+	if log := logging.GetMemoryLog(); log != nil {
+		return log.Zip()
+	}
+
+	logsPath, err := bridge.locator.ProvideLogsPath()
+	if err != nil {
+		return nil, err
+	}
+
+	return logging.ZipLogsForBugReport(logsPath, DefaultMaxSessionCountForBugReport, DefaultMaxBugReportZipSize)
+}
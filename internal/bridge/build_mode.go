@@ -0,0 +1,217 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/safe"
+	"github.com/ProtonMail/proton-bridge/v3/pkg/message"
+	"github.com/sirupsen/logrus"
+)
+
+// GetConversationMode returns whether the messages of the given user are built with normalized threading headers.
+func (bridge *Bridge) GetConversationMode(userID string) (bool, error) {
+	return safe.RLockRetErr(func() (bool, error) {
+		user, ok := bridge.users[userID]
+		if !ok {
+			return false, ErrNoSuchUser
+		}
+
+		return user.GetConversationMode(), nil
+	}, bridge.usersLock)
+}
+
+// SetConversationMode sets whether the messages of the given user are built with normalized References
+// and In-Reply-To header fields, so that clients thread them more like the web client groups conversations.
+// The user is resynced when the mode changes, as the messages already synced have to be rebuilt.
+func (bridge *Bridge) SetConversationMode(ctx context.Context, userID string, enabled bool) error {
+	logrus.WithField("userID", userID).WithField("enabled", enabled).Info("Setting conversation mode")
+
+	return safe.RLockRet(func() error {
+		user, ok := bridge.users[userID]
+		if !ok {
+			return ErrNoSuchUser
+		}
+
+		if err := user.SetConversationMode(ctx, enabled); err != nil {
+			return fmt.Errorf("failed to set conversation mode: %w", err)
+		}
+
+		return nil
+	}, bridge.usersLock)
+}
+
+// GetTranscodeToUTF8 returns whether the messages of the given user are built with text in legacy charsets
+// transcoded to UTF-8.
+func (bridge *Bridge) GetTranscodeToUTF8(userID string) (bool, error) {
+	return safe.RLockRetErr(func() (bool, error) {
+		user, ok := bridge.users[userID]
+		if !ok {
+			return false, ErrNoSuchUser
+		}
+
+		return user.GetTranscodeToUTF8(), nil
+	}, bridge.usersLock)
+}
+
+// SetTranscodeToUTF8 sets whether the messages of the given user are built with text parts and header fields
+// in legacy charsets, such as KOI8-R or GB2312, transcoded to UTF-8 for clients with poor charset support.
+// Disabling it serves the messages in their original charsets again. The user is resynced when the setting
+// changes, as the messages already synced have to be rebuilt.
+func (bridge *Bridge) SetTranscodeToUTF8(ctx context.Context, userID string, enabled bool) error {
+	logrus.WithField("userID", userID).WithField("enabled", enabled).Info("Setting UTF-8 transcoding")
+
+	return safe.RLockRet(func() error {
+		user, ok := bridge.users[userID]
+		if !ok {
+			return ErrNoSuchUser
+		}
+
+		if err := user.SetTranscodeToUTF8(ctx, enabled); err != nil {
+			return fmt.Errorf("failed to set UTF-8 transcoding: %w", err)
+		}
+
+		return nil
+	}, bridge.usersLock)
+}
+
+// GetContactDisplayNames returns whether the messages of the given user are built with the senders' contact names
+// as From display names.
+func (bridge *Bridge) GetContactDisplayNames(userID string) (bool, error) {
+	return safe.RLockRetErr(func() (bool, error) {
+		user, ok := bridge.users[userID]
+		if !ok {
+			return false, ErrNoSuchUser
+		}
+
+		return user.GetContactDisplayNames(), nil
+	}, bridge.usersLock)
+}
+
+// SetContactDisplayNames sets whether the messages of the given user are built with the From display name replaced
+// by the name the user gave the sender in their Proton contacts, so that IMAP clients show "Mom" rather than whatever
+// the sender called themselves, like the web client does. The user is resynced when the setting changes, as the
+// messages already synced have to be rebuilt; the contacts are reloaded on every resync.
+func (bridge *Bridge) SetContactDisplayNames(ctx context.Context, userID string, enabled bool) error {
+	logrus.WithField("userID", userID).WithField("enabled", enabled).Info("Setting contact display names")
+
+	return safe.RLockRet(func() error {
+		user, ok := bridge.users[userID]
+		if !ok {
+			return ErrNoSuchUser
+		}
+
+		if err := user.SetContactDisplayNames(ctx, enabled); err != nil {
+			return fmt.Errorf("failed to set contact display names: %w", err)
+		}
+
+		return nil
+	}, bridge.usersLock)
+}
+
+// GetHeaderTemplate returns the template of extra header fields added to the messages of the given user.
+func (bridge *Bridge) GetHeaderTemplate(userID string) (string, error) {
+	return safe.RLockRetErr(func() (string, error) {
+		user, ok := bridge.users[userID]
+		if !ok {
+			return "", ErrNoSuchUser
+		}
+
+		return user.GetHeaderTemplate(), nil
+	}, bridge.usersLock)
+}
+
+// SetHeaderTemplate sets a text/template of extra header fields, one "Key: Value" per line, added to the messages
+// of the given user, such as the address a message was delivered to or the names of its labels, so that client
+// side filters can act on them. An empty text removes the template. The user is resynced when the template changes,
+// as the messages already synced have to be rebuilt.
+func (bridge *Bridge) SetHeaderTemplate(ctx context.Context, userID string, text string) error {
+	logrus.WithField("userID", userID).Info("Setting header template")
+
+	if text != "" {
+		if _, err := message.ParseHeaderTemplate(text); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidHeaderTemplate, err)
+		}
+	}
+
+	return safe.RLockRet(func() error {
+		user, ok := bridge.users[userID]
+		if !ok {
+			return ErrNoSuchUser
+		}
+
+		if err := user.SetHeaderTemplate(ctx, text); err != nil {
+			return fmt.Errorf("failed to set header template: %w", err)
+		}
+
+		return nil
+	}, bridge.usersLock)
+}
+
+// GetDateNormalization returns the time zone the Date headers of the given user's messages are converted to,
+// empty if they're kept as sent, and whether missing, invalid or far future ones are repaired.
+func (bridge *Bridge) GetDateNormalization(userID string) (string, bool, error) {
+	var (
+		timeZone string
+		repair   bool
+		err      error
+	)
+
+	safe.RLock(func() {
+		user, ok := bridge.users[userID]
+		if !ok {
+			err = ErrNoSuchUser
+			return
+		}
+
+		timeZone, repair = user.GetDateNormalization()
+	}, bridge.usersLock)
+
+	return timeZone, repair, err
+}
+
+// SetDateNormalization sets the IANA time zone, such as "Europe/Zurich", the Date headers of the given user's
+// messages are converted to, empty to keep them as sent, and whether missing, unparsable or far future ones are
+// replaced with the time the server received the message, keeping the original as X-Original-Date. This helps
+// clients that sort by the Date header rather than INTERNALDATE. The user is resynced when the setting changes,
+// as the messages already synced have to be rebuilt.
+func (bridge *Bridge) SetDateNormalization(ctx context.Context, userID string, timeZone string, repair bool) error {
+	logrus.WithField("userID", userID).WithField("timeZone", timeZone).WithField("repair", repair).Info("Setting date normalization")
+
+	if timeZone != "" {
+		if _, err := time.LoadLocation(timeZone); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidTimeZone, err)
+		}
+	}
+
+	return safe.RLockRet(func() error {
+		user, ok := bridge.users[userID]
+		if !ok {
+			return ErrNoSuchUser
+		}
+
+		if err := user.SetDateNormalization(ctx, timeZone, repair); err != nil {
+			return fmt.Errorf("failed to set date normalization: %w", err)
+		}
+
+		return nil
+	}, bridge.usersLock)
+}
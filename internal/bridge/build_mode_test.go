@@ -0,0 +1,294 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/ProtonMail/gluon/async"
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/go-proton-api/server"
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
+	"github.com/ProtonMail/proton-bridge/v3/internal/constants"
+	"github.com/ProtonMail/proton-bridge/v3/internal/events"
+	"github.com/emersion/go-imap"
+	"github.com/emersion/go-vcard"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBridge_ConversationMode(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		_, addrID, err := s.CreateUser("user", password)
+		require.NoError(t, err)
+
+		withClient(ctx, t, s, "user", password, func(ctx context.Context, c *proton.Client) {
+			createNumMessages(ctx, t, c, addrID, proton.InboxLabel, 3)
+		})
+
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			userLoginAndSync(ctx, t, b, "user", password)
+
+			info, err := b.QueryUserInfo("user")
+			require.NoError(t, err)
+
+			// Conversation mode is disabled by default.
+			enabled, err := b.GetConversationMode(info.UserID)
+			require.NoError(t, err)
+			require.False(t, enabled)
+
+			// Enabling conversation mode resyncs the user, so that the messages are rebuilt.
+			syncCh, done := chToType[events.Event, events.SyncFinished](b.GetEvents(events.SyncFinished{}))
+			defer done()
+
+			require.NoError(t, b.SetConversationMode(ctx, info.UserID, true))
+			require.Equal(t, info.UserID, (<-syncCh).UserID)
+
+			enabled, err = b.GetConversationMode(info.UserID)
+			require.NoError(t, err)
+			require.True(t, enabled)
+
+			// The messages are all there after the resync.
+			client, err := eventuallyDial(fmt.Sprintf("%v:%v", constants.Host, b.GetIMAPPort()))
+			require.NoError(t, err)
+			require.NoError(t, client.Login(info.Addresses[0], string(info.BridgePass)))
+			defer func() { _ = client.Logout() }()
+
+			messages, err := clientFetch(client, "INBOX")
+			require.NoError(t, err)
+			require.Len(t, messages, 3)
+
+			// Unknown users can't be configured.
+			require.ErrorIs(t, b.SetConversationMode(ctx, "no-such-user", true), bridge.ErrNoSuchUser)
+		})
+	})
+}
+
+func TestBridge_TranscodeToUTF8(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			userLoginAndSync(ctx, t, b, username, password)
+
+			info, err := b.QueryUserInfo(username)
+			require.NoError(t, err)
+
+			// Messages are served in their original charsets by default.
+			enabled, err := b.GetTranscodeToUTF8(info.UserID)
+			require.NoError(t, err)
+			require.False(t, enabled)
+
+			// Enabling transcoding resyncs the user, so that the messages are rebuilt.
+			syncCh, done := chToType[events.Event, events.SyncFinished](b.GetEvents(events.SyncFinished{}))
+			defer done()
+
+			require.NoError(t, b.SetTranscodeToUTF8(ctx, info.UserID, true))
+			require.Equal(t, info.UserID, (<-syncCh).UserID)
+
+			enabled, err = b.GetTranscodeToUTF8(info.UserID)
+			require.NoError(t, err)
+			require.True(t, enabled)
+
+			// Unknown users can't be configured.
+			require.ErrorIs(t, b.SetTranscodeToUTF8(ctx, "no-such-user", true), bridge.ErrNoSuchUser)
+		})
+	})
+}
+
+func TestBridge_HeaderTemplate(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		_, addrID, err := s.CreateUser("user", password)
+		require.NoError(t, err)
+
+		withClient(ctx, t, s, "user", password, func(ctx context.Context, c *proton.Client) {
+			createNumMessages(ctx, t, c, addrID, proton.InboxLabel, 1)
+		})
+
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			userLoginAndSync(ctx, t, b, "user", password)
+
+			info, err := b.QueryUserInfo("user")
+			require.NoError(t, err)
+
+			// No header fields are added by default.
+			text, err := b.GetHeaderTemplate(info.UserID)
+			require.NoError(t, err)
+			require.Empty(t, text)
+
+			// Invalid templates are rejected.
+			require.ErrorIs(t, b.SetHeaderTemplate(ctx, info.UserID, "X-PM-Labels: {{.Unknown}}"), bridge.ErrInvalidHeaderTemplate)
+
+			// Setting a template resyncs the user, so that the messages are rebuilt with the new fields.
+			syncCh, done := chToType[events.Event, events.SyncFinished](b.GetEvents(events.SyncFinished{}))
+			defer done()
+
+			require.NoError(t, b.SetHeaderTemplate(ctx, info.UserID, `X-PM-Labels: {{join .Labels ", "}}`))
+			require.Equal(t, info.UserID, (<-syncCh).UserID)
+
+			text, err = b.GetHeaderTemplate(info.UserID)
+			require.NoError(t, err)
+			require.Equal(t, `X-PM-Labels: {{join .Labels ", "}}`, text)
+
+			client, err := eventuallyDial(fmt.Sprintf("%v:%v", constants.Host, b.GetIMAPPort()))
+			require.NoError(t, err)
+			require.NoError(t, client.Login(info.Addresses[0], string(info.BridgePass)))
+			defer func() { _ = client.Logout() }()
+
+			messages, err := clientFetch(client, "INBOX")
+			require.NoError(t, err)
+			require.Len(t, messages, 1)
+
+			literal, err := io.ReadAll(messages[0].GetBody(must(imap.ParseBodySectionName("BODY[]"))))
+			require.NoError(t, err)
+			require.Regexp(t, `X-Pm-Labels: .*Inbox`, string(literal))
+
+			// Unknown users can't be configured.
+			require.ErrorIs(t, b.SetHeaderTemplate(ctx, "no-such-user", ""), bridge.ErrNoSuchUser)
+		})
+	})
+}
+
+func TestBridge_ContactDisplayNames(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		_, addrID, err := s.CreateUser("user", password)
+		require.NoError(t, err)
+
+		withClient(ctx, t, s, "user", password, func(ctx context.Context, c *proton.Client) {
+			createNumMessages(ctx, t, c, addrID, proton.InboxLabel, 1)
+			createContactWithName(ctx, t, c, addrID, "sender@pm.me", "Mom")
+		})
+
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			userLoginAndSync(ctx, t, b, "user", password)
+
+			info, err := b.QueryUserInfo("user")
+			require.NoError(t, err)
+
+			// Senders' own display names are kept by default.
+			enabled, err := b.GetContactDisplayNames(info.UserID)
+			require.NoError(t, err)
+			require.False(t, enabled)
+
+			// Enabling contact names resyncs the user, so that the messages are rebuilt with them.
+			syncCh, done := chToType[events.Event, events.SyncFinished](b.GetEvents(events.SyncFinished{}))
+			defer done()
+
+			require.NoError(t, b.SetContactDisplayNames(ctx, info.UserID, true))
+			require.Equal(t, info.UserID, (<-syncCh).UserID)
+
+			enabled, err = b.GetContactDisplayNames(info.UserID)
+			require.NoError(t, err)
+			require.True(t, enabled)
+
+			client, err := eventuallyDial(fmt.Sprintf("%v:%v", constants.Host, b.GetIMAPPort()))
+			require.NoError(t, err)
+			require.NoError(t, client.Login(info.Addresses[0], string(info.BridgePass)))
+			defer func() { _ = client.Logout() }()
+
+			messages, err := clientFetch(client, "INBOX")
+			require.NoError(t, err)
+			require.Len(t, messages, 1)
+			require.Equal(t, "Mom", messages[0].Envelope.From[0].PersonalName)
+
+			// Unknown users can't be configured.
+			require.ErrorIs(t, b.SetContactDisplayNames(ctx, "no-such-user", true), bridge.ErrNoSuchUser)
+		})
+	})
+}
+
+func createContactWithName(ctx context.Context, t *testing.T, c *proton.Client, addrID, email, name string) {
+	user, err := c.GetUser(ctx)
+	require.NoError(t, err)
+
+	addr, err := c.GetAddresses(ctx)
+	require.NoError(t, err)
+
+	salt, err := c.GetSalts(ctx)
+	require.NoError(t, err)
+
+	keyPass, err := salt.SaltForKey(password, user.Keys.Primary().ID)
+	require.NoError(t, err)
+
+	_, addrKRs, err := proton.Unlock(user, addr, keyPass, async.NoopPanicHandler{})
+	require.NoError(t, err)
+
+	card, err := proton.NewCard(addrKRs[addrID], proton.CardTypeSigned)
+	require.NoError(t, err)
+	require.NoError(t, card.Set(addrKRs[addrID], vcard.FieldFormattedName, &vcard.Field{Value: name}))
+	require.NoError(t, card.Set(addrKRs[addrID], vcard.FieldEmail, &vcard.Field{Value: email}))
+
+	res, err := c.CreateContacts(ctx, proton.CreateContactsReq{Contacts: []proton.ContactCards{{Cards: []*proton.Card{card}}}})
+	require.NoError(t, err)
+	require.Equal(t, proton.SuccessCode, res[0].Response.APIError.Code)
+}
+
+func TestBridge_DateNormalization(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		_, addrID, err := s.CreateUser("user", password)
+		require.NoError(t, err)
+
+		withClient(ctx, t, s, "user", password, func(ctx context.Context, c *proton.Client) {
+			createNumMessages(ctx, t, c, addrID, proton.InboxLabel, 1)
+		})
+
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			userLoginAndSync(ctx, t, b, "user", password)
+
+			info, err := b.QueryUserInfo("user")
+			require.NoError(t, err)
+
+			// Dates are kept as sent by default.
+			timeZone, repair, err := b.GetDateNormalization(info.UserID)
+			require.NoError(t, err)
+			require.Empty(t, timeZone)
+			require.False(t, repair)
+
+			// Unknown time zones are rejected.
+			require.ErrorIs(t, b.SetDateNormalization(ctx, info.UserID, "Mars/Olympus_Mons", true), bridge.ErrInvalidTimeZone)
+
+			// Normalizing dates resyncs the user, so that the messages are rebuilt with them.
+			syncCh, done := chToType[events.Event, events.SyncFinished](b.GetEvents(events.SyncFinished{}))
+			defer done()
+
+			require.NoError(t, b.SetDateNormalization(ctx, info.UserID, "Asia/Tokyo", true))
+			require.Equal(t, info.UserID, (<-syncCh).UserID)
+
+			timeZone, repair, err = b.GetDateNormalization(info.UserID)
+			require.NoError(t, err)
+			require.Equal(t, "Asia/Tokyo", timeZone)
+			require.True(t, repair)
+
+			client, err := eventuallyDial(fmt.Sprintf("%v:%v", constants.Host, b.GetIMAPPort()))
+			require.NoError(t, err)
+			require.NoError(t, client.Login(info.Addresses[0], string(info.BridgePass)))
+			defer func() { _ = client.Logout() }()
+
+			messages, err := clientFetch(client, "INBOX")
+			require.NoError(t, err)
+			require.Len(t, messages, 1)
+
+			literal, err := io.ReadAll(messages[0].GetBody(must(imap.ParseBodySectionName("BODY[]"))))
+			require.NoError(t, err)
+			require.Regexp(t, `(?m)^Date: .* \+0900\r$`, string(literal))
+
+			// Unknown users can't be configured.
+			require.ErrorIs(t, b.SetDateNormalization(ctx, "no-such-user", "", false), bridge.ErrNoSuchUser)
+		})
+	})
+}
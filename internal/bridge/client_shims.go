@@ -0,0 +1,96 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"github.com/ProtonMail/proton-bridge/v3/internal/safe"
+	"github.com/ProtonMail/proton-bridge/v3/internal/services/imapservice"
+	"github.com/ProtonMail/proton-bridge/v3/internal/services/imapsmtpserver"
+	"github.com/bradenaw/juniper/xslices"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+// ClientConnection is an IMAP client connected to bridge.
+type ClientConnection struct {
+	imapsmtpserver.Connection
+
+	// UserID is the user the client logged in to; it's empty until it logs in.
+	UserID string
+
+	// Shims are the names of the client shims applying to the client, as identified by the ID it sent.
+	Shims []string
+}
+
+// ListConnections returns the open IMAP connections, the oldest first, with the clients they identified as.
+func (bridge *Bridge) ListConnections() []ClientConnection {
+	return safe.RLockRet(func() []ClientConnection {
+		return xslices.Map(bridge.serverManager.GetConnections(), func(conn imapsmtpserver.Connection) ClientConnection {
+			res := ClientConnection{
+				Connection: conn,
+				Shims:      bridge.clientShims.GetActive(conn.ClientID),
+			}
+
+			for userID, user := range bridge.users {
+				if conn.GluonID != "" && slices.Contains(maps.Values(user.GetGluonIDs()), conn.GluonID) {
+					res.UserID = userID
+					break
+				}
+			}
+
+			return res
+		})
+	}, bridge.usersLock)
+}
+
+// GetClientShims returns the known workarounds for IMAP client quirks.
+func (bridge *Bridge) GetClientShims() []imapservice.ClientShim {
+	return imapservice.GetClientShims()
+}
+
+// IsClientShimEnabled returns whether the client shim with the given name is enabled.
+func (bridge *Bridge) IsClientShimEnabled(name string) bool {
+	return bridge.clientShims.IsEnabled(name)
+}
+
+// SetClientShimEnabled enables or disables the client shim with the given name.
+// The change applies to the next commands of the connected clients.
+func (bridge *Bridge) SetClientShimEnabled(name string, enabled bool) error {
+	if !xslices.Any(imapservice.GetClientShims(), func(shim imapservice.ClientShim) bool { return shim.Name == name }) {
+		return ErrNoSuchClientShim
+	}
+
+	bridge.clientShims.SetEnabled(name, enabled)
+
+	var disabled []string
+
+	for _, shim := range imapservice.GetClientShims() {
+		if !bridge.clientShims.IsEnabled(shim.Name) {
+			disabled = append(disabled, shim.Name)
+		}
+	}
+
+	if err := bridge.vault.SetDisabledClientShims(disabled); err != nil {
+		return err
+	}
+
+	logrus.WithField("shim", name).WithField("enabled", enabled).Info("Client shim changed")
+
+	return nil
+}
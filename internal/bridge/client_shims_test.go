@@ -0,0 +1,100 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/go-proton-api/server"
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
+	"github.com/ProtonMail/proton-bridge/v3/internal/constants"
+	"github.com/ProtonMail/proton-bridge/v3/internal/services/imapservice"
+	"github.com/emersion/go-imap"
+	imapid "github.com/emersion/go-imap-id"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBridge_ClientShims(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			userLoginAndSync(ctx, t, b, username, password)
+
+			info, err := b.QueryUserInfo(username)
+			require.NoError(t, err)
+
+			userID := info.UserID
+
+			require.ErrorIs(t, b.SetClientShimEnabled("no-such-shim", false), bridge.ErrNoSuchClientShim)
+
+			client, err := eventuallyDial(fmt.Sprintf("%v:%v", constants.Host, b.GetIMAPPort()))
+			require.NoError(t, err)
+			defer func() { _ = client.Logout() }()
+
+			_, err = imapid.NewClient(client).ID(imapid.ID{
+				imapid.FieldName:    "Microsoft Outlook",
+				imapid.FieldVersion: "16.0",
+			})
+			require.NoError(t, err)
+
+			require.NoError(t, client.Login(info.Addresses[0], string(info.BridgePass)))
+
+			// The connection lists the account and client, with the shims applying to it.
+			require.Eventually(t, func() bool {
+				conns := b.ListConnections()
+				return len(conns) == 1 && conns[0].UserID == userID && conns[0].ClientID.Name == "Microsoft Outlook"
+			}, 5*time.Second, 100*time.Millisecond)
+			require.Equal(t, []string{imapservice.ShimOutlookSentSeen}, b.ListConnections()[0].Shims)
+
+			appendSent := func(subject string) {
+				literal := fmt.Sprintf("Date: %v\r\nFrom: %v\r\nTo: someone@example.com\r\nSubject: %v\r\n\r\nbody\r\n", time.Now().Format(time.RFC1123Z), info.Addresses[0], subject)
+				require.NoError(t, client.Append("Sent", nil, time.Now(), strings.NewReader(literal)))
+			}
+
+			isSeen := func(subject string) bool {
+				_, err := client.Select("Sent", true)
+				require.NoError(t, err)
+
+				criteria := imap.NewSearchCriteria()
+				criteria.Header.Set("Subject", subject)
+				criteria.WithFlags = []string{imap.SeenFlag}
+
+				uids, err := client.UidSearch(criteria)
+				require.NoError(t, err)
+
+				return len(uids) == 1
+			}
+
+			// Sent messages appended by Outlook are marked as read.
+			appendSent("with shim")
+			require.True(t, isSeen("with shim"))
+
+			// Unless the shim is disabled.
+			require.NoError(t, b.SetClientShimEnabled(imapservice.ShimOutlookSentSeen, false))
+			require.False(t, b.IsClientShimEnabled(imapservice.ShimOutlookSentSeen))
+			require.Empty(t, b.ListConnections()[0].Shims)
+
+			appendSent("without shim")
+			require.False(t, isSeen("without shim"))
+		})
+	})
+}
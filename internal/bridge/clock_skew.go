@@ -0,0 +1,83 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/events"
+	"github.com/go-resty/resty/v2"
+	"github.com/sirupsen/logrus"
+)
+
+// MaxClockSkew is the largest difference between the local clock and the API's which is tolerated.
+// Beyond it, authentication may fail because the API considers proofs and tokens expired or not yet valid.
+const MaxClockSkew = time.Minute
+
+// ClockSkewCheckInterval is how often the API is pinged to measure the clock offset, besides the other API requests.
+const ClockSkewCheckInterval = time.Hour
+
+// GetClockOffset returns the difference between the API's clock and the local clock, as measured on the last
+// API response. It is positive if the local clock is behind.
+func (bridge *Bridge) GetClockOffset() time.Duration {
+	return time.Duration(bridge.clockOffset.Load())
+}
+
+// IsClockSkewed returns whether the local clock differs from the API's by more than MaxClockSkew.
+func (bridge *Bridge) IsClockSkewed() bool {
+	return bridge.clockSkewed.Load()
+}
+
+// checkClockSkew pings the API, so that the clock offset is measured even when no user is signed in
+// or nothing else calls the API; its response is measured by measureClockOffset like any other.
+func (bridge *Bridge) checkClockSkew(ctx context.Context) {
+	if err := bridge.api.Ping(ctx); err != nil {
+		logrus.WithError(err).Debug("Failed to ping the API to check the clock")
+	}
+}
+
+// measureClockOffset records the clock offset from the Date header of an API response
+// and publishes an event if the local clock has become skewed.
+func (bridge *Bridge) measureClockOffset(_ *resty.Client, r *resty.Response) error {
+	date, err := http.ParseTime(r.Header().Get("Date"))
+	if err != nil {
+		return nil //nolint:nilerr
+	}
+
+	// The header has a resolution of one second, so offsets below that are noise.
+	offset := date.Sub(r.ReceivedAt()).Truncate(time.Second)
+
+	bridge.clockOffset.Store(int64(offset))
+
+	skewed := offset > MaxClockSkew || offset < -MaxClockSkew
+
+	if bridge.clockSkewed.Swap(skewed) == skewed {
+		return nil
+	}
+
+	if skewed {
+		logrus.WithField("offset", offset).Warn("The system clock is wrong, please enable automatic time synchronization")
+		bridge.publish(events.ClockSkewDetected{Offset: offset})
+	} else {
+		logrus.WithField("offset", offset).Info("The system clock is no longer skewed")
+	}
+
+	return nil
+}
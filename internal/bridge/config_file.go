@@ -0,0 +1,551 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/dialer"
+	"github.com/ProtonMail/proton-bridge/v3/internal/logging"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+	"github.com/hashicorp/go-multierror"
+	"github.com/pelletier/go-toml/v2"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFile holds the settings of a configuration file, applied at startup with ApplyConfigFile so that bridge
+// can be set up without a frontend, e.g. on a server. Settings left out of the file keep their saved value.
+type ConfigFile struct {
+	IMAP *ConfigServer `yaml:"imap" toml:"imap"`
+	SMTP *ConfigServer `yaml:"smtp" toml:"smtp"`
+
+	// GluonDir is the directory where the messages are cached.
+	GluonDir *string `yaml:"gluon-dir" toml:"gluon-dir"`
+
+	Proxy *ConfigProxy `yaml:"proxy" toml:"proxy"`
+
+	Accounts []ConfigAccount `yaml:"accounts" toml:"accounts"`
+}
+
+// ConfigServer holds the settings of the IMAP or SMTP server.
+type ConfigServer struct {
+	Port        *int    `yaml:"port" toml:"port"`
+	SSL         *bool   `yaml:"ssl" toml:"ssl"`
+	BindAddress *string `yaml:"bind-address" toml:"bind-address"`
+}
+
+// ConfigProxy holds the settings of how the API is reached.
+type ConfigProxy struct {
+	// AlternativeRouting is whether the API may be reached through a proxy when it is blocked.
+	AlternativeRouting *bool    `yaml:"alternative-routing" toml:"alternative-routing"`
+	DoHProviders       []string `yaml:"doh-providers" toml:"doh-providers"`
+	BootstrapIPs       []string `yaml:"bootstrap-ips" toml:"bootstrap-ips"`
+
+	Tor *ConfigTor `yaml:"tor" toml:"tor"`
+}
+
+// ConfigTor holds the settings of routing the API traffic through Tor.
+type ConfigTor struct {
+	Enabled      *bool   `yaml:"enabled" toml:"enabled"`
+	SOCKSAddress *string `yaml:"socks-address" toml:"socks-address"`
+	OnionHost    *string `yaml:"onion-host" toml:"onion-host"`
+}
+
+// ConfigAccount is an account logged in at startup if it isn't already.
+// Passwords are read from files, e.g. secrets mounted in a container, rather than written in the configuration.
+type ConfigAccount struct {
+	Username string `yaml:"username" toml:"username"`
+
+	PasswordFile        string `yaml:"password-file" toml:"password-file"`
+	MailboxPasswordFile string `yaml:"mailbox-password-file" toml:"mailbox-password-file"`
+
+	// AddressMode is either split or combined.
+	AddressMode *string `yaml:"address-mode" toml:"address-mode"`
+}
+
+// ConfigChange is a setting changed by ApplyConfigFile.
+type ConfigChange struct {
+	// Setting is the name of the setting, as in the CLI.
+	Setting string
+
+	// UserID is the user the setting belongs to, if it is a user setting.
+	UserID string
+
+	Old, New string
+}
+
+func (change ConfigChange) String() string {
+	if change.UserID != "" {
+		return fmt.Sprintf("%v (user %v): %q -> %q", change.Setting, change.UserID, change.Old, change.New)
+	}
+
+	return fmt.Sprintf("%v: %q -> %q", change.Setting, change.Old, change.New)
+}
+
+// LoadConfigFile reads the YAML (.yaml or .yml) or TOML (.toml) configuration file at the given path.
+// Unknown settings are rejected so that typos don't go unnoticed; relative password files are relative to the file.
+func LoadConfigFile(path string) (ConfigFile, error) {
+	b, err := os.ReadFile(path) //nolint:gosec
+	if err != nil {
+		return ConfigFile{}, fmt.Errorf("failed to read configuration file: %w", err)
+	}
+
+	var file ConfigFile
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(b))
+		dec.KnownFields(true)
+
+		if err := dec.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+			return ConfigFile{}, fmt.Errorf("%w: %v", ErrInvalidConfigFile, err)
+		}
+
+	case ".toml":
+		dec := toml.NewDecoder(bytes.NewReader(b))
+		dec.DisallowUnknownFields()
+
+		if err := dec.Decode(&file); err != nil {
+			return ConfigFile{}, fmt.Errorf("%w: %v", ErrInvalidConfigFile, err)
+		}
+
+	default:
+		return ConfigFile{}, fmt.Errorf("%w: %q", ErrUnknownConfigFormat, ext)
+	}
+
+	for idx, account := range file.Accounts {
+		file.Accounts[idx].PasswordFile = resolveConfigPath(path, account.PasswordFile)
+		file.Accounts[idx].MailboxPasswordFile = resolveConfigPath(path, account.MailboxPasswordFile)
+	}
+
+	return file, nil
+}
+
+// configSetting is a setting of a configuration file which differs from the saved one.
+type configSetting struct {
+	name     string
+	old, new string
+
+	// validate checks the new value before any setting is applied.
+	validate func() error
+
+	apply func(ctx context.Context) error
+}
+
+// ApplyConfigFile saves the settings of the configuration file which differ from the saved ones, then logs in
+// its accounts which aren't yet. The file takes precedence over the saved settings, so those changed in a frontend
+// are reverted at the next startup; to keep them, leave them out of the file. In safe mode, nothing is applied,
+// as the file may hold the setting preventing normal startup.
+//
+// All the settings are validated first; if any is invalid, nothing is changed and ErrInvalidConfigFile is returned.
+// The changes made are returned, even if applying a later one failed.
+func (bridge *Bridge) ApplyConfigFile(ctx context.Context, file ConfigFile) ([]ConfigChange, error) {
+	if bridge.IsSafeMode() {
+		logrus.Warn("Not applying configuration file in safe mode")
+		return nil, nil
+	}
+
+	settings := bridge.getConfigSettings(file)
+
+	var errs error
+
+	for _, setting := range settings {
+		if err := setting.validate(); err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("%v: %w", setting.name, err))
+		}
+	}
+
+	if err := bridge.validateConfigPorts(file); err != nil {
+		errs = multierror.Append(errs, err)
+	}
+
+	for _, account := range file.Accounts {
+		if err := validateConfigAccount(account); err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("account %q: %w", account.Username, err))
+		}
+	}
+
+	if errs != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidConfigFile, errs)
+	}
+
+	var changes []ConfigChange
+
+	for _, setting := range settings {
+		logrus.WithField("setting", setting.name).Info("Applying setting of configuration file")
+
+		if err := setting.apply(ctx); err != nil {
+			return changes, fmt.Errorf("failed to apply %v: %w", setting.name, err)
+		}
+
+		changes = append(changes, ConfigChange{Setting: setting.name, Old: setting.old, New: setting.new})
+	}
+
+	for _, account := range file.Accounts {
+		accountChanges, err := bridge.applyConfigAccount(ctx, account)
+
+		changes = append(changes, accountChanges...)
+
+		if err != nil {
+			return changes, fmt.Errorf("failed to apply account %q: %w", account.Username, err)
+		}
+	}
+
+	return changes, nil
+}
+
+// getConfigSettings returns the settings of the file which differ from the saved ones.
+func (bridge *Bridge) getConfigSettings(file ConfigFile) []configSetting {
+	var settings []configSetting
+
+	add := func(name string, old, new any, validate func() error, apply func(context.Context) error) {
+		if oldStr, newStr := formatConfigValue(old), formatConfigValue(new); oldStr != newStr {
+			settings = append(settings, configSetting{name: name, old: oldStr, new: newStr, validate: validate, apply: apply})
+		}
+	}
+
+	noValidation := func() error { return nil }
+
+	if imap := file.IMAP; imap != nil {
+		if imap.Port != nil {
+			add("imap-port", bridge.vault.GetIMAPPort(), *imap.Port, func() error { return validateConfigPort(*imap.Port) }, func(ctx context.Context) error {
+				return bridge.SetIMAPPort(ctx, *imap.Port)
+			})
+		}
+
+		if imap.SSL != nil {
+			add("imap-security", bridge.vault.GetIMAPSSL(), *imap.SSL, noValidation, func(ctx context.Context) error {
+				return bridge.SetIMAPSSL(ctx, *imap.SSL)
+			})
+		}
+
+		if imap.BindAddress != nil {
+			add("imap-bind-address", bridge.vault.GetIMAPBindAddress(), *imap.BindAddress, func() error {
+				_, err := parseBindAddress(*imap.BindAddress)
+				return err
+			}, func(ctx context.Context) error {
+				return bridge.SetIMAPBindAddress(ctx, *imap.BindAddress)
+			})
+		}
+	}
+
+	if smtp := file.SMTP; smtp != nil {
+		if smtp.Port != nil {
+			add("smtp-port", bridge.vault.GetSMTPPort(), *smtp.Port, func() error { return validateConfigPort(*smtp.Port) }, func(ctx context.Context) error {
+				return bridge.SetSMTPPort(ctx, *smtp.Port)
+			})
+		}
+
+		if smtp.SSL != nil {
+			add("smtp-security", bridge.vault.GetSMTPSSL(), *smtp.SSL, noValidation, func(ctx context.Context) error {
+				return bridge.SetSMTPSSL(ctx, *smtp.SSL)
+			})
+		}
+
+		if smtp.BindAddress != nil {
+			add("smtp-bind-address", bridge.vault.GetSMTPBindAddress(), *smtp.BindAddress, func() error {
+				_, err := parseBindAddress(*smtp.BindAddress)
+				return err
+			}, func(ctx context.Context) error {
+				return bridge.SetSMTPBindAddress(ctx, *smtp.BindAddress)
+			})
+		}
+	}
+
+	if file.GluonDir != nil {
+		add("change-location", bridge.GetGluonCacheDir(), *file.GluonDir, func() error {
+			if !filepath.IsAbs(*file.GluonDir) {
+				return fmt.Errorf("%w: %q", ErrRelativeConfigPath, *file.GluonDir)
+			}
+
+			return checkWritable(*file.GluonDir)
+		}, func(ctx context.Context) error {
+			return bridge.SetGluonDir(ctx, *file.GluonDir)
+		})
+	}
+
+	if proxy := file.Proxy; proxy != nil {
+		bridge.getConfigProxySettings(proxy, add, noValidation)
+	}
+
+	return settings
+}
+
+func (bridge *Bridge) getConfigProxySettings(
+	proxy *ConfigProxy,
+	add func(string, any, any, func() error, func(context.Context) error),
+	noValidation func() error,
+) {
+	if proxy.AlternativeRouting != nil {
+		add("proxy", bridge.vault.GetProxyAllowed(), *proxy.AlternativeRouting, noValidation, func(context.Context) error {
+			return bridge.SetProxyAllowed(*proxy.AlternativeRouting)
+		})
+	}
+
+	resolver := bridge.vault.GetResolver()
+
+	if proxy.DoHProviders != nil {
+		add("doh-providers", resolver.DoHProviders, proxy.DoHProviders, func() error {
+			for _, provider := range proxy.DoHProviders {
+				if err := dialer.ValidateDoHProvider(provider); err != nil {
+					return fmt.Errorf("%w %q: %v", ErrInvalidDoHProvider, provider, err)
+				}
+			}
+
+			return nil
+		}, func(context.Context) error {
+			return bridge.SetDoHProviders(proxy.DoHProviders)
+		})
+	}
+
+	if proxy.BootstrapIPs != nil {
+		add("bootstrap-ips", resolver.BootstrapIPs, proxy.BootstrapIPs, func() error {
+			for _, ip := range proxy.BootstrapIPs {
+				if net.ParseIP(ip) == nil {
+					return fmt.Errorf("%w: %q", ErrInvalidBootstrapIP, ip)
+				}
+			}
+
+			return nil
+		}, func(context.Context) error {
+			return bridge.SetDoHBootstrapIPs(proxy.BootstrapIPs)
+		})
+	}
+
+	tor := proxy.Tor
+	if tor == nil {
+		return
+	}
+
+	settings := bridge.vault.GetTor()
+
+	if tor.Enabled != nil {
+		add("tor", settings.Enabled, *tor.Enabled, noValidation, func(context.Context) error {
+			return bridge.SetTorEnabled(*tor.Enabled)
+		})
+	}
+
+	if tor.SOCKSAddress != nil {
+		add("socks-address", settings.SOCKSAddress, *tor.SOCKSAddress, func() error {
+			if *tor.SOCKSAddress == "" {
+				return nil
+			}
+
+			if _, _, err := net.SplitHostPort(*tor.SOCKSAddress); err != nil {
+				return fmt.Errorf("%w: %v", ErrInvalidSOCKSAddress, err)
+			}
+
+			return nil
+		}, func(context.Context) error {
+			return bridge.SetTorSOCKSAddress(*tor.SOCKSAddress)
+		})
+	}
+
+	if tor.OnionHost != nil {
+		add("onion-host", settings.OnionHost, *tor.OnionHost, func() error {
+			if *tor.OnionHost == "" {
+				return nil
+			}
+
+			if err := dialer.ValidateOnionHost(*tor.OnionHost); err != nil {
+				return fmt.Errorf("%w: %v", ErrInvalidOnionHost, err)
+			}
+
+			return nil
+		}, func(context.Context) error {
+			return bridge.SetTorOnionHost(*tor.OnionHost)
+		})
+	}
+}
+
+// validateConfigPorts checks that the IMAP and SMTP servers won't listen on the same port once the file is applied.
+func (bridge *Bridge) validateConfigPorts(file ConfigFile) error {
+	imapPort, smtpPort := bridge.vault.GetIMAPPort(), bridge.vault.GetSMTPPort()
+
+	if file.IMAP != nil && file.IMAP.Port != nil {
+		imapPort = *file.IMAP.Port
+	}
+
+	if file.SMTP != nil && file.SMTP.Port != nil {
+		smtpPort = *file.SMTP.Port
+	}
+
+	if imapPort != 0 && imapPort == smtpPort {
+		return fmt.Errorf("smtp-port: %w: %v is also the imap-port", ErrPortConflict, smtpPort)
+	}
+
+	return nil
+}
+
+// applyConfigAccount logs in the account unless it already is, then sets its address mode.
+func (bridge *Bridge) applyConfigAccount(ctx context.Context, account ConfigAccount) ([]ConfigChange, error) {
+	var changes []ConfigChange
+
+	userID, ok := bridge.findConfigAccount(account.Username)
+	if !ok {
+		logrus.WithField("username", logging.Sensitive(account.Username)).Info("Logging in account of configuration file")
+
+		password, err := os.ReadFile(account.PasswordFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read password: %w", err)
+		}
+
+		if userID, err = bridge.LoginFull(ctx, account.Username, bytes.TrimRight(password, "\r\n"),
+			func() (string, error) {
+				return "", ErrConfigAccountTOTP
+			},
+			func() ([]byte, error) {
+				if account.MailboxPasswordFile == "" {
+					return nil, ErrConfigAccountMailboxPassword
+				}
+
+				keyPass, err := os.ReadFile(account.MailboxPasswordFile)
+				if err != nil {
+					return nil, fmt.Errorf("failed to read mailbox password: %w", err)
+				}
+
+				return bytes.TrimRight(keyPass, "\r\n"), nil
+			},
+		); err != nil {
+			return nil, err
+		}
+
+		changes = append(changes, ConfigChange{Setting: "login", UserID: userID, Old: "signed out", New: "connected"})
+	}
+
+	if account.AddressMode == nil {
+		return changes, nil
+	}
+
+	info, err := bridge.GetUserInfo(userID)
+	if err != nil {
+		return changes, err
+	}
+
+	mode, err := parseConfigAddressMode(*account.AddressMode)
+	if err != nil {
+		return changes, err
+	}
+
+	if mode == info.AddressMode {
+		return changes, nil
+	}
+
+	if err := bridge.SetAddressMode(ctx, userID, mode); err != nil {
+		return changes, err
+	}
+
+	return append(changes, ConfigChange{Setting: "mode", UserID: userID, Old: info.AddressMode.String(), New: mode.String()}), nil
+}
+
+// findConfigAccount returns the ID of the connected user with the given username or address.
+func (bridge *Bridge) findConfigAccount(username string) (string, bool) {
+	for _, userID := range bridge.GetUserIDs() {
+		info, err := bridge.GetUserInfo(userID)
+		if err != nil || info.State != Connected {
+			continue
+		}
+
+		if strings.EqualFold(info.Username, username) {
+			return userID, true
+		}
+
+		for _, address := range info.Addresses {
+			if strings.EqualFold(address, username) {
+				return userID, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+func validateConfigAccount(account ConfigAccount) error {
+	if account.Username == "" {
+		return ErrConfigAccountUsername
+	}
+
+	if account.PasswordFile == "" {
+		return ErrConfigAccountPassword
+	}
+
+	for _, path := range []string{account.PasswordFile, account.MailboxPasswordFile} {
+		if path == "" {
+			continue
+		}
+
+		if _, err := os.Stat(path); err != nil {
+			return err
+		}
+	}
+
+	if account.AddressMode != nil {
+		if _, err := parseConfigAddressMode(*account.AddressMode); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validateConfigPort(port int) error {
+	if port < 0 || port > 65535 {
+		return fmt.Errorf("%w: %v", ErrInvalidPort, port)
+	}
+
+	return nil
+}
+
+func parseConfigAddressMode(mode string) (vault.AddressMode, error) {
+	switch strings.ToLower(mode) {
+	case vault.CombinedMode.String():
+		return vault.CombinedMode, nil
+
+	case vault.SplitMode.String():
+		return vault.SplitMode, nil
+
+	default:
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAddressMode, mode)
+	}
+}
+
+// formatConfigValue formats a setting so that the old and new values can be compared and reported.
+func formatConfigValue(value any) string {
+	if values, ok := value.([]string); ok {
+		return strings.Join(values, ",")
+	}
+
+	return fmt.Sprint(value)
+}
+
+func resolveConfigPath(configPath, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+
+	return filepath.Join(filepath.Dir(configPath), path)
+}
@@ -0,0 +1,192 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/go-proton-api/server"
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+	"github.com/ProtonMail/proton-bridge/v3/pkg/ports"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfigFile(t *testing.T) {
+	dir := t.TempDir()
+
+	yamlPath := filepath.Join(dir, "bridge.yaml")
+	require.NoError(t, os.WriteFile(yamlPath, []byte(`
+imap:
+  port: 1143
+  ssl: true
+gluon-dir: /var/lib/bridge
+proxy:
+  doh-providers: [https://dns.example.com/dns-query]
+  tor:
+    enabled: false
+accounts:
+  - username: user@example.com
+    password-file: secrets/password
+    address-mode: split
+`), 0o600))
+
+	file, err := bridge.LoadConfigFile(yamlPath)
+	require.NoError(t, err)
+	require.Equal(t, 1143, *file.IMAP.Port)
+	require.True(t, *file.IMAP.SSL)
+	require.Nil(t, file.IMAP.BindAddress)
+	require.Nil(t, file.SMTP)
+	require.Equal(t, "/var/lib/bridge", *file.GluonDir)
+	require.Equal(t, []string{"https://dns.example.com/dns-query"}, file.Proxy.DoHProviders)
+	require.False(t, *file.Proxy.Tor.Enabled)
+	require.Len(t, file.Accounts, 1)
+	require.Equal(t, filepath.Join(dir, "secrets", "password"), file.Accounts[0].PasswordFile)
+	require.Equal(t, "split", *file.Accounts[0].AddressMode)
+
+	tomlPath := filepath.Join(dir, "bridge.toml")
+	require.NoError(t, os.WriteFile(tomlPath, []byte(`
+gluon-dir = "/var/lib/bridge"
+
+[smtp]
+port = 1025
+bind-address = "0.0.0.0"
+
+[[accounts]]
+username = "user@example.com"
+password-file = "/run/secrets/password"
+`), 0o600))
+
+	file, err = bridge.LoadConfigFile(tomlPath)
+	require.NoError(t, err)
+	require.Nil(t, file.IMAP)
+	require.Equal(t, 1025, *file.SMTP.Port)
+	require.Equal(t, "0.0.0.0", *file.SMTP.BindAddress)
+	require.Equal(t, "/run/secrets/password", file.Accounts[0].PasswordFile)
+
+	// An empty file holds no settings.
+	emptyPath := filepath.Join(dir, "empty.yml")
+	require.NoError(t, os.WriteFile(emptyPath, nil, 0o600))
+
+	file, err = bridge.LoadConfigFile(emptyPath)
+	require.NoError(t, err)
+	require.Equal(t, bridge.ConfigFile{}, file)
+
+	// Unknown settings are rejected.
+	require.NoError(t, os.WriteFile(yamlPath, []byte("imap:\n  prot: 1143\n"), 0o600))
+	_, err = bridge.LoadConfigFile(yamlPath)
+	require.ErrorIs(t, err, bridge.ErrInvalidConfigFile)
+
+	require.NoError(t, os.WriteFile(tomlPath, []byte("gluon_dir = \"/var/lib/bridge\"\n"), 0o600))
+	_, err = bridge.LoadConfigFile(tomlPath)
+	require.ErrorIs(t, err, bridge.ErrInvalidConfigFile)
+
+	// Other formats are rejected.
+	jsonPath := filepath.Join(dir, "bridge.json")
+	require.NoError(t, os.WriteFile(jsonPath, []byte("{}"), 0o600))
+	_, err = bridge.LoadConfigFile(jsonPath)
+	require.ErrorIs(t, err, bridge.ErrUnknownConfigFormat)
+}
+
+func TestBridge_ApplyConfigFile(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		_, _, err := s.CreateUser("config", password)
+		require.NoError(t, err)
+
+		passwordFile := filepath.Join(t.TempDir(), "password")
+		require.NoError(t, os.WriteFile(passwordFile, append(password, '\n'), 0o600))
+
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, mocks *bridge.Mocks) {
+			imapPort := ports.FindFreePortFrom(1143)
+			smtpSSL := true
+			socksAddress := "127.0.0.1:9150"
+			addressMode := "split"
+
+			file := bridge.ConfigFile{
+				IMAP:  &bridge.ConfigServer{Port: &imapPort},
+				SMTP:  &bridge.ConfigServer{SSL: &smtpSSL},
+				Proxy: &bridge.ConfigProxy{Tor: &bridge.ConfigTor{SOCKSAddress: &socksAddress}},
+				Accounts: []bridge.ConfigAccount{{
+					Username:     "config",
+					PasswordFile: passwordFile,
+					AddressMode:  &addressMode,
+				}},
+			}
+
+			// Invalid settings are reported and nothing is applied.
+			invalidPort, onionHost := 70000, "example.com"
+
+			_, err := b.ApplyConfigFile(ctx, bridge.ConfigFile{
+				IMAP:     &bridge.ConfigServer{Port: &invalidPort},
+				SMTP:     file.SMTP,
+				Proxy:    &bridge.ConfigProxy{Tor: &bridge.ConfigTor{OnionHost: &onionHost}},
+				Accounts: []bridge.ConfigAccount{{Username: "config"}},
+			})
+			require.ErrorIs(t, err, bridge.ErrInvalidConfigFile)
+			require.ErrorContains(t, err, "imap-port")
+			require.ErrorContains(t, err, "onion-host")
+			require.ErrorContains(t, err, bridge.ErrConfigAccountPassword.Error())
+			require.False(t, b.GetSMTPSSL())
+			require.Empty(t, b.GetUserIDs())
+
+			_, err = b.ApplyConfigFile(ctx, bridge.ConfigFile{
+				IMAP: &bridge.ConfigServer{Port: &imapPort},
+				SMTP: &bridge.ConfigServer{Port: &imapPort},
+			})
+			require.ErrorIs(t, err, bridge.ErrPortConflict)
+
+			// The settings which differ are applied and the account logged in.
+			mocks.ProxyCtl.EXPECT().DisallowProxy()
+
+			oldIMAPPort := b.GetIMAPPort()
+
+			changes, err := b.ApplyConfigFile(ctx, file)
+			require.NoError(t, err)
+			require.Len(t, b.GetUserIDs(), 1)
+
+			userID := b.GetUserIDs()[0]
+
+			require.Equal(t, []bridge.ConfigChange{
+				{Setting: "imap-port", Old: strconv.Itoa(oldIMAPPort), New: strconv.Itoa(imapPort)},
+				{Setting: "smtp-security", Old: "false", New: "true"},
+				{Setting: "socks-address", Old: "", New: socksAddress},
+				{Setting: "login", UserID: userID, Old: "signed out", New: "connected"},
+				{Setting: "mode", UserID: userID, Old: "combined", New: "split"},
+			}, changes)
+
+			require.Equal(t, imapPort, b.GetIMAPPort())
+			require.True(t, b.GetSMTPSSL())
+			require.Equal(t, socksAddress, b.GetTorSettings().SOCKSAddress)
+
+			info, err := b.GetUserInfo(userID)
+			require.NoError(t, err)
+			require.Equal(t, vault.SplitMode, info.AddressMode)
+
+			// Applying the file again changes nothing.
+			changes, err = b.ApplyConfigFile(ctx, file)
+			require.NoError(t, err)
+			require.Empty(t, changes)
+			require.Len(t, b.GetUserIDs(), 1)
+		})
+	})
+}
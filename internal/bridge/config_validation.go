@@ -0,0 +1,312 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/dialer"
+	"github.com/ProtonMail/proton-bridge/v3/internal/network"
+	"github.com/ProtonMail/proton-bridge/v3/internal/safe"
+	"github.com/ProtonMail/proton-bridge/v3/pkg/ports"
+)
+
+// configProxyTimeout is how long the Tor SOCKS proxy gets to accept a connection when the configuration is validated.
+const configProxyTimeout = 5 * time.Second
+
+// ConfigSeverity is how serious a ConfigIssue is.
+type ConfigSeverity int
+
+const (
+	// ConfigWarning is an issue which degrades a feature but doesn't prevent it from working.
+	ConfigWarning ConfigSeverity = iota
+
+	// ConfigError is an issue which prevents a feature, or bridge itself, from working.
+	ConfigError
+)
+
+func (severity ConfigSeverity) String() string {
+	switch severity {
+	case ConfigWarning:
+		return "warning"
+
+	case ConfigError:
+		return "error"
+
+	default:
+		return "unknown"
+	}
+}
+
+// ConfigIssue is a problem found in the configuration by ValidateConfiguration.
+type ConfigIssue struct {
+	Severity ConfigSeverity
+
+	// Setting is the name of the setting at fault, as in the CLI; paths which can't be changed there are named after
+	// what they hold.
+	Setting string
+
+	// UserID is the user the setting belongs to, if it is a user setting.
+	UserID string
+
+	Err error
+}
+
+func (issue ConfigIssue) String() string {
+	if issue.UserID != "" {
+		return fmt.Sprintf("%v: %v (user %v): %v", issue.Severity, issue.Setting, issue.UserID, issue.Err)
+	}
+
+	return fmt.Sprintf("%v: %v: %v", issue.Severity, issue.Setting, issue.Err)
+}
+
+// ValidateConfiguration checks the saved configuration without changing it: that the ports are valid and free,
+// the data directories writable, the Tor proxy reachable, the TLS certificate and policy usable and the filter rules
+// of the loaded users valid. The ports bridge currently listens on are considered free.
+// Errors are returned before warnings; nothing is returned if the configuration is fine.
+func (bridge *Bridge) ValidateConfiguration(ctx context.Context) []ConfigIssue {
+	var issues []ConfigIssue
+
+	add := func(severity ConfigSeverity, setting string, err error) {
+		issues = append(issues, ConfigIssue{Severity: severity, Setting: setting, Err: err})
+	}
+
+	bridge.validatePorts(add)
+	bridge.validatePaths(add)
+	bridge.validateProxy(ctx, add)
+	bridge.validateTLS(add)
+
+	if address := bridge.vault.GetMetricsListener(); address != "" && bridge.GetMetricsURL() == "" {
+		if err := probeMetricsListener(address); err != nil {
+			add(ConfigError, "metrics-listener", fmt.Errorf("%w: %v", ErrInvalidMetricsListener, err))
+		}
+	}
+
+	if command := bridge.vault.GetIndexHook(); command != "" {
+		if err := probeCommand(command); err != nil {
+			add(ConfigWarning, "index-hook", err)
+		}
+	}
+
+	safe.RLock(func() {
+		for userID, user := range bridge.users {
+			for _, err := range user.ValidateRules() {
+				issues = append(issues, ConfigIssue{Severity: ConfigError, Setting: "rules", UserID: userID, Err: err})
+			}
+		}
+	}, bridge.usersLock)
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		return issues[i].Severity > issues[j].Severity
+	})
+
+	return issues
+}
+
+func (bridge *Bridge) validatePorts(add func(ConfigSeverity, string, error)) {
+	type server struct {
+		setting string
+		port    int
+	}
+
+	servers := []server{
+		{"imap-port", bridge.vault.GetIMAPPort()},
+		{"smtp-port", bridge.vault.GetSMTPPort()},
+	}
+
+	if port := bridge.vault.GetSMTPRelay().Port; port != 0 {
+		servers = append(servers, server{"smtp-relay-port", port})
+	}
+
+	if port := bridge.vault.GetPOP3().Port; port != 0 {
+		servers = append(servers, server{"pop3-port", port})
+	}
+
+	if port := bridge.vault.GetNNTPPort(); port != 0 {
+		servers = append(servers, server{"nntp-port", port})
+	}
+
+	used := make(map[int]string)
+
+	for _, server := range servers {
+		switch other, ok := used[server.port]; {
+		case server.port < 0 || server.port > 65535:
+			add(ConfigError, server.setting, fmt.Errorf("%w: %v", ErrInvalidPort, server.port))
+
+		case server.port == 0:
+			// The server listens on any free port.
+			continue
+
+		case ok:
+			add(ConfigError, server.setting, fmt.Errorf("%w: %v is also the %v", ErrPortConflict, server.port, other))
+
+		case !bridge.serverManager.IsListening(server.port) && !ports.IsPortFree(server.port):
+			add(ConfigError, server.setting, fmt.Errorf("%w: %v", ErrPortInUse, server.port))
+		}
+
+		used[server.port] = server.setting
+	}
+
+	for setting, address := range map[string]string{
+		"imap-bind-address": bridge.vault.GetIMAPBindAddress(),
+		"smtp-bind-address": bridge.vault.GetSMTPBindAddress(),
+	} {
+		if address == "" {
+			continue
+		}
+
+		if err := probeListen([]string{address}, 0); err != nil {
+			add(ConfigError, setting, fmt.Errorf("%w: %v", ErrInvalidBindAddress, err))
+		}
+	}
+}
+
+func (bridge *Bridge) validatePaths(add func(ConfigSeverity, string, error)) {
+	cacheDir := bridge.GetGluonCacheDir()
+
+	if err := checkWritable(cacheDir); err != nil {
+		add(ConfigError, "change-location", err)
+	} else if free, err := getFreeSpace(cacheDir); err != nil {
+		add(ConfigWarning, "change-location", fmt.Errorf("failed to get free space: %w", err))
+	} else if free < SetupMinFreeSpace {
+		add(ConfigWarning, "change-location", fmt.Errorf("%w: %v MB free", ErrInsufficientDiskSpace, free>>20))
+	}
+
+	for setting, provide := range map[string]func() (string, error){
+		"gluon-data": bridge.GetGluonDataDir,
+		"settings":   bridge.locator.ProvideSettingsPath,
+		"logs":       bridge.locator.ProvideLogsPath,
+	} {
+		dir, err := provide()
+		if err != nil {
+			add(ConfigError, setting, err)
+		} else if err := checkWritable(dir); err != nil {
+			add(ConfigError, setting, err)
+		}
+	}
+}
+
+func (bridge *Bridge) validateProxy(ctx context.Context, add func(ConfigSeverity, string, error)) {
+	for _, provider := range bridge.vault.GetResolver().DoHProviders {
+		if err := dialer.ValidateDoHProvider(provider); err != nil {
+			add(ConfigError, "doh-providers", fmt.Errorf("%w: %v", ErrInvalidDoHProvider, err))
+		}
+	}
+
+	for _, ip := range bridge.vault.GetResolver().BootstrapIPs {
+		if net.ParseIP(ip) == nil {
+			add(ConfigError, "bootstrap-ips", fmt.Errorf("%w: %q", ErrInvalidBootstrapIP, ip))
+		}
+	}
+
+	for _, client := range bridge.vault.GetLANAccess().AllowedClients {
+		if _, err := network.ParseAllowedClient(client); err != nil {
+			add(ConfigError, "lan-access", fmt.Errorf("%w: %v", ErrInvalidAllowedClient, err))
+		}
+	}
+
+	tor := bridge.vault.GetTor()
+
+	if tor.OnionHost != "" {
+		if err := dialer.ValidateOnionHost(tor.OnionHost); err != nil {
+			add(ConfigError, "onion-host", fmt.Errorf("%w: %v", ErrInvalidOnionHost, err))
+		}
+	}
+
+	if !tor.Enabled {
+		return
+	}
+
+	address := tor.SOCKSAddress
+	if address == "" {
+		address = dialer.DefaultTorSOCKSAddress
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, configProxyTimeout)
+	defer cancel()
+
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", address)
+	if err != nil {
+		add(ConfigError, "socks-address", fmt.Errorf("%w: %v", ErrProxyUnreachable, err))
+		return
+	}
+
+	if err := conn.Close(); err != nil {
+		add(ConfigWarning, "socks-address", err)
+	}
+}
+
+func (bridge *Bridge) validateTLS(add func(ConfigSeverity, string, error)) {
+	if err := validateTLSPolicy(bridge.vault.GetTLSPolicy()); err != nil {
+		add(ConfigError, "tls-policy", err)
+	}
+
+	cert, err := loadTLSCert(bridge.vault)
+	if err != nil {
+		add(ConfigError, "cert", fmt.Errorf("%w: %v", ErrInvalidTLSCert, err))
+		return
+	}
+
+	// A self-signed certificate is regenerated before it expires; only one provided by the user needs attention.
+	if !bridge.vault.IsBridgeTLSCertCustom() {
+		return
+	}
+
+	if remaining := time.Until(cert.Leaf.NotAfter); remaining <= 0 {
+		add(ConfigError, "cert", fmt.Errorf("%w: expired on %v", ErrInvalidTLSCert, cert.Leaf.NotAfter))
+	} else if remaining < TLSCertWarnBefore {
+		add(ConfigWarning, "cert", fmt.Errorf("the TLS certificate expires on %v", cert.Leaf.NotAfter))
+	}
+}
+
+// checkWritable returns an error if files can't be created in the given directory.
+// If it doesn't exist yet, its closest existing parent is checked instead, as the directory would be created there.
+func checkWritable(dir string) error {
+	for {
+		if _, err := os.Stat(dir); err == nil {
+			break
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("%w: %v", ErrPathNotWritable, err)
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+
+		dir = parent
+	}
+
+	file, err := os.CreateTemp(dir, ".write-check-*")
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrPathNotWritable, err)
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Remove(file.Name())
+}
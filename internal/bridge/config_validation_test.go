@@ -0,0 +1,75 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/go-proton-api/server"
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
+	"github.com/ProtonMail/proton-bridge/v3/pkg/ports"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBridge_ValidateConfiguration(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, mocks *bridge.Mocks) {
+			// The default configuration has no errors.
+			require.Empty(t, configErrors(b.ValidateConfiguration(ctx)))
+
+			// The ports bridge listens on are considered free.
+			require.NoError(t, b.SetPOP3Port(ctx, ports.FindFreePortFrom(1110)))
+			require.Empty(t, configErrors(b.ValidateConfiguration(ctx)))
+
+			// An unreachable Tor proxy is reported.
+			l, err := net.Listen("tcp", "127.0.0.1:0")
+			require.NoError(t, err)
+			require.NoError(t, l.Close())
+
+			mocks.ProxyCtl.EXPECT().DisallowProxy().AnyTimes()
+			mocks.ProxyCtl.EXPECT().CheckTorCircuit(gomock.Any()).Return(nil).AnyTimes()
+
+			require.NoError(t, b.SetTorSOCKSAddress(l.Addr().String()))
+			require.NoError(t, b.SetTorEnabled(true))
+
+			issues := configErrors(b.ValidateConfiguration(ctx))
+			require.Len(t, issues, 1)
+			require.Equal(t, "socks-address", issues[0].Setting)
+			require.ErrorIs(t, issues[0].Err, bridge.ErrProxyUnreachable)
+
+			require.NoError(t, b.SetTorEnabled(false))
+			require.NoError(t, b.SetPOP3Port(ctx, 0))
+		})
+	})
+}
+
+func configErrors(issues []bridge.ConfigIssue) []bridge.ConfigIssue {
+	var errs []bridge.ConfigIssue
+
+	for _, issue := range issues {
+		if issue.Severity == bridge.ConfigError {
+			errs = append(errs, issue)
+		}
+	}
+
+	return errs
+}
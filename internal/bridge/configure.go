@@ -22,7 +22,6 @@ import (
 	"strings"
 
 	"github.com/ProtonMail/proton-bridge/v3/internal/clientconfig"
-	"github.com/ProtonMail/proton-bridge/v3/internal/constants"
 	"github.com/ProtonMail/proton-bridge/v3/internal/logging"
 	"github.com/ProtonMail/proton-bridge/v3/internal/safe"
 	"github.com/ProtonMail/proton-bridge/v3/internal/useragent"
@@ -63,11 +62,12 @@ func (bridge *Bridge) ConfigureAppleMail(ctx context.Context, userID, address st
 		}
 
 		return (&clientconfig.AppleMail{}).Configure(
-			constants.Host,
+			bridge.GetHost(),
+			bridge.GetSMTPHost(),
 			bridge.vault.GetIMAPPort(),
 			bridge.vault.GetSMTPPort(),
-			bridge.vault.GetIMAPSSL(),
-			bridge.vault.GetSMTPSSL(),
+			bridge.UsesIMAPSSL(),
+			bridge.UsesSMTPSSL(),
 			username,
 			addresses,
 			user.BridgePass(),
@@ -23,10 +23,12 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/ProtonMail/gluon/imap"
 	"github.com/ProtonMail/gluon/rfc822"
 	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/proton-bridge/v3/internal/services/imapsmtpserver"
 	"github.com/ProtonMail/proton-bridge/v3/internal/user"
 	"github.com/bradenaw/juniper/iterator"
 	"github.com/bradenaw/juniper/xslices"
@@ -49,6 +51,25 @@ func (c *CheckClientStateResult) AddMissingMessage(userID string, message user.D
 	}
 }
 
+// GetSlowCommands returns the last IMAP commands which ran for longer than the slow command threshold, the oldest first.
+func (bridge *Bridge) GetSlowCommands() []imapsmtpserver.SlowCommand {
+	return bridge.serverManager.GetSlowCommands()
+}
+
+// GetSlowCommandThreshold returns the duration above which IMAP commands are kept for diagnostics.
+func (bridge *Bridge) GetSlowCommandThreshold() time.Duration {
+	return bridge.vault.GetSlowCommandThreshold()
+}
+
+// SetSlowCommandThreshold sets the duration above which IMAP commands are kept for diagnostics; zero disables it.
+func (bridge *Bridge) SetSlowCommandThreshold(threshold time.Duration) error {
+	if threshold < 0 {
+		return ErrInvalidSlowCommandThreshold
+	}
+
+	return bridge.vault.SetSlowCommandThreshold(threshold)
+}
+
 // CheckClientState checks the current IMAP client reported state against the proton server state and reports
 // anything that is out of place.
 func (bridge *Bridge) CheckClientState(ctx context.Context, checkFlags bool, progressCB func(string)) (CheckClientStateResult, error) {
@@ -0,0 +1,81 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/go-proton-api/server"
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
+	"github.com/ProtonMail/proton-bridge/v3/internal/constants"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+	"github.com/emersion/go-imap"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBridge_DeleteMode(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		_, addrID, err := s.CreateUser("user", password)
+		require.NoError(t, err)
+
+		withClient(ctx, t, s, "user", password, func(ctx context.Context, c *proton.Client) {
+			createNumMessages(ctx, t, c, addrID, proton.InboxLabel, 4)
+		})
+
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			userLoginAndSync(ctx, t, b, "user", password)
+
+			info, err := b.QueryUserInfo("user")
+			require.NoError(t, err)
+
+			client, err := eventuallyDial(fmt.Sprintf("%v:%v", constants.Host, b.GetIMAPPort()))
+			require.NoError(t, err)
+			require.NoError(t, client.Login(info.Addresses[0], string(info.BridgePass)))
+			defer func() { _ = client.Logout() }()
+
+			// Deletes the first message of the inbox and waits for the given mailbox to hold the given number of messages.
+			deleteAndExpect := func(mode vault.DeleteMode, labelID string, count int) {
+				require.NoError(t, b.SetDeleteMode(ctx, info.UserID, mode))
+
+				_, err := client.Select("INBOX", false)
+				require.NoError(t, err)
+
+				require.NoError(t, clientStore(client, 1, 1, false, imap.FormatFlagsOp(imap.AddFlags, true), imap.DeletedFlag))
+				require.NoError(t, client.Expunge(nil))
+
+				withClient(ctx, t, s, "user", password, func(ctx context.Context, c *proton.Client) {
+					require.Eventually(t, func() bool {
+						metadata, err := c.GetMessageMetadataPage(ctx, 0, 10, proton.MessageFilter{LabelID: labelID})
+						return err == nil && len(metadata) == count
+					}, 10*time.Second, 100*time.Millisecond)
+				})
+			}
+
+			deleteAndExpect(vault.DeleteModeArchive, proton.ArchiveLabel, 1)
+			deleteAndExpect(vault.DeleteModeTrash, proton.TrashLabel, 1)
+			deleteAndExpect(vault.DeleteModePermanent, proton.AllMailLabel, 3)
+
+			// By default, expunged messages are only removed from the folder.
+			deleteAndExpect(vault.DeleteModeRemove, proton.InboxLabel, 0)
+		})
+	})
+}
@@ -0,0 +1,142 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/safe"
+	"github.com/ProtonMail/proton-bridge/v3/internal/services/imapservice"
+	"github.com/ProtonMail/proton-bridge/v3/pkg/files"
+)
+
+// DeletionReport describes what a destructive operation would delete, without deleting anything,
+// so that frontends can show it to the user before asking them to confirm.
+type DeletionReport struct {
+	// UserIDs are the users which would be signed out and removed.
+	UserIDs []string
+
+	// Files are the files and directories which would be deleted.
+	Files []DeletedFile
+
+	// Size is the total size in bytes of the files which would be deleted.
+	Size int64
+}
+
+type DeletedFile struct {
+	Path  string
+	Size  int64
+	IsDir bool
+}
+
+// PreviewFactoryReset returns what FactoryReset would delete.
+func (bridge *Bridge) PreviewFactoryReset() (DeletionReport, error) {
+	var paths []string
+
+	if err := safe.RLockRet(func() error {
+		for _, userID := range bridge.vault.GetUserIDs() {
+			userPaths, err := bridge.getUserDataPaths(userID)
+			if err != nil {
+				return err
+			}
+
+			paths = append(paths, userPaths...)
+		}
+
+		return nil
+	}, bridge.usersLock); err != nil {
+		return DeletionReport{}, err
+	}
+
+	removalDir, err := bridge.getRemovalDir()
+	if err != nil {
+		return DeletionReport{}, err
+	}
+
+	clearPaths, err := bridge.locator.PreviewClear(bridge.vault.Path(), removalDir)
+	if err != nil {
+		return DeletionReport{}, fmt.Errorf("failed to list data paths: %w", err)
+	}
+
+	return newDeletionReport(bridge.vault.GetUserIDs(), append(paths, clearPaths...))
+}
+
+// PreviewDeleteUser returns what DeleteUser would delete for the given user.
+func (bridge *Bridge) PreviewDeleteUser(userID string) (DeletionReport, error) {
+	return safe.RLockRetErr(func() (DeletionReport, error) {
+		if !bridge.vault.HasUser(userID) {
+			return DeletionReport{}, ErrNoSuchUser
+		}
+
+		paths, err := bridge.getUserDataPaths(userID)
+		if err != nil {
+			return DeletionReport{}, err
+		}
+
+		return newDeletionReport([]string{userID}, paths)
+	}, bridge.usersLock)
+}
+
+// getUserDataPaths returns the local files deleted along with the given user: their sync state.
+// The user's gluon database and message store are kept when they are signed out, so they aren't listed.
+func (bridge *Bridge) getUserDataPaths(userID string) ([]string, error) {
+	syncConfigDir, err := bridge.locator.ProvideIMAPSyncConfigPath()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sync config path: %w", err)
+	}
+
+	return files.Remove(
+		imapservice.GetSyncConfigPath(syncConfigDir, userID),
+		imapservice.GetSyncCheckpointPath(syncConfigDir, userID),
+		imapservice.GetPendingOpsPath(syncConfigDir, userID),
+		imapservice.GetStaleRefreshPath(syncConfigDir, userID),
+	).DryRun()
+}
+
+func newDeletionReport(userIDs, paths []string) (DeletionReport, error) {
+	report := DeletionReport{UserIDs: userIDs}
+
+	seen := make(map[string]struct{})
+
+	for _, path := range paths {
+		if _, ok := seen[path]; ok {
+			continue
+		}
+
+		seen[path] = struct{}{}
+
+		info, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return DeletionReport{}, fmt.Errorf("failed to stat %q: %w", path, err)
+		}
+
+		file := DeletedFile{Path: path, IsDir: info.IsDir()}
+
+		if !file.IsDir {
+			file.Size = info.Size()
+			report.Size += file.Size
+		}
+
+		report.Files = append(report.Files, file)
+	}
+
+	return report, nil
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/go-proton-api/server"
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBridge_PreviewDeleteUser(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			_, err := b.PreviewDeleteUser("no-such-user")
+			require.ErrorIs(t, err, bridge.ErrNoSuchUser)
+
+			userID := must(b.LoginFull(ctx, username, password, nil, nil))
+
+			report, err := b.PreviewDeleteUser(userID)
+			require.NoError(t, err)
+			require.Equal(t, []string{userID}, report.UserIDs)
+			require.NotEmpty(t, report.Files)
+			require.Positive(t, report.Size)
+
+			// Previewing deletes nothing.
+			for _, file := range report.Files {
+				if file.IsDir {
+					require.DirExists(t, file.Path)
+				} else {
+					require.FileExists(t, file.Path)
+				}
+			}
+
+			require.NoError(t, b.DeleteUser(ctx, userID))
+
+			_, err = b.PreviewDeleteUser(userID)
+			require.ErrorIs(t, err, bridge.ErrNoSuchUser)
+		})
+	})
+}
+
+func TestBridge_PreviewFactoryReset(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			userID := must(b.LoginFull(ctx, username, password, nil, nil))
+
+			report, err := b.PreviewFactoryReset()
+			require.NoError(t, err)
+			require.Equal(t, []string{userID}, report.UserIDs)
+			require.Positive(t, report.Size)
+
+			// The vault itself is kept.
+			for _, file := range report.Files {
+				require.NotEqual(t, "vault.enc", filepath.Base(file.Path))
+			}
+
+			require.Equal(t, []string{userID}, b.GetUserIDs())
+		})
+	})
+}
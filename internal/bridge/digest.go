@@ -0,0 +1,63 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"context"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/events"
+	"github.com/ProtonMail/proton-bridge/v3/internal/safe"
+)
+
+// GetDigestEnabled returns whether the weekly digest report of the given user is made.
+func (bridge *Bridge) GetDigestEnabled(userID string) (bool, error) {
+	return safe.RLockRetErr(func() (bool, error) {
+		user, ok := bridge.users[userID]
+		if !ok {
+			return false, ErrNoSuchUser
+		}
+
+		return user.GetDigestEnabled(), nil
+	}, bridge.usersLock)
+}
+
+// SetDigestEnabled sets whether the weekly digest report of the given user is made. The report summarizes the
+// messages received per mailbox, the top senders, the storage growth and the sync errors of the past week,
+// and is delivered as an events.UserDigest event.
+func (bridge *Bridge) SetDigestEnabled(ctx context.Context, userID string, enabled bool) error {
+	return safe.RLockRet(func() error {
+		user, ok := bridge.users[userID]
+		if !ok {
+			return ErrNoSuchUser
+		}
+
+		return user.SetDigestEnabled(ctx, enabled)
+	}, bridge.usersLock)
+}
+
+// GetDigest returns the weekly digest report of the given user for the current week so far.
+func (bridge *Bridge) GetDigest(ctx context.Context, userID string) (events.UserDigest, error) {
+	return safe.RLockRetErr(func() (events.UserDigest, error) {
+		user, ok := bridge.users[userID]
+		if !ok {
+			return events.UserDigest{}, ErrNoSuchUser
+		}
+
+		return user.GetDigest(ctx)
+	}, bridge.usersLock)
+}
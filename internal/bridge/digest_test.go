@@ -0,0 +1,78 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/go-proton-api/server"
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBridge_Digest(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		_, addrID, err := s.CreateUser("user", password)
+		require.NoError(t, err)
+
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			userLoginAndSync(ctx, t, b, "user", password)
+
+			info, err := b.QueryUserInfo("user")
+			require.NoError(t, err)
+
+			userID := info.UserID
+
+			enabled, err := b.GetDigestEnabled(userID)
+			require.NoError(t, err)
+			require.False(t, enabled)
+
+			require.NoError(t, b.SetDigestEnabled(ctx, userID, true))
+
+			enabled, err = b.GetDigestEnabled(userID)
+			require.NoError(t, err)
+			require.True(t, enabled)
+
+			withClient(ctx, t, s, "user", password, func(ctx context.Context, c *proton.Client) {
+				createNumMessages(ctx, t, c, addrID, proton.InboxLabel, 2)
+			})
+
+			// The new messages are counted as they arrive through the event loop.
+			require.Eventually(t, func() bool {
+				digest, err := b.GetDigest(ctx, userID)
+				return err == nil && digest.Received["Inbox"] == 2
+			}, 10*time.Second, 100*time.Millisecond)
+
+			digest, err := b.GetDigest(ctx, userID)
+			require.NoError(t, err)
+			require.Len(t, digest.TopSenders, 1)
+			require.Equal(t, "sender@pm.me", digest.TopSenders[0].Address)
+			require.Equal(t, 2, digest.TopSenders[0].Count)
+			require.Zero(t, digest.SyncErrors)
+
+			// Disabling the digest stops counting.
+			require.NoError(t, b.SetDigestEnabled(ctx, userID, false))
+
+			_, err = b.GetDigestEnabled("no such user")
+			require.ErrorIs(t, err, bridge.ErrNoSuchUser)
+		})
+	})
+}
@@ -0,0 +1,195 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/events"
+	"github.com/ProtonMail/proton-bridge/v3/internal/files"
+	"github.com/ProtonMail/proton-bridge/v3/internal/safe"
+	"github.com/ProtonMail/proton-bridge/v3/internal/services/syncservice"
+	pkgfiles "github.com/ProtonMail/proton-bridge/v3/pkg/files"
+	"github.com/sirupsen/logrus"
+)
+
+// DiskSpaceCheckInterval is how often the free space of the message cache's disk is checked.
+const DiskSpaceCheckInterval = time.Minute
+
+// LowDiskSpaceThreshold is the free space below which syncing is paused.
+// Syncing resumes once twice as much is free again.
+const LowDiskSpaceThreshold = 256 << 20
+
+// IsDiskSpaceLow returns whether syncing is paused because the message cache's disk is almost full.
+func (bridge *Bridge) IsDiskSpaceLow() bool {
+	return bridge.diskSpaceLow.Load()
+}
+
+// checkDiskSpace pauses the sync of all users when the message cache's disk is almost full, rather than letting
+// the cache fail with I/O errors, and resumes it once space is freed.
+func (bridge *Bridge) checkDiskSpace(ctx context.Context) {
+	path := bridge.GetGluonCacheDir()
+
+	free, err := getFreeSpace(path)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to get free disk space")
+		return
+	}
+
+	if bridge.diskFullInjected.Load() {
+		free = 0
+	}
+
+	switch {
+	case free < LowDiskSpaceThreshold && !bridge.diskSpaceLow.Swap(true):
+		logrus.WithField("free", free).Warn("Disk space is low, pausing sync")
+
+		safe.RLock(func() {
+			for _, user := range bridge.users {
+				if err := user.PauseSync(ctx); err != nil {
+					logrus.WithError(err).WithField("userID", user.ID()).Error("Failed to pause sync")
+				}
+			}
+		}, bridge.usersLock)
+
+		bridge.publish(events.DiskSpaceLow{Path: path, Free: free, Required: LowDiskSpaceThreshold})
+
+	case free >= 2*LowDiskSpaceThreshold && bridge.diskSpaceLow.Swap(false):
+		logrus.WithField("free", free).Info("Disk space recovered, resuming sync")
+
+		safe.RLock(func() {
+			for _, user := range bridge.users {
+				if err := user.RetrySync(ctx); err != nil {
+					logrus.WithError(err).WithField("userID", user.ID()).Error("Failed to resume sync")
+				}
+			}
+		}, bridge.usersLock)
+
+		bridge.publish(events.DiskSpaceRecovered{Path: path, Free: free})
+	}
+}
+
+// checkCacheMoveSpace returns an error if the disk of newGluonDir can't hold a copy of the message cache.
+func (bridge *Bridge) checkCacheMoveSpace(newGluonDir string) error {
+	size, err := files.DirSize(bridge.GetGluonCacheDir())
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to get message cache size: %w", err)
+	}
+
+	free, err := getFreeSpace(newGluonDir)
+	if err != nil {
+		return fmt.Errorf("failed to get free disk space: %w", err)
+	}
+
+	if required := uint64(size) + LowDiskSpaceThreshold; free < required {
+		return fmt.Errorf("%w: %v MB required, %v MB free", ErrInsufficientDiskSpace, required>>20, free>>20)
+	}
+
+	return nil
+}
+
+// getFreeSpace returns the free space of the disk dir is on. dir doesn't need to exist yet.
+func getFreeSpace(dir string) (uint64, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return 0, err
+	}
+
+	for {
+		if info, err := os.Stat(dir); err == nil && !info.IsDir() {
+			return 0, fmt.Errorf("%v is not a directory", dir)
+		} else if err == nil {
+			break
+		} else if !os.IsNotExist(err) || filepath.Dir(dir) == dir {
+			return 0, err
+		}
+
+		dir = filepath.Dir(dir)
+	}
+
+	return pkgfiles.FreeSpace(dir)
+}
+
+// diskSpaceRegulator delays the initial sync of a user until the message cache's disk has room for their messages.
+type diskSpaceRegulator struct {
+	b *Bridge
+
+	userID    string
+	usedSpace int
+
+	regulator syncservice.Regulator
+}
+
+func (r *diskSpaceRegulator) Sync(ctx context.Context, job *syncservice.Job) {
+	ticker := time.NewTicker(DiskSpaceCheckInterval)
+	defer ticker.Stop()
+
+	for notified := false; ; notified = true {
+		path := r.b.GetGluonCacheDir()
+
+		free, err := getFreeSpace(path)
+		if err != nil {
+			logrus.WithError(err).Error("Failed to get free disk space")
+			break
+		}
+
+		required := r.requiredSpace(ctx, job)
+		if free >= required {
+			break
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"userID":   r.userID,
+			"free":     free,
+			"required": required,
+		}).Warn("Not enough disk space to sync, delaying sync")
+
+		if !notified {
+			r.b.publish(events.DiskSpaceLow{UserID: r.userID, Path: path, Free: free, Required: required})
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+		}
+	}
+
+	r.regulator.Sync(ctx, job)
+}
+
+// requiredSpace estimates the space needed by the rest of the sync from the space the user uses on the API.
+func (r *diskSpaceRegulator) requiredSpace(ctx context.Context, job *syncservice.Job) uint64 {
+	remaining := uint64(r.usedSpace)
+
+	if status, err := job.GetSyncStatus(ctx); err == nil && status.TotalMessageCount > 0 {
+		synced := status.NumSyncedMessages
+		if synced > status.TotalMessageCount {
+			synced = status.TotalMessageCount
+		}
+
+		remaining -= remaining * uint64(synced) / uint64(status.TotalMessageCount)
+	}
+
+	return remaining + LowDiskSpaceThreshold
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"context"
+	"time"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/events"
+	"github.com/ProtonMail/proton-bridge/v3/internal/services/imapservice"
+	"github.com/ProtonMail/proton-bridge/v3/internal/services/syncservice"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+	"github.com/sirupsen/logrus"
+)
+
+// DoNotDisturbCheckInterval is how often the do not disturb schedule is evaluated.
+const DoNotDisturbCheckInterval = time.Minute
+
+func (bridge *Bridge) GetDoNotDisturb() vault.DoNotDisturb {
+	return bridge.vault.GetDoNotDisturb()
+}
+
+// SetDoNotDisturb sets the do not disturb settings and broadcasts the change of state, if any.
+func (bridge *Bridge) SetDoNotDisturb(dnd vault.DoNotDisturb) error {
+	if err := bridge.vault.SetDoNotDisturb(dnd); err != nil {
+		return err
+	}
+
+	bridge.updateDoNotDisturb()
+
+	return nil
+}
+
+// IsDoNotDisturbActive returns whether new message notifications are currently suppressed.
+func (bridge *Bridge) IsDoNotDisturbActive() bool {
+	return bridge.dndActive.Load()
+}
+
+// updateDoNotDisturb evaluates the do not disturb settings and publishes an event if the state changed.
+func (bridge *Bridge) updateDoNotDisturb() {
+	dnd := bridge.vault.GetDoNotDisturb()
+
+	active := dnd.Enabled || isInQuietHours(dnd.Schedule, time.Now())
+
+	if bridge.dndActive.Swap(active) != active {
+		logrus.WithField("active", active).Info("Do not disturb changed")
+		bridge.publish(events.DoNotDisturbChanged{Active: active})
+	}
+}
+
+func isInQuietHours(hours vault.QuietHours, now time.Time) bool {
+	return imapservice.QuietHours{
+		Enabled: hours.Enabled,
+		Start:   hours.Start,
+		End:     hours.End,
+	}.Contains(now)
+}
+
+// dndRegulator delays the message download of new syncs while do not disturb is active and set to delay syncs.
+type dndRegulator struct {
+	b *Bridge
+
+	regulator syncservice.Regulator
+}
+
+func (r *dndRegulator) Sync(ctx context.Context, job *syncservice.Job) {
+	ticker := time.NewTicker(DoNotDisturbCheckInterval)
+	defer ticker.Stop()
+
+	for r.b.IsDoNotDisturbActive() && r.b.vault.GetDoNotDisturb().DelaySync {
+		logrus.Info("Do not disturb is active, delaying sync")
+
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+		}
+	}
+
+	r.regulator.Sync(ctx, job)
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/safe"
+)
+
+// UserDomain is a domain the user has addresses on.
+type UserDomain struct {
+	Name      string
+	Addresses []string
+}
+
+// GetUserDomains returns the domains of the addresses of the given user, sorted by name.
+func (bridge *Bridge) GetUserDomains(userID string) ([]UserDomain, error) {
+	return safe.RLockRetErr(func() ([]UserDomain, error) {
+		user, ok := bridge.users[userID]
+		if !ok {
+			return nil, ErrNoSuchUser
+		}
+
+		return getUserDomains(user.Emails()), nil
+	}, bridge.usersLock)
+}
+
+func getUserDomains(emails []string) []UserDomain {
+	addresses := make(map[string][]string)
+
+	for _, email := range emails {
+		idx := strings.LastIndex(email, "@")
+		if idx < 0 {
+			continue
+		}
+
+		domain := strings.ToLower(email[idx+1:])
+
+		addresses[domain] = append(addresses[domain], email)
+	}
+
+	domains := make([]UserDomain, 0, len(addresses))
+
+	for name, emails := range addresses {
+		domains = append(domains, UserDomain{Name: name, Addresses: emails})
+	}
+
+	sort.Slice(domains, func(i, j int) bool {
+		return domains[i].Name < domains[j].Name
+	})
+
+	return domains
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/safe"
+	"github.com/sirupsen/logrus"
+)
+
+// GetDraftCoalescingInterval returns how often a draft the given user saves repeatedly is uploaded at most.
+func (bridge *Bridge) GetDraftCoalescingInterval(userID string) (time.Duration, error) {
+	return safe.RLockRetErr(func() (time.Duration, error) {
+		user, ok := bridge.users[userID]
+		if !ok {
+			return 0, ErrNoSuchUser
+		}
+
+		return user.GetDraftCoalescingInterval(), nil
+	}, bridge.usersLock)
+}
+
+// SetDraftCoalescingInterval sets how often a draft the given user saves repeatedly is uploaded at most.
+// Clients like Apple Mail and Thunderbird autosave drafts every few seconds, each save creating a new draft on
+// the server and deleting the previous one; with an interval set, versions saved within it are kept locally and
+// only the latest is uploaded once it's over, or when the draft is moved, flagged or a message is sent.
+// Zero, the default, uploads every save.
+func (bridge *Bridge) SetDraftCoalescingInterval(ctx context.Context, userID string, interval time.Duration) error {
+	logrus.WithField("userID", userID).WithField("interval", interval).Info("Setting draft coalescing interval")
+
+	if interval < 0 {
+		return ErrInvalidDraftCoalescingInterval
+	}
+
+	return safe.RLockRet(func() error {
+		user, ok := bridge.users[userID]
+		if !ok {
+			return ErrNoSuchUser
+		}
+
+		if err := user.SetDraftCoalescingInterval(ctx, interval); err != nil {
+			return fmt.Errorf("failed to set draft coalescing interval: %w", err)
+		}
+
+		return nil
+	}, bridge.usersLock)
+}
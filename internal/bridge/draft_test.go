@@ -173,3 +173,75 @@ Hello
 		})
 	}, server.WithMessageDedup())
 }
+
+func TestBridge_DraftCoalescing(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		_, _, err := s.CreateUser("user", password)
+		require.NoError(t, err)
+
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			userLoginAndSync(ctx, t, b, "user", password)
+
+			info, err := b.QueryUserInfo("user")
+			require.NoError(t, err)
+
+			// Every draft save is uploaded by default.
+			interval, err := b.GetDraftCoalescingInterval(info.UserID)
+			require.NoError(t, err)
+			require.Zero(t, interval)
+
+			require.ErrorIs(t, b.SetDraftCoalescingInterval(ctx, info.UserID, -time.Second), bridge.ErrInvalidDraftCoalescingInterval)
+			require.NoError(t, b.SetDraftCoalescingInterval(ctx, info.UserID, time.Hour))
+
+			client, err := eventuallyDial(fmt.Sprintf("%v:%v", constants.Host, b.GetIMAPPort()))
+			require.NoError(t, err)
+			require.NoError(t, client.Login(info.Addresses[0], string(info.BridgePass)))
+			defer func() { _ = client.Logout() }()
+
+			getDraftSubjects := func() []string {
+				var subjects []string
+
+				withClient(ctx, t, s, "user", password, func(ctx context.Context, c *proton.Client) {
+					metadata, err := c.GetMessageMetadataPage(ctx, 0, 10, proton.MessageFilter{LabelID: proton.DraftsLabel})
+					require.NoError(t, err)
+
+					for _, m := range metadata {
+						subjects = append(subjects, m.Subject)
+					}
+				})
+
+				return subjects
+			}
+
+			draft := func(subject string) string {
+				return fmt.Sprintf("From: %v\r\nTo: bar@proton.local\r\nMessage-Id: <draft@proton.local>\r\nSubject: %v\r\n\r\nHello\r\n", info.Addresses[0], subject)
+			}
+
+			// The first save of a draft is uploaded right away.
+			require.NoError(t, client.Append("Drafts", nil, time.Now(), strings.NewReader(draft("Version 1"))))
+			require.Equal(t, []string{"Version 1"}, getDraftSubjects())
+
+			// The saves within the interval are only kept locally, though clients see them.
+			require.NoError(t, client.Append("Drafts", nil, time.Now(), strings.NewReader(draft("Version 2"))))
+			require.NoError(t, client.Append("Drafts", nil, time.Now(), strings.NewReader(draft("Version 3"))))
+			require.Equal(t, []string{"Version 1"}, getDraftSubjects())
+
+			status, err := client.Status("Drafts", []go_imap.StatusItem{go_imap.StatusMessages})
+			require.NoError(t, err)
+			require.Equal(t, uint32(3), status.Messages)
+
+			// Disabling coalescing uploads the latest held version, replacing the held ones.
+			require.NoError(t, b.SetDraftCoalescingInterval(ctx, info.UserID, 0))
+			require.ElementsMatch(t, []string{"Version 1", "Version 3"}, getDraftSubjects())
+
+			require.Eventually(t, func() bool {
+				status, err := client.Status("Drafts", []go_imap.StatusItem{go_imap.StatusMessages})
+				require.NoError(t, err)
+				return status.Messages == 2
+			}, 5*time.Second, 100*time.Millisecond)
+
+			// Unknown users can't be configured.
+			require.ErrorIs(t, b.SetDraftCoalescingInterval(ctx, "no-such-user", 0), bridge.ErrNoSuchUser)
+		})
+	})
+}
@@ -0,0 +1,323 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/events"
+	"github.com/ProtonMail/proton-bridge/v3/internal/safe"
+	"github.com/bradenaw/juniper/xslices"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrorCode is the stable identifier of a kind of user-facing failure.
+type ErrorCode string
+
+const (
+	ErrorCodeSyncFailed     ErrorCode = "sync_failed"
+	ErrorCodeSendFailed     ErrorCode = "send_failed"
+	ErrorCodeAuthExpired    ErrorCode = "auth_expired"
+	ErrorCodeUserLoadFailed ErrorCode = "user_load_failed"
+	ErrorCodeBadEvent       ErrorCode = "bad_event"
+	ErrorCodeKeychain       ErrorCode = "keychain_unavailable"
+	ErrorCodeVaultCorrupt   ErrorCode = "vault_corrupt"
+	ErrorCodeInterference   ErrorCode = "security_software_interference"
+)
+
+// errorCodeInfo describes how a kind of failure can be resolved.
+type errorCodeInfo struct {
+	retriable bool
+	action    string
+}
+
+var errorCodeInfos = map[ErrorCode]errorCodeInfo{ //nolint:gochecknoglobals
+	ErrorCodeSyncFailed: {
+		retriable: true,
+		action:    "Check your internet connection, then retry the sync.",
+	},
+	ErrorCodeSendFailed: {
+		retriable: false,
+		action:    "Check the recipients and attachments of the message, then send it again from your email client.",
+	},
+	ErrorCodeAuthExpired: {
+		retriable: false,
+		action:    "Your session has expired. Sign in to your account again.",
+	},
+	ErrorCodeUserLoadFailed: {
+		retriable: true,
+		action:    "Check your internet connection, then retry loading the account.",
+	},
+	ErrorCodeBadEvent: {
+		retriable: true,
+		action:    "Retry to resynchronize the account, or sign out and sign in again.",
+	},
+	ErrorCodeKeychain: {
+		retriable: false,
+		action:    "Make sure your keychain is installed and unlocked, then restart Bridge.",
+	},
+	ErrorCodeVaultCorrupt: {
+		retriable: false,
+		action:    "Your settings could not be read and were reset. Sign in to your accounts again.",
+	},
+	ErrorCodeInterference: {
+		retriable: false,
+		action:    "Add an exception for Bridge to your security software, or disable its email scanning.",
+	},
+}
+
+// ActiveError is a user-facing failure that has not been resolved or dismissed yet.
+// Errors not tied to an account, such as keychain issues, have an empty UserID.
+type ActiveError struct {
+	UserID    string
+	Code      ErrorCode
+	Message   string
+	Action    string
+	Retriable bool
+
+	// Count is the number of times the failure occurred since it became active.
+	Count     int
+	FirstSeen time.Time
+	LastSeen  time.Time
+}
+
+// errorCenter keeps track of the active user-facing errors.
+type errorCenter struct {
+	errors []ActiveError
+	lock   sync.Mutex
+}
+
+func newErrorCenter() *errorCenter {
+	return &errorCenter{}
+}
+
+// raise records an occurrence of the given failure; repeated failures update the existing entry.
+func (center *errorCenter) raise(userID string, code ErrorCode, err error) {
+	center.lock.Lock()
+	defer center.lock.Unlock()
+
+	now := time.Now()
+
+	var message string
+
+	if err != nil {
+		message = err.Error()
+	}
+
+	if idx := center.index(userID, code); idx >= 0 {
+		center.errors[idx].Message = message
+		center.errors[idx].Count++
+		center.errors[idx].LastSeen = now
+
+		return
+	}
+
+	info := errorCodeInfos[code]
+
+	center.errors = append(center.errors, ActiveError{
+		UserID:    userID,
+		Code:      code,
+		Message:   message,
+		Action:    info.action,
+		Retriable: info.retriable,
+		Count:     1,
+		FirstSeen: now,
+		LastSeen:  now,
+	})
+}
+
+// resolve removes the given failures, returning whether any was active.
+func (center *errorCenter) resolve(userID string, codes ...ErrorCode) bool {
+	center.lock.Lock()
+	defer center.lock.Unlock()
+
+	var resolved bool
+
+	for _, code := range codes {
+		if idx := center.index(userID, code); idx >= 0 {
+			center.errors = append(center.errors[:idx], center.errors[idx+1:]...)
+			resolved = true
+		}
+	}
+
+	return resolved
+}
+
+// resolveUser removes all the failures of the given user.
+func (center *errorCenter) resolveUser(userID string) {
+	center.lock.Lock()
+	defer center.lock.Unlock()
+
+	center.errors = xslices.Filter(center.errors, func(err ActiveError) bool {
+		return err.UserID != userID
+	})
+}
+
+func (center *errorCenter) get(userID string, code ErrorCode) (ActiveError, bool) {
+	center.lock.Lock()
+	defer center.lock.Unlock()
+
+	if idx := center.index(userID, code); idx >= 0 {
+		return center.errors[idx], true
+	}
+
+	return ActiveError{}, false
+}
+
+func (center *errorCenter) list(userID string) []ActiveError {
+	center.lock.Lock()
+	defer center.lock.Unlock()
+
+	return xslices.Filter(center.errors, func(err ActiveError) bool {
+		return err.UserID == userID
+	})
+}
+
+func (center *errorCenter) index(userID string, code ErrorCode) int {
+	return xslices.IndexFunc(center.errors, func(err ActiveError) bool {
+		return err.UserID == userID && err.Code == code
+	})
+}
+
+// handleEvent raises or resolves the failures reported by the given event, returning whether it reported any.
+func (center *errorCenter) handleEvent(event events.Event) bool {
+	switch event := event.(type) {
+	case events.SyncFailed:
+		// Syncs are cancelled when the user logs out or is resynced; this isn't a failure.
+		if !errors.Is(event.Error, context.Canceled) {
+			center.raise(event.UserID, ErrorCodeSyncFailed, event.Error)
+		}
+
+	case events.SyncFinished:
+		center.resolve(event.UserID, ErrorCodeSyncFailed)
+
+	case events.UserSendFailed:
+		center.raise(event.UserID, ErrorCodeSendFailed, event.Error)
+
+	case events.UserDeauth:
+		center.raise(event.UserID, ErrorCodeAuthExpired, nil)
+
+	case events.UserLoadFail:
+		center.raise(event.UserID, ErrorCodeUserLoadFailed, event.Error)
+
+	case events.UserLoadSuccess:
+		center.resolve(event.UserID, ErrorCodeUserLoadFailed)
+
+	case events.UserBadEvent:
+		center.raise(event.UserID, ErrorCodeBadEvent, event.Error)
+
+	case events.UserLoggedIn:
+		center.resolve(event.UserID, ErrorCodeAuthExpired, ErrorCodeUserLoadFailed)
+
+	case events.UserDeleted:
+		center.resolveUser(event.UserID)
+
+	case events.InterferenceDetected:
+		product := event.Product
+		if product == "" {
+			product = "Security software"
+		}
+
+		center.raise("", ErrorCodeInterference, fmt.Errorf("%v interferes with Bridge: %v", product, event.Detail))
+
+	default:
+		return false
+	}
+
+	return true
+}
+
+// handleStartupError raises the failure matching the given startup error, if any.
+func (center *errorCenter) handleStartupError(err error) {
+	switch {
+	case errors.Is(err, ErrVaultInsecure):
+		center.raise("", ErrorCodeKeychain, err)
+
+	case errors.Is(err, ErrVaultCorrupt):
+		center.raise("", ErrorCodeVaultCorrupt, err)
+	}
+}
+
+// ListActiveErrors returns the user-facing failures of the given user that are still active, oldest first.
+// Failures not tied to an account, such as keychain issues, are listed for the empty user ID.
+func (bridge *Bridge) ListActiveErrors(userID string) []ActiveError {
+	return bridge.errorCenter.list(userID)
+}
+
+// DismissError removes the given failure from the active errors without acting on it.
+func (bridge *Bridge) DismissError(userID string, code ErrorCode) error {
+	logrus.WithField("userID", userID).WithField("code", code).Info("Dismissing error")
+
+	if !bridge.errorCenter.resolve(userID, code) {
+		return ErrNoSuchActiveError
+	}
+
+	bridge.goAnnounce()
+
+	return nil
+}
+
+// RetryError retries the operation which caused the given failure and removes it from the active errors.
+// The failure is raised again if the operation fails again.
+func (bridge *Bridge) RetryError(ctx context.Context, userID string, code ErrorCode) error {
+	logrus.WithField("userID", userID).WithField("code", code).Info("Retrying error")
+
+	activeErr, ok := bridge.errorCenter.get(userID, code)
+	if !ok {
+		return ErrNoSuchActiveError
+	}
+
+	if !activeErr.Retriable {
+		return ErrErrorNotRetriable
+	}
+
+	bridge.errorCenter.resolve(userID, code)
+
+	bridge.goAnnounce()
+
+	switch code { //nolint:exhaustive
+	case ErrorCodeSyncFailed:
+		return safe.RLockRet(func() error {
+			user, ok := bridge.users[userID]
+			if !ok {
+				return ErrNoSuchUser
+			}
+
+			if err := user.RetrySync(ctx); err != nil {
+				return fmt.Errorf("failed to retry sync: %w", err)
+			}
+
+			return nil
+		}, bridge.usersLock)
+
+	case ErrorCodeBadEvent:
+		return bridge.SendBadEventUserFeedback(ctx, userID, true)
+
+	case ErrorCodeUserLoadFailed:
+		bridge.goLoad()
+
+		return nil
+
+	default:
+		return ErrErrorNotRetriable
+	}
+}
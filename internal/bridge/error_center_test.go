@@ -0,0 +1,77 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/go-proton-api/server"
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
+	"github.com/ProtonMail/proton-bridge/v3/internal/events"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBridge_ErrorCenter_Keychain(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			require.Empty(t, b.ListActiveErrors(""))
+
+			// A keychain issue at startup is listed as an error not tied to an account.
+			b.PushError(bridge.ErrVaultInsecure)
+
+			activeErrs := b.ListActiveErrors("")
+			require.Len(t, activeErrs, 1)
+			require.Equal(t, bridge.ErrorCodeKeychain, activeErrs[0].Code)
+			require.False(t, activeErrs[0].Retriable)
+			require.NotEmpty(t, activeErrs[0].Action)
+
+			// It can't be retried, only dismissed.
+			require.ErrorIs(t, b.RetryError(ctx, "", bridge.ErrorCodeKeychain), bridge.ErrErrorNotRetriable)
+			require.NoError(t, b.DismissError("", bridge.ErrorCodeKeychain))
+			require.Empty(t, b.ListActiveErrors(""))
+
+			// Dismissing it again fails.
+			require.ErrorIs(t, b.DismissError("", bridge.ErrorCodeKeychain), bridge.ErrNoSuchActiveError)
+		})
+	})
+}
+
+func TestBridge_ErrorCenter_AuthExpired(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			userID := must(b.LoginFull(ctx, username, password, nil, nil))
+
+			deauthCh, done := b.GetEvents(events.UserDeauth{})
+			defer done()
+
+			// Revoking the session raises an auth error for the user.
+			require.NoError(t, s.RevokeUser(userID))
+			waitForEvent(t, deauthCh, events.UserDeauth{})
+
+			activeErrs := b.ListActiveErrors(userID)
+			require.Len(t, activeErrs, 1)
+			require.Equal(t, bridge.ErrorCodeAuthExpired, activeErrs[0].Code)
+
+			// Logging in again resolves it.
+			require.Equal(t, userID, must(b.LoginFull(ctx, username, password, nil, nil)))
+			require.Empty(t, b.ListActiveErrors(userID))
+		})
+	})
+}
@@ -30,4 +30,100 @@ var (
 	ErrNotImplemented      = errors.New("not implemented")
 
 	ErrSizeTooLarge = errors.New("file is too big")
+
+	ErrNoSuchActiveError = errors.New("no such active error")
+	ErrErrorNotRetriable = errors.New("the error cannot be retried")
+
+	ErrSetupDone             = errors.New("the setup is done")
+	ErrWrongSetupStep        = errors.New("not the current setup step")
+	ErrNoSuchKeychain        = errors.New("no such keychain")
+	ErrSamePorts             = errors.New("IMAP and SMTP ports must differ")
+	ErrPortInUse             = errors.New("the port is in use")
+	ErrInsufficientDiskSpace = errors.New("not enough disk space")
+
+	ErrNoSuchRemoval  = errors.New("no such removal")
+	ErrRemovalExpired = errors.New("the removal can no longer be undone")
+
+	ErrInvalidDoHProvider = errors.New("invalid DoH provider")
+	ErrInvalidBootstrapIP = errors.New("invalid bootstrap IP")
+
+	ErrInvalidSOCKSAddress = errors.New("invalid SOCKS address")
+	ErrInvalidOnionHost    = errors.New("invalid onion host")
+
+	ErrInvalidJitterWindow = errors.New("invalid jitter window")
+
+	ErrLogsOnDisk = errors.New("logs are written to disk, not kept in memory")
+
+	ErrNoUpdateVerification = errors.New("the running version was not installed by the updater")
+
+	ErrInvalidAccessGrantName = errors.New("the access grant needs a name")
+
+	ErrInvalidPairingCode = errors.New("the pairing code is invalid or has expired")
+	ErrPairingRateLimited = errors.New("too many pairing attempts, try again later")
+
+	ErrInvalidTLSVersion  = errors.New("unsupported TLS version")
+	ErrInvalidCipherSuite = errors.New("unsupported or insecure cipher suite")
+
+	ErrInvalidAllowedClient = errors.New("invalid CIDR or IP address")
+
+	ErrInvalidServerLimits = errors.New("server limits can't be negative")
+
+	ErrInvalidSlowCommandThreshold = errors.New("slow command threshold can't be negative")
+
+	ErrInvalidHeaderTemplate = errors.New("invalid header template")
+
+	ErrInvalidTimeZone = errors.New("invalid time zone")
+
+	ErrInvalidDraftCoalescingInterval = errors.New("draft coalescing interval can't be negative")
+
+	ErrInvalidPOP3Port = errors.New("POP3 port can't be negative")
+
+	ErrInvalidNNTPPort = errors.New("NNTP port can't be negative")
+
+	ErrInvalidBindAddress = errors.New("invalid bind address")
+
+	ErrInvalidRelayPort        = errors.New("the relay port must be between 0 and 65535")
+	ErrNoRelayAddress          = errors.New("the relay needs an address to send from")
+	ErrInvalidRelayCredentials = errors.New("the relay password needs a username")
+
+	ErrInvalidSyncCacheMemory = errors.New("sync cache memory is too small")
+
+	ErrInvalidMetricsListener = errors.New("invalid metrics listener address")
+
+	ErrInvalidFaultInjection = errors.New("fault rates must be between 0 and 1 in total, and latency can't be negative")
+
+	ErrInvalidDisplayMetadata = errors.New("invalid display metadata")
+
+	ErrNoSuchClientShim = errors.New("no such client shim")
+
+	ErrSyncSnapshotMismatch = errors.New("sync snapshots are of different users")
+
+	ErrExportInProgress = errors.New("an export of the user is already in progress")
+	ErrNoSuchExport     = errors.New("no export of the user is in progress")
+
+	ErrImportInProgress = errors.New("an import to the user is already in progress")
+	ErrNoSuchImport     = errors.New("no import to the user is in progress")
+
+	ErrInvalidMailtoRule = errors.New("invalid recipient pattern")
+	ErrNoSuchMailtoRule  = errors.New("no such mailto rule")
+	ErrNoSuchAddress     = errors.New("no user has this address")
+	ErrNoMailtoClient    = errors.New("no mail client is set to compose mailto URLs")
+	ErrNoMailClient      = errors.New("no mail client is bound to the account")
+
+	ErrInvalidPort      = errors.New("the port must be between 0 and 65535")
+	ErrPortConflict     = errors.New("servers must listen on different ports")
+	ErrPathNotWritable  = errors.New("the directory is not writable")
+	ErrProxyUnreachable = errors.New("the proxy is unreachable")
+	ErrInvalidTLSCert   = errors.New("invalid TLS certificate")
+
+	ErrInvalidConfigFile            = errors.New("invalid configuration file")
+	ErrUnknownConfigFormat          = errors.New("the configuration file must be .yaml, .yml or .toml")
+	ErrRelativeConfigPath           = errors.New("the path must be absolute")
+	ErrInvalidAddressMode           = errors.New("the address mode must be split or combined")
+	ErrConfigAccountUsername        = errors.New("the username is missing")
+	ErrConfigAccountPassword        = errors.New("the password file is missing")
+	ErrConfigAccountMailboxPassword = errors.New("the account needs a mailbox password file")
+	ErrConfigAccountTOTP            = errors.New("accounts with two-factor authentication must be logged in interactively")
+
+	ErrPathNotAllowed = errors.New("the path is outside the directories allowed while hardened")
 )
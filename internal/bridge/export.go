@@ -0,0 +1,165 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/events"
+	"github.com/ProtonMail/proton-bridge/v3/internal/safe"
+	"github.com/ProtonMail/proton-bridge/v3/internal/user"
+	"github.com/sirupsen/logrus"
+)
+
+// ExportUserCompliance exports all messages of the given user to path for legal review.
+// Each message is written as an EML file, and manifests in JSON and CSV record its SHA-256 hash,
+// original headers and labels. The manifests' own hashes are written to manifest.sha256.
+func (bridge *Bridge) ExportUserCompliance(
+	ctx context.Context,
+	userID string,
+	path string,
+	progressCB func(string, int, int),
+) ([]user.ExportRecord, error) {
+	logrus.WithField("userID", userID).Info("Exporting user messages for compliance")
+
+	if err := bridge.checkHardenedPath(path); err != nil {
+		return nil, err
+	}
+
+	return safe.RLockRetErr(func() ([]user.ExportRecord, error) {
+		user, ok := bridge.users[userID]
+		if !ok {
+			return nil, ErrNoSuchUser
+		}
+
+		records, err := user.ExportCompliance(ctx, path, progressCB)
+		if err != nil {
+			return nil, fmt.Errorf("failed to export user: %w", err)
+		}
+
+		return records, nil
+	}, bridge.usersLock)
+}
+
+// ExportProgressInterval is how often events.UserExportProgress events are emitted during a mailbox export.
+const ExportProgressInterval = time.Second
+
+// ExportMailbox exports the messages of every mailbox of the given user to path, as mboxrd files or as directories
+// of EML files depending on format, and returns once the export ends. Progress is reported with
+// events.UserExportProgress events and the outcome with an events.UserExportFinished event.
+// The export stops when ctx is canceled or CancelExportMailbox is called; only one export per user may run at once.
+func (bridge *Bridge) ExportMailbox(ctx context.Context, userID string, path string, format user.ExportFormat) error {
+	logrus.WithField("userID", userID).WithField("format", format).Info("Exporting user mailboxes")
+
+	if err := bridge.checkHardenedPath(path); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if err := bridge.startExport(userID, cancel); err != nil {
+		return err
+	}
+	defer bridge.stopExport(userID)
+
+	var lastProgress time.Time
+
+	progressCB := func(progress user.ExportProgress) {
+		if time.Since(lastProgress) < ExportProgressInterval {
+			return
+		}
+
+		lastProgress = time.Now()
+
+		bridge.publish(events.UserExportProgress{
+			UserID:   userID,
+			Exported: progress.Exported,
+			Failed:   progress.Failed,
+			Redacted: progress.Redacted,
+			Total:    progress.Total,
+		})
+	}
+
+	progress, err := safe.RLockRetErr(func() (user.ExportProgress, error) {
+		usr, ok := bridge.users[userID]
+		if !ok {
+			return user.ExportProgress{}, ErrNoSuchUser
+		}
+
+		return usr.ExportMailbox(ctx, path, format, progressCB)
+	}, bridge.usersLock)
+	if errors.Is(err, ErrNoSuchUser) {
+		return err
+	}
+
+	bridge.publish(events.UserExportFinished{
+		UserID:   userID,
+		Path:     path,
+		Exported: progress.Exported,
+		Failed:   progress.Failed,
+		Redacted: progress.Redacted,
+		Error:    err,
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to export mailboxes: %w", err)
+	}
+
+	return nil
+}
+
+// CancelExportMailbox stops the mailbox export of the given user which is in progress.
+func (bridge *Bridge) CancelExportMailbox(userID string) error {
+	bridge.exportsLock.Lock()
+	defer bridge.exportsLock.Unlock()
+
+	cancel, ok := bridge.exports[userID]
+	if !ok {
+		return ErrNoSuchExport
+	}
+
+	logrus.WithField("userID", userID).Info("Canceling mailbox export")
+
+	cancel()
+
+	return nil
+}
+
+func (bridge *Bridge) startExport(userID string, cancel context.CancelFunc) error {
+	bridge.exportsLock.Lock()
+	defer bridge.exportsLock.Unlock()
+
+	if _, ok := bridge.exports[userID]; ok {
+		return ErrExportInProgress
+	}
+
+	bridge.exports[userID] = cancel
+
+	return nil
+}
+
+func (bridge *Bridge) stopExport(userID string) {
+	bridge.exportsLock.Lock()
+	defer bridge.exportsLock.Unlock()
+
+	delete(bridge.exports, userID)
+}
@@ -0,0 +1,156 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/go-proton-api/server"
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
+	"github.com/ProtonMail/proton-bridge/v3/internal/events"
+	"github.com/ProtonMail/proton-bridge/v3/internal/user"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBridge_ExportUserCompliance(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		userID, addrID, err := s.CreateUser("export", password)
+		require.NoError(t, err)
+
+		labelID, err := s.CreateLabel(userID, "folder", "", proton.LabelTypeFolder)
+		require.NoError(t, err)
+
+		withClient(ctx, t, s, "export", password, func(ctx context.Context, c *proton.Client) {
+			createNumMessages(ctx, t, c, addrID, labelID, 3)
+		})
+
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			syncCh, done := chToType[events.Event, events.SyncFinished](b.GetEvents(events.SyncFinished{}))
+			defer done()
+
+			require.Equal(t, userID, must(b.LoginFull(ctx, "export", password, nil, nil)))
+			require.Equal(t, userID, (<-syncCh).UserID)
+
+			dir := t.TempDir()
+
+			records, err := b.ExportUserCompliance(ctx, userID, dir, nil)
+			require.NoError(t, err)
+			require.Len(t, records, 3)
+
+			// Each message is written with the hash recorded in the manifest.
+			for _, record := range records {
+				require.Empty(t, record.Error)
+				require.Contains(t, record.Labels, "folder")
+
+				literal, err := os.ReadFile(filepath.Join(dir, record.File))
+				require.NoError(t, err)
+
+				hash := sha256.Sum256(literal)
+				require.Equal(t, hex.EncodeToString(hash[:]), record.SHA256)
+			}
+
+			// The JSON manifest lists the same records.
+			manifestJSON, err := os.ReadFile(filepath.Join(dir, "manifest.json"))
+			require.NoError(t, err)
+
+			var manifest []user.ExportRecord
+			require.NoError(t, json.Unmarshal(manifestJSON, &manifest))
+			require.Len(t, manifest, 3)
+
+			require.FileExists(t, filepath.Join(dir, "manifest.csv"))
+			require.FileExists(t, filepath.Join(dir, "manifest.sha256"))
+		})
+	})
+}
+
+func TestBridge_ExportMailbox(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		userID, addrID, err := s.CreateUser("export", password)
+		require.NoError(t, err)
+
+		labelID, err := s.CreateLabel(userID, "folder", "", proton.LabelTypeFolder)
+		require.NoError(t, err)
+
+		withClient(ctx, t, s, "export", password, func(ctx context.Context, c *proton.Client) {
+			createNumMessages(ctx, t, c, addrID, labelID, 3)
+			createNumMessages(ctx, t, c, addrID, proton.InboxLabel, 2)
+		})
+
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			syncCh, done := chToType[events.Event, events.SyncFinished](b.GetEvents(events.SyncFinished{}))
+			defer done()
+
+			require.Equal(t, userID, must(b.LoginFull(ctx, "export", password, nil, nil)))
+			require.Equal(t, userID, (<-syncCh).UserID)
+
+			finishedCh, done := chToType[events.Event, events.UserExportFinished](b.GetEvents(events.UserExportFinished{}))
+			defer done()
+
+			// Each mailbox is written as an mbox file, except All Mail.
+			mboxDir := t.TempDir()
+
+			require.NoError(t, b.ExportMailbox(ctx, userID, mboxDir, user.ExportFormatMBOX))
+
+			finished := <-finishedCh
+			require.NoError(t, finished.Error)
+			require.Equal(t, 5, finished.Exported)
+			require.Zero(t, finished.Failed)
+
+			countMBOX := func(name string) int {
+				b, err := os.ReadFile(filepath.Join(mboxDir, name))
+				require.NoError(t, err)
+
+				return len(regexp.MustCompile(`(?m)^From `).FindAll(bytes.ReplaceAll(b, []byte("\r"), nil), -1))
+			}
+
+			require.Equal(t, 3, countMBOX(filepath.Join("Folders", "folder.mbox")))
+			require.Equal(t, 2, countMBOX("Inbox.mbox"))
+			require.NoFileExists(t, filepath.Join(mboxDir, "All Mail.mbox"))
+
+			// Each mailbox is written as a directory of EML files.
+			emlDir := t.TempDir()
+
+			require.NoError(t, b.ExportMailbox(ctx, userID, emlDir, user.ExportFormatEML))
+			require.NoError(t, (<-finishedCh).Error)
+
+			files, err := os.ReadDir(filepath.Join(emlDir, "Folders", "folder"))
+			require.NoError(t, err)
+			require.Len(t, files, 3)
+
+			// A canceled export stops and reports why.
+			canceledCtx, cancel := context.WithCancel(ctx)
+			cancel()
+
+			require.ErrorIs(t, b.ExportMailbox(canceledCtx, userID, t.TempDir(), user.ExportFormatMBOX), context.Canceled)
+			require.ErrorIs(t, (<-finishedCh).Error, context.Canceled)
+
+			// There is nothing left to cancel.
+			require.ErrorIs(t, b.CancelExportMailbox(userID), bridge.ErrNoSuchExport)
+			require.ErrorIs(t, b.ExportMailbox(ctx, "no-such-user", t.TempDir(), user.ExportFormatMBOX), bridge.ErrNoSuchUser)
+		})
+	})
+}
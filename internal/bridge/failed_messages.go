@@ -0,0 +1,69 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"context"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/safe"
+	"github.com/ProtonMail/proton-bridge/v3/internal/services/imapservice"
+	"github.com/sirupsen/logrus"
+)
+
+// ListFailedMessages returns the messages of the given user that failed to download or build during sync.
+// They are kept out of the mailboxes until they are retried successfully.
+func (bridge *Bridge) ListFailedMessages(ctx context.Context, userID string) ([]imapservice.FailedMessage, error) {
+	return safe.RLockRetErr(func() ([]imapservice.FailedMessage, error) {
+		user, ok := bridge.users[userID]
+		if !ok {
+			return nil, ErrNoSuchUser
+		}
+
+		return user.ListFailedMessages(ctx)
+	}, bridge.usersLock)
+}
+
+// RetryFailed downloads and builds the given failed messages of the user again, or all of them if none are given.
+// It returns the messages that are still failing; those that no longer exist are dropped.
+func (bridge *Bridge) RetryFailed(ctx context.Context, userID string, messageIDs ...string) ([]imapservice.FailedMessage, error) {
+	logrus.WithField("userID", userID).WithField("count", len(messageIDs)).Info("Retrying failed messages")
+
+	return safe.RLockRetErr(func() ([]imapservice.FailedMessage, error) {
+		user, ok := bridge.users[userID]
+		if !ok {
+			return nil, ErrNoSuchUser
+		}
+
+		return user.RetryFailedMessages(ctx, messageIDs...)
+	}, bridge.usersLock)
+}
+
+// SkipPermanently drops the given failed messages of the user from the list for good.
+// They stay out of the mailboxes and aren't reported as failed again, even after a resync.
+func (bridge *Bridge) SkipPermanently(ctx context.Context, userID string, messageIDs ...string) error {
+	logrus.WithField("userID", userID).WithField("count", len(messageIDs)).Info("Skipping failed messages")
+
+	return safe.RLockRet(func() error {
+		user, ok := bridge.users[userID]
+		if !ok {
+			return ErrNoSuchUser
+		}
+
+		return user.SkipFailedMessages(ctx, messageIDs...)
+	}, bridge.usersLock)
+}
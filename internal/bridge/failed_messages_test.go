@@ -0,0 +1,127 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/go-proton-api/server"
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
+	"github.com/ProtonMail/proton-bridge/v3/internal/constants"
+	"github.com/ProtonMail/proton-bridge/v3/internal/services/imapservice"
+	"github.com/emersion/go-imap"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBridge_FailedMessages(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		_, addrID, err := s.CreateUser("user", password)
+		require.NoError(t, err)
+
+		var messageIDs []string
+
+		withClient(ctx, t, s, "user", password, func(ctx context.Context, c *proton.Client) {
+			messageIDs = createNumMessages(ctx, t, c, addrID, proton.InboxLabel, 3)
+		})
+
+		// The first two messages can't be downloaded until told otherwise.
+		var fixFirst, fixSecond atomic.Bool
+
+		s.AddStatusHook(func(req *http.Request) (int, bool) {
+			switch {
+			case strings.HasSuffix(req.URL.Path, "/mail/v4/messages/"+messageIDs[0]) && !fixFirst.Load():
+				return http.StatusNotFound, true
+
+			case strings.HasSuffix(req.URL.Path, "/mail/v4/messages/"+messageIDs[1]) && !fixSecond.Load():
+				return http.StatusNotFound, true
+			}
+
+			return 0, false
+		})
+
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			userLoginAndSync(ctx, t, b, "user", password)
+
+			info, err := b.QueryUserInfo("user")
+			require.NoError(t, err)
+
+			// The sync finished anyway, with the two messages quarantined.
+			failed, err := b.ListFailedMessages(ctx, info.UserID)
+			require.NoError(t, err)
+			require.ElementsMatch(t, messageIDs[:2], failedMessageIDs(failed))
+
+			for _, message := range failed {
+				require.NotEmpty(t, message.Reason)
+				require.Equal(t, 1, message.Attempts)
+			}
+
+			client, err := eventuallyDial(fmt.Sprintf("%v:%v", constants.Host, b.GetIMAPPort()))
+			require.NoError(t, err)
+			require.NoError(t, client.Login(info.Addresses[0], string(info.BridgePass)))
+			defer func() { _ = client.Logout() }()
+
+			status, err := client.Status("INBOX", []imap.StatusItem{imap.StatusMessages})
+			require.NoError(t, err)
+			require.Equal(t, uint32(1), status.Messages)
+
+			// Only the messages the API serves again leave the list.
+			fixFirst.Store(true)
+
+			failed, err = b.RetryFailed(ctx, info.UserID)
+			require.NoError(t, err)
+			require.Len(t, failed, 1)
+			require.Equal(t, messageIDs[1], failed[0].ID)
+			require.Equal(t, 2, failed[0].Attempts)
+
+			status, err = client.Status("INBOX", []imap.StatusItem{imap.StatusMessages})
+			require.NoError(t, err)
+			require.Equal(t, uint32(2), status.Messages)
+
+			// A skipped message is gone for good.
+			require.NoError(t, b.SkipPermanently(ctx, info.UserID, messageIDs[1]))
+
+			failed, err = b.ListFailedMessages(ctx, info.UserID)
+			require.NoError(t, err)
+			require.Empty(t, failed)
+
+			require.ErrorIs(t, b.SkipPermanently(ctx, info.UserID, messageIDs[1]), imapservice.ErrNoSuchFailedMessage)
+
+			_, err = b.RetryFailed(ctx, info.UserID, messageIDs[2])
+			require.ErrorIs(t, err, imapservice.ErrNoSuchFailedMessage)
+
+			_, err = b.ListFailedMessages(ctx, "no-such-user")
+			require.ErrorIs(t, err, bridge.ErrNoSuchUser)
+		})
+	})
+}
+
+func failedMessageIDs(failed []imapservice.FailedMessage) []string {
+	ids := make([]string, 0, len(failed))
+
+	for _, message := range failed {
+		ids = append(ids, message.ID)
+	}
+
+	return ids
+}
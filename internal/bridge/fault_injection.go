@@ -0,0 +1,74 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"github.com/ProtonMail/proton-bridge/v3/internal/network"
+	"github.com/sirupsen/logrus"
+)
+
+// FaultInjection configures the faults injected for resilience testing.
+type FaultInjection struct {
+	network.Faults
+
+	// DiskFull makes the message cache's disk look full, which pauses syncing.
+	DiskFull bool
+}
+
+// IsZero returns whether no fault is injected.
+func (f FaultInjection) IsZero() bool {
+	return f.Faults.IsZero() && !f.DiskFull
+}
+
+// GetFaultInjection returns the faults injected for resilience testing.
+func (bridge *Bridge) GetFaultInjection() FaultInjection {
+	return FaultInjection{
+		Faults:   bridge.faultInjector.GetFaults(),
+		DiskFull: bridge.diskFullInjected.Load(),
+	}
+}
+
+// SetFaultInjection injects the given faults, so that how bridge copes with rate limiting, a flaky network,
+// a slow API or a full disk can be checked on a real setup; the zero value stops injecting faults.
+// The faults are not saved: bridge always starts without them.
+func (bridge *Bridge) SetFaultInjection(faults FaultInjection) error {
+	if !isFaultRate(faults.TooManyRequests) ||
+		!isFaultRate(faults.DroppedConnections) ||
+		!isFaultRate(faults.TooManyRequests+faults.DroppedConnections) ||
+		faults.Latency < 0 {
+		return ErrInvalidFaultInjection
+	}
+
+	if faults.IsZero() {
+		logrus.Info("Fault injection is disabled")
+	} else {
+		logrus.WithField("faults", faults).Warn("Fault injection is enabled")
+	}
+
+	bridge.faultInjector.SetFaults(faults.Faults)
+
+	if bridge.diskFullInjected.Swap(faults.DiskFull) != faults.DiskFull {
+		bridge.goDiskSpaceCheck()
+	}
+
+	return nil
+}
+
+func isFaultRate(rate float64) bool {
+	return rate >= 0 && rate <= 1
+}
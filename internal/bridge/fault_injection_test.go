@@ -0,0 +1,73 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/go-proton-api/server"
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
+	"github.com/ProtonMail/proton-bridge/v3/internal/events"
+	"github.com/ProtonMail/proton-bridge/v3/internal/network"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBridge_FaultInjection(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			// No fault is injected by default.
+			require.True(t, b.GetFaultInjection().IsZero())
+
+			// Rates must add up to at most 1 and latency can't be negative.
+			require.ErrorIs(t, b.SetFaultInjection(bridge.FaultInjection{Faults: network.Faults{TooManyRequests: 1.5}}), bridge.ErrInvalidFaultInjection)
+			require.ErrorIs(t, b.SetFaultInjection(bridge.FaultInjection{Faults: network.Faults{TooManyRequests: 0.6, DroppedConnections: 0.6}}), bridge.ErrInvalidFaultInjection)
+			require.ErrorIs(t, b.SetFaultInjection(bridge.FaultInjection{Faults: network.Faults{Latency: -time.Second}}), bridge.ErrInvalidFaultInjection)
+
+			// Dropping every connection looks like the network is down.
+			connCh, connDone := b.GetEvents(events.ConnStatusUp{}, events.ConnStatusDown{})
+			defer connDone()
+
+			require.NoError(t, b.SetFaultInjection(bridge.FaultInjection{Faults: network.Faults{DroppedConnections: 1}}))
+
+			_, err := b.LoginFull(ctx, username, password, nil, nil)
+			require.Error(t, err)
+			require.Equal(t, events.ConnStatusDown{}, <-connCh)
+
+			// Bridge recovers once the faults stop.
+			require.NoError(t, b.SetFaultInjection(bridge.FaultInjection{}))
+
+			must(b.LoginFull(ctx, username, password, nil, nil))
+			require.Equal(t, events.ConnStatusUp{}, <-connCh)
+
+			// A full disk pauses syncing until it has room again.
+			diskCh, diskDone := b.GetEvents(events.DiskSpaceLow{}, events.DiskSpaceRecovered{})
+			defer diskDone()
+
+			require.NoError(t, b.SetFaultInjection(bridge.FaultInjection{DiskFull: true}))
+			require.IsType(t, events.DiskSpaceLow{}, <-diskCh)
+			require.True(t, b.IsDiskSpaceLow())
+
+			require.NoError(t, b.SetFaultInjection(bridge.FaultInjection{}))
+			require.IsType(t, events.DiskSpaceRecovered{}, <-diskCh)
+			require.False(t, b.IsDiskSpaceLow())
+		})
+	})
+}
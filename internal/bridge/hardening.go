@@ -0,0 +1,58 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// SetHardenedPaths records the only directories the process may still write to, once Landlock restricted it.
+// Exports, imports and cache moves elsewhere are then refused upfront rather than failing midway.
+func (bridge *Bridge) SetHardenedPaths(paths []string) {
+	bridge.hardenedPathsLock.Lock()
+	defer bridge.hardenedPathsLock.Unlock()
+
+	bridge.hardenedPaths = slices.Clone(paths)
+}
+
+// checkHardenedPath returns ErrPathNotAllowed if the process is hardened and the path isn't beneath an allowed directory.
+func (bridge *Bridge) checkHardenedPath(path string) error {
+	bridge.hardenedPathsLock.RLock()
+	defer bridge.hardenedPathsLock.RUnlock()
+
+	if len(bridge.hardenedPaths) == 0 {
+		return nil
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrPathNotAllowed, path)
+	}
+
+	for _, allowed := range bridge.hardenedPaths {
+		if rel, err := filepath.Rel(allowed, abs); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %v", ErrPathNotAllowed, path)
+}
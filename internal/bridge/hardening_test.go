@@ -0,0 +1,55 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/go-proton-api/server"
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
+	"github.com/ProtonMail/proton-bridge/v3/internal/user"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBridge_HardenedPaths(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			allowed, other := t.TempDir(), t.TempDir()
+
+			// Without hardening, any path may be used.
+			require.ErrorIs(t, b.ImportMessages(ctx, "no-such-user", other), bridge.ErrNoSuchUser)
+
+			b.SetHardenedPaths([]string{allowed})
+
+			// Once hardened, paths outside the allowed directories are refused upfront.
+			require.ErrorIs(t, b.ImportMessages(ctx, "no-such-user", other), bridge.ErrPathNotAllowed)
+			require.ErrorIs(t, b.ExportMailbox(ctx, "no-such-user", other, user.ExportFormatEML), bridge.ErrPathNotAllowed)
+			require.ErrorIs(t, b.SetGluonDir(ctx, filepath.Join(other, "cache")), bridge.ErrPathNotAllowed)
+			require.ErrorIs(t, b.ImportMessages(ctx, "no-such-user", allowed+"-sibling"), bridge.ErrPathNotAllowed)
+
+			_, err := b.ExportUserCompliance(ctx, "no-such-user", filepath.Join(allowed, ".."), nil)
+			require.ErrorIs(t, err, bridge.ErrPathNotAllowed)
+
+			// Paths beneath them are allowed.
+			require.ErrorIs(t, b.ImportMessages(ctx, "no-such-user", filepath.Join(allowed, "mbox")), bridge.ErrNoSuchUser)
+		})
+	})
+}
@@ -20,7 +20,9 @@ package bridge
 import (
 	"context"
 	"crypto/tls"
+	"net"
 	"strings"
+	"time"
 
 	"github.com/Masterminds/semver/v3"
 	imapEvents "github.com/ProtonMail/gluon/events"
@@ -50,6 +52,7 @@ func (bridge *Bridge) handleIMAPEvent(event imapEvents.Event) {
 			"sessionID": event.SessionID,
 			"name":      event.IMAPID.Name,
 			"version":   event.IMAPID.Version,
+			"shims":     bridge.clientShims.GetActive(event.IMAPID),
 		}).Info("Received IMAP ID")
 
 		if event.IMAPID.Name != "" && event.IMAPID.Version != "" {
@@ -91,16 +94,45 @@ func (b *bridgeIMAPSettings) LogServer() bool {
 	return b.b.logIMAPServer
 }
 
+func (b *bridgeIMAPSettings) Hosts() []string {
+	return b.b.getBindHosts(b.b.vault.GetIMAPBindAddress())
+}
+
 func (b *bridgeIMAPSettings) Port() int {
-	return b.b.vault.GetIMAPPort()
+	return b.b.GetIMAPPort()
 }
 
+// SetPort saves the port the server listens on, unless it's the default one of safe mode.
 func (b *bridgeIMAPSettings) SetPort(i int) error {
+	if b.b.safeMode != nil {
+		return nil
+	}
+
 	return b.b.vault.SetIMAPPort(i)
 }
 
 func (b *bridgeIMAPSettings) UseSSL() bool {
-	return b.b.vault.GetIMAPSSL()
+	return b.b.UsesIMAPSSL()
+}
+
+func (b *bridgeIMAPSettings) Limits() imapsmtpserver.Limits {
+	return imapsmtpserver.Limits(b.b.vault.GetServerLimits())
+}
+
+func (b *bridgeIMAPSettings) SlowCommandThreshold() time.Duration {
+	return b.b.vault.GetSlowCommandThreshold()
+}
+
+func (b *bridgeIMAPSettings) ReadOnly() bool {
+	return b.b.safeMode != nil
+}
+
+func (b *bridgeIMAPSettings) RedactedMessages() imapsmtpserver.RedactedMessages {
+	return b.b.redactedMessages
+}
+
+func (b *bridgeIMAPSettings) AllowClient(addr net.Addr) bool {
+	return b.b.AllowClient("IMAP", addr)
 }
 
 func (b *bridgeIMAPSettings) CacheDirectory() string {
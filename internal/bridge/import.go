@@ -0,0 +1,136 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/events"
+	"github.com/ProtonMail/proton-bridge/v3/internal/safe"
+	"github.com/ProtonMail/proton-bridge/v3/internal/user"
+	"github.com/sirupsen/logrus"
+)
+
+// ImportProgressInterval is how often events.UserImportProgress events are emitted during a message import.
+const ImportProgressInterval = time.Second
+
+// ImportMessages imports the messages of the MBOX files, EML files and Maildirs at source to the given user,
+// into the folders and labels of the same paths, and returns once the import ends. Messages already in the account
+// are skipped. Progress is reported with events.UserImportProgress events and the outcome with an
+// events.UserImportFinished event. The import stops when ctx is canceled or CancelImportMessages is called;
+// importing the same source again resumes it. Only one import per user may run at once.
+func (bridge *Bridge) ImportMessages(ctx context.Context, userID string, source string) error {
+	logrus.WithField("userID", userID).Info("Importing messages")
+
+	if err := bridge.checkHardenedPath(source); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if err := bridge.startImport(userID, cancel); err != nil {
+		return err
+	}
+	defer bridge.stopImport(userID)
+
+	var lastProgress time.Time
+
+	progressCB := func(progress user.ImportProgress) {
+		if time.Since(lastProgress) < ImportProgressInterval {
+			return
+		}
+
+		lastProgress = time.Now()
+
+		bridge.publish(events.UserImportProgress{
+			UserID:     userID,
+			Imported:   progress.Imported,
+			Duplicates: progress.Duplicates,
+			Failed:     progress.Failed,
+			Total:      progress.Total,
+		})
+	}
+
+	progress, err := safe.RLockRetErr(func() (user.ImportProgress, error) {
+		usr, ok := bridge.users[userID]
+		if !ok {
+			return user.ImportProgress{}, ErrNoSuchUser
+		}
+
+		return usr.ImportMessages(ctx, source, progressCB)
+	}, bridge.usersLock)
+	if errors.Is(err, ErrNoSuchUser) {
+		return err
+	}
+
+	bridge.publish(events.UserImportFinished{
+		UserID:     userID,
+		Source:     source,
+		Imported:   progress.Imported,
+		Duplicates: progress.Duplicates,
+		Failed:     progress.Failed,
+		Error:      err,
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to import messages: %w", err)
+	}
+
+	return nil
+}
+
+// CancelImportMessages stops the message import to the given user which is in progress.
+func (bridge *Bridge) CancelImportMessages(userID string) error {
+	bridge.importsLock.Lock()
+	defer bridge.importsLock.Unlock()
+
+	cancel, ok := bridge.imports[userID]
+	if !ok {
+		return ErrNoSuchImport
+	}
+
+	logrus.WithField("userID", userID).Info("Canceling message import")
+
+	cancel()
+
+	return nil
+}
+
+func (bridge *Bridge) startImport(userID string, cancel context.CancelFunc) error {
+	bridge.importsLock.Lock()
+	defer bridge.importsLock.Unlock()
+
+	if _, ok := bridge.imports[userID]; ok {
+		return ErrImportInProgress
+	}
+
+	bridge.imports[userID] = cancel
+
+	return nil
+}
+
+func (bridge *Bridge) stopImport(userID string) {
+	bridge.importsLock.Lock()
+	defer bridge.importsLock.Unlock()
+
+	delete(bridge.imports, userID)
+}
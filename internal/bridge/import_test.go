@@ -0,0 +1,166 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge_test
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ProtonMail/gluon/async"
+	"github.com/ProtonMail/gluon/rfc822"
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/go-proton-api/server"
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
+	"github.com/ProtonMail/proton-bridge/v3/internal/events"
+	"github.com/bradenaw/juniper/xslices"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBridge_ImportMessages(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		userID, _, err := s.CreateUser("import", password)
+		require.NoError(t, err)
+
+		// The test server only records the Message-Id of drafts.
+		withClient(ctx, t, s, "import", password, func(ctx context.Context, c *proton.Client) {
+			user, err := c.GetUser(ctx)
+			require.NoError(t, err)
+
+			addrs, err := c.GetAddresses(ctx)
+			require.NoError(t, err)
+
+			salts, err := c.GetSalts(ctx)
+			require.NoError(t, err)
+
+			keyPass, err := salts.SaltForKey(password, user.Keys.Primary().ID)
+			require.NoError(t, err)
+
+			_, addrKRs, err := proton.Unlock(user, addrs, keyPass, async.NoopPanicHandler{})
+			require.NoError(t, err)
+
+			_, err = c.CreateDraft(ctx, addrKRs[addrs[0].ID], proton.CreateDraftReq{
+				Message: proton.DraftTemplate{
+					Subject:    "existing",
+					Sender:     &mail.Address{Address: addrs[0].Email},
+					Body:       "body",
+					MIMEType:   rfc822.TextPlain,
+					ExternalID: "existing@import.test",
+				},
+			})
+			require.NoError(t, err)
+		})
+
+		source := t.TempDir()
+
+		writeImportFile(t, filepath.Join(source, "Folders", "Projects", "Alpha.mbox"), newImportMBOX("a1", "a2"))
+		writeImportFile(t, filepath.Join(source, "Labels", "Todo.mbox"), newImportMBOX("a1"))
+		writeImportFile(t, filepath.Join(source, "Work", "cur", "1:2,S"), newImportLiteral("w1"))
+		writeImportFile(t, filepath.Join(source, "Work", "new", "2"), newImportLiteral("existing"))
+		writeImportFile(t, filepath.Join(source, "note.eml"), newImportLiteral("n1"))
+
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			syncCh, done := chToType[events.Event, events.SyncFinished](b.GetEvents(events.SyncFinished{}))
+			defer done()
+
+			require.Equal(t, userID, must(b.LoginFull(ctx, "import", password, nil, nil)))
+			require.Equal(t, userID, (<-syncCh).UserID)
+
+			finishedCh, done := chToType[events.Event, events.UserImportFinished](b.GetEvents(events.UserImportFinished{}))
+			defer done()
+
+			// The messages already in the account, or met before, are skipped.
+			require.NoError(t, b.ImportMessages(ctx, userID, source))
+
+			finished := <-finishedCh
+			require.NoError(t, finished.Error)
+			require.Equal(t, 4, finished.Imported)
+			require.Equal(t, 2, finished.Duplicates)
+			require.Zero(t, finished.Failed)
+
+			// A canceled import stops and reports why.
+			canceledCtx, cancel := context.WithCancel(ctx)
+			cancel()
+
+			require.ErrorIs(t, b.ImportMessages(canceledCtx, userID, source), context.Canceled)
+			require.ErrorIs(t, (<-finishedCh).Error, context.Canceled)
+
+			require.ErrorIs(t, b.CancelImportMessages(userID), bridge.ErrNoSuchImport)
+			require.ErrorIs(t, b.ImportMessages(ctx, "no-such-user", source), bridge.ErrNoSuchUser)
+		})
+
+		// The messages are in the folders and labels of the same paths, which were created.
+		withClient(ctx, t, s, "import", password, func(ctx context.Context, c *proton.Client) {
+			labels, err := c.GetLabels(ctx, proton.LabelTypeFolder, proton.LabelTypeLabel)
+			require.NoError(t, err)
+
+			labelIDs := make(map[string]string)
+
+			for _, label := range labels {
+				labelIDs[fmt.Sprint(label.Type, label.Path)] = label.ID
+			}
+
+			getLabelIDs := func(subject string) []string {
+				metadata, err := c.GetMessageMetadata(ctx, proton.MessageFilter{Subject: subject})
+				require.NoError(t, err)
+				require.Len(t, metadata, 1)
+
+				return metadata[0].LabelIDs
+			}
+
+			alpha := labelIDs[fmt.Sprint(proton.LabelTypeFolder, []string{"Projects", "Alpha"})]
+			todo := labelIDs[fmt.Sprint(proton.LabelTypeLabel, []string{"Todo"})]
+			work := labelIDs[fmt.Sprint(proton.LabelTypeFolder, []string{"Work"})]
+
+			require.NotEmpty(t, alpha)
+			require.NotEmpty(t, todo)
+			require.NotEmpty(t, work)
+
+			require.Subset(t, getLabelIDs("a1"), []string{alpha, todo})
+			require.Contains(t, getLabelIDs("a2"), alpha)
+			require.NotContains(t, getLabelIDs("a2"), todo)
+			require.Contains(t, getLabelIDs("w1"), work)
+			require.Contains(t, getLabelIDs("n1"), proton.InboxLabel)
+			require.Contains(t, getLabelIDs("existing"), proton.DraftsLabel)
+		})
+	})
+}
+
+func newImportLiteral(externalID string) []byte {
+	return []byte(fmt.Sprintf(
+		"From: Sender <sender@import.test>\r\nTo: Receiver <receiver@import.test>\r\nSubject: %v\r\nMessage-Id: <%v@import.test>\r\n\r\nFrom the import of %v.\r\n",
+		externalID, externalID, externalID,
+	))
+}
+
+func newImportMBOX(externalIDs ...string) []byte {
+	return xslices.Join(xslices.Map(externalIDs, func(externalID string) []byte {
+		return []byte(fmt.Sprintf(
+			"From sender@import.test Mon Jan  2 15:04:05 2006\nFrom: sender@import.test\nSubject: %v\nMessage-Id: <%v@import.test>\n\n>From the import of %v.\n\n",
+			externalID, externalID, externalID,
+		))
+	})...)
+}
+
+func writeImportFile(t *testing.T, path string, b []byte) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o700))
+	require.NoError(t, os.WriteFile(path, b, 0o600))
+}
@@ -0,0 +1,249 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/constants"
+	"github.com/ProtonMail/proton-bridge/v3/internal/events"
+	"github.com/ProtonMail/proton-bridge/v3/internal/interference"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// InterferenceCheckInterval is how often bridge checks whether security software interferes with it.
+	InterferenceCheckInterval = 6 * time.Hour
+
+	// interferenceProbeTimeout bounds the connection made to each server to detect interception.
+	interferenceProbeTimeout = 10 * time.Second
+)
+
+// Interference is a way in which security software was found to interfere with bridge.
+// Product is the name of the software if it could be identified.
+type Interference struct {
+	Kind    events.InterferenceKind
+	Product string
+	Detail  string
+}
+
+// errInterception is returned by the probes when the connection to a server isn't made with bridge itself.
+var errInterception = errors.New("connection intercepted")
+
+// DiagnoseInterference checks whether the connections to the IMAP and SMTP servers are intercepted, as done by
+// the mail shields of antivirus software, and whether bridge's files were recently locked by another process.
+func (bridge *Bridge) DiagnoseInterference(ctx context.Context) []Interference {
+	var found []Interference
+
+	for _, server := range []struct {
+		kind     events.InterferenceKind
+		name     string
+		port     int
+		ssl      bool
+		starttls func(*bufio.ReadWriter) error
+	}{
+		{kind: events.IMAPInterception, name: "IMAP", port: bridge.GetIMAPPort(), ssl: bridge.GetIMAPSSL(), starttls: imapStartTLS},
+		{kind: events.SMTPInterception, name: "SMTP", port: bridge.GetSMTPPort(), ssl: bridge.GetSMTPSSL(), starttls: smtpStartTLS},
+	} {
+		product, err := bridge.probeServer(ctx, server.port, server.ssl, server.starttls)
+		if errors.Is(err, errInterception) {
+			found = append(found, Interference{
+				Kind:    server.kind,
+				Product: product,
+				Detail:  fmt.Sprintf("%v server on port %v: %v", server.name, server.port, err),
+			})
+		} else if err != nil {
+			logrus.WithError(err).WithField("server", server.name).Debug("Could not probe server for interference")
+		}
+	}
+
+	if count, last := interference.Violations(); count > 0 && time.Since(last) < InterferenceCheckInterval {
+		found = append(found, Interference{
+			Kind:    events.FileLocking,
+			Product: interference.IdentifyRunning(),
+			Detail:  fmt.Sprintf("files were locked by another process %v times, last at %v", count, last.Format(time.RFC3339)),
+		})
+	}
+
+	return found
+}
+
+// checkInterference publishes the interference which wasn't found by the previous check.
+// The matching error is resolved once none is found anymore.
+func (bridge *Bridge) checkInterference(ctx context.Context) {
+	found := bridge.DiagnoseInterference(ctx)
+
+	kinds := make(map[events.InterferenceKind]bool, len(found))
+
+	for _, item := range found {
+		kinds[item.Kind] = true
+
+		if bridge.interference[item.Kind] {
+			continue
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"kind":    item.Kind,
+			"product": item.Product,
+		}).Warn("Security software interferes with bridge: ", item.Detail)
+
+		bridge.publish(events.InterferenceDetected{Kind: item.Kind, Product: item.Product, Detail: item.Detail})
+	}
+
+	if len(found) == 0 && len(bridge.interference) > 0 {
+		logrus.Info("Security software no longer interferes with bridge")
+
+		if bridge.errorCenter.resolve("", ErrorCodeInterference) {
+			bridge.goAnnounce()
+		}
+	}
+
+	bridge.interference = kinds
+}
+
+// probeServer connects to the server listening on the given port as a client would and checks that it's served
+// by bridge with its own certificate. If not, it returns errInterception and the product which intercepted it.
+func (bridge *Bridge) probeServer(ctx context.Context, port int, ssl bool, starttls func(*bufio.ReadWriter) error) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, interferenceProbeTimeout)
+	defer cancel()
+
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", net.JoinHostPort(constants.Host, strconv.Itoa(port)))
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close() //nolint:errcheck
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return "", err
+		}
+	}
+
+	if !ssl {
+		if err := starttls(bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))); err != nil {
+			return interference.IdentifyRunning(), err
+		}
+	}
+
+	//nolint:gosec // The certificate is compared with bridge's own rather than verified.
+	client := tls.Client(conn, &tls.Config{InsecureSkipVerify: true, ServerName: constants.Host})
+
+	if err := client.HandshakeContext(ctx); err != nil {
+		if netErr := net.Error(nil); errors.As(err, &netErr) && netErr.Timeout() {
+			return "", err
+		}
+
+		return interference.IdentifyRunning(), fmt.Errorf("%w: TLS handshake failed: %v", errInterception, err)
+	}
+
+	peerCert := client.ConnectionState().PeerCertificates[0]
+
+	if bytes.Equal(peerCert.Raw, bridge.tlsCert.Load().Certificate[0]) {
+		return "", nil
+	}
+
+	product := interference.IdentifyIssuer(peerCert)
+	if product == "" {
+		product = interference.IdentifyRunning()
+	}
+
+	return product, fmt.Errorf("%w: the certificate presented was issued by %v", errInterception, peerCert.Issuer)
+}
+
+// imapStartTLS upgrades the connection to an IMAP server to TLS, as IMAP clients do when SSL isn't used.
+func imapStartTLS(rw *bufio.ReadWriter) error {
+	if greeting, err := rw.ReadString('\n'); err != nil {
+		return err
+	} else if !strings.HasPrefix(greeting, "* OK") {
+		return fmt.Errorf("%w: unexpected greeting %q", errInterception, strings.TrimSpace(greeting))
+	}
+
+	if _, err := rw.WriteString("probe STARTTLS\r\n"); err != nil {
+		return err
+	} else if err := rw.Flush(); err != nil {
+		return err
+	}
+
+	for {
+		line, err := rw.ReadString('\n')
+		if err != nil {
+			return err
+		}
+
+		if strings.HasPrefix(line, "* ") {
+			continue
+		}
+
+		if !strings.HasPrefix(line, "probe OK") {
+			return fmt.Errorf("%w: STARTTLS refused: %q", errInterception, strings.TrimSpace(line))
+		}
+
+		return nil
+	}
+}
+
+// smtpStartTLS upgrades the connection to an SMTP server to TLS, as SMTP clients do when SSL isn't used.
+func smtpStartTLS(rw *bufio.ReadWriter) error {
+	if err := readSMTPReply(rw, "220"); err != nil {
+		return err
+	}
+
+	for _, exchange := range []struct{ command, code string }{
+		{command: "EHLO " + constants.Host, code: "250"},
+		{command: "STARTTLS", code: "220"},
+	} {
+		if _, err := rw.WriteString(exchange.command + "\r\n"); err != nil {
+			return err
+		} else if err := rw.Flush(); err != nil {
+			return err
+		}
+
+		if err := readSMTPReply(rw, exchange.code); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readSMTPReply reads a possibly multi-line SMTP reply and checks that it has the given code.
+func readSMTPReply(rw *bufio.ReadWriter, code string) error {
+	for {
+		line, err := rw.ReadString('\n')
+		if err != nil {
+			return err
+		}
+
+		if !strings.HasPrefix(line, code) {
+			return fmt.Errorf("%w: unexpected reply %q", errInterception, strings.TrimSpace(line))
+		}
+
+		if len(line) < 4 || line[3] != '-' {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/safe"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+	"github.com/sirupsen/logrus"
+)
+
+// GetLabelKeywordMode returns whether the labels of the given user are exposed as IMAP keywords.
+func (bridge *Bridge) GetLabelKeywordMode(userID string) (vault.LabelKeywordMode, error) {
+	return safe.RLockRetErr(func() (vault.LabelKeywordMode, error) {
+		user, ok := bridge.users[userID]
+		if !ok {
+			return vault.LabelKeywordsOff, ErrNoSuchUser
+		}
+
+		return user.GetLabelKeywordMode(), nil
+	}, bridge.usersLock)
+}
+
+// SetLabelKeywordMode sets whether the labels of the given user are exposed as IMAP keywords
+// on messages, in addition to or instead of the Labels mailboxes.
+func (bridge *Bridge) SetLabelKeywordMode(ctx context.Context, userID string, mode vault.LabelKeywordMode) error {
+	logrus.WithField("userID", userID).WithField("mode", mode).Info("Setting label keyword mode")
+
+	return safe.RLockRet(func() error {
+		user, ok := bridge.users[userID]
+		if !ok {
+			return ErrNoSuchUser
+		}
+
+		if err := user.SetLabelKeywordMode(ctx, mode); err != nil {
+			return fmt.Errorf("failed to set label keyword mode: %w", err)
+		}
+
+		return nil
+	}, bridge.usersLock)
+}
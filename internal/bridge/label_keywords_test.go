@@ -0,0 +1,113 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/go-proton-api/server"
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
+	"github.com/ProtonMail/proton-bridge/v3/internal/constants"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+	"github.com/bradenaw/juniper/xslices"
+	"github.com/emersion/go-imap"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBridge_LabelKeywords(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		_, addrID, err := s.CreateUser("user", password)
+		require.NoError(t, err)
+
+		var label proton.Label
+
+		withClient(ctx, t, s, "user", password, func(ctx context.Context, c *proton.Client) {
+			label, err = c.CreateLabel(ctx, proton.CreateLabelReq{
+				Name:  "Work Stuff",
+				Color: "#f66",
+				Type:  proton.LabelTypeLabel,
+			})
+			require.NoError(t, err)
+
+			messageIDs := createNumMessages(ctx, t, c, addrID, proton.InboxLabel, 2)
+
+			require.NoError(t, c.LabelMessages(ctx, messageIDs[:1], label.ID))
+		})
+
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			userLoginAndSync(ctx, t, b, "user", password)
+
+			info, err := b.QueryUserInfo("user")
+			require.NoError(t, err)
+
+			client, err := eventuallyDial(fmt.Sprintf("%v:%v", constants.Host, b.GetIMAPPort()))
+			require.NoError(t, err)
+			require.NoError(t, client.Login(info.Addresses[0], string(info.BridgePass)))
+			defer func() { _ = client.Logout() }()
+
+			countKeywords := func() int {
+				messages, err := clientFetch(client, "INBOX")
+				require.NoError(t, err)
+
+				return len(xslices.Filter(messages, func(message *imap.Message) bool {
+					// Keywords are case-insensitive.
+					return xslices.IndexFunc(message.Flags, func(flag string) bool { return strings.EqualFold(flag, "Work_Stuff") }) >= 0
+				}))
+			}
+
+			// Labels aren't exposed as keywords by default.
+			require.Zero(t, countKeywords())
+
+			// The labelled message gets the keyword of its label.
+			require.NoError(t, b.SetLabelKeywordMode(ctx, info.UserID, vault.LabelKeywordsBoth))
+			require.Equal(t, 1, countKeywords())
+
+			mailboxNames := func() []string {
+				return xslices.Map(clientList(client), func(mailbox *imap.MailboxInfo) string { return mailbox.Name })
+			}
+
+			require.Contains(t, mailboxNames(), "Labels/Work Stuff")
+
+			// The label mailboxes are hidden when labels are only exposed as keywords.
+			require.NoError(t, b.SetLabelKeywordMode(ctx, info.UserID, vault.LabelKeywordsOnly))
+			require.NotContains(t, mailboxNames(), "Labels/Work Stuff")
+			require.Contains(t, mailboxNames(), "INBOX")
+
+			// Appending a message with the keyword applies the label.
+			require.NoError(t, client.Append("INBOX", []string{"Work_Stuff"}, time.Now(), strings.NewReader("From: sender@pm.me\r\nTo: user@pm.me\r\nDate: Mon, 02 Jan 2006 15:04:05 +0000\r\nSubject: keyword\r\n\r\nbody")))
+
+			withClient(ctx, t, s, "user", password, func(ctx context.Context, c *proton.Client) {
+				require.Eventually(t, func() bool {
+					metadata, err := c.GetMessageMetadataPage(ctx, 0, 10, proton.MessageFilter{LabelID: label.ID})
+					return err == nil && len(metadata) == 2
+				}, 10*time.Second, 100*time.Millisecond)
+			})
+
+			require.Equal(t, 2, countKeywords())
+
+			// Disabling the keywords removes them again.
+			require.NoError(t, b.SetLabelKeywordMode(ctx, info.UserID, vault.LabelKeywordsOff))
+			require.Zero(t, countKeywords())
+		})
+	})
+}
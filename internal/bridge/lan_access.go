@@ -0,0 +1,136 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/events"
+	"github.com/ProtonMail/proton-bridge/v3/internal/network"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/exp/slices"
+)
+
+// GetLANAccess returns whether the servers listen beyond localhost, and the remote clients they accept.
+func (bridge *Bridge) GetLANAccess() (bool, []string) {
+	access := bridge.vault.GetLANAccess()
+
+	return access.Enabled, access.AllowedClients
+}
+
+// SetLANAccessEnabled sets whether the servers listen on all interfaces, and restarts the IMAP and SMTP servers.
+// The gRPC server only listens on the new interfaces after bridge restarts.
+func (bridge *Bridge) SetLANAccessEnabled(ctx context.Context, enabled bool) error {
+	access := bridge.vault.GetLANAccess()
+
+	if access.Enabled == enabled {
+		return nil
+	}
+
+	access.Enabled = enabled
+
+	if err := bridge.vault.SetLANAccess(access); err != nil {
+		return err
+	}
+
+	logrus.WithField("enabled", enabled).Info("LAN access changed")
+
+	if err := bridge.restartIMAP(ctx); err != nil {
+		return err
+	}
+
+	if err := bridge.restartSMTP(ctx); err != nil {
+		return err
+	}
+
+	return bridge.restartPOP3(ctx)
+}
+
+// SetAllowedClients sets the CIDRs or IP addresses of the remote clients accepted by the servers.
+// It applies to the next accepted connections; loopback clients are always accepted.
+func (bridge *Bridge) SetAllowedClients(clients []string) error {
+	allowed, err := normalizeAllowedClients(clients)
+	if err != nil {
+		return err
+	}
+
+	access := bridge.vault.GetLANAccess()
+
+	access.AllowedClients = allowed
+
+	if err := bridge.vault.SetLANAccess(access); err != nil {
+		return err
+	}
+
+	logrus.WithField("allowedClients", allowed).Info("LAN allowlist changed")
+
+	return nil
+}
+
+// normalizeAllowedClients returns the canonical form of the given CIDRs or IP addresses, without duplicates.
+func normalizeAllowedClients(clients []string) ([]string, error) {
+	allowed := make([]string, 0, len(clients))
+
+	for _, client := range clients {
+		prefix, err := network.ParseAllowedClient(client)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidAllowedClient, err)
+		}
+
+		if !slices.Contains(allowed, prefix.String()) {
+			allowed = append(allowed, prefix.String())
+		}
+	}
+
+	return allowed, nil
+}
+
+// AllowClient returns whether the client connecting to the server of the given protocol is accepted.
+// Refused clients are logged and reported with a ClientRejected event.
+func (bridge *Bridge) AllowClient(protocol string, remoteAddr net.Addr) bool {
+	return bridge.allowClient(protocol, bridge.vault.GetLANAccess().AllowedClients, remoteAddr)
+}
+
+func (bridge *Bridge) allowClient(protocol string, clients []string, remoteAddr net.Addr) bool {
+	allowed := make([]netip.Prefix, 0, len(clients))
+
+	for _, client := range clients {
+		if prefix, err := network.ParseAllowedClient(client); err == nil {
+			allowed = append(allowed, prefix)
+		}
+	}
+
+	if network.IsAllowedClient(remoteAddr, allowed) {
+		return true
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"protocol":   protocol,
+		"remoteAddr": remoteAddr.String(),
+	}).Warn("Rejected client missing from the allowlist")
+
+	bridge.publish(events.ClientRejected{
+		Protocol:   protocol,
+		RemoteAddr: remoteAddr.String(),
+	})
+
+	return false
+}
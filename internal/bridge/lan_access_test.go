@@ -0,0 +1,95 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/go-proton-api/server"
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
+	"github.com/ProtonMail/proton-bridge/v3/internal/constants"
+	"github.com/ProtonMail/proton-bridge/v3/internal/events"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBridge_LANAccess_AllowedClients(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			rejectedCh, done := b.GetEvents(events.ClientRejected{})
+			defer done()
+
+			remote := &net.TCPAddr{IP: net.ParseIP("192.168.1.20"), Port: 50000}
+
+			// Only loopback clients are allowed by default.
+			require.True(t, b.AllowClient("IMAP", &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 50000}))
+			require.False(t, b.AllowClient("IMAP", remote))
+			require.Equal(t, events.ClientRejected{Protocol: "IMAP", RemoteAddr: remote.String()}, <-rejectedCh)
+
+			// Invalid clients are refused.
+			require.ErrorIs(t, b.SetAllowedClients([]string{"192.168.1.0/24", "my-laptop"}), bridge.ErrInvalidAllowedClient)
+
+			// Allowed clients are stored as CIDRs.
+			require.NoError(t, b.SetAllowedClients([]string{"192.168.1.7/24", "10.0.0.5"}))
+
+			_, clients := b.GetLANAccess()
+			require.Equal(t, []string{"192.168.1.0/24", "10.0.0.5/32"}, clients)
+
+			require.True(t, b.AllowClient("SMTP", remote))
+			require.False(t, b.AllowClient("SMTP", &net.TCPAddr{IP: net.ParseIP("10.0.0.6"), Port: 50000}))
+		})
+	})
+}
+
+func TestBridge_LANAccess_Enabled(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			// The servers run once a user is logged in.
+			readyCh, done := b.GetEvents(events.IMAPServerReady{}, events.SMTPServerReady{})
+			defer done()
+
+			must(b.LoginFull(ctx, username, password, nil, nil))
+			waitForEvent(t, readyCh, events.IMAPServerReady{})
+			waitForEvent(t, readyCh, events.SMTPServerReady{})
+
+			// Enabling LAN access restarts the servers on all interfaces.
+			require.NoError(t, b.SetLANAccessEnabled(ctx, true))
+
+			waitForEvent(t, readyCh, events.IMAPServerReady{})
+			waitForEvent(t, readyCh, events.SMTPServerReady{})
+
+			enabled, _ := b.GetLANAccess()
+			require.True(t, enabled)
+
+			// Mail clients still connect to localhost, which is always allowed.
+			require.Equal(t, constants.Host, b.GetHost())
+
+			for _, port := range []int{b.GetIMAPPort(), b.GetSMTPPort()} {
+				conn, err := net.Dial("tcp", fmt.Sprintf("%v:%v", constants.Host, port))
+				require.NoError(t, err)
+
+				_, err = conn.Read(make([]byte, 1))
+				require.NoError(t, err)
+				require.NoError(t, conn.Close())
+			}
+		})
+	})
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge_test
+
+import (
+	"context"
+	"io/fs"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/go-proton-api/server"
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
+	"github.com/ProtonMail/proton-bridge/v3/internal/constants"
+	"github.com/emersion/go-smtp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBridge_LMTP(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		userID, _, err := s.CreateUser("user", password)
+		require.NoError(t, err)
+
+		folderID, err := s.CreateLabel(userID, "Work", "", proton.LabelTypeFolder)
+		require.NoError(t, err)
+
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			userLoginAndSync(ctx, t, b, "user", password)
+
+			info, err := b.QueryUserInfo("user")
+			require.NoError(t, err)
+
+			// The LMTP server is disabled by default.
+			require.False(t, b.GetLMTPEnabled())
+			require.NoError(t, b.SetLMTPEnabled(ctx, true))
+
+			path, err := b.GetLMTPSocketPath()
+			require.NoError(t, err)
+
+			var conn net.Conn
+
+			require.Eventually(t, func() bool {
+				conn, err = net.Dial("unix", path)
+				return err == nil
+			}, 5*time.Second, 100*time.Millisecond)
+
+			// Only the user can connect to the socket.
+			stat, err := os.Stat(path)
+			require.NoError(t, err)
+			require.Equal(t, fs.FileMode(0o600), stat.Mode().Perm())
+
+			client, err := smtp.NewClientLMTP(conn, constants.Host)
+			require.NoError(t, err)
+			defer client.Close() //nolint:errcheck
+
+			require.NoError(t, client.Hello("localhost"))
+			require.NoError(t, client.Mail("sender@example.com", nil))
+
+			// Recipients are the users' addresses, optionally with a folder as subaddress.
+			inboxRcpt := info.Addresses[0]
+			folderRcpt := strings.Replace(info.Addresses[0], "@", "+Folders/Work@", 1)
+
+			require.NoError(t, client.Rcpt(inboxRcpt))
+			require.NoError(t, client.Rcpt(folderRcpt))
+			require.Error(t, client.Rcpt("nobody@"+s.GetDomain()))
+			require.Error(t, client.Rcpt(strings.Replace(info.Addresses[0], "@", "+NoSuchFolder@", 1)))
+
+			statuses := make(map[string]*smtp.SMTPError)
+
+			w, err := client.LMTPData(func(rcpt string, status *smtp.SMTPError) { statuses[rcpt] = status })
+			require.NoError(t, err)
+
+			_, err = w.Write([]byte("From: sender@example.com\r\nTo: " + info.Addresses[0] + "\r\nSubject: Delivered\r\n\r\nHello\r\n"))
+			require.NoError(t, err)
+			require.NoError(t, w.Close())
+
+			require.Equal(t, map[string]*smtp.SMTPError{inboxRcpt: nil, folderRcpt: nil}, statuses)
+
+			// The message is imported as unread received mail into each folder.
+			withClient(ctx, t, s, "user", password, func(ctx context.Context, c *proton.Client) {
+				for _, labelID := range []string{proton.InboxLabel, folderID} {
+					metadata, err := c.GetMessageMetadataPage(ctx, 0, 10, proton.MessageFilter{LabelID: labelID})
+					require.NoError(t, err)
+					require.Len(t, metadata, 1)
+					require.Equal(t, "Delivered", metadata[0].Subject)
+					require.True(t, metadata[0].Flags.Has(proton.MessageFlagReceived))
+					require.True(t, bool(metadata[0].Unread))
+				}
+			})
+
+			// Disabling the server stops the listener and removes the socket.
+			require.NoError(t, b.SetLMTPEnabled(ctx, false))
+
+			_, err = net.Dial("unix", path)
+			require.Error(t, err)
+			require.NoFileExists(t, path)
+		})
+	})
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"fmt"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/events"
+	"github.com/ProtonMail/proton-bridge/v3/internal/mailto"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+	"github.com/sirupsen/logrus"
+)
+
+// DetectMailClients returns the supported mail clients installed on the system, to which accounts can be bound.
+func (bridge *Bridge) DetectMailClients() []mailto.InstalledClient {
+	return mailto.DetectClients()
+}
+
+// GetUserMailClient returns the mail client the given user's account is bound to; its Client is empty if none is.
+func (bridge *Bridge) GetUserMailClient(userID string) (vault.MailClient, error) {
+	var client vault.MailClient
+
+	if err := bridge.vault.GetUser(userID, func(user *vault.User) {
+		client = user.MailClient()
+	}); err != nil {
+		return vault.MailClient{}, ErrNoSuchUser
+	}
+
+	return client, nil
+}
+
+// SetUserMailClient binds the given user's account to a mail client, one of mailto.Clients, with the executable and
+// profile it is set up in, so that frontends open it with LaunchClient, e.g. after setup or from a notification.
+// An empty Client unbinds the account.
+func (bridge *Bridge) SetUserMailClient(userID string, client vault.MailClient) error {
+	logrus.WithField("userID", userID).WithField("client", client.Client).Info("Setting user mail client")
+
+	if client.Client != "" {
+		parsed, err := mailto.ParseClient(client.Client)
+		if err != nil {
+			return err
+		}
+
+		client.Client = string(parsed)
+	} else {
+		client = vault.MailClient{}
+	}
+
+	var err error
+
+	if getErr := bridge.vault.GetUser(userID, func(user *vault.User) {
+		err = user.SetMailClient(client)
+	}); getErr != nil {
+		return ErrNoSuchUser
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to set mail client: %w", err)
+	}
+
+	bridge.publish(events.UserChanged{UserID: userID})
+
+	return nil
+}
+
+// LaunchClient launches the mail client the given user's account is bound to, with its profile. If it isn't bound
+// to any, the client composing the mailto: URLs is launched with its default profile.
+func (bridge *Bridge) LaunchClient(userID string) error {
+	client, err := bridge.GetUserMailClient(userID)
+	if err != nil {
+		return err
+	}
+
+	if client.Client == "" {
+		settings := bridge.vault.GetMailto()
+
+		client = vault.MailClient{Client: settings.Client, Path: settings.ClientPath}
+	}
+
+	if client.Client == "" {
+		return ErrNoMailClient
+	}
+
+	logrus.WithField("userID", userID).WithField("client", client.Client).Info("Launching mail client")
+
+	return mailto.Launch(mailto.Client(client.Client), client.Path, client.Profile)
+}
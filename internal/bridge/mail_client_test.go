@@ -0,0 +1,85 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge_test
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/go-proton-api/server"
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
+	"github.com/ProtonMail/proton-bridge/v3/internal/events"
+	"github.com/ProtonMail/proton-bridge/v3/internal/mailto"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBridge_MailClient(t *testing.T) {
+	// A command which exits right away stands in for the mail client.
+	exe, err := exec.LookPath("true")
+	require.NoError(t, err)
+
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			userID := must(b.LoginFull(ctx, username, password, nil, nil))
+
+			// No mail client is bound by default, nor set for mailto URLs.
+			client, err := b.GetUserMailClient(userID)
+			require.NoError(t, err)
+			require.Equal(t, vault.MailClient{}, client)
+			require.ErrorIs(t, b.LaunchClient(userID), bridge.ErrNoMailClient)
+
+			// Unknown clients and users are rejected.
+			require.ErrorIs(t, b.SetUserMailClient(userID, vault.MailClient{Client: "pine"}), mailto.ErrUnknownClient)
+			require.ErrorIs(t, b.SetUserMailClient("unknown", vault.MailClient{}), bridge.ErrNoSuchUser)
+			require.ErrorIs(t, b.LaunchClient("unknown"), bridge.ErrNoSuchUser)
+
+			// Binding the account to a client publishes a UserChanged event.
+			changedCh, done := chToType[events.Event, events.UserChanged](b.GetEvents(events.UserChanged{}))
+			defer done()
+
+			require.NoError(t, b.SetUserMailClient(userID, vault.MailClient{Client: "Thunderbird", Path: exe, Profile: "work"}))
+			require.Equal(t, userID, (<-changedCh).UserID)
+
+			client, err = b.GetUserMailClient(userID)
+			require.NoError(t, err)
+			require.Equal(t, vault.MailClient{Client: "thunderbird", Path: exe, Profile: "work"}, client)
+
+			// The bound client is launched.
+			require.NoError(t, b.LaunchClient(userID))
+
+			// A client which isn't installed can't be launched.
+			require.NoError(t, b.SetUserMailClient(userID, vault.MailClient{Client: "thunderbird", Path: filepath.Join(t.TempDir(), "thunderbird")}))
+			require.Error(t, b.LaunchClient(userID))
+
+			// Unbinding the account clears the path and profile too.
+			require.NoError(t, b.SetUserMailClient(userID, vault.MailClient{Path: exe, Profile: "work"}))
+
+			client, err = b.GetUserMailClient(userID)
+			require.NoError(t, err)
+			require.Equal(t, vault.MailClient{}, client)
+
+			// The client composing the mailto URLs is launched for accounts which aren't bound to any.
+			require.NoError(t, b.SetMailtoClient("evolution", exe))
+			require.NoError(t, b.LaunchClient(userID))
+		})
+	})
+}
@@ -0,0 +1,181 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/mailto"
+	"github.com/ProtonMail/proton-bridge/v3/internal/safe"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+	"github.com/bradenaw/juniper/xslices"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/exp/slices"
+)
+
+// IsMailtoHandler returns whether bridge is the default handler of mailto: URLs.
+func (bridge *Bridge) IsMailtoHandler() bool {
+	return bridge.mailtoHandler.IsRegistered()
+}
+
+// SetMailtoHandler registers or unregisters bridge as the handler of mailto: URLs. Depending on the platform,
+// the user may still have to pick bridge as their default handler.
+func (bridge *Bridge) SetMailtoHandler(enabled bool) error {
+	logrus.WithField("enabled", enabled).Info("Setting mailto handler")
+
+	if enabled {
+		return bridge.mailtoHandler.Register()
+	}
+
+	return bridge.mailtoHandler.Unregister()
+}
+
+// GetMailtoSettings returns how the mailto: URLs passed to bridge are composed.
+func (bridge *Bridge) GetMailtoSettings() vault.Mailto {
+	return bridge.vault.GetMailto()
+}
+
+// SetMailtoClient sets the mail client, one of mailto.Clients, whose compose window is opened for the mailto: URLs
+// passed to bridge, and its executable; the client's usual command is run if empty. None is opened if client is empty.
+func (bridge *Bridge) SetMailtoClient(client, path string) error {
+	if client != "" {
+		parsed, err := mailto.ParseClient(client)
+		if err != nil {
+			return err
+		}
+
+		client = string(parsed)
+	}
+
+	return bridge.modMailtoSettings(func(settings *vault.Mailto) {
+		settings.Client = client
+		settings.ClientPath = path
+	})
+}
+
+// SetMailtoRule creates or replaces the rule sending the messages of the mailto: URLs with a recipient matching
+// the given pattern, e.g. someone@example.com or *@example.com, from the given address of one of the users.
+func (bridge *Bridge) SetMailtoRule(pattern, address string) error {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+
+	if _, err := path.Match(pattern, ""); err != nil || !strings.Contains(pattern, "@") {
+		return fmt.Errorf("%w: %q", ErrInvalidMailtoRule, pattern)
+	}
+
+	if !bridge.hasAddress(address) {
+		return fmt.Errorf("%w: %q", ErrNoSuchAddress, address)
+	}
+
+	return bridge.modMailtoSettings(func(settings *vault.Mailto) {
+		if idx := xslices.IndexFunc(settings.Rules, func(rule vault.MailtoRule) bool { return rule.Pattern == pattern }); idx < 0 {
+			settings.Rules = append(settings.Rules, vault.MailtoRule{Pattern: pattern, Address: address})
+		} else {
+			settings.Rules[idx].Address = address
+		}
+	})
+}
+
+// DeleteMailtoRule removes the rule for the given recipient pattern.
+func (bridge *Bridge) DeleteMailtoRule(pattern string) error {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+
+	settings := bridge.vault.GetMailto()
+
+	idx := xslices.IndexFunc(settings.Rules, func(rule vault.MailtoRule) bool { return rule.Pattern == pattern })
+	if idx < 0 {
+		return ErrNoSuchMailtoRule
+	}
+
+	settings.Rules = slices.Delete(settings.Rules, idx, idx+1)
+
+	return bridge.vault.SetMailto(settings)
+}
+
+// GetMailtoSender returns the address sending the message of a mailto: URL: that of the first rule matching one of
+// its recipients or, if none does, the primary address of the first user.
+func (bridge *Bridge) GetMailtoSender(msg mailto.Message) (string, error) {
+	for _, rule := range bridge.vault.GetMailto().Rules {
+		for _, recipient := range msg.Recipients() {
+			if ok, err := path.Match(rule.Pattern, strings.ToLower(recipient)); err == nil && ok {
+				return rule.Address, nil
+			}
+		}
+	}
+
+	for _, userID := range bridge.GetUserIDs() {
+		var address string
+
+		if err := bridge.vault.GetUser(userID, func(user *vault.User) {
+			address = user.PrimaryEmail()
+		}); err != nil {
+			return "", fmt.Errorf("failed to get user: %w", err)
+		}
+
+		if address != "" {
+			return address, nil
+		}
+	}
+
+	return "", ErrNoSuchUser
+}
+
+// HandleMailto opens the compose window of the mail client set with SetMailtoClient with the message of
+// the given mailto: URL, sent from the address picked by GetMailtoSender.
+func (bridge *Bridge) HandleMailto(rawURL string) error {
+	msg, err := mailto.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+
+	settings := bridge.vault.GetMailto()
+	if settings.Client == "" {
+		return ErrNoMailtoClient
+	}
+
+	from, err := bridge.GetMailtoSender(msg)
+	if err != nil {
+		return err
+	}
+
+	logrus.WithField("client", settings.Client).Info("Composing message of mailto URL")
+
+	return mailto.Compose(mailto.Client(settings.Client), settings.ClientPath, from, msg)
+}
+
+func (bridge *Bridge) modMailtoSettings(fn func(*vault.Mailto)) error {
+	settings := bridge.vault.GetMailto()
+
+	fn(&settings)
+
+	return bridge.vault.SetMailto(settings)
+}
+
+// hasAddress returns whether the address is one of the users' addresses.
+func (bridge *Bridge) hasAddress(address string) bool {
+	return safe.RLockRet(func() bool {
+		for _, user := range bridge.users {
+			if slices.ContainsFunc(user.Emails(), func(email string) bool { return strings.EqualFold(email, address) }) {
+				return true
+			}
+		}
+
+		return false
+	}, bridge.usersLock)
+}
@@ -0,0 +1,125 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/go-proton-api/server"
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
+	"github.com/ProtonMail/proton-bridge/v3/internal/mailto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBridge_MailtoHandler(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, mocks *bridge.Mocks) {
+			mocks.MailtoHandler.EXPECT().Register().Return(nil)
+			mocks.MailtoHandler.EXPECT().IsRegistered().Return(true)
+			require.NoError(t, b.SetMailtoHandler(true))
+			require.True(t, b.IsMailtoHandler())
+
+			mocks.MailtoHandler.EXPECT().Unregister().Return(nil)
+			mocks.MailtoHandler.EXPECT().IsRegistered().Return(false)
+			require.NoError(t, b.SetMailtoHandler(false))
+			require.False(t, b.IsMailtoHandler())
+		})
+	})
+}
+
+func TestBridge_MailtoClient(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			// No client is set by default.
+			require.Empty(t, b.GetMailtoSettings().Client)
+			require.ErrorIs(t, b.HandleMailto("mailto:someone@example.com"), bridge.ErrNoMailtoClient)
+
+			// Unknown clients are rejected.
+			require.ErrorIs(t, b.SetMailtoClient("pigeon", ""), mailto.ErrUnknownClient)
+
+			// Known clients are stored with their path.
+			require.NoError(t, b.SetMailtoClient("Thunderbird", "/opt/thunderbird/thunderbird"))
+			require.Equal(t, string(mailto.ClientThunderbird), b.GetMailtoSettings().Client)
+			require.Equal(t, "/opt/thunderbird/thunderbird", b.GetMailtoSettings().ClientPath)
+
+			// Invalid URLs are rejected.
+			require.ErrorIs(t, b.HandleMailto("https://example.com"), mailto.ErrInvalidURL)
+
+			// The client can be cleared.
+			require.NoError(t, b.SetMailtoClient("", ""))
+			require.Empty(t, b.GetMailtoSettings().Client)
+		})
+	})
+}
+
+func TestBridge_MailtoSender(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		userID, _, err := s.CreateUser("mailto", password)
+		require.NoError(t, err)
+
+		alias := "alias@" + s.GetDomain()
+
+		_, err = s.CreateAddress(userID, alias, password)
+		require.NoError(t, err)
+
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			// Without users, there is no address to send from.
+			_, err := b.GetMailtoSender(mailto.Message{To: []string{"someone@example.com"}})
+			require.ErrorIs(t, err, bridge.ErrNoSuchUser)
+
+			userID := must(b.LoginFull(ctx, "mailto", password, nil, nil))
+
+			info, err := b.GetUserInfo(userID)
+			require.NoError(t, err)
+
+			// Without rules, the primary address is used.
+			sender, err := b.GetMailtoSender(mailto.Message{To: []string{"someone@example.com"}})
+			require.NoError(t, err)
+			require.Equal(t, info.Addresses[0], sender)
+
+			// Rules must match addresses and send from one of the users' addresses.
+			require.ErrorIs(t, b.SetMailtoRule("example.com", alias), bridge.ErrInvalidMailtoRule)
+			require.ErrorIs(t, b.SetMailtoRule("[@example.com", alias), bridge.ErrInvalidMailtoRule)
+			require.ErrorIs(t, b.SetMailtoRule("*@example.com", "unknown@example.com"), bridge.ErrNoSuchAddress)
+
+			// Recipients matching a rule are sent from its address.
+			require.NoError(t, b.SetMailtoRule("*@Example.com", alias))
+			require.Len(t, b.GetMailtoSettings().Rules, 1)
+
+			sender, err = b.GetMailtoSender(mailto.Message{To: []string{"other@proton.test"}, Cc: []string{"Someone@example.com"}})
+			require.NoError(t, err)
+			require.Equal(t, alias, sender)
+
+			sender, err = b.GetMailtoSender(mailto.Message{To: []string{"other@proton.test"}})
+			require.NoError(t, err)
+			require.Equal(t, info.Addresses[0], sender)
+
+			// Setting the rule again replaces it.
+			require.NoError(t, b.SetMailtoRule("*@example.com", info.Addresses[0]))
+			require.Len(t, b.GetMailtoSettings().Rules, 1)
+			require.Equal(t, info.Addresses[0], b.GetMailtoSettings().Rules[0].Address)
+
+			// Rules can be deleted.
+			require.NoError(t, b.DeleteMailtoRule("*@example.com"))
+			require.ErrorIs(t, b.DeleteMailtoRule("*@example.com"), bridge.ErrNoSuchMailtoRule)
+			require.Empty(t, b.GetMailtoSettings().Rules)
+		})
+	})
+}
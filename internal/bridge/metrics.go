@@ -0,0 +1,134 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"time"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/telemetry/metrics"
+	"github.com/sirupsen/logrus"
+)
+
+// GetMetricsListener returns the host:port the Prometheus metrics are served on, or an empty string if they aren't.
+func (bridge *Bridge) GetMetricsListener() string {
+	return bridge.vault.GetMetricsListener()
+}
+
+// SetMetricsListener serves the Prometheus metrics of bridge internals, such as sync progress, API latencies and
+// connection counts, on http://<address>/metrics; an empty address stops serving them, which is the default.
+// The address is a host:port, e.g. 127.0.0.1:9154; the metrics are served to anyone who can reach it.
+func (bridge *Bridge) SetMetricsListener(address string) error {
+	if address != "" {
+		if err := validateMetricsListener(address); err != nil {
+			return err
+		}
+	}
+
+	if err := bridge.vault.SetMetricsListener(address); err != nil {
+		return err
+	}
+
+	// The metrics aren't served in safe mode; they are once bridge starts normally.
+	if bridge.safeMode != nil {
+		return nil
+	}
+
+	return bridge.applyMetricsListener(address)
+}
+
+// GetMetricsURL returns the URL the Prometheus metrics are served on, or an empty string if they aren't.
+func (bridge *Bridge) GetMetricsURL() string {
+	bridge.metricsServerLock.Lock()
+	defer bridge.metricsServerLock.Unlock()
+
+	if bridge.metricsListener == nil {
+		return ""
+	}
+
+	return "http://" + bridge.metricsListener.Addr().String() + "/metrics"
+}
+
+func (bridge *Bridge) applyMetricsListener(address string) error {
+	bridge.metricsServerLock.Lock()
+	defer bridge.metricsServerLock.Unlock()
+
+	if bridge.metricsServer != nil {
+		if err := bridge.metricsServer.Close(); err != nil {
+			logrus.WithError(err).Warn("Failed to stop serving metrics")
+		}
+
+		bridge.metricsServer = nil
+		bridge.metricsListener = nil
+	}
+
+	if address == "" {
+		return nil
+	}
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return fmt.Errorf("failed to listen for metrics: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Default)
+
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	bridge.tasks.Once(func(context.Context) {
+		if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+			logrus.WithError(err).Error("Metrics server stopped")
+		}
+	})
+
+	bridge.metricsServer = server
+	bridge.metricsListener = listener
+
+	log := logrus.WithField("address", listener.Addr().String())
+
+	if addrPort, err := netip.ParseAddrPort(listener.Addr().String()); err != nil || !addrPort.Addr().IsLoopback() {
+		log.Warn("Serving metrics beyond this computer")
+	} else {
+		log.Info("Serving metrics")
+	}
+
+	return nil
+}
+
+func validateMetricsListener(address string) error {
+	_, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMetricsListener, err)
+	}
+
+	if port, err := strconv.Atoi(port); err != nil || port < 0 || port > 65535 {
+		return fmt.Errorf("%w: invalid port %q", ErrInvalidMetricsListener, port)
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/go-proton-api/server"
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
+	"github.com/ProtonMail/proton-bridge/v3/internal/constants"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBridge_Metrics(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			// The metrics aren't served by default.
+			require.Empty(t, b.GetMetricsListener())
+			require.Empty(t, b.GetMetricsURL())
+
+			// Addresses must be a host:port.
+			require.ErrorIs(t, b.SetMetricsListener("127.0.0.1"), bridge.ErrInvalidMetricsListener)
+			require.ErrorIs(t, b.SetMetricsListener("127.0.0.1:http"), bridge.ErrInvalidMetricsListener)
+			require.Empty(t, b.GetMetricsListener())
+
+			require.NoError(t, b.SetMetricsListener("127.0.0.1:0"))
+			require.NotEmpty(t, b.GetMetricsURL())
+
+			userLoginAndSync(ctx, t, b, username, password)
+
+			client, err := eventuallyDial(fmt.Sprintf("%v:%v", constants.Host, b.GetIMAPPort()))
+			require.NoError(t, err)
+			defer func() { _ = client.Logout() }()
+
+			// The metrics cover the sync, the API requests and the connections.
+			body := getMetrics(t, b.GetMetricsURL())
+			require.Contains(t, body, "# TYPE bridge_syncs_finished_total counter")
+			require.Contains(t, body, `bridge_api_request_duration_seconds_count{method="POST",service="auth",status="200"}`)
+			require.Contains(t, body, `bridge_open_connections{protocol="imap"}`)
+			require.Contains(t, body, "# TYPE bridge_event_loop_errors_total counter")
+
+			// Stop serving them.
+			url := b.GetMetricsURL()
+
+			require.NoError(t, b.SetMetricsListener(""))
+			require.Empty(t, b.GetMetricsURL())
+
+			_, err = http.Get(url) //nolint:bodyclose,noctx
+			require.Error(t, err)
+
+			require.NoError(t, b.SetMetricsListener("127.0.0.1:0"))
+		})
+
+		// They are served again in the next session, until stopped.
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			require.Equal(t, "127.0.0.1:0", b.GetMetricsListener())
+			require.Contains(t, getMetrics(t, b.GetMetricsURL()), "bridge_syncs_started_total")
+		})
+	})
+}
+
+func getMetrics(t *testing.T, url string) string {
+	res, err := http.Get(url) //nolint:noctx
+	require.NoError(t, err)
+	defer func() { require.NoError(t, res.Body.Close()) }()
+
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	return string(body)
+}
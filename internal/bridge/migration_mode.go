@@ -0,0 +1,58 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/safe"
+	"github.com/sirupsen/logrus"
+)
+
+// GetMigrationMode returns whether the message imports of the given user are tuned for bulk APPEND workloads.
+func (bridge *Bridge) GetMigrationMode(userID string) (bool, error) {
+	return safe.RLockRetErr(func() (bool, error) {
+		user, ok := bridge.users[userID]
+		if !ok {
+			return false, ErrNoSuchUser
+		}
+
+		return user.GetMigrationMode(), nil
+	}, bridge.usersLock)
+}
+
+// SetMigrationMode sets whether the message imports of the given user are tuned for bulk APPEND workloads,
+// such as migrations with imapsync. Progress is reported with events.UserMigrationProgress events
+// and messages which could not be imported with events.UserMigrationFailed events.
+func (bridge *Bridge) SetMigrationMode(ctx context.Context, userID string, enabled bool) error {
+	logrus.WithField("userID", userID).WithField("enabled", enabled).Info("Setting migration mode")
+
+	return safe.RLockRet(func() error {
+		user, ok := bridge.users[userID]
+		if !ok {
+			return ErrNoSuchUser
+		}
+
+		if err := user.SetMigrationMode(ctx, enabled); err != nil {
+			return fmt.Errorf("failed to set migration mode: %w", err)
+		}
+
+		return nil
+	}, bridge.usersLock)
+}
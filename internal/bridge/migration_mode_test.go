@@ -0,0 +1,88 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/go-proton-api/server"
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
+	"github.com/ProtonMail/proton-bridge/v3/internal/constants"
+	"github.com/ProtonMail/proton-bridge/v3/internal/events"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBridge_MigrationMode(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		_, _, err := s.CreateUser("user", password)
+		require.NoError(t, err)
+
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			userLoginAndSync(ctx, t, b, "user", password)
+
+			info, err := b.QueryUserInfo("user")
+			require.NoError(t, err)
+
+			progressCh, done := b.GetEvents(events.UserMigrationProgress{})
+			defer done()
+
+			require.NoError(t, b.SetMigrationMode(ctx, info.UserID, true))
+
+			enabled, err := b.GetMigrationMode(info.UserID)
+			require.NoError(t, err)
+			require.True(t, enabled)
+
+			client, err := eventuallyDial(fmt.Sprintf("%v:%v", constants.Host, b.GetIMAPPort()))
+			require.NoError(t, err)
+			require.NoError(t, client.Login(info.Addresses[0], string(info.BridgePass)))
+			defer func() { _ = client.Logout() }()
+
+			literal := "From: sender@pm.me\r\nTo: user@pm.me\r\nDate: Mon, 02 Jan 2006 15:04:05 +0000\r\nSubject: migrated\r\n\r\nbody"
+
+			// Appending the same message twice, e.g. when a migration is restarted, imports it only once.
+			require.NoError(t, client.Append("INBOX", nil, time.Now(), strings.NewReader(literal)))
+			require.NoError(t, client.Append("INBOX", nil, time.Now(), strings.NewReader(literal)))
+
+			withClient(ctx, t, s, "user", password, func(ctx context.Context, c *proton.Client) {
+				require.Eventually(t, func() bool {
+					inbox, err := c.GetMessageMetadataPage(ctx, 0, 10, proton.MessageFilter{LabelID: proton.InboxLabel})
+					return err == nil && len(inbox) == 1
+				}, 10*time.Second, 100*time.Millisecond)
+
+				all, err := c.GetMessageMetadataPage(ctx, 0, 10, proton.MessageFilter{})
+				require.NoError(t, err)
+				require.Len(t, all, 1)
+			})
+
+			// Disabling migration mode reports the final progress.
+			require.NoError(t, b.SetMigrationMode(ctx, info.UserID, false))
+
+			progress, ok := (<-progressCh).(events.UserMigrationProgress)
+			require.True(t, ok)
+			require.Equal(t, info.UserID, progress.UserID)
+			require.Equal(t, 1, progress.Imported)
+			require.Equal(t, 1, progress.Duplicates)
+			require.Zero(t, progress.Failed)
+		})
+	})
+}
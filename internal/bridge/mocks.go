@@ -7,6 +7,7 @@ import (
 	"os"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/ProtonMail/proton-bridge/v3/internal/bridge/mocks"
@@ -19,8 +20,9 @@ type Mocks struct {
 	TLSReporter *mocks.MockTLSReporter
 	TLSIssueCh  chan struct{}
 
-	Updater     *TestUpdater
-	Autostarter *mocks.MockAutostarter
+	Updater       *TestUpdater
+	Autostarter   *mocks.MockAutostarter
+	MailtoHandler *mocks.MockMailtoHandler
 
 	CrashHandler *mocks.MockPanicHandler
 	Reporter     *mocks.MockReporter
@@ -35,8 +37,9 @@ func NewMocks(tb testing.TB, version, minAuto *semver.Version) *Mocks {
 		TLSReporter: mocks.NewMockTLSReporter(ctl),
 		TLSIssueCh:  make(chan struct{}),
 
-		Updater:     NewTestUpdater(version, minAuto),
-		Autostarter: mocks.NewMockAutostarter(ctl),
+		Updater:       NewTestUpdater(version, minAuto),
+		Autostarter:   mocks.NewMockAutostarter(ctl),
+		MailtoHandler: mocks.NewMockMailtoHandler(ctl),
 
 		CrashHandler: mocks.NewMockPanicHandler(ctl),
 		Reporter:     mocks.NewMockReporter(ctl),
@@ -46,6 +49,10 @@ func NewMocks(tb testing.TB, version, minAuto *semver.Version) *Mocks {
 	// When getting the TLS issue channel, we want to return the test channel.
 	mocks.TLSReporter.EXPECT().GetTLSIssueCh().Return(mocks.TLSIssueCh).AnyTimes()
 
+	// The resolver and Tor settings are applied at startup and whenever they change.
+	mocks.ProxyCtl.EXPECT().SetResolverSettings(gomock.Any()).AnyTimes()
+	mocks.ProxyCtl.EXPECT().SetTorSettings(gomock.Any()).AnyTimes()
+
 	// This is called at the end of any go-routine:
 	mocks.CrashHandler.EXPECT().HandlePanic(gomock.Any()).AnyTimes()
 
@@ -151,6 +158,6 @@ func (testUpdater *TestUpdater) GetVersionInfo(_ context.Context, _ updater.Down
 	return testUpdater.latest, nil
 }
 
-func (testUpdater *TestUpdater) InstallUpdate(_ context.Context, _ updater.Downloader, _ updater.VersionInfo) error {
-	return nil
+func (testUpdater *TestUpdater) InstallUpdate(_ context.Context, _ updater.Downloader, version updater.VersionInfo) (updater.Verification, error) {
+	return updater.Verification{Version: version.Version.String(), VerifiedAt: time.Now()}, nil
 }
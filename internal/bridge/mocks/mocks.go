@@ -1,12 +1,14 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: github.com/ProtonMail/proton-bridge/v3/internal/bridge (interfaces: TLSReporter,ProxyController,Autostarter)
+// Source: github.com/ProtonMail/proton-bridge/v3/internal/bridge (interfaces: TLSReporter,ProxyController,Autostarter,MailtoHandler)
 
 // Package mocks is a generated GoMock package.
 package mocks
 
 import (
+	context "context"
 	reflect "reflect"
 
+	dialer "github.com/ProtonMail/proton-bridge/v3/internal/dialer"
 	gomock "github.com/golang/mock/gomock"
 )
 
@@ -82,6 +84,20 @@ func (mr *MockProxyControllerMockRecorder) AllowProxy() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AllowProxy", reflect.TypeOf((*MockProxyController)(nil).AllowProxy))
 }
 
+// CheckTorCircuit mocks base method.
+func (m *MockProxyController) CheckTorCircuit(arg0 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckTorCircuit", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CheckTorCircuit indicates an expected call of CheckTorCircuit.
+func (mr *MockProxyControllerMockRecorder) CheckTorCircuit(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckTorCircuit", reflect.TypeOf((*MockProxyController)(nil).CheckTorCircuit), arg0)
+}
+
 // DisallowProxy mocks base method.
 func (m *MockProxyController) DisallowProxy() {
 	m.ctrl.T.Helper()
@@ -94,6 +110,56 @@ func (mr *MockProxyControllerMockRecorder) DisallowProxy() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisallowProxy", reflect.TypeOf((*MockProxyController)(nil).DisallowProxy))
 }
 
+// NewTorCircuit mocks base method.
+func (m *MockProxyController) NewTorCircuit() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "NewTorCircuit")
+}
+
+// NewTorCircuit indicates an expected call of NewTorCircuit.
+func (mr *MockProxyControllerMockRecorder) NewTorCircuit() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewTorCircuit", reflect.TypeOf((*MockProxyController)(nil).NewTorCircuit))
+}
+
+// SetResolverSettings mocks base method.
+func (m *MockProxyController) SetResolverSettings(arg0 dialer.ResolverSettings) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetResolverSettings", arg0)
+}
+
+// SetResolverSettings indicates an expected call of SetResolverSettings.
+func (mr *MockProxyControllerMockRecorder) SetResolverSettings(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetResolverSettings", reflect.TypeOf((*MockProxyController)(nil).SetResolverSettings), arg0)
+}
+
+// SetTorSettings mocks base method.
+func (m *MockProxyController) SetTorSettings(arg0 dialer.TorSettings) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetTorSettings", arg0)
+}
+
+// SetTorSettings indicates an expected call of SetTorSettings.
+func (mr *MockProxyControllerMockRecorder) SetTorSettings(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTorSettings", reflect.TypeOf((*MockProxyController)(nil).SetTorSettings), arg0)
+}
+
+// TestResolver mocks base method.
+func (m *MockProxyController) TestResolver(arg0 context.Context, arg1 string) []dialer.LookupResult {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TestResolver", arg0, arg1)
+	ret0, _ := ret[0].([]dialer.LookupResult)
+	return ret0
+}
+
+// TestResolver indicates an expected call of TestResolver.
+func (mr *MockProxyControllerMockRecorder) TestResolver(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TestResolver", reflect.TypeOf((*MockProxyController)(nil).TestResolver), arg0, arg1)
+}
+
 // MockAutostarter is a mock of Autostarter interface.
 type MockAutostarter struct {
 	ctrl     *gomock.Controller
@@ -158,3 +224,68 @@ func (mr *MockAutostarterMockRecorder) IsEnabled() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsEnabled", reflect.TypeOf((*MockAutostarter)(nil).IsEnabled))
 }
+
+// MockMailtoHandler is a mock of MailtoHandler interface.
+type MockMailtoHandler struct {
+	ctrl     *gomock.Controller
+	recorder *MockMailtoHandlerMockRecorder
+}
+
+// MockMailtoHandlerMockRecorder is the mock recorder for MockMailtoHandler.
+type MockMailtoHandlerMockRecorder struct {
+	mock *MockMailtoHandler
+}
+
+// NewMockMailtoHandler creates a new mock instance.
+func NewMockMailtoHandler(ctrl *gomock.Controller) *MockMailtoHandler {
+	mock := &MockMailtoHandler{ctrl: ctrl}
+	mock.recorder = &MockMailtoHandlerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMailtoHandler) EXPECT() *MockMailtoHandlerMockRecorder {
+	return m.recorder
+}
+
+// IsRegistered mocks base method.
+func (m *MockMailtoHandler) IsRegistered() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsRegistered")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsRegistered indicates an expected call of IsRegistered.
+func (mr *MockMailtoHandlerMockRecorder) IsRegistered() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsRegistered", reflect.TypeOf((*MockMailtoHandler)(nil).IsRegistered))
+}
+
+// Register mocks base method.
+func (m *MockMailtoHandler) Register() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Register")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Register indicates an expected call of Register.
+func (mr *MockMailtoHandlerMockRecorder) Register() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Register", reflect.TypeOf((*MockMailtoHandler)(nil).Register))
+}
+
+// Unregister mocks base method.
+func (m *MockMailtoHandler) Unregister() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unregister")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Unregister indicates an expected call of Unregister.
+func (mr *MockMailtoHandlerMockRecorder) Unregister() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unregister", reflect.TypeOf((*MockMailtoHandler)(nil).Unregister))
+}
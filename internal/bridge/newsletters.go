@@ -0,0 +1,64 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"context"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/safe"
+	"github.com/ProtonMail/proton-bridge/v3/internal/user"
+)
+
+// GetNewsletters returns the mailing lists among the most recent received messages of the given user,
+// with the number, size and unread count of their messages and how to unsubscribe from them.
+func (bridge *Bridge) GetNewsletters(ctx context.Context, userID string) ([]user.Newsletter, error) {
+	return safe.RLockRetErr(func() ([]user.Newsletter, error) {
+		user, ok := bridge.users[userID]
+		if !ok {
+			return nil, ErrNoSuchUser
+		}
+
+		return user.GetNewsletters(ctx)
+	}, bridge.usersLock)
+}
+
+// ArchiveNewsletter moves the messages of the given newsletter of the given user from the Inbox to the Archive.
+// It returns the number of messages moved.
+func (bridge *Bridge) ArchiveNewsletter(ctx context.Context, userID, newsletterID string) (int, error) {
+	return safe.RLockRetErr(func() (int, error) {
+		user, ok := bridge.users[userID]
+		if !ok {
+			return 0, ErrNoSuchUser
+		}
+
+		return user.ArchiveNewsletter(ctx, newsletterID)
+	}, bridge.usersLock)
+}
+
+// LabelNewsletter labels the messages of the given newsletter of the given user with the label of the given name.
+// It returns the number of messages labelled.
+func (bridge *Bridge) LabelNewsletter(ctx context.Context, userID, newsletterID, label string) (int, error) {
+	return safe.RLockRetErr(func() (int, error) {
+		user, ok := bridge.users[userID]
+		if !ok {
+			return 0, ErrNoSuchUser
+		}
+
+		return user.LabelNewsletter(ctx, newsletterID, label)
+	}, bridge.usersLock)
+}
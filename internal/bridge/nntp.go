@@ -0,0 +1,132 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/safe"
+	"github.com/ProtonMail/proton-bridge/v3/internal/services/nntp"
+	"github.com/sirupsen/logrus"
+)
+
+// GetNNTPPort returns the port of the NNTP server, or zero if it's disabled.
+func (bridge *Bridge) GetNNTPPort() int {
+	return bridge.vault.GetNNTPPort()
+}
+
+// SetNNTPPort sets the port of the experimental NNTP server and restarts it; zero, the default, disables it.
+// The server only listens on localhost and lets news readers read the folders selected with SetNNTPFolders,
+// with the same credentials as IMAP.
+func (bridge *Bridge) SetNNTPPort(ctx context.Context, newPort int) error {
+	if newPort < 0 {
+		return ErrInvalidNNTPPort
+	}
+
+	if newPort == bridge.vault.GetNNTPPort() {
+		return nil
+	}
+
+	if err := bridge.vault.SetNNTPPort(newPort); err != nil {
+		return err
+	}
+
+	return bridge.restartNNTP(ctx)
+}
+
+// GetNNTPFolders returns the paths of the folders of the given user exposed as newsgroups.
+func (bridge *Bridge) GetNNTPFolders(userID string) ([]string, error) {
+	return safe.RLockRetErr(func() ([]string, error) {
+		user, ok := bridge.users[userID]
+		if !ok {
+			return nil, ErrNoSuchUser
+		}
+
+		return user.GetNNTPFolders(), nil
+	}, bridge.usersLock)
+}
+
+// SetNNTPFolders sets the paths of the folders of the given user exposed as newsgroups, e.g. Lists/golang-nuts.
+// It applies to the next sessions.
+func (bridge *Bridge) SetNNTPFolders(userID string, folders []string) error {
+	logrus.WithField("userID", userID).WithField("count", len(folders)).Info("Setting NNTP folders")
+
+	return safe.RLockRet(func() error {
+		user, ok := bridge.users[userID]
+		if !ok {
+			return ErrNoSuchUser
+		}
+
+		return user.SetNNTPFolders(folders)
+	}, bridge.usersLock)
+}
+
+func (bridge *Bridge) restartNNTP(ctx context.Context) error {
+	return bridge.serverManager.RestartNNTP(ctx)
+}
+
+type bridgeNNTPSettings struct {
+	b *Bridge
+}
+
+func (b *bridgeNNTPSettings) TLSConfig() *tls.Config {
+	return b.b.tlsConfig
+}
+
+// Port returns the port of the NNTP server, which is disabled in safe mode.
+func (b *bridgeNNTPSettings) Port() int {
+	if b.b.safeMode != nil {
+		return 0
+	}
+
+	return b.b.vault.GetNNTPPort()
+}
+
+// UseSSL returns whether the server speaks TLS from the first byte, which it only does if plaintext is refused.
+func (b *bridgeNNTPSettings) UseSSL() bool {
+	return b.b.vault.GetTLSPolicy().RequireTLS
+}
+
+func (b *bridgeNNTPSettings) AllowClient(addr net.Addr) bool {
+	return b.b.AllowClient("NNTP", addr)
+}
+
+func (b *bridgeNNTPSettings) Backend() nntp.Backend {
+	return &bridgeNNTPBackend{b: b.b}
+}
+
+type bridgeNNTPBackend struct {
+	b *Bridge
+}
+
+// Login signs in NNTP clients with the bridge credentials of one of the users' addresses.
+func (b *bridgeNNTPBackend) Login(_ context.Context, username string, password []byte) (nntp.Reader, error) {
+	return safe.RLockRetErr(func() (nntp.Reader, error) {
+		for _, user := range b.b.users {
+			if _, err := user.CheckAuth(username, password); err != nil {
+				continue
+			}
+
+			return user.NewNNTPReader(), nil
+		}
+
+		return nil, nntp.ErrInvalidCredentials
+	}, b.b.usersLock)
+}
@@ -0,0 +1,151 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge_test
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/go-proton-api/server"
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
+	"github.com/ProtonMail/proton-bridge/v3/internal/constants"
+	"github.com/ProtonMail/proton-bridge/v3/pkg/ports"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBridge_NNTP(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		userID, addrID, err := s.CreateUser("user", password)
+		require.NoError(t, err)
+
+		parentID, err := s.CreateLabel(userID, "Lists", "", proton.LabelTypeFolder)
+		require.NoError(t, err)
+
+		folderID, err := s.CreateLabel(userID, "Go", parentID, proton.LabelTypeFolder)
+		require.NoError(t, err)
+
+		withClient(ctx, t, s, "user", password, func(ctx context.Context, c *proton.Client) {
+			createMessages(ctx, t, c, addrID, folderID,
+				[]byte("From: alice@example.com\r\nSubject: Hello\r\n\r\nHello\r\n"),
+				[]byte("From: bob@example.com\r\nSubject: Re: [go] Hello\r\n\r\nHi\r\n"),
+			)
+		})
+
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			userLoginAndSync(ctx, t, b, "user", password)
+
+			info, err := b.GetUserInfo(userID)
+			require.NoError(t, err)
+
+			// The NNTP server is disabled and exposes no folders by default.
+			require.Zero(t, b.GetNNTPPort())
+			require.ErrorIs(t, b.SetNNTPPort(ctx, -1), bridge.ErrInvalidNNTPPort)
+
+			folders, err := b.GetNNTPFolders(userID)
+			require.NoError(t, err)
+			require.Empty(t, folders)
+
+			require.NoError(t, b.SetNNTPFolders(userID, []string{"Lists/Go", "Lists/Missing"}))
+
+			port := ports.FindFreePortFrom(1119)
+			require.NoError(t, b.SetNNTPPort(ctx, port))
+
+			addr := net.JoinHostPort(constants.Host, strconv.Itoa(port))
+
+			var conn net.Conn
+
+			require.Eventually(t, func() bool {
+				conn, err = net.Dial("tcp", addr)
+				return err == nil
+			}, 5*time.Second, 100*time.Millisecond)
+			defer conn.Close() //nolint:errcheck
+
+			r := bufio.NewReader(conn)
+
+			readLine := func() string {
+				line, err := r.ReadString('\n')
+				require.NoError(t, err)
+
+				return strings.TrimSuffix(line, "\r\n")
+			}
+
+			cmd := func(cmd string) string {
+				_, err := conn.Write([]byte(cmd + "\r\n"))
+				require.NoError(t, err)
+
+				return readLine()
+			}
+
+			readLines := func() []string {
+				var lines []string
+
+				for line := readLine(); line != "."; line = readLine() {
+					lines = append(lines, line)
+				}
+
+				return lines
+			}
+
+			require.True(t, strings.HasPrefix(readLine(), "201"))
+
+			// Clients sign in with the bridge password.
+			require.Equal(t, "381 password required", cmd("AUTHINFO USER "+info.Addresses[0]))
+			require.Equal(t, "281 authentication accepted", cmd("AUTHINFO PASS "+string(info.BridgePass)))
+
+			// Only the selected folders which exist are listed.
+			require.Equal(t, "215 list of newsgroups follows", cmd("LIST NEWSGROUPS"))
+			require.Equal(t, []string{"lists.go\tLists/Go"}, readLines())
+
+			require.True(t, strings.HasPrefix(cmd("GROUP lists.go"), "211 2 "))
+
+			// The reply references the first message of the thread.
+			require.Equal(t, "224 overview information follows", cmd("OVER 1-"))
+			overview := make(map[string][]string)
+
+			for _, line := range readLines() {
+				fields := strings.Split(line, "\t")
+				overview[fields[1]] = fields
+			}
+
+			require.Len(t, overview, 2)
+			require.Empty(t, overview["Hello"][5])
+			require.Equal(t, overview["Hello"][4], overview["Re: [go] Hello"][5])
+
+			replyID := overview["Re: [go] Hello"][4]
+
+			require.True(t, strings.HasSuffix(cmd("ARTICLE "+replyID), " "+replyID))
+			article := readLines()
+			require.Contains(t, article, "Subject: Re: [go] Hello")
+			require.Equal(t, "Hi", article[len(article)-1])
+
+			require.Equal(t, "205 bye", cmd("QUIT"))
+
+			// Disabling the server stops the listener.
+			require.NoError(t, b.SetNNTPPort(ctx, 0))
+
+			_, err = net.Dial("tcp", addr)
+			require.Error(t, err)
+		})
+	})
+}
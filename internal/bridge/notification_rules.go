@@ -0,0 +1,118 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/events"
+	"github.com/ProtonMail/proton-bridge/v3/internal/safe"
+	"github.com/ProtonMail/proton-bridge/v3/internal/user"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+	"github.com/sirupsen/logrus"
+)
+
+const webhookTimeout = 10 * time.Second
+
+// GetNotificationRules returns the new message notification rules of the given user.
+func (bridge *Bridge) GetNotificationRules(userID string) (vault.NotificationRules, error) {
+	return safe.RLockRetErr(func() (vault.NotificationRules, error) {
+		user, ok := bridge.users[userID]
+		if !ok {
+			return vault.NotificationRules{}, ErrNoSuchUser
+		}
+
+		return user.GetNotificationRules(), nil
+	}, bridge.usersLock)
+}
+
+// SetNotificationRules sets which new messages of the given user trigger a notification.
+func (bridge *Bridge) SetNotificationRules(ctx context.Context, userID string, rules vault.NotificationRules) error {
+	logrus.WithField("userID", userID).WithField("enabled", rules.Enabled).Info("Setting notification rules")
+
+	return safe.RLockRet(func() error {
+		user, ok := bridge.users[userID]
+		if !ok {
+			return ErrNoSuchUser
+		}
+
+		return user.SetNotificationRules(ctx, rules)
+	}, bridge.usersLock)
+}
+
+type webhookPayload struct {
+	UserID    string   `json:"userID"`
+	MessageID string   `json:"messageID"`
+	Sender    string   `json:"sender"`
+	Subject   string   `json:"subject"`
+	Mailboxes []string `json:"mailboxes"`
+}
+
+// handleUserMessageNotification posts the notification to the user's webhook, if one is configured.
+func (bridge *Bridge) handleUserMessageNotification(user *user.User, event events.UserMessageNotification) {
+	url := user.GetNotificationRules().Webhook
+	if url == "" {
+		return
+	}
+
+	bridge.tasks.Once(func(ctx context.Context) {
+		if err := postWebhook(ctx, url, webhookPayload{
+			UserID:    event.UserID,
+			MessageID: event.MessageID,
+			Sender:    event.Sender,
+			Subject:   event.Subject,
+			Mailboxes: event.Mailboxes,
+		}); err != nil {
+			logrus.WithError(err).WithField("userID", event.UserID).Warn("Failed to post notification webhook")
+		}
+	})
+}
+
+func postWebhook(ctx context.Context, url string, payload webhookPayload) error {
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post webhook: %w", err)
+	}
+	defer res.Body.Close() //nolint:errcheck
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %v", res.Status)
+	}
+
+	return nil
+}
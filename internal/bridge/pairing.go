@@ -0,0 +1,267 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// pairingCodeDigits is the length of the pairing codes, which are typed by the user.
+	pairingCodeDigits = 8
+
+	// pairingCodeLifetime is how long a pairing code may be exchanged for a token.
+	pairingCodeLifetime = 5 * time.Minute
+
+	// maxPairingAttempts is the number of wrong codes after which the pending code is discarded.
+	maxPairingAttempts = 5
+
+	// minPairingAttemptInterval is the shortest time between two pairing attempts.
+	minPairingAttemptInterval = time.Second
+
+	// defaultPairedClientName is the name of the paired clients which don't give one.
+	defaultPairedClientName = "unnamed client"
+)
+
+// pairingCode is a pending pairing code and the role it grants.
+type pairingCode struct {
+	code      string
+	role      vault.AccessRole
+	expiresAt time.Time
+	attempts  int
+}
+
+// pairingClaims are the signed contents of the tokens of paired clients.
+type pairingClaims struct {
+	ClientID string           `json:"id"`
+	Role     vault.AccessRole `json:"role"`
+	IssuedAt int64            `json:"iat"`
+}
+
+// StartPairing creates a short-lived code which a frontend may exchange for a token with the given role.
+// Any code that was pending is discarded.
+func (bridge *Bridge) StartPairing(role vault.AccessRole) (string, time.Time, error) {
+	code, err := newPairingCode()
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("could not generate pairing code: %w", err)
+	}
+
+	bridge.pairingLock.Lock()
+	defer bridge.pairingLock.Unlock()
+
+	bridge.pairingCode = &pairingCode{
+		code:      code,
+		role:      role,
+		expiresAt: time.Now().Add(pairingCodeLifetime),
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"role":      role,
+		"expiresAt": bridge.pairingCode.expiresAt,
+	}).Info("Pairing code created")
+
+	return code, bridge.pairingCode.expiresAt, nil
+}
+
+// Pair exchanges the pending pairing code for a signed token, which is returned along with the new client.
+// Attempts are rate-limited, and the code is discarded after too many wrong ones.
+func (bridge *Bridge) Pair(code, name string) (vault.PairedClient, string, error) {
+	pending, err := bridge.checkPairingCode(code)
+	if err != nil {
+		return vault.PairedClient{}, "", err
+	}
+
+	if name = strings.TrimSpace(name); name == "" {
+		name = defaultPairedClientName
+	}
+
+	client := vault.PairedClient{
+		ID:       uuid.NewString(),
+		Name:     name,
+		Role:     pending.role,
+		PairedAt: time.Now(),
+	}
+
+	key, err := bridge.vault.GetPairingKey()
+	if err != nil {
+		return vault.PairedClient{}, "", fmt.Errorf("could not get pairing key: %w", err)
+	}
+
+	token, err := signPairingToken(key, pairingClaims{
+		ClientID: client.ID,
+		Role:     client.Role,
+		IssuedAt: client.PairedAt.Unix(),
+	})
+	if err != nil {
+		return vault.PairedClient{}, "", fmt.Errorf("could not sign pairing token: %w", err)
+	}
+
+	if err := bridge.vault.AddPairedClient(client); err != nil {
+		return vault.PairedClient{}, "", err
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"clientID": client.ID,
+		"name":     client.Name,
+		"role":     client.Role,
+	}).Info("Client paired")
+
+	return client, token, nil
+}
+
+// ListPairedClients returns the paired clients, including the revoked ones.
+func (bridge *Bridge) ListPairedClients() []vault.PairedClient {
+	return bridge.vault.GetPairedClients()
+}
+
+// RevokePairedClient revokes the paired client with the given ID; its token no longer gives access.
+func (bridge *Bridge) RevokePairedClient(clientID string) error {
+	if err := bridge.vault.RevokePairedClient(clientID, time.Now()); err != nil {
+		return err
+	}
+
+	logrus.WithField("clientID", clientID).Info("Paired client revoked")
+
+	return nil
+}
+
+// GetPairedClientByToken returns the paired client of the given token, if the token is valid and not revoked.
+func (bridge *Bridge) GetPairedClientByToken(token string) (vault.PairedClient, bool) {
+	key, err := bridge.vault.GetPairingKey()
+	if err != nil {
+		return vault.PairedClient{}, false
+	}
+
+	claims, ok := verifyPairingToken(key, token)
+	if !ok {
+		return vault.PairedClient{}, false
+	}
+
+	client, ok := bridge.vault.GetPairedClient(claims.ClientID)
+	if !ok || client.IsRevoked() || client.Role != claims.Role {
+		return vault.PairedClient{}, false
+	}
+
+	return client, true
+}
+
+// checkPairingCode consumes the pending pairing code if it matches the given one.
+func (bridge *Bridge) checkPairingCode(code string) (pairingCode, error) {
+	bridge.pairingLock.Lock()
+	defer bridge.pairingLock.Unlock()
+
+	now := time.Now()
+
+	if now.Sub(bridge.lastPairingAttempt) < minPairingAttemptInterval {
+		return pairingCode{}, ErrPairingRateLimited
+	}
+
+	bridge.lastPairingAttempt = now
+
+	pending := bridge.pairingCode
+
+	if pending == nil {
+		return pairingCode{}, ErrInvalidPairingCode
+	}
+
+	if now.After(pending.expiresAt) {
+		bridge.pairingCode = nil
+		return pairingCode{}, ErrInvalidPairingCode
+	}
+
+	if subtle.ConstantTimeCompare([]byte(strings.TrimSpace(code)), []byte(pending.code)) != 1 {
+		if pending.attempts++; pending.attempts >= maxPairingAttempts {
+			logrus.Warn("Too many wrong pairing codes, discarding the pending one")
+			bridge.pairingCode = nil
+		}
+
+		return pairingCode{}, ErrInvalidPairingCode
+	}
+
+	bridge.pairingCode = nil
+
+	return *pending, nil
+}
+
+// newPairingCode returns a random code of pairingCodeDigits digits.
+func newPairingCode() (string, error) {
+	n, err := rand.Int(rand.Reader, new(big.Int).Exp(big.NewInt(10), big.NewInt(pairingCodeDigits), nil))
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%0*d", pairingCodeDigits, n), nil
+}
+
+// signPairingToken returns the claims, encoded, followed by their signature with the given key.
+func signPairingToken(key []byte, claims pairingClaims) (string, error) {
+	b, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	payload := base64.RawURLEncoding.EncodeToString(b)
+
+	return payload + "." + base64.RawURLEncoding.EncodeToString(signPairingPayload(key, payload)), nil
+}
+
+// verifyPairingToken returns the claims of the given token if it was signed with the given key.
+func verifyPairingToken(key []byte, token string) (pairingClaims, bool) {
+	payload, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return pairingClaims{}, false
+	}
+
+	mac, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(mac, signPairingPayload(key, payload)) {
+		return pairingClaims{}, false
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return pairingClaims{}, false
+	}
+
+	var claims pairingClaims
+
+	if err := json.Unmarshal(b, &claims); err != nil {
+		return pairingClaims{}, false
+	}
+
+	return claims, true
+}
+
+func signPairingPayload(key []byte, payload string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(payload))
+
+	return mac.Sum(nil)
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/go-proton-api/server"
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBridge_Pairing(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			// There is nothing to pair with until a code is created.
+			_, _, err := b.Pair("12345678", "dashboard")
+			require.ErrorIs(t, err, bridge.ErrInvalidPairingCode)
+
+			code, expiresAt, err := b.StartPairing(vault.AccessRoleViewer)
+			require.NoError(t, err)
+			require.Len(t, code, 8)
+			require.True(t, expiresAt.After(time.Now()))
+
+			// Attempts in quick succession are rejected.
+			_, _, err = b.Pair(code, "dashboard")
+			require.ErrorIs(t, err, bridge.ErrPairingRateLimited)
+
+			time.Sleep(time.Second)
+
+			client, token, err := b.Pair(code, "dashboard")
+			require.NoError(t, err)
+			require.Equal(t, "dashboard", client.Name)
+			require.Equal(t, vault.AccessRoleViewer, client.Role)
+
+			// The code can only be used once.
+			time.Sleep(time.Second)
+
+			_, _, err = b.Pair(code, "other")
+			require.ErrorIs(t, err, bridge.ErrInvalidPairingCode)
+
+			// The token identifies the client, unless it was tampered with.
+			paired, ok := b.GetPairedClientByToken(token)
+			require.True(t, ok)
+			require.Equal(t, client.ID, paired.ID)
+
+			_, ok = b.GetPairedClientByToken(token + "x")
+			require.False(t, ok)
+
+			// Revoked clients are listed but their token no longer gives access.
+			require.NoError(t, b.RevokePairedClient(client.ID))
+
+			_, ok = b.GetPairedClientByToken(token)
+			require.False(t, ok)
+
+			require.Len(t, b.ListPairedClients(), 1)
+			require.True(t, b.ListPairedClients()[0].IsRevoked())
+		})
+	})
+}
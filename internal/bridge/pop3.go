@@ -0,0 +1,166 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/safe"
+	"github.com/ProtonMail/proton-bridge/v3/internal/services/pop3"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+	"github.com/sirupsen/logrus"
+)
+
+// GetPOP3Port returns the port of the POP3 server, or zero if it's disabled.
+func (bridge *Bridge) GetPOP3Port() int {
+	return bridge.vault.GetPOP3().Port
+}
+
+// SetPOP3Port sets the port of the POP3 server and restarts it; zero, the default, disables it.
+// The server serves the inbox of each address to devices which can't speak IMAP, with the same credentials.
+func (bridge *Bridge) SetPOP3Port(ctx context.Context, newPort int) error {
+	if newPort < 0 {
+		return ErrInvalidPOP3Port
+	}
+
+	settings := bridge.vault.GetPOP3()
+
+	if newPort == settings.Port {
+		return nil
+	}
+
+	settings.Port = newPort
+
+	if err := bridge.vault.SetPOP3(settings); err != nil {
+		return err
+	}
+
+	return bridge.restartPOP3(ctx)
+}
+
+// GetPOP3SSL returns whether the POP3 server speaks TLS from the first byte rather than offering STLS.
+func (bridge *Bridge) GetPOP3SSL() bool {
+	return bridge.vault.GetPOP3().SSL
+}
+
+// SetPOP3SSL sets whether the POP3 server speaks TLS from the first byte, and restarts it.
+func (bridge *Bridge) SetPOP3SSL(ctx context.Context, newSSL bool) error {
+	settings := bridge.vault.GetPOP3()
+
+	if newSSL == settings.SSL {
+		return nil
+	}
+
+	settings.SSL = newSSL
+
+	if err := bridge.vault.SetPOP3(settings); err != nil {
+		return err
+	}
+
+	return bridge.restartPOP3(ctx)
+}
+
+// UsesPOP3SSL returns whether the POP3 server speaks TLS from the first byte rather than offering STLS.
+func (bridge *Bridge) UsesPOP3SSL() bool {
+	return bridge.vault.GetPOP3().SSL
+}
+
+// GetPOP3DeleteAction returns what happens to the messages POP3 clients delete.
+func (bridge *Bridge) GetPOP3DeleteAction() vault.POP3DeleteAction {
+	return bridge.vault.GetPOP3().DeleteAction
+}
+
+// SetPOP3DeleteAction sets what happens to the messages POP3 clients delete. By default they stay in the inbox,
+// which keeps the server read-only; clients which delete retrieved messages can have them archived or trashed instead.
+// It applies to the next sessions.
+func (bridge *Bridge) SetPOP3DeleteAction(action vault.POP3DeleteAction) error {
+	settings := bridge.vault.GetPOP3()
+
+	settings.DeleteAction = action
+
+	if err := bridge.vault.SetPOP3(settings); err != nil {
+		return err
+	}
+
+	logrus.WithField("action", action).Info("POP3 delete action changed")
+
+	return nil
+}
+
+func (bridge *Bridge) restartPOP3(ctx context.Context) error {
+	return bridge.serverManager.RestartPOP3(ctx)
+}
+
+type bridgePOP3Settings struct {
+	b *Bridge
+}
+
+func (b *bridgePOP3Settings) TLSConfig() *tls.Config {
+	return b.b.tlsConfig
+}
+
+func (b *bridgePOP3Settings) Hosts() []string {
+	return b.b.getListenHosts()
+}
+
+// Port returns the port of the POP3 server, which is disabled in safe mode.
+func (b *bridgePOP3Settings) Port() int {
+	if b.b.safeMode != nil {
+		return 0
+	}
+
+	return b.b.vault.GetPOP3().Port
+}
+
+func (b *bridgePOP3Settings) UseSSL() bool {
+	return b.b.UsesPOP3SSL()
+}
+
+func (b *bridgePOP3Settings) RequireTLS() bool {
+	return b.b.vault.GetTLSPolicy().RequireTLS
+}
+
+func (b *bridgePOP3Settings) AllowClient(addr net.Addr) bool {
+	return b.b.AllowClient("POP3", addr)
+}
+
+func (b *bridgePOP3Settings) Backend() pop3.Backend {
+	return &bridgePOP3Backend{b: b.b}
+}
+
+type bridgePOP3Backend struct {
+	b *Bridge
+}
+
+// Login signs in POP3 clients with the bridge credentials of one of the users' addresses.
+func (b *bridgePOP3Backend) Login(_ context.Context, username string, password []byte) (pop3.Mailbox, error) {
+	return safe.RLockRetErr(func() (pop3.Mailbox, error) {
+		for _, user := range b.b.users {
+			addrID, err := user.CheckAuth(username, password)
+			if err != nil {
+				continue
+			}
+
+			return user.NewPOP3Mailbox(addrID, b.b.vault.GetPOP3().DeleteAction), nil
+		}
+
+		return nil, pop3.ErrInvalidCredentials
+	}, b.b.usersLock)
+}
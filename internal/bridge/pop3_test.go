@@ -0,0 +1,151 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge_test
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/go-proton-api/server"
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
+	"github.com/ProtonMail/proton-bridge/v3/internal/constants"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+	"github.com/ProtonMail/proton-bridge/v3/pkg/ports"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBridge_POP3(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		userID, addrID, err := s.CreateUser("user", password)
+		require.NoError(t, err)
+
+		withClient(ctx, t, s, "user", password, func(ctx context.Context, c *proton.Client) {
+			createNumMessages(ctx, t, c, addrID, proton.InboxLabel, 2)
+		})
+
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			userLoginAndSync(ctx, t, b, "user", password)
+
+			info, err := b.GetUserInfo(userID)
+			require.NoError(t, err)
+
+			// The POP3 server is disabled and read-only by default.
+			require.Zero(t, b.GetPOP3Port())
+			require.Equal(t, vault.POP3Keep, b.GetPOP3DeleteAction())
+			require.ErrorIs(t, b.SetPOP3Port(ctx, -1), bridge.ErrInvalidPOP3Port)
+
+			// Deleted messages are trashed.
+			require.NoError(t, b.SetPOP3DeleteAction(vault.POP3Trash))
+
+			port := ports.FindFreePortFrom(1110)
+			require.NoError(t, b.SetPOP3Port(ctx, port))
+
+			addr := net.JoinHostPort(constants.Host, strconv.Itoa(port))
+
+			var conn net.Conn
+
+			require.Eventually(t, func() bool {
+				conn, err = net.Dial("tcp", addr)
+				return err == nil
+			}, 5*time.Second, 100*time.Millisecond)
+			defer conn.Close() //nolint:errcheck
+
+			r := bufio.NewReader(conn)
+
+			readLine := func() string {
+				line, err := r.ReadString('\n')
+				require.NoError(t, err)
+
+				return strings.TrimSuffix(line, "\r\n")
+			}
+
+			cmd := func(cmd string) string {
+				_, err := conn.Write([]byte(cmd + "\r\n"))
+				require.NoError(t, err)
+
+				return readLine()
+			}
+
+			readLines := func() []string {
+				var lines []string
+
+				for line := readLine(); line != "."; line = readLine() {
+					lines = append(lines, line)
+				}
+
+				return lines
+			}
+
+			require.True(t, strings.HasPrefix(readLine(), "+OK"))
+
+			// Clients sign in with the bridge password.
+			require.Equal(t, "+OK", cmd("USER "+info.Addresses[0]))
+			require.Equal(t, "-ERR [AUTH] invalid username or password", cmd("PASS "+string(password)))
+			require.Equal(t, "+OK", cmd("USER "+info.Addresses[0]))
+			require.True(t, strings.HasPrefix(cmd("PASS "+string(info.BridgePass)), "+OK maildrop has 2 messages"))
+
+			// The unique IDs are stable.
+			require.Equal(t, "+OK", cmd("UIDL"))
+			uids := readLines()
+			require.Len(t, uids, 2)
+
+			require.True(t, strings.HasPrefix(cmd("RETR 1"), "+OK"))
+			message := readLines()
+			require.Contains(t, message, "Subject: Test")
+			require.Equal(t, "Test", message[len(message)-1])
+
+			require.Equal(t, "+OK message 1 deleted", cmd("DELE 1"))
+			require.Equal(t, "+OK bye", cmd("QUIT"))
+
+			withClient(ctx, t, s, "user", password, func(ctx context.Context, c *proton.Client) {
+				require.Eventually(t, func() bool {
+					trash, err := c.GetMessageMetadataPage(ctx, 0, 10, proton.MessageFilter{LabelID: proton.TrashLabel})
+					require.NoError(t, err)
+
+					return len(trash) == 1
+				}, 5*time.Second, 100*time.Millisecond)
+			})
+
+			// The remaining message keeps its unique ID.
+			conn2, err := net.Dial("tcp", addr)
+			require.NoError(t, err)
+			defer conn2.Close() //nolint:errcheck
+
+			conn, r = conn2, bufio.NewReader(conn2)
+
+			require.True(t, strings.HasPrefix(readLine(), "+OK"))
+			require.Equal(t, "+OK", cmd("USER "+info.Addresses[0]))
+			require.True(t, strings.HasPrefix(cmd("PASS "+string(info.BridgePass)), "+OK maildrop has 1 messages"))
+			require.Equal(t, "+OK", cmd("UIDL"))
+			require.Equal(t, []string{strings.Replace(uids[1], "2 ", "1 ", 1)}, readLines())
+			require.Equal(t, "+OK bye", cmd("QUIT"))
+
+			// Disabling the server stops the listener.
+			require.NoError(t, b.SetPOP3Port(ctx, 0))
+
+			_, err = net.Dial("tcp", addr)
+			require.Error(t, err)
+		})
+	})
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// MinJitterWindow and MaxJitterWindow bound the privacy mode jitter window.
+	// Below a second, the timing of the traffic still gives away the user's actions;
+	// beyond ten minutes, sending a message or logging in becomes impractically slow.
+	MinJitterWindow = time.Second
+	MaxJitterWindow = 10 * time.Minute
+)
+
+// GetPrivacyMode returns the privacy mode settings.
+func (bridge *Bridge) GetPrivacyMode() vault.PrivacyMode {
+	settings := bridge.vault.GetPrivacyMode()
+
+	if settings.JitterWindow == 0 {
+		settings.JitterWindow = vault.DefaultJitterWindow
+	}
+
+	return settings
+}
+
+// SetPrivacyMode sets whether privacy mode is enabled.
+// In privacy mode, API requests are held back and sent in batches at random times within the jitter window,
+// so that the network traffic doesn't reveal exactly when mail is read or sent.
+// The trade-off is latency: every request, including those made to send a message, may be delayed by up to the window.
+func (bridge *Bridge) SetPrivacyMode(enabled bool) error {
+	return bridge.modPrivacyMode(func(settings *vault.PrivacyMode) {
+		settings.Enabled = enabled
+	})
+}
+
+// SetPrivacyJitterWindow sets the longest a request is held back in privacy mode.
+func (bridge *Bridge) SetPrivacyJitterWindow(window time.Duration) error {
+	if window < MinJitterWindow || window > MaxJitterWindow {
+		return fmt.Errorf("%w: must be between %v and %v", ErrInvalidJitterWindow, MinJitterWindow, MaxJitterWindow)
+	}
+
+	return bridge.modPrivacyMode(func(settings *vault.PrivacyMode) {
+		settings.JitterWindow = window
+	})
+}
+
+func (bridge *Bridge) modPrivacyMode(fn func(*vault.PrivacyMode)) error {
+	settings := bridge.GetPrivacyMode()
+
+	fn(&settings)
+
+	if err := bridge.vault.SetPrivacyMode(settings); err != nil {
+		return err
+	}
+
+	bridge.applyPrivacyMode(settings)
+
+	return nil
+}
+
+func (bridge *Bridge) applyPrivacyMode(settings vault.PrivacyMode) {
+	if !settings.Enabled {
+		bridge.trafficShaper.SetWindow(0)
+		return
+	}
+
+	logrus.WithField("window", settings.JitterWindow).Info("Privacy mode is enabled, API requests are batched")
+
+	bridge.trafficShaper.SetWindow(settings.JitterWindow)
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/safe"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+	"github.com/sirupsen/logrus"
+)
+
+// ReauthUser signs the given user in again after their session expired, asking only for their password
+// and, if necessary, a TOTP and mailbox password via the callbacks.
+//
+// The user's IMAP data and sync state are kept while they are signed out, so the sync resumes where it stopped
+// and IMAP clients keep their UIDs. While the user is waiting to sign in again, SMTP clients are told to retry
+// later rather than that their credentials are wrong. IMAP logins are still refused as failed authentications,
+// as gluon can't reply NO [UNAVAILABLE] (see TODO.md).
+// The API client can't remember 2FA devices either, so the TOTP is requested again if 2FA is enabled.
+func (bridge *Bridge) ReauthUser(
+	ctx context.Context,
+	userID string,
+	password []byte,
+	getTOTP func() (string, error),
+	getKeyPass func() ([]byte, error),
+) error {
+	logrus.WithField("userID", userID).Info("Re-authenticating user")
+
+	if safe.RLockRet(func() bool { return mapHas(bridge.users, userID) }, bridge.usersLock) {
+		return ErrUserAlreadyLoggedIn
+	}
+
+	var username string
+
+	if err := bridge.vault.GetUser(userID, func(user *vault.User) {
+		username = user.Username()
+	}); err != nil {
+		return ErrNoSuchUser
+	}
+
+	newUserID, err := bridge.LoginFull(ctx, username, password, getTOTP, getKeyPass)
+	if err != nil {
+		return fmt.Errorf("failed to re-authenticate user: %w", err)
+	}
+
+	if newUserID != userID {
+		logrus.WithField("userID", userID).WithField("newUserID", newUserID).Warn("Re-authenticated a different user")
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/go-proton-api/server"
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
+	"github.com/ProtonMail/proton-bridge/v3/internal/events"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBridge_ReauthUser(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			userID := must(b.LoginFull(ctx, username, password, nil, nil))
+
+			// Re-authenticating a connected user fails.
+			require.ErrorIs(t, b.ReauthUser(ctx, userID, password, nil, nil), bridge.ErrUserAlreadyLoggedIn)
+
+			deauthCh, done := b.GetEvents(events.UserDeauth{})
+			defer done()
+
+			// Revoking the session signs the user out and flags them for re-authentication.
+			require.NoError(t, s.RevokeUser(userID))
+			waitForEvent(t, deauthCh, events.UserDeauth{})
+
+			info, err := b.GetUserInfo(userID)
+			require.NoError(t, err)
+			require.Equal(t, bridge.SignedOut, info.State)
+			require.True(t, info.ReauthRequired)
+
+			// Re-authenticating brings the same user back and clears the flag.
+			require.NoError(t, b.ReauthUser(ctx, userID, password, nil, nil))
+
+			info, err = b.GetUserInfo(userID)
+			require.NoError(t, err)
+			require.Equal(t, bridge.Connected, info.State)
+			require.False(t, info.ReauthRequired)
+		})
+	})
+}
+
+func TestBridge_ReauthUser_NoSuchUser(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			require.ErrorIs(t, b.ReauthUser(ctx, "no-such-user", password, nil, nil), bridge.ErrNoSuchUser)
+		})
+	})
+}
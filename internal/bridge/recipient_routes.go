@@ -0,0 +1,63 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"context"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/safe"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+)
+
+// GetRecipientRoutes returns the rules filing the new messages of the given user into folders by recipient.
+func (bridge *Bridge) GetRecipientRoutes(userID string) ([]vault.RecipientRoute, error) {
+	return safe.RLockRetErr(func() ([]vault.RecipientRoute, error) {
+		user, ok := bridge.users[userID]
+		if !ok {
+			return nil, ErrNoSuchUser
+		}
+
+		return user.GetRecipientRoutes(), nil
+	}, bridge.usersLock)
+}
+
+// SetRecipientRoute creates or replaces a rule of the given user filing new messages sent to recipients matching
+// the pattern into the folder at the given path. This sorts the traffic of a catch-all address, e.g. the messages
+// sent to shop@example.com into Shopping. Messages are only moved out of the Inbox, after the local spam filter.
+func (bridge *Bridge) SetRecipientRoute(ctx context.Context, userID, pattern, folder string) error {
+	return safe.RLockRet(func() error {
+		user, ok := bridge.users[userID]
+		if !ok {
+			return ErrNoSuchUser
+		}
+
+		return user.SetRecipientRoute(ctx, pattern, folder)
+	}, bridge.usersLock)
+}
+
+// DeleteRecipientRoute removes the rule of the given user for the given recipient pattern.
+func (bridge *Bridge) DeleteRecipientRoute(ctx context.Context, userID, pattern string) error {
+	return safe.RLockRet(func() error {
+		user, ok := bridge.users[userID]
+		if !ok {
+			return ErrNoSuchUser
+		}
+
+		return user.DeleteRecipientRoute(ctx, pattern)
+	}, bridge.usersLock)
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/go-proton-api/server"
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
+	"github.com/ProtonMail/proton-bridge/v3/internal/constants"
+	"github.com/ProtonMail/proton-bridge/v3/internal/user"
+	"github.com/emersion/go-imap"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBridge_RecipientRoutes(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		_, addrID, err := s.CreateUser("user", password)
+		require.NoError(t, err)
+
+		withClient(ctx, t, s, "user", password, func(ctx context.Context, c *proton.Client) {
+			_, err := c.CreateLabel(ctx, proton.CreateLabelReq{
+				Name:  "Shopping",
+				Color: "#f66",
+				Type:  proton.LabelTypeFolder,
+			})
+			require.NoError(t, err)
+		})
+
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			userLoginAndSync(ctx, t, b, "user", password)
+
+			info, err := b.QueryUserInfo("user")
+			require.NoError(t, err)
+
+			// The domains are derived from the user's addresses.
+			domains, err := b.GetUserDomains(info.UserID)
+			require.NoError(t, err)
+			require.Len(t, domains, 1)
+			require.Equal(t, info.Addresses, domains[0].Addresses)
+
+			// Invalid patterns and empty folders are rejected.
+			require.Error(t, b.SetRecipientRoute(ctx, info.UserID, "[@pm.me", "Shopping"))
+			require.Error(t, b.SetRecipientRoute(ctx, info.UserID, "*@pm.me", ""))
+
+			require.NoError(t, b.SetRecipientRoute(ctx, info.UserID, "Recipient@*", "Shopping"))
+
+			routes, err := b.GetRecipientRoutes(info.UserID)
+			require.NoError(t, err)
+			require.Len(t, routes, 1)
+			require.Equal(t, "recipient@*", routes[0].Pattern)
+
+			client, err := eventuallyDial(fmt.Sprintf("%v:%v", constants.Host, b.GetIMAPPort()))
+			require.NoError(t, err)
+			require.NoError(t, client.Login(info.Addresses[0], string(info.BridgePass)))
+			defer func() { _ = client.Logout() }()
+
+			// A new message sent to the matching recipient is filed into the folder instead of the inbox.
+			withClient(ctx, t, s, "user", password, func(ctx context.Context, c *proton.Client) {
+				createNumMessages(ctx, t, c, addrID, proton.InboxLabel, 1)
+			})
+
+			require.Eventually(t, func() bool {
+				status, err := client.Status("Folders/Shopping", []imap.StatusItem{imap.StatusMessages})
+				return err == nil && status.Messages == 1
+			}, 10*time.Second, 100*time.Millisecond)
+
+			status, err := client.Status("INBOX", []imap.StatusItem{imap.StatusMessages})
+			require.NoError(t, err)
+			require.Zero(t, status.Messages)
+
+			// Once the route is deleted, new messages stay in the inbox.
+			require.NoError(t, b.DeleteRecipientRoute(ctx, info.UserID, "recipient@*"))
+			require.ErrorIs(t, b.DeleteRecipientRoute(ctx, info.UserID, "recipient@*"), user.ErrNoSuchRecipientRoute)
+
+			withClient(ctx, t, s, "user", password, func(ctx context.Context, c *proton.Client) {
+				createNumMessages(ctx, t, c, addrID, proton.InboxLabel, 1)
+			})
+
+			require.Eventually(t, func() bool {
+				status, err := client.Status("INBOX", []imap.StatusItem{imap.StatusMessages})
+				return err == nil && status.Messages == 1
+			}, 10*time.Second, 100*time.Millisecond)
+		})
+	})
+}
@@ -0,0 +1,63 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/safe"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+	"github.com/sirupsen/logrus"
+)
+
+// GetUserRedactionRules returns the rules selecting the messages of the given user whose bodies are never cached.
+func (bridge *Bridge) GetUserRedactionRules(userID string) (vault.RedactionRules, error) {
+	return safe.RLockRetErr(func() (vault.RedactionRules, error) {
+		user, ok := bridge.users[userID]
+		if !ok {
+			return vault.RedactionRules{}, ErrNoSuchUser
+		}
+
+		return user.GetRedactionRules(), nil
+	}, bridge.usersLock)
+}
+
+// SetUserRedactionRules sets the rules selecting the messages of the given user whose bodies are never written
+// to the local message store. Matching messages are still listed over IMAP, but their bodies are fetched from
+// the server on every read. Messages match if they were sent from one of the given domains (or a subdomain)
+// or carry one of the given labels. The user is resynced when the rules change.
+func (bridge *Bridge) SetUserRedactionRules(ctx context.Context, userID string, rules vault.RedactionRules) error {
+	logrus.WithField("userID", userID).
+		WithField("domains", rules.SenderDomains).
+		WithField("labels", rules.LabelIDs).
+		Info("Setting redaction rules")
+
+	return safe.RLockRet(func() error {
+		user, ok := bridge.users[userID]
+		if !ok {
+			return ErrNoSuchUser
+		}
+
+		if err := user.SetRedactionRules(ctx, rules); err != nil {
+			return fmt.Errorf("failed to set redaction rules: %w", err)
+		}
+
+		return nil
+	}, bridge.usersLock)
+}
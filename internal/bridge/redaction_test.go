@@ -0,0 +1,108 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/go-proton-api/server"
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
+	"github.com/ProtonMail/proton-bridge/v3/internal/constants"
+	"github.com/ProtonMail/proton-bridge/v3/internal/events"
+	"github.com/ProtonMail/proton-bridge/v3/internal/services/imapservice"
+	"github.com/ProtonMail/proton-bridge/v3/internal/user"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBridge_RedactionRules(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		userID, addrID, err := s.CreateUser("redaction", password)
+		require.NoError(t, err)
+
+		labelID, err := s.CreateLabel(userID, "sensitive", "", proton.LabelTypeFolder)
+		require.NoError(t, err)
+
+		withClient(ctx, t, s, "redaction", password, func(ctx context.Context, c *proton.Client) {
+			createNumMessages(ctx, t, c, addrID, labelID, 3)
+			createNumMessages(ctx, t, c, addrID, proton.InboxLabel, 2)
+		})
+
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			syncCh, done := chToType[events.Event, events.SyncFinished](b.GetEvents(events.SyncFinished{}))
+			defer done()
+
+			require.Equal(t, userID, must(b.LoginFull(ctx, "redaction", password, nil, nil)))
+			require.Equal(t, userID, (<-syncCh).UserID)
+
+			// Nothing is redacted by default.
+			rules, err := b.GetUserRedactionRules(userID)
+			require.NoError(t, err)
+			require.Empty(t, rules.SenderDomains)
+			require.Empty(t, rules.LabelIDs)
+
+			// Redacting the folder by its IMAP path resyncs the user and stores the folder by ID.
+			require.NoError(t, b.SetUserRedactionRules(ctx, userID, vault.RedactionRules{
+				SenderDomains: []string{" @Bank.com "},
+				LabelIDs:      []string{"Folders/sensitive"},
+			}))
+			require.Equal(t, userID, (<-syncCh).UserID)
+
+			rules, err = b.GetUserRedactionRules(userID)
+			require.NoError(t, err)
+			require.Equal(t, []string{"bank.com"}, rules.SenderDomains)
+			require.Equal(t, []string{labelID}, rules.LabelIDs)
+
+			info, err := b.GetUserInfo(userID)
+			require.NoError(t, err)
+
+			client, err := eventuallyDial(fmt.Sprintf("%v:%v", constants.Host, b.GetIMAPPort()))
+			require.NoError(t, err)
+			require.NoError(t, client.Login(info.Addresses[0], string(info.BridgePass)))
+			defer func() { _ = client.Logout() }()
+
+			// Redacted messages are still listed and their bodies streamed from the API.
+			messages, err := clientFetch(client, `Folders/sensitive`)
+			require.NoError(t, err)
+			require.Len(t, messages, 3)
+
+			for _, message := range messages {
+				require.NotEmpty(t, message.Body)
+			}
+
+			// Redacted messages are left out of exports.
+			finishedCh, done := chToType[events.Event, events.UserExportFinished](b.GetEvents(events.UserExportFinished{}))
+			defer done()
+
+			require.NoError(t, b.ExportMailbox(ctx, userID, t.TempDir(), user.ExportFormatEML))
+
+			finished := <-finishedCh
+			require.NoError(t, finished.Error)
+			require.Equal(t, 2, finished.Exported)
+			require.Equal(t, 3, finished.Redacted)
+
+			// Unknown mailboxes and users are rejected.
+			err = b.SetUserRedactionRules(ctx, userID, vault.RedactionRules{LabelIDs: []string{"Folders/nope"}})
+			require.ErrorIs(t, err, imapservice.ErrNoSuchMailbox)
+			require.ErrorIs(t, b.SetUserRedactionRules(ctx, "no-such-user", vault.RedactionRules{}), bridge.ErrNoSuchUser)
+		})
+	})
+}
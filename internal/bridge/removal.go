@@ -0,0 +1,312 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/safe"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/exp/slices"
+)
+
+// RemovalUndoPeriod is how long the data deleted by DeleteUser and FactoryReset is kept so UndoRemoval can restore it.
+const RemovalUndoPeriod = 24 * time.Hour
+
+// RemovalPurgeInterval is how often removed data which can no longer be restored is purged.
+const RemovalPurgeInterval = time.Hour
+
+const (
+	removalDirName      = "removed"
+	removalManifestName = "manifest.json"
+	removalVaultName    = "vault.enc"
+	removalFilesDirName = "files"
+)
+
+type RemovalKind string
+
+const (
+	RemovalKindUser         RemovalKind = "user"
+	RemovalKindFactoryReset RemovalKind = "factory_reset"
+)
+
+// Removal describes data deleted by DeleteUser or FactoryReset which can still be restored with UndoRemoval.
+type Removal struct {
+	Token     string
+	Kind      RemovalKind
+	UserID    string
+	RemovedAt time.Time
+	ExpiresAt time.Time
+}
+
+type removalManifest struct {
+	Removal
+
+	// Files maps the original path of each removed file to its name in the removal's files directory.
+	Files map[string]string
+}
+
+// ListRemovals returns the removals which can still be undone, oldest first.
+func (bridge *Bridge) ListRemovals() ([]Removal, error) {
+	removalDir, err := bridge.getRemovalDir()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(removalDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list removals: %w", err)
+	}
+
+	var removals []Removal
+
+	for _, entry := range entries {
+		manifest, err := readRemovalManifest(filepath.Join(removalDir, entry.Name()))
+		if err != nil || time.Now().After(manifest.ExpiresAt) {
+			continue
+		}
+
+		removals = append(removals, manifest.Removal)
+	}
+
+	slices.SortFunc(removals, func(a, b Removal) bool {
+		return a.RemovedAt.Before(b.RemovedAt)
+	})
+
+	return removals, nil
+}
+
+// UndoRemoval restores the data deleted by the DeleteUser or FactoryReset call identified by token.
+// A restored user is signed out and has to sign in again, but keeps their settings and sync state.
+// Bridge must be restarted after undoing a factory reset for the restored settings and users to take effect.
+func (bridge *Bridge) UndoRemoval(token string) error {
+	logrus.WithField("token", token).Info("Undoing removal")
+
+	removalDir, err := bridge.getRemovalDir()
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Join(removalDir, filepath.Base(token))
+
+	manifest, err := readRemovalManifest(dir)
+	if err != nil {
+		return ErrNoSuchRemoval
+	}
+
+	if time.Now().After(manifest.ExpiresAt) {
+		return ErrRemovalExpired
+	}
+
+	return safe.LockRet(func() error {
+		switch manifest.Kind {
+		case RemovalKindUser:
+			if bridge.vault.HasUser(manifest.UserID) {
+				return ErrUserAlreadyExists
+			}
+
+			if err := bridge.vault.RestoreUserFromSnapshot(filepath.Join(dir, removalVaultName), manifest.UserID); err != nil {
+				return fmt.Errorf("failed to restore user: %w", err)
+			}
+
+		case RemovalKindFactoryReset:
+			if len(bridge.vault.GetUserIDs()) > 0 {
+				return ErrUserAlreadyExists
+			}
+
+			if err := bridge.vault.RestoreSnapshot(filepath.Join(dir, removalVaultName)); err != nil {
+				return fmt.Errorf("failed to restore vault: %w", err)
+			}
+
+		default:
+			return fmt.Errorf("unknown removal kind %q", manifest.Kind)
+		}
+
+		for path, name := range manifest.Files {
+			if err := moveRemovedFile(filepath.Join(dir, removalFilesDirName, name), path); err != nil {
+				return fmt.Errorf("failed to restore %q: %w", path, err)
+			}
+		}
+
+		if err := os.RemoveAll(dir); err != nil {
+			logrus.WithError(err).Error("Failed to delete restored removal")
+		}
+
+		return nil
+	}, bridge.usersLock)
+}
+
+// keepRemoval moves the given files, along with a snapshot of the vault, to the removal directory,
+// so that UndoRemoval can restore them until the removal expires. Directories are left in place.
+func (bridge *Bridge) keepRemoval(kind RemovalKind, userID string, paths []string) (string, error) {
+	removalDir, err := bridge.getRemovalDir()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+
+	manifest := removalManifest{
+		Removal: Removal{
+			Token:     uuid.NewString(),
+			Kind:      kind,
+			UserID:    userID,
+			RemovedAt: now,
+			ExpiresAt: now.Add(RemovalUndoPeriod),
+		},
+		Files: make(map[string]string),
+	}
+
+	dir := filepath.Join(removalDir, manifest.Token)
+
+	if err := os.MkdirAll(filepath.Join(dir, removalFilesDirName), 0o700); err != nil {
+		return "", fmt.Errorf("failed to create removal directory: %w", err)
+	}
+
+	if err := bridge.vault.Snapshot(filepath.Join(dir, removalVaultName)); err != nil {
+		return "", fmt.Errorf("failed to snapshot vault: %w", err)
+	}
+
+	for _, path := range paths {
+		if info, err := os.Stat(path); err != nil || info.IsDir() {
+			continue
+		}
+
+		name := strconv.Itoa(len(manifest.Files))
+
+		if err := moveRemovedFile(path, filepath.Join(dir, removalFilesDirName, name)); err != nil {
+			return "", fmt.Errorf("failed to move %q: %w", path, err)
+		}
+
+		manifest.Files[path] = name
+	}
+
+	b, err := json.Marshal(manifest)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal removal manifest: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, removalManifestName), b, 0o600); err != nil {
+		return "", fmt.Errorf("failed to write removal manifest: %w", err)
+	}
+
+	return manifest.Token, nil
+}
+
+// purgeExpiredRemovals deletes the removed data which can no longer be restored.
+func (bridge *Bridge) purgeExpiredRemovals() {
+	removalDir, err := bridge.getRemovalDir()
+	if err != nil {
+		logrus.WithError(err).Error("Failed to get removal directory")
+		return
+	}
+
+	entries, err := os.ReadDir(removalDir)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to list removals")
+		return
+	}
+
+	for _, entry := range entries {
+		dir := filepath.Join(removalDir, entry.Name())
+
+		// Removals without a manifest were interrupted and can't be restored either.
+		if manifest, err := readRemovalManifest(dir); err == nil && time.Now().Before(manifest.ExpiresAt) {
+			continue
+		}
+
+		logrus.WithField("token", entry.Name()).Info("Purging expired removal")
+
+		if err := os.RemoveAll(dir); err != nil {
+			logrus.WithError(err).Error("Failed to purge expired removal")
+		}
+	}
+}
+
+func (bridge *Bridge) getRemovalDir() (string, error) {
+	settingsDir, err := bridge.locator.ProvideSettingsPath()
+	if err != nil {
+		return "", fmt.Errorf("failed to get settings path: %w", err)
+	}
+
+	dir := filepath.Join(settingsDir, removalDirName)
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("failed to create removal directory: %w", err)
+	}
+
+	return dir, nil
+}
+
+func readRemovalManifest(dir string) (removalManifest, error) {
+	b, err := os.ReadFile(filepath.Join(dir, removalManifestName)) //nolint:gosec
+	if err != nil {
+		return removalManifest{}, err
+	}
+
+	var manifest removalManifest
+
+	if err := json.Unmarshal(b, &manifest); err != nil {
+		return removalManifest{}, err
+	}
+
+	return manifest, nil
+}
+
+// moveRemovedFile moves the file at from to to, copying it if the two are on different file systems.
+func moveRemovedFile(from, to string) error {
+	if err := os.MkdirAll(filepath.Dir(to), 0o700); err != nil {
+		return err
+	}
+
+	if err := os.Rename(from, to); err == nil {
+		return nil
+	} else if linkErr := new(os.LinkError); !errors.As(err, &linkErr) {
+		return err
+	}
+
+	src, err := os.Open(from) //nolint:gosec
+	if err != nil {
+		return err
+	}
+	defer func() { _ = src.Close() }()
+
+	dst, err := os.OpenFile(to, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600) //nolint:gosec
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(dst, src); err != nil {
+		_ = dst.Close()
+		return err
+	}
+
+	if err := dst.Close(); err != nil {
+		return err
+	}
+
+	return os.Remove(from)
+}
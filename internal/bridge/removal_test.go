@@ -0,0 +1,110 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/go-proton-api/server"
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
+	"github.com/ProtonMail/proton-bridge/v3/internal/updater"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBridge_UndoRemoval_User(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			require.ErrorIs(t, b.UndoRemoval("no-such-removal"), bridge.ErrNoSuchRemoval)
+
+			userID := must(b.LoginFull(ctx, username, password, nil, nil))
+
+			report, err := b.PreviewDeleteUser(userID)
+			require.NoError(t, err)
+			require.NotEmpty(t, report.Files)
+
+			require.NoError(t, b.DeleteUser(ctx, userID))
+			require.Empty(t, b.GetUserIDs())
+
+			// The deleted data is moved away.
+			for _, file := range report.Files {
+				require.NoFileExists(t, file.Path)
+			}
+
+			removals, err := b.ListRemovals()
+			require.NoError(t, err)
+			require.Len(t, removals, 1)
+			require.Equal(t, bridge.RemovalKindUser, removals[0].Kind)
+			require.Equal(t, userID, removals[0].UserID)
+
+			// Undoing the removal brings the user back, signed out, along with their data.
+			require.NoError(t, b.UndoRemoval(removals[0].Token))
+			require.Equal(t, []string{userID}, b.GetUserIDs())
+
+			info, err := b.GetUserInfo(userID)
+			require.NoError(t, err)
+			require.Equal(t, bridge.SignedOut, info.State)
+
+			for _, file := range report.Files {
+				require.FileExists(t, file.Path)
+			}
+
+			// A removal can only be undone once.
+			require.ErrorIs(t, b.UndoRemoval(removals[0].Token), bridge.ErrNoSuchRemoval)
+			require.Empty(t, must(b.ListRemovals()))
+		})
+	})
+}
+
+func TestBridge_UndoRemoval_FactoryReset(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		var token string
+
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			userID := must(b.LoginFull(ctx, username, password, nil, nil))
+			require.NoError(t, b.SetUpdateChannel(updater.EarlyChannel))
+
+			b.FactoryReset(ctx)
+			require.Empty(t, b.GetUserIDs())
+
+			removals, err := b.ListRemovals()
+			require.NoError(t, err)
+			require.Len(t, removals, 1)
+			require.Equal(t, bridge.RemovalKindFactoryReset, removals[0].Kind)
+
+			token = removals[0].Token
+
+			// The reset can't be undone over a new user.
+			require.Equal(t, userID, must(b.LoginFull(ctx, username, password, nil, nil)))
+			require.ErrorIs(t, b.UndoRemoval(token), bridge.ErrUserAlreadyExists)
+			require.NoError(t, b.DeleteUser(ctx, userID))
+		})
+
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			require.Equal(t, updater.StableChannel, b.GetUpdateChannel())
+			require.NoError(t, b.UndoRemoval(token))
+		})
+
+		// After a restart, the previous settings and user are back.
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			require.Equal(t, updater.EarlyChannel, b.GetUpdateChannel())
+			require.Len(t, b.GetUserIDs(), 1)
+		})
+	})
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/dialer"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+)
+
+// GetResolverSettings returns the settings of the DoH resolver used for alternative routing.
+func (bridge *Bridge) GetResolverSettings() vault.Resolver {
+	return bridge.vault.GetResolver()
+}
+
+// SetDoHProviders sets the custom DoH endpoints, which are queried before the built-in ones.
+func (bridge *Bridge) SetDoHProviders(providers []string) error {
+	for _, provider := range providers {
+		if err := dialer.ValidateDoHProvider(provider); err != nil {
+			return fmt.Errorf("%w %q: %v", ErrInvalidDoHProvider, provider, err)
+		}
+	}
+
+	return bridge.modResolverSettings(func(settings *vault.Resolver) {
+		settings.DoHProviders = providers
+	})
+}
+
+// SetDoHBootstrapIPs sets the IPs used to reach the DoH providers without the system resolver.
+func (bridge *Bridge) SetDoHBootstrapIPs(ips []string) error {
+	for _, ip := range ips {
+		if net.ParseIP(ip) == nil {
+			return fmt.Errorf("%w: %q", ErrInvalidBootstrapIP, ip)
+		}
+	}
+
+	return bridge.modResolverSettings(func(settings *vault.Resolver) {
+		settings.BootstrapIPs = ips
+	})
+}
+
+// SetDisableSystemDNS sets whether the API host is resolved over DoH instead of the system resolver.
+func (bridge *Bridge) SetDisableSystemDNS(disable bool) error {
+	return bridge.modResolverSettings(func(settings *vault.Resolver) {
+		settings.DisableSystemDNS = disable
+	})
+}
+
+// TestResolver resolves the given host at each DoH provider and reports how each one did.
+func (bridge *Bridge) TestResolver(ctx context.Context, host string) []dialer.LookupResult {
+	return bridge.proxyCtl.TestResolver(ctx, host)
+}
+
+func (bridge *Bridge) modResolverSettings(fn func(*vault.Resolver)) error {
+	settings := bridge.vault.GetResolver()
+
+	fn(&settings)
+
+	if err := bridge.vault.SetResolver(settings); err != nil {
+		return err
+	}
+
+	bridge.proxyCtl.SetResolverSettings(newResolverSettings(settings))
+
+	return nil
+}
+
+func newResolverSettings(settings vault.Resolver) dialer.ResolverSettings {
+	return dialer.ResolverSettings{
+		DoHProviders:     settings.DoHProviders,
+		BootstrapIPs:     settings.BootstrapIPs,
+		DisableSystemDNS: settings.DisableSystemDNS,
+	}
+}
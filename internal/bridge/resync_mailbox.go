@@ -0,0 +1,41 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"context"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/safe"
+	"github.com/sirupsen/logrus"
+)
+
+// ResyncMailbox rebuilds the local state of a single folder or label of the given user from the API,
+// for when one mailbox got out of sync, without resyncing the rest of the account.
+// Clients see the mailbox with a new UIDVALIDITY and redownload only its messages they don't have cached.
+func (bridge *Bridge) ResyncMailbox(ctx context.Context, userID, labelID string) error {
+	logrus.WithField("userID", userID).WithField("labelID", labelID).Info("Resyncing mailbox")
+
+	return safe.RLockRet(func() error {
+		user, ok := bridge.users[userID]
+		if !ok {
+			return ErrNoSuchUser
+		}
+
+		return user.ResyncMailbox(ctx, labelID)
+	}, bridge.usersLock)
+}
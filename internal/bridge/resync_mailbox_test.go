@@ -0,0 +1,90 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/go-proton-api/server"
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
+	"github.com/ProtonMail/proton-bridge/v3/internal/constants"
+	"github.com/ProtonMail/proton-bridge/v3/internal/events"
+	"github.com/ProtonMail/proton-bridge/v3/internal/services/imapservice"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBridge_ResyncMailbox(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		userID, addrID, err := s.CreateUser("resync", password)
+		require.NoError(t, err)
+
+		labelID, err := s.CreateLabel(userID, "folder", "", proton.LabelTypeFolder)
+		require.NoError(t, err)
+
+		withClient(ctx, t, s, "resync", password, func(ctx context.Context, c *proton.Client) {
+			createNumMessages(ctx, t, c, addrID, labelID, 5)
+			createNumMessages(ctx, t, c, addrID, proton.InboxLabel, 3)
+		})
+
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			syncCh, done := chToType[events.Event, events.SyncFinished](b.GetEvents(events.SyncFinished{}))
+			defer done()
+
+			require.Equal(t, userID, must(b.LoginFull(ctx, "resync", password, nil, nil)))
+			require.Equal(t, userID, (<-syncCh).UserID)
+
+			info, err := b.GetUserInfo(userID)
+			require.NoError(t, err)
+
+			client, err := eventuallyDial(fmt.Sprintf("%v:%v", constants.Host, b.GetIMAPPort()))
+			require.NoError(t, err)
+			require.NoError(t, client.Login(info.Addresses[0], string(info.BridgePass)))
+			defer func() { _ = client.Logout() }()
+
+			folder, err := client.Select(`Folders/folder`, false)
+			require.NoError(t, err)
+			require.Equal(t, uint32(5), folder.Messages)
+
+			inbox, err := client.Select(`INBOX`, false)
+			require.NoError(t, err)
+			require.Equal(t, uint32(3), inbox.Messages)
+
+			// Resyncing the folder rebuilds it with the same messages under a new UIDVALIDITY.
+			require.NoError(t, b.ResyncMailbox(ctx, userID, labelID))
+
+			resynced, err := client.Select(`Folders/folder`, false)
+			require.NoError(t, err)
+			require.Equal(t, uint32(5), resynced.Messages)
+			require.NotEqual(t, folder.UidValidity, resynced.UidValidity)
+
+			// The other mailboxes are left alone.
+			untouched, err := client.Select(`INBOX`, false)
+			require.NoError(t, err)
+			require.Equal(t, uint32(3), untouched.Messages)
+			require.Equal(t, inbox.UidValidity, untouched.UidValidity)
+
+			// System mailboxes can be resynced too, except those derived from the whole account.
+			require.NoError(t, b.ResyncMailbox(ctx, userID, proton.InboxLabel))
+			require.ErrorIs(t, b.ResyncMailbox(ctx, userID, proton.AllMailLabel), imapservice.ErrMailboxNotResyncable)
+			require.ErrorIs(t, b.ResyncMailbox(ctx, userID, "no-such-label"), imapservice.ErrNoSuchMailbox)
+		})
+	})
+}
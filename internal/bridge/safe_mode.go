@@ -0,0 +1,192 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"net"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/constants"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+	"github.com/ProtonMail/proton-bridge/v3/pkg/ports"
+	"github.com/sirupsen/logrus"
+)
+
+// safeModeSettings are the ports the servers listen on in safe mode, in place of the saved ones.
+type safeModeSettings struct {
+	imapPort int
+	smtpPort int
+}
+
+func newSafeModeSettings() *safeModeSettings {
+	imapPort := ports.FindFreePortFrom(vault.DefaultIMAPPort)
+	smtpPort := ports.FindFreePortFrom(vault.DefaultSMTPPort, imapPort)
+
+	return &safeModeSettings{
+		imapPort: imapPort,
+		smtpPort: smtpPort,
+	}
+}
+
+// SafeModeOverride is a saved setting which isn't applied in safe mode.
+type SafeModeOverride struct {
+	// Setting is the name of the setting, as in the CLI.
+	Setting string
+
+	// Value is the saved value of the setting.
+	Value string
+
+	// Err is why the saved value can't be applied, if bridge could tell; nil doesn't rule the setting out.
+	Err error
+}
+
+// IsSafeMode returns whether bridge was started in safe mode to recover from a configuration preventing it from
+// starting normally. In safe mode, the index hook, the metrics, the API fixture recording, the unified inbox and the
+// LMTP, POP3 and NNTP servers are disabled, the IMAP and SMTP servers listen on their default ports on localhost,
+// and the IMAP server serves the cached messages read-only. Settings can still be changed; they apply the next time
+// bridge starts normally.
+func (bridge *Bridge) IsSafeMode() bool {
+	return bridge.safeMode != nil
+}
+
+// GetSafeModeOverrides returns the saved settings which aren't applied in safe mode, trying each of them to find out
+// which one prevents bridge from starting normally. It returns nothing unless bridge is in safe mode.
+func (bridge *Bridge) GetSafeModeOverrides() []SafeModeOverride {
+	if bridge.safeMode == nil {
+		return nil
+	}
+
+	var overrides []SafeModeOverride
+
+	add := func(setting, value string, err error) {
+		overrides = append(overrides, SafeModeOverride{Setting: setting, Value: value, Err: err})
+	}
+
+	if port := bridge.vault.GetIMAPPort(); port != bridge.safeMode.imapPort {
+		add("imap-port", strconv.Itoa(port), probeListen([]string{constants.Host}, port))
+	}
+
+	if address := bridge.vault.GetIMAPBindAddress(); address != "" {
+		add("imap-bind-address", address, probeListen([]string{address}, 0))
+	}
+
+	if port := bridge.vault.GetSMTPPort(); port != bridge.safeMode.smtpPort {
+		add("smtp-port", strconv.Itoa(port), probeListen([]string{constants.Host}, port))
+	}
+
+	if address := bridge.vault.GetSMTPBindAddress(); address != "" {
+		add("smtp-bind-address", address, probeListen([]string{address}, 0))
+	}
+
+	if family := bridge.vault.GetIPFamily(); family != vault.IPv4 {
+		add("ip-family", family.String(), probeListen(loopbackHosts(family), 0))
+	}
+
+	if bridge.vault.GetLANAccess().Enabled {
+		add("lan-access", "enabled", probeListen(wildcardHosts(bridge.vault.GetIPFamily()), 0))
+	}
+
+	if bridge.vault.GetLMTPEnabled() {
+		_, err := bridge.locator.ProvideLMTPSocketPath()
+		add("lmtp", "enabled", err)
+	}
+
+	if port := bridge.vault.GetSMTPRelay().Port; port != 0 {
+		add("smtp-relay-port", strconv.Itoa(port), probeListen(wildcardHosts(bridge.vault.GetIPFamily()), port))
+	}
+
+	if port := bridge.vault.GetPOP3().Port; port != 0 {
+		add("pop3-port", strconv.Itoa(port), probeListen([]string{constants.Host}, port))
+	}
+
+	if port := bridge.vault.GetNNTPPort(); port != 0 {
+		add("nntp-port", strconv.Itoa(port), probeListen([]string{constants.Host}, port))
+	}
+
+	if command := bridge.vault.GetIndexHook(); command != "" {
+		add("index-hook", command, probeCommand(command))
+	}
+
+	if address := bridge.vault.GetMetricsListener(); address != "" {
+		add("metrics-listener", address, probeMetricsListener(address))
+	}
+
+	if bridge.vault.GetRecordAPIFixtures() {
+		add("api-fixtures", "enabled", nil)
+	}
+
+	if bridge.vault.GetUnifiedInbox().Enabled {
+		add("unified-inbox", "enabled", nil)
+	}
+
+	return overrides
+}
+
+func (bridge *Bridge) logSafeModeOverrides() {
+	logrus.Warn("Bridge is in safe mode, optional subsystems are disabled and IMAP is read-only")
+
+	for _, override := range bridge.GetSafeModeOverrides() {
+		logrus.WithFields(logrus.Fields{
+			"setting": override.Setting,
+			"value":   override.Value,
+		}).WithError(override.Err).Warn("Setting not applied in safe mode")
+	}
+}
+
+// probeListen returns why a server can't listen on the given hosts at the given port, if it can't.
+func probeListen(hosts []string, port int) error {
+	for _, host := range hosts {
+		listener, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+		if err != nil {
+			return err
+		}
+
+		if err := listener.Close(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// probeCommand returns why the given command line can't be run, if it can't be found.
+func probeCommand(command string) error {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return nil
+	}
+
+	_, err := exec.LookPath(fields[0])
+
+	return err
+}
+
+func probeMetricsListener(address string) error {
+	if err := validateMetricsListener(address); err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+
+	return listener.Close()
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/go-proton-api/server"
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
+	"github.com/ProtonMail/proton-bridge/v3/internal/constants"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBridge_SafeMode(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			require.False(t, b.IsSafeMode())
+			require.Empty(t, b.GetSafeModeOverrides())
+
+			userLoginAndSync(ctx, t, b, username, password)
+
+			// Set up optional subsystems, one of which can't be applied.
+			require.NoError(t, b.SetIndexHook("no-such-indexer --update"))
+			require.NoError(t, b.SetMetricsListener("127.0.0.1:0"))
+			require.NoError(t, b.SetRecordAPIFixtures(true))
+		})
+
+		withSafeModeBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge) {
+			require.True(t, b.IsSafeMode())
+
+			// The subsystems are disabled but their settings are kept.
+			require.Empty(t, b.GetMetricsURL())
+			require.Equal(t, "127.0.0.1:0", b.GetMetricsListener())
+			require.Empty(t, b.GetAPIFixturePath())
+			require.True(t, b.GetRecordAPIFixtures())
+
+			// The overrides tell which setting fails to apply.
+			errs := make(map[string]error)
+
+			for _, override := range b.GetSafeModeOverrides() {
+				errs[override.Setting] = override.Err
+			}
+
+			require.Contains(t, errs, "metrics-listener")
+			require.Contains(t, errs, "api-fixtures")
+			require.Error(t, errs["index-hook"])
+
+			delete(errs, "index-hook")
+
+			for setting, err := range errs {
+				require.NoError(t, err, setting)
+			}
+
+			// The cached messages are served read-only.
+			info, err := b.QueryUserInfo(username)
+			require.NoError(t, err)
+
+			client, err := eventuallyDial(fmt.Sprintf("%v:%v", constants.Host, b.GetIMAPPort()))
+			require.NoError(t, err)
+			require.NoError(t, client.Login(info.Addresses[0], string(info.BridgePass)))
+			defer func() { _ = client.Logout() }()
+
+			_, err = client.Select("INBOX", true)
+			require.NoError(t, err)
+			require.Error(t, client.Create("Folders/Recovered"))
+		})
+
+		// The settings apply again once bridge starts normally.
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			require.False(t, b.IsSafeMode())
+			require.NotEmpty(t, b.GetMetricsURL())
+			require.NotEmpty(t, b.GetAPIFixturePath())
+			require.NoError(t, b.SetRecordAPIFixtures(false))
+		})
+	})
+}
+
+// withSafeModeBridge is the same as withBridgeWaitForServers, but bridge is started in safe mode.
+func withSafeModeBridge(
+	ctx context.Context,
+	t *testing.T,
+	apiURL string,
+	netCtl *proton.NetCtl,
+	locator bridge.Locator,
+	vaultKey []byte,
+	tests func(*bridge.Bridge),
+) {
+	withMocks(t, func(mocks *bridge.Mocks) {
+		withBridgeNoMocks(ctx, t, mocks, apiURL, netCtl, locator, vaultKey, tests, true, true)
+	})
+}
@@ -0,0 +1,62 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"context"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/safe"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+)
+
+// GetSavedSearches returns the saved searches of the given user.
+func (bridge *Bridge) GetSavedSearches(userID string) ([]vault.SavedSearch, error) {
+	return safe.RLockRetErr(func() ([]vault.SavedSearch, error) {
+		user, ok := bridge.users[userID]
+		if !ok {
+			return nil, ErrNoSuchUser
+		}
+
+		return user.GetSavedSearches(), nil
+	}, bridge.usersLock)
+}
+
+// SetSavedSearch creates or replaces a saved search of the given user.
+// The messages matching the query are listed in the read-only IMAP mailbox Virtual/<name>.
+func (bridge *Bridge) SetSavedSearch(ctx context.Context, userID, name, query string) error {
+	return safe.RLockRet(func() error {
+		user, ok := bridge.users[userID]
+		if !ok {
+			return ErrNoSuchUser
+		}
+
+		return user.SetSavedSearch(ctx, name, query)
+	}, bridge.usersLock)
+}
+
+// DeleteSavedSearch removes a saved search of the given user.
+func (bridge *Bridge) DeleteSavedSearch(ctx context.Context, userID, name string) error {
+	return safe.RLockRet(func() error {
+		user, ok := bridge.users[userID]
+		if !ok {
+			return ErrNoSuchUser
+		}
+
+		return user.DeleteSavedSearch(ctx, name)
+	}, bridge.usersLock)
+}
@@ -0,0 +1,94 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/go-proton-api/server"
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
+	"github.com/ProtonMail/proton-bridge/v3/internal/constants"
+	"github.com/emersion/go-imap"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBridge_SavedSearches(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		_, addrID, err := s.CreateUser("user", password)
+		require.NoError(t, err)
+
+		var messageIDs []string
+
+		withClient(ctx, t, s, "user", password, func(ctx context.Context, c *proton.Client) {
+			messageIDs = createNumMessages(ctx, t, c, addrID, proton.InboxLabel, 5)
+
+			// Star two of the messages.
+			require.NoError(t, c.LabelMessages(ctx, messageIDs[:2], proton.StarredLabel))
+		})
+
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			userLoginAndSync(ctx, t, b, "user", password)
+
+			info, err := b.QueryUserInfo("user")
+			require.NoError(t, err)
+
+			// Invalid queries are rejected.
+			require.Error(t, b.SetSavedSearch(ctx, info.UserID, "Broken", "is:nothing"))
+
+			require.NoError(t, b.SetSavedSearch(ctx, info.UserID, "Flagged", "is:flagged"))
+
+			searches, err := b.GetSavedSearches(info.UserID)
+			require.NoError(t, err)
+			require.Len(t, searches, 1)
+
+			client, err := eventuallyDial(fmt.Sprintf("%v:%v", constants.Host, b.GetIMAPPort()))
+			require.NoError(t, err)
+			require.NoError(t, client.Login(info.Addresses[0], string(info.BridgePass)))
+			defer func() { _ = client.Logout() }()
+
+			// The saved search mailbox lists the starred messages.
+			status, err := client.Select("Virtual/Flagged", false)
+			require.NoError(t, err)
+			require.Equal(t, uint32(2), status.Messages)
+
+			// Starring another message adds it to the mailbox.
+			withClient(ctx, t, s, "user", password, func(ctx context.Context, c *proton.Client) {
+				require.NoError(t, c.LabelMessages(ctx, messageIDs[2:3], proton.StarredLabel))
+			})
+
+			require.Eventually(t, func() bool {
+				status, err := client.Status("Virtual/Flagged", []imap.StatusItem{imap.StatusMessages})
+				return err == nil && status.Messages == 3
+			}, 10*time.Second, 100*time.Millisecond)
+
+			// The mailbox is read-only.
+			require.Error(t, client.Append("Virtual/Flagged", nil, time.Now(), strings.NewReader("From: a@b.c\r\n\r\nbody")))
+
+			// Deleting the saved search removes the mailbox.
+			require.NoError(t, b.DeleteSavedSearch(ctx, info.UserID, "Flagged"))
+
+			_, err = client.Select("Virtual/Flagged", false)
+			require.Error(t, err)
+		})
+	})
+}
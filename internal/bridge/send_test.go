@@ -22,6 +22,7 @@ import (
 	"context"
 	"crypto/tls"
 	"fmt"
+	"io"
 	"net"
 	"os"
 	"strings"
@@ -520,3 +521,67 @@ SGVsbG8gd29ybGQK
 		})
 	})
 }
+
+func TestBridge_SendReceipt(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		_, _, err := s.CreateUser("recipient", password)
+		require.NoError(t, err)
+
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			smtpWaiter := waitForSMTPServerReady(b)
+			defer smtpWaiter.Done()
+
+			userID, err := b.LoginFull(ctx, username, password, nil, nil)
+			require.NoError(t, err)
+
+			smtpWaiter.Wait()
+
+			info, err := b.GetUserInfo(userID)
+			require.NoError(t, err)
+
+			sentCh, done := chToType[events.Event, events.UserMessageSent](b.GetEvents(events.UserMessageSent{}))
+			defer done()
+
+			client, err := smtp.Dial(net.JoinHostPort(constants.Host, fmt.Sprint(b.GetSMTPPort())))
+			require.NoError(t, err)
+			defer client.Close() //nolint:errcheck
+
+			require.NoError(t, client.StartTLS(&tls.Config{InsecureSkipVerify: true}))
+			require.NoError(t, client.Auth(sasl.NewPlainClient(info.Addresses[0], info.Addresses[0], string(info.BridgePass))))
+
+			require.NoError(t, client.SendMail(
+				info.Addresses[0],
+				[]string{"recipient@" + s.GetDomain()},
+				strings.NewReader("Message-Id: <receipt@proton.local>\r\nSubject: Receipt\r\n\r\nHello world!"),
+			))
+
+			// The event correlates the client's Message-ID with the sent message.
+			sent := <-sentCh
+			require.Equal(t, userID, sent.UserID)
+			require.Equal(t, "receipt@proton.local", sent.ExternalID)
+			require.Equal(t, []string{"recipient@" + s.GetDomain()}, sent.Recipients)
+			require.NotEmpty(t, sent.MessageID)
+
+			// The Sent copy carries the same IDs.
+			imapClient, err := eventuallyDial(net.JoinHostPort(constants.Host, fmt.Sprint(b.GetIMAPPort())))
+			require.NoError(t, err)
+			require.NoError(t, imapClient.Login(info.Addresses[0], string(info.BridgePass)))
+			defer imapClient.Logout() //nolint:errcheck
+
+			require.Eventually(t, func() bool {
+				status, err := imapClient.Status(`Sent`, []imap.StatusItem{imap.StatusMessages})
+				require.NoError(t, err)
+				return status.Messages == 1
+			}, 10*time.Second, 100*time.Millisecond)
+
+			messages, err := clientFetch(imapClient, `Sent`)
+			require.NoError(t, err)
+			require.Len(t, messages, 1)
+
+			literal, err := io.ReadAll(messages[0].GetBody(must(imap.ParseBodySectionName("BODY[]"))))
+			require.NoError(t, err)
+			require.Contains(t, string(literal), "X-Pm-Internal-Id: "+sent.MessageID)
+			require.Contains(t, string(literal), "X-Pm-External-Id: <receipt@proton.local>")
+		})
+	})
+}
@@ -0,0 +1,54 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"github.com/ProtonMail/proton-bridge/v3/internal/services/imapsmtpserver"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+	"github.com/sirupsen/logrus"
+)
+
+// GetServerLimits returns the limits throttling the IMAP clients.
+func (bridge *Bridge) GetServerLimits() vault.ServerLimits {
+	return bridge.vault.GetServerLimits()
+}
+
+// SetServerLimits sets the limits throttling the IMAP clients; zero values mean no limit.
+// The command rate and the written bytes are limited right away, the connections per user from their next login.
+func (bridge *Bridge) SetServerLimits(limits vault.ServerLimits) error {
+	if limits.CommandsPerSecond < 0 || limits.MaxConnectionsPerUser < 0 || limits.MaxFetchBytes < 0 {
+		return ErrInvalidServerLimits
+	}
+
+	if err := bridge.vault.SetServerLimits(limits); err != nil {
+		return err
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"commandsPerSecond":     limits.CommandsPerSecond,
+		"maxConnectionsPerUser": limits.MaxConnectionsPerUser,
+		"maxFetchBytes":         limits.MaxFetchBytes,
+	}).Info("Server limits changed")
+
+	return nil
+}
+
+// GetServerLimitStats returns how often the limits throttled the IMAP clients since bridge started.
+func (bridge *Bridge) GetServerLimitStats() imapsmtpserver.LimitStats {
+	return bridge.serverManager.GetLimitStats()
+}
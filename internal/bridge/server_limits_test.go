@@ -0,0 +1,74 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/go-proton-api/server"
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
+	"github.com/ProtonMail/proton-bridge/v3/internal/constants"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBridge_ServerLimits_Validate(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			require.ErrorIs(t, b.SetServerLimits(vault.ServerLimits{CommandsPerSecond: -1}), bridge.ErrInvalidServerLimits)
+			require.Equal(t, vault.ServerLimits{}, b.GetServerLimits())
+		})
+	})
+}
+
+func TestBridge_ServerLimits_MaxConnectionsPerUser(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			userID, err := b.LoginFull(ctx, username, password, nil, nil)
+			require.NoError(t, err)
+
+			info, err := b.GetUserInfo(userID)
+			require.NoError(t, err)
+
+			require.NoError(t, b.SetServerLimits(vault.ServerLimits{MaxConnectionsPerUser: 1}))
+
+			first, err := eventuallyDial(fmt.Sprintf("%v:%v", constants.Host, b.GetIMAPPort()))
+			require.NoError(t, err)
+			require.NoError(t, first.Login(info.Addresses[0], string(info.BridgePass)))
+			defer func() { _ = first.Logout() }()
+
+			// The second connection of the user is closed as it logs in.
+			second, err := eventuallyDial(fmt.Sprintf("%v:%v", constants.Host, b.GetIMAPPort()))
+			require.NoError(t, err)
+			_ = second.Login(info.Addresses[0], string(info.BridgePass))
+
+			require.Eventually(t, func() bool {
+				return second.Noop() != nil
+			}, 5*time.Second, 100*time.Millisecond)
+
+			require.Equal(t, uint64(1), b.GetServerLimitStats().RejectedConnections)
+
+			// The first one is kept.
+			require.NoError(t, first.Noop())
+		})
+	})
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/events"
+	"github.com/ProtonMail/proton-bridge/v3/internal/safe"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+	"github.com/sirupsen/logrus"
+)
+
+// SessionInfo describes the Proton session bridge uses for a user, as the Security panel of the web app does.
+type SessionInfo struct {
+	// UID is the session's API ID.
+	UID string
+
+	// CreatedAt is when the session was created, i.e. when the user signed in.
+	CreatedAt time.Time
+
+	// RefreshedAt is when the session's tokens were last refreshed; it is zero if they weren't since sign-in.
+	RefreshedAt time.Time
+
+	// ClientID identifies the client which created the session, and ClientName is its localized name.
+	ClientID   string
+	ClientName string
+}
+
+// GetSessionInfo returns information about the session of the given connected user.
+func (bridge *Bridge) GetSessionInfo(ctx context.Context, userID string) (SessionInfo, error) {
+	return safe.RLockRetErr(func() (SessionInfo, error) {
+		user, ok := bridge.users[userID]
+		if !ok {
+			return SessionInfo{}, ErrNoSuchUser
+		}
+
+		session, err := user.GetSession(ctx)
+		if err != nil {
+			return SessionInfo{}, fmt.Errorf("failed to get session: %w", err)
+		}
+
+		return SessionInfo{
+			UID:         session.UID,
+			CreatedAt:   time.Unix(session.CreateTime, 0),
+			RefreshedAt: user.GetSessionRefreshedAt(),
+			ClientID:    session.ClientID,
+			ClientName:  session.LocalizedClientName,
+		}, nil
+	}, bridge.usersLock)
+}
+
+// RecreateSession revokes the session of the given connected user and signs them in again with a new one,
+// like revoking it from the web app and signing in again, but keeping the user's local data.
+// If signing in fails, the user is left signed out, waiting to sign in again as if their session had expired.
+func (bridge *Bridge) RecreateSession(
+	ctx context.Context,
+	userID string,
+	password []byte,
+	getTOTP func() (string, error),
+	getKeyPass func() ([]byte, error),
+) error {
+	if err := bridge.revokeSession(ctx, userID); err != nil {
+		return err
+	}
+
+	return bridge.ReauthUser(ctx, userID, password, getTOTP, getKeyPass)
+}
+
+// revokeSession signs the given connected user out of the API, keeping their local data for them to sign in again.
+func (bridge *Bridge) revokeSession(ctx context.Context, userID string) error {
+	logrus.WithField("userID", userID).Info("Revoking session")
+
+	return safe.LockRet(func() error {
+		user, ok := bridge.users[userID]
+		if !ok {
+			return ErrNoSuchUser
+		}
+
+		bridge.logoutUser(ctx, user, true, false, false)
+
+		if err := bridge.vault.GetUser(userID, func(user *vault.User) {
+			if err := user.SetReauthRequired(true); err != nil {
+				logrus.WithError(err).Error("Failed to flag user as requiring re-authentication")
+			}
+		}); err != nil {
+			return err
+		}
+
+		bridge.publish(events.UserLoggedOut{
+			UserID: userID,
+		})
+
+		return nil
+	}, bridge.usersLock)
+}
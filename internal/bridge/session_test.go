@@ -0,0 +1,64 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/go-proton-api/server"
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBridge_SessionInfo(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			userID := must(b.LoginFull(ctx, username, password, nil, nil))
+
+			info, err := b.GetSessionInfo(ctx, userID)
+			require.NoError(t, err)
+			require.NotEmpty(t, info.UID)
+			require.False(t, info.CreatedAt.IsZero())
+
+			// Recreating the session signs the user in again with a new one.
+			require.NoError(t, b.RecreateSession(ctx, userID, password, nil, nil))
+
+			newInfo, err := b.GetSessionInfo(ctx, userID)
+			require.NoError(t, err)
+			require.NotEqual(t, info.UID, newInfo.UID)
+
+			userInfo, err := b.GetUserInfo(userID)
+			require.NoError(t, err)
+			require.Equal(t, bridge.Connected, userInfo.State)
+			require.False(t, userInfo.ReauthRequired)
+
+			// If signing in again fails, the user waits to sign in again with their data kept.
+			require.Error(t, b.RecreateSession(ctx, userID, []byte("wrong"), nil, nil))
+
+			userInfo, err = b.GetUserInfo(userID)
+			require.NoError(t, err)
+			require.Equal(t, bridge.SignedOut, userInfo.State)
+			require.True(t, userInfo.ReauthRequired)
+
+			_, err = b.GetSessionInfo(ctx, userID)
+			require.ErrorIs(t, err, bridge.ErrNoSuchUser)
+		})
+	})
+}
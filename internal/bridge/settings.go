@@ -20,8 +20,11 @@ package bridge
 import (
 	"context"
 	"fmt"
+	"net/netip"
+	"strings"
 
 	"github.com/Masterminds/semver/v3"
+	"github.com/ProtonMail/proton-bridge/v3/internal/constants"
 	"github.com/ProtonMail/proton-bridge/v3/internal/safe"
 	"github.com/ProtonMail/proton-bridge/v3/internal/services/userevents"
 	"github.com/ProtonMail/proton-bridge/v3/internal/updater"
@@ -49,7 +52,12 @@ func (bridge *Bridge) SetKeychainApp(helper string) error {
 	return vault.SetHelper(vaultDir, helper)
 }
 
+// GetIMAPPort returns the port of the IMAP server; in safe mode, it's the default port rather than the saved one.
 func (bridge *Bridge) GetIMAPPort() int {
+	if bridge.safeMode != nil {
+		return bridge.safeMode.imapPort
+	}
+
 	return bridge.vault.GetIMAPPort()
 }
 
@@ -67,6 +75,33 @@ func (bridge *Bridge) SetIMAPPort(ctx context.Context, newPort int) error {
 	return bridge.restartIMAP(ctx)
 }
 
+// GetIMAPBindAddress returns the IP address the IMAP server listens on, or an empty string for the default hosts:
+// localhost, or all interfaces with LAN access.
+func (bridge *Bridge) GetIMAPBindAddress() string {
+	return bridge.vault.GetIMAPBindAddress()
+}
+
+// SetIMAPBindAddress sets the IP address the IMAP server listens on, e.g. that of a LAN interface or 0.0.0.0,
+// and restarts it; empty restores the default hosts. Remote clients must still be allowed with SetAllowedClients.
+func (bridge *Bridge) SetIMAPBindAddress(ctx context.Context, address string) error {
+	address, err := parseBindAddress(address)
+	if err != nil {
+		return err
+	}
+
+	if address == bridge.vault.GetIMAPBindAddress() {
+		return nil
+	}
+
+	if err := bridge.vault.SetIMAPBindAddress(address); err != nil {
+		return err
+	}
+
+	logrus.WithField("address", address).Info("IMAP bind address changed")
+
+	return bridge.restartIMAP(ctx)
+}
+
 func (bridge *Bridge) GetIMAPSSL() bool {
 	return bridge.vault.GetIMAPSSL()
 }
@@ -85,7 +120,12 @@ func (bridge *Bridge) SetIMAPSSL(ctx context.Context, newSSL bool) error {
 	return bridge.restartIMAP(ctx)
 }
 
+// GetSMTPPort returns the port of the SMTP server; in safe mode, it's the default port rather than the saved one.
 func (bridge *Bridge) GetSMTPPort() int {
+	if bridge.safeMode != nil {
+		return bridge.safeMode.smtpPort
+	}
+
 	return bridge.vault.GetSMTPPort()
 }
 
@@ -103,6 +143,59 @@ func (bridge *Bridge) SetSMTPPort(ctx context.Context, newPort int) error {
 	return bridge.restartSMTP(ctx)
 }
 
+// GetLMTPEnabled returns whether the LMTP server local delivery agents inject messages through is enabled.
+func (bridge *Bridge) GetLMTPEnabled() bool {
+	return bridge.vault.GetLMTPEnabled()
+}
+
+// GetLMTPSocketPath returns the path of the unix socket the LMTP server listens on when it's enabled.
+func (bridge *Bridge) GetLMTPSocketPath() (string, error) {
+	return bridge.locator.ProvideLMTPSocketPath()
+}
+
+// SetLMTPEnabled sets whether the LMTP server is enabled and restarts it; it's disabled by default.
+// The server doesn't authenticate its clients, so it only listens on a unix socket only accessible by the user:
+// a message delivered to one of the users' addresses is imported as received mail, into the folder named by the
+// address's subaddress, if any, e.g. alice+Work/Reports@pm.me, or else into the inbox.
+func (bridge *Bridge) SetLMTPEnabled(ctx context.Context, enabled bool) error {
+	if enabled == bridge.vault.GetLMTPEnabled() {
+		return nil
+	}
+
+	if err := bridge.vault.SetLMTPEnabled(enabled); err != nil {
+		return err
+	}
+
+	return bridge.restartLMTP(ctx)
+}
+
+// GetSMTPBindAddress returns the IP address the SMTP server listens on, or an empty string for the default hosts:
+// localhost, or all interfaces with LAN access.
+func (bridge *Bridge) GetSMTPBindAddress() string {
+	return bridge.vault.GetSMTPBindAddress()
+}
+
+// SetSMTPBindAddress sets the IP address the SMTP server listens on, e.g. that of a LAN interface or 0.0.0.0,
+// and restarts it; empty restores the default hosts. Remote clients must still be allowed with SetAllowedClients.
+func (bridge *Bridge) SetSMTPBindAddress(ctx context.Context, address string) error {
+	address, err := parseBindAddress(address)
+	if err != nil {
+		return err
+	}
+
+	if address == bridge.vault.GetSMTPBindAddress() {
+		return nil
+	}
+
+	if err := bridge.vault.SetSMTPBindAddress(address); err != nil {
+		return err
+	}
+
+	logrus.WithField("address", address).Info("SMTP bind address changed")
+
+	return bridge.restartSMTP(ctx)
+}
+
 func (bridge *Bridge) GetSMTPSSL() bool {
 	return bridge.vault.GetSMTPSSL()
 }
@@ -121,6 +214,148 @@ func (bridge *Bridge) SetSMTPSSL(ctx context.Context, newSSL bool) error {
 	return bridge.restartSMTP(ctx)
 }
 
+func (bridge *Bridge) GetIPFamily() vault.IPFamily {
+	return bridge.vault.GetIPFamily()
+}
+
+// SetIPFamily sets the IP version the IMAP and SMTP servers listen on, and restarts them.
+func (bridge *Bridge) SetIPFamily(ctx context.Context, family vault.IPFamily) error {
+	if family == bridge.vault.GetIPFamily() {
+		return nil
+	}
+
+	if err := bridge.vault.SetIPFamily(family); err != nil {
+		return err
+	}
+
+	if err := bridge.restartIMAP(ctx); err != nil {
+		return err
+	}
+
+	if err := bridge.restartSMTP(ctx); err != nil {
+		return err
+	}
+
+	return bridge.restartPOP3(ctx)
+}
+
+// GetHost returns the address mail clients should connect to for IMAP.
+func (bridge *Bridge) GetHost() string {
+	return bridge.getClientHost(bridge.vault.GetIMAPBindAddress())
+}
+
+// GetSMTPHost returns the address mail clients should connect to for SMTP.
+func (bridge *Bridge) GetSMTPHost() string {
+	return bridge.getClientHost(bridge.vault.GetSMTPBindAddress())
+}
+
+// getClientHost returns the address mail clients should connect to for a server with the given bind address:
+// the bind address itself, or localhost if the server listens on the default hosts or on all interfaces.
+func (bridge *Bridge) getClientHost(bindAddress string) string {
+	hosts := bridge.getBindHosts(bindAddress)
+	if len(hosts) != 1 {
+		return bridge.getLoopbackHosts()[0]
+	}
+
+	addr, err := netip.ParseAddr(hosts[0])
+	if err != nil {
+		return bridge.getLoopbackHosts()[0]
+	}
+
+	switch {
+	case addr.IsUnspecified() && addr.Is6():
+		return constants.HostIPv6
+
+	case addr.IsUnspecified():
+		return constants.Host
+
+	default:
+		return addr.String()
+	}
+}
+
+// getBindHosts returns the hosts a server with the given bind address listens on; the bind address is ignored in safe mode.
+func (bridge *Bridge) getBindHosts(address string) []string {
+	if address != "" && bridge.safeMode == nil {
+		return []string{address}
+	}
+
+	return bridge.getListenHosts()
+}
+
+// parseBindAddress returns the canonical form of the given bind address, which is empty or an IP address.
+func parseBindAddress(address string) (string, error) {
+	address = strings.TrimSpace(address)
+	if address == "" {
+		return "", nil
+	}
+
+	addr, err := netip.ParseAddr(address)
+	if err != nil || addr.Zone() != "" {
+		return "", fmt.Errorf("%w: %q is not an IP address", ErrInvalidBindAddress, address)
+	}
+
+	return addr.Unmap().String(), nil
+}
+
+// getListenHosts returns the hosts the servers listen on by default: all interfaces with LAN access, except in safe mode,
+// or else localhost.
+func (bridge *Bridge) getListenHosts() []string {
+	if bridge.vault.GetLANAccess().Enabled && bridge.safeMode == nil {
+		return bridge.getWildcardHosts()
+	}
+
+	return bridge.getLoopbackHosts()
+}
+
+// getIPFamily returns the IP version the servers listen on, which is IPv4 in safe mode.
+func (bridge *Bridge) getIPFamily() vault.IPFamily {
+	if bridge.safeMode != nil {
+		return vault.IPv4
+	}
+
+	return bridge.vault.GetIPFamily()
+}
+
+func (bridge *Bridge) getLoopbackHosts() []string {
+	return loopbackHosts(bridge.getIPFamily())
+}
+
+func (bridge *Bridge) getWildcardHosts() []string {
+	return wildcardHosts(bridge.getIPFamily())
+}
+
+func loopbackHosts(family vault.IPFamily) []string {
+	switch family {
+	case vault.DualStack:
+		return []string{constants.Host, constants.HostIPv6}
+
+	case vault.IPv6:
+		return []string{constants.HostIPv6}
+
+	case vault.IPv4:
+		fallthrough
+
+	default:
+		return []string{constants.Host}
+	}
+}
+
+// wildcardHosts returns the addresses of all interfaces.
+// The IPv6 wildcard address also accepts IPv4 clients where the system maps them, so it is used alone in dual stack.
+func wildcardHosts(family vault.IPFamily) []string {
+	switch family {
+	case vault.DualStack, vault.IPv6:
+		return []string{"::"}
+
+	case vault.IPv4:
+		fallthrough
+
+	default:
+		return []string{"0.0.0.0"}
+	}
+}
+
 func (bridge *Bridge) GetGluonCacheDir() string {
 	return bridge.vault.GetGluonCacheDir()
 }
@@ -129,7 +364,16 @@ func (bridge *Bridge) GetGluonDataDir() (string, error) {
 	return bridge.locator.ProvideGluonDataPath()
 }
 
+// SetGluonDir moves the message cache to newGluonDir. It fails if the new location can't hold a copy of the cache.
 func (bridge *Bridge) SetGluonDir(ctx context.Context, newGluonDir string) error {
+	if err := bridge.checkHardenedPath(newGluonDir); err != nil {
+		return err
+	}
+
+	if err := bridge.checkCacheMoveSpace(newGluonDir); err != nil {
+		return err
+	}
+
 	bridge.usersLock.RLock()
 
 	defer func() {
@@ -170,15 +414,15 @@ func (bridge *Bridge) GetProxyAllowed() bool {
 }
 
 func (bridge *Bridge) SetProxyAllowed(allowed bool) error {
-	if allowed {
-		bridge.proxyCtl.AllowProxy()
-	} else {
-		bridge.proxyCtl.DisallowProxy()
+	bridge.heartbeat.SetDoh(allowed)
+
+	if err := bridge.vault.SetProxyAllowed(allowed); err != nil {
+		return err
 	}
 
-	bridge.heartbeat.SetDoh(allowed)
+	bridge.applyProxyAllowed()
 
-	return bridge.vault.SetProxyAllowed(allowed)
+	return nil
 }
 
 func (bridge *Bridge) GetShowAllMail() bool {
@@ -307,7 +551,27 @@ func (bridge *Bridge) SetColorScheme(colorScheme string) error {
 	return bridge.vault.SetColorScheme(colorScheme)
 }
 
+func (bridge *Bridge) GetIndexHook() string {
+	return bridge.vault.GetIndexHook()
+}
+
+// SetIndexHook sets the command (e.g. `notmuch new`) run after new messages have been received.
+// An empty command disables the hook.
+func (bridge *Bridge) SetIndexHook(command string) error {
+	if err := bridge.vault.SetIndexHook(command); err != nil {
+		return err
+	}
+
+	// The hook stays disabled in safe mode; the command runs once bridge starts normally.
+	if bridge.safeMode == nil {
+		bridge.indexHook.SetCommand(command)
+	}
+
+	return nil
+}
+
 // FactoryReset deletes all users, wipes the vault, and deletes all files.
+// The deleted files and the previous vault are kept for RemovalUndoPeriod so that the reset can be undone with UndoRemoval.
 // Note: it does not clear the keychain. The only entry in the keychain is the vault password,
 // which we need at next startup to decrypt the vault.
 func (bridge *Bridge) FactoryReset(ctx context.Context) {
@@ -319,6 +583,19 @@ func (bridge *Bridge) FactoryReset(ctx context.Context) {
 		}
 	}, bridge.usersLock)
 
+	// Keep the data for a while so that the reset can be undone.
+	except := []string{bridge.vault.Path()}
+
+	if removalDir, err := bridge.getRemovalDir(); err != nil {
+		logrus.WithError(err).Error("Failed to get removal directory")
+	} else if paths, err := bridge.locator.PreviewClear(bridge.vault.Path(), removalDir); err != nil {
+		logrus.WithError(err).Error("Failed to list data paths")
+	} else if _, err := bridge.keepRemoval(RemovalKindFactoryReset, "", paths); err != nil {
+		logrus.WithError(err).Error("Failed to keep removed data")
+	} else {
+		except = append(except, removalDir)
+	}
+
 	// Wipe the vault.
 	gluonCacheDir, err := bridge.locator.ProvideGluonCachePath()
 	if err != nil {
@@ -327,8 +604,8 @@ func (bridge *Bridge) FactoryReset(ctx context.Context) {
 		logrus.WithError(err).Error("Failed to reset vault")
 	}
 
-	// Lastly, delete all files except the vault.
-	if err := bridge.locator.Clear(bridge.vault.Path()); err != nil {
+	// Lastly, delete all files except the vault and the removed data.
+	if err := bridge.locator.Clear(except...); err != nil {
 		logrus.WithError(err).Error("Failed to clear data paths")
 	}
 }
@@ -19,13 +19,17 @@ package bridge_test
 
 import (
 	"context"
+	"net"
 	"os"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/ProtonMail/go-proton-api"
 	"github.com/ProtonMail/go-proton-api/server"
 	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
 	"github.com/ProtonMail/proton-bridge/v3/internal/events"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
 	"github.com/stretchr/testify/require"
 )
 
@@ -123,6 +127,142 @@ func TestBridge_Settings_IMAPSSL(t *testing.T) {
 	})
 }
 
+func TestBridge_Settings_IPFamily(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, mocks *bridge.Mocks) {
+			// The servers only start once a user is logged in.
+			must(b.LoginFull(ctx, username, password, nil, nil))
+
+			canDial := func(host string, port int) bool {
+				conn, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+				if err != nil {
+					return false
+				}
+
+				return conn.Close() == nil
+			}
+
+			// By default, the servers listen on IPv4 only.
+			require.Equal(t, vault.IPv4, b.GetIPFamily())
+			require.Equal(t, "127.0.0.1", b.GetHost())
+			require.True(t, canDial("127.0.0.1", b.GetIMAPPort()))
+			require.False(t, canDial("::1", b.GetIMAPPort()))
+
+			// In dual-stack mode, they listen on both.
+			require.NoError(t, b.SetIPFamily(ctx, vault.DualStack))
+
+			for _, port := range []int{b.GetIMAPPort(), b.GetSMTPPort()} {
+				require.True(t, canDial("127.0.0.1", port))
+				require.True(t, canDial("::1", port))
+			}
+
+			// In IPv6 mode, they listen on IPv6 only.
+			require.NoError(t, b.SetIPFamily(ctx, vault.IPv6))
+			require.Equal(t, "::1", b.GetHost())
+
+			for _, port := range []int{b.GetIMAPPort(), b.GetSMTPPort()} {
+				require.False(t, canDial("127.0.0.1", port))
+				require.True(t, canDial("::1", port))
+			}
+		})
+	})
+}
+
+func TestBridge_Settings_BindAddress(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, mocks *bridge.Mocks) {
+			// The servers only start once a user is logged in.
+			must(b.LoginFull(ctx, username, password, nil, nil))
+
+			canDial := func(host string, port int) bool {
+				conn, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+				if err != nil {
+					return false
+				}
+
+				return conn.Close() == nil
+			}
+
+			// By default, the servers listen on the default hosts.
+			require.Empty(t, b.GetIMAPBindAddress())
+			require.Empty(t, b.GetSMTPBindAddress())
+
+			// Only IP addresses are accepted.
+			require.ErrorIs(t, b.SetIMAPBindAddress(ctx, "localhost"), bridge.ErrInvalidBindAddress)
+			require.ErrorIs(t, b.SetSMTPBindAddress(ctx, "192.168.1.300"), bridge.ErrInvalidBindAddress)
+
+			// Each server listens on its own bind address.
+			require.NoError(t, b.SetIMAPBindAddress(ctx, "::1"))
+			require.Equal(t, "::1", b.GetIMAPBindAddress())
+			require.True(t, canDial("::1", b.GetIMAPPort()))
+			require.False(t, canDial("127.0.0.1", b.GetIMAPPort()))
+			require.True(t, canDial("127.0.0.1", b.GetSMTPPort()))
+
+			// Clients are told to connect to the bind address.
+			require.Equal(t, "::1", b.GetHost())
+			require.Equal(t, "127.0.0.1", b.GetSMTPHost())
+
+			require.NoError(t, b.SetSMTPBindAddress(ctx, " 0.0.0.0 "))
+			require.Equal(t, "0.0.0.0", b.GetSMTPBindAddress())
+			require.True(t, canDial("127.0.0.1", b.GetSMTPPort()))
+
+			// Unless it's a wildcard, which localhost is part of.
+			require.Equal(t, "127.0.0.1", b.GetSMTPHost())
+
+			// Clearing the bind address restores the default hosts.
+			require.NoError(t, b.SetIMAPBindAddress(ctx, ""))
+			require.True(t, canDial("127.0.0.1", b.GetIMAPPort()))
+			require.False(t, canDial("::1", b.GetIMAPPort()))
+			require.Equal(t, "127.0.0.1", b.GetHost())
+		})
+	})
+}
+
+func TestBridge_Settings_PrivacyMode(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, mocks *bridge.Mocks) {
+			// By default, privacy mode is disabled.
+			require.Equal(t, vault.PrivacyMode{JitterWindow: vault.DefaultJitterWindow}, b.GetPrivacyMode())
+
+			// The jitter window must be within bounds.
+			require.ErrorIs(t, b.SetPrivacyJitterWindow(time.Millisecond), bridge.ErrInvalidJitterWindow)
+			require.ErrorIs(t, b.SetPrivacyJitterWindow(time.Hour), bridge.ErrInvalidJitterWindow)
+
+			// Enable privacy mode with a short window.
+			require.NoError(t, b.SetPrivacyJitterWindow(time.Second))
+			require.NoError(t, b.SetPrivacyMode(true))
+			require.Equal(t, vault.PrivacyMode{Enabled: true, JitterWindow: time.Second}, b.GetPrivacyMode())
+
+			// API requests still go through, only later.
+			must(b.LoginFull(ctx, username, password, nil, nil))
+		})
+	})
+}
+
+func TestBridge_Settings_Resolver(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, mocks *bridge.Mocks) {
+			// By default, no custom resolver settings are used.
+			require.Equal(t, vault.Resolver{}, b.GetResolverSettings())
+
+			// Invalid providers and bootstrap IPs are rejected.
+			require.ErrorIs(t, b.SetDoHProviders([]string{"http://dns.example.com/dns-query"}), bridge.ErrInvalidDoHProvider)
+			require.ErrorIs(t, b.SetDoHBootstrapIPs([]string{"not an ip"}), bridge.ErrInvalidBootstrapIP)
+
+			// Valid settings are stored.
+			require.NoError(t, b.SetDoHProviders([]string{"https://dns.example.com/dns-query"}))
+			require.NoError(t, b.SetDoHBootstrapIPs([]string{"192.0.2.1", "2001:db8::1"}))
+			require.NoError(t, b.SetDisableSystemDNS(true))
+
+			require.Equal(t, vault.Resolver{
+				DoHProviders:     []string{"https://dns.example.com/dns-query"},
+				BootstrapIPs:     []string{"192.0.2.1", "2001:db8::1"},
+				DisableSystemDNS: true,
+			}, b.GetResolverSettings())
+		})
+	})
+}
+
 func TestBridge_Settings_SMTPPort(t *testing.T) {
 	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
 		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(bridge *bridge.Bridge, mocks *bridge.Mocks) {
@@ -206,3 +346,25 @@ func TestBridge_Settings_FirstStart(t *testing.T) {
 		})
 	})
 }
+
+func TestBridge_Settings_DoNotDisturb(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(bridge *bridge.Bridge, mocks *bridge.Mocks) {
+			dndCh, done := bridge.GetEvents(events.DoNotDisturbChanged{})
+			defer done()
+
+			// By default, do not disturb is not active.
+			require.False(t, bridge.IsDoNotDisturbActive())
+
+			// Turn do not disturb on; the change is broadcast.
+			require.NoError(t, bridge.SetDoNotDisturb(vault.DoNotDisturb{Enabled: true}))
+			require.True(t, bridge.IsDoNotDisturbActive())
+			require.Equal(t, events.DoNotDisturbChanged{Active: true}, <-dndCh)
+
+			// Turn it off again.
+			require.NoError(t, bridge.SetDoNotDisturb(vault.DoNotDisturb{}))
+			require.False(t, bridge.IsDoNotDisturbActive())
+			require.Equal(t, events.DoNotDisturbChanged{Active: false}, <-dndCh)
+		})
+	})
+}
@@ -0,0 +1,238 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/mailto"
+	"github.com/ProtonMail/proton-bridge/v3/internal/services/imapsmtpserver"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+	"github.com/ProtonMail/proton-bridge/v3/pkg/keychain"
+	"github.com/ProtonMail/proton-bridge/v3/pkg/ports"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+// SetupMinFreeSpace is the free space required on the file system of the message cache.
+const SetupMinFreeSpace = 1 << 30
+
+type SetupStep int
+
+const (
+	SetupStepKeychain SetupStep = iota
+	SetupStepPorts
+	SetupStepCache
+	SetupStepClientConfig
+	SetupStepDone
+)
+
+func (step SetupStep) String() string {
+	switch step {
+	case SetupStepKeychain:
+		return "keychain"
+
+	case SetupStepPorts:
+		return "ports"
+
+	case SetupStepCache:
+		return "cache"
+
+	case SetupStepClientConfig:
+		return "client config"
+
+	case SetupStepDone:
+		return "done"
+
+	default:
+		return "unknown"
+	}
+}
+
+// Setup guides the user through the settings needed on first run: the keychain, the IMAP and SMTP ports,
+// the message cache location and, optionally, the configuration of a mail client.
+// Each step is validated here so that all frontends share the same flow; any step may be skipped to keep
+// the current setting.
+type Setup struct {
+	bridge *Bridge
+
+	step     SetupStep
+	stepLock sync.Mutex
+}
+
+// NewSetup returns a setup flow starting at the first step.
+func (bridge *Bridge) NewSetup() *Setup {
+	return &Setup{bridge: bridge}
+}
+
+// Step returns the current step of the setup.
+func (setup *Setup) Step() SetupStep {
+	setup.stepLock.Lock()
+	defer setup.stepLock.Unlock()
+
+	return setup.step
+}
+
+// Keychains returns the keychains which can be selected.
+func (setup *Setup) Keychains() []string {
+	helpers := maps.Keys(keychain.Helpers)
+
+	slices.Sort(helpers)
+
+	return helpers
+}
+
+// SetKeychain selects the keychain used to store the vault key.
+func (setup *Setup) SetKeychain(helper string) error {
+	return setup.doStep(SetupStepKeychain, func() error {
+		if _, ok := keychain.Helpers[helper]; !ok {
+			return ErrNoSuchKeychain
+		}
+
+		return setup.bridge.SetKeychainApp(helper)
+	})
+}
+
+// CheckPorts returns an error if the given ports can't be used for IMAP and SMTP.
+// The ports bridge currently listens on are considered free.
+func (setup *Setup) CheckPorts(imapPort, smtpPort int) error {
+	if imapPort == smtpPort {
+		return ErrSamePorts
+	}
+
+	for port, current := range map[int]int{
+		imapPort: setup.bridge.GetIMAPPort(),
+		smtpPort: setup.bridge.GetSMTPPort(),
+	} {
+		if port == current {
+			continue
+		}
+
+		if !ports.IsPortFree(port) {
+			return fmt.Errorf("%w: %v", ErrPortInUse, port)
+		}
+	}
+
+	return nil
+}
+
+// SetPorts sets the ports of the IMAP and SMTP servers.
+func (setup *Setup) SetPorts(ctx context.Context, imapPort, smtpPort int) error {
+	return setup.doStep(SetupStepPorts, func() error {
+		if err := setup.CheckPorts(imapPort, smtpPort); err != nil {
+			return err
+		}
+
+		if err := setup.bridge.SetIMAPPort(ctx, imapPort); err != nil {
+			return fmt.Errorf("failed to set IMAP port: %w", err)
+		}
+
+		if err := setup.bridge.SetSMTPPort(ctx, smtpPort); err != nil {
+			return fmt.Errorf("failed to set SMTP port: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// CheckCacheDir returns an error if the given directory can't hold the message cache.
+// The directory doesn't need to exist yet, but the file system it would be created on needs SetupMinFreeSpace free.
+func (setup *Setup) CheckCacheDir(dir string) error {
+	free, err := getFreeSpace(dir)
+	if err != nil {
+		return fmt.Errorf("failed to get free space: %w", err)
+	}
+
+	if free < SetupMinFreeSpace {
+		return fmt.Errorf("%w: %v MB free", ErrInsufficientDiskSpace, free>>20)
+	}
+
+	return nil
+}
+
+// SetCacheDir moves the message cache to the given directory.
+func (setup *Setup) SetCacheDir(ctx context.Context, dir string) error {
+	return setup.doStep(SetupStepCache, func() error {
+		if err := setup.CheckCacheDir(dir); err != nil {
+			return err
+		}
+
+		if imapsmtpserver.ApplyGluonCachePathSuffix(dir) == setup.bridge.GetGluonCacheDir() {
+			return nil
+		}
+
+		return setup.bridge.SetGluonDir(ctx, dir)
+	})
+}
+
+// ConfigureClient configures Apple Mail for the given user and address, and binds the user's account to it
+// unless it's bound to another client already.
+func (setup *Setup) ConfigureClient(ctx context.Context, userID, address string) error {
+	return setup.doStep(SetupStepClientConfig, func() error {
+		if err := setup.bridge.ConfigureAppleMail(ctx, userID, address); err != nil {
+			return err
+		}
+
+		if client, err := setup.bridge.GetUserMailClient(userID); err != nil || client.Client != "" {
+			return err
+		}
+
+		return setup.bridge.SetUserMailClient(userID, vault.MailClient{Client: string(mailto.ClientAppleMail)})
+	})
+}
+
+// Skip moves to the next step, keeping the current setting.
+func (setup *Setup) Skip() error {
+	setup.stepLock.Lock()
+	defer setup.stepLock.Unlock()
+
+	if setup.step == SetupStepDone {
+		return ErrSetupDone
+	}
+
+	logrus.WithField("step", setup.step).Info("Skipping setup step")
+
+	setup.step++
+
+	return nil
+}
+
+// doStep runs fn if step is the current step, moving to the next step if it succeeds.
+func (setup *Setup) doStep(step SetupStep, fn func() error) error {
+	setup.stepLock.Lock()
+	defer setup.stepLock.Unlock()
+
+	if setup.step == SetupStepDone {
+		return ErrSetupDone
+	} else if setup.step != step {
+		return fmt.Errorf("%w: expected %v, got %v", ErrWrongSetupStep, setup.step, step)
+	}
+
+	logrus.WithField("step", step).Info("Running setup step")
+
+	if err := fn(); err != nil {
+		return err
+	}
+
+	setup.step++
+
+	return nil
+}
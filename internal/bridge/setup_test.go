@@ -0,0 +1,75 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge_test
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/go-proton-api/server"
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
+	"github.com/ProtonMail/proton-bridge/v3/pkg/ports"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBridge_Setup(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			setup := b.NewSetup()
+			require.Equal(t, bridge.SetupStepKeychain, setup.Step())
+
+			// Steps must be done in order.
+			require.ErrorIs(t, setup.SetPorts(ctx, 1143, 1025), bridge.ErrWrongSetupStep)
+
+			// Only known keychains can be selected.
+			require.ErrorIs(t, setup.SetKeychain("no-such-keychain"), bridge.ErrNoSuchKeychain)
+			require.Equal(t, bridge.SetupStepKeychain, setup.Step())
+			require.NoError(t, setup.Skip())
+
+			// Ports must differ and be free.
+			l, err := net.Listen("tcp", "127.0.0.1:0")
+			require.NoError(t, err)
+			defer func() { _ = l.Close() }()
+
+			usedPort := l.Addr().(*net.TCPAddr).Port //nolint:forcetypeassert
+			imapPort := ports.FindFreePortFrom(1144, usedPort)
+			smtpPort := ports.FindFreePortFrom(imapPort+1, usedPort)
+
+			require.ErrorIs(t, setup.SetPorts(ctx, imapPort, imapPort), bridge.ErrSamePorts)
+			require.ErrorIs(t, setup.SetPorts(ctx, usedPort, smtpPort), bridge.ErrPortInUse)
+			require.NoError(t, setup.SetPorts(ctx, imapPort, smtpPort))
+			require.Equal(t, imapPort, b.GetIMAPPort())
+			require.Equal(t, smtpPort, b.GetSMTPPort())
+
+			// The cache directory may not exist yet.
+			cacheDir := filepath.Join(t.TempDir(), "cache")
+			require.NoError(t, setup.SetCacheDir(ctx, cacheDir))
+			require.Equal(t, filepath.Join(cacheDir, "gluon"), b.GetGluonCacheDir())
+
+			// Client configuration is optional.
+			require.Equal(t, bridge.SetupStepClientConfig, setup.Step())
+			require.NoError(t, setup.Skip())
+
+			require.Equal(t, bridge.SetupStepDone, setup.Step())
+			require.ErrorIs(t, setup.Skip(), bridge.ErrSetupDone)
+		})
+	})
+}
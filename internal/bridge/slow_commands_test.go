@@ -0,0 +1,76 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/go-proton-api/server"
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
+	"github.com/ProtonMail/proton-bridge/v3/internal/constants"
+	"github.com/ProtonMail/proton-bridge/v3/internal/services/imapsmtpserver"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+	"github.com/bradenaw/juniper/xslices"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBridge_SlowCommands(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			require.Equal(t, vault.DefaultSlowCommandThreshold, b.GetSlowCommandThreshold())
+			require.ErrorIs(t, b.SetSlowCommandThreshold(-time.Second), bridge.ErrInvalidSlowCommandThreshold)
+
+			userID, err := b.LoginFull(ctx, username, password, nil, nil)
+			require.NoError(t, err)
+
+			info, err := b.GetUserInfo(userID)
+			require.NoError(t, err)
+
+			// Record every command.
+			require.NoError(t, b.SetSlowCommandThreshold(time.Nanosecond))
+
+			cli, err := eventuallyDial(fmt.Sprintf("%v:%v", constants.Host, b.GetIMAPPort()))
+			require.NoError(t, err)
+			require.NoError(t, cli.Login(info.Addresses[0], string(info.BridgePass)))
+			defer func() { _ = cli.Logout() }()
+
+			_, err = cli.Select("INBOX", false)
+			require.NoError(t, err)
+
+			require.Eventually(t, func() bool {
+				return xslices.IndexFunc(b.GetSlowCommands(), func(command imapsmtpserver.SlowCommand) bool {
+					return command.Command == "SELECT"
+				}) >= 0
+			}, 5*time.Second, 100*time.Millisecond)
+
+			// Appending a message calls the API, which tells the session and thus its selected mailbox.
+			require.NoError(t, cli.Append("INBOX", nil, time.Now(), strings.NewReader("From: someone@example.com\r\nTo: someone@example.com\r\nDate: Mon, 02 Jan 2006 15:04:05 +0000\r\nSubject: Test\r\n\r\nHello")))
+
+			require.Eventually(t, func() bool {
+				return xslices.IndexFunc(b.GetSlowCommands(), func(command imapsmtpserver.SlowCommand) bool {
+					return command.Command == "APPEND" && command.Mailbox == "INBOX" && command.RemoteCall
+				}) >= 0
+			}, 5*time.Second, 100*time.Millisecond)
+		})
+	})
+}
@@ -20,14 +20,24 @@ package bridge
 import (
 	"context"
 	"crypto/tls"
+	"net"
 
 	"github.com/ProtonMail/proton-bridge/v3/internal/identifier"
+	smtpservice "github.com/ProtonMail/proton-bridge/v3/internal/services/smtp"
 )
 
 func (bridge *Bridge) restartSMTP(ctx context.Context) error {
 	return bridge.serverManager.RestartSMTP(ctx)
 }
 
+func (bridge *Bridge) restartLMTP(ctx context.Context) error {
+	return bridge.serverManager.RestartLMTP(ctx)
+}
+
+func (bridge *Bridge) restartRelay(ctx context.Context) error {
+	return bridge.serverManager.RestartRelay(ctx)
+}
+
 type bridgeSMTPSettings struct {
 	b *Bridge
 }
@@ -40,16 +50,71 @@ func (b *bridgeSMTPSettings) Log() bool {
 	return b.b.logSMTP
 }
 
+func (b *bridgeSMTPSettings) Hosts() []string {
+	return b.b.getBindHosts(b.b.vault.GetSMTPBindAddress())
+}
+
 func (b *bridgeSMTPSettings) Port() int {
-	return b.b.vault.GetSMTPPort()
+	return b.b.GetSMTPPort()
 }
 
+// SetPort saves the port the server listens on, unless it's the default one of safe mode.
 func (b *bridgeSMTPSettings) SetPort(i int) error {
+	if b.b.safeMode != nil {
+		return nil
+	}
+
 	return b.b.vault.SetSMTPPort(i)
 }
 
+// LMTPSocketPath returns the path of the socket of the LMTP server, or empty if it's disabled, as it is in safe mode.
+func (b *bridgeSMTPSettings) LMTPSocketPath() (string, error) {
+	if b.b.safeMode != nil || !b.b.vault.GetLMTPEnabled() {
+		return "", nil
+	}
+
+	return b.b.locator.ProvideLMTPSocketPath()
+}
+
+// RelayPort returns the port of the SMTP relay, which is disabled in safe mode.
+func (b *bridgeSMTPSettings) RelayPort() int {
+	if b.b.safeMode != nil {
+		return 0
+	}
+
+	return b.b.vault.GetSMTPRelay().Port
+}
+
+func (b *bridgeSMTPSettings) RelayHosts() []string {
+	return b.b.getWildcardHosts()
+}
+
+func (b *bridgeSMTPSettings) Relay() smtpservice.RelaySettings {
+	relay := b.b.vault.GetSMTPRelay()
+
+	return smtpservice.RelaySettings{
+		Address:            relay.Address,
+		Username:           relay.Username,
+		Password:           relay.Password,
+		MaxMessagesPerHour: relay.MaxMessagesPerHour,
+		MaxRecipients:      relay.MaxRecipients,
+	}
+}
+
+func (b *bridgeSMTPSettings) AllowRelayClient(addr net.Addr) bool {
+	return b.b.allowClient("SMTP relay", b.b.vault.GetSMTPRelay().AllowedClients, addr)
+}
+
 func (b *bridgeSMTPSettings) UseSSL() bool {
-	return b.b.vault.GetSMTPSSL()
+	return b.b.UsesSMTPSSL()
+}
+
+func (b *bridgeSMTPSettings) RequireTLS() bool {
+	return b.b.vault.GetTLSPolicy().RequireTLS
+}
+
+func (b *bridgeSMTPSettings) AllowClient(addr net.Addr) bool {
+	return b.b.AllowClient("SMTP", addr)
 }
 
 func (b *bridgeSMTPSettings) Identifier() identifier.UserAgentUpdater {
@@ -0,0 +1,81 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+	"github.com/sirupsen/logrus"
+)
+
+// GetSMTPRelay returns the settings of the relay through which LAN devices, such as scanners, send mail.
+func (bridge *Bridge) GetSMTPRelay() vault.SMTPRelay {
+	return bridge.vault.GetSMTPRelay()
+}
+
+// SetSMTPRelay sets the settings of the relay and restarts it. When enabled, the relay listens on all interfaces
+// on its own port, accepts the allowed clients only and sends everything from the given address of one of the users.
+// Limits which aren't positive are replaced by the defaults.
+func (bridge *Bridge) SetSMTPRelay(ctx context.Context, relay vault.SMTPRelay) error {
+	if relay.Port < 0 || relay.Port > 65535 {
+		return fmt.Errorf("%w: %v", ErrInvalidRelayPort, relay.Port)
+	}
+
+	allowed, err := normalizeAllowedClients(relay.AllowedClients)
+	if err != nil {
+		return err
+	}
+
+	relay.AllowedClients = allowed
+	relay.Address = strings.TrimSpace(relay.Address)
+
+	if relay.Address == "" {
+		if relay.Port != 0 {
+			return ErrNoRelayAddress
+		}
+	} else if !bridge.hasAddress(relay.Address) {
+		return fmt.Errorf("%w: %q", ErrNoSuchAddress, relay.Address)
+	}
+
+	if relay.Username == "" && relay.Password != "" {
+		return ErrInvalidRelayCredentials
+	}
+
+	if relay.MaxMessagesPerHour <= 0 {
+		relay.MaxMessagesPerHour = vault.DefaultRelayMaxMessagesPerHour
+	}
+
+	if relay.MaxRecipients <= 0 {
+		relay.MaxRecipients = vault.DefaultRelayMaxRecipients
+	}
+
+	if err := bridge.vault.SetSMTPRelay(relay); err != nil {
+		return err
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"port":           relay.Port,
+		"allowedClients": relay.AllowedClients,
+		"auth":           relay.Username != "",
+	}).Info("SMTP relay changed")
+
+	return bridge.restartRelay(ctx)
+}
@@ -0,0 +1,140 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge_test
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/go-proton-api/server"
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
+	"github.com/ProtonMail/proton-bridge/v3/internal/constants"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+	"github.com/ProtonMail/proton-bridge/v3/pkg/ports"
+	"github.com/emersion/go-sasl"
+	"github.com/emersion/go-smtp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBridge_SMTPRelay(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		_, _, err := s.CreateUser("recipient", password)
+		require.NoError(t, err)
+
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			userID, err := b.LoginFull(ctx, username, password, nil, nil)
+			require.NoError(t, err)
+
+			info, err := b.GetUserInfo(userID)
+			require.NoError(t, err)
+
+			// The relay is disabled by default.
+			require.Zero(t, b.GetSMTPRelay().Port)
+
+			port := ports.FindFreePortFrom(2525)
+
+			// The relay must send from an address of one of the users.
+			require.ErrorIs(t, b.SetSMTPRelay(ctx, vault.SMTPRelay{Port: -1}), bridge.ErrInvalidRelayPort)
+			require.ErrorIs(t, b.SetSMTPRelay(ctx, vault.SMTPRelay{Port: port}), bridge.ErrNoRelayAddress)
+			require.ErrorIs(t, b.SetSMTPRelay(ctx, vault.SMTPRelay{Port: port, Address: "nobody@pm.me"}), bridge.ErrNoSuchAddress)
+			require.ErrorIs(t, b.SetSMTPRelay(ctx, vault.SMTPRelay{Port: port, Address: info.Addresses[0], AllowedClients: []string{"nope"}}), bridge.ErrInvalidAllowedClient)
+			require.ErrorIs(t, b.SetSMTPRelay(ctx, vault.SMTPRelay{Port: port, Address: info.Addresses[0], Password: "secret"}), bridge.ErrInvalidRelayCredentials)
+
+			require.NoError(t, b.SetSMTPRelay(ctx, vault.SMTPRelay{
+				Port:               port,
+				AllowedClients:     []string{"192.168.1.0/24"},
+				Address:            info.Addresses[0],
+				Username:           "scanner",
+				Password:           "secret",
+				MaxMessagesPerHour: 1,
+			}))
+
+			// Limits which aren't positive are replaced by the defaults.
+			require.Equal(t, vault.DefaultRelayMaxRecipients, b.GetSMTPRelay().MaxRecipients)
+
+			addr := net.JoinHostPort(constants.Host, strconv.Itoa(port))
+
+			dial := func(startTLS bool) *smtp.Client {
+				var conn net.Conn
+
+				require.Eventually(t, func() bool {
+					conn, err = net.Dial("tcp", addr)
+					return err == nil
+				}, 5*time.Second, 100*time.Millisecond)
+
+				client, err := smtp.NewClient(conn, constants.Host)
+				require.NoError(t, err)
+
+				if startTLS {
+					require.NoError(t, client.StartTLS(&tls.Config{InsecureSkipVerify: true})) //nolint:gosec
+				}
+
+				return client
+			}
+
+			message := "From: Scanner <scanner@printer.lan>\r\nSubject: Scan\r\n\r\nHello\r\n"
+
+			// Credentials are refused before STARTTLS, so they never cross the network in cleartext.
+			plain := dial(false)
+			require.Error(t, plain.Auth(sasl.NewPlainClient("", "scanner", "secret")))
+			require.NoError(t, plain.Close())
+
+			// Devices supporting only LOGIN can authenticate too.
+			login := dial(true)
+			require.NoError(t, login.Auth(sasl.NewLoginClient("scanner", "secret")))
+			require.NoError(t, login.Close())
+
+			client := dial(true)
+			defer client.Close() //nolint:errcheck
+
+			// The devices must authenticate with the relay credentials.
+			require.Error(t, client.Mail("scanner@printer.lan", nil))
+			require.Error(t, client.Auth(sasl.NewPlainClient("", "scanner", "wrong")))
+			require.NoError(t, client.Auth(sasl.NewPlainClient("", "scanner", "secret")))
+
+			// The message is sent from the relay address, whatever sender the device gives.
+			require.NoError(t, client.SendMail("scanner@printer.lan", []string{"recipient@" + s.GetDomain()}, strings.NewReader(message)))
+
+			// Further messages are refused past the hourly limit.
+			require.Error(t, client.SendMail("scanner@printer.lan", []string{"recipient@" + s.GetDomain()}, strings.NewReader(message)))
+
+			withClient(ctx, t, s, username, password, func(ctx context.Context, c *proton.Client) {
+				require.Eventually(t, func() bool {
+					metadata, err := c.GetMessageMetadataPage(ctx, 0, 10, proton.MessageFilter{LabelID: proton.SentLabel})
+					require.NoError(t, err)
+
+					return len(metadata) == 1 && metadata[0].Sender.Address == info.Addresses[0]
+				}, 10*time.Second, 100*time.Millisecond)
+			})
+
+			// Disabling the relay stops the listener.
+			relay := b.GetSMTPRelay()
+			relay.Port = 0
+			require.NoError(t, b.SetSMTPRelay(ctx, relay))
+
+			_, err = net.Dial("tcp", addr)
+			require.Error(t, err)
+		})
+	})
+}
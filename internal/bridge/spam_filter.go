@@ -0,0 +1,57 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/safe"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+	"github.com/sirupsen/logrus"
+)
+
+// GetSpamFilter returns the local spam filter configuration of the given user.
+func (bridge *Bridge) GetSpamFilter(userID string) (vault.SpamFilter, error) {
+	return safe.RLockRetErr(func() (vault.SpamFilter, error) {
+		user, ok := bridge.users[userID]
+		if !ok {
+			return vault.SpamFilter{}, ErrNoSuchUser
+		}
+
+		return user.GetSpamFilter(), nil
+	}, bridge.usersLock)
+}
+
+// SetSpamFilter sets the local spam filter new messages of the given user are passed through.
+func (bridge *Bridge) SetSpamFilter(ctx context.Context, userID string, filter vault.SpamFilter) error {
+	logrus.WithField("userID", userID).WithField("backend", filter.Backend).Info("Setting spam filter")
+
+	return safe.RLockRet(func() error {
+		user, ok := bridge.users[userID]
+		if !ok {
+			return ErrNoSuchUser
+		}
+
+		if err := user.SetSpamFilter(ctx, filter); err != nil {
+			return fmt.Errorf("failed to set spam filter: %w", err)
+		}
+
+		return nil
+	}, bridge.usersLock)
+}
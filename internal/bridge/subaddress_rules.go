@@ -0,0 +1,63 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"context"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/safe"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+)
+
+// GetSubaddressRules returns the rules labelling the new messages of the given user by subaddress tag.
+func (bridge *Bridge) GetSubaddressRules(userID string) ([]vault.SubaddressRule, error) {
+	return safe.RLockRetErr(func() ([]vault.SubaddressRule, error) {
+		user, ok := bridge.users[userID]
+		if !ok {
+			return nil, ErrNoSuchUser
+		}
+
+		return user.GetSubaddressRules(), nil
+	}, bridge.usersLock)
+}
+
+// SetSubaddressRule creates or replaces a rule of the given user labelling new messages sent to subaddresses with
+// the given tag, e.g. user+news@pm.me for news, with the given label. The label is created when the first matching
+// message arrives if it doesn't exist yet.
+func (bridge *Bridge) SetSubaddressRule(ctx context.Context, userID, tag, label string) error {
+	return safe.RLockRet(func() error {
+		user, ok := bridge.users[userID]
+		if !ok {
+			return ErrNoSuchUser
+		}
+
+		return user.SetSubaddressRule(ctx, tag, label)
+	}, bridge.usersLock)
+}
+
+// DeleteSubaddressRule removes the rule of the given user for the given subaddress tag.
+func (bridge *Bridge) DeleteSubaddressRule(ctx context.Context, userID, tag string) error {
+	return safe.RLockRet(func() error {
+		user, ok := bridge.users[userID]
+		if !ok {
+			return ErrNoSuchUser
+		}
+
+		return user.DeleteSubaddressRule(ctx, tag)
+	}, bridge.usersLock)
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/go-proton-api/server"
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
+	"github.com/ProtonMail/proton-bridge/v3/internal/constants"
+	"github.com/ProtonMail/proton-bridge/v3/internal/user"
+	"github.com/emersion/go-imap"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBridge_SubaddressRules(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		_, addrID, err := s.CreateUser("user", password)
+		require.NoError(t, err)
+
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			userLoginAndSync(ctx, t, b, "user", password)
+
+			info, err := b.QueryUserInfo("user")
+			require.NoError(t, err)
+
+			// Invalid tags and labels are rejected.
+			require.Error(t, b.SetSubaddressRule(ctx, info.UserID, "user+news", "News"))
+			require.Error(t, b.SetSubaddressRule(ctx, info.UserID, "news", "Folders/News"))
+
+			require.NoError(t, b.SetSubaddressRule(ctx, info.UserID, "News", "News"))
+
+			rules, err := b.GetSubaddressRules(info.UserID)
+			require.NoError(t, err)
+			require.Len(t, rules, 1)
+			require.Equal(t, "news", rules[0].Tag)
+
+			client, err := eventuallyDial(fmt.Sprintf("%v:%v", constants.Host, b.GetIMAPPort()))
+			require.NoError(t, err)
+			require.NoError(t, client.Login(info.Addresses[0], string(info.BridgePass)))
+			defer func() { _ = client.Logout() }()
+
+			literal := []byte("From: sender@example.com\r\nTo: user+news@pm.me\r\nSubject: Weekly\r\n\r\nbody\r\n")
+
+			// The label doesn't exist yet; it's created when the first message sent to the subaddress arrives.
+			withClient(ctx, t, s, "user", password, func(ctx context.Context, c *proton.Client) {
+				createMessages(ctx, t, c, addrID, proton.InboxLabel, literal)
+			})
+
+			require.Eventually(t, func() bool {
+				status, err := client.Status("Labels/News", []imap.StatusItem{imap.StatusMessages})
+				return err == nil && status.Messages == 1
+			}, 10*time.Second, 100*time.Millisecond)
+
+			// The next message uses the existing label; the message stays in the inbox too.
+			withClient(ctx, t, s, "user", password, func(ctx context.Context, c *proton.Client) {
+				createMessages(ctx, t, c, addrID, proton.InboxLabel, literal)
+			})
+
+			require.Eventually(t, func() bool {
+				status, err := client.Status("Labels/News", []imap.StatusItem{imap.StatusMessages})
+				return err == nil && status.Messages == 2
+			}, 10*time.Second, 100*time.Millisecond)
+
+			status, err := client.Status("INBOX", []imap.StatusItem{imap.StatusMessages})
+			require.NoError(t, err)
+			require.Equal(t, uint32(2), status.Messages)
+
+			withClient(ctx, t, s, "user", password, func(ctx context.Context, c *proton.Client) {
+				labels, err := c.GetLabels(ctx, proton.LabelTypeLabel)
+				require.NoError(t, err)
+				require.Len(t, labels, 1)
+			})
+
+			require.NoError(t, b.DeleteSubaddressRule(ctx, info.UserID, "news"))
+			require.ErrorIs(t, b.DeleteSubaddressRule(ctx, info.UserID, "news"), user.ErrNoSuchSubaddressRule)
+		})
+	})
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"github.com/ProtonMail/proton-bridge/v3/internal/services/imapservice"
+	"github.com/ProtonMail/proton-bridge/v3/internal/services/syncservice"
+)
+
+// MinSyncCacheMemory is the least memory the sync download cache can be given.
+const MinSyncCacheMemory = 16 * syncservice.Megabyte
+
+// GetSyncCacheMemory returns the memory used by each user's sync download cache.
+func (bridge *Bridge) GetSyncCacheMemory() uint64 {
+	return bridge.vault.GetSyncCacheMemory()
+}
+
+// SetSyncCacheMemory sets the memory used by each user's sync download cache, which keeps the messages and
+// attachments downloaded by a sync until they're built; the least recently used ones are evicted beyond it.
+// Zero uses the default. It applies to the users loaded afterwards, e.g. when bridge restarts.
+func (bridge *Bridge) SetSyncCacheMemory(size uint64) error {
+	if size != 0 && size < MinSyncCacheMemory {
+		return ErrInvalidSyncCacheMemory
+	}
+
+	return bridge.vault.SetSyncCacheMemory(size)
+}
+
+// GetSyncCacheSpill returns whether the downloads evicted from the sync download cache are written to disk.
+func (bridge *Bridge) GetSyncCacheSpill() bool {
+	return bridge.vault.GetSyncCacheSpill()
+}
+
+// SetSyncCacheSpill sets whether the downloads evicted from the sync download cache are written to disk, next to
+// the sync state, rather than dropped and downloaded again if a batch is retried. They are encrypted with a key
+// which only lives in memory, and removed when the sync ends. It applies to the users loaded afterwards.
+func (bridge *Bridge) SetSyncCacheSpill(spill bool) error {
+	return bridge.vault.SetSyncCacheSpill(spill)
+}
+
+func (bridge *Bridge) getSyncCacheLimits(syncConfigDir, userID string) syncservice.DownloadCacheLimits {
+	limits := syncservice.DownloadCacheLimits{
+		MaxMemory: bridge.vault.GetSyncCacheMemory(),
+	}
+
+	if bridge.vault.GetSyncCacheSpill() {
+		limits.SpillDir = imapservice.GetSyncCachePath(syncConfigDir, userID)
+	}
+
+	return limits
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/go-proton-api/server"
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
+	"github.com/ProtonMail/proton-bridge/v3/internal/services/imapservice"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBridge_SyncCache(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		_, addrID, err := s.CreateUser("user", password)
+		require.NoError(t, err)
+
+		withClient(ctx, t, s, "user", password, func(ctx context.Context, c *proton.Client) {
+			createNumMessages(ctx, t, c, addrID, proton.InboxLabel, 10)
+		})
+
+		syncConfigDir, err := locator.ProvideIMAPSyncConfigPath()
+		require.NoError(t, err)
+
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			// The cache uses the default memory and drops what it evicts by default.
+			require.Equal(t, vault.DefaultSyncCacheMemory, b.GetSyncCacheMemory())
+			require.False(t, b.GetSyncCacheSpill())
+
+			// The cache can't be too small.
+			require.ErrorIs(t, b.SetSyncCacheMemory(bridge.MinSyncCacheMemory-1), bridge.ErrInvalidSyncCacheMemory)
+
+			require.NoError(t, b.SetSyncCacheMemory(bridge.MinSyncCacheMemory))
+			require.NoError(t, b.SetSyncCacheSpill(true))
+
+			// The settings apply to the users loaded afterwards, which sync fine with them.
+			userLoginAndSync(ctx, t, b, "user", password)
+
+			info, err := b.QueryUserInfo("user")
+			require.NoError(t, err)
+			require.DirExists(t, imapservice.GetSyncCachePath(syncConfigDir, info.UserID))
+
+			// The spill area is removed along with the user's sync handler.
+			require.NoError(t, b.LogoutUser(ctx, info.UserID))
+			require.NoDirExists(t, imapservice.GetSyncCachePath(syncConfigDir, info.UserID))
+		})
+	})
+}
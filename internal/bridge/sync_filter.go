@@ -0,0 +1,60 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/safe"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+	"github.com/sirupsen/logrus"
+)
+
+// GetUserSyncFilter returns the folders and labels of the given user excluded from the local sync.
+func (bridge *Bridge) GetUserSyncFilter(userID string) (vault.SyncFilter, error) {
+	return safe.RLockRetErr(func() (vault.SyncFilter, error) {
+		user, ok := bridge.users[userID]
+		if !ok {
+			return vault.SyncFilter{}, ErrNoSuchUser
+		}
+
+		return user.GetSyncFilter(), nil
+	}, bridge.usersLock)
+}
+
+// SetUserSyncFilter excludes folders and labels of the given user, such as Spam or large archive labels, from
+// the local sync. They may be given by label ID, IMAP path or name and are stored by ID. Excluded mailboxes are
+// still listed over IMAP but kept empty; messages that are only in excluded mailboxes are not downloaded at all.
+// The user is resynced when the filter changes.
+func (bridge *Bridge) SetUserSyncFilter(ctx context.Context, userID string, filter vault.SyncFilter) error {
+	logrus.WithField("userID", userID).WithField("excluded", filter.ExcludedLabelIDs).Info("Setting sync filter")
+
+	return safe.RLockRet(func() error {
+		user, ok := bridge.users[userID]
+		if !ok {
+			return ErrNoSuchUser
+		}
+
+		if err := user.SetSyncFilter(ctx, filter); err != nil {
+			return fmt.Errorf("failed to set sync filter: %w", err)
+		}
+
+		return nil
+	}, bridge.usersLock)
+}
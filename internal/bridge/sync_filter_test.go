@@ -0,0 +1,95 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/go-proton-api/server"
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
+	"github.com/ProtonMail/proton-bridge/v3/internal/constants"
+	"github.com/ProtonMail/proton-bridge/v3/internal/events"
+	"github.com/ProtonMail/proton-bridge/v3/internal/services/imapservice"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBridge_SyncFilter(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		userID, addrID, err := s.CreateUser("filter", password)
+		require.NoError(t, err)
+
+		labelID, err := s.CreateLabel(userID, "archive", "", proton.LabelTypeFolder)
+		require.NoError(t, err)
+
+		withClient(ctx, t, s, "filter", password, func(ctx context.Context, c *proton.Client) {
+			createNumMessages(ctx, t, c, addrID, labelID, 5)
+			createNumMessages(ctx, t, c, addrID, proton.InboxLabel, 3)
+		})
+
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			syncCh, done := chToType[events.Event, events.SyncFinished](b.GetEvents(events.SyncFinished{}))
+			defer done()
+
+			require.Equal(t, userID, must(b.LoginFull(ctx, "filter", password, nil, nil)))
+			require.Equal(t, userID, (<-syncCh).UserID)
+
+			// Nothing is excluded by default.
+			filter, err := b.GetUserSyncFilter(userID)
+			require.NoError(t, err)
+			require.Empty(t, filter.ExcludedLabelIDs)
+
+			// Excluding the folder by its IMAP path resyncs the user and stores the folder by ID.
+			require.NoError(t, b.SetUserSyncFilter(ctx, userID, vault.SyncFilter{ExcludedLabelIDs: []string{"Folders/archive"}}))
+			require.Equal(t, userID, (<-syncCh).UserID)
+
+			filter, err = b.GetUserSyncFilter(userID)
+			require.NoError(t, err)
+			require.Equal(t, []string{labelID}, filter.ExcludedLabelIDs)
+
+			info, err := b.GetUserInfo(userID)
+			require.NoError(t, err)
+
+			client, err := eventuallyDial(fmt.Sprintf("%v:%v", constants.Host, b.GetIMAPPort()))
+			require.NoError(t, err)
+			require.NoError(t, client.Login(info.Addresses[0], string(info.BridgePass)))
+			defer func() { _ = client.Logout() }()
+
+			// The excluded folder is still listed but empty, and its messages are not in All Mail either.
+			folder, err := client.Select(`Folders/archive`, false)
+			require.NoError(t, err)
+			require.Equal(t, uint32(0), folder.Messages)
+
+			allMail, err := client.Select(`All Mail`, false)
+			require.NoError(t, err)
+			require.Equal(t, uint32(3), allMail.Messages)
+
+			inbox, err := client.Select(`INBOX`, false)
+			require.NoError(t, err)
+			require.Equal(t, uint32(3), inbox.Messages)
+
+			// Unknown mailboxes and users are rejected.
+			err = b.SetUserSyncFilter(ctx, userID, vault.SyncFilter{ExcludedLabelIDs: []string{"Folders/nope"}})
+			require.ErrorIs(t, err, imapservice.ErrNoSuchMailbox)
+			require.ErrorIs(t, b.SetUserSyncFilter(ctx, "no-such-user", vault.SyncFilter{}), bridge.ErrNoSuchUser)
+		})
+	})
+}
@@ -0,0 +1,48 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"context"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/safe"
+	"github.com/ProtonMail/proton-bridge/v3/internal/user"
+)
+
+// SnapshotSyncState captures the sync state of the given user: the server's message counts per mailbox, the last
+// processed event and the messages which failed to build. Snapshots taken before and after a resync can be compared
+// with DiffSyncSnapshots when reporting messages missing after sync.
+func (bridge *Bridge) SnapshotSyncState(ctx context.Context, userID string) (user.SyncSnapshot, error) {
+	return safe.RLockRetErr(func() (user.SyncSnapshot, error) {
+		usr, ok := bridge.users[userID]
+		if !ok {
+			return user.SyncSnapshot{}, ErrNoSuchUser
+		}
+
+		return usr.GetSyncSnapshot(ctx)
+	}, bridge.usersLock)
+}
+
+// DiffSyncSnapshots compares two sync snapshots of the same user, the older one first.
+func DiffSyncSnapshots(before, after user.SyncSnapshot) (user.SyncSnapshotDiff, error) {
+	if before.UserID != after.UserID {
+		return user.SyncSnapshotDiff{}, ErrSyncSnapshotMismatch
+	}
+
+	return before.Diff(after), nil
+}
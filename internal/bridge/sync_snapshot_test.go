@@ -0,0 +1,82 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/go-proton-api/server"
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
+	"github.com/ProtonMail/proton-bridge/v3/internal/user"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBridge_SyncSnapshot(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		_, addrID, err := s.CreateUser("user", password)
+		require.NoError(t, err)
+
+		withClient(ctx, t, s, "user", password, func(ctx context.Context, c *proton.Client) {
+			createNumMessages(ctx, t, c, addrID, proton.InboxLabel, 3)
+		})
+
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			userLoginAndSync(ctx, t, b, "user", password)
+
+			info, err := b.QueryUserInfo("user")
+			require.NoError(t, err)
+
+			before, err := b.SnapshotSyncState(ctx, info.UserID)
+			require.NoError(t, err)
+			require.Equal(t, info.UserID, before.UserID)
+			require.NotEmpty(t, before.EventID)
+			require.Equal(t, 3, before.Mailboxes["Inbox"].Total)
+			require.Empty(t, before.FailedMessageIDs)
+
+			// New messages show up in the diff once their events are processed.
+			withClient(ctx, t, s, "user", password, func(ctx context.Context, c *proton.Client) {
+				createNumMessages(ctx, t, c, addrID, proton.InboxLabel, 2)
+			})
+
+			var after user.SyncSnapshot
+
+			require.Eventually(t, func() bool {
+				after, err = b.SnapshotSyncState(ctx, info.UserID)
+				require.NoError(t, err)
+
+				return after.EventID != before.EventID
+			}, 10*time.Second, 100*time.Millisecond)
+
+			diff, err := bridge.DiffSyncSnapshots(before, after)
+			require.NoError(t, err)
+			require.Equal(t, 2, diff.Mailboxes["Inbox"].Total)
+			require.Equal(t, 2, diff.Mailboxes["All Mail"].Total)
+			require.NotContains(t, diff.Mailboxes, "Sent")
+
+			// Snapshots of different users can't be compared.
+			_, err = bridge.DiffSyncSnapshots(before, user.SyncSnapshot{UserID: "other"})
+			require.ErrorIs(t, err, bridge.ErrSyncSnapshotMismatch)
+
+			_, err = b.SnapshotSyncState(ctx, "no-such-user")
+			require.ErrorIs(t, err, bridge.ErrNoSuchUser)
+		})
+	})
+}
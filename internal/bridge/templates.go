@@ -0,0 +1,71 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"github.com/ProtonMail/proton-bridge/v3/internal/safe"
+)
+
+// GetTemplates returns the message templates of the given user, keyed by name.
+func (bridge *Bridge) GetTemplates(userID string) (map[string]string, error) {
+	return safe.RLockRetErr(func() (map[string]string, error) {
+		user, ok := bridge.users[userID]
+		if !ok {
+			return nil, ErrNoSuchUser
+		}
+
+		return user.GetTemplates(), nil
+	}, bridge.usersLock)
+}
+
+// SetTemplate creates or replaces a message template of the given user.
+// The template can then be expanded while sending by adding the template+name@local recipient.
+func (bridge *Bridge) SetTemplate(userID, name, body string) error {
+	return safe.RLockRet(func() error {
+		user, ok := bridge.users[userID]
+		if !ok {
+			return ErrNoSuchUser
+		}
+
+		return user.SetTemplate(name, body)
+	}, bridge.usersLock)
+}
+
+// DeleteTemplate removes a message template of the given user.
+func (bridge *Bridge) DeleteTemplate(userID, name string) error {
+	return safe.RLockRet(func() error {
+		user, ok := bridge.users[userID]
+		if !ok {
+			return ErrNoSuchUser
+		}
+
+		return user.DeleteTemplate(name)
+	}, bridge.usersLock)
+}
+
+// RenderTemplate renders a message template of the given user with the given variables.
+func (bridge *Bridge) RenderTemplate(userID, name string, vars map[string]string) (string, error) {
+	return safe.RLockRetErr(func() (string, error) {
+		user, ok := bridge.users[userID]
+		if !ok {
+			return "", ErrNoSuchUser
+		}
+
+		return user.RenderTemplate(name, vars)
+	}, bridge.usersLock)
+}
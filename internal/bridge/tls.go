@@ -17,10 +17,141 @@
 
 package bridge
 
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/certs"
+	"github.com/ProtonMail/proton-bridge/v3/internal/events"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// TLSCertCheckInterval is how often the expiry of the TLS certificate is checked.
+	TLSCertCheckInterval = 24 * time.Hour
+
+	// TLSCertRenewBefore is how long before its expiry the self-signed TLS certificate is regenerated.
+	TLSCertRenewBefore = 60 * 24 * time.Hour
+
+	// TLSCertWarnBefore is how long before its expiry a TLS certificate provided by the user is warned about.
+	TLSCertWarnBefore = 30 * 24 * time.Hour
+)
+
 func (bridge *Bridge) GetBridgeTLSCert() ([]byte, []byte) {
 	return bridge.vault.GetBridgeTLSCert()
 }
 
+// GetBridgeTLSCertExpiry returns when the TLS certificate served by the IMAP and SMTP servers expires.
+func (bridge *Bridge) GetBridgeTLSCertExpiry() time.Time {
+	return bridge.tlsCert.Load().Leaf.NotAfter
+}
+
+// SetBridgeTLSCertPath makes the servers use the certificate in the given files, which are read again on every check,
+// so that a certificate renewed by another tool, e.g. an ACME client, is picked up without restarting bridge.
 func (bridge *Bridge) SetBridgeTLSCertPath(certPath, keyPath string) error {
-	return bridge.vault.SetBridgeTLSCertPath(certPath, keyPath)
+	if err := bridge.vault.SetBridgeTLSCertPath(certPath, keyPath); err != nil {
+		return err
+	}
+
+	bridge.goTLSCertCheck()
+
+	return nil
+}
+
+// checkTLSCert reloads the TLS certificate and regenerates it if it is self-signed and expires soon.
+// If it was provided by the user, they are warned instead.
+func (bridge *Bridge) checkTLSCert() {
+	cert, err := loadTLSCert(bridge.vault)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to load TLS certificate")
+		return
+	}
+
+	if remaining := time.Until(cert.Leaf.NotAfter); remaining < TLSCertRenewBefore && !bridge.vault.IsBridgeTLSCertCustom() {
+		logrus.WithField("notAfter", cert.Leaf.NotAfter).Warn("TLS certificate expires soon, regenerating it")
+
+		if cert, err = bridge.renewTLSCert(); err != nil {
+			logrus.WithError(err).Error("Failed to regenerate TLS certificate")
+			return
+		}
+
+		bridge.tlsCert.Store(cert)
+
+		bridge.publish(events.TLSCertRenewed{NotAfter: cert.Leaf.NotAfter})
+
+		return
+	} else if remaining < TLSCertWarnBefore {
+		logrus.WithField("notAfter", cert.Leaf.NotAfter).Warn("TLS certificate expires soon")
+
+		bridge.publish(events.TLSCertExpiring{NotAfter: cert.Leaf.NotAfter})
+	}
+
+	if old := bridge.tlsCert.Swap(cert); !bytes.Equal(old.Certificate[0], cert.Certificate[0]) {
+		logrus.WithField("notAfter", cert.Leaf.NotAfter).Info("Reloaded TLS certificate")
+	}
+}
+
+// renewTLSCert generates a new self-signed TLS certificate and stores it in the vault.
+func (bridge *Bridge) renewTLSCert() (*tls.Certificate, error) {
+	template, err := certs.NewTLSTemplate()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create TLS template: %w", err)
+	}
+
+	certPEM, keyPEM, err := certs.GenerateCert(template)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate TLS certificate: %w", err)
+	}
+
+	if err := bridge.vault.SetBridgeTLSCertKey(certPEM, keyPEM); err != nil {
+		return nil, fmt.Errorf("failed to save TLS certificate: %w", err)
+	}
+
+	return loadTLSCert(bridge.vault)
+}
+
+// loadTLSCert loads the TLS certificate for the bridge from the vault, with its parsed leaf.
+func loadTLSCert(vault *vault.Vault) (*tls.Certificate, error) {
+	cert, err := tls.X509KeyPair(vault.GetBridgeTLSCert())
+	if err != nil {
+		return nil, err
+	}
+
+	if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+		return nil, err
+	}
+
+	return &cert, nil
+}
+
+// newTLSConfig returns a TLS config serving the certificate currently held by the given pointer,
+// restricted by the policy returned by getPolicy. Both are read for every connection.
+func newTLSConfig(cert *atomic.Pointer[tls.Certificate], getPolicy func() vault.TLSPolicy) *tls.Config {
+	getCertificate := func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+		return cert.Load(), nil
+	}
+
+	return &tls.Config{
+		GetCertificate: getCertificate,
+		MinVersion:     tls.VersionTLS12,
+
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			policy := getPolicy()
+
+			if policy.MinVersion == 0 {
+				policy.MinVersion = tls.VersionTLS12
+			}
+
+			return &tls.Config{
+				GetCertificate: getCertificate,
+				MinVersion:     policy.MinVersion, //nolint:gosec // Lower versions are only accepted if the user allows them.
+				CipherSuites:   policy.CipherSuites,
+			}, nil
+		},
+	}
 }
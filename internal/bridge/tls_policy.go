@@ -0,0 +1,108 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/exp/slices"
+)
+
+// GetTLSPolicy returns the policy of the TLS connections accepted by the IMAP and SMTP servers.
+func (bridge *Bridge) GetTLSPolicy() vault.TLSPolicy {
+	return bridge.vault.GetTLSPolicy()
+}
+
+// SetTLSPolicy sets the policy of the TLS connections accepted by the IMAP and SMTP servers.
+// The TLS version and cipher suites apply to new connections; the servers are restarted if plaintext is (dis)allowed.
+// Requiring TLS keeps the STARTTLS listeners as they are but refuses to authenticate SMTP and POP3 clients before
+// they upgrade the connection. IMAP logins can't be refused before STARTTLS yet, as gluon has no option for it,
+// so IMAP clients are only protected if the IMAP server is set to SSL.
+func (bridge *Bridge) SetTLSPolicy(ctx context.Context, policy vault.TLSPolicy) error {
+	if err := validateTLSPolicy(policy); err != nil {
+		return err
+	}
+
+	oldPolicy := bridge.vault.GetTLSPolicy()
+
+	if err := bridge.vault.SetTLSPolicy(policy); err != nil {
+		return err
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"minVersion":   policy.MinVersion,
+		"cipherSuites": policy.CipherSuites,
+		"requireTLS":   policy.RequireTLS,
+	}).Info("TLS policy changed")
+
+	if policy.RequireTLS == oldPolicy.RequireTLS {
+		return nil
+	}
+
+	if err := bridge.restartSMTP(ctx); err != nil {
+		return err
+	}
+
+	if err := bridge.restartPOP3(ctx); err != nil {
+		return err
+	}
+
+	return bridge.restartNNTP(ctx)
+}
+
+// UsesIMAPSSL returns whether the IMAP server speaks TLS from the first byte rather than offering STARTTLS.
+func (bridge *Bridge) UsesIMAPSSL() bool {
+	return bridge.vault.GetIMAPSSL()
+}
+
+// UsesSMTPSSL returns whether the SMTP server speaks TLS from the first byte rather than offering STARTTLS.
+func (bridge *Bridge) UsesSMTPSSL() bool {
+	return bridge.vault.GetSMTPSSL()
+}
+
+// validateTLSPolicy checks that the policy only names TLS versions and secure cipher suites that can be restricted.
+func validateTLSPolicy(policy vault.TLSPolicy) error {
+	switch policy.MinVersion {
+	case 0, tls.VersionTLS10, tls.VersionTLS11, tls.VersionTLS12, tls.VersionTLS13:
+
+	default:
+		return fmt.Errorf("%w: %#04x", ErrInvalidTLSVersion, policy.MinVersion)
+	}
+
+	for _, id := range policy.CipherSuites {
+		idx := slices.IndexFunc(tls.CipherSuites(), func(suite *tls.CipherSuite) bool {
+			return suite.ID == id
+		})
+
+		if idx < 0 {
+			return fmt.Errorf("%w: %v", ErrInvalidCipherSuite, tls.CipherSuiteName(id))
+		}
+
+		if suite := tls.CipherSuites()[idx]; !slices.ContainsFunc(suite.SupportedVersions, func(version uint16) bool {
+			return version < tls.VersionTLS13
+		}) {
+			return fmt.Errorf("%w: %v is a TLS 1.3 cipher suite, which can't be restricted", ErrInvalidCipherSuite, suite.Name)
+		}
+	}
+
+	return nil
+}
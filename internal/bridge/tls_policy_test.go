@@ -0,0 +1,98 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge_test
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"testing"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/go-proton-api/server"
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
+	"github.com/ProtonMail/proton-bridge/v3/internal/constants"
+	"github.com/ProtonMail/proton-bridge/v3/internal/events"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+	"github.com/emersion/go-sasl"
+	"github.com/emersion/go-smtp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBridge_TLSPolicy_Validate(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			require.ErrorIs(t, b.SetTLSPolicy(ctx, vault.TLSPolicy{MinVersion: 0x0200}), bridge.ErrInvalidTLSVersion)
+
+			// Insecure and TLS 1.3 cipher suites can't be allowed.
+			require.ErrorIs(t, b.SetTLSPolicy(ctx, vault.TLSPolicy{CipherSuites: []uint16{tls.TLS_RSA_WITH_RC4_128_SHA}}), bridge.ErrInvalidCipherSuite)
+			require.ErrorIs(t, b.SetTLSPolicy(ctx, vault.TLSPolicy{CipherSuites: []uint16{tls.TLS_AES_128_GCM_SHA256}}), bridge.ErrInvalidCipherSuite)
+
+			require.Equal(t, vault.TLSPolicy{}, b.GetTLSPolicy())
+		})
+	})
+}
+
+func TestBridge_TLSPolicy_RequireTLS(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			// The servers run once a user is logged in.
+			readyCh, done := b.GetEvents(events.SMTPServerReady{})
+			defer done()
+
+			userID := must(b.LoginFull(ctx, username, password, nil, nil))
+			waitForEvent(t, readyCh, events.SMTPServerReady{})
+
+			info, err := b.GetUserInfo(userID)
+			require.NoError(t, err)
+
+			// Requiring TLS keeps the STARTTLS listeners, so that clients set up for STARTTLS keep working.
+			require.NoError(t, b.SetTLSPolicy(ctx, vault.TLSPolicy{MinVersion: tls.VersionTLS13, RequireTLS: true}))
+			require.False(t, b.UsesIMAPSSL())
+			require.False(t, b.UsesSMTPSSL())
+
+			waitForEvent(t, readyCh, events.SMTPServerReady{})
+
+			dial := func() *smtp.Client {
+				client, err := smtp.Dial(fmt.Sprintf("%v:%v", constants.Host, b.GetSMTPPort()))
+				require.NoError(t, err)
+
+				return client
+			}
+
+			auth := sasl.NewPlainClient("", info.Addresses[0], string(info.BridgePass))
+
+			// Clients can't authenticate before STARTTLS.
+			client := dial()
+			require.Error(t, client.Auth(auth))
+			require.NoError(t, client.Close())
+
+			// Older TLS versions are refused.
+			client = dial()
+			require.Error(t, client.StartTLS(&tls.Config{InsecureSkipVerify: true, MaxVersion: tls.VersionTLS12})) //nolint:gosec
+			_ = client.Close()
+
+			// After STARTTLS, they can.
+			client = dial()
+			defer client.Close() //nolint:errcheck
+
+			require.NoError(t, client.StartTLS(&tls.Config{InsecureSkipVerify: true})) //nolint:gosec
+			require.NoError(t, client.Auth(auth))
+		})
+	})
+}
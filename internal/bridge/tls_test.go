@@ -0,0 +1,115 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/gluon/async"
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/go-proton-api/server"
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
+	"github.com/ProtonMail/proton-bridge/v3/internal/events"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBridge_TLSCert_RenewSelfSigned(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, vaultKey []byte) {
+		vaultDir, err := locator.ProvideSettingsPath()
+		require.NoError(t, err)
+
+		v, _, err := vault.New(vaultDir, t.TempDir(), vaultKey, async.NoopPanicHandler{})
+		require.NoError(t, err)
+
+		// The self-signed certificate expires soon.
+		certPEM, keyPEM := newTestCert(t, time.Now().Add(10*24*time.Hour))
+		require.NoError(t, v.SetBridgeTLSCertKey(certPEM, keyPEM))
+		require.NoError(t, v.Close())
+
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, vaultKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			// It is regenerated on startup.
+			require.Eventually(t, func() bool {
+				return b.GetBridgeTLSCertExpiry().After(time.Now().Add(bridge.TLSCertRenewBefore))
+			}, 10*time.Second, 100*time.Millisecond)
+
+			newCertPEM, _ := b.GetBridgeTLSCert()
+			require.False(t, bytes.Equal(certPEM, newCertPEM))
+		})
+	})
+}
+
+func TestBridge_TLSCert_WarnCustom(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, vaultKey []byte) {
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, vaultKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			expiringCh, done := b.GetEvents(events.TLSCertExpiring{})
+			defer done()
+
+			// A certificate provided by the user which expires soon is served but can't be renewed.
+			notAfter := time.Now().Add(10 * 24 * time.Hour).Truncate(time.Second)
+			certPEM, keyPEM := newTestCert(t, notAfter)
+
+			dir := t.TempDir()
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "cert.pem"), certPEM, 0o600))
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "key.pem"), keyPEM, 0o600))
+			require.NoError(t, b.SetBridgeTLSCertPath(filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")))
+
+			event, ok := (<-expiringCh).(events.TLSCertExpiring)
+			require.True(t, ok)
+			require.True(t, notAfter.Equal(event.NotAfter))
+
+			require.True(t, notAfter.Equal(b.GetBridgeTLSCertExpiry()))
+
+			newCertPEM, _ := b.GetBridgeTLSCert()
+			require.Equal(t, certPEM, newCertPEM)
+		})
+	})
+}
+
+// newTestCert returns a self-signed certificate for 127.0.0.1 expiring at the given time.
+func newTestCert(t *testing.T, notAfter time.Time) ([]byte, []byte) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &priv.PublicKey, priv)
+	require.NoError(t, err)
+
+	key, err := x509.MarshalECPrivateKey(priv)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: key})
+}
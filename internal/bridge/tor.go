@@ -0,0 +1,186 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/dialer"
+	"github.com/ProtonMail/proton-bridge/v3/internal/events"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+	"github.com/sirupsen/logrus"
+)
+
+// TorCheckInterval is how often the Tor circuit is checked while routing through Tor.
+const TorCheckInterval = 5 * time.Minute
+
+// TorStatus is the state of the connection to the API through Tor.
+type TorStatus int32
+
+const (
+	TorDisabled   TorStatus = iota // The API is reached directly or via alternative routing.
+	TorConnecting                  // Tor was just enabled and the circuit hasn't been checked yet.
+	TorUp                          // The API can be reached through Tor.
+	TorDown                        // The API can't be reached through Tor.
+)
+
+func (status TorStatus) String() string {
+	switch status {
+	case TorDisabled:
+		return "disabled"
+
+	case TorConnecting:
+		return "connecting"
+
+	case TorUp:
+		return "up"
+
+	case TorDown:
+		return "down"
+
+	default:
+		return "unknown"
+	}
+}
+
+// GetTorSettings returns the settings for routing the API traffic through Tor.
+func (bridge *Bridge) GetTorSettings() vault.Tor {
+	return bridge.vault.GetTor()
+}
+
+// GetTorStatus returns the state of the connection to the API through Tor.
+func (bridge *Bridge) GetTorStatus() TorStatus {
+	return TorStatus(bridge.torStatus.Load())
+}
+
+// SetTorEnabled sets whether the API traffic is routed through Tor.
+// Alternative routing is not used while it is, as Tor already takes care of reaching the API.
+func (bridge *Bridge) SetTorEnabled(enabled bool) error {
+	return bridge.modTorSettings(func(settings *vault.Tor) {
+		settings.Enabled = enabled
+	})
+}
+
+// SetTorSOCKSAddress sets the address of the local Tor SOCKS proxy; the default one is used if empty.
+func (bridge *Bridge) SetTorSOCKSAddress(address string) error {
+	if address != "" {
+		if _, _, err := net.SplitHostPort(address); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidSOCKSAddress, err)
+		}
+	}
+
+	return bridge.modTorSettings(func(settings *vault.Tor) {
+		settings.SOCKSAddress = address
+	})
+}
+
+// SetTorOnionHost sets the onion service to use instead of the API host; none is used if empty.
+func (bridge *Bridge) SetTorOnionHost(host string) error {
+	if host != "" {
+		if err := dialer.ValidateOnionHost(host); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidOnionHost, err)
+		}
+	}
+
+	return bridge.modTorSettings(func(settings *vault.Tor) {
+		settings.OnionHost = host
+	})
+}
+
+// CheckTorCircuit checks the Tor circuit right away.
+func (bridge *Bridge) CheckTorCircuit() {
+	bridge.goTorCheck()
+}
+
+func (bridge *Bridge) modTorSettings(fn func(*vault.Tor)) error {
+	settings := bridge.vault.GetTor()
+
+	fn(&settings)
+
+	if err := bridge.vault.SetTor(settings); err != nil {
+		return err
+	}
+
+	bridge.applyTorSettings(settings)
+
+	bridge.goTorCheck()
+
+	return nil
+}
+
+// applyTorSettings passes the Tor settings to the dialer and updates the state accordingly.
+func (bridge *Bridge) applyTorSettings(settings vault.Tor) {
+	bridge.proxyCtl.SetTorSettings(dialer.TorSettings{
+		Enabled:      settings.Enabled,
+		SOCKSAddress: settings.SOCKSAddress,
+		OnionHost:    settings.OnionHost,
+	})
+
+	bridge.applyProxyAllowed()
+
+	if settings.Enabled {
+		bridge.torStatus.Store(int32(TorConnecting))
+	} else {
+		bridge.torStatus.Store(int32(TorDisabled))
+	}
+}
+
+// applyProxyAllowed allows alternative routing if the user allowed it, unless the traffic goes through Tor.
+func (bridge *Bridge) applyProxyAllowed() {
+	if bridge.vault.GetProxyAllowed() && !bridge.vault.GetTor().Enabled {
+		bridge.proxyCtl.AllowProxy()
+	} else {
+		bridge.proxyCtl.DisallowProxy()
+	}
+}
+
+// checkTorCircuit checks that the API can be reached through Tor, trying a new circuit if it can't.
+func (bridge *Bridge) checkTorCircuit(ctx context.Context) {
+	if !bridge.vault.GetTor().Enabled {
+		return
+	}
+
+	err := bridge.proxyCtl.CheckTorCircuit(ctx)
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to reach the API through Tor, trying a new circuit")
+
+		bridge.proxyCtl.NewTorCircuit()
+
+		err = bridge.proxyCtl.CheckTorCircuit(ctx)
+	}
+
+	status := TorUp
+	if err != nil {
+		status = TorDown
+	}
+
+	if TorStatus(bridge.torStatus.Swap(int32(status))) == status {
+		return
+	}
+
+	if err != nil {
+		logrus.WithError(err).Error("The API can't be reached through Tor")
+		bridge.publish(events.TorCircuitDown{Error: err})
+	} else {
+		logrus.Info("The API can be reached through Tor")
+		bridge.publish(events.TorCircuitUp{})
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/go-proton-api/server"
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
+	"github.com/ProtonMail/proton-bridge/v3/internal/events"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBridge_Tor(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, mocks *bridge.Mocks) {
+			upCh, doneUp := b.GetEvents(events.TorCircuitUp{})
+			defer doneUp()
+
+			downCh, doneDown := b.GetEvents(events.TorCircuitDown{})
+			defer doneDown()
+
+			// Tor is disabled by default.
+			require.Equal(t, bridge.TorDisabled, b.GetTorStatus())
+
+			// Invalid settings are rejected.
+			require.ErrorIs(t, b.SetTorSOCKSAddress("localhost"), bridge.ErrInvalidSOCKSAddress)
+			require.ErrorIs(t, b.SetTorOnionHost("mail-api.proton.me"), bridge.ErrInvalidOnionHost)
+
+			// Alternative routing is never used while routing through Tor.
+			mocks.ProxyCtl.EXPECT().DisallowProxy().AnyTimes()
+
+			// Once enabled, the circuit is checked.
+			mocks.ProxyCtl.EXPECT().CheckTorCircuit(gomock.Any()).Return(nil)
+			require.NoError(t, b.SetTorEnabled(true))
+			require.IsType(t, events.TorCircuitUp{}, <-upCh)
+			require.Equal(t, bridge.TorUp, b.GetTorStatus())
+
+			// If the circuit fails, a new one is tried before giving up.
+			gomock.InOrder(
+				mocks.ProxyCtl.EXPECT().CheckTorCircuit(gomock.Any()).Return(errors.New("circuit failed")),
+				mocks.ProxyCtl.EXPECT().NewTorCircuit(),
+				mocks.ProxyCtl.EXPECT().CheckTorCircuit(gomock.Any()).Return(errors.New("circuit failed")),
+			)
+			b.CheckTorCircuit()
+			require.IsType(t, events.TorCircuitDown{}, <-downCh)
+			require.Equal(t, bridge.TorDown, b.GetTorStatus())
+
+			// The settings are stored.
+			require.NoError(t, b.SetTorEnabled(false))
+			require.Equal(t, bridge.TorDisabled, b.GetTorStatus())
+			require.Equal(t, vault.Tor{}, b.GetTorSettings())
+		})
+	})
+}
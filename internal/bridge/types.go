@@ -20,6 +20,7 @@ package bridge
 import (
 	"context"
 
+	"github.com/ProtonMail/proton-bridge/v3/internal/dialer"
 	"github.com/ProtonMail/proton-bridge/v3/internal/updater"
 )
 
@@ -32,12 +33,19 @@ type Locator interface {
 	GetLicenseFilePath() string
 	GetDependencyLicensesLink() string
 	Clear(...string) error
+	PreviewClear(...string) ([]string, error)
 	ProvideIMAPSyncConfigPath() (string, error)
+	ProvideLMTPSocketPath() (string, error)
 }
 
 type ProxyController interface {
 	AllowProxy()
 	DisallowProxy()
+	SetResolverSettings(dialer.ResolverSettings)
+	TestResolver(ctx context.Context, host string) []dialer.LookupResult
+	SetTorSettings(dialer.TorSettings)
+	CheckTorCircuit(ctx context.Context) error
+	NewTorCircuit()
 }
 
 type TLSReporter interface {
@@ -50,7 +58,14 @@ type Autostarter interface {
 	IsEnabled() bool
 }
 
+// MailtoHandler registers bridge as the handler of mailto: URLs.
+type MailtoHandler interface {
+	Register() error
+	Unregister() error
+	IsRegistered() bool
+}
+
 type Updater interface {
 	GetVersionInfo(context.Context, updater.Downloader, updater.Channel) (updater.VersionInfo, error)
-	InstallUpdate(context.Context, updater.Downloader, updater.VersionInfo) error
+	InstallUpdate(context.Context, updater.Downloader, updater.VersionInfo) (updater.Verification, error)
 }
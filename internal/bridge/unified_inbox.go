@@ -0,0 +1,231 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/safe"
+	"github.com/ProtonMail/proton-bridge/v3/internal/services/unifiedinbox"
+	"github.com/ProtonMail/proton-bridge/v3/internal/user"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+	"github.com/ProtonMail/proton-bridge/v3/pkg/algo"
+	"github.com/sirupsen/logrus"
+)
+
+// UnifiedInboxRefreshInterval is how often the unified inbox is refreshed in the absence of new messages.
+const UnifiedInboxRefreshInterval = 5 * time.Minute
+
+// GetUnifiedInboxPassword returns the password used to log in to the unified inbox as unifiedinbox.Username.
+func (bridge *Bridge) GetUnifiedInboxPassword() []byte {
+	pass := bridge.vault.GetUnifiedInbox().Password
+	if len(pass) == 0 {
+		return nil
+	}
+
+	return algo.B64RawEncode(pass)
+}
+
+// ResetUnifiedInboxPassword replaces the password of the unified inbox. Clients logged in with the old one stay so
+// until they log out.
+func (bridge *Bridge) ResetUnifiedInboxPassword() error {
+	if err := bridge.vault.ResetUnifiedInboxPassword(); err != nil {
+		return fmt.Errorf("failed to reset unified inbox password: %w", err)
+	}
+
+	bridge.unifiedInboxLock.Lock()
+	defer bridge.unifiedInboxLock.Unlock()
+
+	if bridge.unifiedInbox != nil {
+		bridge.unifiedInbox.SetPassword(bridge.GetUnifiedInboxPassword())
+	}
+
+	return nil
+}
+
+// GetUnifiedInbox returns whether the unified inbox is exposed.
+func (bridge *Bridge) GetUnifiedInbox() bool {
+	return bridge.vault.GetUnifiedInbox().Enabled
+}
+
+// SetUnifiedInbox sets whether the unified inbox is exposed.
+// When enabled, the inboxes of all logged-in users can be accessed as a single mailbox
+// by logging in to IMAP as unifiedinbox.Username with the password returned by GetUnifiedInboxPassword.
+// The bridge passwords of the users don't grant access to it.
+func (bridge *Bridge) SetUnifiedInbox(ctx context.Context, enabled bool) error {
+	if enabled == bridge.GetUnifiedInbox() {
+		return nil
+	}
+
+	if err := bridge.vault.SetUnifiedInboxEnabled(enabled); err != nil {
+		return fmt.Errorf("failed to set unified inbox: %w", err)
+	}
+
+	return safe.RLockRet(func() error {
+		for _, user := range bridge.users {
+			if enabled {
+				if err := bridge.addUnifiedInboxSource(ctx, user); err != nil {
+					return err
+				}
+			} else {
+				if err := bridge.removeUnifiedInboxSource(ctx, user.ID(), true); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	}, bridge.usersLock)
+}
+
+// addUnifiedInboxSource adds the inbox of the given user to the unified inbox, if enabled and not in safe mode.
+// The unified inbox is added to the IMAP server along with its first user.
+func (bridge *Bridge) addUnifiedInboxSource(ctx context.Context, user *user.User) error {
+	if !bridge.GetUnifiedInbox() || bridge.safeMode != nil {
+		return nil
+	}
+
+	bridge.unifiedInboxLock.Lock()
+	defer bridge.unifiedInboxLock.Unlock()
+
+	if bridge.unifiedInbox == nil {
+		if len(bridge.vault.GetUnifiedInbox().Password) == 0 {
+			if err := bridge.vault.ResetUnifiedInboxPassword(); err != nil {
+				return fmt.Errorf("failed to set unified inbox password: %w", err)
+			}
+		}
+
+		connector := unifiedinbox.NewConnector(bridge.GetUnifiedInboxPassword(), bridge.panicHandler)
+		connector.AddSource(user)
+
+		if err := bridge.serverManager.AddIMAPUser(
+			ctx,
+			connector,
+			unifiedinbox.AddrID,
+			&unifiedInboxIDProvider{vault: bridge.vault},
+			connector,
+		); err != nil {
+			connector.StateClose()
+			return fmt.Errorf("failed to add unified inbox to IMAP server: %w", err)
+		}
+
+		bridge.unifiedInbox = connector
+	} else {
+		bridge.unifiedInbox.AddSource(user)
+	}
+
+	bridge.goUnifiedInbox()
+
+	return nil
+}
+
+// removeUnifiedInboxSource removes the inbox of the given user from the unified inbox.
+// The unified inbox is removed from the IMAP server along with its last user.
+func (bridge *Bridge) removeUnifiedInboxSource(ctx context.Context, userID string, withData bool) error {
+	bridge.unifiedInboxLock.Lock()
+	defer bridge.unifiedInboxLock.Unlock()
+
+	if bridge.unifiedInbox == nil {
+		return nil
+	}
+
+	if err := bridge.unifiedInbox.RemoveSource(ctx, userID); err != nil {
+		logrus.WithError(err).Warn("Failed to remove user messages from unified inbox")
+	}
+
+	if bridge.unifiedInbox.CountSources() > 0 {
+		return nil
+	}
+
+	if err := bridge.serverManager.RemoveIMAPUser(
+		ctx,
+		withData,
+		&unifiedInboxIDProvider{vault: bridge.vault},
+		unifiedinbox.AddrID,
+	); err != nil {
+		return fmt.Errorf("failed to remove unified inbox from IMAP server: %w", err)
+	}
+
+	bridge.unifiedInbox.StateClose()
+	bridge.unifiedInbox = nil
+
+	return nil
+}
+
+// refreshUnifiedInbox brings the unified inbox in line with the inboxes of its users.
+func (bridge *Bridge) refreshUnifiedInbox(ctx context.Context) {
+	bridge.unifiedInboxLock.Lock()
+	connector := bridge.unifiedInbox
+	bridge.unifiedInboxLock.Unlock()
+
+	if connector == nil {
+		return
+	}
+
+	if err := connector.Refresh(ctx); err != nil {
+		logrus.WithError(err).Warn("Failed to refresh unified inbox")
+	}
+}
+
+// closeUnifiedInbox releases the unified inbox once the IMAP server is closed.
+func (bridge *Bridge) closeUnifiedInbox() {
+	bridge.unifiedInboxLock.Lock()
+	defer bridge.unifiedInboxLock.Unlock()
+
+	if bridge.unifiedInbox != nil {
+		bridge.unifiedInbox.StateClose()
+		bridge.unifiedInbox = nil
+	}
+}
+
+// unifiedInboxIDProvider stores the ID and key of the unified inbox gluon database in the vault.
+type unifiedInboxIDProvider struct {
+	vault *vault.Vault
+}
+
+func (p *unifiedInboxIDProvider) GetGluonID(string) (string, bool) {
+	gluonID := p.vault.GetUnifiedInbox().GluonID
+
+	return gluonID, gluonID != ""
+}
+
+func (p *unifiedInboxIDProvider) GetGluonIDs() map[string]string {
+	if gluonID, ok := p.GetGluonID(unifiedinbox.AddrID); ok {
+		return map[string]string{unifiedinbox.AddrID: gluonID}
+	}
+
+	return map[string]string{}
+}
+
+func (p *unifiedInboxIDProvider) SetGluonID(_, gluonID string) error {
+	return p.vault.SetUnifiedInboxGluonID(gluonID)
+}
+
+func (p *unifiedInboxIDProvider) RemoveGluonID(_, gluonID string) error {
+	if current := p.vault.GetUnifiedInbox().GluonID; current != gluonID {
+		return fmt.Errorf("gluon ID mismatch: %s != %s", current, gluonID)
+	}
+
+	return p.vault.SetUnifiedInboxGluonID("")
+}
+
+func (p *unifiedInboxIDProvider) GluonKey() []byte {
+	return p.vault.GetUnifiedInbox().GluonKey
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/gluon/imap"
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/go-proton-api/server"
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
+	"github.com/ProtonMail/proton-bridge/v3/internal/constants"
+	"github.com/ProtonMail/proton-bridge/v3/internal/services/unifiedinbox"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBridge_UnifiedInbox(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		// Create two users, each with messages in their inbox.
+		_, addrID1, err := s.CreateUser("user1", password)
+		require.NoError(t, err)
+
+		_, addrID2, err := s.CreateUser("user2", password)
+		require.NoError(t, err)
+
+		withClient(ctx, t, s, "user1", password, func(ctx context.Context, c *proton.Client) {
+			createNumMessages(ctx, t, c, addrID1, proton.InboxLabel, 3)
+		})
+
+		withClient(ctx, t, s, "user2", password, func(ctx context.Context, c *proton.Client) {
+			createNumMessages(ctx, t, c, addrID2, proton.InboxLabel, 2)
+		})
+
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			userLoginAndSync(ctx, t, b, "user1", password)
+			userLoginAndSync(ctx, t, b, "user2", password)
+
+			// The unified inbox is disabled by default.
+			require.False(t, b.GetUnifiedInbox())
+			require.NoError(t, b.SetUnifiedInbox(ctx, true))
+			require.True(t, b.GetUnifiedInbox())
+
+			info, err := b.QueryUserInfo("user2")
+			require.NoError(t, err)
+
+			client, err := eventuallyDial(fmt.Sprintf("%v:%v", constants.Host, b.GetIMAPPort()))
+			require.NoError(t, err)
+			defer func() { _ = client.Logout() }()
+
+			// The bridge password of a single user doesn't grant access to the unified inbox; its own password does.
+			require.Error(t, client.Login(unifiedinbox.Username, string(info.BridgePass)))
+			require.NoError(t, client.Login(unifiedinbox.Username, string(b.GetUnifiedInboxPassword())))
+
+			// The inbox contains the messages of both users.
+			require.Eventually(t, func() bool {
+				status, err := client.Select(imap.Inbox, false)
+				require.NoError(t, err)
+
+				return status.Messages == 5
+			}, 10*time.Second, 100*time.Millisecond)
+
+			// Messages cannot be appended.
+			require.Error(t, client.Append(imap.Inbox, nil, time.Now(), strings.NewReader("From: a@b.c\r\n\r\nbody")))
+		})
+	})
+}
@@ -35,6 +35,17 @@ func (bridge *Bridge) InstallUpdate(version updater.VersionInfo) {
 	bridge.installCh <- installJob{version: version, silent: false}
 }
 
+// GetUpdateVerification returns the chain of checks passed by the running version when the updater installed it.
+func (bridge *Bridge) GetUpdateVerification() (updater.Verification, error) {
+	verification := bridge.vault.GetUpdateVerification()
+
+	if verification.Version != bridge.curVersion.String() {
+		return updater.Verification{}, ErrNoUpdateVerification
+	}
+
+	return verification, nil
+}
+
 func (bridge *Bridge) handleUpdate(version updater.VersionInfo) {
 	log := logrus.WithFields(logrus.Fields{
 		"version": version.Version,
@@ -112,7 +123,7 @@ func (bridge *Bridge) installUpdate(ctx context.Context, job installJob) {
 			Silent:  job.silent,
 		})
 
-		err := bridge.updater.InstallUpdate(ctx, bridge.api, job.version)
+		verification, err := bridge.updater.InstallUpdate(ctx, bridge.api, job.version)
 
 		switch {
 		case errors.Is(err, updater.ErrUpdateAlreadyInstalled):
@@ -130,6 +141,10 @@ func (bridge *Bridge) installUpdate(ctx context.Context, job installJob) {
 		default:
 			log.Info("The update was installed successfully")
 
+			if err := bridge.vault.SetUpdateVerification(verification); err != nil {
+				log.WithError(err).Error("Failed to store the update verification")
+			}
+
 			bridge.publish(events.UpdateInstalled{
 				Version: job.version,
 				Silent:  job.silent,
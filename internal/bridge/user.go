@@ -22,6 +22,7 @@ import (
 	"errors"
 	"fmt"
 	"runtime"
+	"time"
 
 	"github.com/ProtonMail/gluon/async"
 	"github.com/ProtonMail/gluon/imap"
@@ -30,6 +31,7 @@ import (
 	"github.com/ProtonMail/proton-bridge/v3/internal/events"
 	"github.com/ProtonMail/proton-bridge/v3/internal/logging"
 	"github.com/ProtonMail/proton-bridge/v3/internal/safe"
+	"github.com/ProtonMail/proton-bridge/v3/internal/secret"
 	"github.com/ProtonMail/proton-bridge/v3/internal/services/imapservice"
 	"github.com/ProtonMail/proton-bridge/v3/internal/try"
 	"github.com/ProtonMail/proton-bridge/v3/internal/user"
@@ -56,6 +58,9 @@ type UserInfo struct {
 	// Signed Out is true if the user is signed out (no AuthUID, user will need to provide credentials to log in again)
 	State UserState
 
+	// ReauthRequired is true if the user was signed out because their session expired; their local data is kept.
+	ReauthRequired bool
+
 	// Addresses holds the user's email addresses. The first address is the primary address.
 	Addresses []string
 
@@ -70,11 +75,14 @@ type UserInfo struct {
 
 	// MaxSpace is the total amount of space available to the user.
 	MaxSpace int
+
+	// Display holds how frontends render the user's account.
+	Display vault.DisplayMetadata
 }
 
-// GetUserIDs returns the IDs of all known users (authorized or not).
+// GetUserIDs returns the IDs of all known users (authorized or not), in the order their accounts are listed.
 func (bridge *Bridge) GetUserIDs() []string {
-	return bridge.vault.GetUserIDs()
+	return bridge.sortUserIDs(bridge.vault.GetUserIDs())
 }
 
 // HasUser returns true iff the given user is known (authorized or not).
@@ -97,6 +105,8 @@ func (bridge *Bridge) GetUserInfo(userID string) (UserInfo, error) {
 				state = SignedOut
 			}
 			info = getUserInfo(user.UserID(), user.Username(), user.PrimaryEmail(), state, user.AddressMode())
+			info.ReauthRequired = state == SignedOut && user.ReauthRequired()
+			info.Display = user.DisplayMetadata()
 		}); err != nil {
 			return UserInfo{}, fmt.Errorf("failed to get user info: %w", err)
 		}
@@ -241,6 +251,7 @@ func (bridge *Bridge) LogoutUser(ctx context.Context, userID string) error {
 }
 
 // DeleteUser deletes the given user.
+// The user's data is kept for RemovalUndoPeriod so that the deletion can be undone with UndoRemoval.
 func (bridge *Bridge) DeleteUser(ctx context.Context, userID string) error {
 	logrus.WithField("userID", userID).Info("Deleting user")
 
@@ -258,6 +269,17 @@ func (bridge *Bridge) DeleteUser(ctx context.Context, userID string) error {
 			bridge.logoutUser(ctx, user, true, true, !bridge.GetTelemetryDisabled())
 		}
 
+		if err := bridge.serverManager.RemoveSuspendedSMTPAccount(ctx, userID); err != nil {
+			logrus.WithError(err).Error("Failed to remove suspended SMTP account")
+		}
+
+		// Keep the user's data for a while so that the removal can be undone.
+		if paths, err := bridge.getUserDataPaths(userID); err != nil {
+			logrus.WithError(err).Error("Failed to list user data")
+		} else if _, err := bridge.keepRemoval(RemovalKindUser, userID, paths); err != nil {
+			logrus.WithError(err).Error("Failed to keep removed user data")
+		}
+
 		if err := imapservice.DeleteSyncState(syncConfigDir, userID); err != nil {
 			return fmt.Errorf("failed to delete use sync config")
 		}
@@ -314,6 +336,10 @@ func (bridge *Bridge) SetAddressMode(ctx context.Context, userID string, mode va
 func (bridge *Bridge) SendBadEventUserFeedback(_ context.Context, userID string, doResync bool) error {
 	logrus.WithField("userID", userID).WithField("doResync", doResync).Info("Passing bad event feedback to user")
 
+	if bridge.errorCenter.resolve(userID, ErrorCodeBadEvent) {
+		bridge.goAnnounce()
+	}
+
 	return safe.LockRet(func() error {
 		ctx := context.Background()
 
@@ -373,6 +399,8 @@ func (bridge *Bridge) loginUser(ctx context.Context, client *proton.Client, auth
 		return "", fmt.Errorf("failed to salt key password: %w", err)
 	}
 
+	defer secret.Wipe(saltedKeyPass)
+
 	if userKR, err := apiUser.Keys.Unlock(saltedKeyPass, nil); err != nil {
 		return "", fmt.Errorf("failed to unlock user keys: %w", err)
 	} else if userKR.CountDecryptionEntities() == 0 {
@@ -447,12 +475,19 @@ func (bridge *Bridge) loadUser(ctx context.Context, user *vault.User) error {
 		return fmt.Errorf("failed to set auth: %w", err)
 	}
 
+	if err := user.SetAuthRefreshedAt(time.Now()); err != nil {
+		return fmt.Errorf("failed to set auth refresh time: %w", err)
+	}
+
 	apiUser, err := client.GetUser(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to get user: %w", err)
 	}
 
-	if err := bridge.addUser(ctx, client, apiUser, auth.UID, auth.RefreshToken, user.KeyPass(), false); err != nil {
+	keyPass := user.KeyPass()
+	defer secret.Wipe(keyPass)
+
+	if err := bridge.addUser(ctx, client, apiUser, auth.UID, auth.RefreshToken, keyPass, false); err != nil {
 		return fmt.Errorf("failed to add user: %w", err)
 	}
 
@@ -533,13 +568,21 @@ func (bridge *Bridge) addUserWithVault(
 		apiUser,
 		bridge.panicHandler,
 		bridge.vault.GetShowAllMail(),
+		bridge.clientShims,
+		bridge.redactedMessages,
 		bridge.vault.GetMaxSyncMemory(),
+		bridge.getSyncCacheLimits(syncSettingsPath, apiUser.ID),
 		statsPath,
 		bridge,
 		bridge.serverManager,
 		bridge.serverManager,
 		&bridgeEventSubscription{b: bridge},
-		bridge.syncService,
+		&diskSpaceRegulator{
+			b:         bridge,
+			userID:    apiUser.ID,
+			usedSpace: apiUser.UsedSpace,
+			regulator: &dndRegulator{b: bridge, regulator: bridge.syncService},
+		},
 		syncSettingsPath,
 	)
 	if err != nil {
@@ -555,6 +598,11 @@ func (bridge *Bridge) addUserWithVault(
 				"event":  event,
 			}).Debug("Received user event")
 
+			// Notifications are dropped while do not disturb is active.
+			if _, ok := event.(events.UserMessageNotification); ok && bridge.IsDoNotDisturbActive() {
+				return
+			}
+
 			bridge.handleUserEvent(ctx, user, event)
 			bridge.publish(event)
 		})
@@ -579,6 +627,13 @@ func (bridge *Bridge) addUserWithVault(
 	// As we need at least one user to send heartbeat, try to send it.
 	defer bridge.goHeartbeat()
 
+	// Post the current announcements to the new user's Proton Bridge mailbox.
+	defer bridge.goAnnounce()
+
+	if err := bridge.addUnifiedInboxSource(ctx, user); err != nil {
+		logrus.WithError(err).Error("Failed to add user to unified inbox")
+	}
+
 	return nil
 }
 
@@ -607,6 +662,10 @@ func (bridge *Bridge) logoutUser(ctx context.Context, user *user.User, withAPI,
 		"withData": withData,
 	}).Debug("Logging out user")
 
+	if err := bridge.removeUnifiedInboxSource(ctx, user.ID(), false); err != nil {
+		logrus.WithError(err).Error("Failed to remove user from unified inbox")
+	}
+
 	if err := user.Logout(ctx, withAPI); err != nil {
 		logrus.WithError(err).Error("Failed to logout user")
 	}
@@ -643,6 +702,7 @@ func getConnUserInfo(user *user.User) UserInfo {
 		BridgePass:  user.BridgePass(),
 		UsedSpace:   user.UsedSpace(),
 		MaxSpace:    user.MaxSpace(),
+		Display:     user.GetDisplayMetadata(),
 	}
 }
 
@@ -0,0 +1,122 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/events"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	maxNicknameLength = 64
+	maxInitialsLength = 2
+)
+
+var reDisplayColor = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+// GetUserDisplayMetadata returns how frontends render the given user's account.
+// It is also available for signed out users, whose accounts are still listed.
+func (bridge *Bridge) GetUserDisplayMetadata(userID string) (vault.DisplayMetadata, error) {
+	var display vault.DisplayMetadata
+
+	if err := bridge.vault.GetUser(userID, func(user *vault.User) {
+		display = user.DisplayMetadata()
+	}); err != nil {
+		return vault.DisplayMetadata{}, ErrNoSuchUser
+	}
+
+	return display, nil
+}
+
+// SetUserDisplayMetadata sets how frontends render the given user's account: its nickname, color as #RRGGBB,
+// avatar initials and sort order. It is stored in the vault so that every frontend renders the account the same
+// way, and a UserChanged event is published so that they refresh it.
+func (bridge *Bridge) SetUserDisplayMetadata(userID string, display vault.DisplayMetadata) error {
+	logrus.WithField("userID", userID).WithField("display", display).Info("Setting user display metadata")
+
+	if err := validateDisplayMetadata(display); err != nil {
+		return err
+	}
+
+	var err error
+
+	if getErr := bridge.vault.GetUser(userID, func(user *vault.User) {
+		err = user.SetDisplayMetadata(display)
+	}); getErr != nil {
+		return ErrNoSuchUser
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to set display metadata: %w", err)
+	}
+
+	bridge.publish(events.UserChanged{UserID: userID})
+
+	return nil
+}
+
+// sortUserIDs sorts the given user IDs by the sort order of their accounts, keeping the vault order for ties.
+func (bridge *Bridge) sortUserIDs(userIDs []string) []string {
+	order := make(map[string]int, len(userIDs))
+
+	for _, userID := range userIDs {
+		if err := bridge.vault.GetUser(userID, func(user *vault.User) {
+			order[userID] = user.DisplayMetadata().SortOrder
+		}); err != nil {
+			logrus.WithError(err).WithField("userID", userID).Warn("Failed to get user sort order")
+		}
+	}
+
+	sort.SliceStable(userIDs, func(i, j int) bool {
+		return order[userIDs[i]] < order[userIDs[j]]
+	})
+
+	return userIDs
+}
+
+func validateDisplayMetadata(display vault.DisplayMetadata) error {
+	if utf8.RuneCountInString(display.Nickname) > maxNicknameLength {
+		return fmt.Errorf("%w: the nickname can't be longer than %d characters", ErrInvalidDisplayMetadata, maxNicknameLength)
+	}
+
+	if strings.IndexFunc(display.Nickname, unicode.IsControl) >= 0 {
+		return fmt.Errorf("%w: the nickname can't contain control characters", ErrInvalidDisplayMetadata)
+	}
+
+	if display.Color != "" && !reDisplayColor.MatchString(display.Color) {
+		return fmt.Errorf("%w: the color must be formatted as #RRGGBB", ErrInvalidDisplayMetadata)
+	}
+
+	if utf8.RuneCountInString(display.Initials) > maxInitialsLength {
+		return fmt.Errorf("%w: the initials can't be longer than %d characters", ErrInvalidDisplayMetadata, maxInitialsLength)
+	}
+
+	if strings.IndexFunc(display.Initials, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) >= 0 {
+		return fmt.Errorf("%w: the initials can only contain letters and digits", ErrInvalidDisplayMetadata)
+	}
+
+	return nil
+}
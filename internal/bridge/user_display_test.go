@@ -0,0 +1,98 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package bridge_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/go-proton-api/server"
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
+	"github.com/ProtonMail/proton-bridge/v3/internal/events"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBridge_UserDisplayMetadata(t *testing.T) {
+	withEnv(t, func(ctx context.Context, s *server.Server, netCtl *proton.NetCtl, locator bridge.Locator, storeKey []byte) {
+		_, _, err := s.CreateUser("user1", password)
+		require.NoError(t, err)
+
+		_, _, err = s.CreateUser("user2", password)
+		require.NoError(t, err)
+
+		withBridge(ctx, t, s.GetHostURL(), netCtl, locator, storeKey, func(b *bridge.Bridge, _ *bridge.Mocks) {
+			userID1, err := b.LoginFull(ctx, "user1", password, nil, nil)
+			require.NoError(t, err)
+
+			userID2, err := b.LoginFull(ctx, "user2", password, nil, nil)
+			require.NoError(t, err)
+
+			// Frontends use their defaults, and accounts are listed in the order they were added.
+			display, err := b.GetUserDisplayMetadata(userID1)
+			require.NoError(t, err)
+			require.Equal(t, vault.DisplayMetadata{}, display)
+			require.Equal(t, []string{userID1, userID2}, b.GetUserIDs())
+
+			// Invalid metadata is rejected.
+			for _, invalid := range []vault.DisplayMetadata{
+				{Nickname: strings.Repeat("a", 65)},
+				{Nickname: "tab\tbed"},
+				{Color: "red"},
+				{Color: "#12345"},
+				{Initials: "ABC"},
+				{Initials: "A."},
+			} {
+				require.ErrorIs(t, b.SetUserDisplayMetadata(userID1, invalid), bridge.ErrInvalidDisplayMetadata)
+			}
+
+			// Setting the metadata tells frontends to refresh the account.
+			changedCh, done := chToType[events.Event, events.UserChanged](b.GetEvents(events.UserChanged{}))
+			defer done()
+
+			want := vault.DisplayMetadata{Nickname: "Work ✉", Color: "#8A6EFF", Initials: "WÖ", SortOrder: -1}
+			require.NoError(t, b.SetUserDisplayMetadata(userID2, want))
+			require.Equal(t, userID2, (<-changedCh).UserID)
+
+			// It is part of the user info and orders the accounts.
+			info, err := b.GetUserInfo(userID2)
+			require.NoError(t, err)
+			require.Equal(t, want, info.Display)
+			require.Equal(t, []string{userID2, userID1}, b.GetUserIDs())
+
+			// It is kept for signed out users.
+			require.NoError(t, b.LogoutUser(ctx, userID2))
+
+			info, err = b.GetUserInfo(userID2)
+			require.NoError(t, err)
+			require.Equal(t, bridge.SignedOut, info.State)
+			require.Equal(t, want, info.Display)
+
+			display, err = b.GetUserDisplayMetadata(userID2)
+			require.NoError(t, err)
+			require.Equal(t, want, display)
+
+			// Unknown users have no metadata.
+			_, err = b.GetUserDisplayMetadata("no-such-user")
+			require.ErrorIs(t, err, bridge.ErrNoSuchUser)
+			require.ErrorIs(t, b.SetUserDisplayMetadata("no-such-user", want), bridge.ErrNoSuchUser)
+		})
+	})
+}
@@ -38,11 +38,24 @@ func (bridge *Bridge) handleUserEvent(ctx context.Context, user *user.User, even
 
 	case events.UncategorizedEventError:
 		bridge.handleUncategorizedErrorEvent(event)
+
+	case events.UserMessageCreated:
+		user.AddDigestMessage(event.LabelIDs, event.Sender)
+		bridge.indexHook.Trigger()
+		bridge.goUnifiedInbox()
+
+	case events.SyncFinished:
+		bridge.indexHook.Trigger()
+		bridge.goUnifiedInbox()
+
+	case events.UserMessageNotification:
+		bridge.handleUserMessageNotification(user, event)
 	}
 }
 
 func (bridge *Bridge) handleUserDeauth(ctx context.Context, user *user.User) {
 	safe.Lock(func() {
+		user.OnDeauth(ctx)
 		bridge.logoutUser(ctx, user, false, false, false)
 		user.ReportConfigStatusFailure("User deauth.")
 	}, bridge.usersLock)
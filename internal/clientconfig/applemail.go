@@ -36,13 +36,13 @@ const (
 type AppleMail struct{}
 
 func (c *AppleMail) Configure(
-	hostname string,
+	imapHost, smtpHost string,
 	imapPort, smtpPort int,
 	imapSSL, smtpSSL bool,
 	username, addresses string,
 	password []byte,
 ) error {
-	mc := prepareMobileConfig(hostname, imapPort, smtpPort, imapSSL, smtpSSL, username, addresses, password)
+	mc := prepareMobileConfig(imapHost, smtpHost, imapPort, smtpPort, imapSSL, smtpSSL, username, addresses, password)
 
 	confPath, err := saveConfigTemporarily(mc)
 	if err != nil {
@@ -63,7 +63,7 @@ func (c *AppleMail) Configure(
 }
 
 func prepareMobileConfig(
-	hostname string,
+	imapHost, smtpHost string,
 	imapPort, smtpPort int,
 	imapSSL, smtpSSL bool,
 	username, addresses string,
@@ -76,14 +76,14 @@ func prepareMobileConfig(
 		AccountDescription: username,
 		Identifier:         "protonmail " + username + strconv.FormatInt(time.Now().Unix(), 10),
 		IMAP: &mobileconfig.IMAP{
-			Hostname: hostname,
+			Hostname: imapHost,
 			Port:     imapPort,
 			TLS:      imapSSL,
 			Username: username,
 			Password: string(password),
 		},
 		SMTP: &mobileconfig.SMTP{
-			Hostname: hostname,
+			Hostname: smtpHost,
 			Port:     smtpPort,
 			TLS:      smtpSSL,
 			Username: username,
@@ -67,6 +67,9 @@ const (
 
 	// Host is the hostname of the bridge server.
 	Host = "127.0.0.1"
+
+	// HostIPv6 is the hostname of the bridge server when it listens on IPv6.
+	HostIPv6 = "::1"
 )
 
 // nolint:goconst
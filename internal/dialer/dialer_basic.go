@@ -54,7 +54,8 @@ func CreateTransportWithDialer(dialer TLSDialer) *http.Transport {
 
 // BasicTLSDialer implements TLSDialer.
 type BasicTLSDialer struct {
-	hostURL string
+	hostURL   string
+	netDialer NetDialer
 }
 
 // NewBasicTLSDialer returns a new BasicTLSDialer.
@@ -64,8 +65,18 @@ func NewBasicTLSDialer(hostURL string) *BasicTLSDialer {
 	}
 }
 
+// SetNetDialer makes the dialer make its connections with the given dialer,
+// e.g. to resolve hosts over DoH or to route them through Tor.
+func (d *BasicTLSDialer) SetNetDialer(netDialer NetDialer) {
+	d.netDialer = netDialer
+}
+
 // DialTLSContext returns a connection to the given address using the given network.
 func (d *BasicTLSDialer) DialTLSContext(ctx context.Context, network, address string) (conn net.Conn, err error) {
+	if d.netDialer != nil {
+		return d.dialTLSWithNetDialer(ctx, network, address)
+	}
+
 	return (&tls.Dialer{
 		NetDialer: &net.Dialer{
 			Timeout: 30 * time.Second,
@@ -75,3 +86,30 @@ func (d *BasicTLSDialer) DialTLSContext(ctx context.Context, network, address st
 		},
 	}).DialContext(ctx, network, address)
 }
+
+func (d *BasicTLSDialer) dialTLSWithNetDialer(ctx context.Context, network, address string) (net.Conn, error) {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+
+	rawConn, err := d.netDialer.DialContext(ctx, network, address)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	conn := tls.Client(rawConn, &tls.Config{
+		ServerName:         host,
+		InsecureSkipVerify: address != d.hostURL, //nolint:gosec
+	})
+
+	if err := conn.HandshakeContext(ctx); err != nil {
+		_ = rawConn.Close()
+		return nil, err
+	}
+
+	return conn, nil
+}
@@ -41,19 +41,23 @@ type ProxyTLSDialer struct {
 	allowProxy       bool
 	proxyProvider    *proxyProvider
 	proxyUseDuration time.Duration
+	torDialer        *TorDialer
 
 	panicHandler async.PanicHandler
 }
 
 // NewProxyTLSDialer constructs a dialer which provides a proxy-managing layer on top of an underlying dialer.
 func NewProxyTLSDialer(dialer TLSDialer, hostURL string, panicHandler async.PanicHandler) *ProxyTLSDialer {
+	proxyProvider := newProxyProvider(dialer, hostURL, DoHProviders, panicHandler)
+
 	return &ProxyTLSDialer{
 		dialer:           dialer,
 		locker:           sync.RWMutex{},
 		directAddress:    formatAsAddress(hostURL),
 		proxyAddress:     formatAsAddress(hostURL),
-		proxyProvider:    newProxyProvider(dialer, hostURL, DoHProviders, panicHandler),
+		proxyProvider:    proxyProvider,
 		proxyUseDuration: proxyUseDuration,
+		torDialer:        NewTorDialer(hostURL, proxyProvider.resolver),
 		panicHandler:     panicHandler,
 	}
 }
@@ -149,6 +153,38 @@ func (d *ProxyTLSDialer) switchToReachableServer() error {
 	return nil
 }
 
+// GetNetDialer returns the dialer which the underlying TLS dialer should make its connections with.
+// It routes them through Tor if enabled, and resolves hosts with the DoH resolver otherwise.
+func (d *ProxyTLSDialer) GetNetDialer() NetDialer {
+	return d.torDialer
+}
+
+// SetResolverSettings changes the DoH providers and bootstrap IPs used to look up proxies,
+// and whether the system resolver may be used.
+func (d *ProxyTLSDialer) SetResolverSettings(settings ResolverSettings) {
+	d.proxyProvider.resolver.SetSettings(settings)
+}
+
+// TestResolver resolves the given host at each DoH provider and reports the results.
+func (d *ProxyTLSDialer) TestResolver(ctx context.Context, host string) []LookupResult {
+	return d.proxyProvider.resolver.Test(ctx, host)
+}
+
+// SetTorSettings changes whether and how connections are routed through Tor.
+func (d *ProxyTLSDialer) SetTorSettings(settings TorSettings) {
+	d.torDialer.SetSettings(settings)
+}
+
+// CheckTorCircuit checks that the API can be reached through Tor.
+func (d *ProxyTLSDialer) CheckTorCircuit(ctx context.Context) error {
+	return d.torDialer.CheckCircuit(ctx)
+}
+
+// NewTorCircuit makes Tor use a new circuit for the connections made from now on.
+func (d *ProxyTLSDialer) NewTorCircuit() {
+	d.torDialer.NewCircuit()
+}
+
 // AllowProxy allows the dialer to switch to a proxy if need be.
 func (d *ProxyTLSDialer) AllowProxy() {
 	d.locker.Lock()
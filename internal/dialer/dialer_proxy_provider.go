@@ -19,14 +19,12 @@ package dialer
 
 import (
 	"context"
-	"encoding/base64"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/ProtonMail/gluon/async"
 	"github.com/go-resty/resty/v2"
-	"github.com/miekg/dns"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
@@ -59,12 +57,11 @@ type proxyProvider struct {
 	// dohLookup is used to look up the given query at the given DoH provider, returning the TXT records>
 	dohLookup func(ctx context.Context, query, provider string) (urls []string, err error)
 
-	providers  []string // List of known doh providers.
-	query      string   // The query string used to find proxies.
-	proxyCache []string // All known proxies, cached in case DoH providers are unreachable.
+	resolver   *Resolver // Resolves queries at the known doh providers.
+	query      string    // The query string used to find proxies.
+	proxyCache []string  // All known proxies, cached in case DoH providers are unreachable.
 
 	cacheRefreshTimeout time.Duration
-	dohTimeout          time.Duration
 	canReachTimeout     time.Duration
 
 	lastLookup time.Time // The time at which we last attempted to find a proxy.
@@ -78,16 +75,15 @@ func newProxyProvider(dialer TLSDialer, hostURL string, providers []string, pani
 	p = &proxyProvider{
 		dialer:              dialer,
 		hostURL:             hostURL,
-		providers:           providers,
+		resolver:            NewResolver(providers, panicHandler),
 		query:               proxyQuery,
 		cacheRefreshTimeout: proxyCacheRefreshTimeout,
-		dohTimeout:          proxyDoHTimeout,
 		canReachTimeout:     proxyCanReachTimeout,
 		panicHandler:        panicHandler,
 	}
 
-	// Use the default DNS lookup method; this can be overridden if necessary.
-	p.dohLookup = p.defaultDoHLookup
+	// Use the resolver's DNS lookup method; this can be overridden if necessary.
+	p.dohLookup = p.resolver.LookupTXT
 
 	return
 }
@@ -160,7 +156,7 @@ func (p *proxyProvider) refreshProxyCache() error {
 	go func() {
 		defer async.HandlePanic(p.panicHandler)
 
-		for _, provider := range p.providers {
+		for _, provider := range p.resolver.Providers() {
 			if proxies, err := p.dohLookup(ctx, p.query, provider); err == nil {
 				resultChan <- proxies
 				return
@@ -201,69 +197,3 @@ func (p *proxyProvider) canReach(url string) bool {
 
 	return true
 }
-
-// defaultDoHLookup is the default implementation of the proxy manager's DoH lookup.
-// It looks up DNS TXT records for the given query URL using the given DoH provider.
-// It returns a list of all found TXT records.
-// If the whole process takes more than proxyDoHTimeout then an error is returned.
-//
-//nolint:nakedret
-func (p *proxyProvider) defaultDoHLookup(ctx context.Context, query, dohProvider string) (data []string, err error) {
-	ctx, cancel := context.WithTimeout(ctx, p.dohTimeout)
-	defer cancel()
-
-	dataChan, errChan := make(chan []string), make(chan error)
-
-	go func() {
-		defer async.HandlePanic(p.panicHandler)
-		// Build new DNS request in RFC1035 format.
-		dnsRequest := new(dns.Msg).SetQuestion(dns.Fqdn(query), dns.TypeTXT)
-
-		// Pack the DNS request message into wire format.
-		rawRequest, err := dnsRequest.Pack()
-		if err != nil {
-			errChan <- errors.Wrap(err, "failed to pack DNS request")
-			return
-		}
-
-		// Encode wire-format DNS request message as base64url (RFC4648) without padding chars.
-		encodedRequest := base64.RawURLEncoding.EncodeToString(rawRequest)
-
-		// Make DoH request to the given DoH provider.
-		rawResponse, err := resty.New().R().SetContext(ctx).SetQueryParam("dns", encodedRequest).Get(dohProvider)
-		if err != nil {
-			errChan <- errors.Wrap(err, "failed to make DoH request")
-			return
-		}
-
-		// Unpack the DNS response.
-		dnsResponse := new(dns.Msg)
-		if err = dnsResponse.Unpack(rawResponse.Body()); err != nil {
-			errChan <- errors.Wrap(err, "failed to unpack DNS response")
-			return
-		}
-
-		// Pick out the TXT answers.
-		for _, answer := range dnsResponse.Answer {
-			if t, ok := answer.(*dns.TXT); ok {
-				data = append(data, t.Txt...)
-			}
-		}
-
-		dataChan <- data
-	}()
-
-	select {
-	case data = <-dataChan:
-		logrus.WithField("data", data).Info("Received TXT records")
-		return
-
-	case err = <-errChan:
-		logrus.WithField("provider", dohProvider).WithError(err).Error("Failed to query DNS records")
-		return
-
-	case <-ctx.Done():
-		logrus.WithField("provider", dohProvider).Error("Timed out querying DNS records")
-		return []string{}, errors.New("timed out querying DNS records")
-	}
-}
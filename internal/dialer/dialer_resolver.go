@@ -0,0 +1,360 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package dialer
+
+import (
+	"context"
+	"encoding/base64"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/ProtonMail/gluon/async"
+	"github.com/go-resty/resty/v2"
+	"github.com/miekg/dns"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/exp/slices"
+)
+
+const resolverDialTimeout = 30 * time.Second
+
+var ErrNoBootstrapIPs = errors.New("system DNS is disabled and no bootstrap IPs are configured")
+
+// ResolverSettings configures how host names are resolved.
+type ResolverSettings struct {
+	// DoHProviders are custom DoH endpoints, queried before the built-in ones.
+	DoHProviders []string
+
+	// BootstrapIPs are the addresses used to reach DoH providers without asking the system resolver.
+	BootstrapIPs []string
+
+	// DisableSystemDNS makes the API host be resolved over DoH rather than by the system resolver.
+	DisableSystemDNS bool
+}
+
+// LookupResult is the outcome of resolving a host at a single DoH provider.
+type LookupResult struct {
+	Provider string
+	Addrs    []string
+	Duration time.Duration
+	Err      error
+}
+
+// Resolver resolves host names over DNS-over-HTTPS.
+type Resolver struct {
+	settings     ResolverSettings
+	settingsLock sync.RWMutex
+
+	providers []string // The built-in DoH providers.
+	timeout   time.Duration
+
+	panicHandler async.PanicHandler
+}
+
+// NewResolver returns a new resolver which falls back to the given built-in DoH providers.
+func NewResolver(providers []string, panicHandler async.PanicHandler) *Resolver {
+	return &Resolver{
+		providers:    providers,
+		timeout:      proxyDoHTimeout,
+		panicHandler: panicHandler,
+	}
+}
+
+// GetSettings returns the resolver's current settings.
+func (r *Resolver) GetSettings() ResolverSettings {
+	r.settingsLock.RLock()
+	defer r.settingsLock.RUnlock()
+
+	return r.settings
+}
+
+// SetSettings replaces the resolver's settings.
+func (r *Resolver) SetSettings(settings ResolverSettings) {
+	r.settingsLock.Lock()
+	defer r.settingsLock.Unlock()
+
+	logrus.WithFields(logrus.Fields{
+		"providers":        settings.DoHProviders,
+		"bootstrapIPs":     settings.BootstrapIPs,
+		"disableSystemDNS": settings.DisableSystemDNS,
+	}).Info("Updating resolver settings")
+
+	r.settings = settings
+}
+
+// Providers returns the DoH providers to query, custom ones first.
+func (r *Resolver) Providers() []string {
+	providers := append([]string{}, r.GetSettings().DoHProviders...)
+
+	for _, provider := range r.providers {
+		if !slices.Contains(providers, provider) {
+			providers = append(providers, provider)
+		}
+	}
+
+	return providers
+}
+
+// LookupTXT looks up DNS TXT records for the given query using the given DoH provider.
+func (r *Resolver) LookupTXT(ctx context.Context, query, provider string) ([]string, error) {
+	answers, err := r.lookup(ctx, query, dns.TypeTXT, provider)
+	if err != nil {
+		return nil, err
+	}
+
+	var data []string
+
+	for _, answer := range answers {
+		if t, ok := answer.(*dns.TXT); ok {
+			data = append(data, t.Txt...)
+		}
+	}
+
+	return data, nil
+}
+
+// LookupHost resolves the given host using the first DoH provider that answers.
+func (r *Resolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	var lastErr error
+
+	for _, provider := range r.Providers() {
+		addrs, err := r.lookupHostAt(ctx, host, provider)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		if len(addrs) > 0 {
+			return addrs, nil
+		}
+	}
+
+	if lastErr == nil {
+		lastErr = errors.New("no addresses found")
+	}
+
+	return nil, errors.Wrapf(lastErr, "failed to resolve %v over DoH", host)
+}
+
+// Test resolves the given host at each DoH provider in turn and reports how each one did.
+func (r *Resolver) Test(ctx context.Context, host string) []LookupResult {
+	var results []LookupResult
+
+	for _, provider := range r.Providers() {
+		start := time.Now()
+
+		addrs, err := r.lookupHostAt(ctx, host, provider)
+		if err == nil && len(addrs) == 0 {
+			err = errors.New("no addresses found")
+		}
+
+		results = append(results, LookupResult{
+			Provider: provider,
+			Addrs:    addrs,
+			Duration: time.Since(start),
+			Err:      err,
+		})
+	}
+
+	return results
+}
+
+// DialContext dials the given address, resolving its host over DoH if the system resolver is disabled.
+func (r *Resolver) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: resolverDialTimeout}
+
+	if !r.GetSettings().DisableSystemDNS {
+		return dialer.DialContext(ctx, network, address)
+	}
+
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+
+	if net.ParseIP(host) != nil {
+		return dialer.DialContext(ctx, network, address)
+	}
+
+	addrs, err := r.LookupHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, addr := range addrs {
+		var conn net.Conn
+
+		if conn, err = dialer.DialContext(ctx, network, net.JoinHostPort(addr, port)); err == nil {
+			return conn, nil
+		}
+	}
+
+	return nil, err
+}
+
+func (r *Resolver) lookupHostAt(ctx context.Context, host, provider string) ([]string, error) {
+	var addrs []string
+
+	for _, qtype := range []uint16{dns.TypeA, dns.TypeAAAA} {
+		answers, err := r.lookup(ctx, host, qtype, provider)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, answer := range answers {
+			switch answer := answer.(type) {
+			case *dns.A:
+				addrs = append(addrs, answer.A.String())
+
+			case *dns.AAAA:
+				addrs = append(addrs, answer.AAAA.String())
+			}
+		}
+	}
+
+	return addrs, nil
+}
+
+// lookup sends a single DNS query of the given type to the given DoH provider.
+// If the whole process takes more than the resolver's timeout then an error is returned.
+func (r *Resolver) lookup(ctx context.Context, query string, qtype uint16, provider string) ([]dns.RR, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	log := logrus.WithFields(logrus.Fields{
+		"provider": provider,
+		"query":    query,
+		"type":     dns.TypeToString[qtype],
+	})
+
+	log.Debug("Sending DoH query")
+
+	start := time.Now()
+
+	answerCh, errCh := make(chan []dns.RR, 1), make(chan error, 1)
+
+	go func() {
+		defer async.HandlePanic(r.panicHandler)
+
+		// Build new DNS request in RFC1035 format.
+		dnsRequest := new(dns.Msg).SetQuestion(dns.Fqdn(query), qtype)
+
+		// Pack the DNS request message into wire format.
+		rawRequest, err := dnsRequest.Pack()
+		if err != nil {
+			errCh <- errors.Wrap(err, "failed to pack DNS request")
+			return
+		}
+
+		// Encode wire-format DNS request message as base64url (RFC4648) without padding chars.
+		encodedRequest := base64.RawURLEncoding.EncodeToString(rawRequest)
+
+		// Make DoH request to the given DoH provider.
+		rawResponse, err := r.newClient().R().SetContext(ctx).SetQueryParam("dns", encodedRequest).Get(provider)
+		if err != nil {
+			errCh <- errors.Wrap(err, "failed to make DoH request")
+			return
+		}
+
+		// Unpack the DNS response.
+		dnsResponse := new(dns.Msg)
+		if err = dnsResponse.Unpack(rawResponse.Body()); err != nil {
+			errCh <- errors.Wrap(err, "failed to unpack DNS response")
+			return
+		}
+
+		answerCh <- dnsResponse.Answer
+	}()
+
+	select {
+	case answers := <-answerCh:
+		log.WithField("answers", len(answers)).WithField("duration", time.Since(start)).Info("Received DoH answer")
+		return answers, nil
+
+	case err := <-errCh:
+		log.WithError(err).Error("Failed to query DNS records")
+		return nil, err
+
+	case <-ctx.Done():
+		log.Error("Timed out querying DNS records")
+		return nil, errors.New("timed out querying DNS records")
+	}
+}
+
+// newClient returns an HTTP client for talking to DoH providers.
+// If bootstrap IPs are configured, they are used when the provider's host can't (or mustn't) be resolved by the system.
+func (r *Resolver) newClient() *resty.Client {
+	return resty.New().SetTransport(&http.Transport{
+		Proxy:       http.ProxyFromEnvironment,
+		DialContext: r.dialProvider,
+	})
+}
+
+func (r *Resolver) dialProvider(ctx context.Context, network, address string) (net.Conn, error) {
+	settings := r.GetSettings()
+
+	dialer := &net.Dialer{Timeout: resolverDialTimeout}
+
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+
+	if net.ParseIP(host) != nil {
+		return dialer.DialContext(ctx, network, address)
+	}
+
+	if !settings.DisableSystemDNS {
+		conn, err := dialer.DialContext(ctx, network, address)
+		if err == nil || len(settings.BootstrapIPs) == 0 {
+			return conn, err
+		}
+
+		logrus.WithError(err).WithField("host", host).Debug("Failed to reach DoH provider, trying bootstrap IPs")
+	} else if len(settings.BootstrapIPs) == 0 {
+		return nil, ErrNoBootstrapIPs
+	}
+
+	for _, ip := range settings.BootstrapIPs {
+		var conn net.Conn
+
+		if conn, err = dialer.DialContext(ctx, network, net.JoinHostPort(ip, port)); err == nil {
+			logrus.WithFields(logrus.Fields{"host": host, "ip": ip}).Debug("Reached DoH provider via bootstrap IP")
+			return conn, nil
+		}
+	}
+
+	return nil, err
+}
+
+// ValidateDoHProvider checks that the given DoH provider is a usable HTTPS URL.
+func ValidateDoHProvider(provider string) error {
+	u, err := url.Parse(provider)
+	if err != nil {
+		return err
+	}
+
+	if u.Scheme != "https" || u.Host == "" {
+		return errors.New("DoH provider must be an https:// URL")
+	}
+
+	return nil
+}
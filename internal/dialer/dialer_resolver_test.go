@@ -0,0 +1,128 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package dialer
+
+import (
+	"context"
+	"encoding/base64"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ProtonMail/gluon/async"
+	"github.com/miekg/dns"
+	r "github.com/stretchr/testify/require"
+)
+
+// newTestDoHServer returns a DoH server answering A queries with the given IP.
+func newTestDoHServer(t *testing.T, ip string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		raw, err := base64.RawURLEncoding.DecodeString(req.URL.Query().Get("dns"))
+		r.NoError(t, err)
+
+		query := new(dns.Msg)
+		r.NoError(t, query.Unpack(raw))
+
+		res := new(dns.Msg).SetReply(query)
+
+		if query.Question[0].Qtype == dns.TypeA {
+			res.Answer = append(res.Answer, &dns.A{
+				Hdr: dns.RR_Header{Name: query.Question[0].Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 60},
+				A:   net.ParseIP(ip),
+			})
+		}
+
+		b, err := res.Pack()
+		r.NoError(t, err)
+
+		_, _ = w.Write(b)
+	}))
+
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestResolver_Providers(t *testing.T) {
+	resolver := NewResolver([]string{Quad9Provider, GoogleProvider}, async.NoopPanicHandler{})
+
+	// By default, only the built-in providers are used.
+	r.Equal(t, []string{Quad9Provider, GoogleProvider}, resolver.Providers())
+
+	// Custom providers come first, without duplicates.
+	resolver.SetSettings(ResolverSettings{DoHProviders: []string{"https://dns.example.com/dns-query", GoogleProvider}})
+	r.Equal(t, []string{"https://dns.example.com/dns-query", GoogleProvider, Quad9Provider}, resolver.Providers())
+}
+
+func TestResolver_LookupHost(t *testing.T) {
+	server := newTestDoHServer(t, "192.0.2.1")
+
+	resolver := NewResolver(nil, async.NoopPanicHandler{})
+	resolver.SetSettings(ResolverSettings{DoHProviders: []string{server.URL}})
+
+	addrs, err := resolver.LookupHost(context.Background(), "mail-api.proton.me")
+	r.NoError(t, err)
+	r.Equal(t, []string{"192.0.2.1"}, addrs)
+}
+
+func TestResolver_Test(t *testing.T) {
+	server := newTestDoHServer(t, "192.0.2.1")
+
+	resolver := NewResolver(nil, async.NoopPanicHandler{})
+	resolver.SetSettings(ResolverSettings{DoHProviders: []string{server.URL, "http://127.0.0.1:1/dns-query"}})
+
+	results := resolver.Test(context.Background(), "mail-api.proton.me")
+	r.Len(t, results, 2)
+
+	// The first provider answers.
+	r.Equal(t, server.URL, results[0].Provider)
+	r.Equal(t, []string{"192.0.2.1"}, results[0].Addrs)
+	r.NoError(t, results[0].Err)
+
+	// The second one is unreachable.
+	r.Equal(t, "http://127.0.0.1:1/dns-query", results[1].Provider)
+	r.Error(t, results[1].Err)
+}
+
+func TestResolver_DisableSystemDNS(t *testing.T) {
+	resolver := NewResolver(nil, async.NoopPanicHandler{})
+	resolver.SetSettings(ResolverSettings{DisableSystemDNS: true})
+
+	// Without bootstrap IPs, named DoH providers can't be reached.
+	_, err := resolver.dialProvider(context.Background(), "tcp", "dns.example.com:443")
+	r.ErrorIs(t, err, ErrNoBootstrapIPs)
+}
+
+func TestResolver_DialContext(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+	defer target.Close()
+
+	_, port, err := net.SplitHostPort(target.Listener.Addr().String())
+	r.NoError(t, err)
+
+	server := newTestDoHServer(t, "127.0.0.1")
+
+	resolver := NewResolver(nil, async.NoopPanicHandler{})
+	resolver.SetSettings(ResolverSettings{DoHProviders: []string{server.URL}, DisableSystemDNS: true})
+
+	// The host is resolved over DoH rather than by the system resolver.
+	conn, err := resolver.DialContext(context.Background(), "tcp", net.JoinHostPort("host.invalid", port))
+	r.NoError(t, err)
+	r.NoError(t, conn.Close())
+}
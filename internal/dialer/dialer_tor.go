@@ -0,0 +1,188 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package dialer
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/proxy"
+)
+
+const (
+	DefaultTorSOCKSAddress = "127.0.0.1:9050"
+
+	torDialTimeout = 30 * time.Second
+)
+
+// NetDialer makes plain network connections.
+type NetDialer interface {
+	DialContext(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+// TorSettings configures routing through Tor.
+type TorSettings struct {
+	// Enabled routes connections through the Tor SOCKS proxy.
+	Enabled bool
+
+	// SOCKSAddress is the address of the local Tor SOCKS proxy; DefaultTorSOCKSAddress if empty.
+	SOCKSAddress string
+
+	// OnionHost is the onion service to connect to instead of the API host, if any.
+	OnionHost string
+}
+
+// TorDialer routes connections through a local Tor SOCKS proxy when enabled,
+// and through the fallback dialer otherwise.
+type TorDialer struct {
+	apiHost  string
+	fallback NetDialer
+
+	settings TorSettings
+
+	// isolation is used as SOCKS credentials; Tor uses a separate circuit for each set of credentials.
+	isolation string
+
+	lock sync.RWMutex
+}
+
+// NewTorDialer returns a new TorDialer for the given API host, which is disabled until configured otherwise.
+func NewTorDialer(hostURL string, fallback NetDialer) *TorDialer {
+	host, _, err := net.SplitHostPort(formatAsAddress(hostURL))
+	if err != nil {
+		panic(err)
+	}
+
+	return &TorDialer{
+		apiHost:   host,
+		fallback:  fallback,
+		isolation: newTorIsolation(),
+	}
+}
+
+// GetSettings returns the dialer's current settings.
+func (d *TorDialer) GetSettings() TorSettings {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+
+	return d.settings
+}
+
+// SetSettings replaces the dialer's settings.
+func (d *TorDialer) SetSettings(settings TorSettings) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	logrus.WithFields(logrus.Fields{
+		"enabled":      settings.Enabled,
+		"socksAddress": settings.SOCKSAddress,
+		"onionHost":    settings.OnionHost,
+	}).Info("Updating Tor settings")
+
+	d.settings = settings
+}
+
+// NewCircuit makes Tor use a new circuit for the connections made from now on.
+func (d *TorDialer) NewCircuit() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	logrus.Info("Requesting a new Tor circuit")
+
+	d.isolation = newTorIsolation()
+}
+
+// CheckCircuit checks that the API (or its onion service) can be reached through Tor.
+func (d *TorDialer) CheckCircuit(ctx context.Context) error {
+	if !d.GetSettings().Enabled {
+		return errors.New("tor is disabled")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, torDialTimeout)
+	defer cancel()
+
+	start := time.Now()
+
+	conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort(d.apiHost, "443"))
+	if err != nil {
+		return err
+	}
+
+	logrus.WithField("duration", time.Since(start)).Debug("Tor circuit is healthy")
+
+	return conn.Close()
+}
+
+// DialContext dials the given address, through Tor if enabled.
+func (d *TorDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	d.lock.RLock()
+	settings, isolation := d.settings, d.isolation
+	d.lock.RUnlock()
+
+	if !settings.Enabled {
+		return d.fallback.DialContext(ctx, network, address)
+	}
+
+	socksAddress := settings.SOCKSAddress
+	if socksAddress == "" {
+		socksAddress = DefaultTorSOCKSAddress
+	}
+
+	if host, port, err := net.SplitHostPort(address); err == nil && host == d.apiHost && settings.OnionHost != "" {
+		address = net.JoinHostPort(settings.OnionHost, port)
+	}
+
+	socks, err := proxy.SOCKS5("tcp", socksAddress, &proxy.Auth{User: isolation, Password: isolation}, &net.Dialer{Timeout: torDialTimeout})
+	if err != nil {
+		return nil, err
+	}
+
+	// The host name is sent to the proxy as-is, so it is resolved by Tor rather than locally.
+	conn, err := socks.(proxy.ContextDialer).DialContext(ctx, network, address)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to dial through tor")
+	}
+
+	return conn, nil
+}
+
+// ValidateOnionHost checks that the given host is an onion service address.
+func ValidateOnionHost(host string) error {
+	if !strings.HasSuffix(host, ".onion") || strings.ContainsAny(host, ":/") {
+		return errors.New("not an onion address")
+	}
+
+	return nil
+}
+
+func newTorIsolation() string {
+	b := make([]byte, 8)
+
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,201 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package dialer
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strconv"
+	"testing"
+
+	r "github.com/stretchr/testify/require"
+)
+
+type socksRequest struct {
+	user, address string
+}
+
+// newTestSOCKSServer returns the address of a SOCKS5 server which records the requests it gets and
+// connects every one of them to the given target.
+func newTestSOCKSServer(t *testing.T, target string) (string, <-chan socksRequest) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	r.NoError(t, err)
+
+	t.Cleanup(func() { _ = l.Close() })
+
+	reqCh := make(chan socksRequest, 16)
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			go serveTestSOCKS(conn, target, reqCh)
+		}
+	}()
+
+	return l.Addr().String(), reqCh
+}
+
+func serveTestSOCKS(conn net.Conn, target string, reqCh chan<- socksRequest) {
+	defer conn.Close() //nolint:errcheck
+
+	buf := make([]byte, 256)
+
+	// Greeting: pick username/password authentication.
+	if _, err := io.ReadFull(conn, buf[:2]); err != nil {
+		return
+	}
+
+	if _, err := io.ReadFull(conn, buf[:buf[1]]); err != nil {
+		return
+	}
+
+	if _, err := conn.Write([]byte{5, 2}); err != nil {
+		return
+	}
+
+	// Authentication: accept any credentials.
+	if _, err := io.ReadFull(conn, buf[:2]); err != nil {
+		return
+	}
+
+	user := make([]byte, buf[1])
+	if _, err := io.ReadFull(conn, user); err != nil {
+		return
+	}
+
+	if _, err := io.ReadFull(conn, buf[:1]); err != nil {
+		return
+	}
+
+	if _, err := io.ReadFull(conn, buf[:buf[0]]); err != nil {
+		return
+	}
+
+	if _, err := conn.Write([]byte{1, 0}); err != nil {
+		return
+	}
+
+	// Connect request; the test dialer always sends a domain name.
+	if _, err := io.ReadFull(conn, buf[:5]); err != nil {
+		return
+	}
+
+	host := make([]byte, buf[4])
+	if _, err := io.ReadFull(conn, host); err != nil {
+		return
+	}
+
+	if _, err := io.ReadFull(conn, buf[:2]); err != nil {
+		return
+	}
+
+	reqCh <- socksRequest{
+		user:    string(user),
+		address: net.JoinHostPort(string(host), strconv.Itoa(int(binary.BigEndian.Uint16(buf[:2])))),
+	}
+
+	upstream, err := net.Dial("tcp", target)
+	if err != nil {
+		return
+	}
+
+	defer upstream.Close() //nolint:errcheck
+
+	if _, err := conn.Write([]byte{5, 0, 0, 1, 127, 0, 0, 1, 0, 0}); err != nil {
+		return
+	}
+
+	go func() { _, _ = io.Copy(upstream, conn) }()
+
+	_, _ = io.Copy(conn, upstream)
+}
+
+type failingNetDialer struct{}
+
+func (failingNetDialer) DialContext(context.Context, string, string) (net.Conn, error) {
+	return nil, io.EOF
+}
+
+func TestTorDialer_Disabled(t *testing.T) {
+	d := NewTorDialer("https://mail-api.proton.me", failingNetDialer{})
+
+	// While disabled, the fallback dialer is used.
+	_, err := d.DialContext(context.Background(), "tcp", "mail-api.proton.me:443")
+	r.ErrorIs(t, err, io.EOF)
+
+	// The circuit can't be checked.
+	r.Error(t, d.CheckCircuit(context.Background()))
+}
+
+func TestTorDialer_Enabled(t *testing.T) {
+	target, err := net.Listen("tcp", "127.0.0.1:0")
+	r.NoError(t, err)
+	defer target.Close() //nolint:errcheck
+
+	socksAddress, reqCh := newTestSOCKSServer(t, target.Addr().String())
+
+	d := NewTorDialer("https://mail-api.proton.me", failingNetDialer{})
+	d.SetSettings(TorSettings{Enabled: true, SOCKSAddress: socksAddress})
+
+	// Connections go through the SOCKS proxy, which resolves the host itself.
+	r.NoError(t, d.CheckCircuit(context.Background()))
+
+	req := <-reqCh
+	r.Equal(t, "mail-api.proton.me:443", req.address)
+
+	// A new circuit is requested by using other credentials.
+	d.NewCircuit()
+
+	r.NoError(t, d.CheckCircuit(context.Background()))
+	r.NotEqual(t, req.user, (<-reqCh).user)
+}
+
+func TestTorDialer_OnionHost(t *testing.T) {
+	target, err := net.Listen("tcp", "127.0.0.1:0")
+	r.NoError(t, err)
+	defer target.Close() //nolint:errcheck
+
+	socksAddress, reqCh := newTestSOCKSServer(t, target.Addr().String())
+
+	d := NewTorDialer("https://mail-api.proton.me", failingNetDialer{})
+	d.SetSettings(TorSettings{Enabled: true, SOCKSAddress: socksAddress, OnionHost: "example.onion"})
+
+	// The API host is replaced by the onion service.
+	conn, err := d.DialContext(context.Background(), "tcp", "mail-api.proton.me:443")
+	r.NoError(t, err)
+	r.NoError(t, conn.Close())
+	r.Equal(t, "example.onion:443", (<-reqCh).address)
+
+	// Other hosts are left alone.
+	conn, err = d.DialContext(context.Background(), "tcp", "proton.me:443")
+	r.NoError(t, err)
+	r.NoError(t, conn.Close())
+	r.Equal(t, "proton.me:443", (<-reqCh).address)
+}
+
+func TestValidateOnionHost(t *testing.T) {
+	r.NoError(t, ValidateOnionHost("example.onion"))
+	r.Error(t, ValidateOnionHost("mail-api.proton.me"))
+	r.Error(t, ValidateOnionHost("example.onion:443"))
+}
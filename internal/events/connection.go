@@ -17,6 +17,11 @@
 
 package events
 
+import (
+	"fmt"
+	"time"
+)
+
 type TLSIssue struct {
 	eventBase
 }
@@ -40,3 +45,35 @@ type ConnStatusDown struct {
 func (event ConnStatusDown) String() string {
 	return "ConnStatusDown"
 }
+
+// ClockSkewDetected is published when the local clock differs from the API's by more than the tolerated skew.
+// Offset is the server time minus the local time.
+type ClockSkewDetected struct {
+	eventBase
+
+	Offset time.Duration
+}
+
+func (event ClockSkewDetected) String() string {
+	return fmt.Sprintf("ClockSkewDetected: Offset: %v", event.Offset)
+}
+
+// TorCircuitUp is published when the API can be reached through Tor.
+type TorCircuitUp struct {
+	eventBase
+}
+
+func (event TorCircuitUp) String() string {
+	return "TorCircuitUp"
+}
+
+// TorCircuitDown is published when the API can't be reached through Tor, even over a new circuit.
+type TorCircuitDown struct {
+	eventBase
+
+	Error error
+}
+
+func (event TorCircuitDown) String() string {
+	return fmt.Sprintf("TorCircuitDown: Error: %s", event.Error)
+}
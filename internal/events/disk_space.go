@@ -0,0 +1,48 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package events
+
+import "fmt"
+
+// DiskSpaceLow is published when the disk holding the message cache is too full to sync.
+// UserID is set if the free space is too low for the initial sync of that user, whose sync is delayed;
+// otherwise the free space fell below the minimum and the sync of all users is paused.
+type DiskSpaceLow struct {
+	eventBase
+
+	UserID   string
+	Path     string
+	Free     uint64
+	Required uint64
+}
+
+func (event DiskSpaceLow) String() string {
+	return fmt.Sprintf("DiskSpaceLow: UserID: %s, Path: %s, Free: %d, Required: %d", event.UserID, event.Path, event.Free, event.Required)
+}
+
+// DiskSpaceRecovered is published when enough disk space is freed for the paused syncs to resume.
+type DiskSpaceRecovered struct {
+	eventBase
+
+	Path string
+	Free uint64
+}
+
+func (event DiskSpaceRecovered) String() string {
+	return fmt.Sprintf("DiskSpaceRecovered: Path: %s, Free: %d", event.Path, event.Free)
+}
@@ -0,0 +1,31 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package events
+
+import "fmt"
+
+// DoNotDisturbChanged is published when do not disturb is turned on or off, manually or by its schedule.
+type DoNotDisturbChanged struct {
+	eventBase
+
+	Active bool
+}
+
+func (event DoNotDisturbChanged) String() string {
+	return fmt.Sprintf("DoNotDisturbChanged: Active: %t", event.Active)
+}
@@ -0,0 +1,58 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package events
+
+import "fmt"
+
+// UserExportProgress is emitted periodically while the messages of a user are exported.
+type UserExportProgress struct {
+	eventBase
+
+	UserID   string
+	Exported int
+	Failed   int
+	Redacted int
+	Total    int
+}
+
+func (event UserExportProgress) String() string {
+	return fmt.Sprintf(
+		"UserExportProgress: UserID: %s, Exported: %d, Failed: %d, Redacted: %d, Total: %d",
+		event.UserID, event.Exported, event.Failed, event.Redacted, event.Total,
+	)
+}
+
+// UserExportFinished is emitted when the export of a user's messages ends. Error is set if the export failed
+// or was canceled.
+type UserExportFinished struct {
+	eventBase
+
+	UserID   string
+	Path     string
+	Exported int
+	Failed   int
+	Redacted int
+	Error    error
+}
+
+func (event UserExportFinished) String() string {
+	return fmt.Sprintf(
+		"UserExportFinished: UserID: %s, Exported: %d, Failed: %d, Redacted: %d, Error: %v",
+		event.UserID, event.Exported, event.Failed, event.Redacted, event.Error,
+	)
+}
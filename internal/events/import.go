@@ -0,0 +1,58 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package events
+
+import "fmt"
+
+// UserImportProgress is emitted periodically while messages are imported to a user.
+type UserImportProgress struct {
+	eventBase
+
+	UserID     string
+	Imported   int
+	Duplicates int
+	Failed     int
+	Total      int
+}
+
+func (event UserImportProgress) String() string {
+	return fmt.Sprintf(
+		"UserImportProgress: UserID: %s, Imported: %d, Duplicates: %d, Failed: %d, Total: %d",
+		event.UserID, event.Imported, event.Duplicates, event.Failed, event.Total,
+	)
+}
+
+// UserImportFinished is emitted when an import of messages to a user ends. Error is set if the import failed
+// or was canceled; importing the same source again then resumes it.
+type UserImportFinished struct {
+	eventBase
+
+	UserID     string
+	Source     string
+	Imported   int
+	Duplicates int
+	Failed     int
+	Error      error
+}
+
+func (event UserImportFinished) String() string {
+	return fmt.Sprintf(
+		"UserImportFinished: UserID: %s, Imported: %d, Duplicates: %d, Failed: %d, Error: %v",
+		event.UserID, event.Imported, event.Duplicates, event.Failed, event.Error,
+	)
+}
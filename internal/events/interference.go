@@ -0,0 +1,48 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package events
+
+import "fmt"
+
+// InterferenceKind is the way in which security software interferes with bridge.
+type InterferenceKind string
+
+const (
+	// IMAPInterception means the connections of clients to the IMAP server are intercepted.
+	IMAPInterception InterferenceKind = "imap_interception"
+
+	// SMTPInterception means the connections of clients to the SMTP server are intercepted.
+	SMTPInterception InterferenceKind = "smtp_interception"
+
+	// FileLocking means the files bridge writes are held open by another process, typically while being scanned.
+	FileLocking InterferenceKind = "file_locking"
+)
+
+// InterferenceDetected is published when security software is found to interfere with bridge.
+// Product is the name of the software if it could be identified.
+type InterferenceDetected struct {
+	eventBase
+
+	Kind    InterferenceKind
+	Product string
+	Detail  string
+}
+
+func (event InterferenceDetected) String() string {
+	return fmt.Sprintf("InterferenceDetected: Kind: %s, Product: %s, Detail: %s", event.Kind, event.Product, event.Detail)
+}
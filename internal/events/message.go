@@ -0,0 +1,188 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package events
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/logging"
+)
+
+// UserMessageCreated is emitted when a new message has been written to the user's local store by the event loop.
+type UserMessageCreated struct {
+	eventBase
+
+	UserID    string
+	MessageID string
+	LabelIDs  []string
+
+	// Sender is the address of the message's sender, if any.
+	Sender string
+}
+
+func (event UserMessageCreated) String() string {
+	return fmt.Sprintf("UserMessageCreated: UserID: %s, MessageID: %s, LabelIDs: %v", event.UserID, event.MessageID, event.LabelIDs)
+}
+
+// UserMessageNotification is emitted when a newly received message matches the user's notification rules.
+type UserMessageNotification struct {
+	eventBase
+
+	UserID    string
+	MessageID string
+	Sender    string
+	Subject   string
+	Mailboxes []string
+}
+
+func (event UserMessageNotification) String() string {
+	return fmt.Sprintf("UserMessageNotification: UserID: %s, MessageID: %s, Sender: %s, Subject: %s, Mailboxes: %v",
+		event.UserID,
+		event.MessageID,
+		logging.Sensitive(event.Sender),
+		logging.Sensitive(event.Subject),
+		logging.Sensitive(strings.Join(event.Mailboxes, ", ")),
+	)
+}
+
+// UserAutoPurgePending is emitted when messages of the user's Trash or Spam become old enough to be purged.
+// They are permanently deleted by the next purge run, unless auto-purge is disabled or they are moved meanwhile.
+type UserAutoPurgePending struct {
+	eventBase
+
+	UserID  string
+	LabelID string
+	Count   int
+	At      time.Time
+}
+
+func (event UserAutoPurgePending) String() string {
+	return fmt.Sprintf("UserAutoPurgePending: UserID: %s, LabelID: %s, Count: %d, At: %s", event.UserID, event.LabelID, event.Count, event.At)
+}
+
+// UserAutoPurged is emitted when messages of the user's Trash or Spam have been permanently deleted.
+type UserAutoPurged struct {
+	eventBase
+
+	UserID  string
+	LabelID string
+	Count   int
+}
+
+func (event UserAutoPurged) String() string {
+	return fmt.Sprintf("UserAutoPurged: UserID: %s, LabelID: %s, Count: %d", event.UserID, event.LabelID, event.Count)
+}
+
+// UserDigest is emitted with the weekly digest report of the user, gathered from the messages bridge received.
+type UserDigest struct {
+	eventBase
+
+	UserID string
+	Since  time.Time
+	Until  time.Time
+
+	// Received counts the new messages by mailbox name, sent messages being counted in Sent.
+	Received map[string]int
+
+	// TopSenders are the addresses which sent the most messages, the most frequent first.
+	TopSenders []DigestSender
+
+	// UsedSpaceGrowth is how much the storage space used by the user grew, in bytes.
+	UsedSpaceGrowth int
+
+	// SyncErrors is the number of messages which currently fail to sync.
+	SyncErrors int
+}
+
+// DigestSender is a sender of the weekly digest report with the number of messages it sent.
+type DigestSender struct {
+	Address string
+	Count   int
+}
+
+func (event UserDigest) String() string {
+	return fmt.Sprintf(
+		"UserDigest: UserID: %s, Since: %s, Until: %s, Mailboxes: %d, UsedSpaceGrowth: %d, SyncErrors: %d",
+		event.UserID, event.Since, event.Until, len(event.Received), event.UsedSpaceGrowth, event.SyncErrors,
+	)
+}
+
+// UserMigrationProgress is emitted periodically while messages are appended to the user's mailboxes in migration mode.
+type UserMigrationProgress struct {
+	eventBase
+
+	UserID     string
+	Imported   int
+	Duplicates int
+	Failed     int
+}
+
+func (event UserMigrationProgress) String() string {
+	return fmt.Sprintf(
+		"UserMigrationProgress: UserID: %s, Imported: %d, Duplicates: %d, Failed: %d",
+		event.UserID, event.Imported, event.Duplicates, event.Failed,
+	)
+}
+
+// UserMigrationFailed is emitted when a message appended in migration mode could not be imported.
+type UserMigrationFailed struct {
+	eventBase
+
+	UserID    string
+	MailboxID string
+	Subject   string
+	Error     error
+}
+
+func (event UserMigrationFailed) String() string {
+	return fmt.Sprintf("UserMigrationFailed: UserID: %s, MailboxID: %s, Error: %s", event.UserID, event.MailboxID, event.Error)
+}
+
+// UserMessageSent is emitted when a message submitted over SMTP was sent.
+// It correlates the Message-ID the client gave the message with the ID of the message on the server,
+// which the Sent copy carries as its X-Pm-Internal-Id header field.
+type UserMessageSent struct {
+	eventBase
+
+	UserID     string
+	AddressID  string
+	MessageID  string
+	ExternalID string
+	Recipients []string
+}
+
+func (event UserMessageSent) String() string {
+	return fmt.Sprintf(
+		"UserMessageSent: UserID: %s, AddressID: %s, MessageID: %s, ExternalID: %s, Recipients: %d",
+		event.UserID, event.AddressID, event.MessageID, event.ExternalID, len(event.Recipients),
+	)
+}
+
+// UserSendFailed is emitted when a message submitted over SMTP could not be sent.
+type UserSendFailed struct {
+	eventBase
+
+	UserID string
+	Error  error
+}
+
+func (event UserSendFailed) String() string {
+	return fmt.Sprintf("UserSendFailed: UserID: %s, Error: %s", event.UserID, event.Error)
+}
@@ -17,10 +17,16 @@
 
 package events
 
+import "fmt"
+
+// Raise is emitted when another instance of the application was launched and asked this one to show itself.
+// Args are the arguments the other instance was launched with, such as a mailto: URL, if any.
 type Raise struct {
 	eventBase
+
+	Args []string
 }
 
 func (event Raise) String() string {
-	return "Raise"
+	return fmt.Sprintf("Raise: Args: %v", event.Args)
 }
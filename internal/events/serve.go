@@ -90,3 +90,15 @@ type SMTPServerError struct {
 func (event SMTPServerError) String() string {
 	return fmt.Sprintf("SMTPServerError: %v", event.Error)
 }
+
+// ClientRejected is published when a server closed the connection of a client missing from the LAN allowlist.
+type ClientRejected struct {
+	eventBase
+
+	Protocol   string
+	RemoteAddr string
+}
+
+func (event ClientRejected) String() string {
+	return fmt.Sprintf("ClientRejected: Protocol: %s, RemoteAddr: %s", event.Protocol, event.RemoteAddr)
+}
@@ -0,0 +1,47 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package events
+
+import (
+	"fmt"
+	"time"
+)
+
+// TLSCertExpiring is published when the TLS certificate of the IMAP and SMTP servers expires soon
+// and bridge can't renew it because it was provided by the user.
+type TLSCertExpiring struct {
+	eventBase
+
+	NotAfter time.Time
+}
+
+func (event TLSCertExpiring) String() string {
+	return fmt.Sprintf("TLSCertExpiring: NotAfter: %v", event.NotAfter)
+}
+
+// TLSCertRenewed is published when bridge regenerated its self-signed TLS certificate ahead of its expiry.
+// Clients which trusted the previous certificate must be made to trust the new one.
+type TLSCertRenewed struct {
+	eventBase
+
+	NotAfter time.Time
+}
+
+func (event TLSCertRenewed) String() string {
+	return fmt.Sprintf("TLSCertRenewed: NotAfter: %v", event.NotAfter)
+}
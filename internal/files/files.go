@@ -113,6 +113,27 @@ func CopyFile(srcFile, dstFile string) error {
 	return nil
 }
 
+// DirSize returns the total size of the files under dir.
+func DirSize(dir string) (int64, error) {
+	var size int64
+
+	if err := filepath.Walk(dir, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !info.IsDir() {
+			size += info.Size()
+		}
+
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+
+	return size, nil
+}
+
 func Exists(filePath string) bool {
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
 		return false
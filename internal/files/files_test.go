@@ -71,3 +71,25 @@ func TestMoveDir(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestDirSize(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(dir, "a"), []byte("aaa"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "b"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "b", "c"), []byte("cc"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	size, err := DirSize(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != 5 {
+		t.Fatalf("expected size 5, got %v", size)
+	}
+}
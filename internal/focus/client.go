@@ -28,7 +28,9 @@ import (
 	"github.com/ProtonMail/proton-bridge/v3/internal/service"
 	"github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
 	"google.golang.org/protobuf/types/known/wrapperspb"
 )
@@ -54,6 +56,32 @@ func TryRaise(settingsPath string) bool {
 	return raised
 }
 
+// TryCommand tries to raise the application by dialing the focus service, passing it the given arguments.
+// Instances which can't take arguments are only raised.
+// It returns true if the service is running and the application was told to raise.
+func TryCommand(settingsPath string, args []string) bool {
+	var raised bool
+
+	if err := withClientConn(context.Background(), settingsPath, func(ctx context.Context, client proto.FocusClient) error {
+		if _, err := client.Command(ctx, &proto.CommandRequest{Args: args}); status.Code(err) == codes.Unimplemented {
+			if _, err := client.Raise(ctx, &wrapperspb.StringValue{Value: "TryCommand"}); err != nil {
+				return fmt.Errorf("failed to call client.Raise: %w", err)
+			}
+		} else if err != nil {
+			return fmt.Errorf("failed to call client.Command: %w", err)
+		}
+
+		raised = true
+
+		return nil
+	}); err != nil {
+		logrus.WithError(err).Debug("Failed to pass command to application")
+		return false
+	}
+
+	return raised
+}
+
 // TryVersion tries to determine the version of the running application instance.
 // It returns the version and true if the version could be determined.
 func TryVersion(settingsPath string) (*semver.Version, bool) {
@@ -19,6 +19,7 @@ package focus
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/Masterminds/semver/v3"
@@ -64,6 +65,48 @@ func TestFocus_Version(t *testing.T) {
 	require.Equal(t, "1.2.3", version.String())
 }
 
+func TestFocus_Command(t *testing.T) {
+	tmpDir := t.TempDir()
+	locations := locations.New(newTestLocationsProvider(tmpDir), "config-name")
+	// Start the focus service.
+	service, err := NewService(locations, semver.MustParse("1.2.3"), nil)
+	require.NoError(t, err)
+	defer service.Close()
+
+	settingsFolder, err := locations.ProvideSettingsPath()
+	require.NoError(t, err)
+
+	// Pass it arguments, it should be raised with them.
+	require.True(t, TryCommand(settingsFolder, []string{"mailto:someone@pm.me"}))
+	require.Equal(t, []string{"mailto:someone@pm.me"}, <-service.GetRaiseCh())
+
+	// A plain raise comes without arguments.
+	require.True(t, TryRaise(settingsFolder))
+	require.Empty(t, <-service.GetRaiseCh())
+}
+
+func TestFocus_Lock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bridge.lock")
+
+	lock, err := TryLock(path)
+	require.NoError(t, err)
+
+	// The lock can't be taken twice, and records who holds it.
+	_, err = TryLock(path)
+	require.ErrorIs(t, err, ErrLocked)
+
+	pid, err := ReadLockPID(path)
+	require.NoError(t, err)
+	require.Equal(t, os.Getpid(), pid)
+
+	// Once released, it can be taken again.
+	require.NoError(t, lock.Close())
+
+	lock, err = TryLock(path)
+	require.NoError(t, err)
+	require.NoError(t, lock.Close())
+}
+
 type TestLocationsProvider struct {
 	config, data, cache string
 }
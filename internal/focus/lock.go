@@ -0,0 +1,89 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package focus
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ErrLocked is returned by TryLock when another instance holds the lock.
+var ErrLocked = errors.New("the lock is held by another instance")
+
+// Lock is an exclusive lock on a file held by the running instance, which has its PID written in the file.
+// The operating system releases it when the process exits, so a crashed instance never leaves a stale lock behind.
+type Lock struct {
+	file *os.File
+}
+
+// TryLock takes the lock on the file at the given path, creating the file if needed.
+// It returns ErrLocked without waiting if another instance holds it.
+func TryLock(path string) (*Lock, error) {
+	file, err := os.OpenFile(filepath.Clean(path), os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+
+	if err := lockFile(file); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	if err := writePID(file); err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("failed to write lock file: %w", err)
+	}
+
+	return &Lock{file: file}, nil
+}
+
+// Close releases the lock.
+func (lock *Lock) Close() error {
+	if err := unlockFile(lock.file); err != nil {
+		_ = lock.file.Close()
+		return err
+	}
+
+	return lock.file.Close()
+}
+
+// ReadLockPID returns the PID of the instance holding the lock on the file at the given path.
+func ReadLockPID(path string) (int, error) {
+	b, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.Atoi(strings.TrimSpace(string(b)))
+}
+
+func writePID(file *os.File) error {
+	if err := file.Truncate(0); err != nil {
+		return err
+	}
+
+	if _, err := file.WriteAt([]byte(strconv.Itoa(os.Getpid())), 0); err != nil {
+		return err
+	}
+
+	return file.Sync()
+}
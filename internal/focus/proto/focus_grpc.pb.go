@@ -26,6 +26,7 @@ const _ = grpc.SupportPackageIsVersion7
 type FocusClient interface {
 	Raise(ctx context.Context, in *wrapperspb.StringValue, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	Version(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*VersionResponse, error)
+	Command(ctx context.Context, in *CommandRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 }
 
 type focusClient struct {
@@ -54,12 +55,22 @@ func (c *focusClient) Version(ctx context.Context, in *emptypb.Empty, opts ...gr
 	return out, nil
 }
 
+func (c *focusClient) Command(ctx context.Context, in *CommandRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/focus.Focus/Command", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // FocusServer is the server API for Focus service.
 // All implementations must embed UnimplementedFocusServer
 // for forward compatibility
 type FocusServer interface {
 	Raise(context.Context, *wrapperspb.StringValue) (*emptypb.Empty, error)
 	Version(context.Context, *emptypb.Empty) (*VersionResponse, error)
+	Command(context.Context, *CommandRequest) (*emptypb.Empty, error)
 	mustEmbedUnimplementedFocusServer()
 }
 
@@ -73,6 +84,9 @@ func (UnimplementedFocusServer) Raise(context.Context, *wrapperspb.StringValue)
 func (UnimplementedFocusServer) Version(context.Context, *emptypb.Empty) (*VersionResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Version not implemented")
 }
+func (UnimplementedFocusServer) Command(context.Context, *CommandRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Command not implemented")
+}
 func (UnimplementedFocusServer) mustEmbedUnimplementedFocusServer() {}
 
 // UnsafeFocusServer may be embedded to opt out of forward compatibility for this service.
@@ -122,6 +136,24 @@ func _Focus_Version_Handler(srv interface{}, ctx context.Context, dec func(inter
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Focus_Command_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CommandRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FocusServer).Command(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/focus.Focus/Command",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FocusServer).Command(ctx, req.(*CommandRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Focus_ServiceDesc is the grpc.ServiceDesc for Focus service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -137,6 +169,10 @@ var Focus_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Version",
 			Handler:    _Focus_Version_Handler,
 		},
+		{
+			MethodName: "Command",
+			Handler:    _Focus_Command_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "focus.proto",
@@ -15,7 +15,8 @@
 // You should have received a copy of the GNU General Public License
 // along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
 
-// Package focus provides a gRPC service for raising the application.
+// Package focus coordinates the instances of the application: the lock held by the running instance,
+// and a gRPC service through which another instance raises it and passes it the arguments it was launched with.
 package focus
 
 import (
@@ -43,7 +44,7 @@ type Service struct {
 	proto.UnimplementedFocusServer
 
 	server  *grpc.Server
-	raiseCh chan struct{}
+	raiseCh chan []string
 	version *semver.Version
 
 	log          *logrus.Entry
@@ -55,7 +56,7 @@ type Service struct {
 func NewService(locator service.Locator, version *semver.Version, panicHandler async.PanicHandler) (*Service, error) {
 	serv := &Service{
 		server:       grpc.NewServer(),
-		raiseCh:      make(chan struct{}, 1),
+		raiseCh:      make(chan []string, 1),
 		version:      version,
 		log:          logrus.WithField("pkg", "focus/service"),
 		panicHandler: panicHandler,
@@ -94,7 +95,15 @@ func NewService(locator service.Locator, version *semver.Version, panicHandler a
 // Raise implements the gRPC FocusService interface; it raises the application.
 func (service *Service) Raise(_ context.Context, reason *wrapperspb.StringValue) (*emptypb.Empty, error) {
 	service.log.WithField("Reason", reason.Value).Debug("Raise")
-	service.raiseCh <- struct{}{}
+	service.raiseCh <- nil
+	return &emptypb.Empty{}, nil
+}
+
+// Command implements the gRPC FocusService interface; it raises the application, passing it the arguments
+// another instance was launched with.
+func (service *Service) Command(_ context.Context, req *proto.CommandRequest) (*emptypb.Empty, error) {
+	service.log.WithField("args", req.GetArgs()).Debug("Command")
+	service.raiseCh <- req.GetArgs()
 	return &emptypb.Empty{}, nil
 }
 
@@ -106,8 +115,9 @@ func (service *Service) Version(context.Context, *emptypb.Empty) (*proto.Version
 	}, nil
 }
 
-// GetRaiseCh returns a channel on which events are sent when the application should be raised.
-func (service *Service) GetRaiseCh() <-chan struct{} {
+// GetRaiseCh returns a channel on which events are sent when the application should be raised,
+// with the arguments the instance requesting it was launched with, if any.
+func (service *Service) GetRaiseCh() <-chan []string {
 	return service.raiseCh
 }
 
@@ -0,0 +1,135 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"time"
+
+	"github.com/abiosoft/ishell"
+)
+
+func (f *frontendCLI) listAccessGrants(_ *ishell.Context) {
+	grants := f.bridge.GetAccessGrants()
+
+	if len(grants) == 0 {
+		f.Println("No access granted. Grant some with `access grant <name> <viewer|operator|admin>`.")
+		return
+	}
+
+	for _, grant := range grants {
+		revoked := ""
+
+		if grant.IsRevoked() {
+			revoked = ", revoked " + grant.RevokedAt.Format(time.RFC822)
+		}
+
+		f.Printf("%s: %s as %s, granted %s%s\n", grant.ID, bold(grant.Name), grant.Role, grant.CreatedAt.Format(time.RFC822), revoked)
+	}
+}
+
+func (f *frontendCLI) grantAccess(c *ishell.Context) {
+	if len(c.Args) != 2 {
+		f.Println("Please specify the name of the client and its role, e.g. `access grant monitoring viewer`.")
+		return
+	}
+
+	role, err := parseAccessRole(c.Args[1])
+	if err != nil {
+		f.printAndLogError("Cannot grant access:", err)
+		return
+	}
+
+	grant, token, err := f.bridge.AddAccessGrant(c.Args[0], role)
+	if err != nil {
+		f.printAndLogError("Cannot grant access:", err)
+		return
+	}
+
+	f.Printf("Granted the %s role to %s (%s).\n", grant.Role, bold(grant.Name), grant.ID)
+	f.Println("The client must send this token as server-token; it is not shown again:")
+	f.Println(bold(token))
+}
+
+func (f *frontendCLI) revokeAccess(c *ishell.Context) {
+	if len(c.Args) != 1 {
+		f.Println("Please specify the ID of the grant.")
+		return
+	}
+
+	if err := f.bridge.RevokeAccessGrant(c.Args[0]); err != nil {
+		f.printAndLogError("Cannot revoke access:", err)
+		return
+	}
+
+	f.Println("Access revoked.")
+}
+
+func (f *frontendCLI) listPairedClients(_ *ishell.Context) {
+	clients := f.bridge.ListPairedClients()
+
+	if len(clients) == 0 {
+		f.Println("No paired clients. Pair one with `pairing start <viewer|operator|admin>`.")
+		return
+	}
+
+	for _, client := range clients {
+		revoked := ""
+
+		if client.IsRevoked() {
+			revoked = ", revoked " + client.RevokedAt.Format(time.RFC822)
+		}
+
+		f.Printf("%s: %s as %s, paired %s%s\n", client.ID, bold(client.Name), client.Role, client.PairedAt.Format(time.RFC822), revoked)
+	}
+}
+
+func (f *frontendCLI) startPairing(c *ishell.Context) {
+	if len(c.Args) != 1 {
+		f.Println("Please specify the role of the client, e.g. `pairing start viewer`.")
+		return
+	}
+
+	role, err := parseAccessRole(c.Args[0])
+	if err != nil {
+		f.printAndLogError("Cannot start pairing:", err)
+		return
+	}
+
+	code, expiresAt, err := f.bridge.StartPairing(role)
+	if err != nil {
+		f.printAndLogError("Cannot start pairing:", err)
+		return
+	}
+
+	f.Printf("Pairing code: %s\n", bold(code))
+	f.Printf("Enter it in the frontend before %s to grant it the %s role.\n", expiresAt.Format(time.Kitchen), role)
+}
+
+func (f *frontendCLI) revokePairedClient(c *ishell.Context) {
+	if len(c.Args) != 1 {
+		f.Println("Please specify the ID of the paired client.")
+		return
+	}
+
+	if err := f.bridge.RevokePairedClient(c.Args[0]); err != nil {
+		f.printAndLogError("Cannot revoke paired client:", err)
+		return
+	}
+
+	f.Println("Paired client revoked.")
+}
@@ -19,19 +19,24 @@ package cli
 
 import (
 	"context"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/ProtonMail/gluon/async"
 	"github.com/ProtonMail/go-proton-api"
 	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
 	"github.com/ProtonMail/proton-bridge/v3/internal/certs"
-	"github.com/ProtonMail/proton-bridge/v3/internal/constants"
+	"github.com/ProtonMail/proton-bridge/v3/internal/mailto"
+	"github.com/ProtonMail/proton-bridge/v3/internal/user"
 	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
 	"github.com/abiosoft/ishell"
+	"github.com/bradenaw/juniper/xslices"
 )
 
 func (f *frontendCLI) listAccounts(_ *ishell.Context) {
-	spacing := "%-2d: %-20s (%-15s, %-15s)\n"
-	f.Printf(bold(strings.ReplaceAll(spacing, "d", "s")), "#", "account", "status", "address mode")
+	spacing := "%-2d: %-20s %-20s (%-15s, %-15s)\n"
+	f.Printf(bold(strings.ReplaceAll(spacing, "d", "s")), "#", "account", "nickname", "status", "address mode")
 	for idx, userID := range f.bridge.GetUserIDs() {
 		user, err := f.bridge.GetUserInfo(userID)
 		if err != nil {
@@ -50,7 +55,7 @@ func (f *frontendCLI) listAccounts(_ *ishell.Context) {
 			panic("Unknown user state")
 		}
 
-		f.Printf(spacing, idx, user.Username, state, user.AddressMode)
+		f.Printf(spacing, idx, user.Username, user.Display.Nickname, state, user.AddressMode)
 	}
 	f.Println()
 }
@@ -88,18 +93,18 @@ func (f *frontendCLI) showAccountAddressInfo(user bridge.UserInfo, address strin
 	)
 
 	imapSecurity := StartTLS
-	if f.bridge.GetIMAPSSL() {
+	if f.bridge.UsesIMAPSSL() {
 		imapSecurity = SSL
 	}
 
 	smtpSecurity := StartTLS
-	if f.bridge.GetSMTPSSL() {
+	if f.bridge.UsesSMTPSSL() {
 		smtpSecurity = SSL
 	}
 
 	f.Println(bold("Configuration for " + address))
 	f.Printf("IMAP Settings\nAddress:   %s\nIMAP port: %d\nUsername:  %s\nPassword:  %s\nSecurity:  %s\n",
-		constants.Host,
+		f.bridge.GetHost(),
 		f.bridge.GetIMAPPort(),
 		address,
 		user.BridgePass,
@@ -107,7 +112,7 @@ func (f *frontendCLI) showAccountAddressInfo(user bridge.UserInfo, address strin
 	)
 	f.Println("")
 	f.Printf("SMTP Settings\nAddress:   %s\nSMTP port: %d\nUsername:  %s\nPassword:  %s\nSecurity:  %s\n",
-		constants.Host,
+		f.bridge.GetSMTPHost(),
 		f.bridge.GetSMTPPort(),
 		address,
 		user.BridgePass,
@@ -205,6 +210,63 @@ func (f *frontendCLI) logoutAccount(c *ishell.Context) {
 	}
 }
 
+func (f *frontendCLI) showSession(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user.UserID == "" {
+		return
+	}
+
+	info, err := f.bridge.GetSessionInfo(context.Background(), user.UserID)
+	if err != nil {
+		f.printAndLogError("Cannot get session: ", err)
+		return
+	}
+
+	refreshedAt := "not since sign-in"
+	if !info.RefreshedAt.IsZero() {
+		refreshedAt = info.RefreshedAt.Format(time.RFC822)
+	}
+
+	f.Println("Session:       ", info.UID)
+	f.Println("Created:       ", info.CreatedAt.Format(time.RFC822))
+	f.Println("Last refreshed:", refreshedAt)
+	f.Println("Client:        ", info.ClientName, "("+info.ClientID+")")
+}
+
+func (f *frontendCLI) recreateSession(c *ishell.Context) {
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	user := f.askUserByIndexOrName(c)
+	if user.UserID == "" {
+		return
+	}
+
+	if !f.yesNoQuestion("Are you sure you want to revoke the session of " + bold(user.Username) + " and sign in again") {
+		return
+	}
+
+	password := f.readStringInAttempts("Password", c.ReadPassword, isNotEmpty)
+	if password == "" {
+		return
+	}
+
+	getTOTP := func() (string, error) {
+		return f.readStringInAttempts("Two factor code", c.ReadLine, isNotEmpty), nil
+	}
+
+	getKeyPass := func() ([]byte, error) {
+		return []byte(f.readStringInAttempts("Mailbox password", c.ReadPassword, isNotEmpty)), nil
+	}
+
+	if err := f.bridge.RecreateSession(context.Background(), user.UserID, []byte(password), getTOTP, getKeyPass); err != nil {
+		f.printAndLogError("Cannot recreate session, sign in again with `login`: ", err)
+		return
+	}
+
+	f.Printf("The session of %s was recreated.\n", bold(user.Username))
+}
+
 func (f *frontendCLI) deleteAccount(c *ishell.Context) {
 	f.ShowPrompt(false)
 	defer f.ShowPrompt(true)
@@ -214,6 +276,14 @@ func (f *frontendCLI) deleteAccount(c *ishell.Context) {
 		return
 	}
 
+	report, err := f.bridge.PreviewDeleteUser(user.UserID)
+	if err != nil {
+		f.printAndLogError("Cannot list account data: ", err)
+		return
+	}
+
+	f.printDeletionReport(report)
+
 	if f.yesNoQuestion("Are you sure you want to " + bold("remove account "+user.Username)) {
 		if err := f.bridge.DeleteUser(context.Background(), user.UserID); err != nil {
 			f.printAndLogError("Cannot delete account: ", err)
@@ -246,10 +316,78 @@ func (f *frontendCLI) deleteAccounts(c *ishell.Context) {
 	c.Println("Keychain cleared")
 }
 
+func (f *frontendCLI) printDeletionReport(report bridge.DeletionReport) {
+	var numFiles int
+
+	for _, file := range report.Files {
+		if !file.IsDir {
+			numFiles++
+		}
+	}
+
+	f.Printf("This removes %v account(s) and deletes %v local file(s) (%.1f MB).\n", len(report.UserIDs), numFiles, float64(report.Size)/(1<<20))
+	f.Printf("This can be undone with the %v command within %v.\n", bold("undo"), bridge.RemovalUndoPeriod)
+}
+
+func (f *frontendCLI) undoRemoval(c *ishell.Context) {
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	removals, err := f.bridge.ListRemovals()
+	if err != nil {
+		f.printAndLogError("Cannot list removals: ", err)
+		return
+	}
+
+	if len(removals) == 0 {
+		f.Println("There is nothing to undo")
+		return
+	}
+
+	for idx, removal := range removals {
+		what := "factory reset"
+		if removal.Kind == bridge.RemovalKindUser {
+			what = "removal of account " + removal.UserID
+		}
+
+		f.Printf("%2d: %v at %v (until %v)\n", idx, what, removal.RemovedAt.Format(time.RFC822), removal.ExpiresAt.Format(time.RFC822))
+	}
+
+	idx, err := strconv.Atoi(f.readStringInAttempts("Index", c.ReadLine, isNotEmpty))
+	if err != nil || idx < 0 || idx >= len(removals) {
+		f.Println("Invalid index")
+		return
+	}
+
+	if err := f.bridge.UndoRemoval(removals[idx].Token); err != nil {
+		f.printAndLogError("Cannot undo removal: ", err)
+		return
+	}
+
+	if removals[idx].Kind == bridge.RemovalKindUser {
+		f.Println("Account restored, please log in again")
+		return
+	}
+
+	f.Println("Everything restored, restarting")
+
+	f.restarter.Set(true, false)
+
+	f.Stop()
+}
+
 func (f *frontendCLI) deleteEverything(c *ishell.Context) {
 	f.ShowPrompt(false)
 	defer f.ShowPrompt(true)
 
+	report, err := f.bridge.PreviewFactoryReset()
+	if err != nil {
+		f.printAndLogError("Cannot list data: ", err)
+		return
+	}
+
+	f.printDeletionReport(report)
+
 	if !f.yesNoQuestion("Do you really want remove everything") {
 		return
 	}
@@ -288,33 +426,769 @@ func (f *frontendCLI) changeMode(c *ishell.Context) {
 	f.Printf("Address mode for account %s changed to %s\n", user.Username, targetMode)
 }
 
-func (f *frontendCLI) configureAppleMail(c *ishell.Context) {
+func (f *frontendCLI) changeSpamFilter(c *ishell.Context) {
 	user := f.askUserByIndexOrName(c)
 	if user.UserID == "" {
 		return
 	}
 
-	if !f.yesNoQuestion("Are you sure you want to configure Apple Mail for " + bold(user.Username) + " with address " + bold(user.Addresses[0])) {
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	backends := map[string]vault.SpamFilterBackend{
+		vault.SpamFilterNone.String():   vault.SpamFilterNone,
+		vault.SpamFilterSpamd.String():  vault.SpamFilterSpamd,
+		vault.SpamFilterRspamd.String(): vault.SpamFilterRspamd,
+	}
+
+	backend := f.readStringInAttempts("Spam filter (none, spamd or rspamd)", c.ReadLine, func(val string) bool {
+		_, ok := backends[strings.ToLower(strings.TrimSpace(val))]
+		return ok
+	})
+	if backend == "" {
 		return
 	}
 
-	cert, _ := f.bridge.GetBridgeTLSCert()
-	installer := certs.NewInstaller()
-	if !installer.IsCertInstalled(cert) {
-		f.Println("Apple Mail requires that a TLS certificate for bridge IMAP and SMTP server is installed in your system keychain.")
-		f.Println("Please provide your credentials in the system popup dialog in order to continue.")
-		if err := installer.InstallCert(cert); err != nil {
-			f.printAndLogError(err)
-			return
+	filter := vault.SpamFilter{Backend: backends[strings.ToLower(strings.TrimSpace(backend))]}
+
+	if filter.Backend != vault.SpamFilterNone {
+		f.Print("Filter address, host:port or unix:/path (leave empty for default): ")
+		filter.Address = strings.TrimSpace(c.ReadLine())
+		filter.MoveToSpam = f.yesNoQuestion("Move messages classified as spam to the Spam folder")
+	}
+
+	if err := f.bridge.SetSpamFilter(context.Background(), user.UserID, filter); err != nil {
+		f.printAndLogError("Cannot set spam filter:", err)
+		return
+	}
+
+	f.Printf("Spam filter for account %s changed to %s\n", user.Username, filter.Backend)
+}
+
+func (f *frontendCLI) changeLabelKeywords(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user.UserID == "" {
+		return
+	}
+
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	modes := map[string]vault.LabelKeywordMode{
+		vault.LabelKeywordsOff.String():  vault.LabelKeywordsOff,
+		vault.LabelKeywordsBoth.String(): vault.LabelKeywordsBoth,
+		vault.LabelKeywordsOnly.String(): vault.LabelKeywordsOnly,
+	}
+
+	f.Println("off: labels are mailboxes, both: labels are mailboxes and keywords, only: labels are keywords.")
+
+	mode := f.readStringInAttempts("Label keywords (off, both or only)", c.ReadLine, func(val string) bool {
+		_, ok := modes[strings.ToLower(strings.TrimSpace(val))]
+		return ok
+	})
+	if mode == "" {
+		return
+	}
+
+	if err := f.bridge.SetLabelKeywordMode(context.Background(), user.UserID, modes[strings.ToLower(strings.TrimSpace(mode))]); err != nil {
+		f.printAndLogError("Cannot set label keywords:", err)
+		return
+	}
+
+	f.Printf("Label keywords for account %s changed to %s\n", user.Username, strings.ToLower(strings.TrimSpace(mode)))
+}
+
+func (f *frontendCLI) changeDeleteMode(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user.UserID == "" {
+		return
+	}
+
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	modes := map[string]vault.DeleteMode{
+		vault.DeleteModeRemove.String():    vault.DeleteModeRemove,
+		vault.DeleteModeTrash.String():     vault.DeleteModeTrash,
+		vault.DeleteModePermanent.String(): vault.DeleteModePermanent,
+		vault.DeleteModeArchive.String():   vault.DeleteModeArchive,
+	}
+
+	f.Println("remove: messages are removed from the folder, trash: moved to Trash, permanent: permanently deleted, archive: moved to Archive.")
+
+	mode := f.readStringInAttempts("Delete mode (remove, trash, permanent or archive)", c.ReadLine, func(val string) bool {
+		_, ok := modes[strings.ToLower(strings.TrimSpace(val))]
+		return ok
+	})
+	if mode == "" {
+		return
+	}
+
+	target := modes[strings.ToLower(strings.TrimSpace(mode))]
+
+	if target == vault.DeleteModePermanent && !f.yesNoQuestion("Messages expunged from any folder will be permanently deleted. Are you sure") {
+		return
+	}
+
+	if err := f.bridge.SetDeleteMode(context.Background(), user.UserID, target); err != nil {
+		f.printAndLogError("Cannot set delete mode:", err)
+		return
+	}
+
+	f.Printf("Delete mode for account %s changed to %s\n", user.Username, target)
+}
+
+func (f *frontendCLI) changeMigrationMode(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user.UserID == "" {
+		return
+	}
+
+	enabled, err := f.bridge.GetMigrationMode(user.UserID)
+	if err != nil {
+		f.printAndLogError("Cannot get migration mode:", err)
+		return
+	}
+
+	question := "Enable migration mode, tuning imports for bulk APPEND from tools like imapsync, for account " + bold(user.Username)
+	if enabled {
+		question = "Disable migration mode for account " + bold(user.Username)
+	}
+
+	if !f.yesNoQuestion(question) {
+		return
+	}
+
+	if err := f.bridge.SetMigrationMode(context.Background(), user.UserID, !enabled); err != nil {
+		f.printAndLogError("Cannot set migration mode:", err)
+		return
+	}
+
+	if enabled {
+		f.Printf("Migration mode for account %s disabled\n", user.Username)
+	} else {
+		f.Printf("Migration mode for account %s enabled\n", user.Username)
+	}
+}
+
+func (f *frontendCLI) changeConversationMode(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user.UserID == "" {
+		return
+	}
+
+	enabled, err := f.bridge.GetConversationMode(user.UserID)
+	if err != nil {
+		f.printAndLogError("Cannot get conversation mode:", err)
+		return
+	}
+
+	question := "Enable conversation mode, normalizing threading headers, and resync account " + bold(user.Username)
+	if enabled {
+		question = "Disable conversation mode and resync account " + bold(user.Username)
+	}
+
+	if !f.yesNoQuestion(question) {
+		return
+	}
+
+	if err := f.bridge.SetConversationMode(context.Background(), user.UserID, !enabled); err != nil {
+		f.printAndLogError("Cannot set conversation mode:", err)
+		return
+	}
+
+	if enabled {
+		f.Printf("Conversation mode for account %s disabled\n", user.Username)
+	} else {
+		f.Printf("Conversation mode for account %s enabled\n", user.Username)
+	}
+}
+
+func (f *frontendCLI) changeTranscodeToUTF8(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user.UserID == "" {
+		return
+	}
+
+	enabled, err := f.bridge.GetTranscodeToUTF8(user.UserID)
+	if err != nil {
+		f.printAndLogError("Cannot get UTF-8 transcoding:", err)
+		return
+	}
+
+	question := "Enable transcoding of legacy charsets to UTF-8 and resync account " + bold(user.Username)
+	if enabled {
+		question = "Disable UTF-8 transcoding, serving original charsets, and resync account " + bold(user.Username)
+	}
+
+	if !f.yesNoQuestion(question) {
+		return
+	}
+
+	if err := f.bridge.SetTranscodeToUTF8(context.Background(), user.UserID, !enabled); err != nil {
+		f.printAndLogError("Cannot set UTF-8 transcoding:", err)
+		return
+	}
+
+	if enabled {
+		f.Printf("UTF-8 transcoding for account %s disabled\n", user.Username)
+	} else {
+		f.Printf("UTF-8 transcoding for account %s enabled\n", user.Username)
+	}
+}
+
+func (f *frontendCLI) changeContactDisplayNames(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user.UserID == "" {
+		return
+	}
+
+	enabled, err := f.bridge.GetContactDisplayNames(user.UserID)
+	if err != nil {
+		f.printAndLogError("Cannot get contact display names:", err)
+		return
+	}
+
+	question := "Show senders by their contact names and resync account " + bold(user.Username)
+	if enabled {
+		question = "Show senders by their own display names and resync account " + bold(user.Username)
+	}
+
+	if !f.yesNoQuestion(question) {
+		return
+	}
+
+	if err := f.bridge.SetContactDisplayNames(context.Background(), user.UserID, !enabled); err != nil {
+		f.printAndLogError("Cannot set contact display names:", err)
+		return
+	}
+
+	if enabled {
+		f.Printf("Contact display names for account %s disabled\n", user.Username)
+	} else {
+		f.Printf("Contact display names for account %s enabled\n", user.Username)
+	}
+}
+
+func (f *frontendCLI) changeDateNormalization(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user.UserID == "" {
+		return
+	}
+
+	timeZone, repair, err := f.bridge.GetDateNormalization(user.UserID)
+	if err != nil {
+		f.printAndLogError("Cannot get date normalization:", err)
+		return
+	}
+
+	if timeZone == "" {
+		timeZone = "as sent"
+	}
+
+	f.Printf("Dates of account %s are shown %s, repairing invalid ones: %v.\n", bold(user.Username), timeZone, repair)
+
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	value := f.readStringInAttempts("Time zone, e.g. Europe/Zurich, or as-sent", c.ReadLine, func(val string) bool {
+		if val == "as-sent" {
+			return true
 		}
+
+		_, err := time.LoadLocation(val)
+		return val != "" && err == nil
+	})
+	if value == "" {
+		return
 	}
 
-	if err := f.bridge.ConfigureAppleMail(context.Background(), user.UserID, user.Addresses[0]); err != nil {
-		f.printAndLogError(err)
+	if value == "as-sent" {
+		value = ""
+	}
+
+	repair = f.yesNoQuestion("Replace missing, invalid or far future dates with the time the message was received")
+
+	if !f.yesNoQuestion("Apply and resync account " + bold(user.Username)) {
 		return
 	}
 
-	f.Printf("Apple Mail configured for %v with address %v\n", user.Username, user.Addresses[0])
+	if err := f.bridge.SetDateNormalization(context.Background(), user.UserID, value, repair); err != nil {
+		f.printAndLogError("Cannot set date normalization:", err)
+		return
+	}
+
+	f.Printf("Date normalization for account %s changed\n", user.Username)
+}
+
+func (f *frontendCLI) changeDisplayMetadata(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user.UserID == "" {
+		return
+	}
+
+	f.Printf("Account %s is shown as %q, color %q, initials %q, sort order %v.\n",
+		bold(user.Username), user.Display.Nickname, user.Display.Color, user.Display.Initials, user.Display.SortOrder)
+
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	var display vault.DisplayMetadata
+
+	f.Print("Nickname, or empty to show the username: ")
+	display.Nickname = strings.TrimSpace(c.ReadLine())
+
+	f.Print("Color as #RRGGBB, or empty for the default: ")
+	display.Color = strings.TrimSpace(c.ReadLine())
+
+	f.Print("Avatar initials, or empty to derive them from the username: ")
+	display.Initials = strings.TrimSpace(c.ReadLine())
+
+	sortOrder := f.readStringInAttempts("Sort order, lowest first", c.ReadLine, func(val string) bool {
+		_, err := strconv.Atoi(val)
+		return err == nil
+	})
+	if sortOrder == "" {
+		return
+	}
+
+	display.SortOrder, _ = strconv.Atoi(sortOrder)
+
+	if err := f.bridge.SetUserDisplayMetadata(user.UserID, display); err != nil {
+		f.printAndLogError("Cannot set display metadata:", err)
+		return
+	}
+
+	f.Printf("Display metadata for account %s changed\n", user.Username)
+}
+
+func (f *frontendCLI) changeMailClient(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user.UserID == "" {
+		return
+	}
+
+	current, err := f.bridge.GetUserMailClient(user.UserID)
+	if err != nil {
+		f.printAndLogError("Cannot get mail client:", err)
+		return
+	}
+
+	if current.Client == "" {
+		f.Printf("Account %s isn't bound to a mail client.\n", bold(user.Username))
+	} else {
+		f.Printf("Account %s is bound to %v %q, profile %q.\n", bold(user.Username), current.Client, current.Path, current.Profile)
+	}
+
+	installed := f.bridge.DetectMailClients()
+	for _, client := range installed {
+		f.Printf("Found %v at %v", client.Client, client.Path)
+
+		if len(client.Profiles) > 0 {
+			f.Printf(", profiles: %v", strings.Join(client.Profiles, ", "))
+		}
+
+		f.Println()
+	}
+
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	clients := xslices.Map(mailto.Clients, func(client mailto.Client) string { return string(client) })
+
+	var client vault.MailClient
+
+	f.Print("Mail client, one of ", strings.Join(clients, ", "), " (leave empty to unbind): ")
+	client.Client = strings.TrimSpace(c.ReadLine())
+
+	if client.Client != "" {
+		// Default to the path of the client found on the system.
+		if idx := xslices.IndexFunc(installed, func(found mailto.InstalledClient) bool {
+			return strings.EqualFold(string(found.Client), client.Client)
+		}); idx >= 0 {
+			client.Path = installed[idx].Path
+		}
+
+		f.Printf("Path of its executable (leave empty for %q): ", client.Path)
+		if path := strings.TrimSpace(c.ReadLine()); path != "" {
+			client.Path = path
+		}
+
+		f.Print("Profile holding the account (leave empty for the default one): ")
+		client.Profile = strings.TrimSpace(c.ReadLine())
+	}
+
+	if err := f.bridge.SetUserMailClient(user.UserID, client); err != nil {
+		f.printAndLogError("Cannot set mail client:", err)
+		return
+	}
+
+	f.Printf("Mail client for account %s changed\n", user.Username)
+}
+
+func (f *frontendCLI) launchClient(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user.UserID == "" {
+		return
+	}
+
+	if err := f.bridge.LaunchClient(user.UserID); err != nil {
+		f.printAndLogError("Cannot launch mail client:", err)
+		return
+	}
+
+	f.Printf("Mail client of account %s launched\n", user.Username)
+}
+
+func (f *frontendCLI) changeDraftCoalescingInterval(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user.UserID == "" {
+		return
+	}
+
+	current, err := f.bridge.GetDraftCoalescingInterval(user.UserID)
+	if err != nil {
+		f.printAndLogError("Cannot get draft coalescing interval:", err)
+		return
+	}
+
+	f.Printf("Drafts of account %s are uploaded at most every %v (0 uploads every save).\n", bold(user.Username), current)
+
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	value := f.readStringInAttempts("Interval, e.g. 1m", c.ReadLine, func(val string) bool {
+		interval, err := time.ParseDuration(val)
+		return err == nil && interval >= 0
+	})
+	if value == "" {
+		return
+	}
+
+	interval, err := time.ParseDuration(value)
+	if err != nil {
+		f.Println("Invalid duration:", err)
+		return
+	}
+
+	if err := f.bridge.SetDraftCoalescingInterval(context.Background(), user.UserID, interval); err != nil {
+		f.printAndLogError("Cannot set draft coalescing interval:", err)
+		return
+	}
+
+	f.Printf("Drafts of account %s are now uploaded at most every %v\n", user.Username, interval)
+}
+
+func (f *frontendCLI) exportAccount(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user.UserID == "" {
+		return
+	}
+
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	location := f.readStringInAttempts("Export directory", c.ReadLine, isNotEmpty)
+	if location == "" {
+		return
+	}
+
+	f.Println(bold("Note that the messages will be stored unencrypted on disk."))
+
+	if !f.yesNoQuestion("Export all messages of account " + bold(user.Username) + " to " + bold(location)) {
+		return
+	}
+
+	records, err := f.bridge.ExportUserCompliance(context.Background(), user.UserID, location, func(_ string, i int, total int) {
+		f.Printf("Exporting message %v of %v\n", i, total)
+	})
+	if err != nil {
+		f.printAndLogError("Cannot export account:", err)
+		return
+	}
+
+	failed := 0
+
+	for _, record := range records {
+		if record.Error != "" {
+			failed++
+		}
+	}
+
+	f.Printf("Exported %v messages to %v", len(records)-failed, bold(location))
+
+	if failed > 0 {
+		f.Printf(" (%v could not be decrypted, see manifest)", failed)
+	}
+
+	f.Println()
+}
+
+func (f *frontendCLI) changeNotificationRules(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user.UserID == "" {
+		return
+	}
+
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	rules := vault.NotificationRules{Enabled: f.yesNoQuestion("Notify about new messages")}
+
+	if rules.Enabled {
+		f.Print("Folders and labels to notify about, comma separated (leave empty for all): ")
+		rules.Mailboxes = splitList(c.ReadLine())
+
+		f.Print("Folders and labels to mute, comma separated: ")
+		rules.Muted = splitList(c.ReadLine())
+
+		quietHours := f.readStringInAttempts("Quiet hours, e.g. 22:00-07:00 (leave empty for none)", c.ReadLine, func(val string) bool {
+			_, err := parseQuietHours(val)
+			return err == nil
+		})
+
+		hours, err := parseQuietHours(quietHours)
+		if err != nil {
+			f.printAndLogError(err)
+			return
+		}
+
+		rules.QuietHours = hours
+
+		f.Print("Webhook URL to post notifications to (leave empty for none): ")
+		rules.Webhook = strings.TrimSpace(c.ReadLine())
+	}
+
+	if err := f.bridge.SetNotificationRules(context.Background(), user.UserID, rules); err != nil {
+		f.printAndLogError("Cannot set notification rules:", err)
+		return
+	}
+
+	f.Printf("Notification rules for account %s changed\n", user.Username)
+}
+
+func (f *frontendCLI) changeNNTPFolders(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user.UserID == "" {
+		return
+	}
+
+	folders, err := f.bridge.GetNNTPFolders(user.UserID)
+	if err != nil {
+		f.printAndLogError("Cannot get NNTP folders:", err)
+		return
+	}
+
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	f.Println("Folders currently exposed as newsgroups:", strings.Join(folders, ", "))
+	f.Print("Folders to expose as newsgroups, comma separated, e.g. Lists/golang-nuts (leave empty for none): ")
+
+	if err := f.bridge.SetNNTPFolders(user.UserID, splitList(c.ReadLine())); err != nil {
+		f.printAndLogError("Cannot set NNTP folders:", err)
+		return
+	}
+
+	f.Printf("NNTP folders for account %s changed\n", user.Username)
+}
+
+func (f *frontendCLI) exportMailbox(c *ishell.Context) {
+	usr := f.askUserByIndexOrName(c)
+	if usr.UserID == "" {
+		return
+	}
+
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	format, err := user.ParseExportFormat(f.readStringInAttempts("Format (mbox or eml)", c.ReadLine, isNotEmpty))
+	if err != nil {
+		f.printAndLogError(err)
+		return
+	}
+
+	location := f.readStringInAttempts("Export directory", c.ReadLine, isNotEmpty)
+	if location == "" {
+		return
+	}
+
+	f.Println(bold("Note that the messages will be stored unencrypted on disk."))
+
+	if !f.yesNoQuestion("Export the mailboxes of account " + bold(usr.Username) + " to " + bold(location)) {
+		return
+	}
+
+	// The export runs in the background; its progress is printed as it goes.
+	go func() {
+		defer async.HandlePanic(f.panicHandler)
+
+		if err := f.bridge.ExportMailbox(context.Background(), usr.UserID, location, format); err != nil {
+			f.printAndLogError("Cannot export mailboxes:", err)
+		}
+	}()
+
+	f.Println("Export started. Use `export-mailbox cancel` to stop it.")
+}
+
+func (f *frontendCLI) cancelExportMailbox(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user.UserID == "" {
+		return
+	}
+
+	if err := f.bridge.CancelExportMailbox(user.UserID); err != nil {
+		f.printAndLogError("Cannot cancel export:", err)
+		return
+	}
+
+	f.Printf("Canceling the export of account %s\n", user.Username)
+}
+
+func (f *frontendCLI) importMessages(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user.UserID == "" {
+		return
+	}
+
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	source := f.readStringInAttempts("MBOX file, EML file, Maildir or directory to import", c.ReadLine, isNotEmpty)
+	if source == "" {
+		return
+	}
+
+	if !f.yesNoQuestion("Import the messages of " + bold(source) + " to account " + bold(user.Username)) {
+		return
+	}
+
+	// The import runs in the background; its progress is printed as it goes.
+	go func() {
+		defer async.HandlePanic(f.panicHandler)
+
+		if err := f.bridge.ImportMessages(context.Background(), user.UserID, source); err != nil {
+			f.printAndLogError("Cannot import messages:", err)
+		}
+	}()
+
+	f.Println("Import started. Use `import-messages cancel` to stop it.")
+}
+
+func (f *frontendCLI) cancelImportMessages(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user.UserID == "" {
+		return
+	}
+
+	if err := f.bridge.CancelImportMessages(user.UserID); err != nil {
+		f.printAndLogError("Cannot cancel import:", err)
+		return
+	}
+
+	f.Printf("Canceling the import to account %s\n", user.Username)
+}
+
+func (f *frontendCLI) changeSyncFilter(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user.UserID == "" {
+		return
+	}
+
+	filter, err := f.bridge.GetUserSyncFilter(user.UserID)
+	if err != nil {
+		f.printAndLogError("Cannot get sync filter:", err)
+		return
+	}
+
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	f.Println("Folders and labels currently excluded from sync:", strings.Join(filter.ExcludedLabelIDs, ", "))
+	f.Print("Folders and labels to exclude, comma separated, e.g. Spam, Labels/Archive (leave empty for none): ")
+
+	excluded := vault.SyncFilter{ExcludedLabelIDs: splitList(c.ReadLine())}
+
+	if err := f.bridge.SetUserSyncFilter(context.Background(), user.UserID, excluded); err != nil {
+		f.printAndLogError("Cannot set sync filter:", err)
+		return
+	}
+
+	f.Printf("Sync filter for account %s changed\n", user.Username)
+}
+
+func (f *frontendCLI) changeRedactionRules(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user.UserID == "" {
+		return
+	}
+
+	rules, err := f.bridge.GetUserRedactionRules(user.UserID)
+	if err != nil {
+		f.printAndLogError("Cannot get redaction rules:", err)
+		return
+	}
+
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	f.Println("Sender domains currently redacted:", strings.Join(rules.SenderDomains, ", "))
+	f.Print("Sender domains to redact, comma separated, e.g. bank.com, clinic.org (leave empty for none): ")
+
+	domains := splitList(c.ReadLine())
+
+	f.Println("Folders and labels currently redacted:", strings.Join(rules.LabelIDs, ", "))
+	f.Print("Folders and labels to redact, comma separated, e.g. Labels/Sensitive (leave empty for none): ")
+
+	labels := splitList(c.ReadLine())
+
+	if err := f.bridge.SetUserRedactionRules(context.Background(), user.UserID, vault.RedactionRules{
+		SenderDomains: domains,
+		LabelIDs:      labels,
+	}); err != nil {
+		f.printAndLogError("Cannot set redaction rules:", err)
+		return
+	}
+
+	f.Printf("Redaction rules for account %s changed\n", user.Username)
+}
+
+func (f *frontendCLI) configureAppleMail(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user.UserID == "" {
+		return
+	}
+
+	if !f.yesNoQuestion("Are you sure you want to configure Apple Mail for " + bold(user.Username) + " with address " + bold(user.Addresses[0])) {
+		return
+	}
+
+	if !f.installAppleMailCert() {
+		return
+	}
+
+	if err := f.bridge.ConfigureAppleMail(context.Background(), user.UserID, user.Addresses[0]); err != nil {
+		f.printAndLogError(err)
+		return
+	}
+
+	f.Printf("Apple Mail configured for %v with address %v\n", user.Username, user.Addresses[0])
+}
+
+// installAppleMailCert installs the bridge TLS certificate in the system keychain if needed, as Apple Mail requires.
+func (f *frontendCLI) installAppleMailCert() bool {
+	cert, _ := f.bridge.GetBridgeTLSCert()
+	installer := certs.NewInstaller()
+	if !installer.IsCertInstalled(cert) {
+		f.Println("Apple Mail requires that a TLS certificate for bridge IMAP and SMTP server is installed in your system keychain.")
+		f.Println("Please provide your credentials in the system popup dialog in order to continue.")
+		if err := installer.InstallCert(cert); err != nil {
+			f.printAndLogError(err)
+			return false
+		}
+	}
+
+	return true
 }
 
 func (f *frontendCLI) badEventSynchronize(_ *ishell.Context) {
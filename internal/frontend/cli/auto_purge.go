@@ -0,0 +1,125 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+	"github.com/abiosoft/ishell"
+)
+
+func (f *frontendCLI) setAutoPurge(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user.UserID == "" {
+		return
+	}
+
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	current, err := f.bridge.GetAutoPurge(user.UserID)
+	if err != nil {
+		f.printAndLogError("Cannot get auto-purge:", err)
+		return
+	}
+
+	f.Println("Messages older than the given number of days are permanently deleted, 0 disables the purge of the folder.")
+
+	trashDays, ok := f.readAutoPurgeDays(c, "Trash", current.TrashDays)
+	if !ok {
+		return
+	}
+
+	spamDays, ok := f.readAutoPurgeDays(c, "Spam", current.SpamDays)
+	if !ok {
+		return
+	}
+
+	purge := vault.AutoPurge{TrashDays: trashDays, SpamDays: spamDays}
+
+	if (purge.TrashDays > 0 || purge.SpamDays > 0) && !f.yesNoQuestion("Old messages will be permanently deleted from Proton. Are you sure") {
+		return
+	}
+
+	if err := f.bridge.SetAutoPurge(user.UserID, purge); err != nil {
+		f.printAndLogError("Cannot set auto-purge:", err)
+		return
+	}
+
+	f.Printf("Auto-purge for account %s changed to Trash: %d days, Spam: %d days\n", user.Username, purge.TrashDays, purge.SpamDays)
+}
+
+func (f *frontendCLI) readAutoPurgeDays(c *ishell.Context, folder string, current int) (int, bool) {
+	val := f.readStringInAttempts(folder+" days (current "+strconv.Itoa(current)+")", c.ReadLine, func(val string) bool {
+		days, err := strconv.Atoi(strings.TrimSpace(val))
+		return err == nil && days >= 0
+	})
+	if val == "" {
+		return 0, false
+	}
+
+	days, err := strconv.Atoi(strings.TrimSpace(val))
+	if err != nil {
+		return 0, false
+	}
+
+	return days, true
+}
+
+func (f *frontendCLI) previewAutoPurge(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user.UserID == "" {
+		return
+	}
+
+	candidates, err := f.bridge.PreviewAutoPurge(context.Background(), user.UserID)
+	if err != nil {
+		f.printAndLogError("Cannot preview auto-purge:", err)
+		return
+	}
+
+	if len(candidates) == 0 {
+		f.Printf("Auto-purge is disabled for account %s\n", user.Username)
+		return
+	}
+
+	for _, labelID := range []string{proton.TrashLabel, proton.SpamLabel} {
+		messages, ok := candidates[labelID]
+		if !ok {
+			continue
+		}
+
+		f.Printf("%s: %d messages would be purged\n", bold(autoPurgeFolderName(labelID)), len(messages))
+
+		for _, message := range messages {
+			f.Printf("  %s\n", message.Subject)
+		}
+	}
+}
+
+func autoPurgeFolderName(labelID string) string {
+	if labelID == proton.SpamLabel {
+		return "Spam"
+	}
+
+	return "Trash"
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"strings"
+	"time"
+
+	"github.com/abiosoft/ishell"
+)
+
+func (f *frontendCLI) listConnections(_ *ishell.Context) {
+	conns := f.bridge.ListConnections()
+	if len(conns) == 0 {
+		f.Println("No IMAP client is connected")
+		return
+	}
+
+	for _, conn := range conns {
+		username := "-"
+
+		if conn.UserID != "" {
+			if user, err := f.bridge.GetUserInfo(conn.UserID); err == nil {
+				username = user.Username
+			}
+		}
+
+		client := "-"
+		if conn.ClientID.Name != "" {
+			client = conn.ClientID.Name + " " + conn.ClientID.Version
+		}
+
+		f.Printf("%-4d %-21s %-20s %-30s since %s\n", conn.SessionID, conn.RemoteAddr, username, client, conn.Since.Format(time.RFC3339))
+
+		if len(conn.Shims) > 0 {
+			f.Printf("     shims: %s\n", strings.Join(conn.Shims, ", "))
+		}
+	}
+}
+
+func (f *frontendCLI) listClientShims(_ *ishell.Context) {
+	for _, shim := range f.bridge.GetClientShims() {
+		status := "enabled"
+		if !f.bridge.IsClientShimEnabled(shim.Name) {
+			status = "disabled"
+		}
+
+		f.Printf("%s (%s, clients matching %q): %s\n", bold(shim.Name), status, shim.Client, shim.Description)
+	}
+}
+
+func (f *frontendCLI) enableClientShim(c *ishell.Context) {
+	f.setClientShimEnabled(c, true)
+}
+
+func (f *frontendCLI) disableClientShim(c *ishell.Context) {
+	f.setClientShimEnabled(c, false)
+}
+
+func (f *frontendCLI) setClientShimEnabled(c *ishell.Context, enabled bool) {
+	if len(c.Args) != 1 {
+		f.Println("Please specify the name of the shim, as listed by `shims list`.")
+		return
+	}
+
+	if err := f.bridge.SetClientShimEnabled(c.Args[0], enabled); err != nil {
+		f.printAndLogError("Cannot change client shim:", err)
+		return
+	}
+
+	if enabled {
+		f.Printf("Client shim %s is enabled\n", c.Args[0])
+	} else {
+		f.Printf("Client shim %s is disabled\n", c.Args[0])
+	}
+}
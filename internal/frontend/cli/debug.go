@@ -20,7 +20,11 @@ package cli
 import (
 	"context"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
 	"github.com/abiosoft/ishell"
 )
 
@@ -72,3 +76,206 @@ func (f *frontendCLI) debugMailboxState(c *ishell.Context) {
 
 	c.Printf("\nMessage download finished. Data is available at %v\n", bold(location))
 }
+
+func (f *frontendCLI) debugSlowCommands(_ *ishell.Context) {
+	threshold := f.bridge.GetSlowCommandThreshold()
+	if threshold == 0 {
+		f.Println("Slow commands are not recorded. Set a threshold with `debug slow-commands threshold`.")
+		return
+	}
+
+	commands := f.bridge.GetSlowCommands()
+	if len(commands) == 0 {
+		f.Println("No IMAP command took longer than", threshold)
+		return
+	}
+
+	for _, command := range commands {
+		mailbox := command.Mailbox
+		if mailbox == "" {
+			mailbox = "-"
+		}
+
+		remote := ""
+		if command.RemoteCall {
+			remote = " (API)"
+		}
+
+		f.Printf("%s  %-11s %-20s %v%s\n", command.Time.Format(time.RFC3339), command.Command, mailbox, command.Duration.Round(time.Millisecond), remote)
+	}
+}
+
+func (f *frontendCLI) debugSlowCommandThreshold(c *ishell.Context) {
+	if len(c.Args) != 1 {
+		f.Println("Please specify the threshold, e.g. 2s, or 0 to stop recording slow commands.")
+		return
+	}
+
+	threshold, err := time.ParseDuration(c.Args[0])
+	if err != nil {
+		f.Println("Invalid duration:", err)
+		return
+	}
+
+	if err := f.bridge.SetSlowCommandThreshold(threshold); err != nil {
+		f.printAndLogError(err)
+	}
+}
+
+func (f *frontendCLI) debugAPIFixtures(_ *ishell.Context) {
+	if path := f.bridge.GetAPIFixturePath(); path != "" {
+		f.Println("API traffic is recorded to", bold(path))
+		return
+	}
+
+	f.Println("API traffic is not recorded. Start recording with `debug api-fixtures start`.")
+}
+
+func (f *frontendCLI) debugStartAPIFixtures(_ *ishell.Context) {
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	f.Println("Every API request and response is recorded, including across restarts, until you stop it.")
+	f.Println("Tokens, keys and login proofs are stripped; messages stay encrypted, but their metadata")
+	f.Println("such as subjects and addresses is recorded as is.\n")
+
+	if !f.yesNoQuestion("Start recording API traffic") {
+		return
+	}
+
+	if err := f.bridge.SetRecordAPIFixtures(true); err != nil {
+		f.printAndLogError("Cannot start recording API traffic:", err)
+		return
+	}
+
+	f.Println("API traffic is recorded to", bold(f.bridge.GetAPIFixturePath()))
+}
+
+func (f *frontendCLI) debugStopAPIFixtures(_ *ishell.Context) {
+	path := f.bridge.GetAPIFixturePath()
+
+	if err := f.bridge.SetRecordAPIFixtures(false); err != nil {
+		f.printAndLogError("Cannot stop recording API traffic:", err)
+		return
+	}
+
+	if path != "" {
+		f.Println("Recording stopped. Attach", bold(path), "to your bug report.")
+	}
+}
+
+func (f *frontendCLI) debugFaults(_ *ishell.Context) {
+	faults := f.bridge.GetFaultInjection()
+	if faults.IsZero() {
+		f.Println("No fault is injected. Inject faults with `debug faults set`.")
+		return
+	}
+
+	f.Printf("Rate limited requests: %v%%\n", faults.TooManyRequests*100)
+	f.Printf("Dropped connections:   %v%%\n", faults.DroppedConnections*100)
+	f.Printf("Latency:               %v\n", faults.Latency)
+	f.Printf("Disk full:             %v\n", faults.DiskFull)
+}
+
+func (f *frontendCLI) debugSetFaults(c *ishell.Context) {
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	if len(c.Args) < 3 || len(c.Args) > 4 || (len(c.Args) == 4 && c.Args[3] != "disk-full") {
+		f.Println("Please specify the percentage of rate limited requests, the percentage of dropped connections,")
+		f.Println("the latency and optionally `disk-full`, e.g. `debug faults set 10 5 2s disk-full`.")
+		return
+	}
+
+	var (
+		faults bridge.FaultInjection
+		err    error
+	)
+
+	if faults.TooManyRequests, err = parsePercentage(c.Args[0]); err != nil {
+		f.Println("Invalid percentage of rate limited requests:", err)
+		return
+	}
+
+	if faults.DroppedConnections, err = parsePercentage(c.Args[1]); err != nil {
+		f.Println("Invalid percentage of dropped connections:", err)
+		return
+	}
+
+	if faults.Latency, err = time.ParseDuration(c.Args[2]); err != nil {
+		f.Println("Invalid latency:", err)
+		return
+	}
+
+	faults.DiskFull = len(c.Args) == 4
+
+	f.Println("Injected faults make bridge misbehave on purpose, until it restarts or you turn them off.\n")
+
+	if !f.yesNoQuestion("Inject faults") {
+		return
+	}
+
+	if err := f.bridge.SetFaultInjection(faults); err != nil {
+		f.printAndLogError("Cannot inject faults:", err)
+	}
+}
+
+func (f *frontendCLI) debugFaultsOff(_ *ishell.Context) {
+	if err := f.bridge.SetFaultInjection(bridge.FaultInjection{}); err != nil {
+		f.printAndLogError("Cannot stop injecting faults:", err)
+	}
+}
+
+func parsePercentage(value string) (float64, error) {
+	percentage, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+	if err != nil {
+		return 0, err
+	}
+
+	return percentage / 100, nil
+}
+
+func (f *frontendCLI) debugSafeMode(_ *ishell.Context) {
+	if !f.bridge.IsSafeMode() {
+		f.Println("Bridge is not in safe mode. Start it with `--safe-mode` to skip the optional features.")
+		return
+	}
+
+	overrides := f.bridge.GetSafeModeOverrides()
+	if len(overrides) == 0 {
+		f.Println("All settings are applied: none of them prevents bridge from starting normally.")
+		return
+	}
+
+	f.Println("These settings are not applied in safe mode:")
+
+	for _, override := range overrides {
+		status := "OK"
+		if override.Err != nil {
+			status = bold("FAILS: " + override.Err.Error())
+		}
+
+		f.Printf("%-18s %-30s %s\n", override.Setting, override.Value, status)
+	}
+
+	f.Println("Change the failing settings, then restart bridge without `--safe-mode`.")
+}
+
+func (f *frontendCLI) debugInterference(_ *ishell.Context) {
+	found := f.bridge.DiagnoseInterference(context.Background())
+	if len(found) == 0 {
+		f.Println("No security software was found to interfere with bridge.")
+		return
+	}
+
+	for _, item := range found {
+		product := item.Product
+		if product == "" {
+			product = "unknown software"
+		}
+
+		f.Printf("%-18s %-20s %s\n", item.Kind, product, item.Detail)
+	}
+
+	f.Println("Add an exception for bridge to your security software, or disable its email scanning.")
+}
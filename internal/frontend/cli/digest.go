@@ -0,0 +1,105 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"context"
+	"sort"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/events"
+	"github.com/abiosoft/ishell"
+)
+
+func (f *frontendCLI) enableDigest(c *ishell.Context) {
+	f.setDigestEnabled(c, true)
+}
+
+func (f *frontendCLI) disableDigest(c *ishell.Context) {
+	f.setDigestEnabled(c, false)
+}
+
+func (f *frontendCLI) setDigestEnabled(c *ishell.Context, enabled bool) {
+	user := f.askUserByIndexOrName(c)
+	if user.UserID == "" {
+		return
+	}
+
+	if err := f.bridge.SetDigestEnabled(context.Background(), user.UserID, enabled); err != nil {
+		f.printAndLogError("Cannot set digest:", err)
+		return
+	}
+
+	if enabled {
+		f.Printf("Weekly digest for account %s is enabled\n", user.Username)
+	} else {
+		f.Printf("Weekly digest for account %s is disabled\n", user.Username)
+	}
+}
+
+func (f *frontendCLI) showDigest(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user.UserID == "" {
+		return
+	}
+
+	enabled, err := f.bridge.GetDigestEnabled(user.UserID)
+	if err != nil {
+		f.printAndLogError("Cannot get digest:", err)
+		return
+	}
+
+	if !enabled {
+		f.Printf("Weekly digest is disabled for account %s\n", user.Username)
+		return
+	}
+
+	digest, err := f.bridge.GetDigest(context.Background(), user.UserID)
+	if err != nil {
+		f.printAndLogError("Cannot get digest:", err)
+		return
+	}
+
+	f.printDigest(user.Username, digest)
+}
+
+func (f *frontendCLI) printDigest(username string, digest events.UserDigest) {
+	f.Printf("Digest of %s from %s to %s\n", bold(username), digest.Since.Format("Jan _2"), digest.Until.Format("Jan _2"))
+
+	mailboxes := make([]string, 0, len(digest.Received))
+
+	for mailbox := range digest.Received {
+		mailboxes = append(mailboxes, mailbox)
+	}
+
+	sort.Strings(mailboxes)
+
+	for _, mailbox := range mailboxes {
+		f.Printf("  %s: %d new messages\n", mailbox, digest.Received[mailbox])
+	}
+
+	if len(digest.TopSenders) > 0 {
+		f.Println("  Top senders:")
+
+		for _, sender := range digest.TopSenders {
+			f.Printf("    %s (%d)\n", sender.Address, sender.Count)
+		}
+	}
+
+	f.Printf("  Storage growth: %.1f MB\n", float64(digest.UsedSpaceGrowth)/(1<<20))
+	f.Printf("  Sync errors: %d\n", digest.SyncErrors)
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
+	"github.com/abiosoft/ishell"
+)
+
+// activeErrors returns the active errors not tied to an account followed by those of each account.
+func (f *frontendCLI) activeErrors() []bridge.ActiveError {
+	activeErrs := f.bridge.ListActiveErrors("")
+
+	for _, userID := range f.bridge.GetUserIDs() {
+		activeErrs = append(activeErrs, f.bridge.ListActiveErrors(userID)...)
+	}
+
+	return activeErrs
+}
+
+func (f *frontendCLI) listErrors(_ *ishell.Context) {
+	activeErrs := f.activeErrors()
+
+	if len(activeErrs) == 0 {
+		f.Println("No active errors")
+		return
+	}
+
+	for idx, activeErr := range activeErrs {
+		account := "Bridge"
+
+		if activeErr.UserID != "" {
+			if info, err := f.bridge.GetUserInfo(activeErr.UserID); err == nil {
+				account = info.Username
+			}
+		}
+
+		f.Printf("%2d: %s %s (%dx, last at %s)\n", idx, bold(account), activeErr.Code, activeErr.Count, activeErr.LastSeen.Format(time.Stamp))
+
+		if activeErr.Message != "" {
+			f.Printf("    %s\n", activeErr.Message)
+		}
+
+		f.Printf("    %s\n", activeErr.Action)
+
+		if activeErr.Retriable {
+			f.Println("    This error can be retried.")
+		}
+	}
+}
+
+func (f *frontendCLI) dismissError(c *ishell.Context) {
+	activeErr, ok := f.askActiveError(c)
+	if !ok {
+		return
+	}
+
+	if err := f.bridge.DismissError(activeErr.UserID, activeErr.Code); err != nil {
+		f.printAndLogError("Cannot dismiss error:", err)
+		return
+	}
+
+	f.Printf("Error %s dismissed\n", activeErr.Code)
+}
+
+func (f *frontendCLI) retryError(c *ishell.Context) {
+	activeErr, ok := f.askActiveError(c)
+	if !ok {
+		return
+	}
+
+	if err := f.bridge.RetryError(context.Background(), activeErr.UserID, activeErr.Code); err != nil {
+		f.printAndLogError("Cannot retry error:", err)
+		return
+	}
+
+	f.Printf("Retrying %s\n", activeErr.Code)
+}
+
+func (f *frontendCLI) askActiveError(c *ishell.Context) (bridge.ActiveError, bool) {
+	activeErrs := f.activeErrors()
+
+	if len(activeErrs) == 0 {
+		f.Println("No active errors")
+		return bridge.ActiveError{}, false
+	}
+
+	if len(c.Args) == 0 {
+		f.Printf("Please choose a number between 0 and %d, as printed by errors list.\n", len(activeErrs)-1)
+		return bridge.ActiveError{}, false
+	}
+
+	idx, err := strconv.Atoi(c.Args[0])
+	if err != nil || idx < 0 || idx >= len(activeErrs) {
+		f.Printf("Wrong input '%s'. Choose a number between 0 and %d.\n", bold(c.Args[0]), len(activeErrs)-1)
+		return bridge.ActiveError{}, false
+	}
+
+	return activeErrs[idx], true
+}
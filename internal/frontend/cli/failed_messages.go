@@ -0,0 +1,95 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/services/imapservice"
+	"github.com/abiosoft/ishell"
+)
+
+func (f *frontendCLI) listFailedMessages(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user.UserID == "" {
+		return
+	}
+
+	failed, err := f.bridge.ListFailedMessages(context.Background(), user.UserID)
+	if err != nil {
+		f.printAndLogError("Cannot list failed messages:", err)
+		return
+	}
+
+	f.printFailedMessages(user.Username, failed)
+}
+
+func (f *frontendCLI) retryFailedMessages(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user.UserID == "" {
+		return
+	}
+
+	failed, err := f.bridge.RetryFailed(context.Background(), user.UserID)
+	if err != nil {
+		f.printAndLogError("Cannot retry failed messages:", err)
+		return
+	}
+
+	f.printFailedMessages(user.Username, failed)
+}
+
+func (f *frontendCLI) skipFailedMessages(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user.UserID == "" {
+		return
+	}
+
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	ids := f.readStringInAttempts("Message IDs to skip, separated by spaces", c.ReadLine, isNotEmpty)
+	if ids == "" {
+		return
+	}
+
+	if !f.yesNoQuestion("Stop retrying these messages of account " + bold(user.Username) + " for good") {
+		return
+	}
+
+	if err := f.bridge.SkipPermanently(context.Background(), user.UserID, strings.Fields(ids)...); err != nil {
+		f.printAndLogError("Cannot skip failed messages:", err)
+		return
+	}
+
+	f.Println("Messages skipped.")
+}
+
+func (f *frontendCLI) printFailedMessages(username string, failed []imapservice.FailedMessage) {
+	if len(failed) == 0 {
+		f.Printf("No failed messages for account %s.\n", username)
+		return
+	}
+
+	f.Printf("Failed messages for account %s:\n", username)
+
+	for _, message := range failed {
+		f.Printf("\t%s  %d attempts, last %s: %s\n", bold(message.ID), message.Attempts, message.LastAttempt.Format("Jan _2 15:04:05"), message.Reason)
+	}
+}
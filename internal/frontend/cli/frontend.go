@@ -22,11 +22,14 @@ import (
 	"errors"
 	"os"
 	"runtime"
+	"strings"
+	"time"
 
 	"github.com/ProtonMail/gluon/async"
 	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
 	"github.com/ProtonMail/proton-bridge/v3/internal/constants"
 	"github.com/ProtonMail/proton-bridge/v3/internal/events"
+	"github.com/ProtonMail/proton-bridge/v3/internal/tenant"
 	"github.com/ProtonMail/proton-bridge/v3/pkg/restarter"
 
 	"github.com/abiosoft/ishell"
@@ -41,6 +44,12 @@ type frontendCLI struct {
 	bridge    *bridge.Bridge
 	restarter *restarter.Restarter
 
+	// tenants manages the tenants in multi-tenant mode; it is nil otherwise.
+	// While a tenant is selected, bridge is the tenant's bridge and daemon is the daemon's own one.
+	tenants *tenant.Manager
+	daemon  *bridge.Bridge
+	tenant  string
+
 	badUserID string
 
 	panicHandler async.PanicHandler
@@ -49,6 +58,7 @@ type frontendCLI struct {
 // New returns a new CLI frontend configured with the given options.
 func New(
 	bridge *bridge.Bridge,
+	tenants *tenant.Manager,
 	restarter *restarter.Restarter,
 	eventCh <-chan events.Event,
 	panicHandler async.PanicHandler,
@@ -58,6 +68,8 @@ func New(
 		Shell:        ishell.New(),
 		bridge:       bridge,
 		restarter:    restarter,
+		tenants:      tenants,
+		daemon:       bridge,
 		badUserID:    "",
 		panicHandler: panicHandler,
 	}
@@ -100,6 +112,96 @@ func New(
 		Func:      fe.changeMode,
 		Completer: fe.completeUsernames,
 	})
+	changeCmd.AddCmd(&ishell.Cmd{
+		Name:      "spam-filter",
+		Help:      "pass incoming messages of account through a local spamd or rspamd filter. Use index or account name as parameter.",
+		Func:      fe.changeSpamFilter,
+		Completer: fe.completeUsernames,
+	})
+	changeCmd.AddCmd(&ishell.Cmd{
+		Name:      "notifications",
+		Help:      "choose which folders and labels of account trigger new message notifications. Use index or account name as parameter.",
+		Func:      fe.changeNotificationRules,
+		Completer: fe.completeUsernames,
+	})
+	changeCmd.AddCmd(&ishell.Cmd{
+		Name:      "nntp-folders",
+		Help:      "choose which folders of account the NNTP server exposes as newsgroups. Use index or account name as parameter.",
+		Func:      fe.changeNNTPFolders,
+		Completer: fe.completeUsernames,
+	})
+	changeCmd.AddCmd(&ishell.Cmd{
+		Name:      "sync-filter",
+		Help:      "choose which folders and labels of account are excluded from the local sync. Use index or account name as parameter.",
+		Func:      fe.changeSyncFilter,
+		Completer: fe.completeUsernames,
+	})
+	changeCmd.AddCmd(&ishell.Cmd{
+		Name:      "redaction",
+		Help:      "choose which messages of account are never cached on disk, by sender domain or label. Use index or account name as parameter.",
+		Func:      fe.changeRedactionRules,
+		Completer: fe.completeUsernames,
+	})
+	changeCmd.AddCmd(&ishell.Cmd{
+		Name:      "label-keywords",
+		Help:      "expose the labels of account as IMAP keywords in addition to or instead of mailboxes. Use index or account name as parameter.",
+		Func:      fe.changeLabelKeywords,
+		Completer: fe.completeUsernames,
+	})
+	changeCmd.AddCmd(&ishell.Cmd{
+		Name:      "delete-mode",
+		Help:      "choose what happens to messages of account deleted and expunged from a folder. Use index or account name as parameter.",
+		Func:      fe.changeDeleteMode,
+		Completer: fe.completeUsernames,
+	})
+	changeCmd.AddCmd(&ishell.Cmd{
+		Name:      "migration-mode",
+		Help:      "toggle tuning of account for bulk APPEND migrations, e.g. with imapsync. Use index or account name as parameter.",
+		Func:      fe.changeMigrationMode,
+		Completer: fe.completeUsernames,
+	})
+	changeCmd.AddCmd(&ishell.Cmd{
+		Name:      "conversation-mode",
+		Help:      "toggle normalizing of threading headers so clients thread messages like conversations. Use index or account name as parameter.",
+		Func:      fe.changeConversationMode,
+		Completer: fe.completeUsernames,
+	})
+	changeCmd.AddCmd(&ishell.Cmd{
+		Name:      "utf8-transcoding",
+		Help:      "toggle transcoding of text in legacy charsets, e.g. KOI8-R or GB2312, to UTF-8. Use index or account name as parameter.",
+		Func:      fe.changeTranscodeToUTF8,
+		Completer: fe.completeUsernames,
+	})
+	changeCmd.AddCmd(&ishell.Cmd{
+		Name:      "contact-names",
+		Help:      "toggle showing senders by the names given to them in Proton contacts. Use index or account name as parameter.",
+		Func:      fe.changeContactDisplayNames,
+		Completer: fe.completeUsernames,
+	})
+	changeCmd.AddCmd(&ishell.Cmd{
+		Name:      "dates",
+		Help:      "set the time zone Date headers are shown in and whether invalid dates are repaired. Use index or account name as parameter.",
+		Func:      fe.changeDateNormalization,
+		Completer: fe.completeUsernames,
+	})
+	changeCmd.AddCmd(&ishell.Cmd{
+		Name:      "display",
+		Help:      "set the nickname, color, avatar initials and sort order the account is shown with. Use index or account name as parameter.",
+		Func:      fe.changeDisplayMetadata,
+		Completer: fe.completeUsernames,
+	})
+	changeCmd.AddCmd(&ishell.Cmd{
+		Name:      "mail-client",
+		Help:      "bind the account to the mail client and profile it is set up in, opened by launch-client. Use index or account name as parameter.",
+		Func:      fe.changeMailClient,
+		Completer: fe.completeUsernames,
+	})
+	changeCmd.AddCmd(&ishell.Cmd{
+		Name:      "draft-coalescing",
+		Help:      "set how often a draft saved repeatedly is uploaded at most, e.g. 1m, or 0 to upload every save. Use index or account name as parameter.",
+		Func:      fe.changeDraftCoalescingInterval,
+		Completer: fe.completeUsernames,
+	})
 	changeCmd.AddCmd(&ishell.Cmd{
 		Name: "change-location",
 		Help: "change the location of the encrypted message cache",
@@ -115,6 +217,57 @@ func New(
 		Help: "change port number of SMTP server.",
 		Func: fe.changeSMTPPort,
 	})
+	changeCmd.AddCmd(&ishell.Cmd{
+		Name: "imap-bind-address",
+		Help: "change the IP address the IMAP server listens on, e.g. of a LAN interface or 0.0.0.0, or empty for the default hosts.",
+		Func: fe.changeIMAPBindAddress,
+	})
+	changeCmd.AddCmd(&ishell.Cmd{
+		Name: "smtp-bind-address",
+		Help: "change the IP address the SMTP server listens on, e.g. of a LAN interface or 0.0.0.0, or empty for the default hosts.",
+		Func: fe.changeSMTPBindAddress,
+	})
+	changeCmd.AddCmd(&ishell.Cmd{
+		Name: "sync-cache",
+		Help: "change the memory of the sync download cache, in MB, and whether what it evicts is written to disk, encrypted. Applies after restart.",
+		Func: fe.changeSyncCache,
+	})
+	changeCmd.AddCmd(&ishell.Cmd{
+		Name: "metrics-listener",
+		Help: "change the host:port the Prometheus metrics are served on, e.g. 127.0.0.1:9154, or empty to stop serving them.",
+		Func: fe.changeMetricsListener,
+	})
+	changeCmd.AddCmd(&ishell.Cmd{
+		Name: "pop3-port",
+		Help: "change port number of the read-only POP3 server serving the inbox, or 0 to disable it.",
+		Func: fe.changePOP3Port,
+	})
+	changeCmd.AddCmd(&ishell.Cmd{
+		Name:    "pop3-security",
+		Help:    "change POP3 SSL settings servers.(alias: ssl-pop3, starttls-pop3)",
+		Aliases: []string{"ssl-pop3", "starttls-pop3"},
+		Func:    fe.changePOP3Security,
+	})
+	changeCmd.AddCmd(&ishell.Cmd{
+		Name: "pop3-delete",
+		Help: "choose whether messages deleted by POP3 clients are kept, archived or trashed.",
+		Func: fe.changePOP3DeleteAction,
+	})
+	changeCmd.AddCmd(&ishell.Cmd{
+		Name: "nntp-port",
+		Help: "change port number of the experimental NNTP server exposing folders as newsgroups, or 0 to disable it.",
+		Func: fe.changeNNTPPort,
+	})
+	changeCmd.AddCmd(&ishell.Cmd{
+		Name: "ip-family",
+		Help: "choose whether IMAP and SMTP servers listen on IPv4, IPv6 or both.",
+		Func: fe.changeIPFamily,
+	})
+	changeCmd.AddCmd(&ishell.Cmd{
+		Name: "keychain",
+		Help: "choose where the vault key is stored, e.g. in a passphrase-protected encrypted file on headless servers.",
+		Func: fe.changeKeychain,
+	})
 	changeCmd.AddCmd(&ishell.Cmd{
 		Name:    "imap-security",
 		Help:    "change IMAP SSL settings servers.(alias: ssl-imap, starttls-imap)",
@@ -127,6 +280,11 @@ func New(
 		Aliases: []string{"ssl-smtp", "starttls-smtp"},
 		Func:    fe.changeSMTPSecurity,
 	})
+	changeCmd.AddCmd(&ishell.Cmd{
+		Name: "index-hook",
+		Help: "change the command run when new messages arrive, e.g. `notmuch new` or `mu index`.",
+		Func: fe.changeIndexHook,
+	})
 	fe.AddCmd(changeCmd)
 
 	// DoH commands.
@@ -144,8 +302,122 @@ func New(
 		Help: "disallow bridge to securely connect to proton via a third party when it is being blocked",
 		Func: fe.disallowProxy,
 	})
+	dohCmd.AddCmd(&ishell.Cmd{
+		Name: "resolver",
+		Help: "show the DoH resolver settings used to find proxies",
+		Func: fe.showResolver,
+	})
+	dohCmd.AddCmd(&ishell.Cmd{
+		Name: "doh-providers",
+		Help: "set custom DoH providers, queried before the built-in ones",
+		Func: fe.changeDoHProviders,
+	})
+	dohCmd.AddCmd(&ishell.Cmd{
+		Name: "bootstrap-ips",
+		Help: "set the IPs used to reach the DoH providers without the system resolver",
+		Func: fe.changeDoHBootstrapIPs,
+	})
+	dohCmd.AddCmd(&ishell.Cmd{
+		Name: "enable-system-dns",
+		Help: "resolve the API host with the system resolver (default)",
+		Func: fe.enableSystemDNS,
+	})
+	dohCmd.AddCmd(&ishell.Cmd{
+		Name: "disable-system-dns",
+		Help: "resolve the API host over DoH instead of the system resolver",
+		Func: fe.disableSystemDNS,
+	})
+	dohCmd.AddCmd(&ishell.Cmd{
+		Name: "test",
+		Help: "resolve a host (the API host by default) at each DoH provider. Example: proxy test mail-api.proton.me",
+		Func: fe.testResolver,
+	})
 	fe.AddCmd(dohCmd)
 
+	// Tor commands.
+	torCmd := &ishell.Cmd{
+		Name: "tor",
+		Help: "route the traffic to Proton through a local Tor SOCKS proxy",
+		Func: fe.showTor,
+	}
+	torCmd.AddCmd(&ishell.Cmd{
+		Name: "enable",
+		Help: "route the traffic to Proton through Tor",
+		Func: fe.enableTor,
+	})
+	torCmd.AddCmd(&ishell.Cmd{
+		Name: "disable",
+		Help: "stop routing the traffic to Proton through Tor",
+		Func: fe.disableTor,
+	})
+	torCmd.AddCmd(&ishell.Cmd{
+		Name: "socks-address",
+		Help: "change the address of the Tor SOCKS proxy",
+		Func: fe.changeTorSOCKSAddress,
+	})
+	torCmd.AddCmd(&ishell.Cmd{
+		Name: "onion-host",
+		Help: "set the onion service to use instead of the API host",
+		Func: fe.changeTorOnionHost,
+	})
+	torCmd.AddCmd(&ishell.Cmd{
+		Name: "check",
+		Help: "check that Proton can be reached through Tor",
+		Func: fe.checkTor,
+	})
+	fe.AddCmd(torCmd)
+
+	// LMTP commands.
+	lmtpCmd := &ishell.Cmd{
+		Name: "lmtp",
+		Help: "let local delivery agents inject messages through an LMTP server listening on a unix socket",
+		Func: fe.showLMTP,
+	}
+	lmtpCmd.AddCmd(&ishell.Cmd{
+		Name: "enable",
+		Help: "start the LMTP server",
+		Func: fe.setLMTPEnabled(true),
+	})
+	lmtpCmd.AddCmd(&ishell.Cmd{
+		Name: "disable",
+		Help: "stop the LMTP server",
+		Func: fe.setLMTPEnabled(false),
+	})
+	fe.AddCmd(lmtpCmd)
+
+	// Mailto commands.
+	mailtoCmd := &ishell.Cmd{
+		Name: "mailto",
+		Help: "compose the mailto links opened on this computer in your mail client, from the right address",
+		Func: fe.showMailto,
+	}
+	mailtoCmd.AddCmd(&ishell.Cmd{
+		Name: "enable",
+		Help: "register bridge as the handler of mailto links",
+		Func: fe.enableMailto,
+	})
+	mailtoCmd.AddCmd(&ishell.Cmd{
+		Name: "disable",
+		Help: "unregister bridge as the handler of mailto links",
+		Func: fe.disableMailto,
+	})
+	mailtoCmd.AddCmd(&ishell.Cmd{
+		Name: "client",
+		Help: "change the mail client the messages are composed in",
+		Func: fe.changeMailtoClient,
+	})
+	mailtoCmd.AddCmd(&ishell.Cmd{
+		Name: "set-rule",
+		Help: "create or replace the rule picking the address sending to some recipients",
+		Func: fe.setMailtoRule,
+	})
+	mailtoCmd.AddCmd(&ishell.Cmd{
+		Name: "delete-rule",
+		Help: "remove the rule for some recipients",
+		Func: fe.deleteMailtoRule,
+	})
+	fe.AddCmd(mailtoCmd)
+
 	//goland:noinspection GoBoolExpressions
 	if runtime.GOOS == "darwin" {
 		// Apple Mail commands.
@@ -191,8 +463,126 @@ func New(
 		Help: "Import a TLS certificate to be used by Bridge",
 		Func: fe.importTLSCerts,
 	})
+	certCmd.AddCmd(&ishell.Cmd{
+		Name: "expiry",
+		Help: "Show when the TLS certificate used by Bridge expires. Self-signed certificates are renewed ahead of time",
+		Func: fe.showTLSCertExpiry,
+	})
 	fe.AddCmd(certCmd)
 
+	// TLS policy commands.
+	tlsPolicyCmd := &ishell.Cmd{
+		Name: "tls-policy",
+		Help: "restrict the TLS connections accepted by the IMAP and SMTP servers",
+		Func: fe.showTLSPolicy,
+	}
+	tlsPolicyCmd.AddCmd(&ishell.Cmd{
+		Name: "min-version",
+		Help: "set the minimum TLS version of new connections. Example: tls-policy min-version 1.2",
+		Func: fe.setTLSMinVersion,
+	})
+	cipherSuitesCmd := &ishell.Cmd{
+		Name: "cipher-suites",
+		Help: "set the allowed TLS 1.0-1.2 cipher suites. Use their names as parameters, or none for the defaults",
+		Func: fe.setTLSCipherSuites,
+	}
+	cipherSuitesCmd.AddCmd(&ishell.Cmd{
+		Name: "list",
+		Help: "list the cipher suites which can be allowed",
+		Func: fe.listCipherSuites,
+	})
+	tlsPolicyCmd.AddCmd(cipherSuitesCmd)
+	tlsPolicyCmd.AddCmd(&ishell.Cmd{
+		Name: "require-tls",
+		Help: "require SMTP and POP3 clients to use SSL or STARTTLS before logging in. IMAP isn't covered: set the IMAP security to SSL to refuse plaintext IMAP logins",
+		Func: fe.setTLSRequired(true),
+	})
+	tlsPolicyCmd.AddCmd(&ishell.Cmd{
+		Name: "allow-plaintext",
+		Help: "allow SMTP and POP3 clients to log in without TLS",
+		Func: fe.setTLSRequired(false),
+	})
+	fe.AddCmd(tlsPolicyCmd)
+
+	// LAN access commands.
+	lanAccessCmd := &ishell.Cmd{
+		Name: "lan-access",
+		Help: "expose the servers to allowed devices of the local network",
+		Func: fe.showLANAccess,
+	}
+	lanAccessCmd.AddCmd(&ishell.Cmd{
+		Name: "enable",
+		Help: "listen on all interfaces, accepting localhost and the allowed clients",
+		Func: fe.setLANAccessEnabled(true),
+	})
+	lanAccessCmd.AddCmd(&ishell.Cmd{
+		Name: "disable",
+		Help: "listen on localhost only",
+		Func: fe.setLANAccessEnabled(false),
+	})
+	lanAccessCmd.AddCmd(&ishell.Cmd{
+		Name: "allow",
+		Help: "set the CIDRs or IP addresses of the allowed clients, or none for localhost only. Example: lan-access allow 192.168.1.0/24",
+		Func: fe.setAllowedClients,
+	})
+	fe.AddCmd(lanAccessCmd)
+
+	// SMTP relay commands.
+	smtpRelayCmd := &ishell.Cmd{
+		Name: "smtp-relay",
+		Help: "let devices of the local network, such as scanners, send mail from an address without its credentials",
+		Func: fe.showSMTPRelay,
+	}
+	smtpRelayCmd.AddCmd(&ishell.Cmd{
+		Name: "port",
+		Help: "set the port the relay listens on, on all interfaces, or 0 to disable it. Example: smtp-relay port 2525",
+		Func: fe.setSMTPRelayPort,
+	})
+	smtpRelayCmd.AddCmd(&ishell.Cmd{
+		Name: "address",
+		Help: "set the address of an account the relayed messages are sent from. Example: smtp-relay address scanner@pm.me",
+		Func: fe.setSMTPRelayAddress,
+	})
+	smtpRelayCmd.AddCmd(&ishell.Cmd{
+		Name: "allow",
+		Help: "set the CIDRs or IP addresses of the devices allowed to relay. Example: smtp-relay allow 192.168.1.0/24",
+		Func: fe.setSMTPRelayAllowedClients,
+	})
+	smtpRelayCmd.AddCmd(&ishell.Cmd{
+		Name: "auth",
+		Help: "require the devices to log in with the given username and a password, or none without a username",
+		Func: fe.setSMTPRelayAuth,
+	})
+	smtpRelayCmd.AddCmd(&ishell.Cmd{
+		Name: "limits",
+		Help: "set the messages relayed per hour and the recipients per message. Example: smtp-relay limits 20 5",
+		Func: fe.setSMTPRelayLimits,
+	})
+	fe.AddCmd(smtpRelayCmd)
+
+	// Server limits commands.
+	serverLimitsCmd := &ishell.Cmd{
+		Name: "server-limits",
+		Help: "throttle IMAP clients which loop or resync aggressively, and show how often they were",
+		Func: fe.showServerLimits,
+	}
+	serverLimitsCmd.AddCmd(&ishell.Cmd{
+		Name: "commands-per-second",
+		Help: "set the commands each connection may run per second, or 0 for no limit. Example: server-limits commands-per-second 50",
+		Func: fe.setCommandsPerSecond,
+	})
+	serverLimitsCmd.AddCmd(&ishell.Cmd{
+		Name: "connections-per-user",
+		Help: "set the connections logged in to the same account, or 0 for no limit",
+		Func: fe.setMaxConnectionsPerUser,
+	})
+	serverLimitsCmd.AddCmd(&ishell.Cmd{
+		Name: "fetch-bytes",
+		Help: "set the bytes written to all clients at once, or 0 for no limit",
+		Func: fe.setMaxFetchBytes,
+	})
+	fe.AddCmd(serverLimitsCmd)
+
 	// All mail visibility commands.
 	allMailCmd := &ishell.Cmd{
 		Name: "all-mail-visibility",
@@ -210,6 +600,51 @@ func New(
 	})
 	fe.AddCmd(allMailCmd)
 
+	// Unified inbox commands.
+	unifiedInboxCmd := &ishell.Cmd{
+		Name: "unified-inbox",
+		Help: "expose the inboxes of all accounts as a single IMAP account",
+	}
+	unifiedInboxCmd.AddCmd(&ishell.Cmd{
+		Name: "enable",
+		Help: "expose the inboxes of all accounts as a single IMAP account",
+		Func: fe.enableUnifiedInbox,
+	})
+	unifiedInboxCmd.AddCmd(&ishell.Cmd{
+		Name: "disable",
+		Help: "stop exposing the unified inbox",
+		Func: fe.disableUnifiedInbox,
+	})
+	unifiedInboxCmd.AddCmd(&ishell.Cmd{
+		Name: "reset-password",
+		Help: "replace the password used to log in to the unified inbox",
+		Func: fe.resetUnifiedInboxPassword,
+	})
+	fe.AddCmd(unifiedInboxCmd)
+
+	// Do not disturb commands.
+	dndCmd := &ishell.Cmd{
+		Name: "dnd",
+		Help: "show and change do not disturb, which suppresses new message notifications",
+		Func: fe.showDoNotDisturb,
+	}
+	dndCmd.AddCmd(&ishell.Cmd{
+		Name: "on",
+		Help: "turn do not disturb on until turned off",
+		Func: fe.enableDoNotDisturb,
+	})
+	dndCmd.AddCmd(&ishell.Cmd{
+		Name: "off",
+		Help: "turn do not disturb off, outside of its schedule",
+		Func: fe.disableDoNotDisturb,
+	})
+	dndCmd.AddCmd(&ishell.Cmd{
+		Name: "schedule",
+		Help: "set the daily do not disturb schedule",
+		Func: fe.scheduleDoNotDisturb,
+	})
+	fe.AddCmd(dndCmd)
+
 	// Updates commands.
 	updatesCmd := &ishell.Cmd{
 		Name: "updates",
@@ -220,6 +655,11 @@ func New(
 		Help: "check for Bridge updates",
 		Func: fe.checkUpdates,
 	})
+	updatesCmd.AddCmd(&ishell.Cmd{
+		Name: "verification",
+		Help: "show how the running version was verified when it was installed",
+		Func: fe.showUpdateVerification,
+	})
 	autoUpdatesCmd := &ishell.Cmd{
 		Name: "autoupdates",
 		Help: "manage bridge updates",
@@ -259,6 +699,11 @@ func New(
 		Aliases: []string{"log", "logs"},
 		Func:    fe.printLogDir,
 	})
+	fe.AddCmd(&ishell.Cmd{
+		Name: "session-log",
+		Help: "print the logs of the current session, when started with --zero-logging.",
+		Func: fe.printSessionLog,
+	})
 	fe.AddCmd(&ishell.Cmd{
 		Name:    "manual",
 		Help:    "print URL with instructions. (alias: man)",
@@ -270,6 +715,16 @@ func New(
 		Help: "print used resources.",
 		Func: fe.printCredits,
 	})
+	fe.AddCmd(&ishell.Cmd{
+		Name: "setup",
+		Help: "choose the keychain, ports and message cache location, and configure a mail client.",
+		Func: fe.runSetup,
+	})
+	fe.AddCmd(&ishell.Cmd{
+		Name: "check-config",
+		Help: "check the configuration for invalid settings, ports in use, unwritable directories and unreachable proxies.",
+		Func: fe.checkConfig,
+	})
 
 	// Account commands.
 	fe.AddCmd(&ishell.Cmd{
@@ -285,6 +740,12 @@ func New(
 		Completer: fe.completeUsernames,
 		Aliases:   []string{"i"},
 	})
+	fe.AddCmd(&ishell.Cmd{
+		Name:      "launch-client",
+		Help:      "launch the mail client the account is bound to, with its profile. Use index or account name as parameter.",
+		Func:      fe.noAccountWrapper(fe.launchClient),
+		Completer: fe.completeUsernames,
+	})
 	fe.AddCmd(&ishell.Cmd{
 		Name:      "login",
 		Help:      "login procedure to add or connect account. Optionally use index or account as parameter. (aliases: a, add, con, connect)",
@@ -306,6 +767,339 @@ func New(
 		Aliases:   []string{"del", "rm", "remove"},
 		Completer: fe.completeUsernames,
 	})
+	fe.AddCmd(&ishell.Cmd{
+		Name:      "export",
+		Help:      "export all messages of the account with a manifest of their hashes for legal review. Use index or account name as parameter.",
+		Func:      fe.noAccountWrapper(fe.exportAccount),
+		Completer: fe.completeUsernames,
+	})
+	exportMailboxCmd := &ishell.Cmd{
+		Name:      "export-mailbox",
+		Help:      "export the mailboxes of the account to MBOX files or directories of EML files. Use index or account name as parameter.",
+		Func:      fe.noAccountWrapper(fe.exportMailbox),
+		Completer: fe.completeUsernames,
+	}
+	exportMailboxCmd.AddCmd(&ishell.Cmd{
+		Name:      "cancel",
+		Help:      "stop the mailbox export of the account in progress. Use index or account name as parameter.",
+		Func:      fe.noAccountWrapper(fe.cancelExportMailbox),
+		Completer: fe.completeUsernames,
+	})
+	fe.AddCmd(exportMailboxCmd)
+	importMessagesCmd := &ishell.Cmd{
+		Name:      "import-messages",
+		Help:      "import the messages of MBOX files, EML files or Maildirs to the account. Use index or account name as parameter.",
+		Func:      fe.noAccountWrapper(fe.importMessages),
+		Completer: fe.completeUsernames,
+	}
+	importMessagesCmd.AddCmd(&ishell.Cmd{
+		Name:      "cancel",
+		Help:      "stop the message import to the account in progress. Use index or account name as parameter.",
+		Func:      fe.noAccountWrapper(fe.cancelImportMessages),
+		Completer: fe.completeUsernames,
+	})
+	fe.AddCmd(importMessagesCmd)
+	sessionCmd := &ishell.Cmd{
+		Name:      "session",
+		Help:      "show when the Proton session of the account was created and last refreshed. Use index or account name as parameter.",
+		Func:      fe.noAccountWrapper(fe.showSession),
+		Completer: fe.completeUsernames,
+	}
+	sessionCmd.AddCmd(&ishell.Cmd{
+		Name:      "recreate",
+		Help:      "revoke the Proton session of the account and sign in again. Use index or account name as parameter.",
+		Func:      fe.noAccountWrapper(fe.recreateSession),
+		Completer: fe.completeUsernames,
+	})
+	fe.AddCmd(sessionCmd)
+	fe.AddCmd(&ishell.Cmd{
+		Name: "undo",
+		Help: "restore an account removed or everything cleared recently.",
+		Func: fe.undoRemoval,
+	})
+
+	templatesCmd := &ishell.Cmd{
+		Name: "templates",
+		Help: "manage canned replies, inserted into a message by adding template+name@local as a recipient",
+	}
+	templatesCmd.AddCmd(&ishell.Cmd{
+		Name:      "list",
+		Help:      "print the templates of account. Use index or account name as parameter.",
+		Func:      fe.noAccountWrapper(fe.listTemplates),
+		Completer: fe.completeUsernames,
+	})
+	templatesCmd.AddCmd(&ishell.Cmd{
+		Name:      "set",
+		Help:      "create or replace a template of account. Use index or account name as parameter.",
+		Func:      fe.noAccountWrapper(fe.setTemplate),
+		Completer: fe.completeUsernames,
+	})
+	templatesCmd.AddCmd(&ishell.Cmd{
+		Name:      "delete",
+		Help:      "remove a template of account. Use index or account name as parameter.",
+		Func:      fe.noAccountWrapper(fe.deleteTemplate),
+		Completer: fe.completeUsernames,
+	})
+	fe.AddCmd(templatesCmd)
+
+	savedSearchesCmd := &ishell.Cmd{
+		Name: "saved-searches",
+		Help: "manage read-only Virtual mailboxes listing the messages matching a query",
+	}
+	savedSearchesCmd.AddCmd(&ishell.Cmd{
+		Name:      "list",
+		Help:      "print the saved searches of account. Use index or account name as parameter.",
+		Func:      fe.noAccountWrapper(fe.listSavedSearches),
+		Completer: fe.completeUsernames,
+	})
+	savedSearchesCmd.AddCmd(&ishell.Cmd{
+		Name:      "set",
+		Help:      "create or replace a saved search of account. Use index or account name as parameter.",
+		Func:      fe.noAccountWrapper(fe.setSavedSearch),
+		Completer: fe.completeUsernames,
+	})
+	savedSearchesCmd.AddCmd(&ishell.Cmd{
+		Name:      "delete",
+		Help:      "remove a saved search of account. Use index or account name as parameter.",
+		Func:      fe.noAccountWrapper(fe.deleteSavedSearch),
+		Completer: fe.completeUsernames,
+	})
+	fe.AddCmd(savedSearchesCmd)
+
+	domainsCmd := &ishell.Cmd{
+		Name:      "domains",
+		Help:      "print the domains of account and their addresses. Use index or account name as parameter.",
+		Func:      fe.noAccountWrapper(fe.listDomains),
+		Completer: fe.completeUsernames,
+	}
+	fe.AddCmd(domainsCmd)
+
+	routesCmd := &ishell.Cmd{
+		Name: "routes",
+		Help: "manage the rules filing new messages into folders by recipient, e.g. for catch-all addresses",
+	}
+	routesCmd.AddCmd(&ishell.Cmd{
+		Name:      "list",
+		Help:      "print the recipient routes of account. Use index or account name as parameter.",
+		Func:      fe.noAccountWrapper(fe.listRecipientRoutes),
+		Completer: fe.completeUsernames,
+	})
+	routesCmd.AddCmd(&ishell.Cmd{
+		Name:      "set",
+		Help:      "create or replace a recipient route of account. Use index or account name as parameter.",
+		Func:      fe.noAccountWrapper(fe.setRecipientRoute),
+		Completer: fe.completeUsernames,
+	})
+	routesCmd.AddCmd(&ishell.Cmd{
+		Name:      "delete",
+		Help:      "remove a recipient route of account. Use index or account name as parameter.",
+		Func:      fe.noAccountWrapper(fe.deleteRecipientRoute),
+		Completer: fe.completeUsernames,
+	})
+	fe.AddCmd(routesCmd)
+
+	subaddressCmd := &ishell.Cmd{
+		Name: "subaddress",
+		Help: "manage the rules labelling new messages by the +tag of their recipient, e.g. user+news@pm.me",
+	}
+	subaddressCmd.AddCmd(&ishell.Cmd{
+		Name:      "list",
+		Help:      "print the subaddress rules of account. Use index or account name as parameter.",
+		Func:      fe.noAccountWrapper(fe.listSubaddressRules),
+		Completer: fe.completeUsernames,
+	})
+	subaddressCmd.AddCmd(&ishell.Cmd{
+		Name:      "set",
+		Help:      "create or replace a subaddress rule of account. Use index or account name as parameter.",
+		Func:      fe.noAccountWrapper(fe.setSubaddressRule),
+		Completer: fe.completeUsernames,
+	})
+	subaddressCmd.AddCmd(&ishell.Cmd{
+		Name:      "delete",
+		Help:      "remove a subaddress rule of account. Use index or account name as parameter.",
+		Func:      fe.noAccountWrapper(fe.deleteSubaddressRule),
+		Completer: fe.completeUsernames,
+	})
+	fe.AddCmd(subaddressCmd)
+
+	newslettersCmd := &ishell.Cmd{
+		Name: "newsletters",
+		Help: "find the mailing lists among received messages and act on all their messages at once",
+	}
+	newslettersCmd.AddCmd(&ishell.Cmd{
+		Name:      "list",
+		Help:      "print the newsletters of account with their statistics. Use index or account name as parameter.",
+		Func:      fe.noAccountWrapper(fe.listNewsletters),
+		Completer: fe.completeUsernames,
+	})
+	newslettersCmd.AddCmd(&ishell.Cmd{
+		Name:      "archive",
+		Help:      "move the messages of a newsletter from the Inbox to the Archive. Use index or account name as parameter.",
+		Func:      fe.noAccountWrapper(fe.archiveNewsletter),
+		Completer: fe.completeUsernames,
+	})
+	newslettersCmd.AddCmd(&ishell.Cmd{
+		Name:      "label",
+		Help:      "label the messages of a newsletter. Use index or account name as parameter.",
+		Func:      fe.noAccountWrapper(fe.labelNewsletter),
+		Completer: fe.completeUsernames,
+	})
+	fe.AddCmd(newslettersCmd)
+
+	syncSnapshotCmd := &ishell.Cmd{
+		Name: "sync-snapshot",
+		Help: "capture the sync state of an account before and after a resync to report missing messages",
+	}
+	syncSnapshotCmd.AddCmd(&ishell.Cmd{
+		Name:      "save",
+		Help:      "save the message counts, last event and failed messages of account to a file. Use index or account name as parameter.",
+		Func:      fe.noAccountWrapper(fe.saveSyncSnapshot),
+		Completer: fe.completeUsernames,
+	})
+	syncSnapshotCmd.AddCmd(&ishell.Cmd{
+		Name: "diff",
+		Help: "print what changed between two saved sync snapshots of the same account",
+		Func: fe.diffSyncSnapshots,
+	})
+	fe.AddCmd(syncSnapshotCmd)
+
+	failedCmd := &ishell.Cmd{
+		Name: "failed",
+		Help: "manage the messages that failed to sync and are kept out of the mailboxes",
+	}
+	failedCmd.AddCmd(&ishell.Cmd{
+		Name:      "list",
+		Help:      "list the failed messages of account with why they failed. Use index or account name as parameter.",
+		Func:      fe.noAccountWrapper(fe.listFailedMessages),
+		Completer: fe.completeUsernames,
+	})
+	failedCmd.AddCmd(&ishell.Cmd{
+		Name:      "retry",
+		Help:      "download and build all failed messages of account again. Use index or account name as parameter.",
+		Func:      fe.noAccountWrapper(fe.retryFailedMessages),
+		Completer: fe.completeUsernames,
+	})
+	failedCmd.AddCmd(&ishell.Cmd{
+		Name:      "skip",
+		Help:      "stop retrying some failed messages of account for good. Use index or account name as parameter.",
+		Func:      fe.noAccountWrapper(fe.skipFailedMessages),
+		Completer: fe.completeUsernames,
+	})
+	fe.AddCmd(failedCmd)
+
+	fe.AddCmd(&ishell.Cmd{
+		Name: "connections",
+		Help: "list the connected IMAP clients, with the account they logged in to, the client they identified as and the shims applying to them",
+		Func: fe.listConnections,
+	})
+
+	shimsCmd := &ishell.Cmd{
+		Name: "shims",
+		Help: "manage the workarounds for quirks of specific IMAP clients",
+	}
+	shimsCmd.AddCmd(&ishell.Cmd{
+		Name: "list",
+		Help: "list the client shims and whether they are enabled",
+		Func: fe.listClientShims,
+	})
+	shimsCmd.AddCmd(&ishell.Cmd{
+		Name: "enable",
+		Help: "enable a client shim. Example: shims enable outlook-sent-seen",
+		Func: fe.enableClientShim,
+	})
+	shimsCmd.AddCmd(&ishell.Cmd{
+		Name: "disable",
+		Help: "disable a client shim. Example: shims disable outlook-sent-seen",
+		Func: fe.disableClientShim,
+	})
+	fe.AddCmd(shimsCmd)
+
+	headerTemplateCmd := &ishell.Cmd{
+		Name: "header-template",
+		Help: "manage extra header fields added to messages, such as the delivery address or label names",
+	}
+	headerTemplateCmd.AddCmd(&ishell.Cmd{
+		Name:      "show",
+		Help:      "print the header template of account. Use index or account name as parameter.",
+		Func:      fe.noAccountWrapper(fe.showHeaderTemplate),
+		Completer: fe.completeUsernames,
+	})
+	headerTemplateCmd.AddCmd(&ishell.Cmd{
+		Name:      "set",
+		Help:      "set the header template of account and resync it. Use index or account name as parameter.",
+		Func:      fe.noAccountWrapper(fe.setHeaderTemplate),
+		Completer: fe.completeUsernames,
+	})
+	headerTemplateCmd.AddCmd(&ishell.Cmd{
+		Name:      "clear",
+		Help:      "remove the header template of account and resync it. Use index or account name as parameter.",
+		Func:      fe.noAccountWrapper(fe.clearHeaderTemplate),
+		Completer: fe.completeUsernames,
+	})
+	fe.AddCmd(headerTemplateCmd)
+
+	autoPurgeCmd := &ishell.Cmd{
+		Name: "auto-purge",
+		Help: "permanently delete old messages in Trash and Spam",
+	}
+	autoPurgeCmd.AddCmd(&ishell.Cmd{
+		Name:      "set",
+		Help:      "set after how many days messages in Trash and Spam of account are deleted. Use index or account name as parameter.",
+		Func:      fe.noAccountWrapper(fe.setAutoPurge),
+		Completer: fe.completeUsernames,
+	})
+	autoPurgeCmd.AddCmd(&ishell.Cmd{
+		Name:      "preview",
+		Help:      "print the messages of account that would be deleted, without deleting them. Use index or account name as parameter.",
+		Func:      fe.noAccountWrapper(fe.previewAutoPurge),
+		Completer: fe.completeUsernames,
+	})
+	fe.AddCmd(autoPurgeCmd)
+
+	digestCmd := &ishell.Cmd{
+		Name: "digest",
+		Help: "weekly report of received messages, top senders, storage growth and sync errors",
+	}
+	digestCmd.AddCmd(&ishell.Cmd{
+		Name:      "enable",
+		Help:      "make the weekly digest of account. Use index or account name as parameter.",
+		Func:      fe.noAccountWrapper(fe.enableDigest),
+		Completer: fe.completeUsernames,
+	})
+	digestCmd.AddCmd(&ishell.Cmd{
+		Name:      "disable",
+		Help:      "stop making the weekly digest of account. Use index or account name as parameter.",
+		Func:      fe.noAccountWrapper(fe.disableDigest),
+		Completer: fe.completeUsernames,
+	})
+	digestCmd.AddCmd(&ishell.Cmd{
+		Name:      "show",
+		Help:      "print the digest of account for the current week so far. Use index or account name as parameter.",
+		Func:      fe.noAccountWrapper(fe.showDigest),
+		Completer: fe.completeUsernames,
+	})
+	fe.AddCmd(digestCmd)
+
+	errorsCmd := &ishell.Cmd{
+		Name: "errors",
+		Help: "manage errors which need your attention",
+	}
+	errorsCmd.AddCmd(&ishell.Cmd{
+		Name: "list",
+		Help: "print the active errors and how to resolve them",
+		Func: fe.listErrors,
+	})
+	errorsCmd.AddCmd(&ishell.Cmd{
+		Name: "dismiss",
+		Help: "remove an error from the list. Use the number printed by errors list as parameter.",
+		Func: fe.dismissError,
+	})
+	errorsCmd.AddCmd(&ishell.Cmd{
+		Name: "retry",
+		Help: "retry the operation which failed. Use the number printed by errors list as parameter.",
+		Func: fe.retryError,
+	})
+	fe.AddCmd(errorsCmd)
 
 	badEventCmd := &ishell.Cmd{
 		Name: "bad-event",
@@ -340,6 +1134,89 @@ func New(
 	})
 	fe.AddCmd(telemetryCmd)
 
+	// Privacy mode commands
+	privacyCmd := &ishell.Cmd{
+		Name: "privacy-mode",
+		Help: "batch and delay requests to Proton so their timing doesn't reveal when mail is read or sent",
+		Func: fe.showPrivacyMode,
+	}
+	privacyCmd.AddCmd(&ishell.Cmd{
+		Name: "enable",
+		Help: "requests to Proton will be batched and delayed by up to the jitter window",
+		Func: fe.enablePrivacyMode,
+	})
+	privacyCmd.AddCmd(&ishell.Cmd{
+		Name: "disable",
+		Help: "requests to Proton will be sent right away",
+		Func: fe.disablePrivacyMode,
+	})
+	privacyCmd.AddCmd(&ishell.Cmd{
+		Name: "jitter-window",
+		Help: "change the longest a request is delayed. Example: privacy-mode jitter-window 1m",
+		Func: fe.changePrivacyJitterWindow,
+	})
+	fe.AddCmd(privacyCmd)
+
+	// Access commands
+	accessCmd := &ishell.Cmd{
+		Name: "access",
+		Help: "manage the roles granted to the clients of the gRPC service",
+		Func: fe.listAccessGrants,
+	}
+	accessCmd.AddCmd(&ishell.Cmd{
+		Name: "grant",
+		Help: "grant a role to a new client token. Use the client name and the role (viewer, operator or admin) as parameters",
+		Func: fe.grantAccess,
+	})
+	accessCmd.AddCmd(&ishell.Cmd{
+		Name: "revoke",
+		Help: "revoke a grant; its token no longer gives access. Use the grant ID as parameter",
+		Func: fe.revokeAccess,
+	})
+	fe.AddCmd(accessCmd)
+
+	// Pairing commands
+	pairingCmd := &ishell.Cmd{
+		Name: "pairing",
+		Help: "pair frontends with bridge using short-lived codes",
+		Func: fe.listPairedClients,
+	}
+	pairingCmd.AddCmd(&ishell.Cmd{
+		Name: "start",
+		Help: "create a pairing code granting a role (viewer, operator or admin) to the frontend entering it. Use the role as parameter",
+		Func: fe.startPairing,
+	})
+	pairingCmd.AddCmd(&ishell.Cmd{
+		Name: "revoke",
+		Help: "revoke a paired client; its token no longer gives access. Use the client ID as parameter",
+		Func: fe.revokePairedClient,
+	})
+	fe.AddCmd(pairingCmd)
+
+	if tenants != nil {
+		tenantsCmd := &ishell.Cmd{
+			Name: "tenants",
+			Help: "manage the tenants hosted by this daemon, each with its own accounts and ports",
+			Func: fe.listTenants,
+		}
+		tenantsCmd.AddCmd(&ishell.Cmd{
+			Name: "add",
+			Help: "add a tenant. Use the tenant name as parameter",
+			Func: fe.addTenant,
+		})
+		tenantsCmd.AddCmd(&ishell.Cmd{
+			Name: "remove",
+			Help: "remove a tenant along with its accounts. Use the tenant name as parameter",
+			Func: fe.removeTenant,
+		})
+		tenantsCmd.AddCmd(&ishell.Cmd{
+			Name: "use",
+			Help: "run the following commands for a tenant. Use the tenant name as parameter, or none for the daemon itself",
+			Func: fe.useTenant,
+		})
+		fe.AddCmd(tenantsCmd)
+	}
+
 	dbgCmd := &ishell.Cmd{
 		Name: "debug",
 		Help: "Debug diagnostics ",
@@ -351,6 +1228,64 @@ func New(
 		Func: fe.debugMailboxState,
 	})
 
+	slowCommandsCmd := &ishell.Cmd{
+		Name: "slow-commands",
+		Help: "list the last IMAP commands which took longer than the threshold, with their mailbox and whether they called the API",
+		Func: fe.debugSlowCommands,
+	}
+	slowCommandsCmd.AddCmd(&ishell.Cmd{
+		Name: "threshold",
+		Help: "set the duration above which IMAP commands are recorded, or 0 to stop recording them. Example: debug slow-commands threshold 2s",
+		Func: fe.debugSlowCommandThreshold,
+	})
+	dbgCmd.AddCmd(slowCommandsCmd)
+
+	apiFixturesCmd := &ishell.Cmd{
+		Name: "api-fixtures",
+		Help: "show where the API traffic is recorded to, with secrets stripped, for bug reports",
+		Func: fe.debugAPIFixtures,
+	}
+	apiFixturesCmd.AddCmd(&ishell.Cmd{
+		Name: "start",
+		Help: "record the API requests and responses to a fixture file in the logs directory",
+		Func: fe.debugStartAPIFixtures,
+	})
+	apiFixturesCmd.AddCmd(&ishell.Cmd{
+		Name: "stop",
+		Help: "stop recording the API traffic",
+		Func: fe.debugStopAPIFixtures,
+	})
+	dbgCmd.AddCmd(apiFixturesCmd)
+
+	faultsCmd := &ishell.Cmd{
+		Name: "faults",
+		Help: "show the faults injected to test how bridge copes with rate limiting, a flaky network, a slow API or a full disk",
+		Func: fe.debugFaults,
+	}
+	faultsCmd.AddCmd(&ishell.Cmd{
+		Name: "set",
+		Help: "inject faults until bridge restarts. Arguments: percentage of rate limited requests, percentage of dropped connections, latency, and optionally `disk-full`. Example: debug faults set 10 5 2s disk-full",
+		Func: fe.debugSetFaults,
+	})
+	faultsCmd.AddCmd(&ishell.Cmd{
+		Name: "off",
+		Help: "stop injecting faults",
+		Func: fe.debugFaultsOff,
+	})
+	dbgCmd.AddCmd(faultsCmd)
+
+	dbgCmd.AddCmd(&ishell.Cmd{
+		Name: "safe-mode",
+		Help: "list the settings which are not applied in safe mode and whether they fail to apply",
+		Func: fe.debugSafeMode,
+	})
+
+	dbgCmd.AddCmd(&ishell.Cmd{
+		Name: "interference",
+		Help: "check whether security software intercepts the connections to the IMAP and SMTP servers or locks bridge's files",
+		Func: fe.debugInterference,
+	})
+
 	fe.AddCmd(dbgCmd)
 
 	go fe.watchEvents(eventCh)
@@ -367,7 +1302,7 @@ func (f *frontendCLI) watchEvents(eventCh <-chan events.Event) { // nolint:gocyc
 	defer async.HandlePanic(f.panicHandler)
 
 	// GODT-1949: Better error events.
-	for _, err := range f.bridge.GetErrors() {
+	for _, err := range f.daemon.GetErrors() {
 		switch {
 		case errors.Is(err, bridge.ErrVaultCorrupt):
 			f.notifyCredentialsError()
@@ -392,7 +1327,7 @@ func (f *frontendCLI) watchEvents(eventCh <-chan events.Event) { // nolint:gocyc
 			f.Println("SMTP server error:", event.Error)
 
 		case events.UserDeauth:
-			user, err := f.bridge.GetUserInfo(event.UserID)
+			user, err := f.daemon.GetUserInfo(event.UserID)
 			if err != nil {
 				return
 			}
@@ -400,7 +1335,7 @@ func (f *frontendCLI) watchEvents(eventCh <-chan events.Event) { // nolint:gocyc
 			f.notifyLogout(user.Username)
 
 		case events.UserBadEvent:
-			user, err := f.bridge.GetUserInfo(event.UserID)
+			user, err := f.daemon.GetUserInfo(event.UserID)
 			if err != nil {
 				return
 			}
@@ -420,7 +1355,7 @@ func (f *frontendCLI) watchEvents(eventCh <-chan events.Event) { // nolint:gocyc
 			f.Printf("An IMAP login attempt failed for user %v\n", event.Username)
 
 		case events.UserAddressEnabled:
-			user, err := f.bridge.GetUserInfo(event.UserID)
+			user, err := f.daemon.GetUserInfo(event.UserID)
 			if err != nil {
 				return
 			}
@@ -428,7 +1363,7 @@ func (f *frontendCLI) watchEvents(eventCh <-chan events.Event) { // nolint:gocyc
 			f.Printf("An address for %s was enabled. You may need to reconfigure your email client.\n", user.Username)
 
 		case events.UserAddressDisabled:
-			user, err := f.bridge.GetUserInfo(event.UserID)
+			user, err := f.daemon.GetUserInfo(event.UserID)
 			if err != nil {
 				return
 			}
@@ -436,15 +1371,105 @@ func (f *frontendCLI) watchEvents(eventCh <-chan events.Event) { // nolint:gocyc
 			f.Printf("An address for %s was disabled. You may need to reconfigure your email client.\n", user.Username)
 
 		case events.UserAddressDeleted:
-			user, err := f.bridge.GetUserInfo(event.UserID)
+			user, err := f.daemon.GetUserInfo(event.UserID)
 			if err != nil {
 				return
 			}
 
 			f.Printf("An address for %s was disabled. You may need to reconfigure your email client.\n", user.Username)
 
+		case events.UserAutoPurgePending:
+			user, err := f.daemon.GetUserInfo(event.UserID)
+			if err != nil {
+				return
+			}
+
+			f.Printf("%d messages in %s of %s will be permanently deleted after %s.\n", event.Count, autoPurgeFolderName(event.LabelID), user.Username, event.At.Format(time.Stamp))
+
+		case events.UserAutoPurged:
+			user, err := f.daemon.GetUserInfo(event.UserID)
+			if err != nil {
+				return
+			}
+
+			f.Printf("%d old messages in %s of %s were permanently deleted.\n", event.Count, autoPurgeFolderName(event.LabelID), user.Username)
+
+		case events.UserDigest:
+			user, err := f.daemon.GetUserInfo(event.UserID)
+			if err != nil {
+				return
+			}
+
+			f.printDigest(user.Username, event)
+
+		case events.UserMigrationProgress:
+			user, err := f.daemon.GetUserInfo(event.UserID)
+			if err != nil {
+				return
+			}
+
+			f.Printf("Migration of %s: %d imported, %d duplicates skipped, %d failed.\n", user.Username, event.Imported, event.Duplicates, event.Failed)
+
+		case events.UserMigrationFailed:
+			user, err := f.daemon.GetUserInfo(event.UserID)
+			if err != nil {
+				return
+			}
+
+			f.Printf("Migration of %s: failed to import %q: %v\n", user.Username, event.Subject, event.Error)
+
+		case events.UserExportProgress:
+			user, err := f.daemon.GetUserInfo(event.UserID)
+			if err != nil {
+				return
+			}
+
+			f.Printf("Export of %s: %d of %d messages exported, %d failed.\n", user.Username, event.Exported, event.Total, event.Failed)
+
+		case events.UserExportFinished:
+			user, err := f.daemon.GetUserInfo(event.UserID)
+			if err != nil {
+				return
+			}
+
+			if event.Error != nil {
+				f.Printf("Export of %s stopped after %d messages: %v\n", user.Username, event.Exported, event.Error)
+			} else {
+				f.Printf("Export of %s finished: %d messages exported to %v, %d could not be decrypted.\n", user.Username, event.Exported, event.Path, event.Failed)
+
+				if event.Redacted > 0 {
+					f.Printf("%d messages matching the redaction rules were left out.\n", event.Redacted)
+				}
+			}
+
+		case events.UserImportProgress:
+			user, err := f.daemon.GetUserInfo(event.UserID)
+			if err != nil {
+				return
+			}
+
+			f.Printf(
+				"Import to %s: %d of %d messages imported, %d duplicates, %d failed.\n",
+				user.Username, event.Imported, event.Total, event.Duplicates, event.Failed,
+			)
+
+		case events.UserImportFinished:
+			user, err := f.daemon.GetUserInfo(event.UserID)
+			if err != nil {
+				return
+			}
+
+			if event.Error != nil {
+				f.Printf("Import to %s stopped after %d messages, import %v again to resume: %v\n", user.Username, event.Imported, event.Source, event.Error)
+			} else {
+				f.Printf(
+					"Import to %s finished: %d messages imported, %d duplicates skipped, %d failed.\n",
+					user.Username, event.Imported, event.Duplicates, event.Failed,
+				)
+			}
+
 		case events.SyncStarted:
-			user, err := f.bridge.GetUserInfo(event.UserID)
+			user, err := f.daemon.GetUserInfo(event.UserID)
 			if err != nil {
 				return
 			}
@@ -452,15 +1477,58 @@ func (f *frontendCLI) watchEvents(eventCh <-chan events.Event) { // nolint:gocyc
 			f.Printf("A sync has begun for %s.\n", user.Username)
 
 		case events.SyncFinished:
-			user, err := f.bridge.GetUserInfo(event.UserID)
+			user, err := f.daemon.GetUserInfo(event.UserID)
 			if err != nil {
 				return
 			}
 
 			f.Printf("A sync has finished for %s.\n", user.Username)
 
+		case events.DoNotDisturbChanged:
+			if event.Active {
+				f.Println("Do not disturb is now active.")
+			} else {
+				f.Println("Do not disturb is no longer active.")
+			}
+
+		case events.TorCircuitUp:
+			f.Println("Proton can be reached through Tor.")
+
+		case events.TorCircuitDown:
+			f.Printf("Proton can't be reached through Tor: %v. Please check that Tor is running.\n", event.Error)
+
+		case events.ClockSkewDetected:
+			f.Printf("The system clock is off by %v, which may cause login to fail. Please enable automatic time synchronization.\n", event.Offset)
+
+		case events.InterferenceDetected:
+			product := event.Product
+			if product == "" {
+				product = "Security software"
+			}
+
+			f.Printf("%v interferes with bridge (%v). Add an exception for bridge to it, or disable its email scanning.\n", product, event.Detail)
+
+		case events.DiskSpaceLow:
+			if event.UserID != "" {
+				f.Printf("Not enough disk space in %v to sync %s: %v MB free, %v MB required. The sync will start once space is freed.\n",
+					event.Path, event.UserID, event.Free>>20, event.Required>>20)
+			} else {
+				f.Printf("Disk space in %v is low (%v MB free). Syncing is paused until space is freed.\n", event.Path, event.Free>>20)
+			}
+
+		case events.DiskSpaceRecovered:
+			f.Println("Disk space was freed, syncing resumes.")
+
+		case events.UserMessageNotification:
+			user, err := f.daemon.GetUserInfo(event.UserID)
+			if err != nil {
+				return
+			}
+
+			f.Printf("New message for %s from %s: %s\n", user.Username, event.Sender, event.Subject)
+
 		case events.SyncProgress:
-			user, err := f.bridge.GetUserInfo(event.UserID)
+			user, err := f.daemon.GetUserInfo(event.UserID)
 			if err != nil {
 				return
 			}
@@ -492,8 +1560,25 @@ func (f *frontendCLI) watchEvents(eventCh <-chan events.Event) { // nolint:gocyc
 		case events.TLSIssue:
 			f.notifyCertIssue()
 
+		case events.TLSCertExpiring:
+			f.Printf("The TLS certificate used by Bridge expires on %v. Please import a renewed one with `cert import`.\n", event.NotAfter.Format(time.RFC822))
+
+		case events.TLSCertRenewed:
+			f.Printf("The TLS certificate used by Bridge was renewed until %v. Email clients which trusted the previous one must trust the new one.\n", event.NotAfter.Format(time.RFC822))
+
+		case events.ClientRejected:
+			if event.Protocol == "SMTP relay" {
+				f.Printf("Refused an SMTP relay connection from %s, which is not allowed by `smtp-relay allow`.\n", event.RemoteAddr)
+			} else {
+				f.Printf("Refused a %s connection from %s, which is not allowed by `lan-access allow`.\n", event.Protocol, event.RemoteAddr)
+			}
+
 		case events.Raise:
 			f.Printf("Hello!")
+
+			if len(event.Args) > 0 {
+				f.Printf(" Another instance was launched with %v.\n", strings.Join(event.Args, " "))
+			}
 		}
 	}
 
@@ -537,6 +1622,11 @@ func (f *frontendCLI) Loop() error {
       jgs   [ ]                                        [ ]
     ~~^_~^~/   \~^-~^~ _~^-~_^~-^~_^~~-^~_~^~-~_~-^~_^/   \~^ ~~_ ^
 `, constants.FullAppName)
+
+	if f.bridge.IsSafeMode() {
+		f.Println(bold("Bridge is in safe mode: optional features are disabled and mail is read-only. See `debug safe-mode`."))
+	}
+
 	f.Run()
 	return nil
 }
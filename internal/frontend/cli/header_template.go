@@ -0,0 +1,99 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"context"
+	"strings"
+
+	"github.com/abiosoft/ishell"
+)
+
+func (f *frontendCLI) showHeaderTemplate(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user.UserID == "" {
+		return
+	}
+
+	text, err := f.bridge.GetHeaderTemplate(user.UserID)
+	if err != nil {
+		f.printAndLogError("Cannot get header template:", err)
+		return
+	}
+
+	if text == "" {
+		f.Printf("Account %s has no header template\n", user.Username)
+		return
+	}
+
+	f.Println(text)
+}
+
+func (f *frontendCLI) setHeaderTemplate(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user.UserID == "" {
+		return
+	}
+
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	f.Println("Enter one header field per line as Key: Value; variables are written as {{.OriginalTo}}, {{.Address}},")
+	f.Println(`{{.MessageID}}, {{.ExternalID}}, {{join .Labels ", "}} and {{join .LabelIDs ", "}}.`)
+	f.Println("Finish with a line containing only a dot.")
+
+	var lines []string
+
+	for line := c.ReadLine(); line != "."; line = c.ReadLine() {
+		lines = append(lines, line)
+	}
+
+	text := strings.TrimSpace(strings.Join(lines, "\n"))
+	if text == "" {
+		return
+	}
+
+	if !f.yesNoQuestion("Set the header template and resync account " + bold(user.Username)) {
+		return
+	}
+
+	if err := f.bridge.SetHeaderTemplate(context.Background(), user.UserID, text); err != nil {
+		f.printAndLogError("Cannot set header template:", err)
+		return
+	}
+
+	f.Printf("Header template for account %s set\n", user.Username)
+}
+
+func (f *frontendCLI) clearHeaderTemplate(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user.UserID == "" {
+		return
+	}
+
+	if !f.yesNoQuestion("Remove the header template and resync account " + bold(user.Username)) {
+		return
+	}
+
+	if err := f.bridge.SetHeaderTemplate(context.Background(), user.UserID, ""); err != nil {
+		f.printAndLogError("Cannot remove header template:", err)
+		return
+	}
+
+	f.Printf("Header template for account %s removed\n", user.Username)
+}
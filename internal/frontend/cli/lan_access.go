@@ -0,0 +1,82 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"context"
+
+	"github.com/abiosoft/ishell"
+)
+
+func (f *frontendCLI) showLANAccess(_ *ishell.Context) {
+	enabled, clients := f.bridge.GetLANAccess()
+
+	if enabled {
+		f.Println("The servers listen on all interfaces.")
+	} else {
+		f.Println("The servers listen on localhost only.")
+	}
+
+	if len(clients) == 0 {
+		f.Println("Allowed clients: localhost only")
+		return
+	}
+
+	f.Println("Allowed clients: localhost and")
+
+	for _, client := range clients {
+		f.Println("   ", client)
+	}
+}
+
+func (f *frontendCLI) setLANAccessEnabled(enabled bool) func(*ishell.Context) {
+	return func(_ *ishell.Context) {
+		if current, _ := f.bridge.GetLANAccess(); current == enabled {
+			f.Println("The LAN access is unchanged.")
+			return
+		}
+
+		if enabled && !f.yesNoQuestion("The servers will listen on all interfaces, accepting the allowed clients. Are you sure you want to continue") {
+			return
+		}
+
+		if err := f.bridge.SetLANAccessEnabled(context.Background(), enabled); err != nil {
+			f.printAndLogError("Cannot change the LAN access:", err)
+			return
+		}
+
+		if enabled {
+			f.Println("IMAP and SMTP now listen on all interfaces. The gRPC server will after Bridge restarts.")
+		} else {
+			f.Println("IMAP and SMTP now listen on localhost only. The gRPC server will after Bridge restarts.")
+		}
+	}
+}
+
+func (f *frontendCLI) setAllowedClients(c *ishell.Context) {
+	if err := f.bridge.SetAllowedClients(c.Args); err != nil {
+		f.printAndLogError("Cannot change the allowed clients:", err)
+		return
+	}
+
+	if len(c.Args) == 0 {
+		f.Println("New connections are accepted from localhost only.")
+	} else {
+		f.Println("New connections are accepted from localhost and the given clients.")
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"strings"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/mailto"
+	"github.com/abiosoft/ishell"
+	"github.com/bradenaw/juniper/xslices"
+)
+
+func (f *frontendCLI) showMailto(_ *ishell.Context) {
+	settings := f.bridge.GetMailtoSettings()
+
+	f.Println("Default handler:", f.bridge.IsMailtoHandler())
+
+	switch {
+	case settings.Client == "":
+		f.Println("Mail client:     none, mailto URLs raise bridge")
+
+	case settings.ClientPath != "":
+		f.Println("Mail client:    ", settings.Client, "("+settings.ClientPath+")")
+
+	default:
+		f.Println("Mail client:    ", settings.Client)
+	}
+
+	if len(settings.Rules) == 0 {
+		f.Println("Rules:           none, messages are sent from the primary address of the first account")
+		return
+	}
+
+	f.Println("Rules:")
+
+	for _, rule := range settings.Rules {
+		f.Printf("  %s -> %s\n", rule.Pattern, rule.Address)
+	}
+}
+
+func (f *frontendCLI) enableMailto(_ *ishell.Context) {
+	if err := f.bridge.SetMailtoHandler(true); err != nil {
+		f.printAndLogError("Cannot register as mailto handler:", err)
+		return
+	}
+
+	if !f.bridge.IsMailtoHandler() {
+		f.Println("Bridge can handle mailto URLs; pick it as the default email app in the system settings.")
+		return
+	}
+
+	f.Println("Bridge handles the mailto URLs.")
+}
+
+func (f *frontendCLI) disableMailto(_ *ishell.Context) {
+	if err := f.bridge.SetMailtoHandler(false); err != nil {
+		f.printAndLogError("Cannot unregister as mailto handler:", err)
+		return
+	}
+
+	f.Println("Bridge no longer handles the mailto URLs.")
+}
+
+func (f *frontendCLI) changeMailtoClient(c *ishell.Context) {
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	clients := xslices.Map(mailto.Clients, func(client mailto.Client) string { return string(client) })
+
+	f.Print("Mail client to compose in, one of ", strings.Join(clients, ", "), " (leave empty for none): ")
+	client := strings.TrimSpace(c.ReadLine())
+
+	var path string
+
+	if client != "" {
+		f.Print("Path of its executable (leave empty for the usual command): ")
+		path = strings.TrimSpace(c.ReadLine())
+	}
+
+	if err := f.bridge.SetMailtoClient(client, path); err != nil {
+		f.printAndLogError("Cannot set mail client:", err)
+		return
+	}
+
+	f.Println("Mail client changed")
+}
+
+func (f *frontendCLI) setMailtoRule(c *ishell.Context) {
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	f.Println("Messages to a matching recipient are sent from the address, e.g. `someone@example.com` or `*@example.com`.")
+
+	pattern := f.readStringInAttempts("Recipient", c.ReadLine, isNotEmpty)
+	if pattern == "" {
+		return
+	}
+
+	address := f.readStringInAttempts("Address to send from", c.ReadLine, isNotEmpty)
+	if address == "" {
+		return
+	}
+
+	if err := f.bridge.SetMailtoRule(pattern, strings.TrimSpace(address)); err != nil {
+		f.printAndLogError("Cannot set mailto rule:", err)
+		return
+	}
+
+	f.Println("Mailto rule stored")
+}
+
+func (f *frontendCLI) deleteMailtoRule(c *ishell.Context) {
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	pattern := f.readStringInAttempts("Recipient", c.ReadLine, isNotEmpty)
+	if pattern == "" {
+		return
+	}
+
+	if err := f.bridge.DeleteMailtoRule(pattern); err != nil {
+		f.printAndLogError("Cannot delete mailto rule:", err)
+		return
+	}
+
+	f.Println("Mailto rule deleted")
+}
@@ -0,0 +1,114 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"context"
+	"strings"
+
+	"github.com/abiosoft/ishell"
+)
+
+func (f *frontendCLI) listNewsletters(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user.UserID == "" {
+		return
+	}
+
+	f.Println("Scanning received messages for newsletters...")
+
+	newsletters, err := f.bridge.GetNewsletters(context.Background(), user.UserID)
+	if err != nil {
+		f.printAndLogError("Cannot get newsletters:", err)
+		return
+	}
+
+	if len(newsletters) == 0 {
+		f.Printf("No newsletters found for account %s\n", user.Username)
+		return
+	}
+
+	for _, newsletter := range newsletters {
+		f.Printf("%s (%s)\n", bold(newsletter.Name), newsletter.ID)
+		f.Printf("\t%d messages, %d unread, %d KB, last received %s\n",
+			newsletter.Count, newsletter.Unread, newsletter.Size/1024, newsletter.LastReceived.Format("Jan _2 2006"))
+
+		if !newsletter.LastRead.IsZero() {
+			f.Printf("\tlast read message from %s\n", newsletter.LastRead.Format("Jan _2 2006"))
+		}
+
+		if len(newsletter.Unsubscribe) > 0 {
+			f.Printf("\tunsubscribe: %s\n", strings.Join(newsletter.Unsubscribe, " "))
+		}
+	}
+}
+
+func (f *frontendCLI) archiveNewsletter(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user.UserID == "" {
+		return
+	}
+
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	newsletterID := f.readStringInAttempts("Newsletter ID", c.ReadLine, isNotEmpty)
+	if newsletterID == "" {
+		return
+	}
+
+	if !f.yesNoQuestion("Move all messages of " + newsletterID + " from the Inbox to the Archive") {
+		return
+	}
+
+	count, err := f.bridge.ArchiveNewsletter(context.Background(), user.UserID, strings.TrimSpace(newsletterID))
+	if err != nil {
+		f.printAndLogError("Cannot archive newsletter:", err)
+		return
+	}
+
+	f.Printf("%d messages archived\n", count)
+}
+
+func (f *frontendCLI) labelNewsletter(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user.UserID == "" {
+		return
+	}
+
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	newsletterID := f.readStringInAttempts("Newsletter ID", c.ReadLine, isNotEmpty)
+	if newsletterID == "" {
+		return
+	}
+
+	label := f.readStringInAttempts("Label", c.ReadLine, isNotEmpty)
+	if label == "" {
+		return
+	}
+
+	count, err := f.bridge.LabelNewsletter(context.Background(), user.UserID, strings.TrimSpace(newsletterID), strings.TrimSpace(label))
+	if err != nil {
+		f.printAndLogError("Cannot label newsletter:", err)
+		return
+	}
+
+	f.Printf("%d messages labelled\n", count)
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"context"
+	"path"
+	"strings"
+
+	"github.com/abiosoft/ishell"
+)
+
+func (f *frontendCLI) listDomains(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user.UserID == "" {
+		return
+	}
+
+	domains, err := f.bridge.GetUserDomains(user.UserID)
+	if err != nil {
+		f.printAndLogError("Cannot get domains:", err)
+		return
+	}
+
+	for _, domain := range domains {
+		f.Printf("%s: %s\n", bold(domain.Name), strings.Join(domain.Addresses, ", "))
+	}
+}
+
+func (f *frontendCLI) listRecipientRoutes(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user.UserID == "" {
+		return
+	}
+
+	routes, err := f.bridge.GetRecipientRoutes(user.UserID)
+	if err != nil {
+		f.printAndLogError("Cannot get recipient routes:", err)
+		return
+	}
+
+	if len(routes) == 0 {
+		f.Printf("Account %s has no recipient routes\n", user.Username)
+		return
+	}
+
+	for _, route := range routes {
+		f.Printf("%s -> %s\n", bold(route.Pattern), route.Folder)
+	}
+}
+
+func (f *frontendCLI) setRecipientRoute(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user.UserID == "" {
+		return
+	}
+
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	f.Println("New messages sent to a matching recipient are moved from the Inbox to the folder, e.g. `shop@example.com` or `*@shop.example.com`.")
+
+	pattern := f.readStringInAttempts("Recipient", c.ReadLine, func(val string) bool {
+		_, err := path.Match(strings.TrimSpace(val), "")
+		return err == nil && strings.Contains(val, "@")
+	})
+	if pattern == "" {
+		return
+	}
+
+	folder := f.readStringInAttempts("Folder path (e.g. Shopping or Work/Projects)", c.ReadLine, isNotEmpty)
+	if folder == "" {
+		return
+	}
+
+	if err := f.bridge.SetRecipientRoute(context.Background(), user.UserID, pattern, strings.TrimSpace(folder)); err != nil {
+		f.printAndLogError("Cannot set recipient route:", err)
+		return
+	}
+
+	f.Println("Recipient route stored")
+}
+
+func (f *frontendCLI) deleteRecipientRoute(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user.UserID == "" {
+		return
+	}
+
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	pattern := f.readStringInAttempts("Recipient", c.ReadLine, isNotEmpty)
+	if pattern == "" {
+		return
+	}
+
+	if err := f.bridge.DeleteRecipientRoute(context.Background(), user.UserID, pattern); err != nil {
+		f.printAndLogError("Cannot delete recipient route:", err)
+		return
+	}
+
+	f.Println("Recipient route deleted")
+}
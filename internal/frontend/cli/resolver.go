@@ -0,0 +1,108 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/constants"
+	"github.com/abiosoft/ishell"
+)
+
+func (f *frontendCLI) showResolver(_ *ishell.Context) {
+	settings := f.bridge.GetResolverSettings()
+
+	if len(settings.DoHProviders) > 0 {
+		f.Println("Custom DoH providers:", strings.Join(settings.DoHProviders, ", "))
+	} else {
+		f.Println("Custom DoH providers: none, only the built-in ones are used")
+	}
+
+	if len(settings.BootstrapIPs) > 0 {
+		f.Println("Bootstrap IPs:       ", strings.Join(settings.BootstrapIPs, ", "))
+	} else {
+		f.Println("Bootstrap IPs:        none")
+	}
+
+	if settings.DisableSystemDNS {
+		f.Println("System DNS:           disabled, the API host is resolved over DoH")
+	} else {
+		f.Println("System DNS:           enabled")
+	}
+}
+
+func (f *frontendCLI) changeDoHProviders(c *ishell.Context) {
+	f.Print("Enter the custom DoH provider URLs, separated by spaces (leave empty to use only the built-in ones): ")
+
+	if err := f.bridge.SetDoHProviders(strings.Fields(c.ReadLine())); err != nil {
+		f.printAndLogError(err)
+	}
+}
+
+func (f *frontendCLI) changeDoHBootstrapIPs(c *ishell.Context) {
+	f.Print("Enter the IPs used to reach the DoH providers, separated by spaces (leave empty to rely on the system resolver): ")
+
+	if err := f.bridge.SetDoHBootstrapIPs(strings.Fields(c.ReadLine())); err != nil {
+		f.printAndLogError(err)
+	}
+}
+
+func (f *frontendCLI) enableSystemDNS(_ *ishell.Context) {
+	if err := f.bridge.SetDisableSystemDNS(false); err != nil {
+		f.printAndLogError(err)
+		return
+	}
+
+	f.Println("The API host is resolved by the system resolver.")
+}
+
+func (f *frontendCLI) disableSystemDNS(_ *ishell.Context) {
+	if len(f.bridge.GetResolverSettings().BootstrapIPs) == 0 {
+		f.Println("No bootstrap IPs are configured: DoH providers must then be given by IP address.")
+	}
+
+	if !f.yesNoQuestion("Are you sure you want to resolve the API host over DoH only") {
+		return
+	}
+
+	if err := f.bridge.SetDisableSystemDNS(true); err != nil {
+		f.printAndLogError(err)
+	}
+}
+
+func (f *frontendCLI) testResolver(c *ishell.Context) {
+	host := ""
+
+	if len(c.Args) > 0 {
+		host = c.Args[0]
+	} else if u, err := url.Parse(constants.APIHost); err == nil {
+		host = u.Host
+	}
+
+	f.Println("Resolving", host, "...")
+
+	for _, result := range f.bridge.TestResolver(context.Background(), host) {
+		if result.Err != nil {
+			f.Printf("%v: failed after %v: %v\n", result.Provider, result.Duration, result.Err)
+		} else {
+			f.Printf("%v: %v in %v\n", result.Provider, strings.Join(result.Addrs, ", "), result.Duration)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/savedsearch"
+	"github.com/abiosoft/ishell"
+)
+
+func (f *frontendCLI) listSavedSearches(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user.UserID == "" {
+		return
+	}
+
+	searches, err := f.bridge.GetSavedSearches(user.UserID)
+	if err != nil {
+		f.printAndLogError("Cannot get saved searches:", err)
+		return
+	}
+
+	if len(searches) == 0 {
+		f.Printf("Account %s has no saved searches\n", user.Username)
+		return
+	}
+
+	for _, search := range searches {
+		f.Printf("%s: %s\n", bold("Virtual/"+search.Name), search.Query)
+	}
+}
+
+func (f *frontendCLI) setSavedSearch(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user.UserID == "" {
+		return
+	}
+
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	name := f.readStringInAttempts("Saved search name", c.ReadLine, isNotEmpty)
+	if name == "" {
+		return
+	}
+
+	f.Println("Terms are combined, e.g. `is:flagged newer:1w` or `from:alice label:work -is:read`.")
+	f.Println("Supported terms: from:, to:, subject:, label: (or in:), is:unread|read|flagged|answered|draft, has:attachment, newer:, older:.")
+
+	query := f.readStringInAttempts("Query", c.ReadLine, func(val string) bool {
+		_, err := savedsearch.Parse(val)
+		return err == nil
+	})
+	if query == "" {
+		return
+	}
+
+	if err := f.bridge.SetSavedSearch(context.Background(), user.UserID, strings.TrimSpace(name), query); err != nil {
+		f.printAndLogError("Cannot set saved search:", err)
+		return
+	}
+
+	f.Printf("Saved search stored, matching messages are listed in %s\n", "Virtual/"+strings.TrimSpace(name))
+}
+
+func (f *frontendCLI) deleteSavedSearch(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user.UserID == "" {
+		return
+	}
+
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	name := f.readStringInAttempts("Saved search name", c.ReadLine, isNotEmpty)
+	if name == "" {
+		return
+	}
+
+	if err := f.bridge.DeleteSavedSearch(context.Background(), user.UserID, strings.TrimSpace(name)); err != nil {
+		f.printAndLogError("Cannot delete saved search:", err)
+		return
+	}
+
+	f.Println("Saved search deleted")
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"strconv"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+	"github.com/abiosoft/ishell"
+)
+
+func (f *frontendCLI) showServerLimits(_ *ishell.Context) {
+	limits := f.bridge.GetServerLimits()
+
+	f.Println("Commands per second per connection:", formatLimit(limits.CommandsPerSecond))
+	f.Println("Connections per user:              ", formatLimit(limits.MaxConnectionsPerUser))
+	f.Println("Bytes written to clients at once:  ", formatLimit(limits.MaxFetchBytes))
+
+	stats := f.bridge.GetServerLimitStats()
+
+	f.Println("")
+	f.Println("Since Bridge started:")
+	f.Println("    Delayed commands:  ", stats.ThrottledCommands)
+	f.Println("    Closed connections:", stats.RejectedConnections)
+	f.Println("    Delayed writes:    ", stats.ThrottledWrites)
+}
+
+func (f *frontendCLI) setCommandsPerSecond(c *ishell.Context) {
+	f.setServerLimit(c, func(limits *vault.ServerLimits, limit int) { limits.CommandsPerSecond = limit })
+}
+
+func (f *frontendCLI) setMaxConnectionsPerUser(c *ishell.Context) {
+	f.setServerLimit(c, func(limits *vault.ServerLimits, limit int) { limits.MaxConnectionsPerUser = limit })
+}
+
+func (f *frontendCLI) setMaxFetchBytes(c *ishell.Context) {
+	f.setServerLimit(c, func(limits *vault.ServerLimits, limit int) { limits.MaxFetchBytes = limit })
+}
+
+// setServerLimit sets the server limit modified by set to the argument of the command.
+func (f *frontendCLI) setServerLimit(c *ishell.Context, set func(*vault.ServerLimits, int)) {
+	if len(c.Args) != 1 {
+		f.Println("Please specify the limit, or 0 for no limit.")
+		return
+	}
+
+	limit, err := strconv.Atoi(c.Args[0])
+	if err != nil || limit < 0 {
+		f.Printf("Wrong input '%s'. Choose a positive number, or 0 for no limit.\n", bold(c.Args[0]))
+		return
+	}
+
+	limits := f.bridge.GetServerLimits()
+
+	set(&limits, limit)
+
+	if err := f.bridge.SetServerLimits(limits); err != nil {
+		f.printAndLogError("Cannot change the server limits:", err)
+		return
+	}
+
+	f.Println("The server limits were changed.")
+}
+
+func formatLimit(limit int) string {
+	if limit == 0 {
+		return "unlimited"
+	}
+
+	return strconv.Itoa(limit)
+}
@@ -0,0 +1,165 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
+	"github.com/abiosoft/ishell"
+)
+
+func (f *frontendCLI) runSetup(c *ishell.Context) {
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	f.Println("Press enter at any step to keep the current setting.")
+
+	setup := f.bridge.NewSetup()
+
+	for setup.Step() != bridge.SetupStepDone {
+		var err error
+
+		switch setup.Step() {
+		case bridge.SetupStepKeychain:
+			err = f.setupKeychain(setup)
+
+		case bridge.SetupStepPorts:
+			err = f.setupPorts(setup)
+
+		case bridge.SetupStepCache:
+			err = f.setupCache(setup)
+
+		case bridge.SetupStepClientConfig:
+			err = f.setupClientConfig(c, setup)
+
+		case bridge.SetupStepDone:
+		}
+
+		if err != nil {
+			f.printAndLogError(err)
+
+			if !f.yesNoQuestion("Try again") {
+				return
+			}
+		}
+	}
+
+	f.Println("Setup done.")
+
+	f.printConfigIssues(f.bridge.ValidateConfiguration(context.Background()))
+}
+
+func (f *frontendCLI) checkConfig(_ *ishell.Context) {
+	issues := f.bridge.ValidateConfiguration(context.Background())
+	if len(issues) == 0 {
+		f.Println("No problems found in the configuration.")
+		return
+	}
+
+	f.printConfigIssues(issues)
+}
+
+func (f *frontendCLI) printConfigIssues(issues []bridge.ConfigIssue) {
+	for _, issue := range issues {
+		f.Println(issue)
+	}
+}
+
+func (f *frontendCLI) setupKeychain(setup *bridge.Setup) error {
+	current, err := f.bridge.GetKeychainApp()
+	if err != nil {
+		return err
+	}
+
+	f.Printf("Keychains available: %v (current %v): ", strings.Join(setup.Keychains(), ", "), current)
+
+	helper := strings.TrimSpace(f.ReadLine())
+	if helper == "" {
+		return setup.Skip()
+	}
+
+	return setup.SetKeychain(helper)
+}
+
+func (f *frontendCLI) setupPorts(setup *bridge.Setup) error {
+	imapPort, err := f.readSetupPort("IMAP", f.bridge.GetIMAPPort())
+	if err != nil {
+		return err
+	}
+
+	smtpPort, err := f.readSetupPort("SMTP", f.bridge.GetSMTPPort())
+	if err != nil {
+		return err
+	}
+
+	if imapPort == f.bridge.GetIMAPPort() && smtpPort == f.bridge.GetSMTPPort() {
+		return setup.Skip()
+	}
+
+	return setup.SetPorts(context.Background(), imapPort, smtpPort)
+}
+
+func (f *frontendCLI) readSetupPort(name string, current int) (int, error) {
+	f.Printf("%v port (current %v): ", name, current)
+
+	port := strings.TrimSpace(f.ReadLine())
+	if port == "" {
+		return current, nil
+	}
+
+	number, err := strconv.Atoi(port)
+	if err != nil {
+		return 0, fmt.Errorf("%v is not a valid port number", port)
+	}
+
+	return number, nil
+}
+
+func (f *frontendCLI) setupCache(setup *bridge.Setup) error {
+	f.Printf("Message cache location (current %v): ", f.bridge.GetGluonCacheDir())
+
+	location := strings.TrimSpace(f.ReadLine())
+	if location == "" {
+		return setup.Skip()
+	}
+
+	return setup.SetCacheDir(context.Background(), location)
+}
+
+func (f *frontendCLI) setupClientConfig(c *ishell.Context, setup *bridge.Setup) error {
+	//goland:noinspection GoBoolExpressions
+	if runtime.GOOS != "darwin" || len(f.bridge.GetUserIDs()) == 0 {
+		return setup.Skip()
+	}
+
+	user := f.askUserByIndexOrName(c)
+	if user.UserID == "" || !f.yesNoQuestion("Configure Apple Mail for "+bold(user.Username)) {
+		return setup.Skip()
+	}
+
+	if !f.installAppleMailCert() {
+		return setup.Skip()
+	}
+
+	return setup.ConfigureClient(context.Background(), user.UserID, user.Addresses[0])
+}
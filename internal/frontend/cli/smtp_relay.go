@@ -0,0 +1,162 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+	"github.com/abiosoft/ishell"
+)
+
+func (f *frontendCLI) showSMTPRelay(_ *ishell.Context) {
+	relay := f.bridge.GetSMTPRelay()
+
+	if relay.Port == 0 {
+		f.Println("Port:            disabled")
+	} else {
+		f.Println("Port:           ", relay.Port, "(all interfaces)")
+	}
+
+	if relay.Address == "" {
+		f.Println("Sends from:      no address set")
+	} else {
+		f.Println("Sends from:     ", relay.Address)
+	}
+
+	if len(relay.AllowedClients) == 0 {
+		f.Println("Allowed clients: localhost only")
+	} else {
+		f.Println("Allowed clients: localhost,", strings.Join(relay.AllowedClients, ", "))
+	}
+
+	if relay.Username == "" {
+		f.Println("Authentication:  none")
+	} else {
+		f.Println("Authentication: ", relay.Username, "(PLAIN or LOGIN, after STARTTLS)")
+	}
+
+	f.Printf("Limits:          %v messages per hour, %v recipients per message\n", relay.MaxMessagesPerHour, relay.MaxRecipients)
+}
+
+func (f *frontendCLI) setSMTPRelayPort(c *ishell.Context) {
+	if len(c.Args) != 1 {
+		f.Println("Usage: smtp-relay port <port>, or 0 to disable the relay")
+		return
+	}
+
+	port, err := strconv.Atoi(c.Args[0])
+	if err != nil {
+		f.printAndLogError("Invalid port:", err)
+		return
+	}
+
+	if port != 0 && !f.isPortFree(c.Args[0]) {
+		f.Println("The port is not free.")
+		return
+	}
+
+	f.modSMTPRelay(func(relay *vault.SMTPRelay) {
+		relay.Port = port
+	})
+}
+
+func (f *frontendCLI) setSMTPRelayAddress(c *ishell.Context) {
+	if len(c.Args) != 1 {
+		f.Println("Usage: smtp-relay address <address>")
+		return
+	}
+
+	f.modSMTPRelay(func(relay *vault.SMTPRelay) {
+		relay.Address = c.Args[0]
+	})
+}
+
+func (f *frontendCLI) setSMTPRelayAllowedClients(c *ishell.Context) {
+	f.modSMTPRelay(func(relay *vault.SMTPRelay) {
+		relay.AllowedClients = c.Args
+	})
+}
+
+func (f *frontendCLI) setSMTPRelayAuth(c *ishell.Context) {
+	if len(c.Args) > 1 {
+		f.Println("Usage: smtp-relay auth [<username>]")
+		return
+	}
+
+	if len(c.Args) == 0 {
+		f.modSMTPRelay(func(relay *vault.SMTPRelay) {
+			relay.Username = ""
+			relay.Password = ""
+		})
+
+		return
+	}
+
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	password := f.readStringInAttempts("Password", c.ReadPassword, isNotEmpty)
+	if password == "" {
+		return
+	}
+
+	f.modSMTPRelay(func(relay *vault.SMTPRelay) {
+		relay.Username = c.Args[0]
+		relay.Password = password
+	})
+}
+
+func (f *frontendCLI) setSMTPRelayLimits(c *ishell.Context) {
+	if len(c.Args) != 2 {
+		f.Println("Usage: smtp-relay limits <messages per hour> <recipients per message>")
+		return
+	}
+
+	messages, err := strconv.Atoi(c.Args[0])
+	if err != nil {
+		f.printAndLogError("Invalid number of messages:", err)
+		return
+	}
+
+	recipients, err := strconv.Atoi(c.Args[1])
+	if err != nil {
+		f.printAndLogError("Invalid number of recipients:", err)
+		return
+	}
+
+	f.modSMTPRelay(func(relay *vault.SMTPRelay) {
+		relay.MaxMessagesPerHour = messages
+		relay.MaxRecipients = recipients
+	})
+}
+
+func (f *frontendCLI) modSMTPRelay(fn func(*vault.SMTPRelay)) {
+	relay := f.bridge.GetSMTPRelay()
+
+	fn(&relay)
+
+	if err := f.bridge.SetSMTPRelay(context.Background(), relay); err != nil {
+		f.printAndLogError("Cannot change the SMTP relay:", err)
+		return
+	}
+
+	f.showSMTPRelay(nil)
+}
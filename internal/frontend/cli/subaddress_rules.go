@@ -0,0 +1,103 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"context"
+	"strings"
+
+	"github.com/abiosoft/ishell"
+)
+
+func (f *frontendCLI) listSubaddressRules(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user.UserID == "" {
+		return
+	}
+
+	rules, err := f.bridge.GetSubaddressRules(user.UserID)
+	if err != nil {
+		f.printAndLogError("Cannot get subaddress rules:", err)
+		return
+	}
+
+	if len(rules) == 0 {
+		f.Printf("Account %s has no subaddress rules\n", user.Username)
+		return
+	}
+
+	for _, rule := range rules {
+		f.Printf("+%s -> %s\n", bold(rule.Tag), rule.Label)
+	}
+}
+
+func (f *frontendCLI) setSubaddressRule(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user.UserID == "" {
+		return
+	}
+
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	f.Println("New messages sent to user+tag@domain are labelled, e.g. tag `news` with label `News`. Missing labels are created.")
+
+	tag := f.readStringInAttempts("Tag", c.ReadLine, func(val string) bool {
+		val = strings.TrimSpace(val)
+		return val != "" && !strings.ContainsAny(val, "+@ \t")
+	})
+	if tag == "" {
+		return
+	}
+
+	label := f.readStringInAttempts("Label", c.ReadLine, func(val string) bool {
+		return isNotEmpty(val) && !strings.Contains(val, "/")
+	})
+	if label == "" {
+		return
+	}
+
+	if err := f.bridge.SetSubaddressRule(context.Background(), user.UserID, tag, strings.TrimSpace(label)); err != nil {
+		f.printAndLogError("Cannot set subaddress rule:", err)
+		return
+	}
+
+	f.Println("Subaddress rule stored")
+}
+
+func (f *frontendCLI) deleteSubaddressRule(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user.UserID == "" {
+		return
+	}
+
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	tag := f.readStringInAttempts("Tag", c.ReadLine, isNotEmpty)
+	if tag == "" {
+		return
+	}
+
+	if err := f.bridge.DeleteSubaddressRule(context.Background(), user.UserID, tag); err != nil {
+		f.printAndLogError("Cannot delete subaddress rule:", err)
+		return
+	}
+
+	f.Println("Subaddress rule deleted")
+}
@@ -0,0 +1,140 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
+	"github.com/ProtonMail/proton-bridge/v3/internal/user"
+	"github.com/abiosoft/ishell"
+	"golang.org/x/exp/maps"
+)
+
+func (f *frontendCLI) saveSyncSnapshot(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user.UserID == "" {
+		return
+	}
+
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	path := f.readStringInAttempts("Snapshot file", c.ReadLine, isNotEmpty)
+	if path == "" {
+		return
+	}
+
+	snapshot, err := f.bridge.SnapshotSyncState(context.Background(), user.UserID)
+	if err != nil {
+		f.printAndLogError("Cannot snapshot sync state:", err)
+		return
+	}
+
+	b, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		f.printAndLogError("Cannot encode sync snapshot:", err)
+		return
+	}
+
+	if err := os.WriteFile(strings.TrimSpace(path), b, 0o600); err != nil {
+		f.printAndLogError("Cannot save sync snapshot:", err)
+		return
+	}
+
+	f.Printf("Sync state of account %s saved to %s\n", user.Username, path)
+}
+
+func (f *frontendCLI) diffSyncSnapshots(c *ishell.Context) {
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	before, ok := f.readSyncSnapshot(c, "Older snapshot file")
+	if !ok {
+		return
+	}
+
+	after, ok := f.readSyncSnapshot(c, "Newer snapshot file")
+	if !ok {
+		return
+	}
+
+	diff, err := bridge.DiffSyncSnapshots(before, after)
+	if err != nil {
+		f.printAndLogError("Cannot compare sync snapshots:", err)
+		return
+	}
+
+	f.Printf("Changes from %s to %s:\n", diff.Since.Format("Jan _2 15:04:05"), diff.Until.Format("Jan _2 15:04:05"))
+
+	if diff.IsEmpty() {
+		f.Println("\tnone")
+		return
+	}
+
+	if diff.OldEventID != diff.NewEventID {
+		f.Printf("\tlast event: %s -> %s\n", diff.OldEventID, diff.NewEventID)
+	}
+
+	names := maps.Keys(diff.Mailboxes)
+	sort.Strings(names)
+
+	for _, name := range names {
+		f.Printf("\t%s: %+d messages, %+d unread\n", bold(name), diff.Mailboxes[name].Total, diff.Mailboxes[name].Unread)
+	}
+
+	if len(diff.NewFailedMessageIDs) > 0 {
+		f.Printf("\tnewly failed to sync: %s\n", strings.Join(diff.NewFailedMessageIDs, " "))
+	}
+
+	if len(diff.FixedMessageIDs) > 0 {
+		f.Printf("\tno longer failing: %s\n", strings.Join(diff.FixedMessageIDs, " "))
+	}
+}
+
+func (f *frontendCLI) readSyncSnapshot(c *ishell.Context, title string) (user.SyncSnapshot, bool) {
+	path := f.readStringInAttempts(title, c.ReadLine, isNotEmpty)
+	if path == "" {
+		return user.SyncSnapshot{}, false
+	}
+
+	var snapshot user.SyncSnapshot
+
+	if err := func() error {
+		b, err := os.ReadFile(strings.TrimSpace(path))
+		if err != nil {
+			return err
+		}
+
+		if err := json.Unmarshal(b, &snapshot); err != nil {
+			return fmt.Errorf("invalid snapshot: %w", err)
+		}
+
+		return nil
+	}(); err != nil {
+		f.printAndLogError("Cannot read sync snapshot:", err)
+		return user.SyncSnapshot{}, false
+	}
+
+	return snapshot, true
+}
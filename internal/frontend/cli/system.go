@@ -21,25 +21,44 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
 	"github.com/ProtonMail/proton-bridge/v3/internal/certs"
+	"github.com/ProtonMail/proton-bridge/v3/internal/services/unifiedinbox"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+	"github.com/ProtonMail/proton-bridge/v3/pkg/keychain"
 	"github.com/ProtonMail/proton-bridge/v3/pkg/ports"
 	"github.com/abiosoft/ishell"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
 )
 
 func (f *frontendCLI) printLogDir(_ *ishell.Context) {
-	if path, err := f.bridge.GetLogsPath(); err != nil {
+	if _, err := f.bridge.GetSessionLogs(); err == nil {
+		f.Println("Logs are kept in memory only. Use `session-log` to print them.")
+	} else if path, err := f.bridge.GetLogsPath(); err != nil {
 		f.Println("Failed to determine location of log files")
 	} else {
 		f.Println("Log files are stored in\n\n ", path)
 	}
 }
 
+func (f *frontendCLI) printSessionLog(_ *ishell.Context) {
+	logs, err := f.bridge.GetSessionLogs()
+	if err != nil {
+		f.printAndLogError("Logs are written to disk, not kept in memory. Use `log-dir` to find them.")
+		return
+	}
+
+	f.Print(string(logs))
+}
+
 func (f *frontendCLI) printManual(_ *ishell.Context) {
 	f.Println("More instructions about the Bridge can be found at\n\n  https://proton.me/mail/bridge")
 }
@@ -132,6 +151,325 @@ func (f *frontendCLI) changeSMTPPort(c *ishell.Context) {
 	}
 }
 
+func (f *frontendCLI) showLMTP(_ *ishell.Context) {
+	if !f.bridge.GetLMTPEnabled() {
+		f.Println("The LMTP server is disabled.")
+		return
+	}
+
+	path, err := f.bridge.GetLMTPSocketPath()
+	if err != nil {
+		f.printAndLogError(err)
+		return
+	}
+
+	f.Println("The LMTP server listens on", path)
+	f.Println("Deliver to an address+Folder/Subfolder recipient to import the message into that folder.")
+}
+
+func (f *frontendCLI) setLMTPEnabled(enabled bool) func(*ishell.Context) {
+	return func(c *ishell.Context) {
+		if err := f.bridge.SetLMTPEnabled(context.Background(), enabled); err != nil {
+			f.printAndLogError(err)
+			return
+		}
+
+		f.showLMTP(c)
+	}
+}
+
+func (f *frontendCLI) changePOP3Port(c *ishell.Context) {
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	newPOP3Port := f.readStringInAttempts(fmt.Sprintf("Set POP3 port, 0 to disable (current %v)", f.bridge.GetPOP3Port()), c.ReadLine, func(port string) bool {
+		return port == "0" || f.isPortFree(port)
+	})
+	if newPOP3Port == "" {
+		f.printAndLogError(errors.New("failed to get new port"))
+		return
+	}
+
+	newPOP3PortInt, err := strconv.Atoi(newPOP3Port)
+	if err != nil {
+		f.printAndLogError(err)
+		return
+	}
+
+	if err := f.bridge.SetPOP3Port(context.Background(), newPOP3PortInt); err != nil {
+		f.printAndLogError(err)
+		return
+	}
+}
+
+func (f *frontendCLI) changeNNTPPort(c *ishell.Context) {
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	newNNTPPort := f.readStringInAttempts(fmt.Sprintf("Set NNTP port, 0 to disable (current %v)", f.bridge.GetNNTPPort()), c.ReadLine, func(port string) bool {
+		return port == "0" || f.isPortFree(port)
+	})
+	if newNNTPPort == "" {
+		f.printAndLogError(errors.New("failed to get new port"))
+		return
+	}
+
+	newNNTPPortInt, err := strconv.Atoi(newNNTPPort)
+	if err != nil {
+		f.printAndLogError(err)
+		return
+	}
+
+	if err := f.bridge.SetNNTPPort(context.Background(), newNNTPPortInt); err != nil {
+		f.printAndLogError(err)
+		return
+	}
+}
+
+func (f *frontendCLI) changePOP3Security(_ *ishell.Context) {
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	newSecurity := "SSL"
+	if f.bridge.GetPOP3SSL() {
+		newSecurity = "STARTTLS"
+	}
+
+	msg := fmt.Sprintf("Are you sure you want to change POP3 setting to %q", newSecurity)
+
+	if f.yesNoQuestion(msg) {
+		if err := f.bridge.SetPOP3SSL(context.Background(), !f.bridge.GetPOP3SSL()); err != nil {
+			f.printAndLogError(err)
+			return
+		}
+	}
+}
+
+func (f *frontendCLI) changePOP3DeleteAction(c *ishell.Context) {
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	actions := []vault.POP3DeleteAction{vault.POP3Keep, vault.POP3Archive, vault.POP3Trash}
+
+	f.Println("Messages deleted by POP3 clients are currently:", f.bridge.GetPOP3DeleteAction())
+
+	for idx, action := range actions {
+		f.Printf("%v: %v\n", idx, action)
+	}
+
+	idx, err := strconv.Atoi(f.readStringInAttempts("Index", c.ReadLine, isNotEmpty))
+	if err != nil || idx < 0 || idx >= len(actions) {
+		f.Println("Invalid index")
+		return
+	}
+
+	if err := f.bridge.SetPOP3DeleteAction(actions[idx]); err != nil {
+		f.printAndLogError(err)
+		return
+	}
+}
+
+func (f *frontendCLI) changeIPFamily(c *ishell.Context) {
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	families := []vault.IPFamily{vault.IPv4, vault.DualStack, vault.IPv6}
+
+	f.Println("The servers currently listen on:", f.bridge.GetIPFamily())
+
+	for idx, family := range families {
+		f.Printf("%v: %v\n", idx, family)
+	}
+
+	idx, err := strconv.Atoi(f.readStringInAttempts("Index", c.ReadLine, isNotEmpty))
+	if err != nil || idx < 0 || idx >= len(families) {
+		f.Println("Invalid index")
+		return
+	}
+
+	if err := f.bridge.SetIPFamily(context.Background(), families[idx]); err != nil {
+		f.printAndLogError(err)
+		return
+	}
+
+	f.Println("Mail clients should now connect to", f.bridge.GetHost())
+}
+
+func (f *frontendCLI) changeKeychain(c *ishell.Context) {
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	current, err := f.bridge.GetKeychainApp()
+	if err != nil {
+		f.printAndLogError(err)
+		return
+	}
+
+	helpers := maps.Keys(keychain.Helpers)
+
+	slices.Sort(helpers)
+
+	if current == "" {
+		current = keychain.DefaultHelper
+	}
+
+	f.Println("The vault key is currently stored in:", current)
+
+	for idx, helper := range helpers {
+		f.Printf("%v: %v\n", idx, helper)
+	}
+
+	idx, err := strconv.Atoi(f.readStringInAttempts("Index", c.ReadLine, isNotEmpty))
+	if err != nil || idx < 0 || idx >= len(helpers) {
+		f.Println("Invalid index")
+		return
+	}
+
+	if helpers[idx] == current {
+		f.Println("The keychain is unchanged.")
+		return
+	}
+
+	if !f.yesNoQuestion("The accounts will have to be added again after Bridge restarts. Are you sure you want to continue") {
+		return
+	}
+
+	if err := f.bridge.SetKeychainApp(helpers[idx]); err != nil {
+		f.printAndLogError(err)
+		return
+	}
+
+	if helpers[idx] == keychain.EncryptedFile {
+		f.Println("Set BRIDGE_KEYCHAIN_PASSPHRASE, or BRIDGE_KEYCHAIN_PASSPHRASE_FILE to the path of a file holding it, before restarting Bridge.")
+	}
+
+	f.Println("Bridge uses the new keychain after it restarts.")
+}
+
+func (f *frontendCLI) changeIMAPBindAddress(c *ishell.Context) {
+	f.changeBindAddress(c, "IMAP", f.bridge.GetIMAPBindAddress, f.bridge.SetIMAPBindAddress)
+}
+
+func (f *frontendCLI) changeSMTPBindAddress(c *ishell.Context) {
+	f.changeBindAddress(c, "SMTP", f.bridge.GetSMTPBindAddress, f.bridge.SetSMTPBindAddress)
+}
+
+func (f *frontendCLI) changeBindAddress(
+	c *ishell.Context,
+	protocol string,
+	get func() string,
+	set func(context.Context, string) error,
+) {
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	current := get()
+	if current == "" {
+		current = "default hosts"
+	}
+
+	f.Printf("Set %v bind address, or leave empty for the default hosts (current %v): ", protocol, current)
+
+	address := strings.TrimSpace(c.ReadLine())
+
+	// Anything but a loopback address exposes the server to other machines.
+	if ip := net.ParseIP(address); ip != nil && !ip.IsLoopback() {
+		f.Println(bold("Warning:"), "the", protocol, "server will accept connections from other machines on", address+".")
+		f.Println("Only the clients allowed with `lan-access allow` can connect; connections from other machines are closed as soon as they are accepted.")
+
+		if !f.yesNoQuestion("Are you sure you want to continue") {
+			return
+		}
+	}
+
+	if err := set(context.Background(), address); err != nil {
+		f.printAndLogError(err)
+		return
+	}
+
+	if address == "" {
+		f.Println("The", protocol, "server now listens on the default hosts.")
+	} else {
+		f.Println("The", protocol, "server now listens on", address)
+	}
+}
+
+func (f *frontendCLI) changeSyncCache(c *ishell.Context) {
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	f.Printf("The sync download cache uses %v MB of memory per account", f.bridge.GetSyncCacheMemory()/1024/1024)
+
+	if f.bridge.GetSyncCacheSpill() {
+		f.Println(" and writes what it evicts to disk.")
+	} else {
+		f.Println(" and drops what it evicts.")
+	}
+
+	minMemory := bridge.MinSyncCacheMemory / 1024 / 1024
+
+	memory := f.readStringInAttempts(fmt.Sprintf("Memory in MB, at least %v, or 0 for the default", minMemory), c.ReadLine, func(val string) bool {
+		size, err := strconv.ParseUint(val, 10, 64)
+		return err == nil && (size == 0 || size >= minMemory)
+	})
+	if memory == "" {
+		return
+	}
+
+	size, _ := strconv.ParseUint(memory, 10, 64)
+
+	spill := f.yesNoQuestion("Write evicted downloads to disk, encrypted, rather than downloading them again")
+
+	if err := f.bridge.SetSyncCacheMemory(size * 1024 * 1024); err != nil {
+		f.printAndLogError("Cannot change sync cache memory:", err)
+		return
+	}
+
+	if err := f.bridge.SetSyncCacheSpill(spill); err != nil {
+		f.printAndLogError("Cannot change sync cache spilling:", err)
+		return
+	}
+
+	f.Println("The sync download cache settings apply after bridge restarts.")
+}
+
+func (f *frontendCLI) changeMetricsListener(c *ishell.Context) {
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	if url := f.bridge.GetMetricsURL(); url != "" {
+		f.Println("Metrics are served on", bold(url))
+	} else {
+		f.Println("Metrics are not served.")
+	}
+
+	f.Print("Set the host:port to serve metrics on, e.g. 127.0.0.1:9154, or leave empty to stop serving them: ")
+
+	address := strings.TrimSpace(c.ReadLine())
+
+	// The metrics tell how bridge is used, e.g. how many clients are connected; keep them on this machine by default.
+	if host, _, err := net.SplitHostPort(address); err == nil {
+		if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+			f.Println(bold("Warning:"), "the metrics will be served to anyone who can reach", address+".")
+
+			if !f.yesNoQuestion("Are you sure you want to continue") {
+				return
+			}
+		}
+	}
+
+	if err := f.bridge.SetMetricsListener(address); err != nil {
+		f.printAndLogError("Cannot change metrics listener:", err)
+		return
+	}
+
+	if url := f.bridge.GetMetricsURL(); url != "" {
+		f.Println("Metrics are now served on", bold(url))
+	} else {
+		f.Println("Metrics are no longer served.")
+	}
+}
+
 func (f *frontendCLI) allowProxy(_ *ishell.Context) {
 	if f.bridge.GetProxyAllowed() {
 		f.Println("Bridge is already set to use alternative routing to connect to Proton if it is being blocked.")
@@ -196,6 +534,60 @@ func (f *frontendCLI) showAllMail(_ *ishell.Context) {
 	}
 }
 
+func (f *frontendCLI) showPrivacyMode(_ *ishell.Context) {
+	settings := f.bridge.GetPrivacyMode()
+
+	if settings.Enabled {
+		f.Println("Privacy mode is enabled: requests to Proton are delayed by up to", settings.JitterWindow)
+	} else {
+		f.Println("Privacy mode is disabled: requests to Proton are sent right away.")
+	}
+}
+
+func (f *frontendCLI) enablePrivacyMode(_ *ishell.Context) {
+	settings := f.bridge.GetPrivacyMode()
+
+	if settings.Enabled {
+		f.Println("Privacy mode is already enabled.")
+		return
+	}
+
+	f.Println("Requests to Proton will be sent in batches, at random times within", settings.JitterWindow, "of being made.")
+	f.Println("This hides when mail is read or sent, but makes everything slower, including sending mail and logging in.")
+
+	if f.yesNoQuestion("Do you want to enable privacy mode") {
+		if err := f.bridge.SetPrivacyMode(true); err != nil {
+			f.printAndLogError(err)
+		}
+	}
+}
+
+func (f *frontendCLI) disablePrivacyMode(_ *ishell.Context) {
+	if err := f.bridge.SetPrivacyMode(false); err != nil {
+		f.printAndLogError(err)
+		return
+	}
+
+	f.Println("Privacy mode is disabled.")
+}
+
+func (f *frontendCLI) changePrivacyJitterWindow(c *ishell.Context) {
+	if len(c.Args) != 1 {
+		f.Println("Please specify the jitter window, e.g. 30s or 2m.")
+		return
+	}
+
+	window, err := time.ParseDuration(c.Args[0])
+	if err != nil {
+		f.Println("Invalid duration:", err)
+		return
+	}
+
+	if err := f.bridge.SetPrivacyJitterWindow(window); err != nil {
+		f.printAndLogError(err)
+	}
+}
+
 func (f *frontendCLI) enableTelemetry(_ *ishell.Context) {
 	if !f.bridge.GetTelemetryDisabled() {
 		f.Println("Usage diagnostics collection is enabled.")
@@ -241,6 +633,109 @@ func (f *frontendCLI) setGluonLocation(c *ishell.Context) {
 	}
 }
 
+func (f *frontendCLI) changeIndexHook(c *ishell.Context) {
+	if command := f.bridge.GetIndexHook(); command != "" {
+		f.Println("The current index hook is:", command)
+	}
+
+	f.Print("Enter the command to run when new messages arrive (leave empty to disable): ")
+
+	if err := f.bridge.SetIndexHook(strings.TrimSpace(c.ReadLine())); err != nil {
+		f.printAndLogError(err)
+	}
+}
+
+func (f *frontendCLI) enableUnifiedInbox(_ *ishell.Context) {
+	if !f.bridge.GetUnifiedInbox() {
+		if err := f.bridge.SetUnifiedInbox(context.Background(), true); err != nil {
+			f.printAndLogError(err)
+			return
+		}
+	}
+
+	f.Println("The unified inbox is enabled.")
+	f.Printf("Log in to IMAP as %q with the password %s to access it.\n", unifiedinbox.Username, f.bridge.GetUnifiedInboxPassword())
+}
+
+func (f *frontendCLI) resetUnifiedInboxPassword(_ *ishell.Context) {
+	if err := f.bridge.ResetUnifiedInboxPassword(); err != nil {
+		f.printAndLogError(err)
+		return
+	}
+
+	f.Printf("The password of the unified inbox is now %s\n", f.bridge.GetUnifiedInboxPassword())
+}
+
+func (f *frontendCLI) disableUnifiedInbox(_ *ishell.Context) {
+	if err := f.bridge.SetUnifiedInbox(context.Background(), false); err != nil {
+		f.printAndLogError(err)
+		return
+	}
+
+	f.Println("The unified inbox is disabled.")
+}
+
+func (f *frontendCLI) showDoNotDisturb(_ *ishell.Context) {
+	dnd := f.bridge.GetDoNotDisturb()
+
+	if f.bridge.IsDoNotDisturbActive() {
+		f.Println("Do not disturb is active.")
+	} else {
+		f.Println("Do not disturb is not active.")
+	}
+
+	if dnd.Schedule.Enabled {
+		f.Printf("Scheduled daily from %s to %s.\n", formatTimeOfDay(dnd.Schedule.Start), formatTimeOfDay(dnd.Schedule.End))
+	}
+
+	if dnd.DelaySync {
+		f.Println("Syncs are delayed while do not disturb is active.")
+	}
+}
+
+func (f *frontendCLI) enableDoNotDisturb(_ *ishell.Context) {
+	dnd := f.bridge.GetDoNotDisturb()
+	dnd.Enabled = true
+
+	if err := f.bridge.SetDoNotDisturb(dnd); err != nil {
+		f.printAndLogError(err)
+	}
+}
+
+func (f *frontendCLI) disableDoNotDisturb(_ *ishell.Context) {
+	dnd := f.bridge.GetDoNotDisturb()
+	dnd.Enabled = false
+
+	if err := f.bridge.SetDoNotDisturb(dnd); err != nil {
+		f.printAndLogError(err)
+	}
+}
+
+func (f *frontendCLI) scheduleDoNotDisturb(c *ishell.Context) {
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	dnd := f.bridge.GetDoNotDisturb()
+
+	schedule := f.readStringInAttempts("Daily schedule, e.g. 22:00-07:00 (leave empty for none)", c.ReadLine, func(val string) bool {
+		_, err := parseQuietHours(val)
+		return err == nil
+	})
+
+	hours, err := parseQuietHours(schedule)
+	if err != nil {
+		f.printAndLogError(err)
+		return
+	}
+
+	dnd.Schedule = hours
+	dnd.DelaySync = f.yesNoQuestion("Delay syncing while do not disturb is active")
+
+	if err := f.bridge.SetDoNotDisturb(dnd); err != nil {
+		f.printAndLogError(err)
+	}
+}
+
 func (f *frontendCLI) tlsCertStatus(_ *ishell.Context) {
 	cert, _ := f.bridge.GetBridgeTLSCert()
 	installer := certs.NewInstaller()
@@ -321,7 +816,11 @@ func (f *frontendCLI) importTLSCerts(c *ishell.Context) {
 		return
 	}
 
-	f.Println("TLS certificate imported. Restart Bridge to use it.")
+	f.Println("TLS certificate imported. New connections will use it.")
+}
+
+func (f *frontendCLI) showTLSCertExpiry(_ *ishell.Context) {
+	f.Println("The TLS certificate used by Bridge expires on", f.bridge.GetBridgeTLSCertExpiry().Format(time.RFC822)+".")
 }
 
 func (f *frontendCLI) isPortFree(port string) bool {
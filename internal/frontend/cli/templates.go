@@ -0,0 +1,107 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/templates"
+	"github.com/abiosoft/ishell"
+	"golang.org/x/exp/maps"
+)
+
+func (f *frontendCLI) listTemplates(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user.UserID == "" {
+		return
+	}
+
+	tmpls, err := f.bridge.GetTemplates(user.UserID)
+	if err != nil {
+		f.printAndLogError("Cannot get templates:", err)
+		return
+	}
+
+	if len(tmpls) == 0 {
+		f.Printf("Account %s has no templates\n", user.Username)
+		return
+	}
+
+	names := maps.Keys(tmpls)
+	sort.Strings(names)
+
+	for _, name := range names {
+		f.Printf("%s (%s)\n", bold(name), templates.Address(name))
+		f.Println(tmpls[name])
+		f.Println()
+	}
+}
+
+func (f *frontendCLI) setTemplate(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user.UserID == "" {
+		return
+	}
+
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	name := f.readStringInAttempts("Template name", c.ReadLine, isNotEmpty)
+	if name == "" {
+		return
+	}
+
+	f.Println("Enter the template body; variables are written as {{.Name}}, {{.Email}}, {{.Subject}} and {{.From}}.")
+	f.Println("Finish with a line containing only a dot.")
+
+	var lines []string
+
+	for line := c.ReadLine(); line != "."; line = c.ReadLine() {
+		lines = append(lines, line)
+	}
+
+	if err := f.bridge.SetTemplate(user.UserID, strings.TrimSpace(name), strings.Join(lines, "\r\n")); err != nil {
+		f.printAndLogError("Cannot set template:", err)
+		return
+	}
+
+	f.Printf("Template saved, add %s as a recipient to insert it into a message\n", templates.Address(strings.TrimSpace(name)))
+}
+
+func (f *frontendCLI) deleteTemplate(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user.UserID == "" {
+		return
+	}
+
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	name := f.readStringInAttempts("Template name", c.ReadLine, isNotEmpty)
+	if name == "" {
+		return
+	}
+
+	if err := f.bridge.DeleteTemplate(user.UserID, strings.TrimSpace(name)); err != nil {
+		f.printAndLogError("Cannot delete template:", err)
+		return
+	}
+
+	f.Println("Template deleted")
+}
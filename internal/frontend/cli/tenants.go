@@ -0,0 +1,116 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"context"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
+	"github.com/abiosoft/ishell"
+)
+
+func (f *frontendCLI) listTenants(_ *ishell.Context) {
+	names := f.tenants.GetTenants()
+
+	if len(names) == 0 {
+		f.Println("No tenants. Add one with `tenants add <name>`.")
+		return
+	}
+
+	for _, name := range names {
+		b, err := f.tenants.GetTenantBridge(name)
+		if err != nil {
+			continue
+		}
+
+		selected := ""
+
+		if name == f.tenant {
+			selected = " (selected)"
+		}
+
+		f.Printf("%s%s: IMAP port %d, SMTP port %d, %d account(s)\n", bold(name), selected, b.GetIMAPPort(), b.GetSMTPPort(), len(b.GetUserIDs()))
+	}
+}
+
+func (f *frontendCLI) addTenant(c *ishell.Context) {
+	if len(c.Args) != 1 {
+		f.Println("Please specify the name of the tenant.")
+		return
+	}
+
+	if err := f.tenants.AddTenant(context.Background(), c.Args[0]); err != nil {
+		f.printAndLogError("Cannot add tenant:", err)
+		return
+	}
+
+	f.Printf("Tenant %s added. Use `tenants use %s` to add its accounts.\n", bold(c.Args[0]), c.Args[0])
+}
+
+func (f *frontendCLI) removeTenant(c *ishell.Context) {
+	if len(c.Args) != 1 {
+		f.Println("Please specify the name of the tenant.")
+		return
+	}
+
+	name := c.Args[0]
+
+	if !f.yesNoQuestion("Are you sure you want to remove tenant " + bold(name) + " and log out its accounts") {
+		return
+	}
+
+	if name == f.tenant {
+		f.selectTenant("", f.daemon)
+	}
+
+	if err := f.tenants.RemoveTenant(context.Background(), name); err != nil {
+		f.printAndLogError("Cannot remove tenant:", err)
+		return
+	}
+
+	f.Printf("Tenant %s removed.\n", bold(name))
+}
+
+func (f *frontendCLI) useTenant(c *ishell.Context) {
+	if len(c.Args) == 0 {
+		f.selectTenant("", f.daemon)
+		f.Println("Commands now apply to the daemon itself.")
+
+		return
+	}
+
+	b, err := f.tenants.GetTenantBridge(c.Args[0])
+	if err != nil {
+		f.printAndLogError("Cannot select tenant:", err)
+		return
+	}
+
+	f.selectTenant(c.Args[0], b)
+	f.Printf("Commands now apply to tenant %s.\n", bold(c.Args[0]))
+}
+
+// selectTenant makes the following commands apply to the given tenant's bridge.
+func (f *frontendCLI) selectTenant(name string, b *bridge.Bridge) {
+	f.tenant, f.bridge = name, b
+
+	if name == "" {
+		f.SetPrompt(">>> ")
+	} else {
+		f.SetPrompt(name + " >>> ")
+	}
+}
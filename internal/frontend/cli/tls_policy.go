@@ -0,0 +1,182 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"strings"
+
+	"github.com/abiosoft/ishell"
+)
+
+func (f *frontendCLI) showTLSPolicy(_ *ishell.Context) {
+	policy := f.bridge.GetTLSPolicy()
+
+	minVersion := "1.2 (default)"
+	if policy.MinVersion != 0 {
+		minVersion = formatTLSVersion(policy.MinVersion)
+	}
+
+	f.Println("Minimum TLS version:", minVersion)
+
+	if len(policy.CipherSuites) == 0 {
+		f.Println("Cipher suites:       default")
+	} else {
+		f.Println("Cipher suites:")
+
+		for _, id := range policy.CipherSuites {
+			f.Println("   ", tls.CipherSuiteName(id))
+		}
+	}
+
+	if policy.RequireTLS {
+		f.Println("SMTP and POP3 clients must use SSL or STARTTLS before logging in.")
+		f.Println("IMAP logins are still accepted before STARTTLS; set IMAP to SSL to refuse them.")
+	} else {
+		f.Println("Plaintext connections are allowed, to be upgraded with STARTTLS.")
+	}
+}
+
+func (f *frontendCLI) setTLSMinVersion(c *ishell.Context) {
+	if len(c.Args) != 1 {
+		f.Println("Please specify the minimum TLS version: 1.0, 1.1, 1.2 or 1.3.")
+		return
+	}
+
+	version, err := parseTLSVersion(c.Args[0])
+	if err != nil {
+		f.printAndLogError(err)
+		return
+	}
+
+	policy := f.bridge.GetTLSPolicy()
+	policy.MinVersion = version
+
+	if err := f.bridge.SetTLSPolicy(context.Background(), policy); err != nil {
+		f.printAndLogError("Cannot change the TLS policy:", err)
+		return
+	}
+
+	f.Println("New connections must use TLS", formatTLSVersion(version), "or above.")
+}
+
+func (f *frontendCLI) setTLSCipherSuites(c *ishell.Context) {
+	var suites []uint16
+
+	for _, arg := range c.Args {
+		id, err := parseCipherSuite(arg)
+		if err != nil {
+			f.printAndLogError(err)
+			return
+		}
+
+		suites = append(suites, id)
+	}
+
+	policy := f.bridge.GetTLSPolicy()
+	policy.CipherSuites = suites
+
+	if err := f.bridge.SetTLSPolicy(context.Background(), policy); err != nil {
+		f.printAndLogError("Cannot change the TLS policy:", err)
+		return
+	}
+
+	if len(suites) == 0 {
+		f.Println("New connections may use the default cipher suites.")
+	} else {
+		f.Println("New connections must use one of the given cipher suites, or TLS 1.3.")
+	}
+}
+
+func (f *frontendCLI) setTLSRequired(required bool) func(*ishell.Context) {
+	return func(_ *ishell.Context) {
+		policy := f.bridge.GetTLSPolicy()
+
+		if policy.RequireTLS == required {
+			f.Println("The TLS policy is unchanged.")
+			return
+		}
+
+		if required {
+			f.Println("This doesn't cover IMAP: IMAP clients can still log in before STARTTLS unless the IMAP security is set to SSL.")
+		}
+
+		if required && !f.yesNoQuestion("SMTP and POP3 clients set up without encryption will no longer be able to log in. Are you sure you want to require TLS") {
+			return
+		}
+
+		policy.RequireTLS = required
+
+		if err := f.bridge.SetTLSPolicy(context.Background(), policy); err != nil {
+			f.printAndLogError("Cannot change the TLS policy:", err)
+			return
+		}
+
+		f.showTLSPolicy(nil)
+	}
+}
+
+// listCipherSuites prints the cipher suites which can be allowed.
+func (f *frontendCLI) listCipherSuites(_ *ishell.Context) {
+	for _, suite := range tls.CipherSuites() {
+		if suite.SupportedVersions[0] < tls.VersionTLS13 {
+			f.Println(suite.Name)
+		}
+	}
+}
+
+func parseTLSVersion(val string) (uint16, error) {
+	for _, version := range []uint16{tls.VersionTLS10, tls.VersionTLS11, tls.VersionTLS12, tls.VersionTLS13} {
+		if strings.TrimPrefix(strings.TrimSpace(val), "TLS") == formatTLSVersion(version) {
+			return version, nil
+		}
+	}
+
+	return 0, fmt.Errorf("invalid TLS version %q, expected 1.0, 1.1, 1.2 or 1.3", val)
+}
+
+func formatTLSVersion(version uint16) string {
+	switch version {
+	case tls.VersionTLS10:
+		return "1.0"
+
+	case tls.VersionTLS11:
+		return "1.1"
+
+	case tls.VersionTLS12:
+		return "1.2"
+
+	case tls.VersionTLS13:
+		return "1.3"
+
+	default:
+		return fmt.Sprintf("%#04x", version)
+	}
+}
+
+func parseCipherSuite(name string) (uint16, error) {
+	for _, suite := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+		if strings.EqualFold(suite.Name, name) {
+			return suite.ID, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown cipher suite %q, see `tls-policy cipher-suites list`", name)
+}
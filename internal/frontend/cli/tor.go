@@ -0,0 +1,87 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"strings"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/dialer"
+	"github.com/abiosoft/ishell"
+)
+
+func (f *frontendCLI) showTor(_ *ishell.Context) {
+	settings := f.bridge.GetTorSettings()
+
+	f.Println("Status:       ", f.bridge.GetTorStatus())
+
+	if settings.SOCKSAddress != "" {
+		f.Println("SOCKS address:", settings.SOCKSAddress)
+	} else {
+		f.Println("SOCKS address:", dialer.DefaultTorSOCKSAddress, "(default)")
+	}
+
+	if settings.OnionHost != "" {
+		f.Println("Onion host:   ", settings.OnionHost)
+	} else {
+		f.Println("Onion host:    none, the API host is reached through an exit node")
+	}
+}
+
+func (f *frontendCLI) enableTor(_ *ishell.Context) {
+	f.Println("The traffic to Proton will be routed through the local Tor SOCKS proxy, which must be running.")
+	f.Println("Alternative routing is not used while Tor is enabled.")
+
+	if !f.yesNoQuestion("Are you sure you want to route the traffic through Tor") {
+		return
+	}
+
+	if err := f.bridge.SetTorEnabled(true); err != nil {
+		f.printAndLogError(err)
+	}
+}
+
+func (f *frontendCLI) disableTor(_ *ishell.Context) {
+	if err := f.bridge.SetTorEnabled(false); err != nil {
+		f.printAndLogError(err)
+		return
+	}
+
+	f.Println("The traffic to Proton is no longer routed through Tor.")
+}
+
+func (f *frontendCLI) changeTorSOCKSAddress(c *ishell.Context) {
+	f.Print("Enter the address of the Tor SOCKS proxy, e.g. 127.0.0.1:9150 (leave empty for the default): ")
+
+	if err := f.bridge.SetTorSOCKSAddress(strings.TrimSpace(c.ReadLine())); err != nil {
+		f.printAndLogError(err)
+	}
+}
+
+func (f *frontendCLI) changeTorOnionHost(c *ishell.Context) {
+	f.Print("Enter the onion service to use instead of the API host (leave empty to use none): ")
+
+	if err := f.bridge.SetTorOnionHost(strings.TrimSpace(c.ReadLine())); err != nil {
+		f.printAndLogError(err)
+	}
+}
+
+func (f *frontendCLI) checkTor(_ *ishell.Context) {
+	f.Println("Checking the Tor circuit...")
+
+	f.bridge.CheckTorCircuit()
+}
@@ -18,6 +18,8 @@
 package cli
 
 import (
+	"time"
+
 	"github.com/ProtonMail/proton-bridge/v3/internal/events"
 	"github.com/ProtonMail/proton-bridge/v3/internal/updater"
 	"github.com/abiosoft/ishell"
@@ -102,3 +104,27 @@ func (f *frontendCLI) selectStableChannel(_ *ishell.Context) {
 		}
 	}
 }
+
+func (f *frontendCLI) showUpdateVerification(_ *ishell.Context) {
+	verification, err := f.bridge.GetUpdateVerification()
+	if err != nil {
+		f.Println("The running version was not installed by the updater, so there is no verification to show.")
+		return
+	}
+
+	f.Println("Version:        ", verification.Version)
+	f.Println("Package SHA-256:", verification.PackageSHA256)
+	f.Println("Signed by:      ", verification.SignedBy)
+
+	if verification.LogID != "" {
+		f.Println("Built by:       ", verification.Builder, "at", verification.BuildTime.Format(time.RFC3339))
+		f.Println("Source:         ", verification.SourceRepo, "@", verification.SourceRevision)
+		f.Println("Logged in:      ", verification.LogID, "entry", verification.LogIndex, "of", verification.LogTreeSize)
+		f.Println("Log root hash:  ", verification.LogRootHash)
+		f.Println("Log signed by:  ", verification.LogSignedBy)
+	} else {
+		f.Println("Provenance:      not checked (bridge was not started with --update-log-key)")
+	}
+
+	f.Println("Verified at:    ", verification.VerifiedAt.Format(time.RFC3339))
+}
@@ -18,8 +18,11 @@
 package cli
 
 import (
+	"fmt"
 	"strings"
+	"time"
 
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
 	"github.com/fatih/color"
 )
 
@@ -110,3 +113,60 @@ Recommendation:
   a different network to access Proton Mail.
 `)
 }
+
+// splitList splits a comma separated list, dropping empty items.
+func splitList(val string) []string {
+	var items []string
+
+	for _, item := range strings.Split(val, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+// parseQuietHours parses a period such as 22:00-07:00. An empty period disables quiet hours.
+func parseQuietHours(val string) (vault.QuietHours, error) {
+	if val = strings.TrimSpace(val); val == "" {
+		return vault.QuietHours{}, nil
+	}
+
+	start, end, ok := strings.Cut(val, "-")
+	if !ok {
+		return vault.QuietHours{}, fmt.Errorf("invalid quiet hours %q", val)
+	}
+
+	startTime, err := time.Parse("15:04", strings.TrimSpace(start))
+	if err != nil {
+		return vault.QuietHours{}, fmt.Errorf("invalid start of quiet hours: %w", err)
+	}
+
+	endTime, err := time.Parse("15:04", strings.TrimSpace(end))
+	if err != nil {
+		return vault.QuietHours{}, fmt.Errorf("invalid end of quiet hours: %w", err)
+	}
+
+	return vault.QuietHours{
+		Enabled: true,
+		Start:   time.Duration(startTime.Hour())*time.Hour + time.Duration(startTime.Minute())*time.Minute,
+		End:     time.Duration(endTime.Hour())*time.Hour + time.Duration(endTime.Minute())*time.Minute,
+	}, nil
+}
+
+// formatTimeOfDay formats an offset from midnight as 15:04.
+func formatTimeOfDay(offset time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(offset.Hours()), int(offset.Minutes())%60)
+}
+
+// parseAccessRole parses the name of a role of the gRPC service's clients, e.g. operator.
+func parseAccessRole(val string) (vault.AccessRole, error) {
+	for _, role := range []vault.AccessRole{vault.AccessRoleViewer, vault.AccessRoleOperator, vault.AccessRoleAdmin} {
+		if strings.EqualFold(strings.TrimSpace(val), role.String()) {
+			return role, nil
+		}
+	}
+
+	return 0, fmt.Errorf("invalid role %q, expected viewer, operator or admin", val)
+}
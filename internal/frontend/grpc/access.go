@@ -0,0 +1,224 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package grpc
+
+import (
+	"context"
+	"errors"
+	"path"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+	"github.com/sirupsen/logrus"
+	codes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	status "google.golang.org/grpc/status"
+)
+
+// grantLookup returns the access grant of the given token, if it is valid.
+type grantLookup func(token string) (vault.AccessGrant, bool)
+
+// pairFunc exchanges a pairing code for a token, returned along with the grant it stands for.
+type pairFunc func(code, name string) (vault.AccessGrant, string, error)
+
+const (
+	// pairingCodeMetadataKey and clientNameMetadataKey are sent by frontends to pair with bridge.
+	// The token is returned in the pairedTokenMetadataKey header of the response; it is sent as server token afterwards.
+	pairingCodeMetadataKey = "pairing-code"
+	clientNameMetadataKey  = "client-name"
+	pairedTokenMetadataKey = "paired-token"
+)
+
+// guiGrantName is the name under which the calls authenticated with the server token of the GUI are reported.
+const guiGrantName = "gui"
+
+// methodRoles is the minimum role needed to call each method of the service.
+// Methods which are not listed, including ones added later, need the admin role.
+var methodRoles = map[string]vault.AccessRole{ //nolint:gochecknoglobals
+	"AvailableKeychains":        vault.AccessRoleViewer,
+	"CheckTokens":               vault.AccessRoleViewer,
+	"ColorSchemeName":           vault.AccessRoleViewer,
+	"CurrentEmailClient":        vault.AccessRoleViewer,
+	"CurrentKeychain":           vault.AccessRoleViewer,
+	"DependencyLicensesLink":    vault.AccessRoleViewer,
+	"DiskCachePath":             vault.AccessRoleViewer,
+	"GetUser":                   vault.AccessRoleViewer,
+	"GetUserList":               vault.AccessRoleViewer,
+	"GoOs":                      vault.AccessRoleViewer,
+	"Hostname":                  vault.AccessRoleViewer,
+	"IsAllMailVisible":          vault.AccessRoleViewer,
+	"IsAutomaticUpdateOn":       vault.AccessRoleViewer,
+	"IsAutostartOn":             vault.AccessRoleViewer,
+	"IsBetaEnabled":             vault.AccessRoleViewer,
+	"IsDoHEnabled":              vault.AccessRoleViewer,
+	"IsPortFree":                vault.AccessRoleViewer,
+	"IsTLSCertificateInstalled": vault.AccessRoleViewer,
+	"IsTelemetryDisabled":       vault.AccessRoleViewer,
+	"LandingPageLink":           vault.AccessRoleViewer,
+	"LicensePath":               vault.AccessRoleViewer,
+	"LogsPath":                  vault.AccessRoleViewer,
+	"MailServerSettings":        vault.AccessRoleViewer,
+	"ReleaseNotesPageLink":      vault.AccessRoleViewer,
+	"ShowOnStartup":             vault.AccessRoleViewer,
+	"Version":                   vault.AccessRoleViewer,
+
+	// There is a single event stream; only clients which may act on the events should hold it.
+	"RunEventStream":  vault.AccessRoleOperator,
+	"StopEventStream": vault.AccessRoleOperator,
+
+	"AddLogEntry":              vault.AccessRoleOperator,
+	"AutoconfigClicked":        vault.AccessRoleOperator,
+	"CheckUpdate":              vault.AccessRoleOperator,
+	"ConfigureUserAppleMail":   vault.AccessRoleOperator,
+	"GuiReady":                 vault.AccessRoleOperator,
+	"InstallUpdate":            vault.AccessRoleOperator,
+	"KBArticleClicked":         vault.AccessRoleOperator,
+	"Login":                    vault.AccessRoleOperator,
+	"Login2FA":                 vault.AccessRoleOperator,
+	"Login2Passwords":          vault.AccessRoleOperator,
+	"LoginAbort":               vault.AccessRoleOperator,
+	"LogoutUser":               vault.AccessRoleOperator,
+	"ReportBug":                vault.AccessRoleOperator,
+	"ReportBugClicked":         vault.AccessRoleOperator,
+	"SendBadEventUserFeedback": vault.AccessRoleOperator,
+	"SetColorSchemeName":       vault.AccessRoleOperator,
+	"SetIsAllMailVisible":      vault.AccessRoleOperator,
+	"SetIsAutomaticUpdateOn":   vault.AccessRoleOperator,
+	"SetIsBetaEnabled":         vault.AccessRoleOperator,
+	"SetIsDoHEnabled":          vault.AccessRoleOperator,
+	"SetIsTelemetryDisabled":   vault.AccessRoleOperator,
+	"SetUserSplitMode":         vault.AccessRoleOperator,
+}
+
+// getMethodRole returns the minimum role needed to call the method with the given full name, e.g. /grpc.Bridge/Quit.
+func getMethodRole(fullMethod string) vault.AccessRole {
+	if role, ok := methodRoles[path.Base(fullMethod)]; ok {
+		return role
+	}
+
+	return vault.AccessRoleAdmin
+}
+
+// authorize checks that the given grant may call the method with the given full name.
+func authorize(grant vault.AccessGrant, fullMethod string) error {
+	if want := getMethodRole(fullMethod); grant.Role < want {
+		logrus.WithFields(logrus.Fields{
+			"grantID": grant.ID,
+			"name":    grant.Name,
+			"role":    grant.Role,
+			"method":  fullMethod,
+		}).Warn("Denied gRPC call")
+
+		return status.Errorf(codes.PermissionDenied, "the %v role is needed to call %v", want, path.Base(fullMethod))
+	}
+
+	return nil
+}
+
+// grantContextKey is the context key under which the grant of the client of a call is stored.
+type grantContextKey struct{}
+
+// newContextWithGrant returns a context carrying the grant of the client of a call.
+func newContextWithGrant(ctx context.Context, grant vault.AccessGrant) context.Context {
+	return context.WithValue(ctx, grantContextKey{}, grant)
+}
+
+// grantFromContext returns the grant of the client of a call; ok is false if the call was not authenticated.
+func grantFromContext(ctx context.Context) (vault.AccessGrant, bool) {
+	grant, ok := ctx.Value(grantContextKey{}).(vault.AccessGrant)
+
+	return grant, ok
+}
+
+// grpcUserForCaller converts a bridge user to a gRPC user as seen by the client of a call.
+// A bridge password gives full IMAP/SMTP access to its account, so it is left out for clients other than admins.
+func grpcUserForCaller(ctx context.Context, user bridge.UserInfo) *User {
+	grpcUser := grpcUserFromInfo(user)
+
+	if grant, ok := grantFromContext(ctx); !ok || grant.Role < vault.AccessRoleAdmin {
+		grpcUser.Password = nil
+	}
+
+	return grpcUser
+}
+
+// newGrantLookup returns a lookup of both the access grants and the paired clients of the given bridge.
+func newGrantLookup(b *bridge.Bridge) grantLookup {
+	return func(token string) (vault.AccessGrant, bool) {
+		if grant, ok := b.GetAccessGrantByToken(token); ok {
+			return grant, true
+		}
+
+		if client, ok := b.GetPairedClientByToken(token); ok {
+			return pairedClientGrant(client), true
+		}
+
+		return vault.AccessGrant{}, false
+	}
+}
+
+// newPairFunc returns a pairFunc pairing clients with the given bridge.
+func newPairFunc(b *bridge.Bridge) pairFunc {
+	return func(code, name string) (vault.AccessGrant, string, error) {
+		client, token, err := b.Pair(code, name)
+		if err != nil {
+			return vault.AccessGrant{}, "", err
+		}
+
+		return pairedClientGrant(client), token, nil
+	}
+}
+
+func pairedClientGrant(client vault.PairedClient) vault.AccessGrant {
+	return vault.AccessGrant{
+		ID:        client.ID,
+		Name:      client.Name,
+		Role:      client.Role,
+		CreatedAt: client.PairedAt,
+	}
+}
+
+// pairClient exchanges the pairing code of the call, if any, for a token.
+// It returns the grant of the new client and the header carrying its token; ok is false if the call has no pairing code.
+func pairClient(ctx context.Context, pair pairFunc) (grant vault.AccessGrant, header metadata.MD, ok bool, err error) {
+	values, _ := metadata.FromIncomingContext(ctx)
+
+	code := values.Get(pairingCodeMetadataKey)
+	if len(code) == 0 {
+		return vault.AccessGrant{}, nil, false, nil
+	}
+
+	if len(code) > 1 {
+		return vault.AccessGrant{}, nil, true, status.Error(codes.Unauthenticated, "more than one pairing code was provided")
+	}
+
+	var name string
+
+	if names := values.Get(clientNameMetadataKey); len(names) > 0 {
+		name = names[0]
+	}
+
+	grant, token, err := pair(code[0], name)
+	if errors.Is(err, bridge.ErrPairingRateLimited) {
+		return vault.AccessGrant{}, nil, true, status.Error(codes.ResourceExhausted, err.Error())
+	} else if err != nil {
+		return vault.AccessGrant{}, nil, true, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	return grant, metadata.Pairs(pairedTokenMetadataKey, token), true, nil
+}
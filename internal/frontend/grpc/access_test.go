@@ -0,0 +1,163 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	status "google.golang.org/grpc/status"
+)
+
+func TestGetMethodRole(t *testing.T) {
+	require.Equal(t, vault.AccessRoleViewer, getMethodRole("/grpc.Bridge/GetUserList"))
+	require.Equal(t, vault.AccessRoleOperator, getMethodRole("/grpc.Bridge/Login"))
+	require.Equal(t, vault.AccessRoleAdmin, getMethodRole("/grpc.Bridge/TriggerReset"))
+	require.Equal(t, vault.AccessRoleAdmin, getMethodRole("/grpc.Bridge/SomeFutureMethod"))
+}
+
+func TestAuthorize(t *testing.T) {
+	viewer := vault.AccessGrant{Name: "viewer", Role: vault.AccessRoleViewer}
+	admin := vault.AccessGrant{Name: "admin", Role: vault.AccessRoleAdmin}
+
+	require.NoError(t, authorize(viewer, "/grpc.Bridge/GetUserList"))
+	require.Equal(t, codes.PermissionDenied, status.Code(authorize(viewer, "/grpc.Bridge/LogoutUser")))
+	require.Equal(t, codes.PermissionDenied, status.Code(authorize(viewer, "/grpc.Bridge/Quit")))
+
+	require.NoError(t, authorize(admin, "/grpc.Bridge/LogoutUser"))
+	require.NoError(t, authorize(admin, "/grpc.Bridge/Quit"))
+}
+
+func TestValidateServerToken(t *testing.T) {
+	lookup := func(token string) (vault.AccessGrant, bool) {
+		if token != "viewer-token" {
+			return vault.AccessGrant{}, false
+		}
+
+		return vault.AccessGrant{Name: "viewer", Role: vault.AccessRoleViewer}, true
+	}
+
+	withToken := func(token string) context.Context {
+		return metadata.NewIncomingContext(context.Background(), metadata.Pairs(serverTokenMetadataKey, token))
+	}
+
+	// The token of the GUI is an admin.
+	grant, err := validateServerToken(withToken("gui-token"), "gui-token", lookup)
+	require.NoError(t, err)
+	require.Equal(t, vault.AccessRoleAdmin, grant.Role)
+
+	// Other tokens have the role they were granted.
+	grant, err = validateServerToken(withToken("viewer-token"), "gui-token", lookup)
+	require.NoError(t, err)
+	require.Equal(t, vault.AccessRoleViewer, grant.Role)
+
+	// Unknown tokens are rejected.
+	_, err = validateServerToken(withToken("other-token"), "gui-token", lookup)
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// So are calls without a token.
+	_, err = validateServerToken(context.Background(), "gui-token", lookup)
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestPairClient(t *testing.T) {
+	pair := func(code, name string) (vault.AccessGrant, string, error) {
+		switch code {
+		case "12345678":
+			return vault.AccessGrant{Name: name, Role: vault.AccessRoleOperator}, "paired-token", nil
+
+		case "too-fast":
+			return vault.AccessGrant{}, "", bridge.ErrPairingRateLimited
+
+		default:
+			return vault.AccessGrant{}, "", bridge.ErrInvalidPairingCode
+		}
+	}
+
+	withCode := func(code string) context.Context {
+		return metadata.NewIncomingContext(context.Background(), metadata.Pairs(pairingCodeMetadataKey, code, clientNameMetadataKey, "dashboard"))
+	}
+
+	// Calls without a pairing code are left to the server token.
+	_, _, ok, err := pairClient(context.Background(), pair)
+	require.False(t, ok)
+	require.NoError(t, err)
+
+	// The token of the new client is returned in the header.
+	grant, header, ok, err := pairClient(withCode("12345678"), pair)
+	require.True(t, ok)
+	require.NoError(t, err)
+	require.Equal(t, "dashboard", grant.Name)
+	require.Equal(t, vault.AccessRoleOperator, grant.Role)
+	require.Equal(t, []string{"paired-token"}, header.Get(pairedTokenMetadataKey))
+
+	_, _, _, err = pairClient(withCode("87654321"), pair)
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, _, _, err = pairClient(withCode("too-fast"), pair)
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+func TestGrpcUserForCaller(t *testing.T) {
+	lookup := func(token string) (vault.AccessGrant, bool) {
+		switch token {
+		case "viewer-token":
+			return vault.AccessGrant{Name: "viewer", Role: vault.AccessRoleViewer}, true
+
+		case "operator-token":
+			return vault.AccessGrant{Name: "operator", Role: vault.AccessRoleOperator}, true
+
+		default:
+			return vault.AccessGrant{}, false
+		}
+	}
+
+	pair := func(string, string) (vault.AccessGrant, string, error) {
+		return vault.AccessGrant{}, "", bridge.ErrInvalidPairingCode
+	}
+
+	info := bridge.UserInfo{UserID: "user-id", Username: "user", BridgePass: []byte("bridge-pass")}
+
+	getUser := func(token string) *User {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(serverTokenMetadataKey, token))
+		method := &grpc.UnaryServerInfo{FullMethod: "/grpc.Bridge/GetUser"}
+
+		res, err := newUnaryTokenValidator("gui-token", lookup, pair)(ctx, nil, method, func(ctx context.Context, _ interface{}) (interface{}, error) {
+			return grpcUserForCaller(ctx, info), nil
+		})
+		require.NoError(t, err)
+
+		return res.(*User) //nolint:forcetypeassert
+	}
+
+	// Viewers and operators don't get the bridge password.
+	require.Empty(t, getUser("viewer-token").Password)
+	require.Empty(t, getUser("operator-token").Password)
+
+	// Admins do.
+	require.Equal(t, []byte("bridge-pass"), getUser("gui-token").Password)
+
+	// Calls which didn't go through the token validator don't either.
+	require.Empty(t, grpcUserForCaller(context.Background(), info).Password)
+}
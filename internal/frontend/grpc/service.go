@@ -38,9 +38,11 @@ import (
 	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
 	"github.com/ProtonMail/proton-bridge/v3/internal/certs"
 	"github.com/ProtonMail/proton-bridge/v3/internal/events"
+	"github.com/ProtonMail/proton-bridge/v3/internal/network"
 	"github.com/ProtonMail/proton-bridge/v3/internal/safe"
 	"github.com/ProtonMail/proton-bridge/v3/internal/service"
 	"github.com/ProtonMail/proton-bridge/v3/internal/updater"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
 	"github.com/bradenaw/juniper/xslices"
 	"github.com/elastic/go-sysinfo"
 	sysinfotypes "github.com/elastic/go-sysinfo/types"
@@ -116,8 +118,11 @@ func NewService(
 		Token: uuid.NewString(),
 	}
 
+	// Frontends of other devices of the network connect over TCP to all interfaces.
+	lanAccess, _ := bridge.GetLANAccess()
+
 	var listener net.Listener
-	if useFileSocket() {
+	if useFileSocket() && !lanAccess {
 		var err error
 		if config.FileSocketPath, err = computeFileSocketPath(); err != nil {
 			logrus.WithError(err).WithError(err).Panic("Could not create gRPC file socket")
@@ -129,11 +134,20 @@ func NewService(
 		}
 	} else {
 		var err error
-		listener, err = net.Listen("tcp", "127.0.0.1:0") // Port should be provided by the OS.
+		host := "127.0.0.1"
+		if lanAccess {
+			host = ""
+		}
+
+		listener, err = net.Listen("tcp", net.JoinHostPort(host, "0")) // Port should be provided by the OS.
 		if err != nil {
 			logrus.WithError(err).Panic("Could not create gRPC listener")
 		}
 
+		listener = network.NewFilterListener(listener, func(addr net.Addr) bool {
+			return bridge.AllowClient("gRPC", addr)
+		})
+
 		// retrieve the port assigned by the system, so that we can put it in the config file.
 		address, ok := listener.Addr().(*net.TCPAddr)
 		if !ok {
@@ -151,8 +165,8 @@ func NewService(
 	s := &Service{
 		grpcServer: grpc.NewServer(
 			grpc.Creds(credentials.NewTLS(tlsConfig)),
-			grpc.UnaryInterceptor(newUnaryTokenValidator(config.Token)),
-			grpc.StreamInterceptor(newStreamTokenValidator(config.Token)),
+			grpc.UnaryInterceptor(newUnaryTokenValidator(config.Token, newGrantLookup(bridge), newPairFunc(bridge))),
+			grpc.StreamInterceptor(newStreamTokenValidator(config.Token, newGrantLookup(bridge), newPairFunc(bridge))),
 		),
 		listener: listener,
 
@@ -505,44 +519,83 @@ func newTLSConfig() (*tls.Config, []byte, error) {
 	}, certPEM, nil
 }
 
-// validateServerToken verify that the server token provided by the client is valid.
-func validateServerToken(ctx context.Context, wantToken string) error {
+// validateServerToken verify that the server token provided by the client is valid, and returns the grant it stands for.
+// The token of the GUI is granted the admin role; other tokens must have been granted a role by the user.
+func validateServerToken(ctx context.Context, wantToken string, lookup grantLookup) (vault.AccessGrant, error) {
 	values, ok := metadata.FromIncomingContext(ctx)
 	if !ok {
-		return status.Error(codes.Unauthenticated, "missing server token")
+		return vault.AccessGrant{}, status.Error(codes.Unauthenticated, "missing server token")
 	}
 
 	token := values.Get(serverTokenMetadataKey)
 	if len(token) == 0 {
-		return status.Error(codes.Unauthenticated, "missing server token")
+		return vault.AccessGrant{}, status.Error(codes.Unauthenticated, "missing server token")
 	}
 
 	if len(token) > 1 {
-		return status.Error(codes.Unauthenticated, "more than one server token was provided")
+		return vault.AccessGrant{}, status.Error(codes.Unauthenticated, "more than one server token was provided")
 	}
 
-	if token[0] != wantToken {
-		return status.Error(codes.Unauthenticated, "invalid server token")
+	if token[0] == wantToken {
+		return vault.AccessGrant{Name: guiGrantName, Role: vault.AccessRoleAdmin}, nil
 	}
 
-	return nil
+	if grant, ok := lookup(token[0]); ok {
+		return grant, nil
+	}
+
+	return vault.AccessGrant{}, status.Error(codes.Unauthenticated, "invalid server token")
 }
 
-// newUnaryTokenValidator checks the server token for every unary gRPC call.
-func newUnaryTokenValidator(wantToken string) grpc.UnaryServerInterceptor {
+// authenticate returns the grant of the client of a call, pairing it first if the call carries a pairing code.
+// The returned header, if any, must be sent to the client.
+func authenticate(ctx context.Context, wantToken string, lookup grantLookup, pair pairFunc) (vault.AccessGrant, metadata.MD, error) {
+	if grant, header, ok, err := pairClient(ctx, pair); ok {
+		return grant, header, err
+	}
+
+	grant, err := validateServerToken(ctx, wantToken, lookup)
+
+	return grant, nil, err
+}
+
+// newUnaryTokenValidator checks the server token, or pairs the client, and its role for every unary gRPC call.
+func newUnaryTokenValidator(wantToken string, lookup grantLookup, pair pairFunc) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
-		if err := validateServerToken(ctx, wantToken); err != nil {
+		grant, header, err := authenticate(ctx, wantToken, lookup, pair)
+		if err != nil {
 			return nil, err
 		}
 
-		return handler(ctx, req)
+		if header != nil {
+			if err := grpc.SetHeader(ctx, header); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := authorize(grant, info.FullMethod); err != nil {
+			return nil, err
+		}
+
+		return handler(newContextWithGrant(ctx, grant), req)
 	}
 }
 
-// newStreamTokenValidator checks the server token for every gRPC stream request.
-func newStreamTokenValidator(wantToken string) grpc.StreamServerInterceptor {
+// newStreamTokenValidator checks the server token, or pairs the client, and its role for every gRPC stream request.
+func newStreamTokenValidator(wantToken string, lookup grantLookup, pair pairFunc) grpc.StreamServerInterceptor {
 	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
-		if err := validateServerToken(stream.Context(), wantToken); err != nil {
+		grant, header, err := authenticate(stream.Context(), wantToken, lookup, pair)
+		if err != nil {
+			return err
+		}
+
+		if header != nil {
+			if err := stream.SetHeader(header); err != nil {
+				return err
+			}
+		}
+
+		if err := authorize(grant, info.FullMethod); err != nil {
 			return err
 		}
 
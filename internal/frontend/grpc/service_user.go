@@ -28,7 +28,7 @@ import (
 	"google.golang.org/protobuf/types/known/wrapperspb"
 )
 
-func (s *Service) GetUserList(_ context.Context, _ *emptypb.Empty) (*UserListResponse, error) {
+func (s *Service) GetUserList(ctx context.Context, _ *emptypb.Empty) (*UserListResponse, error) {
 	s.log.Debug("GetUserList")
 
 	userIDs := s.bridge.GetUserIDs()
@@ -40,7 +40,7 @@ func (s *Service) GetUserList(_ context.Context, _ *emptypb.Empty) (*UserListRes
 			return nil, err
 		}
 
-		userList[idx] = grpcUserFromInfo(user)
+		userList[idx] = grpcUserForCaller(ctx, user)
 	}
 
 	// If there are no active accounts.
@@ -51,7 +51,7 @@ func (s *Service) GetUserList(_ context.Context, _ *emptypb.Empty) (*UserListRes
 	return &UserListResponse{Users: userList}, nil
 }
 
-func (s *Service) GetUser(_ context.Context, userID *wrapperspb.StringValue) (*User, error) {
+func (s *Service) GetUser(ctx context.Context, userID *wrapperspb.StringValue) (*User, error) {
 	s.log.WithField("userID", userID).Debug("GetUser")
 
 	user, err := s.bridge.GetUserInfo(userID.Value)
@@ -59,7 +59,7 @@ func (s *Service) GetUser(_ context.Context, userID *wrapperspb.StringValue) (*U
 		return nil, status.Errorf(codes.NotFound, "user not found %v", userID.Value)
 	}
 
-	return grpcUserFromInfo(user), nil
+	return grpcUserForCaller(ctx, user), nil
 }
 
 func (s *Service) SetUserSplitMode(_ context.Context, splitMode *UserSplitModeRequest) (*emptypb.Empty, error) {
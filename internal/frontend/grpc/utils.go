@@ -60,10 +60,15 @@ func getInitials(fullName string) string {
 
 // grpcUserFromInfo converts a bridge user to a gRPC user.
 func grpcUserFromInfo(user bridge.UserInfo) *User {
+	avatarText := user.Display.Initials
+	if avatarText == "" {
+		avatarText = getInitials(user.Username)
+	}
+
 	return &User{
 		Id:         user.UserID,
 		Username:   user.Username,
-		AvatarText: getInitials(user.Username),
+		AvatarText: avatarText,
 		State:      userStateToGrpc(user.State),
 		SplitMode:  user.AddressMode == vault.SplitMode,
 		UsedBytes:  int64(user.UsedSpace),
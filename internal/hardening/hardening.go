@@ -0,0 +1,55 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+// Package hardening restricts what the process can do once it is initialized, to reduce the impact of
+// a bug exploited through untrusted input (e.g. a parsing bug in a message or an IMAP command).
+//
+// The seccomp filter is synchronized to all threads by the kernel. Landlock and capabilities, however, are set per
+// thread, and the Go runtime can only apply them to all threads when no thread was created by C code, i.e. in
+// builds without cgo. In builds with cgo, Apply reports ErrNeedsNoCgo for them and only the seccomp filter,
+// along with the no_new_privs flag it sets, is in place.
+package hardening
+
+import (
+	"errors"
+)
+
+var (
+	ErrUnsupported = errors.New("not supported on this platform")
+	ErrNeedsNoCgo  = errors.New("restricting all threads is not supported in builds with cgo")
+)
+
+// Config describes what the process still needs once hardened.
+type Config struct {
+	// WritablePaths are the directories the process may read and write.
+	WritablePaths []string
+
+	// ReadOnlyPaths are the directories the process may read and execute from.
+	ReadOnlyPaths []string
+}
+
+// Status reports which restrictions were applied; a nil error means the restriction is in place.
+type Status struct {
+	// Capabilities is the outcome of dropping all capabilities.
+	Capabilities error
+
+	// Landlock is the outcome of restricting filesystem access to the configured paths.
+	Landlock error
+
+	// Seccomp is the outcome of denying system calls bridge never needs.
+	Seccomp error
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package hardening
+
+import (
+	"errors"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// Apply drops all capabilities, restricts filesystem access to the configured paths with Landlock and installs
+// a seccomp filter denying system calls bridge never needs. Each restriction is applied independently, as far as
+// the kernel and the build allow. The restrictions apply to the whole process and are inherited by its children.
+func Apply(cfg Config) Status {
+	return Status{
+		Capabilities: dropCapabilities(),
+		Landlock:     applyLandlock(cfg),
+		Seccomp:      applySeccomp(),
+	}
+}
+
+// DefaultReadOnlyPaths returns the system directories holding the libraries, certificates, configuration
+// and helpers (e.g. the pass keychain helper) the process may still need.
+func DefaultReadOnlyPaths() []string {
+	return []string{"/usr", "/lib", "/lib32", "/lib64", "/bin", "/sbin", "/etc", "/opt", "/proc", "/sys", "/run", "/var/lib", "/nix", "/snap"}
+}
+
+// dropCapabilities clears the capability sets of all threads. Bridge runs unprivileged, so there is usually nothing to drop.
+func dropCapabilities() error {
+	hdr := unix.CapUserHeader{Version: unix.LINUX_CAPABILITY_VERSION_3}
+
+	var data [2]unix.CapUserData
+
+	if err := unix.Capget(&hdr, &data[0]); err != nil {
+		return err
+	}
+
+	if data == [2]unix.CapUserData{} {
+		return nil
+	}
+
+	data = [2]unix.CapUserData{}
+
+	return allThreadsSyscall(unix.SYS_CAPSET, uintptr(unsafe.Pointer(&hdr)), uintptr(unsafe.Pointer(&data[0])), 0)
+}
+
+// allThreadsSyscall runs the given system call on all threads of the process.
+// The Go runtime can't do so when threads may have been created by C code.
+func allThreadsSyscall(trap, a1, a2, a3 uintptr) error {
+	if _, _, errno := syscall.AllThreadsSyscall(trap, a1, a2, a3); errno != 0 {
+		if errors.Is(errno, syscall.ENOTSUP) {
+			return ErrNeedsNoCgo
+		}
+
+		return errno
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package hardening
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+const hardenedDirEnv = "HARDENING_TEST_DIR"
+
+// TestApply hardens a child test process, as the restrictions can't be lifted once applied.
+func TestApply(t *testing.T) {
+	if os.Getenv(hardenedDirEnv) != "" {
+		t.Skip("Running in the hardened process")
+	}
+
+	allowed, forbidden := t.TempDir(), t.TempDir()
+
+	require.NoError(t, os.WriteFile(filepath.Join(forbidden, "file"), []byte("secret"), 0o600))
+
+	cmd := exec.Command(os.Args[0], "-test.run", "^TestApply_Hardened$", "-test.v") //nolint:gosec
+	cmd.Env = append(os.Environ(), hardenedDirEnv+"="+allowed, "HARDENING_TEST_FORBIDDEN="+forbidden)
+
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+	require.Contains(t, string(out), "--- PASS: TestApply_Hardened")
+}
+
+func TestApply_Hardened(t *testing.T) {
+	allowed := os.Getenv(hardenedDirEnv)
+	if allowed == "" {
+		t.Skip("Only runs in a hardened child process")
+	}
+
+	// Threads created by C code can't all be restricted by the Go runtime.
+	_, _, errno := syscall.AllThreadsSyscall(unix.SYS_GETPID, 0, 0, 0)
+	cgo := errors.Is(errno, syscall.ENOTSUP)
+
+	status := Apply(Config{WritablePaths: []string{allowed}, ReadOnlyPaths: DefaultReadOnlyPaths()})
+
+	if cgo && status.Capabilities != nil {
+		require.ErrorIs(t, status.Capabilities, ErrNeedsNoCgo)
+	} else {
+		require.NoError(t, status.Capabilities)
+	}
+
+	if !errors.Is(status.Seccomp, ErrUnsupported) {
+		require.NoError(t, status.Seccomp)
+
+		// Denied system calls fail.
+		_, _, errno := unix.Syscall(unix.SYS_PTRACE, unix.PTRACE_TRACEME, 0, 0)
+		require.ErrorIs(t, errno, unix.EPERM)
+	}
+
+	if _, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, 0, 0, unix.LANDLOCK_CREATE_RULESET_VERSION); errno != 0 {
+		t.Log("Landlock is unavailable:", errno)
+		return
+	}
+
+	if cgo {
+		require.ErrorIs(t, status.Landlock, ErrNeedsNoCgo)
+		return
+	}
+
+	require.NoError(t, status.Landlock)
+
+	// Files can be written in the allowed directory...
+	require.NoError(t, os.WriteFile(filepath.Join(allowed, "file"), []byte("data"), 0o600))
+
+	// ... but not read elsewhere.
+	_, err := os.ReadFile(filepath.Join(os.Getenv("HARDENING_TEST_FORBIDDEN"), "file"))
+	require.ErrorIs(t, err, os.ErrPermission)
+}
@@ -0,0 +1,37 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+//go:build linux
+
+package hardening
+
+import "golang.org/x/sys/unix"
+
+const (
+	auditArch = unix.AUDIT_ARCH_X86_64
+
+	// x32 system calls share the x86-64 architecture but have this bit set in their number.
+	hasX32ABI     = true
+	x32SyscallBit = 0x40000000
+)
+
+var archDeniedSyscalls = []uint32{ //nolint:gochecknoglobals
+	unix.SYS_IOPL,
+	unix.SYS_IOPERM,
+	unix.SYS_USELIB,
+	unix.SYS_CREATE_MODULE,
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+//go:build amd64 || arm64
+
+package hardening
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+func TestSeccompFilter(t *testing.T) {
+	filter := newSeccompFilter(auditArch, []uint32{unix.SYS_PTRACE, unix.SYS_MOUNT}, hasX32ABI)
+
+	// Denied system calls fail with EPERM.
+	require.Equal(t, uint32(seccompRetErrno|uint32(unix.EPERM)), runBPF(t, filter, auditArch, unix.SYS_PTRACE))
+	require.Equal(t, uint32(seccompRetErrno|uint32(unix.EPERM)), runBPF(t, filter, auditArch, unix.SYS_MOUNT))
+
+	// Other system calls are allowed.
+	require.Equal(t, uint32(seccompRetAllow), runBPF(t, filter, auditArch, unix.SYS_READ))
+	require.Equal(t, uint32(seccompRetAllow), runBPF(t, filter, auditArch, unix.SYS_CLOSE))
+
+	// System calls made for a foreign architecture kill the process.
+	require.Equal(t, uint32(seccompRetKillProcess), runBPF(t, filter, unix.AUDIT_ARCH_I386, unix.SYS_READ))
+
+	// x32 system calls are denied as they would bypass the filter.
+	if hasX32ABI {
+		require.Equal(t, uint32(seccompRetErrno|uint32(unix.EPERM)), runBPF(t, filter, auditArch, x32SyscallBit|unix.SYS_READ))
+	}
+}
+
+// runBPF evaluates the subset of classic BPF used by the seccomp filter.
+func runBPF(t *testing.T, filter []unix.SockFilter, arch, nr uint32) uint32 {
+	var acc uint32
+
+	for pc := 0; pc < len(filter); pc++ {
+		ins := filter[pc]
+
+		switch ins.Code {
+		case unix.BPF_LD | unix.BPF_W | unix.BPF_ABS:
+			switch ins.K {
+			case seccompDataNr:
+				acc = nr
+			case seccompDataArch:
+				acc = arch
+			default:
+				t.Fatalf("unexpected load offset %d", ins.K)
+			}
+
+		case unix.BPF_JMP | unix.BPF_JEQ | unix.BPF_K:
+			if acc == ins.K {
+				pc += int(ins.Jt)
+			} else {
+				pc += int(ins.Jf)
+			}
+
+		case unix.BPF_JMP | unix.BPF_JGE | unix.BPF_K:
+			if acc >= ins.K {
+				pc += int(ins.Jt)
+			} else {
+				pc += int(ins.Jf)
+			}
+
+		case unix.BPF_RET | unix.BPF_K:
+			return ins.K
+
+		default:
+			t.Fatalf("unexpected instruction %#x", ins.Code)
+		}
+	}
+
+	t.Fatal("the filter did not return")
+
+	return 0
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package indexhook runs a user-configured command (e.g. `notmuch new` or `mu index`)
+// whenever new mail has been written to the local store, so that local mail indexers stay up to date.
+package indexhook
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultDelay is how long the hook waits for further changes before running the command.
+const DefaultDelay = 2 * time.Second
+
+// Hook coalesces triggers and runs the configured command at most once per delay.
+type Hook struct {
+	command     []string
+	commandLock sync.RWMutex
+
+	delay     time.Duration
+	triggerCh chan struct{}
+}
+
+// New returns a new hook which runs the given command line. An empty command disables the hook.
+func New(command string, delay time.Duration) *Hook {
+	return &Hook{
+		command:   strings.Fields(command),
+		delay:     delay,
+		triggerCh: make(chan struct{}, 1),
+	}
+}
+
+// SetCommand changes the command line run by the hook. An empty command disables the hook.
+func (hook *Hook) SetCommand(command string) {
+	hook.commandLock.Lock()
+	defer hook.commandLock.Unlock()
+
+	hook.command = strings.Fields(command)
+}
+
+// Trigger schedules a run of the hook. It never blocks; triggers received in the meantime are coalesced.
+func (hook *Hook) Trigger() {
+	if !hook.isEnabled() {
+		return
+	}
+
+	select {
+	case hook.triggerCh <- struct{}{}:
+	default:
+	}
+}
+
+// Run runs the hook until the context is cancelled.
+func (hook *Hook) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-hook.triggerCh:
+		}
+
+		// Wait a little so that batches of new messages result in a single run.
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-time.After(hook.delay):
+		}
+
+		hook.run(ctx)
+	}
+}
+
+func (hook *Hook) isEnabled() bool {
+	hook.commandLock.RLock()
+	defer hook.commandLock.RUnlock()
+
+	return len(hook.command) > 0
+}
+
+func (hook *Hook) getCommand() []string {
+	hook.commandLock.RLock()
+	defer hook.commandLock.RUnlock()
+
+	return hook.command
+}
+
+func (hook *Hook) run(ctx context.Context) {
+	command := hook.getCommand()
+	if len(command) == 0 {
+		return
+	}
+
+	logrus.WithField("command", command[0]).Debug("Running index hook")
+
+	if out, err := exec.CommandContext(ctx, command[0], command[1:]...).CombinedOutput(); err != nil { //nolint:gosec
+		logrus.WithError(err).WithField("output", string(out)).Warn("Index hook failed")
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package indexhook
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHook_Run(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test relies on the touch command")
+	}
+
+	path := filepath.Join(t.TempDir(), "indexed")
+
+	hook := New("touch "+path, 10*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go hook.Run(ctx)
+
+	// Trigger several times; the triggers should be coalesced.
+	for i := 0; i < 10; i++ {
+		hook.Trigger()
+	}
+
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestHook_Disabled(t *testing.T) {
+	hook := New("", time.Millisecond)
+
+	// Triggering a disabled hook never queues a run.
+	hook.Trigger()
+	require.Len(t, hook.triggerCh, 0)
+
+	hook.SetCommand("notmuch new")
+	hook.Trigger()
+	hook.Trigger()
+	require.Len(t, hook.triggerCh, 1)
+}
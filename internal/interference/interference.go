@@ -0,0 +1,94 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package interference detects security software, such as antivirus mail shields, interfering with bridge:
+// intercepting the connections of mail clients to its servers, or locking its files while bridge writes them.
+package interference
+
+import (
+	"crypto/x509"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/exp/slices"
+)
+
+// product is a security product known to interfere with bridge.
+type product struct {
+	name string
+
+	// processes are the names of the product's executables, lowercase and without extension.
+	processes []string
+
+	// issuers are lowercase substrings of the names of the CAs the product signs the connections it intercepts with.
+	issuers []string
+}
+
+// products are checked in order; Microsoft Defender comes last as it runs on most Windows systems but only ever
+// locks files.
+var products = []product{ //nolint:gochecknoglobals
+	{name: "Avast", processes: []string{"avastsvc", "avastui"}, issuers: []string{"avast"}},
+	{name: "AVG", processes: []string{"avgsvc", "avgui"}, issuers: []string{"avg web/mail shield", "avg technologies"}},
+	{name: "ESET", processes: []string{"ekrn", "egui"}, issuers: []string{"eset"}},
+	{name: "Kaspersky", processes: []string{"avp", "avpui"}, issuers: []string{"kaspersky"}},
+	{name: "Bitdefender", processes: []string{"vsserv", "bdagent", "bdservicehost"}, issuers: []string{"bitdefender"}},
+	{name: "Norton", processes: []string{"nortonsecurity", "nswscsvc"}, issuers: []string{"norton", "symantec"}},
+	{name: "McAfee", processes: []string{"mcshield", "mfemms"}, issuers: []string{"mcafee"}},
+	{name: "Sophos", processes: []string{"savservice", "sophoshealth"}, issuers: []string{"sophos"}},
+	{name: "Trend Micro", processes: []string{"pccntmon", "tmbmsrv"}, issuers: []string{"trend micro"}},
+	{name: "Microsoft Defender", processes: []string{"msmpeng"}},
+}
+
+// IdentifyIssuer returns the name of the product whose CA issued the given certificate, or "" if it isn't known.
+func IdentifyIssuer(cert *x509.Certificate) string {
+	issuer := strings.ToLower(cert.Issuer.String())
+
+	for _, product := range products {
+		for _, name := range product.issuers {
+			if strings.Contains(issuer, name) {
+				return product.name
+			}
+		}
+	}
+
+	return ""
+}
+
+// IdentifyRunning returns the name of the first known product which has a process running, or "" if there is none
+// or the processes can't be listed.
+func IdentifyRunning() string {
+	names, err := processNames()
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to list the running processes")
+		return ""
+	}
+
+	for _, product := range products {
+		if slices.ContainsFunc(product.processes, func(process string) bool {
+			return slices.Contains(names, process)
+		}) {
+			return product.name
+		}
+	}
+
+	return ""
+}
+
+// normalizeProcessName returns the name of an executable as matched against the known products.
+func normalizeProcessName(name string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(name)), ".exe")
+}
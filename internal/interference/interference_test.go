@@ -0,0 +1,92 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package interference
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var errLocked = errors.New("locked")
+
+func isLocked(err error) bool {
+	return errors.Is(err, errLocked)
+}
+
+func TestIdentifyIssuer(t *testing.T) {
+	cert := func(org string) *x509.Certificate {
+		return &x509.Certificate{Issuer: pkix.Name{CommonName: "Mail Shield Root", Organization: []string{org}}}
+	}
+
+	require.Equal(t, "Avast", IdentifyIssuer(cert("Avast Web/Mail Shield")))
+	require.Equal(t, "ESET", IdentifyIssuer(cert("ESET, spol. s r. o.")))
+	require.Equal(t, "Norton", IdentifyIssuer(cert("Symantec Corporation")))
+	require.Equal(t, "", IdentifyIssuer(cert("Proton AG")))
+}
+
+func TestNormalizeProcessName(t *testing.T) {
+	require.Equal(t, "avastsvc", normalizeProcessName("AvastSvc.exe"))
+	require.Equal(t, "ekrn", normalizeProcessName("ekrn\n"))
+}
+
+func TestRetry_RecoversFromViolation(t *testing.T) {
+	before, _ := Violations()
+
+	var calls int
+
+	require.NoError(t, retry(func() error {
+		if calls++; calls < 3 {
+			return errLocked
+		}
+
+		return nil
+	}, isLocked, 0))
+
+	after, last := Violations()
+	require.Equal(t, 3, calls)
+	require.Equal(t, before+2, after)
+	require.False(t, last.IsZero())
+}
+
+func TestRetry_GivesUp(t *testing.T) {
+	var calls int
+
+	require.ErrorIs(t, retry(func() error {
+		calls++
+		return errLocked
+	}, isLocked, 0), errLocked)
+
+	require.Equal(t, retryAttempts, calls)
+}
+
+func TestRetry_OtherErrors(t *testing.T) {
+	errOther := errors.New("other")
+
+	var calls int
+
+	require.ErrorIs(t, retry(func() error {
+		calls++
+		return errOther
+	}, isLocked, 0), errOther)
+
+	require.Equal(t, 1, calls)
+}
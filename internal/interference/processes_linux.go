@@ -0,0 +1,46 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+//go:build linux
+
+package interference
+
+import (
+	"os"
+	"path/filepath"
+)
+
+func processNames() ([]string, error) {
+	paths, err := filepath.Glob("/proc/[0-9]*/comm")
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(paths))
+
+	for _, path := range paths {
+		// Processes may exit while being listed.
+		comm, err := os.ReadFile(path) //nolint:gosec
+		if err != nil {
+			continue
+		}
+
+		names = append(names, normalizeProcessName(string(comm)))
+	}
+
+	return names, nil
+}
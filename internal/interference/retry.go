@@ -0,0 +1,80 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package interference
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	retryAttempts = 5
+	retryBackoff  = 50 * time.Millisecond
+)
+
+var (
+	violationsLock sync.Mutex //nolint:gochecknoglobals
+	violations     int        //nolint:gochecknoglobals
+	lastViolation  time.Time  //nolint:gochecknoglobals
+)
+
+// Retry calls fn, retrying with backoff while it fails because another process, such as a scanner inspecting the
+// file, is holding it open. Sharing violations are recorded so that they can be reported by Violations.
+func Retry(fn func() error) error {
+	return retry(fn, isSharingViolation, retryBackoff)
+}
+
+// Violations returns the number of sharing violations recorded so far and the time of the last one.
+func Violations() (int, time.Time) {
+	violationsLock.Lock()
+	defer violationsLock.Unlock()
+
+	return violations, lastViolation
+}
+
+func retry(fn func() error, isViolation func(error) bool, backoff time.Duration) error {
+	var err error
+
+	for attempt := 1; ; attempt++ {
+		if err = fn(); err == nil || !isViolation(err) {
+			return err
+		}
+
+		recordViolation()
+
+		if attempt == retryAttempts {
+			return err
+		}
+
+		logrus.WithError(err).WithField("attempt", attempt).Warn("File locked by another process, retrying")
+
+		time.Sleep(backoff)
+
+		backoff *= 2
+	}
+}
+
+func recordViolation() {
+	violationsLock.Lock()
+	defer violationsLock.Unlock()
+
+	violations++
+	lastViolation = time.Now()
+}
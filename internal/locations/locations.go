@@ -72,6 +72,22 @@ func (l *Locations) GetGuiLockFile() string {
 	return filepath.Join(l.userCache, l.configGuiName+".lock")
 }
 
+// ProvideLMTPSocketPath returns the path of the unix socket the LMTP server listens on, next to the lock files
+// (e.g. ~/.cache/<company>/<app>/<app>-lmtp.sock). It creates its directory, only accessible by the user,
+// if it doesn't already exist.
+func (l *Locations) ProvideLMTPSocketPath() (string, error) {
+	if err := os.MkdirAll(l.userCache, 0o700); err != nil {
+		return "", err
+	}
+
+	return filepath.Join(l.userCache, l.configName+"-lmtp.sock"), nil
+}
+
+// GetDataDirs returns the directories where the app keeps its files (config, data and cache).
+func (l *Locations) GetDataDirs() []string {
+	return []string{l.userConfig, l.userData, l.userCache}
+}
+
 // GetLicenseFilePath returns path to liense file.
 func (l *Locations) GetLicenseFilePath() string {
 	path := l.getLicenseFilePath()
@@ -198,6 +214,16 @@ func (l *Locations) ProvideStatsPath() (string, error) {
 	return l.getStatsPath(), nil
 }
 
+// ProvideTenantsPath returns a location for the files of the tenants hosted in multi-tenant mode
+// (e.g. ~/.local/share/<company>/<app>/tenants). It creates it if it doesn't already exist.
+func (l *Locations) ProvideTenantsPath() (string, error) {
+	if err := os.MkdirAll(l.getTenantsPath(), 0o700); err != nil {
+		return "", err
+	}
+
+	return l.getTenantsPath(), nil
+}
+
 func (l *Locations) ProvideIMAPSyncConfigPath() (string, error) {
 	if err := os.MkdirAll(l.getIMAPSyncConfigPath(), 0o700); err != nil {
 		return "", err
@@ -242,17 +268,32 @@ func (l *Locations) getStatsPath() string {
 	return filepath.Join(l.userData, "stats")
 }
 
-// Clear removes everything except the lock and update files.
+func (l *Locations) getTenantsPath() string {
+	return filepath.Join(l.userData, "tenants")
+}
+
+// Clear removes everything except the lock and update files and the files of the tenants.
 func (l *Locations) Clear(except ...string) error {
 	return files.Remove(
 		l.userConfig,
 		l.userData,
 		l.userCache,
 	).Except(
-		append(except, l.GetGuiLockFile(), l.getUpdatesPath())...,
+		append(except, l.GetGuiLockFile(), l.getUpdatesPath(), l.getTenantsPath())...,
 	).Do()
 }
 
+// PreviewClear returns the paths Clear would remove, without removing them.
+func (l *Locations) PreviewClear(except ...string) ([]string, error) {
+	return files.Remove(
+		l.userConfig,
+		l.userData,
+		l.userCache,
+	).Except(
+		append(except, l.GetGuiLockFile(), l.getUpdatesPath(), l.getTenantsPath())...,
+	).DryRun()
+}
+
 // ClearUpdates removes update files.
 func (l *Locations) ClearUpdates() error {
 	return files.Remove(
@@ -91,6 +91,36 @@ func (p *DefaultProvider) UserCache() string {
 	return p.cache
 }
 
+// DirProvider is a locations provider storing everything under a single directory.
+type DirProvider struct {
+	root string
+}
+
+// NewDirProvider returns a locations provider using the config, data and cache subdirectories of the given directory.
+func NewDirProvider(root string) (*DirProvider, error) {
+	provider := &DirProvider{root: root}
+
+	for _, dir := range []string{provider.UserConfig(), provider.UserData(), provider.UserCache()} {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return nil, err
+		}
+	}
+
+	return provider, nil
+}
+
+func (p *DirProvider) UserConfig() string {
+	return filepath.Join(p.root, "config")
+}
+
+func (p *DirProvider) UserData() string {
+	return filepath.Join(p.root, "data")
+}
+
+func (p *DirProvider) UserCache() string {
+	return filepath.Join(p.root, "cache")
+}
+
 // userDataDir returns a directory that can be used to store user-specific data.
 // This is necessary because os.UserDataDir() is not implemented by the Go standard library, sadly.
 // On non-linux systems, it is the same as os.UserConfigDir().
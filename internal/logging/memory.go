@@ -0,0 +1,136 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package logging
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultMemoryLogSize is how much of the logs is kept in memory when they are not written to disk.
+const DefaultMemoryLogSize = 4 * 1024 * 1024
+
+// memoryLog is the log of the current session while logs are kept in memory only.
+var memoryLog atomic.Pointer[MemoryLog] //nolint:gochecknoglobals
+
+// MemoryLog keeps the most recent log lines in memory, up to a maximum size.
+type MemoryLog struct {
+	lines   [][]byte
+	size    int
+	maxSize int
+	lock    sync.Mutex
+}
+
+func NewMemoryLog(maxSize int) *MemoryLog {
+	return &MemoryLog{maxSize: maxSize}
+}
+
+// Write appends the given lines to the log, dropping the oldest ones if it gets too big.
+func (l *MemoryLog) Write(p []byte) (int, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.lines = append(l.lines, bytes.Clone(p))
+	l.size += len(p)
+
+	for l.size > l.maxSize && len(l.lines) > 0 {
+		l.size -= len(l.lines[0])
+		l.lines = l.lines[1:]
+	}
+
+	return len(p), nil
+}
+
+// Bytes returns a copy of the log.
+func (l *MemoryLog) Bytes() []byte {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return bytes.Join(l.lines, nil)
+}
+
+// Zip returns the log as a zip archive, for bug reports.
+func (l *MemoryLog) Zip() (*bytes.Buffer, error) {
+	buffer := new(bytes.Buffer)
+
+	zw := zip.NewWriter(buffer)
+
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: "session.log", Method: zip.Deflate, Modified: time.Now()})
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := w.Write(l.Bytes()); err != nil {
+		return nil, err
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buffer, nil
+}
+
+// Close wipes the log.
+func (l *MemoryLog) Close() error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	for _, line := range l.lines {
+		for i := range line {
+			line[i] = 0
+		}
+	}
+
+	l.lines, l.size = nil, 0
+
+	memoryLog.CompareAndSwap(l, nil)
+
+	return nil
+}
+
+// InitInMemory initializes logging without ever writing to disk: logs are kept in a MemoryLog of the given size,
+// which is available through GetMemoryLog until the returned closer is closed.
+func InitInMemory(maxSize int, level string) (io.Closer, error) {
+	logrus.SetFormatter(&logrus.TextFormatter{
+		DisableColors:   true,
+		FullTimestamp:   true,
+		TimestampFormat: time.StampMilli,
+	})
+
+	logrus.AddHook(newColoredStdOutHook())
+
+	log := NewMemoryLog(maxSize)
+
+	logrus.SetOutput(log)
+
+	memoryLog.Store(log)
+
+	return log, setLevel(level)
+}
+
+// GetMemoryLog returns the log of the current session if logs are kept in memory only, and nil otherwise.
+func GetMemoryLog() *MemoryLog {
+	return memoryLog.Load()
+}
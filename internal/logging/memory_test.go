@@ -0,0 +1,78 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package logging
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryLog_DropsOldestLines(t *testing.T) {
+	log := NewMemoryLog(10)
+
+	for _, line := range []string{"first\n", "second\n", "third\n"} {
+		_, err := log.Write([]byte(line))
+		require.NoError(t, err)
+	}
+
+	// Only the most recent lines fitting in the maximum size are kept.
+	require.Equal(t, "third\n", string(log.Bytes()))
+}
+
+func TestMemoryLog_Zip(t *testing.T) {
+	log := NewMemoryLog(DefaultMemoryLogSize)
+
+	_, err := log.Write([]byte("some line\n"))
+	require.NoError(t, err)
+
+	buffer, err := log.Zip()
+	require.NoError(t, err)
+
+	zr, err := zip.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	require.NoError(t, err)
+	require.Len(t, zr.File, 1)
+
+	f, err := zr.File[0].Open()
+	require.NoError(t, err)
+	defer f.Close() //nolint:errcheck
+
+	b, err := io.ReadAll(f)
+	require.NoError(t, err)
+	require.Equal(t, "some line\n", string(b))
+}
+
+func TestLogging_InitInMemory(t *testing.T) {
+	closer, err := InitInMemory(DefaultMemoryLogSize, "info")
+	require.NoError(t, err)
+
+	logrus.Info("kept in memory")
+
+	// The logs of the session are available...
+	require.Contains(t, string(GetMemoryLog().Bytes()), "kept in memory")
+
+	// ... until logging is closed, which wipes them.
+	log := GetMemoryLog()
+	require.NoError(t, Close(closer))
+	require.Nil(t, GetMemoryLog())
+	require.Empty(t, log.Bytes())
+}
@@ -0,0 +1,167 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package mailto
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+var ErrUnknownClient = errors.New("unknown mail client")
+
+// Client is a mail client whose compose window can be opened from the command line.
+type Client string
+
+const (
+	// ClientThunderbird is Mozilla Thunderbird, which selects the identity of the sending address.
+	ClientThunderbird Client = "thunderbird"
+
+	// ClientEvolution is GNOME Evolution, which composes from its default account.
+	ClientEvolution Client = "evolution"
+
+	// ClientOutlook is Microsoft Outlook, which composes from its default account.
+	ClientOutlook Client = "outlook"
+
+	// ClientAppleMail is Apple Mail, which composes from its default account.
+	ClientAppleMail Client = "applemail"
+)
+
+// Clients are the supported mail clients.
+var Clients = []Client{ClientThunderbird, ClientEvolution, ClientOutlook, ClientAppleMail} // nolint:gochecknoglobals
+
+// ParseClient returns the supported mail client of the given name.
+func ParseClient(name string) (Client, error) {
+	for _, client := range Clients {
+		if strings.EqualFold(name, string(client)) {
+			return client, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: %q", ErrUnknownClient, name)
+}
+
+// Command returns the command line opening the client's compose window with the message, sent from the given address
+// if the client can select it. exe is the client's executable; the command the client is usually run with if empty.
+func (client Client) Command(exe, from string, msg Message) []string {
+	switch client {
+	case ClientThunderbird:
+		return []string{orDefault(exe, "thunderbird"), "-compose", thunderbirdFields(from, msg)}
+
+	case ClientEvolution:
+		return []string{orDefault(exe, "evolution"), msg.String()}
+
+	case ClientOutlook:
+		return []string{orDefault(exe, "outlook.exe"), "/c", "ipm.note", "/m", msg.query()}
+
+	case ClientAppleMail:
+		return []string{"open", "-a", orDefault(exe, "Mail"), msg.String()}
+
+	default:
+		return nil
+	}
+}
+
+// LaunchCommand returns the command line launching the client with the given profile, or its default one if empty;
+// Evolution and Apple Mail have a single profile. exe is the client's executable, as for Command.
+func (client Client) LaunchCommand(exe, profile string) []string {
+	switch client {
+	case ClientThunderbird:
+		if profile != "" {
+			return []string{orDefault(exe, "thunderbird"), "-P", profile}
+		}
+
+		return []string{orDefault(exe, "thunderbird")}
+
+	case ClientEvolution:
+		return []string{orDefault(exe, "evolution")}
+
+	case ClientOutlook:
+		if profile != "" {
+			return []string{orDefault(exe, "outlook.exe"), "/profile", profile}
+		}
+
+		return []string{orDefault(exe, "outlook.exe")}
+
+	case ClientAppleMail:
+		return []string{"open", "-a", orDefault(exe, "Mail")}
+
+	default:
+		return nil
+	}
+}
+
+// Compose opens the client's compose window with the message, sent from the given address if the client can select it,
+// without waiting for the client to exit. exe is the client's executable, as for Command.
+func Compose(client Client, exe, from string, msg Message) error {
+	return start(client, client.Command(exe, from, msg))
+}
+
+// Launch launches the client with the given profile without waiting for it to exit, as for LaunchCommand.
+func Launch(client Client, exe, profile string) error {
+	return start(client, client.LaunchCommand(exe, profile))
+}
+
+func start(client Client, args []string) error {
+	if args == nil {
+		return fmt.Errorf("%w: %q", ErrUnknownClient, client)
+	}
+
+	cmd := exec.Command(args[0], args[1:]...) //nolint:gosec
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start %v: %w", client, err)
+	}
+
+	go func() { _ = cmd.Wait() }()
+
+	return nil
+}
+
+// thunderbirdFields returns the argument of Thunderbird's -compose option: a comma separated list of field='value'.
+// Thunderbird has no escaping for the quotes ending the values, so they are replaced by typographic ones.
+func thunderbirdFields(from string, msg Message) string {
+	var fields []string
+
+	for _, field := range []struct {
+		name  string
+		value string
+	}{
+		{"from", from},
+		{"to", strings.Join(msg.To, ",")},
+		{"cc", strings.Join(msg.Cc, ",")},
+		{"bcc", strings.Join(msg.Bcc, ",")},
+		{"subject", msg.Subject},
+		{"body", msg.Body},
+	} {
+		if field.value != "" {
+			fields = append(fields, field.name+"='"+strings.ReplaceAll(field.value, "'", "’")+"'")
+		}
+	}
+
+	return strings.Join(fields, ",")
+}
+
+func orDefault(value, def string) string {
+	if value == "" {
+		return def
+	}
+
+	return value
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package mailto
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+)
+
+// InstalledClient is a supported mail client found on the system.
+type InstalledClient struct {
+	Client Client
+
+	// Path is the client's executable, or its application bundle on macOS.
+	Path string
+
+	// Profiles are the names of the client's profiles, for clients which have several.
+	Profiles []string
+}
+
+// DetectClients returns the supported mail clients installed on the system.
+func DetectClients() []InstalledClient {
+	return detectClients()
+}
+
+// readThunderbirdProfiles returns the names of the profiles listed in the given Thunderbird profiles.ini file,
+// or none if it can't be read, e.g. because Thunderbird was never started.
+func readThunderbirdProfiles(path string) []string {
+	file, err := os.Open(path) //nolint:gosec
+	if err != nil {
+		return nil
+	}
+
+	defer func() { _ = file.Close() }()
+
+	return parseThunderbirdProfiles(file)
+}
+
+// parseThunderbirdProfiles returns the Name of each [ProfileN] section of a profiles.ini file.
+func parseThunderbirdProfiles(r io.Reader) []string {
+	var (
+		profiles  []string
+		inProfile bool
+	)
+
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			inProfile = strings.HasPrefix(line, "[Profile")
+			continue
+		}
+
+		if name, ok := strings.CutPrefix(line, "Name="); ok && inProfile {
+			profiles = append(profiles, name)
+		}
+	}
+
+	return profiles
+}
@@ -0,0 +1,55 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package mailto
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// detectClients looks for the clients' application bundles.
+func detectClients() []InstalledClient {
+	var clients []InstalledClient
+
+	if exe := "/Applications/Thunderbird.app/Contents/MacOS/thunderbird"; isFile(exe) {
+		clients = append(clients, InstalledClient{Client: ClientThunderbird, Path: exe, Profiles: getThunderbirdProfiles()})
+	}
+
+	for _, app := range []string{"/System/Applications/Mail.app", "/Applications/Mail.app"} {
+		if isFile(app) {
+			clients = append(clients, InstalledClient{Client: ClientAppleMail, Path: app})
+			break
+		}
+	}
+
+	return clients
+}
+
+func getThunderbirdProfiles() []string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+
+	return readThunderbirdProfiles(filepath.Join(home, "Library", "Thunderbird", "profiles.ini"))
+}
+
+func isFile(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,48 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package mailto
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// detectClients looks for the clients' commands in the PATH.
+func detectClients() []InstalledClient {
+	var clients []InstalledClient
+
+	if path, err := exec.LookPath("thunderbird"); err == nil {
+		clients = append(clients, InstalledClient{Client: ClientThunderbird, Path: path, Profiles: getThunderbirdProfiles()})
+	}
+
+	if path, err := exec.LookPath("evolution"); err == nil {
+		clients = append(clients, InstalledClient{Client: ClientEvolution, Path: path})
+	}
+
+	return clients
+}
+
+func getThunderbirdProfiles() []string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+
+	return readThunderbirdProfiles(filepath.Join(home, ".thunderbird", "profiles.ini"))
+}
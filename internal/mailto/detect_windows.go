@@ -0,0 +1,102 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+//go:build windows
+// +build windows
+
+package mailto
+
+import (
+	"os"
+	"path/filepath"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+const (
+	outlookAppPath      = `Software\Microsoft\Windows\CurrentVersion\App Paths\OUTLOOK.EXE`
+	outlookProfilesPath = `Software\Microsoft\Office\16.0\Outlook\Profiles`
+)
+
+// detectClients looks for Thunderbird in the program files and for Outlook in the registered applications.
+func detectClients() []InstalledClient {
+	var clients []InstalledClient
+
+	for _, dir := range []string{os.Getenv("ProgramFiles"), os.Getenv("ProgramFiles(x86)")} {
+		if dir == "" {
+			continue
+		}
+
+		if exe := filepath.Join(dir, "Mozilla Thunderbird", "thunderbird.exe"); isFile(exe) {
+			clients = append(clients, InstalledClient{Client: ClientThunderbird, Path: exe, Profiles: getThunderbirdProfiles()})
+			break
+		}
+	}
+
+	if exe, ok := getOutlookPath(); ok {
+		clients = append(clients, InstalledClient{Client: ClientOutlook, Path: exe, Profiles: getOutlookProfiles()})
+	}
+
+	return clients
+}
+
+func getThunderbirdProfiles() []string {
+	appData := os.Getenv("APPDATA")
+	if appData == "" {
+		return nil
+	}
+
+	return readThunderbirdProfiles(filepath.Join(appData, "Thunderbird", "profiles.ini"))
+}
+
+func getOutlookPath() (string, bool) {
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, outlookAppPath, registry.QUERY_VALUE)
+	if err != nil {
+		return "", false
+	}
+
+	defer func() { _ = key.Close() }()
+
+	exe, _, err := key.GetStringValue("")
+	if err != nil || !isFile(exe) {
+		return "", false
+	}
+
+	return exe, true
+}
+
+// getOutlookProfiles returns the names of the Outlook profiles, the subkeys of its profiles key.
+func getOutlookProfiles() []string {
+	key, err := registry.OpenKey(registry.CURRENT_USER, outlookProfilesPath, registry.ENUMERATE_SUB_KEYS)
+	if err != nil {
+		return nil
+	}
+
+	defer func() { _ = key.Close() }()
+
+	profiles, err := key.ReadSubKeyNames(-1)
+	if err != nil {
+		return nil
+	}
+
+	return profiles
+}
+
+func isFile(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
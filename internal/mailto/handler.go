@@ -0,0 +1,32 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package mailto
+
+import "errors"
+
+var ErrUnsupported = errors.New("registering the mailto handler is not supported on this platform")
+
+// Handler registers an executable as the handler of mailto: URLs for the current user. The executable is then run
+// with the URL as argument, which a running bridge instance is passed by the new one.
+type Handler struct {
+	exe string
+}
+
+func NewHandler(exe string) *Handler {
+	return &Handler{exe: exe}
+}
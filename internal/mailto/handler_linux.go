@@ -0,0 +1,114 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package mailto
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/constants"
+)
+
+const (
+	desktopFileName = "proton-mail-bridge-mailto.desktop"
+	mailtoMIMEType  = "x-scheme-handler/mailto"
+)
+
+// Register installs a desktop entry handling mailto: URLs and makes it their default handler with xdg-mime.
+func (h *Handler) Register() error {
+	path, err := getDesktopFilePath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create applications directory: %w", err)
+	}
+
+	entry := fmt.Sprintf(
+		"[Desktop Entry]\nType=Application\nName=%v\nExec=%v %%u\nNoDisplay=true\nMimeType=%v;\n",
+		constants.FullAppName, quoteDesktopExec(h.exe), mailtoMIMEType,
+	)
+
+	if err := os.WriteFile(path, []byte(entry), 0o600); err != nil {
+		return fmt.Errorf("failed to write desktop entry: %w", err)
+	}
+
+	if out, err := exec.Command("xdg-mime", "default", desktopFileName, mailtoMIMEType).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to set default mailto handler: %w: %s", err, out)
+	}
+
+	return nil
+}
+
+// Unregister removes the desktop entry; the desktop then falls back to the previous handler.
+func (h *Handler) Unregister() error {
+	path, err := getDesktopFilePath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove desktop entry: %w", err)
+	}
+
+	return nil
+}
+
+// IsRegistered returns whether the desktop entry is the default handler of mailto: URLs.
+func (h *Handler) IsRegistered() bool {
+	path, err := getDesktopFilePath()
+	if err != nil {
+		return false
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		return false
+	}
+
+	out, err := exec.Command("xdg-mime", "query", "default", mailtoMIMEType).Output()
+	if err != nil {
+		return false
+	}
+
+	return strings.TrimSpace(string(out)) == desktopFileName
+}
+
+func getDesktopFilePath() (string, error) {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get home directory: %w", err)
+		}
+
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+
+	return filepath.Join(dataHome, "applications", desktopFileName), nil
+}
+
+// quoteDesktopExec quotes the path for the Exec key of a desktop entry.
+func quoteDesktopExec(path string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "`", "\\`", `$`, `\$`).Replace(path) + `"`
+}
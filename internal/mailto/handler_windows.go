@@ -0,0 +1,131 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+//go:build windows
+// +build windows
+
+package mailto
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/constants"
+	"golang.org/x/sys/windows/registry"
+)
+
+const (
+	progID         = "ProtonMailBridge.mailto"
+	progIDPath     = `Software\Classes\` + progID
+	userChoicePath = `Software\Microsoft\Windows\Shell\Associations\UrlAssociations\mailto\UserChoice`
+	registeredPath = `Software\RegisteredApplications`
+)
+
+var (
+	clientPath       = `Software\Clients\Mail\` + constants.FullAppName // nolint:gochecknoglobals
+	capabilitiesPath = clientPath + `\Capabilities`                     // nolint:gochecknoglobals
+)
+
+// Register declares bridge as a mail client handling mailto: URLs. Windows doesn't let applications make themselves
+// the default handler: the user picks it in the default apps settings.
+func (h *Handler) Register() error {
+	for path, values := range map[string]map[string]string{
+		progIDPath: {
+			"":             "URL:MailTo Protocol",
+			"URL Protocol": "",
+		},
+		progIDPath + `\shell\open\command`: {
+			"": fmt.Sprintf(`"%v" "%%1"`, h.exe),
+		},
+		capabilitiesPath: {
+			"ApplicationName":        constants.FullAppName,
+			"ApplicationDescription": constants.FullAppName,
+		},
+		capabilitiesPath + `\URLAssociations`: {
+			"mailto": progID,
+		},
+		registeredPath: {
+			constants.FullAppName: capabilitiesPath,
+		},
+	} {
+		if err := setRegistryValues(path, values); err != nil {
+			return fmt.Errorf("failed to register mailto handler: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Unregister removes the declaration of bridge as a mail client.
+func (h *Handler) Unregister() error {
+	for _, path := range []string{
+		progIDPath + `\shell\open\command`,
+		progIDPath + `\shell\open`,
+		progIDPath + `\shell`,
+		progIDPath,
+		capabilitiesPath + `\URLAssociations`,
+		capabilitiesPath,
+		clientPath,
+	} {
+		if err := registry.DeleteKey(registry.CURRENT_USER, path); err != nil && !errors.Is(err, registry.ErrNotExist) {
+			return fmt.Errorf("failed to unregister mailto handler: %w", err)
+		}
+	}
+
+	key, err := registry.OpenKey(registry.CURRENT_USER, registeredPath, registry.SET_VALUE)
+	if errors.Is(err, registry.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to unregister mailto handler: %w", err)
+	}
+	defer func() { _ = key.Close() }()
+
+	if err := key.DeleteValue(constants.FullAppName); err != nil && !errors.Is(err, registry.ErrNotExist) {
+		return fmt.Errorf("failed to unregister mailto handler: %w", err)
+	}
+
+	return nil
+}
+
+// IsRegistered returns whether the user picked bridge as the default handler of mailto: URLs.
+func (h *Handler) IsRegistered() bool {
+	key, err := registry.OpenKey(registry.CURRENT_USER, userChoicePath, registry.QUERY_VALUE)
+	if err != nil {
+		return false
+	}
+	defer func() { _ = key.Close() }()
+
+	choice, _, err := key.GetStringValue("ProgId")
+
+	return err == nil && choice == progID
+}
+
+func setRegistryValues(path string, values map[string]string) error {
+	key, _, err := registry.CreateKey(registry.CURRENT_USER, path, registry.SET_VALUE)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = key.Close() }()
+
+	for name, value := range values {
+		if err := key.SetStringValue(name, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
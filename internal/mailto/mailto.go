@@ -0,0 +1,172 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package mailto handles the mailto: URLs (RFC 6068) the OS passes to bridge once it's registered as their handler:
+// it parses them and opens the compose window of the user's mail client, set to send from the right address.
+// It also finds the mail clients installed on the system and launches them.
+package mailto
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+)
+
+var ErrInvalidURL = errors.New("invalid mailto URL")
+
+// Message is the message a mailto: URL describes.
+type Message struct {
+	To  []string
+	Cc  []string
+	Bcc []string
+
+	Subject string
+	Body    string
+}
+
+// Recipients returns the addresses of all the recipients of the message.
+func (msg Message) Recipients() []string {
+	return append(append(append([]string{}, msg.To...), msg.Cc...), msg.Bcc...)
+}
+
+// String returns the mailto: URL of the message.
+func (msg Message) String() string {
+	return "mailto:" + msg.query()
+}
+
+// query returns the mailto: URL of the message without its scheme, the form some mail clients take.
+func (msg Message) query() string {
+	var fields []string
+
+	for _, field := range []struct {
+		name  string
+		value string
+	}{
+		{"cc", escapeAddresses(msg.Cc)},
+		{"bcc", escapeAddresses(msg.Bcc)},
+		{"subject", escape(msg.Subject)},
+		{"body", escape(msg.Body)},
+	} {
+		if field.value != "" {
+			fields = append(fields, field.name+"="+field.value)
+		}
+	}
+
+	query := escapeAddresses(msg.To)
+
+	if len(fields) > 0 {
+		query += "?" + strings.Join(fields, "&")
+	}
+
+	return query
+}
+
+// Find returns the first mailto: URL among the given arguments, if any.
+func Find(args []string) (string, bool) {
+	for _, arg := range args {
+		if strings.HasPrefix(strings.ToLower(arg), "mailto:") {
+			return arg, true
+		}
+	}
+
+	return "", false
+}
+
+// Parse parses a mailto: URL, e.g. mailto:a@pm.me,b@pm.me?cc=c@pm.me&subject=Hello%20there.
+// Header fields other than to, cc, bcc, subject and body are ignored.
+func Parse(rawURL string) (Message, error) {
+	scheme, rest, ok := strings.Cut(rawURL, ":")
+	if !ok || !strings.EqualFold(scheme, "mailto") {
+		return Message{}, fmt.Errorf("%w: not a mailto URL", ErrInvalidURL)
+	}
+
+	to, query, _ := strings.Cut(rest, "?")
+
+	var msg Message
+
+	if err := addAddresses(&msg.To, to); err != nil {
+		return Message{}, err
+	}
+
+	// The query isn't parsed with url.ParseQuery as '+' stands for itself in mailto: URLs, not for a space.
+	for _, field := range strings.Split(query, "&") {
+		if field == "" {
+			continue
+		}
+
+		name, value, _ := strings.Cut(field, "=")
+
+		var err error
+
+		switch strings.ToLower(name) {
+		case "to":
+			err = addAddresses(&msg.To, value)
+
+		case "cc":
+			err = addAddresses(&msg.Cc, value)
+
+		case "bcc":
+			err = addAddresses(&msg.Bcc, value)
+
+		case "subject":
+			msg.Subject, err = url.PathUnescape(value)
+
+		case "body":
+			msg.Body, err = url.PathUnescape(value)
+		}
+
+		if err != nil {
+			return Message{}, fmt.Errorf("%w: %v", ErrInvalidURL, err)
+		}
+	}
+
+	return msg, nil
+}
+
+func addAddresses(addresses *[]string, value string) error {
+	value, err := url.PathUnescape(value)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidURL, err)
+	}
+
+	for _, address := range strings.Split(value, ",") {
+		if address = strings.TrimSpace(address); address == "" {
+			continue
+		}
+
+		addr, err := mail.ParseAddress(address)
+		if err != nil {
+			return fmt.Errorf("%w: %q: %v", ErrInvalidURL, address, err)
+		}
+
+		*addresses = append(*addresses, addr.Address)
+	}
+
+	return nil
+}
+
+// escape percent-encodes the value for a mailto: URL, spaces as %20.
+func escape(value string) string {
+	return strings.ReplaceAll(url.QueryEscape(value), "+", "%20")
+}
+
+// escapeAddresses percent-encodes the comma separated addresses for a mailto: URL, leaving the '@' and ',' readable.
+func escapeAddresses(addresses []string) string {
+	return strings.NewReplacer("%40", "@", "%2C", ",").Replace(escape(strings.Join(addresses, ",")))
+}
@@ -0,0 +1,133 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package mailto
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	msg, err := Parse("mailto:a@pm.me,%20B%20%3Cb@pm.me%3E?CC=c@pm.me&bcc=d@pm.me&to=e@pm.me&subject=Hello%20there%2C+you&body=line%0D%0Aline&in-reply-to=x")
+	require.NoError(t, err)
+
+	require.Equal(t, Message{
+		To:      []string{"a@pm.me", "b@pm.me", "e@pm.me"},
+		Cc:      []string{"c@pm.me"},
+		Bcc:     []string{"d@pm.me"},
+		Subject: "Hello there,+you",
+		Body:    "line\r\nline",
+	}, msg)
+
+	require.Equal(t, []string{"a@pm.me", "b@pm.me", "e@pm.me", "c@pm.me", "d@pm.me"}, msg.Recipients())
+
+	// A message read back from its URL is the same.
+	parsed, err := Parse(msg.String())
+	require.NoError(t, err)
+	require.Equal(t, msg, parsed)
+
+	// A URL may have no recipient.
+	msg, err = Parse("MAILTO:?subject=Hi")
+	require.NoError(t, err)
+	require.Equal(t, Message{Subject: "Hi"}, msg)
+
+	_, err = Parse("https://pm.me")
+	require.ErrorIs(t, err, ErrInvalidURL)
+
+	_, err = Parse("mailto:not an address")
+	require.ErrorIs(t, err, ErrInvalidURL)
+
+	_, err = Parse("mailto:a@pm.me?subject=%zz")
+	require.ErrorIs(t, err, ErrInvalidURL)
+}
+
+func TestFind(t *testing.T) {
+	url, ok := Find([]string{"--no-window", "mailto:a@pm.me"})
+	require.True(t, ok)
+	require.Equal(t, "mailto:a@pm.me", url)
+
+	_, ok = Find([]string{"--no-window"})
+	require.False(t, ok)
+}
+
+func TestClient_Command(t *testing.T) {
+	msg := Message{To: []string{"a@pm.me", "b@pm.me"}, Cc: []string{"c@pm.me"}, Subject: "It's me"}
+
+	require.Equal(t,
+		[]string{"thunderbird", "-compose", "from='me@pm.me',to='a@pm.me,b@pm.me',cc='c@pm.me',subject='It’s me'"},
+		ClientThunderbird.Command("", "me@pm.me", msg),
+	)
+
+	require.Equal(t,
+		[]string{"/usr/bin/evolution", "mailto:a@pm.me,b@pm.me?cc=c@pm.me&subject=It%27s%20me"},
+		ClientEvolution.Command("/usr/bin/evolution", "me@pm.me", msg),
+	)
+
+	require.Equal(t,
+		[]string{"outlook.exe", "/c", "ipm.note", "/m", "a@pm.me,b@pm.me?cc=c@pm.me&subject=It%27s%20me"},
+		ClientOutlook.Command("", "me@pm.me", msg),
+	)
+
+	require.Equal(t,
+		[]string{"open", "-a", "Mail", "mailto:a@pm.me,b@pm.me?cc=c@pm.me&subject=It%27s%20me"},
+		ClientAppleMail.Command("", "me@pm.me", msg),
+	)
+
+	client, err := ParseClient("Thunderbird")
+	require.NoError(t, err)
+	require.Equal(t, ClientThunderbird, client)
+
+	_, err = ParseClient("pine")
+	require.ErrorIs(t, err, ErrUnknownClient)
+}
+
+func TestClient_LaunchCommand(t *testing.T) {
+	require.Equal(t, []string{"thunderbird"}, ClientThunderbird.LaunchCommand("", ""))
+	require.Equal(t, []string{"/opt/thunderbird/thunderbird", "-P", "work"}, ClientThunderbird.LaunchCommand("/opt/thunderbird/thunderbird", "work"))
+	require.Equal(t, []string{"evolution"}, ClientEvolution.LaunchCommand("", "work"))
+	require.Equal(t, []string{"outlook.exe", "/profile", "Work"}, ClientOutlook.LaunchCommand("", "Work"))
+	require.Equal(t, []string{"open", "-a", "/System/Applications/Mail.app"}, ClientAppleMail.LaunchCommand("/System/Applications/Mail.app", ""))
+	require.Nil(t, Client("pine").LaunchCommand("", ""))
+}
+
+func TestParseThunderbirdProfiles(t *testing.T) {
+	ini := `[Install4F96D1932A9F858E]
+Default=Profiles/abc.default-release
+Locked=1
+
+[Profile1]
+Name=work
+IsRelative=1
+Path=Profiles/def.work
+
+[Profile0]
+Name=default-release
+IsRelative=1
+Path=Profiles/abc.default-release
+Default=1
+
+[General]
+StartWithLastProfile=1
+Version=2
+`
+
+	require.Equal(t, []string{"work", "default-release"}, parseThunderbirdProfiles(strings.NewReader(ini)))
+	require.Empty(t, parseThunderbirdProfiles(strings.NewReader("")))
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package network
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+)
+
+// ParseAllowedClient parses a CIDR, or a single IP address which is taken as a host prefix.
+func ParseAllowedClient(client string) (netip.Prefix, error) {
+	if !strings.Contains(client, "/") {
+		addr, err := netip.ParseAddr(client)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("invalid IP address %q: %w", client, err)
+		}
+
+		return netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()), nil
+	}
+
+	prefix, err := netip.ParsePrefix(client)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid CIDR %q: %w", client, err)
+	}
+
+	return prefix.Masked(), nil
+}
+
+// IsAllowedClient returns whether the remote address is a loopback one or belongs to one of the allowed prefixes.
+func IsAllowedClient(remoteAddr net.Addr, allowed []netip.Prefix) bool {
+	var addr netip.Addr
+
+	switch remoteAddr := remoteAddr.(type) {
+	case *net.TCPAddr:
+		addr = remoteAddr.AddrPort().Addr().Unmap()
+
+	case *net.UnixAddr:
+		// Clients of unix sockets are local.
+		return true
+
+	default:
+		return false
+	}
+
+	if addr.IsLoopback() {
+		return true
+	}
+
+	for _, prefix := range allowed {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// FilterListener is a listener which closes the connections of the remote addresses refused by a filter,
+// before anything is read from or written to them.
+type FilterListener struct {
+	net.Listener
+
+	allow func(net.Addr) bool
+}
+
+func NewFilterListener(listener net.Listener, allow func(net.Addr) bool) *FilterListener {
+	return &FilterListener{
+		Listener: listener,
+		allow:    allow,
+	}
+}
+
+func (l *FilterListener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+
+		if l.allow(conn.RemoteAddr()) {
+			return conn, nil
+		}
+
+		_ = conn.Close()
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package network
+
+import (
+	"net"
+	"net/netip"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAllowedClient(t *testing.T) {
+	for client, want := range map[string]string{
+		"192.168.1.0/24": "192.168.1.0/24",
+		"192.168.1.7/24": "192.168.1.0/24",
+		"10.0.0.5":       "10.0.0.5/32",
+		"fd00::1":        "fd00::1/128",
+		"fd00::/8":       "fd00::/8",
+	} {
+		prefix, err := ParseAllowedClient(client)
+		require.NoError(t, err)
+		require.Equal(t, want, prefix.String())
+	}
+
+	for _, client := range []string{"", "localhost", "192.168.1.0/33", "10.0.0.256"} {
+		_, err := ParseAllowedClient(client)
+		require.Error(t, err, client)
+	}
+}
+
+func TestIsAllowedClient(t *testing.T) {
+	allowed := []netip.Prefix{netip.MustParsePrefix("192.168.1.0/24")}
+
+	tcpAddr := func(ip string) net.Addr {
+		return &net.TCPAddr{IP: net.ParseIP(ip), Port: 1143}
+	}
+
+	// Loopback clients are always allowed.
+	require.True(t, IsAllowedClient(tcpAddr("127.0.0.1"), nil))
+	require.True(t, IsAllowedClient(tcpAddr("::1"), nil))
+
+	// Remote clients are allowed only if they belong to an allowed prefix, even when IPv4-mapped.
+	require.True(t, IsAllowedClient(tcpAddr("192.168.1.20"), allowed))
+	require.True(t, IsAllowedClient(tcpAddr("::ffff:192.168.1.20"), allowed))
+	require.False(t, IsAllowedClient(tcpAddr("192.168.2.20"), allowed))
+	require.False(t, IsAllowedClient(tcpAddr("192.168.1.20"), nil))
+}
+
+func TestFilterListener(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	var allow atomic.Bool
+
+	listener := NewFilterListener(l, func(net.Addr) bool { return allow.Load() })
+	defer listener.Close()
+
+	// The refused connection is closed without being handed out.
+	refused, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+	defer refused.Close()
+
+	acceptCh := make(chan net.Conn)
+
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			acceptCh <- conn
+		}
+	}()
+
+	_, err = refused.Read(make([]byte, 1))
+	require.Error(t, err)
+
+	// The next connection is allowed.
+	allow.Store(true)
+
+	allowed, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+	defer allowed.Close()
+
+	conn := <-acceptCh
+	defer conn.Close()
+
+	require.Equal(t, allowed.LocalAddr().String(), conn.RemoteAddr().String())
+}
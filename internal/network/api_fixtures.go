@@ -0,0 +1,383 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package network
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+var ErrNoFixture = errors.New("no recorded fixture for request")
+
+// redacted replaces the secrets stripped from recorded fixtures.
+const redacted = "<redacted>"
+
+// redactedHeaders are the headers carrying the session of the user.
+var redactedHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "X-Pm-Uid"}
+
+// redactedFields are the JSON fields carrying tokens, key material or login proofs.
+// BodyKey and AttachmentKeys hold the cleartext session keys of messages sent to external recipients.
+var redactedFields = map[string]struct{}{
+	"AccessToken":     {},
+	"RefreshToken":    {},
+	"UID":             {},
+	"PrivateKey":      {},
+	"Token":           {},
+	"KeySalt":         {},
+	"ClientProof":     {},
+	"ClientEphemeral": {},
+	"ServerProof":     {},
+	"ServerEphemeral": {},
+	"SRPSession":      {},
+	"TwoFactorCode":   {},
+	"BodyKey":         {},
+	"AttachmentKeys":  {},
+}
+
+// Fixture is one API request and its response, as recorded by a FixtureRecorder.
+type Fixture struct {
+	Time   time.Time
+	Method string
+	Path   string
+	Status int
+	Error  string `json:",omitempty"`
+
+	Request  FixtureBody
+	Response FixtureBody
+}
+
+// FixtureBody holds the headers and body of a recorded request or response.
+// JSON bodies are kept readable; anything else is stored as is.
+type FixtureBody struct {
+	Header http.Header     `json:",omitempty"`
+	JSON   json.RawMessage `json:",omitempty"`
+	Raw    []byte          `json:",omitempty"`
+}
+
+// FixtureRecorder is a round tripper which, while recording, writes every API request and its response
+// to a fixture file, one JSON object per line, with the secrets stripped.
+// The file can be attached to a bug report and replayed with a FixtureReplayer.
+type FixtureRecorder struct {
+	rt http.RoundTripper
+
+	file *os.File
+	enc  *json.Encoder
+	lock sync.Mutex
+}
+
+// NewFixtureRecorder returns a new FixtureRecorder on top of the given round tripper, which doesn't record until started.
+func NewFixtureRecorder(rt http.RoundTripper) *FixtureRecorder {
+	return &FixtureRecorder{rt: rt}
+}
+
+// Start records the following requests to a new fixture file in the given directory and returns its path.
+func (r *FixtureRecorder) Start(dir string) (string, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.file != nil {
+		return r.file.Name(), nil
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("api_fixtures_%v.jsonl", time.Now().Format("20060102_150405")))
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600) //nolint:gosec
+	if err != nil {
+		return "", fmt.Errorf("failed to create fixture file: %w", err)
+	}
+
+	r.file = file
+	r.enc = json.NewEncoder(file)
+	r.enc.SetEscapeHTML(false)
+
+	return path, nil
+}
+
+// Stop stops recording and closes the fixture file.
+func (r *FixtureRecorder) Stop() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+
+	err := r.file.Close()
+
+	r.file = nil
+	r.enc = nil
+
+	return err
+}
+
+// Path returns the path of the fixture file being recorded to, or an empty string if not recording.
+func (r *FixtureRecorder) Path() string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.file == nil {
+		return ""
+	}
+
+	return r.file.Name()
+}
+
+func (r *FixtureRecorder) isRecording() bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.file != nil
+}
+
+func (r *FixtureRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !r.isRecording() {
+		return r.rt.RoundTrip(req)
+	}
+
+	fixture := Fixture{
+		Time:   time.Now(),
+		Method: req.Method,
+		Path:   req.URL.RequestURI(),
+	}
+
+	if req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		_ = req.Body.Close()
+
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(body))
+
+		fixture.Request = newFixtureBody(req.Header, body)
+	} else {
+		fixture.Request = newFixtureBody(req.Header, nil)
+	}
+
+	res, err := r.rt.RoundTrip(req)
+	if err != nil {
+		fixture.Error = err.Error()
+		r.record(fixture)
+
+		return nil, err
+	}
+
+	body, err := io.ReadAll(res.Body)
+	_ = res.Body.Close()
+
+	res.Body = io.NopCloser(bytes.NewReader(body))
+
+	if err != nil {
+		return nil, err
+	}
+
+	fixture.Status = res.StatusCode
+	fixture.Response = newFixtureBody(res.Header, body)
+
+	r.record(fixture)
+
+	return res, nil
+}
+
+func (r *FixtureRecorder) record(fixture Fixture) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	// Recording may have stopped while the request was in flight.
+	if r.enc == nil {
+		return
+	}
+
+	_ = r.enc.Encode(fixture)
+}
+
+func newFixtureBody(header http.Header, body []byte) FixtureBody {
+	fixtureBody := FixtureBody{Header: header.Clone()}
+
+	for _, name := range redactedHeaders {
+		if fixtureBody.Header.Get(name) != "" {
+			fixtureBody.Header.Set(name, redacted)
+		}
+	}
+
+	if len(body) == 0 {
+		return fixtureBody
+	}
+
+	var data any
+
+	if err := json.Unmarshal(body, &data); err != nil {
+		fixtureBody.Raw = body
+		return fixtureBody
+	}
+
+	var buf bytes.Buffer
+
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+
+	if err := enc.Encode(redactJSON(data)); err == nil {
+		fixtureBody.JSON = bytes.TrimSpace(buf.Bytes())
+	}
+
+	return fixtureBody
+}
+
+func redactJSON(data any) any {
+	switch data := data.(type) {
+	case map[string]any:
+		for key, value := range data {
+			if _, ok := redactedFields[key]; ok {
+				data[key] = redacted
+			} else {
+				data[key] = redactJSON(value)
+			}
+		}
+
+	case []any:
+		for i, value := range data {
+			data[i] = redactJSON(value)
+		}
+	}
+
+	return data
+}
+
+// LoadFixtures reads the fixtures recorded to the given file.
+func LoadFixtures(path string) ([]Fixture, error) {
+	file, err := os.Open(path) //nolint:gosec
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	var fixtures []Fixture
+
+	for dec := json.NewDecoder(file); ; {
+		var fixture Fixture
+
+		if err := dec.Decode(&fixture); err != nil {
+			if errors.Is(err, io.EOF) {
+				return fixtures, nil
+			}
+
+			return nil, fmt.Errorf("invalid fixture file: %w", err)
+		}
+
+		fixtures = append(fixtures, fixture)
+	}
+}
+
+// FixtureReplayer is a round tripper which answers requests with recorded fixtures instead of contacting the API.
+// Each request gets the response of the first fixture not yet replayed with the same method and path.
+type FixtureReplayer struct {
+	fixtures []Fixture
+	replayed []bool
+	lock     sync.Mutex
+}
+
+// NewFixtureReplayer returns a new FixtureReplayer replaying the given fixtures.
+func NewFixtureReplayer(fixtures []Fixture) *FixtureReplayer {
+	return &FixtureReplayer{
+		fixtures: fixtures,
+		replayed: make([]bool, len(fixtures)),
+	}
+}
+
+func (r *FixtureReplayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	fixture, ok := r.next(req.Method, req.URL.RequestURI())
+	if !ok {
+		return nil, fmt.Errorf("%w: %v %v", ErrNoFixture, req.Method, req.URL.RequestURI())
+	}
+
+	if fixture.Error != "" {
+		return nil, errors.New(fixture.Error)
+	}
+
+	body := fixture.Response.Raw
+
+	if fixture.Response.JSON != nil {
+		body = fixture.Response.JSON
+	}
+
+	header := fixture.Response.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+
+	// Redacted bodies don't have their original length.
+	header.Del("Content-Length")
+
+	// The recorded date would make old fixtures look like the local clock is wrong.
+	header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", fixture.Status, http.StatusText(fixture.Status)),
+		StatusCode:    fixture.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// Remaining returns the fixtures which haven't been replayed yet.
+func (r *FixtureReplayer) Remaining() []Fixture {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var remaining []Fixture
+
+	for i, fixture := range r.fixtures {
+		if !r.replayed[i] {
+			remaining = append(remaining, fixture)
+		}
+	}
+
+	return remaining
+}
+
+func (r *FixtureReplayer) next(method, path string) (Fixture, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for i, fixture := range r.fixtures {
+		if r.replayed[i] || fixture.Method != method || fixture.Path != path {
+			continue
+		}
+
+		r.replayed[i] = true
+
+		return fixture, true
+	}
+
+	return Fixture{}, false
+}
@@ -0,0 +1,174 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package network
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFixtureRecorder_RecordAndReplay(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.URL.Path {
+		case "/auth/v4":
+			_, _ = w.Write([]byte(`{"Code":1000,"UID":"uid","AccessToken":"secret","RefreshToken":"secret"}`))
+
+		case "/mail/v4/attachments/1":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			_, _ = w.Write([]byte{0xde, 0xad})
+
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"Code":2501,"Error":"not found"}`))
+		}
+	}))
+	defer s.Close()
+
+	recorder := NewFixtureRecorder(http.DefaultTransport)
+	c := &http.Client{Transport: recorder}
+
+	// Nothing is recorded until started.
+	get(t, c, s.URL+"/mail/v4/attachments/1")
+	require.Empty(t, recorder.Path())
+
+	path, err := recorder.Start(t.TempDir())
+	require.NoError(t, err)
+	require.Equal(t, path, recorder.Path())
+
+	req, err := http.NewRequest(http.MethodPost, s.URL+"/auth/v4", bytes.NewReader([]byte(`{"Username":"user","ClientProof":"proof"}`)))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer secret")
+
+	res, err := c.Do(req)
+	require.NoError(t, err)
+
+	// The client still gets the unredacted response.
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+	require.Contains(t, string(body), `"AccessToken":"secret"`)
+
+	get(t, c, s.URL+"/mail/v4/attachments/1")
+	get(t, c, s.URL+"/mail/v4/messages/2?Page=1")
+
+	require.NoError(t, recorder.Stop())
+
+	fixtures, err := LoadFixtures(path)
+	require.NoError(t, err)
+	require.Len(t, fixtures, 3)
+
+	// Secrets are stripped from the fixtures.
+	require.Equal(t, "/auth/v4", fixtures[0].Path)
+	require.Equal(t, redacted, fixtures[0].Request.Header.Get("Authorization"))
+	require.JSONEq(t, `{"Username":"user","ClientProof":"<redacted>"}`, string(fixtures[0].Request.JSON))
+	require.JSONEq(t, `{"Code":1000,"UID":"<redacted>","AccessToken":"<redacted>","RefreshToken":"<redacted>"}`, string(fixtures[0].Response.JSON))
+
+	require.Equal(t, []byte{0xde, 0xad}, fixtures[1].Response.Raw)
+	require.Equal(t, "/mail/v4/messages/2?Page=1", fixtures[2].Path)
+	require.Equal(t, http.StatusNotFound, fixtures[2].Status)
+
+	// The fixtures are replayed without the server.
+	replayer := NewFixtureReplayer(fixtures)
+	c = &http.Client{Transport: replayer}
+
+	res, err = c.Get("http://offline/mail/v4/messages/2?Page=1")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNotFound, res.StatusCode)
+	require.NoError(t, res.Body.Close())
+
+	require.Equal(t, []byte{0xde, 0xad}, get(t, c, "http://offline/mail/v4/attachments/1"))
+
+	// Each fixture is replayed once.
+	_, err = c.Get("http://offline/mail/v4/attachments/1") //nolint:bodyclose
+	require.ErrorIs(t, err, ErrNoFixture)
+
+	require.Len(t, replayer.Remaining(), 1)
+}
+
+func TestFixtureRecorder_RedactSessionKeys(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"Code":1000}`))
+	}))
+	defer s.Close()
+
+	recorder := NewFixtureRecorder(http.DefaultTransport)
+	c := &http.Client{Transport: recorder}
+
+	path, err := recorder.Start(t.TempDir())
+	require.NoError(t, err)
+
+	// Clear and MIME packages carry the session keys of the body and attachments in cleartext.
+	req, err := http.NewRequest(http.MethodPost, s.URL+"/mail/v4/messages/1", bytes.NewReader([]byte(`{"Packages":[{
+		"Type":4,
+		"Body":"body",
+		"BodyKey":{"Key":"c2Vzc2lvbg==","Algorithm":"aes256"},
+		"AttachmentKeys":{"att":{"Key":"c2Vzc2lvbg==","Algorithm":"aes256"}}
+	}]}`)))
+	require.NoError(t, err)
+
+	res, err := c.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+
+	require.NoError(t, recorder.Stop())
+
+	fixtures, err := LoadFixtures(path)
+	require.NoError(t, err)
+	require.Len(t, fixtures, 1)
+
+	require.NotContains(t, string(fixtures[0].Request.JSON), "c2Vzc2lvbg==")
+	require.JSONEq(t, `{"Packages":[{
+		"Type":4,
+		"Body":"body",
+		"BodyKey":"<redacted>",
+		"AttachmentKeys":"<redacted>"
+	}]}`, string(fixtures[0].Request.JSON))
+
+	// Replayed responses are dated now rather than when they were recorded.
+	require.NotEmpty(t, fixtures[0].Response.Header.Get("Date"))
+	fixtures[0].Response.Header.Set("Date", time.Now().Add(-24*time.Hour).UTC().Format(http.TimeFormat))
+
+	res, err = (&http.Client{Transport: NewFixtureReplayer(fixtures)}).Post("http://offline/mail/v4/messages/1", "application/json", nil)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+
+	date, err := http.ParseTime(res.Header.Get("Date"))
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now(), date, time.Minute)
+}
+
+func get(t *testing.T, c *http.Client, url string) []byte {
+	res, err := c.Get(url)
+	require.NoError(t, err)
+
+	defer func() { require.NoError(t, res.Body.Close()) }()
+
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	return body
+}
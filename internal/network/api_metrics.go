@@ -0,0 +1,65 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package network
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/telemetry/metrics"
+)
+
+var apiRequestDuration = metrics.NewHistogramVec(
+	"bridge_api_request_duration_seconds",
+	"Duration of the API requests by method, API service (e.g. mail or core) and response status, or error if there was no response.",
+	metrics.DefaultBuckets,
+	"method", "service", "status",
+)
+
+// MetricsRoundTripper is a round tripper which records the duration of the requests it passes on.
+type MetricsRoundTripper struct {
+	rt http.RoundTripper
+}
+
+func NewMetricsRoundTripper(rt http.RoundTripper) *MetricsRoundTripper {
+	return &MetricsRoundTripper{rt: rt}
+}
+
+func (m *MetricsRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+
+	res, err := m.rt.RoundTrip(req)
+
+	status := "error"
+	if err == nil {
+		status = strconv.Itoa(res.StatusCode)
+	}
+
+	apiRequestDuration.WithLabelValues(req.Method, getAPIService(req.URL.Path), status).Observe(time.Since(start).Seconds())
+
+	return res, err
+}
+
+// getAPIService returns the first segment of the given API path, which names the API service, e.g. mail.
+func getAPIService(path string) string {
+	service, _, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+
+	return service
+}
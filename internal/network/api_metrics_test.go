@@ -0,0 +1,52 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package network
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsRoundTripper(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	}))
+	defer s.Close()
+
+	c := &http.Client{Transport: NewMetricsRoundTripper(http.DefaultTransport)}
+
+	ok := apiRequestDuration.WithLabelValues(http.MethodGet, "mail", "422")
+	failed := apiRequestDuration.WithLabelValues(http.MethodGet, "core", "error")
+
+	okCount, failedCount := ok.Count(), failed.Count()
+
+	res, err := c.Get(s.URL + "/mail/v4/messages/ID")
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+
+	s.Close()
+
+	_, err = c.Get(s.URL + "/core/v4/users") //nolint:bodyclose
+	require.Error(t, err)
+
+	require.Equal(t, okCount+1, ok.Count())
+	require.Equal(t, failedCount+1, failed.Count())
+}
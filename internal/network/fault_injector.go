@@ -0,0 +1,129 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package network
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var ErrInjectedFault = errors.New("injected fault: connection dropped")
+
+// Faults configures the faults a FaultInjector injects into the API traffic.
+type Faults struct {
+	// TooManyRequests is the fraction of requests answered with 429 Too Many Requests.
+	TooManyRequests float64
+
+	// DroppedConnections is the fraction of requests failing as if the connection dropped.
+	DroppedConnections float64
+
+	// Latency is added to every request.
+	Latency time.Duration
+}
+
+// IsZero returns whether no fault is injected.
+func (f Faults) IsZero() bool {
+	return f == Faults{}
+}
+
+// FaultInjector is a round tripper which injects rate limiting, dropped connections and latency into the requests
+// it passes on, so that how bridge copes with an unreliable API can be exercised at will.
+type FaultInjector struct {
+	rt http.RoundTripper
+
+	// random returns a number in [0, 1) deciding which fault, if any, a request gets.
+	random func() float64
+
+	faults Faults
+	lock   sync.RWMutex
+}
+
+// NewFaultInjector returns a new FaultInjector on top of the given round tripper, which injects no fault until set.
+func NewFaultInjector(rt http.RoundTripper) *FaultInjector {
+	return &FaultInjector{rt: rt, random: rand.Float64} //nolint:gosec
+}
+
+// SetFaults sets the faults injected into the following requests.
+func (f *FaultInjector) SetFaults(faults Faults) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.faults = faults
+}
+
+// GetFaults returns the faults injected into the requests.
+func (f *FaultInjector) GetFaults() Faults {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	return f.faults
+}
+
+func (f *FaultInjector) RoundTrip(req *http.Request) (*http.Response, error) {
+	faults := f.GetFaults()
+
+	if faults.IsZero() {
+		return f.rt.RoundTrip(req)
+	}
+
+	if faults.Latency > 0 {
+		timer := time.NewTimer(faults.Latency)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+
+	switch r := f.random(); {
+	case r < faults.TooManyRequests:
+		return newTooManyRequestsResponse(req), nil
+
+	case r < faults.TooManyRequests+faults.DroppedConnections:
+		return nil, ErrInjectedFault
+	}
+
+	return f.rt.RoundTrip(req)
+}
+
+func newTooManyRequestsResponse(req *http.Request) *http.Response {
+	body := []byte(`{"Code":2028,"Error":"Injected fault: too many requests"}`)
+
+	return &http.Response{
+		Status:     "429 Too Many Requests",
+		StatusCode: http.StatusTooManyRequests,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header: http.Header{
+			"Content-Type": []string{"application/json"},
+			"Retry-After":  []string{"1"},
+		},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
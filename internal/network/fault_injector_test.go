@@ -0,0 +1,70 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package network
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFaultInjector(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer s.Close()
+
+	injector := NewFaultInjector(http.DefaultTransport)
+	c := &http.Client{Transport: injector}
+
+	status := func() int {
+		res, err := c.Get(s.URL)
+		if err != nil {
+			return 0
+		}
+
+		require.NoError(t, res.Body.Close())
+
+		return res.StatusCode
+	}
+
+	// No fault is injected by default.
+	require.True(t, injector.GetFaults().IsZero())
+	require.Equal(t, http.StatusOK, status())
+
+	// Faults are picked by where the random number falls.
+	injector.SetFaults(Faults{TooManyRequests: 0.2, DroppedConnections: 0.3})
+
+	injector.random = func() float64 { return 0.1 }
+	require.Equal(t, http.StatusTooManyRequests, status())
+
+	injector.random = func() float64 { return 0.4 }
+	_, err := c.Get(s.URL) //nolint:bodyclose
+	require.ErrorIs(t, err, ErrInjectedFault)
+
+	injector.random = func() float64 { return 0.6 }
+	require.Equal(t, http.StatusOK, status())
+
+	// Latency delays every request.
+	injector.SetFaults(Faults{Latency: 100 * time.Millisecond})
+
+	start := time.Now()
+	require.Equal(t, http.StatusOK, status())
+	require.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+}
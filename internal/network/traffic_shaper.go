@@ -0,0 +1,113 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package network
+
+import (
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// TrafficShaper is a round tripper which, while a jitter window is set, holds requests back and releases them
+// together after a random delay within that window. The timing of the traffic then no longer reveals exactly when
+// messages were read or sent, at the cost of every request being delayed by up to the window.
+type TrafficShaper struct {
+	rt http.RoundTripper
+
+	// delay returns how long after its first request a batch is released.
+	delay func(window time.Duration) time.Duration
+
+	window  time.Duration
+	waiting []chan struct{}
+	timer   *time.Timer
+	lock    sync.Mutex
+}
+
+// NewTrafficShaper returns a new TrafficShaper on top of the given round tripper, which lets requests through
+// as they come until a jitter window is set.
+func NewTrafficShaper(rt http.RoundTripper) *TrafficShaper {
+	return &TrafficShaper{rt: rt, delay: randomDelay}
+}
+
+// SetWindow sets the window within which held back requests are released; zero disables shaping.
+func (s *TrafficShaper) SetWindow(window time.Duration) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.window = window
+
+	// Don't keep requests waiting on a window which no longer applies.
+	if s.timer != nil && s.timer.Stop() {
+		s.releaseLocked()
+	}
+}
+
+func (s *TrafficShaper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if waitCh := s.wait(); waitCh != nil {
+		select {
+		case <-waitCh:
+
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+
+	return s.rt.RoundTrip(req)
+}
+
+// wait returns a channel closed when the current batch is released, or nil if shaping is disabled.
+// The first request of a batch schedules its release.
+func (s *TrafficShaper) wait() <-chan struct{} {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.window <= 0 {
+		return nil
+	}
+
+	waitCh := make(chan struct{})
+
+	s.waiting = append(s.waiting, waitCh)
+
+	if s.timer == nil {
+		s.timer = time.AfterFunc(s.delay(s.window), s.release)
+	}
+
+	return waitCh
+}
+
+func (s *TrafficShaper) release() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.releaseLocked()
+}
+
+func (s *TrafficShaper) releaseLocked() {
+	for _, waitCh := range s.waiting {
+		close(waitCh)
+	}
+
+	s.waiting = nil
+	s.timer = nil
+}
+
+func randomDelay(window time.Duration) time.Duration {
+	return time.Duration(rand.Int63n(int64(window))) //nolint:gosec
+}
@@ -0,0 +1,107 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package network
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrafficShaper_Disabled(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer s.Close()
+
+	c := &http.Client{Transport: NewTrafficShaper(http.DefaultTransport)}
+
+	// Requests go through right away.
+	res, err := c.Get(s.URL)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+}
+
+func TestTrafficShaper_Batch(t *testing.T) {
+	var (
+		times []time.Time
+		lock  sync.Mutex
+	)
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		times = append(times, time.Now())
+	}))
+	defer s.Close()
+
+	shaper := NewTrafficShaper(http.DefaultTransport)
+	shaper.SetWindow(time.Second)
+
+	// Release each batch at the end of the window.
+	shaper.delay = func(window time.Duration) time.Duration { return window }
+
+	c := &http.Client{Transport: shaper}
+
+	var wg sync.WaitGroup
+
+	// Requests made at different times...
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			res, err := c.Get(s.URL)
+			require.NoError(t, err)
+			require.NoError(t, res.Body.Close())
+		}()
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	wg.Wait()
+
+	// ... are sent together.
+	require.Len(t, times, 3)
+	require.WithinDuration(t, times[0], times[2], 20*time.Millisecond)
+}
+
+func TestTrafficShaper_Cancel(t *testing.T) {
+	shaper := NewTrafficShaper(http.DefaultTransport)
+	shaper.SetWindow(time.Hour)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://127.0.0.1:1", nil)
+	require.NoError(t, err)
+
+	// A request given up on while held back fails with the context's error.
+	_, err = shaper.RoundTrip(req) //nolint:bodyclose
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// Disabling shaping releases the held back requests.
+	waitCh := shaper.wait()
+	shaper.SetWindow(0)
+	<-waitCh
+}
@@ -0,0 +1,306 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package savedsearch parses and evaluates the queries backing saved-search mailboxes.
+//
+// A query is a list of terms which must all match. Supported terms are:
+//
+//	from:<text>        the sender contains text
+//	to:<text>          a To, Cc or Bcc recipient contains text
+//	subject:<text>     the subject contains text
+//	label:<name>       the message has the label or folder (ID, name or path); in: is an alias
+//	is:<state>         one of unread, read, flagged (or starred), answered, draft
+//	has:attachment     the message has attachments
+//	newer:<age>        the message is younger than age, e.g. 12h, 7d or 2w
+//	older:<age>        the message is older than age
+//	<text>             the subject or sender contains text
+//
+// Values may be quoted to include spaces, and terms prefixed with - are negated.
+package savedsearch
+
+import (
+	"fmt"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/go-proton-api"
+	"golang.org/x/exp/slices"
+)
+
+// Query is a parsed saved-search query.
+type Query struct {
+	terms []term
+}
+
+type matchFunc func(message proton.MessageMetadata, labels map[string]proton.Label, now time.Time) bool
+
+type term struct {
+	match  matchFunc
+	negate bool
+	age    bool
+}
+
+// Parse parses the given query.
+func Parse(query string) (*Query, error) {
+	tokens, err := tokenize(query)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty query")
+	}
+
+	terms := make([]term, 0, len(tokens))
+
+	for _, token := range tokens {
+		term, err := parseTerm(token)
+		if err != nil {
+			return nil, err
+		}
+
+		terms = append(terms, term)
+	}
+
+	return &Query{terms: terms}, nil
+}
+
+// Match returns whether the given message matches the query.
+// The labels are used to resolve label names; now is the reference for age terms.
+func (q *Query) Match(message proton.MessageMetadata, labels map[string]proton.Label, now time.Time) bool {
+	for _, term := range q.terms {
+		if term.match(message, labels, now) == term.negate {
+			return false
+		}
+	}
+
+	return true
+}
+
+// HasAge returns whether the query depends on the age of messages.
+// Such queries must be re-evaluated as time passes.
+func (q *Query) HasAge() bool {
+	return slices.ContainsFunc(q.terms, func(t term) bool { return t.age })
+}
+
+func parseTerm(token string) (term, error) {
+	var negate bool
+
+	if len(token) > 1 && strings.HasPrefix(token, "-") {
+		negate, token = true, token[1:]
+	}
+
+	key, value, ok := strings.Cut(token, ":")
+	if !ok {
+		text := strings.ToLower(unquote(token))
+
+		return term{negate: negate, match: func(message proton.MessageMetadata, _ map[string]proton.Label, _ time.Time) bool {
+			return contains(message.Subject, text) || containsAddress([]*mail.Address{message.Sender}, text)
+		}}, nil
+	}
+
+	key, value = strings.ToLower(key), strings.ToLower(unquote(value))
+
+	if value == "" {
+		return term{}, fmt.Errorf("missing value for %q", key)
+	}
+
+	match, age, err := parseKeyValue(key, value)
+	if err != nil {
+		return term{}, err
+	}
+
+	return term{match: match, negate: negate, age: age}, nil
+}
+
+func parseKeyValue(key, value string) (matchFunc, bool, error) {
+	switch key {
+	case "from":
+		return func(message proton.MessageMetadata, _ map[string]proton.Label, _ time.Time) bool {
+			return containsAddress([]*mail.Address{message.Sender}, value)
+		}, false, nil
+
+	case "to":
+		return func(message proton.MessageMetadata, _ map[string]proton.Label, _ time.Time) bool {
+			return containsAddress(message.ToList, value) ||
+				containsAddress(message.CCList, value) ||
+				containsAddress(message.BCCList, value)
+		}, false, nil
+
+	case "subject":
+		return func(message proton.MessageMetadata, _ map[string]proton.Label, _ time.Time) bool {
+			return contains(message.Subject, value)
+		}, false, nil
+
+	case "label", "in":
+		return func(message proton.MessageMetadata, labels map[string]proton.Label, _ time.Time) bool {
+			return slices.ContainsFunc(message.LabelIDs, func(labelID string) bool {
+				return matchesLabel(labelID, labels, value)
+			})
+		}, false, nil
+
+	case "is":
+		match, err := parseState(value)
+		return match, false, err
+
+	case "has":
+		if value != "attachment" {
+			return nil, false, fmt.Errorf("unknown value %q for has", value)
+		}
+
+		return func(message proton.MessageMetadata, _ map[string]proton.Label, _ time.Time) bool {
+			return message.NumAttachments > 0
+		}, false, nil
+
+	case "newer", "older":
+		age, err := parseAge(value)
+		if err != nil {
+			return nil, false, err
+		}
+
+		newer := key == "newer"
+
+		return func(message proton.MessageMetadata, _ map[string]proton.Label, now time.Time) bool {
+			return time.Unix(message.Time, 0).After(now.Add(-age)) == newer
+		}, true, nil
+
+	default:
+		return nil, false, fmt.Errorf("unknown search term %q", key)
+	}
+}
+
+func parseState(value string) (matchFunc, error) {
+	switch value {
+	case "unread":
+		return func(message proton.MessageMetadata, _ map[string]proton.Label, _ time.Time) bool {
+			return !message.Seen()
+		}, nil
+
+	case "read":
+		return func(message proton.MessageMetadata, _ map[string]proton.Label, _ time.Time) bool {
+			return message.Seen()
+		}, nil
+
+	case "flagged", "starred":
+		return func(message proton.MessageMetadata, _ map[string]proton.Label, _ time.Time) bool {
+			return message.Starred()
+		}, nil
+
+	case "answered":
+		return func(message proton.MessageMetadata, _ map[string]proton.Label, _ time.Time) bool {
+			return bool(message.IsReplied) || bool(message.IsRepliedAll)
+		}, nil
+
+	case "draft":
+		return func(message proton.MessageMetadata, _ map[string]proton.Label, _ time.Time) bool {
+			return message.IsDraft()
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown value %q for is", value)
+	}
+}
+
+// parseAge parses an age such as 12h, 7d or 2w.
+func parseAge(value string) (time.Duration, error) {
+	units := map[byte]time.Duration{
+		'h': time.Hour,
+		'd': 24 * time.Hour,
+		'w': 7 * 24 * time.Hour,
+	}
+
+	if len(value) < 2 {
+		return 0, fmt.Errorf("invalid age %q", value)
+	}
+
+	unit, ok := units[value[len(value)-1]]
+	if !ok {
+		return 0, fmt.Errorf("invalid age unit in %q", value)
+	}
+
+	n, err := strconv.Atoi(value[:len(value)-1])
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid age %q", value)
+	}
+
+	return time.Duration(n) * unit, nil
+}
+
+// tokenize splits the query on whitespace, keeping double-quoted parts together.
+func tokenize(query string) ([]string, error) {
+	var (
+		tokens  []string
+		current strings.Builder
+		quoted  bool
+	)
+
+	for _, r := range query {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			current.WriteRune(r)
+
+		case !quoted && (r == ' ' || r == '\t'):
+			if current.Len() > 0 {
+				tokens = append(tokens, current.String())
+				current.Reset()
+			}
+
+		default:
+			current.WriteRune(r)
+		}
+	}
+
+	if quoted {
+		return nil, fmt.Errorf("unterminated quote in %q", query)
+	}
+
+	if current.Len() > 0 {
+		tokens = append(tokens, current.String())
+	}
+
+	return tokens, nil
+}
+
+func unquote(value string) string {
+	return strings.ReplaceAll(value, `"`, "")
+}
+
+func contains(s, text string) bool {
+	return strings.Contains(strings.ToLower(s), text)
+}
+
+func containsAddress(addresses []*mail.Address, text string) bool {
+	return slices.ContainsFunc(addresses, func(addr *mail.Address) bool {
+		return addr != nil && (contains(addr.Address, text) || contains(addr.Name, text))
+	})
+}
+
+func matchesLabel(labelID string, labels map[string]proton.Label, value string) bool {
+	if strings.EqualFold(labelID, value) {
+		return true
+	}
+
+	label, ok := labels[labelID]
+	if !ok {
+		return false
+	}
+
+	return strings.EqualFold(label.Name, value) || strings.EqualFold(strings.Join(label.Path, "/"), value)
+}
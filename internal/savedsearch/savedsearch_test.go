@@ -0,0 +1,96 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package savedsearch
+
+import (
+	"net/mail"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuery_Match(t *testing.T) {
+	now := time.Date(2023, 10, 10, 12, 0, 0, 0, time.UTC)
+
+	labels := map[string]proton.Label{
+		"work": {ID: "work", Name: "Work", Path: []string{"Projects", "Work"}, Type: proton.LabelTypeFolder},
+	}
+
+	message := proton.MessageMetadata{
+		Subject:        "Quarterly report",
+		Sender:         &mail.Address{Name: "Alice", Address: "alice@example.com"},
+		ToList:         []*mail.Address{{Address: "bob@example.com"}},
+		LabelIDs:       []string{proton.InboxLabel, proton.StarredLabel, "work"},
+		Time:           now.Add(-48 * time.Hour).Unix(),
+		Flags:          proton.MessageFlagReceived,
+		Unread:         true,
+		NumAttachments: 1,
+	}
+
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{query: "report", want: true},
+		{query: "alice", want: true},
+		{query: "from:alice@example.com", want: true},
+		{query: "from:bob", want: false},
+		{query: "to:bob", want: true},
+		{query: `subject:"quarterly report"`, want: true},
+		{query: "label:work", want: true},
+		{query: "in:projects/work", want: true},
+		{query: "label:personal", want: false},
+		{query: "is:flagged newer:1w", want: true},
+		{query: "is:flagged newer:1d", want: false},
+		{query: "older:1d", want: true},
+		{query: "is:unread has:attachment", want: true},
+		{query: "is:read", want: false},
+		{query: "-from:alice", want: false},
+		{query: "-is:draft", want: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			query, err := Parse(test.query)
+			require.NoError(t, err)
+			require.Equal(t, test.want, query.Match(message, labels, now))
+		})
+	}
+}
+
+func TestQuery_HasAge(t *testing.T) {
+	require.True(t, must(Parse("is:flagged newer:1w")).HasAge())
+	require.False(t, must(Parse("is:flagged")).HasAge())
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, query := range []string{"", "   ", "foo:bar", "is:unknown", "has:nothing", "newer:7", "newer:xd", "from:", `subject:"open`} {
+		_, err := Parse(query)
+		require.Error(t, err, query)
+	}
+}
+
+func must[T any](v T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+
+	return v
+}
@@ -0,0 +1,47 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+//go:build linux || darwin
+
+package secret
+
+import "golang.org/x/sys/unix"
+
+// alloc maps anonymous memory for a secret and locks it so it is never swapped to disk.
+func alloc(size int) ([]byte, error) {
+	data, err := unix.Mmap(-1, 0, size, unix.PROT_READ|unix.PROT_WRITE, unix.MAP_PRIVATE|unix.MAP_ANON)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := unix.Mlock(data); err != nil {
+		_ = unix.Munmap(data)
+		return nil, err
+	}
+
+	excludeFromCoreDump(data)
+
+	return data, nil
+}
+
+func free(data []byte) error {
+	if err := unix.Munlock(data); err != nil {
+		return err
+	}
+
+	return unix.Munmap(data)
+}
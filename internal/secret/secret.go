@@ -0,0 +1,139 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+// Package secret provides buffers for secrets that are wiped after use and, where the OS allows,
+// locked into memory so they are neither swapped to disk nor included in core dumps.
+package secret
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+)
+
+var locking atomic.Bool //nolint:gochecknoglobals
+
+func init() { //nolint:gochecknoinits
+	locking.Store(defaultLocking)
+}
+
+// SetLocking sets whether new buffers are locked into memory. Buffers already allocated are not affected.
+func SetLocking(enabled bool) {
+	locking.Store(enabled)
+}
+
+// IsLockingEnabled returns whether new buffers are locked into memory.
+func IsLockingEnabled() bool {
+	return locking.Load()
+}
+
+// Buffer holds a secret. It must be destroyed once the secret is no longer needed.
+type Buffer struct {
+	data   []byte
+	locked bool
+	lock   sync.Mutex
+}
+
+// New returns a zeroed buffer of the given size.
+func New(size int) *Buffer {
+	buf := &Buffer{}
+
+	if size == 0 {
+		return buf
+	}
+
+	if IsLockingEnabled() {
+		if data, err := alloc(size); err != nil {
+			logrus.WithError(err).Debug("Failed to lock secret into memory")
+		} else {
+			buf.data, buf.locked = data, true
+		}
+	}
+
+	if !buf.locked {
+		buf.data = make([]byte, size)
+	}
+
+	return buf
+}
+
+// NewFromBytes moves the given secret into a new buffer, wiping b.
+func NewFromBytes(b []byte) *Buffer {
+	buf := New(len(b))
+
+	copy(buf.data, b)
+
+	Wipe(b)
+
+	return buf
+}
+
+// Bytes returns the secret. The returned slice must not be used after the buffer is destroyed.
+func (buf *Buffer) Bytes() []byte {
+	buf.lock.Lock()
+	defer buf.lock.Unlock()
+
+	return buf.data
+}
+
+// Len returns the size of the secret.
+func (buf *Buffer) Len() int {
+	buf.lock.Lock()
+	defer buf.lock.Unlock()
+
+	return len(buf.data)
+}
+
+// IsLocked returns whether the secret is locked into memory.
+func (buf *Buffer) IsLocked() bool {
+	buf.lock.Lock()
+	defer buf.lock.Unlock()
+
+	return buf.locked
+}
+
+// Destroy wipes the secret and releases its memory. It is safe to call it more than once.
+func (buf *Buffer) Destroy() {
+	buf.lock.Lock()
+	defer buf.lock.Unlock()
+
+	if buf.data == nil {
+		return
+	}
+
+	Wipe(buf.data)
+
+	if buf.locked {
+		if err := free(buf.data); err != nil {
+			logrus.WithError(err).Error("Failed to release locked secret")
+		}
+	}
+
+	buf.data, buf.locked = nil, false
+}
+
+// Wipe overwrites b with zeros.
+func Wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+
+	// Make sure the wipe is not optimized away.
+	runtime.KeepAlive(b)
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package secret
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuffer_NewFromBytesWipesSource(t *testing.T) {
+	src := []byte("my secret")
+
+	buf := NewFromBytes(src)
+	defer buf.Destroy()
+
+	require.Equal(t, []byte("my secret"), buf.Bytes())
+	require.Equal(t, make([]byte, len(src)), src)
+}
+
+func TestBuffer_DestroyWipes(t *testing.T) {
+	// Locked memory is unmapped once destroyed, so check the wipe on regular memory.
+	SetLocking(false)
+	defer SetLocking(defaultLocking)
+
+	buf := NewFromBytes([]byte("my secret"))
+	require.False(t, buf.IsLocked())
+
+	data := buf.Bytes()
+
+	buf.Destroy()
+
+	require.Equal(t, make([]byte, len(data)), data)
+	require.Nil(t, buf.Bytes())
+
+	// Destroying twice is fine.
+	buf.Destroy()
+}
+
+func TestBuffer_Locked(t *testing.T) {
+	if !IsLockingEnabled() {
+		t.Skip("Locking is disabled")
+	}
+
+	buf := NewFromBytes([]byte("my secret"))
+	require.Equal(t, []byte("my secret"), buf.Bytes())
+
+	// Locking may be refused, e.g. if the memory lock limit is reached, in which case regular memory is used.
+	t.Logf("Buffer locked: %v", buf.IsLocked())
+
+	buf.Destroy()
+
+	require.Nil(t, buf.Bytes())
+	require.False(t, buf.IsLocked())
+}
+
+func TestWipe(t *testing.T) {
+	b := []byte("my secret")
+
+	Wipe(b)
+
+	require.Equal(t, make([]byte, len(b)), b)
+}
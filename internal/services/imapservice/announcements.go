@@ -0,0 +1,298 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package imapservice
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ProtonMail/gluon/imap"
+	"github.com/bradenaw/juniper/xslices"
+	"github.com/emersion/go-message/mail"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	announcementsMailboxName = "Proton Bridge"
+	announcementsMailboxID   = imap.MailboxID("bridge-announcements")
+	announcementIDPrefix     = "bridge-announcement-"
+)
+
+// announcementsSender is the sender of the announcements; it's not a real address, they are never sent.
+var announcementsSender = &mail.Address{Name: "Proton Mail Bridge", Address: "bridge@localhost"} //nolint:gochecknoglobals
+
+// Announcement is a message bridge posts to the read-only Proton Bridge mailbox, such as the notes of an update
+// or an error needing the user's attention. Announcements only exist locally, they are never uploaded.
+// They are identified by their ID: posting an announcement again under the same ID doesn't change it.
+type Announcement struct {
+	ID      string
+	Time    time.Time
+	Subject string
+	Body    string
+}
+
+// announcements holds the announcements posted to the Proton Bridge mailbox.
+// It is shared by the connectors of a user, which serve their literals and let the user dismiss them by deleting them.
+type announcements struct {
+	lock sync.Mutex
+
+	// posted are the announcements in the mailbox, with their literal, by message ID.
+	posted map[imap.MessageID]postedAnnouncement
+
+	// dismissed are the IDs of the announcements the user deleted, which aren't posted again.
+	dismissed map[string]struct{}
+}
+
+type postedAnnouncement struct {
+	Announcement
+	literal []byte
+}
+
+func newAnnouncements() *announcements {
+	return &announcements{
+		posted:    make(map[imap.MessageID]postedAnnouncement),
+		dismissed: make(map[string]struct{}),
+	}
+}
+
+// set replaces the posted announcements, returning those to add to the mailbox and the messages to delete.
+func (a *announcements) set(list []Announcement) ([]postedAnnouncement, []imap.MessageID, error) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	var added []postedAnnouncement
+
+	wanted := make(map[imap.MessageID]struct{}, len(list))
+
+	for _, announcement := range list {
+		if _, ok := a.dismissed[announcement.ID]; ok {
+			continue
+		}
+
+		id := announcementMessageID(announcement.ID)
+
+		wanted[id] = struct{}{}
+
+		if _, ok := a.posted[id]; ok {
+			continue
+		}
+
+		literal, err := buildAnnouncementLiteral(announcement)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to build announcement %q: %w", announcement.ID, err)
+		}
+
+		a.posted[id] = postedAnnouncement{Announcement: announcement, literal: literal}
+
+		added = append(added, a.posted[id])
+	}
+
+	var deleted []imap.MessageID
+
+	for id := range a.posted {
+		if _, ok := wanted[id]; !ok {
+			delete(a.posted, id)
+			deleted = append(deleted, id)
+		}
+	}
+
+	return added, deleted, nil
+}
+
+// all returns the posted announcements, oldest first.
+func (a *announcements) all() []postedAnnouncement {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	all := make([]postedAnnouncement, 0, len(a.posted))
+
+	for _, announcement := range a.posted {
+		all = append(all, announcement)
+	}
+
+	slices.SortFunc(all, func(a, b postedAnnouncement) bool {
+		return a.Time.Before(b.Time)
+	})
+
+	return all
+}
+
+func (a *announcements) getLiteral(id imap.MessageID) ([]byte, bool) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	announcement, ok := a.posted[id]
+
+	return announcement.literal, ok
+}
+
+// dismiss removes the given announcements, such that they aren't posted again.
+func (a *announcements) dismiss(ids []imap.MessageID) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	for _, id := range ids {
+		if announcement, ok := a.posted[id]; ok {
+			a.dismissed[announcement.ID] = struct{}{}
+			delete(a.posted, id)
+		}
+	}
+}
+
+func announcementMessageID(announcementID string) imap.MessageID {
+	return imap.MessageID(announcementIDPrefix + announcementID)
+}
+
+func isAnnouncement(id imap.MessageID) bool {
+	return strings.HasPrefix(string(id), announcementIDPrefix)
+}
+
+func containsAnnouncement(ids []imap.MessageID) bool {
+	return slices.ContainsFunc(ids, isAnnouncement)
+}
+
+func withoutAnnouncements(ids []imap.MessageID) []imap.MessageID {
+	return xslices.Filter(ids, func(id imap.MessageID) bool {
+		return !isAnnouncement(id)
+	})
+}
+
+func buildAnnouncementLiteral(announcement Announcement) ([]byte, error) {
+	var header mail.Header
+
+	header.SetAddressList("From", []*mail.Address{announcementsSender})
+	header.SetSubject(announcement.Subject)
+	header.SetDate(announcement.Time)
+	header.SetMessageID(announcement.ID + "@bridge.localhost")
+	header.SetContentType("text/plain", map[string]string{"charset": "utf-8"})
+
+	var buf bytes.Buffer
+
+	w, err := mail.CreateSingleInlineWriter(&buf, header)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := w.Write([]byte(announcement.Body)); err != nil {
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func newAnnouncementsMailboxCreatedUpdate() *imap.MailboxCreated {
+	return imap.NewMailboxCreated(imap.Mailbox{
+		ID:             announcementsMailboxID,
+		Name:           []string{announcementsMailboxName},
+		Flags:          defaultFlags,
+		PermanentFlags: defaultPermanentFlags,
+		Attributes:     imap.NewFlagSet(imap.AttrNoInferiors),
+	})
+}
+
+func newAnnouncementsCreated(list []postedAnnouncement) ([]*imap.MessageCreated, error) {
+	created := make([]*imap.MessageCreated, 0, len(list))
+
+	for _, announcement := range list {
+		parsedMessage, err := imap.NewParsedMessage(announcement.literal)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse announcement %q: %w", announcement.ID, err)
+		}
+
+		created = append(created, &imap.MessageCreated{
+			Message: imap.Message{
+				ID:    announcementMessageID(announcement.ID),
+				Flags: imap.NewFlagSet(),
+				Date:  announcement.Time,
+			},
+			Literal:       announcement.literal,
+			MailboxIDs:    []imap.MailboxID{announcementsMailboxID},
+			ParsedMessage: parsedMessage,
+		})
+	}
+
+	return created, nil
+}
+
+// newAnnouncementsCreatedUpdate adds the given announcements to the mailbox. The mailbox doesn't exist
+// until the user is synced, in which case the announcements are skipped and added along with it later.
+func newAnnouncementsCreatedUpdate(created []*imap.MessageCreated) *imap.MessagesCreated {
+	return imap.NewMessagesCreated(true, created...)
+}
+
+// setAnnouncements replaces the announcements posted to the Proton Bridge mailbox.
+// The updates aren't waited on: the connectors may be detached from gluon, e.g. after a bad event,
+// in which case they are applied once they are added back.
+func (s *Service) setAnnouncements(ctx context.Context, list []Announcement) error {
+	added, deleted, err := s.announcements.set(list)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range deleted {
+		s.publishToAll(ctx, func() imap.Update {
+			return imap.NewMessagesDeleted(id)
+		})
+	}
+
+	if len(added) > 0 {
+		created, err := newAnnouncementsCreated(added)
+		if err != nil {
+			return err
+		}
+
+		s.publishToAll(ctx, func() imap.Update {
+			return newAnnouncementsCreatedUpdate(created)
+		})
+	}
+
+	return nil
+}
+
+// rebuildAnnouncements re-creates the Proton Bridge mailbox from scratch.
+// It is done once the user is synced, as the announcements are not persisted.
+func (s *Service) rebuildAnnouncements(ctx context.Context) error {
+	created, err := newAnnouncementsCreated(s.announcements.all())
+	if err != nil {
+		return err
+	}
+
+	s.publishToAll(ctx, func() imap.Update {
+		return imap.NewMailboxDeleted(announcementsMailboxID)
+	})
+
+	s.publishToAll(ctx, func() imap.Update {
+		return newAnnouncementsMailboxCreatedUpdate()
+	})
+
+	if len(created) > 0 {
+		s.publishToAll(ctx, func() imap.Update {
+			return newAnnouncementsCreatedUpdate(created)
+		})
+	}
+
+	return nil
+}
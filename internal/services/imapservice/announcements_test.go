@@ -0,0 +1,60 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package imapservice
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/gluon/imap"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnnouncements_Set(t *testing.T) {
+	a := newAnnouncements()
+
+	update := Announcement{ID: "update", Time: time.Now(), Subject: "Update", Body: "Release notes"}
+	cert := Announcement{ID: "cert", Time: time.Now(), Subject: "Certificate", Body: "Expires soon"}
+
+	added, deleted, err := a.set([]Announcement{update, cert})
+	require.NoError(t, err)
+	require.Len(t, added, 2)
+	require.Empty(t, deleted)
+
+	literal, ok := a.getLiteral(announcementMessageID("update"))
+	require.True(t, ok)
+	require.Contains(t, string(literal), "Subject: Update")
+
+	// Announcements already posted aren't added again; those no longer wanted are deleted.
+	added, deleted, err = a.set([]Announcement{update})
+	require.NoError(t, err)
+	require.Empty(t, added)
+	require.Equal(t, []imap.MessageID{announcementMessageID("cert")}, deleted)
+
+	// Dismissed announcements aren't posted again.
+	a.dismiss([]imap.MessageID{announcementMessageID("update")})
+
+	added, deleted, err = a.set([]Announcement{update, cert})
+	require.NoError(t, err)
+	require.Len(t, added, 1)
+	require.Equal(t, "cert", added[0].ID)
+	require.Empty(t, deleted)
+
+	_, ok = a.getLiteral(announcementMessageID("update"))
+	require.False(t, ok)
+}
@@ -0,0 +1,203 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package imapservice
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/ProtonMail/proton-bridge/v3/pkg/message"
+	"github.com/sirupsen/logrus"
+)
+
+// buildMode holds the per-user options that change how messages are built. It is shared by the service,
+// its connectors and the sync message builder.
+//
+// In conversation mode, messages are built with normalized threading headers, so that clients thread them
+// like the web client groups conversations. Unread counts and STATUS are still computed per message by gluon's
+// database; reflecting the conversation grouping there needs support from gluon and conversation IDs in the
+// message metadata first.
+//
+// With UTF-8 transcoding, text parts and header fields in legacy charsets such as KOI8-R or GB2312 are
+// converted to UTF-8 for clients with poor charset support. Turning it off serves the original charsets again.
+//
+// The header template adds the user's own header fields, such as the delivery address or the message's labels,
+// for client side filters to act on.
+//
+// With contact display names, the From display name is replaced by the name the user gave the sender in their
+// contacts, like the web client shows it.
+//
+// Date normalization converts Date headers to the user's time zone and replaces the ones clients sort badly,
+// missing, unparsable or far in the future, with the time the server received the message. INTERNALDATE is
+// already that time, so clients sorting by either now agree.
+type buildMode struct {
+	conversation        atomic.Bool
+	transcodeToUTF8     atomic.Bool
+	headerTemplate      atomic.Pointer[message.HeaderTemplate]
+	contactDisplayNames atomic.Bool
+	dateLocation        atomic.Pointer[time.Location]
+	repairDates         atomic.Bool
+
+	names    message.HeaderNames
+	contacts *contactNames
+}
+
+func newBuildMode(
+	conversation, transcodeToUTF8 bool,
+	headerTemplate *message.HeaderTemplate,
+	contactDisplayNames bool,
+	dateLocation *time.Location,
+	repairDates bool,
+	names message.HeaderNames,
+) *buildMode {
+	mode := &buildMode{names: names, contacts: newContactNames()}
+
+	mode.conversation.Store(conversation)
+	mode.transcodeToUTF8.Store(transcodeToUTF8)
+	mode.headerTemplate.Store(headerTemplate)
+	mode.contactDisplayNames.Store(contactDisplayNames)
+	mode.dateLocation.Store(dateLocation)
+	mode.repairDates.Store(repairDates)
+
+	return mode
+}
+
+// jobOpts returns the options to build messages with.
+func (m *buildMode) jobOpts() message.JobOptions {
+	opts := defaultMessageJobOpts()
+
+	if m != nil {
+		opts.NormalizeThreading = m.conversation.Load()
+		opts.TranscodeToUTF8 = m.transcodeToUTF8.Load()
+		opts.HeaderTemplate = m.headerTemplate.Load()
+		opts.HeaderNames = m.names
+		opts.DateLocation = m.dateLocation.Load()
+		opts.RepairDate = m.repairDates.Load()
+
+		if m.contactDisplayNames.Load() {
+			opts.ContactNames = m.contacts
+		}
+	}
+
+	return opts
+}
+
+// setConversationMode changes whether messages are built with normalized threading headers.
+// Messages already known to gluon keep their literal, so a resync is triggered to rebuild them.
+func (s *Service) setConversationMode(ctx context.Context, enabled bool) error {
+	if s.buildMode.conversation.Swap(enabled) == enabled {
+		return nil
+	}
+
+	s.log.WithField("enabled", enabled).Info("Conversation mode changed, resyncing")
+
+	return s.HandleRefreshEvent(ctx, 0)
+}
+
+// setTranscodeToUTF8 changes whether messages are built with their text transcoded to UTF-8.
+// Messages already known to gluon keep their literal, so a resync is triggered to rebuild them.
+func (s *Service) setTranscodeToUTF8(ctx context.Context, enabled bool) error {
+	if s.buildMode.transcodeToUTF8.Swap(enabled) == enabled {
+		return nil
+	}
+
+	s.log.WithField("enabled", enabled).Info("UTF-8 transcoding changed, resyncing")
+
+	return s.HandleRefreshEvent(ctx, 0)
+}
+
+// setHeaderTemplate changes the template of extra header fields messages are built with; nil removes it.
+// Messages already known to gluon keep their literal, so a resync is triggered to rebuild them.
+func (s *Service) setHeaderTemplate(ctx context.Context, tmpl *message.HeaderTemplate) error {
+	if old := s.buildMode.headerTemplate.Swap(tmpl); old.String() == tmpl.String() {
+		return nil
+	}
+
+	s.log.Info("Header template changed, resyncing")
+
+	return s.HandleRefreshEvent(ctx, 0)
+}
+
+// setContactDisplayNames changes whether messages are built with the senders' contact names as From display names.
+// Messages already known to gluon keep their literal, so a resync is triggered to rebuild them, which also reloads
+// the contacts.
+func (s *Service) setContactDisplayNames(ctx context.Context, enabled bool) error {
+	if s.buildMode.contactDisplayNames.Swap(enabled) == enabled {
+		return nil
+	}
+
+	s.log.WithField("enabled", enabled).Info("Contact display names changed, resyncing")
+
+	return s.HandleRefreshEvent(ctx, 0)
+}
+
+// setDateNormalization changes the time zone Date headers are converted to, nil keeping them as sent, and whether
+// invalid ones are repaired. Messages already known to gluon keep their literal, so a resync is triggered to rebuild them.
+func (s *Service) setDateNormalization(ctx context.Context, loc *time.Location, repair bool) error {
+	oldLoc := s.buildMode.dateLocation.Swap(loc)
+	oldRepair := s.buildMode.repairDates.Swap(repair)
+
+	if dateLocationName(oldLoc) == dateLocationName(loc) && oldRepair == repair {
+		return nil
+	}
+
+	s.log.WithFields(logrus.Fields{
+		"timeZone": dateLocationName(loc),
+		"repair":   repair,
+	}).Info("Date normalization changed, resyncing")
+
+	return s.HandleRefreshEvent(ctx, 0)
+}
+
+// dateLocationName returns the name of the time zone Date headers are converted to; nil, keeping them as sent,
+// has none. It differs from (*time.Location).String, which returns UTC for nil.
+func dateLocationName(loc *time.Location) string {
+	if loc == nil {
+		return ""
+	}
+
+	return loc.String()
+}
+
+// loadContactNamesIfEnabled reloads the contact names if messages are built with them. Failing to load them isn't
+// fatal; the senders' own display names are used until the next attempt succeeds.
+func (s *Service) loadContactNamesIfEnabled(ctx context.Context) {
+	if !s.buildMode.contactDisplayNames.Load() {
+		return
+	}
+
+	if err := s.buildMode.contacts.load(ctx, s.client); err != nil {
+		s.log.WithError(err).Warn("Failed to load contact names")
+	}
+}
+
+// headerNames resolves label and address IDs for header templates. Messages are built while the identity
+// is locked, so it reads the lock-free snapshots rather than taking the locks again.
+type headerNames struct {
+	labels   *rwLabels
+	identity *rwIdentity
+}
+
+func (n headerNames) LabelName(labelID string) (string, bool) {
+	return n.labels.GetLabelName(labelID)
+}
+
+func (n headerNames) AddressEmail(addressID string) (string, bool) {
+	return n.identity.GetAddressEmail(addressID)
+}
@@ -0,0 +1,118 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package imapservice
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/ProtonMail/gluon/imap"
+	"golang.org/x/exp/slices"
+)
+
+// ShimOutlookSentSeen marks the copies of sent messages which Outlook appends to Sent as read.
+// Outlook appends them without the \Seen flag, so they show up as unread in the other clients.
+const ShimOutlookSentSeen = "outlook-sent-seen"
+
+// ClientShim is a workaround for a quirk of the IMAP clients whose ID name contains Client.
+type ClientShim struct {
+	Name        string
+	Client      string
+	Description string
+}
+
+// clientShims lists the known shims.
+var clientShims = []ClientShim{ //nolint:gochecknoglobals
+	{
+		Name:        ShimOutlookSentSeen,
+		Client:      "outlook",
+		Description: "Mark the copies of sent messages Outlook appends to Sent as read",
+	},
+}
+
+// GetClientShims returns the known client shims.
+func GetClientShims() []ClientShim {
+	return slices.Clone(clientShims)
+}
+
+// Matches returns whether the shim applies to the client with the given ID.
+func (shim ClientShim) Matches(id imap.IMAPID) bool {
+	return strings.Contains(strings.ToLower(id.Name), shim.Client)
+}
+
+// ClientShims keeps track of which client shims are enabled; all are by default.
+// It's shared by the connectors of all users.
+type ClientShims struct {
+	lock     sync.RWMutex
+	disabled []string
+}
+
+func NewClientShims(disabled []string) *ClientShims {
+	return &ClientShims{disabled: slices.Clone(disabled)}
+}
+
+// SetEnabled enables or disables the shim with the given name.
+func (s *ClientShims) SetEnabled(name string, enabled bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if idx := slices.Index(s.disabled, name); idx >= 0 {
+		s.disabled = slices.Delete(s.disabled, idx, idx+1)
+	}
+
+	if !enabled {
+		s.disabled = append(s.disabled, name)
+	}
+}
+
+// IsEnabled returns whether the shim with the given name is enabled.
+func (s *ClientShims) IsEnabled(name string) bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return !slices.Contains(s.disabled, name)
+}
+
+// GetActive returns the names of the enabled shims applying to the client with the given ID.
+func (s *ClientShims) GetActive(id imap.IMAPID) []string {
+	var active []string
+
+	for _, shim := range clientShims {
+		if shim.Matches(id) && s.IsEnabled(shim.Name) {
+			active = append(active, shim.Name)
+		}
+	}
+
+	return active
+}
+
+// isActive returns whether the shim with the given name is enabled and applies to the client
+// running the IMAP command with the given context.
+func (s *ClientShims) isActive(ctx context.Context, name string) bool {
+	if s == nil {
+		return false
+	}
+
+	id, ok := imap.GetIMAPIDFromContext(ctx)
+	if !ok {
+		return false
+	}
+
+	return slices.Contains(s.GetActive(id), name)
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package imapservice
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ProtonMail/gluon/imap"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientShims(t *testing.T) {
+	outlook := imap.IMAPID{Name: "Microsoft Outlook", Version: "16.0"}
+	thunderbird := imap.IMAPID{Name: "Thunderbird", Version: "115.0"}
+
+	shims := NewClientShims(nil)
+	require.Equal(t, []string{ShimOutlookSentSeen}, shims.GetActive(outlook))
+	require.Empty(t, shims.GetActive(thunderbird))
+
+	// Shims only apply to commands of clients which sent their ID.
+	require.True(t, shims.isActive(imap.NewContextWithIMAPID(context.Background(), outlook), ShimOutlookSentSeen))
+	require.False(t, shims.isActive(imap.NewContextWithIMAPID(context.Background(), thunderbird), ShimOutlookSentSeen))
+	require.False(t, shims.isActive(context.Background(), ShimOutlookSentSeen))
+
+	// Disabled shims don't apply.
+	shims.SetEnabled(ShimOutlookSentSeen, false)
+	require.False(t, shims.IsEnabled(ShimOutlookSentSeen))
+	require.Empty(t, shims.GetActive(outlook))
+
+	shims.SetEnabled(ShimOutlookSentSeen, true)
+	require.True(t, shims.IsEnabled(ShimOutlookSentSeen))
+
+	// Connectors without shims never apply any.
+	require.False(t, (*ClientShims)(nil).isActive(imap.NewContextWithIMAPID(context.Background(), outlook), ShimOutlookSentSeen))
+}
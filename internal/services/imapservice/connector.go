@@ -44,8 +44,10 @@ import (
 
 // Connector contains all IMAP state required to satisfy sync and or imap queries.
 type Connector struct {
-	addrID      string
-	showAllMail uint32
+	addrID           string
+	showAllMail      uint32
+	labelKeywordMode uint32
+	deleteMode       uint32
 
 	flags     imap.FlagSet
 	permFlags imap.FlagSet
@@ -56,14 +58,23 @@ type Connector struct {
 	telemetry     Telemetry
 	panicHandler  async.PanicHandler
 	sendRecorder  *sendrecorder.SendRecorder
+	clientShims   *ClientShims
 
 	addressMode usertypes.AddressMode
 	labels      sharedLabels
 	updateCh    *async.QueuedChannel[imap.Update]
 	log         *logrus.Entry
 
-	sharedCache *SharedCache
-	syncState   *SyncState
+	sharedCache   *SharedCache
+	syncState     *SyncState
+	migration     *migration
+	pendingOps    *pendingOps
+	drafts        *draftCoalescing
+	announcements *announcements
+
+	buildMode  *buildMode
+	syncFilter *syncFilter
+	redactor   *redactor
 }
 
 func NewConnector(
@@ -76,22 +87,35 @@ func NewConnector(
 	panicHandler async.PanicHandler,
 	telemetry Telemetry,
 	showAllMail bool,
+	clientShims *ClientShims,
+	labelKeywordMode LabelKeywordMode,
+	deleteMode DeleteMode,
+	migration *migration,
+	pendingOps *pendingOps,
+	drafts *draftCoalescing,
+	announcements *announcements,
+	buildMode *buildMode,
+	syncFilter *syncFilter,
+	redactor *redactor,
 	syncState *SyncState,
 ) *Connector {
 	userID := identityState.UserID()
 
 	return &Connector{
-		identityState: identityState,
-		addrID:        addrID,
-		showAllMail:   b32(showAllMail),
-		flags:         defaultFlags,
-		permFlags:     defaultPermanentFlags,
-		attrs:         defaultAttributes,
+		identityState:    identityState,
+		addrID:           addrID,
+		showAllMail:      b32(showAllMail),
+		labelKeywordMode: uint32(labelKeywordMode),
+		deleteMode:       uint32(deleteMode),
+		flags:            defaultFlags,
+		permFlags:        defaultPermanentFlags,
+		attrs:            defaultAttributes,
 
 		client:       apiClient,
 		telemetry:    telemetry,
 		panicHandler: panicHandler,
 		sendRecorder: sendRecorder,
+		clientShims:  clientShims,
 
 		updateCh: async.NewQueuedChannel[imap.Update](
 			0,
@@ -107,8 +131,16 @@ func NewConnector(
 			"user-id":         userID,
 		}),
 
-		sharedCache: NewSharedCached(),
-		syncState:   syncState,
+		sharedCache:   NewSharedCached(),
+		syncState:     syncState,
+		migration:     migration,
+		pendingOps:    pendingOps,
+		drafts:        drafts,
+		announcements: announcements,
+
+		buildMode:  buildMode,
+		syncFilter: syncFilter,
+		redactor:   redactor,
 	}
 }
 
@@ -182,14 +214,33 @@ func (s *Connector) CreateMailbox(ctx context.Context, _ connector.IMAPStateWrit
 }
 
 func (s *Connector) GetMessageLiteral(ctx context.Context, id imap.MessageID) ([]byte, error) {
+	if isAnnouncement(id) {
+		if literal, ok := s.announcements.getLiteral(id); ok {
+			return literal, nil
+		}
+
+		return nil, fmt.Errorf("no such announcement %q", id)
+	}
+
+	if literal, uploadedID, ok := s.drafts.getLiteral(id); ok {
+		if literal != nil {
+			return literal, nil
+		}
+
+		id = uploadedID
+	}
+
 	msg, err := s.client.GetFullMessage(ctx, string(id), usertypes.NewProtonAPIScheduler(s.panicHandler), proton.NewDefaultAttachmentAllocator())
 	if err != nil {
 		return nil, err
 	}
 
+	// The store isn't persisted across restarts for redacted messages; they are marked again as gluon downloads them.
+	s.redactor.redact(msg.ID, msg.Sender.Address, msg.LabelIDs)
+
 	var literal []byte
 	err = s.identityState.WithAddrKR(msg.AddressID, func(_, addrKR *crypto.KeyRing) error {
-		l, buildErr := message.DecryptAndBuildRFC822(addrKR, msg.Message, msg.AttData, defaultMessageJobOpts())
+		l, buildErr := message.DecryptAndBuildRFC822(addrKR, msg.Message, msg.AttData, s.buildMode.jobOpts())
 		if buildErr != nil {
 			return buildErr
 		}
@@ -203,6 +254,10 @@ func (s *Connector) GetMessageLiteral(ctx context.Context, id imap.MessageID) ([
 }
 
 func (s *Connector) GetMailboxVisibility(_ context.Context, mboxID imap.MailboxID) imap.MailboxVisibility {
+	if s.isLabelMailboxHidden(mboxID) {
+		return imap.Hidden
+	}
+
 	switch mboxID {
 	case proton.AllMailLabel:
 		if atomic.LoadUint32(&s.showAllMail) != 0 {
@@ -210,7 +265,7 @@ func (s *Connector) GetMailboxVisibility(_ context.Context, mboxID imap.MailboxI
 		}
 		return imap.Hidden
 
-	case proton.AllScheduledLabel:
+	case proton.AllScheduledLabel, announcementsMailboxID:
 		return imap.HiddenIfEmpty
 	default:
 		return imap.Visible
@@ -218,7 +273,7 @@ func (s *Connector) GetMailboxVisibility(_ context.Context, mboxID imap.MailboxI
 }
 
 func (s *Connector) UpdateMailboxName(ctx context.Context, _ connector.IMAPStateWrite, mboxID imap.MailboxID, name []string) error {
-	if len(name) < 2 {
+	if len(name) < 2 || isSavedSearchMailbox(mboxID) || mboxID == announcementsMailboxID {
 		return fmt.Errorf("invalid mailbox name %q: %w", name, connector.ErrOperationNotAllowed)
 	}
 
@@ -235,6 +290,10 @@ func (s *Connector) UpdateMailboxName(ctx context.Context, _ connector.IMAPState
 }
 
 func (s *Connector) DeleteMailbox(ctx context.Context, _ connector.IMAPStateWrite, mboxID imap.MailboxID) error {
+	if isSavedSearchMailbox(mboxID) || mboxID == announcementsMailboxID {
+		return connector.ErrOperationNotAllowed
+	}
+
 	if err := s.client.DeleteLabel(ctx, string(mboxID)); err != nil {
 		return err
 	}
@@ -248,7 +307,7 @@ func (s *Connector) DeleteMailbox(ctx context.Context, _ connector.IMAPStateWrit
 }
 
 func (s *Connector) CreateMessage(ctx context.Context, _ connector.IMAPStateWrite, mailboxID imap.MailboxID, literal []byte, flags imap.FlagSet, _ time.Time) (imap.Message, []byte, error) {
-	if mailboxID == proton.AllMailLabel {
+	if mailboxID == proton.AllMailLabel || isSavedSearchMailbox(mailboxID) || mailboxID == announcementsMailboxID {
 		return imap.Message{}, nil, connector.ErrOperationNotAllowed
 	}
 
@@ -269,26 +328,35 @@ func (s *Connector) CreateMessage(ctx context.Context, _ connector.IMAPStateWrit
 	} else if ok {
 		s.log.WithField("messageID", messageID).Warn("Message already sent")
 
-		// Query the server-side message.
-		full, err := s.client.GetFullMessage(ctx, messageID, usertypes.NewProtonAPIScheduler(s.panicHandler), proton.NewDefaultAttachmentAllocator())
-		if err != nil {
-			return imap.Message{}, nil, fmt.Errorf("failed to fetch message: %w", err)
+		return s.getServerMessage(ctx, messageID)
+	}
+
+	// Drafts saved again shortly after they were uploaded are only uploaded once the coalescing interval is over.
+	if mailboxID == proton.DraftsLabel {
+		if msg, ok := s.drafts.hold(s, literal, flags); ok {
+			return msg, literal, nil
 		}
+	}
 
-		// Build the message as it is on the server.
-		if err := s.identityState.WithAddrKR(full.AddressID, func(_, addrKR *crypto.KeyRing) error {
-			var err error
+	var header *rfc822.Header
 
-			if literal, err = message.DecryptAndBuildRFC822(addrKR, full.Message, full.AttData, defaultMessageJobOpts()); err != nil {
-				return err
-			}
-
-			return nil
-		}); err != nil {
-			return imap.Message{}, nil, fmt.Errorf("failed to build message: %w", err)
+	if mailboxID != proton.DraftsLabel {
+		if header, err = rfc822.Parse(literal).ParseHeader(); err != nil {
+			return imap.Message{}, nil, err
 		}
+	}
+
+	// In migration mode, don't import the same message twice into the same mailbox.
+	if s.migration.isEnabled() && mailboxID != proton.DraftsLabel {
+		if messageID, ok, err := s.findMigrationDuplicate(ctx, mailboxID, migrationKey(mailboxID, hash), header); err != nil {
+			return imap.Message{}, nil, fmt.Errorf("failed to check for duplicate message: %w", err)
+		} else if ok {
+			s.log.WithField("messageID", messageID).Debug("Message already imported")
 
-		return toIMAPMessage(full.MessageMetadata), literal, nil
+			s.migration.onDuplicate(ctx)
+
+			return s.getServerMessage(ctx, messageID)
+		}
 	}
 
 	wantLabelIDs := []string{string(mailboxID)}
@@ -297,16 +365,22 @@ func (s *Connector) CreateMessage(ctx context.Context, _ connector.IMAPStateWrit
 		wantLabelIDs = append(wantLabelIDs, proton.StarredLabel)
 	}
 
+	var keywords []string
+
+	if s.getLabelKeywordMode() != LabelKeywordsOff {
+		rd := s.labels.Read()
+		keywordLabels := keywordLabelIDs(rd, flags)
+		keywords = labelKeywords(rd, usertypes.MapTo[string, imap.MailboxID](keywordLabels))
+		rd.Close()
+
+		wantLabelIDs = append(wantLabelIDs, keywordLabels...)
+	}
+
 	var wantFlags proton.MessageFlag
 
 	unread := !flags.Contains(imap.FlagSeen)
 
 	if mailboxID != proton.DraftsLabel {
-		header, err := rfc822.Parse(literal).ParseHeader()
-		if err != nil {
-			return imap.Message{}, nil, err
-		}
-
 		switch {
 		case mailboxID == proton.InboxLabel:
 			wantFlags = wantFlags.Add(proton.MessageFlagReceived)
@@ -324,12 +398,44 @@ func (s *Connector) CreateMessage(ctx context.Context, _ connector.IMAPStateWrit
 		unread = false
 	}
 
+	if mailboxID == proton.SentLabel && unread && s.clientShims.isActive(ctx, ShimOutlookSentSeen) {
+		s.log.WithField("shim", ShimOutlookSentSeen).Info("Marking sent message appended by client as read")
+
+		unread = false
+	}
+
 	if flags.Contains(imap.FlagAnswered) {
 		wantFlags = wantFlags.Add(proton.MessageFlagReplied)
 	}
 
-	msg, literal, err := s.importMessage(ctx, literal, wantLabelIDs, wantFlags, unread)
+	var (
+		msg        imap.Message
+		newLiteral []byte
+	)
+
+	if s.migration.isEnabled() {
+		err = s.migration.throttle(ctx, func() error {
+			var err error
+
+			msg, newLiteral, err = s.importMessage(ctx, literal, wantLabelIDs, wantFlags, unread)
+
+			return err
+		})
+	} else {
+		msg, newLiteral, err = s.importMessage(ctx, literal, wantLabelIDs, wantFlags, unread)
+	}
+
 	if err != nil {
+		if s.migration.isEnabled() {
+			var subject string
+
+			if header != nil {
+				subject = header.Get("Subject")
+			}
+
+			s.migration.onFailed(ctx, mailboxID, subject, err)
+		}
+
 		if errors.Is(err, proton.ErrImportSizeExceeded) {
 			// Remap error so that Gluon does not put this message in the recovery mailbox.
 			err = fmt.Errorf("%v: %w", err, connector.ErrMessageSizeExceedsLimits)
@@ -340,25 +446,102 @@ func (s *Connector) CreateMessage(ctx context.Context, _ connector.IMAPStateWrit
 		} else {
 			s.log.WithError(err).Error("Failed to import message")
 		}
+
+		return msg, newLiteral, err
+	}
+
+	if s.migration.isEnabled() && mailboxID != proton.DraftsLabel {
+		s.migration.onImported(ctx, migrationKey(mailboxID, hash), string(msg.ID))
+	}
+
+	if mailboxID == proton.DraftsLabel {
+		s.drafts.uploaded(s, literal)
+	}
+
+	msg.Flags = msg.Flags.Add(keywords...)
+
+	return msg, newLiteral, nil
+}
+
+// getServerMessage returns the given message as it is on the server.
+func (s *Connector) getServerMessage(ctx context.Context, messageID string) (imap.Message, []byte, error) {
+	full, err := s.client.GetFullMessage(ctx, messageID, usertypes.NewProtonAPIScheduler(s.panicHandler), proton.NewDefaultAttachmentAllocator())
+	if err != nil {
+		return imap.Message{}, nil, fmt.Errorf("failed to fetch message: %w", err)
+	}
+
+	var literal []byte
+
+	if err := s.identityState.WithAddrKR(full.AddressID, func(_, addrKR *crypto.KeyRing) error {
+		var err error
+
+		if literal, err = message.DecryptAndBuildRFC822(addrKR, full.Message, full.AttData, s.buildMode.jobOpts()); err != nil {
+			return err
+		}
+
+		return nil
+	}); err != nil {
+		return imap.Message{}, nil, fmt.Errorf("failed to build message: %w", err)
 	}
 
-	return msg, literal, err
+	return toIMAPMessage(full.MessageMetadata), literal, nil
 }
 
 func (s *Connector) AddMessagesToMailbox(ctx context.Context, _ connector.IMAPStateWrite, messageIDs []imap.MessageID, mboxID imap.MailboxID) error {
-	if isAllMailOrScheduled(mboxID) {
+	if isAllMailOrScheduled(mboxID) || isSavedSearchMailbox(mboxID) || mboxID == announcementsMailboxID || containsAnnouncement(messageIDs) {
 		return connector.ErrOperationNotAllowed
 	}
 
-	return s.client.LabelMessages(ctx, usertypes.MapTo[imap.MessageID, string](messageIDs), string(mboxID))
+	messageIDs, err := s.drafts.resolve(ctx, messageIDs)
+	if err != nil {
+		return fmt.Errorf("failed to upload held drafts: %w", err)
+	}
+
+	return s.pendingOps.add(ctx, pendingOp{
+		Kind:       pendingOpLabel,
+		LabelID:    string(mboxID),
+		MessageIDs: usertypes.MapTo[imap.MessageID, string](messageIDs),
+		Group:      s.pendingOpGroup(mboxID),
+	})
 }
 
 func (s *Connector) RemoveMessagesFromMailbox(ctx context.Context, _ connector.IMAPStateWrite, messageIDs []imap.MessageID, mboxID imap.MailboxID) error {
-	if isAllMailOrScheduled(mboxID) {
+	if isAllMailOrScheduled(mboxID) || isSavedSearchMailbox(mboxID) {
 		return connector.ErrOperationNotAllowed
 	}
 
+	// Deleting an announcement dismisses it; it only ever existed locally.
+	if mboxID == announcementsMailboxID {
+		s.announcements.dismiss(messageIDs)
+		return nil
+	}
+
+	// Held draft versions were never uploaded, so there's nothing to delete on the server.
+	if messageIDs = s.drafts.discard(messageIDs); len(messageIDs) == 0 {
+		return nil
+	}
+
 	msgIDs := usertypes.MapTo[imap.MessageID, string](messageIDs)
+	mode := s.getDeleteModeFor(mboxID)
+
+	if mode == DeleteModeRemove && mboxID != proton.TrashLabel && mboxID != proton.DraftsLabel {
+		return s.pendingOps.add(ctx, pendingOp{
+			Kind:       pendingOpUnlabel,
+			LabelID:    string(mboxID),
+			MessageIDs: msgIDs,
+			Group:      s.pendingOpGroup(mboxID),
+		})
+	}
+
+	// What happens next depends on the labels of the messages on the server, so apply the pending operations first.
+	if err := s.pendingOps.flush(ctx); err != nil {
+		return err
+	}
+
+	if mode != DeleteModeRemove {
+		return s.deleteWithDeleteMode(ctx, msgIDs, mode)
+	}
+
 	if err := s.client.UnlabelMessages(ctx, msgIDs, string(mboxID)); err != nil {
 		return err
 	}
@@ -437,10 +620,19 @@ func (s *Connector) MoveMessages(ctx context.Context, _ connector.IMAPStateWrite
 	if (mboxFromID == proton.InboxLabel && mboxToID == proton.SentLabel) ||
 		(mboxFromID == proton.SentLabel && mboxToID == proton.InboxLabel) ||
 		isAllMailOrScheduled(mboxFromID) ||
-		isAllMailOrScheduled(mboxToID) {
+		isAllMailOrScheduled(mboxToID) ||
+		isSavedSearchMailbox(mboxFromID) ||
+		isSavedSearchMailbox(mboxToID) ||
+		mboxFromID == announcementsMailboxID ||
+		mboxToID == announcementsMailboxID {
 		return false, connector.ErrOperationNotAllowed
 	}
 
+	messageIDs, err := s.drafts.resolve(ctx, messageIDs)
+	if err != nil {
+		return false, fmt.Errorf("failed to upload held drafts: %w", err)
+	}
+
 	shouldExpungeOldLocation := func() bool {
 		rdLabels := s.labels.Read()
 		defer rdLabels.Close()
@@ -458,12 +650,22 @@ func (s *Connector) MoveMessages(ctx context.Context, _ connector.IMAPStateWrite
 		return result
 	}()
 
-	if err := s.client.LabelMessages(ctx, usertypes.MapTo[imap.MessageID, string](messageIDs), string(mboxToID)); err != nil {
+	if err := s.pendingOps.add(ctx, pendingOp{
+		Kind:       pendingOpLabel,
+		LabelID:    string(mboxToID),
+		MessageIDs: usertypes.MapTo[imap.MessageID, string](messageIDs),
+		Group:      s.pendingOpGroup(mboxToID),
+	}); err != nil {
 		return false, fmt.Errorf("labeling messages: %w", err)
 	}
 
 	if shouldExpungeOldLocation {
-		if err := s.client.UnlabelMessages(ctx, usertypes.MapTo[imap.MessageID, string](messageIDs), string(mboxFromID)); err != nil {
+		if err := s.pendingOps.add(ctx, pendingOp{
+			Kind:       pendingOpUnlabel,
+			LabelID:    string(mboxFromID),
+			MessageIDs: usertypes.MapTo[imap.MessageID, string](messageIDs),
+			Group:      s.pendingOpGroup(mboxFromID),
+		}); err != nil {
 			return false, fmt.Errorf("unlabeling messages: %w", err)
 		}
 	}
@@ -472,28 +674,70 @@ func (s *Connector) MoveMessages(ctx context.Context, _ connector.IMAPStateWrite
 }
 
 func (s *Connector) MarkMessagesSeen(ctx context.Context, _ connector.IMAPStateWrite, messageIDs []imap.MessageID, seen bool) error {
+	kind := pendingOpMarkUnread
+
 	if seen {
-		return s.client.MarkMessagesRead(ctx, usertypes.MapTo[imap.MessageID, string](messageIDs)...)
+		kind = pendingOpMarkRead
 	}
 
-	return s.client.MarkMessagesUnread(ctx, usertypes.MapTo[imap.MessageID, string](messageIDs)...)
+	// Drafts are always read on the server, so held versions don't need to be uploaded for this.
+	// Announcements only exist locally, gluon keeps their flags.
+	if messageIDs = withoutAnnouncements(s.drafts.withoutHeld(messageIDs)); len(messageIDs) == 0 {
+		return nil
+	}
+
+	return s.pendingOps.add(ctx, pendingOp{
+		Kind:       kind,
+		MessageIDs: usertypes.MapTo[imap.MessageID, string](messageIDs),
+		Group:      pendingOpGroupSeen,
+	})
 }
 
 func (s *Connector) MarkMessagesFlagged(ctx context.Context, _ connector.IMAPStateWrite, messageIDs []imap.MessageID, flagged bool) error {
+	kind := pendingOpUnlabel
+
 	if flagged {
-		return s.client.LabelMessages(ctx, usertypes.MapTo[imap.MessageID, string](messageIDs), proton.StarredLabel)
+		kind = pendingOpLabel
+	}
+
+	if messageIDs = withoutAnnouncements(messageIDs); len(messageIDs) == 0 {
+		return nil
 	}
 
-	return s.client.UnlabelMessages(ctx, usertypes.MapTo[imap.MessageID, string](messageIDs), proton.StarredLabel)
+	messageIDs, err := s.drafts.resolve(ctx, messageIDs)
+	if err != nil {
+		return fmt.Errorf("failed to upload held drafts: %w", err)
+	}
+
+	return s.pendingOps.add(ctx, pendingOp{
+		Kind:       kind,
+		LabelID:    proton.StarredLabel,
+		MessageIDs: usertypes.MapTo[imap.MessageID, string](messageIDs),
+		Group:      proton.StarredLabel,
+	})
+}
+
+// pendingOpGroup returns the group of the operations adding messages to or removing them from the given mailbox.
+func (s *Connector) pendingOpGroup(mboxID imap.MailboxID) string {
+	if s.isLocation(mboxID) {
+		return pendingOpGroupLocation
+	}
+
+	return string(mboxID)
 }
 
 func (s *Connector) GetUpdates() <-chan imap.Update {
 	return s.updateCh.GetChannel()
 }
 
-func (s *Connector) Close(_ context.Context) error {
-	// Nothing to do
+func (s *Connector) Close(ctx context.Context) error {
+	// Upload the drafts held back for this connector, they'd be lost otherwise.
+	if err := s.drafts.flushConnector(ctx, s); err != nil {
+		s.log.WithError(err).Error("Failed to upload held drafts")
+	}
+
 	s.sharedCache.Close()
+
 	return nil
 }
 
@@ -702,7 +946,7 @@ func (s *Connector) importMessage(
 			return fmt.Errorf("failed to fetch message: %w", err)
 		}
 
-		if literal, err = message.DecryptAndBuildRFC822(addrKR, full.Message, full.AttData, defaultMessageJobOpts()); err != nil {
+		if literal, err = message.DecryptAndBuildRFC822(addrKR, full.Message, full.AttData, s.buildMode.jobOpts()); err != nil {
 			return fmt.Errorf("failed to build message: %w", err)
 		}
 
@@ -772,7 +1016,7 @@ func (s *Connector) createDraft(ctx context.Context, literal []byte, addrKR *cry
 }
 
 func (s *Connector) publishUpdate(_ context.Context, update imap.Update) {
-	s.updateCh.Enqueue(update)
+	s.updateCh.Enqueue(s.withLabelKeywords(s.withRedaction(s.withSyncFilter(update))))
 }
 
 func fixGODT3003Labels(
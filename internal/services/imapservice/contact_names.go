@@ -0,0 +1,76 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package imapservice
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+)
+
+// contactEmailsPageSize is how many contact emails are fetched per request.
+const contactEmailsPageSize = 150
+
+// contactNames holds the names the user gave their contacts by email. It is read while messages are built,
+// so it is replaced as a whole rather than locked.
+//
+// The event stream doesn't report contact changes, so the names are loaded when the service starts and reloaded
+// whenever the user is resynced; messages built in between use the names as they were last loaded.
+type contactNames struct {
+	names atomic.Pointer[map[string]string]
+}
+
+func newContactNames() *contactNames {
+	contacts := &contactNames{}
+
+	contacts.names.Store(&map[string]string{})
+
+	return contacts
+}
+
+func (c *contactNames) ContactName(email string) (string, bool) {
+	name, ok := (*c.names.Load())[strings.ToLower(email)]
+
+	return name, ok
+}
+
+// load fetches the user's contact emails and replaces the names with theirs.
+func (c *contactNames) load(ctx context.Context, client APIClient) error {
+	names := make(map[string]string)
+
+	for page := 0; ; page++ {
+		emails, err := client.GetContactEmails(ctx, "", page, contactEmailsPageSize)
+		if err != nil {
+			return err
+		}
+
+		for _, email := range emails {
+			if email.Name != "" && email.Name != email.Email {
+				names[strings.ToLower(email.Email)] = email.Name
+			}
+		}
+
+		if len(emails) < contactEmailsPageSize {
+			break
+		}
+	}
+
+	c.names.Store(&names)
+
+	return nil
+}
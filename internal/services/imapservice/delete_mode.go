@@ -0,0 +1,124 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package imapservice
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/ProtonMail/gluon/imap"
+	"github.com/ProtonMail/go-proton-api"
+)
+
+// DeleteMode controls what happens to messages marked \Deleted and expunged from a folder.
+// It doesn't apply to Drafts, Starred and labels, from which messages are always just removed.
+type DeleteMode int
+
+const (
+	DeleteModeRemove DeleteMode = iota
+	DeleteModeTrash
+	DeleteModePermanent
+	DeleteModeArchive
+)
+
+func (s *Connector) SetDeleteMode(mode DeleteMode) {
+	atomic.StoreUint32(&s.deleteMode, uint32(mode))
+}
+
+func (s *Connector) getDeleteMode() DeleteMode {
+	return DeleteMode(atomic.LoadUint32(&s.deleteMode))
+}
+
+// getDeleteModeFor returns the delete mode applying to the messages expunged from the given mailbox.
+// It returns DeleteModeRemove if they should just be removed from the mailbox.
+func (s *Connector) getDeleteModeFor(mboxID imap.MailboxID) DeleteMode {
+	if !s.isLocation(mboxID) {
+		return DeleteModeRemove
+	}
+
+	mode := s.getDeleteMode()
+
+	switch mode {
+	case DeleteModeTrash:
+		if mboxID == proton.TrashLabel {
+			return DeleteModeRemove
+		}
+
+	case DeleteModeArchive:
+		if mboxID == proton.ArchiveLabel {
+			return DeleteModeRemove
+		}
+
+	case DeleteModeRemove, DeleteModePermanent:
+	}
+
+	return mode
+}
+
+// deleteWithDeleteMode applies the given delete mode to the expunged messages.
+func (s *Connector) deleteWithDeleteMode(ctx context.Context, messageIDs []string, mode DeleteMode) error {
+	switch mode {
+	case DeleteModeTrash:
+		return s.client.LabelMessages(ctx, messageIDs, proton.TrashLabel)
+
+	case DeleteModeArchive:
+		return s.client.LabelMessages(ctx, messageIDs, proton.ArchiveLabel)
+
+	case DeleteModePermanent:
+		return s.client.DeleteMessage(ctx, messageIDs...)
+
+	case DeleteModeRemove:
+		return nil
+
+	default:
+		return nil
+	}
+}
+
+// isLocation returns whether the given mailbox is a folder a message can only be in one of.
+// nolint:exhaustive
+func (s *Connector) isLocation(mboxID imap.MailboxID) bool {
+	rd := s.labels.Read()
+	defer rd.Close()
+
+	label, ok := rd.GetLabel(string(mboxID))
+	if !ok {
+		return false
+	}
+
+	switch label.Type {
+	case proton.LabelTypeFolder:
+		return true
+
+	case proton.LabelTypeSystem:
+		switch label.ID {
+		case proton.InboxLabel, proton.SentLabel, proton.ArchiveLabel, proton.SpamLabel, proton.TrashLabel:
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *Service) setDeleteMode(mode DeleteMode) {
+	s.deleteMode = mode
+
+	for _, c := range s.connectors {
+		c.SetDeleteMode(mode)
+	}
+}
@@ -0,0 +1,430 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package imapservice
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ProtonMail/gluon/imap"
+	"github.com/ProtonMail/gluon/rfc822"
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/bradenaw/juniper/xslices"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/exp/slices"
+)
+
+// heldDraftIDPrefix prefixes the IDs under which held draft versions are known to gluon until they are uploaded.
+const heldDraftIDPrefix = "held-draft-"
+
+// draftCoalescing holds back the drafts clients autosave every few seconds, such that each draft is uploaded at most
+// once per interval rather than every time it's saved. The first version of a draft is uploaded right away; versions
+// saved within the interval after an upload are only kept locally, under a placeholder ID, and the latest of them is
+// uploaded once the interval is over. The placeholders are then replaced with the uploaded draft.
+//
+// Versions of the same draft are recognized by their X-Universally-Unique-Identifier, which Apple Mail keeps across
+// saves, or else their Message-ID. Held versions are uploaded early when they're moved, flagged or otherwise changed,
+// when a message is sent and when the connector is closed. They are only kept in memory, so a crash loses at most
+// one interval of edits.
+//
+// It is shared by the connectors of a user, each draft remembering the connector it was last appended through.
+type draftCoalescing struct {
+	log      *logrus.Entry
+	interval atomic.Int64
+
+	lock     sync.Mutex
+	lineages map[string]*draftLineage
+	held     map[imap.MessageID]*draftLineage
+}
+
+// draftLineage is the saved versions of a single draft.
+type draftLineage struct {
+	conn       *Connector
+	lastUpload time.Time
+	versions   []heldDraft
+	timer      *time.Timer
+
+	// replaced maps the uploaded versions to the drafts they were uploaded as, until gluon deleted them.
+	replaced map[imap.MessageID]imap.MessageID
+
+	// uploadLock serializes the uploads of the draft.
+	uploadLock sync.Mutex
+}
+
+type heldDraft struct {
+	id      imap.MessageID
+	literal []byte
+	flags   imap.FlagSet
+}
+
+func newDraftCoalescing(log *logrus.Entry, interval time.Duration) *draftCoalescing {
+	d := &draftCoalescing{
+		log:      log,
+		lineages: make(map[string]*draftLineage),
+		held:     make(map[imap.MessageID]*draftLineage),
+	}
+
+	d.interval.Store(int64(interval))
+
+	return d
+}
+
+func (d *draftCoalescing) getInterval() time.Duration {
+	return time.Duration(d.interval.Load())
+}
+
+// setInterval changes the interval; zero disables coalescing, uploading the drafts held so far.
+func (d *draftCoalescing) setInterval(ctx context.Context, interval time.Duration) {
+	if time.Duration(d.interval.Swap(int64(interval))) == interval || interval > 0 {
+		return
+	}
+
+	if err := d.flushAll(ctx); err != nil {
+		d.log.WithError(err).Warn("Failed to upload held drafts")
+	}
+}
+
+// hold keeps the given draft version locally if its draft was uploaded less than an interval ago.
+// It returns false if the version should be uploaded now, in which case uploaded must be called once it is.
+func (d *draftCoalescing) hold(conn *Connector, literal []byte, flags imap.FlagSet) (imap.Message, bool) {
+	interval := d.getInterval()
+	if interval <= 0 {
+		return imap.Message{}, false
+	}
+
+	key := getDraftLineageKey(literal)
+	if key == "" {
+		return imap.Message{}, false
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.pruneUnsafe(interval)
+
+	lineage, ok := d.lineages[key]
+	if !ok || len(lineage.versions) == 0 && time.Since(lineage.lastUpload) >= interval {
+		return imap.Message{}, false
+	}
+
+	draft := heldDraft{
+		id:      imap.MessageID(heldDraftIDPrefix + uuid.NewString()),
+		literal: literal,
+		flags:   flags,
+	}
+
+	lineage.conn = conn
+	lineage.versions = append(lineage.versions, draft)
+	d.held[draft.id] = lineage
+
+	if lineage.timer == nil {
+		d.scheduleUnsafe(lineage, time.Until(lineage.lastUpload.Add(interval)))
+	}
+
+	d.log.WithField("messageID", draft.id).Debug("Holding back draft version")
+
+	return imap.Message{ID: draft.id, Flags: flags, Date: time.Now()}, true
+}
+
+// uploaded records that a version of the draft was uploaded, starting a new interval.
+func (d *draftCoalescing) uploaded(conn *Connector, literal []byte) {
+	if d.getInterval() <= 0 {
+		return
+	}
+
+	key := getDraftLineageKey(literal)
+	if key == "" {
+		return
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if lineage, ok := d.lineages[key]; ok {
+		lineage.conn = conn
+		lineage.lastUpload = time.Now()
+	} else {
+		d.lineages[key] = &draftLineage{
+			conn:       conn,
+			lastUpload: time.Now(),
+			replaced:   make(map[imap.MessageID]imap.MessageID),
+		}
+	}
+}
+
+// getLiteral returns the literal of a held draft version, or the ID of the draft it was uploaded as.
+func (d *draftCoalescing) getLiteral(id imap.MessageID) ([]byte, imap.MessageID, bool) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	lineage, ok := d.held[id]
+	if !ok {
+		return nil, "", false
+	}
+
+	if idx := xslices.IndexFunc(lineage.versions, func(draft heldDraft) bool { return draft.id == id }); idx >= 0 {
+		return lineage.versions[idx].literal, "", true
+	}
+
+	return nil, lineage.replaced[id], true
+}
+
+// discard drops the held versions among the given messages, which clients delete once they saved a newer version.
+// It returns the other messages, with the uploaded versions replaced by the drafts they were uploaded as.
+func (d *draftCoalescing) discard(ids []imap.MessageID) []imap.MessageID {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	var remaining []imap.MessageID
+
+	for _, id := range ids {
+		lineage, ok := d.held[id]
+		if !ok {
+			remaining = append(remaining, id)
+			continue
+		}
+
+		delete(d.held, id)
+
+		if uploadedID, ok := lineage.replaced[id]; ok {
+			delete(lineage.replaced, id)
+			remaining = append(remaining, uploadedID)
+
+			continue
+		}
+
+		lineage.versions = xslices.Filter(lineage.versions, func(draft heldDraft) bool { return draft.id != id })
+
+		if len(lineage.versions) == 0 && lineage.timer != nil {
+			lineage.timer.Stop()
+			lineage.timer = nil
+		}
+	}
+
+	return remaining
+}
+
+// withoutHeld returns the given messages without the held versions, for operations which don't apply to them.
+func (d *draftCoalescing) withoutHeld(ids []imap.MessageID) []imap.MessageID {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	return xslices.Filter(ids, func(id imap.MessageID) bool {
+		_, ok := d.held[id]
+		return !ok
+	})
+}
+
+// resolve uploads the held versions among the given messages and returns the messages with them replaced by
+// the uploaded drafts, so that operations on them can be applied on the server. Versions which were superseded
+// by a newer one are left out.
+func (d *draftCoalescing) resolve(ctx context.Context, ids []imap.MessageID) ([]imap.MessageID, error) {
+	d.lock.Lock()
+
+	var lineages []*draftLineage
+
+	for _, id := range ids {
+		if lineage, ok := d.held[id]; ok && !slices.Contains(lineages, lineage) {
+			lineages = append(lineages, lineage)
+		}
+	}
+
+	d.lock.Unlock()
+
+	if len(lineages) == 0 {
+		return ids, nil
+	}
+
+	for _, lineage := range lineages {
+		if _, err := d.upload(ctx, lineage); err != nil {
+			return nil, err
+		}
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	var resolved []imap.MessageID
+
+	for _, id := range ids {
+		if lineage, ok := d.held[id]; ok {
+			if id, ok = lineage.replaced[id]; !ok {
+				continue
+			}
+		}
+
+		if !slices.Contains(resolved, id) {
+			resolved = append(resolved, id)
+		}
+	}
+
+	return resolved, nil
+}
+
+// flushAll uploads all held draft versions, e.g. before a message is sent.
+func (d *draftCoalescing) flushAll(ctx context.Context) error {
+	return d.flushWhere(ctx, func(*draftLineage) bool { return true })
+}
+
+// flushConnector uploads the draft versions held for the given connector before it's closed.
+func (d *draftCoalescing) flushConnector(ctx context.Context, conn *Connector) error {
+	return d.flushWhere(ctx, func(lineage *draftLineage) bool { return lineage.conn == conn })
+}
+
+func (d *draftCoalescing) flushWhere(ctx context.Context, fn func(*draftLineage) bool) error {
+	d.lock.Lock()
+
+	var lineages []*draftLineage
+
+	for _, lineage := range d.lineages {
+		if len(lineage.versions) > 0 && fn(lineage) {
+			lineages = append(lineages, lineage)
+		}
+	}
+
+	d.lock.Unlock()
+
+	for _, lineage := range lineages {
+		if _, err := d.upload(ctx, lineage); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// upload uploads the latest held version of the draft and replaces all its held versions with the uploaded draft.
+func (d *draftCoalescing) upload(ctx context.Context, lineage *draftLineage) (imap.Message, error) {
+	lineage.uploadLock.Lock()
+	defer lineage.uploadLock.Unlock()
+
+	d.lock.Lock()
+
+	versions, conn := lineage.versions, lineage.conn
+
+	if lineage.timer != nil {
+		lineage.timer.Stop()
+		lineage.timer = nil
+	}
+
+	lineage.versions = nil
+	lineage.lastUpload = time.Now()
+
+	d.lock.Unlock()
+
+	if len(versions) == 0 {
+		return imap.Message{}, nil
+	}
+
+	latest := versions[len(versions)-1]
+
+	msg, literal, err := conn.importMessage(ctx, latest.literal, []string{proton.DraftsLabel}, 0, false)
+	if err != nil {
+		// Hold on to the versions so that the upload is retried.
+		d.lock.Lock()
+		lineage.versions = append(versions, lineage.versions...)
+
+		if lineage.timer == nil {
+			d.scheduleUnsafe(lineage, pendingOpsRetryDelay)
+		}
+		d.lock.Unlock()
+
+		return imap.Message{}, err
+	}
+
+	parsedMessage, err := imap.NewParsedMessage(literal)
+	if err != nil {
+		return imap.Message{}, err
+	}
+
+	d.lock.Lock()
+
+	// Only the latest version stands for the uploaded draft; the older ones were superseded.
+	for _, draft := range versions[:len(versions)-1] {
+		delete(d.held, draft.id)
+	}
+
+	lineage.replaced[latest.id] = msg.ID
+
+	d.lock.Unlock()
+
+	msg.Flags = msg.Flags.Add(latest.flags.ToSlice()...)
+
+	conn.publishUpdate(ctx, imap.NewMessagesCreated(false, &imap.MessageCreated{
+		Message:       msg,
+		Literal:       literal,
+		MailboxIDs:    []imap.MailboxID{proton.DraftsLabel},
+		ParsedMessage: parsedMessage,
+	}))
+
+	for _, draft := range versions {
+		conn.publishUpdate(ctx, imap.NewMessagesDeleted(draft.id))
+	}
+
+	d.log.WithField("messageID", msg.ID).WithField("versions", len(versions)).Debug("Uploaded held draft")
+
+	return msg, nil
+}
+
+// scheduleUnsafe uploads the latest held version of the draft after the given delay.
+func (d *draftCoalescing) scheduleUnsafe(lineage *draftLineage, delay time.Duration) {
+	lineage.timer = time.AfterFunc(delay, func() {
+		if _, err := d.upload(context.Background(), lineage); err != nil {
+			d.log.WithError(err).Warn("Failed to upload held draft, retrying later")
+		}
+	})
+}
+
+// pruneUnsafe forgets the drafts which have no held versions and were last uploaded more than an interval ago.
+func (d *draftCoalescing) pruneUnsafe(interval time.Duration) {
+	for key, lineage := range d.lineages {
+		if len(lineage.versions) > 0 || time.Since(lineage.lastUpload) < interval {
+			continue
+		}
+
+		for id := range lineage.replaced {
+			delete(d.held, id)
+		}
+
+		delete(d.lineages, key)
+	}
+}
+
+// getDraftLineageKey returns what identifies the versions of the same draft, or an empty string if nothing does.
+func getDraftLineageKey(literal []byte) string {
+	headerLiteral, _ := rfc822.Split(literal)
+
+	header, err := rfc822.NewHeader(headerLiteral)
+	if err != nil {
+		return ""
+	}
+
+	if uid := header.Get("X-Universally-Unique-Identifier"); uid != "" {
+		return "uid:" + strings.ToLower(strings.TrimSpace(uid))
+	}
+
+	if id := header.Get("Message-Id"); id != "" {
+		return "id:" + strings.TrimSpace(id)
+	}
+
+	return ""
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package imapservice
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/gluon/imap"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDraftCoalescing_Hold(t *testing.T) {
+	d := newDraftCoalescing(logrus.WithField("test", t.Name()), time.Hour)
+
+	draft := func(body string) []byte {
+		return []byte("Message-Id: <draft@proton.local>\r\nSubject: Draft\r\n\r\n" + body + "\r\n")
+	}
+
+	// A draft which wasn't uploaded yet is uploaded right away.
+	_, ok := d.hold(nil, draft("one"), imap.NewFlagSet())
+	require.False(t, ok)
+
+	d.uploaded(nil, draft("one"))
+
+	// Versions saved within the interval after the upload are held.
+	msg1, ok := d.hold(nil, draft("two"), imap.NewFlagSet())
+	require.True(t, ok)
+
+	msg2, ok := d.hold(nil, draft("three"), imap.NewFlagSet(imap.FlagSeen))
+	require.True(t, ok)
+	require.True(t, msg2.Flags.Contains(imap.FlagSeen))
+
+	literal, _, ok := d.getLiteral(msg2.ID)
+	require.True(t, ok)
+	require.Equal(t, draft("three"), literal)
+
+	// Other drafts aren't affected.
+	_, ok = d.hold(nil, []byte("Message-Id: <other@proton.local>\r\n\r\nOther\r\n"), imap.NewFlagSet())
+	require.False(t, ok)
+
+	_, _, ok = d.getLiteral("uploaded")
+	require.False(t, ok)
+
+	// Operations which don't apply to held versions skip them.
+	require.Equal(t, []imap.MessageID{"uploaded"}, d.withoutHeld([]imap.MessageID{msg1.ID, "uploaded", msg2.ID}))
+
+	// Discarding the held versions leaves only the uploaded messages.
+	require.Equal(t, []imap.MessageID{"uploaded"}, d.discard([]imap.MessageID{msg1.ID, "uploaded", msg2.ID}))
+
+	_, _, ok = d.getLiteral(msg1.ID)
+	require.False(t, ok)
+
+	// Nothing is left to upload.
+	require.NoError(t, d.flushAll(context.Background()))
+}
+
+func TestDraftCoalescing_Disabled(t *testing.T) {
+	d := newDraftCoalescing(logrus.WithField("test", t.Name()), 0)
+
+	d.uploaded(nil, []byte("Message-Id: <draft@proton.local>\r\n\r\nOne\r\n"))
+
+	_, ok := d.hold(nil, []byte("Message-Id: <draft@proton.local>\r\n\r\nTwo\r\n"), imap.NewFlagSet())
+	require.False(t, ok)
+}
+
+func TestGetDraftLineageKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		literal string
+		want    string
+	}{
+		{
+			name:    "apple mail uuid",
+			literal: "X-Universally-Unique-Identifier: ABC-123\r\nMessage-Id: <one@proton.local>\r\n\r\nBody\r\n",
+			want:    "uid:abc-123",
+		},
+		{
+			name:    "message id",
+			literal: "Message-Id: <one@proton.local>\r\n\r\nBody\r\n",
+			want:    "id:<one@proton.local>",
+		},
+		{
+			name:    "neither",
+			literal: "Subject: Draft\r\n\r\nBody\r\n",
+			want:    "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.want, getDraftLineageKey([]byte(test.literal)))
+		})
+	}
+}
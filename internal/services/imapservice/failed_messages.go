@@ -0,0 +1,144 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package imapservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/ProtonMail/go-proton-api"
+)
+
+var ErrNoSuchFailedMessage = errors.New("no such failed message")
+
+// FailedMessage is a message that could not be downloaded or built and is kept out of the mailboxes until retried.
+type FailedMessage struct {
+	ID          string
+	Reason      string
+	Attempts    int
+	LastAttempt time.Time
+}
+
+func (s *Service) listFailedMessages(ctx context.Context) ([]FailedMessage, error) {
+	status, err := s.syncStateProvider.GetSyncStatus(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sync status: %w", err)
+	}
+
+	failed := make([]FailedMessage, 0, len(status.FailedMessages))
+
+	for id := range status.FailedMessages {
+		info := status.FailedMessageInfo[id]
+
+		failed = append(failed, FailedMessage{
+			ID:          id,
+			Reason:      info.Reason,
+			Attempts:    info.Attempts,
+			LastAttempt: info.LastAttempt,
+		})
+	}
+
+	sort.Slice(failed, func(i, j int) bool {
+		return failed[i].ID < failed[j].ID
+	})
+
+	return failed, nil
+}
+
+// retryFailedMessages downloads and builds the given failed messages again, or all of them if none are given,
+// and returns those that are still failing. Messages that no longer exist on the API are dropped from the list.
+func (s *Service) retryFailedMessages(ctx context.Context, ids []string) ([]FailedMessage, error) {
+	failed, err := s.listFailedMessages(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ids) == 0 {
+		for _, message := range failed {
+			ids = append(ids, message.ID)
+		}
+	} else if err := checkFailedMessageIDs(failed, ids); err != nil {
+		return nil, err
+	}
+
+	for _, id := range ids {
+		if err := s.retryFailedMessage(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.listFailedMessages(ctx)
+}
+
+func (s *Service) retryFailedMessage(ctx context.Context, id string) error {
+	message, err := s.client.GetMessage(ctx, id)
+	if err != nil {
+		if apiErr := new(proton.APIError); errors.As(err, &apiErr) {
+			if apiErr.Status == http.StatusUnprocessableEntity {
+				s.log.WithField("messageID", id).Info("Failed message no longer exists, dropping it")
+				return s.syncStateProvider.RemFailedMessageID(ctx, id)
+			}
+
+			return s.syncStateProvider.AddFailedMessageID(ctx, err.Error(), id)
+		}
+
+		return fmt.Errorf("failed to get message: %w", err)
+	}
+
+	// Building the message records whether it failed again.
+	updates, err := onMessageCreated(ctx, s, message.MessageMetadata, false)
+	if err != nil {
+		return err
+	}
+
+	return waitOnIMAPUpdates(ctx, updates)
+}
+
+// skipFailedMessages drops the given messages from the failed list for good; they won't be quarantined again.
+func (s *Service) skipFailedMessages(ctx context.Context, ids []string) error {
+	failed, err := s.listFailedMessages(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := checkFailedMessageIDs(failed, ids); err != nil {
+		return err
+	}
+
+	return s.syncStateProvider.SkipFailedMessageID(ctx, ids...)
+}
+
+func checkFailedMessageIDs(failed []FailedMessage, ids []string) error {
+	known := make(map[string]struct{}, len(failed))
+
+	for _, message := range failed {
+		known[message.ID] = struct{}{}
+	}
+
+	for _, id := range ids {
+		if _, ok := known[id]; !ok {
+			return fmt.Errorf("%w: %v", ErrNoSuchFailedMessage, id)
+		}
+	}
+
+	return nil
+}
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/ProtonMail/gluon"
 	"github.com/ProtonMail/gluon/imap"
 	"github.com/ProtonMail/go-proton-api"
 )
@@ -82,6 +83,18 @@ func waitOnIMAPUpdates(ctx context.Context, updates []imap.Update) error {
 	return nil
 }
 
+// waitOnMessageUpdates is like waitOnIMAPUpdates, but skips messages not known to gluon
+// such as those which could not be synced.
+func waitOnMessageUpdates(ctx context.Context, updates []imap.Update) error {
+	for _, update := range updates {
+		if err, ok := update.WaitContext(ctx); ok && err != nil && !gluon.IsNoSuchMessage(err) {
+			return fmt.Errorf("failed to apply gluon update %v: %w", update.String(), err)
+		}
+	}
+
+	return nil
+}
+
 func newPlaceHolderMailboxCreatedUpdate(labelName string) *imap.MailboxCreated {
 	return imap.NewMailboxCreated(imap.Mailbox{
 		ID:             imap.MailboxID(labelName),
@@ -0,0 +1,174 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package imapservice
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+
+	"github.com/ProtonMail/gluon/imap"
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/proton-bridge/v3/internal/usertypes"
+)
+
+// LabelKeywordMode controls whether Proton labels are exposed as IMAP keywords on messages.
+// Keywords set by clients on APPEND are mapped back to labels. Keywords changed with STORE
+// are kept locally by gluon, which doesn't forward custom flags to the connector.
+type LabelKeywordMode int
+
+const (
+	LabelKeywordsOff LabelKeywordMode = iota
+	LabelKeywordsBoth
+	LabelKeywordsOnly
+)
+
+// labelKeyword returns the IMAP keyword the given label is exposed as.
+// Characters which are not allowed in an IMAP atom are replaced with underscores.
+func labelKeyword(label proton.Label) string {
+	path := label.Path
+	if len(path) == 0 {
+		path = []string{label.Name}
+	}
+
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' || r >= 0x7f || strings.ContainsRune(`(){%*"\]`, r) {
+			return '_'
+		}
+
+		return r
+	}, strings.Join(path, "/"))
+}
+
+// labelKeywords returns the keywords of the labels among the given mailboxes.
+func labelKeywords(rd labelsRead, mboxIDs []imap.MailboxID) []string {
+	var keywords []string
+
+	for _, mboxID := range mboxIDs {
+		if label, ok := rd.GetLabel(string(mboxID)); ok && label.Type == proton.LabelTypeLabel {
+			keywords = append(keywords, labelKeyword(label))
+		}
+	}
+
+	return keywords
+}
+
+// keywordLabelIDs returns the IDs of the labels matching the keywords among the given flags.
+func keywordLabelIDs(rd labelsRead, flags imap.FlagSet) []string {
+	var labelIDs []string
+
+	for _, label := range rd.GetLabels() {
+		if label.Type == proton.LabelTypeLabel && flags.Contains(labelKeyword(label)) {
+			labelIDs = append(labelIDs, label.ID)
+		}
+	}
+
+	return labelIDs
+}
+
+func (s *Connector) SetLabelKeywordMode(mode LabelKeywordMode) {
+	atomic.StoreUint32(&s.labelKeywordMode, uint32(mode))
+}
+
+func (s *Connector) getLabelKeywordMode() LabelKeywordMode {
+	return LabelKeywordMode(atomic.LoadUint32(&s.labelKeywordMode))
+}
+
+// isLabelMailboxHidden returns whether the given mailbox is hidden because labels are only exposed as keywords.
+func (s *Connector) isLabelMailboxHidden(mboxID imap.MailboxID) bool {
+	if s.getLabelKeywordMode() != LabelKeywordsOnly {
+		return false
+	}
+
+	if mboxID == labelPrefix {
+		return true
+	}
+
+	rd := s.labels.Read()
+	defer rd.Close()
+
+	label, ok := rd.GetLabel(string(mboxID))
+
+	return ok && label.Type == proton.LabelTypeLabel
+}
+
+// withLabelKeywords adds the keywords of the labels of the messages in the given update to their flags.
+func (s *Connector) withLabelKeywords(update imap.Update) imap.Update {
+	if s.getLabelKeywordMode() == LabelKeywordsOff {
+		return update
+	}
+
+	rd := s.labels.Read()
+	defer rd.Close()
+
+	switch update := update.(type) {
+	case *imap.MessagesCreated:
+		for _, message := range update.Messages {
+			message.Message.Flags = message.Message.Flags.Add(labelKeywords(rd, message.MailboxIDs)...)
+		}
+
+	case *imap.MessageUpdated:
+		update.Message.Flags = update.Message.Flags.Add(labelKeywords(rd, update.MailboxIDs)...)
+
+	case *imap.MessageMailboxesUpdated:
+		update.Flags = update.Flags.Add(labelKeywords(rd, update.MailboxIDs)...)
+	}
+
+	return update
+}
+
+// setLabelKeywordMode changes whether labels are exposed as keywords and updates the flags of all messages.
+func (s *Service) setLabelKeywordMode(ctx context.Context, mode LabelKeywordMode) error {
+	if s.labelKeywordMode == mode {
+		return nil
+	}
+
+	s.labelKeywordMode = mode
+
+	for _, c := range s.connectors {
+		c.SetLabelKeywordMode(mode)
+	}
+
+	messages, err := s.GetMailboxMessages(ctx, proton.AllMailLabel)
+	if err != nil {
+		return err
+	}
+
+	apiLabels := s.labels.GetLabelMap()
+
+	var updates []imap.Update
+
+	for _, message := range messages {
+		update := imap.NewMessageMailboxesUpdated(
+			imap.MessageID(message.ID),
+			append(usertypes.MapTo[string, imap.MailboxID](wantLabels(apiLabels, message.LabelIDs)), s.savedSearches.membership[message.ID]...),
+			BuildFlagSetFromMessageMetadata(message),
+		)
+
+		didPublish, err := safePublishMessageUpdate(ctx, s, message.AddressID, update)
+		if err != nil {
+			return err
+		}
+
+		if didPublish {
+			updates = append(updates, update)
+		}
+	}
+
+	return waitOnMessageUpdates(ctx, updates)
+}
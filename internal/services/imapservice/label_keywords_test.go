@@ -0,0 +1,35 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package imapservice
+
+import (
+	"testing"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLabelKeyword(t *testing.T) {
+	require.Equal(t, "Work", labelKeyword(proton.Label{Name: "Work"}))
+
+	// Nested labels are joined with the hierarchy delimiter.
+	require.Equal(t, "Work/Clients", labelKeyword(proton.Label{Name: "Clients", Path: []string{"Work", "Clients"}}))
+
+	// Characters not allowed in an atom are replaced.
+	require.Equal(t, "To_do___soon_", labelKeyword(proton.Label{Path: []string{"To do (*soon)"}}))
+}
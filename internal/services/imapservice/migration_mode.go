@@ -0,0 +1,258 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package imapservice
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ProtonMail/gluon/imap"
+	"github.com/ProtonMail/gluon/rfc822"
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/proton-bridge/v3/internal/events"
+)
+
+const (
+	// migrationBackOff is the delay between imports after the API first rejected an import with 429.
+	migrationBackOff = time.Second
+
+	// migrationMinDelay is the delay below which imports are no longer delayed.
+	migrationMinDelay = 100 * time.Millisecond
+
+	// migrationMaxDelay is the longest delay between imports.
+	migrationMaxDelay = time.Minute
+
+	// migrationMaxRetries is how many times an import rejected with 429 is retried.
+	migrationMaxRetries = 5
+
+	// migrationProgressInterval is the number of appended messages between two progress events.
+	migrationProgressInterval = 100
+)
+
+// migration tunes the import of appended messages for bulk workloads, such as imapsync migrations:
+// imports rejected with 429 are retried and subsequent imports delayed, adapting to the API rate limits,
+// messages which were already imported are not imported again, and progress and failures are reported as events.
+// It is shared by the connectors of a user since the API rate limits apply to the whole account.
+type migration struct {
+	userID         string
+	eventPublisher events.EventPublisher
+
+	enabled atomic.Bool
+
+	lock       sync.Mutex
+	delay      time.Duration
+	imported   map[string]string
+	duplicates int
+	failed     int
+}
+
+func newMigration(userID string, eventPublisher events.EventPublisher, enabled bool) *migration {
+	m := &migration{
+		userID:         userID,
+		eventPublisher: eventPublisher,
+		imported:       make(map[string]string),
+	}
+
+	m.enabled.Store(enabled)
+
+	return m
+}
+
+func (m *migration) isEnabled() bool {
+	return m.enabled.Load()
+}
+
+// setEnabled enables or disables migration mode. Disabling it resets the throttling and the statistics.
+func (m *migration) setEnabled(ctx context.Context, enabled bool) {
+	if m.enabled.Swap(enabled) == enabled || enabled {
+		return
+	}
+
+	m.lock.Lock()
+	event := m.progressEvent()
+	m.delay = 0
+	m.imported = make(map[string]string)
+	m.duplicates = 0
+	m.failed = 0
+	m.lock.Unlock()
+
+	if event.Imported+event.Duplicates+event.Failed > 0 {
+		m.eventPublisher.PublishEvent(ctx, event)
+	}
+}
+
+// throttle runs the given import, waiting for the current delay first and retrying it if the API rejected it with 429.
+func (m *migration) throttle(ctx context.Context, fn func() error) error {
+	for attempt := 0; ; attempt++ {
+		if delay := m.getDelay(); delay > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+
+			case <-time.After(delay):
+			}
+		}
+
+		err := fn()
+		if err == nil {
+			m.speedUp()
+			return nil
+		}
+
+		if !isTooManyRequests(err) || attempt >= migrationMaxRetries {
+			return err
+		}
+
+		m.slowDown()
+	}
+}
+
+func (m *migration) getDelay() time.Duration {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.delay
+}
+
+func (m *migration) slowDown() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.delay *= 2; m.delay < migrationBackOff {
+		m.delay = migrationBackOff
+	} else if m.delay > migrationMaxDelay {
+		m.delay = migrationMaxDelay
+	}
+}
+
+func (m *migration) speedUp() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.delay -= m.delay / 4; m.delay < migrationMinDelay {
+		m.delay = 0
+	}
+}
+
+// getImported returns the ID of the message imported from the same literal into the same mailbox, if any.
+func (m *migration) getImported(key string) (string, bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	messageID, ok := m.imported[key]
+
+	return messageID, ok
+}
+
+func (m *migration) onImported(ctx context.Context, key, messageID string) {
+	m.lock.Lock()
+	m.imported[key] = messageID
+	m.lock.Unlock()
+
+	m.publishProgress(ctx)
+}
+
+func (m *migration) onDuplicate(ctx context.Context) {
+	m.lock.Lock()
+	m.duplicates++
+	m.lock.Unlock()
+
+	m.publishProgress(ctx)
+}
+
+func (m *migration) onFailed(ctx context.Context, mboxID imap.MailboxID, subject string, err error) {
+	m.lock.Lock()
+	m.failed++
+	m.lock.Unlock()
+
+	m.eventPublisher.PublishEvent(ctx, events.UserMigrationFailed{
+		UserID:    m.userID,
+		MailboxID: string(mboxID),
+		Subject:   subject,
+		Error:     err,
+	})
+
+	m.publishProgress(ctx)
+}
+
+func (m *migration) publishProgress(ctx context.Context) {
+	m.lock.Lock()
+	event := m.progressEvent()
+	m.lock.Unlock()
+
+	if (event.Imported+event.Duplicates+event.Failed)%migrationProgressInterval == 0 {
+		m.eventPublisher.PublishEvent(ctx, event)
+	}
+}
+
+func (m *migration) progressEvent() events.UserMigrationProgress {
+	return events.UserMigrationProgress{
+		UserID:     m.userID,
+		Imported:   len(m.imported),
+		Duplicates: m.duplicates,
+		Failed:     m.failed,
+	}
+}
+
+// findMigrationDuplicate returns the ID of a message already imported from the given literal into the given mailbox.
+// Besides the messages imported since migration mode was enabled, it looks up the message by its Message-ID on the server.
+func (s *Connector) findMigrationDuplicate(ctx context.Context, mboxID imap.MailboxID, key string, header *rfc822.Header) (string, bool, error) {
+	if messageID, ok := s.migration.getImported(key); ok {
+		return messageID, true, nil
+	}
+
+	externalID := strings.Trim(header.Get("Message-Id"), " <>")
+	if externalID == "" {
+		return "", false, nil
+	}
+
+	metadata, err := s.client.GetMessageMetadataPage(ctx, 0, 1, proton.MessageFilter{
+		ExternalID: externalID,
+		LabelID:    string(mboxID),
+	})
+	if err != nil {
+		return "", false, err
+	}
+
+	if len(metadata) == 0 {
+		return "", false, nil
+	}
+
+	return metadata[0].ID, true, nil
+}
+
+func migrationKey(mboxID imap.MailboxID, hash string) string {
+	return string(mboxID) + "/" + hash
+}
+
+func isTooManyRequests(err error) bool {
+	apiErr := new(proton.APIError)
+
+	return errors.As(err, &apiErr) && apiErr.Status == http.StatusTooManyRequests
+}
+
+func (s *Service) setMigrationMode(ctx context.Context, enabled bool) {
+	s.log.WithField("enabled", enabled).Info("Setting migration mode")
+
+	s.migration.setEnabled(ctx, enabled)
+}
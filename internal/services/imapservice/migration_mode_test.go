@@ -0,0 +1,107 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package imapservice
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/proton-bridge/v3/internal/events"
+	"github.com/stretchr/testify/require"
+)
+
+type testEventPublisher struct {
+	events []events.Event
+}
+
+func (p *testEventPublisher) PublishEvent(_ context.Context, event events.Event) {
+	p.events = append(p.events, event)
+}
+
+func TestMigration_Throttle(t *testing.T) {
+	m := newMigration("userID", &testEventPublisher{}, true)
+
+	// Imports rejected with 429 are retried after a delay.
+	var calls int
+
+	require.NoError(t, m.throttle(context.Background(), func() error {
+		if calls++; calls == 1 {
+			return &proton.APIError{Status: http.StatusTooManyRequests}
+		}
+
+		return nil
+	}))
+	require.Equal(t, 2, calls)
+
+	// The delay shrinks again once imports succeed.
+	require.Equal(t, migrationBackOff-migrationBackOff/4, m.getDelay())
+
+	for i := 0; i < 10; i++ {
+		m.speedUp()
+	}
+
+	require.Zero(t, m.getDelay())
+
+	// Other errors are not retried.
+	calls = 0
+
+	require.Error(t, m.throttle(context.Background(), func() error {
+		calls++
+		return errors.New("failed")
+	}))
+	require.Equal(t, 1, calls)
+}
+
+func TestMigration_SlowDown(t *testing.T) {
+	m := newMigration("userID", &testEventPublisher{}, true)
+
+	m.slowDown()
+	require.Equal(t, migrationBackOff, m.getDelay())
+
+	m.slowDown()
+	require.Equal(t, 2*migrationBackOff, m.getDelay())
+
+	for i := 0; i < 10; i++ {
+		m.slowDown()
+	}
+
+	require.Equal(t, migrationMaxDelay, m.getDelay())
+}
+
+func TestMigration_Progress(t *testing.T) {
+	publisher := &testEventPublisher{}
+	m := newMigration("userID", publisher, true)
+
+	m.onImported(context.Background(), migrationKey("mboxID", "hash"), "messageID")
+	m.onDuplicate(context.Background())
+
+	messageID, ok := m.getImported(migrationKey("mboxID", "hash"))
+	require.True(t, ok)
+	require.Equal(t, "messageID", messageID)
+
+	// Disabling migration mode reports the final progress and forgets the imported messages.
+	m.setEnabled(context.Background(), false)
+
+	require.Equal(t, []events.Event{events.UserMigrationProgress{UserID: "userID", Imported: 1, Duplicates: 1}}, publisher.events)
+
+	_, ok = m.getImported(migrationKey("mboxID", "hash"))
+	require.False(t, ok)
+}
@@ -0,0 +1,134 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package imapservice
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/proton-bridge/v3/internal/events"
+	"github.com/bradenaw/juniper/xslices"
+	"golang.org/x/exp/slices"
+)
+
+// NotificationRules decide which newly received messages trigger a notification.
+type NotificationRules struct {
+	Enabled bool
+
+	// Mailboxes that trigger notifications; all mailboxes do if empty.
+	// Mailboxes are given by label ID, by name (e.g. "Urgent") or by IMAP path (e.g. "Labels/Urgent").
+	Mailboxes []string
+
+	// Muted mailboxes never trigger notifications, even if listed in Mailboxes.
+	Muted []string
+
+	QuietHours QuietHours
+}
+
+// QuietHours is a daily period, in local time, during which no notifications are triggered.
+type QuietHours struct {
+	Enabled bool
+
+	// Start and End are offsets from midnight. If End is before Start, the period spans midnight.
+	Start, End time.Duration
+}
+
+// Contains returns whether the given time falls within the quiet hours.
+func (hours QuietHours) Contains(t time.Time) bool {
+	if !hours.Enabled || hours.Start == hours.End {
+		return false
+	}
+
+	offset := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+
+	if hours.Start < hours.End {
+		return offset >= hours.Start && offset < hours.End
+	}
+
+	return offset >= hours.Start || offset < hours.End
+}
+
+// ShouldNotify returns whether a message received at the given time into the given mailboxes triggers a notification.
+func (rules NotificationRules) ShouldNotify(labels []proton.Label, now time.Time) bool {
+	if !rules.Enabled || rules.QuietHours.Contains(now) {
+		return false
+	}
+
+	if slices.ContainsFunc(labels, func(label proton.Label) bool { return matchesAny(label, rules.Muted) }) {
+		return false
+	}
+
+	if len(rules.Mailboxes) == 0 {
+		return true
+	}
+
+	return slices.ContainsFunc(labels, func(label proton.Label) bool { return matchesAny(label, rules.Mailboxes) })
+}
+
+func matchesAny(label proton.Label, mailboxes []string) bool {
+	return slices.ContainsFunc(mailboxes, func(mailbox string) bool {
+		return mailbox == label.ID ||
+			strings.EqualFold(mailbox, label.Name) ||
+			strings.EqualFold(mailbox, strings.Join(GetMailboxName(label), "/"))
+	})
+}
+
+// publishNotification emits a notification event for the given message, received into the given mailboxes,
+// if the rules allow it.
+func (s *Service) publishNotification(ctx context.Context, message proton.MessageMetadata, labelIDs []string) {
+	if !s.notificationRules.Enabled || !message.Flags.Has(proton.MessageFlagReceived) {
+		return
+	}
+
+	labels := func() []proton.Label {
+		rd := s.labels.Read()
+		defer rd.Close()
+
+		var labels []proton.Label
+
+		for _, labelID := range labelIDs {
+			if label, ok := rd.GetLabel(labelID); ok && labelID != proton.AllMailLabel {
+				labels = append(labels, label)
+			}
+		}
+
+		return labels
+	}()
+
+	if !s.notificationRules.ShouldNotify(labels, time.Now()) {
+		return
+	}
+
+	var sender string
+
+	if message.Sender != nil {
+		sender = message.Sender.String()
+	}
+
+	s.eventPublisher.PublishEvent(ctx, events.UserMessageNotification{
+		UserID:    s.identityState.UserID(),
+		MessageID: message.ID,
+		Sender:    sender,
+		Subject:   message.Subject,
+		Mailboxes: xslices.Map(labels, func(label proton.Label) string {
+			return strings.Join(GetMailboxName(label), "/")
+		}),
+	})
+}
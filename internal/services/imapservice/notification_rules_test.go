@@ -0,0 +1,76 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package imapservice
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuietHours_Contains(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2023, 10, 1, hour, minute, 0, 0, time.Local)
+	}
+
+	day := QuietHours{Enabled: true, Start: 12 * time.Hour, End: 14 * time.Hour}
+	require.False(t, day.Contains(at(11, 59)))
+	require.True(t, day.Contains(at(12, 0)))
+	require.True(t, day.Contains(at(13, 30)))
+	require.False(t, day.Contains(at(14, 0)))
+
+	// The period may span midnight.
+	night := QuietHours{Enabled: true, Start: 22 * time.Hour, End: 7 * time.Hour}
+	require.True(t, night.Contains(at(23, 0)))
+	require.True(t, night.Contains(at(6, 59)))
+	require.False(t, night.Contains(at(7, 0)))
+	require.False(t, night.Contains(at(12, 0)))
+
+	// Disabled quiet hours never apply.
+	night.Enabled = false
+	require.False(t, night.Contains(at(23, 0)))
+}
+
+func TestNotificationRules_ShouldNotify(t *testing.T) {
+	inbox := proton.Label{ID: proton.InboxLabel, Name: "Inbox", Path: []string{"INBOX"}, Type: proton.LabelTypeSystem}
+	urgent := proton.Label{ID: "urgent-id", Name: "Urgent", Path: []string{"Urgent"}, Type: proton.LabelTypeLabel}
+	news := proton.Label{ID: "news-id", Name: "Newsletters", Path: []string{"Newsletters"}, Type: proton.LabelTypeFolder}
+
+	noon := time.Date(2023, 10, 1, 12, 0, 0, 0, time.Local)
+
+	// Disabled rules never notify.
+	require.False(t, NotificationRules{}.ShouldNotify([]proton.Label{inbox}, noon))
+
+	// Enabled rules without mailboxes notify for everything that isn't muted.
+	all := NotificationRules{Enabled: true, Muted: []string{"Folders/Newsletters"}}
+	require.True(t, all.ShouldNotify([]proton.Label{inbox}, noon))
+	require.False(t, all.ShouldNotify([]proton.Label{news}, noon))
+
+	// Only the listed mailboxes notify, matched by IMAP path, name or ID.
+	some := NotificationRules{Enabled: true, Mailboxes: []string{"inbox", "Urgent"}, Muted: []string{"news-id"}}
+	require.True(t, some.ShouldNotify([]proton.Label{inbox}, noon))
+	require.True(t, some.ShouldNotify([]proton.Label{urgent}, noon))
+	require.False(t, some.ShouldNotify([]proton.Label{news}, noon))
+	require.False(t, some.ShouldNotify([]proton.Label{urgent, news}, noon))
+
+	// Nothing notifies during quiet hours.
+	some.QuietHours = QuietHours{Enabled: true, Start: 11 * time.Hour, End: 13 * time.Hour}
+	require.False(t, some.ShouldNotify([]proton.Label{inbox}, noon))
+}
@@ -0,0 +1,347 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package imapservice
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	// pendingOpsDelay is how long flag and move operations are collected before they are applied in batches.
+	pendingOpsDelay = 500 * time.Millisecond
+
+	// pendingOpsRetryDelay is how long to wait before applying operations again after they failed.
+	pendingOpsRetryDelay = 10 * time.Second
+
+	// pendingOpGroupSeen groups the operations changing whether messages are read.
+	pendingOpGroupSeen = "seen"
+
+	// pendingOpGroupLocation groups the operations moving messages between folders, a message can only be in one of.
+	pendingOpGroupLocation = "location"
+)
+
+type pendingOpKind int
+
+const (
+	pendingOpMarkRead pendingOpKind = iota
+	pendingOpMarkUnread
+	pendingOpLabel
+	pendingOpUnlabel
+)
+
+// pendingOp is a flag or move operation which was applied locally but not yet on the server.
+type pendingOp struct {
+	Kind       pendingOpKind
+	LabelID    string `json:",omitempty"`
+	MessageIDs []string
+
+	// Group is what the operation changes. Operations of different groups don't affect each other
+	// and may be applied in a different order than they were made.
+	Group string
+}
+
+type pendingOpsClient interface {
+	LabelMessages(ctx context.Context, messageIDs []string, labelID string) error
+	UnlabelMessages(ctx context.Context, messageIDs []string, labelID string) error
+	MarkMessagesRead(ctx context.Context, messageIDs ...string) error
+	MarkMessagesUnread(ctx context.Context, messageIDs ...string) error
+}
+
+// pendingOps coalesces the flag and move operations of a user and applies them on the server in batches,
+// such that marking thousands of messages read one by one only results in a few API calls.
+// Operations are journaled to disk until they are applied so that they survive a restart.
+type pendingOps struct {
+	client pendingOpsClient
+	path   string
+	log    *logrus.Entry
+
+	lock sync.Mutex
+	ops  []pendingOp
+
+	// flushLock serializes flushes, so that operations are applied in order.
+	flushLock sync.Mutex
+	triggerCh chan struct{}
+}
+
+func newPendingOps(client pendingOpsClient, path string, log *logrus.Entry) *pendingOps {
+	return &pendingOps{
+		client:    client,
+		path:      path,
+		log:       log,
+		triggerCh: make(chan struct{}, 1),
+	}
+}
+
+func GetPendingOpsPath(path string, userID string) string {
+	return filepath.Join(path, fmt.Sprintf("pending-ops-%v", userID))
+}
+
+// load reads the operations which were not applied before the previous shutdown.
+func (p *pendingOps) load() error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	data, err := os.ReadFile(p.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to read pending operations: %w", err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+
+	for {
+		var op pendingOp
+
+		if err := dec.Decode(&op); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			// The last operation may have been cut short by a crash.
+			p.log.WithError(err).Warn("Failed to read pending operation, skipping the remaining ones")
+			break
+		}
+
+		p.mergeUnsafe(op)
+	}
+
+	if len(p.ops) > 0 {
+		p.log.WithField("count", len(p.ops)).Info("Loaded pending flag and move operations")
+		p.trigger()
+	}
+
+	return nil
+}
+
+// add queues the given operation. If it can't be journaled, it is applied right away instead.
+func (p *pendingOps) add(ctx context.Context, op pendingOp) error {
+	if err := p.addJournaled(op); err != nil {
+		p.log.WithError(err).Warn("Failed to journal operation, applying it now")
+
+		if err := p.flush(ctx); err != nil {
+			return err
+		}
+
+		return p.apply(ctx, op)
+	}
+
+	p.trigger()
+
+	return nil
+}
+
+func (p *pendingOps) addJournaled(op pendingOp) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	b, err := json.Marshal(op)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(p.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600) //nolint:gosec
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(append(b, '\n')); err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	p.mergeUnsafe(op)
+
+	return nil
+}
+
+// mergeUnsafe merges the operation into the last queued operation of its group if they are of the same kind.
+func (p *pendingOps) mergeUnsafe(op pendingOp) {
+	for idx := len(p.ops) - 1; idx >= 0; idx-- {
+		if p.ops[idx].Group != op.Group {
+			continue
+		}
+
+		if p.ops[idx].Kind == op.Kind && p.ops[idx].LabelID == op.LabelID {
+			p.ops[idx].MessageIDs = append(p.ops[idx].MessageIDs, op.MessageIDs...)
+			return
+		}
+
+		break
+	}
+
+	p.ops = append(p.ops, op)
+}
+
+func (p *pendingOps) trigger() {
+	select {
+	case p.triggerCh <- struct{}{}:
+	default:
+	}
+}
+
+// flush applies all queued operations on the server, in order. Operations the server rejects are dropped,
+// those which failed otherwise are kept to be applied later.
+func (p *pendingOps) flush(ctx context.Context) error {
+	p.flushLock.Lock()
+	defer p.flushLock.Unlock()
+
+	p.lock.Lock()
+	ops := p.ops
+	p.ops = nil
+	p.lock.Unlock()
+
+	var (
+		remaining []pendingOp
+		err       error
+	)
+
+	for idx, op := range ops {
+		if err = p.apply(ctx, op); err == nil {
+			continue
+		}
+
+		if isPermanentAPIError(err) {
+			p.log.WithError(err).WithField("count", len(op.MessageIDs)).Error("Server rejected flag or move operation, dropping it")
+			err = nil
+
+			continue
+		}
+
+		remaining = slices.Clone(ops[idx:])
+
+		break
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.ops = append(remaining, p.ops...)
+
+	if storeErr := p.storeUnsafe(); storeErr != nil {
+		p.log.WithError(storeErr).Error("Failed to store pending operations")
+	}
+
+	return err
+}
+
+func (p *pendingOps) apply(ctx context.Context, op pendingOp) error {
+	switch op.Kind {
+	case pendingOpMarkRead:
+		return p.client.MarkMessagesRead(ctx, op.MessageIDs...)
+
+	case pendingOpMarkUnread:
+		return p.client.MarkMessagesUnread(ctx, op.MessageIDs...)
+
+	case pendingOpLabel:
+		return p.client.LabelMessages(ctx, op.MessageIDs, op.LabelID)
+
+	case pendingOpUnlabel:
+		return p.client.UnlabelMessages(ctx, op.MessageIDs, op.LabelID)
+
+	default:
+		return fmt.Errorf("unknown pending operation kind %v", op.Kind)
+	}
+}
+
+// storeUnsafe rewrites the journal with the queued operations, removing it if there are none.
+func (p *pendingOps) storeUnsafe() error {
+	if len(p.ops) == 0 {
+		if err := os.Remove(p.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+
+		return nil
+	}
+
+	var buf bytes.Buffer
+
+	enc := json.NewEncoder(&buf)
+
+	for _, op := range p.ops {
+		if err := enc.Encode(op); err != nil {
+			return err
+		}
+	}
+
+	tmpFile := p.path + ".tmp"
+
+	if err := os.WriteFile(tmpFile, buf.Bytes(), 0o600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpFile, p.path)
+}
+
+// run applies the queued operations shortly after they were added, batching those made meanwhile.
+// Operations still queued when it returns are applied after the next start.
+func (p *pendingOps) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-p.triggerCh:
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-time.After(pendingOpsDelay):
+		}
+
+		if err := p.flush(ctx); err != nil {
+			p.log.WithError(err).Warn("Failed to apply flag and move operations, retrying later")
+
+			select {
+			case <-ctx.Done():
+				return
+
+			case <-time.After(pendingOpsRetryDelay):
+			}
+
+			p.trigger()
+		}
+	}
+}
+
+func isPermanentAPIError(err error) bool {
+	apiErr := new(proton.APIError)
+
+	return errors.As(err, &apiErr) &&
+		apiErr.Status >= http.StatusBadRequest &&
+		apiErr.Status < http.StatusInternalServerError &&
+		apiErr.Status != http.StatusTooManyRequests
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package imapservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+type testPendingOpsClient struct {
+	calls []string
+	err   error
+}
+
+func (c *testPendingOpsClient) LabelMessages(_ context.Context, messageIDs []string, labelID string) error {
+	return c.call("label %v %v", labelID, messageIDs)
+}
+
+func (c *testPendingOpsClient) UnlabelMessages(_ context.Context, messageIDs []string, labelID string) error {
+	return c.call("unlabel %v %v", labelID, messageIDs)
+}
+
+func (c *testPendingOpsClient) MarkMessagesRead(_ context.Context, messageIDs ...string) error {
+	return c.call("read %v", messageIDs)
+}
+
+func (c *testPendingOpsClient) MarkMessagesUnread(_ context.Context, messageIDs ...string) error {
+	return c.call("unread %v", messageIDs)
+}
+
+func (c *testPendingOpsClient) call(format string, args ...any) error {
+	if c.err != nil {
+		return c.err
+	}
+
+	c.calls = append(c.calls, strings.TrimSpace(fmt.Sprintf(format, args...)))
+
+	return nil
+}
+
+func TestPendingOps_Coalesce(t *testing.T) {
+	client := &testPendingOpsClient{}
+	ops := newPendingOps(client, filepath.Join(t.TempDir(), "pending-ops"), logrus.WithField("test", t.Name()))
+
+	require.NoError(t, ops.add(context.Background(), pendingOp{Kind: pendingOpMarkRead, MessageIDs: []string{"a"}, Group: pendingOpGroupSeen}))
+	require.NoError(t, ops.add(context.Background(), pendingOp{Kind: pendingOpLabel, LabelID: proton.StarredLabel, MessageIDs: []string{"a"}, Group: proton.StarredLabel}))
+	require.NoError(t, ops.add(context.Background(), pendingOp{Kind: pendingOpMarkRead, MessageIDs: []string{"b"}, Group: pendingOpGroupSeen}))
+	require.NoError(t, ops.add(context.Background(), pendingOp{Kind: pendingOpMarkUnread, MessageIDs: []string{"a"}, Group: pendingOpGroupSeen}))
+
+	require.NoError(t, ops.flush(context.Background()))
+
+	// Operations of the same kind are merged unless another operation of their group came in between.
+	require.Equal(t, []string{
+		"read [a b]",
+		"label 10 [a]",
+		"unread [a]",
+	}, client.calls)
+}
+
+func TestPendingOps_Journal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pending-ops")
+
+	// The operations are not applied before shutdown.
+	{
+		ops := newPendingOps(&testPendingOpsClient{}, path, logrus.WithField("test", t.Name()))
+
+		require.NoError(t, ops.add(context.Background(), pendingOp{Kind: pendingOpMarkRead, MessageIDs: []string{"a"}, Group: pendingOpGroupSeen}))
+		require.NoError(t, ops.add(context.Background(), pendingOp{Kind: pendingOpMarkRead, MessageIDs: []string{"b"}, Group: pendingOpGroupSeen}))
+		require.FileExists(t, path)
+	}
+
+	// They are applied after the next start.
+	client := &testPendingOpsClient{}
+	ops := newPendingOps(client, path, logrus.WithField("test", t.Name()))
+
+	require.NoError(t, ops.load())
+	require.NoError(t, ops.flush(context.Background()))
+	require.Equal(t, []string{"read [a b]"}, client.calls)
+	require.NoFileExists(t, path)
+}
+
+func TestPendingOps_Errors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pending-ops")
+	client := &testPendingOpsClient{err: errors.New("no connection")}
+	ops := newPendingOps(client, path, logrus.WithField("test", t.Name()))
+
+	// Operations which failed to apply are kept.
+	require.NoError(t, ops.add(context.Background(), pendingOp{Kind: pendingOpMarkRead, MessageIDs: []string{"a"}, Group: pendingOpGroupSeen}))
+	require.Error(t, ops.flush(context.Background()))
+	require.FileExists(t, path)
+
+	client.err = nil
+
+	require.NoError(t, ops.flush(context.Background()))
+	require.Equal(t, []string{"read [a]"}, client.calls)
+
+	// Operations the server rejects are dropped.
+	client.err = &proton.APIError{Status: http.StatusUnprocessableEntity}
+
+	require.NoError(t, ops.add(context.Background(), pendingOp{Kind: pendingOpMarkRead, MessageIDs: []string{"b"}, Group: pendingOpGroupSeen}))
+	require.NoError(t, ops.flush(context.Background()))
+	require.NoFileExists(t, path)
+}
@@ -0,0 +1,101 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package imapservice
+
+import (
+	"context"
+	"net/mail"
+	"path"
+	"strings"
+
+	"github.com/ProtonMail/gluon/imap"
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/bradenaw/juniper/xslices"
+	"golang.org/x/exp/slices"
+)
+
+// RecipientRoute files the new messages sent to the recipients matching Pattern into the folder at path Folder.
+// Pattern is a lowercase address or a glob of addresses as understood by path.Match.
+type RecipientRoute struct {
+	Pattern string
+	Folder  string
+}
+
+// matchRecipientRoute returns the first route matching a recipient of the given message.
+func matchRecipientRoute(routes []RecipientRoute, metadata proton.MessageMetadata) (RecipientRoute, bool) {
+	for _, route := range routes {
+		for _, recipients := range [][]*mail.Address{metadata.ToList, metadata.CCList, metadata.BCCList} {
+			if slices.ContainsFunc(recipients, func(recipient *mail.Address) bool {
+				ok, err := path.Match(route.Pattern, strings.ToLower(recipient.Address))
+				return err == nil && ok
+			}) {
+				return route, true
+			}
+		}
+	}
+
+	return RecipientRoute{}, false
+}
+
+// applyRecipientRoutes moves a newly received message from the Inbox into the folder of the first route matching
+// one of its recipients. Routes to folders which don't exist are ignored; the message then stays in the Inbox.
+func applyRecipientRoutes(
+	ctx context.Context,
+	s *Service,
+	metadata proton.MessageMetadata,
+	update *imap.MessageCreated,
+) {
+	if !metadata.Flags.Has(proton.MessageFlagReceived) || !slices.Contains(update.MailboxIDs, proton.InboxLabel) {
+		return
+	}
+
+	route, ok := matchRecipientRoute(s.recipientRoutes, metadata)
+	if !ok {
+		return
+	}
+
+	log := s.log.WithField("messageID", metadata.ID).WithField("folder", route.Folder)
+
+	folder, ok := getFolderByPath(s.labels.GetLabelMap(), route.Folder)
+	if !ok {
+		log.Warn("Ignoring recipient route to unknown folder")
+		return
+	}
+
+	log.Info("Moving message to folder of recipient route")
+
+	if err := s.client.LabelMessages(ctx, []string{metadata.ID}, folder.ID); err != nil {
+		log.WithError(err).Warn("Failed to move message to folder of recipient route")
+		return
+	}
+
+	update.MailboxIDs = append(xslices.Filter(update.MailboxIDs, func(mboxID imap.MailboxID) bool {
+		return mboxID != proton.InboxLabel
+	}), imap.MailboxID(folder.ID))
+}
+
+// getFolderByPath returns the folder at the given path, e.g. Work/Projects.
+func getFolderByPath(apiLabels map[string]proton.Label, folderPath string) (proton.Label, bool) {
+	for _, label := range apiLabels {
+		if label.Type == proton.LabelTypeFolder && strings.Join(label.Path, "/") == folderPath {
+			return label, true
+		}
+	}
+
+	return proton.Label{}, false
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package imapservice
+
+import (
+	"net/mail"
+	"testing"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchRecipientRoute(t *testing.T) {
+	routes := []RecipientRoute{
+		{Pattern: "shop@example.com", Folder: "Shopping"},
+		{Pattern: "*@news.example.com", Folder: "Newsletters"},
+	}
+
+	match := func(to, cc string) (RecipientRoute, bool) {
+		metadata := proton.MessageMetadata{ToList: []*mail.Address{{Address: to}}}
+
+		if cc != "" {
+			metadata.CCList = []*mail.Address{{Address: cc}}
+		}
+
+		return matchRecipientRoute(routes, metadata)
+	}
+
+	// Addresses are matched case-insensitively.
+	route, ok := match("Shop@Example.com", "")
+	require.True(t, ok)
+	require.Equal(t, "Shopping", route.Folder)
+
+	// Globs match any recipient, including CC.
+	route, ok = match("me@example.com", "weekly@news.example.com")
+	require.True(t, ok)
+	require.Equal(t, "Newsletters", route.Folder)
+
+	_, ok = match("me@example.com", "")
+	require.False(t, ok)
+}
+
+func TestGetFolderByPath(t *testing.T) {
+	labels := map[string]proton.Label{
+		"work":     {ID: "work", Name: "Work", Path: []string{"Work"}, Type: proton.LabelTypeFolder},
+		"projects": {ID: "projects", Name: "Projects", Path: []string{"Work", "Projects"}, Type: proton.LabelTypeFolder},
+		"label":    {ID: "label", Name: "Label", Path: []string{"Label"}, Type: proton.LabelTypeLabel},
+	}
+
+	folder, ok := getFolderByPath(labels, "Work/Projects")
+	require.True(t, ok)
+	require.Equal(t, "projects", folder.ID)
+
+	// Labels are not folders.
+	_, ok = getFolderByPath(labels, "Label")
+	require.False(t, ok)
+}
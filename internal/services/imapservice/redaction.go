@@ -0,0 +1,171 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package imapservice
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/ProtonMail/gluon/imap"
+	"github.com/ProtonMail/gluon/rfc5322"
+	"github.com/ProtonMail/gluon/rfc822"
+	"github.com/ProtonMail/proton-bridge/v3/internal/usertypes"
+	"golang.org/x/exp/slices"
+)
+
+// RedactionRules select the sensitive messages whose bodies are never cached locally. Gluon doesn't find them in
+// its store, so it downloads them from the API whenever a client reads them.
+type RedactionRules struct {
+	// SenderDomains are the domains of the senders whose messages are redacted, including their subdomains.
+	SenderDomains []string
+
+	// LabelIDs are the IDs of the folders and labels whose messages are redacted.
+	LabelIDs []string
+}
+
+// Matches returns whether the message of the given sender, in the given folders and labels, is redacted.
+func (rules RedactionRules) Matches(sender string, labelIDs []string) bool {
+	if _, domain, ok := strings.Cut(strings.ToLower(sender), "@"); ok {
+		for _, ruleDomain := range rules.SenderDomains {
+			ruleDomain = strings.ToLower(ruleDomain)
+
+			if domain == ruleDomain || strings.HasSuffix(domain, "."+ruleDomain) {
+				return true
+			}
+		}
+	}
+
+	for _, labelID := range labelIDs {
+		if slices.Contains(rules.LabelIDs, labelID) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RedactedMessages is the set of the IDs of the redacted messages of all users, whose bodies the store drops.
+// Messages stay redacted until bridge restarts, even if they no longer match the rules.
+type RedactedMessages struct {
+	ids sync.Map
+}
+
+func NewRedactedMessages() *RedactedMessages {
+	return &RedactedMessages{}
+}
+
+// IsRedacted returns whether the body of the message with the given ID mustn't be cached.
+func (m *RedactedMessages) IsRedacted(messageID string) bool {
+	_, ok := m.ids.Load(messageID)
+	return ok
+}
+
+func (m *RedactedMessages) add(messageID string) {
+	m.ids.Store(messageID, struct{}{})
+}
+
+// redactor holds the RedactionRules of a user, shared by the service and its connectors.
+type redactor struct {
+	rules RedactionRules
+	lock  sync.RWMutex
+
+	redacted *RedactedMessages
+}
+
+func newRedactor(rules RedactionRules, redacted *RedactedMessages) *redactor {
+	if redacted == nil {
+		redacted = NewRedactedMessages()
+	}
+
+	return &redactor{rules: rules, redacted: redacted}
+}
+
+// set replaces the rules, returning whether they changed.
+func (r *redactor) set(rules RedactionRules) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if slices.Equal(r.rules.SenderDomains, rules.SenderDomains) && slices.Equal(r.rules.LabelIDs, rules.LabelIDs) {
+		return false
+	}
+
+	r.rules = rules
+
+	return true
+}
+
+// redact marks the message as redacted if it matches the rules, returning whether it does.
+func (r *redactor) redact(messageID, sender string, labelIDs []string) bool {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	if !r.rules.Matches(sender, labelIDs) {
+		return false
+	}
+
+	r.redacted.add(messageID)
+
+	return true
+}
+
+// withRedaction marks the messages of the update which match the redaction rules before gluon stores their literals.
+// Messages moved to a redacted mailbox are evicted from the store the next time they are read.
+func (s *Connector) withRedaction(update imap.Update) imap.Update {
+	switch update := update.(type) {
+	case *imap.MessagesCreated:
+		for _, message := range update.Messages {
+			s.redactor.redact(string(message.Message.ID), getLiteralSender(message.Literal), usertypes.MapTo[imap.MailboxID, string](message.MailboxIDs))
+		}
+
+	case *imap.MessageUpdated:
+		s.redactor.redact(string(update.Message.ID), getLiteralSender(update.Literal), usertypes.MapTo[imap.MailboxID, string](update.MailboxIDs))
+
+	case *imap.MessageMailboxesUpdated:
+		s.redactor.redact(string(update.MessageID), "", usertypes.MapTo[imap.MailboxID, string](update.MailboxIDs))
+	}
+
+	return update
+}
+
+// setRedactionRules changes the redaction rules and resyncs the user, so that the store is rebuilt without the bodies
+// of the newly redacted messages.
+func (s *Service) setRedactionRules(ctx context.Context, rules RedactionRules) error {
+	if !s.redactor.set(rules) {
+		return nil
+	}
+
+	s.log.WithField("labels", rules.LabelIDs).Info("Redaction rules changed, resyncing")
+
+	return s.HandleRefreshEvent(ctx, 0)
+}
+
+// getLiteralSender returns the address of the sender of the message, or an empty string if it has none.
+func getLiteralSender(literal []byte) string {
+	from, err := rfc822.GetHeaderValue(literal, "From")
+	if err != nil {
+		return ""
+	}
+
+	addrs, err := rfc5322.ParseAddressList(from)
+	if err != nil || len(addrs) == 0 {
+		return ""
+	}
+
+	return addrs[0].Address
+}
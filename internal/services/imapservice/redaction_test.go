@@ -0,0 +1,64 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package imapservice
+
+import (
+	"testing"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactionRules_Matches(t *testing.T) {
+	rules := RedactionRules{SenderDomains: []string{"bank.com"}, LabelIDs: []string{"sensitive"}}
+
+	// Senders of the domain and its subdomains match, regardless of case.
+	require.True(t, rules.Matches("alerts@bank.com", nil))
+	require.True(t, rules.Matches("Alerts@Mail.Bank.COM", nil))
+	require.False(t, rules.Matches("alerts@notbank.com", nil))
+	require.False(t, rules.Matches("", nil))
+
+	// Messages in a redacted label match whoever sent them.
+	require.True(t, rules.Matches("friend@proton.me", []string{proton.InboxLabel, "sensitive"}))
+	require.False(t, rules.Matches("friend@proton.me", []string{proton.InboxLabel}))
+}
+
+func TestRedactor_Redact(t *testing.T) {
+	redacted := NewRedactedMessages()
+	redactor := newRedactor(RedactionRules{}, redacted)
+
+	// Nothing is redacted by default.
+	require.False(t, redactor.redact("msg1", "alerts@bank.com", nil))
+	require.False(t, redacted.IsRedacted("msg1"))
+
+	require.True(t, redactor.set(RedactionRules{SenderDomains: []string{"bank.com"}}))
+	require.False(t, redactor.set(RedactionRules{SenderDomains: []string{"bank.com"}}))
+
+	require.True(t, redactor.redact("msg1", "alerts@bank.com", nil))
+	require.True(t, redacted.IsRedacted("msg1"))
+	require.False(t, redacted.IsRedacted("msg2"))
+
+	// Messages stay redacted once the rules no longer match them.
+	require.True(t, redactor.set(RedactionRules{}))
+	require.True(t, redacted.IsRedacted("msg1"))
+}
+
+func TestGetLiteralSender(t *testing.T) {
+	require.Equal(t, "alerts@bank.com", getLiteralSender([]byte("From: Bank <alerts@bank.com>\r\nSubject: Hi\r\n\r\nBody")))
+	require.Equal(t, "", getLiteralSender([]byte("Subject: Hi\r\n\r\nBody")))
+}
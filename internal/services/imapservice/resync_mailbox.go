@@ -0,0 +1,93 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package imapservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ProtonMail/gluon"
+	"github.com/ProtonMail/gluon/imap"
+	"github.com/ProtonMail/go-proton-api"
+)
+
+var (
+	ErrNoSuchMailbox           = errors.New("no such mailbox")
+	ErrMailboxNotResyncable    = errors.New("this mailbox can only be resynced with the whole account")
+	ErrResyncMailboxDuringSync = errors.New("cannot resync a mailbox while the account is syncing")
+)
+
+// resyncMailbox rebuilds the local state of a single mailbox from the API, leaving the rest of the account alone.
+// The mailbox is deleted and recreated in gluon, which gives it a new UIDVALIDITY, and the messages the API lists
+// under the label are added back. Messages missing locally are downloaded; the others keep their cached literal.
+//
+// All Mail and Scheduled are derived from every message of the account and are only rebuilt by a full resync.
+func (s *Service) resyncMailbox(ctx context.Context, labelID string) error {
+	if s.isSyncing.Load() {
+		return ErrResyncMailboxDuringSync
+	}
+
+	label, ok := s.labels.GetLabelMap()[labelID]
+	if !ok || !WantLabel(label) {
+		return ErrNoSuchMailbox
+	}
+
+	if isAllMailOrScheduled(imap.MailboxID(labelID)) {
+		return ErrMailboxNotResyncable
+	}
+
+	messages, err := s.GetMailboxMessages(ctx, labelID)
+	if err != nil {
+		return err
+	}
+
+	s.log.WithField("labelID", labelID).WithField("count", len(messages)).Info("Resyncing mailbox")
+
+	if err := waitOnIMAPUpdates(ctx, s.publishToAll(ctx, func() imap.Update {
+		return imap.NewMailboxDeleted(imap.MailboxID(labelID))
+	})); err != nil && !gluon.IsNoSuchMailbox(err) {
+		return fmt.Errorf("failed to delete mailbox: %w", err)
+	}
+
+	if err := waitOnIMAPUpdates(ctx, s.publishToAll(ctx, func() imap.Update {
+		if label.Type == proton.LabelTypeSystem {
+			return newSystemMailboxCreatedUpdate(imap.MailboxID(labelID), label.Name)
+		}
+
+		return newMailboxCreatedUpdate(imap.MailboxID(labelID), GetMailboxName(label))
+	})); err != nil {
+		return fmt.Errorf("failed to create mailbox: %w", err)
+	}
+
+	// Handling the messages as flag updates sets their mailboxes from their labels, creating those missing locally.
+	messageEvents := make([]proton.MessageEvent, 0, len(messages))
+
+	for _, message := range messages {
+		messageEvents = append(messageEvents, proton.MessageEvent{
+			EventItem: proton.EventItem{ID: message.ID, Action: proton.EventUpdateFlags},
+			Message:   message,
+		})
+	}
+
+	if err := s.HandleMessageEvents(ctx, messageEvents); err != nil {
+		return fmt.Errorf("failed to add messages to mailbox: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,287 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package imapservice
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/gluon"
+	"github.com/ProtonMail/gluon/imap"
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/proton-bridge/v3/internal/savedsearch"
+	"github.com/ProtonMail/proton-bridge/v3/internal/usertypes"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+// SavedSearchRefreshInterval is how often saved searches depending on the age of messages are re-evaluated.
+const SavedSearchRefreshInterval = time.Hour
+
+const (
+	savedSearchPrefix          = "Virtual"
+	savedSearchMailboxIDPrefix = "saved-search-"
+)
+
+// SavedSearch defines a read-only mailbox listing the messages which match a query.
+// The mailbox is exposed as Virtual/<Name>.
+type SavedSearch struct {
+	ID    string
+	Name  string
+	Query string
+}
+
+type compiledSavedSearch struct {
+	SavedSearch
+	query *savedsearch.Query
+}
+
+// savedSearches holds the saved searches of the user and the saved-search mailboxes each message is in.
+// It is only accessed from the service's run loop.
+type savedSearches struct {
+	searches   []compiledSavedSearch
+	membership map[string][]imap.MailboxID
+}
+
+func newSavedSearches(log *logrus.Entry, searches []SavedSearch) *savedSearches {
+	compiled := make([]compiledSavedSearch, 0, len(searches))
+
+	for _, search := range searches {
+		query, err := savedsearch.Parse(search.Query)
+		if err != nil {
+			log.WithError(err).WithField("name", search.Name).Warn("Ignoring invalid saved search")
+			continue
+		}
+
+		compiled = append(compiled, compiledSavedSearch{SavedSearch: search, query: query})
+	}
+
+	return &savedSearches{
+		searches:   compiled,
+		membership: make(map[string][]imap.MailboxID),
+	}
+}
+
+// match returns the saved-search mailboxes the given message belongs in.
+func (s *savedSearches) match(message proton.MessageMetadata, apiLabels map[string]proton.Label, now time.Time) []imap.MailboxID {
+	var mboxIDs []imap.MailboxID
+
+	for _, search := range s.searches {
+		if search.query.Match(message, apiLabels, now) {
+			mboxIDs = append(mboxIDs, savedSearchMailboxID(search.ID))
+		}
+	}
+
+	return mboxIDs
+}
+
+func (s *savedSearches) hasAge() bool {
+	return slices.ContainsFunc(s.searches, func(search compiledSavedSearch) bool {
+		return search.query.HasAge()
+	})
+}
+
+// setMembership records the saved-search mailboxes of the given message, returning whether they changed.
+func (s *savedSearches) setMembership(messageID string, mboxIDs []imap.MailboxID) bool {
+	if slices.Equal(s.membership[messageID], mboxIDs) {
+		return false
+	}
+
+	if len(mboxIDs) == 0 {
+		delete(s.membership, messageID)
+	} else {
+		s.membership[messageID] = mboxIDs
+	}
+
+	return true
+}
+
+func savedSearchMailboxID(searchID string) imap.MailboxID {
+	return imap.MailboxID(savedSearchMailboxIDPrefix + searchID)
+}
+
+func isSavedSearchMailbox(mboxID imap.MailboxID) bool {
+	return strings.HasPrefix(string(mboxID), savedSearchMailboxIDPrefix)
+}
+
+func newSavedSearchMailboxCreatedUpdate(search SavedSearch) *imap.MailboxCreated {
+	return imap.NewMailboxCreated(imap.Mailbox{
+		ID:             savedSearchMailboxID(search.ID),
+		Name:           []string{savedSearchPrefix, search.Name},
+		Flags:          defaultFlags,
+		PermanentFlags: defaultPermanentFlags,
+		Attributes:     imap.NewFlagSet(),
+	})
+}
+
+// withSavedSearches adds the saved-search mailboxes of the given message to its mailboxes.
+func (s *Service) withSavedSearches(message proton.MessageMetadata, mboxIDs []imap.MailboxID) []imap.MailboxID {
+	matched := s.savedSearches.match(message, s.labels.GetLabelMap(), time.Now())
+
+	s.savedSearches.setMembership(message.ID, matched)
+
+	return append(mboxIDs, matched...)
+}
+
+// setSavedSearches replaces the saved searches, creating, renaming and deleting their mailboxes as needed,
+// before re-evaluating them against all messages.
+func (s *Service) setSavedSearches(ctx context.Context, searches []SavedSearch) error {
+	oldSearches := make(map[string]SavedSearch)
+
+	for _, search := range s.savedSearches.searches {
+		oldSearches[search.ID] = search.SavedSearch
+	}
+
+	membership := s.savedSearches.membership
+
+	s.savedSearches = newSavedSearches(s.log, searches)
+	s.savedSearches.membership = membership
+
+	var updates []imap.Update
+
+	for _, search := range s.savedSearches.searches {
+		old, ok := oldSearches[search.ID]
+
+		switch {
+		case !ok:
+			updates = append(updates, s.publishToAll(ctx, func() imap.Update {
+				return newSavedSearchMailboxCreatedUpdate(search.SavedSearch)
+			})...)
+
+		case old.Name != search.Name:
+			updates = append(updates, s.publishToAll(ctx, func() imap.Update {
+				return imap.NewMailboxUpdated(savedSearchMailboxID(search.ID), []string{savedSearchPrefix, search.Name})
+			})...)
+		}
+
+		delete(oldSearches, search.ID)
+	}
+
+	for searchID := range oldSearches {
+		updates = append(updates, s.publishToAll(ctx, func() imap.Update {
+			return imap.NewMailboxDeleted(savedSearchMailboxID(searchID))
+		})...)
+	}
+
+	if len(s.savedSearches.searches) > 0 {
+		updates = append(updates, s.publishToAll(ctx, func() imap.Update {
+			return newPlaceHolderMailboxCreatedUpdate(savedSearchPrefix)
+		})...)
+	}
+
+	if err := waitOnIMAPUpdates(ctx, updates); err != nil {
+		return fmt.Errorf("failed to update saved search mailboxes: %w", err)
+	}
+
+	return s.refreshSavedSearches(ctx)
+}
+
+// rebuildSavedSearches re-creates the saved-search mailboxes from scratch.
+// It is done once the user is synced, as the membership of the mailboxes is not persisted.
+func (s *Service) rebuildSavedSearches(ctx context.Context) error {
+	searches := savedSearchDefinitions(s.savedSearches.searches)
+
+	if len(searches) == 0 {
+		return nil
+	}
+
+	var updates []imap.Update
+
+	for _, search := range searches {
+		updates = append(updates, s.publishToAll(ctx, func() imap.Update {
+			return imap.NewMailboxDeleted(savedSearchMailboxID(search.ID))
+		})...)
+	}
+
+	if err := waitOnIMAPUpdates(ctx, updates); err != nil && !gluon.IsNoSuchMailbox(err) {
+		return fmt.Errorf("failed to delete saved search mailboxes: %w", err)
+	}
+
+	s.savedSearches = newSavedSearches(s.log, nil)
+
+	return s.setSavedSearches(ctx, searches)
+}
+
+// refreshSavedSearches re-evaluates the saved searches against all messages of the user,
+// moving messages in or out of the saved-search mailboxes as necessary.
+func (s *Service) refreshSavedSearches(ctx context.Context) error {
+	if len(s.savedSearches.searches) == 0 && len(s.savedSearches.membership) == 0 {
+		return nil
+	}
+
+	messages, err := s.GetMailboxMessages(ctx, proton.AllMailLabel)
+	if err != nil {
+		return err
+	}
+
+	apiLabels := s.labels.GetLabelMap()
+	now := time.Now()
+
+	var updates []imap.Update
+
+	for _, message := range messages {
+		matched := s.savedSearches.match(message, apiLabels, now)
+
+		if !s.savedSearches.setMembership(message.ID, matched) {
+			continue
+		}
+
+		update := imap.NewMessageMailboxesUpdated(
+			imap.MessageID(message.ID),
+			append(usertypes.MapTo[string, imap.MailboxID](wantLabels(apiLabels, message.LabelIDs)), matched...),
+			BuildFlagSetFromMessageMetadata(message),
+		)
+
+		didPublish, err := safePublishMessageUpdate(ctx, s, message.AddressID, update)
+		if err != nil {
+			return err
+		}
+
+		if didPublish {
+			updates = append(updates, update)
+		}
+	}
+
+	return waitOnMessageUpdates(ctx, updates)
+}
+
+// publishToAll publishes the update built by fn on every connector.
+func (s *Service) publishToAll(ctx context.Context, fn func() imap.Update) []imap.Update {
+	updates := make([]imap.Update, 0, len(s.connectors))
+
+	for _, updateCh := range maps.Values(s.connectors) {
+		update := fn()
+		updateCh.publishUpdate(ctx, update)
+		updates = append(updates, update)
+	}
+
+	return updates
+}
+
+func savedSearchDefinitions(searches []compiledSavedSearch) []SavedSearch {
+	result := make([]SavedSearch, 0, len(searches))
+
+	for _, search := range searches {
+		result = append(result, search.SavedSearch)
+	}
+
+	return result
+}
@@ -32,11 +32,13 @@ import (
 	"github.com/ProtonMail/proton-bridge/v3/internal/events"
 	"github.com/ProtonMail/proton-bridge/v3/internal/services/orderedtasks"
 	"github.com/ProtonMail/proton-bridge/v3/internal/services/sendrecorder"
+	"github.com/ProtonMail/proton-bridge/v3/internal/services/spamfilter"
 	"github.com/ProtonMail/proton-bridge/v3/internal/services/syncservice"
 	"github.com/ProtonMail/proton-bridge/v3/internal/services/userevents"
 	"github.com/ProtonMail/proton-bridge/v3/internal/services/useridentity"
 	"github.com/ProtonMail/proton-bridge/v3/internal/usertypes"
 	"github.com/ProtonMail/proton-bridge/v3/pkg/cpc"
+	"github.com/ProtonMail/proton-bridge/v3/pkg/message"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/exp/maps"
 )
@@ -85,7 +87,25 @@ type Service struct {
 	eventWatcher      *watcher.Watcher[events.Event]
 	connectors        map[string]*Connector
 	maxSyncMemory     uint64
+	syncCacheLimits   syncservice.DownloadCacheLimits
+	syncCheckpoints   syncservice.CheckpointStore
 	showAllMail       bool
+	clientShims       *ClientShims
+	spamFilter        *spamfilter.Filter
+	notificationRules NotificationRules
+	recipientRoutes   []RecipientRoute
+	subaddressRules   []SubaddressRule
+	savedSearches     *savedSearches
+	announcements     *announcements
+	labelKeywordMode  LabelKeywordMode
+	deleteMode        DeleteMode
+	migration         *migration
+	pendingOps        *pendingOps
+	drafts            *draftCoalescing
+	buildMode         *buildMode
+	syncFilter        *syncFilter
+	redactor          *redactor
+	staleRefresh      *staleRefresh
 
 	syncHandler        *syncservice.Handler
 	syncUpdateApplier  *SyncUpdateApplier
@@ -115,7 +135,28 @@ func NewService(
 	subscription events.Subscription,
 	syncConfigDir string,
 	maxSyncMemory uint64,
+	syncCacheLimits syncservice.DownloadCacheLimits,
+	syncCheckpoints syncservice.CheckpointStore,
 	showAllMail bool,
+	clientShims *ClientShims,
+	spamFilter *spamfilter.Filter,
+	notificationRules NotificationRules,
+	recipientRoutes []RecipientRoute,
+	subaddressRules []SubaddressRule,
+	savedSearches []SavedSearch,
+	labelKeywordMode LabelKeywordMode,
+	deleteMode DeleteMode,
+	migrationMode bool,
+	conversationMode bool,
+	transcodeToUTF8 bool,
+	headerTemplate *message.HeaderTemplate,
+	contactDisplayNames bool,
+	dateLocation *time.Location,
+	repairDates bool,
+	draftCoalescingInterval time.Duration,
+	syncFilter SyncFilter,
+	redactionRules RedactionRules,
+	redactedMessages *RedactedMessages,
 ) *Service {
 	subscriberName := fmt.Sprintf("imap-%v", identityState.User.ID)
 
@@ -125,8 +166,19 @@ func NewService(
 	})
 	rwIdentity := newRWIdentity(identityState, bridgePassProvider, keyPassProvider)
 
+	rwLabels := newRWLabels()
+
 	syncUpdateApplier := NewSyncUpdateApplier()
-	syncMessageBuilder := NewSyncMessageBuilder(rwIdentity)
+	sharedBuildMode := newBuildMode(
+		conversationMode,
+		transcodeToUTF8,
+		headerTemplate,
+		contactDisplayNames,
+		dateLocation,
+		repairDates,
+		headerNames{labels: rwLabels, identity: rwIdentity},
+	)
+	syncMessageBuilder := NewSyncMessageBuilder(rwIdentity, sharedBuildMode)
 	syncReporter := newSyncReporter(identityState.User.ID, eventPublisher, time.Second)
 
 	return &Service{
@@ -134,7 +186,7 @@ func NewService(
 		client:        client,
 		log:           log,
 		identityState: rwIdentity,
-		labels:        newRWLabels(),
+		labels:        rwLabels,
 		addressMode:   addressMode,
 
 		gluonIDProvider: gluonIDProvider,
@@ -152,9 +204,28 @@ func NewService(
 		connectors:    make(map[string]*Connector),
 		maxSyncMemory: maxSyncMemory,
 
+		syncCacheLimits: syncCacheLimits,
+		syncCheckpoints: syncCheckpoints,
+
 		eventWatcher:      subscription.Add(events.IMAPServerCreated{}),
 		eventSubscription: subscription,
 		showAllMail:       showAllMail,
+		clientShims:       clientShims,
+		spamFilter:        spamFilter,
+		notificationRules: notificationRules,
+		recipientRoutes:   recipientRoutes,
+		subaddressRules:   subaddressRules,
+		savedSearches:     newSavedSearches(log, savedSearches),
+		announcements:     newAnnouncements(),
+		labelKeywordMode:  labelKeywordMode,
+		deleteMode:        deleteMode,
+		migration:         newMigration(identityState.User.ID, eventPublisher, migrationMode),
+		pendingOps:        newPendingOps(client, GetPendingOpsPath(syncConfigDir, identityState.User.ID), log),
+		drafts:            newDraftCoalescing(log, draftCoalescingInterval),
+		buildMode:         sharedBuildMode,
+		syncFilter:        newSyncFilter(syncFilter),
+		redactor:          newRedactor(redactionRules, redactedMessages),
+		staleRefresh:      newStaleRefresh(GetStaleRefreshPath(syncConfigDir, identityState.User.ID)),
 
 		syncUpdateApplier:  syncUpdateApplier,
 		syncMessageBuilder: syncMessageBuilder,
@@ -179,7 +250,16 @@ func (s *Service) Start(
 		s.syncStateProvider = syncStateProvider
 	}
 
-	s.syncHandler = syncservice.NewHandler(syncRegulator, s.client, s.identityState.UserID(), s.syncStateProvider, s.log, s.panicHandler)
+	if err := s.pendingOps.load(); err != nil {
+		return err
+	}
+
+	// Without the saved fingerprints, messages are only recorded again on the next pass.
+	if err := s.staleRefresh.load(); err != nil {
+		s.log.WithError(err).Warn("Failed to load stale refresh state")
+	}
+
+	s.syncHandler = syncservice.NewHandler(syncRegulator, s.client, s.identityState.UserID(), s.syncStateProvider, s.syncCheckpoints, s.log, s.panicHandler, s.syncCacheLimits)
 
 	// Get user labels
 	apiLabels, err := s.client.GetLabels(ctx, proton.LabelTypeSystem, proton.LabelTypeFolder, proton.LabelTypeLabel)
@@ -189,6 +269,8 @@ func (s *Service) Start(
 
 	s.labels.SetLabels(apiLabels)
 
+	s.loadContactNamesIfEnabled(ctx)
+
 	{
 		connectors, err := s.buildConnectors()
 		if err != nil {
@@ -203,6 +285,7 @@ func (s *Service) Start(
 	}
 
 	group.Go(ctx, s.identityState.identity.User.ID, "imap-service", s.run)
+	group.Go(ctx, s.identityState.identity.User.ID, "imap-pending-ops", s.pendingOps.run)
 	return nil
 }
 
@@ -218,6 +301,13 @@ func (s *Service) Resync(ctx context.Context) error {
 	return err
 }
 
+// ResyncMailbox rebuilds the local state of the mailbox of the given label without resyncing the whole account.
+func (s *Service) ResyncMailbox(ctx context.Context, labelID string) error {
+	_, err := s.cpc.Send(ctx, &resyncMailboxReq{labelID: labelID})
+
+	return err
+}
+
 func (s *Service) CancelSync(ctx context.Context) error {
 	_, err := s.cpc.Send(ctx, &cancelSyncReq{})
 
@@ -254,6 +344,140 @@ func (s *Service) ShowAllMail(ctx context.Context, v bool) error {
 	return err
 }
 
+// SetSpamFilter sets the local spam filter new messages are passed through. A nil filter disables filtering.
+func (s *Service) SetSpamFilter(ctx context.Context, filter *spamfilter.Filter) error {
+	_, err := s.cpc.Send(ctx, &setSpamFilterReq{filter: filter})
+
+	return err
+}
+
+// SetNotificationRules sets the rules deciding which new messages trigger a notification.
+func (s *Service) SetNotificationRules(ctx context.Context, rules NotificationRules) error {
+	_, err := s.cpc.Send(ctx, &setNotificationRulesReq{rules: rules})
+
+	return err
+}
+
+// SetRecipientRoutes sets the rules filing new messages into folders by recipient.
+func (s *Service) SetRecipientRoutes(ctx context.Context, routes []RecipientRoute) error {
+	_, err := s.cpc.Send(ctx, &setRecipientRoutesReq{routes: routes})
+
+	return err
+}
+
+// SetSubaddressRules sets the rules labelling new messages by subaddress tag.
+func (s *Service) SetSubaddressRules(ctx context.Context, rules []SubaddressRule) error {
+	_, err := s.cpc.Send(ctx, &setSubaddressRulesReq{rules: rules})
+
+	return err
+}
+
+// SetSavedSearches replaces the saved searches exposed as read-only mailboxes.
+func (s *Service) SetSavedSearches(ctx context.Context, searches []SavedSearch) error {
+	_, err := s.cpc.Send(ctx, &setSavedSearchesReq{searches: searches})
+
+	return err
+}
+
+// SetAnnouncements replaces the announcements posted to the read-only Proton Bridge mailbox.
+// Announcements the user deleted aren't posted again.
+func (s *Service) SetAnnouncements(ctx context.Context, announcements []Announcement) error {
+	_, err := s.cpc.Send(ctx, &setAnnouncementsReq{announcements: announcements})
+
+	return err
+}
+
+// SetLabelKeywordMode sets whether labels are exposed as IMAP keywords on messages.
+func (s *Service) SetLabelKeywordMode(ctx context.Context, mode LabelKeywordMode) error {
+	_, err := s.cpc.Send(ctx, &setLabelKeywordModeReq{mode: mode})
+
+	return err
+}
+
+// SetDeleteMode sets what happens to messages expunged from a folder.
+func (s *Service) SetDeleteMode(ctx context.Context, mode DeleteMode) error {
+	_, err := s.cpc.Send(ctx, &setDeleteModeReq{mode: mode})
+
+	return err
+}
+
+// SetMigrationMode sets whether message imports are tuned for bulk APPEND workloads.
+func (s *Service) SetMigrationMode(ctx context.Context, enabled bool) error {
+	_, err := s.cpc.Send(ctx, &setMigrationModeReq{enabled: enabled})
+
+	return err
+}
+
+// SetConversationMode sets whether messages are built with normalized threading headers.
+// Changing it resyncs the user, as the messages already synced have to be rebuilt.
+func (s *Service) SetConversationMode(ctx context.Context, enabled bool) error {
+	_, err := s.cpc.Send(ctx, &setConversationModeReq{enabled: enabled})
+
+	return err
+}
+
+// SetTranscodeToUTF8 sets whether text parts and header fields in legacy charsets are transcoded to UTF-8.
+// Changing it resyncs the user, as the messages already synced have to be rebuilt.
+func (s *Service) SetTranscodeToUTF8(ctx context.Context, enabled bool) error {
+	_, err := s.cpc.Send(ctx, &setTranscodeToUTF8Req{enabled: enabled})
+
+	return err
+}
+
+// SetContactDisplayNames sets whether senders' display names are replaced by the names the user gave them in their
+// contacts. Changing it resyncs the user, as the messages already synced have to be rebuilt.
+func (s *Service) SetContactDisplayNames(ctx context.Context, enabled bool) error {
+	_, err := s.cpc.Send(ctx, &setContactDisplayNamesReq{enabled: enabled})
+
+	return err
+}
+
+// SetDateNormalization sets the time zone Date headers are converted to, nil keeping them as sent, and whether
+// missing, invalid or far future ones are replaced with the time the server received the message.
+// Changing it resyncs the user, as the messages already synced have to be rebuilt.
+func (s *Service) SetDateNormalization(ctx context.Context, loc *time.Location, repair bool) error {
+	_, err := s.cpc.Send(ctx, &setDateNormalizationReq{loc: loc, repair: repair})
+
+	return err
+}
+
+// SetSyncFilter sets the folders and labels excluded from the local sync.
+// Changing it resyncs the user, as messages have to be removed or downloaded.
+func (s *Service) SetSyncFilter(ctx context.Context, filter SyncFilter) error {
+	_, err := s.cpc.Send(ctx, &setSyncFilterReq{filter: filter})
+
+	return err
+}
+
+// SetRedactionRules sets the rules selecting the messages whose bodies aren't cached locally.
+// Changing them resyncs the user, as the bodies of newly redacted messages have to be removed from the store.
+func (s *Service) SetRedactionRules(ctx context.Context, rules RedactionRules) error {
+	_, err := s.cpc.Send(ctx, &setRedactionRulesReq{rules: rules})
+
+	return err
+}
+
+// SetDraftCoalescingInterval sets how often a draft saved repeatedly is uploaded at most; zero uploads every save.
+// Drafts held back when it's disabled are uploaded right away.
+func (s *Service) SetDraftCoalescingInterval(ctx context.Context, interval time.Duration) error {
+	_, err := s.cpc.Send(ctx, &setDraftCoalescingIntervalReq{interval: interval})
+
+	return err
+}
+
+// FlushDrafts uploads the drafts held back by draft coalescing.
+func (s *Service) FlushDrafts(ctx context.Context) error {
+	return s.drafts.flushAll(ctx)
+}
+
+// SetHeaderTemplate sets the template of extra header fields added to messages; nil removes it.
+// Changing it resyncs the user, as the messages already synced have to be rebuilt.
+func (s *Service) SetHeaderTemplate(ctx context.Context, tmpl *message.HeaderTemplate) error {
+	_, err := s.cpc.Send(ctx, &setHeaderTemplateReq{tmpl: tmpl})
+
+	return err
+}
+
 func (s *Service) GetLabels(ctx context.Context) (map[string]proton.Label, error) {
 	return cpc.SendTyped[map[string]proton.Label](ctx, s.cpc, &getLabelsReq{})
 }
@@ -262,6 +486,24 @@ func (s *Service) GetSyncFailedMessageIDs(ctx context.Context) ([]string, error)
 	return cpc.SendTyped[[]string](ctx, s.cpc, &getSyncFailedMessagesReq{})
 }
 
+// ListFailedMessages returns the messages that failed to download or build, with why they failed.
+func (s *Service) ListFailedMessages(ctx context.Context) ([]FailedMessage, error) {
+	return cpc.SendTyped[[]FailedMessage](ctx, s.cpc, &listFailedMessagesReq{})
+}
+
+// RetryFailedMessages tries the given failed messages again, or all of them if none are given.
+// It returns the messages that are still failing.
+func (s *Service) RetryFailedMessages(ctx context.Context, ids []string) ([]FailedMessage, error) {
+	return cpc.SendTyped[[]FailedMessage](ctx, s.cpc, &retryFailedMessagesReq{ids: ids})
+}
+
+// SkipFailedMessages stops retrying the given failed messages.
+func (s *Service) SkipFailedMessages(ctx context.Context, ids []string) error {
+	_, err := s.cpc.Send(ctx, &skipFailedMessagesReq{ids: ids})
+
+	return err
+}
+
 func (s *Service) Close() {
 	for _, c := range s.connectors {
 		c.StateClose()
@@ -290,6 +532,13 @@ func (s *Service) HandleRefreshEvent(ctx context.Context, _ proton.RefreshFlag)
 		return fmt.Errorf("failed to clear sync status:%w", err)
 	}
 
+	if err := s.syncCheckpoints.ClearSyncCheckpoint(ctx); err != nil {
+		return fmt.Errorf("failed to clear sync checkpoint: %w", err)
+	}
+
+	// Contact changes aren't reported by the event stream, so pick them up before the messages are rebuilt.
+	s.loadContactNamesIfEnabled(ctx)
+
 	if err := s.addConnectorsToServer(ctx, s.connectors); err != nil {
 		return err
 	}
@@ -331,9 +580,19 @@ func (s *Service) run(ctx context.Context) { //nolint gocyclo
 	s.eventProvider.Subscribe(s.subscription)
 	defer s.eventProvider.Unsubscribe(s.subscription)
 
+	savedSearchTicker := time.NewTicker(SavedSearchRefreshInterval)
+	defer savedSearchTicker.Stop()
+
+	staleRefreshTicker := time.NewTicker(StaleRefreshInterval)
+	defer staleRefreshTicker.Stop()
+
 	for {
 		select {
 		case <-ctx.Done():
+			if err := s.staleRefresh.save(); err != nil {
+				s.log.WithError(err).Warn("Failed to save stale refresh state")
+			}
+
 			return
 
 		case req, ok := <-s.cpc.ReceiveCh():
@@ -351,6 +610,10 @@ func (s *Service) run(ctx context.Context) { //nolint gocyclo
 				req.Reply(ctx, nil, err)
 				s.log.Info("Resync reply sent, handling as refresh event")
 
+			case *resyncMailboxReq:
+				err := s.resyncMailbox(ctx, r.labelID)
+				req.Reply(ctx, nil, err)
+
 			case *cancelSyncReq:
 				s.log.Info("Cancelling sync")
 				s.syncHandler.Cancel()
@@ -382,6 +645,74 @@ func (s *Service) run(ctx context.Context) { //nolint gocyclo
 				req.Reply(ctx, nil, nil)
 				s.setShowAllMail(r.v)
 
+			case *setSpamFilterReq:
+				s.spamFilter = r.filter
+				req.Reply(ctx, nil, nil)
+
+			case *setNotificationRulesReq:
+				s.notificationRules = r.rules
+				req.Reply(ctx, nil, nil)
+
+			case *setRecipientRoutesReq:
+				s.recipientRoutes = r.routes
+				req.Reply(ctx, nil, nil)
+
+			case *setSubaddressRulesReq:
+				s.subaddressRules = r.rules
+				req.Reply(ctx, nil, nil)
+
+			case *setSavedSearchesReq:
+				err := s.setSavedSearches(ctx, r.searches)
+				req.Reply(ctx, nil, err)
+
+			case *setAnnouncementsReq:
+				err := s.setAnnouncements(ctx, r.announcements)
+				req.Reply(ctx, nil, err)
+
+			case *setLabelKeywordModeReq:
+				err := s.setLabelKeywordMode(ctx, r.mode)
+				req.Reply(ctx, nil, err)
+
+			case *setDeleteModeReq:
+				s.setDeleteMode(r.mode)
+				req.Reply(ctx, nil, nil)
+
+			case *setMigrationModeReq:
+				s.setMigrationMode(ctx, r.enabled)
+				req.Reply(ctx, nil, nil)
+
+			case *setConversationModeReq:
+				err := s.setConversationMode(ctx, r.enabled)
+				req.Reply(ctx, nil, err)
+
+			case *setTranscodeToUTF8Req:
+				err := s.setTranscodeToUTF8(ctx, r.enabled)
+				req.Reply(ctx, nil, err)
+
+			case *setHeaderTemplateReq:
+				err := s.setHeaderTemplate(ctx, r.tmpl)
+				req.Reply(ctx, nil, err)
+
+			case *setContactDisplayNamesReq:
+				err := s.setContactDisplayNames(ctx, r.enabled)
+				req.Reply(ctx, nil, err)
+
+			case *setDateNormalizationReq:
+				err := s.setDateNormalization(ctx, r.loc, r.repair)
+				req.Reply(ctx, nil, err)
+
+			case *setSyncFilterReq:
+				err := s.setSyncFilter(ctx, r.filter)
+				req.Reply(ctx, nil, err)
+
+			case *setRedactionRulesReq:
+				err := s.setRedactionRules(ctx, r.rules)
+				req.Reply(ctx, nil, err)
+
+			case *setDraftCoalescingIntervalReq:
+				s.drafts.setInterval(ctx, r.interval)
+				req.Reply(ctx, nil, nil)
+
 			case *getSyncFailedMessagesReq:
 				status, err := s.syncStateProvider.GetSyncStatus(ctx)
 				if err != nil {
@@ -391,6 +722,18 @@ func (s *Service) run(ctx context.Context) { //nolint gocyclo
 
 				req.Reply(ctx, maps.Keys(status.FailedMessages), nil)
 
+			case *listFailedMessagesReq:
+				failed, err := s.listFailedMessages(ctx)
+				req.Reply(ctx, failed, err)
+
+			case *retryFailedMessagesReq:
+				failed, err := s.retryFailedMessages(ctx, r.ids)
+				req.Reply(ctx, failed, err)
+
+			case *skipFailedMessagesReq:
+				err := s.skipFailedMessages(ctx, r.ids)
+				req.Reply(ctx, nil, err)
+
 			default:
 				s.log.Error("Received unknown request")
 			}
@@ -406,6 +749,14 @@ func (s *Service) run(ctx context.Context) { //nolint gocyclo
 					continue
 				}
 
+				if err := s.rebuildSavedSearches(ctx); err != nil {
+					s.log.WithError(err).Error("Failed to rebuild saved search mailboxes")
+				}
+
+				if err := s.rebuildAnnouncements(ctx); err != nil {
+					s.log.WithError(err).Error("Failed to rebuild announcements mailbox")
+				}
+
 				// Start a goroutine to wait on event reset as it is possible that the sync received message
 				// was processed during an event publish. This in turn will block the imap service, since the
 				// event service is unable to reply to the request until the events have been processed.
@@ -471,6 +822,24 @@ func (s *Service) run(ctx context.Context) { //nolint gocyclo
 
 				return nil
 			})
+		case <-savedSearchTicker.C:
+			if s.isSyncing.Load() || !s.savedSearches.hasAge() {
+				continue
+			}
+
+			if err := s.refreshSavedSearches(ctx); err != nil {
+				s.log.WithError(err).Error("Failed to refresh saved search mailboxes")
+			}
+
+		case <-staleRefreshTicker.C:
+			if s.isSyncing.Load() {
+				continue
+			}
+
+			if err := s.refreshStaleMessages(ctx); err != nil {
+				s.log.WithError(err).Warn("Failed to refresh stale messages")
+			}
+
 		case e, ok := <-s.eventWatcher.GetChannel():
 			if !ok {
 				continue
@@ -504,6 +873,16 @@ func (s *Service) buildConnectors() (map[string]*Connector, error) {
 			s.panicHandler,
 			s.telemetry,
 			s.showAllMail,
+			s.clientShims,
+			s.labelKeywordMode,
+			s.deleteMode,
+			s.migration,
+			s.pendingOps,
+			s.drafts,
+			s.announcements,
+			s.buildMode,
+			s.syncFilter,
+			s.redactor,
 			s.syncStateProvider,
 		)
 
@@ -521,6 +900,16 @@ func (s *Service) buildConnectors() (map[string]*Connector, error) {
 			s.panicHandler,
 			s.telemetry,
 			s.showAllMail,
+			s.clientShims,
+			s.labelKeywordMode,
+			s.deleteMode,
+			s.migration,
+			s.pendingOps,
+			s.drafts,
+			s.announcements,
+			s.buildMode,
+			s.syncFilter,
+			s.redactor,
 			s.syncStateProvider,
 		)
 	}
@@ -595,6 +984,10 @@ func (s *Service) setAddressMode(ctx context.Context, mode usertypes.AddressMode
 		return fmt.Errorf("failed to clear sync status:%w", err)
 	}
 
+	if err := s.syncCheckpoints.ClearSyncCheckpoint(ctx); err != nil {
+		return fmt.Errorf("failed to clear sync checkpoint: %w", err)
+	}
+
 	if err := s.rebuildConnectors(); err != nil {
 		return fmt.Errorf("failed to rebuild connectors: %w", err)
 	}
@@ -622,7 +1015,7 @@ func (s *Service) setShowAllMail(v bool) {
 
 func (s *Service) startSyncing() {
 	s.isSyncing.Store(true)
-	s.syncHandler.Execute(s.syncReporter, s.labels.GetLabelMap(), s.syncUpdateApplier, s.syncMessageBuilder, syncservice.DefaultRetryCoolDown)
+	s.syncHandler.Execute(s.syncReporter, s.labels.GetLabelMap(), s.syncUpdateApplier, s.syncMessageBuilder, s.syncFilter, syncservice.DefaultRetryCoolDown)
 }
 
 func (s *Service) cancelSync() {
@@ -632,6 +1025,8 @@ func (s *Service) cancelSync() {
 
 type resyncReq struct{}
 
+type resyncMailboxReq struct{ labelID string }
+
 type cancelSyncReq struct{}
 
 type resumeSyncReq struct{}
@@ -646,12 +1041,65 @@ type onLogoutReq struct{}
 
 type showAllMailReq struct{ v bool }
 
+type setSpamFilterReq struct{ filter *spamfilter.Filter }
+
+type setNotificationRulesReq struct{ rules NotificationRules }
+
+type setRecipientRoutesReq struct{ routes []RecipientRoute }
+
+type setSubaddressRulesReq struct{ rules []SubaddressRule }
+
+type setSavedSearchesReq struct{ searches []SavedSearch }
+
+type setAnnouncementsReq struct{ announcements []Announcement }
+
+type setLabelKeywordModeReq struct{ mode LabelKeywordMode }
+
+type setDeleteModeReq struct{ mode DeleteMode }
+
+type setMigrationModeReq struct{ enabled bool }
+
+type setConversationModeReq struct{ enabled bool }
+
+type setTranscodeToUTF8Req struct{ enabled bool }
+
+type setHeaderTemplateReq struct{ tmpl *message.HeaderTemplate }
+
+type setContactDisplayNamesReq struct{ enabled bool }
+
+type setDateNormalizationReq struct {
+	loc    *time.Location
+	repair bool
+}
+
+type setSyncFilterReq struct{ filter SyncFilter }
+
+type setRedactionRulesReq struct{ rules RedactionRules }
+
+type setDraftCoalescingIntervalReq struct{ interval time.Duration }
+
 type setAddressModeReq struct {
 	mode usertypes.AddressMode
 }
 
 type getSyncFailedMessagesReq struct{}
 
+type listFailedMessagesReq struct{}
+
+type retryFailedMessagesReq struct{ ids []string }
+
+type skipFailedMessagesReq struct{ ids []string }
+
 func GetSyncConfigPath(path string, userID string) string {
 	return filepath.Join(path, fmt.Sprintf("sync-%v", userID))
 }
+
+// GetSyncCheckpointPath returns the path of the file holding the user's sync checkpoint.
+func GetSyncCheckpointPath(path string, userID string) string {
+	return filepath.Join(path, fmt.Sprintf("sync-checkpoint-%v", userID))
+}
+
+// GetSyncCachePath returns the directory the user's sync download cache spills to.
+func GetSyncCachePath(path string, userID string) string {
+	return filepath.Join(path, fmt.Sprintf("sync-cache-%v", userID))
+}
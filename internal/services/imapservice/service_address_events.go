@@ -128,6 +128,16 @@ func addNewAddressSplitMode(ctx context.Context, s *Service, addrID string) erro
 		s.panicHandler,
 		s.telemetry,
 		s.showAllMail,
+		s.clientShims,
+		s.labelKeywordMode,
+		s.deleteMode,
+		s.migration,
+		s.pendingOps,
+		s.drafts,
+		s.announcements,
+		s.buildMode,
+		s.syncFilter,
+		s.redactor,
 		s.syncStateProvider,
 	)
 
@@ -30,18 +30,25 @@ import (
 	"github.com/ProtonMail/go-proton-api"
 	"github.com/ProtonMail/gopenpgp/v2/crypto"
 	"github.com/ProtonMail/proton-bridge/v3/internal"
+	"github.com/ProtonMail/proton-bridge/v3/internal/events"
 	"github.com/ProtonMail/proton-bridge/v3/internal/logging"
 	"github.com/ProtonMail/proton-bridge/v3/internal/usertypes"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/exp/maps"
 )
 
-func (s *Service) HandleMessageEvents(ctx context.Context, events []proton.MessageEvent) error {
+func (s *Service) HandleMessageEvents(ctx context.Context, messageEvents []proton.MessageEvent) error {
 	s.log.Debug("handling message event")
 
-	for _, event := range events {
+	for _, event := range messageEvents {
 		ctx = logging.WithLogrusField(ctx, "messageID", event.ID)
 
+		if event.Action == proton.EventDelete {
+			s.staleRefresh.forget(event.ID)
+		} else {
+			s.staleRefresh.observe(event.Message)
+		}
+
 		switch event.Action {
 		case proton.EventCreate:
 			updates, err := onMessageCreated(logging.WithLogrusField(ctx, "action", "create message"), s, event.Message, false)
@@ -54,10 +61,32 @@ func (s *Service) HandleMessageEvents(ctx context.Context, events []proton.Messa
 				return err
 			}
 
+			if len(updates) > 0 {
+				// The mailboxes may differ from the event's labels, e.g. if the spam filter moved the message.
+				labelIDs := getCreatedMailboxIDs(updates)
+
+				var sender string
+
+				if event.Message.Sender != nil {
+					sender = event.Message.Sender.Address
+				}
+
+				s.eventPublisher.PublishEvent(ctx, events.UserMessageCreated{
+					UserID:    s.identityState.UserID(),
+					MessageID: event.ID,
+					LabelIDs:  labelIDs,
+					Sender:    sender,
+				})
+
+				s.publishNotification(ctx, event.Message, labelIDs)
+			}
+
 		case proton.EventUpdate, proton.EventUpdateFlags:
 			// Draft update means to completely remove old message and upload the new data again, but we should
 			// only do this if the event is of type EventUpdate otherwise label switch operations will not work.
-			if (event.Message.IsDraft() || (event.Message.Flags&proton.MessageFlagSent != 0)) && event.Action == proton.EventUpdate {
+			// Messages excluded from the sync aren't downloaded; their mailboxes are updated in case they were synced.
+			if (event.Message.IsDraft() || (event.Message.Flags&proton.MessageFlagSent != 0)) && event.Action == proton.EventUpdate &&
+				s.syncFilter.WantMessage(s.labels.GetLabelMap(), event.Message) {
 				updates, err := onMessageUpdateDraftOrSent(
 					logging.WithLogrusField(ctx, "action", "update draft or sent message"),
 					s,
@@ -91,6 +120,11 @@ func (s *Service) HandleMessageEvents(ctx context.Context, events []proton.Messa
 
 			// If the update fails on the gluon side because it doesn't exist, we try to create the message instead.
 			if err := waitOnIMAPUpdates(ctx, updates); gluon.IsNoSuchMessage(err) {
+				// Messages excluded from the sync are expected to be missing.
+				if !s.syncFilter.WantMessage(s.labels.GetLabelMap(), event.Message) {
+					continue
+				}
+
 				s.log.WithError(err).Error("Failed to handle update message event in gluon, will try creating it")
 
 				updates, err := onMessageCreated(ctx, s, event.Message, false)
@@ -121,6 +155,23 @@ func (s *Service) HandleMessageEvents(ctx context.Context, events []proton.Messa
 	return nil
 }
 
+// getCreatedMailboxIDs returns the IDs of the mailboxes the created messages were added to.
+func getCreatedMailboxIDs(updates []imap.Update) []string {
+	var mailboxIDs []string
+
+	for _, update := range updates {
+		if update, ok := update.(*imap.MessagesCreated); ok {
+			for _, message := range update.Messages {
+				for _, mailboxID := range message.MailboxIDs {
+					mailboxIDs = append(mailboxIDs, string(mailboxID))
+				}
+			}
+		}
+	}
+
+	return mailboxIDs
+}
+
 func onMessageCreated(
 	ctx context.Context,
 	s *Service,
@@ -132,6 +183,11 @@ func onMessageCreated(
 		"subject":   logging.Sensitive(message.Subject),
 	}).Info("Handling message created event")
 
+	if !s.syncFilter.WantMessage(s.labels.GetLabelMap(), message) {
+		s.log.WithField("messageID", message.ID).Info("Message is excluded from sync, skipping it")
+		return nil, nil
+	}
+
 	full, err := s.client.GetFullMessage(ctx, message.ID, usertypes.NewProtonAPIScheduler(s.panicHandler), proton.NewDefaultAttachmentAllocator())
 	if err != nil {
 		// If the message is not found, it means that it has been deleted before we could fetch it.
@@ -148,12 +204,12 @@ func onMessageCreated(
 	apiLabels := s.labels.GetLabelMap()
 
 	if err := s.identityState.WithAddrKR(message.AddressID, func(_, addrKR *crypto.KeyRing) error {
-		res := buildRFC822(apiLabels, full, addrKR, new(bytes.Buffer))
+		res := buildRFC822(apiLabels, full, addrKR, s.buildMode.jobOpts(), new(bytes.Buffer))
 
 		if res.err != nil {
 			s.log.WithError(err).Error("Failed to build RFC822 message")
 
-			if err := s.syncStateProvider.AddFailedMessageID(ctx, message.ID); err != nil {
+			if err := s.syncStateProvider.AddFailedMessageID(ctx, res.err.Error(), message.ID); err != nil {
 				s.log.WithError(err).Error("Failed to add failed message ID to vault")
 			}
 
@@ -166,6 +222,15 @@ func onMessageCreated(
 			s.log.WithError(err).Error("Failed to remove failed message ID from vault")
 		}
 
+		if s.spamFilter != nil {
+			applySpamFilter(ctx, s, s.spamFilter, full.MessageMetadata, res.update)
+		}
+
+		applyRecipientRoutes(ctx, s, full.MessageMetadata, res.update)
+		applySubaddressRules(ctx, s, full.MessageMetadata, res.update)
+
+		res.update.MailboxIDs = s.withSavedSearches(full.MessageMetadata, res.update.MailboxIDs)
+
 		update = imap.NewMessagesCreated(allowUnknownLabels, res.update)
 		didPublish, err := safePublishMessageUpdate(ctx, s, full.AddressID, update)
 		if err != nil {
@@ -211,12 +276,12 @@ func onMessageUpdateDraftOrSent(ctx context.Context, s *Service, event proton.Me
 	apiLabels := s.labels.GetLabelMap()
 
 	if err := s.identityState.WithAddrKR(event.Message.AddressID, func(_, addrKR *crypto.KeyRing) error {
-		res := buildRFC822(apiLabels, full, addrKR, new(bytes.Buffer))
+		res := buildRFC822(apiLabels, full, addrKR, s.buildMode.jobOpts(), new(bytes.Buffer))
 
 		if res.err != nil {
 			logrus.WithError(err).Error("Failed to build RFC822 message")
 
-			if err := s.syncStateProvider.AddFailedMessageID(ctx, event.ID); err != nil {
+			if err := s.syncStateProvider.AddFailedMessageID(ctx, res.err.Error(), event.ID); err != nil {
 				s.log.WithError(err).Error("Failed to add failed message ID to vault")
 			}
 
@@ -232,7 +297,7 @@ func onMessageUpdateDraftOrSent(ctx context.Context, s *Service, event proton.Me
 		update = imap.NewMessageUpdated(
 			res.update.Message,
 			res.update.Literal,
-			res.update.MailboxIDs,
+			s.withSavedSearches(full.MessageMetadata, res.update.MailboxIDs),
 			res.update.ParsedMessage,
 			true, // Is the message doesn't exist, silently create it.
 		)
@@ -268,7 +333,7 @@ func onMessageUpdate(ctx context.Context, s *Service, message proton.MessageMeta
 
 	update := imap.NewMessageMailboxesUpdated(
 		imap.MessageID(message.ID),
-		usertypes.MapTo[string, imap.MailboxID](wantLabels(s.labels.GetLabelMap(), message.LabelIDs)),
+		s.withSavedSearches(message, usertypes.MapTo[string, imap.MailboxID](wantLabels(s.labels.GetLabelMap(), message.LabelIDs))),
 		flags,
 	)
 
@@ -287,6 +352,8 @@ func onMessageUpdate(ctx context.Context, s *Service, message proton.MessageMeta
 func onMessageDeleted(ctx context.Context, s *Service, event proton.MessageEvent) []imap.Update {
 	s.log.WithField("messageID", event.ID).Info("Handling message deleted event")
 
+	s.savedSearches.setMembership(event.ID, nil)
+
 	updates := make([]imap.Update, 0, len(s.connectors))
 
 	for _, updateCh := range maps.Values(s.connectors) {
@@ -19,6 +19,7 @@ package imapservice
 
 import (
 	"sync"
+	"sync/atomic"
 
 	"github.com/ProtonMail/go-proton-api"
 	"github.com/ProtonMail/gopenpgp/v2/crypto"
@@ -40,6 +41,9 @@ type rwIdentity struct {
 	identity           *useridentity.State
 	bridgePassProvider useridentity.BridgePassProvider
 	keyPassProvider    useridentity.KeyPassProvider
+
+	// emails is a snapshot of the address emails by ID, which can be read without taking the lock.
+	emails atomic.Pointer[map[string]string]
 }
 
 func (r *rwIdentity) GetPrimaryAddress() (proton.Address, error) {
@@ -67,11 +71,32 @@ func newRWIdentity(identity *useridentity.State,
 	bridgePassProvider useridentity.BridgePassProvider,
 	keyPassProvider useridentity.KeyPassProvider,
 ) *rwIdentity {
-	return &rwIdentity{
+	rw := &rwIdentity{
 		identity:           identity,
 		bridgePassProvider: bridgePassProvider,
 		keyPassProvider:    keyPassProvider,
 	}
+
+	rw.publishEmailsUnsafe()
+
+	return rw
+}
+
+// GetAddressEmail returns the email of an address without taking the lock, for use while messages are built.
+func (r *rwIdentity) GetAddressEmail(id string) (string, bool) {
+	email, ok := (*r.emails.Load())[id]
+
+	return email, ok
+}
+
+func (r *rwIdentity) publishEmailsUnsafe() {
+	emails := make(map[string]string, len(r.identity.Addresses))
+
+	for id, addr := range r.identity.Addresses {
+		emails[id] = addr.Email
+	}
+
+	r.emails.Store(&emails)
 }
 
 func (r *rwIdentity) UserID() string {
@@ -112,6 +137,7 @@ func (r *rwIdentity) CheckAuth(email string, password []byte) (string, error) {
 func (r *rwIdentity) Write(f func(identity *useridentity.State) error) error {
 	r.lock.Lock()
 	defer r.lock.Unlock()
+	defer r.publishEmailsUnsafe()
 
 	return f(r.identity)
 }
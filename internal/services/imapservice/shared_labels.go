@@ -19,6 +19,7 @@ package imapservice
 
 import (
 	"sync"
+	"sync/atomic"
 
 	"github.com/ProtonMail/go-proton-api"
 	"github.com/ProtonMail/proton-bridge/v3/internal/usertypes"
@@ -49,6 +50,9 @@ type labelsWrite interface {
 type rwLabels struct {
 	lock   sync.RWMutex
 	labels labelMap
+
+	// names is a snapshot of the label names by ID, which can be read without taking the lock.
+	names atomic.Pointer[map[string]string]
 }
 
 func (r *rwLabels) Read() labelsRead {
@@ -76,6 +80,24 @@ func (r *rwLabels) SetLabels(labels []proton.Label) {
 	defer r.lock.Unlock()
 
 	r.labels = usertypes.GroupBy(labels, func(label proton.Label) string { return label.ID })
+	r.publishNamesUnsafe()
+}
+
+// GetLabelName returns the name of a label without taking the lock, for use while messages are built.
+func (r *rwLabels) GetLabelName(id string) (string, bool) {
+	name, ok := (*r.names.Load())[id]
+
+	return name, ok
+}
+
+func (r *rwLabels) publishNamesUnsafe() {
+	names := make(map[string]string, len(r.labels))
+
+	for id, label := range r.labels {
+		names[id] = label.Name
+	}
+
+	r.names.Store(&names)
 }
 
 func (r *rwLabels) GetLabelMap() labelMap {
@@ -86,9 +108,13 @@ func (r *rwLabels) GetLabelMap() labelMap {
 }
 
 func newRWLabels() *rwLabels {
-	return &rwLabels{
+	labels := &rwLabels{
 		labels: make(labelMap),
 	}
+
+	labels.publishNamesUnsafe()
+
+	return labels
 }
 
 type rwLabelsRead struct {
@@ -112,6 +138,7 @@ type rwLabelsWrite struct {
 }
 
 func (r rwLabelsWrite) Close() {
+	r.rw.publishNamesUnsafe()
 	r.rw.lock.Unlock()
 }
 
@@ -0,0 +1,82 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package imapservice
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ProtonMail/gluon/imap"
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/proton-bridge/v3/internal/services/spamfilter"
+	"github.com/bradenaw/juniper/xslices"
+	"golang.org/x/exp/slices"
+)
+
+// applySpamFilter passes a newly received message through the local spam filter and adds the verdict to its headers.
+// If the filter classifies it as spam and is configured to do so, the message is moved to the Spam folder.
+// Failing to reach the filter is not fatal; the message is then delivered unchanged, as are the following ones
+// until spamfilter.FailureBackoff has passed.
+func applySpamFilter(
+	ctx context.Context,
+	s *Service,
+	filter *spamfilter.Filter,
+	metadata proton.MessageMetadata,
+	update *imap.MessageCreated,
+) {
+	if !metadata.Flags.Has(proton.MessageFlagReceived) || !slices.Contains(metadata.LabelIDs, proton.InboxLabel) {
+		return
+	}
+
+	log := s.log.WithField("messageID", metadata.ID)
+
+	verdict, err := filter.Check(ctx, update.Literal)
+	if errors.Is(err, spamfilter.ErrBackingOff) {
+		log.Debug("Skipping spam filter, which failed recently")
+		return
+	} else if err != nil {
+		log.WithError(err).Warn("Failed to check message with spam filter")
+		return
+	}
+
+	literal := spamfilter.AddVerdictHeaders(update.Literal, verdict)
+
+	parsedMessage, err := imap.NewParsedMessage(literal)
+	if err != nil {
+		log.WithError(err).Warn("Failed to parse message with spam filter verdict")
+		return
+	}
+
+	update.Literal = literal
+	update.ParsedMessage = parsedMessage
+
+	if !verdict.Spam || !filter.MoveToSpam() {
+		return
+	}
+
+	log.WithField("score", verdict.Score).Info("Moving message classified as spam by local filter")
+
+	if err := s.client.LabelMessages(ctx, []string{metadata.ID}, proton.SpamLabel); err != nil {
+		log.WithError(err).Warn("Failed to move message to spam")
+		return
+	}
+
+	update.MailboxIDs = append(xslices.Filter(update.MailboxIDs, func(mboxID imap.MailboxID) bool {
+		return mboxID != proton.InboxLabel
+	}), proton.SpamLabel)
+}
@@ -0,0 +1,233 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package imapservice
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/ProtonMail/go-proton-api"
+	"golang.org/x/exp/slices"
+)
+
+// StaleRefreshInterval is how often a page of messages is checked for remote changes the event stream missed.
+const StaleRefreshInterval = 10 * time.Minute
+
+const (
+	// staleRefreshPageSize is how many messages' metadata are fetched per check.
+	staleRefreshPageSize = 50
+
+	// staleRefreshMaxUpdates is how many stale messages are refreshed per check. Drafts are downloaded in full,
+	// so the rest are left for the next pass to keep the background traffic low.
+	staleRefreshMaxUpdates = 10
+)
+
+// staleRefresh walks the user's messages one page at a time while the user is idle, comparing their metadata
+// against what was last applied to gluon. Messages which changed remotely without an event (e.g. drafts edited
+// or labels migrated server-side) are refreshed through the regular message event handling.
+//
+// The API doesn't expose content hashes or etags, so a fingerprint of the metadata which changes along with the
+// content (size, attachments, subject) and with the labels and flags is used instead. Fingerprints are saved next to
+// the sync state after each pass and on shutdown, so that changes made while bridge wasn't running are picked up too;
+// only messages which were never seen before are recorded without being refreshed.
+type staleRefresh struct {
+	path  string
+	dirty bool
+
+	page         int
+	fingerprints map[string]uint64
+}
+
+// staleRefreshFile is the saved state of the stale message refresh.
+type staleRefreshFile struct {
+	Page         int
+	Fingerprints map[string]uint64
+}
+
+func newStaleRefresh(path string) *staleRefresh {
+	return &staleRefresh{path: path, fingerprints: make(map[string]uint64)}
+}
+
+func GetStaleRefreshPath(path string, userID string) string {
+	return filepath.Join(path, fmt.Sprintf("stale-refresh-%v", userID))
+}
+
+// load reads the fingerprints saved before the previous shutdown.
+func (r *staleRefresh) load() error {
+	data, err := os.ReadFile(r.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to read stale refresh state: %w", err)
+	}
+
+	var file staleRefreshFile
+
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("failed to unmarshal stale refresh state: %w", err)
+	}
+
+	r.page = file.Page
+
+	if file.Fingerprints != nil {
+		r.fingerprints = file.Fingerprints
+	}
+
+	return nil
+}
+
+// save writes the fingerprints if they changed since they were last saved.
+func (r *staleRefresh) save() error {
+	if !r.dirty {
+		return nil
+	}
+
+	data, err := json.Marshal(staleRefreshFile{Page: r.page, Fingerprints: r.fingerprints})
+	if err != nil {
+		return err
+	}
+
+	tmpFile := r.path + ".tmp"
+
+	if err := os.WriteFile(tmpFile, data, 0o600); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmpFile, r.path); err != nil {
+		return err
+	}
+
+	r.dirty = false
+
+	return nil
+}
+
+// observe records the state of a message as applied to gluon by the event stream.
+func (r *staleRefresh) observe(message proton.MessageMetadata) {
+	r.fingerprints[message.ID] = messageFingerprint(message)
+	r.dirty = true
+}
+
+// forget drops a message which was deleted.
+func (r *staleRefresh) forget(messageID string) {
+	delete(r.fingerprints, messageID)
+	r.dirty = true
+}
+
+// advance moves on to the next page, or back to the first one after a partial page.
+func (r *staleRefresh) advance(pageLen int) {
+	if pageLen < staleRefreshPageSize {
+		r.page = 0
+	} else {
+		r.page++
+	}
+
+	r.dirty = true
+}
+
+// diff returns the messages of the page which changed since they were last observed, up to maxUpdates.
+// Messages seen for the first time, and those which changed beyond maxUpdates, are not returned;
+// only the former are recorded, so that the latter are picked up on the next pass.
+func (r *staleRefresh) diff(page []proton.MessageMetadata, maxUpdates int) []proton.MessageMetadata {
+	var stale []proton.MessageMetadata
+
+	for _, message := range page {
+		fingerprint := messageFingerprint(message)
+
+		prev, ok := r.fingerprints[message.ID]
+		if !ok {
+			r.fingerprints[message.ID] = fingerprint
+			r.dirty = true
+
+			continue
+		}
+
+		if prev == fingerprint || len(stale) >= maxUpdates {
+			continue
+		}
+
+		stale = append(stale, message)
+	}
+
+	return stale
+}
+
+// refreshStaleMessages checks the next page of messages and refreshes those which changed remotely.
+func (s *Service) refreshStaleMessages(ctx context.Context) error {
+	page, err := s.client.GetMessageMetadataPage(ctx, s.staleRefresh.page, staleRefreshPageSize, proton.MessageFilter{Desc: true})
+	if err != nil {
+		return fmt.Errorf("failed to get message metadata: %w", err)
+	}
+
+	s.staleRefresh.advance(len(page))
+
+	defer func() {
+		if err := s.staleRefresh.save(); err != nil {
+			s.log.WithError(err).Warn("Failed to save stale refresh state")
+		}
+	}()
+
+	stale := s.staleRefresh.diff(page, staleRefreshMaxUpdates)
+	if len(stale) == 0 {
+		return nil
+	}
+
+	s.log.WithField("count", len(stale)).Info("Refreshing messages changed outside the event stream")
+
+	messageEvents := make([]proton.MessageEvent, 0, len(stale))
+
+	for _, message := range stale {
+		messageEvents = append(messageEvents, proton.MessageEvent{
+			EventItem: proton.EventItem{ID: message.ID, Action: proton.EventUpdate},
+			Message:   message,
+		})
+	}
+
+	return s.HandleMessageEvents(ctx, messageEvents)
+}
+
+func messageFingerprint(message proton.MessageMetadata) uint64 {
+	labelIDs := slices.Clone(message.LabelIDs)
+	slices.Sort(labelIDs)
+
+	hash := fnv.New64a()
+
+	for _, field := range append([]string{
+		message.Subject,
+		message.ExternalID,
+		strconv.Itoa(message.Size),
+		strconv.Itoa(message.NumAttachments),
+		strconv.FormatInt(int64(message.Flags), 10),
+		strconv.FormatBool(bool(message.Unread)),
+		strconv.FormatBool(bool(message.IsReplied)),
+		strconv.FormatBool(bool(message.IsRepliedAll)),
+		strconv.FormatBool(bool(message.IsForwarded)),
+	}, labelIDs...) {
+		_, _ = hash.Write([]byte(field))
+		_, _ = hash.Write([]byte{0})
+	}
+
+	return hash.Sum64()
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package imapservice
+
+import (
+	"testing"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaleRefresh_Diff(t *testing.T) {
+	r := newStaleRefresh(GetStaleRefreshPath(t.TempDir(), "userID"))
+
+	draft := proton.MessageMetadata{ID: "draft", Size: 100, LabelIDs: []string{proton.DraftsLabel}}
+	inbox := proton.MessageMetadata{ID: "inbox", Size: 200, LabelIDs: []string{proton.InboxLabel}}
+
+	// Messages seen for the first time are only recorded.
+	require.Empty(t, r.diff([]proton.MessageMetadata{draft, inbox}, staleRefreshMaxUpdates))
+	require.Empty(t, r.diff([]proton.MessageMetadata{draft, inbox}, staleRefreshMaxUpdates))
+
+	// A draft edited remotely and a message moved remotely are both stale.
+	draft.Size = 150
+	inbox.LabelIDs = []string{proton.ArchiveLabel}
+
+	require.Equal(t, []proton.MessageMetadata{draft, inbox}, r.diff([]proton.MessageMetadata{draft, inbox}, staleRefreshMaxUpdates))
+
+	// Changes applied by the event stream are not stale.
+	r.observe(draft)
+	r.observe(inbox)
+	require.Empty(t, r.diff([]proton.MessageMetadata{draft, inbox}, staleRefreshMaxUpdates))
+
+	// The order of labels doesn't matter.
+	inbox.LabelIDs = []string{proton.ArchiveLabel, proton.StarredLabel}
+	r.observe(inbox)
+	inbox.LabelIDs = []string{proton.StarredLabel, proton.ArchiveLabel}
+	require.Empty(t, r.diff([]proton.MessageMetadata{inbox}, staleRefreshMaxUpdates))
+}
+
+func TestStaleRefresh_MaxUpdates(t *testing.T) {
+	r := newStaleRefresh(GetStaleRefreshPath(t.TempDir(), "userID"))
+
+	first := proton.MessageMetadata{ID: "first", Unread: true}
+	second := proton.MessageMetadata{ID: "second", Unread: true}
+
+	require.Empty(t, r.diff([]proton.MessageMetadata{first, second}, 1))
+
+	first.Unread = false
+	second.Unread = false
+
+	// Only one message is refreshed per pass; the other is left for the next one.
+	require.Equal(t, []proton.MessageMetadata{first}, r.diff([]proton.MessageMetadata{first, second}, 1))
+	r.observe(first)
+	require.Equal(t, []proton.MessageMetadata{second}, r.diff([]proton.MessageMetadata{first, second}, 1))
+
+	// Deleted messages are forgotten.
+	r.forget(second.ID)
+	require.NotContains(t, r.fingerprints, second.ID)
+}
+
+func TestStaleRefresh_SaveLoad(t *testing.T) {
+	path := GetStaleRefreshPath(t.TempDir(), "userID")
+
+	r := newStaleRefresh(path)
+
+	// Nothing was saved yet.
+	require.NoError(t, r.load())
+
+	draft := proton.MessageMetadata{ID: "draft", Size: 100, LabelIDs: []string{proton.DraftsLabel}}
+
+	require.Empty(t, r.diff([]proton.MessageMetadata{draft}, staleRefreshMaxUpdates))
+	r.advance(1)
+	require.NoError(t, r.save())
+
+	// A draft edited while bridge wasn't running is stale after the restart.
+	draft.Size = 150
+
+	r = newStaleRefresh(path)
+	require.NoError(t, r.load())
+	require.Equal(t, []proton.MessageMetadata{draft}, r.diff([]proton.MessageMetadata{draft}, staleRefreshMaxUpdates))
+}
@@ -0,0 +1,150 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package imapservice
+
+import (
+	"context"
+	"net/mail"
+	"strings"
+
+	"github.com/ProtonMail/gluon/imap"
+	"github.com/ProtonMail/go-proton-api"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+// SubaddressRule labels the new messages sent to a subaddress with the given Tag, e.g. user+news@pm.me, with Label.
+// Tag is lowercase.
+type SubaddressRule struct {
+	Tag   string
+	Label string
+}
+
+// getSubaddressTag returns the lowercase tag of the given subaddress, e.g. news for user+news@pm.me.
+func getSubaddressTag(address string) (string, bool) {
+	local, _, ok := strings.Cut(address, "@")
+	if !ok {
+		return "", false
+	}
+
+	_, tag, ok := strings.Cut(local, "+")
+	if !ok || tag == "" {
+		return "", false
+	}
+
+	return strings.ToLower(tag), true
+}
+
+// matchSubaddressRules returns the rules matching the tag of any recipient of the given message.
+func matchSubaddressRules(rules []SubaddressRule, metadata proton.MessageMetadata) []SubaddressRule {
+	var tags []string
+
+	for _, recipients := range [][]*mail.Address{metadata.ToList, metadata.CCList, metadata.BCCList} {
+		for _, recipient := range recipients {
+			if tag, ok := getSubaddressTag(recipient.Address); ok {
+				tags = append(tags, tag)
+			}
+		}
+	}
+
+	var matched []SubaddressRule
+
+	for _, rule := range rules {
+		if slices.Contains(tags, rule.Tag) {
+			matched = append(matched, rule)
+		}
+	}
+
+	return matched
+}
+
+// applySubaddressRules labels a newly received message with the labels of the rules matching its recipients' tags.
+// Labels which don't exist yet are created first.
+func applySubaddressRules(
+	ctx context.Context,
+	s *Service,
+	metadata proton.MessageMetadata,
+	update *imap.MessageCreated,
+) {
+	if !metadata.Flags.Has(proton.MessageFlagReceived) {
+		return
+	}
+
+	for _, rule := range matchSubaddressRules(s.subaddressRules, metadata) {
+		log := s.log.WithField("messageID", metadata.ID).WithField("label", rule.Label)
+
+		label, ok := getLabelByName(s.labels.GetLabelMap(), rule.Label)
+		if !ok {
+			created, err := s.createSubaddressLabel(ctx, rule.Label)
+			if err != nil {
+				log.WithError(err).Warn("Failed to create label of subaddress rule")
+				continue
+			}
+
+			label = created
+		}
+
+		log.Info("Labelling message by subaddress rule")
+
+		if err := s.client.LabelMessages(ctx, []string{metadata.ID}, label.ID); err != nil {
+			log.WithError(err).Warn("Failed to label message by subaddress rule")
+			continue
+		}
+
+		if !slices.Contains(update.MailboxIDs, imap.MailboxID(label.ID)) {
+			update.MailboxIDs = append(update.MailboxIDs, imap.MailboxID(label.ID))
+		}
+	}
+}
+
+// createSubaddressLabel creates the label of a subaddress rule and its mailbox, ahead of the label created event,
+// so that the message being handled can be added to it right away.
+func (s *Service) createSubaddressLabel(ctx context.Context, name string) (proton.Label, error) {
+	label, err := s.client.CreateLabel(ctx, proton.CreateLabelReq{
+		Name:  name,
+		Color: "#f66",
+		Type:  proton.LabelTypeLabel,
+	})
+	if err != nil {
+		return proton.Label{}, err
+	}
+
+	func() {
+		wLabels := s.labels.Write()
+		defer wLabels.Close()
+
+		wLabels.SetLabel(label.ID, label)
+	}()
+
+	for _, updateCh := range maps.Values(s.connectors) {
+		updateCh.publishUpdate(ctx, newMailboxCreatedUpdate(imap.MailboxID(label.ID), GetMailboxName(label)))
+	}
+
+	return label, nil
+}
+
+// getLabelByName returns the label with the given name, compared case-insensitively.
+func getLabelByName(apiLabels map[string]proton.Label, name string) (proton.Label, bool) {
+	for _, label := range apiLabels {
+		if label.Type == proton.LabelTypeLabel && strings.EqualFold(label.Name, name) {
+			return label, true
+		}
+	}
+
+	return proton.Label{}, false
+}
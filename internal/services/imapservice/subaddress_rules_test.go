@@ -0,0 +1,61 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package imapservice
+
+import (
+	"net/mail"
+	"testing"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetSubaddressTag(t *testing.T) {
+	tag, ok := getSubaddressTag("user+News@pm.me")
+	require.True(t, ok)
+	require.Equal(t, "news", tag)
+
+	// Only the first plus separates the tag.
+	tag, ok = getSubaddressTag("user+a+b@pm.me")
+	require.True(t, ok)
+	require.Equal(t, "a+b", tag)
+
+	for _, address := range []string{"user@pm.me", "user+@pm.me", "user+news"} {
+		_, ok := getSubaddressTag(address)
+		require.False(t, ok, address)
+	}
+}
+
+func TestMatchSubaddressRules(t *testing.T) {
+	rules := []SubaddressRule{
+		{Tag: "news", Label: "News"},
+		{Tag: "bank", Label: "Finance"},
+		{Tag: "shop", Label: "Shopping"},
+	}
+
+	metadata := proton.MessageMetadata{
+		ToList: []*mail.Address{{Address: "user+news@pm.me"}},
+		CCList: []*mail.Address{{Address: "other+shop@pm.me"}, {Address: "user@pm.me"}},
+	}
+
+	require.Equal(t, []SubaddressRule{rules[0], rules[2]}, matchSubaddressRules(rules, metadata))
+
+	require.Empty(t, matchSubaddressRules(rules, proton.MessageMetadata{
+		ToList: []*mail.Address{{Address: "user+other@pm.me"}},
+	}))
+}
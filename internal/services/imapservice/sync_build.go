@@ -46,10 +46,18 @@ func defaultMessageJobOpts() message.JobOptions {
 		AddExternalID:          true, // Whether to include ExternalID as X-Pm-External-Id.
 		AddMessageDate:         true, // Whether to include message time as X-Pm-Date.
 		AddMessageIDReference:  true, // Whether to include the MessageID in References.
+		AddAuthResults:         true, // Whether to include Proton's SPF/DKIM/DMARC verdicts as Authentication-Results.
+		RepairMalformed:        true, // Whether to repair malformed MIME structure in PGP/MIME bodies rather than pass it through.
 	}
 }
 
-func buildRFC822(apiLabels map[string]proton.Label, full proton.FullMessage, addrKR *crypto.KeyRing, buffer *bytes.Buffer) *buildRes {
+func buildRFC822(
+	apiLabels map[string]proton.Label,
+	full proton.FullMessage,
+	addrKR *crypto.KeyRing,
+	opts message.JobOptions,
+	buffer *bytes.Buffer,
+) *buildRes {
 	var (
 		update *imap.MessageCreated
 		err    error
@@ -57,7 +65,7 @@ func buildRFC822(apiLabels map[string]proton.Label, full proton.FullMessage, add
 
 	buffer.Grow(full.Size)
 
-	if buildErr := message.DecryptAndBuildRFC822Into(addrKR, full.Message, full.AttData, defaultMessageJobOpts(), buffer); buildErr != nil {
+	if buildErr := message.DecryptAndBuildRFC822Into(addrKR, full.Message, full.AttData, opts, buffer); buildErr != nil {
 		update = newMessageCreatedFailedUpdate(apiLabels, full.MessageMetadata, buildErr)
 		err = buildErr
 	} else if created, parseErr := newMessageCreatedUpdate(apiLabels, full.MessageMetadata, buffer.Bytes()); parseErr != nil {
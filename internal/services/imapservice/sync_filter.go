@@ -0,0 +1,169 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package imapservice
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ProtonMail/gluon/imap"
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/proton-bridge/v3/internal/services/syncservice"
+	"github.com/ProtonMail/proton-bridge/v3/internal/usertypes"
+	"github.com/bradenaw/juniper/xslices"
+	"golang.org/x/exp/slices"
+)
+
+// SyncFilter excludes folders and labels from the local sync. The messages which are only in excluded mailboxes
+// aren't downloaded. The excluded mailboxes are still listed, so that the mailbox hierarchy doesn't change, but empty.
+type SyncFilter struct {
+	ExcludedLabelIDs []string
+}
+
+// aggregateLabels list messages which are also in other mailboxes; they don't keep a message synced on their own.
+var aggregateLabels = []imap.MailboxID{proton.AllMailLabel, proton.StarredLabel} //nolint:gochecknoglobals
+
+// syncFilter holds the SyncFilter of a user, shared by the service, its connectors and its sync jobs.
+type syncFilter struct {
+	excluded []imap.MailboxID
+	lock     sync.RWMutex
+}
+
+func newSyncFilter(filter SyncFilter) *syncFilter {
+	return &syncFilter{excluded: usertypes.MapTo[string, imap.MailboxID](filter.ExcludedLabelIDs)}
+}
+
+// set replaces the filter, returning whether the excluded mailboxes changed.
+func (f *syncFilter) set(filter SyncFilter) bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	excluded := usertypes.MapTo[string, imap.MailboxID](filter.ExcludedLabelIDs)
+
+	if xslices.Equal(f.excluded, excluded) {
+		return false
+	}
+
+	f.excluded = excluded
+
+	return true
+}
+
+// empty returns whether no mailbox is excluded.
+func (f *syncFilter) empty() bool {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	return len(f.excluded) == 0
+}
+
+// WantMessage returns whether a message with the given metadata is synced.
+func (f *syncFilter) WantMessage(labels syncservice.LabelMap, metadata proton.MessageMetadata) bool {
+	_, ok := f.apply(usertypes.MapTo[string, imap.MailboxID](wantLabels(labels, metadata.LabelIDs)), func(imap.MailboxID) bool {
+		return true
+	})
+
+	return ok
+}
+
+// apply returns the given mailboxes of a message without the excluded ones, or false if the message isn't synced
+// because all its folders and labels are excluded. isLabel tells folders and labels from virtual mailboxes,
+// such as the saved searches, which don't keep a message synced on their own either.
+func (f *syncFilter) apply(mboxIDs []imap.MailboxID, isLabel func(imap.MailboxID) bool) ([]imap.MailboxID, bool) {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	if len(f.excluded) == 0 {
+		return mboxIDs, true
+	}
+
+	var kept, dropped bool
+
+	for _, mboxID := range mboxIDs {
+		if !isLabel(mboxID) || slices.Contains(aggregateLabels, mboxID) {
+			continue
+		}
+
+		if slices.Contains(f.excluded, mboxID) {
+			dropped = true
+		} else {
+			kept = true
+		}
+	}
+
+	if dropped && !kept {
+		return nil, false
+	}
+
+	return xslices.Filter(mboxIDs, func(mboxID imap.MailboxID) bool {
+		return !slices.Contains(f.excluded, mboxID)
+	}), true
+}
+
+// withSyncFilter removes the excluded mailboxes from the messages in the given update, and the messages which aren't
+// synced from the messages it creates.
+func (s *Connector) withSyncFilter(update imap.Update) imap.Update {
+	switch update.(type) {
+	case *imap.MessagesCreated, *imap.MessageUpdated, *imap.MessageMailboxesUpdated:
+		if s.syncFilter.empty() {
+			return update
+		}
+
+	default:
+		// Mailbox updates are published while the labels are being changed; they must not wait on the labels lock.
+		return update
+	}
+
+	rd := s.labels.Read()
+	defer rd.Close()
+
+	isLabel := func(mboxID imap.MailboxID) bool {
+		_, ok := rd.GetLabel(string(mboxID))
+		return ok
+	}
+
+	switch update := update.(type) {
+	case *imap.MessagesCreated:
+		update.Messages = xslices.Filter(update.Messages, func(message *imap.MessageCreated) bool {
+			mboxIDs, ok := s.syncFilter.apply(message.MailboxIDs, isLabel)
+			message.MailboxIDs = mboxIDs
+
+			return ok
+		})
+
+	case *imap.MessageUpdated:
+		update.MailboxIDs, _ = s.syncFilter.apply(update.MailboxIDs, isLabel)
+
+	case *imap.MessageMailboxesUpdated:
+		update.MailboxIDs, _ = s.syncFilter.apply(update.MailboxIDs, isLabel)
+	}
+
+	return update
+}
+
+// setSyncFilter changes the folders and labels excluded from the sync and resyncs the user, so that the messages
+// of newly excluded mailboxes are removed and those of newly included ones are downloaded.
+func (s *Service) setSyncFilter(ctx context.Context, filter SyncFilter) error {
+	if !s.syncFilter.set(filter) {
+		return nil
+	}
+
+	s.log.WithField("excluded", filter.ExcludedLabelIDs).Info("Sync filter changed, resyncing")
+
+	return s.HandleRefreshEvent(ctx, 0)
+}
@@ -0,0 +1,49 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package imapservice
+
+import (
+	"testing"
+
+	"github.com/ProtonMail/gluon/imap"
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncFilter_Apply(t *testing.T) {
+	isLabel := func(mboxID imap.MailboxID) bool { return mboxID != "search" }
+
+	filter := newSyncFilter(SyncFilter{})
+
+	// Nothing is excluded by default.
+	mboxIDs, ok := filter.apply([]imap.MailboxID{proton.SpamLabel, proton.AllMailLabel}, isLabel)
+	require.True(t, ok)
+	require.Equal(t, []imap.MailboxID{proton.SpamLabel, proton.AllMailLabel}, mboxIDs)
+
+	require.True(t, filter.set(SyncFilter{ExcludedLabelIDs: []string{proton.SpamLabel, "archive"}}))
+	require.False(t, filter.set(SyncFilter{ExcludedLabelIDs: []string{proton.SpamLabel, "archive"}}))
+
+	// Messages only in excluded mailboxes aren't synced; aggregate and virtual mailboxes don't keep them.
+	_, ok = filter.apply([]imap.MailboxID{proton.SpamLabel, proton.AllMailLabel, proton.StarredLabel, "search"}, isLabel)
+	require.False(t, ok)
+
+	// Messages also in other mailboxes are synced without the excluded ones.
+	mboxIDs, ok = filter.apply([]imap.MailboxID{proton.InboxLabel, "archive", proton.AllMailLabel}, isLabel)
+	require.True(t, ok)
+	require.Equal(t, []imap.MailboxID{proton.InboxLabel, proton.AllMailLabel}, mboxIDs)
+}
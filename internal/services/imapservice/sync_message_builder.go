@@ -27,11 +27,12 @@ import (
 )
 
 type SyncMessageBuilder struct {
-	state *rwIdentity
+	state     *rwIdentity
+	buildMode *buildMode
 }
 
-func NewSyncMessageBuilder(rw *rwIdentity) *SyncMessageBuilder {
-	return &SyncMessageBuilder{state: rw}
+func NewSyncMessageBuilder(rw *rwIdentity, buildMode *buildMode) *SyncMessageBuilder {
+	return &SyncMessageBuilder{state: rw, buildMode: buildMode}
 }
 
 func (s SyncMessageBuilder) WithKeys(f func(*crypto.KeyRing, map[string]*crypto.KeyRing) error) error {
@@ -46,7 +47,7 @@ func (s SyncMessageBuilder) BuildMessage(
 ) (syncservice.BuildResult, error) {
 	buffer.Grow(full.Size)
 
-	if err := message.DecryptAndBuildRFC822Into(addrKR, full.Message, full.AttData, defaultMessageJobOpts(), buffer); err != nil {
+	if err := message.DecryptAndBuildRFC822Into(addrKR, full.Message, full.AttData, s.buildMode.jobOpts(), buffer); err != nil {
 		return syncservice.BuildResult{}, err
 	}
 
@@ -22,6 +22,20 @@ import (
 	"time"
 
 	"github.com/ProtonMail/proton-bridge/v3/internal/events"
+	"github.com/ProtonMail/proton-bridge/v3/internal/telemetry/metrics"
+)
+
+var (
+	syncsStarted  = metrics.NewCounter("bridge_syncs_started_total", "Syncs started.")
+	syncsFinished = metrics.NewCounter("bridge_syncs_finished_total", "Syncs finished.")
+	syncsFailed   = metrics.NewCounter("bridge_syncs_failed_total", "Syncs which failed and will be retried.")
+
+	syncDuration = metrics.NewHistogram("bridge_sync_duration_seconds", "Duration of the finished syncs.",
+		[]float64{10, 30, 60, 300, 900, 1800, 3600, 3 * 3600, 6 * 3600, 12 * 3600, 24 * 3600},
+	)
+
+	syncedMessages = metrics.NewCounter("bridge_synced_messages_total", "Messages synced.")
+	syncProgress   = metrics.NewGaugeVec("bridge_sync_progress_ratio", "Progress of the ongoing syncs, from 0 to 1, by user.", "user_id")
 )
 
 type syncReporter struct {
@@ -32,6 +46,9 @@ type syncReporter struct {
 	total int64
 	count int64
 
+	// resumed is the count the sync resumed from; the remaining time is estimated from what was synced since.
+	resumed int64
+
 	last time.Time
 	freq time.Duration
 }
@@ -39,15 +56,25 @@ type syncReporter struct {
 func (rep *syncReporter) OnStart(ctx context.Context) {
 	rep.start = time.Now()
 	rep.eventPublisher.PublishEvent(ctx, events.SyncStarted{UserID: rep.userID})
+
+	syncsStarted.Inc()
+	syncProgress.WithLabelValues(rep.userID).Set(0)
 }
 
 func (rep *syncReporter) OnFinished(ctx context.Context) {
+	syncsFinished.Inc()
+	syncDuration.Observe(time.Since(rep.start).Seconds())
+	syncProgress.DeleteLabelValues(rep.userID)
+
 	rep.eventPublisher.PublishEvent(ctx, events.SyncFinished{
 		UserID: rep.userID,
 	})
 }
 
 func (rep *syncReporter) OnError(ctx context.Context, err error) {
+	syncsFailed.Inc()
+	syncProgress.DeleteLabelValues(rep.userID)
+
 	rep.eventPublisher.PublishEvent(ctx, events.SyncFailed{
 		UserID: rep.userID,
 		Error:  err,
@@ -57,6 +84,8 @@ func (rep *syncReporter) OnError(ctx context.Context, err error) {
 func (rep *syncReporter) OnProgress(ctx context.Context, delta int64) {
 	rep.count += delta
 
+	syncedMessages.Add(float64(delta))
+
 	var progress float64
 	var remaining time.Duration
 
@@ -66,9 +95,11 @@ func (rep *syncReporter) OnProgress(ctx context.Context, delta int64) {
 		progress = 1
 	} else {
 		progress = float64(rep.count) / float64(rep.total)
-		remaining = time.Since(rep.start) * time.Duration(rep.total-(rep.count+1)) / time.Duration(rep.count+1)
+		remaining = time.Since(rep.start) * time.Duration(rep.total-(rep.count+1)) / time.Duration(rep.count-rep.resumed+1)
 	}
 
+	syncProgress.WithLabelValues(rep.userID).Set(progress)
+
 	if time.Since(rep.last) > rep.freq {
 		rep.eventPublisher.PublishEvent(ctx, events.SyncProgress{
 			UserID:    rep.userID,
@@ -81,9 +112,30 @@ func (rep *syncReporter) OnProgress(ctx context.Context, delta int64) {
 	}
 }
 
-func (rep *syncReporter) InitializeProgressCounter(_ context.Context, current int64, total int64) {
+func (rep *syncReporter) InitializeProgressCounter(ctx context.Context, current int64, total int64) {
 	rep.count = current
 	rep.total = total
+	rep.resumed = current
+
+	if current == 0 || total == 0 {
+		return
+	}
+
+	// A resumed sync reports how far it got right away rather than after its first batch.
+	progress := float64(current) / float64(total)
+	if progress > 1 {
+		progress = 1
+	}
+
+	syncProgress.WithLabelValues(rep.userID).Set(progress)
+
+	rep.eventPublisher.PublishEvent(ctx, events.SyncProgress{
+		UserID:   rep.userID,
+		Progress: progress,
+		Elapsed:  time.Since(rep.start),
+	})
+
+	rep.last = time.Now()
 }
 
 func newSyncReporter(userID string, eventsPublisher events.EventPublisher, freq time.Duration) *syncReporter {
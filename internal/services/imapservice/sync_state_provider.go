@@ -24,7 +24,9 @@ import (
 	"fmt"
 	"os"
 	"sync"
+	"time"
 
+	"github.com/ProtonMail/proton-bridge/v3/internal/interference"
 	"github.com/ProtonMail/proton-bridge/v3/internal/services/syncservice"
 	"github.com/bradenaw/juniper/xmaps"
 )
@@ -58,18 +60,33 @@ func NewSyncState(filePath string) (*SyncState, error) {
 	return s, nil
 }
 
-func (s *SyncState) AddFailedMessageID(_ context.Context, ids ...string) error {
+func (s *SyncState) AddFailedMessageID(_ context.Context, reason string, ids ...string) error {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
-	count := len(s.status.FailedMessages)
+	s.initFailedMessageInfoUnsafe()
+
+	var changed bool
 
 	for _, id := range ids {
+		// Messages the user chose to skip are not quarantined again.
+		if s.status.SkippedMessages.Contains(id) {
+			continue
+		}
+
+		info := s.status.FailedMessageInfo[id]
+		info.Reason = reason
+		info.Attempts++
+		info.LastAttempt = time.Now()
+
 		s.status.FailedMessages.Add(id)
+		s.status.FailedMessageInfo[id] = info
+
+		changed = true
 	}
 
 	// Only update if something change.
-	if count == len(s.status.FailedMessages) {
+	if !changed {
 		return nil
 	}
 
@@ -84,6 +101,7 @@ func (s *SyncState) RemFailedMessageID(_ context.Context, ids ...string) error {
 
 	for _, id := range ids {
 		s.status.FailedMessages.Remove(id)
+		delete(s.status.FailedMessageInfo, id)
 	}
 
 	// Only update if something change.
@@ -94,6 +112,35 @@ func (s *SyncState) RemFailedMessageID(_ context.Context, ids ...string) error {
 	return s.storeUnsafe()
 }
 
+// SkipFailedMessageID removes the given messages from the failed list and stops them from being recorded as failed again.
+func (s *SyncState) SkipFailedMessageID(_ context.Context, ids ...string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.status.SkippedMessages == nil {
+		s.status.SkippedMessages = make(map[string]struct{})
+	}
+
+	for _, id := range ids {
+		s.status.FailedMessages.Remove(id)
+		delete(s.status.FailedMessageInfo, id)
+		s.status.SkippedMessages.Add(id)
+	}
+
+	return s.storeUnsafe()
+}
+
+// initFailedMessageInfoUnsafe makes sure sync files written before failure details were tracked can be updated.
+func (s *SyncState) initFailedMessageInfoUnsafe() {
+	if s.status.FailedMessages == nil {
+		s.status.FailedMessages = make(map[string]struct{})
+	}
+
+	if s.status.FailedMessageInfo == nil {
+		s.status.FailedMessageInfo = make(map[string]syncservice.FailedMessageInfo)
+	}
+}
+
 func (s *SyncState) GetSyncStatus(_ context.Context) (syncservice.Status, error) {
 	s.lock.Lock()
 	defer s.lock.Unlock()
@@ -109,6 +156,11 @@ func (s *SyncState) ClearSyncStatus(_ context.Context) error {
 
 	s.status = syncservice.DefaultStatus()
 
+	// Skipped messages are a user decision and survive a resync.
+	for id := range oldStatus.SkippedMessages {
+		s.status.SkippedMessages.Add(id)
+	}
+
 	if err := s.storeUnsafe(); err != nil {
 		s.status = oldStatus
 		return err
@@ -177,11 +229,11 @@ func storeImpl(status *syncservice.Status, path string) error {
 
 	tmpFile := path + ".tmp"
 
-	if err := os.WriteFile(tmpFile, syncFileData, 0o600); err != nil {
+	if err := interference.Retry(func() error { return os.WriteFile(tmpFile, syncFileData, 0o600) }); err != nil {
 		return fmt.Errorf("failed to write sync state to tmp file: %w", err)
 	}
 
-	if err := os.Rename(tmpFile, path); err != nil {
+	if err := interference.Retry(func() error { return os.Rename(tmpFile, path) }); err != nil {
 		return fmt.Errorf("failed to update sync state: %w", err)
 	}
 
@@ -220,13 +272,18 @@ func (s *SyncState) loadUnsafe() error {
 }
 
 func DeleteSyncState(configDir, userID string) error {
-	path := GetSyncConfigPath(configDir, userID)
-
-	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
-		return err
+	for _, path := range []string{
+		GetSyncConfigPath(configDir, userID),
+		GetSyncCheckpointPath(configDir, userID),
+		GetPendingOpsPath(configDir, userID),
+		GetStaleRefreshPath(configDir, userID),
+	} {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
 	}
 
-	return nil
+	return os.RemoveAll(GetSyncCachePath(configDir, userID))
 }
 
 func MigrateVaultSettings(
@@ -66,6 +66,39 @@ func TestMigrateSyncSettings_DoesNotExist(t *testing.T) {
 	require.True(t, status.HasMessages)
 }
 
+func TestSyncState_FailedMessages(t *testing.T) {
+	ctx := context.Background()
+	path := GetSyncConfigPath(t.TempDir(), "test")
+
+	state, err := NewSyncState(path)
+	require.NoError(t, err)
+
+	require.NoError(t, state.AddFailedMessageID(ctx, "first", "foo", "bar"))
+	require.NoError(t, state.AddFailedMessageID(ctx, "second", "foo"))
+
+	status, err := state.GetSyncStatus(ctx)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"foo", "bar"}, maps.Keys(status.FailedMessages))
+	require.Equal(t, "second", status.FailedMessageInfo["foo"].Reason)
+	require.Equal(t, 2, status.FailedMessageInfo["foo"].Attempts)
+	require.Equal(t, 1, status.FailedMessageInfo["bar"].Attempts)
+
+	// Skipped messages leave the list and are not added back, even after a resync.
+	require.NoError(t, state.SkipFailedMessageID(ctx, "foo"))
+	require.NoError(t, state.ClearSyncStatus(ctx))
+	require.NoError(t, state.AddFailedMessageID(ctx, "third", "foo"))
+
+	// The state survives a reload.
+	state, err = NewSyncState(path)
+	require.NoError(t, err)
+
+	status, err = state.GetSyncStatus(ctx)
+	require.NoError(t, err)
+	require.Empty(t, status.FailedMessages)
+	require.Empty(t, status.FailedMessageInfo)
+	require.True(t, status.SkippedMessages.Contains("foo"))
+}
+
 func generateTestState(path string) (syncservice.Status, error) {
 	status := syncservice.DefaultStatus()
 
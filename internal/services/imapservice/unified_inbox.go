@@ -0,0 +1,94 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package imapservice
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/ProtonMail/gluon/imap"
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/gopenpgp/v2/crypto"
+	"github.com/ProtonMail/proton-bridge/v3/internal/usertypes"
+)
+
+// GetMailboxMessages returns the metadata of all messages with the given label.
+func (s *Service) GetMailboxMessages(ctx context.Context, labelID string) ([]proton.MessageMetadata, error) {
+	const pageSize = 150
+
+	var messages []proton.MessageMetadata
+
+	for page := 0; ; page++ {
+		metadata, err := s.client.GetMessageMetadataPage(ctx, page, pageSize, proton.MessageFilter{LabelID: labelID})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get message metadata: %w", err)
+		}
+
+		messages = append(messages, metadata...)
+
+		if len(metadata) < pageSize {
+			return messages, nil
+		}
+	}
+}
+
+// BuildMessage fetches and decrypts the given message, returning it as a gluon message creation update.
+func (s *Service) BuildMessage(ctx context.Context, messageID string) (*imap.MessageCreated, error) {
+	full, err := s.client.GetFullMessage(ctx, messageID, usertypes.NewProtonAPIScheduler(s.panicHandler), proton.NewDefaultAttachmentAllocator())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get full message: %w", err)
+	}
+
+	var update *imap.MessageCreated
+
+	apiLabels := s.labels.GetLabelMap()
+
+	if err := s.identityState.WithAddrKR(full.AddressID, func(_, addrKR *crypto.KeyRing) error {
+		res := buildRFC822(apiLabels, full, addrKR, s.buildMode.jobOpts(), new(bytes.Buffer))
+		if res.err != nil {
+			return res.err
+		}
+
+		update = res.update
+
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to build message: %w", err)
+	}
+
+	return update, nil
+}
+
+// SetMessagesSeen marks the given messages as read or unread.
+func (s *Service) SetMessagesSeen(ctx context.Context, messageIDs []string, seen bool) error {
+	if seen {
+		return s.client.MarkMessagesRead(ctx, messageIDs...)
+	}
+
+	return s.client.MarkMessagesUnread(ctx, messageIDs...)
+}
+
+// SetMessagesFlagged stars or unstars the given messages.
+func (s *Service) SetMessagesFlagged(ctx context.Context, messageIDs []string, flagged bool) error {
+	if flagged {
+		return s.client.LabelMessages(ctx, messageIDs, proton.StarredLabel)
+	}
+
+	return s.client.UnlabelMessages(ctx, messageIDs, proton.StarredLabel)
+}
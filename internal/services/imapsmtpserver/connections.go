@@ -0,0 +1,95 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package imapsmtpserver
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	imapEvents "github.com/ProtonMail/gluon/events"
+	"github.com/ProtonMail/gluon/imap"
+)
+
+// Connection is an IMAP client connected to bridge.
+type Connection struct {
+	SessionID  int
+	RemoteAddr string
+	Since      time.Time
+
+	// GluonID is the gluon user the connection logged in to; it's empty until the client logs in.
+	GluonID string
+
+	// ClientID is what the client told about itself with the ID command, if anything.
+	ClientID imap.IMAPID
+}
+
+// connectionLog keeps track of the connections of the IMAP server from its events.
+type connectionLog struct {
+	lock  sync.Mutex
+	conns map[int]Connection // Keyed by session ID.
+}
+
+func newConnectionLog() *connectionLog {
+	return &connectionLog{conns: make(map[int]Connection)}
+}
+
+// getConnections returns the open connections, the oldest first.
+func (l *connectionLog) getConnections() []Connection {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	conns := make([]Connection, 0, len(l.conns))
+
+	for _, conn := range l.conns {
+		conns = append(conns, conn)
+	}
+
+	sort.Slice(conns, func(i, j int) bool { return conns[i].SessionID < conns[j].SessionID })
+
+	return conns
+}
+
+func (l *connectionLog) handleEvent(event imapEvents.Event) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	switch event := event.(type) {
+	case imapEvents.SessionAdded:
+		l.conns[event.SessionID] = Connection{
+			SessionID:  event.SessionID,
+			RemoteAddr: event.RemoteAddr.String(),
+			Since:      time.Now(),
+		}
+
+	case imapEvents.Login:
+		if conn, ok := l.conns[event.SessionID]; ok {
+			conn.GluonID = event.UserID
+			l.conns[event.SessionID] = conn
+		}
+
+	case imapEvents.IMAPID:
+		if conn, ok := l.conns[event.SessionID]; ok {
+			conn.ClientID = event.IMAPID
+			l.conns[event.SessionID] = conn
+		}
+
+	case imapEvents.SessionRemoved:
+		delete(l.conns, event.SessionID)
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package imapsmtpserver
+
+import (
+	"net"
+	"testing"
+
+	imapEvents "github.com/ProtonMail/gluon/events"
+	"github.com/ProtonMail/gluon/imap"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnectionLog(t *testing.T) {
+	l := newConnectionLog()
+
+	addr := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 50000}
+
+	l.handleEvent(imapEvents.SessionAdded{SessionID: 2, RemoteAddr: addr})
+	l.handleEvent(imapEvents.SessionAdded{SessionID: 1, RemoteAddr: addr})
+
+	// The client may identify itself before or after logging in.
+	l.handleEvent(imapEvents.IMAPID{SessionID: 1, IMAPID: imap.IMAPID{Name: "Outlook", Version: "16.0"}})
+	l.handleEvent(imapEvents.Login{SessionID: 1, UserID: "gluon-id"})
+
+	conns := l.getConnections()
+	require.Len(t, conns, 2)
+	require.Equal(t, 1, conns[0].SessionID)
+	require.Equal(t, "127.0.0.1:50000", conns[0].RemoteAddr)
+	require.Equal(t, "gluon-id", conns[0].GluonID)
+	require.Equal(t, "Outlook", conns[0].ClientID.Name)
+	require.Empty(t, conns[1].GluonID)
+
+	l.handleEvent(imapEvents.SessionRemoved{SessionID: 1})
+	require.Len(t, l.getConnections(), 1)
+}
@@ -22,14 +22,17 @@ import (
 	"crypto/tls"
 	"fmt"
 	"io"
+	"net"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/ProtonMail/gluon"
 	"github.com/ProtonMail/gluon/async"
 	imapEvents "github.com/ProtonMail/gluon/events"
 	"github.com/ProtonMail/gluon/imap"
+	"github.com/ProtonMail/gluon/profiling"
 	"github.com/ProtonMail/gluon/reporter"
 	"github.com/ProtonMail/gluon/store"
 	"github.com/ProtonMail/gluon/store/fallback_v0"
@@ -43,14 +46,24 @@ type IMAPSettingsProvider interface {
 	TLSConfig() *tls.Config
 	LogClient() bool
 	LogServer() bool
+	Hosts() []string
 	Port() int
 	SetPort(int) error
 	UseSSL() bool
+	AllowClient(net.Addr) bool
 	CacheDirectory() string
 	DataDirectory() (string, error)
 	SetCacheDirectory(string) error
 	EventPublisher() IMAPEventPublisher
+	Limits() Limits
+	SlowCommandThreshold() time.Duration
 	Version() *semver.Version
+
+	// ReadOnly returns whether the users added to the server can read their messages but not change them.
+	ReadOnly() bool
+
+	// RedactedMessages returns the messages whose literals aren't kept in the store.
+	RedactedMessages() RedactedMessages
 }
 
 type IMAPEventPublisher interface {
@@ -75,6 +88,8 @@ func newIMAPServer(
 	tasks *async.Group,
 	uidValidityGenerator imap.UIDValidityGenerator,
 	panicHandler async.PanicHandler,
+	cmdProfiler profiling.CmdProfilerBuilder,
+	redacted RedactedMessages,
 ) (*gluon.Server, error) {
 	gluonCacheDir = ApplyGluonCachePathSuffix(gluonCacheDir)
 	gluonConfigDir = ApplyGluonConfigPathSuffix(gluonConfigDir)
@@ -114,12 +129,13 @@ func newIMAPServer(
 		gluon.WithTLS(tlsConfig),
 		gluon.WithDataDir(gluonCacheDir),
 		gluon.WithDatabaseDir(gluonConfigDir),
-		gluon.WithStoreBuilder(new(storeBuilder)),
+		gluon.WithStoreBuilder(&storeBuilder{redacted: redacted}),
 		gluon.WithLogger(imapClientLog, imapServerLog),
 		getGluonVersionInfo(version),
 		gluon.WithReporter(reporter),
 		gluon.WithUIDValidityGenerator(uidValidityGenerator),
 		gluon.WithPanicHandler(panicHandler),
+		gluon.WithCmdProfiler(cmdProfiler),
 	)
 	if err != nil {
 		return nil, err
@@ -161,14 +177,21 @@ func getGluonVersionInfo(version *semver.Version) gluon.Option {
 	)
 }
 
-type storeBuilder struct{}
+type storeBuilder struct {
+	redacted RedactedMessages
+}
 
-func (*storeBuilder) New(path, userID string, passphrase []byte) (store.Store, error) {
-	return store.NewOnDiskStore(
+func (builder *storeBuilder) New(path, userID string, passphrase []byte) (store.Store, error) {
+	onDiskStore, err := store.NewOnDiskStore(
 		filepath.Join(path, userID),
 		passphrase,
 		store.WithFallback(fallback_v0.NewOnDiskStoreV0WithCompressor(&fallback_v0.GZipCompressor{})),
 	)
+	if err != nil {
+		return nil, err
+	}
+
+	return redactingStore{Store: retryingStore{Store: onDiskStore}, redacted: builder.redacted}, nil
 }
 
 func (*storeBuilder) Delete(path, userID string) error {
@@ -0,0 +1,312 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package imapsmtpserver
+
+import (
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	imapEvents "github.com/ProtonMail/gluon/events"
+	"github.com/ProtonMail/gluon/profiling"
+	"github.com/sirupsen/logrus"
+)
+
+// Limits protect bridge and the API from IMAP clients which loop or resync aggressively.
+// A zero value means no limit.
+type Limits struct {
+	// CommandsPerSecond is the number of commands each connection may run per second; further commands are delayed.
+	CommandsPerSecond int
+
+	// MaxConnectionsPerUser is the number of connections logged in to the same user; further ones are closed.
+	MaxConnectionsPerUser int
+
+	// MaxFetchBytes is the number of bytes written to all clients at once, most of which are FETCH responses;
+	// further writes wait for the pending ones. A write which takes longer than writeReservationTimeout,
+	// e.g. because its client stopped reading, gives its share back so that it doesn't hold up the other clients.
+	MaxFetchBytes int
+}
+
+// writeReservationTimeout is how long a write may hold its share of MaxFetchBytes.
+const writeReservationTimeout = 5 * time.Second
+
+// LimitStats count how often the limits throttled the IMAP clients.
+type LimitStats struct {
+	ThrottledCommands   uint64
+	RejectedConnections uint64
+	ThrottledWrites     uint64
+}
+
+// limiter applies the limits returned by getLimits to the connections of the IMAP server.
+// It learns which user each connection logged in to from the events of the IMAP server.
+type limiter struct {
+	getLimits func() Limits
+
+	throttledCommands   atomic.Uint64
+	rejectedConnections atomic.Uint64
+	throttledWrites     atomic.Uint64
+
+	connLock  sync.Mutex
+	conns     map[string]net.Conn // Keyed by remote address.
+	sessions  map[int]string      // Remote addresses keyed by session ID.
+	userConns map[string][]int    // Session IDs keyed by user ID, in login order.
+
+	writeLock    sync.Mutex
+	writeCond    *sync.Cond
+	writing      int
+	writeTimeout time.Duration
+}
+
+func newLimiter(getLimits func() Limits) *limiter {
+	l := &limiter{
+		getLimits:    getLimits,
+		conns:        make(map[string]net.Conn),
+		sessions:     make(map[int]string),
+		userConns:    make(map[string][]int),
+		writeTimeout: writeReservationTimeout,
+	}
+
+	l.writeCond = sync.NewCond(&l.writeLock)
+
+	return l
+}
+
+func (l *limiter) getStats() LimitStats {
+	return LimitStats{
+		ThrottledCommands:   l.throttledCommands.Load(),
+		RejectedConnections: l.rejectedConnections.Load(),
+		ThrottledWrites:     l.throttledWrites.Load(),
+	}
+}
+
+// New implements profiling.CmdProfilerBuilder: gluon creates a profiler for each connection,
+// which is told when each command starts.
+func (l *limiter) New() profiling.CmdProfiler {
+	return &commandLimiter{limiter: l}
+}
+
+func (l *limiter) Collect(profiling.CmdProfiler) {}
+
+// listener returns a listener whose connections are tracked and whose writes are limited.
+func (l *limiter) listener(listener net.Listener) net.Listener {
+	return &limitedListener{Listener: listener, limiter: l}
+}
+
+func (l *limiter) handleEvent(event imapEvents.Event) {
+	l.connLock.Lock()
+	defer l.connLock.Unlock()
+
+	switch event := event.(type) {
+	case imapEvents.SessionAdded:
+		l.sessions[event.SessionID] = event.RemoteAddr.String()
+
+	case imapEvents.Login:
+		l.userConns[event.UserID] = append(l.userConns[event.UserID], event.SessionID)
+
+		if limit := l.getLimits().MaxConnectionsPerUser; limit > 0 && len(l.userConns[event.UserID]) > limit {
+			l.rejectedConnections.Add(1)
+
+			logrus.WithFields(logrus.Fields{
+				"userID":    event.UserID,
+				"sessionID": event.SessionID,
+				"limit":     limit,
+			}).Warn("Closing IMAP connection over the per-user limit")
+
+			if conn, ok := l.conns[l.sessions[event.SessionID]]; ok {
+				_ = conn.Close()
+			}
+		}
+
+	case imapEvents.SessionRemoved:
+		for userID, sessionIDs := range l.userConns {
+			l.userConns[userID] = removeSession(sessionIDs, event.SessionID)
+
+			if len(l.userConns[userID]) == 0 {
+				delete(l.userConns, userID)
+			}
+		}
+
+		delete(l.sessions, event.SessionID)
+	}
+}
+
+// acquireWrite waits until n more bytes may be written to the clients, and returns how many were reserved.
+func (l *limiter) acquireWrite(n int) int {
+	limit := l.getLimits().MaxFetchBytes
+	if limit <= 0 {
+		return 0
+	}
+
+	if n > limit {
+		n = limit
+	}
+
+	l.writeLock.Lock()
+	defer l.writeLock.Unlock()
+
+	if l.writing > 0 && l.writing+n > limit {
+		l.throttledWrites.Add(1)
+
+		for l.writing > 0 && l.writing+n > l.getLimits().MaxFetchBytes {
+			l.writeCond.Wait()
+		}
+	}
+
+	l.writing += n
+
+	return n
+}
+
+func (l *limiter) releaseWrite(n int) {
+	if n == 0 {
+		return
+	}
+
+	l.writeLock.Lock()
+	defer l.writeLock.Unlock()
+
+	l.writing -= n
+
+	l.writeCond.Broadcast()
+}
+
+func (l *limiter) addConn(conn net.Conn) {
+	l.connLock.Lock()
+	defer l.connLock.Unlock()
+
+	l.conns[conn.RemoteAddr().String()] = conn
+}
+
+func (l *limiter) removeConn(conn net.Conn) {
+	l.connLock.Lock()
+	defer l.connLock.Unlock()
+
+	delete(l.conns, conn.RemoteAddr().String())
+}
+
+func removeSession(sessionIDs []int, sessionID int) []int {
+	for i, id := range sessionIDs {
+		if id == sessionID {
+			return append(sessionIDs[:i], sessionIDs[i+1:]...)
+		}
+	}
+
+	return sessionIDs
+}
+
+// commandLimiter delays the commands of a connection beyond the allowed rate, allowing bursts of one second of commands.
+type commandLimiter struct {
+	limiter *limiter
+
+	tokens float64
+	last   time.Time
+}
+
+func (c *commandLimiter) Start(cmdType int) {
+	if cmdType == CmdTypeRemoteCall {
+		return
+	}
+
+	if delay := c.take(c.limiter.getLimits().CommandsPerSecond, time.Now()); delay > 0 {
+		c.limiter.throttledCommands.Add(1)
+		time.Sleep(delay)
+	}
+}
+
+func (c *commandLimiter) Stop(int) {}
+
+// take consumes a token, refilled at the given rate, and returns how long to wait for it to be available.
+func (c *commandLimiter) take(rate int, now time.Time) time.Duration {
+	if rate <= 0 {
+		return 0
+	}
+
+	if c.last.IsZero() {
+		c.tokens = float64(rate)
+	} else {
+		c.tokens += now.Sub(c.last).Seconds() * float64(rate)
+	}
+
+	if c.tokens > float64(rate) {
+		c.tokens = float64(rate)
+	}
+
+	c.last = now
+	c.tokens--
+
+	if c.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-c.tokens / float64(rate) * float64(time.Second))
+}
+
+type limitedListener struct {
+	net.Listener
+
+	limiter *limiter
+}
+
+func (l *limitedListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	l.limiter.addConn(conn)
+
+	return &limitedConn{Conn: conn, limiter: l.limiter}, nil
+}
+
+type limitedConn struct {
+	net.Conn
+
+	limiter   *limiter
+	closeOnce sync.Once
+}
+
+func (c *limitedConn) Write(b []byte) (int, error) {
+	n := c.limiter.acquireWrite(len(b))
+	if n == 0 {
+		return c.Conn.Write(b)
+	}
+
+	var releaseOnce sync.Once
+
+	release := func() { releaseOnce.Do(func() { c.limiter.releaseWrite(n) }) }
+
+	// The write itself is left to block: a deadline would break the TLS connections, which can't be written to after a timeout.
+	timer := time.AfterFunc(c.limiter.writeTimeout, func() {
+		logrus.WithField("remote", c.RemoteAddr().String()).Debug("Releasing the write budget of a stalled IMAP connection")
+		release()
+	})
+
+	defer func() {
+		timer.Stop()
+		release()
+	}()
+
+	return c.Conn.Write(b)
+}
+
+func (c *limitedConn) Close() error {
+	c.closeOnce.Do(func() { c.limiter.removeConn(c.Conn) })
+
+	return c.Conn.Close()
+}
@@ -0,0 +1,170 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package imapsmtpserver
+
+import (
+	"io"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	imapEvents "github.com/ProtonMail/gluon/events"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandLimiter(t *testing.T) {
+	c := &commandLimiter{}
+	now := time.Now()
+
+	// No limit.
+	require.Zero(t, c.take(0, now))
+
+	// A burst of one second of commands is allowed.
+	c = &commandLimiter{}
+
+	for i := 0; i < 10; i++ {
+		require.Zero(t, c.take(10, now))
+	}
+
+	// Further commands wait for their turn.
+	require.Equal(t, 100*time.Millisecond, c.take(10, now))
+	require.Equal(t, 200*time.Millisecond, c.take(10, now))
+
+	// Tokens are refilled over time.
+	require.Zero(t, c.take(10, now.Add(time.Second)))
+}
+
+func TestLimiter_MaxConnectionsPerUser(t *testing.T) {
+	l := newLimiter(func() Limits { return Limits{MaxConnectionsPerUser: 1} })
+
+	first, firstPeer := newTestConn(t)
+	second, secondPeer := newTestConn(t)
+
+	l.addConn(first)
+	l.addConn(second)
+
+	l.handleEvent(imapEvents.SessionAdded{SessionID: 1, RemoteAddr: first.RemoteAddr()})
+	l.handleEvent(imapEvents.SessionAdded{SessionID: 2, RemoteAddr: second.RemoteAddr()})
+
+	// The first connection of the user is kept.
+	l.handleEvent(imapEvents.Login{SessionID: 1, UserID: "user"})
+	require.Zero(t, l.getStats().RejectedConnections)
+
+	// The second one is closed.
+	l.handleEvent(imapEvents.Login{SessionID: 2, UserID: "user"})
+	require.Equal(t, uint64(1), l.getStats().RejectedConnections)
+
+	_, err := secondPeer.Read(make([]byte, 1))
+	require.Error(t, err)
+
+	require.NoError(t, firstPeer.SetReadDeadline(time.Now().Add(100*time.Millisecond)))
+	_, err = firstPeer.Read(make([]byte, 1))
+	require.ErrorIs(t, err, os.ErrDeadlineExceeded)
+
+	// Once a session is removed, the user may connect again.
+	l.handleEvent(imapEvents.SessionRemoved{SessionID: 2})
+	l.handleEvent(imapEvents.SessionRemoved{SessionID: 1})
+	require.Empty(t, l.userConns)
+}
+
+func TestLimiter_MaxFetchBytes(t *testing.T) {
+	l := newLimiter(func() Limits { return Limits{MaxFetchBytes: 10} })
+
+	// Writes larger than the limit reserve the whole limit.
+	require.Equal(t, 10, l.acquireWrite(100))
+
+	doneCh := make(chan int)
+
+	go func() { doneCh <- l.acquireWrite(5) }()
+
+	// Further writes wait for the pending ones.
+	select {
+	case <-doneCh:
+		require.Fail(t, "write should be throttled")
+
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	l.releaseWrite(10)
+	require.Equal(t, 5, <-doneCh)
+	require.Equal(t, uint64(1), l.getStats().ThrottledWrites)
+}
+
+func TestLimiter_MaxFetchBytes_StalledConn(t *testing.T) {
+	l := newLimiter(func() Limits { return Limits{MaxFetchBytes: 10} })
+	l.writeTimeout = 100 * time.Millisecond
+
+	stalled, _ := newTestConn(t)
+	other, otherPeer := newTestConn(t)
+
+	stalledConn := &limitedConn{Conn: stalled, limiter: l}
+	otherConn := &limitedConn{Conn: other, limiter: l}
+
+	// The peer of the first connection never reads, so a write larger than the socket buffers blocks for good.
+	go func() { _, _ = stalledConn.Write(make([]byte, 64<<20)) }()
+
+	require.Eventually(t, func() bool {
+		l.writeLock.Lock()
+		defer l.writeLock.Unlock()
+
+		return l.writing > 0
+	}, time.Second, 10*time.Millisecond)
+
+	// The other connection isn't held up for longer than the timeout.
+	doneCh := make(chan error)
+
+	go func() {
+		_, err := otherConn.Write([]byte("* 1 FETCH"))
+		doneCh <- err
+	}()
+
+	select {
+	case err := <-doneCh:
+		require.NoError(t, err)
+
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "write should not be blocked by the stalled connection")
+	}
+
+	b := make([]byte, 9)
+
+	_, err := io.ReadFull(otherPeer, b)
+	require.NoError(t, err)
+	require.Equal(t, "* 1 FETCH", string(b))
+}
+
+// newTestConn returns a TCP connection accepted by a test listener, and its peer.
+func newTestConn(t *testing.T) (net.Conn, net.Conn) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	peer, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+
+	conn, err := listener.Accept()
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = conn.Close()
+		_ = peer.Close()
+	})
+
+	return conn, peer
+}
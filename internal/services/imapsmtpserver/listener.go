@@ -19,28 +19,179 @@ package imapsmtpserver
 
 import (
 	"crypto/tls"
-	"fmt"
+	"errors"
 	"net"
+	"strconv"
+	"sync"
+	"time"
 
-	"github.com/ProtonMail/proton-bridge/v3/internal/constants"
+	"github.com/ProtonMail/proton-bridge/v3/internal/network"
+	"github.com/hashicorp/go-multierror"
+	"github.com/sirupsen/logrus"
 )
 
-func newListener(port int, useTLS bool, tlsConfig *tls.Config) (net.Listener, error) {
-	if useTLS {
-		tlsListener, err := tls.Listen("tcp", fmt.Sprintf("%v:%v", constants.Host, port), tlsConfig)
+// newListener listens on the given port of each of the given hosts, accepting only the clients allowed by allowClient.
+// If port is 0, the port picked for the first host is used for the others.
+func newListener(hosts []string, port int, useTLS bool, tlsConfig *tls.Config, allowClient func(net.Addr) bool) (net.Listener, error) {
+	listeners := make([]net.Listener, 0, len(hosts))
+
+	for _, host := range hosts {
+		listener, err := listen(net.JoinHostPort(host, strconv.Itoa(port)), useTLS, tlsConfig, allowClient)
 		if err != nil {
+			for _, listener := range listeners {
+				_ = listener.Close()
+			}
+
 			return nil, err
 		}
 
-		return tlsListener, nil
+		listeners = append(listeners, listener)
+
+		port = getPort(listener.Addr())
+	}
+
+	if len(listeners) == 1 {
+		return listeners[0], nil
+	}
+
+	return newMultiListener(listeners), nil
+}
+
+// newTrackedListener is the same as newListener, but the port is reported by IsListening until the listener is closed.
+func (sm *Service) newTrackedListener(hosts []string, port int, useTLS bool, tlsConfig *tls.Config, allowClient func(net.Addr) bool) (net.Listener, error) {
+	listener, err := newListener(hosts, port, useTLS, tlsConfig, allowClient)
+	if err != nil {
+		return nil, err
 	}
 
-	netListener, err := net.Listen("tcp", fmt.Sprintf("%v:%v", constants.Host, port))
+	return sm.listenPorts.track(listener), nil
+}
+
+func listen(addr string, useTLS bool, tlsConfig *tls.Config, allowClient func(net.Addr) bool) (net.Listener, error) {
+	netListener, err := net.Listen("tcp", addr)
 	if err != nil {
 		return nil, err
 	}
 
-	return netListener, nil
+	// Refused clients are dropped before the TLS handshake.
+	listener := net.Listener(network.NewFilterListener(netListener, allowClient))
+
+	if useTLS {
+		return tls.NewListener(listener, tlsConfig), nil
+	}
+
+	return listener, nil
+}
+
+const (
+	acceptRetryMinDelay = 5 * time.Millisecond
+	acceptRetryMaxDelay = time.Second
+)
+
+// multiListener accepts connections from several listeners, e.g. on the IPv4 and IPv6 loopback addresses.
+type multiListener struct {
+	listeners []net.Listener
+
+	connCh  chan net.Conn
+	errCh   chan error
+	closeCh chan struct{}
+
+	closeOnce sync.Once
+}
+
+func newMultiListener(listeners []net.Listener) *multiListener {
+	l := &multiListener{
+		listeners: listeners,
+		connCh:    make(chan net.Conn),
+		errCh:     make(chan error),
+		closeCh:   make(chan struct{}),
+	}
+
+	for _, listener := range listeners {
+		go l.accept(listener)
+	}
+
+	return l
+}
+
+// accept forwards the connections of the given listener until it's closed. As net/http does, other errors, such as
+// running out of file descriptors, are retried with a growing delay so that they don't stop the whole address family.
+func (l *multiListener) accept(listener net.Listener) {
+	var delay time.Duration
+
+	for {
+		conn, err := listener.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			select {
+			case l.errCh <- err:
+			case <-l.closeCh:
+			}
+
+			return
+		} else if err != nil {
+			if delay == 0 {
+				delay = acceptRetryMinDelay
+			} else if delay *= 2; delay > acceptRetryMaxDelay {
+				delay = acceptRetryMaxDelay
+			}
+
+			logrus.WithError(err).WithField("addr", listener.Addr()).Warnf("Failed to accept connection, retrying in %v", delay)
+
+			select {
+			case <-time.After(delay):
+				continue
+			case <-l.closeCh:
+				return
+			}
+		}
+
+		delay = 0
+
+		select {
+		case l.connCh <- conn:
+		case <-l.closeCh:
+			_ = conn.Close()
+			return
+		}
+	}
+}
+
+func (l *multiListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.connCh:
+		return conn, nil
+
+	case err := <-l.errCh:
+		return nil, err
+
+	case <-l.closeCh:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *multiListener) Close() error {
+	err := net.ErrClosed
+
+	l.closeOnce.Do(func() {
+		close(l.closeCh)
+
+		var multiErr error
+
+		for _, listener := range l.listeners {
+			if err := listener.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+				multiErr = multierror.Append(multiErr, err)
+			}
+		}
+
+		err = multiErr
+	})
+
+	return err
+}
+
+// Addr returns the address of the first listener.
+func (l *multiListener) Addr() net.Addr {
+	return l.listeners[0].Addr()
 }
 
 func getPort(addr net.Addr) int {
@@ -55,3 +206,55 @@ func getPort(addr net.Addr) int {
 		return 0
 	}
 }
+
+// listenPorts counts the open listeners of the servers by port.
+type listenPorts struct {
+	lock  sync.Mutex
+	ports map[int]int
+}
+
+func newListenPorts() *listenPorts {
+	return &listenPorts{ports: make(map[int]int)}
+}
+
+func (p *listenPorts) has(port int) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.ports[port] > 0
+}
+
+// track counts the given listener until it's closed.
+func (p *listenPorts) track(listener net.Listener) net.Listener {
+	port := getPort(listener.Addr())
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.ports[port]++
+
+	return &trackedListener{Listener: listener, ports: p, port: port}
+}
+
+func (p *listenPorts) untrack(port int) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.ports[port]--; p.ports[port] <= 0 {
+		delete(p.ports, port)
+	}
+}
+
+type trackedListener struct {
+	net.Listener
+
+	ports     *listenPorts
+	port      int
+	closeOnce sync.Once
+}
+
+func (l *trackedListener) Close() error {
+	l.closeOnce.Do(func() { l.ports.untrack(l.port) })
+
+	return l.Listener.Close()
+}
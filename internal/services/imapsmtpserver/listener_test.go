@@ -0,0 +1,73 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package imapsmtpserver
+
+import (
+	"net"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// failingListener fails to accept its first connections, then accepts one and is closed.
+type failingListener struct {
+	net.Listener
+
+	failures int
+	conn     net.Conn
+}
+
+func (l *failingListener) Accept() (net.Conn, error) {
+	if l.failures > 0 {
+		l.failures--
+		return nil, &net.OpError{Op: "accept", Net: "tcp", Err: syscall.EMFILE}
+	}
+
+	if conn := l.conn; conn != nil {
+		l.conn = nil
+		return conn, nil
+	}
+
+	return nil, net.ErrClosed
+}
+
+func (l *failingListener) Addr() net.Addr {
+	return &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)}
+}
+
+func (l *failingListener) Close() error {
+	return nil
+}
+
+func TestMultiListener_RetryAccept(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+
+	l := newMultiListener([]net.Listener{&failingListener{failures: 3, conn: server}})
+	defer l.Close()
+
+	// The connection accepted after the failures is still forwarded.
+	conn, err := l.Accept()
+	require.NoError(t, err)
+	require.Equal(t, server, conn)
+
+	// Only closing the listener stops it.
+	_, err = l.Accept()
+	require.ErrorIs(t, err, net.ErrClosed)
+}
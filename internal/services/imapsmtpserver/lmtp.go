@@ -0,0 +1,135 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package imapsmtpserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/constants"
+	"github.com/ProtonMail/proton-bridge/v3/internal/logging"
+	smtpservice "github.com/ProtonMail/proton-bridge/v3/internal/services/smtp"
+	"github.com/emersion/go-smtp"
+	"github.com/sirupsen/logrus"
+)
+
+func newLMTPServer(accounts *smtpservice.Accounts, settings SMTPSettingsProvider) *smtp.Server {
+	logrus.WithField("logSMTP", settings.Log()).Info("Creating LMTP server")
+
+	lmtpServer := smtp.NewServer(smtpservice.NewLMTPBackend(accounts))
+
+	lmtpServer.LMTP = true
+	lmtpServer.Domain = constants.Host
+	lmtpServer.AuthDisabled = true
+	lmtpServer.MaxLineLength = 1 << 16
+	lmtpServer.ErrorLog = logging.NewSMTPLogger()
+
+	if settings.Log() {
+		lmtpServer.Debug = logging.NewSMTPDebugLogger()
+	}
+
+	return lmtpServer
+}
+
+func (sm *Service) restartLMTP(ctx context.Context) error {
+	logrus.Info("Restarting LMTP server")
+
+	sm.closeLMTPServer()
+
+	if sm.shouldStartServers() {
+		return sm.serveLMTP(ctx)
+	}
+
+	return nil
+}
+
+// serveLMTP starts the LMTP server if it's enabled. As its clients aren't authenticated, it only listens on a unix socket
+// which only the user can connect to.
+func (sm *Service) serveLMTP(_ context.Context) error {
+	path, err := sm.smtpSettings.LMTPSocketPath()
+	if err != nil {
+		return fmt.Errorf("failed to get LMTP socket path: %w", err)
+	} else if path == "" {
+		return nil
+	}
+
+	logrus.WithField("path", path).Info("Starting LMTP server")
+
+	lmtpListener, err := listenUnix(path)
+	if err != nil {
+		return fmt.Errorf("failed to create LMTP listener: %w", err)
+	}
+
+	lmtpServer := newLMTPServer(sm.smtpAccounts, sm.smtpSettings)
+
+	sm.lmtpServer = lmtpServer
+	sm.lmtpListener = lmtpListener
+
+	sm.tasks.Once(func(context.Context) {
+		if err := lmtpServer.Serve(lmtpListener); err != nil {
+			logrus.WithError(err).Info("LMTP server stopped")
+		}
+	})
+
+	return nil
+}
+
+// listenUnix listens on a unix socket at the given path, replacing the one a previous instance may have left behind,
+// and restricts it to the user. Closing the listener removes the socket.
+func listenUnix(path string) (net.Listener, error) {
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.Chmod(path, 0o600); err != nil {
+		_ = listener.Close()
+		return nil, err
+	}
+
+	return listener, nil
+}
+
+func (sm *Service) closeLMTPServer() {
+	// As with SMTP, the listener is closed first so that go-smtp doesn't block on it.
+	if sm.lmtpListener != nil {
+		logrus.Info("Closing LMTP Listener")
+
+		if err := sm.lmtpListener.Close(); err != nil {
+			logrus.WithError(err).Error("Failed to close LMTP listener")
+		}
+
+		sm.lmtpListener = nil
+	}
+
+	if sm.lmtpServer != nil {
+		if err := sm.lmtpServer.Close(); err != nil {
+			logrus.WithError(err).Debug("Failed to close LMTP server (expected -- we close the listener ourselves)")
+		}
+
+		sm.lmtpServer = nil
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package imapsmtpserver
+
+import (
+	"net"
+	"sync"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/telemetry/metrics"
+)
+
+var (
+	openConnections     = metrics.NewGaugeVec("bridge_open_connections", "Open client connections by protocol.", "protocol")
+	acceptedConnections = metrics.NewCounterVec("bridge_accepted_connections_total", "Client connections accepted by protocol.", "protocol")
+)
+
+// countConnections returns a listener whose connections are counted in the metrics of the given protocol.
+func countConnections(listener net.Listener, protocol string) net.Listener {
+	return &countedListener{
+		Listener: listener,
+		open:     openConnections.WithLabelValues(protocol),
+		accepted: acceptedConnections.WithLabelValues(protocol),
+	}
+}
+
+type countedListener struct {
+	net.Listener
+
+	open     *metrics.Gauge
+	accepted *metrics.Counter
+}
+
+func (l *countedListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	l.open.Inc()
+	l.accepted.Inc()
+
+	return &countedConn{Conn: conn, open: l.open}, nil
+}
+
+type countedConn struct {
+	net.Conn
+
+	open      *metrics.Gauge
+	closeOnce sync.Once
+}
+
+func (c *countedConn) Close() error {
+	c.closeOnce.Do(c.open.Dec)
+
+	return c.Conn.Close()
+}
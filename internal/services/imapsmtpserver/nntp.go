@@ -0,0 +1,100 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package imapsmtpserver
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/constants"
+	"github.com/ProtonMail/proton-bridge/v3/internal/services/nntp"
+	"github.com/sirupsen/logrus"
+)
+
+type NNTPSettingsProvider interface {
+	TLSConfig() *tls.Config
+	Port() int
+	UseSSL() bool
+	AllowClient(net.Addr) bool
+	Backend() nntp.Backend
+}
+
+func (sm *Service) restartNNTP(ctx context.Context) error {
+	logrus.Info("Restarting NNTP server")
+
+	sm.closeNNTPServer()
+
+	if sm.shouldStartServers() {
+		return sm.serveNNTP(ctx)
+	}
+
+	return nil
+}
+
+// serveNNTP starts the NNTP server if it's enabled. It only listens on localhost, as it doesn't offer STARTTLS.
+func (sm *Service) serveNNTP(_ context.Context) error {
+	port := sm.nntpSettings.Port()
+	if port == 0 {
+		return nil
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"port": port,
+		"ssl":  sm.nntpSettings.UseSSL(),
+	}).Info("Starting NNTP server")
+
+	nntpListener, err := sm.newTrackedListener([]string{constants.Host}, port, sm.nntpSettings.UseSSL(), sm.nntpSettings.TLSConfig(), sm.nntpSettings.AllowClient)
+	if err != nil {
+		return fmt.Errorf("failed to create NNTP listener: %w", err)
+	}
+
+	nntpServer := nntp.NewServer(sm.nntpSettings.Backend(), sm.panicHandler)
+
+	sm.nntpServer = nntpServer
+	sm.nntpListener = nntpListener
+
+	sm.tasks.Once(func(context.Context) {
+		if err := nntpServer.Serve(nntpListener); err != nil {
+			logrus.WithError(err).Info("NNTP server stopped")
+		}
+	})
+
+	return nil
+}
+
+func (sm *Service) closeNNTPServer() {
+	if sm.nntpListener != nil {
+		logrus.Info("Closing NNTP Listener")
+
+		if err := sm.nntpListener.Close(); err != nil {
+			logrus.WithError(err).Error("Failed to close NNTP listener")
+		}
+
+		sm.nntpListener = nil
+	}
+
+	if sm.nntpServer != nil {
+		if err := sm.nntpServer.Close(); err != nil {
+			logrus.WithError(err).Error("Failed to close NNTP server")
+		}
+
+		sm.nntpServer = nil
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package imapsmtpserver
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/services/pop3"
+	"github.com/sirupsen/logrus"
+)
+
+type POP3SettingsProvider interface {
+	TLSConfig() *tls.Config
+	Hosts() []string
+	Port() int
+	UseSSL() bool
+	RequireTLS() bool
+	AllowClient(net.Addr) bool
+	Backend() pop3.Backend
+}
+
+func (sm *Service) restartPOP3(ctx context.Context) error {
+	logrus.Info("Restarting POP3 server")
+
+	sm.closePOP3Server()
+
+	if sm.shouldStartServers() {
+		return sm.servePOP3(ctx)
+	}
+
+	return nil
+}
+
+// servePOP3 starts the POP3 server if it's enabled.
+func (sm *Service) servePOP3(_ context.Context) error {
+	port := sm.pop3Settings.Port()
+	if port == 0 {
+		return nil
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"hosts": sm.pop3Settings.Hosts(),
+		"port":  port,
+		"ssl":   sm.pop3Settings.UseSSL(),
+	}).Info("Starting POP3 server")
+
+	pop3Listener, err := sm.newTrackedListener(sm.pop3Settings.Hosts(), port, sm.pop3Settings.UseSSL(), sm.pop3Settings.TLSConfig(), sm.pop3Settings.AllowClient)
+	if err != nil {
+		return fmt.Errorf("failed to create POP3 listener: %w", err)
+	}
+
+	// STLS is only offered on plaintext connections.
+	var tlsConfig *tls.Config
+
+	if !sm.pop3Settings.UseSSL() {
+		tlsConfig = sm.pop3Settings.TLSConfig()
+	}
+
+	pop3Server := pop3.NewServer(sm.pop3Settings.Backend(), tlsConfig, sm.pop3Settings.RequireTLS(), sm.panicHandler)
+
+	sm.pop3Server = pop3Server
+	sm.pop3Listener = pop3Listener
+
+	sm.tasks.Once(func(context.Context) {
+		if err := pop3Server.Serve(pop3Listener); err != nil {
+			logrus.WithError(err).Info("POP3 server stopped")
+		}
+	})
+
+	return nil
+}
+
+func (sm *Service) closePOP3Server() {
+	if sm.pop3Listener != nil {
+		logrus.Info("Closing POP3 Listener")
+
+		if err := sm.pop3Listener.Close(); err != nil {
+			logrus.WithError(err).Error("Failed to close POP3 listener")
+		}
+
+		sm.pop3Listener = nil
+	}
+
+	if sm.pop3Server != nil {
+		if err := sm.pop3Server.Close(); err != nil {
+			logrus.WithError(err).Error("Failed to close POP3 server")
+		}
+
+		sm.pop3Server = nil
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package imapsmtpserver
+
+import (
+	"context"
+
+	imapEvents "github.com/ProtonMail/gluon/events"
+	"github.com/ProtonMail/gluon/profiling"
+)
+
+// CmdTypeRemoteCall is reported to the command profiler of an IMAP session when its running command calls the API.
+const CmdTypeRemoteCall = profiling.CmdTypeTotal
+
+// cmdProfilerBuilders lets several command profilers observe the commands of each IMAP session.
+type cmdProfilerBuilders []profiling.CmdProfilerBuilder
+
+func (builders cmdProfilerBuilders) New() profiling.CmdProfiler {
+	profilers := make(cmdProfilers, 0, len(builders))
+
+	for _, builder := range builders {
+		profilers = append(profilers, builder.New())
+	}
+
+	return profilers
+}
+
+func (builders cmdProfilerBuilders) Collect(profiler profiling.CmdProfiler) {
+	profilers, ok := profiler.(cmdProfilers)
+	if !ok {
+		return
+	}
+
+	for i, builder := range builders {
+		builder.Collect(profilers[i])
+	}
+}
+
+type cmdProfilers []profiling.CmdProfiler
+
+func (profilers cmdProfilers) Start(cmdType int) {
+	for _, profiler := range profilers {
+		profiler.Start(cmdType)
+	}
+}
+
+func (profilers cmdProfilers) Stop(cmdType int) {
+	for _, profiler := range profilers {
+		profiler.Stop(cmdType)
+	}
+}
+
+// watchingPublisher passes the events of the IMAP server to watchers before publishing them.
+type watchingPublisher struct {
+	IMAPEventPublisher
+
+	watchers []func(imapEvents.Event)
+}
+
+func newWatchingPublisher(publisher IMAPEventPublisher, watchers ...func(imapEvents.Event)) *watchingPublisher {
+	return &watchingPublisher{
+		IMAPEventPublisher: publisher,
+		watchers:           watchers,
+	}
+}
+
+func (p *watchingPublisher) PublishIMAPEvent(ctx context.Context, event imapEvents.Event) {
+	for _, watcher := range p.watchers {
+		watcher(event)
+	}
+
+	p.IMAPEventPublisher.PublishIMAPEvent(ctx, event)
+}
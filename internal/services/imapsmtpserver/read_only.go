@@ -0,0 +1,80 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package imapsmtpserver
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ProtonMail/gluon/connector"
+	"github.com/ProtonMail/gluon/imap"
+)
+
+// errReadOnly is returned to the clients of a read-only IMAP server; gluon answers them with NO.
+var errReadOnly = fmt.Errorf("%w: the mailbox is read-only in safe mode", connector.ErrOperationNotAllowed)
+
+// readOnlyConnector serves the messages of the wrapped connector but refuses to change them.
+// Marking messages as seen is still allowed since clients do it implicitly when fetching a message body.
+type readOnlyConnector struct {
+	connector.Connector
+}
+
+func newReadOnlyConnector(conn connector.Connector) *readOnlyConnector {
+	return &readOnlyConnector{Connector: conn}
+}
+
+func (c *readOnlyConnector) CreateMailbox(context.Context, connector.IMAPStateWrite, []string) (imap.Mailbox, error) {
+	return imap.Mailbox{}, errReadOnly
+}
+
+func (c *readOnlyConnector) UpdateMailboxName(context.Context, connector.IMAPStateWrite, imap.MailboxID, []string) error {
+	return errReadOnly
+}
+
+func (c *readOnlyConnector) DeleteMailbox(context.Context, connector.IMAPStateWrite, imap.MailboxID) error {
+	return errReadOnly
+}
+
+func (c *readOnlyConnector) CreateMessage(context.Context, connector.IMAPStateWrite, imap.MailboxID, []byte, imap.FlagSet, time.Time) (imap.Message, []byte, error) {
+	return imap.Message{}, nil, errReadOnly
+}
+
+func (c *readOnlyConnector) AddMessagesToMailbox(context.Context, connector.IMAPStateWrite, []imap.MessageID, imap.MailboxID) error {
+	return errReadOnly
+}
+
+func (c *readOnlyConnector) RemoveMessagesFromMailbox(context.Context, connector.IMAPStateWrite, []imap.MessageID, imap.MailboxID) error {
+	return errReadOnly
+}
+
+func (c *readOnlyConnector) MoveMessages(context.Context, connector.IMAPStateWrite, []imap.MessageID, imap.MailboxID, imap.MailboxID) (bool, error) {
+	return false, errReadOnly
+}
+
+func (c *readOnlyConnector) MarkMessagesSeen(ctx context.Context, cache connector.IMAPStateWrite, messageIDs []imap.MessageID, seen bool) error {
+	if !seen {
+		return errReadOnly
+	}
+
+	return c.Connector.MarkMessagesSeen(ctx, cache, messageIDs, seen)
+}
+
+func (c *readOnlyConnector) MarkMessagesFlagged(context.Context, connector.IMAPStateWrite, []imap.MessageID, bool) error {
+	return errReadOnly
+}
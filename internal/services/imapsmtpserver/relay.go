@@ -0,0 +1,121 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package imapsmtpserver
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/constants"
+	"github.com/ProtonMail/proton-bridge/v3/internal/logging"
+	smtpservice "github.com/ProtonMail/proton-bridge/v3/internal/services/smtp"
+	"github.com/emersion/go-sasl"
+	"github.com/emersion/go-smtp"
+	"github.com/sirupsen/logrus"
+)
+
+func newRelayServer(accounts *smtpservice.Accounts, settings SMTPSettingsProvider) *smtp.Server {
+	logrus.WithField("logSMTP", settings.Log()).Info("Creating SMTP relay server")
+
+	backend := smtpservice.NewRelayBackend(accounts, settings.Relay())
+
+	relayServer := smtp.NewServer(backend)
+
+	relayServer.TLSConfig = settings.TLSConfig()
+	relayServer.Domain = constants.Host
+	// The relay is reachable from the network, so credentials are only accepted after STARTTLS.
+	relayServer.AllowInsecureAuth = false
+	relayServer.AuthDisabled = !backend.AuthRequired()
+	relayServer.MaxLineLength = 1 << 16
+	relayServer.ErrorLog = logging.NewSMTPLogger()
+
+	// Scanners and printers often only support LOGIN, which go-smtp doesn't provide.
+	relayServer.EnableAuth(sasl.Login, func(conn *smtp.Conn) sasl.Server {
+		return sasl.NewLoginServer(func(username, password string) error {
+			return conn.Session().AuthPlain(username, password)
+		})
+	})
+
+	if settings.Log() {
+		relayServer.Debug = logging.NewSMTPDebugLogger()
+	}
+
+	return relayServer
+}
+
+func (sm *Service) restartRelay(ctx context.Context) error {
+	logrus.Info("Restarting SMTP relay server")
+
+	sm.closeRelayServer()
+
+	if sm.shouldStartServers() {
+		return sm.serveRelay(ctx)
+	}
+
+	return nil
+}
+
+// serveRelay starts the SMTP relay if it's enabled. Unlike the other servers it listens on all interfaces,
+// only accepting connections from the clients allowed to use the relay.
+func (sm *Service) serveRelay(_ context.Context) error {
+	port := sm.smtpSettings.RelayPort()
+	if port == 0 {
+		return nil
+	}
+
+	logrus.WithField("port", port).Info("Starting SMTP relay server")
+
+	relayListener, err := sm.newTrackedListener(sm.smtpSettings.RelayHosts(), port, false, nil, sm.smtpSettings.AllowRelayClient)
+	if err != nil {
+		return fmt.Errorf("failed to create SMTP relay listener: %w", err)
+	}
+
+	relayServer := newRelayServer(sm.smtpAccounts, sm.smtpSettings)
+
+	sm.relayServer = relayServer
+	sm.relayListener = relayListener
+
+	sm.tasks.Once(func(context.Context) {
+		if err := relayServer.Serve(relayListener); err != nil {
+			logrus.WithError(err).Info("SMTP relay server stopped")
+		}
+	})
+
+	return nil
+}
+
+func (sm *Service) closeRelayServer() {
+	// As with SMTP, the listener is closed first so that go-smtp doesn't block on it.
+	if sm.relayListener != nil {
+		logrus.Info("Closing SMTP relay Listener")
+
+		if err := sm.relayListener.Close(); err != nil {
+			logrus.WithError(err).Error("Failed to close SMTP relay listener")
+		}
+
+		sm.relayListener = nil
+	}
+
+	if sm.relayServer != nil {
+		if err := sm.relayServer.Close(); err != nil {
+			logrus.WithError(err).Debug("Failed to close SMTP relay server (expected -- we close the listener ourselves)")
+		}
+
+		sm.relayServer = nil
+	}
+}
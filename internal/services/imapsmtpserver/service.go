@@ -31,6 +31,8 @@ import (
 	"github.com/ProtonMail/gluon/reporter"
 	"github.com/ProtonMail/proton-bridge/v3/internal/events"
 	"github.com/ProtonMail/proton-bridge/v3/internal/services/imapservice"
+	"github.com/ProtonMail/proton-bridge/v3/internal/services/nntp"
+	"github.com/ProtonMail/proton-bridge/v3/internal/services/pop3"
 	bridgesmtp "github.com/ProtonMail/proton-bridge/v3/internal/services/smtp"
 	"github.com/ProtonMail/proton-bridge/v3/internal/services/syncservice"
 	"github.com/ProtonMail/proton-bridge/v3/pkg/cpc"
@@ -49,8 +51,22 @@ type Service struct {
 	smtpListener net.Listener
 	smtpAccounts *bridgesmtp.Accounts
 
+	lmtpServer   *smtp.Server
+	lmtpListener net.Listener
+
+	relayServer   *smtp.Server
+	relayListener net.Listener
+
+	pop3Server   *pop3.Server
+	pop3Listener net.Listener
+
+	nntpServer   *nntp.Server
+	nntpListener net.Listener
+
 	smtpSettings   SMTPSettingsProvider
 	imapSettings   IMAPSettingsProvider
+	pop3Settings   POP3SettingsProvider
+	nntpSettings   NNTPSettingsProvider
 	eventPublisher events.EventPublisher
 	panicHandler   async.PanicHandler
 	reporter       reporter.Reporter
@@ -61,12 +77,19 @@ type Service struct {
 
 	uidValidityGenerator imap.UIDValidityGenerator
 	telemetry            Telemetry
+
+	limiter      *limiter
+	slowCommands *slowCommandLog
+	connections  *connectionLog
+	listenPorts  *listenPorts
 }
 
 func NewService(
 	ctx context.Context,
 	smtpSettings SMTPSettingsProvider,
 	imapSettings IMAPSettingsProvider,
+	pop3Settings POP3SettingsProvider,
+	nntpSettings NNTPSettingsProvider,
 	eventPublisher events.EventPublisher,
 	panicHandler async.PanicHandler,
 	reporter reporter.Reporter,
@@ -81,11 +104,18 @@ func NewService(
 		reporter:             reporter,
 		smtpSettings:         smtpSettings,
 		imapSettings:         imapSettings,
+		pop3Settings:         pop3Settings,
+		nntpSettings:         nntpSettings,
 		eventPublisher:       eventPublisher,
 		log:                  logrus.WithField("service", "server-manager"),
 		tasks:                async.NewGroup(ctx, panicHandler),
 		uidValidityGenerator: uidValidityGenerator,
 		telemetry:            telemetry,
+
+		limiter:      newLimiter(imapSettings.Limits),
+		slowCommands: newSlowCommandLog(imapSettings.SlowCommandThreshold),
+		connections:  newConnectionLog(),
+		listenPorts:  newListenPorts(),
 	}
 }
 
@@ -130,6 +160,30 @@ func (sm *Service) RestartSMTP(ctx context.Context) error {
 	return err
 }
 
+func (sm *Service) RestartLMTP(ctx context.Context) error {
+	_, err := sm.requests.Send(ctx, &smRequestRestartLMTP{})
+
+	return err
+}
+
+func (sm *Service) RestartRelay(ctx context.Context) error {
+	_, err := sm.requests.Send(ctx, &smRequestRestartRelay{})
+
+	return err
+}
+
+func (sm *Service) RestartPOP3(ctx context.Context) error {
+	_, err := sm.requests.Send(ctx, &smRequestRestartPOP3{})
+
+	return err
+}
+
+func (sm *Service) RestartNNTP(ctx context.Context) error {
+	_, err := sm.requests.Send(ctx, &smRequestRestartNNTP{})
+
+	return err
+}
+
 func (sm *Service) AddIMAPUser(
 	ctx context.Context,
 	connector connector.Connector,
@@ -147,6 +201,31 @@ func (sm *Service) AddIMAPUser(
 	return err
 }
 
+// GetLimitStats returns how often the limits throttled the IMAP clients.
+func (sm *Service) GetLimitStats() LimitStats {
+	return sm.limiter.getStats()
+}
+
+// GetSlowCommands returns the last IMAP commands which ran for longer than the slow command threshold, the oldest first.
+func (sm *Service) GetSlowCommands() []SlowCommand {
+	return sm.slowCommands.getCommands()
+}
+
+// GetConnections returns the open IMAP connections, the oldest first.
+func (sm *Service) GetConnections() []Connection {
+	return sm.connections.getConnections()
+}
+
+// IsListening returns whether one of the servers listens on the given port.
+func (sm *Service) IsListening(port int) bool {
+	return sm.listenPorts.has(port)
+}
+
+// ReportRemoteCall tells the slow command log that the IMAP command running with the given context, if any, calls the API.
+func (sm *Service) ReportRemoteCall(ctx context.Context) {
+	sm.slowCommands.reportRemoteCall(ctx)
+}
+
 func (sm *Service) SetGluonDir(ctx context.Context, gluonDir string) error {
 	_, err := sm.requests.Send(ctx, &smRequestSetGluonDir{
 		dir: gluonDir,
@@ -177,6 +256,18 @@ func (sm *Service) RemoveSMTPAccount(ctx context.Context, service *bridgesmtp.Se
 	return err
 }
 
+func (sm *Service) SuspendSMTPAccount(ctx context.Context, service *bridgesmtp.Service, auth *bridgesmtp.SuspendedAuth) error {
+	_, err := sm.requests.Send(ctx, &smRequestSuspendSMTPAccount{account: service, auth: auth})
+
+	return err
+}
+
+func (sm *Service) RemoveSuspendedSMTPAccount(ctx context.Context, userID string) error {
+	_, err := sm.requests.Send(ctx, &smRequestRemoveSuspendedSMTPAccount{userID: userID})
+
+	return err
+}
+
 func (sm *Service) run(ctx context.Context, subscription events.Subscription) {
 	eventSub := subscription.Add()
 	defer subscription.Remove(eventSub)
@@ -195,6 +286,11 @@ func (sm *Service) run(ctx context.Context, subscription events.Subscription) {
 					logrus.WithError(err).Error("Failed to close SMTP server")
 				}
 
+				sm.closeLMTPServer()
+				sm.closeRelayServer()
+				sm.closePOP3Server()
+				sm.closeNNTPServer()
+
 				if err := sm.stopIMAPListener(ctx); err != nil {
 					logrus.WithError(err)
 				}
@@ -222,6 +318,22 @@ func (sm *Service) run(ctx context.Context, subscription events.Subscription) {
 				err := sm.restartIMAP(ctx)
 				request.Reply(ctx, nil, err)
 
+			case *smRequestRestartLMTP:
+				err := sm.restartLMTP(ctx)
+				request.Reply(ctx, nil, err)
+
+			case *smRequestRestartRelay:
+				err := sm.restartRelay(ctx)
+				request.Reply(ctx, nil, err)
+
+			case *smRequestRestartPOP3:
+				err := sm.restartPOP3(ctx)
+				request.Reply(ctx, nil, err)
+
+			case *smRequestRestartNNTP:
+				err := sm.restartNNTP(ctx)
+				request.Reply(ctx, nil, err)
+
 			case *smRequestAddIMAPUser:
 				err := sm.handleAddIMAPUser(ctx, r.connector, r.addrID, r.idProvider, r.syncStateProvider)
 				request.Reply(ctx, nil, err)
@@ -249,6 +361,16 @@ func (sm *Service) run(ctx context.Context, subscription events.Subscription) {
 				logrus.WithField("user", r.account.UserID()).Debug("Removing SMTP Account")
 				sm.smtpAccounts.RemoveAccount(r.account)
 				request.Reply(ctx, nil, nil)
+
+			case *smRequestSuspendSMTPAccount:
+				logrus.WithField("user", r.account.UserID()).Debug("Suspending SMTP Account")
+				sm.smtpAccounts.SuspendAccount(r.account, r.auth)
+				request.Reply(ctx, nil, nil)
+
+			case *smRequestRemoveSuspendedSMTPAccount:
+				logrus.WithField("user", r.userID).Debug("Removing suspended SMTP Account")
+				sm.smtpAccounts.RemoveSuspendedAccount(r.userID)
+				request.Reply(ctx, nil, nil)
 			}
 		}
 	}
@@ -268,6 +390,30 @@ func (sm *Service) handleLoadedUserCountChange(ctx context.Context) {
 				logrus.WithError(err).Error("Failed to start SMTP server")
 			}
 		}
+
+		if sm.lmtpListener == nil {
+			if err := sm.serveLMTP(ctx); err != nil {
+				logrus.WithError(err).Error("Failed to start LMTP server")
+			}
+		}
+
+		if sm.relayListener == nil {
+			if err := sm.serveRelay(ctx); err != nil {
+				logrus.WithError(err).Error("Failed to start SMTP relay server")
+			}
+		}
+
+		if sm.pop3Listener == nil {
+			if err := sm.servePOP3(ctx); err != nil {
+				logrus.WithError(err).Error("Failed to start POP3 server")
+			}
+		}
+
+		if sm.nntpListener == nil {
+			if err := sm.serveNNTP(ctx); err != nil {
+				logrus.WithError(err).Error("Failed to start NNTP server")
+			}
+		}
 	} else {
 		if sm.imapListener != nil {
 			if err := sm.stopIMAPListener(ctx); err != nil {
@@ -280,6 +426,11 @@ func (sm *Service) handleLoadedUserCountChange(ctx context.Context) {
 				logrus.WithError(err).Error("Failed to stop SMTP server")
 			}
 		}
+
+		sm.closeLMTPServer()
+		sm.closeRelayServer()
+		sm.closePOP3Server()
+		sm.closeNNTPServer()
 	}
 }
 
@@ -294,6 +445,18 @@ func (sm *Service) handleClose(ctx context.Context) {
 		logrus.WithError(err).Error("Failed to close SMTP server")
 	}
 
+	// Close the LMTP server.
+	sm.closeLMTPServer()
+
+	// Close the SMTP relay server.
+	sm.closeRelayServer()
+
+	// Close the POP3 server.
+	sm.closePOP3Server()
+
+	// Close the NNTP server.
+	sm.closeNNTPServer()
+
 	// Cancel and wait needs to be called here since the SMTP server does not have a way to exit
 	// the task on context cancellation. Therefor we need to wait here after we issued a close request.
 	sm.tasks.CancelAndWait()
@@ -330,6 +493,11 @@ func (sm *Service) handleAddIMAPUserImpl(ctx context.Context,
 	})
 	log.Info("Adding user to imap server")
 
+	if sm.imapSettings.ReadOnly() {
+		log.Info("Serving IMAP user read-only")
+		connector = newReadOnlyConnector(connector)
+	}
+
 	if gluonID, ok := idProvider.GetGluonID(addrID); ok {
 		log.WithField("gluonID", gluonID).Info("Loading existing IMAP user")
 
@@ -457,10 +625,12 @@ func (sm *Service) createIMAPServer(ctx context.Context) (*gluon.Server, error)
 		sm.reporter,
 		sm.imapSettings.LogClient(),
 		sm.imapSettings.LogServer(),
-		sm.imapSettings.EventPublisher(),
+		newWatchingPublisher(sm.imapSettings.EventPublisher(), sm.limiter.handleEvent, sm.slowCommands.handleEvent, sm.connections.handleEvent),
 		sm.tasks,
 		sm.uidValidityGenerator,
 		sm.panicHandler,
+		cmdProfilerBuilders{sm.limiter, sm.slowCommands},
+		sm.imapSettings.RedactedMessages(),
 	)
 	if err == nil {
 		sm.eventPublisher.PublishEvent(ctx, events.IMAPServerCreated{})
@@ -570,15 +740,18 @@ func (sm *Service) restartSMTP(ctx context.Context) error {
 func (sm *Service) serveSMTP(ctx context.Context) error {
 	port, err := func() (int, error) {
 		logrus.WithFields(logrus.Fields{
-			"port": sm.smtpSettings.Port(),
-			"ssl":  sm.smtpSettings.UseSSL(),
+			"hosts": sm.smtpSettings.Hosts(),
+			"port":  sm.smtpSettings.Port(),
+			"ssl":   sm.smtpSettings.UseSSL(),
 		}).Info("Starting SMTP server")
 
-		smtpListener, err := newListener(sm.smtpSettings.Port(), sm.smtpSettings.UseSSL(), sm.smtpSettings.TLSConfig())
+		smtpListener, err := sm.newTrackedListener(sm.smtpSettings.Hosts(), sm.smtpSettings.Port(), sm.smtpSettings.UseSSL(), sm.smtpSettings.TLSConfig(), sm.smtpSettings.AllowClient)
 		if err != nil {
 			return 0, fmt.Errorf("failed to create SMTP listener: %w", err)
 		}
 
+		smtpListener = countConnections(smtpListener, "smtp")
+
 		sm.smtpListener = smtpListener
 
 		sm.tasks.Once(func(context.Context) {
@@ -616,16 +789,17 @@ func (sm *Service) serveIMAP(ctx context.Context) error {
 		}
 
 		logrus.WithFields(logrus.Fields{
-			"port": sm.imapSettings.Port(),
-			"ssl":  sm.imapSettings.UseSSL(),
+			"hosts": sm.imapSettings.Hosts(),
+			"port":  sm.imapSettings.Port(),
+			"ssl":   sm.imapSettings.UseSSL(),
 		}).Info("Starting IMAP server")
 
-		imapListener, err := newListener(sm.imapSettings.Port(), sm.imapSettings.UseSSL(), sm.imapSettings.TLSConfig())
+		imapListener, err := sm.newTrackedListener(sm.imapSettings.Hosts(), sm.imapSettings.Port(), sm.imapSettings.UseSSL(), sm.imapSettings.TLSConfig(), sm.imapSettings.AllowClient)
 		if err != nil {
 			return 0, fmt.Errorf("failed to create IMAP listener: %w", err)
 		}
 
-		sm.imapListener = imapListener
+		sm.imapListener = sm.limiter.listener(countConnections(imapListener, "imap"))
 
 		if err := sm.imapServer.Serve(ctx, sm.imapListener); err != nil {
 			return 0, fmt.Errorf("failed to serve IMAP: %w", err)
@@ -719,6 +893,14 @@ type smRequestRestartIMAP struct{}
 
 type smRequestRestartSMTP struct{}
 
+type smRequestRestartLMTP struct{}
+
+type smRequestRestartRelay struct{}
+
+type smRequestRestartPOP3 struct{}
+
+type smRequestRestartNNTP struct{}
+
 type smRequestAddIMAPUser struct {
 	connector         connector.Connector
 	addrID            string
@@ -743,3 +925,12 @@ type smRequestAddSMTPAccount struct {
 type smRequestRemoveSMTPAccount struct {
 	account *bridgesmtp.Service
 }
+
+type smRequestSuspendSMTPAccount struct {
+	account *bridgesmtp.Service
+	auth    *bridgesmtp.SuspendedAuth
+}
+
+type smRequestRemoveSuspendedSMTPAccount struct {
+	userID string
+}
@@ -0,0 +1,228 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package imapsmtpserver
+
+import (
+	"context"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	imapEvents "github.com/ProtonMail/gluon/events"
+	"github.com/ProtonMail/gluon/profiling"
+)
+
+// slowCommandBufferSize is the number of slow commands kept for diagnostics.
+const slowCommandBufferSize = 100
+
+// SlowCommand is an IMAP command which ran for longer than the slow command threshold.
+type SlowCommand struct {
+	Time     time.Time
+	Command  string
+	Mailbox  string // Empty if the mailbox selected by the session isn't known.
+	Duration time.Duration
+
+	// RemoteCall is whether the command called the API.
+	RemoteCall bool
+}
+
+// slowCommandLog keeps the last IMAP commands which ran for longer than the threshold returned by getThreshold.
+// It learns the session of a command when the command calls the API, and the mailbox selected by each session
+// from the events of the IMAP server.
+type slowCommandLog struct {
+	getThreshold func() time.Duration
+
+	lock      sync.Mutex
+	commands  []SlowCommand
+	mailboxes map[int]string // Selected mailboxes keyed by session ID.
+
+	// reportLock serializes the reports of API calls, during which reportingSession is the session of the caller.
+	reportLock       sync.Mutex
+	reportingSession int
+}
+
+func newSlowCommandLog(getThreshold func() time.Duration) *slowCommandLog {
+	return &slowCommandLog{
+		getThreshold: getThreshold,
+		mailboxes:    make(map[int]string),
+	}
+}
+
+// New implements profiling.CmdProfilerBuilder.
+func (l *slowCommandLog) New() profiling.CmdProfiler {
+	return &slowCommandProfiler{
+		log:     l,
+		started: make(map[int]time.Time),
+	}
+}
+
+func (l *slowCommandLog) Collect(profiling.CmdProfiler) {}
+
+// getCommands returns the slow commands, the oldest first.
+func (l *slowCommandLog) getCommands() []SlowCommand {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return append([]SlowCommand(nil), l.commands...)
+}
+
+// reportRemoteCall tells the profiler of the IMAP session running the command with the given context, if any,
+// that the command calls the API.
+func (l *slowCommandLog) reportRemoteCall(ctx context.Context) {
+	l.reportLock.Lock()
+	defer l.reportLock.Unlock()
+
+	// Gluon labels the contexts of the commands with their session.
+	l.reportingSession = 0
+
+	if label, ok := pprof.Label(ctx, "SessionID"); ok {
+		if sessionID, err := strconv.Atoi(label); err == nil {
+			l.reportingSession = sessionID
+		}
+	}
+
+	profiling.Start(ctx, CmdTypeRemoteCall)
+}
+
+func (l *slowCommandLog) handleEvent(event imapEvents.Event) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	switch event := event.(type) {
+	case imapEvents.Select:
+		l.mailboxes[event.SessionID] = event.Mailbox
+
+	case imapEvents.SessionRemoved:
+		delete(l.mailboxes, event.SessionID)
+	}
+}
+
+func (l *slowCommandLog) add(command SlowCommand, sessionID int) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	command.Mailbox = l.mailboxes[sessionID]
+
+	if len(l.commands) == slowCommandBufferSize {
+		l.commands = l.commands[1:]
+	}
+
+	l.commands = append(l.commands, command)
+}
+
+func (l *slowCommandLog) unselect(sessionID int) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	delete(l.mailboxes, sessionID)
+}
+
+// slowCommandProfiler times the commands of an IMAP session.
+type slowCommandProfiler struct {
+	log *slowCommandLog
+
+	lock       sync.Mutex
+	sessionID  int // Zero until the session called the API.
+	started    map[int]time.Time
+	remoteCall bool
+}
+
+func (p *slowCommandProfiler) Start(cmdType int) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	switch cmdType {
+	case CmdTypeRemoteCall:
+		// This is called by reportRemoteCall, which holds the report lock.
+		if p.log.reportingSession != 0 {
+			p.sessionID = p.log.reportingSession
+		}
+
+		p.remoteCall = true
+
+		return
+
+	case profiling.CmdTypeExamine, profiling.CmdTypeClose, profiling.CmdTypeUnselect:
+		// Only SELECT is reported by the IMAP server.
+		p.log.unselect(p.sessionID)
+	}
+
+	p.started[cmdType] = time.Now()
+	p.remoteCall = false
+}
+
+func (p *slowCommandProfiler) Stop(cmdType int) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	started, ok := p.started[cmdType]
+	if !ok {
+		return
+	}
+
+	delete(p.started, cmdType)
+
+	// IDLE lasts until the client has something to do.
+	if cmdType == profiling.CmdTypeIdle {
+		return
+	}
+
+	threshold := p.log.getThreshold()
+	if threshold <= 0 {
+		return
+	}
+
+	if duration := time.Since(started); duration >= threshold {
+		p.log.add(SlowCommand{
+			Time:       started,
+			Command:    getCommandName(cmdType),
+			Duration:   duration,
+			RemoteCall: p.remoteCall,
+		}, p.sessionID)
+	}
+}
+
+func getCommandName(cmdType int) string {
+	switch cmdType {
+	case profiling.CmdTypeSubscribe:
+		return "SUBSCRIBE"
+
+	case profiling.CmdTypeUnsubscribe:
+		return "UNSUBSCRIBE"
+
+	case profiling.CmdTypeUIDFetch:
+		return "UID FETCH"
+
+	case profiling.CmdTypeUIDCopy:
+		return "UID COPY"
+
+	case profiling.CmdTypeUIDMove:
+		return "UID MOVE"
+
+	case profiling.CmdTypeUIDStore:
+		return "UID STORE"
+
+	case profiling.CmdTypeUIDSearch:
+		return "UID SEARCH"
+
+	default:
+		return strings.TrimSpace(profiling.CmdTypeToString(cmdType))
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package imapsmtpserver
+
+import (
+	"context"
+	"runtime/pprof"
+	"testing"
+	"time"
+
+	imapEvents "github.com/ProtonMail/gluon/events"
+	"github.com/ProtonMail/gluon/profiling"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlowCommandLog(t *testing.T) {
+	threshold := time.Duration(0)
+
+	l := newSlowCommandLog(func() time.Duration { return threshold })
+	p := l.New()
+
+	// Nothing is kept while disabled.
+	p.Start(profiling.CmdTypeFetch)
+	p.Stop(profiling.CmdTypeFetch)
+	require.Empty(t, l.getCommands())
+
+	threshold = time.Nanosecond
+
+	// Commands are kept once slower than the threshold, except IDLE.
+	p.Start(profiling.CmdTypeIdle)
+	p.Stop(profiling.CmdTypeIdle)
+	p.Start(profiling.CmdTypeUIDFetch)
+	time.Sleep(time.Millisecond)
+	p.Stop(profiling.CmdTypeUIDFetch)
+
+	commands := l.getCommands()
+	require.Len(t, commands, 1)
+	require.Equal(t, "UID FETCH", commands[0].Command)
+	require.Empty(t, commands[0].Mailbox)
+	require.False(t, commands[0].RemoteCall)
+	require.GreaterOrEqual(t, commands[0].Duration, time.Millisecond)
+}
+
+func TestSlowCommandLog_RemoteCall(t *testing.T) {
+	l := newSlowCommandLog(func() time.Duration { return time.Nanosecond })
+	p := l.New()
+
+	// Gluon runs the commands of session 7 with a labelled context holding the profiler of the session.
+	ctx := profiling.WithProfiler(pprof.WithLabels(context.Background(), pprof.Labels("SessionID", "7")), p)
+
+	l.handleEvent(imapEvents.Select{SessionID: 7, Mailbox: "INBOX"})
+
+	// The API call tells the profiler its session, whose selected mailbox is then known.
+	p.Start(profiling.CmdTypeFetch)
+	l.reportRemoteCall(ctx)
+	p.Stop(profiling.CmdTypeFetch)
+
+	commands := l.getCommands()
+	require.Len(t, commands, 1)
+	require.Equal(t, "INBOX", commands[0].Mailbox)
+	require.True(t, commands[0].RemoteCall)
+
+	// Examining another mailbox forgets the selected one.
+	p.Start(profiling.CmdTypeExamine)
+	p.Stop(profiling.CmdTypeExamine)
+
+	commands = l.getCommands()
+	require.Len(t, commands, 2)
+	require.Empty(t, commands[1].Mailbox)
+	require.False(t, commands[1].RemoteCall)
+}
+
+func TestSlowCommandLog_BufferSize(t *testing.T) {
+	l := newSlowCommandLog(func() time.Duration { return time.Nanosecond })
+	p := l.New()
+
+	for i := 0; i < slowCommandBufferSize+10; i++ {
+		p.Start(profiling.CmdTypeNoop)
+		p.Stop(profiling.CmdTypeNoop)
+	}
+
+	require.Len(t, l.getCommands(), slowCommandBufferSize)
+}
@@ -19,6 +19,7 @@ package imapsmtpserver
 
 import (
 	"crypto/tls"
+	"net"
 
 	"github.com/ProtonMail/proton-bridge/v3/internal/constants"
 	"github.com/ProtonMail/proton-bridge/v3/internal/identifier"
@@ -32,9 +33,17 @@ import (
 type SMTPSettingsProvider interface {
 	TLSConfig() *tls.Config
 	Log() bool
+	Hosts() []string
 	Port() int
 	SetPort(int) error
+	LMTPSocketPath() (string, error)
+	RelayPort() int
+	RelayHosts() []string
+	Relay() smtpservice.RelaySettings
+	AllowRelayClient(net.Addr) bool
 	UseSSL() bool
+	RequireTLS() bool
+	AllowClient(net.Addr) bool
 	Identifier() identifier.UserAgentUpdater
 }
 
@@ -45,7 +54,7 @@ func newSMTPServer(accounts *smtpservice.Accounts, settings SMTPSettingsProvider
 
 	smtpServer.TLSConfig = settings.TLSConfig()
 	smtpServer.Domain = constants.Host
-	smtpServer.AllowInsecureAuth = true
+	smtpServer.AllowInsecureAuth = !settings.RequireTLS()
 	smtpServer.MaxLineLength = 1 << 16
 	smtpServer.ErrorLog = logging.NewSMTPLogger()
 
@@ -0,0 +1,118 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package imapsmtpserver
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"github.com/ProtonMail/gluon/imap"
+	"github.com/ProtonMail/gluon/rfc822"
+	"github.com/ProtonMail/gluon/store"
+	"github.com/ProtonMail/proton-bridge/v3/internal/interference"
+)
+
+// retryingStore retries the operations on the message files which fail because security software is scanning them.
+type retryingStore struct {
+	store.Store
+}
+
+func (s retryingStore) Get(messageID imap.InternalMessageID) ([]byte, error) {
+	var literal []byte
+
+	if err := interference.Retry(func() (err error) {
+		literal, err = s.Store.Get(messageID)
+		return
+	}); err != nil {
+		return nil, err
+	}
+
+	return literal, nil
+}
+
+func (s retryingStore) Set(messageID imap.InternalMessageID, reader io.Reader) error {
+	// The reader must be buffered so that it can be replayed; the on-disk store reads it whole anyway.
+	literal, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+
+	return interference.Retry(func() error {
+		return s.Store.Set(messageID, bytes.NewReader(literal))
+	})
+}
+
+func (s retryingStore) Delete(messageID ...imap.InternalMessageID) error {
+	return interference.Retry(func() error {
+		return s.Store.Delete(messageID...)
+	})
+}
+
+// errRedacted is returned for the literals of redacted messages, which gluon then downloads from the connector.
+var errRedacted = errors.New("the message is redacted from the store")
+
+// RedactedMessages tells the messages whose literals mustn't be kept in the store.
+type RedactedMessages interface {
+	IsRedacted(messageID string) bool
+}
+
+// redactingStore doesn't keep the literals of redacted messages, so that they're downloaded whenever they're read.
+// Messages are told by their X-Pm-Internal-Id header, which bridge adds to all literals.
+type redactingStore struct {
+	store.Store
+
+	redacted RedactedMessages
+}
+
+// Get evicts the literals of messages redacted since they were stored, e.g. because they were labelled.
+func (s redactingStore) Get(messageID imap.InternalMessageID) ([]byte, error) {
+	literal, err := s.Store.Get(messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.isRedacted(literal) {
+		if err := s.Store.Delete(messageID); err != nil {
+			return nil, err
+		}
+
+		return nil, errRedacted
+	}
+
+	return literal, nil
+}
+
+func (s redactingStore) Set(messageID imap.InternalMessageID, reader io.Reader) error {
+	literal, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+
+	if s.isRedacted(literal) {
+		return nil
+	}
+
+	return s.Store.Set(messageID, bytes.NewReader(literal))
+}
+
+func (s redactingStore) isRedacted(literal []byte) bool {
+	messageID, err := rfc822.GetHeaderValue(literal, "X-Pm-Internal-Id")
+
+	return err == nil && messageID != "" && s.redacted.IsRedacted(messageID)
+}
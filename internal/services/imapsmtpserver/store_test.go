@@ -0,0 +1,73 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package imapsmtpserver
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ProtonMail/gluon/imap"
+	"github.com/ProtonMail/gluon/store"
+	"github.com/stretchr/testify/require"
+)
+
+type redactedSet map[string]bool
+
+func (s redactedSet) IsRedacted(messageID string) bool {
+	return s[messageID]
+}
+
+func TestRedactingStore(t *testing.T) {
+	disk, err := store.NewOnDiskStore(t.TempDir(), []byte("pass"))
+	require.NoError(t, err)
+
+	redacted := redactedSet{}
+	s := redactingStore{Store: disk, redacted: redacted}
+
+	literal := func(id string) []byte {
+		return []byte("X-Pm-Internal-Id: " + id + "\r\nSubject: Hi\r\n\r\nBody")
+	}
+
+	// Literals of redacted messages aren't stored.
+	redacted["redacted"] = true
+
+	redactedID := imap.NewInternalMessageID()
+
+	require.NoError(t, s.Set(redactedID, bytes.NewReader(literal("redacted"))))
+
+	_, err = disk.Get(redactedID)
+	require.Error(t, err)
+
+	// Other literals are.
+	id := imap.NewInternalMessageID()
+
+	require.NoError(t, s.Set(id, bytes.NewReader(literal("plain"))))
+
+	got, err := s.Get(id)
+	require.NoError(t, err)
+	require.Equal(t, literal("plain"), got)
+
+	// Literals of messages redacted after being stored are evicted when read.
+	redacted["plain"] = true
+
+	_, err = s.Get(id)
+	require.ErrorIs(t, err, errRedacted)
+
+	_, err = disk.Get(id)
+	require.Error(t, err)
+}
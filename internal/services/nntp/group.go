@@ -0,0 +1,85 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package nntp
+
+import (
+	"path"
+	"sort"
+	"strings"
+)
+
+// maxArticleNumber is the highest article number RFC 3977 allows.
+const maxArticleNumber = 1<<31 - 1
+
+// numberedArticle is an article with its number in its newsgroup.
+type numberedArticle struct {
+	Article
+
+	number int
+}
+
+// numberArticles sorts the articles by date and numbers them with their Unix time, bumped past the previous
+// article's number if needed. Unlike indexes, these numbers don't change when older articles are removed,
+// unless they were received in the same second, so news readers can keep track of the articles they have read.
+func numberArticles(articles []Article) []numberedArticle {
+	sort.SliceStable(articles, func(i, j int) bool {
+		if !articles[i].Date.Equal(articles[j].Date) {
+			return articles[i].Date.Before(articles[j].Date)
+		}
+
+		return articles[i].ID < articles[j].ID
+	})
+
+	numbered := make([]numberedArticle, 0, len(articles))
+
+	var prev int
+
+	for _, article := range articles {
+		number := int(article.Date.Unix())
+
+		if number <= prev {
+			number = prev + 1
+		}
+
+		if number > maxArticleNumber {
+			break
+		}
+
+		numbered = append(numbered, numberedArticle{Article: article, number: number})
+
+		prev = number
+	}
+
+	return numbered
+}
+
+// matchWildmat returns whether the name matches the wildmat (RFC 3977 section 4), a comma separated list of
+// patterns, negated by a leading "!", the last matching one deciding.
+func matchWildmat(wildmat, name string) bool {
+	var match bool
+
+	for _, pattern := range strings.Split(wildmat, ",") {
+		negated := strings.HasPrefix(pattern, "!")
+
+		if ok, err := path.Match(strings.TrimPrefix(pattern, "!"), name); err == nil && ok {
+			match = !negated
+		}
+	}
+
+	return match
+}
@@ -0,0 +1,161 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package nntp implements an experimental, read-only NNTP server (RFC 3977) for news readers,
+// with the READER, OVER and AUTHINFO USER (RFC 4643) capabilities.
+package nntp
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/ProtonMail/gluon/async"
+	"github.com/sirupsen/logrus"
+)
+
+var ErrInvalidCredentials = errors.New("invalid username or password")
+
+// Backend signs in NNTP clients.
+type Backend interface {
+	// Login returns the newsgroups of the account the given credentials belong to.
+	Login(ctx context.Context, username string, password []byte) (Reader, error)
+}
+
+// Reader gives a signed in client access to its newsgroups.
+type Reader interface {
+	Groups(ctx context.Context) ([]Group, error)
+	Articles(ctx context.Context, group string) ([]Article, error)
+	Retrieve(ctx context.Context, id string) ([]byte, error)
+}
+
+// Group is a newsgroup.
+type Group struct {
+	Name        string
+	Description string
+}
+
+// Article is the overview of an article of a newsgroup.
+type Article struct {
+	// ID identifies the article to the reader.
+	ID string
+
+	// MessageID is the article's message-id, including the angle brackets.
+	MessageID string
+
+	Subject string
+	From    string
+	Date    time.Time
+
+	// References are the message-ids of the articles the article replies to, which news readers thread articles by.
+	References string
+
+	// Size is the size of the article, which may be an estimate.
+	Size int
+}
+
+// Server serves NNTP clients, each connection in its own goroutine.
+type Server struct {
+	backend      Backend
+	panicHandler async.PanicHandler
+	log          *logrus.Entry
+
+	lock   sync.Mutex
+	conns  map[net.Conn]struct{}
+	closed bool
+}
+
+// NewServer returns a server signing in clients with the given backend.
+func NewServer(backend Backend, panicHandler async.PanicHandler) *Server {
+	return &Server{
+		backend:      backend,
+		panicHandler: panicHandler,
+		log:          logrus.WithField("pkg", "nntp"),
+		conns:        make(map[net.Conn]struct{}),
+	}
+}
+
+// Serve accepts the connections of the listener until it or the server is closed.
+func (s *Server) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if s.isClosed() {
+				return nil
+			}
+
+			return err
+		}
+
+		if !s.track(conn) {
+			_ = conn.Close()
+			return nil
+		}
+
+		go func() {
+			defer async.HandlePanic(s.panicHandler)
+			defer s.untrack(conn)
+
+			newSession(s, conn).serve()
+		}()
+	}
+}
+
+// Close closes the connections of the server; the listener has to be closed by the caller.
+func (s *Server) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.closed = true
+
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+
+	return nil
+}
+
+func (s *Server) isClosed() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.closed
+}
+
+func (s *Server) track(conn net.Conn) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.closed {
+		return false
+	}
+
+	s.conns[conn] = struct{}{}
+
+	return true
+}
+
+func (s *Server) untrack(conn net.Conn) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.conns, conn)
+
+	_ = conn.Close()
+}
@@ -0,0 +1,206 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package nntp
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/gluon/async"
+	"github.com/stretchr/testify/require"
+)
+
+type testBackend struct {
+	reader *testReader
+}
+
+func (b *testBackend) Login(_ context.Context, username string, password []byte) (Reader, error) {
+	if username != "user" || string(password) != "pass" {
+		return nil, ErrInvalidCredentials
+	}
+
+	return b.reader, nil
+}
+
+type testReader struct {
+	articles map[string][]Article
+	literals map[string]string
+}
+
+func (r *testReader) Groups(context.Context) ([]Group, error) {
+	return []Group{{Name: "lists.go", Description: "Lists/Go"}, {Name: "lists.empty", Description: "Lists/Empty"}}, nil
+}
+
+func (r *testReader) Articles(_ context.Context, group string) ([]Article, error) {
+	return r.articles[group], nil
+}
+
+func (r *testReader) Retrieve(_ context.Context, id string) ([]byte, error) {
+	return []byte(r.literals[id]), nil
+}
+
+type testClient struct {
+	t *testing.T
+	r *bufio.Reader
+	c net.Conn
+}
+
+func (c *testClient) cmd(cmd string) string {
+	_, err := c.c.Write([]byte(cmd + "\r\n"))
+	require.NoError(c.t, err)
+
+	return c.line()
+}
+
+func (c *testClient) line() string {
+	line, err := c.r.ReadString('\n')
+	require.NoError(c.t, err)
+
+	return strings.TrimSuffix(line, "\r\n")
+}
+
+func (c *testClient) lines() []string {
+	var lines []string
+
+	for line := c.line(); line != "."; line = c.line() {
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+func newTestClient(t *testing.T, backend Backend) *testClient {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := NewServer(backend, async.NoopPanicHandler{})
+
+	go func() { _ = server.Serve(l) }()
+
+	t.Cleanup(func() {
+		_ = server.Close()
+		_ = l.Close()
+	})
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = conn.Close() })
+
+	client := &testClient{t: t, r: bufio.NewReader(conn), c: conn}
+
+	require.True(t, strings.HasPrefix(client.line(), "201"))
+
+	return client
+}
+
+func TestServer(t *testing.T) {
+	date := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	number := int(date.Unix())
+
+	reader := &testReader{
+		articles: map[string][]Article{
+			"lists.go": {
+				{ID: "b", MessageID: "<b@example.com>", Subject: "Re: Hello", From: "bob@example.com", Date: date, References: "<a@example.com>", Size: 20},
+				{ID: "a", MessageID: "<a@example.com>", Subject: "Hello", From: "alice@example.com", Date: date, Size: 10},
+			},
+		},
+		literals: map[string]string{
+			"a": "Subject: Hello\r\n\r\n.dot\r\nbody\r\n",
+		},
+	}
+
+	client := newTestClient(t, &testBackend{reader: reader})
+
+	// Newsgroups can't be accessed before signing in.
+	require.Equal(t, "480 authentication required", client.cmd("GROUP lists.go"))
+
+	require.Equal(t, "381 password required", client.cmd("AUTHINFO USER user"))
+	require.Equal(t, "481 invalid username or password", client.cmd("AUTHINFO PASS wrong"))
+	require.Equal(t, "381 password required", client.cmd("AUTHINFO USER user"))
+	require.Equal(t, "281 authentication accepted", client.cmd("AUTHINFO PASS pass"))
+
+	require.Equal(t, "215 list of newsgroups follows", client.cmd("LIST"))
+	require.Equal(t, []string{"lists.go " + itoa(number+1) + " " + itoa(number) + " n", "lists.empty 0 1 n"}, client.lines())
+
+	require.Equal(t, "215 list of newsgroups follows", client.cmd("LIST NEWSGROUPS *.go"))
+	require.Equal(t, []string{"lists.go\tLists/Go"}, client.lines())
+
+	require.Equal(t, "411 no such newsgroup", client.cmd("GROUP lists.nope"))
+	require.Equal(t, "211 2 "+itoa(number)+" "+itoa(number+1)+" lists.go", client.cmd("GROUP lists.go"))
+
+	// Articles of the same date are numbered by ID, and replies reference the articles they reply to.
+	require.Equal(t, "224 overview information follows", client.cmd("OVER "+itoa(number)+"-"))
+	require.Equal(t, []string{
+		itoa(number) + "\tHello\talice@example.com\tMon, 01 Jan 2024 00:00:00 +0000\t<a@example.com>\t\t10\t",
+		itoa(number+1) + "\tRe: Hello\tbob@example.com\tMon, 01 Jan 2024 00:00:00 +0000\t<b@example.com>\t<a@example.com>\t20\t",
+	}, client.lines())
+
+	require.Equal(t, "220 "+itoa(number)+" <a@example.com>", client.cmd("ARTICLE"))
+	require.Equal(t, []string{"Subject: Hello", "", "..dot", "body"}, client.lines())
+
+	require.Equal(t, "221 "+itoa(number)+" <a@example.com>", client.cmd("HEAD <a@example.com>"))
+	require.Equal(t, []string{"Subject: Hello"}, client.lines())
+
+	require.Equal(t, "222 "+itoa(number)+" <a@example.com>", client.cmd("BODY "+itoa(number)))
+	require.Equal(t, []string{"..dot", "body"}, client.lines())
+
+	require.Equal(t, "223 "+itoa(number+1)+" <b@example.com>", client.cmd("NEXT"))
+	require.Equal(t, "421 no next article", client.cmd("NEXT"))
+	require.Equal(t, "423 no article with that number", client.cmd("STAT 1"))
+	require.Equal(t, "430 no such article", client.cmd("STAT <nope@example.com>"))
+
+	require.Equal(t, "440 posting not permitted", client.cmd("POST"))
+	require.Equal(t, "205 bye", client.cmd("QUIT"))
+}
+
+func TestNumberArticles(t *testing.T) {
+	date := time.Unix(1000, 0)
+
+	articles := numberArticles([]Article{
+		{ID: "c", Date: date.Add(5 * time.Second)},
+		{ID: "b", Date: date},
+		{ID: "a", Date: date},
+		{ID: "d", Date: date.Add(time.Minute)},
+	})
+
+	// Articles are numbered by date, bumped past the previous ones.
+	require.Equal(t, []string{"a", "b", "c"}, []string{articles[0].ID, articles[1].ID, articles[2].ID})
+	require.Equal(t, []int{1000, 1001, 1005, 1060}, []int{articles[0].number, articles[1].number, articles[2].number, articles[3].number})
+
+	// Removing older articles doesn't renumber the newer ones.
+	articles = numberArticles([]Article{{ID: "d", Date: date.Add(time.Minute)}, {ID: "c", Date: date.Add(5 * time.Second)}})
+	require.Equal(t, []int{1005, 1060}, []int{articles[0].number, articles[1].number})
+}
+
+func TestMatchWildmat(t *testing.T) {
+	require.True(t, matchWildmat("*", "lists.go"))
+	require.True(t, matchWildmat("lists.*", "lists.go"))
+	require.False(t, matchWildmat("lists.*,!lists.go", "lists.go"))
+	require.True(t, matchWildmat("!lists.*,lists.go", "lists.go"))
+	require.False(t, matchWildmat("news.*", "lists.go"))
+}
+
+func itoa(n int) string {
+	return strconv.Itoa(n)
+}
@@ -0,0 +1,730 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package nntp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// idleTimeout is how long an idle client stays connected; RFC 3977 requires at least three minutes.
+	idleTimeout = 10 * time.Minute
+
+	// maxLineLength bounds the length of command lines, which RFC 3977 limits to 512 octets.
+	maxLineLength = 1024
+)
+
+var errLineTooLong = errors.New("line too long")
+
+// overviewFormat lists the fields of the overview lines, in order.
+var overviewFormat = []string{"Subject:", "From:", "Date:", "Message-ID:", "References:", ":bytes", ":lines"}
+
+type session struct {
+	server *Server
+	conn   net.Conn
+	r      *bufio.Reader
+	w      *bufio.Writer
+
+	username string
+	reader   Reader
+
+	// groups are the newsgroups of the reader, listed on first use.
+	groups []Group
+
+	// articles caches the articles of the newsgroups the client opened during the session.
+	articles map[string][]numberedArticle
+
+	group   string
+	current int
+}
+
+func newSession(server *Server, conn net.Conn) *session {
+	return &session{
+		server:   server,
+		conn:     conn,
+		r:        bufio.NewReaderSize(conn, maxLineLength),
+		w:        bufio.NewWriter(conn),
+		articles: make(map[string][]numberedArticle),
+		current:  -1,
+	}
+}
+
+func (s *session) serve() {
+	s.reply("201 Proton Mail Bridge NNTP server ready, posting prohibited")
+
+	for {
+		if err := s.w.Flush(); err != nil {
+			return
+		}
+
+		_ = s.conn.SetReadDeadline(time.Now().Add(idleTimeout))
+
+		line, err := s.readLine()
+		if errors.Is(err, errLineTooLong) {
+			s.reply("501 line too long")
+			continue
+		} else if err != nil {
+			return
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			s.reply("500 unknown command")
+			continue
+		}
+
+		if quit := s.handle(strings.ToUpper(fields[0]), fields[1:]); quit {
+			_ = s.w.Flush()
+			return
+		}
+	}
+}
+
+func (s *session) readLine() (string, error) {
+	line, err := s.r.ReadSlice('\n')
+	if errors.Is(err, bufio.ErrBufferFull) {
+		// Skip the rest of the line.
+		for errors.Is(err, bufio.ErrBufferFull) {
+			_, err = s.r.ReadSlice('\n')
+		}
+
+		if err != nil {
+			return "", err
+		}
+
+		return "", errLineTooLong
+	} else if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(string(line), "\r\n"), nil
+}
+
+// handle handles a command, returning whether the session is over.
+func (s *session) handle(cmd string, args []string) bool {
+	switch cmd {
+	case "QUIT":
+		s.reply("205 bye")
+		return true
+
+	case "CAPABILITIES":
+		s.handleCapabilities()
+
+	case "MODE":
+		if len(args) == 1 && strings.EqualFold(args[0], "READER") {
+			s.reply("201 posting prohibited")
+		} else {
+			s.reply("501 unknown mode")
+		}
+
+	case "DATE":
+		s.reply("111 %s", time.Now().UTC().Format("20060102150405"))
+
+	case "AUTHINFO":
+		s.handleAuthinfo(args)
+
+	case "POST", "IHAVE":
+		s.reply("440 posting not permitted")
+
+	default:
+		if s.reader == nil {
+			s.reply("480 authentication required")
+			return false
+		}
+
+		s.handleReader(cmd, args)
+	}
+
+	return false
+}
+
+func (s *session) handleReader(cmd string, args []string) {
+	switch cmd {
+	case "LIST":
+		s.handleList(args)
+
+	case "NEWGROUPS":
+		// Newsgroups are the folders the user selected, which clients find with LIST.
+		s.reply("231 list of new newsgroups follows")
+		s.reply(".")
+
+	case "GROUP":
+		s.handleGroup(args)
+
+	case "LISTGROUP":
+		s.handleListGroup(args)
+
+	case "NEXT":
+		s.handleMove(1, "421 no next article")
+
+	case "LAST":
+		s.handleMove(-1, "422 no previous article")
+
+	case "ARTICLE":
+		s.handleArticle(args, 220, true, true)
+
+	case "HEAD":
+		s.handleArticle(args, 221, true, false)
+
+	case "BODY":
+		s.handleArticle(args, 222, false, true)
+
+	case "STAT":
+		s.handleArticle(args, 223, false, false)
+
+	case "OVER", "XOVER":
+		s.handleOver(args)
+
+	default:
+		s.reply("500 unknown command")
+	}
+}
+
+func (s *session) handleCapabilities() {
+	s.reply("101 capability list follows")
+	s.reply("VERSION 2")
+	s.reply("READER")
+
+	if s.reader == nil {
+		s.reply("AUTHINFO USER")
+	} else {
+		s.reply("OVER")
+		s.reply("LIST ACTIVE NEWSGROUPS OVERVIEW.FMT")
+	}
+
+	s.reply("IMPLEMENTATION Proton Mail Bridge")
+	s.reply(".")
+}
+
+func (s *session) handleAuthinfo(args []string) {
+	if s.reader != nil {
+		s.reply("502 already authenticated")
+		return
+	}
+
+	if len(args) != 2 {
+		s.reply("501 syntax error")
+		return
+	}
+
+	switch strings.ToUpper(args[0]) {
+	case "USER":
+		s.username = args[1]
+		s.reply("381 password required")
+
+	case "PASS":
+		if s.username == "" {
+			s.reply("482 AUTHINFO USER first")
+			return
+		}
+
+		username := s.username
+		s.username = ""
+
+		reader, err := s.server.backend.Login(context.Background(), username, []byte(args[1]))
+		if err != nil {
+			s.server.log.WithError(err).Warn("Login failed")
+			s.reply("481 invalid username or password")
+
+			return
+		}
+
+		s.reader = reader
+		s.reply("281 authentication accepted")
+
+	default:
+		s.reply("501 unknown AUTHINFO mechanism")
+	}
+}
+
+func (s *session) getGroups() ([]Group, bool) {
+	if s.groups == nil {
+		groups, err := s.reader.Groups(context.Background())
+		if err != nil {
+			s.server.log.WithError(err).Error("Failed to list newsgroups")
+			s.reply("403 failed to list newsgroups")
+
+			return nil, false
+		}
+
+		s.groups = groups
+	}
+
+	return s.groups, true
+}
+
+// getArticles returns the articles of the given newsgroup, replying with an error if it doesn't exist.
+func (s *session) getArticles(name string) ([]numberedArticle, bool) {
+	if articles, ok := s.articles[name]; ok {
+		return articles, true
+	}
+
+	groups, ok := s.getGroups()
+	if !ok {
+		return nil, false
+	}
+
+	if !hasGroup(groups, name) {
+		s.reply("411 no such newsgroup")
+		return nil, false
+	}
+
+	articles, err := s.reader.Articles(context.Background(), name)
+	if err != nil {
+		s.server.log.WithError(err).Error("Failed to list articles")
+		s.reply("403 failed to list articles")
+
+		return nil, false
+	}
+
+	s.articles[name] = numberArticles(articles)
+
+	return s.articles[name], true
+}
+
+func (s *session) handleList(args []string) {
+	keyword := "ACTIVE"
+
+	if len(args) > 0 {
+		keyword = strings.ToUpper(args[0])
+	}
+
+	wildmat := "*"
+
+	if len(args) > 1 {
+		wildmat = args[1]
+	}
+
+	switch keyword {
+	case "OVERVIEW.FMT":
+		s.reply("215 order of fields in overview database")
+
+		for _, field := range overviewFormat {
+			s.reply("%s", field)
+		}
+
+		s.reply(".")
+
+	case "ACTIVE", "NEWSGROUPS":
+		groups, ok := s.getGroups()
+		if !ok {
+			return
+		}
+
+		lines := make([]string, 0, len(groups))
+
+		for _, group := range groups {
+			if !matchWildmat(wildmat, group.Name) {
+				continue
+			}
+
+			if keyword == "NEWSGROUPS" {
+				lines = append(lines, group.Name+"\t"+group.Description)
+				continue
+			}
+
+			articles, ok := s.getArticles(group.Name)
+			if !ok {
+				return
+			}
+
+			low, high := getWatermarks(articles)
+
+			lines = append(lines, fmt.Sprintf("%s %d %d n", group.Name, high, low))
+		}
+
+		s.reply("215 list of newsgroups follows")
+
+		for _, line := range lines {
+			s.reply("%s", line)
+		}
+
+		s.reply(".")
+
+	default:
+		s.reply("501 unknown LIST keyword")
+	}
+}
+
+func (s *session) handleGroup(args []string) {
+	if len(args) != 1 {
+		s.reply("501 syntax error")
+		return
+	}
+
+	articles, ok := s.selectGroup(args[0])
+	if !ok {
+		return
+	}
+
+	low, high := getWatermarks(articles)
+
+	s.reply("211 %d %d %d %s", len(articles), low, high, s.group)
+}
+
+func (s *session) handleListGroup(args []string) {
+	if len(args) == 0 && s.group == "" {
+		s.reply("412 no newsgroup selected")
+		return
+	}
+
+	name := s.group
+
+	if len(args) > 0 {
+		name = args[0]
+	}
+
+	articles, ok := s.selectGroup(name)
+	if !ok {
+		return
+	}
+
+	low, high := getWatermarks(articles)
+
+	first, last := 0, maxArticleNumber
+
+	if len(args) > 1 {
+		if first, last, ok = parseRange(args[1]); !ok {
+			s.reply("501 invalid range")
+			return
+		}
+	}
+
+	s.reply("211 %d %d %d %s list follows", len(articles), low, high, s.group)
+
+	for _, article := range articles {
+		if article.number >= first && article.number <= last {
+			s.reply("%d", article.number)
+		}
+	}
+
+	s.reply(".")
+}
+
+func (s *session) selectGroup(name string) ([]numberedArticle, bool) {
+	articles, ok := s.getArticles(name)
+	if !ok {
+		return nil, false
+	}
+
+	s.group = name
+	s.current = -1
+
+	if len(articles) > 0 {
+		s.current = 0
+	}
+
+	return articles, true
+}
+
+func (s *session) handleMove(delta int, noArticle string) {
+	if s.group == "" {
+		s.reply("412 no newsgroup selected")
+		return
+	}
+
+	if s.current < 0 {
+		s.reply("420 current article number is invalid")
+		return
+	}
+
+	articles := s.articles[s.group]
+
+	if next := s.current + delta; next < 0 || next >= len(articles) {
+		s.reply("%s", noArticle)
+	} else {
+		s.current = next
+		s.reply("223 %d %s", articles[next].number, articles[next].MessageID)
+	}
+}
+
+// getArticle returns the article the arguments refer to, by message-id, number or the current one,
+// along with its number in the selected newsgroup or 0 if it isn't in it.
+func (s *session) getArticle(args []string) (numberedArticle, int, bool) {
+	if len(args) > 0 && strings.HasPrefix(args[0], "<") {
+		for name, articles := range s.articles {
+			for _, article := range articles {
+				if article.MessageID != args[0] {
+					continue
+				}
+
+				if name != s.group {
+					return article, 0, true
+				}
+
+				return article, article.number, true
+			}
+		}
+
+		s.reply("430 no such article")
+
+		return numberedArticle{}, 0, false
+	}
+
+	if s.group == "" {
+		s.reply("412 no newsgroup selected")
+		return numberedArticle{}, 0, false
+	}
+
+	articles := s.articles[s.group]
+
+	if len(args) == 0 {
+		if s.current < 0 {
+			s.reply("420 current article number is invalid")
+			return numberedArticle{}, 0, false
+		}
+
+		return articles[s.current], articles[s.current].number, true
+	}
+
+	number, err := strconv.Atoi(args[0])
+	if err != nil {
+		s.reply("501 invalid article number")
+		return numberedArticle{}, 0, false
+	}
+
+	for idx, article := range articles {
+		if article.number == number {
+			s.current = idx
+			return article, number, true
+		}
+	}
+
+	s.reply("423 no article with that number")
+
+	return numberedArticle{}, 0, false
+}
+
+func (s *session) handleArticle(args []string, code int, withHead, withBody bool) {
+	article, number, ok := s.getArticle(args)
+	if !ok {
+		return
+	}
+
+	if !withHead && !withBody {
+		s.reply("%d %d %s", code, number, article.MessageID)
+		return
+	}
+
+	literal, err := s.reader.Retrieve(context.Background(), article.ID)
+	if err != nil {
+		s.server.log.WithError(err).Error("Failed to retrieve article")
+		s.reply("403 failed to retrieve article")
+
+		return
+	}
+
+	lines := splitLines(literal)
+
+	// The header ends at the first empty line.
+	headerEnd := len(lines)
+
+	for idx, line := range lines {
+		if len(line) == 0 {
+			headerEnd = idx
+			break
+		}
+	}
+
+	switch {
+	case withHead && !withBody:
+		lines = lines[:headerEnd]
+
+	case !withHead && withBody:
+		if headerEnd < len(lines) {
+			lines = lines[headerEnd+1:]
+		} else {
+			lines = nil
+		}
+	}
+
+	s.reply("%d %d %s", code, number, article.MessageID)
+	s.writeLines(lines)
+}
+
+func (s *session) handleOver(args []string) {
+	if len(args) > 0 && strings.HasPrefix(args[0], "<") {
+		article, _, ok := s.getArticle(args)
+		if !ok {
+			return
+		}
+
+		s.reply("224 overview information follows")
+		s.reply("0\t%s", formatOverview(article.Article))
+		s.reply(".")
+
+		return
+	}
+
+	if s.group == "" {
+		s.reply("412 no newsgroup selected")
+		return
+	}
+
+	var first, last int
+
+	if len(args) == 0 {
+		if s.current < 0 {
+			s.reply("420 current article number is invalid")
+			return
+		}
+
+		first = s.articles[s.group][s.current].number
+		last = first
+	} else {
+		var ok bool
+
+		if first, last, ok = parseRange(args[0]); !ok {
+			s.reply("501 invalid range")
+			return
+		}
+	}
+
+	var lines []string
+
+	for _, article := range s.articles[s.group] {
+		if article.number >= first && article.number <= last {
+			lines = append(lines, fmt.Sprintf("%d\t%s", article.number, formatOverview(article.Article)))
+		}
+	}
+
+	if len(lines) == 0 {
+		s.reply("423 no articles in that range")
+		return
+	}
+
+	s.reply("224 overview information follows")
+
+	for _, line := range lines {
+		s.reply("%s", line)
+	}
+
+	s.reply(".")
+}
+
+func (s *session) reply(format string, args ...any) {
+	_, _ = fmt.Fprintf(s.w, format+"\r\n", args...)
+}
+
+// writeLines writes the lines of a multi-line response, dot-stuffing those starting with the termination octet.
+func (s *session) writeLines(lines [][]byte) {
+	for _, line := range lines {
+		if len(line) > 0 && line[0] == '.' {
+			_ = s.w.WriteByte('.')
+		}
+
+		_, _ = s.w.Write(line)
+		_, _ = s.w.WriteString("\r\n")
+	}
+
+	_, _ = s.w.WriteString(".\r\n")
+}
+
+func hasGroup(groups []Group, name string) bool {
+	for _, group := range groups {
+		if group.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getWatermarks returns the lowest and highest article numbers of a newsgroup; the high one is below the low one if it's empty.
+func getWatermarks(articles []numberedArticle) (int, int) {
+	if len(articles) == 0 {
+		return 1, 0
+	}
+
+	return articles[0].number, articles[len(articles)-1].number
+}
+
+// parseRange parses a range of article numbers: a number, "n-" or "n-m".
+func parseRange(arg string) (int, int, bool) {
+	firstArg, lastArg, isRange := strings.Cut(arg, "-")
+
+	first, err := strconv.Atoi(firstArg)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	if !isRange {
+		return first, first, true
+	}
+
+	if lastArg == "" {
+		return first, maxArticleNumber, true
+	}
+
+	last, err := strconv.Atoi(lastArg)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	return first, last, true
+}
+
+// formatOverview returns the fields of an overview line following the article number.
+// The number of lines isn't known without downloading the article, so that field is left empty.
+func formatOverview(article Article) string {
+	fields := []string{
+		article.Subject,
+		article.From,
+		article.Date.Format(time.RFC1123Z),
+		article.MessageID,
+		article.References,
+		strconv.Itoa(article.Size),
+		"",
+	}
+
+	for idx, field := range fields {
+		fields[idx] = strings.Map(func(r rune) rune {
+			if r == '\t' || r == '\r' || r == '\n' {
+				return ' '
+			}
+
+			return r
+		}, field)
+	}
+
+	return strings.Join(fields, "\t")
+}
+
+// splitLines splits a literal into its lines, without their line endings.
+func splitLines(literal []byte) [][]byte {
+	lines := bytes.Split(literal, []byte("\n"))
+
+	// A literal ending with a line ending doesn't have an extra empty line.
+	if len(lines) > 0 && len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+
+	for i, line := range lines {
+		lines[i] = bytes.TrimSuffix(line, []byte("\r"))
+	}
+
+	return lines
+}
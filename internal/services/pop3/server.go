@@ -0,0 +1,158 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package pop3 implements a minimal POP3 server (RFC 1939), with the CAPA, UIDL, TOP and STLS extensions,
+// for devices which can't speak IMAP.
+package pop3
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"sync"
+
+	"github.com/ProtonMail/gluon/async"
+	"github.com/sirupsen/logrus"
+)
+
+var ErrInvalidCredentials = errors.New("invalid username or password")
+
+// Backend signs in POP3 clients.
+type Backend interface {
+	// Login returns the maildrop of the account the given credentials belong to.
+	Login(ctx context.Context, username string, password []byte) (Mailbox, error)
+}
+
+// Mailbox is the maildrop of a signed in client. It's listed once when the session starts,
+// the messages keeping their numbers until the client quits.
+type Mailbox interface {
+	List(ctx context.Context) ([]Message, error)
+	Retrieve(ctx context.Context, id string) ([]byte, error)
+
+	// Delete removes the messages the client marked as deleted, once it quits.
+	Delete(ctx context.Context, ids []string) error
+}
+
+// Message is a message of a maildrop.
+type Message struct {
+	// ID identifies the message to the mailbox.
+	ID string
+
+	// UID is the unique ID reported by UIDL, which must not change between sessions
+	// and be 1 to 70 printable ASCII characters long.
+	UID string
+
+	// Size is the size of the message, which may be an estimate as clients only use it to show progress.
+	Size int
+}
+
+// Server serves POP3 clients, each connection in its own goroutine.
+type Server struct {
+	backend      Backend
+	tlsConfig    *tls.Config
+	requireTLS   bool
+	panicHandler async.PanicHandler
+	log          *logrus.Entry
+
+	lock   sync.Mutex
+	conns  map[net.Conn]struct{}
+	closed bool
+}
+
+// NewServer returns a server signing in clients with the given backend.
+// Clients can upgrade plaintext connections with STLS if tlsConfig is set; if requireTLS is set, they must do so
+// before signing in.
+func NewServer(backend Backend, tlsConfig *tls.Config, requireTLS bool, panicHandler async.PanicHandler) *Server {
+	return &Server{
+		backend:      backend,
+		tlsConfig:    tlsConfig,
+		requireTLS:   requireTLS,
+		panicHandler: panicHandler,
+		log:          logrus.WithField("pkg", "pop3"),
+		conns:        make(map[net.Conn]struct{}),
+	}
+}
+
+// Serve accepts the connections of the listener until it or the server is closed.
+func (s *Server) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if s.isClosed() {
+				return nil
+			}
+
+			return err
+		}
+
+		if !s.track(conn) {
+			_ = conn.Close()
+			return nil
+		}
+
+		go func() {
+			defer async.HandlePanic(s.panicHandler)
+			defer s.untrack(conn)
+
+			newSession(s, conn).serve()
+		}()
+	}
+}
+
+// Close closes the connections of the server; the listener has to be closed by the caller.
+func (s *Server) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.closed = true
+
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+
+	return nil
+}
+
+func (s *Server) isClosed() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.closed
+}
+
+func (s *Server) track(conn net.Conn) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.closed {
+		return false
+	}
+
+	s.conns[conn] = struct{}{}
+
+	return true
+}
+
+func (s *Server) untrack(conn net.Conn) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.conns, conn)
+
+	_ = conn.Close()
+}
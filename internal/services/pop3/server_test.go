@@ -0,0 +1,179 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package pop3
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/gluon/async"
+	"github.com/stretchr/testify/require"
+)
+
+type testBackend struct {
+	mailbox *testMailbox
+}
+
+func (b *testBackend) Login(_ context.Context, username string, password []byte) (Mailbox, error) {
+	if username != "user" || string(password) != "pass" {
+		return nil, ErrInvalidCredentials
+	}
+
+	return b.mailbox, nil
+}
+
+type testMailbox struct {
+	messages map[string]string
+	deleted  []string
+}
+
+func (m *testMailbox) List(context.Context) ([]Message, error) {
+	return []Message{
+		{ID: "a", UID: "uid-a", Size: len(m.messages["a"])},
+		{ID: "b", UID: "uid-b", Size: len(m.messages["b"])},
+	}, nil
+}
+
+func (m *testMailbox) Retrieve(_ context.Context, id string) ([]byte, error) {
+	return []byte(m.messages[id]), nil
+}
+
+func (m *testMailbox) Delete(_ context.Context, ids []string) error {
+	m.deleted = append(m.deleted, ids...)
+	return nil
+}
+
+type testClient struct {
+	t *testing.T
+	r *bufio.Reader
+	c net.Conn
+}
+
+func (c *testClient) cmd(cmd string) string {
+	_, err := c.c.Write([]byte(cmd + "\r\n"))
+	require.NoError(c.t, err)
+
+	return c.line()
+}
+
+func (c *testClient) line() string {
+	line, err := c.r.ReadString('\n')
+	require.NoError(c.t, err)
+
+	return strings.TrimSuffix(line, "\r\n")
+}
+
+func (c *testClient) lines() []string {
+	var lines []string
+
+	for line := c.line(); line != "."; line = c.line() {
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+func newTestClient(t *testing.T, backend Backend) *testClient {
+	return newTestClientWithServer(t, NewServer(backend, nil, false, async.NoopPanicHandler{}))
+}
+
+func newTestClientWithServer(t *testing.T, server *Server) *testClient {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	go func() { _ = server.Serve(l) }()
+
+	t.Cleanup(func() {
+		_ = server.Close()
+		_ = l.Close()
+	})
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = conn.Close() })
+
+	client := &testClient{t: t, r: bufio.NewReader(conn), c: conn}
+
+	require.True(t, strings.HasPrefix(client.line(), "+OK"))
+
+	return client
+}
+
+func TestServer(t *testing.T) {
+	mailbox := &testMailbox{messages: map[string]string{
+		"a": "Subject: a\r\n\r\nline 1\r\n.line 2\r\nline 3\r\n",
+		"b": "Subject: b\n\nbody\n",
+	}}
+
+	client := newTestClient(t, &testBackend{mailbox: mailbox})
+
+	// Messages can't be accessed before signing in.
+	require.Equal(t, "-ERR not authenticated", client.cmd("STAT"))
+
+	require.Equal(t, "+OK capability list follows", client.cmd("CAPA"))
+	require.NotContains(t, client.lines(), "STLS")
+
+	// Bad credentials are rejected.
+	require.Equal(t, "+OK", client.cmd("USER user"))
+	require.Equal(t, "-ERR [AUTH] invalid username or password", client.cmd("PASS wrong"))
+
+	require.Equal(t, "+OK", client.cmd("USER user"))
+	require.Equal(t, "+OK maildrop has 2 messages (56 octets)", client.cmd("PASS pass"))
+
+	require.Equal(t, "+OK 2 56", client.cmd("STAT"))
+
+	require.Equal(t, "+OK", client.cmd("UIDL"))
+	require.Equal(t, []string{"1 uid-a", "2 uid-b"}, client.lines())
+	require.Equal(t, "+OK 2 uid-b", client.cmd("UIDL 2"))
+
+	// Lines starting with the termination octet are byte-stuffed.
+	require.Equal(t, "+OK 39 octets", client.cmd("RETR 1"))
+	require.Equal(t, []string{"Subject: a", "", "line 1", "..line 2", "line 3"}, client.lines())
+
+	// Bare line feeds are sent as CRLF.
+	require.Equal(t, "+OK", client.cmd("TOP 2 0"))
+	require.Equal(t, []string{"Subject: b", ""}, client.lines())
+
+	// Deleted messages are hidden until reset.
+	require.Equal(t, "+OK message 1 deleted", client.cmd("DELE 1"))
+	require.Equal(t, "-ERR no such message", client.cmd("RETR 1"))
+	require.Equal(t, "+OK 1 17", client.cmd("STAT"))
+	require.Equal(t, "+OK", client.cmd("RSET"))
+	require.Equal(t, "+OK 2 56", client.cmd("STAT"))
+
+	// Messages are only deleted when the client quits.
+	require.Equal(t, "+OK message 2 deleted", client.cmd("DELE 2"))
+	require.Empty(t, mailbox.deleted)
+	require.Equal(t, "+OK bye", client.cmd("QUIT"))
+	require.Equal(t, []string{"b"}, mailbox.deleted)
+}
+
+func TestServer_RequireTLS(t *testing.T) {
+	client := newTestClientWithServer(t, NewServer(&testBackend{mailbox: &testMailbox{}}, nil, true, async.NoopPanicHandler{}))
+
+	// Signing in isn't offered nor allowed on plaintext connections.
+	require.Equal(t, "+OK capability list follows", client.cmd("CAPA"))
+	require.NotContains(t, client.lines(), "USER")
+
+	require.Equal(t, "-ERR [AUTH] plaintext authentication disallowed, use STLS first", client.cmd("USER user"))
+	require.Equal(t, "-ERR USER first", client.cmd("PASS pass"))
+}
@@ -0,0 +1,488 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package pop3
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// idleTimeout is how long an idle client stays connected, the minimum RFC 1939 allows.
+	idleTimeout = 10 * time.Minute
+
+	// maxLineLength bounds the length of command lines, which RFC 2449 limits to 255 octets.
+	maxLineLength = 1024
+)
+
+var errLineTooLong = errors.New("line too long")
+
+type session struct {
+	server *Server
+	conn   net.Conn
+	r      *bufio.Reader
+	w      *bufio.Writer
+	isTLS  bool
+
+	username string
+	mailbox  Mailbox
+	messages []Message
+	deleted  map[int]bool
+}
+
+func newSession(server *Server, conn net.Conn) *session {
+	_, isTLS := conn.(*tls.Conn)
+
+	return &session{
+		server:  server,
+		conn:    conn,
+		r:       bufio.NewReaderSize(conn, maxLineLength),
+		w:       bufio.NewWriter(conn),
+		isTLS:   isTLS,
+		deleted: make(map[int]bool),
+	}
+}
+
+func (s *session) serve() {
+	s.reply("+OK Proton Mail Bridge POP3 server ready")
+
+	for {
+		if err := s.w.Flush(); err != nil {
+			return
+		}
+
+		_ = s.conn.SetReadDeadline(time.Now().Add(idleTimeout))
+
+		line, err := s.readLine()
+		if errors.Is(err, errLineTooLong) {
+			s.reply("-ERR line too long")
+			continue
+		} else if err != nil {
+			return
+		}
+
+		cmd, arg, _ := strings.Cut(line, " ")
+
+		if quit := s.handle(strings.ToUpper(cmd), arg); quit {
+			_ = s.w.Flush()
+			return
+		}
+	}
+}
+
+func (s *session) readLine() (string, error) {
+	line, err := s.r.ReadSlice('\n')
+	if errors.Is(err, bufio.ErrBufferFull) {
+		// Skip the rest of the line.
+		for errors.Is(err, bufio.ErrBufferFull) {
+			_, err = s.r.ReadSlice('\n')
+		}
+
+		if err != nil {
+			return "", err
+		}
+
+		return "", errLineTooLong
+	} else if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(string(line), "\r\n"), nil
+}
+
+// handle handles a command, returning whether the session is over.
+func (s *session) handle(cmd, arg string) bool {
+	switch cmd {
+	case "CAPA":
+		s.handleCapa()
+
+	case "QUIT":
+		s.handleQuit()
+		return true
+
+	case "NOOP":
+		if s.checkTransaction() {
+			s.reply("+OK")
+		}
+
+	case "STLS":
+		s.handleSTLS()
+
+	case "USER":
+		s.handleUser(arg)
+
+	case "PASS":
+		s.handlePass(arg)
+
+	case "STAT":
+		if s.checkTransaction() {
+			count, size := s.stat()
+			s.reply("+OK %d %d", count, size)
+		}
+
+	case "LIST":
+		s.handleList(arg, func(msg Message) string { return strconv.Itoa(msg.Size) })
+
+	case "UIDL":
+		s.handleList(arg, func(msg Message) string { return msg.UID })
+
+	case "RETR":
+		s.handleRetr(arg)
+
+	case "TOP":
+		s.handleTop(arg)
+
+	case "DELE":
+		s.handleDele(arg)
+
+	case "RSET":
+		if s.checkTransaction() {
+			s.deleted = make(map[int]bool)
+			s.reply("+OK")
+		}
+
+	default:
+		s.reply("-ERR unknown command")
+	}
+
+	return false
+}
+
+func (s *session) handleCapa() {
+	capabilities := []string{"UIDL", "TOP", "RESP-CODES", "AUTH-RESP-CODE", "PIPELINING"}
+
+	// USER isn't advertised while it would be refused, as RFC 2595 recommends.
+	if s.canLogin() {
+		capabilities = append([]string{"USER"}, capabilities...)
+	}
+
+	if s.canSTLS() {
+		capabilities = append(capabilities, "STLS")
+	}
+
+	s.reply("+OK capability list follows")
+
+	for _, capability := range capabilities {
+		s.reply("%s", capability)
+	}
+
+	s.reply(".")
+}
+
+func (s *session) handleQuit() {
+	if s.mailbox == nil {
+		s.reply("+OK bye")
+		return
+	}
+
+	// Only the UPDATE state removes the messages marked as deleted.
+	var ids []string
+
+	for idx, msg := range s.messages {
+		if s.deleted[idx] {
+			ids = append(ids, msg.ID)
+		}
+	}
+
+	if len(ids) > 0 {
+		if err := s.mailbox.Delete(context.Background(), ids); err != nil {
+			s.server.log.WithError(err).Error("Failed to delete messages")
+			s.reply("-ERR [SYS/TEMP] some deleted messages not removed")
+
+			return
+		}
+	}
+
+	s.reply("+OK bye")
+}
+
+// canLogin returns whether the client may sign in on this connection, which requires TLS if the server does.
+func (s *session) canLogin() bool {
+	return s.isTLS || !s.server.requireTLS
+}
+
+func (s *session) canSTLS() bool {
+	return !s.isTLS && s.server.tlsConfig != nil && s.mailbox == nil
+}
+
+func (s *session) handleSTLS() {
+	if !s.canSTLS() {
+		s.reply("-ERR STLS not available")
+		return
+	}
+
+	s.reply("+OK begin TLS negotiation")
+
+	if err := s.w.Flush(); err != nil {
+		return
+	}
+
+	tlsConn := tls.Server(s.conn, s.server.tlsConfig)
+
+	if err := tlsConn.Handshake(); err != nil {
+		s.server.log.WithError(err).Debug("TLS handshake failed")
+		_ = s.conn.Close()
+
+		return
+	}
+
+	s.conn = tlsConn
+	s.r = bufio.NewReaderSize(tlsConn, maxLineLength)
+	s.w = bufio.NewWriter(tlsConn)
+	s.isTLS = true
+	s.username = ""
+}
+
+func (s *session) handleUser(arg string) {
+	if s.mailbox != nil {
+		s.reply("-ERR already authenticated")
+		return
+	}
+
+	if !s.canLogin() {
+		s.reply("-ERR [AUTH] plaintext authentication disallowed, use STLS first")
+		return
+	}
+
+	if arg == "" {
+		s.reply("-ERR missing username")
+		return
+	}
+
+	s.username = arg
+	s.reply("+OK")
+}
+
+func (s *session) handlePass(arg string) {
+	if s.mailbox != nil {
+		s.reply("-ERR already authenticated")
+		return
+	}
+
+	if s.username == "" {
+		s.reply("-ERR USER first")
+		return
+	}
+
+	username := s.username
+	s.username = ""
+
+	mailbox, err := s.server.backend.Login(context.Background(), username, []byte(arg))
+	if err != nil {
+		s.server.log.WithError(err).Warn("Login failed")
+		s.reply("-ERR [AUTH] invalid username or password")
+
+		return
+	}
+
+	messages, err := mailbox.List(context.Background())
+	if err != nil {
+		s.server.log.WithError(err).Error("Failed to list messages")
+		s.reply("-ERR [SYS/TEMP] failed to list messages")
+
+		return
+	}
+
+	s.mailbox = mailbox
+	s.messages = messages
+
+	count, size := s.stat()
+	s.reply("+OK maildrop has %d messages (%d octets)", count, size)
+}
+
+func (s *session) checkTransaction() bool {
+	if s.mailbox == nil {
+		s.reply("-ERR not authenticated")
+		return false
+	}
+
+	return true
+}
+
+func (s *session) stat() (int, int) {
+	var count, size int
+
+	for idx, msg := range s.messages {
+		if !s.deleted[idx] {
+			count++
+			size += msg.Size
+		}
+	}
+
+	return count, size
+}
+
+// getMessage returns the index of the message with the given number, if it exists and wasn't deleted.
+func (s *session) getMessage(arg string) (int, bool) {
+	num, err := strconv.Atoi(arg)
+	if err != nil || num < 1 || num > len(s.messages) || s.deleted[num-1] {
+		s.reply("-ERR no such message")
+		return 0, false
+	}
+
+	return num - 1, true
+}
+
+func (s *session) handleList(arg string, fn func(Message) string) {
+	if !s.checkTransaction() {
+		return
+	}
+
+	if arg != "" {
+		if idx, ok := s.getMessage(arg); ok {
+			s.reply("+OK %d %s", idx+1, fn(s.messages[idx]))
+		}
+
+		return
+	}
+
+	s.reply("+OK")
+
+	for idx, msg := range s.messages {
+		if !s.deleted[idx] {
+			s.reply("%d %s", idx+1, fn(msg))
+		}
+	}
+
+	s.reply(".")
+}
+
+func (s *session) handleRetr(arg string) {
+	if !s.checkTransaction() {
+		return
+	}
+
+	idx, ok := s.getMessage(arg)
+	if !ok {
+		return
+	}
+
+	literal, err := s.retrieve(idx)
+	if err != nil {
+		return
+	}
+
+	s.reply("+OK %d octets", len(literal))
+	s.writeLines(splitLines(literal))
+}
+
+func (s *session) handleTop(arg string) {
+	if !s.checkTransaction() {
+		return
+	}
+
+	numArg, linesArg, _ := strings.Cut(arg, " ")
+
+	idx, ok := s.getMessage(numArg)
+	if !ok {
+		return
+	}
+
+	bodyLines, err := strconv.Atoi(linesArg)
+	if err != nil || bodyLines < 0 {
+		s.reply("-ERR invalid number of lines")
+		return
+	}
+
+	literal, err := s.retrieve(idx)
+	if err != nil {
+		return
+	}
+
+	lines := splitLines(literal)
+
+	// Keep the header, the empty line ending it and the requested number of body lines.
+	end := len(lines)
+
+	for i, line := range lines {
+		if len(line) == 0 {
+			end = i + 1 + bodyLines
+			break
+		}
+	}
+
+	if end > len(lines) {
+		end = len(lines)
+	}
+
+	s.reply("+OK")
+	s.writeLines(lines[:end])
+}
+
+func (s *session) retrieve(idx int) ([]byte, error) {
+	literal, err := s.mailbox.Retrieve(context.Background(), s.messages[idx].ID)
+	if err != nil {
+		s.server.log.WithError(err).Error("Failed to retrieve message")
+		s.reply("-ERR [SYS/TEMP] failed to retrieve message")
+	}
+
+	return literal, err
+}
+
+func (s *session) handleDele(arg string) {
+	if !s.checkTransaction() {
+		return
+	}
+
+	if idx, ok := s.getMessage(arg); ok {
+		s.deleted[idx] = true
+		s.reply("+OK message %d deleted", idx+1)
+	}
+}
+
+func (s *session) reply(format string, args ...any) {
+	_, _ = fmt.Fprintf(s.w, format+"\r\n", args...)
+}
+
+// writeLines writes the lines of a multi-line response, byte-stuffing those starting with the termination octet.
+func (s *session) writeLines(lines [][]byte) {
+	for _, line := range lines {
+		if len(line) > 0 && line[0] == '.' {
+			_ = s.w.WriteByte('.')
+		}
+
+		_, _ = s.w.Write(line)
+		_, _ = s.w.WriteString("\r\n")
+	}
+
+	_, _ = s.w.WriteString(".\r\n")
+}
+
+// splitLines splits a literal into its lines, without their line endings.
+func splitLines(literal []byte) [][]byte {
+	lines := bytes.Split(literal, []byte("\n"))
+
+	// A literal ending with a line ending doesn't have an extra empty line.
+	if len(lines) > 0 && len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+
+	for i, line := range lines {
+		lines[i] = bytes.TrimSuffix(line, []byte("\r"))
+	}
+
+	return lines
+}
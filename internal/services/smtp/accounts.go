@@ -19,18 +19,42 @@ package smtp
 
 import (
 	"context"
+	"errors"
 	"io"
 	"sync"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/services/useridentity"
 )
 
 type Accounts struct {
 	accountsLock sync.RWMutex
 	accounts     map[string]*Service
+
+	// suspended holds the credentials of accounts whose session expired, until they sign in again.
+	suspended map[string]*SuspendedAuth
+}
+
+// SuspendedAuth is enough of an account's identity to recognize its clients while it can't send.
+type SuspendedAuth struct {
+	identityState *useridentity.State
+	bridgePass    []byte
+}
+
+func NewSuspendedAuth(identityState *useridentity.State, bridgePass []byte) *SuspendedAuth {
+	return &SuspendedAuth{
+		identityState: identityState,
+		bridgePass:    bridgePass,
+	}
+}
+
+func (auth *SuspendedAuth) BridgePass() []byte {
+	return auth.bridgePass
 }
 
 func NewAccounts() *Accounts {
 	return &Accounts{
-		accounts: make(map[string]*Service),
+		accounts:  make(map[string]*Service),
+		suspended: make(map[string]*SuspendedAuth),
 	}
 }
 
@@ -39,6 +63,27 @@ func (s *Accounts) AddAccount(account *Service) {
 	defer s.accountsLock.Unlock()
 
 	s.accounts[account.UserID()] = account
+
+	delete(s.suspended, account.UserID())
+}
+
+// SuspendAccount removes the account, but keeps recognizing its clients so that they are told to retry later
+// rather than that their credentials are wrong.
+func (s *Accounts) SuspendAccount(account *Service, auth *SuspendedAuth) {
+	s.accountsLock.Lock()
+	defer s.accountsLock.Unlock()
+
+	delete(s.accounts, account.UserID())
+
+	s.suspended[account.UserID()] = auth
+}
+
+// RemoveSuspendedAccount forgets the credentials of the given suspended account, if any.
+func (s *Accounts) RemoveSuspendedAccount(userID string) {
+	s.accountsLock.Lock()
+	defer s.accountsLock.Unlock()
+
+	delete(s.suspended, userID)
 }
 
 func (s *Accounts) RemoveAccount(account *Service) {
@@ -62,6 +107,12 @@ func (s *Accounts) CheckAuth(user string, password []byte) (string, string, erro
 		return id, addrID, nil
 	}
 
+	for _, auth := range s.suspended {
+		if _, err := auth.identityState.CheckAuth(user, password, auth); err == nil {
+			return "", "", ErrAccountUnavailable
+		}
+	}
+
 	for _, service := range s.accounts {
 		service.telemetry.ReportSMTPAuthFailed(user)
 	}
@@ -84,3 +135,62 @@ func (s *Accounts) SendMail(ctx context.Context, userID, addrID, from string, to
 
 	return service.SendMail(ctx, addrID, from, to, r)
 }
+
+// resolveSender returns the account and address ID of the address messages relayed for LAN devices are sent from.
+func (s *Accounts) resolveSender(ctx context.Context, email string) (string, string, error) {
+	s.accountsLock.RLock()
+	defer s.accountsLock.RUnlock()
+
+	for id, service := range s.accounts {
+		addrID, err := service.resolveSender(ctx, email)
+		if errors.Is(err, ErrNoSuchSender) {
+			continue
+		}
+
+		return id, addrID, err
+	}
+
+	for _, auth := range s.suspended {
+		if _, err := auth.identityState.GetAddr(email); err == nil {
+			return "", "", ErrAccountUnavailable
+		}
+	}
+
+	return "", "", ErrNoSuchSender
+}
+
+// resolveRecipient returns the account an LMTP recipient belongs to and where messages delivered to it go.
+func (s *Accounts) resolveRecipient(ctx context.Context, rcpt string) (string, lmtpRoute, error) {
+	s.accountsLock.RLock()
+	defer s.accountsLock.RUnlock()
+
+	for id, service := range s.accounts {
+		route, err := service.resolveRecipient(ctx, rcpt)
+		if errors.Is(err, ErrNoSuchRecipient) {
+			continue
+		}
+
+		return id, route, err
+	}
+
+	for _, auth := range s.suspended {
+		if _, err := auth.identityState.GetAddr(rcpt); err == nil {
+			return "", lmtpRoute{}, ErrAccountUnavailable
+		}
+	}
+
+	return "", lmtpRoute{}, ErrNoSuchRecipient
+}
+
+// deliverMail imports a message delivered over LMTP into the given account.
+func (s *Accounts) deliverMail(ctx context.Context, userID string, route lmtpRoute, literal []byte) error {
+	s.accountsLock.RLock()
+	defer s.accountsLock.RUnlock()
+
+	service, ok := s.accounts[userID]
+	if !ok {
+		return ErrAccountUnavailable
+	}
+
+	return service.deliverMail(ctx, route, literal)
+}
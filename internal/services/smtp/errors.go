@@ -22,3 +22,7 @@ import "errors"
 var ErrInvalidRecipient = errors.New("invalid recipient")
 var ErrInvalidReturnPath = errors.New("invalid return path")
 var ErrNoSuchUser = errors.New("no such user")
+var ErrAccountUnavailable = errors.New("account is temporarily unavailable")
+var ErrNoSuchRecipient = errors.New("no such recipient")
+var ErrNoSuchFolder = errors.New("no such folder")
+var ErrNoSuchSender = errors.New("no such sender address")
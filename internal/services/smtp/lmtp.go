@@ -0,0 +1,152 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package smtp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/gopenpgp/v2/crypto"
+	"github.com/ProtonMail/proton-bridge/v3/internal/usertypes"
+	"github.com/ProtonMail/proton-bridge/v3/pkg/cpc"
+	"github.com/bradenaw/juniper/stream"
+)
+
+// lmtpSystemFolders are the system folders LMTP recipients can deliver to, by their lower case names.
+var lmtpSystemFolders = map[string]string{ //nolint:gochecknoglobals
+	"inbox":   proton.InboxLabel,
+	"archive": proton.ArchiveLabel,
+	"spam":    proton.SpamLabel,
+	"trash":   proton.TrashLabel,
+}
+
+// lmtpRoute is where a message delivered to an LMTP recipient is imported.
+type lmtpRoute struct {
+	addrID  string
+	labelID string
+}
+
+type resolveRecipientReq struct {
+	rcpt string
+}
+
+type deliverMailReq struct {
+	route   lmtpRoute
+	literal []byte
+}
+
+func (s *Service) resolveRecipient(ctx context.Context, rcpt string) (lmtpRoute, error) {
+	return cpc.SendTyped[lmtpRoute](ctx, s.cpc, &resolveRecipientReq{rcpt: rcpt})
+}
+
+func (s *Service) deliverMail(ctx context.Context, route lmtpRoute, literal []byte) error {
+	_, err := s.cpc.Send(ctx, &deliverMailReq{route: route, literal: literal})
+
+	return err
+}
+
+// lmtpResolveRecipient returns where messages delivered to the given LMTP recipient go. The recipient is one of
+// the user's addresses, optionally with the folder to deliver to as its subaddress, e.g. alice+Work/Reports@pm.me
+// or alice+Archive@pm.me; messages go to the inbox otherwise.
+func (s *Service) lmtpResolveRecipient(ctx context.Context, rcpt string) (lmtpRoute, error) {
+	addr, err := s.identityState.GetAddr(rcpt)
+	if err != nil {
+		return lmtpRoute{}, ErrNoSuchRecipient
+	}
+
+	labelID, err := s.getLMTPFolder(ctx, getSubaddress(rcpt))
+	if err != nil {
+		return lmtpRoute{}, err
+	}
+
+	return lmtpRoute{addrID: addr.ID, labelID: labelID}, nil
+}
+
+// getLMTPFolder returns the ID of the folder with the given path, as shown over IMAP with or without the Folders/ prefix.
+func (s *Service) getLMTPFolder(ctx context.Context, path string) (string, error) {
+	if path == "" {
+		return proton.InboxLabel, nil
+	}
+
+	if labelID, ok := lmtpSystemFolders[strings.ToLower(path)]; ok {
+		return labelID, nil
+	}
+
+	if prefix := "folders/"; len(path) > len(prefix) && strings.EqualFold(path[:len(prefix)], prefix) {
+		path = path[len(prefix):]
+	}
+
+	labels, err := s.client.GetLabels(ctx, proton.LabelTypeFolder)
+	if err != nil {
+		return "", fmt.Errorf("failed to get folders: %w", err)
+	}
+
+	for _, label := range labels {
+		if strings.EqualFold(strings.Join(label.Path, "/"), path) {
+			return label.ID, nil
+		}
+	}
+
+	return "", ErrNoSuchFolder
+}
+
+// lmtpDeliverMail imports the message as unread mail received by the route's address.
+func (s *Service) lmtpDeliverMail(ctx context.Context, route lmtpRoute, literal []byte) error {
+	addr, ok := s.identityState.GetAddrByID(route.addrID)
+	if !ok {
+		return ErrNoSuchRecipient
+	}
+
+	return usertypes.WithAddrKR(s.identityState.User, addr, s.keyPassProvider.KeyPass(), func(_, addrKR *crypto.KeyRing) error {
+		str, err := s.client.ImportMessages(ctx, addrKR, 1, 1, proton.ImportReq{
+			Metadata: proton.ImportMetadata{
+				AddressID: route.addrID,
+				LabelIDs:  []string{route.labelID},
+				Unread:    proton.Bool(true),
+				Flags:     proton.MessageFlagReceived,
+			},
+			Message: literal,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to prepare message for import: %w", err)
+		}
+
+		res, err := stream.Collect(ctx, str)
+		if err != nil {
+			return fmt.Errorf("failed to import message: %w", err)
+		}
+
+		s.log.WithField("messageID", res[0].MessageID).Info("Delivered LMTP message")
+
+		return nil
+	})
+}
+
+// getSubaddress returns the part of the address's local part after the first plus sign, if any.
+func getSubaddress(email string) string {
+	local, _, ok := strings.Cut(email, "@")
+	if !ok {
+		return ""
+	}
+
+	_, subaddress, _ := strings.Cut(local, "+")
+
+	return subaddress
+}
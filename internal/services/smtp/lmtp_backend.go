@@ -0,0 +1,154 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package smtp
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/emersion/go-smtp"
+	"github.com/sirupsen/logrus"
+)
+
+// LMTPBackend lets local delivery agents inject messages into the accounts' folders over LMTP.
+// Its sessions aren't authenticated, so it must only ever be reachable from the local host.
+type LMTPBackend struct {
+	accounts *Accounts
+}
+
+func NewLMTPBackend(accounts *Accounts) *LMTPBackend {
+	return &LMTPBackend{accounts: accounts}
+}
+
+type lmtpSession struct {
+	accounts *Accounts
+
+	rcpts []lmtpRecipient
+}
+
+type lmtpRecipient struct {
+	rcpt   string
+	userID string
+	route  lmtpRoute
+}
+
+func (be *LMTPBackend) NewSession(*smtp.Conn) (smtp.Session, error) {
+	return &lmtpSession{accounts: be.accounts}, nil
+}
+
+func (s *lmtpSession) AuthPlain(string, string) error {
+	return smtp.ErrAuthUnsupported
+}
+
+func (s *lmtpSession) Reset() {
+	s.rcpts = nil
+}
+
+func (s *lmtpSession) Logout() error {
+	s.Reset()
+	return nil
+}
+
+func (s *lmtpSession) Mail(string, *smtp.MailOptions) error {
+	return nil
+}
+
+// Rcpt accepts the recipients which are the address of a signed in account, possibly with a folder as subaddress.
+func (s *lmtpSession) Rcpt(to string) error {
+	userID, route, err := s.accounts.resolveRecipient(context.Background(), to)
+	if err != nil {
+		return toLMTPError(err)
+	}
+
+	s.rcpts = append(s.rcpts, lmtpRecipient{rcpt: to, userID: userID, route: route})
+
+	return nil
+}
+
+func (s *lmtpSession) Data(r io.Reader) error {
+	literal, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	for _, rcpt := range s.rcpts {
+		if err := s.deliver(rcpt, literal); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// LMTPData delivers the message to each recipient, reporting their status separately.
+func (s *lmtpSession) LMTPData(r io.Reader, status smtp.StatusCollector) error {
+	literal, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	for _, rcpt := range s.rcpts {
+		status.SetStatus(rcpt.rcpt, s.deliver(rcpt, literal))
+	}
+
+	return nil
+}
+
+func (s *lmtpSession) deliver(rcpt lmtpRecipient, literal []byte) error {
+	if err := s.accounts.deliverMail(context.Background(), rcpt.userID, rcpt.route, literal); err != nil {
+		logrus.WithField("pkg", "lmtp").WithError(err).Error("Delivering message failed.")
+
+		return toLMTPError(err)
+	}
+
+	return nil
+}
+
+// toLMTPError tells the delivery agent whether to give up on a recipient or to retry later.
+func toLMTPError(err error) error {
+	switch {
+	case errors.Is(err, ErrNoSuchRecipient):
+		return &smtp.SMTPError{
+			Code:         550,
+			EnhancedCode: smtp.EnhancedCode{5, 1, 1},
+			Message:      "no such recipient",
+		}
+
+	case errors.Is(err, ErrNoSuchFolder):
+		return &smtp.SMTPError{
+			Code:         550,
+			EnhancedCode: smtp.EnhancedCode{5, 1, 1},
+			Message:      "no such folder",
+		}
+
+	case errors.Is(err, ErrAccountUnavailable):
+		return &smtp.SMTPError{
+			Code:         450,
+			EnhancedCode: smtp.EnhancedCode{4, 2, 1},
+			Message:      "account temporarily unavailable, sign in to Bridge again",
+		}
+
+	default:
+		return &smtp.SMTPError{
+			Code:         451,
+			EnhancedCode: smtp.EnhancedCode{4, 3, 0},
+			Message:      "failed to deliver message, try again later",
+		}
+	}
+}
@@ -0,0 +1,32 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package smtp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetSubaddress(t *testing.T) {
+	require.Equal(t, "", getSubaddress("alice@pm.me"))
+	require.Equal(t, "Work", getSubaddress("alice+Work@pm.me"))
+	require.Equal(t, "Folders/Work/Reports", getSubaddress("alice+Folders/Work/Reports@pm.me"))
+	require.Equal(t, "a+b", getSubaddress("alice+a+b@pm.me"))
+	require.Equal(t, "", getSubaddress("alice"))
+}
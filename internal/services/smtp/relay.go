@@ -0,0 +1,81 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package smtp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/mail"
+
+	"github.com/ProtonMail/gluon/rfc5322"
+	"github.com/ProtonMail/proton-bridge/v3/pkg/cpc"
+	"github.com/emersion/go-message/textproto"
+)
+
+type resolveSenderReq struct {
+	email string
+}
+
+func (s *Service) resolveSender(ctx context.Context, email string) (string, error) {
+	return cpc.SendTyped[string](ctx, s.cpc, &resolveSenderReq{email: email})
+}
+
+// relayResolveSender returns the ID of the user's address with the given email, which relayed messages are sent from.
+func (s *Service) relayResolveSender(email string) (string, error) {
+	addr, err := s.identityState.GetAddr(email)
+	if err != nil {
+		return "", ErrNoSuchSender
+	}
+
+	return addr.ID, nil
+}
+
+// rewriteFrom replaces the sender of the message with the given address, keeping the display name the device chose.
+// Devices on the LAN often make up a sender address of their own, which the server would refuse to send from.
+func rewriteFrom(r io.Reader, address string) ([]byte, error) {
+	br := bufio.NewReader(r)
+
+	header, err := textproto.ReadHeader(br)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+
+	from := &mail.Address{Address: address}
+
+	if addrs, err := rfc5322.ParseAddressList(header.Get("From")); err == nil && len(addrs) > 0 {
+		from.Name = addrs[0].Name
+	}
+
+	header.Set("From", from.String())
+	header.Del("Sender")
+
+	buf := new(bytes.Buffer)
+
+	if err := textproto.WriteHeader(buf, header); err != nil {
+		return nil, fmt.Errorf("failed to write header: %w", err)
+	}
+
+	if _, err := io.Copy(buf, br); err != nil {
+		return nil, fmt.Errorf("failed to read body: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,238 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package smtp
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/emersion/go-smtp"
+	"github.com/sirupsen/logrus"
+)
+
+// RelaySettings configures the relay through which devices on the LAN send mail from one of the accounts' addresses.
+type RelaySettings struct {
+	// Address is the account address all relayed messages are sent from.
+	Address string
+
+	// Username and Password are the credentials devices must authenticate with, if Username isn't empty.
+	Username string
+	Password string
+
+	// MaxMessagesPerHour is how many messages may be relayed in any hour.
+	MaxMessagesPerHour int
+
+	// MaxRecipients is how many recipients a single message may have.
+	MaxRecipients int
+}
+
+// RelayBackend accepts messages from devices which can't sign in with a Bridge password, such as printers or
+// NAS boxes, and sends them from a single designated address. It's rate limited so that a compromised device
+// can't use the account to send spam.
+type RelayBackend struct {
+	accounts *Accounts
+	settings RelaySettings
+	limiter  *relayLimiter
+}
+
+func NewRelayBackend(accounts *Accounts, settings RelaySettings) *RelayBackend {
+	return &RelayBackend{
+		accounts: accounts,
+		settings: settings,
+		limiter:  newRelayLimiter(settings.MaxMessagesPerHour, time.Hour),
+	}
+}
+
+// AuthRequired reports whether devices must authenticate before relaying.
+func (be *RelayBackend) AuthRequired() bool {
+	return be.settings.Username != ""
+}
+
+type relaySession struct {
+	backend *RelayBackend
+
+	authenticated bool
+
+	to []string
+}
+
+func (be *RelayBackend) NewSession(*smtp.Conn) (smtp.Session, error) {
+	return &relaySession{backend: be}, nil
+}
+
+func (s *relaySession) AuthPlain(username, password string) error {
+	settings := s.backend.settings
+
+	if !s.backend.AuthRequired() {
+		return smtp.ErrAuthUnsupported
+	}
+
+	usernameOK := subtle.ConstantTimeCompare([]byte(username), []byte(settings.Username)) == 1
+	passwordOK := subtle.ConstantTimeCompare([]byte(password), []byte(settings.Password)) == 1
+
+	if !usernameOK || !passwordOK {
+		logrus.WithField("pkg", "relay").Error("Incorrect relay credentials.")
+
+		return errors.New("invalid username or password")
+	}
+
+	s.authenticated = true
+
+	return nil
+}
+
+func (s *relaySession) Reset() {
+	s.to = nil
+}
+
+func (s *relaySession) Logout() error {
+	s.Reset()
+	return nil
+}
+
+func (s *relaySession) Mail(string, *smtp.MailOptions) error {
+	if s.backend.AuthRequired() && !s.authenticated {
+		return smtp.ErrAuthRequired
+	}
+
+	return nil
+}
+
+func (s *relaySession) Rcpt(to string) error {
+	if len(s.to) >= s.backend.settings.MaxRecipients {
+		return &smtp.SMTPError{
+			Code:         452,
+			EnhancedCode: smtp.EnhancedCode{4, 5, 3},
+			Message:      "too many recipients",
+		}
+	}
+
+	if len(to) > 0 {
+		s.to = append(s.to, to)
+	}
+
+	return nil
+}
+
+// Data sends the message from the designated address, whatever sender the device put in it.
+func (s *relaySession) Data(r io.Reader) error {
+	if len(s.to) == 0 {
+		return ErrInvalidRecipient
+	}
+
+	address := s.backend.settings.Address
+
+	literal, err := rewriteFrom(r, address)
+	if err != nil {
+		return err
+	}
+
+	if !s.backend.limiter.allow() {
+		logrus.WithField("pkg", "relay").Warn("Relay rate limit reached, refusing message.")
+
+		return &smtp.SMTPError{
+			Code:         451,
+			EnhancedCode: smtp.EnhancedCode{4, 7, 0},
+			Message:      "too many messages relayed, try again later",
+		}
+	}
+
+	if err := s.send(address, literal); err != nil {
+		logrus.WithField("pkg", "relay").WithError(err).Error("Relaying message failed.")
+
+		return toRelayError(err)
+	}
+
+	return nil
+}
+
+func (s *relaySession) send(address string, literal []byte) error {
+	ctx := context.Background()
+
+	userID, addrID, err := s.backend.accounts.resolveSender(ctx, address)
+	if err != nil {
+		return err
+	}
+
+	return s.backend.accounts.SendMail(ctx, userID, addrID, address, s.to, bytes.NewReader(literal))
+}
+
+func toRelayError(err error) error {
+	switch {
+	case errors.Is(err, ErrAccountUnavailable):
+		return &smtp.SMTPError{
+			Code:         450,
+			EnhancedCode: smtp.EnhancedCode{4, 2, 1},
+			Message:      "account temporarily unavailable, sign in to Bridge again",
+		}
+
+	case errors.Is(err, ErrNoSuchSender), errors.Is(err, ErrNoSuchUser):
+		return &smtp.SMTPError{
+			Code:         451,
+			EnhancedCode: smtp.EnhancedCode{4, 3, 0},
+			Message:      "relay address isn't available, check the relay settings",
+		}
+
+	default:
+		return err
+	}
+}
+
+// relayLimiter allows at most max events within any window of the given length.
+type relayLimiter struct {
+	lock sync.Mutex
+
+	max    int
+	window time.Duration
+	sent   []time.Time
+
+	now func() time.Time
+}
+
+func newRelayLimiter(max int, window time.Duration) *relayLimiter {
+	return &relayLimiter{
+		max:    max,
+		window: window,
+		now:    time.Now,
+	}
+}
+
+// allow records an event and returns true unless the limit has been reached.
+func (l *relayLimiter) allow() bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	now := l.now()
+
+	for len(l.sent) > 0 && now.Sub(l.sent[0]) >= l.window {
+		l.sent = l.sent[1:]
+	}
+
+	if len(l.sent) >= l.max {
+		return false
+	}
+
+	l.sent = append(l.sent, now)
+
+	return true
+}
@@ -0,0 +1,64 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package smtp
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewriteFrom(t *testing.T) {
+	literal := "From: Scanner <scanner@printer.lan>\r\nSender: root@printer.lan\r\nSubject: Scan\r\n\r\nbody\r\n"
+
+	b, err := rewriteFrom(strings.NewReader(literal), "alias@pm.me")
+	require.NoError(t, err)
+
+	require.Contains(t, string(b), "From: \"Scanner\" <alias@pm.me>\r\n")
+	require.Contains(t, string(b), "Subject: Scan\r\n")
+	require.NotContains(t, string(b), "Sender:")
+	require.True(t, strings.HasSuffix(string(b), "\r\n\r\nbody\r\n"))
+}
+
+func TestRewriteFrom_NoFrom(t *testing.T) {
+	b, err := rewriteFrom(strings.NewReader("Subject: Scan\r\n\r\nbody\r\n"), "alias@pm.me")
+	require.NoError(t, err)
+
+	require.Contains(t, string(b), "From: <alias@pm.me>\r\n")
+}
+
+func TestRelayLimiter(t *testing.T) {
+	now := time.Now()
+
+	limiter := newRelayLimiter(2, time.Hour)
+	limiter.now = func() time.Time { return now }
+
+	require.True(t, limiter.allow())
+	require.True(t, limiter.allow())
+	require.False(t, limiter.allow())
+
+	now = now.Add(30 * time.Minute)
+	require.False(t, limiter.allow())
+
+	now = now.Add(30 * time.Minute)
+	require.True(t, limiter.allow())
+	require.True(t, limiter.allow())
+	require.False(t, limiter.allow())
+}
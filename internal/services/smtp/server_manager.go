@@ -22,6 +22,7 @@ import "context"
 type ServerManager interface {
 	AddSMTPAccount(ctx context.Context, service *Service) error
 	RemoveSMTPAccount(ctx context.Context, service *Service) error
+	SuspendSMTPAccount(ctx context.Context, service *Service, auth *SuspendedAuth) error
 }
 
 type NullServerManager struct{}
@@ -39,3 +40,8 @@ func (n NullServerManager) RemoveSMTPAccount(_ context.Context, _ *Service) erro
 	// Does nothing.
 	return nil
 }
+
+func (n NullServerManager) SuspendSMTPAccount(_ context.Context, _ *Service, _ *SuspendedAuth) error {
+	// Does nothing.
+	return nil
+}
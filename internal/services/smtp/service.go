@@ -28,6 +28,7 @@ import (
 	"github.com/ProtonMail/gluon/logging"
 	"github.com/ProtonMail/gluon/reporter"
 	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/proton-bridge/v3/internal/events"
 	bridgelogging "github.com/ProtonMail/proton-bridge/v3/internal/logging"
 	"github.com/ProtonMail/proton-bridge/v3/internal/services/orderedtasks"
 	"github.com/ProtonMail/proton-bridge/v3/internal/services/sendrecorder"
@@ -44,6 +45,11 @@ type Telemetry interface {
 	ReportSMTPAuthFailed(username string)
 }
 
+// DraftFlusher uploads the drafts whose upload is being held back, so a sent message's draft is current.
+type DraftFlusher interface {
+	FlushDrafts(ctx context.Context) error
+}
+
 type Service struct {
 	userID       string
 	panicHandler async.PanicHandler
@@ -55,8 +61,11 @@ type Service struct {
 
 	bridgePassProvider useridentity.BridgePassProvider
 	keyPassProvider    useridentity.KeyPassProvider
+	templateProvider   TemplateProvider
+	draftFlusher       DraftFlusher
 	identityState      *useridentity.State
 	telemetry          Telemetry
+	eventPublisher     events.EventPublisher
 
 	eventService userevents.Subscribable
 	subscription *userevents.EventChanneledSubscriber
@@ -73,7 +82,10 @@ func NewService(
 	reporter reporter.Reporter,
 	bridgePassProvider useridentity.BridgePassProvider,
 	keyPassProvider useridentity.KeyPassProvider,
+	templateProvider TemplateProvider,
+	draftFlusher DraftFlusher,
 	telemetry Telemetry,
+	eventPublisher events.EventPublisher,
 	eventService userevents.Subscribable,
 	mode usertypes.AddressMode,
 	identityState *useridentity.State,
@@ -95,7 +107,10 @@ func NewService(
 
 		bridgePassProvider: bridgePassProvider,
 		keyPassProvider:    keyPassProvider,
+		templateProvider:   templateProvider,
+		draftFlusher:       draftFlusher,
 		telemetry:          telemetry,
+		eventPublisher:     eventPublisher,
 		identityState:      identityState,
 		eventService:       eventService,
 
@@ -135,6 +150,13 @@ func (s *Service) OnLogout(ctx context.Context) error {
 	return err
 }
 
+// Suspend removes the account from the SMTP server, but keeps recognizing its clients until it signs in again.
+func (s *Service) Suspend(ctx context.Context) error {
+	_, err := s.cpc.Send(ctx, &onSuspendReq{})
+
+	return err
+}
+
 func (s *Service) checkAuth(ctx context.Context, email string, password []byte) (string, error) {
 	return cpc.SendTyped[string](ctx, s.cpc, &checkAuthReq{
 		email:    email,
@@ -224,6 +246,21 @@ func (s *Service) run(ctx context.Context) {
 				addrID, err := s.identityState.CheckAuth(r.email, r.password, s.bridgePassProvider)
 				request.Reply(ctx, addrID, err)
 
+			case *resolveSenderReq:
+				s.log.WithField("sender", bridgelogging.Sensitive(r.email)).Debug("Resolving relay sender")
+				addrID, err := s.relayResolveSender(r.email)
+				request.Reply(ctx, addrID, err)
+
+			case *resolveRecipientReq:
+				s.log.WithField("recipient", bridgelogging.Sensitive(r.rcpt)).Debug("Resolving LMTP recipient")
+				route, err := s.lmtpResolveRecipient(ctx, r.rcpt)
+				request.Reply(ctx, route, err)
+
+			case *deliverMailReq:
+				s.log.WithField("labelID", r.route.labelID).Debug("Received LMTP delivery")
+				err := s.lmtpDeliverMail(ctx, r.route, r.literal)
+				request.Reply(ctx, nil, err)
+
 			case *resyncReq:
 				err := s.identityState.OnRefreshEvent(ctx)
 				request.Reply(ctx, nil, err)
@@ -232,6 +269,11 @@ func (s *Service) run(ctx context.Context) {
 				err := s.serverManager.RemoveSMTPAccount(ctx, s)
 				request.Reply(ctx, nil, err)
 
+			case *onSuspendReq:
+				auth := NewSuspendedAuth(s.identityState.Clone(), s.bridgePassProvider.BridgePass())
+				err := s.serverManager.SuspendSMTPAccount(ctx, s, auth)
+				request.Reply(ctx, nil, err)
+
 			default:
 				s.log.Error("Received unknown request")
 			}
@@ -262,11 +304,21 @@ func (s *Service) sendMail(ctx context.Context, req *sendMailReq) error {
 		s.log.Debugf("Send mail request finished in %v", end.Sub(start))
 	}()
 
+	// The client may have saved the message as a draft moments ago; don't let its upload lag behind the send.
+	if err := s.draftFlusher.FlushDrafts(ctx); err != nil {
+		s.log.WithError(err).Warn("Failed to flush held drafts before sending")
+	}
+
 	if err := s.smtpSendMail(ctx, req.authID, req.from, req.to, req.r); err != nil {
 		if apiErr := new(proton.APIError); errors.As(err, &apiErr) {
 			s.log.WithError(apiErr).WithField("Details", apiErr.DetailsToString()).Error("failed to send message")
 		}
 
+		s.eventPublisher.PublishEvent(ctx, events.UserSendFailed{
+			UserID: s.userID,
+			Error:  err,
+		})
+
 		return err
 	}
 
@@ -285,3 +337,5 @@ type checkAuthReq struct {
 type resyncReq struct{}
 
 type onLogoutReq struct{}
+
+type onSuspendReq struct{}
@@ -35,6 +35,7 @@ import (
 	"github.com/ProtonMail/gluon/rfc822"
 	"github.com/ProtonMail/go-proton-api"
 	"github.com/ProtonMail/gopenpgp/v2/crypto"
+	"github.com/ProtonMail/proton-bridge/v3/internal/events"
 	"github.com/ProtonMail/proton-bridge/v3/internal/logging"
 	"github.com/ProtonMail/proton-bridge/v3/internal/services/sendrecorder"
 	"github.com/ProtonMail/proton-bridge/v3/internal/usertypes"
@@ -97,6 +98,13 @@ func (s *Service) smtpSendMail(ctx context.Context, authID string, from string,
 		from = sender
 	}
 
+	// Expand the canned replies addressed to template+name@local.
+	if to, err = expandTemplates(s.templateProvider, parser, to); err != nil {
+		s.log.Debug("Message failed to send, removing from send recorder")
+		s.recorder.RemoveOnFail(hash, srID)
+		return fmt.Errorf("failed to expand templates: %w", err)
+	}
+
 	// Load the user's mail settings.
 	settings, err := s.client.GetMailSettings(ctx)
 	if err != nil {
@@ -105,6 +113,8 @@ func (s *Service) smtpSendMail(ctx context.Context, authID string, from string,
 		return fmt.Errorf("failed to get mail settings: %w", err)
 	}
 
+	var sent proton.Message
+
 	if err := usertypes.WithAddrKR(s.identityState.User, fromAddr, s.keyPassProvider.KeyPass(), func(userKR, addrKR *crypto.KeyRing) error {
 		// Use the first key for encrypting the message.
 		addrKR, err := addrKR.FirstKey()
@@ -138,7 +148,7 @@ func (s *Service) smtpSendMail(ctx context.Context, authID string, from string,
 		}
 
 		// Send the message using the correct key.
-		sent, err := s.sendWithKey(
+		sent, err = s.sendWithKey(
 			ctx,
 			authID,
 			s.addressMode,
@@ -162,6 +172,15 @@ func (s *Service) smtpSendMail(ctx context.Context, authID string, from string,
 		return err
 	}
 
+	// Let automation tell which of the messages it submitted was sent as which message on the server.
+	s.eventPublisher.PublishEvent(ctx, events.UserMessageSent{
+		UserID:     s.userID,
+		AddressID:  sent.AddressID,
+		MessageID:  sent.ID,
+		ExternalID: sent.ExternalID,
+		Recipients: to,
+	})
+
 	return nil
 }
 
@@ -193,6 +212,13 @@ func (s *Service) sendWithKey(
 		s.log.WithError(err).Warn("Failed to get parent ID")
 	}
 
+	// Some clients drop the inline images when forwarding HTML mail but keep referencing them.
+	if parentID != "" && message.InReplyTo == "" && message.MIMEType == rfc822.TextHTML {
+		if err := s.repairInlineImages(ctx, parentID, &message); err != nil {
+			s.log.WithError(err).Warn("Failed to repair inline images")
+		}
+	}
+
 	var decBody string
 
 	// nolint:exhaustive
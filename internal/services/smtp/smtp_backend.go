@@ -60,6 +60,19 @@ func (be *Backend) NewSession(*smtp.Conn) (smtp.Session, error) {
 func (s *smtpSession) AuthPlain(username, password string) error {
 	userID, authID, err := s.accounts.CheckAuth(username, []byte(password))
 	if err != nil {
+		if errors.Is(err, ErrAccountUnavailable) {
+			logrus.WithFields(logrus.Fields{
+				"username": username,
+				"pkg":      "smtp",
+			}).Warn("Login to account waiting for re-authentication.")
+
+			return &smtp.SMTPError{
+				Code:         454,
+				EnhancedCode: smtp.EnhancedCode{4, 7, 0},
+				Message:      "account temporarily unavailable, sign in to Bridge again",
+			}
+		}
+
 		if !errors.Is(err, ErrNoSuchUser) {
 			return fmt.Errorf("unknown error")
 		}
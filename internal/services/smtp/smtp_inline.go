@@ -0,0 +1,169 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package smtp
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/gopenpgp/v2/crypto"
+	"github.com/ProtonMail/proton-bridge/v3/internal/logging"
+	"github.com/ProtonMail/proton-bridge/v3/pkg/message"
+	"github.com/bradenaw/juniper/xslices"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/exp/slices"
+)
+
+var cidRegexp = regexp.MustCompile(`(?i)["'(=\s]cid:([^"'()\s>]+)`)
+
+// getMissingContentIDs returns the content IDs referenced by cid: URLs in the HTML body
+// that don't belong to any of the message's attachments.
+func getMissingContentIDs(msg message.Message) []string {
+	var missing []string
+
+	for _, match := range cidRegexp.FindAllStringSubmatch(string(msg.RichBody), -1) {
+		cid := match[1]
+
+		if unescaped, err := url.PathUnescape(cid); err == nil {
+			cid = unescaped
+		}
+
+		if xslices.IndexFunc(msg.Attachments, func(att message.Attachment) bool {
+			return strings.EqualFold(att.ContentID, cid)
+		}) >= 0 {
+			continue
+		}
+
+		if xslices.IndexFunc(missing, func(other string) bool {
+			return strings.EqualFold(other, cid)
+		}) >= 0 {
+			continue
+		}
+
+		missing = append(missing, cid)
+	}
+
+	return missing
+}
+
+// getAttachmentContentID returns the content ID of an attachment stored on the server.
+func getAttachmentContentID(att proton.Attachment) string {
+	for key, values := range att.Headers.Values {
+		if strings.EqualFold(key, "Content-Id") && len(values) > 0 {
+			return strings.Trim(values[0], " <>")
+		}
+	}
+
+	return ""
+}
+
+// repairInlineImages re-attaches the inline images of a forwarded message whose cid: references
+// were kept in the HTML body by the client while the images themselves were dropped.
+// They are taken from the original message; references that can't be resolved are left as they are.
+func (s *Service) repairInlineImages(ctx context.Context, parentID string, msg *message.Message) error {
+	missing := getMissingContentIDs(*msg)
+	if len(missing) == 0 {
+		return nil
+	}
+
+	parent, err := s.client.GetMessage(ctx, parentID)
+	if err != nil {
+		return fmt.Errorf("failed to get original message: %w", err)
+	}
+
+	var attachments []proton.Attachment
+
+	for _, att := range parent.Attachments {
+		if cid := getAttachmentContentID(att); cid != "" && slices.ContainsFunc(missing, func(other string) bool {
+			return strings.EqualFold(other, cid)
+		}) {
+			attachments = append(attachments, att)
+		}
+	}
+
+	if len(attachments) > 0 {
+		if err := s.identityState.WithAddrKR(parent.AddressID, s.keyPassProvider.KeyPass(), func(_, addrKR *crypto.KeyRing) error {
+			for _, att := range attachments {
+				data, err := s.getDecryptedAttachment(ctx, addrKR, att)
+				if err != nil {
+					return err
+				}
+
+				mimeType, params, err := mime.ParseMediaType(string(att.MIMEType))
+				if err != nil {
+					mimeType, params = string(att.MIMEType), nil
+				}
+
+				msg.Attachments = append(msg.Attachments, message.Attachment{
+					Name:        att.Name,
+					ContentID:   getAttachmentContentID(att),
+					MIMEType:    mimeType,
+					MIMEParams:  params,
+					Disposition: proton.InlineDisposition,
+					Data:        data,
+				})
+
+				missing = xslices.Filter(missing, func(cid string) bool {
+					return !strings.EqualFold(cid, getAttachmentContentID(att))
+				})
+			}
+
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		s.log.WithFields(logrus.Fields{
+			"parentID": parentID,
+			"count":    len(attachments),
+		}).Info("Re-attached inline images from the original message")
+	}
+
+	if len(missing) > 0 {
+		s.log.WithField("contentIDs", logging.Sensitive(strings.Join(missing, ", "))).Warn("Message references inline images that aren't attached")
+	}
+
+	return nil
+}
+
+func (s *Service) getDecryptedAttachment(ctx context.Context, kr *crypto.KeyRing, att proton.Attachment) ([]byte, error) {
+	keyPackets, err := base64.StdEncoding.DecodeString(att.KeyPackets)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode key packets: %w", err)
+	}
+
+	data, err := s.client.GetAttachment(ctx, att.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attachment: %w", err)
+	}
+
+	msg := crypto.NewPGPSplitMessage(keyPackets, data).GetPGPMessage()
+
+	dec, err := kr.Decrypt(msg, nil, crypto.GetUnixTime())
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt attachment: %w", err)
+	}
+
+	return dec.GetBinary(), nil
+}
@@ -0,0 +1,52 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package smtp
+
+import (
+	"testing"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/proton-bridge/v3/pkg/message"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetMissingContentIDs(t *testing.T) {
+	msg := message.Message{
+		RichBody: `<p><img src="cid:logo@example.com"> <img src='cid:Photo%40example.com'></p>` +
+			`<div style="background: url(cid:bg)"></div><img src=cid:attached>` +
+			`<img src="cid:logo@example.com"><p>cid:not-a-reference</p>`,
+		Attachments: []message.Attachment{
+			{Name: "attached.png", ContentID: "ATTACHED"},
+			{Name: "other.pdf"},
+		},
+	}
+
+	// References are unescaped and deduplicated; attached ones are matched case-insensitively.
+	require.Equal(t, []string{"logo@example.com", "Photo@example.com", "bg"}, getMissingContentIDs(msg))
+
+	// Plain bodies have nothing to repair.
+	require.Empty(t, getMissingContentIDs(message.Message{PlainBody: `<img src="cid:logo">`}))
+}
+
+func TestGetAttachmentContentID(t *testing.T) {
+	require.Equal(t, "logo@example.com", getAttachmentContentID(proton.Attachment{
+		Headers: proton.Headers{Values: map[string][]string{"content-id": {"<logo@example.com>"}}},
+	}))
+
+	require.Empty(t, getAttachmentContentID(proton.Attachment{}))
+}
@@ -0,0 +1,211 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package smtp
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html"
+	"net/mail"
+	"strings"
+
+	"github.com/ProtonMail/gluon/rfc5322"
+	"github.com/ProtonMail/proton-bridge/v3/internal/templates"
+	"github.com/ProtonMail/proton-bridge/v3/pkg/message/parser"
+	"github.com/bradenaw/juniper/xslices"
+	"github.com/emersion/go-message"
+)
+
+var ErrNoSuchTemplate = errors.New("no such template")
+
+type TemplateProvider interface {
+	GetTemplate(name string) (string, bool)
+}
+
+// expandTemplates expands the templates referenced by template+name@local recipients into the message body.
+// It returns the recipients with the template addresses removed.
+func expandTemplates(provider TemplateProvider, p *parser.Parser, to []string) ([]string, error) {
+	var names []string
+
+	to = xslices.Filter(to, func(addr string) bool {
+		name, ok := templates.NameFromAddress(addr)
+		if ok {
+			names = append(names, name)
+		}
+
+		return !ok
+	})
+
+	if len(names) == 0 {
+		return to, nil
+	}
+
+	vars := getTemplateVars(p.Root().Header)
+
+	var text []string
+
+	for _, name := range names {
+		body, ok := provider.GetTemplate(name)
+		if !ok {
+			return nil, fmt.Errorf("%w: %v", ErrNoSuchTemplate, name)
+		}
+
+		rendered, err := templates.Render(body, vars)
+		if err != nil {
+			return nil, err
+		}
+
+		text = append(text, rendered)
+	}
+
+	for _, key := range []string{"To", "Cc", "Bcc"} {
+		removeTemplateRecipients(&p.Root().Header, key)
+	}
+
+	if err := insertTemplateText(p.Root(), strings.Join(text, "\r\n")); err != nil {
+		return nil, err
+	}
+
+	return to, nil
+}
+
+func getTemplateVars(header message.Header) map[string]string {
+	vars := make(map[string]string)
+
+	if subject, err := header.Text("Subject"); err == nil {
+		vars[templates.VarSubject] = subject
+	}
+
+	if from, err := rfc5322.ParseAddressList(header.Get("From")); err == nil && len(from) > 0 {
+		vars[templates.VarFrom] = getDisplayName(from[0])
+	}
+
+	if to, err := rfc5322.ParseAddressList(header.Get("To")); err == nil {
+		if to = xslices.Filter(to, func(addr *mail.Address) bool {
+			_, ok := templates.NameFromAddress(addr.Address)
+			return !ok
+		}); len(to) > 0 {
+			vars[templates.VarName] = getDisplayName(to[0])
+			vars[templates.VarEmail] = to[0].Address
+		}
+	}
+
+	return vars
+}
+
+func getDisplayName(addr *mail.Address) string {
+	if addr.Name != "" {
+		return addr.Name
+	}
+
+	return addr.Address
+}
+
+func removeTemplateRecipients(header *message.Header, key string) {
+	if !header.Has(key) {
+		return
+	}
+
+	addrs, err := rfc5322.ParseAddressList(header.Get(key))
+	if err != nil {
+		return
+	}
+
+	addrs = xslices.Filter(addrs, func(addr *mail.Address) bool {
+		_, ok := templates.NameFromAddress(addr.Address)
+		return !ok
+	})
+
+	if len(addrs) == 0 {
+		header.Del(key)
+	} else {
+		header.Set(key, strings.Join(xslices.Map(addrs, func(addr *mail.Address) string { return addr.String() }), ", "))
+	}
+}
+
+// insertTemplateText prepends the rendered template text to every text body part of the message.
+func insertTemplateText(part *parser.Part, text string) error {
+	if part.Header.Has("Content-Disposition") {
+		if disp, _, err := part.Header.ContentDisposition(); err == nil && disp == "attachment" {
+			return nil
+		}
+	}
+
+	// Parts without a content type are plain text.
+	contentType := "text/plain"
+
+	if part.Header.Has("Content-Type") {
+		t, _, err := part.ContentType()
+		if err != nil {
+			return fmt.Errorf("failed to get content type: %w", err)
+		}
+
+		contentType = t
+	}
+
+	switch {
+	case strings.HasPrefix(contentType, "multipart/"):
+		for _, child := range part.Children() {
+			if err := insertTemplateText(child, text); err != nil {
+				return err
+			}
+		}
+
+	case contentType == "text/plain":
+		if err := part.ConvertToUTF8(); err != nil {
+			return fmt.Errorf("failed to convert part to UTF-8: %w", err)
+		}
+
+		if len(bytes.TrimSpace(part.Body)) == 0 {
+			part.Body = []byte(text)
+		} else {
+			part.Body = append([]byte(text+"\r\n\r\n"), part.Body...)
+		}
+
+	case contentType == "text/html":
+		if err := part.ConvertToUTF8(); err != nil {
+			return fmt.Errorf("failed to convert part to UTF-8: %w", err)
+		}
+
+		part.Body = insertHTMLText(part.Body, strings.NewReplacer("\r\n", "<br>", "\n", "<br>").Replace(html.EscapeString(text)))
+	}
+
+	return nil
+}
+
+// insertHTMLText inserts the given HTML right after the opening body tag, if there is one.
+func insertHTMLText(body []byte, text string) []byte {
+	idx := bytes.Index(bytes.ToLower(body), []byte("<body"))
+	if idx < 0 {
+		return append([]byte("<div>"+text+"</div>"), body...)
+	}
+
+	end := bytes.IndexByte(body[idx:], '>')
+	if end < 0 {
+		return append([]byte("<div>"+text+"</div>"), body...)
+	}
+
+	end += idx + 1
+
+	res := make([]byte, 0, len(body)+len(text)+len("<div></div>"))
+	res = append(res, body[:end]...)
+	res = append(res, "<div>"+text+"</div>"...)
+
+	return append(res, body[end:]...)
+}
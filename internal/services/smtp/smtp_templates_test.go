@@ -0,0 +1,102 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package smtp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/proton-bridge/v3/pkg/message/parser"
+	"github.com/stretchr/testify/require"
+)
+
+type testTemplateProvider map[string]string
+
+func (p testTemplateProvider) GetTemplate(name string) (string, bool) {
+	body, ok := p[name]
+	return body, ok
+}
+
+func TestExpandTemplates(t *testing.T) {
+	const literal = "From: Bob <bob@pm.me>\r\n" +
+		"To: Alice <alice@example.com>, template+thanks@local\r\n" +
+		"Subject: Invoice\r\n" +
+		"Content-Type: text/plain; charset=utf-8\r\n" +
+		"\r\n" +
+		"Original text\r\n"
+
+	p, err := parser.New(strings.NewReader(literal))
+	require.NoError(t, err)
+
+	to, err := expandTemplates(testTemplateProvider{
+		"thanks": "Thanks {{.Name}} for your mail about {{.Subject}}.\r\n{{.From}}",
+	}, p, []string{"alice@example.com", "template+thanks@local"})
+	require.NoError(t, err)
+
+	// The template recipient is removed from the envelope and the headers.
+	require.Equal(t, []string{"alice@example.com"}, to)
+	require.Equal(t, `"Alice" <alice@example.com>`, p.Root().Header.Get("To"))
+
+	// The template is rendered in front of the original text.
+	require.Equal(t, "Thanks Alice for your mail about Invoice.\r\nBob\r\n\r\nOriginal text\r\n", string(p.Root().Body))
+}
+
+func TestExpandTemplates_HTML(t *testing.T) {
+	const literal = "From: bob@pm.me\r\n" +
+		"To: alice@example.com\r\n" +
+		"Bcc: template+sig@local\r\n" +
+		"Content-Type: text/html; charset=utf-8\r\n" +
+		"\r\n" +
+		"<html><body class=\"x\"><p>Hi</p></body></html>"
+
+	p, err := parser.New(strings.NewReader(literal))
+	require.NoError(t, err)
+
+	to, err := expandTemplates(testTemplateProvider{"sig": "<Bob>\n{{.Email}}"}, p, []string{"alice@example.com", "template+sig@local"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"alice@example.com"}, to)
+	require.False(t, p.Root().Header.Has("Bcc"))
+	require.Equal(t, `<html><body class="x"><div>&lt;Bob&gt;<br>alice@example.com</div><p>Hi</p></body></html>`, string(p.Root().Body))
+}
+
+func TestExpandTemplates_NoSuchTemplate(t *testing.T) {
+	p, err := parser.New(strings.NewReader("To: template+missing@local\r\n\r\nHello\r\n"))
+	require.NoError(t, err)
+
+	_, err = expandTemplates(testTemplateProvider{}, p, []string{"template+missing@local"})
+	require.ErrorIs(t, err, ErrNoSuchTemplate)
+}
+
+func TestExpandTemplates_NoTemplate(t *testing.T) {
+	p, err := parser.New(strings.NewReader("To: alice@example.com\r\n\r\nHello\r\n"))
+	require.NoError(t, err)
+
+	to, err := expandTemplates(testTemplateProvider{}, p, []string{"alice@example.com"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"alice@example.com"}, to)
+	require.Equal(t, "Hello\r\n", string(p.Root().Body))
+}
+
+func TestExpandTemplates_NoContentType(t *testing.T) {
+	p, err := parser.New(strings.NewReader("To: alice@example.com, template+hi@local\r\n\r\n"))
+	require.NoError(t, err)
+
+	_, err = expandTemplates(testTemplateProvider{"hi": "Hi there"}, p, []string{"alice@example.com", "template+hi@local"})
+	require.NoError(t, err)
+	require.Equal(t, "Hi there", string(p.Root().Body))
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package spamfilter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// rspamdChecker uses the HTTP protocol of the rspamd normal worker.
+type rspamdChecker struct {
+	url    string
+	client *http.Client
+}
+
+// newRspamdChecker returns a checker for the rspamd worker at the given address of the given network, tcp or unix.
+func newRspamdChecker(network, address string) *rspamdChecker {
+	if network == "unix" {
+		// The host of the URL is only used for the Host header; every connection goes to the socket.
+		return &rspamdChecker{
+			url: "http://rspamd/checkv2",
+			client: &http.Client{Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					return dial(ctx, network, address)
+				},
+			}},
+		}
+	}
+
+	return &rspamdChecker{
+		url:    "http://" + address + "/checkv2",
+		client: &http.Client{},
+	}
+}
+
+type rspamdResult struct {
+	Score         float64 `json:"score"`
+	RequiredScore float64 `json:"required_score"`
+	Action        string  `json:"action"`
+}
+
+func (c *rspamdChecker) Check(ctx context.Context, literal []byte) (Verdict, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(literal))
+	if err != nil {
+		return Verdict{}, err
+	}
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return Verdict{}, fmt.Errorf("failed to contact rspamd: %w", err)
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	if res.StatusCode != http.StatusOK {
+		return Verdict{}, fmt.Errorf("rspamd returned status %v", res.Status)
+	}
+
+	var result rspamdResult
+
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return Verdict{}, fmt.Errorf("failed to decode rspamd response: %w", err)
+	}
+
+	return Verdict{
+		Spam:      isRspamdSpamAction(result.Action),
+		Score:     result.Score,
+		Threshold: result.RequiredScore,
+	}, nil
+}
+
+// isRspamdSpamAction returns whether the given rspamd action means that the message should be treated as spam.
+func isRspamdSpamAction(action string) bool {
+	switch action {
+	case "reject", "add header", "rewrite subject":
+		return true
+
+	default:
+		return false
+	}
+}
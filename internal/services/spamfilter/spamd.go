@@ -0,0 +1,114 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package spamfilter
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// spamdChecker speaks the spamc protocol to a SpamAssassin spamd daemon.
+type spamdChecker struct {
+	network string
+	address string
+}
+
+func (c *spamdChecker) Check(ctx context.Context, literal []byte) (Verdict, error) {
+	conn, err := dial(ctx, c.network, c.address)
+	if err != nil {
+		return Verdict{}, fmt.Errorf("failed to connect to spamd: %w", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	if _, err := fmt.Fprintf(conn, "CHECK SPAMC/1.5\r\nContent-length: %v\r\n\r\n", len(literal)); err != nil {
+		return Verdict{}, fmt.Errorf("failed to write spamd request: %w", err)
+	}
+
+	if _, err := conn.Write(literal); err != nil {
+		return Verdict{}, fmt.Errorf("failed to write spamd request: %w", err)
+	}
+
+	return readSpamdResponse(bufio.NewReader(conn))
+}
+
+func readSpamdResponse(r *bufio.Reader) (Verdict, error) {
+	status, err := r.ReadString('\n')
+	if err != nil {
+		return Verdict{}, fmt.Errorf("failed to read spamd response: %w", err)
+	}
+
+	// SPAMD/1.1 0 EX_OK
+	if fields := strings.Fields(status); len(fields) < 2 || !strings.HasPrefix(fields[0], "SPAMD/") {
+		return Verdict{}, fmt.Errorf("invalid spamd response %q", status)
+	} else if fields[1] != "0" {
+		return Verdict{}, fmt.Errorf("spamd returned error: %v", strings.Join(fields[1:], " "))
+	}
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return Verdict{}, fmt.Errorf("failed to read spamd response: %w", err)
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			return Verdict{}, errors.New("spamd response has no verdict")
+		}
+
+		if value, ok := strings.CutPrefix(line, "Spam:"); ok {
+			return parseSpamdVerdict(value)
+		}
+	}
+}
+
+// parseSpamdVerdict parses the value of a spamd verdict header, e.g. "True ; 15.0 / 5.0".
+func parseSpamdVerdict(value string) (Verdict, error) {
+	isSpam, scores, ok := strings.Cut(value, ";")
+	if !ok {
+		return Verdict{}, fmt.Errorf("invalid spamd verdict %q", value)
+	}
+
+	score, threshold, ok := strings.Cut(scores, "/")
+	if !ok {
+		return Verdict{}, fmt.Errorf("invalid spamd verdict %q", value)
+	}
+
+	var (
+		verdict Verdict
+		err     error
+	)
+
+	switch strings.ToLower(strings.TrimSpace(isSpam)) {
+	case "true", "yes":
+		verdict.Spam = true
+	}
+
+	if verdict.Score, err = strconv.ParseFloat(strings.TrimSpace(score), 64); err != nil {
+		return Verdict{}, fmt.Errorf("invalid spamd score: %w", err)
+	}
+
+	if verdict.Threshold, err = strconv.ParseFloat(strings.TrimSpace(threshold), 64); err != nil {
+		return Verdict{}, fmt.Errorf("invalid spamd threshold: %w", err)
+	}
+
+	return verdict, nil
+}
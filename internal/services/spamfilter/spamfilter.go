@@ -0,0 +1,171 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package spamfilter passes incoming messages through a local spam filter (SpamAssassin's spamd or rspamd).
+package spamfilter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CheckTimeout bounds the time spent waiting for a verdict. Messages are checked in the event loop as they arrive,
+// so a slow filter delays every new message; past the timeout they are delivered without a verdict.
+const CheckTimeout = 5 * time.Second
+
+// FailureBackoff is how long messages skip the filter after it failed, so that a filter that is down
+// doesn't cost each new message the whole timeout.
+const FailureBackoff = time.Minute
+
+// ErrBackingOff is returned by Filter.Check while messages skip the filter after a failure.
+var ErrBackingOff = errors.New("spam filter failed recently, skipping it")
+
+// Verdict is the result of checking a message.
+type Verdict struct {
+	Spam      bool
+	Score     float64
+	Threshold float64
+}
+
+// Checker submits a message literal to a spam filter and returns its verdict.
+type Checker interface {
+	Check(ctx context.Context, literal []byte) (Verdict, error)
+}
+
+// Filter holds the configured checker along with what to do with messages classified as spam.
+type Filter struct {
+	checker    Checker
+	moveToSpam bool
+
+	failedLock sync.Mutex
+	failedAt   time.Time
+}
+
+// NewSpamd returns a filter using SpamAssassin's spamd listening at the given address,
+// either host:port or unix:/path/to/socket; empty uses the default port on localhost.
+func NewSpamd(address string, moveToSpam bool) *Filter {
+	network, address := splitAddress(address, "127.0.0.1:783")
+
+	return NewWithChecker(&spamdChecker{network: network, address: address}, moveToSpam)
+}
+
+// NewRspamd returns a filter using the rspamd normal worker listening at the given address,
+// either host:port or unix:/path/to/socket; empty uses the default port on localhost.
+func NewRspamd(address string, moveToSpam bool) *Filter {
+	network, address := splitAddress(address, "127.0.0.1:11333")
+
+	return NewWithChecker(newRspamdChecker(network, address), moveToSpam)
+}
+
+// NewWithChecker returns a filter using the given checker.
+func NewWithChecker(checker Checker, moveToSpam bool) *Filter {
+	return &Filter{
+		checker:    checker,
+		moveToSpam: moveToSpam,
+	}
+}
+
+// Check returns the verdict of the filter for the given message literal.
+// After a failure, it returns ErrBackingOff without contacting the filter for FailureBackoff.
+func (f *Filter) Check(ctx context.Context, literal []byte) (Verdict, error) {
+	if f.isBackingOff() {
+		return Verdict{}, ErrBackingOff
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, CheckTimeout)
+	defer cancel()
+
+	verdict, err := f.checker.Check(ctx, literal)
+	if err != nil && ctx.Err() != context.Canceled {
+		f.setFailed()
+	}
+
+	return verdict, err
+}
+
+func (f *Filter) isBackingOff() bool {
+	f.failedLock.Lock()
+	defer f.failedLock.Unlock()
+
+	return !f.failedAt.IsZero() && time.Since(f.failedAt) < FailureBackoff
+}
+
+func (f *Filter) setFailed() {
+	f.failedLock.Lock()
+	defer f.failedLock.Unlock()
+
+	f.failedAt = time.Now()
+}
+
+// MoveToSpam returns whether messages classified as spam should be moved to the Spam folder.
+func (f *Filter) MoveToSpam() bool {
+	return f.moveToSpam
+}
+
+// AddVerdictHeaders prepends the SpamAssassin-style verdict headers to the given message literal.
+func AddVerdictHeaders(literal []byte, verdict Verdict) []byte {
+	status := "No"
+	if verdict.Spam {
+		status = "Yes"
+	}
+
+	var buf bytes.Buffer
+
+	buf.Grow(len(literal) + 128)
+
+	_, _ = fmt.Fprintf(&buf, "X-Spam-Status: %v, score=%.1f required=%.1f\r\n", status, verdict.Score, verdict.Threshold)
+	_, _ = fmt.Fprintf(&buf, "X-Spam-Score: %.1f\r\n", verdict.Score)
+	_, _ = buf.Write(literal)
+
+	return buf.Bytes()
+}
+
+func splitAddress(address, defaultAddress string) (string, string) {
+	if address == "" {
+		address = defaultAddress
+	}
+
+	if path, ok := strings.CutPrefix(address, "unix:"); ok {
+		return "unix", path
+	}
+
+	return "tcp", address
+}
+
+func dial(ctx context.Context, network, address string) (net.Conn, error) {
+	var dialer net.Dialer
+
+	conn, err := dialer.DialContext(ctx, network, address)
+	if err != nil {
+		return nil, err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+	}
+
+	return conn, nil
+}
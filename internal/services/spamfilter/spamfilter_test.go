@@ -0,0 +1,160 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package spamfilter
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testLiteral = "From: spammer@example.com\r\nSubject: Buy now\r\n\r\nCheap stuff\r\n"
+
+func TestFilter_Spamd(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+
+		// Read the request line and headers to learn the message length.
+		var length int
+
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+
+			if value, ok := strings.CutPrefix(strings.TrimSpace(line), "Content-length: "); ok {
+				length, _ = strconv.Atoi(value)
+			} else if strings.TrimSpace(line) == "" {
+				break
+			}
+		}
+
+		body := make([]byte, length)
+		if _, err := io.ReadFull(r, body); err != nil {
+			return
+		}
+
+		if string(body) == testLiteral {
+			_, _ = conn.Write([]byte("SPAMD/1.1 0 EX_OK\r\nSpam: True ; 15.2 / 5.0\r\n\r\n"))
+		}
+	}()
+
+	filter := NewSpamd(l.Addr().String(), true)
+
+	verdict, err := filter.Check(context.Background(), []byte(testLiteral))
+	require.NoError(t, err)
+	require.Equal(t, Verdict{Spam: true, Score: 15.2, Threshold: 5.0}, verdict)
+	require.True(t, filter.MoveToSpam())
+}
+
+func TestFilter_Rspamd(t *testing.T) {
+	srv := httptest.NewServer(newRspamdHandler(t))
+	defer srv.Close()
+
+	filter := NewRspamd(strings.TrimPrefix(srv.URL, "http://"), false)
+
+	verdict, err := filter.Check(context.Background(), []byte(testLiteral))
+	require.NoError(t, err)
+	require.Equal(t, Verdict{Spam: false, Score: 2.5, Threshold: 15}, verdict)
+}
+
+func TestFilter_Rspamd_UnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rspamd.sock")
+
+	l, err := net.Listen("unix", path)
+	require.NoError(t, err)
+
+	srv := &httptest.Server{Listener: l, Config: &http.Server{Handler: newRspamdHandler(t)}} //nolint:gosec
+	srv.Start()
+	defer srv.Close()
+
+	filter := NewRspamd("unix:"+path, false)
+
+	verdict, err := filter.Check(context.Background(), []byte(testLiteral))
+	require.NoError(t, err)
+	require.Equal(t, Verdict{Spam: false, Score: 2.5, Threshold: 15}, verdict)
+}
+
+func TestFilter_FailureBackoff(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	// Nothing listens at the address anymore, so the first check fails to connect.
+	address := l.Addr().String()
+	require.NoError(t, l.Close())
+
+	filter := NewSpamd(address, true)
+
+	_, err = filter.Check(context.Background(), []byte(testLiteral))
+	require.Error(t, err)
+	require.NotErrorIs(t, err, ErrBackingOff)
+
+	// The following checks skip the filter instead of waiting for it again.
+	_, err = filter.Check(context.Background(), []byte(testLiteral))
+	require.ErrorIs(t, err, ErrBackingOff)
+}
+
+func newRspamdHandler(t *testing.T) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/checkv2", r.URL.Path)
+
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.Equal(t, testLiteral, string(body))
+
+		_, _ = w.Write([]byte(`{"score": 2.5, "required_score": 15, "action": "no action"}`))
+	})
+}
+
+func TestParseSpamdVerdict(t *testing.T) {
+	verdict, err := parseSpamdVerdict(" False ; 1.3 / 5.0")
+	require.NoError(t, err)
+	require.Equal(t, Verdict{Spam: false, Score: 1.3, Threshold: 5.0}, verdict)
+
+	_, err = parseSpamdVerdict("True")
+	require.Error(t, err)
+
+	_, err = parseSpamdVerdict("True ; high / 5.0")
+	require.Error(t, err)
+}
+
+func TestAddVerdictHeaders(t *testing.T) {
+	literal := AddVerdictHeaders([]byte(testLiteral), Verdict{Spam: true, Score: 7.3, Threshold: 5})
+
+	require.Equal(t, "X-Spam-Status: Yes, score=7.3 required=5.0\r\nX-Spam-Score: 7.3\r\n"+testLiteral, string(literal))
+}
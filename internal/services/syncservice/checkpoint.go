@@ -0,0 +1,86 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package syncservice
+
+import (
+	"context"
+	"sync"
+
+	"golang.org/x/exp/slices"
+)
+
+// checkpointTracker keeps the checkpoint of a job up to date as its batches go down the pipeline.
+// Batches are applied in the order they are created, so the checkpoint only grows at the end and shrinks at the front.
+type checkpointTracker struct {
+	store      CheckpointStore
+	checkpoint Checkpoint
+	lock       sync.Mutex
+}
+
+func newCheckpointTracker(store CheckpointStore, checkpoint Checkpoint) *checkpointTracker {
+	return &checkpointTracker{
+		store:      store,
+		checkpoint: checkpoint.clone(),
+	}
+}
+
+// resume returns the message to page on from, if any, and the batches left pending by a previous run,
+// which are to be downloaded again before paging on.
+func (t *checkpointTracker) resume() (string, [][]string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.checkpoint.MetadataEndID, t.checkpoint.clone().Batches
+}
+
+// addBatch records that the given messages were batched for download.
+func (t *checkpointTracker) addBatch(ctx context.Context, ids []string) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.checkpoint.MetadataEndID = ids[len(ids)-1]
+	t.checkpoint.Batches = append(t.checkpoint.Batches, slices.Clone(ids))
+
+	return t.store.SetSyncCheckpoint(ctx, t.checkpoint.clone())
+}
+
+// applied records that the batched messages up to and including the given one were applied.
+// Nothing is recorded if the message wasn't batched.
+func (t *checkpointTracker) applied(ctx context.Context, lastMessageID string) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	for i, batch := range t.checkpoint.Batches {
+		idx := slices.Index(batch, lastMessageID)
+		if idx < 0 {
+			continue
+		}
+
+		batches := t.checkpoint.Batches[i+1:]
+
+		if rest := batch[idx+1:]; len(rest) > 0 {
+			batches = append([][]string{rest}, batches...)
+		}
+
+		t.checkpoint.Batches = batches
+
+		return t.store.SetSyncCheckpoint(ctx, t.checkpoint.clone())
+	}
+
+	return nil
+}
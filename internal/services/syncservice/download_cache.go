@@ -18,98 +18,299 @@
 package syncservice
 
 import (
+	"container/list"
+	"encoding/json"
 	"sync"
 
 	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/proton-bridge/v3/internal/telemetry/metrics"
+	"github.com/sirupsen/logrus"
 )
 
+var downloadCacheRequests = metrics.NewCounterVec(
+	"bridge_sync_download_cache_requests_total",
+	"Lookups in the sync download cache, which keeps what was downloaded when a batch is retried, by kind and result.",
+	"kind", "result",
+)
+
+// observeDownloadCache records whether a lookup of the given kind, message or attachment, hit the cache.
+func observeDownloadCache(kind string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+
+	downloadCacheRequests.WithLabelValues(kind, result).Inc()
+}
+
+// DefaultDownloadCacheMaxDisk is the size of the spill area of the download cache, when it spills to disk.
+const DefaultDownloadCacheMaxDisk = 2 * Gigabyte
+
+// DownloadCacheLimits bound the memory used by the download cache, which keeps the messages and attachments
+// downloaded by a sync until they're built, so that a batch which is retried doesn't download them again.
+type DownloadCacheLimits struct {
+	// MaxMemory is the size of the messages and attachments kept in memory; the least recently used ones are
+	// evicted beyond it. Zero means no limit.
+	MaxMemory uint64
+
+	// SpillDir is where the evicted messages and attachments are written, encrypted with a key which only lives
+	// in memory, rather than dropped. Empty drops them.
+	SpillDir string
+
+	// MaxDisk is the size of the spill area; the oldest entries are removed beyond it.
+	MaxDisk uint64
+}
+
+type cacheKind int
+
+const (
+	cacheKindMessage cacheKind = iota
+	cacheKindAttachment
+)
+
+type cacheKey struct {
+	kind cacheKind
+	id   string
+}
+
+type cacheEntry struct {
+	key        cacheKey
+	message    proton.Message
+	attachment []byte
+	size       uint64
+}
+
+// DownloadCache keeps downloaded messages and attachments within its memory budget, evicting the least recently used
+// ones to its spill area, if any. It's only a cache: whatever it loses is downloaded again.
 type DownloadCache struct {
-	messageLock    sync.RWMutex
-	messages       map[string]proton.Message
-	attachmentLock sync.RWMutex
-	attachments    map[string][]byte
+	lock   sync.Mutex
+	limits DownloadCacheLimits
+
+	entries map[cacheKey]*list.Element // Elements of lru, holding *cacheEntry.
+	lru     *list.List                 // Most recently used first.
+	memory  uint64
+
+	spill *spillArea // Nil if entries aren't spilled.
 }
 
-func newDownloadCache() *DownloadCache {
-	return &DownloadCache{
-		messages:    make(map[string]proton.Message, 64),
-		attachments: make(map[string][]byte, 64),
+func newDownloadCache(limits DownloadCacheLimits) *DownloadCache {
+	cache := &DownloadCache{
+		limits:  limits,
+		entries: make(map[cacheKey]*list.Element, 64),
+		lru:     list.New(),
+	}
+
+	if limits.SpillDir != "" {
+		spill, err := newSpillArea(limits.SpillDir, limits.MaxDisk)
+		if err != nil {
+			logrus.WithError(err).Error("Failed to create download cache spill area, evicted downloads will be dropped")
+		} else {
+			cache.spill = spill
+		}
 	}
+
+	return cache
 }
 
 func (s *DownloadCache) StoreMessage(message proton.Message) {
-	s.messageLock.Lock()
-	defer s.messageLock.Unlock()
-
-	s.messages[message.ID] = message
+	s.store(&cacheEntry{
+		key:     cacheKey{kind: cacheKindMessage, id: message.ID},
+		message: message,
+		size:    messageCacheSize(message),
+	})
 }
 
 func (s *DownloadCache) StoreAttachment(id string, data []byte) {
-	s.attachmentLock.Lock()
-	defer s.attachmentLock.Unlock()
-
-	s.attachments[id] = data
+	s.store(&cacheEntry{
+		key:        cacheKey{kind: cacheKindAttachment, id: id},
+		attachment: data,
+		size:       uint64(len(data)),
+	})
 }
 
 func (s *DownloadCache) DeleteMessages(id ...string) {
-	s.messageLock.Lock()
-	defer s.messageLock.Unlock()
+	s.delete(cacheKindMessage, id)
+}
 
-	for _, id := range id {
-		delete(s.messages, id)
+func (s *DownloadCache) DeleteAttachments(id ...string) {
+	s.delete(cacheKindAttachment, id)
+}
+
+func (s *DownloadCache) GetMessage(id string) (proton.Message, bool) {
+	entry, ok := s.get(cacheKey{kind: cacheKindMessage, id: id})
+	if !ok {
+		return proton.Message{}, false
 	}
+
+	return entry.message, true
 }
 
-func (s *DownloadCache) DeleteAttachments(id ...string) {
-	s.attachmentLock.Lock()
-	defer s.attachmentLock.Unlock()
+func (s *DownloadCache) GetAttachment(id string) ([]byte, bool) {
+	entry, ok := s.get(cacheKey{kind: cacheKindAttachment, id: id})
+	if !ok {
+		return nil, false
+	}
+
+	return entry.attachment, true
+}
+
+// Clear removes every message and attachment, including the spilled ones.
+func (s *DownloadCache) Clear() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.entries = make(map[cacheKey]*list.Element, 64)
+	s.lru.Init()
+	s.memory = 0
 
-	for _, id := range id {
-		delete(s.attachments, id)
+	if s.spill != nil {
+		s.spill.clear()
 	}
 }
 
-func (s *DownloadCache) GetMessage(id string) (proton.Message, bool) {
-	s.messageLock.RLock()
-	defer s.messageLock.RUnlock()
+// Close removes the spill area, if any.
+func (s *DownloadCache) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
 
-	v, ok := s.messages[id]
+	if s.spill == nil {
+		return nil
+	}
 
-	return v, ok
+	return s.spill.close()
 }
 
-func (s *DownloadCache) GetAttachment(id string) ([]byte, bool) {
-	s.attachmentLock.RLock()
-	defer s.attachmentLock.RUnlock()
+// Count returns the number of messages and attachments in the cache, in memory or spilled.
+func (s *DownloadCache) Count() (int, int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
 
-	v, ok := s.attachments[id]
+	var count [2]int
 
-	return v, ok
+	for key := range s.entries {
+		count[key.kind]++
+	}
+
+	if s.spill != nil {
+		for key := range s.spill.files {
+			count[key.kind]++
+		}
+	}
+
+	return count[cacheKindMessage], count[cacheKindAttachment]
 }
 
-func (s *DownloadCache) Clear() {
-	s.messageLock.Lock()
-	s.messages = make(map[string]proton.Message, 64)
-	s.messageLock.Unlock()
+// MemoryUsage returns the size of the messages and attachments kept in memory.
+func (s *DownloadCache) MemoryUsage() uint64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
 
-	s.attachmentLock.Lock()
-	s.attachments = make(map[string][]byte, 64)
-	s.attachmentLock.Unlock()
+	return s.memory
 }
 
-func (s *DownloadCache) Count() (int, int) {
-	var (
-		messageCount    int
-		attachmentCount int
-	)
+func (s *DownloadCache) store(entry *cacheEntry) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.removeUnsafe(entry.key)
+
+	s.entries[entry.key] = s.lru.PushFront(entry)
+	s.memory += entry.size
+
+	for s.limits.MaxMemory > 0 && s.memory > s.limits.MaxMemory {
+		s.evictUnsafe(s.lru.Back().Value.(*cacheEntry)) //nolint:forcetypeassert
+	}
+}
+
+func (s *DownloadCache) get(key cacheKey) (*cacheEntry, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
 
-	s.messageLock.Lock()
-	messageCount = len(s.messages)
-	s.messageLock.Unlock()
+	if elem, ok := s.entries[key]; ok {
+		s.lru.MoveToFront(elem)
+		return elem.Value.(*cacheEntry), true //nolint:forcetypeassert
+	}
+
+	if s.spill == nil {
+		return nil, false
+	}
+
+	data, ok := s.spill.read(key)
+	if !ok {
+		return nil, false
+	}
+
+	entry := &cacheEntry{key: key}
 
-	s.attachmentLock.Lock()
-	attachmentCount = len(s.attachments)
-	s.attachmentLock.Unlock()
+	switch key.kind {
+	case cacheKindMessage:
+		if err := json.Unmarshal(data, &entry.message); err != nil {
+			logrus.WithError(err).Warn("Failed to decode spilled message")
+			s.spill.remove(key)
+
+			return nil, false
+		}
+
+	case cacheKindAttachment:
+		entry.attachment = data
+	}
+
+	return entry, true
+}
+
+func (s *DownloadCache) delete(kind cacheKind, ids []string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, id := range ids {
+		s.removeUnsafe(cacheKey{kind: kind, id: id})
+	}
+}
+
+func (s *DownloadCache) removeUnsafe(key cacheKey) {
+	if elem, ok := s.entries[key]; ok {
+		s.memory -= elem.Value.(*cacheEntry).size //nolint:forcetypeassert
+		s.lru.Remove(elem)
+		delete(s.entries, key)
+	}
+
+	if s.spill != nil {
+		s.spill.remove(key)
+	}
+}
+
+// evictUnsafe moves the given entry from memory to the spill area, if any.
+func (s *DownloadCache) evictUnsafe(entry *cacheEntry) {
+	s.memory -= entry.size
+	s.lru.Remove(s.entries[entry.key])
+	delete(s.entries, entry.key)
+
+	if s.spill == nil {
+		return
+	}
+
+	data := entry.attachment
+
+	if entry.key.kind == cacheKindMessage {
+		b, err := json.Marshal(entry.message)
+		if err != nil {
+			logrus.WithError(err).Warn("Failed to encode message to spill")
+			return
+		}
+
+		data = b
+	}
+
+	s.spill.write(entry.key, data)
+}
+
+// messageCacheSize estimates the memory used by the given message, which is mostly its encrypted body.
+func messageCacheSize(message proton.Message) uint64 {
+	size := len(message.Body) + len(message.Header) + len(message.Subject) + 1024
+
+	for _, attachment := range message.Attachments {
+		size += len(attachment.KeyPackets) + len(attachment.Signature) + 256
+	}
 
-	return messageCount, attachmentCount
+	return uint64(size)
 }
@@ -0,0 +1,189 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package syncservice
+
+import (
+	"container/list"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/sirupsen/logrus"
+)
+
+// spillArea stores the entries evicted from the download cache in files, encrypted with a random key which is never
+// written anywhere: the files are unreadable once the cache is gone, e.g. after a crash, and are removed on start.
+// It isn't safe for concurrent use; the download cache guards it.
+type spillArea struct {
+	dir     string
+	aead    cipher.AEAD
+	maxSize uint64
+
+	files map[cacheKey]*list.Element // Elements of order, holding spillFile.
+	order *list.List                 // Oldest first.
+	size  uint64
+}
+
+type spillFile struct {
+	key  cacheKey
+	size uint64
+}
+
+func newSpillArea(dir string, maxSize uint64) (*spillArea, error) {
+	key := make([]byte, 32)
+
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	if maxSize == 0 {
+		maxSize = DefaultDownloadCacheMaxDisk
+	}
+
+	// Whatever was spilled before can't be decrypted anymore.
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, fmt.Errorf("failed to remove previous spill area: %w", err)
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create spill area: %w", err)
+	}
+
+	return &spillArea{
+		dir:     dir,
+		aead:    aead,
+		maxSize: maxSize,
+		files:   make(map[cacheKey]*list.Element),
+		order:   list.New(),
+	}, nil
+}
+
+// write stores the given data, removing the oldest files if the spill area is full.
+// Data which can't be written is dropped, as it can always be downloaded again.
+func (a *spillArea) write(key cacheKey, data []byte) {
+	a.remove(key)
+
+	nonce := make([]byte, a.aead.NonceSize())
+
+	if _, err := rand.Read(nonce); err != nil {
+		logrus.WithError(err).Warn("Failed to generate nonce for spilled download")
+		return
+	}
+
+	sealed := a.aead.Seal(nonce, nonce, data, []byte(a.path(key)))
+	size := uint64(len(sealed))
+
+	if size > a.maxSize {
+		return
+	}
+
+	for a.size+size > a.maxSize {
+		a.remove(a.order.Front().Value.(spillFile).key) //nolint:forcetypeassert
+	}
+
+	if err := os.WriteFile(a.path(key), sealed, 0o600); err != nil {
+		logrus.WithError(err).Warn("Failed to spill download to disk")
+		return
+	}
+
+	a.files[key] = a.order.PushBack(spillFile{key: key, size: size})
+	a.size += size
+}
+
+// read returns the data stored for the given key, if any.
+func (a *spillArea) read(key cacheKey) ([]byte, bool) {
+	if _, ok := a.files[key]; !ok {
+		return nil, false
+	}
+
+	sealed, err := os.ReadFile(a.path(key))
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to read spilled download")
+		a.remove(key)
+
+		return nil, false
+	}
+
+	nonceSize := a.aead.NonceSize()
+
+	if len(sealed) < nonceSize {
+		a.remove(key)
+		return nil, false
+	}
+
+	data, err := a.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], []byte(a.path(key)))
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to decrypt spilled download")
+		a.remove(key)
+
+		return nil, false
+	}
+
+	return data, true
+}
+
+func (a *spillArea) remove(key cacheKey) {
+	elem, ok := a.files[key]
+	if !ok {
+		return
+	}
+
+	if err := os.Remove(a.path(key)); err != nil && !os.IsNotExist(err) {
+		logrus.WithError(err).Warn("Failed to remove spilled download")
+	}
+
+	a.size -= elem.Value.(spillFile).size //nolint:forcetypeassert
+	a.order.Remove(elem)
+	delete(a.files, key)
+}
+
+func (a *spillArea) clear() {
+	for key := range a.files {
+		a.remove(key)
+	}
+}
+
+// close removes the spill area.
+func (a *spillArea) close() error {
+	a.clear()
+
+	return os.RemoveAll(a.dir)
+}
+
+// path returns the path of the file of the given key; message and attachment IDs are hashed, as they aren't
+// meant to be file names.
+func (a *spillArea) path(key cacheKey) string {
+	hash := sha256.Sum256([]byte(key.id))
+
+	return filepath.Join(a.dir, fmt.Sprintf("%d-%s", key.kind, hex.EncodeToString(hash[:])))
+}
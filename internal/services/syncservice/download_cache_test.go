@@ -0,0 +1,131 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package syncservice
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownloadCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newDownloadCache(DownloadCacheLimits{MaxMemory: 25})
+
+	cache.StoreAttachment("a", bytes.Repeat([]byte("a"), 10))
+	cache.StoreAttachment("b", bytes.Repeat([]byte("b"), 10))
+
+	// Using a keeps it over b.
+	_, ok := cache.GetAttachment("a")
+	require.True(t, ok)
+
+	cache.StoreAttachment("c", bytes.Repeat([]byte("c"), 10))
+
+	_, ok = cache.GetAttachment("b")
+	require.False(t, ok)
+
+	for _, id := range []string{"a", "c"} {
+		_, ok := cache.GetAttachment(id)
+		require.True(t, ok)
+	}
+
+	require.Equal(t, uint64(20), cache.MemoryUsage())
+
+	// Deleting frees the memory.
+	cache.DeleteAttachments("a", "c")
+	require.Zero(t, cache.MemoryUsage())
+}
+
+func TestDownloadCache_SpillsToDisk(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "spill")
+
+	// Files left by a previous cache can't be decrypted and are removed.
+	require.NoError(t, os.MkdirAll(dir, 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "stale"), []byte("stale"), 0o600))
+
+	cache := newDownloadCache(DownloadCacheLimits{MaxMemory: 2048, SpillDir: dir})
+
+	require.NoFileExists(t, filepath.Join(dir, "stale"))
+
+	message := proton.Message{
+		MessageMetadata: proton.MessageMetadata{ID: "msg", Subject: "subject"},
+		Header:          "Subject: subject\r\n",
+		ParsedHeaders:   proton.Headers{Values: map[string][]string{"Subject": {"subject"}}, Order: []string{"Subject"}},
+		Body:            "-----BEGIN PGP MESSAGE-----",
+		Attachments: []proton.Attachment{{
+			ID:         "att",
+			Name:       "file.txt",
+			Headers:    proton.Headers{Values: map[string][]string{"Content-Type": {"text/plain"}}, Order: []string{"Content-Type"}},
+			KeyPackets: "keys",
+		}},
+	}
+
+	cache.StoreMessage(message)
+	cache.StoreAttachment("att", bytes.Repeat([]byte("secret"), 300))
+
+	// The message didn't fit along with the attachment; it was spilled, encrypted.
+	require.Equal(t, uint64(1800), cache.MemoryUsage())
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	data, err := os.ReadFile(filepath.Join(dir, files[0].Name()))
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "PGP MESSAGE")
+
+	spilled, ok := cache.GetMessage("msg")
+	require.True(t, ok)
+	require.Equal(t, message, spilled)
+
+	messages, attachments := cache.Count()
+	require.Equal(t, 1, messages)
+	require.Equal(t, 1, attachments)
+
+	// Deleting the message removes its file.
+	cache.DeleteMessages("msg")
+
+	files, err = os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Empty(t, files)
+
+	// Closing the cache removes the spill area.
+	require.NoError(t, cache.Close())
+	require.NoDirExists(t, dir)
+}
+
+func TestDownloadCache_SpillAreaIsBounded(t *testing.T) {
+	cache := newDownloadCache(DownloadCacheLimits{MaxMemory: 100, SpillDir: t.TempDir(), MaxDisk: 250})
+
+	for _, id := range []string{"a", "b", "c", "d"} {
+		cache.StoreAttachment(id, bytes.Repeat([]byte(id), 100))
+	}
+
+	// d is in memory, c is spilled, and b was removed from the spill area to make room for c; a was before.
+	for id, cached := range map[string]bool{"a": false, "b": false, "c": true, "d": true} {
+		data, ok := cache.GetAttachment(id)
+		require.Equal(t, cached, ok, id)
+
+		if cached {
+			require.Equal(t, bytes.Repeat([]byte(id), 100), data)
+		}
+	}
+}
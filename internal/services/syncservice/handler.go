@@ -40,6 +40,7 @@ type Handler struct {
 	client         APIClient
 	userID         string
 	syncState      StateProvider
+	checkpoints    CheckpointStore
 	log            *logrus.Entry
 	group          *async.Group
 	syncFinishedCh chan error
@@ -52,25 +53,32 @@ func NewHandler(
 	client APIClient,
 	userID string,
 	state StateProvider,
+	checkpoints CheckpointStore,
 	log *logrus.Entry,
 	panicHandler async.PanicHandler,
+	cacheLimits DownloadCacheLimits,
 ) *Handler {
 	return &Handler{
 		client:         client,
 		userID:         userID,
 		syncState:      state,
+		checkpoints:    checkpoints,
 		log:            log,
 		syncFinishedCh: make(chan error),
 		group:          async.NewGroup(context.Background(), panicHandler),
 		regulator:      regulator,
 		panicHandler:   panicHandler,
-		downloadCache:  newDownloadCache(),
+		downloadCache:  newDownloadCache(cacheLimits),
 	}
 }
 
 func (t *Handler) Close() {
 	t.group.CancelAndWait()
 	close(t.syncFinishedCh)
+
+	if err := t.downloadCache.Close(); err != nil {
+		t.log.WithError(err).Warn("Failed to remove download cache spill area")
+	}
 }
 
 func (t *Handler) CancelAndWait() {
@@ -90,6 +98,7 @@ func (t *Handler) Execute(
 	labels LabelMap,
 	updateApplier UpdateApplier,
 	messageBuilder MessageBuilder,
+	messageFilter MessageFilter,
 	coolDown time.Duration,
 ) {
 	t.log.Info("Sync triggered")
@@ -103,7 +112,7 @@ func (t *Handler) Execute(
 			if err = ctx.Err(); err != nil {
 				t.log.WithError(err).Error("Sync aborted")
 				break
-			} else if err = t.run(ctx, syncReporter, labels, updateApplier, messageBuilder); err != nil {
+			} else if err = t.run(ctx, syncReporter, labels, updateApplier, messageBuilder, messageFilter); err != nil {
 				t.log.WithError(err).Error("Failed to sync, will retry later")
 				sleepCtx(ctx, coolDown)
 			} else {
@@ -131,6 +140,7 @@ func (t *Handler) run(ctx context.Context,
 	labels LabelMap,
 	updateApplier UpdateApplier,
 	messageBuilder MessageBuilder,
+	messageFilter MessageFilter,
 ) error {
 	syncStatus, err := t.syncState.GetSyncStatus(ctx)
 	if err != nil {
@@ -193,15 +203,29 @@ func (t *Handler) run(ctx context.Context,
 	if !syncStatus.HasMessages {
 		t.log.Info("Syncing messages")
 
+		checkpoint, err := t.checkpoints.GetSyncCheckpoint(ctx)
+		if err != nil {
+			t.log.WithError(err).Warn("Failed to load sync checkpoint, resuming from the last synced message")
+			checkpoint = Checkpoint{}
+		} else if !checkpoint.IsEmpty() {
+			t.log.WithFields(logrus.Fields{
+				"metadataEndID": checkpoint.MetadataEndID,
+				"batches":       len(checkpoint.Batches),
+			}).Info("Resuming sync from checkpoint")
+		}
+
 		stageContext := NewJob(
 			ctx,
 			t.client,
 			t.userID,
 			labels,
 			messageBuilder,
+			messageFilter,
 			updateApplier,
 			syncReporter,
 			t.syncState,
+			t.checkpoints,
+			checkpoint,
 			t.panicHandler,
 			t.downloadCache,
 			t.log,
@@ -223,6 +247,10 @@ func (t *Handler) run(ctx context.Context,
 			return fmt.Errorf("failed to set sync as completed: %w", err)
 		}
 
+		if err := t.checkpoints.ClearSyncCheckpoint(ctx); err != nil {
+			t.log.WithError(err).Warn("Failed to clear sync checkpoint")
+		}
+
 		t.log.Info("Synced messages")
 	} else {
 		t.log.Info("Messages are already synced, skipping")
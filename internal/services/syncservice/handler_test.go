@@ -90,12 +90,16 @@ func TestTask_NoStateAndSucceeds(t *testing.T) {
 
 	tt.syncReporter.EXPECT().OnProgress(gomock.Any(), gomock.Eq(MessageDelta))
 
+	// A checkpoint left by an interrupted sync is cleared once the messages are synced.
+	tt.checkpoints.checkpoint = Checkpoint{MetadataEndID: MessageID, Batches: [][]string{{MessageID}}}
+
 	// First run.
-	err := tt.task.run(context.Background(), tt.syncReporter, labels, tt.updateApplier, tt.messageBuilder)
+	err := tt.task.run(context.Background(), tt.syncReporter, labels, tt.updateApplier, tt.messageBuilder, nil)
 	require.NoError(t, err)
+	require.True(t, tt.checkpoints.checkpoint.IsEmpty())
 
 	// Second Run, it's completed sync labels only.
-	err = tt.task.run(context.Background(), tt.syncReporter, labels, tt.updateApplier, tt.messageBuilder)
+	err = tt.task.run(context.Background(), tt.syncReporter, labels, tt.updateApplier, tt.messageBuilder, nil)
 	require.NoError(t, err)
 }
 
@@ -141,7 +145,7 @@ func TestTask_StateHasLabels(t *testing.T) {
 
 	tt.syncReporter.EXPECT().OnProgress(gomock.Any(), gomock.Eq(MessageDelta))
 
-	err := tt.task.run(context.Background(), tt.syncReporter, labels, tt.updateApplier, tt.messageBuilder)
+	err := tt.task.run(context.Background(), tt.syncReporter, labels, tt.updateApplier, tt.messageBuilder, nil)
 	require.NoError(t, err)
 }
 
@@ -177,7 +181,7 @@ func TestTask_StateHasLabelsAndMessageCount(t *testing.T) {
 
 	tt.syncReporter.EXPECT().OnProgress(gomock.Any(), gomock.Eq(MessageDelta))
 
-	err := tt.task.run(context.Background(), tt.syncReporter, labels, tt.updateApplier, tt.messageBuilder)
+	err := tt.task.run(context.Background(), tt.syncReporter, labels, tt.updateApplier, tt.messageBuilder, nil)
 	require.NoError(t, err)
 }
 
@@ -205,7 +209,7 @@ func TestTask_StateHasSyncedState(t *testing.T) {
 
 	tt.updateApplier.EXPECT().SyncSystemLabelsOnly(gomock.Any(), gomock.Eq(labels)).Return(nil)
 
-	err := tt.task.run(context.Background(), tt.syncReporter, labels, tt.updateApplier, tt.messageBuilder)
+	err := tt.task.run(context.Background(), tt.syncReporter, labels, tt.updateApplier, tt.messageBuilder, nil)
 	require.NoError(t, err)
 }
 
@@ -272,7 +276,7 @@ func TestTask_RepeatsOnSyncFailure(t *testing.T) {
 	tt.syncReporter.EXPECT().OnFinished(gomock.Any())
 	tt.syncReporter.EXPECT().OnProgress(gomock.Any(), gomock.Eq(MessageDelta))
 
-	tt.task.Execute(tt.syncReporter, labels, tt.updateApplier, tt.messageBuilder, time.Microsecond)
+	tt.task.Execute(tt.syncReporter, labels, tt.updateApplier, tt.messageBuilder, nil, time.Microsecond)
 	require.NoError(t, <-tt.task.OnSyncFinishedCH())
 }
 
@@ -325,6 +329,7 @@ type thandler struct {
 	messageBuilder *MockMessageBuilder
 	client         *MockAPIClient
 	syncReporter   *MockReporter
+	checkpoints    *testCheckpointStore
 }
 
 func (t thandler) addMessageSyncCompletedExpectation(messageID string, delta int64) { //nolint:unparam
@@ -343,7 +348,8 @@ func newTestHandler(mockCtrl *gomock.Controller, userID string) thandler { // no
 	client := NewMockAPIClient(mockCtrl)
 	messageBuilder := NewMockMessageBuilder(mockCtrl)
 	syncReporter := NewMockReporter(mockCtrl)
-	task := NewHandler(regulator, client, userID, syncState, logrus.WithField("test", "test"), &async.NoopPanicHandler{})
+	checkpoints := &testCheckpointStore{}
+	task := NewHandler(regulator, client, userID, syncState, checkpoints, logrus.WithField("test", "test"), &async.NoopPanicHandler{}, DownloadCacheLimits{})
 
 	return thandler{
 		task:           task,
@@ -353,5 +359,6 @@ func newTestHandler(mockCtrl *gomock.Controller, userID string) thandler { // no
 		messageBuilder: messageBuilder,
 		syncReporter:   syncReporter,
 		client:         client,
+		checkpoints:    checkpoints,
 	}
 }
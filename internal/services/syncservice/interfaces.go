@@ -20,15 +20,18 @@ package syncservice
 import (
 	"bytes"
 	"context"
+	"time"
 
 	"github.com/ProtonMail/gluon/imap"
 	"github.com/ProtonMail/go-proton-api"
 	"github.com/ProtonMail/gopenpgp/v2/crypto"
 	"github.com/bradenaw/juniper/xmaps"
+	"golang.org/x/exp/slices"
 )
 
 type StateProvider interface {
-	AddFailedMessageID(context.Context, ...string) error
+	// AddFailedMessageID records that the given messages failed to sync for the given reason.
+	AddFailedMessageID(ctx context.Context, reason string, ids ...string) error
 	RemFailedMessageID(context.Context, ...string) error
 	GetSyncStatus(context.Context) (Status, error)
 	ClearSyncStatus(context.Context) error
@@ -38,6 +41,38 @@ type StateProvider interface {
 	SetMessageCount(context.Context, int64) error
 }
 
+// CheckpointStore persists the checkpoint of the message stage of a sync, so that it resumes where it left off after a
+// restart or a crash rather than from the last synced message.
+type CheckpointStore interface {
+	GetSyncCheckpoint(context.Context) (Checkpoint, error)
+	SetSyncCheckpoint(context.Context, Checkpoint) error
+	ClearSyncCheckpoint(context.Context) error
+}
+
+// Checkpoint is how far the message stage of a sync got beyond the last synced message.
+type Checkpoint struct {
+	// MetadataEndID is the last message batched for download; the message list is paged on from it.
+	MetadataEndID string
+
+	// Batches hold the IDs of the messages batched for download but not applied yet, in order.
+	Batches [][]string
+}
+
+// IsEmpty returns whether the checkpoint holds nothing to resume from.
+func (c Checkpoint) IsEmpty() bool {
+	return c.MetadataEndID == "" && len(c.Batches) == 0
+}
+
+func (c Checkpoint) clone() Checkpoint {
+	batches := make([][]string, 0, len(c.Batches))
+
+	for _, batch := range c.Batches {
+		batches = append(batches, slices.Clone(batch))
+	}
+
+	return Checkpoint{MetadataEndID: c.MetadataEndID, Batches: batches}
+}
+
 type Status struct {
 	HasLabels           bool
 	HasMessages         bool
@@ -46,11 +81,25 @@ type Status struct {
 	LastSyncedMessageID string
 	NumSyncedMessages   int64
 	TotalMessageCount   int64
+
+	// FailedMessageInfo holds why and how often the messages in FailedMessages failed.
+	FailedMessageInfo map[string]FailedMessageInfo
+
+	// SkippedMessages are failed messages the user chose to stop retrying; they aren't recorded as failed again.
+	SkippedMessages xmaps.Set[string]
+}
+
+type FailedMessageInfo struct {
+	Reason      string
+	Attempts    int
+	LastAttempt time.Time
 }
 
 func DefaultStatus() Status {
 	return Status{
-		FailedMessages: make(map[string]struct{}),
+		FailedMessages:    make(map[string]struct{}),
+		FailedMessageInfo: make(map[string]FailedMessageInfo),
+		SkippedMessages:   make(map[string]struct{}),
 	}
 }
 
@@ -74,6 +123,11 @@ type MessageBuilder interface {
 	BuildMessage(apiLabels map[string]proton.Label, full proton.FullMessage, addrKR *crypto.KeyRing, buffer *bytes.Buffer) (BuildResult, error)
 }
 
+// MessageFilter selects the messages which are synced; the others are skipped without being downloaded.
+type MessageFilter interface {
+	WantMessage(labels LabelMap, metadata proton.MessageMetadata) bool
+}
+
 type UpdateApplier interface {
 	ApplySyncUpdates(ctx context.Context, updates []BuildResult) error
 	SyncSystemLabelsOnly(ctx context.Context, labels map[string]proton.Label) error
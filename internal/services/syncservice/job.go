@@ -39,12 +39,14 @@ type Job struct {
 	ctx    context.Context
 	cancel func()
 
-	client APIClient
-	state  StateProvider
+	client     APIClient
+	state      StateProvider
+	checkpoint *checkpointTracker
 
 	userID         string
 	labels         LabelMap
 	messageBuilder MessageBuilder
+	messageFilter  MessageFilter
 	updateApplier  UpdateApplier
 	syncReporter   Reporter
 
@@ -65,9 +67,12 @@ func NewJob(ctx context.Context,
 	userID string,
 	labels LabelMap,
 	messageBuilder MessageBuilder,
+	messageFilter MessageFilter,
 	updateApplier UpdateApplier,
 	syncReporter Reporter,
 	state StateProvider,
+	checkpoints CheckpointStore,
+	checkpoint Checkpoint,
 	panicHandler async.PanicHandler,
 	cache *DownloadCache,
 	log *logrus.Entry,
@@ -80,9 +85,11 @@ func NewJob(ctx context.Context,
 		userID:         userID,
 		cancel:         cancel,
 		state:          state,
+		checkpoint:     newCheckpointTracker(checkpoints, checkpoint),
 		log:            log,
 		labels:         labels,
 		messageBuilder: messageBuilder,
+		messageFilter:  messageFilter,
 		updateApplier:  updateApplier,
 		syncReporter:   syncReporter,
 		errorCh:        async.NewQueuedChannel[error](4, 8, panicHandler, fmt.Sprintf("sync-job-error-%v", userID)),
@@ -91,6 +98,16 @@ func NewJob(ctx context.Context,
 	}
 }
 
+// GetSyncStatus returns the sync status of the job's user.
+func (j *Job) GetSyncStatus(ctx context.Context) (Status, error) {
+	return j.state.GetSyncStatus(ctx)
+}
+
+// wantMessage returns whether the given message is synced; a job without filter syncs all messages.
+func (j *Job) wantMessage(metadata proton.MessageMetadata) bool {
+	return j.messageFilter == nil || j.messageFilter.WantMessage(j.labels, metadata)
+}
+
 func (j *Job) Close() {
 	j.errorCh.CloseAndDiscardQueued()
 	j.wg.Wait()
@@ -113,6 +130,13 @@ func (j *Job) onStageCompleted(ctx context.Context, count int64) {
 }
 
 func (j *Job) onJobFinished(ctx context.Context, lastMessageID string, count int64) {
+	// The checkpoint goes first: if bridge stops in between, the batch isn't applied again.
+	if err := j.checkpoint.applied(ctx, lastMessageID); err != nil {
+		j.log.WithError(err).Error("Failed to store sync checkpoint")
+		j.onError(err)
+		return
+	}
+
 	if err := j.state.SetLastMessageID(ctx, lastMessageID, count); err != nil {
 		j.log.WithError(err).Error("Failed to store last synced message id")
 		j.onError(err)
@@ -20,6 +20,7 @@ package syncservice
 import (
 	"context"
 	"errors"
+	"sync"
 	"testing"
 
 	"github.com/ProtonMail/gluon/async"
@@ -198,6 +199,7 @@ type tjob struct {
 	updateApplier  *MockUpdateApplier
 	syncReporter   *MockReporter
 	state          *MockStateProvider
+	checkpoints    *testCheckpointStore
 }
 
 func newTestJob(
@@ -211,6 +213,7 @@ func newTestJob(
 	updateApplier := NewMockUpdateApplier(mockCtrl)
 	syncReporter := NewMockReporter(mockCtrl)
 	state := NewMockStateProvider(mockCtrl)
+	checkpoints := &testCheckpointStore{}
 
 	job := NewJob(
 		ctx,
@@ -218,11 +221,14 @@ func newTestJob(
 		userID,
 		labels,
 		messageBuilder,
+		nil,
 		updateApplier,
 		syncReporter,
 		state,
+		checkpoints,
+		Checkpoint{},
 		&async.NoopPanicHandler{},
-		newDownloadCache(),
+		newDownloadCache(DownloadCacheLimits{}),
 		logrus.WithField("s", "test"),
 	)
 
@@ -233,5 +239,37 @@ func newTestJob(
 		updateApplier:  updateApplier,
 		syncReporter:   syncReporter,
 		state:          state,
+		checkpoints:    checkpoints,
 	}
 }
+
+// testCheckpointStore keeps the sync checkpoint in memory.
+type testCheckpointStore struct {
+	checkpoint Checkpoint
+	lock       sync.Mutex
+}
+
+func (s *testCheckpointStore) GetSyncCheckpoint(_ context.Context) (Checkpoint, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.checkpoint.clone(), nil
+}
+
+func (s *testCheckpointStore) SetSyncCheckpoint(_ context.Context, checkpoint Checkpoint) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.checkpoint = checkpoint.clone()
+
+	return nil
+}
+
+func (s *testCheckpointStore) ClearSyncCheckpoint(_ context.Context) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.checkpoint = Checkpoint{}
+
+	return nil
+}
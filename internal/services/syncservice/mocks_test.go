@@ -332,10 +332,10 @@ func (m *MockStateProvider) EXPECT() *MockStateProviderMockRecorder {
 }
 
 // AddFailedMessageID mocks base method.
-func (m *MockStateProvider) AddFailedMessageID(arg0 context.Context, arg1 ...string) error {
+func (m *MockStateProvider) AddFailedMessageID(arg0 context.Context, arg1 string, arg2 ...string) error {
 	m.ctrl.T.Helper()
-	varargs := []interface{}{arg0}
-	for _, a := range arg1 {
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
 		varargs = append(varargs, a)
 	}
 	ret := m.ctrl.Call(m, "AddFailedMessageID", varargs...)
@@ -344,9 +344,9 @@ func (m *MockStateProvider) AddFailedMessageID(arg0 context.Context, arg1 ...str
 }
 
 // AddFailedMessageID indicates an expected call of AddFailedMessageID.
-func (mr *MockStateProviderMockRecorder) AddFailedMessageID(arg0 interface{}, arg1 ...interface{}) *gomock.Call {
+func (mr *MockStateProviderMockRecorder) AddFailedMessageID(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	varargs := append([]interface{}{arg0}, arg1...)
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddFailedMessageID", reflect.TypeOf((*MockStateProvider)(nil).AddFailedMessageID), varargs...)
 }
 
@@ -125,7 +125,7 @@ func (b *BuildStage) run(ctx context.Context) {
 					if !ok {
 						req.job.log.Errorf("Address '%v' on message '%v' does not have an unlocked kerying", msg.AddressID, msg.ID)
 
-						if err := req.job.state.AddFailedMessageID(req.getContext(), msg.ID); err != nil {
+						if err := req.job.state.AddFailedMessageID(req.getContext(), "no unlocked keyring for the message's address", msg.ID); err != nil {
 							req.job.log.WithError(err).Error("Failed to add failed message ID")
 						}
 
@@ -142,7 +142,7 @@ func (b *BuildStage) run(ctx context.Context) {
 					if err != nil {
 						req.job.log.WithError(err).WithField("msgID", msg.ID).Error("Failed to build message (syn)")
 
-						if err := req.job.state.AddFailedMessageID(req.getContext(), msg.ID); err != nil {
+						if err := req.job.state.AddFailedMessageID(req.getContext(), err.Error(), msg.ID); err != nil {
 							req.job.log.WithError(err).Error("Failed to add failed message ID")
 						}
 
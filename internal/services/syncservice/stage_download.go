@@ -109,6 +109,17 @@ func (d *DownloadStage) run(ctx context.Context) {
 						return proton.FullMessage{}, nil
 					}
 
+					// Quarantine the message rather than failing the whole batch: retrying won't help.
+					if isPermanentMessageError(err) {
+						d.log.WithError(err).WithField("msgID", input).Error("Failed to download message, quarantining")
+
+						if err := request.job.state.AddFailedMessageID(ctx, err.Error(), input); err != nil {
+							return proton.FullMessage{}, err
+						}
+
+						return proton.FullMessage{}, nil
+					}
+
 					return proton.FullMessage{}, err
 				}
 
@@ -192,6 +203,8 @@ func (d *DownloadStage) run(ctx context.Context) {
 
 func downloadMessage(ctx context.Context, cache *DownloadCache, client APIClient, id string) (proton.Message, error) {
 	msg, ok := cache.GetMessage(id)
+	observeDownloadCache("message", ok)
+
 	if ok {
 		return msg, nil
 	}
@@ -206,8 +219,25 @@ func downloadMessage(ctx context.Context, cache *DownloadCache, client APIClient
 	return msg, nil
 }
 
+// isPermanentMessageError returns whether the API rejected a single message in a way that retrying won't fix.
+func isPermanentMessageError(err error) bool {
+	var apiErr *proton.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+
+	switch apiErr.Status {
+	case 401, 403, 408, 422, 429:
+		return false
+	}
+
+	return apiErr.Status >= 400 && apiErr.Status < 500
+}
+
 func downloadAttachment(ctx context.Context, cache *DownloadCache, client APIClient, id string, size int64) ([]byte, error) {
 	data, ok := cache.GetAttachment(id)
+	observeDownloadCache("attachment", ok)
+
 	if ok {
 		return data, nil
 	}
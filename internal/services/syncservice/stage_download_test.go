@@ -35,7 +35,7 @@ import (
 func TestDownloadMessage_NotInCache(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	client := NewMockAPIClient(mockCtrl)
-	cache := newDownloadCache()
+	cache := newDownloadCache(DownloadCacheLimits{})
 	client.EXPECT().GetMessage(gomock.Any(), gomock.Any()).Return(proton.Message{}, nil)
 
 	_, err := downloadMessage(context.Background(), cache, client, "msg")
@@ -45,7 +45,7 @@ func TestDownloadMessage_NotInCache(t *testing.T) {
 func TestDownloadMessage_InCache(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	client := NewMockAPIClient(mockCtrl)
-	cache := newDownloadCache()
+	cache := newDownloadCache(DownloadCacheLimits{})
 
 	msg := proton.Message{
 		MessageMetadata: proton.MessageMetadata{ID: "msg", Size: 1024},
@@ -61,7 +61,7 @@ func TestDownloadMessage_InCache(t *testing.T) {
 func TestDownloadAttachment_NotInCache(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	client := NewMockAPIClient(mockCtrl)
-	cache := newDownloadCache()
+	cache := newDownloadCache(DownloadCacheLimits{})
 	client.EXPECT().GetAttachmentInto(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
 
 	_, err := downloadAttachment(context.Background(), cache, client, "id", 1024)
@@ -71,7 +71,7 @@ func TestDownloadAttachment_NotInCache(t *testing.T) {
 func TestDownloadAttachment_InCache(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	client := NewMockAPIClient(mockCtrl)
-	cache := newDownloadCache()
+	cache := newDownloadCache(DownloadCacheLimits{})
 	attachment := []byte("hello world")
 	cache.StoreAttachment("id", attachment)
 
@@ -248,6 +248,49 @@ func TestDownloadStage_RunWith422(t *testing.T) {
 	require.Zero(t, cachedAttachments)
 }
 
+func TestDownloadStage_QuarantinesPermanentlyFailingMessage(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+
+	input := NewChannelConsumerProducer[DownloadRequest]()
+	output := NewChannelConsumerProducer[BuildRequest]()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	tj := newTestJob(ctx, mockCtrl, "", map[string]proton.Label{})
+
+	msg := proton.Message{MessageMetadata: proton.MessageMetadata{ID: "ok"}}
+
+	tj.client.EXPECT().GetMessage(gomock.Any(), gomock.Eq("bad")).Return(proton.Message{}, &proton.APIError{Status: 404, Message: "not found"})
+	tj.client.EXPECT().GetMessage(gomock.Any(), gomock.Eq("ok")).Return(msg, nil)
+	tj.state.EXPECT().AddFailedMessageID(gomock.Any(), gomock.Any(), gomock.Eq([]string{"bad"})).Return(nil)
+
+	tj.syncReporter.EXPECT().OnProgress(gomock.Any(), gomock.Any())
+	tj.state.EXPECT().SetLastMessageID(gomock.Any(), gomock.Eq("f"), gomock.Eq(int64(2))).Return(nil)
+
+	tj.syncReporter.EXPECT().OnProgress(gomock.Any(), gomock.Eq(int64(2)))
+
+	tj.job.begin()
+	defer tj.job.end()
+	childJob := tj.job.newChildJob("f", 2)
+
+	stage := NewDownloadStage(input, output, 4, &async.NoopPanicHandler{})
+
+	go func() {
+		stage.run(ctx)
+	}()
+
+	input.Produce(ctx, DownloadRequest{
+		childJob: childJob,
+		ids:      []string{"bad", "ok"},
+	})
+
+	out, err := output.Consume(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []proton.FullMessage{{Message: msg}}, out.batch)
+	out.onFinished(ctx)
+	cancel()
+}
+
 func TestDownloadStage_CancelledJobIsDiscarded(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 
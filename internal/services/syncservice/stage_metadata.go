@@ -20,6 +20,7 @@ package syncservice
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	"github.com/ProtonMail/gluon/async"
 	"github.com/ProtonMail/gluon/logging"
@@ -132,6 +133,7 @@ type metadataIterator struct {
 	stage          *Job
 	client         *network.ProtonClientRetryWrapper[APIClient]
 	lastMessageID  string
+	pending        [][]string
 	remaining      []proton.MessageMetadata
 	downloadReqIDs []string
 	expectedSize   uint64
@@ -142,21 +144,45 @@ func newMetadataIterator(ctx context.Context, stage *Job, metadataPageSize int,
 	if err != nil {
 		return nil, err
 	}
+
+	// Batches checkpointed by a previous run are downloaded again as they were, and paging goes on after them.
+	lastMessageID, pending := stage.checkpoint.resume()
+	if lastMessageID == "" {
+		lastMessageID = syncStatus.LastSyncedMessageID
+	}
+
 	return &metadataIterator{
 		stage:          stage,
 		client:         network.NewClientRetryWrapper(stage.client, coolDown),
-		lastMessageID:  syncStatus.LastSyncedMessageID,
+		lastMessageID:  lastMessageID,
+		pending:        pending,
 		remaining:      nil,
 		downloadReqIDs: make([]string, 0, metadataPageSize),
 	}, nil
 }
 
 func (m *metadataIterator) Next(maxDownloadMem uint64, metadataPageSize int, maxMessages int) (DownloadRequest, bool, error) {
+	// The messages skipped by the filter count as done for all stages.
+	var skipped int64
+
+	defer func() {
+		if skipped > 0 {
+			m.stage.onStageCompleted(m.stage.ctx, skipped*NumSyncStages)
+		}
+	}()
+
 	for {
 		if m.stage.ctx.Err() != nil {
 			return DownloadRequest{}, false, m.stage.ctx.Err()
 		}
 
+		if len(m.pending) != 0 {
+			ids := m.pending[0]
+			m.pending = m.pending[1:]
+
+			return DownloadRequest{childJob: m.stage.newChildJob(ids[len(ids)-1], int64(len(ids))), ids: ids}, true, nil
+		}
+
 		if len(m.remaining) == 0 {
 			metadata, err := network.RetryWithClient(m.stage.ctx, m.client, func(ctx context.Context, c APIClient) ([]proton.MessageMetadata, error) {
 				// To get the metadata of the messages in batches we need to initialize the state with a call to
@@ -206,13 +232,20 @@ func (m *metadataIterator) Next(maxDownloadMem uint64, metadataPageSize int, max
 
 		if len(m.remaining) == 0 {
 			if len(m.downloadReqIDs) != 0 {
-				return DownloadRequest{childJob: m.stage.newChildJob(m.downloadReqIDs[len(m.downloadReqIDs)-1], int64(len(m.downloadReqIDs))), ids: m.downloadReqIDs}, false, nil
+				req, err := m.newDownloadRequest(m.downloadReqIDs)
+
+				return req, false, err
 			}
 
 			return DownloadRequest{}, false, nil
 		}
 
 		for idx, meta := range m.remaining {
+			if !m.stage.wantMessage(meta) {
+				skipped++
+				continue
+			}
+
 			nextSize := m.expectedSize + uint64(meta.Size)
 			if nextSize >= maxDownloadMem || len(m.downloadReqIDs) >= maxMessages {
 				m.expectedSize = 0
@@ -220,7 +253,9 @@ func (m *metadataIterator) Next(maxDownloadMem uint64, metadataPageSize int, max
 				downloadReqIDs := m.downloadReqIDs
 				m.downloadReqIDs = make([]string, 0, metadataPageSize)
 
-				return DownloadRequest{childJob: m.stage.newChildJob(downloadReqIDs[len(downloadReqIDs)-1], int64(len(downloadReqIDs))), ids: downloadReqIDs}, true, nil
+				req, err := m.newDownloadRequest(downloadReqIDs)
+
+				return req, err == nil, err
 			}
 
 			m.downloadReqIDs = append(m.downloadReqIDs, meta.ID)
@@ -230,3 +265,12 @@ func (m *metadataIterator) Next(maxDownloadMem uint64, metadataPageSize int, max
 		m.remaining = nil
 	}
 }
+
+// newDownloadRequest checkpoints the given batch before it goes down the pipeline.
+func (m *metadataIterator) newDownloadRequest(ids []string) (DownloadRequest, error) {
+	if err := m.stage.checkpoint.addBatch(m.stage.ctx, ids); err != nil {
+		return DownloadRequest{}, fmt.Errorf("failed to store sync checkpoint: %w", err)
+	}
+
+	return DownloadRequest{childJob: m.stage.newChildJob(ids[len(ids)-1], int64(len(ids))), ids: ids}, nil
+}
@@ -30,6 +30,7 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/exp/slices"
 )
 
 const TestMetadataPageSize = 5
@@ -328,6 +329,126 @@ func TestMetadataIterator_RespectsSizeLimit(t *testing.T) {
 	require.Equal(t, []string{testMsgID(3)}, j.ids)
 }
 
+func TestMetadataIterator_ResumesFromCheckpoint(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	ctx := context.Background()
+	tj := newTestJob(ctx, mockCtrl, "u", getTestLabels())
+
+	tj.state.EXPECT().GetSyncStatus(gomock.Any()).Return(Status{
+		LastSyncedMessageID: testMsgID(0),
+	}, nil)
+
+	tj.job.checkpoint = newCheckpointTracker(tj.checkpoints, Checkpoint{
+		MetadataEndID: testMsgID(2),
+		Batches:       [][]string{{testMsgID(1), testMsgID(2)}},
+	})
+
+	// Paging goes on after the checkpointed batch rather than after the last synced message.
+	tj.client.EXPECT().GetMessageMetadataPage(
+		gomock.Any(),
+		gomock.Eq(0),
+		gomock.Eq(TestMetadataPageSize),
+		gomock.Eq(proton.MessageFilter{Desc: true, EndID: testMsgID(2)}),
+	).Return([]proton.MessageMetadata{
+		{
+			ID:   testMsgID(2),
+			Size: 100,
+		},
+		{
+			ID:   testMsgID(3),
+			Size: 100,
+		},
+	}, nil)
+
+	tj.client.EXPECT().GetMessageMetadataPage(
+		gomock.Any(),
+		gomock.Eq(0),
+		gomock.Eq(TestMetadataPageSize),
+		gomock.Eq(proton.MessageFilter{Desc: true, EndID: testMsgID(3)}),
+	).Return([]proton.MessageMetadata{
+		{
+			ID:   testMsgID(3),
+			Size: 100,
+		},
+	}, nil)
+
+	iter, err := newMetadataIterator(ctx, tj.job, TestMetadataPageSize, &network.NoCoolDown{})
+	require.NoError(t, err)
+
+	// The checkpointed batch is downloaded again first.
+	j, hasMore, err := iter.Next(TestMaxDownloadMem, TestMetadataPageSize, TestMaxMessages)
+	require.NoError(t, err)
+	require.True(t, hasMore)
+	require.Equal(t, []string{testMsgID(1), testMsgID(2)}, j.ids)
+
+	j, hasMore, err = iter.Next(TestMaxDownloadMem, TestMetadataPageSize, TestMaxMessages)
+	require.NoError(t, err)
+	require.False(t, hasMore)
+	require.Equal(t, []string{testMsgID(3)}, j.ids)
+
+	// New batches are checkpointed...
+	require.Equal(t, Checkpoint{
+		MetadataEndID: testMsgID(3),
+		Batches:       [][]string{{testMsgID(1), testMsgID(2)}, {testMsgID(3)}},
+	}, tj.checkpoints.checkpoint)
+
+	// ... until their messages are applied, possibly a chunk at a time.
+	require.NoError(t, tj.job.checkpoint.applied(ctx, testMsgID(1)))
+	require.Equal(t, [][]string{{testMsgID(2)}, {testMsgID(3)}}, tj.checkpoints.checkpoint.Batches)
+
+	require.NoError(t, tj.job.checkpoint.applied(ctx, testMsgID(3)))
+	require.Empty(t, tj.checkpoints.checkpoint.Batches)
+	require.Equal(t, testMsgID(3), tj.checkpoints.checkpoint.MetadataEndID)
+}
+
+func TestMetadataIterator_SkipsFilteredMessages(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	ctx := context.Background()
+	tj := newTestJob(ctx, mockCtrl, "u", getTestLabels())
+
+	tj.job.messageFilter = testLabelFilter(proton.SpamLabel)
+
+	tj.state.EXPECT().GetSyncStatus(gomock.Any()).Return(Status{}, nil)
+
+	tj.client.EXPECT().GetMessageMetadataPage(
+		gomock.Any(),
+		gomock.Eq(0),
+		gomock.Eq(TestMetadataPageSize),
+		gomock.Eq(proton.MessageFilter{Desc: true}),
+	).Return([]proton.MessageMetadata{
+		{ID: testMsgID(0), Size: 100, LabelIDs: []string{proton.InboxLabel}},
+		{ID: testMsgID(1), Size: 100, LabelIDs: []string{proton.SpamLabel}},
+		{ID: testMsgID(2), Size: 100, LabelIDs: []string{proton.InboxLabel}},
+	}, nil)
+
+	tj.client.EXPECT().GetMessageMetadataPage(
+		gomock.Any(),
+		gomock.Eq(0),
+		gomock.Eq(TestMetadataPageSize),
+		gomock.Eq(proton.MessageFilter{Desc: true, EndID: testMsgID(2)}),
+	).Return([]proton.MessageMetadata{
+		{ID: testMsgID(2), Size: 100, LabelIDs: []string{proton.InboxLabel}},
+	}, nil)
+
+	// The skipped message counts as done for all stages.
+	tj.syncReporter.EXPECT().OnProgress(gomock.Any(), gomock.Eq(int64(NumSyncStages)))
+
+	iter, err := newMetadataIterator(ctx, tj.job, TestMetadataPageSize, &network.NoCoolDown{})
+	require.NoError(t, err)
+
+	j, hasMore, err := iter.Next(TestMaxDownloadMem, TestMetadataPageSize, TestMaxMessages)
+	require.NoError(t, err)
+	require.False(t, hasMore)
+	require.Equal(t, []string{testMsgID(0), testMsgID(2)}, j.ids)
+}
+
+// testLabelFilter skips the messages with the given label.
+type testLabelFilter string
+
+func (f testLabelFilter) WantMessage(_ LabelMap, metadata proton.MessageMetadata) bool {
+	return !slices.Contains(metadata.LabelIDs, string(f))
+}
+
 func testMsgID(i int) string {
 	return fmt.Sprintf("msg-id-%v", i)
 }
@@ -386,6 +507,7 @@ func newTestFixedMetadataJob(
 	updateApplier := NewMockUpdateApplier(mockCtrl)
 	syncReporter := NewMockReporter(mockCtrl)
 	state := NewMockStateProvider(mockCtrl)
+	checkpoints := &testCheckpointStore{}
 	client := newFixedMetadataClient(50)
 
 	job := NewJob(
@@ -394,11 +516,14 @@ func newTestFixedMetadataJob(
 		userID,
 		labels,
 		messageBuilder,
+		nil,
 		updateApplier,
 		syncReporter,
 		state,
+		checkpoints,
+		Checkpoint{},
 		&async.NoopPanicHandler{},
-		newDownloadCache(),
+		newDownloadCache(DownloadCacheLimits{}),
 		logrus.WithField("s", "test"),
 	)
 
@@ -409,6 +534,7 @@ func newTestFixedMetadataJob(
 		updateApplier:  updateApplier,
 		syncReporter:   syncReporter,
 		state:          state,
+		checkpoints:    checkpoints,
 	}
 }
 
@@ -0,0 +1,402 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package unifiedinbox implements a gluon connector exposing the inboxes of all loaded users as a single mailbox.
+package unifiedinbox
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ProtonMail/gluon/async"
+	"github.com/ProtonMail/gluon/connector"
+	"github.com/ProtonMail/gluon/imap"
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/proton-bridge/v3/internal/services/imapservice"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/exp/maps"
+)
+
+const (
+	// Username is the IMAP username used to log in to the unified inbox.
+	Username = "all-accounts"
+
+	// AddrID is the ID under which the unified inbox is registered with the IMAP server.
+	AddrID = "unified-inbox"
+
+	// MailboxID is the ID of the single mailbox exposed by the unified inbox.
+	MailboxID = imap.MailboxID("unified-inbox")
+)
+
+// Source provides the inbox of a single user.
+type Source interface {
+	ID() string
+	GetInboxMessages(ctx context.Context) ([]proton.MessageMetadata, error)
+	BuildMessage(ctx context.Context, messageID string) (*imap.MessageCreated, error)
+	SetMessagesSeen(ctx context.Context, messageIDs []string, seen bool) error
+	SetMessagesFlagged(ctx context.Context, messageIDs []string, flagged bool) error
+}
+
+// Connector multiplexes the inboxes of several users into a single read-only mailbox.
+// Only flag changes are allowed; they are routed to the user owning the message.
+type Connector struct {
+	log *logrus.Entry
+
+	// password is the encoded password used to log in to the unified inbox.
+	password     []byte
+	passwordLock sync.RWMutex
+
+	sources     map[string]Source
+	sourcesLock sync.RWMutex
+
+	// state holds the flags of the messages currently in the mailbox, keyed by unified message ID.
+	state     *state
+	cache     connector.IMAPState
+	cacheLock sync.RWMutex
+
+	refreshLock sync.Mutex
+	updateCh    *async.QueuedChannel[imap.Update]
+}
+
+func NewConnector(password []byte, panicHandler async.PanicHandler) *Connector {
+	return &Connector{
+		log:      logrus.WithField("pkg", "unified-inbox"),
+		password: password,
+		sources:  make(map[string]Source),
+		state:    newState(),
+		updateCh: async.NewQueuedChannel[imap.Update](0, 0, panicHandler, "unified-inbox-update"),
+	}
+}
+
+// SetPassword replaces the encoded password used to log in to the unified inbox.
+func (c *Connector) SetPassword(password []byte) {
+	c.passwordLock.Lock()
+	defer c.passwordLock.Unlock()
+
+	c.password = password
+}
+
+// AddSource adds the inbox of the given user to the unified inbox.
+func (c *Connector) AddSource(source Source) {
+	c.sourcesLock.Lock()
+	defer c.sourcesLock.Unlock()
+
+	c.sources[source.ID()] = source
+}
+
+// RemoveSource removes the inbox of the given user from the unified inbox.
+// Its messages are removed from the mailbox.
+func (c *Connector) RemoveSource(ctx context.Context, userID string) error {
+	c.sourcesLock.Lock()
+	delete(c.sources, userID)
+	c.sourcesLock.Unlock()
+
+	c.refreshLock.Lock()
+	defer c.refreshLock.Unlock()
+
+	return c.apply(ctx, userID, nil, nil)
+}
+
+// CountSources returns the number of users whose inbox is part of the unified inbox.
+func (c *Connector) CountSources() int {
+	c.sourcesLock.RLock()
+	defer c.sourcesLock.RUnlock()
+
+	return len(c.sources)
+}
+
+// Refresh synchronizes the unified inbox with the current inbox of every user.
+func (c *Connector) Refresh(ctx context.Context) error {
+	c.sourcesLock.RLock()
+	sources := maps.Values(c.sources)
+	c.sourcesLock.RUnlock()
+
+	c.refreshLock.Lock()
+	defer c.refreshLock.Unlock()
+
+	for _, source := range sources {
+		if err := c.refreshSource(ctx, source); err != nil {
+			return fmt.Errorf("failed to refresh inbox of user %v: %w", source.ID(), err)
+		}
+	}
+
+	return nil
+}
+
+func (c *Connector) refreshSource(ctx context.Context, source Source) error {
+	messages, err := source.GetInboxMessages(ctx)
+	if err != nil {
+		return err
+	}
+
+	return c.apply(ctx, source.ID(), messages, source)
+}
+
+// apply brings the messages of the given user in line with the given inbox content.
+// The source is used to build messages not yet part of the mailbox; it may be nil if there are no messages.
+func (c *Connector) apply(ctx context.Context, userID string, messages []proton.MessageMetadata, source Source) error {
+	if !c.isInitialized() {
+		return nil
+	}
+
+	var updates []imap.Update
+
+	inInbox := make(map[string]struct{}, len(messages))
+
+	for _, message := range messages {
+		id := newMessageID(userID, message.ID)
+		flags := imapservice.BuildFlagSetFromMessageMetadata(message)
+
+		inInbox[id] = struct{}{}
+
+		known, ok := c.state.get(id)
+		if !ok {
+			created, err := source.BuildMessage(ctx, message.ID)
+			if err != nil {
+				c.log.WithError(err).WithField("messageID", message.ID).Warn("Failed to build message")
+				continue
+			}
+
+			created.Message.ID = imap.MessageID(id)
+			created.Message.Flags = flags
+			created.MailboxIDs = []imap.MailboxID{MailboxID}
+
+			updates = append(updates, imap.NewMessagesCreated(true, created))
+		} else if !known.Equals(flags) {
+			updates = append(updates, imap.NewMessageFlagsUpdated(imap.MessageID(id), flags))
+		}
+
+		c.state.set(id, flags)
+	}
+
+	for _, id := range c.state.ids(userID) {
+		if _, ok := inInbox[id]; !ok {
+			updates = append(updates, imap.NewMessagesDeleted(imap.MessageID(id)))
+			c.state.delete(id)
+		}
+	}
+
+	if len(updates) == 0 {
+		return nil
+	}
+
+	for _, update := range updates {
+		c.updateCh.Enqueue(update)
+	}
+
+	for _, update := range updates {
+		if err, ok := update.WaitContext(ctx); ok && err != nil {
+			return fmt.Errorf("failed to apply update %v: %w", update.String(), err)
+		}
+	}
+
+	return c.storeState(ctx)
+}
+
+func (c *Connector) Init(ctx context.Context, cache connector.IMAPState) error {
+	c.cacheLock.Lock()
+	defer c.cacheLock.Unlock()
+
+	if err := cache.Read(ctx, func(ctx context.Context, read connector.IMAPStateRead) error {
+		value, ok, err := read.GetSettings(ctx)
+		if err != nil || !ok {
+			return err
+		}
+
+		return c.state.load(value)
+	}); err != nil {
+		return fmt.Errorf("failed to load unified inbox state: %w", err)
+	}
+
+	c.cache = cache
+
+	c.updateCh.Enqueue(imap.NewMailboxCreated(imap.Mailbox{
+		ID:             MailboxID,
+		Name:           []string{imap.Inbox},
+		Flags:          defaultFlags,
+		PermanentFlags: defaultFlags,
+		Attributes:     imap.NewFlagSet(),
+	}))
+
+	return nil
+}
+
+func (c *Connector) isInitialized() bool {
+	c.cacheLock.RLock()
+	defer c.cacheLock.RUnlock()
+
+	return c.cache != nil
+}
+
+func (c *Connector) storeState(ctx context.Context) error {
+	c.cacheLock.RLock()
+	defer c.cacheLock.RUnlock()
+
+	value, err := c.state.save()
+	if err != nil {
+		return err
+	}
+
+	return c.cache.Write(ctx, func(ctx context.Context, write connector.IMAPStateWrite) error {
+		return write.StoreSettings(ctx, value)
+	})
+}
+
+func (c *Connector) Authorize(_ context.Context, username string, password []byte) bool {
+	if !strings.EqualFold(username, Username) {
+		return false
+	}
+
+	c.passwordLock.RLock()
+	defer c.passwordLock.RUnlock()
+
+	// The bridge passwords of the users are deliberately not accepted: each only grants access to its own user.
+	return len(c.password) > 0 && subtle.ConstantTimeCompare(c.password, password) == 1
+}
+
+func (c *Connector) CreateMailbox(context.Context, connector.IMAPStateWrite, []string) (imap.Mailbox, error) {
+	return imap.Mailbox{}, connector.ErrOperationNotAllowed
+}
+
+func (c *Connector) GetMessageLiteral(ctx context.Context, id imap.MessageID) ([]byte, error) {
+	source, messageID, err := c.getSource(id)
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := source.BuildMessage(ctx, messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	return created.Literal, nil
+}
+
+func (c *Connector) GetMailboxVisibility(context.Context, imap.MailboxID) imap.MailboxVisibility {
+	return imap.Visible
+}
+
+func (c *Connector) UpdateMailboxName(context.Context, connector.IMAPStateWrite, imap.MailboxID, []string) error {
+	return connector.ErrOperationNotAllowed
+}
+
+func (c *Connector) DeleteMailbox(context.Context, connector.IMAPStateWrite, imap.MailboxID) error {
+	return connector.ErrOperationNotAllowed
+}
+
+func (c *Connector) CreateMessage(context.Context, connector.IMAPStateWrite, imap.MailboxID, []byte, imap.FlagSet, time.Time) (imap.Message, []byte, error) {
+	return imap.Message{}, nil, connector.ErrOperationNotAllowed
+}
+
+func (c *Connector) AddMessagesToMailbox(context.Context, connector.IMAPStateWrite, []imap.MessageID, imap.MailboxID) error {
+	return connector.ErrOperationNotAllowed
+}
+
+func (c *Connector) RemoveMessagesFromMailbox(context.Context, connector.IMAPStateWrite, []imap.MessageID, imap.MailboxID) error {
+	return connector.ErrOperationNotAllowed
+}
+
+func (c *Connector) MoveMessages(context.Context, connector.IMAPStateWrite, []imap.MessageID, imap.MailboxID, imap.MailboxID) (bool, error) {
+	return false, connector.ErrOperationNotAllowed
+}
+
+func (c *Connector) MarkMessagesSeen(ctx context.Context, _ connector.IMAPStateWrite, messageIDs []imap.MessageID, seen bool) error {
+	return c.routeFlag(ctx, messageIDs, imap.FlagSeen, seen, Source.SetMessagesSeen)
+}
+
+func (c *Connector) MarkMessagesFlagged(ctx context.Context, _ connector.IMAPStateWrite, messageIDs []imap.MessageID, flagged bool) error {
+	return c.routeFlag(ctx, messageIDs, imap.FlagFlagged, flagged, Source.SetMessagesFlagged)
+}
+
+// routeFlag groups the given messages by owner and applies the flag change through the owning source.
+func (c *Connector) routeFlag(
+	ctx context.Context,
+	messageIDs []imap.MessageID,
+	flag string,
+	value bool,
+	fn func(Source, context.Context, []string, bool) error,
+) error {
+	bySource := make(map[Source][]string)
+
+	for _, id := range messageIDs {
+		source, messageID, err := c.getSource(id)
+		if err != nil {
+			return err
+		}
+
+		bySource[source] = append(bySource[source], messageID)
+	}
+
+	for source, ids := range bySource {
+		if err := fn(source, ctx, ids, value); err != nil {
+			return err
+		}
+	}
+
+	for _, id := range messageIDs {
+		if flags, ok := c.state.get(string(id)); ok {
+			c.state.set(string(id), flags.Set(flag, value))
+		}
+	}
+
+	return nil
+}
+
+func (c *Connector) getSource(id imap.MessageID) (Source, string, error) {
+	userID, messageID, ok := strings.Cut(string(id), "/")
+	if !ok {
+		return nil, "", fmt.Errorf("invalid unified inbox message ID %q", id)
+	}
+
+	c.sourcesLock.RLock()
+	defer c.sourcesLock.RUnlock()
+
+	source, ok := c.sources[userID]
+	if !ok {
+		return nil, "", fmt.Errorf("no such user %q: %w", userID, connector.ErrOperationNotAllowed)
+	}
+
+	return source, messageID, nil
+}
+
+func (c *Connector) GetUpdates() <-chan imap.Update {
+	return c.updateCh.GetChannel()
+}
+
+func (c *Connector) Close(context.Context) error {
+	c.cacheLock.Lock()
+	defer c.cacheLock.Unlock()
+
+	c.cache = nil
+
+	return nil
+}
+
+// StateClose discards any pending update; the connector must not be used afterwards.
+func (c *Connector) StateClose() {
+	c.updateCh.CloseAndDiscardQueued()
+}
+
+func newMessageID(userID, messageID string) string {
+	return userID + "/" + messageID
+}
+
+var defaultFlags = imap.NewFlagSet(imap.FlagSeen, imap.FlagFlagged) // nolint:gochecknoglobals
@@ -0,0 +1,116 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package unifiedinbox
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ProtonMail/gluon/async"
+	"github.com/ProtonMail/gluon/imap"
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/stretchr/testify/require"
+)
+
+type testSource struct {
+	id   string
+	seen []string
+}
+
+func (s *testSource) ID() string {
+	return s.id
+}
+
+func (s *testSource) GetInboxMessages(context.Context) ([]proton.MessageMetadata, error) {
+	return nil, nil
+}
+
+func (s *testSource) BuildMessage(context.Context, string) (*imap.MessageCreated, error) {
+	return nil, nil
+}
+
+func (s *testSource) SetMessagesSeen(_ context.Context, messageIDs []string, _ bool) error {
+	s.seen = append(s.seen, messageIDs...)
+	return nil
+}
+
+func (s *testSource) SetMessagesFlagged(context.Context, []string, bool) error {
+	return nil
+}
+
+func TestConnector_Authorize(t *testing.T) {
+	c := NewConnector([]byte("unified"), async.NoopPanicHandler{})
+	defer c.StateClose()
+
+	c.AddSource(&testSource{id: "user1"})
+	c.AddSource(&testSource{id: "user2"})
+
+	require.True(t, c.Authorize(context.Background(), Username, []byte("unified")))
+	require.True(t, c.Authorize(context.Background(), "All-Accounts", []byte("unified")))
+	require.False(t, c.Authorize(context.Background(), Username, []byte("wrong")))
+	require.False(t, c.Authorize(context.Background(), "user1", []byte("unified")))
+
+	c.SetPassword([]byte("rotated"))
+	require.False(t, c.Authorize(context.Background(), Username, []byte("unified")))
+	require.True(t, c.Authorize(context.Background(), Username, []byte("rotated")))
+
+	// Without a password, nobody can log in.
+	empty := NewConnector(nil, async.NoopPanicHandler{})
+	defer empty.StateClose()
+
+	require.False(t, empty.Authorize(context.Background(), Username, nil))
+}
+
+func TestConnector_MarkMessagesSeen(t *testing.T) {
+	c := NewConnector([]byte("unified"), async.NoopPanicHandler{})
+	defer c.StateClose()
+
+	source1, source2 := &testSource{id: "user1"}, &testSource{id: "user2"}
+
+	c.AddSource(source1)
+	c.AddSource(source2)
+
+	// Flag changes are routed to the user owning the message.
+	require.NoError(t, c.MarkMessagesSeen(context.Background(), nil, []imap.MessageID{"user1/a", "user2/b", "user1/c"}, true))
+	require.ElementsMatch(t, []string{"a", "c"}, source1.seen)
+	require.ElementsMatch(t, []string{"b"}, source2.seen)
+
+	// Messages of unknown users are rejected.
+	require.Error(t, c.MarkMessagesSeen(context.Background(), nil, []imap.MessageID{"user3/d"}, true))
+}
+
+func TestState_SaveLoad(t *testing.T) {
+	s := newState()
+
+	s.set(newMessageID("user1", "a"), imap.NewFlagSet(imap.FlagSeen))
+	s.set(newMessageID("user1", "b"), imap.NewFlagSet())
+	s.set(newMessageID("user2", "c"), imap.NewFlagSet(imap.FlagFlagged))
+
+	value, err := s.save()
+	require.NoError(t, err)
+
+	loaded := newState()
+	require.NoError(t, loaded.load(value))
+
+	require.ElementsMatch(t, []string{"user1/a", "user1/b"}, loaded.ids("user1"))
+	require.ElementsMatch(t, []string{"user2/c"}, loaded.ids("user2"))
+
+	flags, ok := loaded.get("user2/c")
+	require.True(t, ok)
+	require.True(t, flags.Contains(imap.FlagFlagged))
+}
@@ -0,0 +1,163 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package unifiedinbox
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"github.com/ProtonMail/gluon/imap"
+	"github.com/ProtonMail/proton-bridge/v3/internal/services/syncservice"
+	"github.com/bradenaw/juniper/xmaps"
+)
+
+// state tracks the flags of the messages in the unified inbox.
+// It is persisted in the gluon database so that changes made while bridge was not running are picked up.
+type state struct {
+	messages map[string]imap.FlagSet
+	lock     sync.RWMutex
+}
+
+func newState() *state {
+	return &state{messages: make(map[string]imap.FlagSet)}
+}
+
+func (s *state) get(id string) (imap.FlagSet, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	flags, ok := s.messages[id]
+
+	return flags, ok
+}
+
+func (s *state) set(id string, flags imap.FlagSet) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.messages[id] = flags
+}
+
+func (s *state) delete(id string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.messages, id)
+}
+
+// ids returns the IDs of the known messages belonging to the given user.
+func (s *state) ids(userID string) []string {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	var ids []string
+
+	for id := range s.messages {
+		if strings.HasPrefix(id, userID+"/") {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids
+}
+
+func (s *state) clear() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.messages = make(map[string]imap.FlagSet)
+}
+
+func (s *state) load(value string) error {
+	var messages map[string][]string
+
+	if err := json.Unmarshal([]byte(value), &messages); err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.messages = make(map[string]imap.FlagSet, len(messages))
+
+	for id, flags := range messages {
+		s.messages[id] = imap.NewFlagSetFromSlice(flags)
+	}
+
+	return nil
+}
+
+func (s *state) save() (string, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	messages := make(map[string][]string, len(s.messages))
+
+	for id, flags := range s.messages {
+		messages[id] = flags.ToSlice()
+	}
+
+	b, err := json.Marshal(messages)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+// The connector is its own sync state provider: there is nothing to sync up front,
+// messages are added to the mailbox when the inboxes are refreshed.
+
+func (c *Connector) AddFailedMessageID(context.Context, string, ...string) error {
+	return nil
+}
+
+func (c *Connector) RemFailedMessageID(context.Context, ...string) error {
+	return nil
+}
+
+func (c *Connector) GetSyncStatus(context.Context) (syncservice.Status, error) {
+	return syncservice.Status{
+		HasLabels:      true,
+		HasMessages:    true,
+		FailedMessages: xmaps.Set[string]{},
+	}, nil
+}
+
+func (c *Connector) ClearSyncStatus(context.Context) error {
+	c.state.clear()
+	return nil
+}
+
+func (c *Connector) SetHasLabels(context.Context, bool) error {
+	return nil
+}
+
+func (c *Connector) SetHasMessages(context.Context, bool) error {
+	return nil
+}
+
+func (c *Connector) SetLastMessageID(context.Context, string, int64) error {
+	return nil
+}
+
+func (c *Connector) SetMessageCount(context.Context, int64) error {
+	return nil
+}
@@ -34,11 +34,18 @@ import (
 	"github.com/ProtonMail/proton-bridge/v3/internal/events"
 	"github.com/ProtonMail/proton-bridge/v3/internal/network"
 	"github.com/ProtonMail/proton-bridge/v3/internal/services/orderedtasks"
+	"github.com/ProtonMail/proton-bridge/v3/internal/telemetry/metrics"
 	"github.com/ProtonMail/proton-bridge/v3/pkg/cpc"
 	"github.com/bradenaw/juniper/xmaps"
 	"github.com/sirupsen/logrus"
 )
 
+var eventLoopErrors = metrics.NewCounterVec(
+	"bridge_event_loop_errors_total",
+	"Errors of the API event loop by stage: fetching the events, applying them or storing the new event ID.",
+	"stage",
+)
+
 // Service polls from the given event source and ensures that all the respective subscribers get notified
 // before proceeding to the next event. The events are published in the following order:
 // * Refresh
@@ -249,6 +256,7 @@ func (s *Service) run(ctx context.Context, lastEventID string) {
 		})
 		if err != nil {
 			s.log.WithError(err).Errorf("Failed to get event (caused by %T)", internal.ErrCause(err))
+			eventLoopErrors.WithLabelValues("fetch").Inc()
 			continue
 		}
 
@@ -272,12 +280,14 @@ func (s *Service) run(ctx context.Context, lastEventID string) {
 				subscriberName = "?"
 			}
 			s.log.WithField("subscriber", subscriberName).WithError(err).Errorf("Failed to apply event")
+			eventLoopErrors.WithLabelValues("apply").Inc()
 			continue
 		}
 
 		newEventID := newEvents[len(newEvents)-1].EventID
 		if err := s.eventIDStore.Store(ctx, newEventID); err != nil {
 			s.log.WithError(err).Errorf("Failed to store new event ID: %v", err)
+			eventLoopErrors.WithLabelValues("store").Inc()
 			s.onBadEvent(ctx, events.UserBadEvent{
 				Error:  fmt.Errorf("failed to store new event ID: %w", err),
 				UserID: s.userID,
@@ -25,6 +25,7 @@ import (
 
 	"github.com/ProtonMail/go-proton-api"
 	"github.com/ProtonMail/gopenpgp/v2/crypto"
+	"github.com/ProtonMail/proton-bridge/v3/internal/secret"
 	"github.com/ProtonMail/proton-bridge/v3/internal/usertypes"
 	"github.com/ProtonMail/proton-bridge/v3/pkg/algo"
 	"golang.org/x/exp/maps"
@@ -236,6 +237,8 @@ func (s *State) CheckAuth(email string, password []byte, bridgePassProvider Brid
 		return "", fmt.Errorf("failed to decode password: %w", err)
 	}
 
+	defer secret.Wipe(dec)
+
 	if subtle.ConstantTimeCompare(bridgePassProvider.BridgePass(), dec) != 1 {
 		return "", fmt.Errorf("invalid password")
 	}
@@ -0,0 +1,116 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package metrics
+
+import "sort"
+
+// NewCounter declares a counter in the registry.
+func (r *Registry) NewCounter(name, help string) *Counter {
+	counter := &Counter{}
+
+	r.register(name, help, "counter", nil, counter)
+
+	return counter
+}
+
+// NewCounterVec declares a family of counters partitioned by the given labels in the registry.
+func (r *Registry) NewCounterVec(name, help string, labels ...string) CounterVec {
+	vec := CounterVec{newVec(labels, func() *Counter { return &Counter{} })}
+
+	r.register(name, help, "counter", labels, vec)
+
+	return vec
+}
+
+// NewGauge declares a gauge in the registry.
+func (r *Registry) NewGauge(name, help string) *Gauge {
+	gauge := &Gauge{}
+
+	r.register(name, help, "gauge", nil, gauge)
+
+	return gauge
+}
+
+// NewGaugeVec declares a family of gauges partitioned by the given labels in the registry.
+func (r *Registry) NewGaugeVec(name, help string, labels ...string) GaugeVec {
+	vec := GaugeVec{newVec(labels, func() *Gauge { return &Gauge{} })}
+
+	r.register(name, help, "gauge", labels, vec)
+
+	return vec
+}
+
+// NewHistogram declares a histogram with the given bucket upper bounds in the registry.
+func (r *Registry) NewHistogram(name, help string, buckets []float64) *Histogram {
+	histogram := newHistogram(sortedBuckets(buckets))
+
+	r.register(name, help, "histogram", nil, histogram)
+
+	return histogram
+}
+
+// NewHistogramVec declares a family of histograms with the given bucket upper bounds, partitioned by the given
+// labels, in the registry.
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) HistogramVec {
+	buckets = sortedBuckets(buckets)
+
+	vec := HistogramVec{newVec(labels, func() *Histogram { return newHistogram(buckets) })}
+
+	r.register(name, help, "histogram", labels, vec)
+
+	return vec
+}
+
+// NewCounter declares a counter in the default registry.
+func NewCounter(name, help string) *Counter {
+	return Default.NewCounter(name, help)
+}
+
+// NewCounterVec declares a family of counters partitioned by the given labels in the default registry.
+func NewCounterVec(name, help string, labels ...string) CounterVec {
+	return Default.NewCounterVec(name, help, labels...)
+}
+
+// NewGauge declares a gauge in the default registry.
+func NewGauge(name, help string) *Gauge {
+	return Default.NewGauge(name, help)
+}
+
+// NewGaugeVec declares a family of gauges partitioned by the given labels in the default registry.
+func NewGaugeVec(name, help string, labels ...string) GaugeVec {
+	return Default.NewGaugeVec(name, help, labels...)
+}
+
+// NewHistogram declares a histogram with the given bucket upper bounds in the default registry.
+func NewHistogram(name, help string, buckets []float64) *Histogram {
+	return Default.NewHistogram(name, help, buckets)
+}
+
+// NewHistogramVec declares a family of histograms with the given bucket upper bounds, partitioned by the given
+// labels, in the default registry.
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) HistogramVec {
+	return Default.NewHistogramVec(name, help, buckets, labels...)
+}
+
+func sortedBuckets(buckets []float64) []float64 {
+	buckets = append([]float64(nil), buckets...)
+
+	sort.Float64s(buckets)
+
+	return buckets
+}
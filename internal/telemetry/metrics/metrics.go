@@ -0,0 +1,283 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultBuckets are the upper bounds of the histogram buckets suited to durations in seconds.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Counter is a value which only goes up, such as the number of requests made.
+type Counter struct {
+	value atomicFloat
+}
+
+// Inc adds one to the counter.
+func (c *Counter) Inc() {
+	c.value.add(1)
+}
+
+// Add adds the given delta to the counter; negative deltas are ignored, as counters only go up.
+func (c *Counter) Add(delta float64) {
+	if delta > 0 {
+		c.value.add(delta)
+	}
+}
+
+// Value returns the current value of the counter.
+func (c *Counter) Value() float64 {
+	return c.value.load()
+}
+
+func (c *Counter) collect(labels []labelPair) []sample {
+	return []sample{{labels: labels, value: c.value.load()}}
+}
+
+// Gauge is a value which goes up and down, such as the number of open connections.
+type Gauge struct {
+	value atomicFloat
+}
+
+// Set sets the gauge to the given value.
+func (g *Gauge) Set(value float64) {
+	g.value.store(value)
+}
+
+// Inc adds one to the gauge.
+func (g *Gauge) Inc() {
+	g.value.add(1)
+}
+
+// Dec subtracts one from the gauge.
+func (g *Gauge) Dec() {
+	g.value.add(-1)
+}
+
+// Value returns the current value of the gauge.
+func (g *Gauge) Value() float64 {
+	return g.value.load()
+}
+
+func (g *Gauge) collect(labels []labelPair) []sample {
+	return []sample{{labels: labels, value: g.value.load()}}
+}
+
+// Histogram counts observations, such as request durations, in buckets.
+type Histogram struct {
+	buckets []float64
+	counts  []atomic.Uint64 // Not cumulative; the last one counts the observations above every bucket.
+	count   atomic.Uint64
+	sum     atomicFloat
+}
+
+func newHistogram(buckets []float64) *Histogram {
+	return &Histogram{
+		buckets: buckets,
+		counts:  make([]atomic.Uint64, len(buckets)+1),
+	}
+}
+
+// Observe adds the given observation to the histogram.
+func (h *Histogram) Observe(value float64) {
+	h.counts[sort.SearchFloat64s(h.buckets, value)].Add(1)
+	h.count.Add(1)
+	h.sum.add(value)
+}
+
+// Count returns the number of observations.
+func (h *Histogram) Count() uint64 {
+	return h.count.Load()
+}
+
+func (h *Histogram) collect(labels []labelPair) []sample {
+	samples := make([]sample, 0, len(h.buckets)+3)
+
+	var cumulative uint64
+
+	for idx := range h.counts {
+		bound := math.Inf(1)
+		if idx < len(h.buckets) {
+			bound = h.buckets[idx]
+		}
+
+		cumulative += h.counts[idx].Load()
+
+		samples = append(samples, sample{
+			suffix: "_bucket",
+			labels: append(labels[:len(labels):len(labels)], labelPair{name: "le", value: formatValue(bound)}),
+			value:  float64(cumulative),
+		})
+	}
+
+	return append(samples,
+		sample{suffix: "_sum", labels: labels, value: h.sum.load()},
+		sample{suffix: "_count", labels: labels, value: float64(cumulative)},
+	)
+}
+
+// CounterVec is a family of counters partitioned by label values.
+type CounterVec struct {
+	*vec[*Counter]
+}
+
+// WithLabelValues returns the counter with the given label values, in the order the labels were declared.
+func (v CounterVec) WithLabelValues(values ...string) *Counter {
+	return v.with(values)
+}
+
+// GaugeVec is a family of gauges partitioned by label values.
+type GaugeVec struct {
+	*vec[*Gauge]
+}
+
+// WithLabelValues returns the gauge with the given label values, in the order the labels were declared.
+func (v GaugeVec) WithLabelValues(values ...string) *Gauge {
+	return v.with(values)
+}
+
+// DeleteLabelValues removes the gauge with the given label values, e.g. once what it measured is gone.
+func (v GaugeVec) DeleteLabelValues(values ...string) {
+	v.delete(values)
+}
+
+// HistogramVec is a family of histograms partitioned by label values.
+type HistogramVec struct {
+	*vec[*Histogram]
+}
+
+// WithLabelValues returns the histogram with the given label values, in the order the labels were declared.
+func (v HistogramVec) WithLabelValues(values ...string) *Histogram {
+	return v.with(values)
+}
+
+type vec[T interface{ collect([]labelPair) []sample }] struct {
+	labels   []string
+	newChild func() T
+
+	lock     sync.RWMutex
+	children map[string]vecChild[T]
+}
+
+type vecChild[T any] struct {
+	values []string
+	metric T
+}
+
+func newVec[T interface{ collect([]labelPair) []sample }](labels []string, newChild func() T) *vec[T] {
+	return &vec[T]{
+		labels:   labels,
+		newChild: newChild,
+		children: make(map[string]vecChild[T]),
+	}
+}
+
+// with returns the child with the given label values, creating it if needed.
+// It panics if the number of values doesn't match the number of labels, as that's a programming error.
+func (v *vec[T]) with(values []string) T {
+	if len(values) != len(v.labels) {
+		panic(fmt.Sprintf("got %d label values for labels %v", len(values), v.labels))
+	}
+
+	key := strings.Join(values, "\xff")
+
+	v.lock.RLock()
+	child, ok := v.children[key]
+	v.lock.RUnlock()
+
+	if ok {
+		return child.metric
+	}
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	if child, ok := v.children[key]; ok {
+		return child.metric
+	}
+
+	child = vecChild[T]{values: append([]string(nil), values...), metric: v.newChild()}
+
+	v.children[key] = child
+
+	return child.metric
+}
+
+func (v *vec[T]) delete(values []string) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	delete(v.children, strings.Join(values, "\xff"))
+}
+
+func (v *vec[T]) collect([]labelPair) []sample {
+	v.lock.RLock()
+	defer v.lock.RUnlock()
+
+	keys := make([]string, 0, len(v.children))
+
+	for key := range v.children {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	var samples []sample
+
+	for _, key := range keys {
+		child := v.children[key]
+
+		labels := make([]labelPair, len(v.labels))
+
+		for idx, name := range v.labels {
+			labels[idx] = labelPair{name: name, value: child.values[idx]}
+		}
+
+		samples = append(samples, child.metric.collect(labels)...)
+	}
+
+	return samples
+}
+
+type atomicFloat struct {
+	bits atomic.Uint64
+}
+
+func (f *atomicFloat) load() float64 {
+	return math.Float64frombits(f.bits.Load())
+}
+
+func (f *atomicFloat) store(value float64) {
+	f.bits.Store(math.Float64bits(value))
+}
+
+func (f *atomicFloat) add(delta float64) {
+	for {
+		old := f.bits.Load()
+
+		if f.bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+delta)) {
+			return
+		}
+	}
+}
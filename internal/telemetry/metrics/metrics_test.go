@@ -0,0 +1,102 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package metrics_test
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/telemetry/metrics"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_Write(t *testing.T) {
+	reg := metrics.NewRegistry()
+
+	requests := reg.NewCounterVec("test_requests_total", "Requests made.", "method", "path")
+	requests.WithLabelValues("GET", `/a"b\c`).Inc()
+	requests.WithLabelValues("GET", `/a"b\c`).Add(2)
+	requests.WithLabelValues("POST", "/").Add(-1) // Counters only go up.
+
+	conns := reg.NewGauge("test_connections", "Open connections.\nNot closed yet.")
+	conns.Inc()
+	conns.Inc()
+	conns.Dec()
+
+	durations := reg.NewHistogram("test_duration_seconds", "Durations.", []float64{1, 0.1})
+	durations.Observe(0.05)
+	durations.Observe(0.5)
+	durations.Observe(0.5)
+	durations.Observe(3)
+
+	var b strings.Builder
+
+	require.NoError(t, reg.Write(&b))
+	require.Equal(t, strings.Join([]string{
+		"# HELP test_connections Open connections.\\nNot closed yet.",
+		"# TYPE test_connections gauge",
+		"test_connections 1",
+		"# HELP test_duration_seconds Durations.",
+		"# TYPE test_duration_seconds histogram",
+		`test_duration_seconds_bucket{le="0.1"} 1`,
+		`test_duration_seconds_bucket{le="1"} 3`,
+		`test_duration_seconds_bucket{le="+Inf"} 4`,
+		"test_duration_seconds_sum 4.05",
+		"test_duration_seconds_count 4",
+		"# HELP test_requests_total Requests made.",
+		"# TYPE test_requests_total counter",
+		`test_requests_total{method="GET",path="/a\"b\\c"} 3`,
+		`test_requests_total{method="POST",path="/"} 0`,
+		"",
+	}, "\n"), b.String())
+}
+
+func TestRegistry_Vecs(t *testing.T) {
+	reg := metrics.NewRegistry()
+
+	progress := reg.NewGaugeVec("test_progress_ratio", "Progress.", "user_id")
+	progress.WithLabelValues("user1").Set(0.5)
+	progress.WithLabelValues("user2").Set(1)
+	progress.DeleteLabelValues("user2")
+
+	durations := reg.NewHistogramVec("test_duration_seconds", "Durations.", []float64{1}, "status")
+	durations.WithLabelValues("200").Observe(2)
+
+	require.Equal(t, uint64(1), durations.WithLabelValues("200").Count())
+	require.Panics(t, func() { progress.WithLabelValues("user1", "extra") })
+
+	rec := httptest.NewRecorder()
+	reg.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	require.Equal(t, "text/plain; version=0.0.4; charset=utf-8", rec.Header().Get("Content-Type"))
+	require.Contains(t, rec.Body.String(), `test_progress_ratio{user_id="user1"} 0.5`)
+	require.NotContains(t, rec.Body.String(), "user2")
+	require.Contains(t, rec.Body.String(), `test_duration_seconds_bucket{status="200",le="1"} 0`)
+	require.Contains(t, rec.Body.String(), `test_duration_seconds_bucket{status="200",le="+Inf"} 1`)
+}
+
+func TestRegistry_InvalidDeclarations(t *testing.T) {
+	reg := metrics.NewRegistry()
+
+	reg.NewCounter("test_total", "Test.")
+
+	require.Panics(t, func() { reg.NewCounter("test_total", "Declared twice.") })
+	require.Panics(t, func() { reg.NewGauge("test-gauge", "Invalid name.") })
+	require.Panics(t, func() { reg.NewHistogramVec("test_seconds", "Reserved label.", metrics.DefaultBuckets, "le") })
+}
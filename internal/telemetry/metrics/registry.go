@@ -0,0 +1,164 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package metrics exposes bridge internals, such as sync progress, API latencies and connection counts,
+// in the Prometheus text format. Packages declare their metrics in the Default registry, where they're
+// recorded whether or not anything scrapes them; bridge only serves the registry when asked to.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Default is the registry the package-level constructors declare metrics in.
+var Default = NewRegistry()
+
+var (
+	reMetricName = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	reLabelName  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	valueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+// Registry holds metrics and writes them in the Prometheus text format.
+type Registry struct {
+	lock     sync.RWMutex
+	families map[string]family
+}
+
+type family struct {
+	help      string
+	kind      string
+	collector collector
+}
+
+type collector interface {
+	collect(labels []labelPair) []sample
+}
+
+type labelPair struct {
+	name, value string
+}
+
+type sample struct {
+	suffix string
+	labels []labelPair
+	value  float64
+}
+
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]family)}
+}
+
+// ServeHTTP serves the metrics of the registry, as scraped by Prometheus.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	if err := r.Write(w); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// Write writes the metrics of the registry in the Prometheus text format, sorted by name.
+func (r *Registry) Write(w io.Writer) error {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	names := make([]string, 0, len(r.families))
+
+	for name := range r.families {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	buf := bufio.NewWriter(w)
+
+	for _, name := range names {
+		family := r.families[name]
+
+		fmt.Fprintf(buf, "# HELP %s %s\n", name, helpEscaper.Replace(family.help))
+		fmt.Fprintf(buf, "# TYPE %s %s\n", name, family.kind)
+
+		for _, sample := range family.collector.collect(nil) {
+			buf.WriteString(name + sample.suffix)
+
+			if len(sample.labels) > 0 {
+				pairs := make([]string, 0, len(sample.labels))
+
+				for _, label := range sample.labels {
+					pairs = append(pairs, label.name+`="`+valueEscaper.Replace(label.value)+`"`)
+				}
+
+				buf.WriteString("{" + strings.Join(pairs, ",") + "}")
+			}
+
+			buf.WriteString(" " + formatValue(sample.value) + "\n")
+		}
+	}
+
+	return buf.Flush()
+}
+
+// register adds the given metric to the registry. It panics if the names are invalid or the metric is already
+// declared, as metrics are declared when the program starts.
+func (r *Registry) register(name, help, kind string, labels []string, collector collector) {
+	if !reMetricName.MatchString(name) {
+		panic(fmt.Sprintf("invalid metric name %q", name))
+	}
+
+	for _, label := range labels {
+		if !reLabelName.MatchString(label) || label == "le" {
+			panic(fmt.Sprintf("invalid label name %q of metric %q", label, name))
+		}
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if _, ok := r.families[name]; ok {
+		panic(fmt.Sprintf("metric %q is already declared", name))
+	}
+
+	r.families[name] = family{help: help, kind: kind, collector: collector}
+}
+
+func formatValue(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+
+	case math.IsInf(value, -1):
+		return "-Inf"
+
+	case math.IsNaN(value):
+		return "NaN"
+
+	default:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package templates renders the user's canned replies.
+//
+// Templates use the text/template syntax; variables are referenced as {{.Name}}.
+// Unknown variables render as empty strings.
+package templates
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// Variables set when a template is expanded while sending a message over SMTP.
+const (
+	VarName    = "Name"    // Display name (or address, if none) of the first recipient.
+	VarEmail   = "Email"   // Address of the first recipient.
+	VarSubject = "Subject" // Subject of the message being sent.
+	VarFrom    = "From"    // Display name (or address, if none) of the sender.
+)
+
+const (
+	addressPrefix = "template+"
+	addressDomain = "local"
+)
+
+// Render renders the template body with the given variables.
+func Render(body string, vars map[string]string) (string, error) {
+	tmpl, err := template.New("template").Option("missingkey=zero").Parse(body)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	var b strings.Builder
+
+	if err := tmpl.Execute(&b, vars); err != nil {
+		return "", fmt.Errorf("failed to render template: %w", err)
+	}
+
+	return b.String(), nil
+}
+
+// Address returns the SMTP convenience address which expands the template with the given name.
+func Address(name string) string {
+	return addressPrefix + name + "@" + addressDomain
+}
+
+// NameFromAddress returns the name of the template referenced by the given template+name@local address.
+func NameFromAddress(address string) (string, bool) {
+	local, domain, ok := strings.Cut(strings.Trim(address, "<>"), "@")
+	if !ok || !strings.EqualFold(domain, addressDomain) {
+		return "", false
+	}
+
+	if len(local) <= len(addressPrefix) || !strings.EqualFold(local[:len(addressPrefix)], addressPrefix) {
+		return "", false
+	}
+
+	return local[len(addressPrefix):], true
+}
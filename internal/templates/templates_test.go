@@ -0,0 +1,58 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package templates
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRender(t *testing.T) {
+	res, err := Render("Hello {{.Name}}, re: {{.Subject}}{{.Unknown}}", map[string]string{
+		VarName:    "Alice",
+		VarSubject: "Lunch",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "Hello Alice, re: Lunch", res)
+
+	_, err = Render("Hello {{.Name", nil)
+	require.Error(t, err)
+}
+
+func TestNameFromAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		name    string
+		ok      bool
+	}{
+		{address: "template+thanks@local", name: "thanks", ok: true},
+		{address: "<Template+thanks@LOCAL>", name: "thanks", ok: true},
+		{address: Address("out-of-office"), name: "out-of-office", ok: true},
+		{address: "template+@local", ok: false},
+		{address: "template+thanks@proton.me", ok: false},
+		{address: "user+thanks@local", ok: false},
+		{address: "local", ok: false},
+	}
+
+	for _, test := range tests {
+		name, ok := NameFromAddress(test.address)
+		require.Equal(t, test.ok, ok, test.address)
+		require.Equal(t, test.name, name, test.address)
+	}
+}
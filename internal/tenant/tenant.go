@@ -0,0 +1,246 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+// Package tenant implements multi-tenant mode, where a single daemon hosts several isolated bridges.
+// Each tenant, e.g. a member of a family server, has its own vault, keychain entry and listeners.
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
+	"github.com/ProtonMail/proton-bridge/v3/internal/locations"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrInvalidName  = errors.New("invalid tenant name: use up to 32 lowercase letters, digits, '-' or '_'")
+	ErrTenantExists = errors.New("tenant already exists")
+	ErrNoSuchTenant = errors.New("no such tenant")
+)
+
+// namePattern restricts tenant names so that they can safely be used in paths and keychain entries.
+var namePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// StartFunc starts the bridge serving the given tenant, whose files are stored in the given locations.
+// The bridges of the other tenants are given, e.g. to avoid their ports.
+// It returns the bridge and a function tearing it down.
+type StartFunc func(
+	ctx context.Context,
+	name string,
+	locations *locations.Locations,
+	others []*bridge.Bridge,
+) (*bridge.Bridge, func(context.Context), error)
+
+// RemoveFunc removes the data the given tenant keeps outside its locations, e.g. its accounts and keychain entry.
+// It is called before the tenant's bridge is torn down.
+type RemoveFunc func(ctx context.Context, name string, bridge *bridge.Bridge, locations *locations.Locations) error
+
+// Manager keeps track of the tenants hosted by the daemon.
+// Tenants are persisted as directories in the tenants directory.
+type Manager struct {
+	dir        string
+	configName string
+
+	start  StartFunc
+	remove RemoveFunc
+
+	tenants     map[string]*tenant
+	tenantsLock sync.RWMutex
+}
+
+type tenant struct {
+	bridge    *bridge.Bridge
+	locations *locations.Locations
+	stop      func(context.Context)
+}
+
+// NewManager returns a manager of the tenants stored in the given directory.
+func NewManager(dir, configName string, start StartFunc, remove RemoveFunc) *Manager {
+	return &Manager{
+		dir:        dir,
+		configName: configName,
+		start:      start,
+		remove:     remove,
+		tenants:    make(map[string]*tenant),
+	}
+}
+
+// ValidateName returns ErrInvalidName if the given name can't be used for a tenant.
+func ValidateName(name string) error {
+	if !namePattern.MatchString(name) {
+		return ErrInvalidName
+	}
+
+	return nil
+}
+
+// Load starts the bridges of the tenants created previously.
+// Tenants which fail to start are logged and skipped.
+func (m *Manager) Load(ctx context.Context) error {
+	entries, err := os.ReadDir(m.dir)
+	if err != nil {
+		return fmt.Errorf("failed to read tenants: %w", err)
+	}
+
+	m.tenantsLock.Lock()
+	defer m.tenantsLock.Unlock()
+
+	for _, entry := range entries {
+		if !entry.IsDir() || ValidateName(entry.Name()) != nil {
+			continue
+		}
+
+		if err := m.startTenant(ctx, entry.Name()); err != nil {
+			logrus.WithError(err).WithField("tenant", entry.Name()).Error("Failed to start tenant")
+		}
+	}
+
+	return nil
+}
+
+// GetTenants returns the names of the running tenants, sorted.
+func (m *Manager) GetTenants() []string {
+	m.tenantsLock.RLock()
+	defer m.tenantsLock.RUnlock()
+
+	names := make([]string, 0, len(m.tenants))
+
+	for name := range m.tenants {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// GetTenantBridge returns the bridge serving the given tenant.
+func (m *Manager) GetTenantBridge(name string) (*bridge.Bridge, error) {
+	m.tenantsLock.RLock()
+	defer m.tenantsLock.RUnlock()
+
+	tenant, ok := m.tenants[name]
+	if !ok {
+		return nil, ErrNoSuchTenant
+	}
+
+	return tenant.bridge, nil
+}
+
+// AddTenant creates a tenant and starts its bridge.
+func (m *Manager) AddTenant(ctx context.Context, name string) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+
+	m.tenantsLock.Lock()
+	defer m.tenantsLock.Unlock()
+
+	if _, err := os.Stat(m.getTenantDir(name)); err == nil {
+		return ErrTenantExists
+	}
+
+	if err := m.startTenant(ctx, name); err != nil {
+		if err := os.RemoveAll(m.getTenantDir(name)); err != nil {
+			logrus.WithError(err).WithField("tenant", name).Error("Failed to remove files of tenant")
+		}
+
+		return err
+	}
+
+	logrus.WithField("tenant", name).Info("Tenant added")
+
+	return nil
+}
+
+// RemoveTenant stops the bridge of the given tenant and removes all its data.
+func (m *Manager) RemoveTenant(ctx context.Context, name string) error {
+	m.tenantsLock.Lock()
+	defer m.tenantsLock.Unlock()
+
+	tenant, ok := m.tenants[name]
+	if !ok {
+		return ErrNoSuchTenant
+	}
+
+	if err := m.remove(ctx, name, tenant.bridge, tenant.locations); err != nil {
+		return fmt.Errorf("failed to remove tenant data: %w", err)
+	}
+
+	tenant.stop(ctx)
+
+	delete(m.tenants, name)
+
+	if err := os.RemoveAll(m.getTenantDir(name)); err != nil {
+		return fmt.Errorf("failed to remove tenant files: %w", err)
+	}
+
+	logrus.WithField("tenant", name).Info("Tenant removed")
+
+	return nil
+}
+
+// Close stops the bridges of all tenants.
+func (m *Manager) Close(ctx context.Context) {
+	m.tenantsLock.Lock()
+	defer m.tenantsLock.Unlock()
+
+	for name, tenant := range m.tenants {
+		tenant.stop(ctx)
+		delete(m.tenants, name)
+	}
+}
+
+func (m *Manager) startTenant(ctx context.Context, name string) error {
+	provider, err := locations.NewDirProvider(m.getTenantDir(name))
+	if err != nil {
+		return fmt.Errorf("failed to create tenant locations: %w", err)
+	}
+
+	locations := locations.New(provider, m.configName)
+
+	others := make([]*bridge.Bridge, 0, len(m.tenants))
+
+	for _, tenant := range m.tenants {
+		others = append(others, tenant.bridge)
+	}
+
+	bridge, stop, err := m.start(ctx, name, locations, others)
+	if err != nil {
+		return fmt.Errorf("failed to start tenant: %w", err)
+	}
+
+	m.tenants[name] = &tenant{
+		bridge:    bridge,
+		locations: locations,
+		stop:      stop,
+	}
+
+	return nil
+}
+
+func (m *Manager) getTenantDir(name string) string {
+	return filepath.Join(m.dir, name)
+}
@@ -0,0 +1,128 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package tenant_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/bridge"
+	"github.com/ProtonMail/proton-bridge/v3/internal/locations"
+	"github.com/ProtonMail/proton-bridge/v3/internal/tenant"
+	"github.com/stretchr/testify/require"
+)
+
+type testHost struct {
+	running map[string]*locations.Locations
+	removed []string
+	failing string
+}
+
+func newTestHost() *testHost {
+	return &testHost{running: make(map[string]*locations.Locations)}
+}
+
+func (h *testHost) start(_ context.Context, name string, locations *locations.Locations, others []*bridge.Bridge) (*bridge.Bridge, func(context.Context), error) {
+	if name == h.failing {
+		return nil, nil, errors.New("failed to start")
+	}
+
+	if len(others) != len(h.running) {
+		return nil, nil, errors.New("the other tenants are not given")
+	}
+
+	h.running[name] = locations
+
+	return nil, func(context.Context) { delete(h.running, name) }, nil
+}
+
+func (h *testHost) remove(_ context.Context, name string, _ *bridge.Bridge, _ *locations.Locations) error {
+	h.removed = append(h.removed, name)
+	return nil
+}
+
+func TestManager(t *testing.T) {
+	dir, host := t.TempDir(), newTestHost()
+
+	manager := tenant.NewManager(dir, "bridge", host.start, host.remove)
+	require.NoError(t, manager.Load(context.Background()))
+	require.Empty(t, manager.GetTenants())
+
+	require.NoError(t, manager.AddTenant(context.Background(), "bob"))
+	require.NoError(t, manager.AddTenant(context.Background(), "alice"))
+	require.ErrorIs(t, manager.AddTenant(context.Background(), "alice"), tenant.ErrTenantExists)
+	require.Equal(t, []string{"alice", "bob"}, manager.GetTenants())
+
+	// Each tenant has its own files.
+	settings, err := host.running["alice"].ProvideSettingsPath()
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, "alice", "config"), settings)
+
+	// Tenants are started again when the daemon restarts.
+	manager.Close(context.Background())
+	require.Empty(t, host.running)
+
+	manager = tenant.NewManager(dir, "bridge", host.start, host.remove)
+	require.NoError(t, manager.Load(context.Background()))
+	require.Equal(t, []string{"alice", "bob"}, manager.GetTenants())
+	require.Len(t, host.running, 2)
+
+	// A removed tenant is stopped and its files are deleted.
+	require.NoError(t, manager.RemoveTenant(context.Background(), "bob"))
+	require.ErrorIs(t, manager.RemoveTenant(context.Background(), "bob"), tenant.ErrNoSuchTenant)
+	require.Equal(t, []string{"bob"}, host.removed)
+	require.Equal(t, []string{"alice"}, manager.GetTenants())
+	require.NotContains(t, host.running, "bob")
+	require.NoDirExists(t, filepath.Join(dir, "bob"))
+
+	_, err = manager.GetTenantBridge("bob")
+	require.ErrorIs(t, err, tenant.ErrNoSuchTenant)
+}
+
+func TestManager_StartFailure(t *testing.T) {
+	dir, host := t.TempDir(), newTestHost()
+	host.failing = "alice"
+
+	manager := tenant.NewManager(dir, "bridge", host.start, host.remove)
+
+	// The files of a tenant which can't be started are not kept.
+	require.Error(t, manager.AddTenant(context.Background(), "alice"))
+	require.Empty(t, manager.GetTenants())
+	require.NoDirExists(t, filepath.Join(dir, "alice"))
+
+	// Existing tenants which fail to start are skipped.
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "alice"), 0o700))
+	require.NoError(t, manager.AddTenant(context.Background(), "bob"))
+	manager.Close(context.Background())
+
+	require.NoError(t, manager.Load(context.Background()))
+	require.Equal(t, []string{"bob"}, manager.GetTenants())
+}
+
+func TestValidateName(t *testing.T) {
+	for _, name := range []string{"alice", "bob-2", "team_a", "0"} {
+		require.NoError(t, tenant.ValidateName(name), name)
+	}
+
+	for _, name := range []string{"", "Alice", "../etc", "a/b", "-a", "a.b", "abcdefghijklmnopqrstuvwxyz0123456"} {
+		require.ErrorIs(t, tenant.ValidateName(name), tenant.ErrInvalidName, name)
+	}
+}
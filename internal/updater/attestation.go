@@ -0,0 +1,258 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package updater
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/gopenpgp/v2/crypto"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrAttestationMismatch = errors.New("the attestation does not match the update package")
+	ErrInclusionProof      = errors.New("invalid transparency log inclusion proof")
+	ErrCheckpoint          = errors.New("invalid transparency log checkpoint")
+)
+
+// Attestation is the provenance attestation published next to an update package (as <package>.att, signed in <package>.att.sig).
+// It looks like this:
+//
+//	{
+//	  "Statement": {
+//	    "Subject": {"Name": "bridge_3.4.0_linux.tgz", "SHA256": "9f86d08..."},
+//	    "Builder": "https://github.com/ProtonMail/proton-bridge/actions/runs/42",
+//	    "SourceRepo": "https://github.com/ProtonMail/proton-bridge",
+//	    "SourceRevision": "1a2b3c4",
+//	    "BuildTime": "2023-06-01T12:00:00Z"
+//	  },
+//	  "LogEntry": {
+//	    "LogID": "bridge-releases",
+//	    "Index": 41,
+//	    "TreeSize": 42,
+//	    "InclusionProof": ["base64...", "..."],
+//	    "Checkpoint": "bridge-releases\n42\nbase64...\n",
+//	    "CheckpointSig": "-----BEGIN PGP SIGNATURE-----..."
+//	  }
+//	}
+//
+// The statement is kept raw as its exact bytes are the leaf logged in the transparency log.
+type Attestation struct {
+	Statement json.RawMessage
+	LogEntry  LogEntry
+}
+
+// Statement describes how an update package was built.
+type Statement struct {
+	Subject struct {
+		Name   string
+		SHA256 string
+	}
+
+	Builder        string
+	SourceRepo     string
+	SourceRevision string
+	BuildTime      time.Time
+}
+
+// LogEntry locates a statement in the transparency log.
+type LogEntry struct {
+	LogID          string
+	Index          uint64
+	TreeSize       uint64
+	InclusionProof [][]byte
+	Checkpoint     string
+	CheckpointSig  string
+}
+
+// Verification is the chain of checks an update package passed before being installed.
+// The provenance and log fields are empty if the attestation was not required.
+type Verification struct {
+	Version       string
+	PackageSHA256 string
+	SignedBy      string
+
+	Builder        string
+	SourceRepo     string
+	SourceRevision string
+	BuildTime      time.Time
+
+	LogID       string
+	LogIndex    uint64
+	LogTreeSize uint64
+	LogRootHash string
+	LogSignedBy string
+
+	VerifiedAt time.Time
+}
+
+// newVerification returns the verification of a package whose signature was checked with the given key.
+func newVerification(kr *crypto.KeyRing, update VersionInfo, pkg []byte) Verification {
+	hash := sha256.Sum256(pkg)
+
+	return Verification{
+		Version:       update.Version.String(),
+		PackageSHA256: hex.EncodeToString(hash[:]),
+		SignedBy:      getFingerprint(kr),
+		VerifiedAt:    time.Now(),
+	}
+}
+
+// verifyAttestation checks that the (already signature-verified) attestation is about the given package,
+// and that its statement is included in the transparency log at a checkpoint signed with the log key.
+// It adds the provenance and log fields to the verification.
+func verifyAttestation(logKR *crypto.KeyRing, b []byte, update VersionInfo, pkg []byte, verification *Verification) error {
+	var att Attestation
+
+	if err := json.Unmarshal(b, &att); err != nil {
+		return fmt.Errorf("failed to parse attestation: %w", err)
+	}
+
+	var stmt Statement
+
+	if err := json.Unmarshal(att.Statement, &stmt); err != nil {
+		return fmt.Errorf("failed to parse attestation statement: %w", err)
+	}
+
+	hash := sha256.Sum256(pkg)
+
+	if stmt.Subject.Name != path.Base(update.Package) || !strings.EqualFold(stmt.Subject.SHA256, hex.EncodeToString(hash[:])) {
+		return ErrAttestationMismatch
+	}
+
+	root, err := verifyCheckpoint(logKR, att.LogEntry)
+	if err != nil {
+		return err
+	}
+
+	if err := verifyInclusion(hashLeaf(att.Statement), att.LogEntry.Index, att.LogEntry.TreeSize, att.LogEntry.InclusionProof, root); err != nil {
+		return err
+	}
+
+	verification.Builder = stmt.Builder
+	verification.SourceRepo = stmt.SourceRepo
+	verification.SourceRevision = stmt.SourceRevision
+	verification.BuildTime = stmt.BuildTime
+
+	verification.LogID = att.LogEntry.LogID
+	verification.LogIndex = att.LogEntry.Index
+	verification.LogTreeSize = att.LogEntry.TreeSize
+	verification.LogRootHash = hex.EncodeToString(root)
+	verification.LogSignedBy = getFingerprint(logKR)
+
+	return nil
+}
+
+// verifyCheckpoint checks the checkpoint's signature and that it matches the log entry, and returns the log's root hash.
+// A checkpoint is made of the log ID, the tree size and the base64-encoded root hash, one per line.
+func verifyCheckpoint(kr *crypto.KeyRing, entry LogEntry) ([]byte, error) {
+	sig, err := crypto.NewPGPSignatureFromArmored(entry.CheckpointSig)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCheckpoint, err)
+	}
+
+	if err := kr.VerifyDetached(crypto.NewPlainMessageFromString(entry.Checkpoint), sig, crypto.GetUnixTime()); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCheckpoint, err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(entry.Checkpoint, "\n"), "\n")
+	if len(lines) != 3 {
+		return nil, fmt.Errorf("%w: malformed", ErrCheckpoint)
+	}
+
+	if lines[0] != entry.LogID {
+		return nil, fmt.Errorf("%w: log ID mismatch", ErrCheckpoint)
+	}
+
+	if size, err := strconv.ParseUint(lines[1], 10, 64); err != nil || size != entry.TreeSize {
+		return nil, fmt.Errorf("%w: tree size mismatch", ErrCheckpoint)
+	}
+
+	root, err := base64.StdEncoding.DecodeString(lines[2])
+	if err != nil || len(root) != sha256.Size {
+		return nil, fmt.Errorf("%w: malformed root hash", ErrCheckpoint)
+	}
+
+	return root, nil
+}
+
+// verifyInclusion checks the inclusion proof of the leaf at the given index in a tree of the given size (RFC 9162, 2.1.3.2).
+func verifyInclusion(leafHash []byte, index, size uint64, proof [][]byte, root []byte) error {
+	if index >= size {
+		return fmt.Errorf("%w: index %d out of tree of size %d", ErrInclusionProof, index, size)
+	}
+
+	fn, sn, r := index, size-1, leafHash
+
+	for _, p := range proof {
+		if sn == 0 {
+			return fmt.Errorf("%w: proof too long", ErrInclusionProof)
+		}
+
+		if fn&1 == 1 || fn == sn {
+			r = hashChildren(p, r)
+
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			r = hashChildren(r, p)
+		}
+
+		fn >>= 1
+		sn >>= 1
+	}
+
+	if sn != 0 {
+		return fmt.Errorf("%w: proof too short", ErrInclusionProof)
+	}
+
+	if !bytes.Equal(r, root) {
+		return fmt.Errorf("%w: root hash mismatch", ErrInclusionProof)
+	}
+
+	return nil
+}
+
+func hashLeaf(leaf []byte) []byte {
+	hash := sha256.Sum256(append([]byte{0x00}, leaf...))
+	return hash[:]
+}
+
+func hashChildren(left, right []byte) []byte {
+	hash := sha256.Sum256(append(append([]byte{0x01}, left...), right...))
+	return hash[:]
+}
+
+func getFingerprint(kr *crypto.KeyRing) string {
+	if keys := kr.GetKeys(); len(keys) > 0 {
+		return keys[0].GetFingerprint()
+	}
+
+	return ""
+}
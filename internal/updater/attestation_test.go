@@ -0,0 +1,265 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package updater
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/ProtonMail/gopenpgp/v2/crypto"
+	"github.com/ProtonMail/proton-bridge/v3/internal/updater/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyInclusion(t *testing.T) {
+	for size := 1; size <= 9; size++ {
+		leaves := make([][]byte, size)
+
+		for i := range leaves {
+			leaves[i] = []byte(fmt.Sprintf("leaf %d", i))
+		}
+
+		root := testTreeHash(leaves)
+
+		for index := range leaves {
+			proof := testInclusionProof(leaves, index)
+
+			require.NoError(t, verifyInclusion(hashLeaf(leaves[index]), uint64(index), uint64(size), proof, root))
+
+			// The proof does not hold for another leaf or another index.
+			require.ErrorIs(t, verifyInclusion(hashLeaf([]byte("other")), uint64(index), uint64(size), proof, root), ErrInclusionProof)
+
+			if size > 1 {
+				require.ErrorIs(t, verifyInclusion(hashLeaf(leaves[index]), uint64((index+1)%size), uint64(size), proof, root), ErrInclusionProof)
+			}
+		}
+	}
+}
+
+func TestVerifyInclusion_OutOfTree(t *testing.T) {
+	leaves := [][]byte{[]byte("leaf 0"), []byte("leaf 1")}
+
+	require.ErrorIs(t, verifyInclusion(hashLeaf(leaves[0]), 2, 2, testInclusionProof(leaves, 0), testTreeHash(leaves)), ErrInclusionProof)
+}
+
+func TestUpdater_InstallUpdate(t *testing.T) {
+	kr, logKR := newTestKeyRing(t), newTestKeyRing(t)
+	pkg := []byte("the update package")
+	update := VersionInfo{Version: semver.MustParse("3.4.0"), Package: "https://proton.me/download/bridge/bridge_3.4.0_linux.tgz"}
+
+	ctl := gomock.NewController(t)
+	downloader := mocks.NewMockDownloader(ctl)
+	installer := mocks.NewMockInstaller(ctl)
+
+	installer.EXPECT().IsAlreadyInstalled(update.Version).Return(false)
+	downloader.EXPECT().DownloadAndVerify(gomock.Any(), kr, update.Package, update.Package+".sig").Return(pkg, nil)
+	downloader.EXPECT().DownloadAndVerify(gomock.Any(), kr, update.Package+".att", update.Package+".att.sig").Return(newTestAttestation(t, logKR, "bridge_3.4.0_linux.tgz", pkg), nil)
+	installer.EXPECT().InstallUpdate(update.Version, gomock.Any()).DoAndReturn(func(_ *semver.Version, r io.Reader) error {
+		b, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, pkg, b)
+
+		return nil
+	})
+
+	updater := NewUpdater(installer, kr, "bridge", "linux")
+	require.NoError(t, updater.RequireAttestation(logKR))
+
+	verification, err := updater.InstallUpdate(context.Background(), downloader, update)
+	require.NoError(t, err)
+
+	hash := sha256.Sum256(pkg)
+
+	require.Equal(t, "3.4.0", verification.Version)
+	require.Equal(t, hex.EncodeToString(hash[:]), verification.PackageSHA256)
+	require.Equal(t, kr.GetKeys()[0].GetFingerprint(), verification.SignedBy)
+	require.Equal(t, "1a2b3c4", verification.SourceRevision)
+	require.Equal(t, "bridge-releases", verification.LogID)
+	require.Equal(t, uint64(2), verification.LogIndex)
+	require.Equal(t, uint64(5), verification.LogTreeSize)
+	require.Equal(t, logKR.GetKeys()[0].GetFingerprint(), verification.LogSignedBy)
+}
+
+func TestUpdater_InstallUpdate_AttestationNotRequired(t *testing.T) {
+	kr := newTestKeyRing(t)
+	pkg := []byte("the update package")
+	update := VersionInfo{Version: semver.MustParse("3.4.0"), Package: "https://proton.me/download/bridge/bridge_3.4.0_linux.tgz"}
+
+	ctl := gomock.NewController(t)
+	downloader := mocks.NewMockDownloader(ctl)
+	installer := mocks.NewMockInstaller(ctl)
+
+	// Without a log key, no attestation is downloaded.
+	installer.EXPECT().IsAlreadyInstalled(update.Version).Return(false)
+	downloader.EXPECT().DownloadAndVerify(gomock.Any(), kr, update.Package, update.Package+".sig").Return(pkg, nil)
+	installer.EXPECT().InstallUpdate(update.Version, gomock.Any()).Return(nil)
+
+	verification, err := NewUpdater(installer, kr, "bridge", "linux").InstallUpdate(context.Background(), downloader, update)
+	require.NoError(t, err)
+
+	require.Equal(t, "3.4.0", verification.Version)
+	require.Equal(t, kr.GetKeys()[0].GetFingerprint(), verification.SignedBy)
+	require.Empty(t, verification.LogID)
+}
+
+func TestUpdater_RequireAttestation_UpdateKey(t *testing.T) {
+	kr := newTestKeyRing(t)
+
+	// The log key must not be the update key.
+	require.ErrorIs(t, NewUpdater(nil, kr, "bridge", "linux").RequireAttestation(kr), ErrLogKeyIsUpdateKey)
+}
+
+func TestUpdater_InstallUpdate_AttestationMismatch(t *testing.T) {
+	kr, logKR := newTestKeyRing(t), newTestKeyRing(t)
+	update := VersionInfo{Version: semver.MustParse("3.4.0"), Package: "https://proton.me/download/bridge/bridge_3.4.0_linux.tgz"}
+
+	ctl := gomock.NewController(t)
+	downloader := mocks.NewMockDownloader(ctl)
+	installer := mocks.NewMockInstaller(ctl)
+
+	// The attestation is about another package: nothing is installed.
+	installer.EXPECT().IsAlreadyInstalled(update.Version).Return(false)
+	downloader.EXPECT().DownloadAndVerify(gomock.Any(), kr, update.Package, update.Package+".sig").Return([]byte("the update package"), nil)
+	downloader.EXPECT().DownloadAndVerify(gomock.Any(), kr, update.Package+".att", update.Package+".att.sig").Return(newTestAttestation(t, logKR, "bridge_3.4.0_linux.tgz", []byte("another package")), nil)
+
+	updater := NewUpdater(installer, kr, "bridge", "linux")
+	require.NoError(t, updater.RequireAttestation(logKR))
+
+	_, err := updater.InstallUpdate(context.Background(), downloader, update)
+	require.ErrorIs(t, err, ErrDownloadVerify)
+}
+
+func TestVerifyAttestation_Checkpoint(t *testing.T) {
+	kr, logKR := newTestKeyRing(t), newTestKeyRing(t)
+	pkg := []byte("the update package")
+	update := VersionInfo{Version: semver.MustParse("3.4.0"), Package: "bridge_3.4.0_linux.tgz"}
+
+	var att Attestation
+
+	require.NoError(t, json.Unmarshal(newTestAttestation(t, logKR, "bridge_3.4.0_linux.tgz", pkg), &att))
+
+	// A checkpoint signed by someone else than the log, including the update key, is rejected.
+	for _, signer := range []*crypto.KeyRing{kr, newTestKeyRing(t)} {
+		att.LogEntry.CheckpointSig = signTestCheckpoint(t, signer, att.LogEntry.Checkpoint)
+
+		b, err := json.Marshal(att)
+		require.NoError(t, err)
+
+		require.ErrorIs(t, verifyAttestation(logKR, b, update, pkg, &Verification{}), ErrCheckpoint)
+	}
+}
+
+// newTestAttestation returns an attestation for the given package, logged at index 2 of a log of 5 entries
+// whose checkpoint is signed with the given log key.
+func newTestAttestation(t *testing.T, logKR *crypto.KeyRing, name string, pkg []byte) []byte {
+	hash := sha256.Sum256(pkg)
+
+	stmt, err := json.Marshal(map[string]any{
+		"Subject":        map[string]string{"Name": name, "SHA256": hex.EncodeToString(hash[:])},
+		"Builder":        "builder",
+		"SourceRepo":     "https://github.com/ProtonMail/proton-bridge",
+		"SourceRevision": "1a2b3c4",
+		"BuildTime":      time.Now(),
+	})
+	require.NoError(t, err)
+
+	leaves := [][]byte{[]byte("a"), []byte("b"), stmt, []byte("d"), []byte("e")}
+
+	checkpoint := fmt.Sprintf("bridge-releases\n%d\n%s\n", len(leaves), base64.StdEncoding.EncodeToString(testTreeHash(leaves)))
+
+	b, err := json.Marshal(Attestation{
+		Statement: stmt,
+		LogEntry: LogEntry{
+			LogID:          "bridge-releases",
+			Index:          2,
+			TreeSize:       uint64(len(leaves)),
+			InclusionProof: testInclusionProof(leaves, 2),
+			Checkpoint:     checkpoint,
+			CheckpointSig:  signTestCheckpoint(t, logKR, checkpoint),
+		},
+	})
+	require.NoError(t, err)
+
+	return b
+}
+
+func newTestKeyRing(t *testing.T) *crypto.KeyRing {
+	key, err := crypto.GenerateKey("test", "test@proton.me", "x25519", 0)
+	require.NoError(t, err)
+
+	kr, err := crypto.NewKeyRing(key)
+	require.NoError(t, err)
+
+	return kr
+}
+
+func signTestCheckpoint(t *testing.T, kr *crypto.KeyRing, checkpoint string) string {
+	sig, err := kr.SignDetached(crypto.NewPlainMessageFromString(checkpoint))
+	require.NoError(t, err)
+
+	armored, err := sig.GetArmored()
+	require.NoError(t, err)
+
+	return armored
+}
+
+// testTreeHash returns the Merkle tree hash of the given leaves (RFC 9162, 2.1.1).
+func testTreeHash(leaves [][]byte) []byte {
+	if len(leaves) == 1 {
+		return hashLeaf(leaves[0])
+	}
+
+	k := testSplit(len(leaves))
+
+	return hashChildren(testTreeHash(leaves[:k]), testTreeHash(leaves[k:]))
+}
+
+// testInclusionProof returns the inclusion proof of the leaf at the given index (RFC 9162, 2.1.3.1).
+func testInclusionProof(leaves [][]byte, index int) [][]byte {
+	if len(leaves) == 1 {
+		return nil
+	}
+
+	k := testSplit(len(leaves))
+
+	if index < k {
+		return append(testInclusionProof(leaves[:k], index), testTreeHash(leaves[k:]))
+	}
+
+	return append(testInclusionProof(leaves[k:], index-k), testTreeHash(leaves[:k]))
+}
+
+// testSplit returns the largest power of two smaller than n.
+func testSplit(n int) int {
+	k := 1
+
+	for k*2 < n {
+		k *= 2
+	}
+
+	return k
+}
@@ -34,6 +34,7 @@ var (
 	ErrDownloadVerify         = errors.New("failed to download or verify the update")
 	ErrInstall                = errors.New("failed to install the update")
 	ErrUpdateAlreadyInstalled = errors.New("update is already installed")
+	ErrLogKeyIsUpdateKey      = errors.New("the transparency log key must differ from the update key")
 )
 
 type Downloader interface {
@@ -46,10 +47,11 @@ type Installer interface {
 }
 
 type Updater struct {
-	installer Installer
-	verifier  *crypto.KeyRing
-	product   string
-	platform  string
+	installer   Installer
+	verifier    *crypto.KeyRing
+	logVerifier *crypto.KeyRing
+	product     string
+	platform    string
 }
 
 func NewUpdater(installer Installer, verifier *crypto.KeyRing, product, platform string) *Updater {
@@ -86,9 +88,26 @@ func (u *Updater) GetVersionInfo(ctx context.Context, downloader Downloader, cha
 	return version, nil
 }
 
-func (u *Updater) InstallUpdate(ctx context.Context, downloader Downloader, update VersionInfo) error {
+// RequireAttestation makes InstallUpdate also require a provenance attestation for each package,
+// included in the transparency log at a checkpoint signed with the given key.
+// The key must be the log's (or a witness') own key: a checkpoint signed with the update key adds nothing to the package signature.
+func (u *Updater) RequireAttestation(logVerifier *crypto.KeyRing) error {
+	if getFingerprint(logVerifier) == getFingerprint(u.verifier) {
+		return ErrLogKeyIsUpdateKey
+	}
+
+	u.logVerifier = logVerifier
+
+	return nil
+}
+
+// InstallUpdate downloads, verifies and installs the given update.
+// Besides its signature, the package must match its provenance attestation, which must be included in the transparency log,
+// if RequireAttestation was called.
+// It returns the chain of checks the package passed.
+func (u *Updater) InstallUpdate(ctx context.Context, downloader Downloader, update VersionInfo) (Verification, error) {
 	if u.installer.IsAlreadyInstalled(update.Version) {
-		return ErrUpdateAlreadyInstalled
+		return Verification{}, ErrUpdateAlreadyInstalled
 	}
 
 	b, err := downloader.DownloadAndVerify(
@@ -98,15 +117,34 @@ func (u *Updater) InstallUpdate(ctx context.Context, downloader Downloader, upda
 		update.Package+".sig",
 	)
 	if err != nil {
-		return ErrDownloadVerify
+		return Verification{}, ErrDownloadVerify
+	}
+
+	verification := newVerification(u.verifier, update, b)
+
+	if u.logVerifier != nil {
+		att, err := downloader.DownloadAndVerify(
+			ctx,
+			u.verifier,
+			update.Package+".att",
+			update.Package+".att.sig",
+		)
+		if err != nil {
+			return Verification{}, ErrDownloadVerify
+		}
+
+		if err := verifyAttestation(u.logVerifier, att, update, b, &verification); err != nil {
+			logrus.WithError(err).Error("Failed to verify update provenance")
+			return Verification{}, ErrDownloadVerify
+		}
 	}
 
 	if err := u.installer.InstallUpdate(update.Version, bytes.NewReader(b)); err != nil {
 		logrus.WithError(err).Error("Failed to install update")
-		return ErrInstall
+		return Verification{}, ErrInstall
 	}
 
-	return nil
+	return verification, nil
 }
 
 // getVersionFileURL returns the URL of the version file.
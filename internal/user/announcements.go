@@ -0,0 +1,34 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package user
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/services/imapservice"
+)
+
+// SetAnnouncements replaces the announcements posted to the user's read-only Proton Bridge mailbox.
+func (user *User) SetAnnouncements(ctx context.Context, announcements []imapservice.Announcement) error {
+	if err := user.imapService.SetAnnouncements(ctx, announcements); err != nil {
+		return fmt.Errorf("failed to set imap announcements: %w", err)
+	}
+
+	return nil
+}
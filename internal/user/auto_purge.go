@@ -0,0 +1,227 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/proton-bridge/v3/internal/events"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+	"github.com/bradenaw/juniper/xslices"
+	"github.com/sirupsen/logrus"
+)
+
+// AutoPurgeInterval is the period between two purges of old messages in Trash and Spam.
+// Messages are announced with a UserAutoPurgePending event one period before they are deleted.
+// Their age is counted from when a purge run first saw them in the folder, so it's up to one period late.
+const AutoPurgeInterval = 12 * time.Hour
+
+// GetAutoPurge returns the user's Trash and Spam auto-purge settings.
+func (user *User) GetAutoPurge() vault.AutoPurge {
+	return user.vault.AutoPurge()
+}
+
+// SetAutoPurge sets the user's Trash and Spam auto-purge settings and starts a purge run,
+// which deletes the messages that are still old enough and whose announced deletion time has passed.
+func (user *User) SetAutoPurge(purge vault.AutoPurge) error {
+	if purge.TrashDays < 0 || purge.SpamDays < 0 {
+		return errors.New("the number of days must not be negative")
+	}
+
+	user.log.WithFields(logrus.Fields{
+		"trashDays": purge.TrashDays,
+		"spamDays":  purge.SpamDays,
+	}).Info("Setting auto-purge")
+
+	if err := user.vault.SetAutoPurge(purge); err != nil {
+		return fmt.Errorf("failed to set auto-purge: %w", err)
+	}
+
+	user.goAutoPurge()
+
+	return nil
+}
+
+// PreviewAutoPurge returns, by label ID, the messages the current settings would purge, without deleting them.
+func (user *User) PreviewAutoPurge(ctx context.Context) (map[string][]proton.MessageMetadata, error) {
+	purge, state, now := user.vault.AutoPurge(), user.vault.AutoPurgeState(), time.Now()
+
+	candidates := make(map[string][]proton.MessageMetadata)
+
+	for labelID, days := range getAutoPurgeDays(purge) {
+		messages, err := user.imapService.GetMailboxMessages(ctx, labelID)
+		if err != nil {
+			return nil, err
+		}
+
+		cutoff := now.AddDate(0, 0, -days)
+
+		candidates[labelID] = xslices.Filter(messages, func(message proton.MessageMetadata) bool {
+			seenAt, ok := state.Seen[labelID][message.ID]
+
+			return ok && seenAt.Before(cutoff)
+		})
+	}
+
+	return candidates, nil
+}
+
+// autoPurge permanently deletes the old messages of Trash and Spam whose announced deletion time has passed,
+// and announces those which became old enough since.
+// The state is kept in the vault, so that the messages' age and deletion time survive restarts.
+func (user *User) autoPurge(ctx context.Context) {
+	purge, state, now := user.vault.AutoPurge(), user.vault.AutoPurgeState(), time.Now()
+
+	newState := vault.AutoPurgeState{
+		Seen:    make(map[string]map[string]time.Time),
+		PurgeAt: make(map[string]time.Time),
+	}
+
+	for labelID, days := range getAutoPurgeDays(purge) {
+		messages, err := user.imapService.GetMailboxMessages(ctx, labelID)
+		if err != nil {
+			user.log.WithError(err).WithField("labelID", labelID).Error("Failed to get messages to purge")
+
+			// Keep the folder's state for the next run.
+			newState.Seen[labelID] = state.Seen[labelID]
+
+			for messageID := range state.Seen[labelID] {
+				if purgeAt, ok := state.PurgeAt[messageID]; ok {
+					newState.PurgeAt[messageID] = purgeAt
+				}
+			}
+
+			continue
+		}
+
+		plan := planAutoPurge(xslices.Map(messages, func(message proton.MessageMetadata) string { return message.ID }), days, state.Seen[labelID], state.PurgeAt, now)
+
+		newState.Seen[labelID] = plan.seen
+
+		for messageID, purgeAt := range plan.purgeAt {
+			newState.PurgeAt[messageID] = purgeAt
+		}
+
+		if len(plan.toDelete) > 0 {
+			if err := user.client.DeleteMessage(ctx, plan.toDelete...); err != nil {
+				user.log.WithError(err).WithField("labelID", labelID).Error("Failed to purge messages")
+
+				// Try again on the next run.
+				for _, messageID := range plan.toDelete {
+					newState.PurgeAt[messageID] = state.PurgeAt[messageID]
+				}
+			} else {
+				user.log.WithField("labelID", labelID).WithField("count", len(plan.toDelete)).Info("Purged old messages")
+
+				for _, messageID := range plan.toDelete {
+					delete(newState.Seen[labelID], messageID)
+				}
+
+				user.eventCh.Enqueue(events.UserAutoPurged{
+					UserID:  user.ID(),
+					LabelID: labelID,
+					Count:   len(plan.toDelete),
+				})
+			}
+		}
+
+		if len(plan.toAnnounce) > 0 {
+			user.eventCh.Enqueue(events.UserAutoPurgePending{
+				UserID:  user.ID(),
+				LabelID: labelID,
+				Count:   len(plan.toAnnounce),
+				At:      now.Add(AutoPurgeInterval),
+			})
+		}
+	}
+
+	if err := user.vault.SetAutoPurgeState(newState); err != nil {
+		user.log.WithError(err).Error("Failed to store the auto-purge state")
+	}
+}
+
+// autoPurgePlan is what a purge run does with the messages of a folder.
+type autoPurgePlan struct {
+	// seen maps the IDs of the messages in the folder to when they were first seen there.
+	seen map[string]time.Time
+
+	// purgeAt maps the IDs of the announced messages, including the ones to announce, to when they may be deleted.
+	purgeAt map[string]time.Time
+
+	toDelete   []string
+	toAnnounce []string
+}
+
+// planAutoPurge decides which of the messages currently in a folder are deleted or announced by a purge run at the given time.
+// A message is old once it has been in the folder for the given number of days; old messages are announced first,
+// and deleted by the first run after the announced time. Messages which left the folder are forgotten,
+// so that the age of a message moved back to it is counted again from zero.
+func planAutoPurge(messageIDs []string, days int, seen, purgeAt map[string]time.Time, now time.Time) autoPurgePlan {
+	plan := autoPurgePlan{
+		seen:    make(map[string]time.Time, len(messageIDs)),
+		purgeAt: make(map[string]time.Time),
+	}
+
+	cutoff := now.AddDate(0, 0, -days)
+
+	for _, messageID := range messageIDs {
+		seenAt, ok := seen[messageID]
+		if !ok {
+			seenAt = now
+		}
+
+		plan.seen[messageID] = seenAt
+
+		if !seenAt.Before(cutoff) {
+			continue
+		}
+
+		switch at, ok := purgeAt[messageID]; {
+		case !ok:
+			plan.purgeAt[messageID] = now.Add(AutoPurgeInterval)
+			plan.toAnnounce = append(plan.toAnnounce, messageID)
+
+		case now.Before(at):
+			plan.purgeAt[messageID] = at
+
+		default:
+			plan.toDelete = append(plan.toDelete, messageID)
+		}
+	}
+
+	return plan
+}
+
+// getAutoPurgeDays returns after how many days the messages of each purged folder are deleted, by label ID.
+func getAutoPurgeDays(purge vault.AutoPurge) map[string]int {
+	days := make(map[string]int)
+
+	if purge.TrashDays > 0 {
+		days[proton.TrashLabel] = purge.TrashDays
+	}
+
+	if purge.SpamDays > 0 {
+		days[proton.SpamLabel] = purge.SpamDays
+	}
+
+	return days
+}
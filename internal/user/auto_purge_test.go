@@ -0,0 +1,59 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package user
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlanAutoPurge(t *testing.T) {
+	now := time.Now()
+
+	// Messages seen for the first time are only recorded, however old they are.
+	plan := planAutoPurge([]string{"a", "b"}, 30, nil, nil, now)
+	require.Equal(t, map[string]time.Time{"a": now, "b": now}, plan.seen)
+	require.Empty(t, plan.toAnnounce)
+	require.Empty(t, plan.toDelete)
+
+	// Messages in the folder for long enough are announced first.
+	seen := map[string]time.Time{"a": now.AddDate(0, 0, -31), "b": now.AddDate(0, 0, -29)}
+
+	plan = planAutoPurge([]string{"a", "b"}, 30, seen, nil, now)
+	require.Equal(t, []string{"a"}, plan.toAnnounce)
+	require.Empty(t, plan.toDelete)
+	require.Equal(t, map[string]time.Time{"a": now.Add(AutoPurgeInterval)}, plan.purgeAt)
+
+	// They aren't deleted before the announced time, however often purge runs.
+	later := now.Add(time.Minute)
+
+	plan = planAutoPurge([]string{"a", "b"}, 30, seen, plan.purgeAt, later)
+	require.Empty(t, plan.toAnnounce)
+	require.Empty(t, plan.toDelete)
+	require.Equal(t, map[string]time.Time{"a": now.Add(AutoPurgeInterval)}, plan.purgeAt)
+
+	// They are deleted once it has passed.
+	plan = planAutoPurge([]string{"a", "b"}, 30, seen, plan.purgeAt, now.Add(AutoPurgeInterval))
+	require.Equal(t, []string{"a"}, plan.toDelete)
+
+	// Messages which left the folder are forgotten.
+	plan = planAutoPurge([]string{"b"}, 30, seen, nil, now)
+	require.Equal(t, map[string]time.Time{"b": seen["b"]}, plan.seen)
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package user
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/gopenpgp/v2/crypto"
+	"github.com/ProtonMail/proton-bridge/v3/internal/secret"
+	"github.com/ProtonMail/proton-bridge/v3/internal/usertypes"
+	"github.com/ProtonMail/proton-bridge/v3/pkg/message"
+)
+
+// buildMessage downloads, decrypts and builds the given message for the gateways serving it outside IMAP.
+func (user *User) buildMessage(ctx context.Context, messageID string) ([]byte, error) {
+	apiUser, err := user.identityService.GetAPIUser(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api user: %w", err)
+	}
+
+	apiAddrs, err := user.identityService.GetAddresses(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get addresses: %w", err)
+	}
+
+	full, err := user.client.GetFullMessage(ctx, messageID, usertypes.NewProtonAPIScheduler(user.panicHandler), proton.NewDefaultAttachmentAllocator())
+	if err != nil {
+		return nil, fmt.Errorf("failed to download message: %w", err)
+	}
+
+	keyPass := user.vault.KeyPass()
+	defer secret.Wipe(keyPass)
+
+	var literal []byte
+
+	if err := usertypes.WithAddrKR(apiUser, apiAddrs[full.AddressID], keyPass, func(_, addrKR *crypto.KeyRing) error {
+		literal, err = message.DecryptAndBuildRFC822(addrKR, full.Message, full.AttData, message.JobOptions{
+			AddInternalID:  true,
+			AddExternalID:  true,
+			AddMessageDate: true,
+		})
+
+		return err
+	}); err != nil {
+		return nil, fmt.Errorf("failed to build message: %w", err)
+	}
+
+	return literal, nil
+}
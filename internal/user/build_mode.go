@@ -0,0 +1,191 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package user
+
+import (
+	"context"
+	"fmt"
+	"time"
+	_ "time/tzdata" // Windows has no time zone database of its own.
+
+	"github.com/ProtonMail/proton-bridge/v3/pkg/message"
+	"github.com/sirupsen/logrus"
+)
+
+// GetConversationMode returns whether the user's messages are built with normalized threading headers.
+func (user *User) GetConversationMode() bool {
+	return user.vault.ConversationMode()
+}
+
+// SetConversationMode sets whether the user's messages are built with normalized threading headers.
+func (user *User) SetConversationMode(ctx context.Context, enabled bool) error {
+	user.log.WithField("enabled", enabled).Info("Setting conversation mode")
+
+	if err := user.vault.SetConversationMode(enabled); err != nil {
+		return fmt.Errorf("failed to set conversation mode: %w", err)
+	}
+
+	if err := user.imapService.SetConversationMode(ctx, enabled); err != nil {
+		return fmt.Errorf("failed to set imap conversation mode: %w", err)
+	}
+
+	return nil
+}
+
+// GetTranscodeToUTF8 returns whether the user's messages are built with text in legacy charsets transcoded to UTF-8.
+func (user *User) GetTranscodeToUTF8() bool {
+	return user.vault.TranscodeToUTF8()
+}
+
+// SetTranscodeToUTF8 sets whether the user's messages are built with text in legacy charsets transcoded to UTF-8.
+func (user *User) SetTranscodeToUTF8(ctx context.Context, enabled bool) error {
+	user.log.WithField("enabled", enabled).Info("Setting UTF-8 transcoding")
+
+	if err := user.vault.SetTranscodeToUTF8(enabled); err != nil {
+		return fmt.Errorf("failed to set UTF-8 transcoding: %w", err)
+	}
+
+	if err := user.imapService.SetTranscodeToUTF8(ctx, enabled); err != nil {
+		return fmt.Errorf("failed to set imap UTF-8 transcoding: %w", err)
+	}
+
+	return nil
+}
+
+// GetContactDisplayNames returns whether the user's messages are built with contact names as From display names.
+func (user *User) GetContactDisplayNames() bool {
+	return user.vault.ContactDisplayNames()
+}
+
+// SetContactDisplayNames sets whether the user's messages are built with contact names as From display names.
+func (user *User) SetContactDisplayNames(ctx context.Context, enabled bool) error {
+	user.log.WithField("enabled", enabled).Info("Setting contact display names")
+
+	if err := user.vault.SetContactDisplayNames(enabled); err != nil {
+		return fmt.Errorf("failed to set contact display names: %w", err)
+	}
+
+	if err := user.imapService.SetContactDisplayNames(ctx, enabled); err != nil {
+		return fmt.Errorf("failed to set imap contact display names: %w", err)
+	}
+
+	return nil
+}
+
+// GetDateNormalization returns the time zone the Date headers of the user's messages are converted to, empty if
+// they're kept as sent, and whether missing, invalid or far future ones are replaced with the receive time.
+func (user *User) GetDateNormalization() (string, bool) {
+	return user.vault.DateTimeZone(), user.vault.RepairDates()
+}
+
+// SetDateNormalization sets the IANA time zone the Date headers of the user's messages are converted to, empty to keep
+// them as sent, and whether missing, invalid or far future ones are replaced with the receive time.
+// The time zone is checked before anything is saved.
+func (user *User) SetDateNormalization(ctx context.Context, timeZone string, repair bool) error {
+	user.log.WithFields(logrus.Fields{
+		"timeZone": timeZone,
+		"repair":   repair,
+	}).Info("Setting date normalization")
+
+	var loc *time.Location
+
+	if timeZone != "" {
+		var err error
+
+		if loc, err = time.LoadLocation(timeZone); err != nil {
+			return err
+		}
+	}
+
+	if err := user.vault.SetDateTimeZone(timeZone); err != nil {
+		return fmt.Errorf("failed to set date time zone: %w", err)
+	}
+
+	if err := user.vault.SetRepairDates(repair); err != nil {
+		return fmt.Errorf("failed to set date repair: %w", err)
+	}
+
+	if err := user.imapService.SetDateNormalization(ctx, loc, repair); err != nil {
+		return fmt.Errorf("failed to set imap date normalization: %w", err)
+	}
+
+	return nil
+}
+
+// GetHeaderTemplate returns the template of extra header fields added to the user's messages.
+func (user *User) GetHeaderTemplate() string {
+	return user.vault.HeaderTemplate()
+}
+
+// SetHeaderTemplate sets the template of extra header fields added to the user's messages.
+// An empty text removes the template. The text is checked before anything is saved.
+func (user *User) SetHeaderTemplate(ctx context.Context, text string) error {
+	user.log.Info("Setting header template")
+
+	var tmpl *message.HeaderTemplate
+
+	if text != "" {
+		var err error
+
+		if tmpl, err = message.ParseHeaderTemplate(text); err != nil {
+			return err
+		}
+	}
+
+	if err := user.vault.SetHeaderTemplate(text); err != nil {
+		return fmt.Errorf("failed to set header template: %w", err)
+	}
+
+	if err := user.imapService.SetHeaderTemplate(ctx, tmpl); err != nil {
+		return fmt.Errorf("failed to set imap header template: %w", err)
+	}
+
+	return nil
+}
+
+// newHeaderTemplate parses the header template stored in the vault.
+// A template that no longer parses is ignored rather than keeping the user from loading.
+func newHeaderTemplate(text string) *message.HeaderTemplate {
+	if text == "" {
+		return nil
+	}
+
+	tmpl, err := message.ParseHeaderTemplate(text)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to parse header template, ignoring it")
+		return nil
+	}
+
+	return tmpl
+}
+
+// newDateLocation loads the time zone stored in the vault.
+// A time zone that no longer loads is ignored rather than keeping the user from loading.
+func newDateLocation(name string) *time.Location {
+	if name == "" {
+		return nil
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to load date time zone, ignoring it")
+		return nil
+	}
+
+	return loc
+}
@@ -34,6 +34,7 @@ import (
 	"github.com/ProtonMail/go-proton-api"
 	"github.com/ProtonMail/gopenpgp/v2/constants"
 	"github.com/ProtonMail/gopenpgp/v2/crypto"
+	"github.com/ProtonMail/proton-bridge/v3/internal/secret"
 	imapservice "github.com/ProtonMail/proton-bridge/v3/internal/services/imapservice"
 	"github.com/ProtonMail/proton-bridge/v3/internal/usertypes"
 	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
@@ -185,6 +186,9 @@ func (user *User) DebugDownloadMessages(
 		return fmt.Errorf("failed to get address: %w", err)
 	}
 
+	keyPass := user.vault.KeyPass()
+	defer secret.Wipe(keyPass)
+
 	counter := 1
 	for _, msg := range msgs {
 		if progressCB != nil {
@@ -206,7 +210,7 @@ func (user *User) DebugDownloadMessages(
 			return err
 		}
 
-		if err := usertypes.WithAddrKR(apiUser, apiAddrs[msg.AddressID], user.vault.KeyPass(), func(_, addrKR *crypto.KeyRing) error {
+		if err := usertypes.WithAddrKR(apiUser, apiAddrs[msg.AddressID], keyPass, func(_, addrKR *crypto.KeyRing) error {
 			switch {
 			case len(message.Attachments) > 0:
 				return decodeMultipartMessage(msgDir, addrKR, message.Message, message.AttData)
@@ -0,0 +1,65 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package user
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/services/imapservice"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+)
+
+// GetDeleteMode returns what happens to the user's messages expunged from a folder.
+func (user *User) GetDeleteMode() vault.DeleteMode {
+	return user.vault.DeleteMode()
+}
+
+// SetDeleteMode sets what happens to the user's messages expunged from a folder.
+func (user *User) SetDeleteMode(ctx context.Context, mode vault.DeleteMode) error {
+	user.log.WithField("mode", mode).Info("Setting delete mode")
+
+	if err := user.vault.SetDeleteMode(mode); err != nil {
+		return fmt.Errorf("failed to set delete mode: %w", err)
+	}
+
+	if err := user.imapService.SetDeleteMode(ctx, newDeleteMode(mode)); err != nil {
+		return fmt.Errorf("failed to set imap delete mode: %w", err)
+	}
+
+	return nil
+}
+
+func newDeleteMode(mode vault.DeleteMode) imapservice.DeleteMode {
+	switch mode {
+	case vault.DeleteModeRemove:
+		return imapservice.DeleteModeRemove
+
+	case vault.DeleteModeTrash:
+		return imapservice.DeleteModeTrash
+
+	case vault.DeleteModePermanent:
+		return imapservice.DeleteModePermanent
+
+	case vault.DeleteModeArchive:
+		return imapservice.DeleteModeArchive
+
+	default:
+		return imapservice.DeleteModeRemove
+	}
+}
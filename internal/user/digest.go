@@ -0,0 +1,185 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package user
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/proton-bridge/v3/internal/events"
+	"golang.org/x/exp/slices"
+)
+
+// DigestInterval is the period covered by the digest report.
+const DigestInterval = 7 * 24 * time.Hour
+
+// digestCheckInterval is how often the user is checked for a due digest report.
+const digestCheckInterval = time.Hour
+
+// digestTopSenders is the number of senders listed by the digest report.
+const digestTopSenders = 5
+
+// GetDigestEnabled returns whether the user's weekly digest report is made.
+func (user *User) GetDigestEnabled() bool {
+	return user.vault.Digest().Enabled
+}
+
+// SetDigestEnabled sets whether the user's weekly digest report is made. Enabling it starts a new period.
+func (user *User) SetDigestEnabled(ctx context.Context, enabled bool) error {
+	if enabled == user.vault.Digest().Enabled {
+		return nil
+	}
+
+	user.log.WithField("enabled", enabled).Info("Setting digest")
+
+	if err := user.vault.SetDigestEnabled(enabled); err != nil {
+		return fmt.Errorf("failed to set digest: %w", err)
+	}
+
+	if !enabled {
+		return nil
+	}
+
+	return user.resetDigest(ctx, time.Now())
+}
+
+// AddDigestMessage counts a new message in the given mailboxes in the statistics of the weekly digest report.
+func (user *User) AddDigestMessage(mailboxIDs []string, sender string) {
+	if !user.vault.Digest().Enabled {
+		return
+	}
+
+	// The user's own messages don't count towards the top senders.
+	if slices.Contains(mailboxIDs, proton.SentLabel) || slices.Contains(mailboxIDs, proton.DraftsLabel) {
+		sender = ""
+	}
+
+	if err := user.vault.AddDigestMessage(mailboxIDs, sender); err != nil {
+		user.log.WithError(err).Error("Failed to count message in digest")
+	}
+}
+
+// GetDigest returns the weekly digest report of the current period so far.
+func (user *User) GetDigest(ctx context.Context) (events.UserDigest, error) {
+	return user.buildDigest(ctx, time.Now())
+}
+
+// sendDigest emits the weekly digest report and starts a new period, if the current one is over.
+func (user *User) sendDigest(ctx context.Context) {
+	digest := user.vault.Digest()
+
+	if !digest.Enabled || time.Since(digest.Since) < DigestInterval {
+		return
+	}
+
+	now := time.Now()
+
+	report, err := user.buildDigest(ctx, now)
+	if err != nil {
+		user.log.WithError(err).Error("Failed to build digest")
+		return
+	}
+
+	user.eventCh.Enqueue(report)
+
+	if err := user.resetDigest(ctx, now); err != nil {
+		user.log.WithError(err).Error("Failed to reset digest")
+	}
+}
+
+func (user *User) resetDigest(ctx context.Context, now time.Time) error {
+	apiUser, err := user.identityService.GetAPIUser(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get api user: %w", err)
+	}
+
+	if err := user.vault.ResetDigest(now, apiUser.UsedSpace); err != nil {
+		return fmt.Errorf("failed to reset digest: %w", err)
+	}
+
+	return nil
+}
+
+// buildDigest builds the digest report from the statistics gathered since the last one, which only needs local data.
+func (user *User) buildDigest(ctx context.Context, now time.Time) (events.UserDigest, error) {
+	digest := user.vault.Digest()
+
+	apiUser, err := user.identityService.GetAPIUser(ctx)
+	if err != nil {
+		return events.UserDigest{}, fmt.Errorf("failed to get api user: %w", err)
+	}
+
+	apiLabels, err := user.imapService.GetLabels(ctx)
+	if err != nil {
+		return events.UserDigest{}, fmt.Errorf("failed to get labels: %w", err)
+	}
+
+	failed, err := user.imapService.GetSyncFailedMessageIDs(ctx)
+	if err != nil {
+		return events.UserDigest{}, fmt.Errorf("failed to get sync failed messages: %w", err)
+	}
+
+	received := make(map[string]int, len(digest.Received))
+
+	for mailboxID, count := range digest.Received {
+		name := mailboxID
+
+		if label, ok := apiLabels[mailboxID]; ok {
+			name = strings.Join(label.Path, "/")
+		}
+
+		received[name] += count
+	}
+
+	return events.UserDigest{
+		UserID:          user.ID(),
+		Since:           digest.Since,
+		Until:           now,
+		Received:        received,
+		TopSenders:      getTopSenders(digest.Senders, digestTopSenders),
+		UsedSpaceGrowth: apiUser.UsedSpace - digest.UsedSpace,
+		SyncErrors:      len(failed),
+	}, nil
+}
+
+// getTopSenders returns the senders which sent the most messages, the most frequent first.
+func getTopSenders(senders map[string]int, count int) []events.DigestSender {
+	top := make([]events.DigestSender, 0, len(senders))
+
+	for address, n := range senders {
+		top = append(top, events.DigestSender{Address: address, Count: n})
+	}
+
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Count != top[j].Count {
+			return top[i].Count > top[j].Count
+		}
+
+		return top[i].Address < top[j].Address
+	})
+
+	if len(top) > count {
+		top = top[:count]
+	}
+
+	return top
+}
@@ -0,0 +1,37 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package user
+
+import (
+	"testing"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/events"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetTopSenders(t *testing.T) {
+	senders := map[string]int{"a@pm.me": 1, "b@pm.me": 3, "c@pm.me": 1, "d@pm.me": 2}
+
+	require.Equal(t, []events.DigestSender{
+		{Address: "b@pm.me", Count: 3},
+		{Address: "d@pm.me", Count: 2},
+		{Address: "a@pm.me", Count: 1},
+	}, getTopSenders(senders, 3))
+
+	require.Empty(t, getTopSenders(nil, 3))
+}
@@ -0,0 +1,50 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package user
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// GetDraftCoalescingInterval returns how often a draft the user saves repeatedly is uploaded at most.
+func (user *User) GetDraftCoalescingInterval() time.Duration {
+	return user.vault.DraftCoalescingInterval()
+}
+
+// SetDraftCoalescingInterval sets how often a draft the user saves repeatedly is uploaded at most.
+// Setting it to zero uploads every save and uploads any draft currently held back.
+func (user *User) SetDraftCoalescingInterval(ctx context.Context, interval time.Duration) error {
+	user.log.WithField("interval", interval).Info("Setting draft coalescing interval")
+
+	if err := user.vault.SetDraftCoalescingInterval(interval); err != nil {
+		return fmt.Errorf("failed to set draft coalescing interval: %w", err)
+	}
+
+	if err := user.imapService.SetDraftCoalescingInterval(ctx, interval); err != nil {
+		return fmt.Errorf("failed to set imap draft coalescing interval: %w", err)
+	}
+
+	return nil
+}
+
+// FlushDrafts uploads the user's drafts whose upload is being held back.
+func (user *User) FlushDrafts(ctx context.Context) error {
+	return user.imapService.FlushDrafts(ctx)
+}
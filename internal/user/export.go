@@ -0,0 +1,258 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package user
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/gopenpgp/v2/crypto"
+	"github.com/ProtonMail/proton-bridge/v3/internal/secret"
+	"github.com/ProtonMail/proton-bridge/v3/internal/usertypes"
+	"github.com/ProtonMail/proton-bridge/v3/pkg/message"
+	"github.com/bradenaw/juniper/xslices"
+)
+
+const (
+	exportMessagesDir    = "messages"
+	exportManifestJSON   = "manifest.json"
+	exportManifestCSV    = "manifest.csv"
+	exportManifestHashes = "manifest.sha256"
+)
+
+// ExportRecord describes one message of a compliance export.
+type ExportRecord struct {
+	MessageID  string
+	ExternalID string
+	AddressID  string
+	File       string
+	SHA256     string
+	Size       int
+
+	Subject string
+	From    string
+	To      []string
+	CC      []string
+	BCC     []string
+	Date    time.Time
+	Labels  []string
+
+	// Header holds the header fields of the message as originally received, before bridge rebuilt it.
+	Header string
+
+	// Error is set if the message couldn't be exported; File and SHA256 are then empty.
+	Error string `json:",omitempty"`
+}
+
+// ExportCompliance writes every message of the user to path as an EML file, alongside a manifest in JSON and CSV
+// listing the SHA-256 hash, original headers and labels of each message. The manifests are themselves hashed
+// into manifest.sha256, so that the export can be checked for tampering along a chain of custody.
+// Messages that can't be decrypted are listed in the manifests with their error rather than failing the export.
+// Messages matching the user's redaction rules are left out of the export entirely.
+func (user *User) ExportCompliance(ctx context.Context, path string, progressCB func(string, int, int)) ([]ExportRecord, error) {
+	messagesDir := filepath.Join(path, exportMessagesDir)
+	if err := os.MkdirAll(messagesDir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create directory '%v': %w", messagesDir, err)
+	}
+
+	apiUser, err := user.identityService.GetAPIUser(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api user: %w", err)
+	}
+
+	apiAddrs, err := user.identityService.GetAddresses(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get addresses: %w", err)
+	}
+
+	apiLabels, err := user.imapService.GetLabels(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get labels: %w", err)
+	}
+
+	messageIDs, err := user.client.GetAllMessageIDs(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message ids: %w", err)
+	}
+
+	records := make([]ExportRecord, 0, len(messageIDs))
+
+	redaction := newRedactionRules(user.vault.RedactionRules())
+
+	keyPass := user.vault.KeyPass()
+	defer secret.Wipe(keyPass)
+
+	for idx, messageID := range messageIDs {
+		if progressCB != nil {
+			progressCB(user.ID(), idx+1, len(messageIDs))
+		}
+
+		full, err := user.client.GetFullMessage(ctx, messageID, usertypes.NewProtonAPIScheduler(user.panicHandler), proton.NewDefaultAttachmentAllocator())
+		if err != nil {
+			return nil, fmt.Errorf("failed to download message '%v': %w", messageID, err)
+		}
+
+		if isRedacted(redaction, full.MessageMetadata) {
+			user.log.WithField("messageID", messageID).Debug("Skipping redacted message")
+			continue
+		}
+
+		record := newExportRecord(full.Message, apiLabels)
+
+		if err := usertypes.WithAddrKR(apiUser, apiAddrs[full.AddressID], keyPass, func(_, addrKR *crypto.KeyRing) error {
+			literal, err := message.DecryptAndBuildRFC822(addrKR, full.Message, full.AttData, message.JobOptions{
+				AddInternalID:  true,
+				AddExternalID:  true,
+				AddMessageDate: true,
+			})
+			if err != nil {
+				return err
+			}
+
+			hash := sha256.Sum256(literal)
+
+			record.File = filepath.Join(exportMessagesDir, messageID+".eml")
+			record.SHA256 = hex.EncodeToString(hash[:])
+			record.Size = len(literal)
+
+			return os.WriteFile(filepath.Join(path, record.File), literal, 0o600)
+		}); err != nil {
+			user.log.WithError(err).WithField("messageID", messageID).Warn("Failed to export message")
+			record.Error = err.Error()
+		}
+
+		records = append(records, record)
+	}
+
+	if err := writeExportManifests(path, records); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+func newExportRecord(msg proton.Message, apiLabels map[string]proton.Label) ExportRecord {
+	return ExportRecord{
+		MessageID:  msg.ID,
+		ExternalID: msg.ExternalID,
+		AddressID:  msg.AddressID,
+		Subject:    msg.Subject,
+		From:       formatExportAddress(msg.Sender),
+		To:         xslices.Map(msg.ToList, formatExportAddress),
+		CC:         xslices.Map(msg.CCList, formatExportAddress),
+		BCC:        xslices.Map(msg.BCCList, formatExportAddress),
+		Date:       time.Unix(msg.Time, 0).UTC(),
+		Labels: xslices.Map(msg.LabelIDs, func(labelID string) string {
+			if label, ok := apiLabels[labelID]; ok {
+				return strings.Join(label.Path, "/")
+			}
+
+			return labelID
+		}),
+		Header: msg.Header,
+	}
+}
+
+func formatExportAddress(addr *mail.Address) string {
+	if addr == nil {
+		return ""
+	}
+
+	return addr.String()
+}
+
+func writeExportManifests(path string, records []ExportRecord) error {
+	b, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(path, exportManifestJSON), b, 0o600); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	var csvBuf strings.Builder
+
+	w := csv.NewWriter(&csvBuf)
+
+	if err := w.Write([]string{"MessageID", "ExternalID", "AddressID", "File", "SHA256", "Size", "Date", "From", "To", "CC", "BCC", "Subject", "Labels", "Error"}); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	for _, record := range records {
+		if err := w.Write([]string{
+			record.MessageID,
+			record.ExternalID,
+			record.AddressID,
+			record.File,
+			record.SHA256,
+			strconv.Itoa(record.Size),
+			record.Date.Format(time.RFC3339),
+			record.From,
+			strings.Join(record.To, ", "),
+			strings.Join(record.CC, ", "),
+			strings.Join(record.BCC, ", "),
+			record.Subject,
+			strings.Join(record.Labels, ", "),
+			record.Error,
+		}); err != nil {
+			return fmt.Errorf("failed to write manifest: %w", err)
+		}
+	}
+
+	w.Flush()
+
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(path, exportManifestCSV), []byte(csvBuf.String()), 0o600); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	// Hash the manifests in the format of sha256sum so the export can be verified with standard tools.
+	var hashes strings.Builder
+
+	for _, name := range []string{exportManifestJSON, exportManifestCSV} {
+		b, err := os.ReadFile(filepath.Join(path, name)) //nolint:gosec
+		if err != nil {
+			return fmt.Errorf("failed to read manifest: %w", err)
+		}
+
+		hash := sha256.Sum256(b)
+
+		hashes.WriteString(hex.EncodeToString(hash[:]) + "  " + name + "\n")
+	}
+
+	if err := os.WriteFile(filepath.Join(path, exportManifestHashes), []byte(hashes.String()), 0o600); err != nil {
+		return fmt.Errorf("failed to write manifest hashes: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,322 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package user
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/gopenpgp/v2/crypto"
+	"github.com/ProtonMail/proton-bridge/v3/internal/secret"
+	"github.com/ProtonMail/proton-bridge/v3/internal/services/imapservice"
+	"github.com/ProtonMail/proton-bridge/v3/internal/usertypes"
+	"github.com/ProtonMail/proton-bridge/v3/pkg/message"
+	"github.com/hashicorp/go-multierror"
+)
+
+var ErrInvalidExportFormat = errors.New("invalid export format")
+
+// ExportFormat is the format messages are exported in by ExportMailbox.
+type ExportFormat int
+
+const (
+	// ExportFormatMBOX writes each mailbox as an mboxrd file, e.g. Folders/Work.mbox.
+	ExportFormatMBOX ExportFormat = iota
+
+	// ExportFormatEML writes each mailbox as a directory holding one EML file per message.
+	ExportFormatEML
+)
+
+func (format ExportFormat) String() string {
+	switch format {
+	case ExportFormatMBOX:
+		return "mbox"
+
+	case ExportFormatEML:
+		return "eml"
+
+	default:
+		return "unknown"
+	}
+}
+
+// ParseExportFormat returns the export format of the given name, "mbox" or "eml".
+func ParseExportFormat(name string) (ExportFormat, error) {
+	for _, format := range []ExportFormat{ExportFormatMBOX, ExportFormatEML} {
+		if strings.EqualFold(name, format.String()) {
+			return format, nil
+		}
+	}
+
+	return 0, fmt.Errorf("%w: %q", ErrInvalidExportFormat, name)
+}
+
+// ExportProgress is the progress of a mailbox export.
+type ExportProgress struct {
+	// Exported and Failed count the messages exported and those which couldn't be decrypted so far.
+	Exported int
+	Failed   int
+
+	// Redacted counts the messages skipped because they match the user's redaction rules.
+	Redacted int
+
+	// Total is the number of messages of the user.
+	Total int
+}
+
+// ExportMailbox writes the messages of every mailbox of the user, except All Mail, to path in the given format,
+// laid out like the IMAP mailbox hierarchy. A message in several mailboxes is written to each of them.
+// The messages are downloaded from the API, so that those excluded from the local sync are exported too.
+// Messages that can't be decrypted are skipped and counted as failed; those matching the user's redaction rules
+// are skipped too. progressCB is called after each message.
+// If ctx is canceled, the export stops and the messages exported so far are left in path.
+func (user *User) ExportMailbox(
+	ctx context.Context,
+	path string,
+	format ExportFormat,
+	progressCB func(ExportProgress),
+) (ExportProgress, error) {
+	apiUser, err := user.identityService.GetAPIUser(ctx)
+	if err != nil {
+		return ExportProgress{}, fmt.Errorf("failed to get api user: %w", err)
+	}
+
+	apiAddrs, err := user.identityService.GetAddresses(ctx)
+	if err != nil {
+		return ExportProgress{}, fmt.Errorf("failed to get addresses: %w", err)
+	}
+
+	apiLabels, err := user.imapService.GetLabels(ctx)
+	if err != nil {
+		return ExportProgress{}, fmt.Errorf("failed to get labels: %w", err)
+	}
+
+	messageIDs, err := user.client.GetAllMessageIDs(ctx, "")
+	if err != nil {
+		return ExportProgress{}, fmt.Errorf("failed to get message ids: %w", err)
+	}
+
+	writer := newExportWriter(path, format)
+	defer func() {
+		if err := writer.close(); err != nil {
+			user.log.WithError(err).Error("Failed to close export files")
+		}
+	}()
+
+	keyPass := user.vault.KeyPass()
+	defer secret.Wipe(keyPass)
+
+	progress := ExportProgress{Total: len(messageIDs)}
+
+	redaction := newRedactionRules(user.vault.RedactionRules())
+
+	for _, messageID := range messageIDs {
+		if err := ctx.Err(); err != nil {
+			return progress, err
+		}
+
+		full, err := user.client.GetFullMessage(ctx, messageID, usertypes.NewProtonAPIScheduler(user.panicHandler), proton.NewDefaultAttachmentAllocator())
+		if err != nil {
+			return progress, fmt.Errorf("failed to download message '%v': %w", messageID, err)
+		}
+
+		if isRedacted(redaction, full.MessageMetadata) {
+			progress.Redacted++
+
+			if progressCB != nil {
+				progressCB(progress)
+			}
+
+			continue
+		}
+
+		var literal []byte
+
+		if err := usertypes.WithAddrKR(apiUser, apiAddrs[full.AddressID], keyPass, func(_, addrKR *crypto.KeyRing) error {
+			literal, err = message.DecryptAndBuildRFC822(addrKR, full.Message, full.AttData, message.JobOptions{
+				AddInternalID:  true,
+				AddExternalID:  true,
+				AddMessageDate: true,
+			})
+
+			return err
+		}); err != nil {
+			user.log.WithError(err).WithField("messageID", messageID).Warn("Failed to export message")
+			progress.Failed++
+		} else {
+			for _, labelID := range full.LabelIDs {
+				label, ok := apiLabels[labelID]
+				if !ok || labelID == proton.AllMailLabel || !imapservice.WantLabel(label) {
+					continue
+				}
+
+				if err := writer.write(label, full.MessageMetadata, literal); err != nil {
+					return progress, fmt.Errorf("failed to write message '%v': %w", messageID, err)
+				}
+			}
+
+			progress.Exported++
+		}
+
+		if progressCB != nil {
+			progressCB(progress)
+		}
+	}
+
+	return progress, nil
+}
+
+// exportWriter writes exported messages to the files of their mailboxes, keeping the mbox files open.
+type exportWriter struct {
+	path   string
+	format ExportFormat
+
+	files   map[string]*os.File
+	buffers map[string]*bufio.Writer
+}
+
+func newExportWriter(path string, format ExportFormat) *exportWriter {
+	return &exportWriter{
+		path:    path,
+		format:  format,
+		files:   make(map[string]*os.File),
+		buffers: make(map[string]*bufio.Writer),
+	}
+}
+
+func (w *exportWriter) write(label proton.Label, metadata proton.MessageMetadata, literal []byte) error {
+	name := filepath.Join(w.path, getExportPath(imapservice.GetMailboxName(label)))
+
+	if w.format == ExportFormatEML {
+		if err := os.MkdirAll(name, 0o700); err != nil {
+			return err
+		}
+
+		return os.WriteFile(filepath.Join(name, metadata.ID+".eml"), literal, 0o600)
+	}
+
+	buf, ok := w.buffers[label.ID]
+	if !ok {
+		if err := os.MkdirAll(filepath.Dir(name), 0o700); err != nil {
+			return err
+		}
+
+		file, err := os.OpenFile(name+".mbox", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600) //nolint:gosec
+		if err != nil {
+			return err
+		}
+
+		buf = bufio.NewWriter(file)
+
+		w.files[label.ID] = file
+		w.buffers[label.ID] = buf
+	}
+
+	from := "MAILER-DAEMON"
+
+	if metadata.Sender != nil && metadata.Sender.Address != "" {
+		from = metadata.Sender.Address
+	}
+
+	return writeMBOXMessage(buf, from, time.Unix(metadata.Time, 0), literal)
+}
+
+func (w *exportWriter) close() error {
+	var multiErr error
+
+	for labelID, file := range w.files {
+		if err := w.buffers[labelID].Flush(); err != nil {
+			multiErr = multierror.Append(multiErr, err)
+		}
+
+		if err := file.Close(); err != nil {
+			multiErr = multierror.Append(multiErr, err)
+		}
+	}
+
+	return multiErr
+}
+
+// writeMBOXMessage writes a message in the mboxrd format: a From_ line, then the message with LF line endings
+// and the lines starting with any number of '>' followed by "From " quoted with an extra '>', then an empty line.
+func writeMBOXMessage(w io.Writer, from string, date time.Time, literal []byte) error {
+	if _, err := fmt.Fprintf(w, "From %v %v\n", from, date.UTC().Format(time.ANSIC)); err != nil {
+		return err
+	}
+
+	lines := bytes.Split(literal, []byte("\n"))
+
+	if len(lines) > 0 && len(bytes.TrimSuffix(lines[len(lines)-1], []byte("\r"))) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+
+	for _, line := range lines {
+		line = bytes.TrimSuffix(line, []byte("\r"))
+
+		if bytes.HasPrefix(bytes.TrimLeft(line, ">"), []byte("From ")) {
+			if _, err := w.Write([]byte(">")); err != nil {
+				return err
+			}
+		}
+
+		if _, err := w.Write(line); err != nil {
+			return err
+		}
+
+		if _, err := w.Write([]byte("\n")); err != nil {
+			return err
+		}
+	}
+
+	_, err := w.Write([]byte("\n"))
+
+	return err
+}
+
+// getExportPath returns the relative file path of a mailbox, with the characters file systems don't allow
+// in names replaced by underscores.
+func getExportPath(name []string) string {
+	components := make([]string, 0, len(name))
+
+	for _, component := range name {
+		component = strings.Map(func(r rune) rune {
+			if r < ' ' || strings.ContainsRune(`/\:*?"<>|`, r) {
+				return '_'
+			}
+
+			return r
+		}, component)
+
+		if component == "" || component == "." || component == ".." {
+			component = "_"
+		}
+
+		components = append(components, component)
+	}
+
+	return filepath.Join(components...)
+}
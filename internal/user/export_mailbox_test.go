@@ -0,0 +1,60 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package user
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteMBOXMessage(t *testing.T) {
+	var buf bytes.Buffer
+
+	literal := []byte("Subject: Hi\r\n\r\nFrom here on\r\n>From there\r\nbye\r\n")
+
+	require.NoError(t, writeMBOXMessage(&buf, "sender@pm.me", time.Date(2023, 5, 4, 3, 2, 1, 0, time.UTC), literal))
+
+	// Lines are LF terminated and those starting with From, after any quoting, are quoted once more.
+	require.Equal(t, "From sender@pm.me Thu May  4 03:02:01 2023\nSubject: Hi\n\n>From here on\n>>From there\nbye\n\n", buf.String())
+
+	// The literal is left untouched, as it's written to every mailbox of the message.
+	require.Equal(t, "Subject: Hi\r\n\r\nFrom here on\r\n>From there\r\nbye\r\n", string(literal))
+}
+
+func TestGetExportPath(t *testing.T) {
+	require.Equal(t, "INBOX", getExportPath([]string{"INBOX"}))
+	require.Equal(t, filepath.Join("Folders", "Work", "Clients"), getExportPath([]string{"Folders", "Work", "Clients"}))
+	require.Equal(t, filepath.Join("Labels", "a_b", "_"), getExportPath([]string{"Labels", "a/b", ".."}))
+}
+
+func TestParseExportFormat(t *testing.T) {
+	format, err := ParseExportFormat("MBOX")
+	require.NoError(t, err)
+	require.Equal(t, ExportFormatMBOX, format)
+
+	format, err = ParseExportFormat("eml")
+	require.NoError(t, err)
+	require.Equal(t, ExportFormatEML, format)
+
+	_, err = ParseExportFormat("pst")
+	require.ErrorIs(t, err, ErrInvalidExportFormat)
+}
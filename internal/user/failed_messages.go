@@ -0,0 +1,55 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package user
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/services/imapservice"
+)
+
+// ListFailedMessages returns the messages that failed to download or build, with why they failed.
+func (user *User) ListFailedMessages(ctx context.Context) ([]imapservice.FailedMessage, error) {
+	failed, err := user.imapService.ListFailedMessages(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list failed messages: %w", err)
+	}
+
+	return failed, nil
+}
+
+// RetryFailedMessages tries the given failed messages again, or all of them if none are given,
+// and returns those still failing.
+func (user *User) RetryFailedMessages(ctx context.Context, messageIDs ...string) ([]imapservice.FailedMessage, error) {
+	failed, err := user.imapService.RetryFailedMessages(ctx, messageIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retry failed messages: %w", err)
+	}
+
+	return failed, nil
+}
+
+// SkipFailedMessages stops retrying the given failed messages.
+func (user *User) SkipFailedMessages(ctx context.Context, messageIDs ...string) error {
+	if err := user.imapService.SkipFailedMessages(ctx, messageIDs); err != nil {
+		return fmt.Errorf("failed to skip failed messages: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,662 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package user
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/gluon/rfc822"
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/gopenpgp/v2/crypto"
+	"github.com/ProtonMail/proton-bridge/v3/internal/secret"
+	"github.com/ProtonMail/proton-bridge/v3/internal/services/imapservice"
+	"github.com/ProtonMail/proton-bridge/v3/internal/usertypes"
+	"github.com/bradenaw/juniper/stream"
+	"github.com/bradenaw/juniper/xslices"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	// importBatchSize is the number of messages uploaded at once.
+	importBatchSize = 10
+
+	// importBackOff is the delay before retrying a batch the API first rejected with 429.
+	importBackOff = time.Second
+
+	// importMaxDelay is the longest delay before retrying a batch.
+	importMaxDelay = time.Minute
+
+	// importMaxRetries is how many times a batch rejected with 429 is retried.
+	importMaxRetries = 5
+
+	// importStateVersion is the version of the import state file; files of other versions are ignored.
+	importStateVersion = 1
+)
+
+// ImportProgress is the progress of a message import.
+type ImportProgress struct {
+	// Imported counts the messages uploaded so far, including those uploaded by an interrupted import of the same source.
+	Imported int
+
+	// Duplicates counts the messages skipped because a message with the same Message-Id was already in the account.
+	Duplicates int
+
+	// Failed counts the messages which couldn't be parsed or were rejected by the API.
+	Failed int
+
+	// Total is the number of messages in the source.
+	Total int
+}
+
+// ImportMessages uploads the messages of the MBOX files, EML files and Maildirs at source to the user's primary address.
+// Each message goes to the mailbox of the same path, e.g. Folders/Work or Labels/Todo; missing folders and labels
+// are created, and mailboxes without a prefix are taken as folders. Messages whose Message-Id is already in the account
+// aren't uploaded again, though they are given the label they would have been imported into.
+// Messages are uploaded in batches, which are retried with increasing delays while the API rate limits them.
+// The messages uploaded so far are recorded after each batch, so an import of the same source interrupted by
+// a cancellation, an error or a restart resumes where it left off. progressCB is called after each message.
+func (user *User) ImportMessages(ctx context.Context, source string, progressCB func(ImportProgress)) (ImportProgress, error) {
+	source, err := filepath.Abs(source)
+	if err != nil {
+		return ImportProgress{}, err
+	}
+
+	var total int
+
+	if err := walkImportSource(source, func(importMessage) error {
+		total++
+		return nil
+	}); err != nil {
+		return ImportProgress{}, fmt.Errorf("failed to read import source: %w", err)
+	}
+
+	apiUser, err := user.identityService.GetAPIUser(ctx)
+	if err != nil {
+		return ImportProgress{}, fmt.Errorf("failed to get api user: %w", err)
+	}
+
+	apiAddrs, err := user.identityService.GetAddresses(ctx)
+	if err != nil {
+		return ImportProgress{}, fmt.Errorf("failed to get addresses: %w", err)
+	}
+
+	addr, err := usertypes.GetPrimaryAddr(apiAddrs)
+	if err != nil {
+		return ImportProgress{}, fmt.Errorf("failed to get primary address: %w", err)
+	}
+
+	apiLabels, err := user.imapService.GetLabels(ctx)
+	if err != nil {
+		return ImportProgress{}, fmt.Errorf("failed to get labels: %w", err)
+	}
+
+	state, err := loadImportState(user.importStatePath, source)
+	if err != nil {
+		return ImportProgress{}, err
+	}
+
+	keyPass := user.vault.KeyPass()
+	defer secret.Wipe(keyPass)
+
+	imp := &messageImporter{
+		user:       user,
+		addrID:     addr.ID,
+		labels:     newImportLabels(user.client, apiLabels),
+		state:      state,
+		pending:    make(map[string]int),
+		imported:   make(map[string]string),
+		unfiled:    make(map[string]struct{}),
+		progress:   ImportProgress{Total: total},
+		progressCB: progressCB,
+	}
+
+	if err := usertypes.WithAddrKR(apiUser, addr, keyPass, func(_, addrKR *crypto.KeyRing) error {
+		imp.addrKR = addrKR
+
+		if err := walkImportSource(source, func(msg importMessage) error {
+			return imp.add(ctx, msg)
+		}); err != nil {
+			return err
+		}
+
+		return imp.flush(ctx)
+	}); err != nil {
+		return imp.progress, err
+	}
+
+	if err := state.clear(); err != nil {
+		user.log.WithError(err).Warn("Failed to remove import state")
+	}
+
+	return imp.progress, nil
+}
+
+// messageImporter uploads the messages of an import in batches.
+type messageImporter struct {
+	user   *User
+	addrID string
+	addrKR *crypto.KeyRing
+
+	labels *importLabels
+	state  *importState
+
+	// batch holds the messages waiting to be uploaded, and pending their index in batch by Message-Id.
+	batch   []importBatchEntry
+	pending map[string]int
+
+	// imported holds the IDs of the messages found or uploaded so far by Message-Id,
+	// and unfiled the Message-Ids of those which were uploaded to the archive as they were only in labels.
+	imported map[string]string
+	unfiled  map[string]struct{}
+
+	progress   ImportProgress
+	progressCB func(ImportProgress)
+}
+
+type importBatchEntry struct {
+	key        string
+	externalID string
+	req        proton.ImportReq
+}
+
+func (imp *messageImporter) add(ctx context.Context, msg importMessage) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	key := getImportKey(msg)
+
+	if imp.state.has(key) {
+		imp.progress.Imported++
+		imp.report()
+
+		return nil
+	}
+
+	label, err := imp.labels.get(ctx, msg.Mailbox)
+	if err != nil {
+		return err
+	}
+
+	header, err := rfc822.Parse(msg.Literal).ParseHeader()
+	if err != nil {
+		imp.user.log.WithError(err).WithField("mailbox", msg.Mailbox).Warn("Failed to parse imported message")
+		imp.progress.Failed++
+		imp.report()
+
+		return nil
+	}
+
+	externalID := strings.Trim(header.Get("Message-Id"), " <>")
+
+	if externalID != "" {
+		if ok, err := imp.addDuplicate(ctx, externalID, label); err != nil {
+			return err
+		} else if ok {
+			imp.state.add(key)
+			imp.progress.Duplicates++
+			imp.report()
+
+			return nil
+		}
+	}
+
+	imp.batch = append(imp.batch, importBatchEntry{
+		key:        key,
+		externalID: externalID,
+		req:        newImportReq(imp.addrID, label, header, msg),
+	})
+
+	if externalID != "" {
+		imp.pending[externalID] = len(imp.batch) - 1
+	}
+
+	if len(imp.batch) < importBatchSize {
+		return nil
+	}
+
+	return imp.flush(ctx)
+}
+
+// addDuplicate returns whether a message with the given Message-Id is already in the account or waiting to be uploaded.
+// If so, it is given the label, or moved to the folder if it was only in labels so far.
+func (imp *messageImporter) addDuplicate(ctx context.Context, externalID string, label proton.Label) (bool, error) {
+	_, unfiled := imp.unfiled[externalID]
+
+	if idx, ok := imp.pending[externalID]; ok {
+		req := &imp.batch[idx].req
+
+		switch {
+		case isImportLabel(label):
+			if !slices.Contains(req.Metadata.LabelIDs, label.ID) {
+				req.Metadata.LabelIDs = append(req.Metadata.LabelIDs, label.ID)
+			}
+
+		case unfiled:
+			req.Metadata.LabelIDs = xslices.Filter(req.Metadata.LabelIDs, func(labelID string) bool {
+				return labelID != proton.ArchiveLabel
+			})
+			req.Metadata.LabelIDs = append(req.Metadata.LabelIDs, label.ID)
+
+			delete(imp.unfiled, externalID)
+		}
+
+		return true, nil
+	}
+
+	messageID, ok := imp.imported[externalID]
+	if !ok {
+		metadata, err := imp.user.client.GetMessageMetadataPage(ctx, 0, 1, proton.MessageFilter{ExternalID: externalID})
+		if err != nil {
+			return false, fmt.Errorf("failed to look up message: %w", err)
+		}
+
+		if len(metadata) == 0 {
+			return false, nil
+		}
+
+		messageID = metadata[0].ID
+		imp.imported[externalID] = messageID
+	}
+
+	if !isImportLabel(label) && !unfiled {
+		return true, nil
+	}
+
+	if err := imp.user.client.LabelMessages(ctx, []string{messageID}, label.ID); err != nil {
+		return false, fmt.Errorf("failed to label message: %w", err)
+	}
+
+	if !isImportLabel(label) {
+		delete(imp.unfiled, externalID)
+	}
+
+	return true, nil
+}
+
+// flush uploads the messages waiting in the batch. If the batch is rejected, its messages are uploaded one by one
+// so that a single invalid message doesn't fail the others.
+func (imp *messageImporter) flush(ctx context.Context) error {
+	if len(imp.batch) == 0 {
+		return nil
+	}
+
+	batch := imp.batch
+
+	imp.batch = nil
+	imp.pending = make(map[string]int)
+
+	messageIDs, err := imp.upload(ctx, batch)
+	if err == nil {
+		for idx, entry := range batch {
+			imp.onImported(entry, messageIDs[idx])
+		}
+	} else {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+
+		for _, entry := range batch {
+			messageIDs, err := imp.upload(ctx, []importBatchEntry{entry})
+			if err != nil {
+				if ctxErr := ctx.Err(); ctxErr != nil {
+					return ctxErr
+				}
+
+				imp.user.log.WithError(err).Warn("Failed to import message")
+				imp.progress.Failed++
+			} else {
+				imp.onImported(entry, messageIDs[0])
+			}
+		}
+	}
+
+	if err := imp.state.save(); err != nil {
+		return err
+	}
+
+	imp.report()
+
+	return nil
+}
+
+func (imp *messageImporter) onImported(entry importBatchEntry, messageID string) {
+	if entry.externalID != "" {
+		imp.imported[entry.externalID] = messageID
+
+		if slices.Contains(entry.req.Metadata.LabelIDs, proton.ArchiveLabel) {
+			imp.unfiled[entry.externalID] = struct{}{}
+		}
+	}
+
+	imp.state.add(entry.key)
+	imp.progress.Imported++
+}
+
+// upload uploads the given messages, retrying with increasing delays while the API rejects them with 429.
+func (imp *messageImporter) upload(ctx context.Context, batch []importBatchEntry) ([]string, error) {
+	var delay time.Duration
+
+	for attempt := 0; ; attempt++ {
+		if delay > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+
+			case <-time.After(delay):
+			}
+		}
+
+		messageIDs, err := imp.tryUpload(ctx, batch)
+		if err == nil || !isTooManyRequests(err) || attempt >= importMaxRetries {
+			return messageIDs, err
+		}
+
+		if delay *= 2; delay < importBackOff {
+			delay = importBackOff
+		} else if delay > importMaxDelay {
+			delay = importMaxDelay
+		}
+	}
+}
+
+func (imp *messageImporter) tryUpload(ctx context.Context, batch []importBatchEntry) ([]string, error) {
+	// The requests are encrypted in place, so they are copied to keep the plain messages for retries.
+	reqs := xslices.Map(batch, func(entry importBatchEntry) proton.ImportReq {
+		return entry.req
+	})
+
+	str, err := imp.user.client.ImportMessages(ctx, imp.addrKR, 1, 1, reqs...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare messages for import: %w", err)
+	}
+
+	res, err := stream.Collect(ctx, str)
+	if err != nil {
+		return nil, fmt.Errorf("failed to import messages: %w", err)
+	}
+
+	return xslices.Map(res, func(res proton.ImportRes) string {
+		return res.MessageID
+	}), nil
+}
+
+func (imp *messageImporter) report() {
+	if imp.progressCB != nil {
+		imp.progressCB(imp.progress)
+	}
+}
+
+// newImportReq returns the request importing the message into the given mailbox, with the flags the IMAP service
+// gives appended messages. Messages only in a label are imported to the archive as well.
+func newImportReq(addrID string, label proton.Label, header *rfc822.Header, msg importMessage) proton.ImportReq {
+	labelIDs := []string{label.ID}
+
+	if isImportLabel(label) {
+		labelIDs = []string{proton.ArchiveLabel, label.ID}
+	}
+
+	if msg.Flagged {
+		labelIDs = append(labelIDs, proton.StarredLabel)
+	}
+
+	var flags proton.MessageFlag
+
+	unread := msg.Unread
+
+	switch {
+	case label.ID == proton.DraftsLabel:
+		unread = false
+
+	case label.ID == proton.InboxLabel:
+		flags = flags.Add(proton.MessageFlagReceived)
+
+	case label.ID == proton.SentLabel:
+		flags = flags.Add(proton.MessageFlagSent)
+
+	case header.Has("Received"):
+		flags = flags.Add(proton.MessageFlagReceived)
+
+	default:
+		flags = flags.Add(proton.MessageFlagSent)
+	}
+
+	return proton.ImportReq{
+		Metadata: proton.ImportMetadata{
+			AddressID: addrID,
+			LabelIDs:  labelIDs,
+			Unread:    proton.Bool(unread),
+			Flags:     flags,
+		},
+		Message: msg.Literal,
+	}
+}
+
+// isImportLabel returns whether messages imported into the given mailbox also need a folder.
+func isImportLabel(label proton.Label) bool {
+	return label.Type == proton.LabelTypeLabel || label.ID == proton.StarredLabel
+}
+
+// getImportKey identifies a message of an import source, to skip it when an interrupted import is resumed.
+func getImportKey(msg importMessage) string {
+	hash := sha256.New()
+
+	_, _ = hash.Write([]byte(msg.Mailbox))
+	_, _ = hash.Write([]byte{0})
+	_, _ = hash.Write(msg.Literal)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func isTooManyRequests(err error) bool {
+	apiErr := new(proton.APIError)
+
+	return errors.As(err, &apiErr) && apiErr.Status == http.StatusTooManyRequests
+}
+
+// importLabels resolves the mailboxes of an import source to labels, creating the missing ones.
+type importLabels struct {
+	client *proton.Client
+	labels map[string]proton.Label
+}
+
+func newImportLabels(client *proton.Client, apiLabels map[string]proton.Label) *importLabels {
+	labels := make(map[string]proton.Label)
+
+	for _, label := range apiLabels {
+		if imapservice.WantLabel(label) {
+			labels[getImportLabelKey(imapservice.GetMailboxName(label))] = label
+		}
+	}
+
+	// Messages can't be imported to All Mail alone, so they go to the archive instead.
+	if allMail, ok := apiLabels[proton.AllMailLabel]; ok {
+		if archive, ok := apiLabels[proton.ArchiveLabel]; ok {
+			labels[getImportLabelKey(imapservice.GetMailboxName(allMail))] = archive
+		}
+	}
+
+	return &importLabels{client: client, labels: labels}
+}
+
+// get returns the label of the mailbox of the given path. Mailboxes under Labels are labels, which can't be nested,
+// so Labels/a/b is the label a/b; the others are folders, under Folders or not, and their missing parents are created too.
+func (l *importLabels) get(ctx context.Context, mailbox string) (proton.Label, error) {
+	name := strings.Split(strings.Trim(mailbox, "/"), "/")
+
+	if label, ok := l.labels[getImportLabelKey(name)]; ok {
+		return label, nil
+	}
+
+	if labelName := getImportLabelPath(proton.LabelTypeLabel, name); labelName != nil {
+		return l.create(ctx, name, proton.CreateLabelReq{
+			Name:  strings.Join(labelName, "/"),
+			Color: "#f66",
+			Type:  proton.LabelTypeLabel,
+		})
+	}
+
+	path := name
+
+	if folderPath := getImportLabelPath(proton.LabelTypeFolder, name); folderPath != nil {
+		path = folderPath
+	}
+
+	var label proton.Label
+
+	for idx := range path {
+		folderName := imapservice.GetMailboxName(proton.Label{Type: proton.LabelTypeFolder, Path: path[:idx+1]})
+
+		folder, ok := l.labels[getImportLabelKey(folderName)]
+		if !ok {
+			var err error
+
+			if folder, err = l.create(ctx, folderName, proton.CreateLabelReq{
+				Name:     path[idx],
+				Color:    "#f66",
+				Type:     proton.LabelTypeFolder,
+				ParentID: label.ID,
+			}); err != nil {
+				return proton.Label{}, err
+			}
+		}
+
+		label = folder
+	}
+
+	l.labels[getImportLabelKey(name)] = label
+
+	return label, nil
+}
+
+func (l *importLabels) create(ctx context.Context, path []string, req proton.CreateLabelReq) (proton.Label, error) {
+	label, err := l.client.CreateLabel(ctx, req)
+	if err != nil {
+		return proton.Label{}, fmt.Errorf("failed to create mailbox %q: %w", strings.Join(path, "/"), err)
+	}
+
+	l.labels[getImportLabelKey(path)] = label
+
+	return label, nil
+}
+
+func getImportLabelKey(name []string) string {
+	return strings.ToLower(strings.Join(name, "/"))
+}
+
+// getImportLabelPath returns the path of the mailbox name under the prefix of the labels of the given type, if any.
+func getImportLabelPath(labelType proton.LabelType, name []string) []string {
+	prefix := imapservice.GetMailboxName(proton.Label{Type: labelType})
+
+	if len(name) <= len(prefix) || !strings.EqualFold(strings.Join(name[:len(prefix)], "/"), strings.Join(prefix, "/")) {
+		return nil
+	}
+
+	return name[len(prefix):]
+}
+
+type importStateFile struct {
+	Version  int
+	Source   string
+	Imported []string
+}
+
+// importState records the messages of an import source handled so far, in a file next to the user's sync state.
+// Only the state of the last import is kept: starting an import of another source discards it.
+type importState struct {
+	path     string
+	source   string
+	imported map[string]struct{}
+}
+
+func loadImportState(path, source string) (*importState, error) {
+	state := &importState{path: path, source: source, imported: make(map[string]struct{})}
+
+	data, err := os.ReadFile(filepath.Clean(path))
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read import state: %w", err)
+	}
+
+	var file importStateFile
+
+	if err := json.Unmarshal(data, &file); err != nil || file.Version != importStateVersion || file.Source != source {
+		return state, nil
+	}
+
+	for _, key := range file.Imported {
+		state.imported[key] = struct{}{}
+	}
+
+	return state, nil
+}
+
+func (state *importState) has(key string) bool {
+	_, ok := state.imported[key]
+
+	return ok
+}
+
+func (state *importState) add(key string) {
+	state.imported[key] = struct{}{}
+}
+
+func (state *importState) save() error {
+	keys := make([]string, 0, len(state.imported))
+
+	for key := range state.imported {
+		keys = append(keys, key)
+	}
+
+	data, err := json.Marshal(importStateFile{Version: importStateVersion, Source: state.source, Imported: keys})
+	if err != nil {
+		return fmt.Errorf("failed to marshal import state: %w", err)
+	}
+
+	// The state is replaced at once so that a crash while writing it leaves the previous one.
+	tmpPath := state.path + ".tmp"
+
+	if err := os.WriteFile(tmpPath, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write import state: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, state.path); err != nil {
+		return fmt.Errorf("failed to update import state: %w", err)
+	}
+
+	return nil
+}
+
+func (state *importState) clear() error {
+	if err := os.Remove(state.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove import state: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,269 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package user
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ProtonMail/gluon/rfc822"
+)
+
+var ErrInvalidImportSource = errors.New("invalid import source")
+
+// importMessage is a message read from an import source.
+type importMessage struct {
+	// Mailbox is the path of the mailbox the message was found in, e.g. "Folders/Work" or "Inbox".
+	Mailbox string
+
+	Literal []byte
+	Unread  bool
+	Flagged bool
+}
+
+// walkImportSource calls fn with each message found at path, which may be an MBOX or EML file, a Maildir,
+// or a directory holding any of them, such as one written by ExportMailbox. The mailbox of a message is the path
+// of its MBOX file without the extension or of the directory holding it, relative to path; messages at the top
+// of a directory go to the inbox. Maildir++ subfolders, such as .Work.Clients, are mailboxes of their own.
+func walkImportSource(path string, fn func(importMessage) error) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidImportSource, err)
+	}
+
+	if !info.IsDir() {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".eml":
+			return readEMLFile(path, importInbox, fn)
+
+		default:
+			return readMBOXFile(path, strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)), fn)
+		}
+	}
+
+	return filepath.WalkDir(path, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(path, name)
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() {
+			if !isMaildir(name) {
+				return nil
+			}
+
+			if err := readMaildir(name, getImportMailbox(rel), fn); err != nil {
+				return err
+			}
+
+			return filepath.SkipDir
+		}
+
+		switch strings.ToLower(filepath.Ext(name)) {
+		case ".mbox":
+			return readMBOXFile(name, getImportMailbox(strings.TrimSuffix(rel, filepath.Ext(rel))), fn)
+
+		case ".eml":
+			return readEMLFile(name, getImportMailbox(filepath.Dir(rel)), fn)
+
+		default:
+			return nil
+		}
+	})
+}
+
+// importInbox is the mailbox of the messages at the top of the source.
+const importInbox = "Inbox"
+
+// getImportMailbox returns the mailbox path of a path relative to the import source.
+func getImportMailbox(rel string) string {
+	if rel == "." || rel == "" {
+		return importInbox
+	}
+
+	return filepath.ToSlash(rel)
+}
+
+func readEMLFile(path, mailbox string, fn func(importMessage) error) error {
+	literal, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return err
+	}
+
+	return fn(importMessage{Mailbox: mailbox, Literal: normalizeImportLiteral(literal)})
+}
+
+// readMBOXFile reads the messages of an mboxo or mboxrd file: each starts with a From_ line,
+// and the lines of its body starting with any number of '>' followed by "From " have one '>' removed.
+// A Status header field without R, as written by most mail clients, marks the message unread.
+func readMBOXFile(path, mailbox string, fn func(importMessage) error) error {
+	file, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = file.Close() }()
+
+	reader := bufio.NewReader(file)
+
+	var (
+		literal bytes.Buffer
+		started bool
+	)
+
+	flush := func() error {
+		if !started {
+			return nil
+		}
+
+		// The empty line separating messages isn't part of the message.
+		b := bytes.TrimSuffix(literal.Bytes(), []byte("\n"))
+
+		header, err := rfc822.Parse(b).ParseHeader()
+		if err != nil {
+			return fmt.Errorf("%w: %v: %v", ErrInvalidImportSource, path, err)
+		}
+
+		status := header.Get("Status")
+
+		return fn(importMessage{
+			Mailbox: mailbox,
+			Literal: normalizeImportLiteral(b),
+			Unread:  header.Has("Status") && !strings.Contains(status, "R"),
+			Flagged: strings.Contains(header.Get("X-Status"), "F"),
+		})
+	}
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			switch {
+			case bytes.HasPrefix(line, []byte("From ")):
+				if err := flush(); err != nil {
+					return err
+				}
+
+				literal.Reset()
+				started = true
+
+			case !started:
+				return fmt.Errorf("%w: %v is not an MBOX file", ErrInvalidImportSource, path)
+
+			case bytes.HasPrefix(bytes.TrimLeft(line, ">"), []byte("From ")):
+				literal.Write(line[1:])
+
+			default:
+				literal.Write(line)
+			}
+		}
+
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return err
+		}
+	}
+
+	return flush()
+}
+
+// isMaildir returns whether the directory at path is a Maildir, holding cur and new subdirectories.
+func isMaildir(path string) bool {
+	for _, sub := range []string{"cur", "new"} {
+		if info, err := os.Stat(filepath.Join(path, sub)); err != nil || !info.IsDir() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// readMaildir reads the messages of a Maildir and of its Maildir++ subfolders. The messages in new,
+// and those in cur without the S info flag, are unread; those with the F info flag are flagged.
+func readMaildir(path, mailbox string, fn func(importMessage) error) error {
+	for _, sub := range []string{"new", "cur"} {
+		entries, err := os.ReadDir(filepath.Join(path, sub))
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+
+			literal, err := os.ReadFile(filepath.Join(path, sub, entry.Name()))
+			if err != nil {
+				return err
+			}
+
+			var info string
+
+			if idx := strings.LastIndex(entry.Name(), ":2,"); idx >= 0 {
+				info = entry.Name()[idx+3:]
+			}
+
+			if err := fn(importMessage{
+				Mailbox: mailbox,
+				Literal: normalizeImportLiteral(literal),
+				Unread:  sub == "new" || !strings.Contains(info, "S"),
+				Flagged: strings.Contains(info, "F"),
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() || !strings.HasPrefix(entry.Name(), ".") || !isMaildir(filepath.Join(path, entry.Name())) {
+			continue
+		}
+
+		subMailbox := strings.ReplaceAll(strings.TrimPrefix(entry.Name(), "."), ".", "/")
+
+		if mailbox != importInbox {
+			subMailbox = mailbox + "/" + subMailbox
+		}
+
+		if err := readMaildir(filepath.Join(path, entry.Name()), subMailbox, fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// normalizeImportLiteral returns the literal with CRLF line endings, as files on disk often have bare LFs.
+func normalizeImportLiteral(literal []byte) []byte {
+	return bytes.ReplaceAll(bytes.ReplaceAll(literal, []byte("\r\n"), []byte("\n")), []byte("\n"), []byte("\r\n"))
+}
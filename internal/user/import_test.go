@@ -0,0 +1,123 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package user
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWalkImportSource_MBOX(t *testing.T) {
+	dir := t.TempDir()
+
+	var buf bytes.Buffer
+
+	require.NoError(t, writeMBOXMessage(&buf, "a@pm.me", time.Now(), []byte("Subject: 1\r\nStatus: O\r\n\r\nFrom here on\r\n")))
+	require.NoError(t, writeMBOXMessage(&buf, "a@pm.me", time.Now(), []byte("Subject: 2\r\nStatus: RO\r\nX-Status: F\r\n\r\nbye\r\n")))
+
+	writeImportTestFile(t, filepath.Join(dir, "Folders", "Work.mbox"), buf.Bytes())
+	writeImportTestFile(t, filepath.Join(dir, "note.eml"), []byte("Subject: 3\n\nhi\n"))
+
+	msgs := collectImportSource(t, dir)
+	require.Len(t, msgs, 3)
+
+	// Messages written by ExportMailbox are read back as they were, with their flags.
+	require.Equal(t, importMessage{Mailbox: "Folders/Work", Literal: []byte("Subject: 1\r\nStatus: O\r\n\r\nFrom here on\r\n"), Unread: true}, msgs[0])
+	require.Equal(t, importMessage{Mailbox: "Folders/Work", Literal: []byte("Subject: 2\r\nStatus: RO\r\nX-Status: F\r\n\r\nbye\r\n"), Flagged: true}, msgs[1])
+
+	// Messages at the top of the source go to the inbox.
+	require.Equal(t, importMessage{Mailbox: "Inbox", Literal: []byte("Subject: 3\r\n\r\nhi\r\n")}, msgs[2])
+
+	// A single file is a mailbox of its own.
+	msgs = collectImportSource(t, filepath.Join(dir, "Folders", "Work.mbox"))
+	require.Len(t, msgs, 2)
+	require.Equal(t, "Work", msgs[0].Mailbox)
+
+	// Other files aren't MBOX files.
+	writeImportTestFile(t, filepath.Join(dir, "notes.txt"), []byte("Subject: 4\n\nhi\n"))
+	require.ErrorIs(t, walkImportSource(filepath.Join(dir, "notes.txt"), func(importMessage) error { return nil }), ErrInvalidImportSource)
+}
+
+func TestWalkImportSource_Maildir(t *testing.T) {
+	dir := t.TempDir()
+
+	writeImportTestFile(t, filepath.Join(dir, "new", "1"), []byte("Subject: 1\n\nhi\n"))
+	writeImportTestFile(t, filepath.Join(dir, "cur", "2:2,FS"), []byte("Subject: 2\n\nhi\n"))
+	writeImportTestFile(t, filepath.Join(dir, ".Work.Clients", "cur", "3:2,"), []byte("Subject: 3\n\nhi\n"))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".Work.Clients", "new"), 0o700))
+
+	msgs := collectImportSource(t, dir)
+	require.Len(t, msgs, 3)
+
+	require.Equal(t, importMessage{Mailbox: "Inbox", Literal: []byte("Subject: 1\r\n\r\nhi\r\n"), Unread: true}, msgs[0])
+	require.Equal(t, importMessage{Mailbox: "Inbox", Literal: []byte("Subject: 2\r\n\r\nhi\r\n"), Flagged: true}, msgs[1])
+	require.Equal(t, importMessage{Mailbox: "Work/Clients", Literal: []byte("Subject: 3\r\n\r\nhi\r\n"), Unread: true}, msgs[2])
+}
+
+func TestImportState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "import-state")
+
+	state, err := loadImportState(path, "/a")
+	require.NoError(t, err)
+	require.False(t, state.has("key"))
+
+	state.add("key")
+	require.NoError(t, state.save())
+
+	// An import of the same source resumes where the last one left off.
+	state, err = loadImportState(path, "/a")
+	require.NoError(t, err)
+	require.True(t, state.has("key"))
+
+	// An import of another source starts over.
+	state, err = loadImportState(path, "/b")
+	require.NoError(t, err)
+	require.False(t, state.has("key"))
+
+	require.NoError(t, state.clear())
+	require.NoFileExists(t, path)
+}
+
+func TestGetImportLabelPath(t *testing.T) {
+	require.Equal(t, []string{"Work", "Clients"}, getImportLabelPath(proton.LabelTypeFolder, []string{"folders", "Work", "Clients"}))
+	require.Equal(t, []string{"Todo"}, getImportLabelPath(proton.LabelTypeLabel, []string{"Labels", "Todo"}))
+	require.Nil(t, getImportLabelPath(proton.LabelTypeLabel, []string{"Labels"}))
+	require.Nil(t, getImportLabelPath(proton.LabelTypeFolder, []string{"Work"}))
+}
+
+func collectImportSource(t *testing.T, path string) []importMessage {
+	var msgs []importMessage
+
+	require.NoError(t, walkImportSource(path, func(msg importMessage) error {
+		msgs = append(msgs, msg)
+		return nil
+	}))
+
+	return msgs
+}
+
+func writeImportTestFile(t *testing.T, path string, b []byte) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o700))
+	require.NoError(t, os.WriteFile(path, b, 0o600))
+}
@@ -0,0 +1,62 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package user
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/services/imapservice"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+)
+
+// GetLabelKeywordMode returns whether the user's labels are exposed as IMAP keywords.
+func (user *User) GetLabelKeywordMode() vault.LabelKeywordMode {
+	return user.vault.LabelKeywordMode()
+}
+
+// SetLabelKeywordMode sets whether the user's labels are exposed as IMAP keywords.
+func (user *User) SetLabelKeywordMode(ctx context.Context, mode vault.LabelKeywordMode) error {
+	user.log.WithField("mode", mode).Info("Setting label keyword mode")
+
+	if err := user.vault.SetLabelKeywordMode(mode); err != nil {
+		return fmt.Errorf("failed to set label keyword mode: %w", err)
+	}
+
+	if err := user.imapService.SetLabelKeywordMode(ctx, newLabelKeywordMode(mode)); err != nil {
+		return fmt.Errorf("failed to set imap label keyword mode: %w", err)
+	}
+
+	return nil
+}
+
+func newLabelKeywordMode(mode vault.LabelKeywordMode) imapservice.LabelKeywordMode {
+	switch mode {
+	case vault.LabelKeywordsOff:
+		return imapservice.LabelKeywordsOff
+
+	case vault.LabelKeywordsBoth:
+		return imapservice.LabelKeywordsBoth
+
+	case vault.LabelKeywordsOnly:
+		return imapservice.LabelKeywordsOnly
+
+	default:
+		return imapservice.LabelKeywordsOff
+	}
+}
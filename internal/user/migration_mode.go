@@ -0,0 +1,43 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package user
+
+import (
+	"context"
+	"fmt"
+)
+
+// GetMigrationMode returns whether the user's message imports are tuned for bulk APPEND workloads.
+func (user *User) GetMigrationMode() bool {
+	return user.vault.MigrationMode()
+}
+
+// SetMigrationMode sets whether the user's message imports are tuned for bulk APPEND workloads.
+func (user *User) SetMigrationMode(ctx context.Context, enabled bool) error {
+	user.log.WithField("enabled", enabled).Info("Setting migration mode")
+
+	if err := user.vault.SetMigrationMode(enabled); err != nil {
+		return fmt.Errorf("failed to set migration mode: %w", err)
+	}
+
+	if err := user.imapService.SetMigrationMode(ctx, enabled); err != nil {
+		return fmt.Errorf("failed to set imap migration mode: %w", err)
+	}
+
+	return nil
+}
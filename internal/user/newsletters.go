@@ -0,0 +1,313 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ProtonMail/gluon/rfc822"
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/bradenaw/juniper/xslices"
+	"golang.org/x/exp/slices"
+)
+
+// newsletterScanSize is the number of the most recent received messages scanned for newsletters.
+const newsletterScanSize = 1000
+
+var ErrNoSuchNewsletter = errors.New("no such newsletter")
+
+// Newsletter aggregates the received messages of one mailing list, identified by their List-Id header or,
+// for lists which only set List-Unsubscribe, by their sender's address.
+type Newsletter struct {
+	ID   string
+	Name string
+
+	Count  int
+	Unread int
+	Size   int
+
+	LastReceived time.Time
+
+	// LastRead is the time of the most recent message which has been read, a proxy for when the list was last opened.
+	LastRead time.Time
+
+	// Unsubscribe lists the URIs of the List-Unsubscribe header of the most recent message, e.g. https and mailto.
+	Unsubscribe []string
+
+	// OneClick is set if the most recent message supports one-click unsubscription (RFC 8058).
+	OneClick bool
+
+	messageIDs []string
+	inboxIDs   []string
+}
+
+// listHeader holds the mailing list header fields of a message.
+type listHeader struct {
+	id          string
+	name        string
+	unsubscribe []string
+	oneClick    bool
+}
+
+// newsletterHeaders caches the list header fields of scanned messages, which never change,
+// so that repeated scans only download the headers of new messages.
+type newsletterHeaders struct {
+	lock    sync.Mutex
+	headers map[string]listHeader
+}
+
+func newNewsletterHeaders() *newsletterHeaders {
+	return &newsletterHeaders{headers: make(map[string]listHeader)}
+}
+
+func (h *newsletterHeaders) get(messageID string) (listHeader, bool) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	header, ok := h.headers[messageID]
+
+	return header, ok
+}
+
+func (h *newsletterHeaders) set(messageID string, header listHeader) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.headers[messageID] = header
+}
+
+// GetNewsletters returns the newsletters among the user's most recent received messages, the largest first.
+func (user *User) GetNewsletters(ctx context.Context) ([]Newsletter, error) {
+	newsletters, err := user.scanNewsletters(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(newsletters, func(i, j int) bool {
+		if newsletters[i].Count != newsletters[j].Count {
+			return newsletters[i].Count > newsletters[j].Count
+		}
+
+		return newsletters[i].ID < newsletters[j].ID
+	})
+
+	return newsletters, nil
+}
+
+// ArchiveNewsletter moves the messages of the given newsletter which are in the Inbox to the Archive.
+// It returns the number of messages moved.
+func (user *User) ArchiveNewsletter(ctx context.Context, newsletterID string) (int, error) {
+	newsletter, err := user.getNewsletter(ctx, newsletterID)
+	if err != nil {
+		return 0, err
+	}
+
+	user.log.WithField("newsletter", newsletterID).WithField("count", len(newsletter.inboxIDs)).Info("Archiving newsletter")
+
+	if len(newsletter.inboxIDs) == 0 {
+		return 0, nil
+	}
+
+	if err := user.client.LabelMessages(ctx, newsletter.inboxIDs, proton.ArchiveLabel); err != nil {
+		return 0, fmt.Errorf("failed to archive messages: %w", err)
+	}
+
+	return len(newsletter.inboxIDs), nil
+}
+
+// LabelNewsletter labels the messages of the given newsletter with the label of the given name.
+// It returns the number of messages labelled.
+func (user *User) LabelNewsletter(ctx context.Context, newsletterID, labelName string) (int, error) {
+	newsletter, err := user.getNewsletter(ctx, newsletterID)
+	if err != nil {
+		return 0, err
+	}
+
+	labels, err := user.imapService.GetLabels(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get labels: %w", err)
+	}
+
+	var labelID string
+
+	for _, label := range labels {
+		if label.Type == proton.LabelTypeLabel && strings.EqualFold(label.Name, labelName) {
+			labelID = label.ID
+			break
+		}
+	}
+
+	if labelID == "" {
+		return 0, fmt.Errorf("no such label %q", labelName)
+	}
+
+	user.log.WithField("newsletter", newsletterID).WithField("count", len(newsletter.messageIDs)).Info("Labelling newsletter")
+
+	if err := user.client.LabelMessages(ctx, newsletter.messageIDs, labelID); err != nil {
+		return 0, fmt.Errorf("failed to label messages: %w", err)
+	}
+
+	return len(newsletter.messageIDs), nil
+}
+
+func (user *User) getNewsletter(ctx context.Context, newsletterID string) (Newsletter, error) {
+	newsletters, err := user.scanNewsletters(ctx)
+	if err != nil {
+		return Newsletter{}, err
+	}
+
+	idx := slices.IndexFunc(newsletters, func(newsletter Newsletter) bool { return newsletter.ID == newsletterID })
+	if idx < 0 {
+		return Newsletter{}, ErrNoSuchNewsletter
+	}
+
+	return newsletters[idx], nil
+}
+
+// scanNewsletters groups the user's most recent received messages which aren't in Trash or Spam by mailing list.
+func (user *User) scanNewsletters(ctx context.Context) ([]Newsletter, error) {
+	metadata, err := user.client.GetMessageMetadata(ctx, proton.MessageFilter{LabelID: proton.AllMailLabel})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get messages: %w", err)
+	}
+
+	metadata = xslices.Filter(metadata, func(metadata proton.MessageMetadata) bool {
+		return metadata.Flags.Has(proton.MessageFlagReceived) &&
+			!slices.Contains(metadata.LabelIDs, proton.TrashLabel) &&
+			!slices.Contains(metadata.LabelIDs, proton.SpamLabel)
+	})
+
+	sort.Slice(metadata, func(i, j int) bool { return metadata[i].Time > metadata[j].Time })
+
+	if len(metadata) > newsletterScanSize {
+		metadata = metadata[:newsletterScanSize]
+	}
+
+	headers := make([]listHeader, 0, len(metadata))
+
+	for _, metadata := range metadata {
+		header, ok := user.newsletterHeaders.get(metadata.ID)
+		if !ok {
+			message, err := user.client.GetMessage(ctx, metadata.ID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get message: %w", err)
+			}
+
+			header = getListHeader(message.Header, message.Sender)
+
+			user.newsletterHeaders.set(metadata.ID, header)
+		}
+
+		headers = append(headers, header)
+	}
+
+	return getNewsletters(metadata, headers), nil
+}
+
+// getNewsletters aggregates the given messages, the most recent first, by the mailing list of their header.
+func getNewsletters(metadata []proton.MessageMetadata, headers []listHeader) []Newsletter {
+	var newsletters []Newsletter
+
+	index := make(map[string]int)
+
+	for i, metadata := range metadata {
+		header := headers[i]
+		if header.id == "" {
+			continue
+		}
+
+		idx, ok := index[header.id]
+		if !ok {
+			idx = len(newsletters)
+			index[header.id] = idx
+
+			newsletters = append(newsletters, Newsletter{
+				ID:           header.id,
+				Name:         header.name,
+				LastReceived: time.Unix(metadata.Time, 0),
+				Unsubscribe:  header.unsubscribe,
+				OneClick:     header.oneClick,
+			})
+		}
+
+		newsletter := &newsletters[idx]
+
+		newsletter.Count++
+		newsletter.Size += metadata.Size
+		newsletter.messageIDs = append(newsletter.messageIDs, metadata.ID)
+
+		if metadata.Unread {
+			newsletter.Unread++
+		} else if newsletter.LastRead.IsZero() {
+			newsletter.LastRead = time.Unix(metadata.Time, 0)
+		}
+
+		if slices.Contains(metadata.LabelIDs, proton.InboxLabel) {
+			newsletter.inboxIDs = append(newsletter.inboxIDs, metadata.ID)
+		}
+	}
+
+	return newsletters
+}
+
+// getListHeader returns the mailing list header fields of the given message header.
+// The returned ID is empty if the message has neither List-Id nor List-Unsubscribe.
+func getListHeader(literal string, sender *mail.Address) listHeader {
+	header, err := rfc822.NewHeader([]byte(literal))
+	if err != nil {
+		return listHeader{}
+	}
+
+	var res listHeader
+
+	for _, value := range strings.Split(header.Get("List-Unsubscribe"), ",") {
+		if uri := strings.Trim(strings.TrimSpace(value), "<>"); uri != "" {
+			res.unsubscribe = append(res.unsubscribe, uri)
+		}
+	}
+
+	res.oneClick = strings.EqualFold(strings.TrimSpace(header.Get("List-Unsubscribe-Post")), "List-Unsubscribe=One-Click") &&
+		slices.ContainsFunc(res.unsubscribe, func(uri string) bool { return strings.HasPrefix(uri, "https:") })
+
+	if listID := header.Get("List-Id"); listID != "" {
+		name, id, ok := strings.Cut(listID, "<")
+		if !ok {
+			res.id = strings.ToLower(strings.TrimSpace(listID))
+			res.name = res.id
+		} else {
+			res.id = strings.ToLower(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(id), ">")))
+			res.name = strings.Trim(strings.TrimSpace(name), `"`)
+		}
+	} else if len(res.unsubscribe) > 0 && sender != nil {
+		res.id = strings.ToLower(sender.Address)
+		res.name = sender.Name
+	}
+
+	if res.name == "" {
+		res.name = res.id
+	}
+
+	return res
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package user
+
+import (
+	"net/mail"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetListHeader(t *testing.T) {
+	sender := &mail.Address{Name: "Shop", Address: "News@Shop.example.com"}
+
+	header := getListHeader(
+		"From: news@shop.example.com\r\n"+
+			"List-Id: \"Go Nuts\" <Golang-Nuts.googlegroups.com>\r\n"+
+			"List-Unsubscribe: <mailto:unsub@example.com>,\r\n <https://example.com/unsub>\r\n"+
+			"List-Unsubscribe-Post: List-Unsubscribe=One-Click\r\n\r\n",
+		sender,
+	)
+	require.Equal(t, "golang-nuts.googlegroups.com", header.id)
+	require.Equal(t, "Go Nuts", header.name)
+	require.Equal(t, []string{"mailto:unsub@example.com", "https://example.com/unsub"}, header.unsubscribe)
+	require.True(t, header.oneClick)
+
+	// Without List-Id, the list is identified by its sender.
+	header = getListHeader("List-Unsubscribe: <mailto:unsub@example.com>\r\n\r\n", sender)
+	require.Equal(t, "news@shop.example.com", header.id)
+	require.Equal(t, "Shop", header.name)
+	require.False(t, header.oneClick)
+
+	// Other messages aren't newsletters.
+	require.Empty(t, getListHeader("Subject: Hello\r\n\r\n", sender).id)
+}
+
+func TestGetNewsletters(t *testing.T) {
+	news := listHeader{id: "news", name: "News", unsubscribe: []string{"mailto:unsub@example.com"}}
+
+	metadata := []proton.MessageMetadata{
+		{ID: "3", Time: 300, Size: 10, Unread: true, LabelIDs: []string{proton.InboxLabel}},
+		{ID: "2", Time: 200, Size: 20, LabelIDs: []string{proton.InboxLabel}},
+		{ID: "1", Time: 100, Size: 30, LabelIDs: []string{proton.ArchiveLabel}},
+		{ID: "0", Time: 50, Size: 40, LabelIDs: []string{proton.InboxLabel}},
+	}
+
+	newsletters := getNewsletters(metadata, []listHeader{news, news, news, {}})
+	require.Len(t, newsletters, 1)
+
+	newsletter := newsletters[0]
+	require.Equal(t, "news", newsletter.ID)
+	require.Equal(t, 3, newsletter.Count)
+	require.Equal(t, 1, newsletter.Unread)
+	require.Equal(t, 60, newsletter.Size)
+	require.Equal(t, time.Unix(300, 0), newsletter.LastReceived)
+	require.Equal(t, time.Unix(200, 0), newsletter.LastRead)
+	require.Equal(t, []string{"3", "2", "1"}, newsletter.messageIDs)
+	require.Equal(t, []string{"3", "2"}, newsletter.inboxIDs)
+}
@@ -0,0 +1,237 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package user
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/proton-bridge/v3/internal/services/nntp"
+	"github.com/ProtonMail/proton-bridge/v3/pkg/message"
+)
+
+// GetNNTPFolders returns the paths of the user's folders exposed as newsgroups.
+func (user *User) GetNNTPFolders() []string {
+	return user.vault.NNTPFolders()
+}
+
+// SetNNTPFolders sets the paths of the user's folders exposed as newsgroups, e.g. Lists/golang-nuts.
+func (user *User) SetNNTPFolders(folders []string) error {
+	user.log.WithField("folders", folders).Info("Setting NNTP folders")
+
+	if err := user.vault.SetNNTPFolders(folders); err != nil {
+		return fmt.Errorf("failed to set NNTP folders: %w", err)
+	}
+
+	return nil
+}
+
+// nntpReader exposes the folders the user selected as newsgroups.
+type nntpReader struct {
+	user *User
+
+	// folders maps newsgroup names to the IDs of their folders.
+	folders map[string]string
+}
+
+// NewNNTPReader returns the newsgroups of an NNTP client which signed in as the user.
+func (user *User) NewNNTPReader() nntp.Reader {
+	return &nntpReader{
+		user:    user,
+		folders: make(map[string]string),
+	}
+}
+
+// Groups returns a newsgroup for each selected folder which exists, named after its path, e.g. lists.golang-nuts.
+func (r *nntpReader) Groups(ctx context.Context) ([]nntp.Group, error) {
+	labels, err := r.user.imapService.GetLabels(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get labels: %w", err)
+	}
+
+	var groups []nntp.Group
+
+	for _, path := range r.user.vault.NNTPFolders() {
+		label, ok := getNNTPFolder(labels, path)
+		if !ok {
+			r.user.log.WithField("path", path).Warn("NNTP folder doesn't exist")
+			continue
+		}
+
+		name := getNewsgroupName(label.Path)
+
+		r.folders[name] = label.ID
+
+		groups = append(groups, nntp.Group{
+			Name:        name,
+			Description: strings.Join(label.Path, "/"),
+		})
+	}
+
+	return groups, nil
+}
+
+// Articles returns the messages of the newsgroup's folder. The metadata of messages doesn't include their threading
+// headers, so the messages of a thread, which share the same subject once reply prefixes and list tags are removed,
+// reference its first one for news readers to thread them.
+func (r *nntpReader) Articles(ctx context.Context, group string) ([]nntp.Article, error) {
+	folderID, ok := r.folders[group]
+	if !ok {
+		return nil, fmt.Errorf("no such newsgroup: %v", group)
+	}
+
+	metadata, err := r.user.client.GetMessageMetadata(ctx, proton.MessageFilter{LabelID: folderID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get folder messages: %w", err)
+	}
+
+	// The first message of each thread in the folder.
+	roots := make(map[string]proton.MessageMetadata)
+
+	for _, metadata := range metadata {
+		thread := getThreadSubject(metadata.Subject)
+
+		if root, ok := roots[thread]; !ok || isThreadRootBefore(metadata, root) {
+			roots[thread] = metadata
+		}
+	}
+
+	articles := make([]nntp.Article, 0, len(metadata))
+
+	for _, metadata := range metadata {
+		article := nntp.Article{
+			ID:        metadata.ID,
+			MessageID: getNNTPMessageID(metadata),
+			Subject:   metadata.Subject,
+			Date:      time.Unix(metadata.Time, 0),
+			Size:      metadata.Size,
+		}
+
+		if metadata.Sender != nil {
+			article.From = metadata.Sender.String()
+		}
+
+		if root := roots[getThreadSubject(metadata.Subject)]; root.ID != metadata.ID {
+			article.References = getNNTPMessageID(root)
+		}
+
+		articles = append(articles, article)
+	}
+
+	r.user.log.WithField("group", group).WithField("count", len(articles)).Debug("Listed NNTP articles")
+
+	return articles, nil
+}
+
+// Retrieve downloads, decrypts and builds the given message.
+func (r *nntpReader) Retrieve(ctx context.Context, id string) ([]byte, error) {
+	return r.user.buildMessage(ctx, id)
+}
+
+// getNNTPFolder returns the folder with the given path, which may be prefixed with Folders/ as in IMAP.
+func getNNTPFolder(labels map[string]proton.Label, path string) (proton.Label, bool) {
+	if prefix := "folders/"; len(path) > len(prefix) && strings.EqualFold(path[:len(prefix)], prefix) {
+		path = path[len(prefix):]
+	}
+
+	for _, label := range labels {
+		if label.Type == proton.LabelTypeFolder && strings.EqualFold(strings.Join(label.Path, "/"), path) {
+			return label, true
+		}
+	}
+
+	return proton.Label{}, false
+}
+
+// getNewsgroupName returns the newsgroup name of a folder path: its lowercased components joined with dots,
+// with the characters newsgroup names can't contain replaced by underscores.
+func getNewsgroupName(path []string) string {
+	components := make([]string, 0, len(path))
+
+	for _, component := range path {
+		components = append(components, strings.Map(func(r rune) rune {
+			if r <= ' ' || r > '~' || r == '.' || r == ',' || r == '*' || r == '?' || r == '[' || r == '\\' || r == '!' {
+				return '_'
+			}
+
+			return r
+		}, strings.ToLower(component)))
+	}
+
+	return strings.Join(components, ".")
+}
+
+// getThreadSubject returns the subject of the thread a message belongs to: its subject without the reply and
+// forward prefixes and mailing list tags, such as "Re: [golang-nuts] Fwd:", lowercased.
+func getThreadSubject(subject string) string {
+	subject = strings.ToLower(strings.Join(strings.Fields(subject), " "))
+
+	for {
+		trimmed := subject
+
+		for _, prefix := range []string{"re:", "fwd:", "fw:", "aw:"} {
+			trimmed = strings.TrimPrefix(trimmed, prefix)
+		}
+
+		if strings.HasPrefix(trimmed, "[") {
+			if end := strings.Index(trimmed, "]"); end > 0 {
+				trimmed = trimmed[end+1:]
+			}
+		}
+
+		trimmed = strings.TrimSpace(trimmed)
+
+		if trimmed == subject {
+			return subject
+		}
+
+		subject = trimmed
+	}
+}
+
+// isThreadRootBefore returns whether a message is more likely to start its thread than the other one:
+// it is older or, if received at the same time, isn't a reply or forward.
+func isThreadRootBefore(metadata, other proton.MessageMetadata) bool {
+	if metadata.Time != other.Time {
+		return metadata.Time < other.Time
+	}
+
+	if isReply, otherIsReply := isThreadReply(metadata.Subject), isThreadReply(other.Subject); isReply != otherIsReply {
+		return otherIsReply
+	}
+
+	return metadata.ID < other.ID
+}
+
+// isThreadReply returns whether the subject has reply or forward prefixes or mailing list tags.
+func isThreadReply(subject string) bool {
+	return getThreadSubject(subject) != strings.ToLower(strings.Join(strings.Fields(subject), " "))
+}
+
+// getNNTPMessageID returns the message-id of a message, made up from its internal ID if it doesn't have one,
+// as it is when the message is built.
+func getNNTPMessageID(metadata proton.MessageMetadata) string {
+	if metadata.ExternalID != "" {
+		return "<" + metadata.ExternalID + ">"
+	}
+
+	return "<" + metadata.ID + "@" + message.InternalIDDomain + ">"
+}
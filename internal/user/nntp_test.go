@@ -0,0 +1,51 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package user
+
+import (
+	"testing"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetThreadSubject(t *testing.T) {
+	require.Equal(t, "hello world", getThreadSubject("Hello  World"))
+	require.Equal(t, "hello world", getThreadSubject("Re: Hello World"))
+	require.Equal(t, "hello world", getThreadSubject("RE: [golang-nuts] Fwd: Hello World"))
+	require.Equal(t, "hello [world]", getThreadSubject("[list] Hello [World]"))
+	require.Equal(t, "", getThreadSubject("Re:"))
+}
+
+func TestIsThreadRootBefore(t *testing.T) {
+	hello := proton.MessageMetadata{ID: "b", Subject: "Hello", Time: 10}
+	reply := proton.MessageMetadata{ID: "a", Subject: "Re: Hello", Time: 10}
+
+	// Messages received at the same time are ordered by whether they are replies.
+	require.True(t, isThreadRootBefore(hello, reply))
+	require.False(t, isThreadRootBefore(reply, hello))
+
+	// Older messages come first.
+	reply.Time = 5
+	require.True(t, isThreadRootBefore(reply, hello))
+}
+
+func TestGetNewsgroupName(t *testing.T) {
+	require.Equal(t, "lists.golang-nuts", getNewsgroupName([]string{"Lists", "golang-nuts"}))
+	require.Equal(t, "mailing_lists.lkml_v2_0", getNewsgroupName([]string{"Mailing Lists", "LKML v2.0"}))
+}
@@ -0,0 +1,59 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package user
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/services/imapservice"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+)
+
+// GetNotificationRules returns the user's new message notification rules.
+func (user *User) GetNotificationRules() vault.NotificationRules {
+	return user.vault.NotificationRules()
+}
+
+// SetNotificationRules sets the rules deciding which new messages of the user trigger a notification.
+func (user *User) SetNotificationRules(ctx context.Context, rules vault.NotificationRules) error {
+	user.log.WithField("enabled", rules.Enabled).Info("Setting notification rules")
+
+	if err := user.vault.SetNotificationRules(rules); err != nil {
+		return fmt.Errorf("failed to set notification rules: %w", err)
+	}
+
+	if err := user.imapService.SetNotificationRules(ctx, newNotificationRules(rules)); err != nil {
+		return fmt.Errorf("failed to set imap notification rules: %w", err)
+	}
+
+	return nil
+}
+
+func newNotificationRules(rules vault.NotificationRules) imapservice.NotificationRules {
+	return imapservice.NotificationRules{
+		Enabled:   rules.Enabled,
+		Mailboxes: rules.Mailboxes,
+		Muted:     rules.Muted,
+		QuietHours: imapservice.QuietHours{
+			Enabled: rules.QuietHours.Enabled,
+			Start:   rules.QuietHours.Start,
+			End:     rules.QuietHours.End,
+		},
+	}
+}
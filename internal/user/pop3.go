@@ -0,0 +1,113 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package user
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/proton-bridge/v3/internal/services/pop3"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+)
+
+// pop3Mailbox serves the inbox of a user to a POP3 client.
+type pop3Mailbox struct {
+	user *User
+
+	// addrID restricts the maildrop to the messages of one address, in split mode.
+	addrID string
+
+	deleteAction vault.POP3DeleteAction
+}
+
+// NewPOP3Mailbox returns the maildrop of a POP3 client which signed in with the given address.
+func (user *User) NewPOP3Mailbox(addrID string, deleteAction vault.POP3DeleteAction) pop3.Mailbox {
+	if user.vault.AddressMode() == vault.CombinedMode {
+		addrID = ""
+	}
+
+	return &pop3Mailbox{
+		user:         user,
+		addrID:       addrID,
+		deleteAction: deleteAction,
+	}
+}
+
+// List returns the messages of the inbox, oldest first so that message numbers grow with new mail.
+func (mbox *pop3Mailbox) List(ctx context.Context) ([]pop3.Message, error) {
+	metadata, err := mbox.user.client.GetMessageMetadata(ctx, proton.MessageFilter{
+		LabelID:   proton.InboxLabel,
+		AddressID: mbox.addrID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get inbox messages: %w", err)
+	}
+
+	sort.Slice(metadata, func(i, j int) bool {
+		if metadata[i].Time != metadata[j].Time {
+			return metadata[i].Time < metadata[j].Time
+		}
+
+		return metadata[i].ID < metadata[j].ID
+	})
+
+	messages := make([]pop3.Message, 0, len(metadata))
+
+	for _, metadata := range metadata {
+		messages = append(messages, pop3.Message{
+			ID:   metadata.ID,
+			UID:  getPOP3UID(metadata.ID),
+			Size: metadata.Size,
+		})
+	}
+
+	return messages, nil
+}
+
+// Retrieve downloads, decrypts and builds the given message.
+func (mbox *pop3Mailbox) Retrieve(ctx context.Context, id string) ([]byte, error) {
+	return mbox.user.buildMessage(ctx, id)
+}
+
+// Delete applies the delete action to the messages the client deleted; they stay in the inbox by default.
+func (mbox *pop3Mailbox) Delete(ctx context.Context, ids []string) error {
+	switch mbox.deleteAction {
+	case vault.POP3Archive:
+		return mbox.user.client.LabelMessages(ctx, ids, proton.ArchiveLabel)
+
+	case vault.POP3Trash:
+		return mbox.user.client.LabelMessages(ctx, ids, proton.TrashLabel)
+
+	case vault.POP3Keep:
+		return nil
+
+	default:
+		return nil
+	}
+}
+
+// getPOP3UID derives the UIDL of a message from its ID, which is too long and may contain characters UIDL forbids.
+func getPOP3UID(messageID string) string {
+	hash := sha256.Sum256([]byte(messageID))
+
+	return hex.EncodeToString(hash[:16])
+}
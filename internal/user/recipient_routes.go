@@ -0,0 +1,109 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/services/imapservice"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+	"github.com/bradenaw/juniper/xslices"
+	"golang.org/x/exp/slices"
+)
+
+var ErrNoSuchRecipientRoute = errors.New("no such recipient route")
+
+// GetRecipientRoutes returns the rules filing the user's new messages into folders by recipient.
+func (user *User) GetRecipientRoutes() []vault.RecipientRoute {
+	return user.vault.GetRecipientRoutes()
+}
+
+// SetRecipientRoute creates or replaces the rule filing the user's new messages sent to recipients matching
+// the given pattern, e.g. shop@example.com or *@shop.example.com, into the folder at the given path.
+func (user *User) SetRecipientRoute(ctx context.Context, pattern, folder string) error {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+
+	if err := validateRecipientRoute(pattern, folder); err != nil {
+		return err
+	}
+
+	routes := user.vault.GetRecipientRoutes()
+
+	if idx := xslices.IndexFunc(routes, func(route vault.RecipientRoute) bool { return route.Pattern == pattern }); idx < 0 {
+		routes = append(routes, vault.RecipientRoute{Pattern: pattern, Folder: folder})
+	} else {
+		routes[idx].Folder = folder
+	}
+
+	user.log.WithField("pattern", pattern).WithField("folder", folder).Info("Setting recipient route")
+
+	return user.setRecipientRoutes(ctx, routes)
+}
+
+// DeleteRecipientRoute removes the user's rule for the given recipient pattern.
+func (user *User) DeleteRecipientRoute(ctx context.Context, pattern string) error {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+
+	routes := user.vault.GetRecipientRoutes()
+
+	idx := xslices.IndexFunc(routes, func(route vault.RecipientRoute) bool { return route.Pattern == pattern })
+	if idx < 0 {
+		return ErrNoSuchRecipientRoute
+	}
+
+	user.log.WithField("pattern", pattern).Info("Deleting recipient route")
+
+	return user.setRecipientRoutes(ctx, slices.Delete(routes, idx, idx+1))
+}
+
+func (user *User) setRecipientRoutes(ctx context.Context, routes []vault.RecipientRoute) error {
+	if err := user.vault.SetRecipientRoutes(routes); err != nil {
+		return fmt.Errorf("failed to set recipient routes: %w", err)
+	}
+
+	if err := user.imapService.SetRecipientRoutes(ctx, newRecipientRoutes(routes)); err != nil {
+		return fmt.Errorf("failed to set imap recipient routes: %w", err)
+	}
+
+	return nil
+}
+
+func newRecipientRoutes(routes []vault.RecipientRoute) []imapservice.RecipientRoute {
+	return xslices.Map(routes, func(route vault.RecipientRoute) imapservice.RecipientRoute {
+		return imapservice.RecipientRoute{
+			Pattern: route.Pattern,
+			Folder:  route.Folder,
+		}
+	})
+}
+
+func validateRecipientRoute(pattern, folder string) error {
+	if _, err := path.Match(pattern, ""); err != nil || !strings.Contains(pattern, "@") {
+		return fmt.Errorf("invalid recipient pattern %q", pattern)
+	}
+
+	if folder == "" {
+		return fmt.Errorf("invalid folder %q", folder)
+	}
+
+	return nil
+}
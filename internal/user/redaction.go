@@ -0,0 +1,98 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package user
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/proton-bridge/v3/internal/services/imapservice"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+	"golang.org/x/exp/slices"
+)
+
+// GetRedactionRules returns the rules selecting the user's messages whose bodies are never cached locally.
+func (user *User) GetRedactionRules() vault.RedactionRules {
+	return user.vault.RedactionRules()
+}
+
+// SetRedactionRules sets the rules selecting the user's messages whose bodies are never cached locally.
+// Labels may be given by label ID, IMAP path or name and are stored by ID. The user is resynced if the rules change.
+func (user *User) SetRedactionRules(ctx context.Context, rules vault.RedactionRules) error {
+	labels, err := user.imapService.GetLabels(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get labels: %w", err)
+	}
+
+	var labelIDs []string
+
+	for _, mailbox := range rules.LabelIDs {
+		label, ok := findMailbox(labels, mailbox)
+		if !ok {
+			return fmt.Errorf("%w: %q", imapservice.ErrNoSuchMailbox, mailbox)
+		}
+
+		if !slices.Contains(labelIDs, label.ID) {
+			labelIDs = append(labelIDs, label.ID)
+		}
+	}
+
+	var domains []string
+
+	for _, domain := range rules.SenderDomains {
+		domain = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(domain), "@"))
+
+		if domain != "" && !slices.Contains(domains, domain) {
+			domains = append(domains, domain)
+		}
+	}
+
+	slices.Sort(labelIDs)
+	slices.Sort(domains)
+
+	user.log.WithField("domains", domains).WithField("labels", labelIDs).Info("Setting redaction rules")
+
+	rules = vault.RedactionRules{SenderDomains: domains, LabelIDs: labelIDs}
+
+	if err := user.vault.SetRedactionRules(rules); err != nil {
+		return fmt.Errorf("failed to set redaction rules: %w", err)
+	}
+
+	if err := user.imapService.SetRedactionRules(ctx, newRedactionRules(rules)); err != nil {
+		return fmt.Errorf("failed to set imap redaction rules: %w", err)
+	}
+
+	return nil
+}
+
+// isRedacted returns whether the given message matches the redaction rules and so mustn't be written to disk.
+func isRedacted(rules imapservice.RedactionRules, metadata proton.MessageMetadata) bool {
+	var sender string
+
+	if metadata.Sender != nil {
+		sender = metadata.Sender.Address
+	}
+
+	return rules.Matches(sender, metadata.LabelIDs)
+}
+
+func newRedactionRules(rules vault.RedactionRules) imapservice.RedactionRules {
+	return imapservice.RedactionRules{SenderDomains: rules.SenderDomains, LabelIDs: rules.LabelIDs}
+}
@@ -0,0 +1,50 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package user
+
+import "fmt"
+
+// ValidateRules returns an error for each of the user's filing, filtering and search rules which doesn't compile,
+// e.g. because it was saved by a version which didn't check it.
+func (user *User) ValidateRules() []error {
+	var errs []error
+
+	for _, route := range user.vault.GetRecipientRoutes() {
+		if err := validateRecipientRoute(route.Pattern, route.Folder); err != nil {
+			errs = append(errs, fmt.Errorf("recipient route: %w", err))
+		}
+	}
+
+	for _, rule := range user.vault.GetSubaddressRules() {
+		if err := validateSubaddressRule(rule.Tag, rule.Label); err != nil {
+			errs = append(errs, fmt.Errorf("subaddress rule: %w", err))
+		}
+	}
+
+	for _, search := range user.vault.GetSavedSearches() {
+		if err := validateSavedSearch(search.Name, search.Query); err != nil {
+			errs = append(errs, fmt.Errorf("saved search %q: %w", search.Name, err))
+		}
+	}
+
+	if _, err := newSpamFilter(user.vault.SpamFilter()); err != nil {
+		errs = append(errs, fmt.Errorf("spam filter: %w", err))
+	}
+
+	return errs
+}
@@ -0,0 +1,110 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/savedsearch"
+	"github.com/ProtonMail/proton-bridge/v3/internal/services/imapservice"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+	"github.com/bradenaw/juniper/xslices"
+	"github.com/google/uuid"
+	"golang.org/x/exp/slices"
+)
+
+var ErrNoSuchSavedSearch = errors.New("no such saved search")
+
+// GetSavedSearches returns the user's saved searches.
+func (user *User) GetSavedSearches() []vault.SavedSearch {
+	return user.vault.GetSavedSearches()
+}
+
+// SetSavedSearch creates or replaces the user's saved search with the given name.
+// It is exposed over IMAP as the read-only mailbox Virtual/<name>.
+func (user *User) SetSavedSearch(ctx context.Context, name, query string) error {
+	if err := validateSavedSearch(name, query); err != nil {
+		return err
+	}
+
+	searches := user.vault.GetSavedSearches()
+
+	if idx := xslices.IndexFunc(searches, func(search vault.SavedSearch) bool { return search.Name == name }); idx < 0 {
+		searches = append(searches, vault.SavedSearch{ID: uuid.NewString(), Name: name, Query: query})
+	} else if searches[idx].Query != query {
+		// A new ID makes the mailbox be rebuilt from scratch.
+		searches[idx] = vault.SavedSearch{ID: uuid.NewString(), Name: name, Query: query}
+	} else {
+		return nil
+	}
+
+	user.log.WithField("name", name).Info("Setting saved search")
+
+	return user.setSavedSearches(ctx, searches)
+}
+
+// DeleteSavedSearch removes the user's saved search with the given name.
+func (user *User) DeleteSavedSearch(ctx context.Context, name string) error {
+	searches := user.vault.GetSavedSearches()
+
+	idx := xslices.IndexFunc(searches, func(search vault.SavedSearch) bool { return search.Name == name })
+	if idx < 0 {
+		return ErrNoSuchSavedSearch
+	}
+
+	user.log.WithField("name", name).Info("Deleting saved search")
+
+	return user.setSavedSearches(ctx, slices.Delete(searches, idx, idx+1))
+}
+
+func (user *User) setSavedSearches(ctx context.Context, searches []vault.SavedSearch) error {
+	if err := user.vault.SetSavedSearches(searches); err != nil {
+		return fmt.Errorf("failed to set saved searches: %w", err)
+	}
+
+	if err := user.imapService.SetSavedSearches(ctx, newSavedSearches(searches)); err != nil {
+		return fmt.Errorf("failed to set imap saved searches: %w", err)
+	}
+
+	return nil
+}
+
+func newSavedSearches(searches []vault.SavedSearch) []imapservice.SavedSearch {
+	return xslices.Map(searches, func(search vault.SavedSearch) imapservice.SavedSearch {
+		return imapservice.SavedSearch{
+			ID:    search.ID,
+			Name:  search.Name,
+			Query: search.Query,
+		}
+	})
+}
+
+func validateSavedSearch(name, query string) error {
+	if name == "" || strings.ContainsAny(name, "/\r\n") {
+		return fmt.Errorf("invalid saved search name %q", name)
+	}
+
+	if _, err := savedsearch.Parse(query); err != nil {
+		return fmt.Errorf("invalid saved search query: %w", err)
+	}
+
+	return nil
+}
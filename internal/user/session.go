@@ -0,0 +1,51 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package user
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ProtonMail/go-proton-api"
+)
+
+var ErrNoSuchSession = errors.New("the session is not listed by the API")
+
+// GetSession returns the API's information about the user's current session.
+func (user *User) GetSession(ctx context.Context) (proton.AuthSession, error) {
+	sessions, err := user.client.AuthSessions(ctx)
+	if err != nil {
+		return proton.AuthSession{}, err
+	}
+
+	authUID := user.vault.AuthUID()
+
+	for _, session := range sessions {
+		if session.UID == authUID {
+			return session, nil
+		}
+	}
+
+	return proton.AuthSession{}, ErrNoSuchSession
+}
+
+// GetSessionRefreshedAt returns when the user's session was last refreshed, or the zero time if it never was.
+func (user *User) GetSessionRefreshedAt() time.Time {
+	return user.vault.AuthRefreshedAt()
+}
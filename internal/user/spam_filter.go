@@ -0,0 +1,68 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package user
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/services/spamfilter"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+)
+
+// GetSpamFilter returns the user's local spam filter configuration.
+func (user *User) GetSpamFilter() vault.SpamFilter {
+	return user.vault.SpamFilter()
+}
+
+// SetSpamFilter sets the user's local spam filter configuration.
+func (user *User) SetSpamFilter(ctx context.Context, settings vault.SpamFilter) error {
+	user.log.WithField("backend", settings.Backend).Info("Setting spam filter")
+
+	filter, err := newSpamFilter(settings)
+	if err != nil {
+		return err
+	}
+
+	if err := user.vault.SetSpamFilter(settings); err != nil {
+		return fmt.Errorf("failed to set spam filter: %w", err)
+	}
+
+	if err := user.imapService.SetSpamFilter(ctx, filter); err != nil {
+		return fmt.Errorf("failed to set imap spam filter: %w", err)
+	}
+
+	return nil
+}
+
+// newSpamFilter returns the filter matching the given configuration, or nil if filtering is disabled.
+func newSpamFilter(settings vault.SpamFilter) (*spamfilter.Filter, error) {
+	switch settings.Backend {
+	case vault.SpamFilterNone:
+		return nil, nil
+
+	case vault.SpamFilterSpamd:
+		return spamfilter.NewSpamd(settings.Address, settings.MoveToSpam), nil
+
+	case vault.SpamFilterRspamd:
+		return spamfilter.NewRspamd(settings.Address, settings.MoveToSpam), nil
+
+	default:
+		return nil, fmt.Errorf("unknown spam filter backend %v", settings.Backend)
+	}
+}
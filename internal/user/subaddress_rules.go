@@ -0,0 +1,108 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/services/imapservice"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+	"github.com/bradenaw/juniper/xslices"
+	"golang.org/x/exp/slices"
+)
+
+var ErrNoSuchSubaddressRule = errors.New("no such subaddress rule")
+
+// GetSubaddressRules returns the rules labelling the user's new messages by subaddress tag.
+func (user *User) GetSubaddressRules() []vault.SubaddressRule {
+	return user.vault.GetSubaddressRules()
+}
+
+// SetSubaddressRule creates or replaces the rule labelling the user's new messages sent to subaddresses with the
+// given tag, e.g. news for user+news@pm.me, with the given label.
+func (user *User) SetSubaddressRule(ctx context.Context, tag, label string) error {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+
+	if err := validateSubaddressRule(tag, label); err != nil {
+		return err
+	}
+
+	rules := user.vault.GetSubaddressRules()
+
+	if idx := xslices.IndexFunc(rules, func(rule vault.SubaddressRule) bool { return rule.Tag == tag }); idx < 0 {
+		rules = append(rules, vault.SubaddressRule{Tag: tag, Label: label})
+	} else {
+		rules[idx].Label = label
+	}
+
+	user.log.WithField("tag", tag).WithField("label", label).Info("Setting subaddress rule")
+
+	return user.setSubaddressRules(ctx, rules)
+}
+
+// DeleteSubaddressRule removes the user's rule for the given subaddress tag.
+func (user *User) DeleteSubaddressRule(ctx context.Context, tag string) error {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+
+	rules := user.vault.GetSubaddressRules()
+
+	idx := xslices.IndexFunc(rules, func(rule vault.SubaddressRule) bool { return rule.Tag == tag })
+	if idx < 0 {
+		return ErrNoSuchSubaddressRule
+	}
+
+	user.log.WithField("tag", tag).Info("Deleting subaddress rule")
+
+	return user.setSubaddressRules(ctx, slices.Delete(rules, idx, idx+1))
+}
+
+func (user *User) setSubaddressRules(ctx context.Context, rules []vault.SubaddressRule) error {
+	if err := user.vault.SetSubaddressRules(rules); err != nil {
+		return fmt.Errorf("failed to set subaddress rules: %w", err)
+	}
+
+	if err := user.imapService.SetSubaddressRules(ctx, newSubaddressRules(rules)); err != nil {
+		return fmt.Errorf("failed to set imap subaddress rules: %w", err)
+	}
+
+	return nil
+}
+
+func newSubaddressRules(rules []vault.SubaddressRule) []imapservice.SubaddressRule {
+	return xslices.Map(rules, func(rule vault.SubaddressRule) imapservice.SubaddressRule {
+		return imapservice.SubaddressRule{
+			Tag:   rule.Tag,
+			Label: rule.Label,
+		}
+	})
+}
+
+func validateSubaddressRule(tag, label string) error {
+	if tag == "" || strings.ContainsAny(tag, "+@ \t") {
+		return fmt.Errorf("invalid subaddress tag %q", tag)
+	}
+
+	if label == "" || strings.Contains(label, "/") {
+		return fmt.Errorf("invalid label %q", label)
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/services/syncservice"
+)
+
+// syncCheckpointVersion is the version of the sync checkpoint file; files of other versions are ignored.
+const syncCheckpointVersion = 1
+
+type syncCheckpointFile struct {
+	Version    int
+	Checkpoint syncservice.Checkpoint
+}
+
+// syncCheckpointStore persists the user's sync checkpoint to a file next to their sync state, so that a sync
+// interrupted by a restart or a crash resumes where it left off. It is written after every download batch.
+type syncCheckpointStore struct {
+	path string
+	lock sync.Mutex
+}
+
+func newSyncCheckpointStore(path string) *syncCheckpointStore {
+	return &syncCheckpointStore{path: path}
+}
+
+func (store *syncCheckpointStore) GetSyncCheckpoint(_ context.Context) (syncservice.Checkpoint, error) {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	data, err := os.ReadFile(store.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return syncservice.Checkpoint{}, nil
+	} else if err != nil {
+		return syncservice.Checkpoint{}, fmt.Errorf("failed to read sync checkpoint: %w", err)
+	}
+
+	var file syncCheckpointFile
+
+	if err := json.Unmarshal(data, &file); err != nil {
+		return syncservice.Checkpoint{}, fmt.Errorf("failed to unmarshal sync checkpoint: %w", err)
+	}
+
+	if file.Version != syncCheckpointVersion {
+		return syncservice.Checkpoint{}, fmt.Errorf("unsupported sync checkpoint version %v", file.Version)
+	}
+
+	return file.Checkpoint, nil
+}
+
+func (store *syncCheckpointStore) SetSyncCheckpoint(_ context.Context, checkpoint syncservice.Checkpoint) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	data, err := json.Marshal(syncCheckpointFile{Version: syncCheckpointVersion, Checkpoint: checkpoint})
+	if err != nil {
+		return fmt.Errorf("failed to marshal sync checkpoint: %w", err)
+	}
+
+	// The checkpoint is replaced at once so that a crash while writing it leaves the previous one.
+	tmpPath := store.path + ".tmp"
+
+	if err := os.WriteFile(tmpPath, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write sync checkpoint: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, store.path); err != nil {
+		return fmt.Errorf("failed to update sync checkpoint: %w", err)
+	}
+
+	return nil
+}
+
+func (store *syncCheckpointStore) ClearSyncCheckpoint(_ context.Context) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	if err := os.Remove(store.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove sync checkpoint: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package user
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/services/syncservice"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncCheckpointStore(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "sync-checkpoint")
+
+	// Without a file, there is nothing to resume from.
+	checkpoint, err := newSyncCheckpointStore(path).GetSyncCheckpoint(ctx)
+	require.NoError(t, err)
+	require.True(t, checkpoint.IsEmpty())
+
+	// The checkpoint survives a restart.
+	want := syncservice.Checkpoint{
+		MetadataEndID: "msg-3",
+		Batches:       [][]string{{"msg-2"}, {"msg-3"}},
+	}
+
+	require.NoError(t, newSyncCheckpointStore(path).SetSyncCheckpoint(ctx, want))
+
+	checkpoint, err = newSyncCheckpointStore(path).GetSyncCheckpoint(ctx)
+	require.NoError(t, err)
+	require.Equal(t, want, checkpoint)
+
+	// Clearing it twice is fine.
+	require.NoError(t, newSyncCheckpointStore(path).ClearSyncCheckpoint(ctx))
+	require.NoError(t, newSyncCheckpointStore(path).ClearSyncCheckpoint(ctx))
+
+	checkpoint, err = newSyncCheckpointStore(path).GetSyncCheckpoint(ctx)
+	require.NoError(t, err)
+	require.True(t, checkpoint.IsEmpty())
+
+	// Unknown versions aren't resumed from.
+	require.NoError(t, os.WriteFile(path, []byte(`{"Version":2}`), 0o600))
+
+	_, err = newSyncCheckpointStore(path).GetSyncCheckpoint(ctx)
+	require.Error(t, err)
+}
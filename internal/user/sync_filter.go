@@ -0,0 +1,98 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package user
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/proton-bridge/v3/internal/services/imapservice"
+	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
+	"golang.org/x/exp/slices"
+)
+
+// GetSyncFilter returns the folders and labels excluded from the user's local sync.
+func (user *User) GetSyncFilter() vault.SyncFilter {
+	return user.vault.SyncFilter()
+}
+
+// SetSyncFilter excludes the given folders and labels from the user's local sync. They may be given by label ID,
+// by IMAP path, e.g. "Labels/Archive 2019", or by name, and are stored by ID so that they stay excluded once renamed.
+// The user is resynced if the filter changes.
+func (user *User) SetSyncFilter(ctx context.Context, filter vault.SyncFilter) error {
+	labels, err := user.imapService.GetLabels(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get labels: %w", err)
+	}
+
+	var labelIDs []string
+
+	for _, mailbox := range filter.ExcludedLabelIDs {
+		label, ok := findMailbox(labels, mailbox)
+		if !ok {
+			return fmt.Errorf("%w: %q", imapservice.ErrNoSuchMailbox, mailbox)
+		}
+
+		if !slices.Contains(labelIDs, label.ID) {
+			labelIDs = append(labelIDs, label.ID)
+		}
+	}
+
+	slices.Sort(labelIDs)
+
+	user.log.WithField("excluded", labelIDs).Info("Setting sync filter")
+
+	filter = vault.SyncFilter{ExcludedLabelIDs: labelIDs}
+
+	if err := user.vault.SetSyncFilter(filter); err != nil {
+		return fmt.Errorf("failed to set sync filter: %w", err)
+	}
+
+	if err := user.imapService.SetSyncFilter(ctx, newSyncFilter(filter)); err != nil {
+		return fmt.Errorf("failed to set imap sync filter: %w", err)
+	}
+
+	return nil
+}
+
+// findMailbox returns the folder or label exposed as a mailbox matching the given ID, IMAP path or name.
+func findMailbox(labels map[string]proton.Label, mailbox string) (proton.Label, bool) {
+	matches := []func(proton.Label) bool{
+		func(label proton.Label) bool { return label.ID == mailbox },
+		func(label proton.Label) bool {
+			return strings.EqualFold(strings.Join(imapservice.GetMailboxName(label), "/"), mailbox)
+		},
+		func(label proton.Label) bool { return strings.EqualFold(label.Name, mailbox) },
+	}
+
+	for _, match := range matches {
+		for _, label := range labels {
+			if imapservice.WantLabel(label) && match(label) {
+				return label, true
+			}
+		}
+	}
+
+	return proton.Label{}, false
+}
+
+func newSyncFilter(filter vault.SyncFilter) imapservice.SyncFilter {
+	return imapservice.SyncFilter{ExcludedLabelIDs: filter.ExcludedLabelIDs}
+}
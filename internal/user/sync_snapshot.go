@@ -0,0 +1,152 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package user
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/services/imapservice"
+	"github.com/bradenaw/juniper/xmaps"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+// SyncSnapshot is the sync state of a user at one point in time, for comparing before and after a resync
+// when messages appear to be missing. It's meant to be saved as JSON and attached to bug reports.
+type SyncSnapshot struct {
+	UserID string
+	Time   time.Time
+
+	// EventID is the last event the user has processed.
+	EventID string
+
+	// Mailboxes holds the server's message counts of every label exposed as a mailbox, by mailbox name.
+	Mailboxes map[string]MailboxCount
+
+	// FailedMessageIDs are the messages which couldn't be built during sync, sorted.
+	FailedMessageIDs []string
+}
+
+type MailboxCount struct {
+	Total  int
+	Unread int
+}
+
+// SyncSnapshotDiff holds what changed between two sync snapshots of a user.
+type SyncSnapshotDiff struct {
+	Since, Until time.Time
+
+	// OldEventID and NewEventID differ if events were processed in between.
+	OldEventID, NewEventID string
+
+	// Mailboxes holds the change in counts of the mailboxes whose counts changed; mailboxes which only exist in one
+	// of the snapshots count as empty in the other.
+	Mailboxes map[string]MailboxCount
+
+	// NewFailedMessageIDs failed to build since the old snapshot; FixedMessageIDs were built since.
+	NewFailedMessageIDs []string
+	FixedMessageIDs     []string
+}
+
+// IsEmpty returns whether nothing changed between the snapshots.
+func (diff SyncSnapshotDiff) IsEmpty() bool {
+	return diff.OldEventID == diff.NewEventID &&
+		len(diff.Mailboxes) == 0 &&
+		len(diff.NewFailedMessageIDs) == 0 &&
+		len(diff.FixedMessageIDs) == 0
+}
+
+// Diff compares the snapshot with a newer one.
+func (snap SyncSnapshot) Diff(newer SyncSnapshot) SyncSnapshotDiff {
+	diff := SyncSnapshotDiff{
+		Since:      snap.Time,
+		Until:      newer.Time,
+		OldEventID: snap.EventID,
+		NewEventID: newer.EventID,
+		Mailboxes:  make(map[string]MailboxCount),
+	}
+
+	for _, name := range maps.Keys(xmaps.Union(xmaps.SetFromSlice(maps.Keys(snap.Mailboxes)), xmaps.SetFromSlice(maps.Keys(newer.Mailboxes)))) {
+		oldCount, newCount := snap.Mailboxes[name], newer.Mailboxes[name]
+
+		if oldCount != newCount {
+			diff.Mailboxes[name] = MailboxCount{
+				Total:  newCount.Total - oldCount.Total,
+				Unread: newCount.Unread - oldCount.Unread,
+			}
+		}
+	}
+
+	oldFailed, newFailed := xmaps.SetFromSlice(snap.FailedMessageIDs), xmaps.SetFromSlice(newer.FailedMessageIDs)
+
+	diff.NewFailedMessageIDs = sortedKeys(xmaps.Difference(newFailed, oldFailed))
+	diff.FixedMessageIDs = sortedKeys(xmaps.Difference(oldFailed, newFailed))
+
+	return diff
+}
+
+// GetSyncSnapshot captures the current sync state of the user.
+func (user *User) GetSyncSnapshot(ctx context.Context) (SyncSnapshot, error) {
+	apiLabels, err := user.imapService.GetLabels(ctx)
+	if err != nil {
+		return SyncSnapshot{}, fmt.Errorf("failed to get labels: %w", err)
+	}
+
+	counts, err := user.client.GetGroupedMessageCount(ctx)
+	if err != nil {
+		return SyncSnapshot{}, fmt.Errorf("failed to get message counts: %w", err)
+	}
+
+	failed, err := user.imapService.GetSyncFailedMessageIDs(ctx)
+	if err != nil {
+		return SyncSnapshot{}, fmt.Errorf("failed to get sync failed messages: %w", err)
+	}
+
+	mailboxes := make(map[string]MailboxCount, len(counts))
+
+	for _, count := range counts {
+		label, ok := apiLabels[count.LabelID]
+		if !ok || !imapservice.WantLabel(label) {
+			continue
+		}
+
+		mailboxes[strings.Join(imapservice.GetMailboxName(label), "/")] = MailboxCount{Total: count.Total, Unread: count.Unread}
+	}
+
+	failed = slices.Clone(failed)
+	sort.Strings(failed)
+
+	return SyncSnapshot{
+		UserID:           user.ID(),
+		Time:             time.Now(),
+		EventID:          user.vault.EventID(),
+		Mailboxes:        mailboxes,
+		FailedMessageIDs: failed,
+	}, nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := maps.Keys(m)
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package user
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncSnapshot_Diff(t *testing.T) {
+	before := SyncSnapshot{
+		Time:    time.Date(2023, time.March, 1, 10, 0, 0, 0, time.UTC),
+		EventID: "event-1",
+		Mailboxes: map[string]MailboxCount{
+			"Inbox":       {Total: 10, Unread: 2},
+			"Sent":        {Total: 5},
+			"Folders/Old": {Total: 3},
+		},
+		FailedMessageIDs: []string{"msg-1", "msg-2"},
+	}
+
+	after := SyncSnapshot{
+		Time:    time.Date(2023, time.March, 1, 11, 0, 0, 0, time.UTC),
+		EventID: "event-2",
+		Mailboxes: map[string]MailboxCount{
+			"Inbox":       {Total: 8, Unread: 3},
+			"Sent":        {Total: 5},
+			"Folders/New": {Total: 3},
+		},
+		FailedMessageIDs: []string{"msg-2", "msg-3"},
+	}
+
+	diff := before.Diff(after)
+
+	require.False(t, diff.IsEmpty())
+	require.Equal(t, SyncSnapshotDiff{
+		Since:      before.Time,
+		Until:      after.Time,
+		OldEventID: "event-1",
+		NewEventID: "event-2",
+		Mailboxes: map[string]MailboxCount{
+			"Inbox":       {Total: -2, Unread: 1},
+			"Folders/Old": {Total: -3},
+			"Folders/New": {Total: 3},
+		},
+		NewFailedMessageIDs: []string{"msg-3"},
+		FixedMessageIDs:     []string{"msg-1"},
+	}, diff)
+
+	// Nothing changes between a snapshot and itself.
+	require.True(t, after.Diff(after).IsEmpty())
+}
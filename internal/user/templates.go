@@ -0,0 +1,70 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package user
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ProtonMail/proton-bridge/v3/internal/templates"
+)
+
+var ErrNoSuchTemplate = errors.New("no such template")
+
+// GetTemplates returns the user's message templates, keyed by name.
+func (user *User) GetTemplates() map[string]string {
+	return user.vault.GetTemplates()
+}
+
+// SetTemplate creates or replaces the user's message template with the given name.
+func (user *User) SetTemplate(name, body string) error {
+	if name == "" || strings.ContainsAny(name, " \t\r\n<>@") {
+		return fmt.Errorf("invalid template name %q", name)
+	}
+
+	// Make sure the template can be rendered before storing it.
+	if _, err := templates.Render(body, nil); err != nil {
+		return err
+	}
+
+	user.log.WithField("name", name).Info("Setting message template")
+
+	return user.vault.SetTemplate(name, body)
+}
+
+// DeleteTemplate removes the user's message template with the given name.
+func (user *User) DeleteTemplate(name string) error {
+	if _, ok := user.vault.GetTemplate(name); !ok {
+		return ErrNoSuchTemplate
+	}
+
+	user.log.WithField("name", name).Info("Deleting message template")
+
+	return user.vault.DeleteTemplate(name)
+}
+
+// RenderTemplate renders the user's message template with the given name.
+func (user *User) RenderTemplate(name string, vars map[string]string) (string, error) {
+	body, ok := user.vault.GetTemplate(name)
+	if !ok {
+		return "", ErrNoSuchTemplate
+	}
+
+	return templates.Render(body, vars)
+}
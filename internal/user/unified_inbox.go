@@ -0,0 +1,45 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package user
+
+import (
+	"context"
+
+	"github.com/ProtonMail/gluon/imap"
+	"github.com/ProtonMail/go-proton-api"
+)
+
+// GetInboxMessages returns the metadata of all messages in the user's inbox.
+func (user *User) GetInboxMessages(ctx context.Context) ([]proton.MessageMetadata, error) {
+	return user.imapService.GetMailboxMessages(ctx, proton.InboxLabel)
+}
+
+// BuildMessage returns the given message of the user as a gluon message creation update.
+func (user *User) BuildMessage(ctx context.Context, messageID string) (*imap.MessageCreated, error) {
+	return user.imapService.BuildMessage(ctx, messageID)
+}
+
+// SetMessagesSeen marks the given messages of the user as read or unread.
+func (user *User) SetMessagesSeen(ctx context.Context, messageIDs []string, seen bool) error {
+	return user.imapService.SetMessagesSeen(ctx, messageIDs, seen)
+}
+
+// SetMessagesFlagged stars or unstars the given messages of the user.
+func (user *User) SetMessagesFlagged(ctx context.Context, messageIDs []string, flagged bool) error {
+	return user.imapService.SetMessagesFlagged(ctx, messageIDs, flagged)
+}
@@ -30,6 +30,7 @@ import (
 	"github.com/ProtonMail/proton-bridge/v3/internal/configstatus"
 	"github.com/ProtonMail/proton-bridge/v3/internal/events"
 	"github.com/ProtonMail/proton-bridge/v3/internal/safe"
+	"github.com/ProtonMail/proton-bridge/v3/internal/secret"
 	"github.com/ProtonMail/proton-bridge/v3/internal/services/imapservice"
 	"github.com/ProtonMail/proton-bridge/v3/internal/services/orderedtasks"
 	"github.com/ProtonMail/proton-bridge/v3/internal/services/sendrecorder"
@@ -80,6 +81,14 @@ type User struct {
 	// goStatusProgress triggers a check/sending if progress is needed.
 	goStatusProgress func()
 
+	// goAutoPurge triggers a purge of old messages in Trash and Spam.
+	goAutoPurge func()
+
+	newsletterHeaders *newsletterHeaders
+
+	// importStatePath is the file recording the progress of the last message import.
+	importStatePath string
+
 	eventService     *userevents.Service
 	identityService  *useridentity.Service
 	smtpService      *smtp.Service
@@ -97,7 +106,10 @@ func New(
 	apiUser proton.User,
 	crashHandler async.PanicHandler,
 	showAllMail bool,
+	clientShims *imapservice.ClientShims,
+	redactedMessages *imapservice.RedactedMessages,
 	maxSyncMemory uint64,
+	syncCacheLimits syncservice.DownloadCacheLimits,
 	statsDir string,
 	telemetryManager telemetry.Availability,
 	imapServerManager imapservice.IMAPServerManager,
@@ -114,7 +126,10 @@ func New(
 		apiUser,
 		crashHandler,
 		showAllMail,
+		clientShims,
+		redactedMessages,
 		maxSyncMemory,
+		syncCacheLimits,
 		statsDir,
 		telemetryManager,
 		imapServerManager,
@@ -144,7 +159,10 @@ func newImpl(
 	apiUser proton.User,
 	crashHandler async.PanicHandler,
 	showAllMail bool,
+	clientShims *imapservice.ClientShims,
+	redactedMessages *imapservice.RedactedMessages,
 	maxSyncMemory uint64,
+	syncCacheLimits syncservice.DownloadCacheLimits,
 	statsDir string,
 	telemetryManager telemetry.Availability,
 	imapServerManager imapservice.IMAPServerManager,
@@ -188,6 +206,11 @@ func newImpl(
 
 	sendRecorder := sendrecorder.NewSendRecorder(sendrecorder.SendEntryExpiry)
 
+	spamFilter, err := newSpamFilter(encVault.SpamFilter())
+	if err != nil {
+		logrus.WithField("userID", apiUser.ID).WithError(err).Error("Failed to create spam filter, filtering is disabled")
+	}
+
 	// Create the user object.
 	user := &User{
 		log: logrus.WithField("userID", apiUser.ID),
@@ -210,6 +233,10 @@ func newImpl(
 		configStatus:     configStatus,
 		telemetryManager: telemetryManager,
 
+		newsletterHeaders: newNewsletterHeaders(),
+
+		importStatePath: filepath.Join(syncConfigDir, fmt.Sprintf("import-state-%v", apiUser.ID)),
+
 		serviceGroup: orderedtasks.NewOrderedCancelGroup(crashHandler),
 		smtpService:  nil,
 	}
@@ -239,6 +266,9 @@ func newImpl(
 		reporter,
 		encVault,
 		encVault,
+		encVault,
+		user,
+		user,
 		user,
 		user.eventService,
 		addressMode,
@@ -263,7 +293,28 @@ func newImpl(
 		eventSubscription,
 		syncConfigDir,
 		user.maxSyncMemory,
+		syncCacheLimits,
+		newSyncCheckpointStore(imapservice.GetSyncCheckpointPath(syncConfigDir, apiUser.ID)),
 		showAllMail,
+		clientShims,
+		spamFilter,
+		newNotificationRules(encVault.NotificationRules()),
+		newRecipientRoutes(encVault.GetRecipientRoutes()),
+		newSubaddressRules(encVault.GetSubaddressRules()),
+		newSavedSearches(encVault.GetSavedSearches()),
+		newLabelKeywordMode(encVault.LabelKeywordMode()),
+		newDeleteMode(encVault.DeleteMode()),
+		encVault.MigrationMode(),
+		encVault.ConversationMode(),
+		encVault.TranscodeToUTF8(),
+		newHeaderTemplate(encVault.HeaderTemplate()),
+		encVault.ContactDisplayNames(),
+		newDateLocation(encVault.DateTimeZone()),
+		encVault.RepairDates(),
+		encVault.DraftCoalescingInterval(),
+		newSyncFilter(encVault.SyncFilter()),
+		newRedactionRules(encVault.RedactionRules()),
+		redactedMessages,
 	)
 
 	// Check for status_progress when triggered.
@@ -272,12 +323,22 @@ func newImpl(
 	})
 	defer user.goStatusProgress()
 
+	// Purge old messages in Trash and Spam periodically or when triggered.
+	user.goAutoPurge = user.tasks.PeriodicOrTrigger(AutoPurgeInterval, 0, user.autoPurge)
+
+	// Send the weekly digest report when it's due.
+	user.tasks.Periodic(digestCheckInterval, 0, user.sendDigest)
+
 	// When we receive an auth object, we update it in the vault.
 	// This will be used to authorize the user on the next run.
 	user.client.AddAuthHandler(func(auth proton.Auth) {
 		if err := user.vault.SetAuth(auth.UID, auth.RefreshToken); err != nil {
 			user.log.WithError(err).Error("Failed to update auth in vault")
 		}
+
+		if err := user.vault.SetAuthRefreshedAt(time.Now()); err != nil {
+			user.log.WithError(err).Error("Failed to record session refresh in vault")
+		}
 	})
 
 	// When we are deauthorized, we send a deauth event to the event channel.
@@ -396,6 +457,11 @@ func (user *User) GetAddressMode() vault.AddressMode {
 	return user.vault.AddressMode()
 }
 
+// GetDisplayMetadata returns how frontends render the user's account.
+func (user *User) GetDisplayMetadata() vault.DisplayMetadata {
+	return user.vault.DisplayMetadata()
+}
+
 // SetAddressMode sets the user's address mode.
 func (user *User) SetAddressMode(ctx context.Context, mode vault.AddressMode) error {
 	user.log.WithField("mode", mode).Info("Setting address mode")
@@ -438,6 +504,33 @@ func (user *User) BadEventFeedbackResync(ctx context.Context) error {
 	return nil
 }
 
+// PauseSync stops the user's message sync until RetrySync is called.
+func (user *User) PauseSync(ctx context.Context) error {
+	if err := user.imapService.CancelSync(ctx); err != nil {
+		return fmt.Errorf("failed to cancel imap sync: %w", err)
+	}
+
+	return nil
+}
+
+// RetrySync restarts the user's message sync, resuming from where the previous attempt stopped.
+func (user *User) RetrySync(ctx context.Context) error {
+	if err := user.imapService.ResumeSync(ctx); err != nil {
+		return fmt.Errorf("failed to resume imap sync: %w", err)
+	}
+
+	return nil
+}
+
+// ResyncMailbox rebuilds the local state of the mailbox of the given label from the API.
+func (user *User) ResyncMailbox(ctx context.Context, labelID string) error {
+	if err := user.imapService.ResyncMailbox(ctx, labelID); err != nil {
+		return fmt.Errorf("failed to resync mailbox: %w", err)
+	}
+
+	return nil
+}
+
 func (user *User) OnBadEvent(ctx context.Context) {
 	if err := user.imapService.OnBadEvent(ctx); err != nil {
 		user.log.WithError(err).Error("Failed to notify imap service of bad event")
@@ -511,7 +604,10 @@ func (user *User) GluonKey() []byte {
 
 // BridgePass returns the user's bridge password, used for authentication over SMTP and IMAP.
 func (user *User) BridgePass() []byte {
-	return algo.B64RawEncode(user.vault.BridgePass())
+	pass := user.vault.BridgePass()
+	defer secret.Wipe(pass)
+
+	return algo.B64RawEncode(pass)
 }
 
 // UsedSpace returns the total space used by the user on the API.
@@ -575,6 +671,19 @@ func (user *User) OnStatusDown(ctx context.Context) {
 	}
 }
 
+// OnDeauth prepares the user for being logged out because its session expired.
+// The user is flagged as having to sign in again, and its SMTP clients are told to retry later
+// rather than that their credentials are wrong.
+func (user *User) OnDeauth(ctx context.Context) {
+	if err := user.vault.SetReauthRequired(true); err != nil {
+		user.log.WithError(err).Error("Failed to flag user as requiring re-authentication")
+	}
+
+	if err := user.smtpService.Suspend(ctx); err != nil {
+		user.log.WithError(err).Error("Failed to suspend smtp account")
+	}
+}
+
 // Logout logs the user out from the API.
 func (user *User) Logout(ctx context.Context, withAPI bool) error {
 	user.log.WithField("withAPI", withAPI).Info("Logging out user")
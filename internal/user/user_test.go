@@ -29,6 +29,7 @@ import (
 	"github.com/ProtonMail/proton-bridge/v3/internal/events"
 	"github.com/ProtonMail/proton-bridge/v3/internal/services/imapservice"
 	"github.com/ProtonMail/proton-bridge/v3/internal/services/smtp"
+	"github.com/ProtonMail/proton-bridge/v3/internal/services/syncservice"
 	"github.com/ProtonMail/proton-bridge/v3/internal/telemetry/mocks"
 	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
 	"github.com/ProtonMail/proton-bridge/v3/tests"
@@ -150,7 +151,10 @@ func withUser(tb testing.TB, ctx context.Context, _ *server.Server, m *proton.Ma
 		apiUser,
 		nil,
 		true,
+		nil,
+		nil,
 		vault.DefaultMaxSyncMemory,
+		syncservice.DownloadCacheLimits{MaxMemory: vault.DefaultSyncCacheMemory},
 		tb.TempDir(),
 		manager,
 		nullIMAPServerManager,
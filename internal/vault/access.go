@@ -0,0 +1,171 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package vault
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+var (
+	ErrNoSuchAccessGrant      = errors.New("no such access grant")
+	ErrAccessGrantRevoked     = errors.New("access grant is already revoked")
+	ErrAccessGrantTokenExists = errors.New("an access grant with this token already exists")
+	ErrNoSuchPairedClient     = errors.New("no such paired client")
+	ErrPairedClientRevoked    = errors.New("paired client is already revoked")
+)
+
+// GetAccessGrants returns all access grants, including the revoked ones.
+func (vault *Vault) GetAccessGrants() []AccessGrant {
+	return vault.getSafe().Settings.AccessGrants
+}
+
+// AddAccessGrant stores a grant of the given role to the given token.
+func (vault *Vault) AddAccessGrant(id, name string, role AccessRole, token string, createdAt time.Time) error {
+	vault.lock.Lock()
+	defer vault.lock.Unlock()
+
+	hash := hashAccessToken(token)
+
+	if slices.ContainsFunc(vault.getUnsafe().Settings.AccessGrants, func(grant AccessGrant) bool {
+		return grant.TokenHash == hash
+	}) {
+		return ErrAccessGrantTokenExists
+	}
+
+	return vault.modUnsafe(func(data *Data) {
+		data.Settings.AccessGrants = append(data.Settings.AccessGrants, AccessGrant{
+			ID:        id,
+			Name:      name,
+			Role:      role,
+			TokenHash: hash,
+			CreatedAt: createdAt,
+		})
+	})
+}
+
+// RevokeAccessGrant marks the grant with the given ID as revoked.
+func (vault *Vault) RevokeAccessGrant(id string, revokedAt time.Time) error {
+	vault.lock.Lock()
+	defer vault.lock.Unlock()
+
+	idx := slices.IndexFunc(vault.getUnsafe().Settings.AccessGrants, func(grant AccessGrant) bool {
+		return grant.ID == id
+	})
+
+	if idx < 0 {
+		return ErrNoSuchAccessGrant
+	}
+
+	if vault.getUnsafe().Settings.AccessGrants[idx].IsRevoked() {
+		return ErrAccessGrantRevoked
+	}
+
+	return vault.modUnsafe(func(data *Data) {
+		data.Settings.AccessGrants[idx].RevokedAt = revokedAt
+	})
+}
+
+// GetAccessGrantByToken returns the grant of the given token, if it exists and isn't revoked.
+func (vault *Vault) GetAccessGrantByToken(token string) (AccessGrant, bool) {
+	hash := []byte(hashAccessToken(token))
+
+	for _, grant := range vault.GetAccessGrants() {
+		if subtle.ConstantTimeCompare([]byte(grant.TokenHash), hash) == 1 && !grant.IsRevoked() {
+			return grant, true
+		}
+	}
+
+	return AccessGrant{}, false
+}
+
+func hashAccessToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(hash[:])
+}
+
+// GetPairingKey returns the key signing the tokens of paired clients, generating it if needed.
+func (vault *Vault) GetPairingKey() ([]byte, error) {
+	vault.lock.Lock()
+	defer vault.lock.Unlock()
+
+	if key := vault.getUnsafe().Settings.Pairing.Key; len(key) > 0 {
+		return key, nil
+	}
+
+	key := newRandomToken(32)
+
+	if err := vault.modUnsafe(func(data *Data) {
+		data.Settings.Pairing.Key = key
+	}); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// GetPairedClients returns all paired clients, including the revoked ones.
+func (vault *Vault) GetPairedClients() []PairedClient {
+	return vault.getSafe().Settings.Pairing.Clients
+}
+
+// GetPairedClient returns the paired client with the given ID.
+func (vault *Vault) GetPairedClient(id string) (PairedClient, bool) {
+	for _, client := range vault.GetPairedClients() {
+		if client.ID == id {
+			return client, true
+		}
+	}
+
+	return PairedClient{}, false
+}
+
+// AddPairedClient stores a newly paired client.
+func (vault *Vault) AddPairedClient(client PairedClient) error {
+	return vault.modSafe(func(data *Data) {
+		data.Settings.Pairing.Clients = append(data.Settings.Pairing.Clients, client)
+	})
+}
+
+// RevokePairedClient marks the paired client with the given ID as revoked.
+func (vault *Vault) RevokePairedClient(id string, revokedAt time.Time) error {
+	vault.lock.Lock()
+	defer vault.lock.Unlock()
+
+	idx := slices.IndexFunc(vault.getUnsafe().Settings.Pairing.Clients, func(client PairedClient) bool {
+		return client.ID == id
+	})
+
+	if idx < 0 {
+		return ErrNoSuchPairedClient
+	}
+
+	if vault.getUnsafe().Settings.Pairing.Clients[idx].IsRevoked() {
+		return ErrPairedClientRevoked
+	}
+
+	return vault.modUnsafe(func(data *Data) {
+		data.Settings.Pairing.Clients[idx].RevokedAt = revokedAt
+	})
+}
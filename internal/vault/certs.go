@@ -46,6 +46,13 @@ func (vault *Vault) GetBridgeTLSCert() ([]byte, []byte) {
 	return certs.Bridge.Cert, certs.Bridge.Key
 }
 
+// IsBridgeTLSCertCustom returns whether the certificate for the bridge is read from files provided by the user.
+func (vault *Vault) IsBridgeTLSCertCustom() bool {
+	certs := vault.getSafe().Certs
+
+	return certs.CustomCertPath != "" && certs.CustomKeyPath != ""
+}
+
 // SetBridgeTLSCertPath sets the path to PEM-encoded certificates for the bridge.
 func (vault *Vault) SetBridgeTLSCertPath(certPath, keyPath string) error {
 	if _, _, err := readPEMCert(certPath, keyPath); err != nil {
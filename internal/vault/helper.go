@@ -92,6 +92,22 @@ func GetVaultKey(kc *keychain.Keychain) ([]byte, error) {
 	return keyDec, nil
 }
 
+// GetPreviousVaultKey returns the vault key as it was before it was last rotated in the keychain.
+// It returns keychain.ErrNoPreviousVersion if the keychain doesn't keep versions of its secrets.
+func GetPreviousVaultKey(kc *keychain.Keychain) ([]byte, error) {
+	_, keyEnc, err := kc.GetPrevious(vaultSecretName)
+	if err != nil {
+		return nil, fmt.Errorf("could not get previous keychain item: %w", err)
+	}
+
+	keyDec, err := base64.StdEncoding.DecodeString(keyEnc)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode keychain item: %w", err)
+	}
+
+	return keyDec, nil
+}
+
 func SetVaultKey(kc *keychain.Keychain, key []byte) error {
 	return kc.Put(vaultSecretName, base64.StdEncoding.EncodeToString(key))
 }
@@ -26,6 +26,7 @@ import (
 	"github.com/ProtonMail/proton-bridge/v3/internal/updater"
 	"github.com/ProtonMail/proton-bridge/v3/internal/useragent"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/exp/slices"
 )
 
 const (
@@ -56,6 +57,42 @@ func (vault *Vault) SetSMTPPort(port int) error {
 	})
 }
 
+// GetIMAPBindAddress returns the IP address the IMAP server listens on, or an empty string for the default hosts.
+func (vault *Vault) GetIMAPBindAddress() string {
+	return vault.getSafe().Settings.IMAPBindAddress
+}
+
+// SetIMAPBindAddress sets the IP address the IMAP server listens on; empty uses the default hosts.
+func (vault *Vault) SetIMAPBindAddress(address string) error {
+	return vault.modSafe(func(data *Data) {
+		data.Settings.IMAPBindAddress = address
+	})
+}
+
+// GetSMTPBindAddress returns the IP address the SMTP server listens on, or an empty string for the default hosts.
+func (vault *Vault) GetSMTPBindAddress() string {
+	return vault.getSafe().Settings.SMTPBindAddress
+}
+
+// SetSMTPBindAddress sets the IP address the SMTP server listens on; empty uses the default hosts.
+func (vault *Vault) SetSMTPBindAddress(address string) error {
+	return vault.modSafe(func(data *Data) {
+		data.Settings.SMTPBindAddress = address
+	})
+}
+
+// GetLMTPEnabled returns whether the LMTP server is enabled.
+func (vault *Vault) GetLMTPEnabled() bool {
+	return vault.getSafe().Settings.LMTPEnabled
+}
+
+// SetLMTPEnabled sets whether the LMTP server is enabled.
+func (vault *Vault) SetLMTPEnabled(enabled bool) error {
+	return vault.modSafe(func(data *Data) {
+		data.Settings.LMTPEnabled = enabled
+	})
+}
+
 // GetIMAPSSL sets whether the IMAP server should use SSL.
 func (vault *Vault) GetIMAPSSL() bool {
 	return vault.getSafe().Settings.IMAPSSL
@@ -68,6 +105,42 @@ func (vault *Vault) SetIMAPSSL(ssl bool) error {
 	})
 }
 
+// GetIPFamily returns the IP version the IMAP and SMTP servers listen on.
+func (vault *Vault) GetIPFamily() IPFamily {
+	return vault.getSafe().Settings.IPFamily
+}
+
+// SetIPFamily sets the IP version the IMAP and SMTP servers listen on.
+func (vault *Vault) SetIPFamily(family IPFamily) error {
+	return vault.modSafe(func(data *Data) {
+		data.Settings.IPFamily = family
+	})
+}
+
+// GetNNTPPort returns the port that the NNTP server listens on, or zero if it's disabled.
+func (vault *Vault) GetNNTPPort() int {
+	return vault.getSafe().Settings.NNTPPort
+}
+
+// SetNNTPPort sets the port that the NNTP server should listen on; zero disables it.
+func (vault *Vault) SetNNTPPort(port int) error {
+	return vault.modSafe(func(data *Data) {
+		data.Settings.NNTPPort = port
+	})
+}
+
+// GetPOP3 returns the settings of the POP3 server.
+func (vault *Vault) GetPOP3() POP3 {
+	return vault.getSafe().Settings.POP3
+}
+
+// SetPOP3 sets the settings of the POP3 server.
+func (vault *Vault) SetPOP3(pop3 POP3) error {
+	return vault.modSafe(func(data *Data) {
+		data.Settings.POP3 = pop3
+	})
+}
+
 // GetSMTPSSL sets whether the SMTP server should use SSL.
 func (vault *Vault) GetSMTPSSL() bool {
 	return vault.getSafe().Settings.SMTPSSL
@@ -198,6 +271,18 @@ func (vault *Vault) SetTelemetryDisabled(telemetryDisabled bool) error {
 	})
 }
 
+// GetUpdateVerification returns the chain of checks passed by the last update installed by the updater.
+func (vault *Vault) GetUpdateVerification() updater.Verification {
+	return vault.getSafe().Settings.UpdateVerification
+}
+
+// SetUpdateVerification sets the chain of checks passed by the last update installed by the updater.
+func (vault *Vault) SetUpdateVerification(verification updater.Verification) error {
+	return vault.modSafe(func(data *Data) {
+		data.Settings.UpdateVerification = verification
+	})
+}
+
 // GetLastVersion returns the last version of the bridge that was run.
 func (vault *Vault) GetLastVersion() *semver.Version {
 	return semver.MustParse(vault.getSafe().Settings.LastVersion)
@@ -270,3 +355,234 @@ func (vault *Vault) SetLastHeartbeatSent(timestamp time.Time) error {
 		data.Settings.LastHeartbeatSent = timestamp
 	})
 }
+
+// GetIndexHook returns the command run after new messages have been written to the local store.
+func (vault *Vault) GetIndexHook() string {
+	return vault.getSafe().Settings.IndexHook
+}
+
+// SetIndexHook sets the command run after new messages have been written to the local store.
+func (vault *Vault) SetIndexHook(command string) error {
+	return vault.modSafe(func(data *Data) {
+		data.Settings.IndexHook = command
+	})
+}
+
+// GetDoNotDisturb returns the do not disturb settings.
+func (vault *Vault) GetDoNotDisturb() DoNotDisturb {
+	return vault.getSafe().Settings.DoNotDisturb
+}
+
+// SetDoNotDisturb sets the do not disturb settings.
+func (vault *Vault) SetDoNotDisturb(dnd DoNotDisturb) error {
+	return vault.modSafe(func(data *Data) {
+		data.Settings.DoNotDisturb = dnd
+	})
+}
+
+// GetResolver returns the DoH resolver settings.
+func (vault *Vault) GetResolver() Resolver {
+	return vault.getSafe().Settings.Resolver
+}
+
+// SetResolver sets the DoH resolver settings.
+func (vault *Vault) SetResolver(resolver Resolver) error {
+	return vault.modSafe(func(data *Data) {
+		data.Settings.Resolver = resolver
+	})
+}
+
+// GetTor returns the Tor routing settings.
+func (vault *Vault) GetTor() Tor {
+	return vault.getSafe().Settings.Tor
+}
+
+// SetTor sets the Tor routing settings.
+func (vault *Vault) SetTor(tor Tor) error {
+	return vault.modSafe(func(data *Data) {
+		data.Settings.Tor = tor
+	})
+}
+
+// GetTLSPolicy returns the policy of the TLS connections accepted by the IMAP and SMTP servers.
+func (vault *Vault) GetTLSPolicy() TLSPolicy {
+	return vault.getSafe().Settings.TLSPolicy
+}
+
+// SetTLSPolicy sets the policy of the TLS connections accepted by the IMAP and SMTP servers.
+func (vault *Vault) SetTLSPolicy(policy TLSPolicy) error {
+	return vault.modSafe(func(data *Data) {
+		data.Settings.TLSPolicy = policy
+	})
+}
+
+// GetMailto returns how the mailto: URLs passed to bridge are composed.
+func (vault *Vault) GetMailto() Mailto {
+	return vault.getSafe().Settings.Mailto
+}
+
+// SetMailto sets how the mailto: URLs passed to bridge are composed.
+func (vault *Vault) SetMailto(mailto Mailto) error {
+	return vault.modSafe(func(data *Data) {
+		data.Settings.Mailto = mailto
+	})
+}
+
+// GetSMTPRelay returns the settings of the SMTP relay for LAN devices.
+func (vault *Vault) GetSMTPRelay() SMTPRelay {
+	return vault.getSafe().Settings.SMTPRelay
+}
+
+// SetSMTPRelay sets the settings of the SMTP relay for LAN devices.
+func (vault *Vault) SetSMTPRelay(relay SMTPRelay) error {
+	return vault.modSafe(func(data *Data) {
+		data.Settings.SMTPRelay = relay
+	})
+}
+
+// GetLANAccess returns the exposure of the servers beyond localhost.
+func (vault *Vault) GetLANAccess() LANAccess {
+	return vault.getSafe().Settings.LANAccess
+}
+
+// SetLANAccess sets the exposure of the servers beyond localhost.
+func (vault *Vault) SetLANAccess(access LANAccess) error {
+	return vault.modSafe(func(data *Data) {
+		data.Settings.LANAccess = access
+	})
+}
+
+// GetServerLimits returns the limits throttling the IMAP clients.
+func (vault *Vault) GetServerLimits() ServerLimits {
+	return vault.getSafe().Settings.ServerLimits
+}
+
+// SetServerLimits sets the limits throttling the IMAP clients.
+func (vault *Vault) SetServerLimits(limits ServerLimits) error {
+	return vault.modSafe(func(data *Data) {
+		data.Settings.ServerLimits = limits
+	})
+}
+
+// GetSlowCommandThreshold returns the duration above which IMAP commands are kept for diagnostics.
+func (vault *Vault) GetSlowCommandThreshold() time.Duration {
+	return vault.getSafe().Settings.SlowCommandThreshold
+}
+
+// SetSlowCommandThreshold sets the duration above which IMAP commands are kept for diagnostics.
+func (vault *Vault) SetSlowCommandThreshold(threshold time.Duration) error {
+	return vault.modSafe(func(data *Data) {
+		data.Settings.SlowCommandThreshold = threshold
+	})
+}
+
+// GetRecordAPIFixtures returns whether the API traffic is recorded to fixture files.
+func (vault *Vault) GetRecordAPIFixtures() bool {
+	return vault.getSafe().Settings.RecordAPIFixtures
+}
+
+// SetRecordAPIFixtures sets whether the API traffic is recorded to fixture files.
+func (vault *Vault) SetRecordAPIFixtures(record bool) error {
+	return vault.modSafe(func(data *Data) {
+		data.Settings.RecordAPIFixtures = record
+	})
+}
+
+// GetSyncCacheMemory returns the memory used by each user's sync download cache.
+func (vault *Vault) GetSyncCacheMemory() uint64 {
+	if v := vault.getSafe().Settings.SyncCacheMemory; v != 0 {
+		return v
+	}
+
+	return DefaultSyncCacheMemory
+}
+
+// SetSyncCacheMemory sets the memory used by each user's sync download cache; zero uses the default.
+func (vault *Vault) SetSyncCacheMemory(size uint64) error {
+	return vault.modSafe(func(data *Data) {
+		data.Settings.SyncCacheMemory = size
+	})
+}
+
+// GetSyncCacheSpill returns whether the downloads evicted from the sync download cache are written to disk.
+func (vault *Vault) GetSyncCacheSpill() bool {
+	return vault.getSafe().Settings.SyncCacheSpill
+}
+
+// SetSyncCacheSpill sets whether the downloads evicted from the sync download cache are written to disk.
+func (vault *Vault) SetSyncCacheSpill(spill bool) error {
+	return vault.modSafe(func(data *Data) {
+		data.Settings.SyncCacheSpill = spill
+	})
+}
+
+// GetMetricsListener returns the host:port the Prometheus metrics are served on, or an empty string if they aren't.
+func (vault *Vault) GetMetricsListener() string {
+	return vault.getSafe().Settings.MetricsListener
+}
+
+// SetMetricsListener sets the host:port the Prometheus metrics are served on; empty doesn't serve them.
+func (vault *Vault) SetMetricsListener(address string) error {
+	return vault.modSafe(func(data *Data) {
+		data.Settings.MetricsListener = address
+	})
+}
+
+// GetDisabledClientShims returns the names of the workarounds for IMAP client quirks which are turned off.
+func (vault *Vault) GetDisabledClientShims() []string {
+	return slices.Clone(vault.getSafe().Settings.DisabledClientShims)
+}
+
+// SetDisabledClientShims sets the names of the workarounds for IMAP client quirks which are turned off.
+func (vault *Vault) SetDisabledClientShims(names []string) error {
+	return vault.modSafe(func(data *Data) {
+		data.Settings.DisabledClientShims = slices.Clone(names)
+	})
+}
+
+// GetPrivacyMode returns the privacy mode settings.
+func (vault *Vault) GetPrivacyMode() PrivacyMode {
+	return vault.getSafe().Settings.PrivacyMode
+}
+
+// SetPrivacyMode sets the privacy mode settings.
+func (vault *Vault) SetPrivacyMode(privacyMode PrivacyMode) error {
+	return vault.modSafe(func(data *Data) {
+		data.Settings.PrivacyMode = privacyMode
+	})
+}
+
+// GetUnifiedInbox returns the unified inbox settings.
+func (vault *Vault) GetUnifiedInbox() UnifiedInbox {
+	return vault.getSafe().Settings.UnifiedInbox
+}
+
+// SetUnifiedInboxEnabled sets whether the unified inbox is exposed.
+// The key of its gluon database and its password are generated the first time it is enabled.
+func (vault *Vault) SetUnifiedInboxEnabled(enabled bool) error {
+	return vault.modSafe(func(data *Data) {
+		if enabled && len(data.Settings.UnifiedInbox.GluonKey) == 0 {
+			data.Settings.UnifiedInbox.GluonKey = newRandomToken(32)
+		}
+
+		if enabled && len(data.Settings.UnifiedInbox.Password) == 0 {
+			data.Settings.UnifiedInbox.Password = newRandomToken(16)
+		}
+
+		data.Settings.UnifiedInbox.Enabled = enabled
+	})
+}
+
+// ResetUnifiedInboxPassword replaces the password of the unified inbox with a new random one.
+func (vault *Vault) ResetUnifiedInboxPassword() error {
+	return vault.modSafe(func(data *Data) {
+		data.Settings.UnifiedInbox.Password = newRandomToken(16)
+	})
+}
+
+// SetUnifiedInboxGluonID sets the ID of the gluon database backing the unified inbox.
+func (vault *Vault) SetUnifiedInboxGluonID(gluonID string) error {
+	return vault.modSafe(func(data *Data) {
+		data.Settings.UnifiedInbox.GluonID = gluonID
+	})
+}
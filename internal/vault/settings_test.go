@@ -18,8 +18,10 @@
 package vault_test
 
 import (
+	"crypto/tls"
 	"math"
 	"testing"
+	"time"
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/ProtonMail/gluon/async"
@@ -64,6 +66,301 @@ func TestVault_Settings_SMTP(t *testing.T) {
 	require.Equal(t, true, s.GetSMTPSSL())
 }
 
+func TestVault_Settings_LMTP(t *testing.T) {
+	// Create a new test vault.
+	s := newVault(t)
+
+	// The LMTP server is disabled by default.
+	require.False(t, s.GetLMTPEnabled())
+
+	// Enable it.
+	require.NoError(t, s.SetLMTPEnabled(true))
+	require.True(t, s.GetLMTPEnabled())
+}
+
+func TestVault_Settings_NNTP(t *testing.T) {
+	// Create a new test vault.
+	s := newVault(t)
+
+	// The NNTP server is disabled by default.
+	require.Zero(t, s.GetNNTPPort())
+
+	// Enable it.
+	require.NoError(t, s.SetNNTPPort(1119))
+	require.Equal(t, 1119, s.GetNNTPPort())
+}
+
+func TestVault_Settings_POP3(t *testing.T) {
+	// Create a new test vault.
+	s := newVault(t)
+
+	// The POP3 server is disabled and read-only by default.
+	require.Equal(t, vault.POP3{}, s.GetPOP3())
+
+	// Enable it.
+	require.NoError(t, s.SetPOP3(vault.POP3{Port: 1110, SSL: true, DeleteAction: vault.POP3Trash}))
+	require.Equal(t, vault.POP3{Port: 1110, SSL: true, DeleteAction: vault.POP3Trash}, s.GetPOP3())
+}
+
+func TestVault_Settings_BindAddress(t *testing.T) {
+	// Create a new test vault.
+	s := newVault(t)
+
+	// The servers listen on the default hosts by default.
+	require.Empty(t, s.GetIMAPBindAddress())
+	require.Empty(t, s.GetSMTPBindAddress())
+
+	// Modify the bind addresses.
+	require.NoError(t, s.SetIMAPBindAddress("192.168.1.10"))
+	require.NoError(t, s.SetSMTPBindAddress("0.0.0.0"))
+
+	// Check the new bind addresses.
+	require.Equal(t, "192.168.1.10", s.GetIMAPBindAddress())
+	require.Equal(t, "0.0.0.0", s.GetSMTPBindAddress())
+}
+
+func TestVault_Settings_SyncCache(t *testing.T) {
+	// Create a new test vault.
+	s := newVault(t)
+
+	// The sync download cache uses the default memory and drops what it evicts by default.
+	require.Equal(t, vault.DefaultSyncCacheMemory, s.GetSyncCacheMemory())
+	require.False(t, s.GetSyncCacheSpill())
+
+	// Modify the settings.
+	require.NoError(t, s.SetSyncCacheMemory(64*1024*1024))
+	require.NoError(t, s.SetSyncCacheSpill(true))
+	require.Equal(t, uint64(64*1024*1024), s.GetSyncCacheMemory())
+	require.True(t, s.GetSyncCacheSpill())
+
+	// Zero resets the memory to the default.
+	require.NoError(t, s.SetSyncCacheMemory(0))
+	require.Equal(t, vault.DefaultSyncCacheMemory, s.GetSyncCacheMemory())
+}
+
+func TestVault_Settings_MetricsListener(t *testing.T) {
+	// Create a new test vault.
+	s := newVault(t)
+
+	// The metrics aren't served by default.
+	require.Empty(t, s.GetMetricsListener())
+
+	// Serve them.
+	require.NoError(t, s.SetMetricsListener("127.0.0.1:9154"))
+	require.Equal(t, "127.0.0.1:9154", s.GetMetricsListener())
+}
+
+func TestVault_Settings_IPFamily(t *testing.T) {
+	// Create a new test vault.
+	s := newVault(t)
+
+	// The servers listen on IPv4 by default.
+	require.Equal(t, vault.IPv4, s.GetIPFamily())
+
+	// Modify the IP family.
+	require.NoError(t, s.SetIPFamily(vault.DualStack))
+
+	// Check the new IP family.
+	require.Equal(t, vault.DualStack, s.GetIPFamily())
+}
+
+func TestVault_Settings_Resolver(t *testing.T) {
+	// Create a new test vault.
+	s := newVault(t)
+
+	// No custom resolver settings by default.
+	require.Equal(t, vault.Resolver{}, s.GetResolver())
+
+	// Modify the resolver settings.
+	require.NoError(t, s.SetResolver(vault.Resolver{
+		DoHProviders:     []string{"https://dns.example.com/dns-query"},
+		BootstrapIPs:     []string{"192.0.2.1"},
+		DisableSystemDNS: true,
+	}))
+
+	// Check the new resolver settings.
+	require.Equal(t, vault.Resolver{
+		DoHProviders:     []string{"https://dns.example.com/dns-query"},
+		BootstrapIPs:     []string{"192.0.2.1"},
+		DisableSystemDNS: true,
+	}, s.GetResolver())
+}
+
+func TestVault_Settings_Tor(t *testing.T) {
+	// Create a new test vault.
+	s := newVault(t)
+
+	// Tor is disabled by default.
+	require.Equal(t, vault.Tor{}, s.GetTor())
+
+	// Modify the Tor settings.
+	require.NoError(t, s.SetTor(vault.Tor{Enabled: true, SOCKSAddress: "127.0.0.1:9150"}))
+
+	// Check the new Tor settings.
+	require.Equal(t, vault.Tor{Enabled: true, SOCKSAddress: "127.0.0.1:9150"}, s.GetTor())
+}
+
+func TestVault_Settings_Mailto(t *testing.T) {
+	// Create a new test vault.
+	s := newVault(t)
+
+	// No mail client is set by default.
+	require.Equal(t, vault.Mailto{}, s.GetMailto())
+
+	mailto := vault.Mailto{
+		Client: "thunderbird",
+		Rules:  []vault.MailtoRule{{Pattern: "*@example.com", Address: "alias@pm.me"}},
+	}
+
+	// Modify the mailto settings.
+	require.NoError(t, s.SetMailto(mailto))
+
+	// Check the new mailto settings.
+	require.Equal(t, mailto, s.GetMailto())
+}
+
+func TestVault_Settings_SMTPRelay(t *testing.T) {
+	// Create a new test vault.
+	s := newVault(t)
+
+	// The relay is disabled by default, with strict limits.
+	require.Equal(t, vault.SMTPRelay{
+		MaxMessagesPerHour: vault.DefaultRelayMaxMessagesPerHour,
+		MaxRecipients:      vault.DefaultRelayMaxRecipients,
+	}, s.GetSMTPRelay())
+
+	relay := vault.SMTPRelay{
+		Port:               2525,
+		AllowedClients:     []string{"192.168.1.0/24"},
+		Address:            "scanner@pm.me",
+		Username:           "scanner",
+		Password:           "secret",
+		MaxMessagesPerHour: 10,
+		MaxRecipients:      2,
+	}
+
+	// Modify the relay settings.
+	require.NoError(t, s.SetSMTPRelay(relay))
+
+	// Check the new relay settings.
+	require.Equal(t, relay, s.GetSMTPRelay())
+}
+
+func TestVault_Settings_PrivacyMode(t *testing.T) {
+	// Create a new test vault.
+	s := newVault(t)
+
+	// Privacy mode is disabled by default.
+	require.Equal(t, vault.PrivacyMode{JitterWindow: vault.DefaultJitterWindow}, s.GetPrivacyMode())
+
+	// Modify the privacy mode settings.
+	require.NoError(t, s.SetPrivacyMode(vault.PrivacyMode{Enabled: true, JitterWindow: time.Minute}))
+
+	// Check the new privacy mode settings.
+	require.Equal(t, vault.PrivacyMode{Enabled: true, JitterWindow: time.Minute}, s.GetPrivacyMode())
+}
+
+func TestVault_Settings_TLSPolicy(t *testing.T) {
+	// Create a new test vault.
+	s := newVault(t)
+
+	// There are no restrictions by default.
+	require.Equal(t, vault.TLSPolicy{}, s.GetTLSPolicy())
+
+	policy := vault.TLSPolicy{
+		MinVersion:   tls.VersionTLS12,
+		CipherSuites: []uint16{tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384},
+		RequireTLS:   true,
+	}
+
+	// Modify the TLS policy.
+	require.NoError(t, s.SetTLSPolicy(policy))
+
+	// Check the new TLS policy.
+	require.Equal(t, policy, s.GetTLSPolicy())
+}
+
+func TestVault_Settings_LANAccess(t *testing.T) {
+	// Create a new test vault.
+	s := newVault(t)
+
+	// The servers aren't exposed by default.
+	require.Equal(t, vault.LANAccess{}, s.GetLANAccess())
+
+	access := vault.LANAccess{
+		Enabled:        true,
+		AllowedClients: []string{"192.168.1.0/24", "fd00::1/128"},
+	}
+
+	// Modify the LAN access.
+	require.NoError(t, s.SetLANAccess(access))
+
+	// Check the new LAN access.
+	require.Equal(t, access, s.GetLANAccess())
+}
+
+func TestVault_Settings_ServerLimits(t *testing.T) {
+	// Create a new test vault.
+	s := newVault(t)
+
+	// There are no limits by default.
+	require.Equal(t, vault.ServerLimits{}, s.GetServerLimits())
+
+	limits := vault.ServerLimits{
+		CommandsPerSecond:     50,
+		MaxConnectionsPerUser: 10,
+		MaxFetchBytes:         64 << 20,
+	}
+
+	// Modify the server limits.
+	require.NoError(t, s.SetServerLimits(limits))
+
+	// Check the new server limits.
+	require.Equal(t, limits, s.GetServerLimits())
+}
+
+func TestVault_Settings_SlowCommandThreshold(t *testing.T) {
+	// Create a new test vault.
+	s := newVault(t)
+
+	// Check the default threshold.
+	require.Equal(t, vault.DefaultSlowCommandThreshold, s.GetSlowCommandThreshold())
+
+	// Modify the threshold.
+	require.NoError(t, s.SetSlowCommandThreshold(5*time.Second))
+
+	// Check the new threshold.
+	require.Equal(t, 5*time.Second, s.GetSlowCommandThreshold())
+}
+
+func TestVault_Settings_RecordAPIFixtures(t *testing.T) {
+	// Create a new test vault.
+	s := newVault(t)
+
+	// The API traffic isn't recorded by default.
+	require.False(t, s.GetRecordAPIFixtures())
+
+	// Start recording.
+	require.NoError(t, s.SetRecordAPIFixtures(true))
+
+	// Check the new setting.
+	require.True(t, s.GetRecordAPIFixtures())
+}
+
+func TestVault_Settings_DisabledClientShims(t *testing.T) {
+	// Create a new test vault.
+	s := newVault(t)
+
+	// All client shims are enabled by default.
+	require.Empty(t, s.GetDisabledClientShims())
+
+	// Disable one.
+	require.NoError(t, s.SetDisabledClientShims([]string{"outlook-sent-seen"}))
+
+	// Check the disabled shims.
+	require.Equal(t, []string{"outlook-sent-seen"}, s.GetDisabledClientShims())
+}
+
 func TestVault_Settings_GluonDir(t *testing.T) {
 	// create a new test vault.
 	s, corrupt, err := vault.New(t.TempDir(), "/path/to/gluon", []byte("my secret key"), async.NoopPanicHandler{})
@@ -94,6 +391,31 @@ func TestVault_Settings_UpdateChannel(t *testing.T) {
 	require.Equal(t, updater.EarlyChannel, s.GetUpdateChannel())
 }
 
+func TestVault_Settings_UpdateVerification(t *testing.T) {
+	// create a new test vault.
+	s := newVault(t)
+
+	// No update has been verified by default.
+	require.Empty(t, s.GetUpdateVerification().Version)
+
+	// Store the verification of an installed update.
+	verification := updater.Verification{
+		Version:       "3.4.0",
+		PackageSHA256: "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+		LogID:         "bridge-releases",
+		LogIndex:      41,
+		LogTreeSize:   42,
+		BuildTime:     time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	require.NoError(t, s.SetUpdateVerification(verification))
+
+	// Check the stored verification.
+	require.Equal(t, verification.Version, s.GetUpdateVerification().Version)
+	require.Equal(t, verification.LogIndex, s.GetUpdateVerification().LogIndex)
+	require.True(t, verification.BuildTime.Equal(s.GetUpdateVerification().BuildTime))
+}
+
 func TestVault_Settings_UpdateRollout(t *testing.T) {
 	// create a new test vault.
 	s := newVault(t)
@@ -267,3 +589,143 @@ func Test_Settings_PasswordArchive(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, user.BridgePass(), bridgePass)
 }
+
+func TestVault_Settings_IndexHook(t *testing.T) {
+	// create a new test vault.
+	s := newVault(t)
+
+	// Check the default index hook.
+	require.Equal(t, "", s.GetIndexHook())
+
+	// Modify the index hook.
+	require.NoError(t, s.SetIndexHook("notmuch new"))
+
+	// Check the new index hook.
+	require.Equal(t, "notmuch new", s.GetIndexHook())
+}
+
+func TestVault_Settings_DoNotDisturb(t *testing.T) {
+	// create a new test vault.
+	s := newVault(t)
+
+	// Check the default do not disturb settings.
+	require.Equal(t, vault.DoNotDisturb{}, s.GetDoNotDisturb())
+
+	// Modify the do not disturb settings.
+	dnd := vault.DoNotDisturb{
+		Enabled:   true,
+		Schedule:  vault.QuietHours{Enabled: true, Start: 22 * time.Hour, End: 7 * time.Hour},
+		DelaySync: true,
+	}
+	require.NoError(t, s.SetDoNotDisturb(dnd))
+
+	// Check the new do not disturb settings.
+	require.Equal(t, dnd, s.GetDoNotDisturb())
+}
+
+func TestVault_Settings_UnifiedInbox(t *testing.T) {
+	// create a new test vault.
+	s := newVault(t)
+
+	// Check the default unified inbox settings.
+	require.Equal(t, vault.UnifiedInbox{}, s.GetUnifiedInbox())
+
+	// Enable the unified inbox; a gluon key and a password are generated.
+	require.NoError(t, s.SetUnifiedInboxEnabled(true))
+	require.True(t, s.GetUnifiedInbox().Enabled)
+	require.Len(t, s.GetUnifiedInbox().GluonKey, 32)
+
+	// Set the gluon ID.
+	require.NoError(t, s.SetUnifiedInboxGluonID("gluonID"))
+	require.Equal(t, "gluonID", s.GetUnifiedInbox().GluonID)
+
+	// Disabling and re-enabling keeps the gluon key and the password.
+	key, pass := s.GetUnifiedInbox().GluonKey, s.GetUnifiedInbox().Password
+	require.Len(t, pass, 16)
+	require.NoError(t, s.SetUnifiedInboxEnabled(false))
+	require.False(t, s.GetUnifiedInbox().Enabled)
+	require.NoError(t, s.SetUnifiedInboxEnabled(true))
+	require.Equal(t, key, s.GetUnifiedInbox().GluonKey)
+	require.Equal(t, pass, s.GetUnifiedInbox().Password)
+
+	// The password can be reset.
+	require.NoError(t, s.ResetUnifiedInboxPassword())
+	require.Len(t, s.GetUnifiedInbox().Password, 16)
+	require.NotEqual(t, pass, s.GetUnifiedInbox().Password)
+}
+
+func TestVault_Settings_AccessGrants(t *testing.T) {
+	// create a new test vault.
+	s := newVault(t)
+
+	// There are no grants by default.
+	require.Empty(t, s.GetAccessGrants())
+
+	createdAt := time.Now().UTC().Truncate(time.Second)
+
+	require.NoError(t, s.AddAccessGrant("id1", "monitoring", vault.AccessRoleViewer, "token1", createdAt))
+	require.NoError(t, s.AddAccessGrant("id2", "helpdesk", vault.AccessRoleOperator, "token2", createdAt))
+	require.ErrorIs(t, s.AddAccessGrant("id3", "other", vault.AccessRoleAdmin, "token1", createdAt), vault.ErrAccessGrantTokenExists)
+
+	// The token itself is not stored.
+	require.Len(t, s.GetAccessGrants(), 2)
+	require.NotEqual(t, "token1", s.GetAccessGrants()[0].TokenHash)
+
+	grant, ok := s.GetAccessGrantByToken("token1")
+	require.True(t, ok)
+	require.Equal(t, "monitoring", grant.Name)
+	require.Equal(t, vault.AccessRoleViewer, grant.Role)
+
+	_, ok = s.GetAccessGrantByToken("unknown")
+	require.False(t, ok)
+
+	// A revoked grant is kept but no longer matches its token.
+	require.NoError(t, s.RevokeAccessGrant("id1", createdAt.Add(time.Hour)))
+	require.ErrorIs(t, s.RevokeAccessGrant("id1", createdAt), vault.ErrAccessGrantRevoked)
+	require.ErrorIs(t, s.RevokeAccessGrant("unknown", createdAt), vault.ErrNoSuchAccessGrant)
+
+	_, ok = s.GetAccessGrantByToken("token1")
+	require.False(t, ok)
+
+	require.Len(t, s.GetAccessGrants(), 2)
+	require.True(t, s.GetAccessGrants()[0].IsRevoked())
+	require.True(t, createdAt.Add(time.Hour).Equal(s.GetAccessGrants()[0].RevokedAt))
+}
+
+func TestVault_Settings_Pairing(t *testing.T) {
+	// create a new test vault.
+	s := newVault(t)
+
+	// The key is generated once.
+	key, err := s.GetPairingKey()
+	require.NoError(t, err)
+	require.Len(t, key, 32)
+
+	again, err := s.GetPairingKey()
+	require.NoError(t, err)
+	require.Equal(t, key, again)
+
+	// There are no paired clients by default.
+	require.Empty(t, s.GetPairedClients())
+
+	pairedAt := time.Now().UTC().Truncate(time.Second)
+
+	require.NoError(t, s.AddPairedClient(vault.PairedClient{ID: "id1", Name: "dashboard", Role: vault.AccessRoleViewer, PairedAt: pairedAt}))
+
+	client, ok := s.GetPairedClient("id1")
+	require.True(t, ok)
+	require.Equal(t, "dashboard", client.Name)
+	require.False(t, client.IsRevoked())
+
+	_, ok = s.GetPairedClient("unknown")
+	require.False(t, ok)
+
+	// A revoked client is kept.
+	require.NoError(t, s.RevokePairedClient("id1", pairedAt.Add(time.Hour)))
+	require.ErrorIs(t, s.RevokePairedClient("id1", pairedAt), vault.ErrPairedClientRevoked)
+	require.ErrorIs(t, s.RevokePairedClient("unknown", pairedAt), vault.ErrNoSuchPairedClient)
+
+	client, ok = s.GetPairedClient("id1")
+	require.True(t, ok)
+	require.True(t, client.IsRevoked())
+}
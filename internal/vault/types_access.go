@@ -0,0 +1,86 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package vault
+
+import "time"
+
+// AccessRole is the role of a client of the gRPC service. Each role may do everything the lower ones may.
+type AccessRole int
+
+const (
+	AccessRoleViewer   AccessRole = iota // May read the status and settings.
+	AccessRoleOperator                   // May also manage accounts and everyday settings.
+	AccessRoleAdmin                      // May also change the setup, e.g. reset or restart bridge.
+)
+
+func (role AccessRole) String() string {
+	switch role {
+	case AccessRoleViewer:
+		return "viewer"
+
+	case AccessRoleOperator:
+		return "operator"
+
+	case AccessRoleAdmin:
+		return "admin"
+
+	default:
+		return "unknown"
+	}
+}
+
+// AccessGrant binds a role to a token presented by clients of the gRPC service.
+// Grants are revoked rather than removed so that they can be audited.
+type AccessGrant struct {
+	ID   string
+	Name string
+	Role AccessRole
+
+	// TokenHash is the SHA-256 sum of the token, as hexadecimal string; the token itself is not stored.
+	TokenHash string
+
+	CreatedAt time.Time
+	RevokedAt time.Time
+}
+
+func (grant AccessGrant) IsRevoked() bool {
+	return !grant.RevokedAt.IsZero()
+}
+
+// Pairing holds the frontends which exchanged a pairing code for a token.
+type Pairing struct {
+	// Key signs the tokens of the paired clients; it is generated the first time a client is paired.
+	Key []byte
+
+	// Clients are never removed but revoked, like access grants.
+	Clients []PairedClient
+}
+
+// PairedClient is a frontend which was granted a role by exchanging a pairing code.
+type PairedClient struct {
+	ID   string
+	Name string
+	Role AccessRole
+
+	PairedAt  time.Time
+	RevokedAt time.Time
+}
+
+func (client PairedClient) IsRevoked() bool {
+	return !client.RevokedAt.IsZero()
+}
@@ -21,6 +21,7 @@ import (
 	"crypto/cipher"
 
 	"github.com/ProtonMail/gopenpgp/v2/crypto"
+	"github.com/ProtonMail/proton-bridge/v3/internal/secret"
 	"github.com/vmihailenco/msgpack/v5"
 )
 
@@ -48,6 +49,9 @@ func unmarshalFile[T any](gcm cipher.AEAD, b []byte, data *T) error {
 		}
 	}
 
+	// The decoded data holds its own copy of the secrets, so the plaintext can be wiped.
+	defer secret.Wipe(dec)
+
 	return msgpack.Unmarshal(dec, data)
 }
 
@@ -57,6 +61,8 @@ func marshalFile[T any](gcm cipher.AEAD, t T) ([]byte, error) {
 		return nil, err
 	}
 
+	defer secret.Wipe(dec)
+
 	nonce, err := crypto.RandomToken(gcm.NonceSize())
 	if err != nil {
 		return nil, err
@@ -35,9 +35,28 @@ type Settings struct {
 	IMAPSSL  bool
 	SMTPSSL  bool
 
+	// LMTPEnabled is whether the LMTP server local delivery agents inject messages through listens on its socket.
+	LMTPEnabled bool
+
+	// NNTPPort is the port of the experimental NNTP server exposing folders as newsgroups; zero disables it.
+	NNTPPort int
+
+	// POP3 configures the read-only POP3 gateway to the inbox.
+	POP3 POP3
+
+	// IPFamily is the IP version the IMAP and SMTP servers listen on.
+	IPFamily IPFamily
+
+	// IMAPBindAddress and SMTPBindAddress are the IP addresses the servers listen on; empty uses the default hosts.
+	IMAPBindAddress string
+	SMTPBindAddress string
+
 	UpdateChannel updater.Channel
 	UpdateRollout float64
 
+	// UpdateVerification is the chain of checks passed by the last update installed by the updater.
+	UpdateVerification updater.Verification
+
 	ColorScheme       string
 	ProxyAllowed      bool
 	ShowAllMail       bool
@@ -56,13 +75,81 @@ type Settings struct {
 
 	PasswordArchive PasswordArchive
 
+	IndexHook string
+
+	DoNotDisturb DoNotDisturb
+
+	UnifiedInbox UnifiedInbox
+
+	Resolver Resolver
+
+	Tor Tor
+
+	PrivacyMode PrivacyMode
+
+	// AccessGrants bind roles to the tokens of the clients of the gRPC service.
+	AccessGrants []AccessGrant
+
+	// Pairing holds the frontends paired with bridge and the key signing their tokens.
+	Pairing Pairing
+
+	// TLSPolicy restricts the TLS connections accepted by the IMAP and SMTP servers.
+	TLSPolicy TLSPolicy
+
+	// LANAccess exposes the servers to the allowed devices of the local network.
+	LANAccess LANAccess
+
+	// ServerLimits throttle the IMAP clients.
+	ServerLimits ServerLimits
+
+	// SlowCommandThreshold is the duration above which IMAP commands are kept for diagnostics; zero disables it.
+	SlowCommandThreshold time.Duration
+
+	// DisabledClientShims are the names of the workarounds for IMAP client quirks which are turned off.
+	DisabledClientShims []string
+
+	// RecordAPIFixtures records the API traffic, secrets stripped, for bug reports; it's only exposed for debugging.
+	RecordAPIFixtures bool
+
+	// SyncCacheMemory is the memory used by each user's sync download cache; zero uses DefaultSyncCacheMemory.
+	SyncCacheMemory uint64
+
+	// SyncCacheSpill writes the downloads evicted from the sync download cache to disk, encrypted, rather than dropping them.
+	SyncCacheSpill bool
+
+	// MetricsListener is the host:port the Prometheus metrics are served on; empty doesn't serve them.
+	MetricsListener string
+
+	// Mailto configures the composition of the mailto: URLs bridge is passed as their handler.
+	Mailto Mailto
+
+	// SMTPRelay configures the relay LAN devices, such as scanners, send mail through.
+	SMTPRelay SMTPRelay
+
 	// **WARNING**: These entry can't be removed until they vault has proper migration support.
 	SyncWorkers int
 	SyncAttPool int
 }
 
+// DefaultIMAPPort and DefaultSMTPPort are the ports the servers listen on unless they are taken.
+const (
+	DefaultIMAPPort = 1143
+	DefaultSMTPPort = 1025
+)
+
 const DefaultMaxSyncMemory = 2 * 1024 * uint64(1024*1024)
 
+const DefaultSyncCacheMemory = 256 * uint64(1024*1024)
+
+const DefaultJitterWindow = 30 * time.Second
+
+const DefaultSlowCommandThreshold = 2 * time.Second
+
+const (
+	DefaultRelayMaxMessagesPerHour = 20
+	DefaultRelayMaxRecipients      = 5
+)
+
 func GetDefaultSyncWorkerCount() int {
 	const minSyncWorkers = 16
 
@@ -77,8 +164,8 @@ func GetDefaultSyncWorkerCount() int {
 
 func newDefaultSettings(gluonDir string) Settings {
 	syncWorkers := GetDefaultSyncWorkerCount()
-	imapPort := ports.FindFreePortFrom(1143)
-	smtpPort := ports.FindFreePortFrom(1025, imapPort)
+	imapPort := ports.FindFreePortFrom(DefaultIMAPPort)
+	smtpPort := ports.FindFreePortFrom(DefaultSMTPPort, imapPort)
 
 	return Settings{
 		GluonDir: gluonDir,
@@ -88,6 +175,8 @@ func newDefaultSettings(gluonDir string) Settings {
 		IMAPSSL:  false,
 		SMTPSSL:  false,
 
+		IPFamily: IPv4,
+
 		UpdateChannel: updater.DefaultUpdateChannel,
 		UpdateRollout: rand.Float64(), //nolint:gosec
 
@@ -109,5 +198,219 @@ func newDefaultSettings(gluonDir string) Settings {
 		LastHeartbeatSent: time.Time{},
 
 		PasswordArchive: PasswordArchive{},
+
+		IndexHook: "",
+
+		DoNotDisturb: DoNotDisturb{},
+
+		UnifiedInbox: UnifiedInbox{},
+
+		Resolver: Resolver{},
+
+		Tor: Tor{},
+
+		PrivacyMode: PrivacyMode{
+			JitterWindow: DefaultJitterWindow,
+		},
+
+		SlowCommandThreshold: DefaultSlowCommandThreshold,
+
+		SMTPRelay: SMTPRelay{
+			MaxMessagesPerHour: DefaultRelayMaxMessagesPerHour,
+			MaxRecipients:      DefaultRelayMaxRecipients,
+		},
 	}
 }
+
+// IPFamily selects the loopback addresses the IMAP and SMTP servers listen on.
+type IPFamily int
+
+const (
+	IPv4      IPFamily = iota // Listen on 127.0.0.1 only.
+	DualStack                 // Listen on both 127.0.0.1 and ::1.
+	IPv6                      // Listen on ::1 only.
+)
+
+func (family IPFamily) String() string {
+	switch family {
+	case IPv4:
+		return "ipv4"
+
+	case DualStack:
+		return "dual-stack"
+
+	case IPv6:
+		return "ipv6"
+
+	default:
+		return "unknown"
+	}
+}
+
+// DoNotDisturb configures when new message notifications are suppressed.
+type DoNotDisturb struct {
+	// Enabled is set when do not disturb was turned on manually.
+	Enabled bool
+
+	// Schedule is the daily period during which do not disturb is active.
+	Schedule QuietHours
+
+	// DelaySync delays the download of messages of new syncs while do not disturb is active.
+	DelaySync bool
+}
+
+// UnifiedInbox holds the state of the virtual mailbox aggregating the inboxes of all users.
+type UnifiedInbox struct {
+	Enabled bool
+
+	// GluonID and GluonKey identify and decrypt the gluon database backing the unified inbox.
+	GluonID  string
+	GluonKey []byte
+
+	// Password is the raw token used to log in to the unified inbox, separate from the bridge passwords of the users.
+	Password []byte
+}
+
+// Resolver configures how the DoH resolver used for alternative routing looks up hosts.
+type Resolver struct {
+	// DoHProviders are custom DoH endpoints, queried before the built-in ones.
+	DoHProviders []string
+
+	// BootstrapIPs are used to reach the DoH providers without the system resolver.
+	BootstrapIPs []string
+
+	// DisableSystemDNS resolves the API host over DoH instead of the system resolver.
+	DisableSystemDNS bool
+}
+
+// Tor configures routing of the API traffic through a local Tor SOCKS proxy.
+type Tor struct {
+	Enabled bool
+
+	// SOCKSAddress is the address of the Tor SOCKS proxy; the default one is used if empty.
+	SOCKSAddress string
+
+	// OnionHost is the onion service to use instead of the API host, if any.
+	OnionHost string
+}
+
+// TLSPolicy restricts the TLS connections accepted by the IMAP and SMTP servers.
+type TLSPolicy struct {
+	// MinVersion is the lowest accepted TLS version, such as tls.VersionTLS12; TLS 1.2 is the lowest if zero.
+	MinVersion uint16
+
+	// CipherSuites are the accepted TLS 1.0-1.2 cipher suites; Go's default ones are accepted if empty.
+	// The TLS 1.3 cipher suites can't be restricted.
+	CipherSuites []uint16
+
+	// RequireTLS refuses to authenticate SMTP and POP3 clients before they upgrade the connection with STARTTLS.
+	// The NNTP server, which has no STARTTLS, speaks TLS from the first byte. IMAP logins are not affected yet.
+	RequireTLS bool
+}
+
+// LANAccess exposes the IMAP, SMTP and gRPC servers beyond localhost.
+type LANAccess struct {
+	// Enabled makes the servers listen on all interfaces rather than on the loopback ones only.
+	Enabled bool
+
+	// AllowedClients are the CIDRs of the remote clients accepted by the servers; loopback clients always are.
+	AllowedClients []string
+}
+
+// POP3 configures the POP3 server serving the inbox to devices which can't speak IMAP.
+type POP3 struct {
+	// Port is the port the POP3 server listens on; zero disables it.
+	Port int
+
+	// SSL makes the server speak TLS from the first byte rather than offering STLS.
+	SSL bool
+
+	// DeleteAction is what happens to the messages clients delete.
+	DeleteAction POP3DeleteAction
+}
+
+// POP3DeleteAction is what happens to the messages POP3 clients delete.
+type POP3DeleteAction int
+
+const (
+	POP3Keep    POP3DeleteAction = iota // Leave the messages in the inbox.
+	POP3Archive                         // Move the messages to the archive.
+	POP3Trash                           // Move the messages to the trash.
+)
+
+func (action POP3DeleteAction) String() string {
+	switch action {
+	case POP3Keep:
+		return "keep"
+
+	case POP3Archive:
+		return "archive"
+
+	case POP3Trash:
+		return "trash"
+
+	default:
+		return "unknown"
+	}
+}
+
+// ServerLimits protect bridge and the API from IMAP clients which loop or resync aggressively.
+// A zero value means no limit.
+type ServerLimits struct {
+	CommandsPerSecond     int
+	MaxConnectionsPerUser int
+	MaxFetchBytes         int
+}
+
+// PrivacyMode configures the batching and delaying of API requests hiding when the user acts.
+type PrivacyMode struct {
+	Enabled bool
+
+	// JitterWindow is the longest a request is held back.
+	JitterWindow time.Duration
+}
+
+// Mailto configures how the mailto: URLs passed to bridge are composed.
+type Mailto struct {
+	// Client is the mail client whose compose window is opened, such as "thunderbird"; none is opened if empty.
+	Client string
+
+	// ClientPath is the executable of the mail client; the client's usual command is run if empty.
+	ClientPath string
+
+	// Rules pick the address sending to the recipients of the URLs, the first matching one winning;
+	// the primary address of the first account is used if none matches.
+	Rules []MailtoRule
+}
+
+// SMTPRelay configures the SMTP relay through which LAN devices, such as scanners, send mail from an address of one
+// of the users without being given the account's credentials.
+type SMTPRelay struct {
+	// Port is the port of the relay, which listens on all interfaces; zero disables it.
+	Port int
+
+	// AllowedClients are the CIDRs or IP addresses of the devices allowed to relay; loopback ones always are.
+	AllowedClients []string
+
+	// Address is the address the relayed messages are sent from, whatever sender the devices give.
+	Address string
+
+	// Username and Password are the credentials the devices must authenticate with; they aren't authenticated if empty.
+	Username string
+	Password string
+
+	// MaxMessagesPerHour is how many messages are relayed per hour at most, all devices together.
+	MaxMessagesPerHour int
+
+	// MaxRecipients is how many recipients a relayed message can have at most.
+	MaxRecipients int
+}
+
+// MailtoRule sends the messages of the mailto: URLs with a matching recipient from an address.
+type MailtoRule struct {
+	// Pattern matches the recipient addresses, e.g. someone@example.com or *@example.com.
+	Pattern string
+
+	// Address is the address sending to them.
+	Address string
+}
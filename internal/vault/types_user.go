@@ -17,7 +17,11 @@
 
 package vault
 
-import "github.com/ProtonMail/gluon/imap"
+import (
+	"time"
+
+	"github.com/ProtonMail/gluon/imap"
+)
 
 // UserData holds information about a single bridge user.
 // The user may or may not be logged in.
@@ -38,6 +42,81 @@ type UserData struct {
 	SyncStatus SyncStatus
 	EventID    string
 
+	SpamFilter        SpamFilter
+	NotificationRules NotificationRules
+
+	// Templates maps template names to template bodies used for canned replies.
+	Templates map[string]string
+
+	// SavedSearches define read-only mailboxes listing the messages matching a query.
+	SavedSearches []SavedSearch
+
+	// RecipientRoutes file new messages into folders by the address they were sent to.
+	RecipientRoutes []RecipientRoute
+
+	// SubaddressRules label new messages by the +tag of the address they were sent to.
+	SubaddressRules []SubaddressRule
+
+	// LabelKeywordMode controls whether labels are exposed as IMAP keywords.
+	LabelKeywordMode LabelKeywordMode
+
+	// DeleteMode controls what happens to messages expunged from a folder.
+	DeleteMode DeleteMode
+
+	// AutoPurge configures the permanent deletion of old messages in Trash and Spam.
+	AutoPurge AutoPurge
+
+	// AutoPurgeState tracks the messages of Trash and Spam for their auto-purge.
+	AutoPurgeState AutoPurgeState
+
+	// MigrationMode tunes message imports for bulk APPEND workloads, such as imapsync migrations.
+	MigrationMode bool
+
+	// ConversationMode normalizes the threading headers of messages so clients thread them like conversations.
+	ConversationMode bool
+
+	// TranscodeToUTF8 converts text parts and header fields in legacy charsets to UTF-8 when building messages.
+	TranscodeToUTF8 bool
+
+	// HeaderTemplate is a text/template of extra header fields added to messages when building them.
+	HeaderTemplate string
+
+	// ContactDisplayNames replaces From display names with the names of the senders in the user's contacts.
+	ContactDisplayNames bool
+
+	// DateTimeZone is the IANA time zone Date headers are converted to when building messages; empty keeps them as sent.
+	DateTimeZone string
+
+	// RepairDates replaces missing, invalid or far future Date headers with the time the server received the message.
+	RepairDates bool
+
+	// DraftCoalescingInterval is how often a draft saved repeatedly is uploaded at most; zero uploads every save.
+	DraftCoalescingInterval time.Duration
+
+	// NNTPFolders are the paths of the folders exposed as newsgroups by the NNTP server.
+	NNTPFolders []string
+
+	// SyncFilter excludes folders and labels from the local sync.
+	SyncFilter SyncFilter
+
+	// RedactionRules keep the bodies of matching messages out of the local cache.
+	RedactionRules RedactionRules
+
+	// Digest holds the statistics of the weekly digest report, gathered since the last report.
+	Digest Digest
+
+	// ReauthRequired is set when the user's session expired; their local data is kept until they sign in again.
+	ReauthRequired bool
+
+	// AuthRefreshedAt is when the user's session was last refreshed.
+	AuthRefreshedAt time.Time
+
+	// Display holds how frontends render the account.
+	Display DisplayMetadata
+
+	// MailClient is the mail client frontends open for the account.
+	MailClient MailClient
+
 	// **WARNING**: This value can't be removed until we have vault migration support.
 	UIDValidity map[string]imap.UID
 }
@@ -62,6 +141,211 @@ func (mode AddressMode) String() string {
 	}
 }
 
+// LabelKeywordMode controls whether Proton labels are exposed as IMAP keywords on messages.
+type LabelKeywordMode int
+
+const (
+	LabelKeywordsOff  LabelKeywordMode = iota // Labels are only exposed as mailboxes.
+	LabelKeywordsBoth                         // Labels are exposed as both mailboxes and keywords.
+	LabelKeywordsOnly                         // Labels are only exposed as keywords.
+)
+
+func (mode LabelKeywordMode) String() string {
+	switch mode {
+	case LabelKeywordsOff:
+		return "off"
+
+	case LabelKeywordsBoth:
+		return "both"
+
+	case LabelKeywordsOnly:
+		return "only"
+
+	default:
+		return "unknown"
+	}
+}
+
+// DeleteMode controls what happens to messages marked \Deleted and expunged from a folder.
+type DeleteMode int
+
+const (
+	DeleteModeRemove    DeleteMode = iota // Messages are removed from the folder.
+	DeleteModeTrash                       // Messages are moved to Trash.
+	DeleteModePermanent                   // Messages are permanently deleted.
+	DeleteModeArchive                     // Messages are moved to Archive.
+)
+
+func (mode DeleteMode) String() string {
+	switch mode {
+	case DeleteModeRemove:
+		return "remove"
+
+	case DeleteModeTrash:
+		return "trash"
+
+	case DeleteModePermanent:
+		return "permanent"
+
+	case DeleteModeArchive:
+		return "archive"
+
+	default:
+		return "unknown"
+	}
+}
+
+// AutoPurge configures the local permanent deletion of old messages in Trash and Spam.
+// A number of days of zero disables purging of the folder.
+type AutoPurge struct {
+	TrashDays int
+	SpamDays  int
+}
+
+// AutoPurgeState tracks the messages of Trash and Spam between auto-purge runs.
+type AutoPurgeState struct {
+	// Seen maps the IDs of the messages of each folder, by label ID, to when they were first seen there.
+	Seen map[string]map[string]time.Time
+
+	// PurgeAt maps the IDs of the messages announced as about to be purged to when they may be deleted.
+	PurgeAt map[string]time.Time
+}
+
+// SyncFilter excludes folders and labels, such as Spam or large archive labels, from the local sync.
+type SyncFilter struct {
+	// ExcludedLabelIDs are the IDs of the excluded folders and labels.
+	ExcludedLabelIDs []string
+}
+
+// RedactionRules select the sensitive messages whose bodies are never cached locally, but downloaded from the API
+// whenever a client reads them. They're also left out of the exports.
+type RedactionRules struct {
+	// SenderDomains are the domains, including their subdomains, of the senders whose messages are redacted.
+	SenderDomains []string
+
+	// LabelIDs are the IDs of the folders and labels, e.g. a "Sensitive" label, whose messages are redacted.
+	LabelIDs []string
+}
+
+// DisplayMetadata holds how frontends render an account, so that they all render it the same way.
+// Empty fields fall back to the frontend's defaults.
+type DisplayMetadata struct {
+	// Nickname is shown instead of the username.
+	Nickname string
+
+	// Color is the account's color, as #RRGGBB.
+	Color string
+
+	// Initials are shown in the account's avatar instead of the ones derived from the username.
+	Initials string
+
+	// SortOrder orders the accounts in lists, lowest first; accounts with the same sort order keep the order they were added in.
+	SortOrder int
+}
+
+// MailClient binds an account to the mail client it is set up in, so that frontends open the right program and profile.
+type MailClient struct {
+	// Client is the mail client, such as "thunderbird"; the one composing mailto URLs is used if empty.
+	Client string
+
+	// Path is the executable of the mail client; the client's usual command is run if empty.
+	Path string
+
+	// Profile is the client's profile holding the account, for clients which have several; the default one if empty.
+	Profile string
+}
+
+// Digest holds the statistics of the user's weekly digest report, gathered from the messages bridge received.
+type Digest struct {
+	Enabled bool
+
+	// Since is when the statistics started being gathered, i.e. when the last report was made.
+	Since time.Time
+
+	// UsedSpace is the storage space the user used at that time.
+	UsedSpace int
+
+	// Received counts the new messages by mailbox ID.
+	Received map[string]int
+
+	// Senders counts the received messages by sender address.
+	Senders map[string]int
+}
+
+// SpamFilter configures the local spam filter that incoming messages are passed through.
+type SpamFilter struct {
+	Backend    SpamFilterBackend
+	Address    string
+	MoveToSpam bool
+}
+
+// SavedSearch is a named query exposed as a read-only mailbox.
+// The ID changes whenever the query does, so that the mailbox is rebuilt.
+type SavedSearch struct {
+	ID    string
+	Name  string
+	Query string
+}
+
+// RecipientRoute files the new messages sent to the recipients matching Pattern into the folder at path Folder,
+// e.g. the messages sent to shop@example.com through a catch-all address into Shopping.
+// Pattern is an address or a glob of addresses as understood by path.Match, such as *@shop.example.com.
+type RecipientRoute struct {
+	Pattern string
+	Folder  string
+}
+
+// SubaddressRule labels the new messages sent to a subaddress with the given Tag, e.g. user+news@pm.me, with Label.
+// The label is created when the first such message arrives if it doesn't exist yet.
+type SubaddressRule struct {
+	Tag   string
+	Label string
+}
+
+// NotificationRules configure which newly received messages trigger desktop notifications and webhook calls.
+type NotificationRules struct {
+	Enabled bool
+
+	// Mailboxes trigger notifications; all mailboxes do if empty. Muted mailboxes never do.
+	Mailboxes []string
+	Muted     []string
+
+	QuietHours QuietHours
+
+	// Webhook, if set, is the URL new message notifications are posted to.
+	Webhook string
+}
+
+// QuietHours is a daily period, in local time, during which no notifications are triggered.
+type QuietHours struct {
+	Enabled    bool
+	Start, End time.Duration
+}
+
+type SpamFilterBackend int
+
+const (
+	SpamFilterNone SpamFilterBackend = iota
+	SpamFilterSpamd
+	SpamFilterRspamd
+)
+
+func (backend SpamFilterBackend) String() string {
+	switch backend {
+	case SpamFilterNone:
+		return "none"
+
+	case SpamFilterSpamd:
+		return "spamd"
+
+	case SpamFilterRspamd:
+		return "rspamd"
+
+	default:
+		return "unknown"
+	}
+}
+
 type SyncStatus struct {
 	HasLabels        bool
 	HasMessages      bool
@@ -88,5 +372,7 @@ func newDefaultUser(userID, username, primaryEmail, authUID, authRef string, key
 		AuthUID: authUID,
 		AuthRef: authRef,
 		KeyPass: keyPass,
+
+		Templates: make(map[string]string),
 	}
 }
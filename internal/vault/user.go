@@ -19,8 +19,10 @@ package vault
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/bradenaw/juniper/xslices"
+	"golang.org/x/exp/maps"
 	"golang.org/x/exp/slices"
 )
 
@@ -92,7 +94,7 @@ func (user *User) SetAddressMode(mode AddressMode) error {
 	})
 }
 
-// BridgePass returns the user's bridge password as raw token bytes (unencoded).
+// BridgePass returns a copy of the user's bridge password as raw token bytes (unencoded), which may be wiped after use.
 func (user *User) BridgePass() []byte {
 	return user.vault.getUser(user.userID).BridgePass
 }
@@ -122,15 +124,29 @@ func (user *User) SetAuth(authUID, authRef string) error {
 	})
 }
 
+// AuthRefreshedAt returns when the user's session was last refreshed.
+func (user *User) AuthRefreshedAt() time.Time {
+	return user.vault.getUser(user.userID).AuthRefreshedAt
+}
+
+// SetAuthRefreshedAt sets when the user's session was last refreshed.
+func (user *User) SetAuthRefreshedAt(refreshedAt time.Time) error {
+	return user.vault.modUser(user.userID, func(data *UserData) {
+		data.AuthRefreshedAt = refreshedAt
+	})
+}
+
 func (user *User) setAuthAndKeyPassUnsafe(authUID, authRef string, keyPass []byte) error {
 	return user.vault.modUserUnsafe(user.userID, func(userData *UserData) {
 		userData.AuthRef = authRef
 		userData.AuthUID = authUID
+		userData.ReauthRequired = false
 		userData.KeyPass = keyPass
+		userData.AuthRefreshedAt = time.Time{}
 	})
 }
 
-// KeyPass returns the user's (salted) key password.
+// KeyPass returns a copy of the user's (salted) key password, which may be wiped after use.
 func (user *User) KeyPass() []byte {
 	return user.vault.getUser(user.userID).KeyPass
 }
@@ -212,12 +228,390 @@ func (user *User) SetEventID(eventID string) error {
 	})
 }
 
+// SpamFilter returns the user's local spam filter configuration.
+func (user *User) SpamFilter() SpamFilter {
+	return user.vault.getUser(user.userID).SpamFilter
+}
+
+// SetSpamFilter sets the user's local spam filter configuration.
+func (user *User) SetSpamFilter(filter SpamFilter) error {
+	return user.vault.modUser(user.userID, func(data *UserData) {
+		data.SpamFilter = filter
+	})
+}
+
+// NotificationRules returns the user's new message notification rules.
+func (user *User) NotificationRules() NotificationRules {
+	return user.vault.getUser(user.userID).NotificationRules
+}
+
+// SetNotificationRules sets the user's new message notification rules.
+func (user *User) SetNotificationRules(rules NotificationRules) error {
+	return user.vault.modUser(user.userID, func(data *UserData) {
+		data.NotificationRules = rules
+	})
+}
+
+// GetTemplates returns the user's message templates, keyed by name.
+func (user *User) GetTemplates() map[string]string {
+	return maps.Clone(user.vault.getUser(user.userID).Templates)
+}
+
+// GetTemplate returns the body of the user's message template with the given name.
+func (user *User) GetTemplate(name string) (string, bool) {
+	body, ok := user.vault.getUser(user.userID).Templates[name]
+
+	return body, ok
+}
+
+// SetTemplate creates or replaces the user's message template with the given name.
+func (user *User) SetTemplate(name, body string) error {
+	return user.vault.modUser(user.userID, func(data *UserData) {
+		if data.Templates == nil {
+			data.Templates = make(map[string]string)
+		}
+
+		data.Templates[name] = body
+	})
+}
+
+// DeleteTemplate removes the user's message template with the given name.
+func (user *User) DeleteTemplate(name string) error {
+	return user.vault.modUser(user.userID, func(data *UserData) {
+		delete(data.Templates, name)
+	})
+}
+
+// GetSavedSearches returns the user's saved searches.
+func (user *User) GetSavedSearches() []SavedSearch {
+	return slices.Clone(user.vault.getUser(user.userID).SavedSearches)
+}
+
+// SetSavedSearches sets the user's saved searches.
+func (user *User) SetSavedSearches(searches []SavedSearch) error {
+	return user.vault.modUser(user.userID, func(data *UserData) {
+		data.SavedSearches = slices.Clone(searches)
+	})
+}
+
+// GetRecipientRoutes returns the rules filing the user's new messages into folders by recipient.
+func (user *User) GetRecipientRoutes() []RecipientRoute {
+	return slices.Clone(user.vault.getUser(user.userID).RecipientRoutes)
+}
+
+// SetRecipientRoutes sets the rules filing the user's new messages into folders by recipient.
+func (user *User) SetRecipientRoutes(routes []RecipientRoute) error {
+	return user.vault.modUser(user.userID, func(data *UserData) {
+		data.RecipientRoutes = slices.Clone(routes)
+	})
+}
+
+// GetSubaddressRules returns the rules labelling the user's new messages by subaddress tag.
+func (user *User) GetSubaddressRules() []SubaddressRule {
+	return slices.Clone(user.vault.getUser(user.userID).SubaddressRules)
+}
+
+// SetSubaddressRules sets the rules labelling the user's new messages by subaddress tag.
+func (user *User) SetSubaddressRules(rules []SubaddressRule) error {
+	return user.vault.modUser(user.userID, func(data *UserData) {
+		data.SubaddressRules = slices.Clone(rules)
+	})
+}
+
+// LabelKeywordMode returns whether the user's labels are exposed as IMAP keywords.
+func (user *User) LabelKeywordMode() LabelKeywordMode {
+	return user.vault.getUser(user.userID).LabelKeywordMode
+}
+
+// SetLabelKeywordMode sets whether the user's labels are exposed as IMAP keywords.
+func (user *User) SetLabelKeywordMode(mode LabelKeywordMode) error {
+	return user.vault.modUser(user.userID, func(data *UserData) {
+		data.LabelKeywordMode = mode
+	})
+}
+
+// DeleteMode returns what happens to messages expunged from a folder.
+func (user *User) DeleteMode() DeleteMode {
+	return user.vault.getUser(user.userID).DeleteMode
+}
+
+// SetDeleteMode sets what happens to messages expunged from a folder.
+func (user *User) SetDeleteMode(mode DeleteMode) error {
+	return user.vault.modUser(user.userID, func(data *UserData) {
+		data.DeleteMode = mode
+	})
+}
+
+// AutoPurge returns the user's Trash and Spam auto-purge settings.
+func (user *User) AutoPurge() AutoPurge {
+	return user.vault.getUser(user.userID).AutoPurge
+}
+
+// SetAutoPurge sets the user's Trash and Spam auto-purge settings.
+func (user *User) SetAutoPurge(purge AutoPurge) error {
+	return user.vault.modUser(user.userID, func(data *UserData) {
+		data.AutoPurge = purge
+	})
+}
+
+// AutoPurgeState returns the state of the auto-purge of the user's Trash and Spam.
+func (user *User) AutoPurgeState() AutoPurgeState {
+	state := user.vault.getUser(user.userID).AutoPurgeState
+
+	seen := make(map[string]map[string]time.Time, len(state.Seen))
+
+	for labelID, messages := range state.Seen {
+		seen[labelID] = maps.Clone(messages)
+	}
+
+	return AutoPurgeState{Seen: seen, PurgeAt: maps.Clone(state.PurgeAt)}
+}
+
+// SetAutoPurgeState sets the state of the auto-purge of the user's Trash and Spam.
+func (user *User) SetAutoPurgeState(state AutoPurgeState) error {
+	return user.vault.modUser(user.userID, func(data *UserData) {
+		data.AutoPurgeState = state
+	})
+}
+
+// Digest returns the statistics of the user's weekly digest report.
+func (user *User) Digest() Digest {
+	digest := user.vault.getUser(user.userID).Digest
+
+	digest.Received = maps.Clone(digest.Received)
+	digest.Senders = maps.Clone(digest.Senders)
+
+	return digest
+}
+
+// SetDigestEnabled sets whether the user's weekly digest report is made.
+func (user *User) SetDigestEnabled(enabled bool) error {
+	return user.vault.modUser(user.userID, func(data *UserData) {
+		data.Digest.Enabled = enabled
+	})
+}
+
+// ResetDigest clears the statistics of the user's weekly digest report, starting a new period at the given time.
+func (user *User) ResetDigest(since time.Time, usedSpace int) error {
+	return user.vault.modUser(user.userID, func(data *UserData) {
+		data.Digest = Digest{
+			Enabled:   data.Digest.Enabled,
+			Since:     since,
+			UsedSpace: usedSpace,
+		}
+	})
+}
+
+// AddDigestMessage counts a new message in the given mailboxes in the statistics of the user's weekly digest report.
+// The sender is only counted if it's set, i.e. if the message was received rather than sent.
+func (user *User) AddDigestMessage(mailboxIDs []string, sender string) error {
+	return user.vault.modUser(user.userID, func(data *UserData) {
+		if data.Digest.Received == nil {
+			data.Digest.Received = make(map[string]int)
+		}
+
+		for _, mailboxID := range mailboxIDs {
+			data.Digest.Received[mailboxID]++
+		}
+
+		if sender == "" {
+			return
+		}
+
+		if data.Digest.Senders == nil {
+			data.Digest.Senders = make(map[string]int)
+		}
+
+		data.Digest.Senders[sender]++
+	})
+}
+
+// MigrationMode returns whether message imports are tuned for bulk APPEND workloads.
+func (user *User) MigrationMode() bool {
+	return user.vault.getUser(user.userID).MigrationMode
+}
+
+// SetMigrationMode sets whether message imports are tuned for bulk APPEND workloads.
+func (user *User) SetMigrationMode(enabled bool) error {
+	return user.vault.modUser(user.userID, func(data *UserData) {
+		data.MigrationMode = enabled
+	})
+}
+
+// ConversationMode returns whether messages are built with normalized threading headers.
+func (user *User) ConversationMode() bool {
+	return user.vault.getUser(user.userID).ConversationMode
+}
+
+// SetConversationMode sets whether messages are built with normalized threading headers.
+func (user *User) SetConversationMode(enabled bool) error {
+	return user.vault.modUser(user.userID, func(data *UserData) {
+		data.ConversationMode = enabled
+	})
+}
+
+// TranscodeToUTF8 returns whether text in legacy charsets is transcoded to UTF-8 when building messages.
+func (user *User) TranscodeToUTF8() bool {
+	return user.vault.getUser(user.userID).TranscodeToUTF8
+}
+
+// SetTranscodeToUTF8 sets whether text in legacy charsets is transcoded to UTF-8 when building messages.
+func (user *User) SetTranscodeToUTF8(enabled bool) error {
+	return user.vault.modUser(user.userID, func(data *UserData) {
+		data.TranscodeToUTF8 = enabled
+	})
+}
+
+// HeaderTemplate returns the template of extra header fields added when building messages.
+func (user *User) HeaderTemplate() string {
+	return user.vault.getUser(user.userID).HeaderTemplate
+}
+
+// SetHeaderTemplate sets the template of extra header fields added when building messages.
+func (user *User) SetHeaderTemplate(text string) error {
+	return user.vault.modUser(user.userID, func(data *UserData) {
+		data.HeaderTemplate = text
+	})
+}
+
+// ContactDisplayNames returns whether From display names are replaced with contact names when building messages.
+func (user *User) ContactDisplayNames() bool {
+	return user.vault.getUser(user.userID).ContactDisplayNames
+}
+
+// SetContactDisplayNames sets whether From display names are replaced with contact names when building messages.
+func (user *User) SetContactDisplayNames(enabled bool) error {
+	return user.vault.modUser(user.userID, func(data *UserData) {
+		data.ContactDisplayNames = enabled
+	})
+}
+
+// DateTimeZone returns the time zone Date headers are converted to when building messages.
+func (user *User) DateTimeZone() string {
+	return user.vault.getUser(user.userID).DateTimeZone
+}
+
+// SetDateTimeZone sets the time zone Date headers are converted to when building messages; empty keeps them as sent.
+func (user *User) SetDateTimeZone(name string) error {
+	return user.vault.modUser(user.userID, func(data *UserData) {
+		data.DateTimeZone = name
+	})
+}
+
+// RepairDates returns whether invalid Date headers are replaced with the receive time when building messages.
+func (user *User) RepairDates() bool {
+	return user.vault.getUser(user.userID).RepairDates
+}
+
+// SetRepairDates sets whether invalid Date headers are replaced with the receive time when building messages.
+func (user *User) SetRepairDates(enabled bool) error {
+	return user.vault.modUser(user.userID, func(data *UserData) {
+		data.RepairDates = enabled
+	})
+}
+
+// DraftCoalescingInterval returns how often a draft saved repeatedly is uploaded at most.
+func (user *User) DraftCoalescingInterval() time.Duration {
+	return user.vault.getUser(user.userID).DraftCoalescingInterval
+}
+
+// SetDraftCoalescingInterval sets how often a draft saved repeatedly is uploaded at most; zero uploads every save.
+func (user *User) SetDraftCoalescingInterval(interval time.Duration) error {
+	return user.vault.modUser(user.userID, func(data *UserData) {
+		data.DraftCoalescingInterval = interval
+	})
+}
+
+// NNTPFolders returns the paths of the user's folders exposed as newsgroups.
+func (user *User) NNTPFolders() []string {
+	return slices.Clone(user.vault.getUser(user.userID).NNTPFolders)
+}
+
+// SetNNTPFolders sets the paths of the user's folders exposed as newsgroups.
+func (user *User) SetNNTPFolders(folders []string) error {
+	return user.vault.modUser(user.userID, func(data *UserData) {
+		data.NNTPFolders = slices.Clone(folders)
+	})
+}
+
+// SyncFilter returns the folders and labels excluded from the user's local sync.
+func (user *User) SyncFilter() SyncFilter {
+	filter := user.vault.getUser(user.userID).SyncFilter
+
+	return SyncFilter{ExcludedLabelIDs: slices.Clone(filter.ExcludedLabelIDs)}
+}
+
+// SetSyncFilter sets the folders and labels excluded from the user's local sync.
+func (user *User) SetSyncFilter(filter SyncFilter) error {
+	return user.vault.modUser(user.userID, func(data *UserData) {
+		data.SyncFilter = SyncFilter{ExcludedLabelIDs: slices.Clone(filter.ExcludedLabelIDs)}
+	})
+}
+
+// RedactionRules returns the rules selecting the user's messages whose bodies aren't cached locally.
+func (user *User) RedactionRules() RedactionRules {
+	rules := user.vault.getUser(user.userID).RedactionRules
+
+	return RedactionRules{
+		SenderDomains: slices.Clone(rules.SenderDomains),
+		LabelIDs:      slices.Clone(rules.LabelIDs),
+	}
+}
+
+// SetRedactionRules sets the rules selecting the user's messages whose bodies aren't cached locally.
+func (user *User) SetRedactionRules(rules RedactionRules) error {
+	return user.vault.modUser(user.userID, func(data *UserData) {
+		data.RedactionRules = RedactionRules{
+			SenderDomains: slices.Clone(rules.SenderDomains),
+			LabelIDs:      slices.Clone(rules.LabelIDs),
+		}
+	})
+}
+
+// ReauthRequired returns whether the user's session expired and they have to sign in again.
+func (user *User) ReauthRequired() bool {
+	return user.vault.getUser(user.userID).ReauthRequired
+}
+
+// SetReauthRequired sets whether the user's session expired and they have to sign in again.
+// It is cleared automatically when the user signs in again.
+func (user *User) SetReauthRequired(required bool) error {
+	return user.vault.modUser(user.userID, func(data *UserData) {
+		data.ReauthRequired = required
+	})
+}
+
+// DisplayMetadata returns how frontends render the user's account.
+func (user *User) DisplayMetadata() DisplayMetadata {
+	return user.vault.getUser(user.userID).Display
+}
+
+// SetDisplayMetadata sets how frontends render the user's account.
+func (user *User) SetDisplayMetadata(display DisplayMetadata) error {
+	return user.vault.modUser(user.userID, func(data *UserData) {
+		data.Display = display
+	})
+}
+
+// MailClient returns the mail client frontends open for the user's account.
+func (user *User) MailClient() MailClient {
+	return user.vault.getUser(user.userID).MailClient
+}
+
+// SetMailClient sets the mail client frontends open for the user's account.
+func (user *User) SetMailClient(client MailClient) error {
+	return user.vault.modUser(user.userID, func(data *UserData) {
+		data.MailClient = client
+	})
+}
+
 // Clear clears the user's auth secrets.
 func (user *User) Clear() error {
 	return user.vault.modUser(user.userID, func(data *UserData) {
 		data.AuthUID = ""
 		data.AuthRef = ""
 		data.KeyPass = nil
+		data.AuthRefreshedAt = time.Time{}
 	})
 }
 
@@ -20,7 +20,9 @@ package vault_test
 import (
 	"runtime"
 	"testing"
+	"time"
 
+	"github.com/ProtonMail/proton-bridge/v3/internal/secret"
 	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
 	"github.com/stretchr/testify/require"
 )
@@ -63,6 +65,26 @@ func TestUser_New(t *testing.T) {
 	require.False(t, user.SyncStatus().HasMessages)
 }
 
+func TestUser_WipeSecrets(t *testing.T) {
+	// Create a new test vault.
+	s := newVault(t)
+
+	// Create a new user; the key password given is wiped once stored.
+	keyPass := []byte("keyPass")
+
+	user, err := s.AddUser("userID", "username", "username@pm.me", "authUID", "authRef", keyPass)
+	require.NoError(t, err)
+
+	secret.Wipe(keyPass)
+
+	// The secrets returned are copies which can be wiped after use.
+	secret.Wipe(user.KeyPass())
+	secret.Wipe(user.BridgePass())
+
+	require.Equal(t, "keyPass", string(user.KeyPass()))
+	require.NotEqual(t, make([]byte, len(user.BridgePass())), user.BridgePass())
+}
+
 func TestUser_Clear(t *testing.T) {
 	// Create a new test vault.
 	s := newVault(t)
@@ -145,6 +167,450 @@ func TestUser_SyncStatus(t *testing.T) {
 	require.Empty(t, user.SyncStatus().LastMessageID)
 }
 
+func TestUser_SpamFilter(t *testing.T) {
+	// Create a new test vault.
+	s := newVault(t)
+
+	// Create a user.
+	user, err := s.AddUser("userID", "username", "username@pm.me", "authUID", "authRef", []byte("keyPass"))
+	require.NoError(t, err)
+
+	// The spam filter is disabled by default.
+	require.Equal(t, vault.SpamFilter{}, user.SpamFilter())
+
+	// Configure the spam filter.
+	filter := vault.SpamFilter{Backend: vault.SpamFilterRspamd, Address: "127.0.0.1:11333", MoveToSpam: true}
+	require.NoError(t, user.SetSpamFilter(filter))
+	require.Equal(t, filter, user.SpamFilter())
+}
+
+func TestUser_NotificationRules(t *testing.T) {
+	// Create a new test vault.
+	s := newVault(t)
+
+	// Create a user.
+	user, err := s.AddUser("userID", "username", "username@pm.me", "authUID", "authRef", []byte("keyPass"))
+	require.NoError(t, err)
+
+	// Notifications are disabled by default.
+	require.False(t, user.NotificationRules().Enabled)
+
+	// Configure the notification rules.
+	rules := vault.NotificationRules{
+		Enabled:    true,
+		Mailboxes:  []string{"INBOX", "Urgent"},
+		Muted:      []string{"Newsletters"},
+		QuietHours: vault.QuietHours{Enabled: true, Start: 22 * time.Hour, End: 7 * time.Hour},
+		Webhook:    "http://localhost:8080/hook",
+	}
+	require.NoError(t, user.SetNotificationRules(rules))
+	require.Equal(t, rules, user.NotificationRules())
+}
+
+func TestUser_Templates(t *testing.T) {
+	// Create a new test vault.
+	s := newVault(t)
+
+	// Create a user.
+	user, err := s.AddUser("userID", "username", "username@pm.me", "authUID", "authRef", []byte("keyPass"))
+	require.NoError(t, err)
+
+	// There are no templates by default.
+	require.Empty(t, user.GetTemplates())
+
+	// Add a template.
+	require.NoError(t, user.SetTemplate("thanks", "Thank you, {{.Name}}!"))
+	require.Equal(t, map[string]string{"thanks": "Thank you, {{.Name}}!"}, user.GetTemplates())
+
+	body, ok := user.GetTemplate("thanks")
+	require.True(t, ok)
+	require.Equal(t, "Thank you, {{.Name}}!", body)
+
+	// Remove the template.
+	require.NoError(t, user.DeleteTemplate("thanks"))
+
+	_, ok = user.GetTemplate("thanks")
+	require.False(t, ok)
+}
+
+func TestUser_SavedSearches(t *testing.T) {
+	// Create a new test vault.
+	s := newVault(t)
+
+	// Create a user.
+	user, err := s.AddUser("userID", "username", "username@pm.me", "authUID", "authRef", []byte("keyPass"))
+	require.NoError(t, err)
+
+	// There are no saved searches by default.
+	require.Empty(t, user.GetSavedSearches())
+
+	// Set the saved searches.
+	searches := []vault.SavedSearch{{ID: "id", Name: "Flagged-this-week", Query: "is:flagged newer:1w"}}
+	require.NoError(t, user.SetSavedSearches(searches))
+	require.Equal(t, searches, user.GetSavedSearches())
+
+	// Remove them.
+	require.NoError(t, user.SetSavedSearches(nil))
+	require.Empty(t, user.GetSavedSearches())
+}
+
+func TestUser_RecipientRoutes(t *testing.T) {
+	// Create a new test vault.
+	s := newVault(t)
+
+	// Create a user.
+	user, err := s.AddUser("userID", "username", "username@pm.me", "authUID", "authRef", []byte("keyPass"))
+	require.NoError(t, err)
+
+	// There are no recipient routes by default.
+	require.Empty(t, user.GetRecipientRoutes())
+
+	// Set the recipient routes.
+	routes := []vault.RecipientRoute{{Pattern: "shop@example.com", Folder: "Shopping"}}
+	require.NoError(t, user.SetRecipientRoutes(routes))
+	require.Equal(t, routes, user.GetRecipientRoutes())
+
+	// Remove them.
+	require.NoError(t, user.SetRecipientRoutes(nil))
+	require.Empty(t, user.GetRecipientRoutes())
+}
+
+func TestUser_SubaddressRules(t *testing.T) {
+	// Create a new test vault.
+	s := newVault(t)
+
+	// Create a user.
+	user, err := s.AddUser("userID", "username", "username@pm.me", "authUID", "authRef", []byte("keyPass"))
+	require.NoError(t, err)
+
+	// There are no subaddress rules by default.
+	require.Empty(t, user.GetSubaddressRules())
+
+	// Set the subaddress rules.
+	rules := []vault.SubaddressRule{{Tag: "news", Label: "News"}}
+	require.NoError(t, user.SetSubaddressRules(rules))
+	require.Equal(t, rules, user.GetSubaddressRules())
+
+	// Remove them.
+	require.NoError(t, user.SetSubaddressRules(nil))
+	require.Empty(t, user.GetSubaddressRules())
+}
+
+func TestUser_LabelKeywordMode(t *testing.T) {
+	// Create a new test vault.
+	s := newVault(t)
+
+	// Create a user.
+	user, err := s.AddUser("userID", "username", "username@pm.me", "authUID", "authRef", []byte("keyPass"))
+	require.NoError(t, err)
+
+	// Labels are not exposed as keywords by default.
+	require.Equal(t, vault.LabelKeywordsOff, user.LabelKeywordMode())
+
+	// Expose them as keywords only.
+	require.NoError(t, user.SetLabelKeywordMode(vault.LabelKeywordsOnly))
+	require.Equal(t, vault.LabelKeywordsOnly, user.LabelKeywordMode())
+}
+
+func TestUser_DeleteMode(t *testing.T) {
+	// Create a new test vault.
+	s := newVault(t)
+
+	// Create a user.
+	user, err := s.AddUser("userID", "username", "username@pm.me", "authUID", "authRef", []byte("keyPass"))
+	require.NoError(t, err)
+
+	// Expunged messages are removed from their folder by default.
+	require.Equal(t, vault.DeleteModeRemove, user.DeleteMode())
+
+	// Move them to Trash instead.
+	require.NoError(t, user.SetDeleteMode(vault.DeleteModeTrash))
+	require.Equal(t, vault.DeleteModeTrash, user.DeleteMode())
+}
+
+func TestUser_AutoPurge(t *testing.T) {
+	// Create a new test vault.
+	s := newVault(t)
+
+	// Create a user.
+	user, err := s.AddUser("userID", "username", "username@pm.me", "authUID", "authRef", []byte("keyPass"))
+	require.NoError(t, err)
+
+	// Nothing is purged by default.
+	require.Equal(t, vault.AutoPurge{}, user.AutoPurge())
+
+	// Purge Trash after 30 days.
+	require.NoError(t, user.SetAutoPurge(vault.AutoPurge{TrashDays: 30}))
+	require.Equal(t, vault.AutoPurge{TrashDays: 30}, user.AutoPurge())
+
+	// The messages seen in Trash and when they may be purged are kept.
+	seenAt := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	require.NoError(t, user.SetAutoPurgeState(vault.AutoPurgeState{
+		Seen:    map[string]map[string]time.Time{"trash": {"messageID": seenAt}},
+		PurgeAt: map[string]time.Time{"messageID": seenAt.Add(12 * time.Hour)},
+	}))
+
+	state := user.AutoPurgeState()
+	require.True(t, seenAt.Equal(state.Seen["trash"]["messageID"]))
+	require.True(t, seenAt.Add(12*time.Hour).Equal(state.PurgeAt["messageID"]))
+}
+
+func TestUser_SyncFilter(t *testing.T) {
+	// Create a new test vault.
+	s := newVault(t)
+
+	// Create a user.
+	user, err := s.AddUser("userID", "username", "username@pm.me", "authUID", "authRef", []byte("keyPass"))
+	require.NoError(t, err)
+
+	// Everything is synced by default.
+	require.Empty(t, user.SyncFilter().ExcludedLabelIDs)
+
+	// Exclude Spam.
+	require.NoError(t, user.SetSyncFilter(vault.SyncFilter{ExcludedLabelIDs: []string{"spam"}}))
+	require.Equal(t, vault.SyncFilter{ExcludedLabelIDs: []string{"spam"}}, user.SyncFilter())
+}
+
+func TestUser_RedactionRules(t *testing.T) {
+	// Create a new test vault.
+	s := newVault(t)
+
+	// Create a user.
+	user, err := s.AddUser("userID", "username", "username@pm.me", "authUID", "authRef", []byte("keyPass"))
+	require.NoError(t, err)
+
+	// Nothing is redacted by default.
+	require.Equal(t, vault.RedactionRules{}, user.RedactionRules())
+
+	// Redact the messages of a domain and of a label.
+	rules := vault.RedactionRules{SenderDomains: []string{"bank.com"}, LabelIDs: []string{"labelID"}}
+
+	require.NoError(t, user.SetRedactionRules(rules))
+	require.Equal(t, rules, user.RedactionRules())
+}
+
+func TestUser_MigrationMode(t *testing.T) {
+	// Create a new test vault.
+	s := newVault(t)
+
+	// Create a user.
+	user, err := s.AddUser("userID", "username", "username@pm.me", "authUID", "authRef", []byte("keyPass"))
+	require.NoError(t, err)
+
+	// Migration mode is disabled by default.
+	require.False(t, user.MigrationMode())
+
+	// Enable migration mode.
+	require.NoError(t, user.SetMigrationMode(true))
+	require.True(t, user.MigrationMode())
+}
+
+func TestUser_ConversationMode(t *testing.T) {
+	// Create a new test vault.
+	s := newVault(t)
+
+	// Create a user.
+	user, err := s.AddUser("userID", "username", "username@pm.me", "authUID", "authRef", []byte("keyPass"))
+	require.NoError(t, err)
+
+	// Conversation mode is disabled by default.
+	require.False(t, user.ConversationMode())
+
+	// Enable conversation mode.
+	require.NoError(t, user.SetConversationMode(true))
+	require.True(t, user.ConversationMode())
+}
+
+func TestUser_TranscodeToUTF8(t *testing.T) {
+	// Create a new test vault.
+	s := newVault(t)
+
+	// Create a user.
+	user, err := s.AddUser("userID", "username", "username@pm.me", "authUID", "authRef", []byte("keyPass"))
+	require.NoError(t, err)
+
+	// Messages are served in their original charsets by default.
+	require.False(t, user.TranscodeToUTF8())
+
+	// Enable transcoding.
+	require.NoError(t, user.SetTranscodeToUTF8(true))
+	require.True(t, user.TranscodeToUTF8())
+}
+
+func TestUser_HeaderTemplate(t *testing.T) {
+	// Create a new test vault.
+	s := newVault(t)
+
+	// Create a user.
+	user, err := s.AddUser("userID", "username", "username@pm.me", "authUID", "authRef", []byte("keyPass"))
+	require.NoError(t, err)
+
+	// No header fields are added by default.
+	require.Empty(t, user.HeaderTemplate())
+
+	// Set a template.
+	require.NoError(t, user.SetHeaderTemplate("X-Original-To: {{.OriginalTo}}"))
+	require.Equal(t, "X-Original-To: {{.OriginalTo}}", user.HeaderTemplate())
+}
+
+func TestUser_ContactDisplayNames(t *testing.T) {
+	// Create a new test vault.
+	s := newVault(t)
+
+	// Create a user.
+	user, err := s.AddUser("userID", "username", "username@pm.me", "authUID", "authRef", []byte("keyPass"))
+	require.NoError(t, err)
+
+	// Senders' own display names are kept by default.
+	require.False(t, user.ContactDisplayNames())
+
+	// Use contact names instead.
+	require.NoError(t, user.SetContactDisplayNames(true))
+	require.True(t, user.ContactDisplayNames())
+}
+
+func TestUser_DateNormalization(t *testing.T) {
+	// Create a new test vault.
+	s := newVault(t)
+
+	// Create a user.
+	user, err := s.AddUser("userID", "username", "username@pm.me", "authUID", "authRef", []byte("keyPass"))
+	require.NoError(t, err)
+
+	// Dates are kept as sent by default.
+	require.Empty(t, user.DateTimeZone())
+	require.False(t, user.RepairDates())
+
+	// Normalize them.
+	require.NoError(t, user.SetDateTimeZone("Europe/Zurich"))
+	require.NoError(t, user.SetRepairDates(true))
+	require.Equal(t, "Europe/Zurich", user.DateTimeZone())
+	require.True(t, user.RepairDates())
+}
+
+func TestUser_DraftCoalescingInterval(t *testing.T) {
+	// Create a new test vault.
+	s := newVault(t)
+
+	// Create a user.
+	user, err := s.AddUser("userID", "username", "username@pm.me", "authUID", "authRef", []byte("keyPass"))
+	require.NoError(t, err)
+
+	// Every draft save is uploaded by default.
+	require.Zero(t, user.DraftCoalescingInterval())
+
+	// Upload drafts at most once a minute.
+	require.NoError(t, user.SetDraftCoalescingInterval(time.Minute))
+	require.Equal(t, time.Minute, user.DraftCoalescingInterval())
+}
+
+func TestUser_NNTPFolders(t *testing.T) {
+	// Create a new test vault.
+	s := newVault(t)
+
+	// Create a user.
+	user, err := s.AddUser("userID", "username", "username@pm.me", "authUID", "authRef", []byte("keyPass"))
+	require.NoError(t, err)
+
+	// No folders are exposed by default.
+	require.Empty(t, user.NNTPFolders())
+
+	// Expose some folders.
+	require.NoError(t, user.SetNNTPFolders([]string{"Lists/golang-nuts", "Lists/lkml"}))
+	require.Equal(t, []string{"Lists/golang-nuts", "Lists/lkml"}, user.NNTPFolders())
+}
+
+func TestUser_DisplayMetadata(t *testing.T) {
+	// Create a new test vault.
+	s := newVault(t)
+
+	// Create a user.
+	user, err := s.AddUser("userID", "username", "username@pm.me", "authUID", "authRef", []byte("keyPass"))
+	require.NoError(t, err)
+
+	// Frontends use their defaults by default.
+	require.Equal(t, vault.DisplayMetadata{}, user.DisplayMetadata())
+
+	// Set the display metadata.
+	display := vault.DisplayMetadata{Nickname: "Work", Color: "#8A6EFF", Initials: "W", SortOrder: 2}
+	require.NoError(t, user.SetDisplayMetadata(display))
+	require.Equal(t, display, user.DisplayMetadata())
+}
+
+func TestUser_MailClient(t *testing.T) {
+	// Create a new test vault.
+	s := newVault(t)
+
+	// Create a user.
+	user, err := s.AddUser("userID", "username", "username@pm.me", "authUID", "authRef", []byte("keyPass"))
+	require.NoError(t, err)
+
+	// No mail client is bound by default.
+	require.Equal(t, vault.MailClient{}, user.MailClient())
+
+	// Bind the account to a mail client.
+	client := vault.MailClient{Client: "thunderbird", Path: "/usr/bin/thunderbird", Profile: "work"}
+	require.NoError(t, user.SetMailClient(client))
+	require.Equal(t, client, user.MailClient())
+}
+
+func TestUser_Digest(t *testing.T) {
+	// Create a new test vault.
+	s := newVault(t)
+
+	// Create a user.
+	user, err := s.AddUser("userID", "username", "username@pm.me", "authUID", "authRef", []byte("keyPass"))
+	require.NoError(t, err)
+
+	// The digest is disabled by default.
+	require.False(t, user.Digest().Enabled)
+
+	// Enable it and start a period.
+	since := time.Now().Truncate(time.Second)
+
+	require.NoError(t, user.SetDigestEnabled(true))
+	require.NoError(t, user.ResetDigest(since, 1000))
+
+	// Count some messages.
+	require.NoError(t, user.AddDigestMessage([]string{"0", "5"}, "alice@example.com"))
+	require.NoError(t, user.AddDigestMessage([]string{"0", "5"}, "alice@example.com"))
+	require.NoError(t, user.AddDigestMessage([]string{"7", "5"}, ""))
+
+	require.Equal(t, vault.Digest{
+		Enabled:   true,
+		Since:     since,
+		UsedSpace: 1000,
+		Received:  map[string]int{"0": 2, "5": 3, "7": 1},
+		Senders:   map[string]int{"alice@example.com": 2},
+	}, user.Digest())
+
+	// Resetting the digest keeps it enabled.
+	require.NoError(t, user.ResetDigest(since.Add(time.Hour), 2000))
+	require.Equal(t, vault.Digest{Enabled: true, Since: since.Add(time.Hour), UsedSpace: 2000}, user.Digest())
+}
+
+func TestUser_ReauthRequired(t *testing.T) {
+	// Create a new test vault.
+	s := newVault(t)
+
+	// Create a user.
+	user, err := s.AddUser("userID", "username", "username@pm.me", "authUID", "authRef", []byte("keyPass"))
+	require.NoError(t, err)
+	require.False(t, user.ReauthRequired())
+
+	// The user's session expires.
+	require.NoError(t, user.SetReauthRequired(true))
+	require.NoError(t, user.Clear())
+	require.True(t, user.ReauthRequired())
+	require.NoError(t, user.Close())
+
+	// Signing in again clears the flag.
+	user, _, err = s.GetOrAddUser("userID", "username", "username@pm.me", "newAuthUID", "newAuthRef", []byte("keyPass"))
+	require.NoError(t, err)
+	require.False(t, user.ReauthRequired())
+}
+
 func TestUser_PrimaryEmail(t *testing.T) {
 	// Create a new test vault.
 	s := newVault(t)
@@ -30,6 +30,8 @@ import (
 	"sync"
 
 	"github.com/ProtonMail/gluon/async"
+	"github.com/ProtonMail/proton-bridge/v3/internal/interference"
+	"github.com/ProtonMail/proton-bridge/v3/internal/secret"
 	"github.com/bradenaw/juniper/parallel"
 	"github.com/bradenaw/juniper/xslices"
 	"github.com/sirupsen/logrus"
@@ -55,19 +57,12 @@ func New(vaultDir, gluonCacheDir string, key []byte, panicHandler async.PanicHan
 		return nil, false, err
 	}
 
-	hash256 := sha256.Sum256(key)
-
-	aes, err := aes.NewCipher(hash256[:])
-	if err != nil {
-		return nil, false, err
-	}
-
-	gcm, err := cipher.NewGCM(aes)
+	gcm, err := newGCM(key)
 	if err != nil {
 		return nil, false, err
 	}
 
-	vault, corrupt, err := newVault(filepath.Join(vaultDir, "vault.enc"), gluonCacheDir, gcm)
+	vault, corrupt, err := newVault(getVaultPath(vaultDir), gluonCacheDir, gcm)
 	if err != nil {
 		return nil, false, err
 	}
@@ -294,6 +289,134 @@ func (vault *Vault) Reset(gluonDir string) error {
 	})
 }
 
+// Snapshot writes a copy of the encrypted vault to path. It can only be read back by a vault using the same key.
+func (vault *Vault) Snapshot(path string) error {
+	vault.lock.RLock()
+	defer vault.lock.RUnlock()
+
+	return os.WriteFile(path, vault.enc, 0o600)
+}
+
+// RestoreSnapshot replaces the contents of the vault by those of the snapshot at path.
+func (vault *Vault) RestoreSnapshot(path string) error {
+	vault.lock.Lock()
+	defer vault.lock.Unlock()
+
+	if len(vault.ref) > 0 {
+		return errors.New("vault is still in use")
+	}
+
+	snapshot, err := vault.readSnapshotUnsafe(path)
+	if err != nil {
+		return err
+	}
+
+	return vault.modUnsafe(func(data *Data) {
+		*data = snapshot
+	})
+}
+
+// RestoreUserFromSnapshot adds the given user back to the vault from the snapshot at path.
+func (vault *Vault) RestoreUserFromSnapshot(path, userID string) error {
+	vault.lock.Lock()
+	defer vault.lock.Unlock()
+
+	snapshot, err := vault.readSnapshotUnsafe(path)
+	if err != nil {
+		return err
+	}
+
+	findUser := func(user UserData) bool { return user.UserID == userID }
+
+	idx := xslices.IndexFunc(snapshot.Users, findUser)
+	if idx < 0 {
+		return fmt.Errorf("user %s is not in the snapshot", userID)
+	}
+
+	if xslices.IndexFunc(vault.getUnsafe().Users, findUser) >= 0 {
+		return fmt.Errorf("user %s already exists", userID)
+	}
+
+	return vault.modUnsafe(func(data *Data) {
+		data.Users = append(data.Users, snapshot.Users[idx])
+	})
+}
+
+// NeedsRekey returns whether the vault in the given directory exists but can't be decrypted with the given key,
+// e.g. because the key was rotated in the keychain.
+func NeedsRekey(vaultDir string, key []byte) (bool, error) {
+	enc, err := os.ReadFile(getVaultPath(vaultDir))
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return false, err
+	}
+
+	return unmarshalFile(gcm, enc, new(Data)) != nil, nil
+}
+
+// Rekey re-encrypts the vault in the given directory, replacing the old key with the new one.
+func Rekey(vaultDir string, oldKey, newKey []byte) error {
+	path := getVaultPath(vaultDir)
+
+	enc, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return fmt.Errorf("failed to read vault: %w", err)
+	}
+
+	oldCipher, err := newGCM(oldKey)
+	if err != nil {
+		return err
+	}
+
+	newCipher, err := newGCM(newKey)
+	if err != nil {
+		return err
+	}
+
+	var data Data
+
+	if err := unmarshalFile(oldCipher, enc, &data); err != nil {
+		return fmt.Errorf("failed to decrypt vault with old key: %w", err)
+	}
+
+	if enc, err = marshalFile(newCipher, data); err != nil {
+		return fmt.Errorf("failed to encrypt vault with new key: %w", err)
+	}
+
+	tmpFile := path + ".tmp"
+
+	if err := interference.Retry(func() error { return os.WriteFile(tmpFile, enc, 0o600) }); err != nil {
+		return fmt.Errorf("failed write new vault to disk: %w", err)
+	}
+
+	if err := interference.Retry(func() error { return os.Rename(tmpFile, path) }); err != nil {
+		return fmt.Errorf("failed to overwrite old vault data: %w", err)
+	}
+
+	return nil
+}
+
+func (vault *Vault) readSnapshotUnsafe(path string) (Data, error) {
+	enc, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return Data{}, fmt.Errorf("failed to read vault snapshot: %w", err)
+	}
+
+	var data Data
+
+	if err := unmarshalFile(vault.gcm, enc, &data); err != nil {
+		return Data{}, fmt.Errorf("failed to decrypt vault snapshot: %w", err)
+	}
+
+	return data, nil
+}
+
 func (vault *Vault) Path() string {
 	return vault.path
 }
@@ -341,6 +464,23 @@ func (vault *Vault) detachUser(userID string) error {
 	return nil
 }
 
+func getVaultPath(vaultDir string) string {
+	return filepath.Join(vaultDir, "vault.enc")
+}
+
+// newGCM returns the cipher encrypting the vault with the given key.
+func newGCM(key []byte) (cipher.AEAD, error) {
+	hash256 := sha256.Sum256(key)
+	defer secret.Wipe(hash256[:])
+
+	aes, err := aes.NewCipher(hash256[:])
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(aes)
+}
+
 func newVault(path, gluonDir string, gcm cipher.AEAD) (*Vault, bool, error) {
 	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
 		if _, err := initVault(path, gluonDir, gcm); err != nil {
@@ -418,11 +558,11 @@ func (vault *Vault) modUnsafe(fn func(data *Data)) error {
 
 	tmpFile := vault.path + ".tmp"
 
-	if err := os.WriteFile(tmpFile, vault.enc, 0o600); err != nil {
+	if err := interference.Retry(func() error { return os.WriteFile(tmpFile, vault.enc, 0o600) }); err != nil {
 		return fmt.Errorf("failed write new vault to disk: %w", err)
 	}
 
-	if err := os.Rename(tmpFile, vault.path); err != nil {
+	if err := interference.Retry(func() error { return os.Rename(tmpFile, vault.path) }); err != nil {
 		return fmt.Errorf("failed to overwrite old vault data: %w", err)
 	}
 
@@ -79,6 +79,40 @@ func TestVault_Corrupt_JunkData(t *testing.T) {
 	}
 }
 
+func TestVault_Rekey(t *testing.T) {
+	vaultDir, gluonDir := t.TempDir(), t.TempDir()
+
+	// There is nothing to re-encrypt before the vault is created.
+	needsRekey, err := vault.NeedsRekey(vaultDir, []byte("old key"))
+	require.NoError(t, err)
+	require.False(t, needsRekey)
+
+	{
+		s, corrupt, err := vault.New(vaultDir, gluonDir, []byte("old key"), async.NoopPanicHandler{})
+		require.NoError(t, err)
+		require.False(t, corrupt)
+		require.NoError(t, s.SetIMAPPort(1234))
+	}
+
+	needsRekey, err = vault.NeedsRekey(vaultDir, []byte("new key"))
+	require.NoError(t, err)
+	require.True(t, needsRekey)
+
+	// The vault can't be re-encrypted without the key it was written with.
+	require.Error(t, vault.Rekey(vaultDir, []byte("bad key"), []byte("new key")))
+	require.NoError(t, vault.Rekey(vaultDir, []byte("old key"), []byte("new key")))
+
+	needsRekey, err = vault.NeedsRekey(vaultDir, []byte("new key"))
+	require.NoError(t, err)
+	require.False(t, needsRekey)
+
+	// The data is kept.
+	s, corrupt, err := vault.New(vaultDir, gluonDir, []byte("new key"), async.NoopPanicHandler{})
+	require.NoError(t, err)
+	require.False(t, corrupt)
+	require.Equal(t, 1234, s.GetIMAPPort())
+}
+
 func TestVault_Reset(t *testing.T) {
 	s := newVault(t)
 
@@ -98,6 +132,34 @@ func TestVault_Reset(t *testing.T) {
 	require.Equal(t, ports.FindFreePortFrom(1025), s.GetSMTPPort())
 }
 
+func TestVault_Snapshot(t *testing.T) {
+	s := newVault(t)
+
+	require.NoError(t, s.SetIMAPPort(1234))
+
+	user, err := s.AddUser("userID", "username", "username@pm.me", "authUID", "authRef", []byte("keyPass"))
+	require.NoError(t, err)
+	require.NoError(t, user.Close())
+
+	snapshot := filepath.Join(t.TempDir(), "vault.enc")
+	require.NoError(t, s.Snapshot(snapshot))
+
+	// A user removed from the vault can be restored from the snapshot, but only once.
+	require.NoError(t, s.DeleteUser("userID"))
+	require.False(t, s.HasUser("userID"))
+	require.NoError(t, s.RestoreUserFromSnapshot(snapshot, "userID"))
+	require.True(t, s.HasUser("userID"))
+	require.Error(t, s.RestoreUserFromSnapshot(snapshot, "userID"))
+	require.Error(t, s.RestoreUserFromSnapshot(snapshot, "otherID"))
+
+	// A reset vault can be restored entirely from the snapshot.
+	require.NoError(t, s.Reset(s.GetGluonCacheDir()))
+	require.Empty(t, s.GetUserIDs())
+	require.NoError(t, s.RestoreSnapshot(snapshot))
+	require.Equal(t, 1234, s.GetIMAPPort())
+	require.Equal(t, []string{"userID"}, s.GetUserIDs())
+}
+
 func newVault(t *testing.T) *vault.Vault {
 	t.Helper()
 
@@ -53,25 +53,28 @@ func (op *OpRemove) Do() error {
 	return multiErr
 }
 
-func remove(dir string, except ...string) error {
-	var toRemove []string
+// DryRun returns the paths Do would remove, without removing anything.
+func (op *OpRemove) DryRun() ([]string, error) {
+	var paths []string
 
-	if err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
-		for _, exception := range except {
-			if path == exception || strings.HasPrefix(exception, path) || strings.HasPrefix(path, exception) {
-				return nil
-			}
+	for _, target := range op.targets {
+		targetPaths, err := collect(target, op.exceptions...)
+		if err != nil {
+			return nil, err
 		}
 
-		toRemove = append(toRemove, path)
+		paths = append(paths, targetPaths...)
+	}
+
+	return paths, nil
+}
 
-		return nil
-	}); err != nil {
+func remove(dir string, except ...string) error {
+	toRemove, err := collect(dir, except...)
+	if err != nil {
 		return err
 	}
 
-	sort.Sort(sort.Reverse(sort.StringSlice(toRemove)))
-
 	var multiErr error
 	for _, target := range toRemove {
 		if err := os.RemoveAll(target); err != nil {
@@ -81,3 +84,30 @@ func remove(dir string, except ...string) error {
 
 	return multiErr
 }
+
+// collect returns the paths under dir which aren't exceptions, deepest first.
+func collect(dir string, except ...string) ([]string, error) {
+	var toCollect []string
+
+	if err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		for _, exception := range except {
+			if path == exception || strings.HasPrefix(exception, path) || strings.HasPrefix(path, exception) {
+				return nil
+			}
+		}
+
+		toCollect = append(toCollect, path)
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	sort.Sort(sort.Reverse(sort.StringSlice(toCollect)))
+
+	return toCollect, nil
+}
@@ -91,6 +91,39 @@ func TestRemoveWithExceptions(t *testing.T) {
 	assert.FileExists(t, filepath.Join(dir, "subdir4", "file8"))
 }
 
+func TestRemoveDryRun(t *testing.T) {
+	dir := newTestDir(t,
+		"subdir1",
+		"subdir2",
+	)
+	defer delTestDir(t, dir)
+
+	createTestFiles(t, dir,
+		"subdir1/file1",
+		"subdir2/file2",
+		"subdir2/file3",
+	)
+
+	paths, err := Remove(
+		filepath.Join(dir, "subdir1"),
+		filepath.Join(dir, "subdir2"),
+		filepath.Join(dir, "missing"),
+	).Except(
+		filepath.Join(dir, "subdir2", "file3"),
+	).DryRun()
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{
+		filepath.Join(dir, "subdir1"),
+		filepath.Join(dir, "subdir1", "file1"),
+		filepath.Join(dir, "subdir2", "file2"),
+	}, paths)
+
+	// Nothing is removed.
+	assert.FileExists(t, filepath.Join(dir, "subdir1", "file1"))
+	assert.FileExists(t, filepath.Join(dir, "subdir2", "file2"))
+}
+
 func newTestDir(t *testing.T, subdirs ...string) string {
 	dir := t.TempDir()
 
@@ -0,0 +1,34 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package keychain
+
+import "github.com/docker/docker-credential-helpers/credentials"
+
+// Backend stores the secrets of the keychain, each under a URL. It is the interface of the docker credential helpers,
+// so that any of them can be used as a backend.
+type Backend = credentials.Helper
+
+// RegisterBackend makes a backend constructed for the URL of the keychain items available under the given name,
+// so that it can be selected like the system keychains. It must be called before the keychain is created.
+func RegisterBackend(name string, newBackend func(url string) (Backend, error)) {
+	if Helpers == nil {
+		Helpers = make(map[string]helperConstructor)
+	}
+
+	Helpers[name] = newBackend
+}
@@ -20,6 +20,7 @@ package keychain
 import (
 	"errors"
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/docker/docker-credential-helpers/credentials"
@@ -28,17 +29,34 @@ import (
 )
 
 const (
-	MacOSKeychain = "macos-keychain"
+	MacOSKeychain        = "macos-keychain"
+	MacOSKeychainTouchID = "macos-keychain-touchid"
 )
 
+// accessGroupEnv optionally names the keychain access group new items are stored in.
+// It requires the app to be signed with the matching keychain-access-groups entitlement.
+const accessGroupEnv = "BRIDGE_KEYCHAIN_ACCESS_GROUP"
+
+// errSecMissingEntitlement is returned by the keychain when the app may not use the requested access group.
+const errSecMissingEntitlement = "-34018"
+
 func init() { //nolint:gochecknoinits
 	Helpers = make(map[string]helperConstructor)
 
 	// MacOS always provides a keychain.
 	Helpers[MacOSKeychain] = newMacOSHelper
 
+	// The same keychain items can be gated behind Touch ID or Apple Watch confirmation.
+	Helpers[MacOSKeychainTouchID] = newMacOSTouchIDHelper
+
 	// Use MacOSKeychain by default.
 	DefaultHelper = MacOSKeychain
+
+	// External secret managers are available if configured.
+	addSecretProviders()
+
+	// The encrypted file is always available.
+	addFileHelper()
 }
 
 func parseError(original error) error {
@@ -52,11 +70,21 @@ func parseError(original error) error {
 }
 
 func newMacOSHelper(url string) (credentials.Helper, error) {
-	return &macOSHelper{url: url}, nil
+	return &macOSHelper{url: url, accessGroup: os.Getenv(accessGroupEnv)}, nil
+}
+
+func newMacOSTouchIDHelper(url string) (credentials.Helper, error) {
+	helper, err := newMacOSHelper(url)
+	if err != nil {
+		return nil, err
+	}
+
+	return &confirmingHelper{Helper: helper, confirm: confirmWithTouchID}, nil
 }
 
 type macOSHelper struct {
-	url string
+	url         string
+	accessGroup string
 }
 
 func newQuery(service, account string) keychain.Item {
@@ -87,6 +115,21 @@ func (h *macOSHelper) Add(creds *credentials.Credentials) error {
 
 	query := newQuery(hostURL, userID)
 	query.SetData([]byte(creds.Secret))
+
+	if h.accessGroup != "" {
+		query.SetAccessGroup(h.accessGroup)
+
+		// Fall back to the default access group if the app isn't entitled to the requested one.
+		if err := keychain.AddItem(query); err == nil || !strings.Contains(err.Error(), errSecMissingEntitlement) {
+			return parseError(err)
+		}
+
+		logrus.WithField("pkg", "keychain/darwin").WithField("accessGroup", h.accessGroup).Warn("Missing keychain access group entitlement")
+
+		query = newQuery(hostURL, userID)
+		query.SetData([]byte(creds.Secret))
+	}
+
 	return parseError(keychain.AddItem(query))
 }
 
@@ -0,0 +1,137 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package keychain
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"unsafe"
+
+	"github.com/docker/docker-credential-helpers/credentials"
+	"github.com/docker/docker-credential-helpers/wincred"
+	"golang.org/x/sys/execabs"
+	"golang.org/x/sys/windows"
+)
+
+const (
+	WindowsDPAPI      = "windows-dpapi"
+	WindowsDPAPIHello = "windows-dpapi-hello"
+)
+
+// dpapiPrefix keeps the DPAPI-protected entries apart from the plain ones in the Credential Manager.
+const dpapiPrefix = "dpapi:"
+
+// helloScript asks for Windows Hello verification (face, fingerprint or PIN) and prints the result.
+const helloScript = `
+Add-Type -AssemblyName System.Runtime.WindowsRuntime
+$asTask = ([System.WindowsRuntimeSystemExtensions].GetMethods() | Where-Object { $_.Name -eq 'AsTask' -and $_.GetParameters().Count -eq 1 -and $_.GetParameters()[0].ParameterType.Name -eq 'IAsyncOperation` + "`" + `1' })[0]
+$null = [Windows.Security.Credentials.UI.UserConsentVerifier, Windows.Security.Credentials.UI, ContentType = WindowsRuntime]
+$op = [Windows.Security.Credentials.UI.UserConsentVerifier]::RequestVerificationAsync('Proton Mail Bridge wants to unlock its vault')
+$task = $asTask.MakeGenericMethod([Windows.Security.Credentials.UI.UserConsentVerificationResult]).Invoke($null, @($op))
+$null = $task.Wait(-1)
+$task.Result
+`
+
+// newDPAPIHelper returns a helper storing secrets in the Credential Manager, encrypted with DPAPI for the current user.
+func newDPAPIHelper(string) (credentials.Helper, error) {
+	entropy, err := getDPAPIEntropy()
+	if err != nil {
+		return nil, err
+	}
+
+	return &protectedHelper{
+		helper:    &wincred.Wincred{},
+		prefix:    dpapiPrefix,
+		protect:   func(b []byte) ([]byte, error) { return dpapiProtect(b, entropy) },
+		unprotect: func(b []byte) ([]byte, error) { return dpapiUnprotect(b, entropy) },
+	}, nil
+}
+
+// newDPAPIHelloHelper is like newDPAPIHelper but asks for Windows Hello verification before releasing a secret.
+func newDPAPIHelloHelper(url string) (credentials.Helper, error) {
+	helper, err := newDPAPIHelper(url)
+	if err != nil {
+		return nil, err
+	}
+
+	return &confirmingHelper{Helper: helper, confirm: confirmWithWindowsHello}, nil
+}
+
+// getDPAPIEntropy returns the additional entropy used to protect secrets, derived from the current user's SID.
+// It only namespaces bridge's blobs: the SID is public and the derivation is known, so any process running
+// as the user can still unprotect them. DPAPI protects them from other users and offline access, not from those.
+func getDPAPIEntropy() ([]byte, error) {
+	user, err := windows.GetCurrentProcessToken().GetTokenUser()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current user: %w", err)
+	}
+
+	hash := sha256.Sum256([]byte("proton-bridge-keychain:" + user.User.Sid.String()))
+
+	return hash[:], nil
+}
+
+func dpapiProtect(data, entropy []byte) ([]byte, error) {
+	var out windows.DataBlob
+
+	if err := windows.CryptProtectData(newDataBlob(data), nil, newDataBlob(entropy), 0, nil, windows.CRYPTPROTECT_UI_FORBIDDEN, &out); err != nil {
+		return nil, fmt.Errorf("failed to protect data: %w", err)
+	}
+
+	return takeDataBlob(&out), nil
+}
+
+func dpapiUnprotect(data, entropy []byte) ([]byte, error) {
+	var out windows.DataBlob
+
+	if err := windows.CryptUnprotectData(newDataBlob(data), nil, newDataBlob(entropy), 0, nil, windows.CRYPTPROTECT_UI_FORBIDDEN, &out); err != nil {
+		return nil, fmt.Errorf("failed to unprotect data: %w", err)
+	}
+
+	return takeDataBlob(&out), nil
+}
+
+func newDataBlob(b []byte) *windows.DataBlob {
+	if len(b) == 0 {
+		return &windows.DataBlob{}
+	}
+
+	return &windows.DataBlob{Size: uint32(len(b)), Data: &b[0]}
+}
+
+// takeDataBlob copies the data allocated by DPAPI and frees it.
+func takeDataBlob(blob *windows.DataBlob) []byte {
+	defer windows.LocalFree(windows.Handle(unsafe.Pointer(blob.Data))) //nolint:errcheck
+
+	return bytes.Clone(unsafe.Slice(blob.Data, blob.Size))
+}
+
+func confirmWithWindowsHello() error {
+	out, err := execabs.Command("powershell.exe", "-NoProfile", "-NonInteractive", "-Command", helloScript).Output()
+	if err != nil {
+		return fmt.Errorf("failed to request Windows Hello verification: %w", err)
+	}
+
+	if result := strings.TrimSpace(string(out)); result != "Verified" {
+		return fmt.Errorf("%w: %v", ErrNotConfirmed, result)
+	}
+
+	return nil
+}
@@ -0,0 +1,354 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package keychain
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/docker/docker-credential-helpers/credentials"
+	"golang.org/x/crypto/argon2"
+)
+
+const EncryptedFile = "encrypted-file"
+
+const (
+	filePassphraseEnv     = "BRIDGE_KEYCHAIN_PASSPHRASE"
+	filePassphraseFileEnv = "BRIDGE_KEYCHAIN_PASSPHRASE_FILE"
+	filePathEnv           = "BRIDGE_KEYCHAIN_FILE"
+)
+
+// fileVersion is the version of the format of the encrypted file.
+const fileVersion = 1
+
+// The argon2id parameters of new files, as recommended by RFC 9106 for memory constrained environments.
+const (
+	fileKDFTime    = 3
+	fileKDFMemory  = 64 * 1024
+	fileKDFThreads = 4
+	fileSaltLen    = 16
+	fileKeyLen     = 32
+)
+
+// The bounds of the argon2id parameters read from files, so that a corrupt file can't crash or exhaust the process.
+const (
+	fileKDFMaxTime    = 16
+	fileKDFMaxMemory  = 1024 * 1024
+	fileKDFMaxThreads = 64
+)
+
+var (
+	// ErrNoPassphrase is returned when the passphrase of the encrypted file keychain isn't configured.
+	ErrNoPassphrase = fmt.Errorf("neither %v nor %v is set", filePassphraseEnv, filePassphraseFileEnv)
+
+	// ErrWrongPassphrase is returned when the encrypted file can't be decrypted with the passphrase.
+	ErrWrongPassphrase = errors.New("wrong keychain passphrase, or corrupt keychain file")
+
+	// ErrInvalidFile is returned when the parameters of the encrypted file are out of bounds.
+	ErrInvalidFile = errors.New("invalid keychain file")
+)
+
+// fileLock serializes the accesses to the encrypted files of all keychains of the process.
+var fileLock sync.Mutex //nolint:gochecknoglobals
+
+// addFileHelper registers the encrypted file helper, for systems without a usable keychain such as headless servers.
+func addFileHelper() {
+	RegisterBackend(EncryptedFile, newFileHelper)
+}
+
+// hasFilePassphrase returns whether the passphrase of the encrypted file helper is configured.
+func hasFilePassphrase() bool {
+	return os.Getenv(filePassphraseEnv) != "" || os.Getenv(filePassphraseFileEnv) != ""
+}
+
+func newFileHelper(string) (Backend, error) {
+	passphrase, err := getFilePassphrase()
+	if err != nil {
+		return nil, err
+	}
+
+	path := os.Getenv(filePathEnv)
+
+	if path == "" {
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return nil, fmt.Errorf("could not get config dir: %w", err)
+		}
+
+		path = filepath.Join(dir, "protonmail", "keychain.enc")
+	}
+
+	return &fileHelper{path: path, passphrase: passphrase}, nil
+}
+
+// getFilePassphrase returns the passphrase set in the environment, or read from the file it names.
+func getFilePassphrase() ([]byte, error) {
+	if passphrase := os.Getenv(filePassphraseEnv); passphrase != "" {
+		return []byte(passphrase), nil
+	}
+
+	path := os.Getenv(filePassphraseFileEnv)
+	if path == "" {
+		return nil, ErrNoPassphrase
+	}
+
+	b, err := os.ReadFile(path) //nolint:gosec
+	if err != nil {
+		return nil, fmt.Errorf("could not read passphrase file: %w", err)
+	}
+
+	passphrase := strings.TrimRight(string(b), "\r\n")
+	if passphrase == "" {
+		return nil, ErrNoPassphrase
+	}
+
+	return []byte(passphrase), nil
+}
+
+// fileHelper stores the secrets in a file encrypted with AES-GCM, with a key derived from a passphrase with argon2id.
+type fileHelper struct {
+	path       string
+	passphrase []byte
+
+	// key is the key derived from the passphrase with the salt and parameters of the file.
+	key  []byte
+	salt []byte
+}
+
+// encryptedFile is the content of the file, whose data is the encrypted JSON of the entries by URL.
+type encryptedFile struct {
+	Version int
+
+	Salt    []byte
+	Time    uint32
+	Memory  uint32
+	Threads uint8
+
+	Nonce []byte
+	Data  []byte
+}
+
+// validate checks the argon2id parameters of the file, which are otherwise passed as is to the key derivation.
+func (file *encryptedFile) validate() error {
+	if file.Time < 1 || file.Time > fileKDFMaxTime {
+		return fmt.Errorf("%w: time parameter %v", ErrInvalidFile, file.Time)
+	}
+
+	if file.Threads < 1 || file.Threads > fileKDFMaxThreads {
+		return fmt.Errorf("%w: threads parameter %v", ErrInvalidFile, file.Threads)
+	}
+
+	if file.Memory < 8*uint32(file.Threads) || file.Memory > fileKDFMaxMemory {
+		return fmt.Errorf("%w: memory parameter %v", ErrInvalidFile, file.Memory)
+	}
+
+	if len(file.Salt) == 0 {
+		return fmt.Errorf("%w: empty salt", ErrInvalidFile)
+	}
+
+	return nil
+}
+
+type fileEntry struct {
+	Username string
+	Secret   string
+}
+
+func (h *fileHelper) Add(creds *credentials.Credentials) error {
+	return h.update(func(entries map[string]fileEntry) {
+		entries[creds.ServerURL] = fileEntry{Username: creds.Username, Secret: creds.Secret}
+	})
+}
+
+func (h *fileHelper) Delete(url string) error {
+	return h.update(func(entries map[string]fileEntry) {
+		delete(entries, url)
+	})
+}
+
+func (h *fileHelper) Get(url string) (string, string, error) {
+	fileLock.Lock()
+	defer fileLock.Unlock()
+
+	entries, _, err := h.load()
+	if err != nil {
+		return "", "", err
+	}
+
+	entry, ok := entries[url]
+	if !ok {
+		return "", "", credentials.NewErrCredentialsNotFound()
+	}
+
+	return entry.Username, entry.Secret, nil
+}
+
+func (h *fileHelper) List() (map[string]string, error) {
+	fileLock.Lock()
+	defer fileLock.Unlock()
+
+	entries, _, err := h.load()
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[string]string, len(entries))
+
+	for url, entry := range entries {
+		res[url] = entry.Username
+	}
+
+	return res, nil
+}
+
+func (h *fileHelper) update(fn func(map[string]fileEntry)) error {
+	fileLock.Lock()
+	defer fileLock.Unlock()
+
+	entries, file, err := h.load()
+	if err != nil {
+		return err
+	}
+
+	fn(entries)
+
+	return h.save(file, entries)
+}
+
+// load returns the entries of the file, and its header to keep when saving; none if it doesn't exist yet.
+func (h *fileHelper) load() (map[string]fileEntry, *encryptedFile, error) {
+	b, err := os.ReadFile(h.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return make(map[string]fileEntry), nil, nil
+	} else if err != nil {
+		return nil, nil, fmt.Errorf("could not read keychain file: %w", err)
+	}
+
+	var file encryptedFile
+
+	if err := json.Unmarshal(b, &file); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrWrongPassphrase, err)
+	}
+
+	if file.Version != fileVersion {
+		return nil, nil, fmt.Errorf("unsupported keychain file version %v", file.Version)
+	}
+
+	if err := file.validate(); err != nil {
+		return nil, nil, err
+	}
+
+	gcm, err := h.getCipher(&file)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(file.Nonce) != gcm.NonceSize() {
+		return nil, nil, fmt.Errorf("%w: nonce of %v bytes", ErrInvalidFile, len(file.Nonce))
+	}
+
+	dec, err := gcm.Open(nil, file.Nonce, file.Data, nil)
+	if err != nil {
+		return nil, nil, ErrWrongPassphrase
+	}
+
+	entries := make(map[string]fileEntry)
+
+	if err := json.Unmarshal(dec, &entries); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrWrongPassphrase, err)
+	}
+
+	return entries, &file, nil
+}
+
+// save encrypts the entries with a new nonce, and the key of the file or a new one.
+func (h *fileHelper) save(file *encryptedFile, entries map[string]fileEntry) error {
+	if file == nil {
+		salt := make([]byte, fileSaltLen)
+
+		if _, err := rand.Read(salt); err != nil {
+			return err
+		}
+
+		file = &encryptedFile{
+			Version: fileVersion,
+			Salt:    salt,
+			Time:    fileKDFTime,
+			Memory:  fileKDFMemory,
+			Threads: fileKDFThreads,
+		}
+	}
+
+	gcm, err := h.getCipher(file)
+	if err != nil {
+		return err
+	}
+
+	dec, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+
+	file.Nonce = make([]byte, gcm.NonceSize())
+
+	if _, err := rand.Read(file.Nonce); err != nil {
+		return err
+	}
+
+	file.Data = gcm.Seal(nil, file.Nonce, dec, nil)
+
+	b, err := json.Marshal(file)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(h.path), 0o700); err != nil {
+		return fmt.Errorf("could not create keychain dir: %w", err)
+	}
+
+	// Write a temporary file first so that the keychain isn't lost if writing fails midway.
+	if err := os.WriteFile(h.path+".tmp", b, 0o600); err != nil {
+		return fmt.Errorf("could not write keychain file: %w", err)
+	}
+
+	return os.Rename(h.path+".tmp", h.path)
+}
+
+// getCipher returns the cipher of the file, deriving its key only when its salt changed as that is deliberately slow.
+func (h *fileHelper) getCipher(file *encryptedFile) (cipher.AEAD, error) {
+	if h.key == nil || string(h.salt) != string(file.Salt) {
+		h.key = argon2.IDKey(h.passphrase, file.Salt, file.Time, file.Memory, file.Threads, fileKeyLen)
+		h.salt = file.Salt
+	}
+
+	block, err := aes.NewCipher(h.key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,155 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package keychain
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker-credential-helpers/credentials"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileHelper(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keychain.enc")
+
+	t.Setenv(filePathEnv, path)
+	t.Setenv(filePassphraseEnv, "passphrase")
+
+	kc, err := NewKeychain(EncryptedFile, "bridge-test")
+	require.NoError(t, err)
+
+	// The file is created with the first secret.
+	require.Empty(t, must(kc.List()))
+	require.NoError(t, kc.Put("userID", "secret"))
+
+	username, secret, err := kc.Get("userID")
+	require.NoError(t, err)
+	require.Equal(t, "userID", username)
+	require.Equal(t, "secret", secret)
+
+	// The secret is encrypted.
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NotContains(t, string(b), "secret")
+
+	// Another keychain with the same passphrase reads it.
+	other, err := NewKeychain(EncryptedFile, "bridge-test")
+	require.NoError(t, err)
+	require.Equal(t, []string{"userID"}, must(other.List()))
+
+	// Keychains of other names don't list it.
+	require.Empty(t, must(must(NewKeychain(EncryptedFile, "other")).List()))
+
+	require.NoError(t, kc.Delete("userID"))
+	require.Empty(t, must(other.List()))
+
+	_, _, err = kc.Get("userID")
+	require.True(t, credentials.IsErrCredentialsNotFound(err))
+}
+
+func TestFileHelper_Passphrase(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Setenv(filePathEnv, filepath.Join(dir, "keychain.enc"))
+	t.Setenv(filePassphraseEnv, "")
+
+	// The passphrase is required.
+	_, err := newFileHelper("")
+	require.ErrorIs(t, err, ErrNoPassphrase)
+
+	// It can be read from a file, e.g. a container secret.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "passphrase"), []byte("passphrase\n"), 0o600))
+	t.Setenv(filePassphraseFileEnv, filepath.Join(dir, "passphrase"))
+
+	helper, err := newFileHelper("")
+	require.NoError(t, err)
+	require.NoError(t, helper.Add(&credentials.Credentials{ServerURL: "url", Username: "user", Secret: "secret"}))
+
+	// The passphrase of the file is the same as given directly.
+	t.Setenv(filePassphraseEnv, "passphrase")
+
+	helper, err = newFileHelper("")
+	require.NoError(t, err)
+
+	_, secret, err := helper.Get("url")
+	require.NoError(t, err)
+	require.Equal(t, "secret", secret)
+
+	// Another passphrase can't decrypt the file.
+	t.Setenv(filePassphraseEnv, "wrong")
+
+	helper, err = newFileHelper("")
+	require.NoError(t, err)
+
+	_, err = helper.List()
+	require.ErrorIs(t, err, ErrWrongPassphrase)
+}
+
+func TestFileHelper_InvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keychain.enc")
+
+	t.Setenv(filePathEnv, path)
+	t.Setenv(filePassphraseEnv, "passphrase")
+
+	helper, err := newFileHelper("")
+	require.NoError(t, err)
+	require.NoError(t, helper.Add(&credentials.Credentials{ServerURL: "url", Username: "user", Secret: "secret"}))
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var valid encryptedFile
+
+	require.NoError(t, json.Unmarshal(b, &valid))
+
+	// Parameters which would crash or exhaust the process are rejected before deriving the key.
+	for name, modify := range map[string]func(*encryptedFile){
+		"no time":      func(file *encryptedFile) { file.Time = 0 },
+		"no threads":   func(file *encryptedFile) { file.Threads = 0 },
+		"huge memory":  func(file *encryptedFile) { file.Memory = 1 << 31 },
+		"no salt":      func(file *encryptedFile) { file.Salt = nil },
+		"short nonce":  func(file *encryptedFile) { file.Nonce = file.Nonce[:4] },
+		"many threads": func(file *encryptedFile) { file.Threads = 255 },
+	} {
+		t.Run(name, func(t *testing.T) {
+			file := valid
+			modify(&file)
+
+			b, err := json.Marshal(file)
+			require.NoError(t, err)
+			require.NoError(t, os.WriteFile(path, b, 0o600))
+
+			helper, err := newFileHelper("")
+			require.NoError(t, err)
+
+			_, err = helper.List()
+			require.ErrorIs(t, err, ErrInvalidFile)
+		})
+	}
+}
+
+func must[T any](val T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+
+	return val
+}
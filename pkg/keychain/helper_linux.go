@@ -52,11 +52,20 @@ func init() { //nolint:gochecknoinits
 
 	// If Pass is available, use it by default.
 	// Otherwise, if SecretService is available, use it by default.
+	// Headless servers without any of them use the encrypted file if its passphrase is configured.
 	if _, ok := Helpers[Pass]; ok {
 		DefaultHelper = Pass
 	} else if _, ok := Helpers[SecretService]; ok {
 		DefaultHelper = SecretService
+	} else if _, ok := Helpers[SecretServiceDBus]; !ok && hasFilePassphrase() {
+		DefaultHelper = EncryptedFile
 	}
+
+	// External secret managers are available if configured.
+	addSecretProviders()
+
+	// The encrypted file is always available.
+	addFileHelper()
 }
 
 func newDBusHelper(string) (credentials.Helper, error) {
@@ -0,0 +1,107 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package keychain
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+
+	"github.com/docker/docker-credential-helpers/credentials"
+)
+
+// ErrNotConfirmed indicates that the user did not confirm the release of a secret.
+var ErrNotConfirmed = errors.New("the user did not confirm access to the keychain")
+
+// confirmingHelper asks the user to confirm, e.g. with biometrics, before releasing a secret of another helper.
+type confirmingHelper struct {
+	credentials.Helper
+
+	confirm func() error
+}
+
+func (h *confirmingHelper) Get(url string) (string, string, error) {
+	if err := h.confirm(); err != nil {
+		return "", "", err
+	}
+
+	return h.Helper.Get(url)
+}
+
+// protectedHelper stores secrets in another helper once protected, e.g. encrypted with an OS API.
+// Its entries are stored under a prefix, apart from the ones stored directly in the other helper.
+type protectedHelper struct {
+	helper credentials.Helper
+	prefix string
+
+	protect   func([]byte) ([]byte, error)
+	unprotect func([]byte) ([]byte, error)
+}
+
+func (h *protectedHelper) Add(creds *credentials.Credentials) error {
+	blob, err := h.protect([]byte(creds.Secret))
+	if err != nil {
+		return err
+	}
+
+	return h.helper.Add(&credentials.Credentials{
+		ServerURL: h.prefix + creds.ServerURL,
+		Username:  creds.Username,
+		Secret:    base64.StdEncoding.EncodeToString(blob),
+	})
+}
+
+func (h *protectedHelper) Delete(url string) error {
+	return h.helper.Delete(h.prefix + url)
+}
+
+func (h *protectedHelper) Get(url string) (string, string, error) {
+	username, secret, err := h.helper.Get(h.prefix + url)
+	if err != nil {
+		return "", "", err
+	}
+
+	blob, err := base64.StdEncoding.DecodeString(secret)
+	if err != nil {
+		return "", "", err
+	}
+
+	dec, err := h.unprotect(blob)
+	if err != nil {
+		return "", "", err
+	}
+
+	return username, string(dec), nil
+}
+
+func (h *protectedHelper) List() (map[string]string, error) {
+	list, err := h.helper.List()
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[string]string)
+
+	for url, username := range list {
+		if strings.HasPrefix(url, h.prefix) {
+			res[strings.TrimPrefix(url, h.prefix)] = username
+		}
+	}
+
+	return res, nil
+}
@@ -0,0 +1,95 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package keychain
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/docker/docker-credential-helpers/credentials"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestProtectedHelper(store TestHelper) *protectedHelper {
+	return &protectedHelper{
+		helper:    store,
+		prefix:    "test:",
+		protect:   func(b []byte) ([]byte, error) { return bytes.ToUpper(b), nil },
+		unprotect: func(b []byte) ([]byte, error) { return bytes.ToLower(b), nil },
+	}
+}
+
+func TestProtectedHelper(t *testing.T) {
+	store := NewTestHelper()
+
+	// A plain entry in the underlying helper must not be visible.
+	require.NoError(t, store.Add(&credentials.Credentials{ServerURL: "plain", Username: "user", Secret: "plain"}))
+
+	keychain := newKeychain(newTestProtectedHelper(store), hostURL("bridge"))
+
+	require.NoError(t, keychain.Put("user1", "secret"))
+
+	// The secret must be stored protected.
+	require.NotContains(t, store[testProtectedURL(keychain, "user1")].Secret, "secret")
+
+	list, err := keychain.List()
+	require.NoError(t, err)
+	require.Equal(t, []string{"user1"}, list)
+
+	_, secret, err := keychain.Get("user1")
+	require.NoError(t, err)
+	require.Equal(t, "secret", secret)
+
+	require.NoError(t, keychain.Delete("user1"))
+
+	list, err = keychain.List()
+	require.NoError(t, err)
+	require.Empty(t, list)
+}
+
+func TestConfirmingHelper(t *testing.T) {
+	var confirmed bool
+
+	keychain := newKeychain(&confirmingHelper{
+		Helper: newTestProtectedHelper(NewTestHelper()),
+		confirm: func() error {
+			if !confirmed {
+				return ErrNotConfirmed
+			}
+
+			return nil
+		},
+	}, hostURL("bridge"))
+
+	require.NoError(t, keychain.Put("user1", "secret"))
+
+	// The secret is not released without confirmation.
+	_, _, err := keychain.Get("user1")
+	require.True(t, errors.Is(err, ErrNotConfirmed))
+
+	confirmed = true
+
+	_, secret, err := keychain.Get("user1")
+	require.NoError(t, err)
+	require.Equal(t, "secret", secret)
+}
+
+func testProtectedURL(kc *Keychain, userID string) string {
+	return "test:" + kc.secretURL(userID)
+}
@@ -0,0 +1,218 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package keychain
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/docker/docker-credential-helpers/credentials"
+)
+
+const (
+	HashiCorpVault    = "hashicorp-vault"
+	AWSSecretsManager = "aws-secrets-manager"
+)
+
+const (
+	// defaultProviderCacheTTL is how long secrets fetched from a secret manager are cached.
+	// It bounds how long it takes for a secret rotated in the secret manager to be used.
+	defaultProviderCacheTTL = 5 * time.Minute
+
+	// providerTimeout bounds each request to a secret manager.
+	providerTimeout = 30 * time.Second
+)
+
+// ErrSecretNotFound is returned by secret providers when the requested secret doesn't exist.
+var ErrSecretNotFound = errors.New("secret not found")
+
+// SecretProvider is an external secret manager, e.g. for server deployments.
+// Secrets are identified by slash-separated names.
+type SecretProvider interface {
+	// Get returns the current version of the secret, or the one before it if previous is set.
+	// It returns ErrNoPreviousVersion if there is no such version.
+	Get(ctx context.Context, name string, previous bool) (string, error)
+
+	// Put stores a new version of the secret.
+	Put(ctx context.Context, name, value string) error
+
+	// Delete removes the secret with all its versions.
+	Delete(ctx context.Context, name string) error
+
+	// List returns the names of the secrets directly under the given prefix.
+	List(ctx context.Context, prefix string) ([]string, error)
+}
+
+// addSecretProviders registers the helpers of the external secret managers configured in the environment.
+func addSecretProviders() {
+	if _, ok := os.LookupEnv(vaultAddrEnv); ok {
+		Helpers[HashiCorpVault] = newProviderHelperConstructor(newHashiCorpVaultProvider)
+	}
+
+	if _, ok := os.LookupEnv(awsAccessKeyIDEnv); ok && getAWSRegion() != "" {
+		Helpers[AWSSecretsManager] = newProviderHelperConstructor(newAWSSecretsManagerProvider)
+	}
+}
+
+func newProviderHelperConstructor(newProvider func() (SecretProvider, error)) helperConstructor {
+	return func(url string) (credentials.Helper, error) {
+		provider, err := newProvider()
+		if err != nil {
+			return nil, err
+		}
+
+		return newProviderHelper(provider, url, getProviderCacheTTL()), nil
+	}
+}
+
+func getProviderCacheTTL() time.Duration {
+	if ttl, err := time.ParseDuration(os.Getenv("BRIDGE_SECRET_CACHE_TTL")); err == nil {
+		return ttl
+	}
+
+	return defaultProviderCacheTTL
+}
+
+// providerHelper stores secrets in an external secret manager, caching them for a while.
+type providerHelper struct {
+	provider SecretProvider
+	url      string
+	ttl      time.Duration
+
+	cache     map[string]cachedSecret
+	cacheLock sync.Mutex
+}
+
+type cachedSecret struct {
+	secret    string
+	fetchedAt time.Time
+}
+
+func newProviderHelper(provider SecretProvider, url string, ttl time.Duration) *providerHelper {
+	return &providerHelper{
+		provider: provider,
+		url:      url,
+		ttl:      ttl,
+		cache:    make(map[string]cachedSecret),
+	}
+}
+
+func (h *providerHelper) Add(creds *credentials.Credentials) error {
+	ctx, cancel := context.WithTimeout(context.Background(), providerTimeout)
+	defer cancel()
+
+	if err := h.provider.Put(ctx, creds.ServerURL, creds.Secret); err != nil {
+		return err
+	}
+
+	h.setCached(creds.ServerURL, creds.Secret)
+
+	return nil
+}
+
+func (h *providerHelper) Delete(url string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), providerTimeout)
+	defer cancel()
+
+	h.cacheLock.Lock()
+	delete(h.cache, url)
+	h.cacheLock.Unlock()
+
+	return h.provider.Delete(ctx, url)
+}
+
+func (h *providerHelper) Get(url string) (string, string, error) {
+	if secret, ok := h.getCached(url); ok {
+		return path.Base(url), secret, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), providerTimeout)
+	defer cancel()
+
+	secret, err := h.provider.Get(ctx, url, false)
+	if err != nil {
+		return "", "", err
+	}
+
+	h.setCached(url, secret)
+
+	return path.Base(url), secret, nil
+}
+
+// GetPrevious returns the version of the secret before it was last rotated. It is never cached.
+func (h *providerHelper) GetPrevious(url string) (string, string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), providerTimeout)
+	defer cancel()
+
+	secret, err := h.provider.Get(ctx, url, true)
+	if err != nil {
+		return "", "", err
+	}
+
+	return path.Base(url), secret, nil
+}
+
+func (h *providerHelper) List() (map[string]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), providerTimeout)
+	defer cancel()
+
+	names, err := h.provider.List(ctx, h.url)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[string]string)
+
+	for _, name := range names {
+		res[name] = path.Base(name)
+	}
+
+	return res, nil
+}
+
+func (h *providerHelper) getCached(url string) (string, bool) {
+	h.cacheLock.Lock()
+	defer h.cacheLock.Unlock()
+
+	cached, ok := h.cache[url]
+	if !ok {
+		return "", false
+	}
+
+	if time.Since(cached.fetchedAt) > h.ttl {
+		delete(h.cache, url)
+		return "", false
+	}
+
+	return cached.secret, true
+}
+
+func (h *providerHelper) setCached(url, secret string) {
+	h.cacheLock.Lock()
+	defer h.cacheLock.Unlock()
+
+	if h.ttl <= 0 {
+		return
+	}
+
+	h.cache[url] = cachedSecret{secret: secret, fetchedAt: time.Now()}
+}
@@ -0,0 +1,167 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package keychain
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testProvider keeps all versions of its secrets in memory.
+type testProvider struct {
+	secrets map[string][]string
+	gets    int
+}
+
+func newTestProvider() *testProvider {
+	return &testProvider{secrets: make(map[string][]string)}
+}
+
+func (p *testProvider) Get(_ context.Context, name string, previous bool) (string, error) {
+	p.gets++
+
+	versions, ok := p.secrets[name]
+	if !ok {
+		return "", ErrSecretNotFound
+	}
+
+	if previous {
+		if len(versions) < 2 {
+			return "", ErrNoPreviousVersion
+		}
+
+		return versions[len(versions)-2], nil
+	}
+
+	return versions[len(versions)-1], nil
+}
+
+func (p *testProvider) Put(_ context.Context, name, value string) error {
+	p.secrets[name] = append(p.secrets[name], value)
+	return nil
+}
+
+func (p *testProvider) Delete(_ context.Context, name string) error {
+	delete(p.secrets, name)
+	return nil
+}
+
+func (p *testProvider) List(_ context.Context, prefix string) ([]string, error) {
+	var names []string
+
+	for name := range p.secrets {
+		if strings.HasPrefix(name, prefix+"/") {
+			names = append(names, name)
+		}
+	}
+
+	return names, nil
+}
+
+func TestProviderHelper(t *testing.T) {
+	provider := newTestProvider()
+
+	keychain := newKeychain(newProviderHelper(provider, hostURL("bridge"), 0), hostURL("bridge"))
+
+	testSecretProvider(t, keychain)
+
+	// Without cache, each read hits the provider.
+	require.Equal(t, 3, provider.gets)
+}
+
+func TestProviderHelper_Cache(t *testing.T) {
+	provider := newTestProvider()
+
+	helper := newProviderHelper(provider, hostURL("bridge"), time.Hour)
+	keychain := newKeychain(helper, hostURL("bridge"))
+
+	require.NoError(t, keychain.Put("user1", "secret1"))
+
+	// The secret just written is cached.
+	_, secret, err := keychain.Get("user1")
+	require.NoError(t, err)
+	require.Equal(t, "secret1", secret)
+	require.Zero(t, provider.gets)
+
+	// A secret rotated in the provider is only seen once the cached one expires.
+	require.NoError(t, provider.Put(context.Background(), keychain.secretURL("user1"), "secret2"))
+
+	_, secret, err = keychain.Get("user1")
+	require.NoError(t, err)
+	require.Equal(t, "secret1", secret)
+
+	helper.ttl = 0
+
+	_, secret, err = keychain.Get("user1")
+	require.NoError(t, err)
+	require.Equal(t, "secret2", secret)
+	require.Equal(t, 1, provider.gets)
+
+	// The previous version is always read from the provider.
+	_, secret, err = keychain.GetPrevious("user1")
+	require.NoError(t, err)
+	require.Equal(t, "secret1", secret)
+}
+
+func TestKeychain_GetPrevious_Unsupported(t *testing.T) {
+	keychain := newKeychain(NewTestHelper(), hostURL("bridge"))
+
+	require.NoError(t, keychain.Put("user1", "secret"))
+
+	_, _, err := keychain.GetPrevious("user1")
+	require.ErrorIs(t, err, ErrNoPreviousVersion)
+}
+
+// testSecretProvider checks the behaviour of a keychain backed by a secret provider.
+func testSecretProvider(t *testing.T, keychain *Keychain) {
+	list, err := keychain.List()
+	require.NoError(t, err)
+	require.Empty(t, list)
+
+	require.NoError(t, keychain.Put("user1", "secret1"))
+	require.NoError(t, keychain.Put("user2", "other"))
+
+	list, err = keychain.List()
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"user1", "user2"}, list)
+
+	// There is no previous version until the secret is rotated.
+	_, _, err = keychain.GetPrevious("user1")
+	require.ErrorIs(t, err, ErrNoPreviousVersion)
+
+	require.NoError(t, keychain.Put("user1", "secret2"))
+
+	userID, secret, err := keychain.Get("user1")
+	require.NoError(t, err)
+	require.Equal(t, "user1", userID)
+	require.Equal(t, "secret2", secret)
+
+	_, secret, err = keychain.GetPrevious("user1")
+	require.NoError(t, err)
+	require.Equal(t, "secret1", secret)
+
+	require.NoError(t, keychain.Delete("user1"))
+
+	list, err = keychain.List()
+	require.NoError(t, err)
+	require.Equal(t, []string{"user2"}, list)
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package keychain
+
+/*
+#cgo CFLAGS: -x objective-c -fobjc-arc
+#cgo LDFLAGS: -framework Foundation -framework LocalAuthentication
+
+#include <stdlib.h>
+
+#import <Foundation/Foundation.h>
+#import <LocalAuthentication/LocalAuthentication.h>
+
+enum { touchIDVerified, touchIDUnavailable, touchIDDenied };
+
+// evaluateTouchID asks the user to confirm with Touch ID or a paired Apple Watch and waits for the answer.
+static int evaluateTouchID(const char *reason) {
+	@autoreleasepool {
+		LAContext *context = [[LAContext alloc] init];
+		LAPolicy policy = LAPolicyDeviceOwnerAuthenticationWithBiometricsOrWatch;
+
+		if (![context canEvaluatePolicy:policy error:nil]) {
+			return touchIDUnavailable;
+		}
+
+		__block BOOL verified = NO;
+		dispatch_semaphore_t sema = dispatch_semaphore_create(0);
+
+		[context evaluatePolicy:policy localizedReason:[NSString stringWithUTF8String:reason] reply:^(BOOL success, NSError *error) {
+			verified = success;
+			dispatch_semaphore_signal(sema);
+		}];
+
+		dispatch_semaphore_wait(sema, DISPATCH_TIME_FOREVER);
+
+		return verified ? touchIDVerified : touchIDDenied;
+	}
+}
+*/
+import "C"
+
+import (
+	"unsafe"
+
+	"github.com/sirupsen/logrus"
+)
+
+// touchIDReason completes the sentence "Proton Mail Bridge is trying to ..." shown by the system prompt.
+const touchIDReason = "unlock its vault"
+
+// confirmWithTouchID asks the user to confirm with Touch ID or Apple Watch.
+// If neither is available, e.g. on a Mac without a sensor, the secret is released without confirmation.
+func confirmWithTouchID() error {
+	reason := C.CString(touchIDReason)
+	defer C.free(unsafe.Pointer(reason))
+
+	switch C.evaluateTouchID(reason) {
+	case C.touchIDVerified:
+		return nil
+
+	case C.touchIDUnavailable:
+		logrus.WithField("pkg", "keychain/darwin").Warn("Touch ID is not available, skipping confirmation")
+		return nil
+
+	default:
+		return ErrNotConfirmed
+	}
+}
@@ -30,8 +30,18 @@ func init() { //nolint:gochecknoinits
 	// Windows always provides a keychain.
 	Helpers[WindowsCredentials] = newWinCredHelper
 
+	// The Credential Manager can also hold secrets encrypted with DPAPI, optionally released after Windows Hello verification.
+	Helpers[WindowsDPAPI] = newDPAPIHelper
+	Helpers[WindowsDPAPIHello] = newDPAPIHelloHelper
+
 	// Use WindowsCredentials by default.
 	DefaultHelper = WindowsCredentials
+
+	// External secret managers are available if configured.
+	addSecretProviders()
+
+	// The encrypted file is always available.
+	addFileHelper()
 }
 
 func newWinCredHelper(string) (credentials.Helper, error) {
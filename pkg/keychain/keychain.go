@@ -26,8 +26,13 @@ import (
 	"github.com/docker/docker-credential-helpers/credentials"
 )
 
-// helperConstructor constructs a keychain helperConstructor.
-type helperConstructor func(string) (credentials.Helper, error)
+// helperConstructor constructs a keychain helper, the backend storing its secrets.
+type helperConstructor func(string) (Backend, error)
+
+// versionedHelper is implemented by helpers keeping the previous version of rotated secrets.
+type versionedHelper interface {
+	GetPrevious(url string) (string, string, error)
+}
 
 // Version is the keychain data version.
 const Version = "k11"
@@ -39,6 +44,9 @@ var (
 	// ErrMacKeychainRebuild is returned on macOS with blocked or corrupted keychain.
 	ErrMacKeychainRebuild = errors.New("keychain error -25293")
 
+	// ErrNoPreviousVersion is returned when the keychain doesn't keep a previous version of a secret.
+	ErrNoPreviousVersion = errors.New("no previous version of the secret")
+
 	// Helpers holds all discovered keychain helpers. It is populated in init().
 	Helpers map[string]helperConstructor //nolint:gochecknoglobals
 
@@ -148,6 +156,20 @@ func (kc *Keychain) Get(userID string) (string, string, error) {
 	return kc.helper.Get(kc.secretURL(userID))
 }
 
+// GetPrevious returns the username and secret for the given userID as they were before the secret was last rotated.
+// It returns ErrNoPreviousVersion if the keychain doesn't keep versions of its secrets.
+func (kc *Keychain) GetPrevious(userID string) (string, string, error) {
+	kc.locker.Lock()
+	defer kc.locker.Unlock()
+
+	helper, ok := kc.helper.(versionedHelper)
+	if !ok {
+		return "", "", ErrNoPreviousVersion
+	}
+
+	return helper.GetPrevious(kc.secretURL(userID))
+}
+
 func (kc *Keychain) Put(userID, secret string) error {
 	kc.locker.Lock()
 	defer kc.locker.Unlock()
@@ -0,0 +1,339 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package keychain
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	awsRegionEnv          = "AWS_REGION"
+	awsDefaultRegionEnv   = "AWS_DEFAULT_REGION"
+	awsAccessKeyIDEnv     = "AWS_ACCESS_KEY_ID"
+	awsSecretAccessKeyEnv = "AWS_SECRET_ACCESS_KEY" //nolint:gosec
+	awsSessionTokenEnv    = "AWS_SESSION_TOKEN"     //nolint:gosec
+	awsEndpointEnv        = "AWS_ENDPOINT_URL_SECRETS_MANAGER"
+)
+
+const (
+	awsService    = "secretsmanager"
+	awsAlgorithm  = "AWS4-HMAC-SHA256"
+	awsTimeFormat = "20060102T150405Z"
+	awsDateFormat = "20060102"
+
+	awsResourceNotFound = "ResourceNotFoundException"
+)
+
+// awsSecretsManagerProvider stores secrets in AWS Secrets Manager.
+// Requests are signed with the credentials found in the environment.
+type awsSecretsManagerProvider struct {
+	region   string
+	endpoint string
+
+	client *http.Client
+	now    func() time.Time
+}
+
+// awsError is an error returned by the AWS API.
+type awsError struct {
+	Type    string `json:"__type"`
+	Message string `json:"message"`
+}
+
+func (err *awsError) Error() string {
+	return fmt.Sprintf("%v: %v", err.Type, err.Message)
+}
+
+func getAWSRegion() string {
+	if region := os.Getenv(awsRegionEnv); region != "" {
+		return region
+	}
+
+	return os.Getenv(awsDefaultRegionEnv)
+}
+
+func newAWSSecretsManagerProvider() (SecretProvider, error) {
+	provider := &awsSecretsManagerProvider{
+		region:   getAWSRegion(),
+		endpoint: strings.TrimRight(os.Getenv(awsEndpointEnv), "/"),
+		client:   &http.Client{},
+		now:      time.Now,
+	}
+
+	if provider.region == "" {
+		return nil, fmt.Errorf("neither %v nor %v is set", awsRegionEnv, awsDefaultRegionEnv)
+	}
+
+	if provider.endpoint == "" {
+		provider.endpoint = fmt.Sprintf("https://%v.%v.amazonaws.com", awsService, provider.region)
+	}
+
+	return provider, nil
+}
+
+func (p *awsSecretsManagerProvider) Get(ctx context.Context, name string, previous bool) (string, error) {
+	stage := "AWSCURRENT"
+
+	if previous {
+		stage = "AWSPREVIOUS"
+	}
+
+	var res struct {
+		SecretString string
+	}
+
+	if err := p.do(ctx, "GetSecretValue", map[string]any{"SecretId": name, "VersionStage": stage}, &res); err != nil {
+		if isAWSError(err, awsResourceNotFound) {
+			if previous {
+				return "", ErrNoPreviousVersion
+			}
+
+			return "", ErrSecretNotFound
+		}
+
+		return "", err
+	}
+
+	return res.SecretString, nil
+}
+
+func (p *awsSecretsManagerProvider) Put(ctx context.Context, name, value string) error {
+	err := p.do(ctx, "PutSecretValue", map[string]any{"SecretId": name, "SecretString": value}, nil)
+	if !isAWSError(err, awsResourceNotFound) {
+		return err
+	}
+
+	return p.do(ctx, "CreateSecret", map[string]any{"Name": name, "SecretString": value}, nil)
+}
+
+func (p *awsSecretsManagerProvider) Delete(ctx context.Context, name string) error {
+	// Skip the recovery window so that the secret can be created again right away.
+	if err := p.do(ctx, "DeleteSecret", map[string]any{"SecretId": name, "ForceDeleteWithoutRecovery": true}, nil); err != nil && !isAWSError(err, awsResourceNotFound) {
+		return err
+	}
+
+	return nil
+}
+
+func (p *awsSecretsManagerProvider) List(ctx context.Context, prefix string) ([]string, error) {
+	prefix = strings.TrimSuffix(prefix, "/") + "/"
+
+	var names []string
+
+	for token := ""; ; {
+		req := map[string]any{
+			"Filters": []map[string]any{{"Key": "name", "Values": []string{prefix}}},
+		}
+
+		if token != "" {
+			req["NextToken"] = token
+		}
+
+		var res struct {
+			SecretList []struct {
+				Name string
+			}
+			NextToken string
+		}
+
+		if err := p.do(ctx, "ListSecrets", req, &res); err != nil {
+			return nil, err
+		}
+
+		for _, secret := range res.SecretList {
+			// The filter matches on prefix; only keep the secrets directly under it.
+			if rest := strings.TrimPrefix(secret.Name, prefix); rest != secret.Name && rest != "" && !strings.Contains(rest, "/") {
+				names = append(names, secret.Name)
+			}
+		}
+
+		if token = res.NextToken; token == "" {
+			return names, nil
+		}
+	}
+}
+
+func (p *awsSecretsManagerProvider) do(ctx context.Context, action string, req, res any) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint+"/", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	httpReq.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	httpReq.Header.Set("X-Amz-Target", "secretsmanager."+action)
+
+	if err := p.sign(httpReq, body); err != nil {
+		return err
+	}
+
+	httpRes, err := p.client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("secrets manager request failed: %w", err)
+	}
+	defer func() { _ = httpRes.Body.Close() }()
+
+	resBody, err := io.ReadAll(httpRes.Body)
+	if err != nil {
+		return err
+	}
+
+	if httpRes.StatusCode != http.StatusOK {
+		var apiErr awsError
+
+		if err := json.Unmarshal(resBody, &apiErr); err != nil || apiErr.Type == "" {
+			return fmt.Errorf("secrets manager request failed with status %v", httpRes.StatusCode)
+		}
+
+		// The type may be prefixed with a namespace, e.g. "com.amazonaws.secretsmanager#ResourceNotFoundException".
+		apiErr.Type = apiErr.Type[strings.LastIndex(apiErr.Type, "#")+1:]
+
+		return &apiErr
+	}
+
+	if res == nil {
+		return nil
+	}
+
+	return json.Unmarshal(resBody, res)
+}
+
+// sign signs the request with AWS Signature Version 4.
+// The credentials are read on each request so that rotated credentials are picked up.
+func (p *awsSecretsManagerProvider) sign(req *http.Request, body []byte) error {
+	accessKeyID, secretAccessKey := os.Getenv(awsAccessKeyIDEnv), os.Getenv(awsSecretAccessKeyEnv)
+
+	if accessKeyID == "" || secretAccessKey == "" {
+		return fmt.Errorf("%v and %v must be set", awsAccessKeyIDEnv, awsSecretAccessKeyEnv)
+	}
+
+	now := p.now().UTC()
+
+	req.Header.Set("Host", req.URL.Host)
+	req.Header.Set("X-Amz-Date", now.Format(awsTimeFormat))
+
+	if token := os.Getenv(awsSessionTokenEnv); token != "" {
+		req.Header.Set("X-Amz-Security-Token", token)
+	}
+
+	scope := strings.Join([]string{now.Format(awsDateFormat), p.region, awsService, "aws4_request"}, "/")
+
+	canonicalHeaders, signedHeaders := getCanonicalHeaders(req.Header)
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		getCanonicalPath(req.URL),
+		req.URL.Query().Encode(),
+		canonicalHeaders,
+		signedHeaders,
+		hashHex(body),
+	}, "\n")
+
+	stringToSign := strings.Join([]string{
+		awsAlgorithm,
+		now.Format(awsTimeFormat),
+		scope,
+		hashHex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := getAWSSigningKey(secretAccessKey, now.Format(awsDateFormat), p.region, awsService)
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"%v Credential=%v/%v, SignedHeaders=%v, Signature=%v",
+		awsAlgorithm, accessKeyID, scope, signedHeaders, hex.EncodeToString(hmacSHA256(key, []byte(stringToSign))),
+	))
+
+	return nil
+}
+
+func getCanonicalPath(u *url.URL) string {
+	if path := u.EscapedPath(); path != "" {
+		return path
+	}
+
+	return "/"
+}
+
+func getCanonicalHeaders(header http.Header) (string, string) {
+	names := make([]string, 0, len(header))
+
+	for name := range header {
+		names = append(names, strings.ToLower(name))
+	}
+
+	sort.Strings(names)
+
+	var canonical strings.Builder
+
+	for _, name := range names {
+		values := header.Values(name)
+
+		for i := range values {
+			values[i] = strings.TrimSpace(values[i])
+		}
+
+		canonical.WriteString(name + ":" + strings.Join(values, ",") + "\n")
+	}
+
+	return canonical.String(), strings.Join(names, ";")
+}
+
+func getAWSSigningKey(secretAccessKey, date, region, service string) []byte {
+	key := hmacSHA256([]byte("AWS4"+secretAccessKey), []byte(date))
+	key = hmacSHA256(key, []byte(region))
+	key = hmacSHA256(key, []byte(service))
+
+	return hmacSHA256(key, []byte("aws4_request"))
+}
+
+func hmacSHA256(key, data []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+
+	return mac.Sum(nil)
+}
+
+func hashHex(data []byte) string {
+	hash := sha256.Sum256(data)
+
+	return hex.EncodeToString(hash[:])
+}
+
+func isAWSError(err error, errType string) bool {
+	var apiErr *awsError
+
+	return errors.As(err, &apiErr) && apiErr.Type == errType
+}
@@ -0,0 +1,258 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package keychain
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	vaultAddrEnv      = "VAULT_ADDR"
+	vaultTokenEnv     = "VAULT_TOKEN"
+	vaultTokenFileEnv = "VAULT_TOKEN_FILE"
+	vaultNamespaceEnv = "VAULT_NAMESPACE"
+	vaultMountEnv     = "BRIDGE_VAULT_KV_MOUNT"
+)
+
+// defaultVaultMount is the mount path of the KV version 2 secrets engine used when none is configured.
+const defaultVaultMount = "secret"
+
+// vaultSecretKey is the key under which the secret is stored in the KV entry.
+const vaultSecretKey = "secret"
+
+// hashiCorpVaultProvider stores secrets in the KV version 2 secrets engine of a HashiCorp Vault server.
+type hashiCorpVaultProvider struct {
+	addr      string
+	mount     string
+	namespace string
+
+	token     string
+	tokenFile string
+
+	client *http.Client
+}
+
+func newHashiCorpVaultProvider() (SecretProvider, error) {
+	provider := &hashiCorpVaultProvider{
+		addr:      strings.TrimRight(os.Getenv(vaultAddrEnv), "/"),
+		mount:     strings.Trim(os.Getenv(vaultMountEnv), "/"),
+		namespace: os.Getenv(vaultNamespaceEnv),
+		token:     os.Getenv(vaultTokenEnv),
+		tokenFile: os.Getenv(vaultTokenFileEnv),
+		client:    &http.Client{},
+	}
+
+	if provider.addr == "" {
+		return nil, fmt.Errorf("%v is not set", vaultAddrEnv)
+	}
+
+	if provider.token == "" && provider.tokenFile == "" {
+		return nil, fmt.Errorf("neither %v nor %v is set", vaultTokenEnv, vaultTokenFileEnv)
+	}
+
+	if provider.mount == "" {
+		provider.mount = defaultVaultMount
+	}
+
+	return provider, nil
+}
+
+func (p *hashiCorpVaultProvider) Get(ctx context.Context, name string, previous bool) (string, error) {
+	query := url.Values{}
+
+	if previous {
+		version, err := p.getCurrentVersion(ctx, name)
+		if err != nil {
+			return "", err
+		}
+
+		if version <= 1 {
+			return "", ErrNoPreviousVersion
+		}
+
+		query.Set("version", strconv.Itoa(version-1))
+	}
+
+	var res struct {
+		Data struct {
+			Data map[string]string `json:"data"`
+		} `json:"data"`
+	}
+
+	if err := p.do(ctx, http.MethodGet, "data/"+name, query, nil, &res); err != nil {
+		if previous && errors.Is(err, ErrSecretNotFound) {
+			return "", ErrNoPreviousVersion
+		}
+
+		return "", err
+	}
+
+	secret, ok := res.Data.Data[vaultSecretKey]
+	if !ok {
+		return "", ErrSecretNotFound
+	}
+
+	return secret, nil
+}
+
+func (p *hashiCorpVaultProvider) Put(ctx context.Context, name, value string) error {
+	req := map[string]any{"data": map[string]string{vaultSecretKey: value}}
+
+	return p.do(ctx, http.MethodPost, "data/"+name, nil, req, nil)
+}
+
+func (p *hashiCorpVaultProvider) Delete(ctx context.Context, name string) error {
+	if err := p.do(ctx, http.MethodDelete, "metadata/"+name, nil, nil, nil); err != nil && !errors.Is(err, ErrSecretNotFound) {
+		return err
+	}
+
+	return nil
+}
+
+func (p *hashiCorpVaultProvider) List(ctx context.Context, prefix string) ([]string, error) {
+	var res struct {
+		Data struct {
+			Keys []string `json:"keys"`
+		} `json:"data"`
+	}
+
+	if err := p.do(ctx, "LIST", "metadata/"+prefix, nil, nil, &res); err != nil {
+		if errors.Is(err, ErrSecretNotFound) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	var names []string //nolint:prealloc
+
+	for _, key := range res.Data.Keys {
+		// Keys ending with a slash are folders, not secrets.
+		if strings.HasSuffix(key, "/") {
+			continue
+		}
+
+		names = append(names, strings.TrimSuffix(prefix, "/")+"/"+key)
+	}
+
+	return names, nil
+}
+
+func (p *hashiCorpVaultProvider) getCurrentVersion(ctx context.Context, name string) (int, error) {
+	var res struct {
+		Data struct {
+			CurrentVersion int `json:"current_version"`
+		} `json:"data"`
+	}
+
+	if err := p.do(ctx, http.MethodGet, "metadata/"+name, nil, nil, &res); err != nil {
+		return 0, err
+	}
+
+	return res.Data.CurrentVersion, nil
+}
+
+// getToken returns the token to authenticate with.
+// The token file is read on each request so that a token renewed by e.g. Vault Agent is picked up.
+func (p *hashiCorpVaultProvider) getToken() (string, error) {
+	if p.tokenFile == "" {
+		return p.token, nil
+	}
+
+	b, err := os.ReadFile(p.tokenFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read vault token: %w", err)
+	}
+
+	return strings.TrimSpace(string(b)), nil
+}
+
+func (p *hashiCorpVaultProvider) do(ctx context.Context, method, path string, query url.Values, req, res any) error {
+	token, err := p.getToken()
+	if err != nil {
+		return err
+	}
+
+	var body io.Reader
+
+	if req != nil {
+		b, err := json.Marshal(req)
+		if err != nil {
+			return err
+		}
+
+		body = bytes.NewReader(b)
+	}
+
+	reqURL := fmt.Sprintf("%v/v1/%v/%v", p.addr, p.mount, path)
+
+	if len(query) > 0 {
+		reqURL += "?" + query.Encode()
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, method, reqURL, body)
+	if err != nil {
+		return err
+	}
+
+	httpReq.Header.Set("X-Vault-Token", token)
+
+	if p.namespace != "" {
+		httpReq.Header.Set("X-Vault-Namespace", p.namespace)
+	}
+
+	if req != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+
+	httpRes, err := p.client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("vault request failed: %w", err)
+	}
+	defer func() { _ = httpRes.Body.Close() }()
+
+	if httpRes.StatusCode == http.StatusNotFound {
+		return ErrSecretNotFound
+	}
+
+	if httpRes.StatusCode < 200 || httpRes.StatusCode >= 300 {
+		var errRes struct {
+			Errors []string `json:"errors"`
+		}
+
+		_ = json.NewDecoder(httpRes.Body).Decode(&errRes)
+
+		return fmt.Errorf("vault request failed with status %v: %v", httpRes.StatusCode, strings.Join(errRes.Errors, "; "))
+	}
+
+	if res == nil || httpRes.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
+	return json.NewDecoder(httpRes.Body).Decode(res)
+}
@@ -0,0 +1,228 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package keychain
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashiCorpVaultProvider(t *testing.T) {
+	server := httptest.NewServer(newTestVaultServer(t, "token"))
+	defer server.Close()
+
+	t.Setenv(vaultAddrEnv, server.URL)
+	t.Setenv(vaultTokenEnv, "token")
+
+	provider, err := newHashiCorpVaultProvider()
+	require.NoError(t, err)
+
+	testSecretProvider(t, newKeychain(newProviderHelper(provider, hostURL("bridge"), 0), hostURL("bridge")))
+}
+
+func TestAWSSecretsManagerProvider(t *testing.T) {
+	server := httptest.NewServer(newTestSecretsManagerServer(t, "AKID"))
+	defer server.Close()
+
+	t.Setenv(awsRegionEnv, "us-east-1")
+	t.Setenv(awsAccessKeyIDEnv, "AKID")
+	t.Setenv(awsSecretAccessKeyEnv, "secret")
+	t.Setenv(awsEndpointEnv, server.URL)
+
+	provider, err := newAWSSecretsManagerProvider()
+	require.NoError(t, err)
+
+	testSecretProvider(t, newKeychain(newProviderHelper(provider, hostURL("bridge"), 0), hostURL("bridge")))
+}
+
+func TestAWSSigningKey(t *testing.T) {
+	// Example from the AWS Signature Version 4 documentation.
+	key := getAWSSigningKey("wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "20150830", "us-east-1", "iam")
+
+	require.Equal(t, "c4afb1cc5771d871763a393e44b703571b55cc28424d1a5e86da6ed3c154a4b9", hex.EncodeToString(key))
+}
+
+// newTestVaultServer serves a minimal in-memory KV version 2 secrets engine mounted at "secret".
+func newTestVaultServer(t *testing.T, token string) http.Handler {
+	var lock sync.Mutex
+
+	secrets := make(map[string][]string)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		if r.Header.Get("X-Vault-Token") != token {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		kind, name, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/v1/secret/"), "/")
+		require.True(t, ok)
+
+		versions, exists := secrets[name]
+
+		switch {
+		case kind == "data" && r.Method == http.MethodPost:
+			var req struct {
+				Data map[string]string `json:"data"`
+			}
+
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+			secrets[name] = append(versions, req.Data[vaultSecretKey])
+
+		case kind == "data" && r.Method == http.MethodGet:
+			version := len(versions)
+
+			if v := r.URL.Query().Get("version"); v != "" {
+				version, _ = strconv.Atoi(v)
+			}
+
+			if !exists || version < 1 || version > len(versions) {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+
+			writeTestJSON(t, w, map[string]any{"data": map[string]any{"data": map[string]string{vaultSecretKey: versions[version-1]}}})
+
+		case kind == "metadata" && r.Method == http.MethodGet:
+			if !exists {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+
+			writeTestJSON(t, w, map[string]any{"data": map[string]any{"current_version": len(versions)}})
+
+		case kind == "metadata" && r.Method == http.MethodDelete:
+			delete(secrets, name)
+			w.WriteHeader(http.StatusNoContent)
+
+		case kind == "metadata" && r.Method == "LIST":
+			var keys []string
+
+			for secretName := range secrets {
+				if key := strings.TrimPrefix(secretName, name+"/"); key != secretName {
+					keys = append(keys, key)
+				}
+			}
+
+			if len(keys) == 0 {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+
+			writeTestJSON(t, w, map[string]any{"data": map[string]any{"keys": keys}})
+
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// newTestSecretsManagerServer serves a minimal in-memory AWS Secrets Manager.
+func newTestSecretsManagerServer(t *testing.T, accessKeyID string) http.Handler {
+	var lock sync.Mutex
+
+	secrets := make(map[string][]string)
+
+	notFound := func(w http.ResponseWriter) {
+		w.WriteHeader(http.StatusBadRequest)
+		writeTestJSON(t, w, map[string]string{"__type": awsResourceNotFound, "message": "not found"})
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		require.True(t, strings.HasPrefix(r.Header.Get("Authorization"), awsAlgorithm+" Credential="+accessKeyID+"/"))
+		require.Contains(t, r.Header.Get("Authorization"), "SignedHeaders=content-type;host;x-amz-date;x-amz-target,")
+
+		var req struct {
+			Name, SecretID, SecretString, VersionStage, NextToken string
+		}
+
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		if req.SecretID == "" {
+			req.SecretID = req.Name
+		}
+
+		versions, exists := secrets[req.SecretID]
+
+		switch strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "secretsmanager.") {
+		case "CreateSecret":
+			secrets[req.SecretID] = []string{req.SecretString}
+
+		case "PutSecretValue":
+			if !exists {
+				notFound(w)
+				return
+			}
+
+			secrets[req.SecretID] = append(versions, req.SecretString)
+
+		case "GetSecretValue":
+			idx := len(versions) - 1
+
+			if req.VersionStage == "AWSPREVIOUS" {
+				idx--
+			}
+
+			if idx < 0 {
+				notFound(w)
+				return
+			}
+
+			writeTestJSON(t, w, map[string]string{"SecretString": versions[idx]})
+
+		case "DeleteSecret":
+			if !exists {
+				notFound(w)
+				return
+			}
+
+			delete(secrets, req.SecretID)
+
+		case "ListSecrets":
+			list := []map[string]string{}
+
+			// Filtering is done by the client.
+			for name := range secrets {
+				list = append(list, map[string]string{"Name": name})
+			}
+
+			writeTestJSON(t, w, map[string]any{"SecretList": list})
+
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	})
+}
+
+func writeTestJSON(t *testing.T, w http.ResponseWriter, v any) {
+	require.NoError(t, json.NewEncoder(w).Encode(v))
+}
@@ -23,6 +23,7 @@ import (
 	"net/mail"
 	"strings"
 	"time"
+	"unicode"
 	"unicode/utf8"
 
 	"github.com/ProtonMail/gluon/rfc5322"
@@ -30,11 +31,13 @@ import (
 	"github.com/ProtonMail/go-proton-api"
 	"github.com/ProtonMail/gopenpgp/v2/crypto"
 	"github.com/ProtonMail/proton-bridge/v3/pkg/algo"
+	"github.com/ProtonMail/proton-bridge/v3/pkg/message/parser"
 	"github.com/bradenaw/juniper/xslices"
 	"github.com/emersion/go-message"
 	"github.com/emersion/go-message/textproto"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/exp/slices"
 )
 
 var (
@@ -45,6 +48,9 @@ var (
 // InternalIDDomain is used as a placeholder for reference/message ID headers to improve compatibility with various clients.
 const InternalIDDomain = `protonmail.internalid`
 
+// AuthServID identifies the Authentication-Results header fields generated by the bridge.
+const AuthServID = `protonmail.bridge`
+
 func BuildRFC822Into(kr *crypto.KeyRing, decrypted *DecryptedMessage, opts JobOptions, buf *bytes.Buffer) error {
 	switch {
 	case len(decrypted.Msg.Attachments) > 0:
@@ -67,14 +73,16 @@ func buildSimpleRFC822(decrypted *DecryptedMessage, opts JobOptions, buf *bytes.
 		return buildMultipartRFC822(decrypted, opts, buf)
 	}
 
-	hdr := getTextPartHeader(getMessageHeader(decrypted.Msg, opts), decrypted.Body.Bytes(), decrypted.Msg.MIMEType)
+	body := getTextBody(decrypted, opts)
+
+	hdr := getTextPartHeader(getMessageHeader(decrypted.Msg, opts), body, decrypted.Msg.MIMEType)
 
 	w, err := message.CreateWriter(buf, hdr)
 	if err != nil {
 		return err
 	}
 
-	if _, err := w.Write(decrypted.Body.Bytes()); err != nil {
+	if _, err := w.Write(body); err != nil {
 		return err
 	}
 
@@ -144,7 +152,9 @@ func writeTextPart(
 		return writeCustomTextPart(w, decrypted, decrypted.BodyErr)
 	}
 
-	return writePart(w, getTextPartHeader(message.Header{}, decrypted.Body.Bytes(), decrypted.Msg.MIMEType), decrypted.Body.Bytes())
+	body := getTextBody(decrypted, opts)
+
+	return writePart(w, getTextPartHeader(message.Header{}, body, decrypted.Msg.MIMEType), body)
 }
 
 func writeAttachmentPart(
@@ -171,7 +181,13 @@ func writeAttachmentPart(
 		return writeCustomAttachmentPart(w, att, &crypto.PGPMessage{Data: pgpMessageBuffer.Bytes()}, decryptedAttachment.Err)
 	}
 
-	return writePart(w, getAttachmentPartHeader(att), decryptedAttachment.Data.Bytes())
+	hdr, data := getAttachmentPartHeader(att), decryptedAttachment.Data.Bytes()
+
+	if opts.TranscodeToUTF8 {
+		hdr, data = transcodeInlineTextPart(att, hdr, data)
+	}
+
+	return writePart(w, hdr, data)
 }
 
 func writeRelatedParts(
@@ -221,7 +237,14 @@ func buildPGPRFC822(kr *crypto.KeyRing, decrypted *DecryptedMessage, opts JobOpt
 		return writeMultipartSignedRFC822(hdr, decrypted.Body.Bytes(), sigs[0], buf)
 	}
 
-	return writeMultipartEncryptedRFC822(hdr, decrypted.Body.Bytes(), buf)
+	body := decrypted.Body.Bytes()
+
+	// Signed bodies are left alone as repairing them would invalidate the signature.
+	if opts.RepairMalformed {
+		body = parser.Repair(body)
+	}
+
+	return writeMultipartEncryptedRFC822(hdr, body, buf)
 }
 
 func buildPGPMIMEFallbackRFC822(decrypted *DecryptedMessage, opts JobOptions, buf *bytes.Buffer) error {
@@ -358,7 +381,13 @@ func addressEmpty(address *mail.Address) bool {
 }
 
 func getMessageHeader(msg proton.Message, opts JobOptions) message.Header {
-	hdr := toMessageHeader(msg.ParsedHeaders)
+	parsedHeaders := msg.ParsedHeaders
+
+	if opts.TranscodeToUTF8 {
+		parsedHeaders = transcodeHeaders(parsedHeaders)
+	}
+
+	hdr := toMessageHeader(parsedHeaders)
 
 	// SetText will RFC2047-encode.
 	if msg.Subject != "" {
@@ -367,7 +396,7 @@ func getMessageHeader(msg proton.Message, opts JobOptions) message.Header {
 
 	// mail.Address.String() will RFC2047-encode if necessary.
 	if !addressEmpty(msg.Sender) {
-		hdr.Set("From", msg.Sender.String())
+		hdr.Set("From", withContactName(msg.Sender, opts.ContactNames).String())
 	}
 
 	if len(msg.ReplyTos) > 0 && !msg.IsDraft() {
@@ -390,6 +419,11 @@ func getMessageHeader(msg proton.Message, opts JobOptions) message.Header {
 
 	setMessageIDIfNeeded(msg, &hdr)
 
+	// Repair dates clients would sort wrongly, before the sanitization below keeps the invalid ones as they are.
+	if opts.RepairDate {
+		setRepairedDateIfNeeded(msg, &hdr)
+	}
+
 	// Sanitize the date; it needs to have a valid unix timestamp.
 	if opts.SanitizeDate {
 		if date, err := rfc5322.ParseDateTime(hdr.Get("Date")); err != nil || date.Before(time.Unix(0, 0)) {
@@ -402,6 +436,13 @@ func getMessageHeader(msg proton.Message, opts JobOptions) message.Header {
 		}
 	}
 
+	// Show the date in the user's time zone if requested; the instant stays the same.
+	if opts.DateLocation != nil {
+		if date, err := rfc5322.ParseDateTime(hdr.Get("Date")); err == nil {
+			hdr.Set("Date", date.In(opts.DateLocation).Format(time.RFC1123Z))
+		}
+	}
+
 	// Set our internal ID if requested.
 	// This is important for us to detect whether APPENDed things are actually "move like outlook".
 	if opts.AddInternalID {
@@ -422,6 +463,11 @@ func getMessageHeader(msg proton.Message, opts JobOptions) message.Header {
 		hdr.Set("X-Pm-Date", time.Unix(msg.Time, 0).In(time.UTC).Format(time.RFC1123Z))
 	}
 
+	// Normalize the threading headers before our own reference is added, so that it never ends up in In-Reply-To.
+	if opts.NormalizeThreading {
+		setThreadingHeadersIfNeeded(&hdr)
+	}
+
 	// Include the message ID in the references (supposedly this somehow improves outlook support...).
 	if opts.AddMessageIDReference {
 		if refs := hdr.Values("References"); xslices.IndexFunc(refs, func(ref string) bool {
@@ -431,9 +477,56 @@ func getMessageHeader(msg proton.Message, opts JobOptions) message.Header {
 		}
 	}
 
+	// Expose the verdicts of Proton's SPF/DKIM/DMARC checks so that client side filters can act on them.
+	if opts.AddAuthResults {
+		setAuthResultsIfNeeded(msg, &hdr)
+	}
+
+	// Add the user's own fields last so that they see the header as it will be served.
+	if opts.HeaderTemplate != nil {
+		setTemplateHeaders(msg, opts, &hdr)
+	}
+
 	return hdr
 }
 
+// withContactName returns the address with its display name replaced by the name the user gave the contact, if any.
+func withContactName(addr *mail.Address, names ContactNames) *mail.Address {
+	if names == nil {
+		return addr
+	}
+
+	if name, ok := names.ContactName(addr.Address); ok && name != "" {
+		return &mail.Address{Name: name, Address: addr.Address}
+	}
+
+	return addr
+}
+
+// maxDateSkew is how far ahead of the time the server received a message its date may be before it's repaired.
+// Dates are absolute, so this only has to allow for the sender's clock being off.
+const maxDateSkew = 24 * time.Hour
+
+// setRepairedDateIfNeeded replaces a missing or unparsable date, or one too far ahead of the time the server received
+// the message, with the time it was received. The original value is kept as X-Original-Date.
+func setRepairedDateIfNeeded(msg proton.Message, hdr *message.Header) {
+	if msg.Time <= 0 {
+		return
+	}
+
+	received := time.Unix(msg.Time, 0)
+
+	if date, err := rfc5322.ParseDateTime(hdr.Get("Date")); err == nil && !date.After(received.Add(maxDateSkew)) {
+		return
+	}
+
+	if original := hdr.Get("Date"); original != "" && !hdr.Has("X-Original-Date") {
+		hdr.Set("X-Original-Date", original)
+	}
+
+	hdr.Set("Date", received.In(time.UTC).Format(time.RFC1123Z))
+}
+
 // SanitizeMessageDate will return time from msgTime timestamp. If timestamp is
 // not after epoch the RFC822 publish day will be used. No message should
 // realistically be older than RFC822 itself.
@@ -456,6 +549,90 @@ func setMessageIDIfNeeded(msg proton.Message, hdr *message.Header) {
 	}
 }
 
+// setThreadingHeadersIfNeeded rewrites References and In-Reply-To as space separated lists of unique message IDs.
+// A missing References is filled in from In-Reply-To and a missing In-Reply-To from the last reference,
+// so that clients threading on either header field group the message with the rest of its conversation.
+func setThreadingHeadersIfNeeded(hdr *message.Header) {
+	refs := parseMessageIDList(hdr.Values("References"))
+	inReplyTo := parseMessageIDList(hdr.Values("In-Reply-To"))
+
+	if len(refs) == 0 {
+		refs = inReplyTo
+	}
+
+	if len(inReplyTo) == 0 && len(refs) > 0 {
+		inReplyTo = refs[len(refs)-1:]
+	}
+
+	if len(refs) > 0 {
+		hdr.Set("References", strings.Join(refs, " "))
+	}
+
+	if len(inReplyTo) > 0 {
+		hdr.Set("In-Reply-To", strings.Join(inReplyTo, " "))
+	}
+}
+
+// parseMessageIDList returns the unique message IDs, enclosed in angle brackets, of the given header field values.
+// Both whitespace and commas are accepted as separators, as some clients use the latter.
+func parseMessageIDList(values []string) []string {
+	var ids []string
+
+	for _, value := range values {
+		for _, field := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || unicode.IsSpace(r) }) {
+			if id := strings.Trim(field, "<>"); id != "" && !slices.Contains(ids, "<"+id+">") {
+				ids = append(ids, "<"+id+">")
+			}
+		}
+	}
+
+	return ids
+}
+
+// setAuthResultsIfNeeded adds an Authentication-Results header field (RFC 8601) to received messages.
+// Existing fields using our authserv-id are removed first, as they can only have been forged by the sender.
+func setAuthResultsIfNeeded(msg proton.Message, hdr *message.Header) {
+	if !msg.Flags.Has(proton.MessageFlagReceived) {
+		return
+	}
+
+	for fields := hdr.FieldsByKey("Authentication-Results"); fields.Next(); {
+		if servID, _, _ := strings.Cut(fields.Value(), ";"); strings.EqualFold(strings.TrimSpace(servID), AuthServID) {
+			fields.Del()
+		}
+	}
+
+	hdr.Add("Authentication-Results", getAuthResults(msg.Flags))
+}
+
+// getAuthResults returns the Authentication-Results value matching the given message flags.
+// The API only reports failures for SPF and DKIM, so methods without a verdict are omitted.
+func getAuthResults(flags proton.MessageFlag) string {
+	var results []string
+
+	if flags.Has(proton.MessageFlagSPFFail) {
+		results = append(results, "spf=fail")
+	}
+
+	if flags.Has(proton.MessageFlagDKIMFail) {
+		results = append(results, "dkim=fail")
+	}
+
+	switch {
+	case flags.Has(proton.MessageFlagDMARCFail):
+		results = append(results, "dmarc=fail")
+
+	case flags.Has(proton.MessageFlagDMARCPass):
+		results = append(results, "dmarc=pass")
+	}
+
+	if len(results) == 0 {
+		results = append(results, "none")
+	}
+
+	return AuthServID + "; " + strings.Join(results, "; ")
+}
+
 func getTextPartHeader(hdr message.Header, body []byte, mimeType rfc822.MIMEType) message.Header {
 	params := make(map[string]string)
 
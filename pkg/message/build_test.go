@@ -18,6 +18,7 @@
 package message
 
 import (
+	"encoding/base64"
 	"net/mail"
 	"os"
 	"path/filepath"
@@ -25,11 +26,16 @@ import (
 	"testing"
 	"time"
 
+	"github.com/ProtonMail/gluon/imap"
+	"github.com/ProtonMail/gluon/rfc822"
+	"github.com/ProtonMail/go-proton-api"
 	"github.com/ProtonMail/gopenpgp/v2/crypto"
 	"github.com/ProtonMail/proton-bridge/v3/utils"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/simplifiedchinese"
 )
 
 func TestBuildPlainMessage(t *testing.T) {
@@ -866,6 +872,64 @@ func TestBuildMessageWithExistingOriginalDate(t *testing.T) {
 		expectHeader(`X-Original-Date`, is("Sun, 15 Jan 2023 04:23:03 +0100 (W. Europe Standard Time)"))
 }
 
+func TestBuildMessageRepairDate(t *testing.T) {
+	m := gomock.NewController(t)
+	defer m.Finish()
+
+	kr := utils.MakeKeyRing(t)
+	received := time.Date(2023, time.January, 15, 4, 23, 13, 0, time.UTC)
+
+	// A date far in the future is replaced with the time the message was received.
+	msg := newTestMessageWithHeaders(t, kr, "messageID", "addressID", "text/plain", "body", received, map[string][]string{
+		"Date": {"Fri, 15 Jan 2123 04:23:13 +0000"},
+	})
+
+	res, err := DecryptAndBuildRFC822(kr, msg, nil, JobOptions{})
+	require.NoError(t, err)
+	section(t, res).expectDate(is(`Fri, 15 Jan 2123 04:23:13 +0000`))
+
+	res, err = DecryptAndBuildRFC822(kr, msg, nil, JobOptions{RepairDate: true})
+	require.NoError(t, err)
+	section(t, res).
+		expectDate(is(`Sun, 15 Jan 2023 04:23:13 +0000`)).
+		expectHeader(`X-Original-Date`, is(`Fri, 15 Jan 2123 04:23:13 +0000`))
+
+	// So is an unparsable date.
+	msg = newTestMessageWithHeaders(t, kr, "messageID", "addressID", "text/plain", "body", received, map[string][]string{
+		"Date": {"yesterday"},
+	})
+
+	res, err = DecryptAndBuildRFC822(kr, msg, nil, JobOptions{RepairDate: true})
+	require.NoError(t, err)
+	section(t, res).
+		expectDate(is(`Sun, 15 Jan 2023 04:23:13 +0000`)).
+		expectHeader(`X-Original-Date`, is(`yesterday`))
+
+	// A date a little ahead of the server's clock is kept.
+	msg = newTestMessageWithHeaders(t, kr, "messageID", "addressID", "text/plain", "body", received, map[string][]string{
+		"Date": {"Sun, 15 Jan 2023 10:00:00 +0000"},
+	})
+
+	res, err = DecryptAndBuildRFC822(kr, msg, nil, JobOptions{RepairDate: true})
+	require.NoError(t, err)
+	section(t, res).
+		expectDate(is(`Sun, 15 Jan 2023 10:00:00 +0000`)).
+		expectHeader(`X-Original-Date`, isMissing())
+}
+
+func TestBuildMessageDateLocation(t *testing.T) {
+	m := gomock.NewController(t)
+	defer m.Finish()
+
+	kr := utils.MakeKeyRing(t)
+	msg := newTestMessage(t, kr, "messageID", "addressID", "text/plain", "body", time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC))
+
+	res, err := DecryptAndBuildRFC822(kr, msg, nil, JobOptions{DateLocation: time.FixedZone("", -5*60*60)})
+	require.NoError(t, err)
+
+	section(t, res).expectDate(is(`Tue, 31 Dec 2019 19:00:00 -0500`))
+}
+
 func TestBuildMessageInternalID(t *testing.T) {
 	m := gomock.NewController(t)
 	defer m.Finish()
@@ -992,6 +1056,76 @@ func TestBuildIncludeMessageIDReference(t *testing.T) {
 	section(t, resRef).expectHeader(`References`, is(`<myreference@domain.com> <messageID@protonmail.internalid>`))
 }
 
+func TestBuildAuthResults(t *testing.T) {
+	m := gomock.NewController(t)
+	defer m.Finish()
+
+	kr := utils.MakeKeyRing(t)
+	msg := newTestMessageWithHeaders(t, kr, "messageID", "addressID", "text/plain", "body", time.Now(), map[string][]string{
+		"Authentication-Results": {"protonmail.bridge; spf=pass; dkim=pass; dmarc=pass"},
+	})
+
+	// Drafts and sent messages are not verified, so no verdict is added.
+	res, err := DecryptAndBuildRFC822(kr, msg, nil, JobOptions{AddAuthResults: true})
+	require.NoError(t, err)
+
+	section(t, res).expectHeader(`Authentication-Results`, is(`protonmail.bridge; spf=pass; dkim=pass; dmarc=pass`))
+
+	// Received messages carry the verdicts of the API; the forged header field is dropped.
+	msg.Flags = proton.MessageFlagReceived | proton.MessageFlagSPFFail | proton.MessageFlagDMARCPass
+
+	resAuth, err := DecryptAndBuildRFC822(kr, msg, nil, JobOptions{AddAuthResults: true})
+	require.NoError(t, err)
+
+	section(t, resAuth).expectHeader(`Authentication-Results`, is(`protonmail.bridge; spf=fail; dmarc=pass`))
+
+	// Without any verdict, the result is none.
+	msg.Flags = proton.MessageFlagReceived
+
+	resNone, err := DecryptAndBuildRFC822(kr, msg, nil, JobOptions{AddAuthResults: true})
+	require.NoError(t, err)
+
+	section(t, resNone).expectHeader(`Authentication-Results`, is(`protonmail.bridge; none`))
+}
+
+func TestBuildNormalizeThreading(t *testing.T) {
+	m := gomock.NewController(t)
+	defer m.Finish()
+
+	kr := utils.MakeKeyRing(t)
+	msg := newTestMessageWithHeaders(t, kr, "messageID", "addressID", "text/plain", "body", time.Now(), map[string][]string{
+		"References": {"<first@domain.com>,<second@domain.com>  second@domain.com"},
+	})
+
+	// Without normalization, the header fields are left as they are.
+	res, err := DecryptAndBuildRFC822(kr, msg, nil, JobOptions{})
+	require.NoError(t, err)
+
+	section(t, res).
+		expectHeader(`References`, is(`<first@domain.com>,<second@domain.com>  second@domain.com`)).
+		expectHeader(`In-Reply-To`, isMissing())
+
+	// The missing In-Reply-To is taken from the last reference; our own reference is never used.
+	resNorm, err := DecryptAndBuildRFC822(kr, msg, nil, JobOptions{NormalizeThreading: true, AddMessageIDReference: true})
+	require.NoError(t, err)
+
+	section(t, resNorm).
+		expectHeader(`References`, is(`<first@domain.com> <second@domain.com> <messageID@protonmail.internalid>`)).
+		expectHeader(`In-Reply-To`, is(`<second@domain.com>`))
+
+	// The missing References is taken from In-Reply-To.
+	msg = newTestMessageWithHeaders(t, kr, "messageID", "addressID", "text/plain", "body", time.Now(), map[string][]string{
+		"In-Reply-To": {"parent@domain.com"},
+	})
+
+	resReply, err := DecryptAndBuildRFC822(kr, msg, nil, JobOptions{NormalizeThreading: true})
+	require.NoError(t, err)
+
+	section(t, resReply).
+		expectHeader(`References`, is(`<parent@domain.com>`)).
+		expectHeader(`In-Reply-To`, is(`<parent@domain.com>`))
+}
+
 func TestBuildMessageIsDeterministic(t *testing.T) {
 	m := gomock.NewController(t)
 	defer m.Finish()
@@ -1255,7 +1389,7 @@ func TestBuildCustomMessageOnlyAttachmentIsUndecryptable(t *testing.T) {
 		expectTransferEncoding(isMissing())
 }
 
-func readFile(t *testing.T, path string) string {
+func readFile(t testing.TB, path string) string {
 	t.Helper()
 
 	b, err := os.ReadFile(filepath.Join("testdata", path))
@@ -1298,3 +1432,190 @@ func TestBuildComplexMIMEType(t *testing.T) {
 		expectContentTypeParam(`name`, is(`Cat_August_2010-4.jpeg`)).
 		expectContentDispositionParam(`filename`, is(`Cat_August_2010-4.jpeg`))
 }
+
+func TestBuildEncryptedMessageRepairsMalformedHeader(t *testing.T) {
+	m := gomock.NewController(t)
+	defer m.Finish()
+
+	const body = "Subject: repaired\r\nBad Key: value\r\nContent-Type: text/plain\r\n\r\nhello\r\n"
+
+	kr := utils.MakeKeyRing(t)
+	msg := newTestMessage(t, kr, "messageID", "addressID", "multipart/mixed", body, time.Now())
+
+	// Without repairing, the invalid field name can't be written.
+	_, err := DecryptAndBuildRFC822(kr, msg, nil, JobOptions{})
+	require.Error(t, err)
+
+	res, err := DecryptAndBuildRFC822(kr, msg, nil, JobOptions{RepairMalformed: true})
+	require.NoError(t, err)
+
+	parsed, err := imap.NewParsedMessage(res)
+	require.NoError(t, err)
+	require.Contains(t, parsed.Envelope, "repaired")
+
+	section(t, res).
+		expectHeader(`Subject`, is(`repaired`)).
+		expectHeader(`Bad Key`, is(``)).
+		expectContentType(is(`text/plain`)).
+		expectBody(is("hello\r\n"))
+}
+
+func FuzzBuildEncryptedMessage(f *testing.F) {
+	entries, err := os.ReadDir("testdata")
+	require.NoError(f, err)
+
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), "pgp-mime-body") {
+			f.Add([]byte(readFile(f, entry.Name())))
+		}
+	}
+
+	key, err := crypto.GenerateKey("name", "email", "x25519", 0)
+	require.NoError(f, err)
+
+	kr, err := crypto.NewKeyRing(key)
+	require.NoError(f, err)
+
+	f.Fuzz(func(t *testing.T, body []byte) {
+		msg := newTestMessage(t, kr, "messageID", "addressID", "multipart/mixed", string(body), time.Now())
+
+		res, err := DecryptAndBuildRFC822(kr, msg, nil, JobOptions{RepairMalformed: true})
+		require.NoError(t, err)
+
+		// Every header of the built message must be one that gluon can parse rather than discard.
+		require.NoError(t, rfc822.Parse(res).Walk(func(section *rfc822.Section) error {
+			header, _ := rfc822.Split(section.Literal())
+
+			_, err := rfc822.NewHeader(header)
+
+			return err
+		}))
+	})
+}
+
+func TestBuildTranscodeToUTF8(t *testing.T) {
+	m := gomock.NewController(t)
+	defer m.Finish()
+
+	koi8r, err := charmap.KOI8R.NewEncoder().Bytes([]byte("Привет"))
+	require.NoError(t, err)
+
+	kr := utils.MakeKeyRing(t)
+	msg := newTestMessageWithHeaders(t, kr, "messageID", "addressID", "text/plain", string(koi8r), time.Now(), map[string][]string{
+		"Content-Type": {"text/plain; charset=koi8-r"},
+		"Thread-Topic": {"=?koi8-r?B?" + base64.StdEncoding.EncodeToString(koi8r) + "?="},
+	})
+
+	// By default, the original charsets are kept.
+	res, err := DecryptAndBuildRFC822(kr, msg, nil, JobOptions{})
+	require.NoError(t, err)
+
+	section(t, res).
+		expectContentTypeParam(`charset`, is(``)).
+		expectHeader(`Thread-Topic`, contains(`koi8-r`))
+
+	res, err = DecryptAndBuildRFC822(kr, msg, nil, JobOptions{TranscodeToUTF8: true})
+	require.NoError(t, err)
+
+	section(t, res).
+		expectContentTypeParam(`charset`, is(`utf-8`)).
+		expectBody(is(`Привет`)).
+		expectHeader(`Thread-Topic`, contains(`utf-8`)).
+		expectDecodedHeader(`Thread-Topic`, is(`Привет`))
+}
+
+func TestBuildTranscodeToUTF8InlineTextPart(t *testing.T) {
+	m := gomock.NewController(t)
+	defer m.Finish()
+
+	gb2312, err := simplifiedchinese.GBK.NewEncoder().Bytes([]byte("你好"))
+	require.NoError(t, err)
+
+	kr := utils.MakeKeyRing(t)
+	msg := newTestMessage(t, kr, "messageID", "addressID", "text/plain", "body", time.Now())
+	inline := addTestAttachment(t, kr, &msg, "attachID0", "inline.txt", "text/plain; charset=gb2312", "inline", string(gb2312))
+	attached := addTestAttachment(t, kr, &msg, "attachID1", "attached.txt", "text/plain; charset=gb2312", "attachment", string(gb2312))
+
+	res, err := DecryptAndBuildRFC822(kr, msg, [][]byte{inline, attached}, JobOptions{TranscodeToUTF8: true})
+	require.NoError(t, err)
+
+	// Inline text parts are transcoded...
+	section(t, res, 1, 2).
+		expectContentTypeParam(`charset`, is(`utf-8`)).
+		expectBody(is(`你好`))
+
+	// ...but attached files are left as they are.
+	section(t, res, 2).
+		expectContentTypeParam(`charset`, is(`gb2312`))
+}
+
+type testHeaderNames map[string]string
+
+func (names testHeaderNames) LabelName(labelID string) (string, bool) {
+	name, ok := names[labelID]
+	return name, ok
+}
+
+func (names testHeaderNames) AddressEmail(addressID string) (string, bool) {
+	email, ok := names[addressID]
+	return email, ok
+}
+
+func TestBuildHeaderTemplate(t *testing.T) {
+	m := gomock.NewController(t)
+	defer m.Finish()
+
+	tmpl, err := ParseHeaderTemplate("X-Original-To: {{.OriginalTo}}\nX-PM-Labels: {{join .Labels \", \"}}\nX-PM-External-Id: {{.ExternalID}}\n")
+	require.NoError(t, err)
+
+	kr := utils.MakeKeyRing(t)
+	msg := newTestMessageWithHeaders(t, kr, "messageID", "addressID", "text/plain", "body", time.Now(), map[string][]string{
+		"Delivered-To": {"alias@example.com"},
+	})
+	msg.LabelIDs = []string{proton.InboxLabel, "labelID", "unknownID"}
+
+	res, err := DecryptAndBuildRFC822(kr, msg, nil, JobOptions{
+		HeaderTemplate: tmpl,
+		HeaderNames:    testHeaderNames{proton.InboxLabel: "Inbox", "labelID": "Café", "addressID": "user@example.com"},
+	})
+	require.NoError(t, err)
+
+	section(t, res).
+		expectHeader(`X-Original-To`, is(`alias@example.com`)).
+		expectDecodedHeader(`X-Pm-Labels`, is(`Inbox, Café`)).
+		expectHeader(`X-Pm-External-Id`, is(``)).
+		expectBody(is(`body`))
+}
+
+type testContactNames map[string]string
+
+func (names testContactNames) ContactName(email string) (string, bool) {
+	name, ok := names[email]
+	return name, ok
+}
+
+func TestBuildContactNames(t *testing.T) {
+	m := gomock.NewController(t)
+	defer m.Finish()
+
+	kr := utils.MakeKeyRing(t)
+	msg := newTestMessage(t, kr, "messageID", "addressID", "text/plain", "body", time.Now())
+	msg.Sender = &mail.Address{Name: "Jane Doe", Address: "jane@example.com"}
+
+	// Without contact names, the sender's own display name is kept.
+	res, err := DecryptAndBuildRFC822(kr, msg, nil, JobOptions{})
+	require.NoError(t, err)
+
+	section(t, res).expectHeader(`From`, is(`"Jane Doe" <jane@example.com>`))
+
+	// Senders without a contact name keep theirs too.
+	res, err = DecryptAndBuildRFC822(kr, msg, nil, JobOptions{ContactNames: testContactNames{"john@example.com": "John"}})
+	require.NoError(t, err)
+
+	section(t, res).expectHeader(`From`, is(`"Jane Doe" <jane@example.com>`))
+
+	res, err = DecryptAndBuildRFC822(kr, msg, nil, JobOptions{ContactNames: testContactNames{"jane@example.com": "Mom"}})
+	require.NoError(t, err)
+
+	section(t, res).expectHeader(`From`, is(`"Mom" <jane@example.com>`))
+}
@@ -0,0 +1,191 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package message
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"mime"
+	"strings"
+	"text/template"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/emersion/go-message"
+	"github.com/sirupsen/logrus"
+)
+
+var ErrInvalidHeaderField = errors.New("invalid header field")
+
+// HeaderTemplate is a user supplied text/template producing extra header fields for built messages,
+// one "Key: Value" field per line. Lines that come out empty or with an empty value are skipped,
+// so that a field can be left out for messages that have nothing to put in it. For example:
+//
+//	X-Original-To: {{.OriginalTo}}
+//	X-PM-Labels: {{join .Labels ", "}}
+//
+// The fields replace any fields of the same name the message already has.
+type HeaderTemplate struct {
+	text string
+	tmpl *template.Template
+}
+
+// HeaderTemplateData is what a header template is executed with.
+// Conversation IDs are not part of the message metadata returned by the API, so they can't be offered yet.
+type HeaderTemplateData struct {
+	MessageID  string   // The Proton message ID.
+	ExternalID string   // The message ID assigned by the sender, if any.
+	Address    string   // The email of the Proton address the message belongs to.
+	OriginalTo string   // The address the message was delivered to, which differs from Address for aliases.
+	LabelIDs   []string // The IDs of all labels and folders the message is in.
+	Labels     []string // The names of all labels and folders the message is in.
+}
+
+// HeaderNames resolves the IDs used in message metadata to names for header templates.
+type HeaderNames interface {
+	LabelName(labelID string) (string, bool)
+	AddressEmail(addressID string) (string, bool)
+}
+
+// ParseHeaderTemplate parses a header template and checks that it produces valid header fields.
+func ParseHeaderTemplate(text string) (*HeaderTemplate, error) {
+	tmpl, err := template.New("headers").Funcs(template.FuncMap{"join": strings.Join}).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &HeaderTemplate{text: text, tmpl: tmpl}
+
+	// Catch references to unknown fields and malformed lines now rather than when messages are built.
+	if _, err := res.execute(HeaderTemplateData{
+		MessageID:  "id",
+		Address:    "address@example.com",
+		OriginalTo: "alias@example.com",
+		LabelIDs:   []string{proton.InboxLabel},
+		Labels:     []string{"Inbox"},
+	}); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// String returns the text the template was parsed from, or an empty string for a nil template.
+func (t *HeaderTemplate) String() string {
+	if t == nil {
+		return ""
+	}
+
+	return t.text
+}
+
+// execute runs the template and returns the resulting non-empty header fields in order.
+func (t *HeaderTemplate) execute(data HeaderTemplateData) ([][2]string, error) {
+	var out strings.Builder
+
+	if err := t.tmpl.Execute(&out, data); err != nil {
+		return nil, err
+	}
+
+	var fields [][2]string
+
+	for scanner := bufio.NewScanner(strings.NewReader(out.String())); scanner.Scan(); {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+		if !ok || !isValidHeaderTemplateKey(key) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidHeaderField, line)
+		}
+
+		if value = strings.TrimSpace(value); value != "" {
+			fields = append(fields, [2]string{key, value})
+		}
+	}
+
+	return fields, nil
+}
+
+// isValidHeaderTemplateKey returns whether a template may set the given field.
+// Fields describing the MIME structure are off limits as changing them would break the message.
+func isValidHeaderTemplateKey(key string) bool {
+	if key == "" || strings.HasPrefix(strings.ToLower(key), "content-") || strings.EqualFold(key, "MIME-Version") {
+		return false
+	}
+
+	for _, c := range []byte(key) {
+		if c < 33 || c > 126 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// setTemplateHeaders adds the fields produced by the header template to the header.
+// A template failing for a single message shouldn't keep the message from being served, so errors are only logged.
+func setTemplateHeaders(msg proton.Message, opts JobOptions, hdr *message.Header) {
+	fields, err := opts.HeaderTemplate.execute(getHeaderTemplateData(msg, opts.HeaderNames, hdr))
+	if err != nil {
+		logrus.WithError(err).WithField("messageID", msg.ID).Warn("Failed to execute header template")
+		return
+	}
+
+	// Fields from the template replace the message's own, but a template may add the same field several times.
+	for _, field := range fields {
+		hdr.Del(field[0])
+	}
+
+	// Fields are added to the top of the header, so add them in reverse to keep the template's order.
+	for i := len(fields) - 1; i >= 0; i-- {
+		hdr.Add(fields[i][0], mime.QEncoding.Encode("utf-8", fields[i][1]))
+	}
+}
+
+func getHeaderTemplateData(msg proton.Message, names HeaderNames, hdr *message.Header) HeaderTemplateData {
+	data := HeaderTemplateData{
+		MessageID:  msg.ID,
+		ExternalID: msg.ExternalID,
+		LabelIDs:   msg.LabelIDs,
+	}
+
+	if names != nil {
+		data.Address, _ = names.AddressEmail(msg.AddressID)
+
+		for _, labelID := range msg.LabelIDs {
+			if name, ok := names.LabelName(labelID); ok {
+				data.Labels = append(data.Labels, name)
+			}
+		}
+	}
+
+	switch {
+	case hdr.Get("X-Original-To") != "":
+		data.OriginalTo = hdr.Get("X-Original-To")
+
+	case hdr.Get("Delivered-To") != "":
+		data.OriginalTo = hdr.Get("Delivered-To")
+
+	default:
+		data.OriginalTo = data.Address
+	}
+
+	return data
+}
@@ -0,0 +1,81 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package message
+
+import (
+	"testing"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/emersion/go-message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHeaderTemplate(t *testing.T) {
+	tests := []struct {
+		text    string
+		wantErr bool
+	}{
+		{text: ""},
+		{text: "X-Original-To: {{.OriginalTo}}"},
+		{text: "{{range .Labels}}X-PM-Label: {{.}}\n{{end}}"},
+
+		// Unknown fields and syntax errors are caught.
+		{text: "X-Conversation-Id: {{.ConversationID}}", wantErr: true},
+		{text: "X-Original-To: {{.OriginalTo", wantErr: true},
+
+		// Lines must be valid header fields, which can't change the MIME structure.
+		{text: "not a field", wantErr: true},
+		{text: "Bad Key: {{.Address}}", wantErr: true},
+		{text: "Content-Type: text/html", wantErr: true},
+	}
+
+	for _, test := range tests {
+		tmpl, err := ParseHeaderTemplate(test.text)
+
+		if test.wantErr {
+			assert.Error(t, err, test.text)
+		} else if assert.NoError(t, err, test.text) {
+			assert.Equal(t, test.text, tmpl.String())
+		}
+	}
+}
+
+func TestSetTemplateHeaders(t *testing.T) {
+	tmpl, err := ParseHeaderTemplate("{{range .Labels}}X-PM-Label: {{.}}\n{{end}}X-Original-To: {{.OriginalTo}}\nX-Empty: {{.ExternalID}}")
+	require.NoError(t, err)
+
+	var hdr message.Header
+
+	hdr.Set("X-Pm-Label", "stale")
+	hdr.Set("X-Original-To", "original@example.com")
+
+	setTemplateHeaders(proton.Message{MessageMetadata: proton.MessageMetadata{
+		ID:        "messageID",
+		AddressID: "addressID",
+		LabelIDs:  []string{"a", "b"},
+	}}, JobOptions{
+		HeaderTemplate: tmpl,
+		HeaderNames:    testHeaderNames{"a": "Work", "b": "Personal"},
+	}, &hdr)
+
+	// Existing fields are replaced, and the message's own delivery address is kept.
+	assert.Equal(t, []string{"Work", "Personal"}, hdr.Values("X-Pm-Label"))
+	assert.Equal(t, "original@example.com", hdr.Get("X-Original-To"))
+	assert.False(t, hdr.Has("X-Empty"))
+}
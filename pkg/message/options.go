@@ -17,11 +17,27 @@
 
 package message
 
+import "time"
+
 type JobOptions struct {
-	IgnoreDecryptionErrors bool // Whether to ignore decryption errors and create a "custom message" instead.
-	SanitizeDate           bool // Whether to replace all dates before 1970 with RFC822's birthdate.
-	AddInternalID          bool // Whether to include MessageID as X-Pm-Internal-Id.
-	AddExternalID          bool // Whether to include ExternalID as X-Pm-External-Id.
-	AddMessageDate         bool // Whether to include message time as X-Pm-Date.
-	AddMessageIDReference  bool // Whether to include the MessageID in References.
+	IgnoreDecryptionErrors bool            // Whether to ignore decryption errors and create a "custom message" instead.
+	SanitizeDate           bool            // Whether to replace all dates before 1970 with RFC822's birthdate.
+	AddInternalID          bool            // Whether to include MessageID as X-Pm-Internal-Id.
+	AddExternalID          bool            // Whether to include ExternalID as X-Pm-External-Id.
+	AddMessageDate         bool            // Whether to include message time as X-Pm-Date.
+	AddMessageIDReference  bool            // Whether to include the MessageID in References.
+	AddAuthResults         bool            // Whether to include Proton's SPF/DKIM/DMARC verdicts as Authentication-Results.
+	NormalizeThreading     bool            // Whether to normalize References and In-Reply-To, filling in one from the other.
+	RepairMalformed        bool            // Whether to repair malformed MIME structure in PGP/MIME bodies rather than pass it through.
+	TranscodeToUTF8        bool            // Whether to transcode message bodies, inline text parts and encoded header words to UTF-8.
+	RepairDate             bool            // Whether to replace missing, invalid or far future dates with the time the server received the message.
+	DateLocation           *time.Location  // Converts the Date header to this time zone, if set.
+	HeaderTemplate         *HeaderTemplate // Template of extra header fields to add, if any.
+	HeaderNames            HeaderNames     // Resolves label and address IDs for the header template.
+	ContactNames           ContactNames    // Replaces the From display name with the sender's contact name, if set.
+}
+
+// ContactNames looks up the names the user gave their contacts.
+type ContactNames interface {
+	ContactName(email string) (string, bool)
 }
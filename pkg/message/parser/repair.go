@@ -0,0 +1,330 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package parser
+
+import (
+	"bytes"
+	"mime"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxRepairDepth bounds how deeply nested parts are repaired.
+const maxRepairDepth = 32
+
+// Repair rewrites the parts of a literal that strict parsers reject instead of failing on them,
+// leaving everything else byte for byte as it was:
+//   - header lines that are neither fields nor continuations are dropped, as are fields with invalid names;
+//   - header field values that aren't valid UTF-8 are assumed to be ISO-8859-1 and transcoded;
+//   - multipart bodies that are missing their closing delimiter get one;
+//   - base64 bodies have characters outside the base64 alphabet removed.
+//
+// The literal is returned as is if nothing needed repairing.
+func Repair(literal []byte) []byte {
+	if repaired, changed := repairEntity(literal, 0); changed {
+		return repaired
+	}
+
+	return literal
+}
+
+func repairEntity(b []byte, depth int) ([]byte, bool) {
+	rawHeader, body := splitEntity(b)
+
+	header, fields, headerChanged := repairHeader(rawHeader)
+
+	var bodyChanged bool
+
+	mediaType, params, _ := mime.ParseMediaType(fields.get("Content-Type"))
+
+	switch {
+	case depth >= maxRepairDepth:
+		// Leave deeply nested parts alone.
+
+	case strings.HasPrefix(mediaType, "multipart/") && params["boundary"] != "":
+		body, bodyChanged = repairMultipart(body, params["boundary"], depth)
+
+	case mediaType == "message/rfc822":
+		body, bodyChanged = repairEntity(body, depth+1)
+
+	case strings.EqualFold(strings.TrimSpace(fields.get("Content-Transfer-Encoding")), "base64"):
+		body, bodyChanged = repairBase64(body)
+	}
+
+	if !headerChanged && !bodyChanged {
+		return b, false
+	}
+
+	return append(header, body...), true
+}
+
+// splitEntity splits an entity into its header, including the empty line ending it, and its body.
+func splitEntity(b []byte) ([]byte, []byte) {
+	for offset := 0; offset < len(b); {
+		end := bytes.IndexByte(b[offset:], '\n')
+		if end < 0 {
+			break
+		}
+
+		end += offset + 1
+
+		if len(bytes.TrimRight(b[offset:end], "\r\n")) == 0 {
+			return b[:end], b[end:]
+		}
+
+		offset = end
+	}
+
+	return b, nil
+}
+
+type repairedFields [][2]string
+
+func (fields repairedFields) get(key string) string {
+	for _, field := range fields {
+		if strings.EqualFold(field[0], key) {
+			return field[1]
+		}
+	}
+
+	return ""
+}
+
+// repairHeader drops the lines of a raw header that aren't valid fields and fixes up field values.
+// Line endings are kept as they are so that an intact header is left untouched.
+func repairHeader(raw []byte) ([]byte, repairedFields, bool) {
+	var (
+		header  []byte
+		fields  repairedFields
+		changed bool
+
+		// Whether the last field was kept, so its continuation lines are kept too.
+		inField bool
+	)
+
+	for len(raw) > 0 {
+		var line []byte
+
+		if end := bytes.IndexByte(raw, '\n'); end >= 0 {
+			line, raw = raw[:end+1], raw[end+1:]
+		} else {
+			line, raw = raw, nil
+		}
+
+		content, ending := splitLineEnding(line)
+
+		// Stray carriage returns are dropped at the end of a line and turned into spaces elsewhere.
+		if bytes.IndexByte(content, '\r') >= 0 {
+			content = bytes.ReplaceAll(bytes.TrimRight(content, "\r"), []byte{'\r'}, []byte{' '})
+			changed = true
+		}
+
+		if !utf8.Valid(content) {
+			content = latin1ToUTF8(content)
+			changed = true
+		}
+
+		switch {
+		case len(bytes.TrimSpace(content)) == 0:
+			// Either the empty line ending the header or a blank continuation line, both of which are fine.
+
+		case content[0] == ' ' || content[0] == '\t':
+			if !inField {
+				changed = true
+				continue
+			}
+
+			fields[len(fields)-1][1] += " " + string(bytes.TrimSpace(content))
+
+		default:
+			key, value, ok := bytes.Cut(content, []byte{':'})
+			if !ok {
+				inField = false
+				changed = true
+
+				continue
+			}
+
+			// Allow for whitespace between the field name and the colon, which some mailers insert.
+			if trimmed := bytes.TrimRight(key, " \t"); len(trimmed) != len(key) {
+				key = trimmed
+				changed = true
+			}
+
+			if !isValidFieldName(key) {
+				inField = false
+				changed = true
+
+				continue
+			}
+
+			// A value starting with a colon would be read as part of the field name,
+			// and an empty value at the very end of the literal as a name without a colon.
+			if len(value) > 0 && value[0] == ':' || len(value) == 0 && len(ending) == 0 {
+				value = append([]byte{' '}, value...)
+				changed = true
+			}
+
+			content = append(append(key[:len(key):len(key)], ':'), value...)
+			fields = append(fields, [2]string{string(key), string(bytes.TrimSpace(value))})
+			inField = true
+		}
+
+		header = append(append(header, content...), ending...)
+	}
+
+	return header, fields, changed
+}
+
+func splitLineEnding(line []byte) ([]byte, []byte) {
+	switch {
+	case bytes.HasSuffix(line, []byte("\r\n")):
+		return line[:len(line)-2], line[len(line)-2:]
+
+	case bytes.HasSuffix(line, []byte("\n")):
+		return line[:len(line)-1], line[len(line)-1:]
+
+	default:
+		return line, nil
+	}
+}
+
+func isValidFieldName(key []byte) bool {
+	if len(key) == 0 {
+		return false
+	}
+
+	for _, c := range key {
+		if c < 33 || c > 126 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func latin1ToUTF8(b []byte) []byte {
+	res := make([]byte, 0, len(b)*2)
+
+	for _, c := range b {
+		res = utf8.AppendRune(res, rune(c))
+	}
+
+	return res
+}
+
+// repairMultipart repairs each part of a multipart body and closes it if its closing delimiter is missing.
+func repairMultipart(body []byte, boundary string, depth int) ([]byte, bool) {
+	var (
+		delimiter = []byte("--" + boundary)
+		closing   = []byte("--" + boundary + "--")
+
+		res     []byte
+		changed bool
+
+		part   []byte
+		inPart bool
+	)
+
+	flushPart := func() {
+		if !inPart {
+			return
+		}
+
+		// The line ending before a delimiter belongs to the delimiter, not to the part.
+		content, ending := splitLineEnding(part)
+
+		repaired, partChanged := repairEntity(content, depth+1)
+
+		res = append(append(res, repaired...), ending...)
+		changed = changed || partChanged
+		part = nil
+	}
+
+	for rest := body; len(rest) > 0; {
+		var line []byte
+
+		if end := bytes.IndexByte(rest, '\n'); end >= 0 {
+			line, rest = rest[:end+1], rest[end+1:]
+		} else {
+			line, rest = rest, nil
+		}
+
+		trimmed := bytes.TrimRight(line, " \t\r\n")
+
+		switch {
+		case bytes.Equal(trimmed, closing):
+			flushPart()
+
+			// Everything after the closing delimiter is the epilogue.
+			res = append(append(res, line...), rest...)
+
+			return res, changed
+
+		case bytes.Equal(trimmed, delimiter):
+			flushPart()
+
+			res = append(res, line...)
+			inPart = true
+
+		case inPart:
+			part = append(part, line...)
+
+		default:
+			res = append(res, line...)
+		}
+	}
+
+	if !inPart {
+		// There's no delimiter at all, so there are no parts to repair either.
+		return body, false
+	}
+
+	// The last part keeps its own trailing line ending, so the closing delimiter needs one of its own.
+	_, ending := splitLineEnding(part)
+
+	flushPart()
+
+	if len(ending) == 0 {
+		res = append(res, "\r\n"...)
+	}
+
+	return append(append(res, closing...), "\r\n"...), true
+}
+
+// repairBase64 removes characters which can't appear in base64 encoded data.
+func repairBase64(body []byte) ([]byte, bool) {
+	isValid := func(c byte) bool {
+		return c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' ||
+			c == '+' || c == '/' || c == '=' || c == '\r' || c == '\n' || c == ' ' || c == '\t'
+	}
+
+	if bytes.IndexFunc(body, func(r rune) bool { return r >= utf8.RuneSelf || !isValid(byte(r)) }) < 0 {
+		return body, false
+	}
+
+	res := make([]byte, 0, len(body))
+
+	for _, c := range body {
+		if isValid(c) {
+			res = append(res, c)
+		}
+	}
+
+	return res, true
+}
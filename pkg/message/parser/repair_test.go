@@ -0,0 +1,104 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ProtonMail/gluon/rfc822"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepairLeavesValidMessage(t *testing.T) {
+	b, err := os.ReadFile(filepath.Join("testdata", "complex_structure.eml"))
+	require.NoError(t, err)
+
+	require.Equal(t, b, Repair(b))
+}
+
+func TestRepairHeader(t *testing.T) {
+	const (
+		in  = "Subject : hello\r\nBad Key: value\r\nnot a field\r\nFrom: a@b.c\r\n\tcontinued\r\nX-Latin: caf\xe9\r\nX-Colon::value\r\n\r\nbody"
+		out = "Subject: hello\r\nFrom: a@b.c\r\n\tcontinued\r\nX-Latin: café\r\nX-Colon: :value\r\n\r\nbody"
+	)
+
+	require.Equal(t, out, string(Repair([]byte(in))))
+}
+
+func TestRepairMultipartMissingClosingDelimiter(t *testing.T) {
+	const (
+		in  = "Content-Type: multipart/mixed; boundary=b\r\n\r\n--b\r\nContent-Type: text/plain\r\n\r\nhello\r\n--b\r\nBad Key: value\r\n\r\nworld"
+		out = "Content-Type: multipart/mixed; boundary=b\r\n\r\n--b\r\nContent-Type: text/plain\r\n\r\nhello\r\n--b\r\n\r\nworld\r\n--b--\r\n"
+	)
+
+	require.Equal(t, out, string(Repair([]byte(in))))
+}
+
+func TestRepairNestedMessage(t *testing.T) {
+	const (
+		in  = "Content-Type: message/rfc822\r\n\r\nSubject: inner\r\nBad Key: value\r\n\r\nbody"
+		out = "Content-Type: message/rfc822\r\n\r\nSubject: inner\r\n\r\nbody"
+	)
+
+	require.Equal(t, out, string(Repair([]byte(in))))
+}
+
+func TestRepairBase64(t *testing.T) {
+	const (
+		in  = "Content-Transfer-Encoding: base64\r\n\r\naGVs*bG8=\r\n"
+		out = "Content-Transfer-Encoding: base64\r\n\r\naGVsbG8=\r\n"
+	)
+
+	require.Equal(t, out, string(Repair([]byte(in))))
+}
+
+func FuzzRepair(f *testing.F) {
+	for _, dir := range []string{"testdata", filepath.Join("..", "testdata")} {
+		entries, err := os.ReadDir(dir)
+		require.NoError(f, err)
+
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+
+			b, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+			require.NoError(f, err)
+
+			f.Add(b)
+		}
+	}
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+		repaired := Repair(b)
+
+		// Repairing is idempotent...
+		require.Equal(t, repaired, Repair(repaired))
+
+		// ...and leaves no header that gluon would have to discard.
+		require.NoError(t, rfc822.Parse(repaired).Walk(func(section *rfc822.Section) error {
+			header, _ := rfc822.Split(section.Literal())
+
+			_, err := rfc822.NewHeader(header)
+
+			return err
+		}))
+	})
+}
@@ -0,0 +1,147 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package message
+
+import (
+	"mime"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ProtonMail/go-proton-api"
+	pmmime "github.com/ProtonMail/proton-bridge/v3/pkg/mime"
+	"github.com/emersion/go-message"
+)
+
+// encodedWordRegexp matches RFC 2047 encoded words, capturing their charset and encoding.
+var encodedWordRegexp = regexp.MustCompile(`=\?([^?\s]+)\?([bBqQ])\?[^?\s]*\?=`)
+
+// transcodeHeaders returns a copy of the given header fields with encoded words in legacy charsets
+// re-encoded as UTF-8.
+func transcodeHeaders(hdr proton.Headers) proton.Headers {
+	res := proton.Headers{
+		Values: make(map[string][]string, len(hdr.Values)),
+		Order:  hdr.Order,
+	}
+
+	for key, vals := range hdr.Values {
+		res.Values[key] = make([]string, len(vals))
+
+		for i, val := range vals {
+			res.Values[key][i] = transcodeHeaderValue(val)
+		}
+	}
+
+	return res
+}
+
+// transcodeHeaderValue re-encodes the encoded words of a header field value that use a charset other than UTF-8.
+// Each word is replaced on its own, so the structure of address lists and the like is left intact.
+func transcodeHeaderValue(val string) string {
+	return encodedWordRegexp.ReplaceAllStringFunc(val, func(word string) string {
+		match := encodedWordRegexp.FindStringSubmatch(word)
+
+		if charset := strings.ToLower(match[1]); charset == "utf-8" || charset == "us-ascii" {
+			return word
+		}
+
+		// Words that decode to plain ASCII are left alone: written out as is, they could change how
+		// the field is parsed, e.g. by adding a comma to an address list.
+		decoded, err := pmmime.WordDec.Decode(word)
+		if err != nil || !utf8.ValidString(decoded) || isASCII(decoded) {
+			return word
+		}
+
+		if strings.EqualFold(match[2], "b") {
+			return mime.BEncoding.Encode("utf-8", decoded)
+		}
+
+		return mime.QEncoding.Encode("utf-8", decoded)
+	})
+}
+
+// transcodeText converts text in the charset given by its content type, or in the charset it appears to
+// be in if none is given, to UTF-8. The text is returned as is if it can't be converted.
+func transcodeText(text []byte, contentType string) []byte {
+	decoded, err := pmmime.DecodeCharset(text, contentType)
+	if err != nil || !utf8.Valid(decoded) {
+		return text
+	}
+
+	return decoded
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+
+	return true
+}
+
+// getTextBody returns the decrypted message body, transcoded to UTF-8 if requested.
+// The charset is taken from the message's original content type, if it has one.
+func getTextBody(decrypted *DecryptedMessage, opts JobOptions) []byte {
+	body := decrypted.Body.Bytes()
+
+	if !opts.TranscodeToUTF8 || utf8.Valid(body) {
+		return body
+	}
+
+	contentType := string(decrypted.Msg.MIMEType)
+
+	if vals := decrypted.Msg.ParsedHeaders.Values["Content-Type"]; len(vals) > 0 {
+		if _, params, err := pmmime.ParseMediaType(vals[0]); err == nil && params["charset"] != "" {
+			contentType = mime.FormatMediaType(contentType, map[string]string{"charset": params["charset"]})
+		}
+	}
+
+	return transcodeText(body, contentType)
+}
+
+// transcodeInlineTextPart transcodes the data of an inline text part to UTF-8 and updates the charset of its
+// content type to match. Files attached as attachments are left as they are.
+func transcodeInlineTextPart(att proton.Attachment, hdr message.Header, data []byte) (message.Header, []byte) {
+	if att.Disposition != proton.InlineDisposition {
+		return hdr, data
+	}
+
+	mimeType, params, err := hdr.ContentType()
+	if err != nil || !strings.HasPrefix(mimeType, "text/") {
+		return hdr, data
+	}
+
+	if charset := strings.ToLower(params["charset"]); charset == "utf-8" || charset == "us-ascii" {
+		return hdr, data
+	} else if charset == "" && utf8.Valid(data) {
+		return hdr, data
+	}
+
+	transcoded := transcodeText(data, mime.FormatMediaType(mimeType, params))
+	if !utf8.Valid(transcoded) {
+		return hdr, data
+	}
+
+	params["charset"] = "utf-8"
+
+	hdr.SetContentType(mimeType, params)
+
+	return hdr, transcoded
+}
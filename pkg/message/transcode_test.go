@@ -0,0 +1,51 @@
+// Copyright (c) 2023 Proton AG
+//
+// This file is part of Proton Mail Bridge.
+//
+// Proton Mail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Proton Mail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Proton Mail Bridge. If not, see <https://www.gnu.org/licenses/>.
+
+package message
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranscodeHeaderValue(t *testing.T) {
+	tests := []struct {
+		in, out string
+	}{
+		// UTF-8 and plain text are left alone.
+		{in: "plain text", out: "plain text"},
+		{in: "=?utf-8?q?caf=C3=A9?=", out: "=?utf-8?q?caf=C3=A9?="},
+
+		// Legacy charsets are re-encoded as UTF-8, keeping the encoding.
+		{in: "=?iso-8859-1?q?caf=E9?=", out: "=?utf-8?q?caf=C3=A9?="},
+		{in: "=?koi8-r?B?8NLJ18XU?=", out: "=?utf-8?b?0J/RgNC40LLQtdGC?="},
+
+		// Each word is replaced on its own, so address lists stay intact.
+		{in: "=?iso-8859-1?q?Andr=E9?= <andre@example.com>, Bob <bob@example.com>", out: "=?utf-8?q?Andr=C3=A9?= <andre@example.com>, Bob <bob@example.com>"},
+
+		// Words that decode to plain ASCII are kept as they are.
+		{in: "=?iso-8859-1?q?Doe=2C_John?= <john@example.com>", out: "=?iso-8859-1?q?Doe=2C_John?= <john@example.com>"},
+
+		// Words in unknown charsets are kept as they are.
+		{in: "=?x-unknown?q?abc?=", out: "=?x-unknown?q?abc?="},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.out, transcodeHeaderValue(test.in), test.in)
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"crypto/x509"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"net/http/cookiejar"
 	"os"
 	"path/filepath"
@@ -36,6 +37,7 @@ import (
 	"github.com/ProtonMail/proton-bridge/v3/internal/cookies"
 	"github.com/ProtonMail/proton-bridge/v3/internal/events"
 	frontend "github.com/ProtonMail/proton-bridge/v3/internal/frontend/grpc"
+	"github.com/ProtonMail/proton-bridge/v3/internal/network"
 	"github.com/ProtonMail/proton-bridge/v3/internal/service"
 	"github.com/ProtonMail/proton-bridge/v3/internal/useragent"
 	"github.com/ProtonMail/proton-bridge/v3/internal/vault"
@@ -145,12 +147,25 @@ func (t *testCtx) initBridge() (<-chan events.Event, error) {
 		logrus.SetLevel(logrus.TraceLevel)
 	}
 
+	var roundTripper http.RoundTripper = t.netCtl.NewRoundTripper(&tls.Config{InsecureSkipVerify: true})
+
+	// Replay recorded API fixtures, e.g. from a bug report, instead of talking to the test server.
+	if path := os.Getenv("FEATURE_TEST_API_FIXTURES"); path != "" {
+		fixtures, err := network.LoadFixtures(path)
+		if err != nil {
+			return nil, fmt.Errorf("could not load API fixtures: %w", err)
+		}
+
+		roundTripper = network.NewFixtureReplayer(fixtures)
+	}
+
 	// Create the bridge.
 	bridge, eventCh, err := bridge.New(
 		// App stuff
 		t.locator,
 		vault,
 		t.mocks.Autostarter,
+		t.mocks.MailtoHandler,
 		t.mocks.Updater,
 		t.version,
 
@@ -159,7 +174,7 @@ func (t *testCtx) initBridge() (<-chan events.Event, error) {
 		persister,
 		useragent.New(),
 		t.mocks.TLSReporter,
-		t.netCtl.NewRoundTripper(&tls.Config{InsecureSkipVerify: true}),
+		roundTripper,
 		t.mocks.ProxyCtl,
 		t.mocks.CrashHandler,
 		t.reporter,
@@ -169,6 +184,9 @@ func (t *testCtx) initBridge() (<-chan events.Event, error) {
 		logIMAP,
 		logIMAP,
 		logSMTP,
+
+		// Safe mode
+		false,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("could not create bridge: %w", err)